@@ -0,0 +1,51 @@
+// Command backup writes an encrypted snapshot of the database and prunes old backups.
+//
+//	ENC_MASTER_KEY=... go run ./cmd/backup -dir data/backups -keep 7 -max-age-days 30
+//
+// The snapshot is consistent while the server is running, so it can be run from cron or as
+// `docker exec moneyy ./backup`. Backups are encrypted with ENC_MASTER_KEY and can only be
+// restored with the same key. The most recent backup is never pruned.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"money/internal/database"
+	"money/internal/env"
+	"money/internal/sync/encryption"
+)
+
+func main() {
+	env.Load()
+
+	dir := flag.String("dir", env.Get("BACKUP_DIR", "data/backups"), "directory backups are written to")
+	keep := flag.Int("keep", env.GetInt("BACKUP_KEEP", 7), "number of backups to keep, 0 for no limit")
+	maxAgeDays := flag.Int("max-age-days", env.GetInt("BACKUP_RETENTION_DAYS", 30), "days backups are kept, 0 for no limit")
+	flag.Parse()
+
+	enc, err := encryption.NewService(env.MustGet("ENC_MASTER_KEY"))
+	if err != nil {
+		log.Fatalf("Failed to initialize encryption: %v", err)
+	}
+
+	dbManager, err := database.NewManager()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer dbManager.Close()
+
+	retention := database.Retention{Keep: *keep, MaxAge: time.Duration(*maxAgeDays) * 24 * time.Hour}
+	info, pruned, err := dbManager.Backup(context.Background(), *dir, enc, retention)
+	if info != nil {
+		log.Printf("Wrote %s (%d bytes, schema version %d)", info.Path, info.Size, info.SchemaVersion)
+	}
+	for _, name := range pruned {
+		log.Printf("Pruned %s", name)
+	}
+	if err != nil {
+		log.Fatalf("Backup failed: %v", err)
+	}
+}
@@ -0,0 +1,407 @@
+// Command loadtest drives sustained load at the hot API endpoints of a running server and
+// compares the latencies against recorded baseline numbers, exiting non-zero on a regression.
+//
+//	go run ./cmd/loadtest -url http://localhost:4000 -token "$TOKEN" -demo
+//
+// With -demo the requests run against the demo dataset, which every instance provisions the
+// same way, so numbers from different runs are comparable. The import scenario replaces the
+// user's data with its own export and is only run when -import is set.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// scenario is one endpoint under load
+type scenario struct {
+	name    string
+	request func() (*http.Request, error)
+}
+
+// result summarises a scenario run
+type result struct {
+	Requests  int     `json:"requests"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	RPS       float64 `json:"rps"`
+	P50MS     float64 `json:"p50_ms"`
+	P95MS     float64 `json:"p95_ms"`
+	P99MS     float64 `json:"p99_ms"`
+	MaxMS     float64 `json:"max_ms"`
+}
+
+// baseline holds recorded results by scenario name
+type baseline struct {
+	RecordedAt time.Time          `json:"recorded_at"`
+	Notes      string             `json:"notes,omitempty"`
+	Scenarios  map[string]*result `json:"scenarios"`
+}
+
+// client sends authenticated requests to the server under test
+type client struct {
+	baseURL string
+	token   string
+	demo    bool
+	http    *http.Client
+}
+
+func (c *client) newRequest(method, path string, body io.Reader, contentType string) (*http.Request, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.demo {
+		req.Header.Set("X-Demo-Mode", "true")
+	}
+	return req, nil
+}
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:4000", "Base URL of the server under test")
+	token := flag.String("token", os.Getenv("MONEYY_TOKEN"), "Bearer token, a session JWT or API key (default $MONEYY_TOKEN)")
+	demo := flag.Bool("demo", false, "Send requests in demo mode against the demo dataset")
+	duration := flag.Duration("duration", 30*time.Second, "How long to load each scenario")
+	rate := flag.Int("rate", 10, "Requests per second to send to each scenario")
+	workers := flag.Int("workers", 4, "Concurrent requests in flight per scenario")
+	optionsAccount := flag.String("options-account", "", "Stock options account for the options summary (default: the first one found)")
+	runImport := flag.Bool("import", false, "Also load archive import, replacing the user's data with its own export")
+	baselinePath := flag.String("baseline", "cmd/loadtest/baseline.json", "Baseline file to compare against or record to")
+	tolerance := flag.Float64("tolerance", 0.25, "Allowed p95 slowdown over the baseline, e.g. 0.25 for 25%")
+	slack := flag.Duration("slack", 10*time.Millisecond, "Allowed p95 slowdown regardless of -tolerance, so fast endpoints aren't flagged for jitter")
+	record := flag.Bool("record", false, "Write the results to the baseline file instead of comparing")
+	notes := flag.String("notes", "", "With -record, a description of the machine and settings the baseline was taken on")
+	flag.Parse()
+
+	if *token == "" {
+		log.Fatal("A token is required, pass -token or set MONEYY_TOKEN")
+	}
+	if *rate <= 0 || *workers <= 0 {
+		log.Fatal("-rate and -workers must be positive")
+	}
+
+	c := &client{baseURL: *baseURL, token: *token, demo: *demo, http: &http.Client{Timeout: time.Minute}}
+	scenarios, err := buildScenarios(c, *optionsAccount, *runImport)
+	if err != nil {
+		log.Fatalf("Failed to prepare scenarios: %v", err)
+	}
+
+	results := make(map[string]*result, len(scenarios))
+	for _, sc := range scenarios {
+		log.Printf("Running %s for %s at %d req/s", sc.name, *duration, *rate)
+		results[sc.name] = run(c.http, sc, *duration, *rate, *workers)
+	}
+
+	if *record {
+		if err := writeBaseline(*baselinePath, &baseline{RecordedAt: time.Now().UTC(), Notes: *notes, Scenarios: results}); err != nil {
+			log.Fatalf("Failed to record baseline: %v", err)
+		}
+		printResults(scenarios, results, nil)
+		log.Printf("Recorded baseline to %s", *baselinePath)
+		return
+	}
+
+	base, err := readBaseline(*baselinePath)
+	if err != nil {
+		log.Printf("No baseline to compare against: %v", err)
+	}
+	regressions := printResults(scenarios, results, compareFunc(base, *tolerance, *slack))
+	if len(regressions) > 0 {
+		for _, r := range regressions {
+			log.Printf("REGRESSION: %s", r)
+		}
+		os.Exit(1)
+	}
+}
+
+// buildScenarios prepares the requests for each hot endpoint
+func buildScenarios(c *client, optionsAccount string, runImport bool) ([]scenario, error) {
+	projection, err := json.Marshal(map[string]interface{}{
+		"config": map[string]interface{}{
+			"time_horizon_years":    30,
+			"inflation_rate":        0.02,
+			"annual_salary":         120000,
+			"annual_salary_growth":  0.03,
+			"monthly_expenses":      4500,
+			"annual_expense_growth": 0.02,
+			"monthly_savings_rate":  0.25,
+			"investment_returns":    map[string]float64{"tfsa": 0.06, "rrsp": 0.06, "brokerage": 0.06, "savings": 0.02},
+			"savings_allocation":    map[string]float64{"tfsa": 0.4, "rrsp": 0.4, "brokerage": 0.2},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scenarios := []scenario{
+		{name: "accounts-with-balance", request: func() (*http.Request, error) {
+			return c.newRequest(http.MethodGet, "/api/accounts-with-balance", nil, "")
+		}},
+		{name: "projection-calculate", request: func() (*http.Request, error) {
+			return c.newRequest(http.MethodPost, "/api/projections/calculate", bytes.NewReader(projection), "application/json")
+		}},
+	}
+
+	if optionsAccount == "" {
+		optionsAccount, err = findOptionsAccount(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if optionsAccount != "" {
+		scenarios = append(scenarios, scenario{name: "options-summary", request: func() (*http.Request, error) {
+			return c.newRequest(http.MethodGet, "/api/accounts/"+optionsAccount+"/options/summary", nil, "")
+		}})
+	} else {
+		log.Print("No stock options account found, skipping options-summary")
+	}
+
+	if runImport {
+		archive, err := export(c)
+		if err != nil {
+			return nil, err
+		}
+		scenarios = append(scenarios, scenario{name: "import-archive", request: func() (*http.Request, error) {
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			file, err := form.CreateFormFile("file", "export.zip")
+			if err != nil {
+				return nil, err
+			}
+			if _, err := file.Write(archive); err != nil {
+				return nil, err
+			}
+			if err := form.WriteField("mode", "replace"); err != nil {
+				return nil, err
+			}
+			if err := form.Close(); err != nil {
+				return nil, err
+			}
+			return c.newRequest(http.MethodPost, "/api/data/import", &body, form.FormDataContentType())
+		}})
+	}
+
+	return scenarios, nil
+}
+
+// findOptionsAccount returns the ID of the user's first stock options account, if any
+func findOptionsAccount(c *client) (string, error) {
+	req, err := c.newRequest(http.MethodGet, "/api/accounts", nil, "")
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to list accounts: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to list accounts: status %d", resp.StatusCode)
+	}
+
+	var list struct {
+		Accounts []struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		} `json:"accounts"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return "", fmt.Errorf("failed to decode accounts: %w", err)
+	}
+	for _, acc := range list.Accounts {
+		if acc.Type == "stock_options" {
+			return acc.ID, nil
+		}
+	}
+	return "", nil
+}
+
+// export downloads the user's archive for the import scenario
+func export(c *client) ([]byte, error) {
+	req, err := c.newRequest(http.MethodPost, "/api/data/export", nil, "")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to export: status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// run sends requests at a fixed rate for the duration, with at most workers in flight.
+// When every worker is busy the rate drops, which shows up as a lower RPS.
+func run(httpClient *http.Client, sc scenario, duration time.Duration, rate, workers int) *result {
+	ticks := make(chan struct{})
+	var mu sync.Mutex
+	var latencies []time.Duration
+	errors := 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range ticks {
+				latency, ok := send(httpClient, sc)
+				mu.Lock()
+				latencies = append(latencies, latency)
+				if !ok {
+					errors++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(time.Second / time.Duration(rate))
+	for time.Since(start) < duration {
+		<-ticker.C
+		ticks <- struct{}{}
+	}
+	ticker.Stop()
+	close(ticks)
+	wg.Wait()
+
+	return summarise(latencies, errors, time.Since(start))
+}
+
+// send makes one request and reports its latency and whether it succeeded
+func send(httpClient *http.Client, sc scenario) (time.Duration, bool) {
+	req, err := sc.request()
+	if err != nil {
+		return 0, false
+	}
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return time.Since(start), false
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return time.Since(start), resp.StatusCode < 300
+}
+
+// summarise computes throughput and latency percentiles
+func summarise(latencies []time.Duration, errors int, elapsed time.Duration) *result {
+	r := &result{Requests: len(latencies), Errors: errors}
+	if len(latencies) == 0 {
+		return r
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	r.ErrorRate = float64(errors) / float64(len(latencies))
+	r.RPS = float64(len(latencies)) / elapsed.Seconds()
+	r.P50MS = ms(percentile(latencies, 0.50))
+	r.P95MS = ms(percentile(latencies, 0.95))
+	r.P99MS = ms(percentile(latencies, 0.99))
+	r.MaxMS = ms(latencies[len(latencies)-1])
+	return r
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// compareFunc returns a check that reports a regression against the baseline, or "" when
+// the scenario is within tolerance or has no baseline
+func compareFunc(base *baseline, tolerance float64, slack time.Duration) func(name string, r *result) string {
+	return func(name string, r *result) string {
+		if base == nil {
+			return ""
+		}
+		return compare(name, base.Scenarios[name], r, tolerance, slack)
+	}
+}
+
+// compare reports a regression when p95 latency exceeds the baseline by more than both the
+// tolerance and the slack, or errors rise by more than a percentage point
+func compare(name string, base, r *result, tolerance float64, slack time.Duration) string {
+	if base == nil {
+		return ""
+	}
+	if r.ErrorRate > base.ErrorRate+0.01 {
+		return fmt.Sprintf("%s error rate %.1f%% over baseline %.1f%%", name, r.ErrorRate*100, base.ErrorRate*100)
+	}
+	limit := base.P95MS * (1 + tolerance)
+	if floor := base.P95MS + ms(slack); floor > limit {
+		limit = floor
+	}
+	if r.P95MS > limit {
+		return fmt.Sprintf("%s p95 %.1fms over limit %.1fms (baseline %.1fms)", name, r.P95MS, limit, base.P95MS)
+	}
+	return ""
+}
+
+// printResults writes a results table and returns any regressions found by check
+func printResults(scenarios []scenario, results map[string]*result, check func(string, *result) string) []string {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SCENARIO\tREQUESTS\tERRORS\tRPS\tP50 MS\tP95 MS\tP99 MS\tMAX MS\tSTATUS")
+
+	var regressions []string
+	for _, sc := range scenarios {
+		r := results[sc.name]
+		status := "ok"
+		if check != nil {
+			if regression := check(sc.name, r); regression != "" {
+				regressions = append(regressions, regression)
+				status = "REGRESSED"
+			}
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%s\n",
+			sc.name, r.Requests, r.Errors, r.RPS, r.P50MS, r.P95MS, r.P99MS, r.MaxMS, status)
+	}
+	w.Flush()
+	return regressions
+}
+
+func readBaseline(path string) (*baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var base baseline
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, fmt.Errorf("invalid baseline %s: %w", path, err)
+	}
+	return &base, nil
+}
+
+func writeBaseline(path string, base *baseline) error {
+	data, err := json.MarshalIndent(base, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarise_Percentiles(t *testing.T) {
+	latencies := make([]time.Duration, 0, 100)
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	r := summarise(latencies, 2, 10*time.Second)
+
+	if r.Requests != 100 || r.Errors != 2 || r.ErrorRate != 0.02 {
+		t.Errorf("Unexpected counts: %+v", r)
+	}
+	if r.RPS != 10 {
+		t.Errorf("Expected 10 req/s, got %.2f", r.RPS)
+	}
+	if r.P50MS != 50 || r.P95MS != 95 || r.P99MS != 99 || r.MaxMS != 100 {
+		t.Errorf("Unexpected percentiles: p50=%.1f p95=%.1f p99=%.1f max=%.1f", r.P50MS, r.P95MS, r.P99MS, r.MaxMS)
+	}
+}
+
+func TestCompare_FlagsSlowdownsBeyondTolerance(t *testing.T) {
+	base := &result{P95MS: 100, ErrorRate: 0}
+
+	if got := compare("x", base, &result{P95MS: 120}, 0.25, time.Millisecond); got != "" {
+		t.Errorf("Expected 20%% slowdown to be within tolerance, got %q", got)
+	}
+	if got := compare("x", base, &result{P95MS: 130}, 0.25, time.Millisecond); got == "" {
+		t.Error("Expected 30% slowdown to be a regression")
+	}
+	if got := compare("x", base, &result{P95MS: 90, ErrorRate: 0.05}, 0.25, time.Millisecond); got == "" {
+		t.Error("Expected new errors to be a regression")
+	}
+	if got := compare("x", nil, &result{P95MS: 1000}, 0.25, time.Millisecond); got != "" {
+		t.Errorf("Expected no regression without a baseline, got %q", got)
+	}
+}
+
+func TestCompare_SlackCoversJitterOnFastEndpoints(t *testing.T) {
+	base := &result{P95MS: 3}
+
+	if got := compare("x", base, &result{P95MS: 9}, 0.25, 10*time.Millisecond); got != "" {
+		t.Errorf("Expected a few milliseconds of jitter to be allowed, got %q", got)
+	}
+	if got := compare("x", base, &result{P95MS: 14}, 0.25, 10*time.Millisecond); got == "" {
+		t.Error("Expected a slowdown beyond the slack to be a regression")
+	}
+}
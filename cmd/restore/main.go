@@ -0,0 +1,63 @@
+// Command restore replaces the database with a backup written by cmd/backup.
+//
+//	ENC_MASTER_KEY=... go run ./cmd/restore -confirm data/backups/moneyy-20260101-030000-v077.db.enc
+//
+// Stop the server first. The backup is decrypted and checked before the database is touched,
+// and the replaced database is kept next to it with a .pre-restore suffix. Backups from an
+// older release are migrated forward when the server next starts. Without a file argument
+// the available backups are listed.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"money/internal/database"
+	"money/internal/env"
+	"money/internal/sync/encryption"
+)
+
+func main() {
+	env.Load()
+
+	dir := flag.String("dir", env.Get("BACKUP_DIR", "data/backups"), "directory backups are listed from")
+	dbPath := flag.String("db", env.Get("DB_PATH", "data/moneyy.db"), "database file to replace")
+	migrations := flag.String("migrations", "migrations", "migrations directory of this release")
+	confirm := flag.Bool("confirm", false, "replace the database; required because the current data is overwritten")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		backups, err := database.ListBackups(*dir)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "BACKUP\tCREATED\tSCHEMA\tSIZE")
+		for _, b := range backups {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n", b.Path, b.CreatedAt.Format("2006-01-02 15:04:05"), b.SchemaVersion, b.Size)
+		}
+		tw.Flush()
+		return
+	}
+	if !*confirm {
+		log.Fatalf("Restoring overwrites %s; stop the server and rerun with -confirm", *dbPath)
+	}
+
+	enc, err := encryption.NewService(env.MustGet("ENC_MASTER_KEY"))
+	if err != nil {
+		log.Fatalf("Failed to initialize encryption: %v", err)
+	}
+
+	result, err := database.RestoreBackup(context.Background(), flag.Arg(0), *dbPath, *migrations, enc)
+	if err != nil {
+		log.Fatalf("Restore failed: %v", err)
+	}
+	if result.PreviousPath != "" {
+		log.Printf("Moved the previous database to %s", result.PreviousPath)
+	}
+	log.Printf("Restored %s at schema version %d; start the server to apply newer migrations", *dbPath, result.SchemaVersion)
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"money/internal/account"
+	"money/internal/advisor"
+	"money/internal/analytics"
+	"money/internal/apikeys"
+	"money/internal/auth"
+	"money/internal/auth/passkey"
+	"money/internal/background"
+	"money/internal/balance"
+	"money/internal/calculators"
+	"money/internal/credit"
+	"money/internal/currency"
+	"money/internal/dashboard"
+	"money/internal/data"
+	"money/internal/database"
+	"money/internal/env"
+	"money/internal/flags"
+	"money/internal/holdings"
+	"money/internal/hooks"
+	"money/internal/income"
+	"money/internal/inflation"
+	"money/internal/kvstore"
+	"money/internal/logger"
+	"money/internal/moneyy"
+	"money/internal/notification"
+	"money/internal/preferences"
+	"money/internal/projections"
+	"money/internal/reports"
+	"money/internal/risk"
+	"money/internal/server"
+	"money/internal/server/handlers"
+	"money/internal/settings"
+	"money/internal/share"
+	"money/internal/status"
+	"money/internal/sync"
+	"money/internal/sync/encryption"
+	"money/internal/transaction"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// services holds every service the server is built from
+type services struct {
+	jobs         *background.Group
+	store        kvstore.Store
+	balance      *balance.Service
+	currency     *currency.Service
+	holdings     *holdings.Service
+	inflation    *inflation.Service
+	transaction  *transaction.Service
+	account      *account.Service
+	projections  *projections.Service
+	sync         *sync.Service
+	export       *data.ExportService
+	imports      *data.ImportService
+	deletion     *data.DeletionService
+	snapshots    *data.SnapshotService
+	integrity    *data.IntegrityService
+	audit        *data.AuditExportService
+	demo         *data.DemoService
+	income       *income.Service
+	apiKeys      *apikeys.Service
+	moneyy       *moneyy.Service
+	analytics    *analytics.Service
+	calculators  *calculators.Service
+	credit       *credit.Service
+	share        *share.Service
+	advisor      *advisor.Service
+	notification *notification.Service
+	reports      *reports.Service
+	dashboard    *dashboard.Service
+	flags        *flags.Service
+	preferences  *preferences.Service
+	risk         *risk.Service
+	settings     *settings.Service
+	status       *status.Service
+	hooks        *hooks.Service
+}
+
+// newServices initializes the services with dependency injection
+func newServices(db *sql.DB, encryptionKey string) (*services, error) {
+	svc := &services{}
+
+	// Background work that shutdown drains (syncs and schedulers). Schedulers lease their
+	// runs through the database, so instances sharing it don't run the same job twice.
+	svc.jobs = background.NewGroup()
+	svc.jobs.SetLocker(database.NewJobLocker(db))
+
+	// REDIS_URL shares passkey sessions, rate limits and summaries between instances behind
+	// a load balancer; without it they're kept in memory and the database
+	redisURL := env.Get("REDIS_URL", "")
+	store, err := kvstore.Open(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open REDIS_URL store: %w", err)
+	}
+	svc.store = store
+
+	// Instance status (depends on the background group)
+	svc.status = status.NewService(db, svc.jobs, version)
+	svc.status.SetBackupSource(func(ctx context.Context) (*time.Time, error) {
+		return database.LastBackup(ctx, db)
+	})
+
+	// Balance service (no dependencies)
+	svc.balance = balance.NewService(db)
+
+	// Currency service (depends on the background group for rate refreshes)
+	svc.currency = currency.NewService(db, svc.jobs)
+
+	// Holdings service (no dependencies)
+	svc.holdings = holdings.NewService(db)
+
+	// Transaction service (no dependencies)
+	svc.transaction = transaction.NewService(db)
+
+	// Inflation service (no dependencies; the BLS key is optional)
+	svc.inflation = inflation.NewService(db, env.Get("BLS_API_KEY", ""))
+
+	// Income service (no dependencies)
+	svc.income = income.NewService(db)
+
+	// Account service (depends on balance service)
+	svc.account = account.NewService(
+		db,
+		db, // balance DB is same now
+		svc.balance,
+	)
+	// Account numbers in external references are encrypted under the master key
+	accountEncryption, err := encryption.NewService(encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize account encryption: %w", err)
+	}
+	svc.account.SetEncryption(accountEncryption)
+	// Currency totals convert with the cached exchange rates
+	svc.account.SetExchangeRates(svc.currency)
+	// Net worth recomputes rebuild the materialized dashboard summaries after balance writes
+	svc.balance.SetSummaryRefresher(svc.account.RefreshSummaries)
+	if redisURL != "" {
+		svc.account.SetSummaryCache(svc.store)
+	}
+
+	// Projections service (depends on account, transaction, holdings, inflation and income)
+	svc.projections = projections.NewService(
+		db,
+		db, // all same DB now
+		svc.account,
+		svc.transaction,
+		svc.holdings,
+		svc.inflation,
+		svc.income,
+	)
+
+	// Sync service (depends on account, balance, holdings and transaction)
+	svc.sync = sync.NewService(
+		db,
+		svc.account,
+		svc.balance,
+		svc.holdings,
+		svc.transaction,
+		encryptionKey,
+		svc.jobs,
+	)
+
+	// Data export/import, account deletion, snapshot and integrity services (no dependencies)
+	svc.export = data.NewExportService(db)
+	svc.imports = data.NewImportService(db)
+	svc.deletion = data.NewDeletionService(db)
+	svc.snapshots = data.NewSnapshotService(db)
+	svc.integrity = data.NewIntegrityService(db)
+
+	// Audit log export service (chains are keyed from the encryption key)
+	svc.audit = data.NewAuditExportService(db, encryptionKey)
+
+	// Demo service (depends on import/export services)
+	svc.demo = data.NewDemoService(db)
+
+	// API Keys service (depends on encryption key)
+	apiKeysSvc, err := apikeys.NewService(db, encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize API keys service: %w", err)
+	}
+	svc.apiKeys = apiKeysSvc
+
+	// Moneyy service (depends on API keys service)
+	svc.moneyy = moneyy.NewService(svc.apiKeys)
+
+	// Analytics service (depends on income, transaction and inflation services)
+	svc.analytics = analytics.NewService(db, svc.income, svc.transaction, svc.inflation)
+
+	// Calculators service (depends on income service for its tax math)
+	svc.calculators = calculators.NewService(svc.income)
+
+	// Credit score service (no dependencies)
+	svc.credit = credit.NewService(db)
+
+	// Report share link service (no dependencies)
+	svc.share = share.NewService(db)
+
+	// Advisor access service (no dependencies)
+	svc.advisor = advisor.NewService(db)
+
+	// Notification service (depends on account, balance, income, holdings, analytics, credit and transaction services)
+	svc.notification = notification.NewService(db, svc.account, svc.balance, svc.income, svc.holdings, svc.analytics, svc.credit, svc.transaction)
+	svc.notification.SetSender(notification.ChannelWebhook, notification.NewWebhookSender())
+	if host := env.Get("SMTP_HOST", ""); host != "" {
+		svc.notification.SetSender(notification.ChannelEmail, notification.NewEmailSender(host, env.GetInt("SMTP_PORT", 587),
+			env.Get("SMTP_USERNAME", ""), env.Get("SMTP_PASSWORD", ""), env.Get("SMTP_FROM", "moneyy@localhost")))
+	}
+
+	// Scheduled report delivery (depends on account and export services)
+	svc.reports = reports.NewService(db, accountEncryption)
+	svc.reports.SetFileEncryption(data.EncryptArchive)
+	svc.reports.SetGenerator(reports.ReportEstate, func(ctx context.Context) (*reports.File, error) {
+		report, err := svc.account.GetEstateReport(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &reports.File{Name: "estate-summary.pdf", ContentType: "application/pdf", Content: account.RenderEstateReportPDF(report)}, nil
+	})
+	svc.reports.SetGenerator(reports.ReportNetWorth, func(ctx context.Context) (*reports.File, error) {
+		statement, err := svc.account.GetNetWorthStatement(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &reports.File{Name: "net-worth-statement.pdf", ContentType: "application/pdf", Content: account.RenderNetWorthStatementPDF(statement)}, nil
+	})
+	svc.reports.SetGenerator(reports.ReportExport, func(ctx context.Context) (*reports.File, error) {
+		archive, err := svc.export.ExportData(ctx, auth.GetUserID(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return &reports.File{Name: "money-export.zip", ContentType: "application/zip", Content: archive}, nil
+	})
+	if host := env.Get("SMTP_HOST", ""); host != "" {
+		svc.reports.SetMailer(reports.NewMailer(host, env.GetInt("SMTP_PORT", 587),
+			env.Get("SMTP_USERNAME", ""), env.Get("SMTP_PASSWORD", ""), env.Get("SMTP_FROM", "moneyy@localhost")))
+	}
+
+	// Sync side effects are delivered from the outbox once a sync commits
+	svc.sync.Subscribe(sync.EventConflictFlagged, svc.notification.NotifySyncConflict)
+
+	// Budget alerts are evaluated as transactions post
+	svc.transaction.OnPosted(svc.notification.NotifyBudgetThresholds)
+
+	// Extension hooks (depends on transaction service); they vet new transactions and
+	// enrich synced ones
+	svc.hooks = hooks.NewService(db, accountEncryption, svc.transaction)
+	svc.transaction.OnValidate(svc.hooks.ValidateTransaction)
+	svc.sync.Subscribe(sync.EventAccountSynced, svc.hooks.EnrichSync)
+
+	// Dashboard service (no dependencies)
+	svc.dashboard = dashboard.NewService(db)
+
+	// Feature flag service (no dependencies)
+	svc.flags = flags.NewService(db)
+
+	// Preferences service (no dependencies)
+	svc.preferences = preferences.NewService(db)
+
+	// Risk scoring service (depends on account, holdings and income services)
+	svc.risk = risk.NewService(db, svc.account, svc.holdings, svc.income)
+
+	// Runtime settings service (ADMIN_USER_IDS may change them)
+	svc.settings = settings.NewService(db, strings.Split(env.Get("ADMIN_USER_IDS", passkey.SingleUserID), ","))
+	svc.settings.Watch(settings.KeyLogLevel, func(_ context.Context, value string) error {
+		logger.SetLevel(value)
+		return nil
+	})
+	svc.settings.Watch(settings.KeyLogModuleLevels, func(_ context.Context, value string) error {
+		logger.SetModuleLevels(value)
+		return nil
+	})
+	svc.settings.Watch(settings.KeySyncDefaultFrequency, func(_ context.Context, value string) error {
+		return svc.sync.SetDefaultFrequency(sync.SyncFrequency(value))
+	})
+	svc.settings.Watch(settings.KeyFeatureFlags, svc.flags.ApplyInstanceFlags)
+
+	return svc, nil
+}
+
+// apiDeprecations schedules the retirement of API versions and modules. Add an entry when a
+// module's v2 routes replace its v1 ones, so v1 clients see Deprecation and Sunset headers.
+var apiDeprecations = []server.Deprecation{}
+
+// newRouter builds the HTTP router with its middleware and every API route. Static files
+// are left to the caller.
+func newRouter(svc *services, authProvider auth.AuthProvider, proxies server.ProxyConfig, requestLog server.RequestLogConfig, corsConfig server.CORSConfig, timeouts server.TimeoutConfig, authRateLimit server.RateLimitConfig) chi.Router {
+	r := chi.NewRouter()
+
+	// Middleware
+	r.Use(middleware.RequestID)
+	// Forwarding headers are only believed from the configured proxies
+	r.Use(proxies.RealIPMiddleware())
+	r.Use(requestLog.RequestLogger())
+	r.Use(middleware.Recoverer)
+
+	// CORS middleware
+	r.Use(corsConfig.CORSHandler())
+
+	shareHandler := handlers.NewShareHandler(svc.share, svc.account)
+
+	// Each version gets its own router over the same services under /api/<version>, and the
+	// unversioned /api paths stay as an alias so older SPA and CLI clients keep working
+	versions := make(map[server.APIVersion]http.Handler, len(server.APIVersions))
+	for _, version := range server.APIVersions {
+		api := newAPIRouter(svc, authProvider, shareHandler, version, timeouts, authRateLimit)
+		versions[version] = api
+		r.Mount(version.Root(), api)
+	}
+	r.Mount("/api", server.UnversionedHandler(versions))
+
+	return r
+}
+
+// newAPIRouter builds the routes for one API version. Versions share handlers until a module
+// changes its contract, at which point it registers different routes for the newer version.
+func newAPIRouter(svc *services, authProvider auth.AuthProvider, shareHandler *handlers.ShareHandler, version server.APIVersion, timeouts server.TimeoutConfig, authRateLimit server.RateLimitConfig) chi.Router {
+	r := chi.NewRouter()
+	r.Use(server.VersionMiddleware(version, apiDeprecations))
+	r.Use(timeouts.TimeoutMiddleware())
+
+	// Health check - must be public for Docker healthcheck
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+
+	// Auth routes (public), rate limited per client address against passkey guessing
+	r.Route("/auth", func(r chi.Router) {
+		r.Use(authRateLimit.RateLimitMiddleware())
+		authProvider.RegisterRoutes(r)
+	})
+
+	// Shared reports (public, the link token is the credential)
+	shareHandler.RegisterPublicRoutes(r)
+
+	// Instance status for uptime monitors (public, holds no user data)
+	handlers.NewStatusHandler(svc.status).RegisterPublicRoutes(r)
+
+	// Protected routes group
+	r.Group(func(r chi.Router) {
+		// Apply auth middleware to protected routes only
+		r.Use(auth.AuthMiddleware(authProvider, svc.apiKeys))
+		// Let advisors act for clients who granted them access
+		r.Use(auth.DelegationMiddleware(svc.advisor))
+		// Apply demo mode middleware after auth
+		r.Use(auth.DemoModeMiddleware(passkey.DemoUserID))
+		// Generate the demo dataset on the first demo request
+		r.Use(svc.demo.ProvisionMiddleware(passkey.DemoUserID))
+		r.Use(svc.preferences.LocationMiddleware)
+		// Mask private account balances for users in privacy mode
+		r.Use(svc.preferences.PrivacyMiddleware)
+
+		handlers.NewAccountHandler(svc.account).RegisterRoutes(r)
+		handlers.NewEntityHandler(svc.account).RegisterRoutes(r)
+		handlers.NewBalanceHandler(svc.balance).RegisterRoutes(r)
+		handlers.NewCurrencyHandler(svc.currency).RegisterRoutes(r)
+		handlers.NewInflationHandler(svc.inflation).RegisterRoutes(r)
+		handlers.NewHoldingsHandler(svc.holdings).RegisterRoutes(r)
+		handlers.NewProjectionsHandler(svc.projections).RegisterRoutes(r)
+		handlers.NewSyncHandler(svc.sync).RegisterRoutes(r)
+		handlers.NewTransactionHandler(svc.transaction).RegisterRoutes(r)
+		handlers.NewDataHandler(svc.export, svc.imports, svc.deletion, svc.snapshots, svc.audit, svc.holdings).RegisterRoutes(r)
+		handlers.NewDemoHandler(svc.demo).RegisterRoutes(r)
+		handlers.NewIncomeHandler(svc.income).RegisterRoutes(r)
+		handlers.NewAPIKeysHandler(svc.apiKeys, svc.moneyy).RegisterRoutes(r)
+		handlers.NewNotificationHandler(svc.notification).RegisterRoutes(r)
+		handlers.NewReportsHandler(svc.reports).RegisterRoutes(r)
+		handlers.NewDashboardHandler(svc.dashboard).RegisterRoutes(r)
+		handlers.NewAnalyticsHandler(svc.analytics, svc.inflation).RegisterRoutes(r)
+		handlers.NewCalculatorsHandler(svc.calculators).RegisterRoutes(r)
+		handlers.NewFlagsHandler(svc.flags).RegisterRoutes(r)
+		handlers.NewPreferencesHandler(svc.preferences).RegisterRoutes(r)
+		handlers.NewRiskHandler(svc.risk).RegisterRoutes(r)
+		handlers.NewCreditHandler(svc.credit).RegisterRoutes(r)
+		shareHandler.RegisterRoutes(r)
+		handlers.NewAdvisorHandler(svc.advisor).RegisterRoutes(r)
+		handlers.NewSettingsHandler(svc.settings).RegisterRoutes(r)
+		handlers.NewIntegrityHandler(svc.integrity, svc.settings).RegisterRoutes(r)
+		handlers.NewHooksHandler(svc.hooks, svc.settings).RegisterRoutes(r)
+	})
+
+	return r
+}
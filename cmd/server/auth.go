@@ -5,8 +5,14 @@ import (
 
 	"money/internal/auth"
 	"money/internal/auth/passkey"
+	"money/internal/kvstore"
 )
 
-func initializeAuthProvider(db *sql.DB) (auth.AuthProvider, error) {
-	return passkey.NewPasskeyAuthProvider(db)
+func initializeAuthProvider(db *sql.DB, store kvstore.Store) (auth.AuthProvider, error) {
+	provider, err := passkey.NewPasskeyAuthProvider(db)
+	if err != nil {
+		return nil, err
+	}
+	provider.SetSessionStore(store)
+	return provider, nil
 }
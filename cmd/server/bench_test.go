@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"testing"
+	"time"
+
+	"money/internal/account"
+	"money/internal/civil"
+	"money/internal/logger"
+	"money/internal/projections"
+
+	"github.com/google/uuid"
+)
+
+// Benchmarks for the hot endpoints, run through the full router like the end-to-end tests.
+// Compare against the recorded numbers with:
+//
+//	go test ./cmd/server -run '^$' -bench . -benchmem -count 6 > new.txt
+//	benchstat cmd/server/testdata/bench_baseline.txt new.txt
+
+// Size of the seeded dataset, roughly a few years of a busy user's data
+const (
+	benchAccounts     = 25
+	benchBalanceDays  = 365
+	benchEquityGrants = 10
+)
+
+// newBenchServer starts a test server with request logging quietened to warnings
+func newBenchServer(b *testing.B) (*testServer, string) {
+	b.Helper()
+	b.Setenv("LOG_LEVEL", "warn")
+	logger.Init()
+
+	s := newTestServer(b)
+	return s, s.login()
+}
+
+// seedAccounts creates accounts with a year of daily balances each and returns their IDs
+func seedAccounts(b *testing.B, s *testServer, token string) []string {
+	b.Helper()
+
+	types := []account.AccountType{
+		account.AccountTypeChecking, account.AccountTypeSavings, account.AccountTypeTFSA,
+		account.AccountTypeRRSP, account.AccountTypeBrokerage,
+	}
+	ids := make([]string, 0, benchAccounts)
+	for i := 0; i < benchAccounts; i++ {
+		var acc account.Account
+		status := s.do(http.MethodPost, "/api/accounts", token, nil, account.CreateAccountRequest{
+			Name:     fmt.Sprintf("Bench %d", i),
+			Type:     types[i%len(types)],
+			Currency: account.CurrencyCAD,
+			IsAsset:  true,
+		}, &acc)
+		if status != http.StatusCreated {
+			b.Fatalf("Failed to create account: status %d", status)
+		}
+		ids = append(ids, acc.ID)
+	}
+
+	tx, err := s.db.DB().Begin()
+	if err != nil {
+		b.Fatalf("Failed to begin seeding: %v", err)
+	}
+	defer tx.Rollback()
+	start := civil.DateOf(time.Now()).AddDays(-benchBalanceDays)
+	for i, id := range ids {
+		for day := 0; day < benchBalanceDays; day++ {
+			_, err := tx.Exec(`
+				INSERT INTO balances (id, account_id, amount, date, notes, source, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, '', 'manual', $5, $5)
+			`, uuid.New().String(), id, float64(1000*(i+1)+day*10), start.AddDays(day), time.Now())
+			if err != nil {
+				b.Fatalf("Failed to seed balance: %v", err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		b.Fatalf("Failed to commit seeded balances: %v", err)
+	}
+	return ids
+}
+
+// seedOptionsAccount creates a stock options account with vesting grants and an FMV
+func seedOptionsAccount(b *testing.B, s *testServer, token string) string {
+	b.Helper()
+
+	var acc account.Account
+	status := s.do(http.MethodPost, "/api/accounts", token, nil, account.CreateAccountRequest{
+		Name:     "Bench Options",
+		Type:     account.AccountTypeStockOptions,
+		Currency: account.CurrencyCAD,
+		IsAsset:  true,
+	}, &acc)
+	if status != http.StatusCreated {
+		b.Fatalf("Failed to create options account: status %d", status)
+	}
+
+	months, cliff, frequency := 48, 12, "monthly"
+	strike := 2.50
+	today := civil.DateOf(time.Now())
+	for i := 0; i < benchEquityGrants; i++ {
+		var grant account.EquityGrant
+		status := s.do(http.MethodPost, "/api/accounts/"+acc.ID+"/options/grants", token, nil, account.CreateEquityGrantRequest{
+			GrantType:   account.GrantTypeISO,
+			GrantDate:   today.AddDays(-180 * i),
+			Quantity:    1000 * (i + 1),
+			StrikePrice: &strike,
+			FMVAtGrant:  strike,
+			CompanyName: "Bench Corp",
+			Currency:    "CAD",
+		}, &grant)
+		if status != http.StatusCreated {
+			b.Fatalf("Failed to create grant: status %d", status)
+		}
+		status = s.do(http.MethodPost, "/api/accounts/"+acc.ID+"/options/grants/"+grant.ID+"/vesting-schedule", token, nil, account.SetVestingScheduleRequest{
+			ScheduleType:       "time_based",
+			CliffMonths:        &cliff,
+			TotalVestingMonths: &months,
+			VestingFrequency:   &frequency,
+		}, nil)
+		if status != http.StatusCreated {
+			b.Fatalf("Failed to set vesting schedule: status %d", status)
+		}
+	}
+
+	status = s.do(http.MethodPost, "/api/accounts/"+acc.ID+"/options/fmv", token, nil, account.RecordFMVRequest{
+		Currency:      "CAD",
+		EffectiveDate: today,
+		FMVPerShare:   12.75,
+	}, nil)
+	if status != http.StatusCreated && status != http.StatusOK {
+		b.Fatalf("Failed to record FMV: status %d", status)
+	}
+	return acc.ID
+}
+
+func BenchmarkAccountsWithBalance(b *testing.B) {
+	s, token := newBenchServer(b)
+	seedAccounts(b, s, token)
+
+	for b.Loop() {
+		var resp account.ListAccountsWithBalanceResponse
+		if status := s.do(http.MethodGet, "/api/accounts-with-balance", token, nil, nil, &resp); status != http.StatusOK {
+			b.Fatalf("Unexpected status %d", status)
+		}
+	}
+}
+
+func BenchmarkOptionsSummary(b *testing.B) {
+	s, token := newBenchServer(b)
+	accountID := seedOptionsAccount(b, s, token)
+
+	for b.Loop() {
+		if status := s.do(http.MethodGet, "/api/accounts/"+accountID+"/options/summary", token, nil, nil, nil); status != http.StatusOK {
+			b.Fatalf("Unexpected status %d", status)
+		}
+	}
+}
+
+func BenchmarkProjectionCalculate(b *testing.B) {
+	s, token := newBenchServer(b)
+	seedAccounts(b, s, token)
+	req := projections.ProjectionRequest{
+		Config: &projections.Config{
+			TimeHorizonYears:    30,
+			InflationRate:       0.02,
+			AnnualSalary:        120000,
+			AnnualSalaryGrowth:  0.03,
+			MonthlyExpenses:     4500,
+			AnnualExpenseGrowth: 0.02,
+			MonthlySavingsRate:  0.25,
+			InvestmentReturns:   map[string]float64{"tfsa": 0.06, "rrsp": 0.06, "brokerage": 0.06, "savings": 0.02},
+			SavingsAllocation:   map[string]float64{"tfsa": 0.4, "rrsp": 0.4, "brokerage": 0.2},
+		},
+	}
+
+	for b.Loop() {
+		if status := s.do(http.MethodPost, "/api/projections/calculate", token, nil, req, nil); status != http.StatusOK {
+			b.Fatalf("Unexpected status %d", status)
+		}
+	}
+}
+
+func BenchmarkImportArchive(b *testing.B) {
+	s, token := newBenchServer(b)
+	seedAccounts(b, s, token)
+	archive := exportArchive(b, s, token)
+	b.SetBytes(int64(len(archive)))
+
+	for b.Loop() {
+		importArchive(b, s, token, archive)
+	}
+}
+
+// exportArchive downloads the user's export archive
+func exportArchive(b *testing.B, s *testServer, token string) []byte {
+	b.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, s.url+"/api/data/export", nil)
+	if err != nil {
+		b.Fatalf("Failed to build export request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		b.Fatalf("Export failed: %v", err)
+	}
+	defer resp.Body.Close()
+	archive, err := io.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		b.Fatalf("Export failed: status %d error %v", resp.StatusCode, err)
+	}
+	return archive
+}
+
+// importArchive uploads an archive, replacing the user's data
+func importArchive(b *testing.B, s *testServer, token string, archive []byte) {
+	b.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", "export.zip")
+	if err != nil {
+		b.Fatalf("Failed to build import form: %v", err)
+	}
+	file.Write(archive)
+	form.WriteField("mode", "replace")
+	form.Close()
+
+	req, err := http.NewRequest(http.MethodPost, s.url+"/api/data/import", &body)
+	if err != nil {
+		b.Fatalf("Failed to build import request: %v", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		b.Fatalf("Import failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		b.Fatalf("Import failed: status %d: %s", resp.StatusCode, msg)
+	}
+}
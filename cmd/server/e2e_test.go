@@ -0,0 +1,964 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"money/internal/account"
+	"money/internal/advisor"
+	"money/internal/apikeys"
+	"money/internal/auth"
+	"money/internal/auth/passkey"
+	"money/internal/database"
+	"money/internal/projections"
+	"money/internal/server"
+	"money/internal/settings"
+	"money/internal/share"
+	"money/internal/sync"
+	"money/internal/sync/wealthsimple"
+)
+
+// The end-to-end tests boot the full router, middleware included, against a disposable
+// database migrated the same way as at startup, and talk to it over HTTP
+
+const testJWTSecret = "e2e-test-secret-at-least-32-characters"
+
+// TestMain runs from the module root so migrations resolve as they do in production
+func TestMain(m *testing.M) {
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to get working directory: %v\n", err)
+		os.Exit(1)
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			fmt.Fprintln(os.Stderr, "module root not found")
+			os.Exit(1)
+		}
+		dir = parent
+	}
+	if err := os.Chdir(dir); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to change to module root: %v\n", err)
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
+
+// testServer is a running API server backed by its own database
+type testServer struct {
+	t   testing.TB
+	db  *database.Manager
+	svc *services
+	url string
+}
+
+// newTestServer starts the API server on a fresh, fully migrated database
+func newTestServer(t testing.TB) *testServer {
+	t.Helper()
+	t.Setenv("JWT_SECRET", testJWTSecret)
+
+	dbManager, err := database.NewManagerWithOptions(database.Options{
+		Path:             filepath.Join(t.TempDir(), "e2e.db"),
+		MaxOpenConns:     10,
+		MaxIdleConns:     5,
+		BusyTimeout:      5 * time.Second,
+		StatementTimeout: 30 * time.Second,
+		OperationTimeout: 2 * time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := dbManager.Migrate(); err != nil {
+		dbManager.Close()
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	encryptionKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("k"), 32))
+	svc, err := newServices(dbManager.DB(), encryptionKey)
+	if err != nil {
+		dbManager.Close()
+		t.Fatalf("Failed to initialize services: %v", err)
+	}
+
+	authProvider, err := initializeAuthProvider(dbManager.DB(), svc.store)
+	if err != nil {
+		dbManager.Close()
+		t.Fatalf("Failed to initialize auth provider: %v", err)
+	}
+	if err := authProvider.Initialize(context.Background()); err != nil {
+		dbManager.Close()
+		t.Fatalf("Failed to initialize auth: %v", err)
+	}
+
+	corsConfig := server.CORSConfig{Origins: []string{"http://localhost:5173"}}
+	srv := httptest.NewServer(newRouter(svc, authProvider, server.ProxyConfig{}, server.RequestLogConfig{}, corsConfig, server.TimeoutConfig{Default: 30 * time.Second}, server.RateLimitConfig{}))
+	t.Cleanup(func() {
+		srv.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := svc.jobs.Drain(ctx); err != nil {
+			t.Errorf("Background jobs did not stop: %v", err)
+		}
+		dbManager.Close()
+	})
+
+	return &testServer{t: t, db: dbManager, svc: svc, url: srv.URL}
+}
+
+// login creates a session for the self-hosted user and returns its bearer token
+func (s *testServer) login() string {
+	s.t.Helper()
+	return s.loginAs(passkey.SingleUserID, "admin@selfhosted.local")
+}
+
+// loginAs creates a session for an existing user and returns its bearer token
+func (s *testServer) loginAs(userID, email string) string {
+	s.t.Helper()
+
+	token, err := auth.GenerateJWT(userID, email, []byte(testJWTSecret))
+	if err != nil {
+		s.t.Fatalf("Failed to generate token: %v", err)
+	}
+	err = auth.NewSessionRepository(s.db.DB()).Create(context.Background(), &auth.Session{
+		UserID:    userID,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		s.t.Fatalf("Failed to create session: %v", err)
+	}
+	return token
+}
+
+// do sends a request and decodes a JSON response into out when it is not nil
+func (s *testServer) do(method, path, token string, headers map[string]string, body, out interface{}) int {
+	s.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("Failed to encode request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, s.url+path, reader)
+	if err != nil {
+		s.t.Fatalf("Failed to build request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			s.t.Fatalf("Failed to decode %s %s response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestE2E_HealthIsPublic(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+
+	// Act
+	var resp map[string]string
+	status := s.do(http.MethodGet, "/api/health", "", nil, nil, &resp)
+
+	// Assert
+	if status != http.StatusOK || resp["status"] != "ok" {
+		t.Fatalf("Expected health to be ok, got status %d body %v", status, resp)
+	}
+}
+
+func TestE2E_StatusIsPublicAndHoldsNoUserData(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+
+	// Act
+	var resp map[string]any
+	code := s.do(http.MethodGet, "/api/status", "", nil, nil, &resp)
+
+	// Assert
+	if code != http.StatusOK || resp["status"] != "ok" || resp["version"] != "dev" {
+		t.Fatalf("Expected an ok status for the dev build, got status %d body %v", code, resp)
+	}
+	migrations, _ := resp["migrations"].(map[string]any)
+	if migrations["status"] != "up_to_date" || migrations["version"] != migrations["latest"] {
+		t.Errorf("Expected migrations up to date, got %v", migrations)
+	}
+	for _, key := range []string{"queues", "uptime_seconds", "started_at"} {
+		if _, ok := resp[key]; !ok {
+			t.Errorf("Expected %s in the status, got %v", key, resp)
+		}
+	}
+}
+
+func TestE2E_ProtectedRoutesRequireAuth(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	forged, err := auth.GenerateJWT(passkey.SingleUserID, "admin@selfhosted.local", []byte(testJWTSecret))
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	// Act & Assert
+	if status := s.do(http.MethodGet, "/api/accounts", "", nil, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", status)
+	}
+	if status := s.do(http.MethodGet, "/api/accounts", "not-a-jwt", nil, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a malformed token, got %d", status)
+	}
+	if status := s.do(http.MethodGet, "/api/accounts", forged, nil, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a token without a session, got %d", status)
+	}
+	if status := s.do(http.MethodGet, "/api/accounts", s.login(), nil, nil, nil); status != http.StatusOK {
+		t.Errorf("Expected 200 with a session token, got %d", status)
+	}
+}
+
+func TestE2E_VersionedAndUnversionedRoutesShareServices(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	token := s.login()
+	status := s.do(http.MethodPost, "/api/v2/accounts", token, nil, account.CreateAccountRequest{
+		Name:     "Versioned",
+		Type:     account.AccountTypeChecking,
+		Currency: account.CurrencyCAD,
+		IsAsset:  true,
+	}, nil)
+	if status != http.StatusCreated {
+		t.Fatalf("Expected account to be created through v2, got status %d", status)
+	}
+
+	// Act
+	var v1, legacy account.ListAccountsResponse
+	v1Status := s.do(http.MethodGet, "/api/v1/accounts", token, nil, nil, &v1)
+	legacyStatus := s.do(http.MethodGet, "/api/accounts", token, nil, nil, &legacy)
+	unknownStatus := s.do(http.MethodGet, "/api/accounts", token, map[string]string{server.APIVersionHeader: "v9"}, nil, nil)
+
+	// Assert
+	if v1Status != http.StatusOK || legacyStatus != http.StatusOK {
+		t.Fatalf("Expected v1 and unversioned lists to succeed, got %d and %d", v1Status, legacyStatus)
+	}
+	if len(v1.Accounts) != 1 || len(legacy.Accounts) != 1 || v1.Accounts[0].ID != legacy.Accounts[0].ID {
+		t.Errorf("Expected both versions to list the account created through v2, got %d and %d", len(v1.Accounts), len(legacy.Accounts))
+	}
+	if unknownStatus != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unsupported API version, got %d", unknownStatus)
+	}
+}
+
+func TestE2E_AccountCRUD(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	token := s.login()
+
+	// Act: create
+	var created account.Account
+	status := s.do(http.MethodPost, "/api/accounts", token, nil, account.CreateAccountRequest{
+		Name:     "E2E Chequing",
+		Type:     account.AccountTypeChecking,
+		Currency: account.CurrencyCAD,
+		IsAsset:  true,
+	}, &created)
+
+	// Assert
+	if status != http.StatusCreated || created.ID == "" {
+		t.Fatalf("Expected account to be created, got status %d", status)
+	}
+
+	// Act: update
+	name := "E2E Everyday"
+	var updated account.Account
+	status = s.do(http.MethodPut, "/api/accounts/"+created.ID, token, nil, account.UpdateAccountRequest{Name: &name}, &updated)
+
+	// Assert
+	if status != http.StatusOK || updated.Name != name {
+		t.Fatalf("Expected account to be renamed, got status %d name %q", status, updated.Name)
+	}
+
+	// Act: list
+	var list account.ListAccountsResponse
+	status = s.do(http.MethodGet, "/api/accounts", token, nil, nil, &list)
+
+	// Assert
+	if status != http.StatusOK || len(list.Accounts) != 1 || list.Accounts[0].ID != created.ID {
+		t.Fatalf("Expected the new account to be listed, got status %d accounts %d", status, len(list.Accounts))
+	}
+
+	// Act: delete
+	status = s.do(http.MethodDelete, "/api/accounts/"+created.ID, token, nil, nil, nil)
+
+	// Assert
+	if status != http.StatusOK && status != http.StatusNoContent {
+		t.Fatalf("Expected account to be deleted, got status %d", status)
+	}
+	if status := s.do(http.MethodGet, "/api/accounts/"+created.ID, token, nil, nil, nil); status == http.StatusOK {
+		t.Error("Expected deleted account to be gone")
+	}
+}
+
+func TestE2E_DemoModeServesDemoData(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	token := s.login()
+	demo := map[string]string{"X-Demo-Mode": "true"}
+
+	// Act
+	var demoList, ownList account.ListAccountsResponse
+	demoStatus := s.do(http.MethodGet, "/api/accounts", token, demo, nil, &demoList)
+	ownStatus := s.do(http.MethodGet, "/api/accounts", token, nil, nil, &ownList)
+
+	// Assert
+	if demoStatus != http.StatusOK || len(demoList.Accounts) == 0 {
+		t.Fatalf("Expected demo accounts to be provisioned, got status %d accounts %d", demoStatus, len(demoList.Accounts))
+	}
+	for _, acc := range demoList.Accounts {
+		if acc.UserID != passkey.DemoUserID {
+			t.Errorf("Expected demo account %s to belong to the demo user, got %s", acc.ID, acc.UserID)
+		}
+	}
+	if ownStatus != http.StatusOK || len(ownList.Accounts) != 0 {
+		t.Errorf("Expected demo data to stay out of the user's accounts, got status %d accounts %d", ownStatus, len(ownList.Accounts))
+	}
+}
+
+func TestE2E_AdminChangesRuntimeSettings(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	token := s.login()
+	demo := map[string]string{"X-Demo-Mode": "true"}
+	change := settings.UpdateSettingRequest{Value: "hourly"}
+
+	// Act
+	demoStatus := s.do(http.MethodPut, "/api/admin/settings/"+settings.KeySyncDefaultFrequency, token, demo, change, nil)
+	invalidStatus := s.do(http.MethodPut, "/api/admin/settings/"+settings.KeySyncDefaultFrequency, token, nil, settings.UpdateSettingRequest{Value: "yearly"}, nil)
+	status := s.do(http.MethodPut, "/api/admin/settings/"+settings.KeySyncDefaultFrequency, token, nil, change, nil)
+	var list settings.ListSettingsResponse
+	listStatus := s.do(http.MethodGet, "/api/admin/settings", token, nil, nil, &list)
+
+	// Assert
+	if demoStatus != http.StatusForbidden {
+		t.Errorf("Expected demo mode to be refused, got status %d", demoStatus)
+	}
+	if invalidStatus != http.StatusBadRequest {
+		t.Errorf("Expected an invalid value to be rejected, got status %d", invalidStatus)
+	}
+	if status != http.StatusOK || listStatus != http.StatusOK {
+		t.Fatalf("Expected the admin to change settings, got status %d and %d", status, listStatus)
+	}
+	for _, setting := range list.Settings {
+		if setting.Key == settings.KeySyncDefaultFrequency && (setting.Value == nil || *setting.Value != "hourly") {
+			t.Errorf("Expected the new sync frequency to be stored, got %v", setting.Value)
+		}
+	}
+}
+
+func TestE2E_ServiceKeysCannotManageKeys(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	token := s.login()
+	var created apikeys.ServiceKeySecretResponse
+	if status := s.do(http.MethodPost, "/api/service-keys", token, nil, apikeys.CreateServiceKeyRequest{
+		Name: "automation", Scopes: []string{auth.ScopeWrite},
+	}, &created); status != http.StatusCreated {
+		t.Fatalf("Expected the session to create a key, got status %d", status)
+	}
+
+	// Act
+	accountsStatus := s.do(http.MethodGet, "/api/accounts", created.Key, nil, nil, nil)
+	mintStatus := s.do(http.MethodPost, "/api/service-keys", created.Key, nil, apikeys.CreateServiceKeyRequest{
+		Name: "escalated", Scopes: []string{auth.ScopeWrite},
+	}, nil)
+	rotateStatus := s.do(http.MethodPost, "/api/service-keys/"+created.ID+"/rotate", created.Key, nil, nil, nil)
+
+	// Assert
+	if accountsStatus != http.StatusOK {
+		t.Errorf("Expected the write key to read accounts, got status %d", accountsStatus)
+	}
+	if mintStatus != http.StatusForbidden || rotateStatus != http.StatusForbidden {
+		t.Errorf("Expected key management to be refused for a service key, got %d and %d", mintStatus, rotateStatus)
+	}
+}
+
+func TestE2E_ServiceKeysCannotHandOutOrDestroyData(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	token := s.login()
+	var created apikeys.ServiceKeySecretResponse
+	if status := s.do(http.MethodPost, "/api/service-keys", token, nil, apikeys.CreateServiceKeyRequest{
+		Name: "automation", Scopes: []string{auth.ScopeWrite},
+	}, &created); status != http.StatusCreated {
+		t.Fatalf("Expected the session to create a key, got status %d", status)
+	}
+	requests := []struct{ method, path string }{
+		{http.MethodPost, "/api/advisors/invites"},
+		{http.MethodPost, "/api/data/deletion"},
+		{http.MethodGet, "/api/data/export/complete"},
+		{http.MethodPost, "/api/shares"},
+		{http.MethodPost, "/api/reports/schedules"},
+		{http.MethodPost, "/api/hooks"},
+		{http.MethodGet, "/api/admin/settings"},
+	}
+
+	for _, req := range requests {
+		// Act
+		status := s.do(req.method, req.path, created.Key, nil, map[string]string{}, nil)
+
+		// Assert
+		if status != http.StatusForbidden {
+			t.Errorf("Expected %s %s to be refused for a service key, got status %d", req.method, req.path, status)
+		}
+	}
+}
+
+func TestE2E_ServiceKeyRequestsAreLogged(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	token := s.login()
+	var created apikeys.ServiceKeySecretResponse
+	if status := s.do(http.MethodPost, "/api/service-keys", token, nil, apikeys.CreateServiceKeyRequest{
+		Name: "reader", Scopes: []string{auth.ScopeRead},
+	}, &created); status != http.StatusCreated {
+		t.Fatalf("Expected the session to create a key, got status %d", status)
+	}
+
+	// Act
+	readStatus := s.do(http.MethodGet, "/api/accounts", created.Key, nil, nil, nil)
+	writeStatus := s.do(http.MethodPost, "/api/accounts", created.Key, nil, map[string]string{}, nil)
+	var usage apikeys.UsageResponse
+	usageStatus := s.do(http.MethodGet, "/api/service-keys/"+created.ID+"/usage", token, nil, nil, &usage)
+
+	// Assert
+	if readStatus != http.StatusOK || writeStatus != http.StatusForbidden {
+		t.Fatalf("Expected the read key to read and not write, got %d and %d", readStatus, writeStatus)
+	}
+	if usageStatus != http.StatusOK {
+		t.Fatalf("Expected the key's usage, got status %d", usageStatus)
+	}
+	if usage.Summary.TotalRequests != 2 || usage.Summary.ErrorRequests != 1 || len(usage.Entries) != 2 {
+		t.Fatalf("Expected 2 logged requests with 1 error, got %+v", usage.Summary)
+	}
+	statuses := make(map[int]int)
+	for _, entry := range usage.Entries {
+		if entry.APIKeyID != created.ID || entry.Endpoint != "/api/accounts" || entry.IPAddress == nil {
+			t.Errorf("Expected the entry to name the key, endpoint and client IP, got %+v", entry)
+		}
+		statuses[entry.StatusCode]++
+	}
+	if statuses[http.StatusOK] != 1 || statuses[http.StatusForbidden] != 1 {
+		t.Errorf("Expected one 200 and one 403 logged, got %v", statuses)
+	}
+}
+
+func TestE2E_DelegatesCannotRevealPrivateBalances(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	ownerToken := s.login()
+	advisorID, advisorEmail := "e2e-advisor", "advisor@example.com"
+	if _, err := s.db.DB().Exec(`
+		INSERT INTO users (id, email, created_at, updated_at) VALUES ($1, $2, $3, $3)
+	`, advisorID, advisorEmail, time.Now()); err != nil {
+		t.Fatalf("Failed to create advisor: %v", err)
+	}
+	if _, err := s.db.DB().Exec(`UPDATE users SET privacy_mode = 1 WHERE id = $1`, passkey.SingleUserID); err != nil {
+		t.Fatalf("Failed to turn on privacy mode: %v", err)
+	}
+	invite, err := s.svc.advisor.InviteAdvisor(auth.WithUserID(context.Background(), passkey.SingleUserID), &advisor.InviteAdvisorRequest{
+		Email: advisorEmail, Access: auth.AccessRead, Modules: []string{auth.ModuleAccounts},
+	})
+	if err != nil {
+		t.Fatalf("InviteAdvisor failed: %v", err)
+	}
+	if _, err := s.svc.advisor.AcceptInvite(auth.WithUserID(context.Background(), advisorID), &advisor.AcceptInviteRequest{Token: invite.InviteToken}); err != nil {
+		t.Fatalf("AcceptInvite failed: %v", err)
+	}
+	advisorToken := s.loginAs(advisorID, advisorEmail)
+	actingFor := map[string]string{auth.ActingForHeader: passkey.SingleUserID}
+
+	// Act
+	ownerStatus := s.do(http.MethodGet, "/api/accounts?reveal=true", ownerToken, nil, nil, nil)
+	maskedStatus := s.do(http.MethodGet, "/api/accounts", advisorToken, actingFor, nil, nil)
+	revealStatus := s.do(http.MethodGet, "/api/accounts?reveal=true", advisorToken, actingFor, nil, nil)
+
+	// Assert
+	if ownerStatus != http.StatusOK || maskedStatus != http.StatusOK {
+		t.Fatalf("Expected the owner to reveal and the advisor to read masked, got %d and %d", ownerStatus, maskedStatus)
+	}
+	if revealStatus != http.StatusForbidden {
+		t.Errorf("Expected a freshly logged in delegate to be refused reveal, got status %d", revealStatus)
+	}
+}
+
+func TestE2E_SharedReportsMaskPrivateAccounts(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	token := s.login()
+	publicID := account.CreateTestAccount(t, s.db.DB(), passkey.SingleUserID, account.AccountTypeSavings)
+	account.CreateTestBalance(t, s.db.DB(), publicID, 1000)
+	privateID := account.CreateTestAccount(t, s.db.DB(), passkey.SingleUserID, account.AccountTypeSavings)
+	account.CreateTestBalance(t, s.db.DB(), privateID, 5000)
+	if _, err := s.db.DB().Exec(`UPDATE accounts SET is_private = 1 WHERE id = $1`, privateID); err != nil {
+		t.Fatalf("Failed to mark the account private: %v", err)
+	}
+	var link share.LinkSecretResponse
+	if status := s.do(http.MethodPost, "/api/shares", token, nil, share.CreateLinkRequest{Report: share.ReportNetWorth}, &link); status != http.StatusCreated {
+		t.Fatalf("Expected the share link to be created, got status %d", status)
+	}
+
+	// Act
+	var statement account.NetWorthStatement
+	status := s.do(http.MethodGet, "/api/shared/"+link.Token, "", nil, nil, &statement)
+
+	// Assert
+	if status != http.StatusOK {
+		t.Fatalf("Expected the shared statement, got status %d", status)
+	}
+	for _, acc := range statement.Assets {
+		if acc.ID == privateID && (!acc.BalanceMasked || acc.CurrentBalance != nil) {
+			t.Errorf("Expected the private account's balance to be masked, got %+v", acc)
+		}
+		if acc.ID == publicID && acc.CurrentBalance == nil {
+			t.Errorf("Expected the public account's balance to be shown, got %+v", acc)
+		}
+	}
+	if totals := statement.ByCurrency["CAD"]; totals == nil || totals.Assets != 1000 {
+		t.Errorf("Expected totals without the private account, got %+v", totals)
+	}
+}
+
+func TestE2E_SharedReportAccessLogsClientIP(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	token := s.login()
+	var link share.LinkSecretResponse
+	if status := s.do(http.MethodPost, "/api/shares", token, nil, share.CreateLinkRequest{Report: share.ReportNetWorth}, &link); status != http.StatusCreated {
+		t.Fatalf("Expected the share link to be created, got status %d", status)
+	}
+
+	// Act
+	viewStatus := s.do(http.MethodGet, "/api/shared/"+link.Token, "", nil, nil, nil)
+	var accesses share.ListAccessesResponse
+	listStatus := s.do(http.MethodGet, "/api/shares/"+link.ID+"/accesses", token, nil, nil, &accesses)
+
+	// Assert
+	if viewStatus != http.StatusOK || listStatus != http.StatusOK {
+		t.Fatalf("Expected the view to be logged, got status %d and %d", viewStatus, listStatus)
+	}
+	if len(accesses.Accesses) != 1 || accesses.Accesses[0].IPAddress == nil || *accesses.Accesses[0].IPAddress != "127.0.0.1" {
+		t.Errorf("Expected the client IP without a port, got %+v", accesses.Accesses)
+	}
+}
+
+func TestE2E_SyncWithSandboxProvider(t *testing.T) {
+	// Arrange
+	cfg := wealthsimple.DefaultSandboxConfig()
+	cfg.LatencyMS = 0
+	cfg.BalanceDrift = 0
+	wealthsimple.EnableSandbox(cfg)
+	s := newTestServer(t)
+	token := s.login()
+
+	// Act
+	credentialID := s.connectSandbox(token, cfg.OTPCode)
+
+	// The initial sync runs in the background
+	syncStatus := s.waitForSync(token, credentialID)
+
+	// Assert
+	if syncStatus.Status != sync.StatusConnected {
+		t.Fatalf("Expected connection to be connected, got %s (%s)", syncStatus.Status, syncStatus.LastSyncError)
+	}
+	var list account.ListAccountsResponse
+	s.do(http.MethodGet, "/api/accounts", token, nil, nil, &list)
+	synced := 0
+	for _, acc := range list.Accounts {
+		if acc.IsSynced {
+			synced++
+		}
+	}
+	if synced != len(cfg.Accounts) {
+		t.Errorf("Expected %d synced accounts, got %d", len(cfg.Accounts), synced)
+	}
+}
+
+func TestE2E_SyncResumesFromCheckpoint(t *testing.T) {
+	// Arrange
+	cfg := wealthsimple.DefaultSandboxConfig()
+	cfg.LatencyMS = 0
+	cfg.BalanceDrift = 0
+	wealthsimple.EnableSandbox(cfg)
+	s := newTestServer(t)
+	token := s.login()
+	credentialID := s.connectSandbox(token, cfg.OTPCode)
+	s.waitForSync(token, credentialID)
+	before := s.syncJobCounts(credentialID)
+	checkpointed := cfg.Accounts[0].ID
+
+	// A shutdown stopped the next run after its first account
+	_, err := s.db.DB().Exec(`
+		UPDATE sync_credentials SET status = $1, sync_checkpoint = $2 WHERE id = $3
+	`, sync.StatusSyncing, `["`+checkpointed+`"]`, credentialID)
+	if err != nil {
+		t.Fatalf("Failed to simulate an interrupted sync: %v", err)
+	}
+
+	// Act
+	resumed, err := s.svc.sync.ResumeInterruptedSyncs(context.Background())
+	status := s.waitForSync(token, credentialID)
+
+	// Assert
+	if err != nil || resumed != 1 {
+		t.Fatalf("Expected one sync to resume, got %d (%v)", resumed, err)
+	}
+	if status.Status != sync.StatusConnected {
+		t.Fatalf("Expected the resumed sync to finish, got %s (%s)", status.Status, status.LastSyncError)
+	}
+	after := s.syncJobCounts(credentialID)
+	for providerID, count := range after {
+		want := before[providerID] + 1
+		if providerID == checkpointed {
+			want = before[providerID]
+		}
+		if count != want {
+			t.Errorf("Expected %d sync jobs for %s, got %d", want, providerID, count)
+		}
+	}
+	var checkpoint *string
+	s.db.DB().QueryRow(`SELECT sync_checkpoint FROM sync_credentials WHERE id = $1`, credentialID).Scan(&checkpoint)
+	if checkpoint != nil {
+		t.Errorf("Expected the checkpoint to be cleared, got %s", *checkpoint)
+	}
+}
+
+func TestE2E_PausedAccountsSkipConnectionSync(t *testing.T) {
+	// Arrange
+	cfg := wealthsimple.DefaultSandboxConfig()
+	cfg.LatencyMS = 0
+	cfg.BalanceDrift = 0
+	wealthsimple.EnableSandbox(cfg)
+	s := newTestServer(t)
+	token := s.login()
+	credentialID := s.connectSandbox(token, cfg.OTPCode)
+	s.waitForSync(token, credentialID)
+
+	var synced sync.ListSyncedAccountsResponse
+	if status := s.do(http.MethodGet, "/api/sync/accounts", token, nil, nil, &synced); status != http.StatusOK {
+		t.Fatalf("Expected synced accounts, got status %d", status)
+	}
+	if len(synced.Accounts) < 2 {
+		t.Fatalf("Expected at least 2 synced accounts, got %d", len(synced.Accounts))
+	}
+	paused, other := synced.Accounts[0], synced.Accounts[1]
+	var pausedAccount sync.SyncedAccount
+	status := s.do(http.MethodPut, "/api/sync/accounts/"+paused.AccountID+"/pause", token, nil,
+		sync.SetAccountPausedRequest{Paused: true}, &pausedAccount)
+	if status != http.StatusOK || !pausedAccount.Paused {
+		t.Fatalf("Expected the account to be paused, got status %d", status)
+	}
+	before := s.syncJobCounts(credentialID)
+
+	// Act
+	s.do(http.MethodPost, "/api/sync/connections/"+credentialID+"/sync", token, nil, nil, nil)
+	s.waitForSync(token, credentialID)
+	afterConnection := s.syncJobCounts(credentialID)
+	var triggered sync.TriggerAccountSyncResponse
+	status = s.do(http.MethodPost, "/api/sync/accounts/"+other.AccountID+"/sync", token, nil, nil, &triggered)
+	s.waitForSyncJob(triggered.JobID)
+	afterAccount := s.syncJobCounts(credentialID)
+
+	// Assert
+	if status != http.StatusAccepted || triggered.JobID == "" {
+		t.Fatalf("Expected a single account sync to start, got status %d", status)
+	}
+	for providerID, count := range afterConnection {
+		want := before[providerID] + 1
+		if providerID == paused.ProviderAccountID {
+			want = before[providerID]
+		}
+		if count != want {
+			t.Errorf("Expected %d sync jobs for %s after the connection sync, got %d", want, providerID, count)
+		}
+	}
+	for providerID, count := range afterAccount {
+		want := afterConnection[providerID]
+		if providerID == other.ProviderAccountID {
+			want++
+		}
+		if count != want {
+			t.Errorf("Expected %d sync jobs for %s after the account sync, got %d", want, providerID, count)
+		}
+	}
+}
+
+func TestE2E_SyncRecordsCashInterestAsIncome(t *testing.T) {
+	// Arrange
+	cfg := wealthsimple.DefaultSandboxConfig()
+	cfg.LatencyMS = 0
+	cfg.BalanceDrift = 0
+	wealthsimple.EnableSandbox(cfg)
+	s := newTestServer(t)
+	token := s.login()
+	credentialID := s.connectSandbox(token, cfg.OTPCode)
+	s.waitForSync(token, credentialID)
+	var synced sync.ListSyncedAccountsResponse
+	s.do(http.MethodGet, "/api/sync/accounts", token, nil, nil, &synced)
+	var cash sync.SyncedAccount
+	for _, acc := range synced.Accounts {
+		if acc.ProviderAccountID == "sandbox-cash" {
+			cash = acc
+		}
+	}
+	if cash.AccountID == "" {
+		t.Fatal("Expected the sandbox cash account to be synced")
+	}
+
+	// Act
+	var triggered sync.TriggerAccountSyncResponse
+	s.do(http.MethodPost, "/api/sync/accounts/"+cash.AccountID+"/sync", token, nil, nil, &triggered)
+	s.waitForSyncJob(triggered.JobID)
+
+	// Assert
+	var transactions, linked int
+	var total float64
+	err := s.db.DB().QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(t.amount), 0), COUNT(r.id)
+		FROM transactions t
+		LEFT JOIN income_records r ON r.id = t.income_record_id AND r.category = 'investment' AND r.is_taxable
+		WHERE t.account_id = $1 AND t.category = 'Interest Income'
+	`, cash.AccountID).Scan(&transactions, &total, &linked)
+	if err != nil {
+		t.Fatalf("Failed to read interest transactions: %v", err)
+	}
+	if transactions != 3 {
+		t.Errorf("Expected 3 monthly interest payments recorded once despite the second sync, got %d", transactions)
+	}
+	if linked != transactions {
+		t.Errorf("Expected every payment reported as taxable investment income, got %d of %d", linked, transactions)
+	}
+	if want := 3 * 28.65; total < want-0.01 || total > want+0.01 {
+		t.Errorf("Expected %.2f of interest, got %.2f", want, total)
+	}
+}
+
+func TestE2E_DiscoverAccountsBeforeSync(t *testing.T) {
+	// Arrange
+	cfg := wealthsimple.DefaultSandboxConfig()
+	cfg.LatencyMS = 0
+	cfg.BalanceDrift = 0
+	wealthsimple.EnableSandbox(cfg)
+	s := newTestServer(t)
+	token := s.login()
+	var initiated sync.InitiateConnectionResponse
+	s.do(http.MethodPost, "/api/sync/wealthsimple/initiate", token, nil, sync.InitiateConnectionRequest{
+		Username: "e2e@example.com",
+		Password: "password",
+	}, &initiated)
+	var verified sync.VerifyOTPResponse
+	s.do(http.MethodPost, "/api/sync/wealthsimple/verify-otp", token, nil, sync.VerifyOTPRequest{
+		CredentialID:   initiated.CredentialID,
+		OTPCode:        cfg.OTPCode,
+		SelectAccounts: true,
+	}, &verified)
+	if verified.Status != sync.StatusConnected {
+		t.Fatalf("Expected the connection to wait for account selection, got %s", verified.Status)
+	}
+
+	// Act
+	var discovered sync.DiscoverAccountsResponse
+	status := s.do(http.MethodGet, "/api/sync/connections/"+initiated.CredentialID+"/discover", token, nil, nil, &discovered)
+	if status != http.StatusOK || len(discovered.Accounts) < 2 {
+		t.Fatalf("Expected discovered accounts, got status %d", status)
+	}
+	chosen, skipped := discovered.Accounts[0], discovered.Accounts[1]
+	var selected sync.SelectAccountsResponse
+	status = s.do(http.MethodPost, "/api/sync/connections/"+initiated.CredentialID+"/accounts", token, nil, sync.SelectAccountsRequest{
+		Accounts: []sync.AccountChoice{
+			{ProviderAccountID: chosen.ProviderAccountID, Name: "Renamed Account"},
+			{ProviderAccountID: skipped.ProviderAccountID, Skip: true},
+		},
+	}, &selected)
+	syncStatus := s.waitForSync(token, initiated.CredentialID)
+
+	// Assert
+	if status != http.StatusAccepted || len(selected.Created) != 1 || selected.Skipped != 1 {
+		t.Fatalf("Expected one account created and one skipped, got status %d: %+v", status, selected)
+	}
+	if syncStatus.Status != sync.StatusConnected {
+		t.Fatalf("Expected the sync to finish, got %s (%s)", syncStatus.Status, syncStatus.LastSyncError)
+	}
+	var list account.ListAccountsResponse
+	s.do(http.MethodGet, "/api/accounts", token, nil, nil, &list)
+	var synced []string
+	for _, acc := range list.Accounts {
+		if acc.IsSynced {
+			synced = append(synced, acc.Name)
+		}
+	}
+	if len(synced) != 1 || synced[0] != "Renamed Account" {
+		t.Errorf("Expected only the chosen account to be created, got %v", synced)
+	}
+	if counts := s.syncJobCounts(initiated.CredentialID); counts[chosen.ProviderAccountID] != 1 {
+		t.Errorf("Expected the chosen account to be synced once, got %v", counts)
+	}
+}
+
+// connectSandbox connects the sandbox provider and returns the connection ID
+func (s *testServer) connectSandbox(token, otpCode string) string {
+	s.t.Helper()
+
+	var initiated sync.InitiateConnectionResponse
+	status := s.do(http.MethodPost, "/api/sync/wealthsimple/initiate", token, nil, sync.InitiateConnectionRequest{
+		Username: "e2e@example.com",
+		Password: "password",
+	}, &initiated)
+	if status != http.StatusOK || initiated.CredentialID == "" {
+		s.t.Fatalf("Expected connection to be initiated, got status %d", status)
+	}
+	status = s.do(http.MethodPost, "/api/sync/wealthsimple/verify-otp", token, nil, sync.VerifyOTPRequest{
+		CredentialID: initiated.CredentialID,
+		OTPCode:      otpCode,
+	}, nil)
+	if status != http.StatusOK {
+		s.t.Fatalf("Expected OTP to be accepted, got status %d", status)
+	}
+	return initiated.CredentialID
+}
+
+// waitForSync polls a connection until its background sync finishes
+func (s *testServer) waitForSync(token, credentialID string) sync.ConnectionSyncStatusResponse {
+	s.t.Helper()
+
+	var syncStatus sync.ConnectionSyncStatusResponse
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		status := s.do(http.MethodGet, "/api/sync/connections/"+credentialID+"/status", token, nil, nil, &syncStatus)
+		if status != http.StatusOK {
+			s.t.Fatalf("Expected sync status, got status %d", status)
+		}
+		if syncStatus.Status != sync.StatusSyncing {
+			return syncStatus
+		}
+		if time.Now().After(deadline) {
+			s.t.Fatal("Timed out waiting for the sync")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// waitForSyncJob polls a sync job until it is no longer running
+func (s *testServer) waitForSyncJob(jobID string) {
+	s.t.Helper()
+
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		var status sync.SyncJobStatus
+		if err := s.db.DB().QueryRow(`SELECT status FROM sync_jobs WHERE id = $1`, jobID).Scan(&status); err != nil {
+			s.t.Fatalf("Failed to get sync job: %v", err)
+		}
+		if status != sync.SyncJobStatusRunning {
+			if status != sync.SyncJobStatusCompleted {
+				s.t.Fatalf("Expected the sync job to complete, got %s", status)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			s.t.Fatal("Timed out waiting for the sync job")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// syncJobCounts counts the sync jobs run for each provider account of a connection
+func (s *testServer) syncJobCounts(credentialID string) map[string]int {
+	s.t.Helper()
+
+	rows, err := s.db.DB().Query(`
+		SELECT sa.provider_account_id, COUNT(j.id)
+		FROM synced_accounts sa
+		LEFT JOIN sync_jobs j ON j.synced_account_id = sa.id
+		WHERE sa.credential_id = $1
+		GROUP BY sa.provider_account_id
+	`, credentialID)
+	if err != nil {
+		s.t.Fatalf("Failed to count sync jobs: %v", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var providerID string
+		var count int
+		if err := rows.Scan(&providerID, &count); err != nil {
+			s.t.Fatalf("Failed to scan sync job count: %v", err)
+		}
+		counts[providerID] = count
+	}
+	return counts
+}
+
+func TestE2E_ProjectionCalculation(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	token := s.login()
+	var created account.Account
+	s.do(http.MethodPost, "/api/accounts", token, nil, account.CreateAccountRequest{
+		Name:     "E2E Savings",
+		Type:     account.AccountTypeSavings,
+		Currency: account.CurrencyCAD,
+		IsAsset:  true,
+	}, &created)
+
+	// Act
+	var resp projections.ProjectionResponse
+	status := s.do(http.MethodPost, "/api/projections/calculate", token, nil, projections.ProjectionRequest{
+		Config: &projections.Config{
+			TimeHorizonYears:   5,
+			InflationRate:      0.02,
+			AnnualSalary:       90000,
+			MonthlyExpenses:    3000,
+			MonthlySavingsRate: 0.2,
+			InvestmentReturns:  map[string]float64{"savings": 0.03},
+			SavingsAllocation:  map[string]float64{"savings": 1},
+		},
+	}, &resp)
+
+	// Assert
+	if status != http.StatusOK {
+		t.Fatalf("Expected projection to be calculated, got status %d", status)
+	}
+	if len(resp.NetWorth) == 0 {
+		t.Fatal("Expected net worth data points")
+	}
+	if last := resp.NetWorth[len(resp.NetWorth)-1]; last.Value <= resp.NetWorth[0].Value {
+		t.Errorf("Expected net worth to grow with savings, got %.2f to %.2f", resp.NetWorth[0].Value, last.Value)
+	}
+}
@@ -9,29 +9,21 @@ import (
 	"syscall"
 	"time"
 
-	"money/internal/account"
-	"money/internal/apikeys"
-	"money/internal/auth"
-	"money/internal/auth/passkey"
-	"money/internal/balance"
-	"money/internal/currency"
-	"money/internal/data"
+	"money/internal/civil"
 	"money/internal/database"
 	"money/internal/env"
-	"money/internal/holdings"
-	"money/internal/income"
 	"money/internal/logger"
-	"money/internal/moneyy"
-	"money/internal/projections"
-	"money/internal/server/handlers"
-	"money/internal/sync"
-	"money/internal/transaction"
-
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/cors"
+	"money/internal/server"
+	"money/internal/sync/wealthsimple"
 )
 
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+// backfills fill columns added by expand migrations. Remove an entry in the release that
+// ships its contract migration.
+var backfills []database.Backfill
+
 func main() {
 	// Load environment variables
 	env.MustLoad()
@@ -47,6 +39,12 @@ func main() {
 	}
 	defer dbManager.Close()
 
+	// Refuse to start against a dirty or newer schema, or with contract migrations pending
+	// that older instances may still depend on
+	if err := dbManager.CheckMigrations(env.GetBool("MIGRATE_ALLOW_CONTRACT", false)); err != nil {
+		log.Fatalf("Refusing to start: %v", err)
+	}
+
 	// Run migrations
 	logger.Info("Running database migrations")
 	if err := dbManager.Migrate(); err != nil {
@@ -60,67 +58,47 @@ func main() {
 	// Initialize services with dependency injection
 	logger.Info("Initializing services")
 
-	// Balance service (no dependencies)
-	balanceSvc := balance.NewService(db)
-
-	// Currency service (no dependencies)
-	currencySvc := currency.NewService(db)
-
-	// Holdings service (no dependencies)
-	holdingsSvc := holdings.NewService(db)
+	encryptionKey := env.MustGet("ENC_MASTER_KEY")
+	// SYNC_PROVIDER_MODE=sandbox simulates Wealthsimple for local development
+	if env.Get("SYNC_PROVIDER_MODE", "live") == "sandbox" {
+		if env.Get("APP_ENV", "development") == "production" {
+			log.Fatalf("The sync sandbox cannot be enabled in production")
+		}
+		sandboxCfg, err := wealthsimple.LoadSandboxConfig(env.Get("SYNC_SANDBOX_CONFIG", ""))
+		if err != nil {
+			log.Fatalf("Failed to load sync sandbox config: %v", err)
+		}
+		wealthsimple.EnableSandbox(sandboxCfg)
+		logger.Info("Sync provider sandbox enabled", "accounts", len(sandboxCfg.Accounts), "otp_code", sandboxCfg.OTPCode)
+	}
 
-	// Transaction service (no dependencies)
-	transactionSvc := transaction.NewService(db)
+	svc, err := newServices(db, encryptionKey)
+	if err != nil {
+		log.Fatalf("Failed to initialize services: %v", err)
+	}
 
-	// Account service (depends on balance service)
-	accountSvc := account.NewService(
-		db,
-		db,  // balance DB is same now
-		balanceSvc,
-	)
+	// FEATURE_FLAGS configures instance-wide rollout
+	if err := svc.flags.ApplyInstanceFlags(context.Background(), env.Get("FEATURE_FLAGS", "")); err != nil {
+		log.Fatalf("Failed to apply feature flags: %v", err)
+	}
 
-	// Projections service (depends on account and transaction)
-	projectionsSvc := projections.NewService(
-		db,
-		db,  // all same DB now
-		accountSvc,
-		transactionSvc,
-	)
+	// Settings changed through the admin API override the environment
+	if err := svc.settings.Load(context.Background()); err != nil {
+		log.Fatalf("Failed to apply runtime settings: %v", err)
+	}
 
-	// Sync service (depends on account, balance, and holdings)
-	encryptionKey := env.MustGet("ENC_MASTER_KEY")
-	syncSvc := sync.NewService(
-		db,
-		accountSvc,
-		balanceSvc,
-		holdingsSvc,
-		encryptionKey,
-	)
-
-	// Data export/import services (no dependencies)
-	exportSvc := data.NewExportService(db)
-	importSvc := data.NewImportService(db)
-
-	// Demo service (depends on import/export services)
-	demoSvc := data.NewDemoService(db)
-
-	// Income service (no dependencies)
-	incomeSvc := income.NewService(db)
-
-	// API Keys service (depends on encryption key)
-	apiKeysSvc, err := apikeys.NewService(db, encryptionKey)
+	// DEFAULT_TIMEZONE applies to users without one
+	defaultTimezone, err := time.LoadLocation(env.Get("DEFAULT_TIMEZONE", "UTC"))
 	if err != nil {
-		log.Fatalf("Failed to initialize API keys service: %v", err)
+		log.Fatalf("Invalid DEFAULT_TIMEZONE: %v", err)
 	}
-
-	// Moneyy service (depends on API keys service)
-	moneySvc := moneyy.NewService(apiKeysSvc)
+	civil.SetDefaultLocation(defaultTimezone)
 
 	logger.Info("All services initialized successfully")
 
 	// Initialize authentication provider
 	logger.Info("Initializing authentication provider")
-	authProvider, err := initializeAuthProvider(db)
+	authProvider, err := initializeAuthProvider(db, svc.store)
 	if err != nil {
 		log.Fatalf("Failed to initialize auth provider: %v", err)
 	}
@@ -132,61 +110,107 @@ func main() {
 
 	logger.Info("Authentication initialized")
 
-	// Setup HTTP router
-	logger.Info("Registering HTTP handlers")
-	r := chi.NewRouter()
-
-	// Middleware
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
-	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
-
-	// CORS middleware
-	corsOrigins := env.Get("CORS_ORIGINS", "http://localhost:5173")
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{corsOrigins},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Demo-Mode"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: false,
-		MaxAge:           300,
-	}))
-
-	// API routes under /api prefix
-	r.Route("/api", func(r chi.Router) {
-		// Health check - must be public for Docker healthcheck
-		r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(`{"status":"ok"}`))
-		})
+	// Run notification checks in the background until shutdown
+	notificationInterval := time.Duration(env.GetInt("NOTIFICATION_CHECK_INTERVAL_HOURS", 24)) * time.Hour
+	svc.notification.Start(svc.jobs, notificationInterval)
 
-		// Auth routes (public)
-		r.Route("/auth", func(r chi.Router) {
-			authProvider.RegisterRoutes(r)
-		})
+	// Deliver notifications to email and webhooks, holding them through quiet hours and
+	// batching them into digests where users asked for it
+	svc.notification.StartDispatch(svc.jobs)
 
-		// Protected routes group
-		r.Group(func(r chi.Router) {
-			// Apply auth middleware to protected routes only
-			r.Use(auth.AuthMiddleware(authProvider))
-			// Apply demo mode middleware after auth
-			r.Use(auth.DemoModeMiddleware(passkey.DemoUserID))
-
-			handlers.NewAccountHandler(accountSvc).RegisterRoutes(r)
-			handlers.NewBalanceHandler(balanceSvc).RegisterRoutes(r)
-			handlers.NewCurrencyHandler(currencySvc).RegisterRoutes(r)
-			handlers.NewHoldingsHandler(holdingsSvc).RegisterRoutes(r)
-			handlers.NewProjectionsHandler(projectionsSvc).RegisterRoutes(r)
-			handlers.NewSyncHandler(syncSvc).RegisterRoutes(r)
-			handlers.NewTransactionHandler(transactionSvc).RegisterRoutes(r)
-			handlers.NewDataHandler(exportSvc, importSvc).RegisterRoutes(r)
-			handlers.NewDemoHandler(demoSvc).RegisterRoutes(r)
-			handlers.NewIncomeHandler(incomeSvc).RegisterRoutes(r)
-			handlers.NewAPIKeysHandler(apiKeysSvc, moneySvc).RegisterRoutes(r)
-		})
-	})
+	// Refresh CPI readings daily; agencies publish monthly
+	svc.inflation.Start(svc.jobs, 24*time.Hour)
+
+	// Purge expired API key usage entries in the background
+	usageRetention := time.Duration(env.GetInt("API_KEY_USAGE_RETENTION_DAYS", 90)) * 24 * time.Hour
+	svc.apiKeys.StartUsageRetention(svc.jobs, usageRetention)
+
+	// Post scheduled transactions as their dates pass
+	svc.transaction.StartScheduledPosting(svc.jobs)
+
+	// Sweep month-end cash surpluses into investment accounts
+	svc.transaction.StartSweeps(svc.jobs)
+
+	// Post scheduled loan and mortgage payments on their due dates
+	svc.account.StartPaymentAutoPosting(svc.jobs)
+
+	// Record the shares sold to cover withholding as RSUs vest
+	svc.account.StartSellToCover(svc.jobs)
+
+	// Record option grants that expire unexercised
+	svc.account.StartGrantExpirations(svc.jobs)
+
+	// Recalculate exercises and sales after strike price or FMV edits
+	svc.account.StartEquityRecalc(svc.jobs)
+
+	// Deliver scheduled reports to S3, WebDAV and email as their cron schedules come due
+	svc.reports.StartDeliveries(svc.jobs)
+
+	// Purge the data of users whose account deletion grace window has ended
+	svc.deletion.StartPurging(svc.jobs)
+
+	// Fingerprint every user's data daily so unexpected changes can be diffed
+	svc.snapshots.StartSnapshots(svc.jobs)
+
+	// Check the instance's data daily for orphaned rows and mismatched totals
+	svc.integrity.StartIntegrityChecks(svc.jobs)
+
+	// Rebuild net worth snapshots after backdated balance changes
+	svc.balance.StartNetWorthRecompute(svc.jobs)
+
+	// Retry sync outbox events that were committed but not yet delivered
+	svc.sync.StartOutboxDispatch(svc.jobs)
+
+	// Fill columns added by expand migrations in batches while serving
+	dbManager.StartBackfills(svc.jobs, backfills)
+
+	// Pick up runtime settings changed by other instances
+	svc.settings.StartWatching(svc.jobs, 30*time.Second)
+
+	// Continue syncs that were running when the server last stopped
+	if resumed, err := svc.sync.ResumeInterruptedSyncs(context.Background()); err != nil {
+		logger.Error("Failed to resume interrupted syncs", "error", err)
+	} else if resumed > 0 {
+		logger.Info("Resumed interrupted syncs", "count", resumed)
+	}
+
+	// Setup HTTP router
+	logger.Info("Registering HTTP handlers")
+	sampleRates, err := server.ParseSampleRates(env.Get("LOG_REQUEST_SAMPLE_RATES", "/api/health=0.01"))
+	if err != nil {
+		log.Fatalf("Invalid request log configuration: %v", err)
+	}
+	requestLog := server.RequestLogConfig{
+		SampleRates:   sampleRates,
+		SlowThreshold: time.Duration(env.GetInt("LOG_SLOW_REQUEST_MS", 2000)) * time.Millisecond,
+	}
+	corsConfig := server.CORSConfig{
+		Origins:          server.ParseCORSOrigins(env.Get("CORS_ORIGINS", "http://localhost:5173")),
+		AllowCredentials: env.GetBool("CORS_ALLOW_CREDENTIALS", false),
+	}
+	if err := corsConfig.Validate(env.Get("APP_ENV", "development")); err != nil {
+		log.Fatalf("Invalid CORS configuration: %v", err)
+	}
+	routeTimeouts, err := server.ParseRouteTimeouts(env.Get("ROUTE_TIMEOUTS", server.DefaultRouteTimeouts))
+	if err != nil {
+		log.Fatalf("Invalid route timeout configuration: %v", err)
+	}
+	timeouts := server.TimeoutConfig{
+		Default: time.Duration(env.GetInt("REQUEST_TIMEOUT_SECONDS", 30)) * time.Second,
+		Routes:  routeTimeouts,
+	}
+	authRateLimit := server.RateLimitConfig{
+		Name:   "auth",
+		Limit:  env.GetInt("AUTH_RATE_LIMIT_PER_MINUTE", 20),
+		Window: time.Minute,
+		Store:  svc.store,
+	}
+	trustedProxies, err := server.ParseTrustedProxies(env.Get("TRUSTED_PROXIES", ""))
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	proxies := server.ProxyConfig{TrustedProxies: trustedProxies}
+	r := newRouter(svc, authProvider, proxies, requestLog, corsConfig, timeouts, authRateLimit)
 
 	// Serve static files from ./static directory (production)
 	staticDir := "./static"
@@ -218,23 +242,59 @@ func main() {
 	port := env.Get("SERVER_PORT", "4000")
 	addr := ":" + port
 
+	// Requests are bounded per route group, so the write timeout only has to outlast the longest
 	srv := &http.Server{
 		Addr:         addr,
 		Handler:      r,
 		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		WriteTimeout: timeouts.WriteTimeout(),
 		IdleTimeout:  120 * time.Second,
 	}
 
-	logger.Info("Server starting", "address", addr)
+	// Optional built-in TLS for deployments without a reverse proxy
+	tlsConfig := server.TLSConfig{
+		CertFile:         env.Get("TLS_CERT_FILE", ""),
+		KeyFile:          env.Get("TLS_KEY_FILE", ""),
+		AutocertDomains:  server.ParseDomains(env.Get("TLS_AUTOCERT_DOMAINS", "")),
+		AutocertCacheDir: env.Get("TLS_AUTOCERT_CACHE_DIR", "/app/data/certs"),
+		AutocertEmail:    env.Get("TLS_AUTOCERT_EMAIL", ""),
+	}
+	if redirectPort := env.Get("TLS_HTTP_REDIRECT_PORT", "80"); redirectPort != "off" {
+		tlsConfig.RedirectAddr = ":" + redirectPort
+	}
+	if err := tlsConfig.Validate(); err != nil {
+		log.Fatalf("Invalid TLS configuration: %v", err)
+	}
+
+	var redirectSrv *http.Server
+	if tlsConfig.Enabled() {
+		redirectSrv = tlsConfig.Configure(srv)
+	}
+
+	logger.Info("Server starting", "address", addr, "tls", tlsConfig.Enabled(), "autocert", tlsConfig.Autocert())
 
 	// Start server in a goroutine
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if tlsConfig.Enabled() {
+			err = tlsConfig.ListenAndServe(srv)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
 		}
 	}()
 
+	if redirectSrv != nil {
+		logger.Info("HTTP redirect server starting", "address", redirectSrv.Addr)
+		go func() {
+			if err := redirectSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Failed to start HTTP redirect server: %v", err)
+			}
+		}()
+	}
+
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -242,12 +302,23 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// In-flight requests finish first, since they can hand work to the background, then
+	// syncs and schedulers stop. Both share one deadline.
+	shutdownTimeout := time.Duration(env.GetInt("SHUTDOWN_TIMEOUT_SECONDS", 30)) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Error("Server forced to shutdown", "error", err)
 	}
+	if redirectSrv != nil {
+		if err := redirectSrv.Shutdown(ctx); err != nil {
+			logger.Error("HTTP redirect server forced to shutdown", "error", err)
+		}
+	}
+	if err := svc.jobs.Drain(ctx); err != nil {
+		logger.Error("Background jobs forced to stop", "error", err)
+	}
 
 	logger.Info("Server stopped")
 }
@@ -137,6 +137,9 @@ func (s *Service) CreateAssetDetails(ctx context.Context, accountID string, req
 	if err != nil {
 		return nil, fmt.Errorf("failed to create asset details: %w", err)
 	}
+	if err := balance.InvalidateSummaries(ctx, s.db, auth.GetUserID(ctx)); err != nil {
+		return nil, err
+	}
 
 	details := &AssetDetails{
 		ID:                 id,
@@ -177,6 +180,8 @@ func (s *Service) GetAssetDetails(ctx context.Context, accountID string) (*Asset
 	}
 
 	var details AssetDetails
+	var typeSpecificData []byte
+	var notes sql.NullString
 
 	err := s.db.QueryRowContext(ctx, `
 		SELECT ad.id, ad.account_id, ad.asset_type, ad.purchase_price, ad.purchase_date,
@@ -188,12 +193,17 @@ func (s *Service) GetAssetDetails(ctx context.Context, accountID string) (*Asset
 	`, accountID, userID).Scan(
 		&details.ID, &details.AccountID, &details.AssetType, &details.PurchasePrice, &details.PurchaseDate,
 		&details.DepreciationMethod, &details.UsefulLifeYears, &details.SalvageValue, &details.DepreciationRate,
-		&details.TypeSpecificData, &details.Notes, &details.CreatedAt, &details.UpdatedAt,
+		&typeSpecificData, &notes, &details.CreatedAt, &details.UpdatedAt,
 	)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get asset details: %w", err)
 	}
+	// Both are optional and stored as NULL when not given
+	if typeSpecificData != nil {
+		details.TypeSpecificData = typeSpecificData
+	}
+	details.Notes = notes.String
 
 	return &details, nil
 }
@@ -225,6 +235,9 @@ func (s *Service) UpdateAssetDetails(ctx context.Context, accountID string, req
 	if err != nil {
 		return nil, fmt.Errorf("failed to update asset details: %w", err)
 	}
+	if err := balance.InvalidateSummaries(ctx, s.db, auth.GetUserID(ctx)); err != nil {
+		return nil, err
+	}
 
 	// Fetch and return the updated details
 	return s.GetAssetDetails(ctx, accountID)
@@ -251,8 +264,9 @@ func (s *Service) GetAssetValuation(ctx context.Context, accountID string) (*Ass
 	}, nil
 }
 
-// GetAssetsSummary retrieves all assets with calculated current values
-func (s *Service) GetAssetsSummary(ctx context.Context) (*AssetsSummaryResponse, error) {
+// buildAssetsSummary values all assets as of now, leaving out private accounts while
+// privacy mode masks them
+func (s *Service) buildAssetsSummary(ctx context.Context) (*AssetsSummaryResponse, error) {
 	userID := auth.GetUserID(ctx)
 	if userID == "" {
 		return nil, fmt.Errorf("user not authenticated")
@@ -264,9 +278,9 @@ func (s *Service) GetAssetsSummary(ctx context.Context) (*AssetsSummaryResponse,
 			ad.type_specific_data, ad.notes, ad.created_at, ad.updated_at
 		FROM asset_details ad
 		JOIN accounts a ON ad.account_id = a.id
-		WHERE a.user_id = $1
+		WHERE a.user_id = $1 AND (a.is_private = 0 OR $2 = 0)
 		ORDER BY ad.created_at DESC
-	`, userID)
+	`, userID, auth.MaskPrivate(ctx))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get assets: %w", err)
@@ -348,6 +362,9 @@ func (s *Service) RecordDepreciation(ctx context.Context, accountID string, req
 	if err != nil {
 		return nil, fmt.Errorf("failed to record depreciation: %w", err)
 	}
+	if err := balance.InvalidateSummaries(ctx, s.db, auth.GetUserID(ctx)); err != nil {
+		return nil, err
+	}
 
 	return &DepreciationEntry{
 		ID:                      id,
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"testing"
 	"time"
+
+	"money/internal/auth"
 )
 
 func TestCreateAssetDetails_Success(t *testing.T) {
@@ -394,6 +396,56 @@ func TestGetAssetsSummary_Success(t *testing.T) {
 	}
 }
 
+func TestGetAssetsSummary_LeavesOutPrivateAccountsInPrivacyMode(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-assets-summary-private"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupAccountService(t, db)
+
+	usefulLife := 10
+	publicID := CreateTestAccount(t, db, userID, AccountTypeVehicle)
+	privateID := CreateTestAccount(t, db, userID, AccountTypeVehicle)
+	for _, accountID := range []string{publicID, privateID} {
+		if _, err := service.CreateAssetDetails(ctx, accountID, &CreateAssetDetailsRequest{
+			AccountID:          accountID,
+			AssetType:          "vehicle",
+			PurchasePrice:      25000.00,
+			PurchaseDate:       Date{Time: time.Now()},
+			DepreciationMethod: "straight_line",
+			UsefulLifeYears:    &usefulLife,
+			SalvageValue:       5000.00,
+			TypeSpecificData:   json.RawMessage("{}"),
+		}); err != nil {
+			t.Fatalf("CreateAssetDetails failed: %v", err)
+		}
+	}
+	if _, err := service.SetPrivacy(ctx, privateID, &SetAccountPrivacyRequest{IsPrivate: true}); err != nil {
+		t.Fatalf("SetPrivacy failed: %v", err)
+	}
+
+	// Act
+	masked, err := service.GetAssetsSummary(auth.WithMaskPrivate(ctx))
+	if err != nil {
+		t.Fatalf("GetAssetsSummary failed: %v", err)
+	}
+	revealed, err := service.GetAssetsSummary(ctx)
+	if err != nil {
+		t.Fatalf("GetAssetsSummary failed: %v", err)
+	}
+
+	// Assert
+	if len(masked.Assets) != 1 || masked.Assets[0].AccountID != publicID {
+		t.Errorf("Expected only the public account's asset in privacy mode, got %+v", masked.Assets)
+	}
+	if len(revealed.Assets) != 2 {
+		t.Errorf("Expected both assets outside privacy mode, got %d", len(revealed.Assets))
+	}
+}
+
 func TestGetAssetsSummary_IsolationBetweenUsers(t *testing.T) {
 	db := SetupTestDB(t)
 	defer CleanupTestDB(t, db)
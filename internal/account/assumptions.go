@@ -0,0 +1,84 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"money/internal/auth"
+)
+
+// ProjectionAssumptions are per-account growth rates that take precedence over the
+// projection config's type-level investment return and appreciation maps
+type ProjectionAssumptions struct {
+	AccountID            string   `json:"account_id"`
+	ExpectedReturn       *float64 `json:"expected_return"`       // annual return, e.g. 0.07 for 7%
+	ExpectedAppreciation *float64 `json:"expected_appreciation"` // annual appreciation, negative for depreciation
+}
+
+// UpdateProjectionAssumptionsRequest sets or clears an account's overrides; null clears a rate
+type UpdateProjectionAssumptionsRequest struct {
+	ExpectedReturn       *float64 `json:"expected_return"`
+	ExpectedAppreciation *float64 `json:"expected_appreciation"`
+}
+
+// validateRate checks that an annual rate is within a plausible range
+func validateRate(name string, rate *float64) error {
+	if rate != nil && (*rate <= -1 || *rate > 1) {
+		return fmt.Errorf("%s must be between -1 and 1 (e.g. 0.07 for 7%%)", name)
+	}
+	return nil
+}
+
+// GetProjectionAssumptions returns an account's projection overrides
+func (s *Service) GetProjectionAssumptions(ctx context.Context, accountID string) (*ProjectionAssumptions, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	assumptions := &ProjectionAssumptions{AccountID: accountID}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT expected_return, expected_appreciation
+		FROM accounts
+		WHERE id = $1 AND user_id = $2
+	`, accountID, userID).Scan(&assumptions.ExpectedReturn, &assumptions.ExpectedAppreciation)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("account not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get projection assumptions: %w", err)
+	}
+
+	return assumptions, nil
+}
+
+// UpdateProjectionAssumptions replaces an account's projection overrides
+func (s *Service) UpdateProjectionAssumptions(ctx context.Context, accountID string, req *UpdateProjectionAssumptionsRequest) (*ProjectionAssumptions, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	if err := validateRate("expected_return", req.ExpectedReturn); err != nil {
+		return nil, err
+	}
+	if err := validateRate("expected_appreciation", req.ExpectedAppreciation); err != nil {
+		return nil, err
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE accounts
+		SET expected_return = $1, expected_appreciation = $2, updated_at = $3
+		WHERE id = $4
+	`, req.ExpectedReturn, req.ExpectedAppreciation, time.Now(), accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update projection assumptions: %w", err)
+	}
+
+	return &ProjectionAssumptions{
+		AccountID:            accountID,
+		ExpectedReturn:       req.ExpectedReturn,
+		ExpectedAppreciation: req.ExpectedAppreciation,
+	}, nil
+}
@@ -0,0 +1,365 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/background"
+	"money/internal/civil"
+	"money/internal/jurisdiction"
+	"money/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+var accountLog = logger.Module("account")
+
+// autoPostInterval is how often due loan and mortgage payments are auto-posted
+const autoPostInterval = time.Hour
+
+// autoPostNotes marks payments posted from the amortization schedule
+const autoPostNotes = "Auto-posted scheduled payment"
+
+// paymentTables maps the account types that can auto-post payments to their payment table
+var paymentTables = map[AccountType]string{
+	AccountTypeLoan:     "loan_payments",
+	AccountTypeMortgage: "mortgage_payments",
+}
+
+// AutoPostingSettings is an account's scheduled payment auto-posting state
+type AutoPostingSettings struct {
+	AccountID            string     `json:"account_id"`
+	Enabled              bool       `json:"enabled"`
+	LastPostedDate       *Date      `json:"last_posted_date,omitempty"` // Payments due after this date are posted next
+	UnreconciledPayments int        `json:"unreconciled_payments"`      // Real payments that differed from the schedule
+	UpdatedAt            *time.Time `json:"updated_at,omitempty"`
+}
+
+// SetAutoPostingRequest turns auto-posting on or off for an account
+type SetAutoPostingRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// paymentAmounts is a payment's date and split, shared by loan and mortgage payments
+type paymentAmounts struct {
+	Date      Date
+	Payment   float64
+	Principal float64
+	Interest  float64
+	Extra     float64
+	Notes     string
+}
+
+// reconciledPayment is an auto-posted payment after a real payment replaced its amounts
+type reconciledPayment struct {
+	ID                  string
+	BalanceAfter        float64
+	CreatedAt           time.Time
+	NeedsReconciliation bool
+}
+
+// SetAutoPosting turns scheduled payment auto-posting on or off for a loan or mortgage.
+// Turning it on posts payments due from today; payments missed while it was off are not
+// backfilled.
+func (s *Service) SetAutoPosting(ctx context.Context, accountID string, req *SetAutoPostingRequest) (*AutoPostingSettings, error) {
+	if _, err := s.paymentTable(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO payment_auto_posting (id, account_id, enabled, last_posted_date, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (account_id) DO UPDATE SET
+			last_posted_date = CASE WHEN payment_auto_posting.enabled THEN payment_auto_posting.last_posted_date
+				ELSE excluded.last_posted_date END,
+			enabled = excluded.enabled,
+			updated_at = excluded.updated_at
+	`, uuid.New().String(), accountID, req.Enabled, civil.TodayIn(ctx), now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set auto-posting: %w", err)
+	}
+
+	return s.GetAutoPosting(ctx, accountID)
+}
+
+// GetAutoPosting retrieves an account's auto-posting state and how many of its payments
+// need reconciling
+func (s *Service) GetAutoPosting(ctx context.Context, accountID string) (*AutoPostingSettings, error) {
+	table, err := s.paymentTable(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &AutoPostingSettings{AccountID: accountID}
+	var lastPosted Date
+	var updatedAt time.Time
+	err = s.db.QueryRowContext(ctx, `
+		SELECT enabled, last_posted_date, updated_at FROM payment_auto_posting WHERE account_id = $1
+	`, accountID).Scan(&settings.Enabled, &lastPosted, &updatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get auto-posting: %w", err)
+	}
+	if err == nil {
+		settings.LastPostedDate = &lastPosted
+		settings.UpdatedAt = &updatedAt
+	}
+
+	err = s.db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(*) FROM %s WHERE account_id = $1 AND needs_reconciliation
+	`, table), accountID).Scan(&settings.UnreconciledPayments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count unreconciled payments: %w", err)
+	}
+
+	return settings, nil
+}
+
+// ClearReconciliationFlags marks every flagged payment on the account as reviewed
+func (s *Service) ClearReconciliationFlags(ctx context.Context, accountID string) (*AutoPostingSettings, error) {
+	table, err := s.paymentTable(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.ExecContext(ctx, fmt.Sprintf(`
+		UPDATE %s SET needs_reconciliation = 0 WHERE account_id = $1 AND needs_reconciliation
+	`, table), accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear reconciliation flags: %w", err)
+	}
+
+	return s.GetAutoPosting(ctx, accountID)
+}
+
+// paymentTable returns the payment table of a loan or mortgage account the user owns
+func (s *Service) paymentTable(ctx context.Context, accountID string) (string, error) {
+	acc, err := s.Get(ctx, accountID)
+	if err != nil {
+		return "", err
+	}
+	table, ok := paymentTables[acc.Type]
+	if !ok {
+		return "", fmt.Errorf("auto-posting is only available for loans and mortgages")
+	}
+	return table, nil
+}
+
+// PostDuePayments auto-posts every enabled account's scheduled payments whose due dates
+// have passed. Failures are logged per account so one bad schedule doesn't block the rest.
+func (s *Service) PostDuePayments(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT p.account_id, a.user_id, a.type, p.last_posted_date, u.jurisdiction
+		FROM payment_auto_posting p
+		JOIN accounts a ON a.id = p.account_id
+		JOIN users u ON u.id = a.user_id
+		WHERE p.enabled AND a.is_active
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get auto-posting accounts: %w", err)
+	}
+
+	type autoPostAccount struct {
+		accountID   string
+		userID      string
+		accountType AccountType
+		lastPosted  Date
+		code        string
+	}
+	var accounts []autoPostAccount
+	for rows.Next() {
+		var a autoPostAccount
+		if err := rows.Scan(&a.accountID, &a.userID, &a.accountType, &a.lastPosted, &a.code); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan auto-posting account: %w", err)
+		}
+		accounts = append(accounts, a)
+	}
+	rows.Close()
+
+	posted := 0
+	for _, a := range accounts {
+		userCtx := jurisdiction.WithProfile(auth.WithUserID(ctx, a.userID), jurisdiction.Lookup(a.code))
+		n, err := s.postDuePayments(userCtx, a.accountID, a.accountType, a.lastPosted)
+		if err != nil {
+			accountLog.Error("Auto-posting failed", "account_id", a.accountID, "error", err)
+			continue
+		}
+		posted += n
+	}
+
+	return posted, nil
+}
+
+// postDuePayments posts an account's scheduled payments due after lastPosted, skipping any
+// date already covered by a recorded payment. A payment due on a weekend or bank holiday in
+// the user's jurisdiction is posted on the next business day, when the bank would process it.
+func (s *Service) postDuePayments(ctx context.Context, accountID string, accountType AccountType, lastPosted Date) (int, error) {
+	var schedule *AmortizationScheduleResponse
+	var err error
+	switch accountType {
+	case AccountTypeLoan:
+		schedule, err = s.GetLoanAmortizationSchedule(ctx, accountID)
+	case AccountTypeMortgage:
+		schedule, err = s.GetAmortizationSchedule(ctx, accountID)
+	default:
+		return 0, fmt.Errorf("auto-posting is not supported for %s accounts", accountType)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	after := lastPosted
+	var latestPayment Date
+	err = s.db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT payment_date FROM %s WHERE account_id = $1 ORDER BY payment_date DESC LIMIT 1
+	`, paymentTables[accountType]), accountID).Scan(&latestPayment)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to get latest payment: %w", err)
+	}
+	if err == nil && latestPayment.After(after.Time) {
+		after = latestPayment
+	}
+
+	today := civil.TodayIn(ctx)
+	postedThrough := today
+	profile := jurisdiction.FromContext(ctx)
+	posted := 0
+	for _, entry := range schedule.Schedule {
+		dueDate := civil.DateOf(entry.PaymentDate)
+		if !dueDate.After(after.Time) {
+			continue
+		}
+		postDate := profile.NextBusinessDay(dueDate)
+		if postDate.After(today.Time) {
+			// Leave a due date waiting on a business day to be picked up on a later run
+			if !dueDate.After(today.Time) {
+				postedThrough = dueDate.AddDays(-1)
+			}
+			break
+		}
+
+		switch accountType {
+		case AccountTypeLoan:
+			_, err = s.recordLoanPayment(ctx, accountID, &CreateLoanPaymentRequest{
+				AccountID:       accountID,
+				PaymentDate:     postDate,
+				PaymentAmount:   entry.PaymentAmount,
+				PrincipalAmount: entry.PrincipalAmount,
+				InterestAmount:  entry.InterestAmount,
+				Notes:           autoPostNotes,
+			}, true)
+		case AccountTypeMortgage:
+			_, err = s.recordMortgagePayment(ctx, accountID, &CreateMortgagePaymentRequest{
+				AccountID:       accountID,
+				PaymentDate:     postDate,
+				PaymentAmount:   entry.PaymentAmount,
+				PrincipalAmount: entry.PrincipalAmount,
+				InterestAmount:  entry.InterestAmount,
+				Notes:           autoPostNotes,
+			}, true)
+		}
+		if err != nil {
+			return posted, fmt.Errorf("failed to post payment due %s: %w", dueDate, err)
+		}
+		posted++
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE payment_auto_posting SET last_posted_date = $1, updated_at = $2 WHERE account_id = $3
+	`, postedThrough, time.Now(), accountID)
+	if err != nil {
+		return posted, fmt.Errorf("failed to update auto-posting: %w", err)
+	}
+
+	return posted, nil
+}
+
+// StartPaymentAutoPosting posts due loan and mortgage payments hourly until jobs drains
+func (s *Service) StartPaymentAutoPosting(jobs *background.Group) {
+	jobs.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(autoPostInterval)
+		defer ticker.Stop()
+
+		for {
+			jobs.Exclusive(ctx, "payment_auto_posting", autoPostInterval, func(ctx context.Context) {
+				if posted, err := s.PostDuePayments(ctx); err != nil {
+					accountLog.Error("Payment auto-posting failed", "error", err)
+				} else if posted > 0 {
+					accountLog.Info("Auto-posted scheduled payments", "count", posted)
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// reconcileAutoPostedPayment applies a real payment to the payment auto-posted on the same
+// date. The auto-posted amounts are replaced, balances from that date on shift by the
+// difference in principal, and the payment is flagged when the real payment differs from
+// the schedule. Returns sql.ErrNoRows when nothing was auto-posted on that date.
+func (s *Service) reconcileAutoPostedPayment(ctx context.Context, table, accountID string, p paymentAmounts) (*reconciledPayment, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var reconciled reconciledPayment
+	var scheduled paymentAmounts
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT id, payment_amount, principal_amount, extra_payment, balance_after, created_at
+		FROM %s
+		WHERE account_id = $1 AND payment_date = $2 AND auto_posted
+		LIMIT 1
+	`, table), accountID, p.Date).Scan(
+		&reconciled.ID, &scheduled.Payment, &scheduled.Principal, &scheduled.Extra,
+		&reconciled.BalanceAfter, &reconciled.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find auto-posted payment: %w", err)
+	}
+
+	// Balances are stored negative, so paying more principal raises them toward zero
+	delta := (p.Principal + p.Extra) - (scheduled.Principal + scheduled.Extra)
+	reconciled.BalanceAfter += delta
+	reconciled.NeedsReconciliation = math.Abs(p.Payment-scheduled.Payment) >= 0.01 || math.Abs(delta) >= 0.01
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+		UPDATE %s SET
+			payment_amount = $1, principal_amount = $2, interest_amount = $3, extra_payment = $4,
+			balance_after = $5, notes = $6, auto_posted = 0, needs_reconciliation = $7
+		WHERE id = $8
+	`, table), p.Payment, p.Principal, p.Interest, p.Extra,
+		reconciled.BalanceAfter, p.Notes, reconciled.NeedsReconciliation, reconciled.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile payment: %w", err)
+	}
+
+	if delta != 0 {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+			UPDATE %s SET balance_after = balance_after + $1 WHERE account_id = $2 AND payment_date > $3
+		`, table), delta, accountID, p.Date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to adjust later payment balances: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit payment reconciliation: %w", err)
+	}
+
+	return &reconciled, nil
+}
@@ -0,0 +1,135 @@
+package account
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func createAutoPostedLoan(t *testing.T, service *Service, accountID, userID string) {
+	t.Helper()
+
+	ctx := CreateAuthContext(userID)
+	start := time.Now().AddDate(0, -3, 0).Add(-24 * time.Hour)
+	_, err := service.CreateLoanDetails(ctx, accountID, &CreateLoanDetailsRequest{
+		AccountID:        accountID,
+		OriginalAmount:   10000.00,
+		InterestRate:     0.06,
+		RateType:         "fixed",
+		StartDate:        Date{Time: time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)},
+		TermMonths:       24,
+		PaymentAmount:    443.21,
+		PaymentFrequency: "monthly",
+	})
+	if err != nil {
+		t.Fatalf("CreateLoanDetails failed: %v", err)
+	}
+	if _, err := service.SetAutoPosting(ctx, accountID, &SetAutoPostingRequest{Enabled: true}); err != nil {
+		t.Fatalf("SetAutoPosting failed: %v", err)
+	}
+
+	// Pretend auto-posting was turned on when the loan started
+	if _, err := service.db.Exec(`UPDATE payment_auto_posting SET last_posted_date = $1 WHERE account_id = $2`,
+		Date{Time: time.Date(start.Year(), start.Month(), start.Day()-1, 0, 0, 0, 0, time.UTC)}, accountID); err != nil {
+		t.Fatalf("Failed to backdate auto-posting: %v", err)
+	}
+}
+
+func TestPostDuePayments_PostsScheduledLoanPayments(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-auto-post-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeLoan)
+	service := SetupAccountService(t, db)
+	createAutoPostedLoan(t, service, accountID, userID)
+
+	// Act
+	_, err := service.PostDuePayments(ctx)
+	if err != nil {
+		t.Fatalf("PostDuePayments failed: %v", err)
+	}
+	// Running again must not post the same due dates twice
+	if _, err := service.PostDuePayments(ctx); err != nil {
+		t.Fatalf("PostDuePayments failed: %v", err)
+	}
+
+	// Assert
+	payments, err := service.GetLoanPayments(ctx, accountID)
+	if err != nil {
+		t.Fatalf("GetLoanPayments failed: %v", err)
+	}
+	if len(payments.Payments) != 4 {
+		t.Fatalf("Expected 4 auto-posted payments, got %d", len(payments.Payments))
+	}
+	oldest := payments.Payments[len(payments.Payments)-1]
+	if !oldest.AutoPosted {
+		t.Error("Expected payment to be marked as auto-posted")
+	}
+	if math.Abs(oldest.InterestAmount-50.00) > 0.01 {
+		t.Errorf("Expected first payment interest 50.00 from the schedule, got %.2f", oldest.InterestAmount)
+	}
+	if math.Abs(oldest.BalanceAfter-(-10000.00+oldest.PrincipalAmount)) > 0.01 {
+		t.Errorf("Expected balance to drop by the principal, got %.2f", oldest.BalanceAfter)
+	}
+}
+
+func TestRecordLoanPayment_ReconcilesAutoPostedPayment(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-auto-post-2"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeLoan)
+	service := SetupAccountService(t, db)
+	createAutoPostedLoan(t, service, accountID, userID)
+	if _, err := service.PostDuePayments(ctx); err != nil {
+		t.Fatalf("PostDuePayments failed: %v", err)
+	}
+	before, err := service.GetLoanPayments(ctx, accountID)
+	if err != nil {
+		t.Fatalf("GetLoanPayments failed: %v", err)
+	}
+	scheduled := before.Payments[len(before.Payments)-1]
+	latestBefore := before.Payments[0].BalanceAfter
+
+	// Act
+	payment, err := service.RecordLoanPayment(ctx, accountID, &CreateLoanPaymentRequest{
+		AccountID:       accountID,
+		PaymentDate:     scheduled.PaymentDate,
+		PaymentAmount:   scheduled.PaymentAmount + 100,
+		PrincipalAmount: scheduled.PrincipalAmount,
+		InterestAmount:  scheduled.InterestAmount,
+		ExtraPayment:    100,
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("RecordLoanPayment failed: %v", err)
+	}
+	if payment.ID != scheduled.ID || !payment.NeedsReconciliation {
+		t.Errorf("Expected the auto-posted payment to be replaced and flagged, got %+v", payment)
+	}
+	after, err := service.GetLoanPayments(ctx, accountID)
+	if err != nil {
+		t.Fatalf("GetLoanPayments failed: %v", err)
+	}
+	if len(after.Payments) != len(before.Payments) {
+		t.Errorf("Expected %d payments, got %d", len(before.Payments), len(after.Payments))
+	}
+	if math.Abs(after.Payments[0].BalanceAfter-(latestBefore+100)) > 0.01 {
+		t.Errorf("Expected later balances to reflect the extra payment, got %.2f", after.Payments[0].BalanceAfter)
+	}
+	settings, err := service.GetAutoPosting(ctx, accountID)
+	if err != nil {
+		t.Fatalf("GetAutoPosting failed: %v", err)
+	}
+	if settings.UnreconciledPayments != 1 {
+		t.Errorf("Expected 1 unreconciled payment, got %d", settings.UnreconciledPayments)
+	}
+}
@@ -0,0 +1,232 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// BalanceChange explains how an account's balance moved over a period. The flow amounts
+// are signed so that, together with MarketGrowth and Unexplained, they add up to Change.
+type BalanceChange struct {
+	AccountID      string      `json:"account_id"`
+	AccountName    string      `json:"account_name"`
+	AccountType    AccountType `json:"account_type"`
+	Currency       Currency    `json:"currency"`
+	From           Date        `json:"from"`
+	To             Date        `json:"to"`
+	OpeningBalance float64     `json:"opening_balance"` // Latest balance before the period
+	ClosingBalance float64     `json:"closing_balance"` // Latest balance by the end of the period
+	Change         float64     `json:"change"`
+	Contributions  float64     `json:"contributions"` // Money put into the account
+	Withdrawals    float64     `json:"withdrawals"`   // Money taken out, as a negative amount
+	Interest       float64     `json:"interest"`      // Interest earned, or charged when negative
+	Dividends      float64     `json:"dividends"`
+	Fees           float64     `json:"fees"`          // As a negative amount
+	MarketGrowth   float64     `json:"market_growth"` // Change in value not explained by flows
+	Unexplained    float64     `json:"unexplained"`   // Change not explained by flows on accounts without a market value
+	HasOpening     bool        `json:"has_opening_balance"`
+	HasClosing     bool        `json:"has_closing_balance"`
+}
+
+// marketValuedTypes are account types whose value moves with markets, so a change the
+// recorded flows don't explain is attributed to market growth
+var marketValuedTypes = map[AccountType]bool{
+	AccountTypeBrokerage:    true,
+	AccountTypeTFSA:         true,
+	AccountTypeRRSP:         true,
+	AccountTypeCrypto:       true,
+	AccountTypeRealEstate:   true,
+	AccountTypeVehicle:      true,
+	AccountTypeCollectible:  true,
+	AccountTypeStockOptions: true,
+}
+
+// GetBalanceChange decomposes an account's balance change between from and to into
+// contributions, withdrawals, interest, dividends and fees from the account's transactions,
+// holding transactions and loan or mortgage payments. Whatever those flows don't explain is
+// market growth for investment and asset accounts, and unexplained for the rest.
+func (s *Service) GetBalanceChange(ctx context.Context, accountID string, from, to time.Time) (*BalanceChange, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("to must not be before from")
+	}
+
+	acc, err := s.Get(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &BalanceChange{
+		AccountID:   acc.ID,
+		AccountName: acc.Name,
+		AccountType: acc.Type,
+		Currency:    acc.Currency,
+		From:        Date{Time: from},
+		To:          Date{Time: to},
+	}
+
+	change.OpeningBalance, change.HasOpening, err = s.balanceAsOf(ctx, accountID, "date < $2", from)
+	if err != nil {
+		return nil, err
+	}
+	change.ClosingBalance, change.HasClosing, err = s.balanceAsOf(ctx, accountID, "date <= $2", to)
+	if err != nil {
+		return nil, err
+	}
+	change.Change = change.ClosingBalance - change.OpeningBalance
+
+	if err := s.addTransactionFlows(ctx, change, from, to); err != nil {
+		return nil, err
+	}
+	if err := s.addHoldingFlows(ctx, change, from, to); err != nil {
+		return nil, err
+	}
+	if table, ok := paymentTables[acc.Type]; ok {
+		if err := s.addPaymentFlows(ctx, change, table, from, to); err != nil {
+			return nil, err
+		}
+	}
+
+	explained := change.Contributions + change.Withdrawals + change.Interest + change.Dividends + change.Fees
+	residual := roundCents(change.Change - explained)
+	if marketValuedTypes[acc.Type] {
+		change.MarketGrowth = residual
+	} else {
+		change.Unexplained = residual
+	}
+
+	change.Change = roundCents(change.Change)
+	change.Contributions = roundCents(change.Contributions)
+	change.Withdrawals = roundCents(change.Withdrawals)
+	change.Interest = roundCents(change.Interest)
+	change.Dividends = roundCents(change.Dividends)
+	change.Fees = roundCents(change.Fees)
+	return change, nil
+}
+
+// balanceAsOf returns the account's latest balance matching the date condition
+func (s *Service) balanceAsOf(ctx context.Context, accountID, condition string, at time.Time) (float64, bool, error) {
+	var amount float64
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT amount FROM balances
+		WHERE account_id = $1 AND %s
+		ORDER BY date DESC, created_at DESC
+		LIMIT 1
+	`, condition), accountID, at).Scan(&amount)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get balance: %w", err)
+	}
+	return amount, true, nil
+}
+
+// addTransactionFlows classifies the account's transactions in the period, using split
+// categories when a transaction is split. Pending transactions already replaced by their
+// posted transaction are skipped.
+func (s *Service) addTransactionFlows(ctx context.Context, change *BalanceChange, from, to time.Time) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT COALESCE(sp.category, t.category, ''), COALESCE(sp.amount, t.amount)
+		FROM transactions t
+		LEFT JOIN transaction_splits sp ON sp.transaction_id = t.id
+		WHERE t.account_id = $1 AND t.date >= $2 AND t.date <= $3
+		  AND t.settled_transaction_id IS NULL
+	`, change.AccountID, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var category string
+		var amount float64
+		if err := rows.Scan(&category, &amount); err != nil {
+			return fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		switch {
+		case categoryHasWord(category, "interest"):
+			change.Interest += amount
+		case categoryHasWord(category, "dividend", "dividends"):
+			change.Dividends += amount
+		case categoryHasWord(category, "fee", "fees"):
+			change.Fees += amount
+		case amount >= 0:
+			change.Contributions += amount
+		default:
+			change.Withdrawals += amount
+		}
+	}
+	return rows.Err()
+}
+
+// addHoldingFlows adds the cash deposits, withdrawals and dividends recorded against the
+// account's holdings. Buys and sells move value between positions and cash, so they are
+// left to market growth.
+func (s *Service) addHoldingFlows(ctx context.Context, change *BalanceChange, from, to time.Time) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ht.type, COALESCE(SUM(ABS(ht.total_amount)), 0)
+		FROM holding_transactions ht
+		JOIN holdings h ON h.id = ht.holding_id
+		WHERE h.account_id = $1 AND ht.type IN ('deposit', 'withdrawal', 'dividend')
+		  AND ht.transaction_date >= $2 AND ht.transaction_date <= $3
+		GROUP BY ht.type
+	`, change.AccountID, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to get holding transactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var txnType string
+		var total float64
+		if err := rows.Scan(&txnType, &total); err != nil {
+			return fmt.Errorf("failed to scan holding transaction: %w", err)
+		}
+		switch txnType {
+		case "deposit":
+			change.Contributions += total
+		case "withdrawal":
+			change.Withdrawals -= total
+		case "dividend":
+			change.Dividends += total
+		}
+	}
+	return rows.Err()
+}
+
+// addPaymentFlows adds loan or mortgage payments. A payment reduces what is owed by its
+// full amount, less the interest charged with it.
+func (s *Service) addPaymentFlows(ctx context.Context, change *BalanceChange, table string, from, to time.Time) error {
+	var paid, interest float64
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COALESCE(SUM(payment_amount + COALESCE(extra_payment, 0)), 0), COALESCE(SUM(interest_amount), 0)
+		FROM %s
+		WHERE account_id = $1 AND payment_date >= $2 AND payment_date <= $3
+	`, table), change.AccountID, from, to).Scan(&paid, &interest)
+	if err != nil {
+		return fmt.Errorf("failed to get payments: %w", err)
+	}
+	change.Contributions += paid
+	change.Interest -= interest
+	return nil
+}
+
+// categoryHasWord reports whether a free-form category contains one of the words, so
+// "Interest Income" matches interest while "Coffee" does not match fee
+func categoryHasWord(category string, words ...string) bool {
+	fields := strings.FieldsFunc(strings.ToLower(category), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, field := range fields {
+		for _, word := range words {
+			if field == word {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package account
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestGetBalanceChange_DecomposesInvestmentAccount(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-balance-change-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupAccountService(t, db)
+
+	accountID := CreateTestAccount(t, db, userID, AccountTypeTFSA)
+	from := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, time.March, 31, 23, 59, 59, 0, time.UTC)
+
+	insertBalance := func(amount float64, date time.Time) {
+		_, err := db.Exec(`
+			INSERT INTO balances (id, account_id, amount, date, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`, "test-balance-"+uuid.New().String(), accountID, amount, date, time.Now())
+		if err != nil {
+			t.Fatalf("Failed to insert balance: %v", err)
+		}
+	}
+	insertBalance(10000, from.AddDate(0, 0, -3))
+	insertBalance(13214, from.AddDate(0, 0, 20))
+	insertBalance(99999, to.AddDate(0, 0, 2))
+
+	insertTransaction := func(amount float64, category string, date time.Time) {
+		id := "test-txn-" + uuid.New().String()
+		_, err := db.Exec(`
+			INSERT INTO transactions (id, user_id, account_id, date, description, amount, currency, category)
+			VALUES ($1, $2, $3, $4, 'test', $5, 'CAD', $6)
+		`, id, userID, accountID, date, amount, category)
+		if err != nil {
+			t.Fatalf("Failed to insert transaction: %v", err)
+		}
+	}
+	defer db.Exec(`DELETE FROM transactions WHERE user_id = $1`, userID)
+	insertTransaction(2000, "Contribution", from.AddDate(0, 0, 4))
+	insertTransaction(-14, "Account Fees", from.AddDate(0, 0, 9))
+	insertTransaction(500, "Contribution", from.AddDate(0, 0, -5))
+
+	holdingID := "test-holding-" + uuid.New().String()
+	_, err := db.Exec(`
+		INSERT INTO holdings (id, account_id, type, symbol, quantity, cost_basis)
+		VALUES ($1, $2, 'etf', 'TESTXEQT', 100, 25)
+	`, holdingID, accountID)
+	if err != nil {
+		t.Fatalf("Failed to insert holding: %v", err)
+	}
+	defer db.Exec(`DELETE FROM holding_transactions WHERE holding_id = $1`, holdingID)
+	_, err = db.Exec(`
+		INSERT INTO holding_transactions (id, holding_id, type, total_amount, transaction_date)
+		VALUES ($1, $2, 'dividend', 300, $3)
+	`, uuid.New().String(), holdingID, from.AddDate(0, 0, 14))
+	if err != nil {
+		t.Fatalf("Failed to insert holding transaction: %v", err)
+	}
+
+	// Act
+	change, err := service.GetBalanceChange(ctx, accountID, from, to)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetBalanceChange failed: %v", err)
+	}
+	if change.OpeningBalance != 10000 || change.ClosingBalance != 13214 || change.Change != 3214 {
+		t.Errorf("Expected a change of 3214 from 10000 to 13214, got %+v", change)
+	}
+	if change.Contributions != 2000 || change.Fees != -14 || change.Dividends != 300 {
+		t.Errorf("Expected contributions 2000, fees -14 and dividends 300, got %+v", change)
+	}
+	if change.MarketGrowth != 928 || change.Unexplained != 0 {
+		t.Errorf("Expected market growth of 928, got %f (unexplained %f)", change.MarketGrowth, change.Unexplained)
+	}
+}
@@ -0,0 +1,424 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"money/internal/civil"
+)
+
+// upcomingVestingDays is how far ahead the equity summary lists vesting events
+const upcomingVestingDays = 90
+
+// ClassTotals adds up one asset class in one currency. Totals are never summed across
+// currencies.
+type ClassTotals struct {
+	Currency     string  `json:"currency"`
+	Value        float64 `json:"value"`        // Latest market value or balance
+	CostBasis    float64 `json:"cost_basis"`   // What was paid, for the holdings where it is known
+	Appreciation float64 `json:"appreciation"` // Value less cost basis, for the holdings where it is known
+	Debt         float64 `json:"debt"`         // Debt secured by the class, owed as a positive amount
+	Equity       float64 `json:"equity"`       // Value less debt
+}
+
+// CryptoPosition is a crypto holding valued at its latest quote, falling back to cost basis
+type CryptoPosition struct {
+	AccountID      string   `json:"account_id"`
+	AccountName    string   `json:"account_name"`
+	Currency       string   `json:"currency"`
+	Symbol         string   `json:"symbol"`
+	Quantity       float64  `json:"quantity"`
+	Price          *float64 `json:"price,omitempty"`      // Latest quote, if one is recorded
+	CostBasis      *float64 `json:"cost_basis,omitempty"` // Total paid for the position
+	Value          float64  `json:"value"`
+	UnrealizedGain *float64 `json:"unrealized_gain,omitempty"`
+}
+
+// CryptoSummary combines the user's crypto accounts and their positions
+type CryptoSummary struct {
+	Accounts   []*AccountWithBalance   `json:"accounts"`
+	Positions  []*CryptoPosition       `json:"positions"`
+	ByCurrency map[string]*ClassTotals `json:"by_currency"`
+}
+
+// EquityAccountSummary is the options summary of one stock options account
+type EquityAccountSummary struct {
+	AccountID string          `json:"account_id"`
+	Name      string          `json:"name"`
+	Summary   *OptionsSummary `json:"summary"`
+}
+
+// EquitySummary combines every stock options account
+type EquitySummary struct {
+	AsOf            Date                        `json:"as_of"`
+	Accounts        []*EquityAccountSummary     `json:"accounts"`
+	TotalGrants     int                         `json:"total_grants"`
+	TotalShares     int                         `json:"total_shares"`
+	VestedShares    int                         `json:"vested_shares"`
+	UnvestedShares  int                         `json:"unvested_shares"`
+	ExercisedShares int                         `json:"exercised_shares"`
+	SoldShares      int                         `json:"sold_shares"`
+	ByCurrency      map[string]*CurrencySummary `json:"by_currency"`      // CurrentFMV is only set when every account agrees
+	UpcomingVesting []VestingEvent              `json:"upcoming_vesting"` // Events in the next 90 days, soonest first
+}
+
+// PropertySummary is a real estate account with its value, secured debt and appreciation
+type PropertySummary struct {
+	AccountID           string   `json:"account_id"`
+	Name                string   `json:"name"`
+	Currency            string   `json:"currency"`
+	Value               float64  `json:"value"`
+	PurchasePrice       *float64 `json:"purchase_price,omitempty"`
+	PurchaseDate        *Date    `json:"purchase_date,omitempty"`
+	Appreciation        *float64 `json:"appreciation,omitempty"`
+	AppreciationPercent *float64 `json:"appreciation_percent,omitempty"`
+	Debt                float64  `json:"debt"` // HELOCs secured by the property and the mortgages they are linked to
+	Equity              float64  `json:"equity"`
+	LoanToValue         *float64 `json:"loan_to_value,omitempty"`
+}
+
+// RealEstateSummary combines the user's properties with the mortgages and HELOCs against them
+type RealEstateSummary struct {
+	Properties []*PropertySummary      `json:"properties"`
+	Debts      []*AccountWithBalance   `json:"debts"`       // Mortgage and HELOC accounts
+	ByCurrency map[string]*ClassTotals `json:"by_currency"` // Debt includes mortgages not linked to a property
+}
+
+// accountsOfType returns the user's active accounts of the given types with their latest
+// balances. Masked private accounts are left out, since their details would reveal the balance.
+func (s *Service) accountsOfType(ctx context.Context, types ...AccountType) ([]*AccountWithBalance, error) {
+	all, err := s.ListWithBalance(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[AccountType]bool, len(types))
+	for _, t := range types {
+		wanted[t] = true
+	}
+	accounts := make([]*AccountWithBalance, 0)
+	for _, acc := range all.Accounts {
+		if wanted[acc.Type] && !acc.BalanceMasked {
+			accounts = append(accounts, acc)
+		}
+	}
+	return accounts, nil
+}
+
+// classTotals returns the running totals for a currency, creating them on first use
+func classTotals(byCurrency map[string]*ClassTotals, currency string) *ClassTotals {
+	if byCurrency[currency] == nil {
+		byCurrency[currency] = &ClassTotals{Currency: currency}
+	}
+	return byCurrency[currency]
+}
+
+// roundClassTotals rounds the totals to cents and fills in equity
+func roundClassTotals(byCurrency map[string]*ClassTotals) {
+	for _, t := range byCurrency {
+		t.Value = roundCents(t.Value)
+		t.CostBasis = roundCents(t.CostBasis)
+		t.Appreciation = roundCents(t.Appreciation)
+		t.Debt = roundCents(t.Debt)
+		t.Equity = roundCents(t.Value - t.Debt)
+	}
+}
+
+// roundCents rounds an amount to two decimal places
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// GetCryptoSummary values the positions and cash in the user's crypto accounts. Accounts
+// without holdings count at their latest balance.
+func (s *Service) GetCryptoSummary(ctx context.Context) (*CryptoSummary, error) {
+	accounts, err := s.accountsOfType(ctx, AccountTypeCrypto)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &CryptoSummary{
+		Accounts:   accounts,
+		Positions:  make([]*CryptoPosition, 0),
+		ByCurrency: make(map[string]*ClassTotals),
+	}
+
+	for _, acc := range accounts {
+		positions, cash, err := s.cryptoPositions(ctx, acc)
+		if err != nil {
+			return nil, err
+		}
+
+		totals := classTotals(summary.ByCurrency, string(acc.Currency))
+		if len(positions) == 0 && cash == nil {
+			totals.Value += balanceOrZero(acc)
+			continue
+		}
+		if cash != nil {
+			totals.Value += *cash
+		}
+		for _, p := range positions {
+			totals.Value += p.Value
+			if p.CostBasis != nil {
+				totals.CostBasis += *p.CostBasis
+				totals.Appreciation += *p.UnrealizedGain
+			}
+		}
+		summary.Positions = append(summary.Positions, positions...)
+	}
+
+	sort.SliceStable(summary.Positions, func(i, j int) bool {
+		return summary.Positions[i].Value > summary.Positions[j].Value
+	})
+	roundClassTotals(summary.ByCurrency)
+	return summary, nil
+}
+
+// cryptoPositions reads an account's holdings, valuing positions at the latest quote, and
+// returns the cash held alongside them, or nil when there is none
+func (s *Service) cryptoPositions(ctx context.Context, acc *AccountWithBalance) ([]*CryptoPosition, *float64, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT h.type, h.symbol, h.quantity, h.cost_basis, h.amount, q.price
+		FROM holdings h
+		LEFT JOIN market_data q ON q.symbol = UPPER(h.symbol)
+		WHERE h.account_id = $1
+		ORDER BY h.symbol
+	`, acc.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get crypto holdings: %w", err)
+	}
+	defer rows.Close()
+
+	positions := make([]*CryptoPosition, 0)
+	var cash *float64
+	for rows.Next() {
+		var holdingType string
+		var symbol *string
+		var quantity, unitCost, amount, price *float64
+		if err := rows.Scan(&holdingType, &symbol, &quantity, &unitCost, &amount, &price); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan crypto holding: %w", err)
+		}
+		if holdingType == "cash" {
+			if amount != nil {
+				total := *amount
+				if cash != nil {
+					total += *cash
+				}
+				cash = &total
+			}
+			continue
+		}
+		if symbol == nil || quantity == nil {
+			continue
+		}
+
+		p := &CryptoPosition{
+			AccountID:   acc.ID,
+			AccountName: acc.Name,
+			Currency:    string(acc.Currency),
+			Symbol:      *symbol,
+			Quantity:    *quantity,
+			Price:       price,
+		}
+		if unitCost != nil {
+			costBasis := roundCents(*quantity * *unitCost)
+			p.CostBasis = &costBasis
+		}
+		switch {
+		case price != nil:
+			p.Value = roundCents(*quantity * *price)
+		case p.CostBasis != nil:
+			p.Value = *p.CostBasis
+		}
+		if p.CostBasis != nil {
+			gain := roundCents(p.Value - *p.CostBasis)
+			p.UnrealizedGain = &gain
+		}
+		positions = append(positions, p)
+	}
+	return positions, cash, rows.Err()
+}
+
+// GetEquitySummary combines the options summaries of every stock options account with the
+// vesting events coming up across them
+func (s *Service) GetEquitySummary(ctx context.Context) (*EquitySummary, error) {
+	accounts, err := s.accountsOfType(ctx, AccountTypeStockOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	today := civil.TodayIn(ctx)
+	summary := &EquitySummary{
+		AsOf:            Date{Time: today.Time},
+		Accounts:        make([]*EquityAccountSummary, 0, len(accounts)),
+		ByCurrency:      make(map[string]*CurrencySummary),
+		UpcomingVesting: make([]VestingEvent, 0),
+	}
+	fmvAgrees := make(map[string]bool)
+
+	for _, acc := range accounts {
+		data, err := s.loadOptionsData(ctx, acc.ID)
+		if err != nil {
+			return nil, err
+		}
+		options := summarizeOptions(data, today.Time)
+		summary.Accounts = append(summary.Accounts, &EquityAccountSummary{
+			AccountID: acc.ID,
+			Name:      acc.Name,
+			Summary:   options,
+		})
+
+		summary.TotalGrants += options.TotalGrants
+		summary.TotalShares += options.TotalShares
+		summary.VestedShares += options.VestedShares
+		summary.UnvestedShares += options.UnvestedShares
+		summary.ExercisedShares += options.ExercisedShares
+		summary.SoldShares += options.SoldShares
+
+		for currency, cs := range options.ByCurrency {
+			total, seen := summary.ByCurrency[currency]
+			if !seen {
+				total = &CurrencySummary{Currency: currency, CurrentFMV: cs.CurrentFMV}
+				summary.ByCurrency[currency] = total
+				fmvAgrees[currency] = true
+			} else if !sameFMV(total.CurrentFMV, cs.CurrentFMV) {
+				fmvAgrees[currency] = false
+			}
+			total.VestedValue += cs.VestedValue
+			total.UnvestedValue += cs.UnvestedValue
+			total.TotalIntrinsicValue += cs.TotalIntrinsicValue
+			total.VestedShares += cs.VestedShares
+			total.UnvestedShares += cs.UnvestedShares
+		}
+
+		upcoming, err := s.GetUpcomingVestingEvents(ctx, acc.ID, upcomingVestingDays)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range upcoming.Events {
+			if event.VestDate.Time.After(today.Time) {
+				summary.UpcomingVesting = append(summary.UpcomingVesting, event)
+			}
+		}
+	}
+
+	for currency, agrees := range fmvAgrees {
+		if !agrees {
+			summary.ByCurrency[currency].CurrentFMV = nil
+		}
+	}
+	sort.SliceStable(summary.UpcomingVesting, func(i, j int) bool {
+		return summary.UpcomingVesting[i].VestDate.Time.Before(summary.UpcomingVesting[j].VestDate.Time)
+	})
+	return summary, nil
+}
+
+// sameFMV reports whether two optional FMVs are equal
+func sameFMV(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// GetRealEstateSummary values the user's properties against their purchase price and the
+// HELOCs and mortgages secured by them
+func (s *Service) GetRealEstateSummary(ctx context.Context) (*RealEstateSummary, error) {
+	accounts, err := s.accountsOfType(ctx, AccountTypeRealEstate, AccountTypeMortgage, AccountTypeLineOfCredit)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &RealEstateSummary{
+		Properties: make([]*PropertySummary, 0),
+		Debts:      make([]*AccountWithBalance, 0),
+		ByCurrency: make(map[string]*ClassTotals),
+	}
+
+	// Debt owed on each mortgage and HELOC, and the property each is secured by when known
+	owed := make(map[string]float64)
+	securedBy := make(map[string]string)
+	for _, acc := range accounts {
+		switch acc.Type {
+		case AccountTypeMortgage:
+			// Mortgage balances are stored as negative amounts
+			owed[acc.ID] = math.Abs(balanceOrZero(acc))
+		case AccountTypeLineOfCredit:
+			heloc, err := s.GetHELOCDetails(ctx, acc.ID)
+			if err != nil {
+				continue // A line of credit that isn't a HELOC isn't secured by a property
+			}
+			balanceOwed, _, err := s.helocBalance(ctx, acc.ID)
+			if err != nil {
+				return nil, err
+			}
+			owed[acc.ID] = balanceOwed
+			if heloc.PropertyAccountID != nil {
+				securedBy[acc.ID] = *heloc.PropertyAccountID
+				if heloc.MortgageAccountID != nil {
+					securedBy[*heloc.MortgageAccountID] = *heloc.PropertyAccountID
+				}
+			}
+		default:
+			continue
+		}
+		summary.Debts = append(summary.Debts, acc)
+		classTotals(summary.ByCurrency, string(acc.Currency)).Debt += owed[acc.ID]
+	}
+
+	for _, acc := range accounts {
+		if acc.Type != AccountTypeRealEstate {
+			continue
+		}
+
+		property := &PropertySummary{
+			AccountID: acc.ID,
+			Name:      acc.Name,
+			Currency:  string(acc.Currency),
+			Value:     balanceOrZero(acc),
+		}
+		if details, err := s.GetAssetDetails(ctx, acc.ID); err == nil {
+			purchaseDate := details.PurchaseDate
+			property.PurchasePrice = &details.PurchasePrice
+			property.PurchaseDate = &purchaseDate
+			if acc.CurrentBalance == nil {
+				// No balance recorded yet, so fall back to the valuation method
+				if value, _, err := s.calculateCurrentValue(ctx, details, time.Now()); err == nil {
+					property.Value = value
+				}
+			}
+			appreciation := roundCents(property.Value - details.PurchasePrice)
+			property.Appreciation = &appreciation
+			if details.PurchasePrice > 0 {
+				percent := roundCents(appreciation / details.PurchasePrice * 100)
+				property.AppreciationPercent = &percent
+			}
+		}
+		for debtID, propertyID := range securedBy {
+			if propertyID == acc.ID {
+				property.Debt += owed[debtID]
+			}
+		}
+		property.Value = roundCents(property.Value)
+		property.Debt = roundCents(property.Debt)
+		property.Equity = roundCents(property.Value - property.Debt)
+		if property.Value > 0 && property.Debt > 0 {
+			ltv := math.Round(property.Debt/property.Value*10000) / 10000
+			property.LoanToValue = &ltv
+		}
+		summary.Properties = append(summary.Properties, property)
+
+		totals := classTotals(summary.ByCurrency, property.Currency)
+		totals.Value += property.Value
+		if property.PurchasePrice != nil {
+			totals.CostBasis += *property.PurchasePrice
+			totals.Appreciation += *property.Appreciation
+		}
+	}
+
+	roundClassTotals(summary.ByCurrency)
+	return summary, nil
+}
@@ -0,0 +1,131 @@
+package account
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestGetRealEstateSummary_DebtAndAppreciation(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-real-estate-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupAccountService(t, db)
+
+	propertyID := CreateTestAccount(t, db, userID, AccountTypeRealEstate)
+	CreateTestBalance(t, db, propertyID, 600000)
+	_, err := service.CreateAssetDetails(ctx, propertyID, &CreateAssetDetailsRequest{
+		AssetType:          "real_estate",
+		PurchasePrice:      500000,
+		PurchaseDate:       Date{Time: time.Now().AddDate(-5, 0, 0)},
+		DepreciationMethod: "manual",
+	})
+	if err != nil {
+		t.Fatalf("CreateAssetDetails failed: %v", err)
+	}
+	mortgageID := CreateTestAccount(t, db, userID, AccountTypeMortgage)
+	CreateTestBalance(t, db, mortgageID, -300000)
+	otherMortgageID := CreateTestAccount(t, db, userID, AccountTypeMortgage)
+	CreateTestBalance(t, db, otherMortgageID, -50000)
+	helocID := CreateTestAccount(t, db, userID, AccountTypeLineOfCredit)
+	_, err = service.CreateHELOCDetails(ctx, helocID, &CreateHELOCDetailsRequest{
+		PropertyAccountID: &propertyID,
+		MortgageAccountID: &mortgageID,
+		MaxLTV:            0.80,
+		InterestRate:      0.06,
+	})
+	if err != nil {
+		t.Fatalf("CreateHELOCDetails failed: %v", err)
+	}
+	_, err = service.RecordHELOCTransaction(ctx, helocID, &CreateHELOCTransactionRequest{
+		TransactionDate: Date{Time: time.Now()},
+		Type:            HELOCDraw,
+		Amount:          30000,
+	})
+	if err != nil {
+		t.Fatalf("RecordHELOCTransaction failed: %v", err)
+	}
+
+	// Act
+	summary, err := service.GetRealEstateSummary(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetRealEstateSummary failed: %v", err)
+	}
+	if len(summary.Properties) != 1 || len(summary.Debts) != 3 {
+		t.Fatalf("Expected 1 property and 3 debts, got %d and %d", len(summary.Properties), len(summary.Debts))
+	}
+	property := summary.Properties[0]
+	if property.Debt != 330000 || property.Equity != 270000 {
+		t.Errorf("Expected linked debt 330000 and equity 270000, got %f and %f", property.Debt, property.Equity)
+	}
+	if property.Appreciation == nil || *property.Appreciation != 100000 || *property.AppreciationPercent != 20 {
+		t.Errorf("Expected appreciation of 100000 (20%%), got %v", property.Appreciation)
+	}
+	if property.LoanToValue == nil || *property.LoanToValue != 0.55 {
+		t.Errorf("Expected LTV 0.55, got %v", property.LoanToValue)
+	}
+	totals := summary.ByCurrency["CAD"]
+	if totals == nil || totals.Debt != 380000 || totals.Equity != 220000 {
+		t.Errorf("Expected class debt to include the unlinked mortgage, got %+v", totals)
+	}
+}
+
+func TestGetCryptoSummary_ValuesPositionsAtLatestQuote(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-crypto-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupAccountService(t, db)
+
+	walletID := CreateTestAccount(t, db, userID, AccountTypeCrypto)
+	insertHolding := func(symbol string, quantity, unitCost float64) {
+		_, err := db.Exec(`
+			INSERT INTO holdings (id, account_id, type, symbol, quantity, cost_basis)
+			VALUES ($1, $2, 'crypto', $3, $4, $5)
+		`, uuid.New().String(), walletID, symbol, quantity, unitCost)
+		if err != nil {
+			t.Fatalf("Failed to insert holding: %v", err)
+		}
+	}
+	insertHolding("TESTBTC", 0.5, 40000)
+	insertHolding("TESTETH", 2, 2000)
+	_, err := db.Exec(`
+		INSERT INTO market_data (id, symbol, price, currency, last_updated, source, created_at)
+		VALUES ($1, 'TESTBTC', 60000, 'CAD', $2, 'test', $2)
+	`, uuid.New().String(), time.Now())
+	if err != nil {
+		t.Fatalf("Failed to insert quote: %v", err)
+	}
+	defer db.Exec(`DELETE FROM market_data WHERE symbol = 'TESTBTC'`)
+
+	// Act
+	summary, err := service.GetCryptoSummary(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetCryptoSummary failed: %v", err)
+	}
+	if len(summary.Positions) != 2 || summary.Positions[0].Symbol != "TESTBTC" {
+		t.Fatalf("Expected the quoted position first, got %+v", summary.Positions)
+	}
+	if gain := summary.Positions[0].UnrealizedGain; gain == nil || *gain != 10000 {
+		t.Errorf("Expected an unrealized gain of 10000, got %v", gain)
+	}
+	if summary.Positions[1].Value != 4000 {
+		t.Errorf("Expected an unquoted position at cost basis, got %f", summary.Positions[1].Value)
+	}
+	totals := summary.ByCurrency["CAD"]
+	if totals == nil || totals.Value != 34000 || totals.CostBasis != 24000 || totals.Appreciation != 10000 {
+		t.Errorf("Unexpected totals: %+v", totals)
+	}
+}
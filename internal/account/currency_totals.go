@@ -0,0 +1,165 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"money/internal/currency"
+)
+
+// CurrencyBalanceSummary totals account balances in a single currency
+type CurrencyBalanceSummary struct {
+	Currency    string  `json:"currency"`
+	Accounts    int     `json:"accounts"`
+	Masked      int     `json:"masked_accounts,omitempty"`   // Private accounts left out of the totals
+	Excluded    int     `json:"excluded_accounts,omitempty"` // Accounts excluded from net worth, left out of the totals
+	Assets      float64 `json:"assets"`
+	Liabilities float64 `json:"liabilities"` // Positive amount owed
+	NetWorth    float64 `json:"net_worth"`   // Assets - Liabilities
+}
+
+// ConvertedTotal is every currency's totals converted into one base currency
+type ConvertedTotal struct {
+	BaseCurrency      string             `json:"base_currency"`
+	Assets            float64            `json:"assets"`
+	Liabilities       float64            `json:"liabilities"`
+	NetWorth          float64            `json:"net_worth"`
+	Rates             map[string]float64 `json:"rates"`                        // Rate used to convert each currency into the base
+	MissingCurrencies []string           `json:"missing_currencies,omitempty"` // Currencies left out for lack of an exchange rate
+}
+
+// summarizeByCurrency totals account balances per currency. Liabilities are stored as
+// negative balances, so their magnitude is reported as the amount owed.
+func summarizeByCurrency(accounts []*AccountWithBalance) map[string]*CurrencyBalanceSummary {
+	byCurrency := make(map[string]*CurrencyBalanceSummary)
+	for _, acc := range accounts {
+		currency := string(acc.Currency)
+		summary, ok := byCurrency[currency]
+		if !ok {
+			summary = &CurrencyBalanceSummary{Currency: currency}
+			byCurrency[currency] = summary
+		}
+		summary.Accounts++
+		if acc.BalanceMasked {
+			summary.Masked++
+		}
+		if acc.ExcludeFromNetWorth {
+			summary.Excluded++
+			continue
+		}
+		if acc.CurrentBalance == nil {
+			continue
+		}
+		if acc.IsAsset {
+			summary.Assets += *acc.CurrentBalance
+		} else {
+			summary.Liabilities += math.Abs(*acc.CurrentBalance)
+		}
+	}
+
+	for _, summary := range byCurrency {
+		summary.Assets = math.Round(summary.Assets*100) / 100
+		summary.Liabilities = math.Round(summary.Liabilities*100) / 100
+		summary.NetWorth = math.Round((summary.Assets-summary.Liabilities)*100) / 100
+	}
+	return byCurrency
+}
+
+// ConvertTotals converts per-currency totals into a base currency using the latest stored
+// exchange rates. Currencies without a rate are reported as missing rather than guessed.
+func (s *Service) ConvertTotals(ctx context.Context, byCurrency map[string]*CurrencyBalanceSummary, baseCurrency string) (*ConvertedTotal, error) {
+	baseCurrency = strings.ToUpper(baseCurrency)
+	if baseCurrency != string(CurrencyCAD) && baseCurrency != string(CurrencyUSD) && baseCurrency != string(CurrencyINR) {
+		return nil, fmt.Errorf("invalid base currency: %s", baseCurrency)
+	}
+
+	rates, err := s.latestExchangeRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertCurrencyTotals(byCurrency, baseCurrency, rates), nil
+}
+
+// convertCurrencyTotals converts totals with rates keyed by from and to currency,
+// falling back to the inverse of the opposite rate
+func convertCurrencyTotals(byCurrency map[string]*CurrencyBalanceSummary, baseCurrency string, rates map[string]map[string]float64) *ConvertedTotal {
+	total := &ConvertedTotal{
+		BaseCurrency: baseCurrency,
+		Rates:        make(map[string]float64),
+	}
+
+	for currency, summary := range byCurrency {
+		rate := 0.0
+		switch {
+		case currency == baseCurrency:
+			rate = 1
+		case rates[currency][baseCurrency] > 0:
+			rate = rates[currency][baseCurrency]
+		case rates[baseCurrency][currency] > 0:
+			rate = 1 / rates[baseCurrency][currency]
+		}
+		if rate == 0 {
+			total.MissingCurrencies = append(total.MissingCurrencies, currency)
+			continue
+		}
+
+		total.Rates[currency] = rate
+		total.Assets += summary.Assets * rate
+		total.Liabilities += summary.Liabilities * rate
+	}
+	sort.Strings(total.MissingCurrencies)
+
+	total.Assets = math.Round(total.Assets*100) / 100
+	total.Liabilities = math.Round(total.Liabilities*100) / 100
+	total.NetWorth = math.Round((total.Assets-total.Liabilities)*100) / 100
+	return total
+}
+
+// SetExchangeRates sets the cached rate source used for conversions
+func (s *Service) SetExchangeRates(rates *currency.Service) {
+	s.rates = rates
+}
+
+// latestExchangeRates returns the most recent rate for each currency pair, from the rate
+// cache when one is set
+func (s *Service) latestExchangeRates(ctx context.Context) (map[string]map[string]float64, error) {
+	if s.rates != nil {
+		latest, err := s.rates.GetLatestRates(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+		}
+		return latest.Rates, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e1.from_currency, e1.to_currency, e1.rate
+		FROM exchange_rates e1
+		WHERE e1.date = (
+			SELECT MAX(e2.date) FROM exchange_rates e2
+			WHERE e2.from_currency = e1.from_currency AND e2.to_currency = e1.to_currency
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := make(map[string]map[string]float64)
+	for rows.Next() {
+		var from, to string
+		var rate float64
+		if err := rows.Scan(&from, &to, &rate); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange rate: %w", err)
+		}
+		if rates[from] == nil {
+			rates[from] = make(map[string]float64)
+		}
+		rates[from][to] = rate
+	}
+
+	return rates, rows.Err()
+}
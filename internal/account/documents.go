@@ -0,0 +1,353 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/civil"
+
+	"github.com/google/uuid"
+)
+
+// MaxDocumentSize caps the size of a single stored document
+const MaxDocumentSize = 20 << 20
+
+// DefaultDocumentReminderDays is how long before expiry or renewal a reminder is sent
+const DefaultDocumentReminderDays = 30
+
+// Document types
+const (
+	DocumentTypeContract       = "contract"
+	DocumentTypeInsurance      = "insurance"
+	DocumentTypeGrantAgreement = "grant_agreement"
+	DocumentTypeStatement      = "statement"
+	DocumentTypeTax            = "tax"
+	DocumentTypeOther          = "other"
+)
+
+// AccountDocument is a stored file's metadata. The content is only read on download.
+type AccountDocument struct {
+	ID           string    `json:"id"`
+	AccountID    string    `json:"account_id"`
+	Name         string    `json:"name"`
+	DocumentType string    `json:"document_type"` // contract, insurance, grant_agreement, statement, tax, other
+	ContentType  string    `json:"content_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	ExpiryDate   *Date     `json:"expiry_date,omitempty"`
+	RenewalDate  *Date     `json:"renewal_date,omitempty"`
+	ReminderDays int       `json:"reminder_days"`
+	Notes        *string   `json:"notes,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// UploadDocumentRequest represents a document to store against an account
+type UploadDocumentRequest struct {
+	Name         string
+	DocumentType string
+	ContentType  string
+	Content      []byte
+	ExpiryDate   *Date
+	RenewalDate  *Date
+	ReminderDays *int
+	Notes        *string
+}
+
+// UpdateDocumentRequest updates a document's metadata; the file itself is not replaced
+type UpdateDocumentRequest struct {
+	Name         *string `json:"name,omitempty"`
+	DocumentType *string `json:"document_type,omitempty"`
+	ExpiryDate   *Date   `json:"expiry_date,omitempty"`
+	RenewalDate  *Date   `json:"renewal_date,omitempty"`
+	ReminderDays *int    `json:"reminder_days,omitempty"`
+	Notes        *string `json:"notes,omitempty"`
+}
+
+// DocumentsResponse lists an account's documents
+type DocumentsResponse struct {
+	Documents []AccountDocument `json:"documents"`
+}
+
+// ExpiringDocument is a document whose expiry or renewal date is within its reminder window
+type ExpiringDocument struct {
+	AccountDocument
+	AccountName   string `json:"account_name"`
+	DueDate       Date   `json:"due_date"`
+	DueReason     string `json:"due_reason"` // expiry, renewal
+	DaysRemaining int    `json:"days_remaining"`
+}
+
+// ExpiringDocumentsResponse lists documents due for a reminder
+type ExpiringDocumentsResponse struct {
+	Documents []ExpiringDocument `json:"documents"`
+}
+
+// documentColumns are the metadata columns read for a document
+const documentColumns = `id, account_id, name, document_type, content_type, size_bytes,
+	expiry_date, renewal_date, reminder_days, notes, created_at, updated_at`
+
+// UploadDocument stores a file against an account
+func (s *Service) UploadDocument(ctx context.Context, accountID string, req *UploadDocumentRequest) (*AccountDocument, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("document name is required")
+	}
+	if len(req.Content) == 0 {
+		return nil, fmt.Errorf("document is empty")
+	}
+	if len(req.Content) > MaxDocumentSize {
+		return nil, fmt.Errorf("document exceeds the %d MB limit", MaxDocumentSize>>20)
+	}
+	if req.DocumentType == "" {
+		req.DocumentType = DocumentTypeOther
+	}
+	if err := validateDocumentType(req.DocumentType); err != nil {
+		return nil, err
+	}
+	if req.ContentType == "" {
+		req.ContentType = "application/octet-stream"
+	}
+	reminderDays := DefaultDocumentReminderDays
+	if req.ReminderDays != nil {
+		if *req.ReminderDays < 0 {
+			return nil, fmt.Errorf("reminder_days must not be negative")
+		}
+		reminderDays = *req.ReminderDays
+	}
+
+	now := time.Now()
+	doc := &AccountDocument{
+		ID:           uuid.New().String(),
+		AccountID:    accountID,
+		Name:         req.Name,
+		DocumentType: req.DocumentType,
+		ContentType:  req.ContentType,
+		SizeBytes:    int64(len(req.Content)),
+		ExpiryDate:   req.ExpiryDate,
+		RenewalDate:  req.RenewalDate,
+		ReminderDays: reminderDays,
+		Notes:        req.Notes,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO account_documents (
+			id, account_id, name, document_type, content_type, size_bytes, content,
+			expiry_date, renewal_date, reminder_days, notes, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $12)
+	`, doc.ID, accountID, doc.Name, doc.DocumentType, doc.ContentType, doc.SizeBytes, req.Content,
+		doc.ExpiryDate, doc.RenewalDate, doc.ReminderDays, doc.Notes, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store document: %w", err)
+	}
+
+	return doc, nil
+}
+
+// ListDocuments lists an account's documents, newest first
+func (s *Service) ListDocuments(ctx context.Context, accountID string) (*DocumentsResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+documentColumns+`
+		FROM account_documents
+		WHERE account_id = $1
+		ORDER BY created_at DESC
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	defer rows.Close()
+
+	documents := make([]AccountDocument, 0)
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		documents = append(documents, *doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	return &DocumentsResponse{Documents: documents}, nil
+}
+
+// GetDocument retrieves a document's metadata
+func (s *Service) GetDocument(ctx context.Context, accountID, documentID string) (*AccountDocument, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	doc, err := scanDocument(s.db.QueryRowContext(ctx, `
+		SELECT `+documentColumns+`
+		FROM account_documents
+		WHERE id = $1 AND account_id = $2
+	`, documentID, accountID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("document not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+	return doc, nil
+}
+
+// GetDocumentContent retrieves a document with its file content
+func (s *Service) GetDocumentContent(ctx context.Context, accountID, documentID string) (*AccountDocument, []byte, error) {
+	doc, err := s.GetDocument(ctx, accountID, documentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var content []byte
+	err = s.db.QueryRowContext(ctx, `SELECT content FROM account_documents WHERE id = $1`, documentID).Scan(&content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read document: %w", err)
+	}
+	return doc, content, nil
+}
+
+// UpdateDocument updates a document's metadata
+func (s *Service) UpdateDocument(ctx context.Context, accountID, documentID string, req *UpdateDocumentRequest) (*AccountDocument, error) {
+	doc, err := s.GetDocument(ctx, accountID, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return nil, fmt.Errorf("document name is required")
+		}
+		doc.Name = *req.Name
+	}
+	if req.DocumentType != nil {
+		if err := validateDocumentType(*req.DocumentType); err != nil {
+			return nil, err
+		}
+		doc.DocumentType = *req.DocumentType
+	}
+	if req.ExpiryDate != nil {
+		doc.ExpiryDate = req.ExpiryDate
+	}
+	if req.RenewalDate != nil {
+		doc.RenewalDate = req.RenewalDate
+	}
+	if req.ReminderDays != nil {
+		if *req.ReminderDays < 0 {
+			return nil, fmt.Errorf("reminder_days must not be negative")
+		}
+		doc.ReminderDays = *req.ReminderDays
+	}
+	if req.Notes != nil {
+		doc.Notes = req.Notes
+	}
+	doc.UpdatedAt = time.Now()
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE account_documents
+		SET name = $1, document_type = $2, expiry_date = $3, renewal_date = $4, reminder_days = $5,
+			notes = $6, updated_at = $7
+		WHERE id = $8
+	`, doc.Name, doc.DocumentType, doc.ExpiryDate, doc.RenewalDate, doc.ReminderDays, doc.Notes, doc.UpdatedAt, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update document: %w", err)
+	}
+
+	return doc, nil
+}
+
+// DeleteDocument removes a document and its content
+func (s *Service) DeleteDocument(ctx context.Context, accountID, documentID string) error {
+	if _, err := s.GetDocument(ctx, accountID, documentID); err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM account_documents WHERE id = $1`, documentID); err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	return nil
+}
+
+// GetExpiringDocuments lists the user's documents whose expiry or renewal date falls within
+// their reminder window, including ones already past due
+func (s *Service) GetExpiringDocuments(ctx context.Context) (*ExpiringDocumentsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT d.id, d.account_id, d.name, d.document_type, d.content_type, d.size_bytes,
+			d.expiry_date, d.renewal_date, d.reminder_days, d.notes, d.created_at, d.updated_at, a.name
+		FROM account_documents d
+		JOIN accounts a ON a.id = d.account_id
+		WHERE a.user_id = $1 AND (d.expiry_date IS NOT NULL OR d.renewal_date IS NOT NULL)
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expiring documents: %w", err)
+	}
+	defer rows.Close()
+
+	today := civil.TodayIn(ctx)
+	documents := make([]ExpiringDocument, 0)
+	for rows.Next() {
+		var doc ExpiringDocument
+		err := rows.Scan(&doc.ID, &doc.AccountID, &doc.Name, &doc.DocumentType, &doc.ContentType, &doc.SizeBytes,
+			&doc.ExpiryDate, &doc.RenewalDate, &doc.ReminderDays, &doc.Notes, &doc.CreatedAt, &doc.UpdatedAt, &doc.AccountName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+
+		// A renewal comes before expiry, so it is the date to act on when both are set
+		due, reason := doc.ExpiryDate, "expiry"
+		if doc.RenewalDate != nil && (due == nil || doc.RenewalDate.Before(due.Time)) {
+			due, reason = doc.RenewalDate, "renewal"
+		}
+		days := int(due.Sub(today.Time).Hours() / 24)
+		if days > doc.ReminderDays {
+			continue
+		}
+		doc.DueDate = *due
+		doc.DueReason = reason
+		doc.DaysRemaining = days
+		documents = append(documents, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get expiring documents: %w", err)
+	}
+
+	return &ExpiringDocumentsResponse{Documents: documents}, nil
+}
+
+// validateDocumentType checks a document type is one the vault knows
+func validateDocumentType(documentType string) error {
+	switch documentType {
+	case DocumentTypeContract, DocumentTypeInsurance, DocumentTypeGrantAgreement,
+		DocumentTypeStatement, DocumentTypeTax, DocumentTypeOther:
+		return nil
+	}
+	return fmt.Errorf("invalid document_type: %s", documentType)
+}
+
+// scanDocument scans a row of documentColumns
+func scanDocument(row interface{ Scan(...interface{}) error }) (*AccountDocument, error) {
+	var doc AccountDocument
+	err := row.Scan(&doc.ID, &doc.AccountID, &doc.Name, &doc.DocumentType, &doc.ContentType, &doc.SizeBytes,
+		&doc.ExpiryDate, &doc.RenewalDate, &doc.ReminderDays, &doc.Notes, &doc.CreatedAt, &doc.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
@@ -0,0 +1,109 @@
+package account
+
+import (
+	"bytes"
+	"testing"
+
+	"money/internal/civil"
+)
+
+func TestUploadDocument_StoresContentAndMetadata(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-documents-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeMortgage)
+	service := SetupAccountService(t, db)
+	content := []byte("%PDF-1.4 mortgage contract")
+
+	// Act
+	doc, err := service.UploadDocument(ctx, accountID, &UploadDocumentRequest{
+		Name:         "Mortgage contract.pdf",
+		DocumentType: DocumentTypeContract,
+		ContentType:  "application/pdf",
+		Content:      content,
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("UploadDocument failed: %v", err)
+	}
+	if doc.SizeBytes != int64(len(content)) || doc.ReminderDays != DefaultDocumentReminderDays {
+		t.Errorf("Expected size and default reminder days to be set, got %+v", doc)
+	}
+	list, err := service.ListDocuments(ctx, accountID)
+	if err != nil {
+		t.Fatalf("ListDocuments failed: %v", err)
+	}
+	if len(list.Documents) != 1 || list.Documents[0].ID != doc.ID {
+		t.Fatalf("Expected the uploaded document to be listed, got %+v", list.Documents)
+	}
+	_, stored, err := service.GetDocumentContent(ctx, accountID, doc.ID)
+	if err != nil {
+		t.Fatalf("GetDocumentContent failed: %v", err)
+	}
+	if !bytes.Equal(stored, content) {
+		t.Errorf("Expected stored content %q, got %q", content, stored)
+	}
+
+	// Another user can't read it
+	otherUserID := "test-user-documents-2"
+	CreateTestUser(t, db, otherUserID)
+	if _, _, err := service.GetDocumentContent(CreateAuthContext(otherUserID), accountID, doc.ID); err == nil {
+		t.Error("Expected error reading another user's document")
+	}
+}
+
+func TestGetExpiringDocuments_WithinReminderWindow(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-documents-3"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeRealEstate)
+	service := SetupAccountService(t, db)
+
+	today := civil.TodayIn(ctx)
+	soon := today.AddDays(10)
+	later := today.AddDays(90)
+	renewal := today.AddDays(5)
+	for _, req := range []*UploadDocumentRequest{
+		{Name: "Home insurance", DocumentType: DocumentTypeInsurance, Content: []byte("policy"), ExpiryDate: &soon},
+		{Name: "Title deed", Content: []byte("deed"), ExpiryDate: &later},
+		{Name: "Warranty", Content: []byte("warranty"), ExpiryDate: &later, RenewalDate: &renewal},
+	} {
+		if _, err := service.UploadDocument(ctx, accountID, req); err != nil {
+			t.Fatalf("UploadDocument failed: %v", err)
+		}
+	}
+
+	// Act
+	resp, err := service.GetExpiringDocuments(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetExpiringDocuments failed: %v", err)
+	}
+	if len(resp.Documents) != 2 {
+		t.Fatalf("Expected 2 documents due for a reminder, got %d", len(resp.Documents))
+	}
+	for _, doc := range resp.Documents {
+		switch doc.Name {
+		case "Home insurance":
+			if doc.DueReason != "expiry" || doc.DaysRemaining != 10 {
+				t.Errorf("Expected expiry in 10 days, got %s in %d", doc.DueReason, doc.DaysRemaining)
+			}
+		case "Warranty":
+			if doc.DueReason != "renewal" || !doc.DueDate.Equal(renewal.Time) {
+				t.Errorf("Expected renewal on %s, got %s on %s", renewal, doc.DueReason, doc.DueDate)
+			}
+		default:
+			t.Errorf("Unexpected document %s", doc.Name)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/civil"
+)
+
+// employerMatchTypes are the account types that can hold an employer-matched plan
+var employerMatchTypes = map[AccountType]bool{
+	AccountTypeRRSP:      true,
+	AccountTypeTFSA:      true,
+	AccountTypeBrokerage: true,
+	AccountTypeOther:     true,
+}
+
+// EmployerMatchDetails holds the employer matching terms of a retirement account
+type EmployerMatchDetails struct {
+	ID                       string              `json:"id"`
+	AccountID                string              `json:"account_id"`
+	EmployeeContributionRate float64             `json:"employee_contribution_rate"`      // e.g. 0.05 for 5% of gross salary
+	MatchRate                float64             `json:"match_rate"`                      // Employer dollars per employee dollar, e.g. 0.5
+	MatchCapRate             *float64            `json:"match_cap_rate,omitempty"`        // Employer match limited to this share of salary
+	AnnualMatchCap           *float64            `json:"annual_match_cap,omitempty"`      // Employer match limited to this amount per year
+	EmploymentStartDate      *Date               `json:"employment_start_date,omitempty"` // Vesting service counts from this date
+	EmployerContributions    float64             `json:"employer_contributions"`          // Employer money already in the account
+	VestingSchedule          []EmployerMatchStep `json:"vesting_schedule"`                // Empty when employer money vests immediately
+	VestedPercent            float64             `json:"vested_percent"`                  // Vested share of the employer money today
+	VestedAmount             float64             `json:"vested_amount"`
+	UnvestedAmount           float64             `json:"unvested_amount"` // Forfeited if employment ended today
+	CreatedAt                time.Time           `json:"created_at"`
+	UpdatedAt                time.Time           `json:"updated_at"`
+}
+
+// EmployerMatchStep is the share of employer money vested after a number of years of service
+type EmployerMatchStep struct {
+	YearsOfService int     `json:"years_of_service"`
+	VestedPercent  float64 `json:"vested_percent"` // e.g. 0.5 for 50%
+}
+
+// SetEmployerMatchRequest represents the request to set an account's employer matching terms.
+// The vesting schedule replaces any recorded before.
+type SetEmployerMatchRequest struct {
+	EmployeeContributionRate float64             `json:"employee_contribution_rate"`
+	MatchRate                float64             `json:"match_rate"`
+	MatchCapRate             *float64            `json:"match_cap_rate,omitempty"`
+	AnnualMatchCap           *float64            `json:"annual_match_cap,omitempty"`
+	EmploymentStartDate      *Date               `json:"employment_start_date,omitempty"`
+	EmployerContributions    float64             `json:"employer_contributions"`
+	VestingSchedule          []EmployerMatchStep `json:"vesting_schedule"`
+}
+
+// SetEmployerMatch records the employer matching terms of a retirement account
+func (s *Service) SetEmployerMatch(ctx context.Context, accountID string, req *SetEmployerMatchRequest) (*EmployerMatchDetails, error) {
+	acc, err := s.Get(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if !employerMatchTypes[acc.Type] {
+		return nil, fmt.Errorf("employer matching is not supported for %s accounts", acc.Type)
+	}
+	if err := validateEmployerMatch(req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	details := &EmployerMatchDetails{
+		ID:                       uuid.New().String(),
+		AccountID:                accountID,
+		EmployeeContributionRate: req.EmployeeContributionRate,
+		MatchRate:                req.MatchRate,
+		MatchCapRate:             req.MatchCapRate,
+		AnnualMatchCap:           req.AnnualMatchCap,
+		EmploymentStartDate:      req.EmploymentStartDate,
+		EmployerContributions:    req.EmployerContributions,
+		VestingSchedule:          make([]EmployerMatchStep, 0, len(req.VestingSchedule)),
+		UpdatedAt:                now,
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO employer_match_details (
+			id, account_id, employee_contribution_rate, match_rate, match_cap_rate,
+			annual_match_cap, employment_start_date, employer_contributions,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		ON CONFLICT (account_id) DO UPDATE SET
+			employee_contribution_rate = excluded.employee_contribution_rate,
+			match_rate = excluded.match_rate,
+			match_cap_rate = excluded.match_cap_rate,
+			annual_match_cap = excluded.annual_match_cap,
+			employment_start_date = excluded.employment_start_date,
+			employer_contributions = excluded.employer_contributions,
+			updated_at = excluded.updated_at
+		RETURNING id, created_at
+	`, details.ID, accountID, details.EmployeeContributionRate, details.MatchRate, details.MatchCapRate,
+		details.AnnualMatchCap, details.EmploymentStartDate, details.EmployerContributions,
+		now).Scan(&details.ID, &details.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set employer match: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM employer_match_vesting WHERE account_id = $1`, accountID); err != nil {
+		return nil, fmt.Errorf("failed to replace vesting schedule: %w", err)
+	}
+	for _, step := range req.VestingSchedule {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO employer_match_vesting (id, account_id, years_of_service, vested_percent, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`, uuid.New().String(), accountID, step.YearsOfService, step.VestedPercent, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add vesting step: %w", err)
+		}
+		details.VestingSchedule = append(details.VestingSchedule, step)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit employer match: %w", err)
+	}
+
+	sort.Slice(details.VestingSchedule, func(i, j int) bool {
+		return details.VestingSchedule[i].YearsOfService < details.VestingSchedule[j].YearsOfService
+	})
+	details.setVesting(civil.TodayIn(ctx).Time)
+	return details, nil
+}
+
+// validateEmployerMatch checks the matching rates, caps and vesting schedule
+func validateEmployerMatch(req *SetEmployerMatchRequest) error {
+	if req.EmployeeContributionRate < 0 || req.EmployeeContributionRate > 1 {
+		return fmt.Errorf("employee_contribution_rate must be between 0 and 1")
+	}
+	if req.MatchRate < 0 || req.MatchRate > 10 {
+		return fmt.Errorf("match_rate must be between 0 and 10")
+	}
+	if req.MatchCapRate != nil && (*req.MatchCapRate < 0 || *req.MatchCapRate > 1) {
+		return fmt.Errorf("match_cap_rate must be between 0 and 1")
+	}
+	if req.AnnualMatchCap != nil && *req.AnnualMatchCap < 0 {
+		return fmt.Errorf("annual_match_cap must not be negative")
+	}
+	if req.EmployerContributions < 0 {
+		return fmt.Errorf("employer_contributions must not be negative")
+	}
+	if len(req.VestingSchedule) > 0 && req.EmploymentStartDate == nil {
+		return fmt.Errorf("employment_start_date is required for a vesting schedule")
+	}
+	seen := make(map[int]bool)
+	for _, step := range req.VestingSchedule {
+		if step.YearsOfService < 0 {
+			return fmt.Errorf("years_of_service must not be negative")
+		}
+		if step.VestedPercent < 0 || step.VestedPercent > 1 {
+			return fmt.Errorf("vested_percent must be between 0 and 1")
+		}
+		if seen[step.YearsOfService] {
+			return fmt.Errorf("vesting schedule lists %d years of service more than once", step.YearsOfService)
+		}
+		seen[step.YearsOfService] = true
+	}
+	return nil
+}
+
+// GetEmployerMatch retrieves the employer matching terms of an account with how much of the
+// employer money is vested today
+func (s *Service) GetEmployerMatch(ctx context.Context, accountID string) (*EmployerMatchDetails, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	details, err := getEmployerMatch(ctx, s.db, accountID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("employer match not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	details.setVesting(civil.TodayIn(ctx).Time)
+	return details, nil
+}
+
+// getEmployerMatch reads an account's matching terms, returning sql.ErrNoRows when it has none
+func getEmployerMatch(ctx context.Context, db *sql.DB, accountID string) (*EmployerMatchDetails, error) {
+	var details EmployerMatchDetails
+	err := db.QueryRowContext(ctx, `
+		SELECT id, account_id, employee_contribution_rate, match_rate, match_cap_rate,
+			annual_match_cap, employment_start_date, employer_contributions,
+			created_at, updated_at
+		FROM employer_match_details
+		WHERE account_id = $1
+	`, accountID).Scan(
+		&details.ID, &details.AccountID, &details.EmployeeContributionRate, &details.MatchRate, &details.MatchCapRate,
+		&details.AnnualMatchCap, &details.EmploymentStartDate, &details.EmployerContributions,
+		&details.CreatedAt, &details.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get employer match: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT years_of_service, vested_percent
+		FROM employer_match_vesting
+		WHERE account_id = $1
+		ORDER BY years_of_service
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vesting schedule: %w", err)
+	}
+	defer rows.Close()
+
+	details.VestingSchedule = make([]EmployerMatchStep, 0)
+	for rows.Next() {
+		var step EmployerMatchStep
+		if err := rows.Scan(&step.YearsOfService, &step.VestedPercent); err != nil {
+			return nil, fmt.Errorf("failed to scan vesting step: %w", err)
+		}
+		details.VestingSchedule = append(details.VestingSchedule, step)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vesting schedule: %w", err)
+	}
+
+	return &details, nil
+}
+
+// setVesting fills in the vested share of the recorded employer money on the date
+func (d *EmployerMatchDetails) setVesting(date time.Time) {
+	d.VestedPercent = d.VestedPercentOn(date)
+	d.VestedAmount = roundCents(d.EmployerContributions * d.VestedPercent)
+	d.UnvestedAmount = roundCents(d.EmployerContributions - d.VestedAmount)
+}
+
+// VestedPercentOn returns the share of employer money vested on the date: the highest step
+// reached by completed years of service, or all of it without a schedule
+func (d *EmployerMatchDetails) VestedPercentOn(date time.Time) float64 {
+	if d == nil || len(d.VestingSchedule) == 0 || d.EmploymentStartDate == nil {
+		return 1
+	}
+	years := completedYears(d.EmploymentStartDate.Time, date)
+	vested := 0.0
+	for _, step := range d.VestingSchedule {
+		if step.YearsOfService <= years && step.VestedPercent > vested {
+			vested = step.VestedPercent
+		}
+	}
+	return vested
+}
+
+// MonthlyContributions returns the employee contribution and the employer match for a
+// month at the annual gross salary, applying the match caps
+func (d *EmployerMatchDetails) MonthlyContributions(annualGrossSalary float64) (employee, employer float64) {
+	if d == nil || annualGrossSalary <= 0 {
+		return 0, 0
+	}
+	annualEmployee := annualGrossSalary * d.EmployeeContributionRate
+	annualEmployer := annualEmployee * d.MatchRate
+	if d.MatchCapRate != nil {
+		annualEmployer = math.Min(annualEmployer, annualGrossSalary*(*d.MatchCapRate))
+	}
+	if d.AnnualMatchCap != nil {
+		annualEmployer = math.Min(annualEmployer, *d.AnnualMatchCap)
+	}
+	return annualEmployee / 12, annualEmployer / 12
+}
+
+// completedYears counts the full years between start and date
+func completedYears(start, date time.Time) int {
+	years := date.Year() - start.Year()
+	if date.Before(start.AddDate(years, 0, 0)) {
+		years--
+	}
+	if years < 0 {
+		return 0
+	}
+	return years
+}
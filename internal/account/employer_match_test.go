@@ -0,0 +1,75 @@
+package account
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetEmployerMatch_FlagsUnvestedEmployerMoney(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-employer-match-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupAccountService(t, db)
+
+	accountID := CreateTestAccount(t, db, userID, AccountTypeRRSP)
+	capRate := 0.04
+	req := &SetEmployerMatchRequest{
+		EmployeeContributionRate: 0.06,
+		MatchRate:                1,
+		MatchCapRate:             &capRate,
+		EmploymentStartDate:      &Date{Time: time.Now().AddDate(-2, -1, 0)},
+		EmployerContributions:    10000,
+		VestingSchedule: []EmployerMatchStep{
+			{YearsOfService: 3, VestedPercent: 1},
+			{YearsOfService: 1, VestedPercent: 0.25},
+			{YearsOfService: 2, VestedPercent: 0.5},
+		},
+	}
+
+	// Act
+	_, err := service.SetEmployerMatch(ctx, accountID, req)
+	if err != nil {
+		t.Fatalf("SetEmployerMatch failed: %v", err)
+	}
+	details, err := service.GetEmployerMatch(ctx, accountID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetEmployerMatch failed: %v", err)
+	}
+	if len(details.VestingSchedule) != 3 || details.VestingSchedule[0].YearsOfService != 1 {
+		t.Errorf("Expected the vesting schedule ordered by service, got %+v", details.VestingSchedule)
+	}
+	if details.VestedPercent != 0.5 || details.VestedAmount != 5000 || details.UnvestedAmount != 5000 {
+		t.Errorf("Expected half the employer money vested after two years, got %+v", details)
+	}
+	employee, employer := details.MonthlyContributions(120000)
+	if employee != 600 || employer != 400 {
+		t.Errorf("Expected 600 from the employee and a capped 400 match, got %f and %f", employee, employer)
+	}
+}
+
+func TestSetEmployerMatch_RejectsNonRetirementAccounts(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-employer-match-2"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupAccountService(t, db)
+
+	accountID := CreateTestAccount(t, db, userID, AccountTypeMortgage)
+
+	// Act
+	_, err := service.SetEmployerMatch(ctx, accountID, &SetEmployerMatchRequest{MatchRate: 1})
+
+	// Assert
+	if err == nil {
+		t.Error("Expected an error for a mortgage account")
+	}
+}
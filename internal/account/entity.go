@@ -0,0 +1,628 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+)
+
+// EntityType identifies who owns a group of accounts
+type EntityType string
+
+const (
+	EntityTypePersonal           EntityType = "personal"
+	EntityTypeSoleProprietorship EntityType = "sole_proprietorship"
+	EntityTypeCorporation        EntityType = "corporation"
+)
+
+// EntityTransactionType identifies a movement of money between entities
+type EntityTransactionType string
+
+const (
+	EntityTransactionOwnerDraw           EntityTransactionType = "owner_draw"
+	EntityTransactionDividend            EntityTransactionType = "dividend"
+	EntityTransactionSalary              EntityTransactionType = "salary"
+	EntityTransactionCapitalContribution EntityTransactionType = "capital_contribution"
+	EntityTransactionLoanRepayment       EntityTransactionType = "loan_repayment"
+)
+
+// Entity is a person or business that owns accounts. Accounts without an entity belong to
+// the user personally.
+type Entity struct {
+	ID                 string     `json:"id"`
+	UserID             string     `json:"user_id"`
+	Name               string     `json:"name"`
+	Type               EntityType `json:"type"`
+	TaxJurisdiction    *string    `json:"tax_jurisdiction,omitempty"`
+	CorporateTaxRate   *float64   `json:"corporate_tax_rate,omitempty"` // Percent, for corporations
+	FiscalYearEndMonth int        `json:"fiscal_year_end_month"`        // 1-12
+	Notes              *string    `json:"notes,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// CreateEntityRequest represents the request to create an entity
+type CreateEntityRequest struct {
+	Name               string     `json:"name"`
+	Type               EntityType `json:"type"`
+	TaxJurisdiction    *string    `json:"tax_jurisdiction,omitempty"`
+	CorporateTaxRate   *float64   `json:"corporate_tax_rate,omitempty"`
+	FiscalYearEndMonth *int       `json:"fiscal_year_end_month,omitempty"` // Defaults to December
+	Notes              *string    `json:"notes,omitempty"`
+}
+
+// UpdateEntityRequest represents the request to update an entity
+type UpdateEntityRequest struct {
+	Name               *string  `json:"name,omitempty"`
+	TaxJurisdiction    *string  `json:"tax_jurisdiction,omitempty"`
+	CorporateTaxRate   *float64 `json:"corporate_tax_rate,omitempty"`
+	FiscalYearEndMonth *int     `json:"fiscal_year_end_month,omitempty"`
+	Notes              *string  `json:"notes,omitempty"`
+}
+
+// ListEntitiesResponse represents the response for listing entities
+type ListEntitiesResponse struct {
+	Entities []*Entity `json:"entities"`
+}
+
+// AssignEntityRequest moves an account to an entity, or back to the user when EntityID is empty
+type AssignEntityRequest struct {
+	EntityID *string `json:"entity_id"`
+}
+
+// EntityTransaction is money moving between two entities, such as an owner draw or dividend
+type EntityTransaction struct {
+	ID           string                `json:"id"`
+	FromEntityID string                `json:"from_entity_id"`
+	ToEntityID   string                `json:"to_entity_id"`
+	Type         EntityTransactionType `json:"type"`
+	Amount       float64               `json:"amount"`
+	Currency     Currency              `json:"currency"`
+	Date         Date                  `json:"date"`
+	TransferID   *string               `json:"transfer_id,omitempty"` // Transfer that moved the money, if recorded
+	Notes        *string               `json:"notes,omitempty"`
+	CreatedAt    time.Time             `json:"created_at"`
+}
+
+// CreateEntityTransactionRequest represents the request to record an inter-entity transaction
+type CreateEntityTransactionRequest struct {
+	FromEntityID string                `json:"from_entity_id"`
+	ToEntityID   string                `json:"to_entity_id"`
+	Type         EntityTransactionType `json:"type"`
+	Amount       float64               `json:"amount"`
+	Currency     Currency              `json:"currency"`
+	Date         Date                  `json:"date"`
+	TransferID   *string               `json:"transfer_id,omitempty"`
+	Notes        *string               `json:"notes,omitempty"`
+}
+
+// ListEntityTransactionsResponse represents the response for listing inter-entity transactions
+type ListEntityTransactionsResponse struct {
+	Transactions []*EntityTransaction `json:"transactions"`
+}
+
+// EntitySummary totals the balances of the accounts an entity owns
+type EntitySummary struct {
+	Entity         *Entity                            `json:"entity,omitempty"` // Nil for accounts the user owns personally
+	Accounts       []*AccountWithBalance              `json:"accounts"`
+	ByCurrency     map[string]*CurrencyBalanceSummary `json:"by_currency"`
+	ConvertedTotal *ConvertedTotal                    `json:"converted_total,omitempty"` // Set when a base currency is requested
+}
+
+// EntitySummariesResponse is every entity's summary plus the user's unassigned accounts
+type EntitySummariesResponse struct {
+	Entities   []*EntitySummary `json:"entities"`
+	Unassigned *EntitySummary   `json:"unassigned"`
+}
+
+// EntityTaxTotals totals an entity's inter-entity transactions in one currency
+type EntityTaxTotals struct {
+	Currency           string                            `json:"currency"`
+	Paid               map[EntityTransactionType]float64 `json:"paid"`
+	Received           map[EntityTransactionType]float64 `json:"received"`
+	DeductiblePayments float64                           `json:"deductible_payments"` // Salary paid by a business
+	TaxableReceipts    float64                           `json:"taxable_receipts"`    // Salary and dividends received
+	ShareholderLoan    float64                           `json:"shareholder_loan"`    // Draws from a corporation not yet repaid, as of period end
+}
+
+// EntityTaxSummary reports an entity's inter-entity transactions for one fiscal year
+type EntityTaxSummary struct {
+	EntityID         string                      `json:"entity_id"`
+	EntityType       EntityType                  `json:"entity_type"`
+	FiscalYear       int                         `json:"fiscal_year"`
+	PeriodStart      string                      `json:"period_start"`
+	PeriodEnd        string                      `json:"period_end"`
+	CorporateTaxRate *float64                    `json:"corporate_tax_rate,omitempty"`
+	ByCurrency       map[string]*EntityTaxTotals `json:"by_currency"`
+}
+
+// validEntityType reports whether t is a supported entity type
+func validEntityType(t EntityType) bool {
+	switch t {
+	case EntityTypePersonal, EntityTypeSoleProprietorship, EntityTypeCorporation:
+		return true
+	}
+	return false
+}
+
+// validateEntityTaxSettings checks the tax fields shared by create and update
+func validateEntityTaxSettings(corporateTaxRate *float64, fiscalYearEndMonth *int) error {
+	if corporateTaxRate != nil && (*corporateTaxRate < 0 || *corporateTaxRate > 100) {
+		return fmt.Errorf("corporate tax rate must be between 0 and 100")
+	}
+	if fiscalYearEndMonth != nil && (*fiscalYearEndMonth < 1 || *fiscalYearEndMonth > 12) {
+		return fmt.Errorf("fiscal year end month must be between 1 and 12")
+	}
+	return nil
+}
+
+// CreateEntity creates an entity for the authenticated user
+func (s *Service) CreateEntity(ctx context.Context, req *CreateEntityRequest) (*Entity, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	if req.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if !validEntityType(req.Type) {
+		return nil, fmt.Errorf("invalid entity type: %s", req.Type)
+	}
+	if err := validateEntityTaxSettings(req.CorporateTaxRate, req.FiscalYearEndMonth); err != nil {
+		return nil, err
+	}
+
+	entity := &Entity{
+		ID:                 uuid.New().String(),
+		UserID:             userID,
+		Name:               req.Name,
+		Type:               req.Type,
+		TaxJurisdiction:    req.TaxJurisdiction,
+		CorporateTaxRate:   req.CorporateTaxRate,
+		FiscalYearEndMonth: 12,
+		Notes:              req.Notes,
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
+	}
+	if req.FiscalYearEndMonth != nil {
+		entity.FiscalYearEndMonth = *req.FiscalYearEndMonth
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO entities (id, user_id, name, type, tax_jurisdiction, corporate_tax_rate, fiscal_year_end_month, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, entity.ID, userID, entity.Name, entity.Type, entity.TaxJurisdiction, entity.CorporateTaxRate,
+		entity.FiscalYearEndMonth, entity.Notes, entity.CreatedAt, entity.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create entity: %w", err)
+	}
+
+	return entity, nil
+}
+
+// ListEntities lists the authenticated user's entities
+func (s *Service) ListEntities(ctx context.Context) (*ListEntitiesResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, name, type, tax_jurisdiction, corporate_tax_rate, fiscal_year_end_month, notes, created_at, updated_at
+		FROM entities
+		WHERE user_id = $1
+		ORDER BY name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list entities: %w", err)
+	}
+	defer rows.Close()
+
+	entities := make([]*Entity, 0)
+	for rows.Next() {
+		entity, err := scanEntity(rows)
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, entity)
+	}
+
+	return &ListEntitiesResponse{Entities: entities}, rows.Err()
+}
+
+// GetEntity retrieves one of the authenticated user's entities
+func (s *Service) GetEntity(ctx context.Context, id string) (*Entity, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	entity, err := scanEntity(s.db.QueryRowContext(ctx, `
+		SELECT id, user_id, name, type, tax_jurisdiction, corporate_tax_rate, fiscal_year_end_month, notes, created_at, updated_at
+		FROM entities
+		WHERE id = $1 AND user_id = $2
+	`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("entity not found")
+	}
+	return entity, err
+}
+
+// UpdateEntity updates an entity's name and tax settings
+func (s *Service) UpdateEntity(ctx context.Context, id string, req *UpdateEntityRequest) (*Entity, error) {
+	entity, err := s.GetEntity(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateEntityTaxSettings(req.CorporateTaxRate, req.FiscalYearEndMonth); err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if *req.Name == "" {
+			return nil, fmt.Errorf("name is required")
+		}
+		entity.Name = *req.Name
+	}
+	if req.TaxJurisdiction != nil {
+		entity.TaxJurisdiction = req.TaxJurisdiction
+	}
+	if req.CorporateTaxRate != nil {
+		entity.CorporateTaxRate = req.CorporateTaxRate
+	}
+	if req.FiscalYearEndMonth != nil {
+		entity.FiscalYearEndMonth = *req.FiscalYearEndMonth
+	}
+	if req.Notes != nil {
+		entity.Notes = req.Notes
+	}
+	entity.UpdatedAt = time.Now()
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE entities
+		SET name = $1, tax_jurisdiction = $2, corporate_tax_rate = $3, fiscal_year_end_month = $4, notes = $5, updated_at = $6
+		WHERE id = $7 AND user_id = $8
+	`, entity.Name, entity.TaxJurisdiction, entity.CorporateTaxRate, entity.FiscalYearEndMonth, entity.Notes,
+		entity.UpdatedAt, id, entity.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update entity: %w", err)
+	}
+
+	return entity, nil
+}
+
+// DeleteEntity deletes an entity. Its accounts return to the user and its inter-entity
+// transactions are removed.
+func (s *Service) DeleteEntity(ctx context.Context, id string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM entities WHERE id = $1 AND user_id = $2
+	`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete entity: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("entity not found")
+	}
+	return nil
+}
+
+// AssignAccountEntity moves an account, synced or not, to one of the user's entities
+func (s *Service) AssignAccountEntity(ctx context.Context, accountID string, req *AssignEntityRequest) (*Account, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	var entityID *string
+	if req.EntityID != nil && *req.EntityID != "" {
+		if _, err := s.GetEntity(ctx, *req.EntityID); err != nil {
+			return nil, err
+		}
+		entityID = req.EntityID
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE accounts SET entity_id = $1, updated_at = $2 WHERE id = $3
+	`, entityID, time.Now(), accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to assign account entity: %w", err)
+	}
+
+	return s.Get(ctx, accountID)
+}
+
+// CreateEntityTransaction records money moving between two of the user's entities
+func (s *Service) CreateEntityTransaction(ctx context.Context, req *CreateEntityTransactionRequest) (*EntityTransaction, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	switch req.Type {
+	case EntityTransactionOwnerDraw, EntityTransactionDividend, EntityTransactionSalary,
+		EntityTransactionCapitalContribution, EntityTransactionLoanRepayment:
+	default:
+		return nil, fmt.Errorf("invalid entity transaction type: %s", req.Type)
+	}
+	if req.FromEntityID == req.ToEntityID {
+		return nil, fmt.Errorf("from and to entities must differ")
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	if req.Currency != CurrencyCAD && req.Currency != CurrencyUSD && req.Currency != CurrencyINR {
+		return nil, fmt.Errorf("invalid currency: %s", req.Currency)
+	}
+	if req.Date.Time.IsZero() {
+		return nil, fmt.Errorf("date is required")
+	}
+	for _, entityID := range []string{req.FromEntityID, req.ToEntityID} {
+		if _, err := s.GetEntity(ctx, entityID); err != nil {
+			return nil, err
+		}
+	}
+	if req.TransferID != nil {
+		var exists bool
+		err := s.db.QueryRowContext(ctx, `
+			SELECT EXISTS(SELECT 1 FROM transfers WHERE id = $1 AND user_id = $2)
+		`, *req.TransferID, userID).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify transfer: %w", err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("transfer not found")
+		}
+	}
+
+	txn := &EntityTransaction{
+		ID:           uuid.New().String(),
+		FromEntityID: req.FromEntityID,
+		ToEntityID:   req.ToEntityID,
+		Type:         req.Type,
+		Amount:       req.Amount,
+		Currency:     req.Currency,
+		Date:         req.Date,
+		TransferID:   req.TransferID,
+		Notes:        req.Notes,
+		CreatedAt:    time.Now(),
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO entity_transactions (id, user_id, from_entity_id, to_entity_id, type, amount, currency, date, transfer_id, notes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, txn.ID, userID, txn.FromEntityID, txn.ToEntityID, txn.Type, txn.Amount, txn.Currency, txn.Date,
+		txn.TransferID, txn.Notes, txn.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create entity transaction: %w", err)
+	}
+
+	return txn, nil
+}
+
+// ListEntityTransactions lists the user's inter-entity transactions, optionally only those
+// involving one entity
+func (s *Service) ListEntityTransactions(ctx context.Context, entityID string) (*ListEntityTransactionsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, from_entity_id, to_entity_id, type, amount, currency, date, transfer_id, notes, created_at
+		FROM entity_transactions
+		WHERE user_id = $1 AND ($2 = '' OR from_entity_id = $2 OR to_entity_id = $2)
+		ORDER BY date DESC, created_at DESC
+	`, userID, entityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list entity transactions: %w", err)
+	}
+	defer rows.Close()
+
+	transactions := make([]*EntityTransaction, 0)
+	for rows.Next() {
+		txn := &EntityTransaction{}
+		err := rows.Scan(&txn.ID, &txn.FromEntityID, &txn.ToEntityID, &txn.Type, &txn.Amount, &txn.Currency,
+			&txn.Date, &txn.TransferID, &txn.Notes, &txn.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan entity transaction: %w", err)
+		}
+		transactions = append(transactions, txn)
+	}
+
+	return &ListEntityTransactionsResponse{Transactions: transactions}, rows.Err()
+}
+
+// DeleteEntityTransaction deletes an inter-entity transaction
+func (s *Service) DeleteEntityTransaction(ctx context.Context, id string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM entity_transactions WHERE id = $1 AND user_id = $2
+	`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete entity transaction: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("entity transaction not found")
+	}
+	return nil
+}
+
+// GetEntitySummaries totals account balances per entity, keeping the user's unassigned
+// accounts separate. With a base currency, each entity's totals are also converted.
+func (s *Service) GetEntitySummaries(ctx context.Context, baseCurrency string) (*EntitySummariesResponse, error) {
+	entities, err := s.ListEntities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := s.ListWithBalance(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[string]*EntitySummary, len(entities.Entities))
+	response := &EntitySummariesResponse{
+		Entities:   make([]*EntitySummary, 0, len(entities.Entities)),
+		Unassigned: &EntitySummary{Accounts: make([]*AccountWithBalance, 0)},
+	}
+	for _, entity := range entities.Entities {
+		summary := &EntitySummary{Entity: entity, Accounts: make([]*AccountWithBalance, 0)}
+		summaries[entity.ID] = summary
+		response.Entities = append(response.Entities, summary)
+	}
+
+	for _, acc := range accounts.Accounts {
+		summary := response.Unassigned
+		if acc.EntityID != nil {
+			if owner, ok := summaries[*acc.EntityID]; ok {
+				summary = owner
+			}
+		}
+		summary.Accounts = append(summary.Accounts, acc)
+	}
+
+	for _, summary := range append(response.Entities, response.Unassigned) {
+		summary.ByCurrency = summarizeByCurrency(summary.Accounts)
+		if baseCurrency == "" {
+			continue
+		}
+		converted, err := s.ConvertTotals(ctx, summary.ByCurrency, baseCurrency)
+		if err != nil {
+			return nil, err
+		}
+		summary.ConvertedTotal = converted
+	}
+
+	return response, nil
+}
+
+// GetEntityTaxSummary totals an entity's inter-entity transactions for the fiscal year
+// ending in the given calendar year
+func (s *Service) GetEntityTaxSummary(ctx context.Context, entityID string, fiscalYear int) (*EntityTaxSummary, error) {
+	entity, err := s.GetEntity(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := s.ListEntityTransactions(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	return entityTaxSummary(entity, fiscalYear, transactions.Transactions), nil
+}
+
+// fiscalYearBounds returns the first and last day of the fiscal year ending in the
+// given calendar year
+func fiscalYearBounds(fiscalYear, endMonth int) (time.Time, time.Time) {
+	end := time.Date(fiscalYear, time.Month(endMonth)+1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+	start := end.AddDate(-1, 0, 1)
+	return start, end
+}
+
+// entityTaxSummary applies each transaction type's tax treatment from the entity's side.
+// Salary is deductible to a paying business and taxable to the person receiving it;
+// dividends are taxable to the receiver but not deductible; draws, contributions and loan
+// repayments move capital and are neither. Draws from a corporation that haven't been
+// repaid are reported as a shareholder loan.
+func entityTaxSummary(entity *Entity, fiscalYear int, transactions []*EntityTransaction) *EntityTaxSummary {
+	start, end := fiscalYearBounds(fiscalYear, entity.FiscalYearEndMonth)
+	summary := &EntityTaxSummary{
+		EntityID:         entity.ID,
+		EntityType:       entity.Type,
+		FiscalYear:       fiscalYear,
+		PeriodStart:      start.Format("2006-01-02"),
+		PeriodEnd:        end.Format("2006-01-02"),
+		CorporateTaxRate: entity.CorporateTaxRate,
+		ByCurrency:       make(map[string]*EntityTaxTotals),
+	}
+
+	totalsFor := func(currency Currency) *EntityTaxTotals {
+		totals, ok := summary.ByCurrency[string(currency)]
+		if !ok {
+			totals = &EntityTaxTotals{
+				Currency: string(currency),
+				Paid:     make(map[EntityTransactionType]float64),
+				Received: make(map[EntityTransactionType]float64),
+			}
+			summary.ByCurrency[string(currency)] = totals
+		}
+		return totals
+	}
+
+	for _, txn := range transactions {
+		day := time.Date(txn.Date.Year(), txn.Date.Month(), txn.Date.Day(), 0, 0, 0, 0, time.UTC)
+		if day.After(end) {
+			continue
+		}
+		paid := txn.FromEntityID == entity.ID
+
+		if entity.Type == EntityTypeCorporation {
+			switch {
+			case paid && txn.Type == EntityTransactionOwnerDraw:
+				totalsFor(txn.Currency).ShareholderLoan += txn.Amount
+			case !paid && txn.Type == EntityTransactionLoanRepayment:
+				totalsFor(txn.Currency).ShareholderLoan -= txn.Amount
+			}
+		}
+		if day.Before(start) {
+			continue
+		}
+
+		totals := totalsFor(txn.Currency)
+		if paid {
+			totals.Paid[txn.Type] += txn.Amount
+			if txn.Type == EntityTransactionSalary && entity.Type != EntityTypePersonal {
+				totals.DeductiblePayments += txn.Amount
+			}
+		} else {
+			totals.Received[txn.Type] += txn.Amount
+			if txn.Type == EntityTransactionSalary || txn.Type == EntityTransactionDividend {
+				totals.TaxableReceipts += txn.Amount
+			}
+		}
+	}
+
+	for _, totals := range summary.ByCurrency {
+		totals.DeductiblePayments = math.Round(totals.DeductiblePayments*100) / 100
+		totals.TaxableReceipts = math.Round(totals.TaxableReceipts*100) / 100
+		totals.ShareholderLoan = math.Round(math.Max(totals.ShareholderLoan, 0)*100) / 100
+	}
+	return summary
+}
+
+// scanEntity scans an entity row
+func scanEntity(row interface{ Scan(...interface{}) error }) (*Entity, error) {
+	entity := &Entity{}
+	err := row.Scan(&entity.ID, &entity.UserID, &entity.Name, &entity.Type, &entity.TaxJurisdiction,
+		&entity.CorporateTaxRate, &entity.FiscalYearEndMonth, &entity.Notes, &entity.CreatedAt, &entity.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan entity: %w", err)
+	}
+	return entity, nil
+}
@@ -0,0 +1,84 @@
+package account
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEntitySummaries_SeparateBusinessAccounts(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-entities-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupAccountService(t, db)
+
+	corp, err := service.CreateEntity(ctx, &CreateEntityRequest{Name: "Holdco Inc.", Type: EntityTypeCorporation})
+	if err != nil {
+		t.Fatalf("CreateEntity failed: %v", err)
+	}
+	personalID := CreateTestAccount(t, db, userID, AccountTypeChecking)
+	CreateTestBalance(t, db, personalID, 1000)
+	businessID := CreateTestAccount(t, db, userID, AccountTypeChecking)
+	CreateTestBalance(t, db, businessID, 25000)
+
+	// Act
+	if _, err := service.AssignAccountEntity(ctx, businessID, &AssignEntityRequest{EntityID: &corp.ID}); err != nil {
+		t.Fatalf("AssignAccountEntity failed: %v", err)
+	}
+	summaries, err := service.GetEntitySummaries(ctx, "")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetEntitySummaries failed: %v", err)
+	}
+	if len(summaries.Entities) != 1 || len(summaries.Entities[0].Accounts) != 1 {
+		t.Fatalf("Expected one entity owning one account, got %+v", summaries.Entities)
+	}
+	if got := summaries.Entities[0].ByCurrency["CAD"].Assets; got != 25000 {
+		t.Errorf("Expected business assets of 25000, got %f", got)
+	}
+	if got := summaries.Unassigned.ByCurrency["CAD"].Assets; got != 1000 {
+		t.Errorf("Expected personal assets of 1000, got %f", got)
+	}
+}
+
+func TestEntityTaxSummary_AppliesTreatmentPerFiscalYear(t *testing.T) {
+	corp := &Entity{ID: "corp", Type: EntityTypeCorporation, FiscalYearEndMonth: 6}
+	person := &Entity{ID: "person", Type: EntityTypePersonal, FiscalYearEndMonth: 12}
+	date := func(s string) Date {
+		d, _ := time.Parse("2006-01-02", s)
+		return Date{Time: d}
+	}
+	transactions := []*EntityTransaction{
+		{FromEntityID: "corp", ToEntityID: "person", Type: EntityTransactionSalary, Amount: 60000, Currency: CurrencyCAD, Date: date("2024-03-31")},
+		{FromEntityID: "corp", ToEntityID: "person", Type: EntityTransactionDividend, Amount: 10000, Currency: CurrencyCAD, Date: date("2024-06-30")},
+		{FromEntityID: "corp", ToEntityID: "person", Type: EntityTransactionOwnerDraw, Amount: 8000, Currency: CurrencyCAD, Date: date("2023-05-01")},
+		{FromEntityID: "person", ToEntityID: "corp", Type: EntityTransactionLoanRepayment, Amount: 3000, Currency: CurrencyCAD, Date: date("2024-01-15")},
+		{FromEntityID: "corp", ToEntityID: "person", Type: EntityTransactionSalary, Amount: 5000, Currency: CurrencyCAD, Date: date("2024-07-15")},
+	}
+
+	// Act
+	corpSummary := entityTaxSummary(corp, 2024, transactions)
+	personSummary := entityTaxSummary(person, 2024, transactions)
+
+	// Assert
+	if corpSummary.PeriodStart != "2023-07-01" || corpSummary.PeriodEnd != "2024-06-30" {
+		t.Errorf("Expected fiscal year 2023-07-01 to 2024-06-30, got %s to %s", corpSummary.PeriodStart, corpSummary.PeriodEnd)
+	}
+	cad := corpSummary.ByCurrency["CAD"]
+	if cad.DeductiblePayments != 60000 {
+		t.Errorf("Expected 60000 of deductible salary, got %f", cad.DeductiblePayments)
+	}
+	if cad.Paid[EntityTransactionDividend] != 10000 {
+		t.Errorf("Expected 10000 of dividends paid, got %f", cad.Paid[EntityTransactionDividend])
+	}
+	if cad.ShareholderLoan != 5000 {
+		t.Errorf("Expected a 5000 shareholder loan, got %f", cad.ShareholderLoan)
+	}
+	if got := personSummary.ByCurrency["CAD"].TaxableReceipts; got != 75000 {
+		t.Errorf("Expected 75000 of taxable salary and dividends, got %f", got)
+	}
+}
@@ -0,0 +1,408 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"money/internal/background"
+	"money/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// equityRecalcInterval is how often queued equity recalculations are processed
+const equityRecalcInterval = 15 * time.Second
+
+// EquityRecalculation is an audit note of the derived amounts a recalculation changed on an
+// exercise or sale after its grant's strike price or the FMV history was edited
+type EquityRecalculation struct {
+	ID         string    `json:"id"`
+	AccountID  string    `json:"account_id"`
+	ExerciseID *string   `json:"exercise_id,omitempty"`
+	SaleID     *string   `json:"sale_id,omitempty"`
+	Reason     string    `json:"reason"`
+	Note       string    `json:"note"` // Old and new values of the changed fields
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// EquityRecalculationsResponse lists an account's recalculation notes, newest first.
+// Pending is set while a recalculation is queued, so clients know amounts are about to change.
+type EquityRecalculationsResponse struct {
+	Recalculations []EquityRecalculation `json:"recalculations"`
+	Pending        bool                  `json:"pending"`
+}
+
+// queueEquityRecalc queues a recalculation of the account's exercises on or after from,
+// within the caller's transaction so it is only queued if the edit commits. The zero date
+// recalculates every exercise.
+func queueEquityRecalc(ctx context.Context, db database.Querier, accountID string, from Date, reason string) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO equity_recalc_queue (account_id, from_date, reason, requested_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (account_id) DO UPDATE SET
+			from_date = MIN(equity_recalc_queue.from_date, excluded.from_date),
+			reason = CASE WHEN instr(equity_recalc_queue.reason, excluded.reason) > 0 THEN equity_recalc_queue.reason
+				ELSE equity_recalc_queue.reason || ', ' || excluded.reason END,
+			requested_at = excluded.requested_at
+	`, accountID, from.Time.Format("2006-01-02"), reason, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to queue equity recalculation: %w", err)
+	}
+	return nil
+}
+
+// GetEquityRecalculations returns the account's recalculation notes
+func (s *Service) GetEquityRecalculations(ctx context.Context, accountID string) (*EquityRecalculationsResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, account_id, exercise_id, sale_id, reason, note, created_at
+		FROM equity_recalculations
+		WHERE account_id = $1
+		ORDER BY created_at DESC
+		LIMIT 200
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get equity recalculations: %w", err)
+	}
+	defer rows.Close()
+
+	response := &EquityRecalculationsResponse{Recalculations: make([]EquityRecalculation, 0)}
+	for rows.Next() {
+		var r EquityRecalculation
+		if err := rows.Scan(&r.ID, &r.AccountID, &r.ExerciseID, &r.SaleID, &r.Reason, &r.Note, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan equity recalculation: %w", err)
+		}
+		response.Recalculations = append(response.Recalculations, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM equity_recalc_queue WHERE account_id = $1)
+	`, accountID).Scan(&response.Pending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get equity recalculation state: %w", err)
+	}
+
+	return response, nil
+}
+
+// ProcessEquityRecalcs recalculates every queued account and returns how many exercises
+// and sales changed. A request queued while its recalculation was running stays queued for
+// the next pass.
+func (s *Service) ProcessEquityRecalcs(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT account_id, from_date, reason, CAST(requested_at AS TEXT) FROM equity_recalc_queue ORDER BY requested_at
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list queued equity recalculations: %w", err)
+	}
+	// requested_at is read back as the stored text so the dequeue matches it exactly
+	type queued struct {
+		accountID, from, reason, requestedAt string
+	}
+	var pending []queued
+	for rows.Next() {
+		var q queued
+		if err := rows.Scan(&q.accountID, &q.from, &q.reason, &q.requestedAt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan queued equity recalculation: %w", err)
+		}
+		pending = append(pending, q)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, q := range pending {
+		if ctx.Err() != nil {
+			break
+		}
+		n, err := s.recalculateEquity(ctx, q.accountID, q.from, q.reason, q.requestedAt)
+		if err != nil {
+			accountLog.Error("Equity recalculation failed", "account_id", q.accountID, "error", err)
+			continue
+		}
+		changed += n
+	}
+	return changed, nil
+}
+
+// StartEquityRecalc processes queued equity recalculations until jobs drains
+func (s *Service) StartEquityRecalc(jobs *background.Group) {
+	jobs.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(equityRecalcInterval)
+		defer ticker.Stop()
+
+		for {
+			jobs.Exclusive(ctx, "equity_recalc", equityRecalcInterval, func(ctx context.Context) {
+				if changed, err := s.ProcessEquityRecalcs(ctx); err != nil {
+					accountLog.Error("Equity recalculation failed", "error", err)
+				} else if changed > 0 {
+					accountLog.Info("Recalculated equity amounts", "count", changed)
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// recalcExercise is the stored state of an exercise a recalculation may change
+type recalcExercise struct {
+	id             string
+	grantID        string
+	date           Date
+	quantity       int
+	strikePrice    float64
+	fmv            float64
+	fmvFromHistory bool
+	cost           float64
+	benefit        float64
+}
+
+// recalculateEquity reprices the account's exercises on or after from against their
+// grant's current strike price and, where the FMV was looked up, the current FMV history.
+// Sales of a repriced exercise whose cost basis still follows its FMV are repriced too.
+// Every change is noted, and the request dequeued, in one transaction.
+func (s *Service) recalculateEquity(ctx context.Context, accountID, from, reason, requestedAt string) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	strikes, currencies, err := optionGrantPrices(ctx, tx, accountID)
+	if err != nil {
+		return 0, err
+	}
+	fmvEntries, err := fmvEntriesOf(ctx, tx, accountID)
+	if err != nil {
+		return 0, err
+	}
+	exercises, err := recalcExercisesOf(ctx, tx, accountID)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	now := time.Now()
+	for _, e := range exercises {
+		strike, ok := strikes[e.grantID]
+		if !ok || e.date.String() < from {
+			continue
+		}
+
+		fmv := e.fmv
+		if e.fmvFromHistory {
+			if current, found := fmvInEffect(fmvEntries, currencies[e.grantID], e.date.Time); found {
+				fmv = current
+			}
+		}
+		cost := float64(e.quantity) * strike
+		benefit := math.Max(float64(e.quantity)*(fmv-strike), 0)
+
+		var notes []string
+		if strike != e.strikePrice {
+			notes = append(notes, fmt.Sprintf("strike price %.4f -> %.4f", e.strikePrice, strike))
+		}
+		if fmv != e.fmv {
+			notes = append(notes, fmt.Sprintf("FMV %.4f -> %.4f", e.fmv, fmv))
+		}
+		if !sameCents(cost, e.cost) {
+			notes = append(notes, fmt.Sprintf("exercise cost %.2f -> %.2f", e.cost, cost))
+		}
+		if !sameCents(benefit, e.benefit) {
+			notes = append(notes, fmt.Sprintf("taxable benefit %.2f -> %.2f", e.benefit, benefit))
+		}
+		if len(notes) == 0 {
+			continue
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			UPDATE equity_exercises
+			SET strike_price = $1, fmv_at_exercise = $2, exercise_cost = $3, taxable_benefit = $4
+			WHERE id = $5
+		`, strike, fmv, cost, benefit, e.id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to recalculate exercise: %w", err)
+		}
+		exerciseID := e.id
+		if err := noteEquityRecalc(ctx, tx, accountID, &exerciseID, nil, reason, strings.Join(notes, ", "), now); err != nil {
+			return 0, err
+		}
+		changed++
+
+		if fmv != e.fmv {
+			n, err := repriceExerciseSales(ctx, tx, accountID, e.id, e.fmv, fmv, reason, now)
+			if err != nil {
+				return 0, err
+			}
+			changed += n
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM equity_recalc_queue WHERE account_id = $1 AND requested_at = $2
+	`, accountID, requestedAt); err != nil {
+		return 0, fmt.Errorf("failed to dequeue equity recalculation: %w", err)
+	}
+
+	return changed, tx.Commit()
+}
+
+// repriceExerciseSales moves the cost basis of an exercise's sales to its new FMV. Sales
+// whose cost basis no longer matches the old FMV were entered by hand and are left alone.
+func repriceExerciseSales(ctx context.Context, db database.Querier, accountID, exerciseID string, oldFMV, newFMV float64, reason string, now time.Time) (int, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, quantity, total_proceeds, cost_basis, capital_gain FROM equity_sales WHERE exercise_id = $1
+	`, exerciseID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get exercise sales: %w", err)
+	}
+	type sale struct {
+		id                               string
+		quantity                         int
+		proceeds, costBasis, capitalGain float64
+	}
+	var sales []sale
+	for rows.Next() {
+		var sl sale
+		if err := rows.Scan(&sl.id, &sl.quantity, &sl.proceeds, &sl.costBasis, &sl.capitalGain); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan exercise sale: %w", err)
+		}
+		sales = append(sales, sl)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, sl := range sales {
+		if !sameCents(sl.costBasis, float64(sl.quantity)*oldFMV) {
+			continue
+		}
+		costBasis := float64(sl.quantity) * newFMV
+		capitalGain := sl.proceeds - costBasis
+		_, err := db.ExecContext(ctx, `
+			UPDATE equity_sales SET cost_basis = $1, capital_gain = $2 WHERE id = $3
+		`, costBasis, capitalGain, sl.id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to recalculate sale: %w", err)
+		}
+		note := fmt.Sprintf("cost basis %.2f -> %.2f, capital gain %.2f -> %.2f", sl.costBasis, costBasis, sl.capitalGain, capitalGain)
+		saleID := sl.id
+		if err := noteEquityRecalc(ctx, db, accountID, &exerciseID, &saleID, reason, note, now); err != nil {
+			return 0, err
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// noteEquityRecalc records the audit note of a recalculated exercise or sale
+func noteEquityRecalc(ctx context.Context, db database.Querier, accountID string, exerciseID, saleID *string, reason, note string, now time.Time) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO equity_recalculations (id, account_id, exercise_id, sale_id, reason, note, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, uuid.New().String(), accountID, exerciseID, saleID, reason, note, now)
+	if err != nil {
+		return fmt.Errorf("failed to record equity recalculation: %w", err)
+	}
+	return nil
+}
+
+// optionGrantPrices returns the strike price and currency of each of the account's option
+// grants that has a strike price
+func optionGrantPrices(ctx context.Context, db database.Querier, accountID string) (map[string]float64, map[string]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, strike_price, currency FROM equity_grants
+		WHERE account_id = $1 AND grant_type IN ('iso', 'nso') AND strike_price IS NOT NULL
+	`, accountID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get option grants: %w", err)
+	}
+	defer rows.Close()
+
+	strikes := make(map[string]float64)
+	currencies := make(map[string]string)
+	for rows.Next() {
+		var id, currency string
+		var strike float64
+		if err := rows.Scan(&id, &strike, &currency); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan option grant: %w", err)
+		}
+		strikes[id] = strike
+		currencies[id] = currency
+	}
+	return strikes, currencies, rows.Err()
+}
+
+// fmvEntriesOf returns the account's FMV history
+func fmvEntriesOf(ctx context.Context, db database.Querier, accountID string) ([]FMVEntry, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT currency, effective_date, fmv_per_share FROM fmv_history WHERE account_id = $1
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get FMV history: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]FMVEntry, 0)
+	for rows.Next() {
+		entry := FMVEntry{AccountID: accountID}
+		if err := rows.Scan(&entry.Currency, &entry.EffectiveDate, &entry.FMVPerShare); err != nil {
+			return nil, fmt.Errorf("failed to scan FMV entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// recalcExercisesOf returns the stored amounts of the account's exercises
+func recalcExercisesOf(ctx context.Context, db database.Querier, accountID string) ([]recalcExercise, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT e.id, e.grant_id, e.exercise_date, e.quantity, e.strike_price, e.fmv_at_exercise,
+			e.fmv_from_history, e.exercise_cost, e.taxable_benefit
+		FROM equity_exercises e
+		JOIN equity_grants g ON g.id = e.grant_id
+		WHERE g.account_id = $1
+		ORDER BY e.exercise_date
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exercises: %w", err)
+	}
+	defer rows.Close()
+
+	var exercises []recalcExercise
+	for rows.Next() {
+		var e recalcExercise
+		err := rows.Scan(&e.id, &e.grantID, &e.date, &e.quantity, &e.strikePrice, &e.fmv,
+			&e.fmvFromHistory, &e.cost, &e.benefit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan exercise: %w", err)
+		}
+		exercises = append(exercises, e)
+	}
+	return exercises, rows.Err()
+}
+
+// sameCents reports whether two amounts agree to the cent
+func sameCents(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
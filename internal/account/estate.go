@@ -0,0 +1,418 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/pdf"
+)
+
+// Beneficiary is a person or organization designated to receive an account
+type Beneficiary struct {
+	Name         string   `json:"name"`
+	Relationship string   `json:"relationship,omitempty"`
+	Percentage   *float64 `json:"percentage,omitempty"` // Share of the account, 0-100
+	Contingent   bool     `json:"contingent"`           // Receives only if the primary beneficiaries can't
+}
+
+// EstateDetails holds the registration and beneficiary designations of an account
+type EstateDetails struct {
+	AccountID        string        `json:"account_id"`
+	IsRegistered     bool          `json:"is_registered"`
+	RegistrationType *string       `json:"registration_type,omitempty"` // e.g. RRSP, TFSA, 401(k)
+	Beneficiaries    []Beneficiary `json:"beneficiaries"`
+	SuccessorHolder  *string       `json:"successor_holder,omitempty"`
+	Notes            *string       `json:"notes,omitempty"`
+	UpdatedAt        *time.Time    `json:"updated_at,omitempty"`
+}
+
+// SetEstateDetailsRequest represents the request to set an account's estate designations
+type SetEstateDetailsRequest struct {
+	IsRegistered     bool          `json:"is_registered"`
+	RegistrationType *string       `json:"registration_type,omitempty"`
+	Beneficiaries    []Beneficiary `json:"beneficiaries"`
+	SuccessorHolder  *string       `json:"successor_holder,omitempty"`
+	Notes            *string       `json:"notes,omitempty"`
+}
+
+// EstateConnection describes the sync connection an account is kept up to date by
+type EstateConnection struct {
+	Provider   string     `json:"provider"`
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	LastSyncAt *time.Time `json:"last_sync_at,omitempty"`
+}
+
+// EstateAccount is one account in the estate report
+type EstateAccount struct {
+	AccountID        string            `json:"account_id"`
+	Name             string            `json:"name"`
+	Type             AccountType       `json:"type"`
+	Institution      *string           `json:"institution,omitempty"`
+	Currency         Currency          `json:"currency"`
+	ApproximateValue *float64          `json:"approximate_value,omitempty"`
+	ValueAsOf        *string           `json:"value_as_of,omitempty"`
+	ValueMasked      bool              `json:"value_masked,omitempty"` // Private account in privacy mode
+	Owner            *string           `json:"owner,omitempty"`        // Owning entity, nil when held personally
+	Estate           EstateDetails     `json:"estate"`
+	Connection       *EstateConnection `json:"connection,omitempty"`
+}
+
+// EstateReport lists every account with what an executor needs to locate and settle it
+type EstateReport struct {
+	GeneratedAt time.Time                          `json:"generated_at"`
+	Accounts    []EstateAccount                    `json:"accounts"`
+	ByCurrency  map[string]*CurrencyBalanceSummary `json:"by_currency"`
+	Warnings    []string                           `json:"warnings"` // e.g. registered accounts without a beneficiary
+}
+
+// validateEstateDetails checks beneficiary designations
+func validateEstateDetails(req *SetEstateDetailsRequest) error {
+	primaryTotal := 0.0
+	hasPercentages := false
+	for _, b := range req.Beneficiaries {
+		if strings.TrimSpace(b.Name) == "" {
+			return fmt.Errorf("beneficiary name is required")
+		}
+		if b.Percentage == nil {
+			continue
+		}
+		if *b.Percentage <= 0 || *b.Percentage > 100 {
+			return fmt.Errorf("beneficiary percentage must be between 0 and 100")
+		}
+		if !b.Contingent {
+			primaryTotal += *b.Percentage
+			hasPercentages = true
+		}
+	}
+	if hasPercentages && math.Abs(primaryTotal-100) > 0.01 {
+		return fmt.Errorf("primary beneficiary percentages must add up to 100, got %.2f", primaryTotal)
+	}
+	return nil
+}
+
+// SetEstateDetails records an account's registration and beneficiary designations
+func (s *Service) SetEstateDetails(ctx context.Context, accountID string, req *SetEstateDetailsRequest) (*EstateDetails, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+	if err := validateEstateDetails(req); err != nil {
+		return nil, err
+	}
+
+	beneficiaries := req.Beneficiaries
+	if beneficiaries == nil {
+		beneficiaries = []Beneficiary{}
+	}
+	beneficiariesJSON, err := json.Marshal(beneficiaries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal beneficiaries: %w", err)
+	}
+
+	now := time.Now()
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO account_estate_details (account_id, is_registered, registration_type, beneficiaries,
+			successor_holder, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		ON CONFLICT (account_id) DO UPDATE SET
+			is_registered = excluded.is_registered,
+			registration_type = excluded.registration_type,
+			beneficiaries = excluded.beneficiaries,
+			successor_holder = excluded.successor_holder,
+			notes = excluded.notes,
+			updated_at = excluded.updated_at
+	`, accountID, req.IsRegistered, req.RegistrationType, string(beneficiariesJSON), req.SuccessorHolder, req.Notes, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save estate details: %w", err)
+	}
+
+	return &EstateDetails{
+		AccountID:        accountID,
+		IsRegistered:     req.IsRegistered,
+		RegistrationType: req.RegistrationType,
+		Beneficiaries:    beneficiaries,
+		SuccessorHolder:  req.SuccessorHolder,
+		Notes:            req.Notes,
+		UpdatedAt:        &now,
+	}, nil
+}
+
+// GetEstateDetails returns an account's estate designations, empty when none were recorded
+func (s *Service) GetEstateDetails(ctx context.Context, accountID string) (*EstateDetails, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	details, err := s.estateDetails(ctx, []string{accountID})
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := details[accountID]; ok {
+		return d, nil
+	}
+	return &EstateDetails{AccountID: accountID, Beneficiaries: []Beneficiary{}}, nil
+}
+
+// estateDetails loads the estate designations recorded for the given accounts
+func (s *Service) estateDetails(ctx context.Context, accountIDs []string) (map[string]*EstateDetails, error) {
+	details := make(map[string]*EstateDetails, len(accountIDs))
+	if len(accountIDs) == 0 {
+		return details, nil
+	}
+
+	placeholders := make([]string, len(accountIDs))
+	args := make([]interface{}, len(accountIDs))
+	for i, id := range accountIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT account_id, is_registered, registration_type, beneficiaries, successor_holder, notes, updated_at
+		FROM account_estate_details
+		WHERE account_id IN (%s)
+	`, strings.Join(placeholders, ", ")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get estate details: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		d := &EstateDetails{}
+		var registrationType, successorHolder, notes sql.NullString
+		var beneficiariesJSON string
+		var updatedAt time.Time
+		if err := rows.Scan(&d.AccountID, &d.IsRegistered, &registrationType, &beneficiariesJSON,
+			&successorHolder, &notes, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan estate details: %w", err)
+		}
+		if err := json.Unmarshal([]byte(beneficiariesJSON), &d.Beneficiaries); err != nil {
+			return nil, fmt.Errorf("failed to parse beneficiaries: %w", err)
+		}
+		if registrationType.Valid {
+			d.RegistrationType = &registrationType.String
+		}
+		if successorHolder.Valid {
+			d.SuccessorHolder = &successorHolder.String
+		}
+		if notes.Valid {
+			d.Notes = &notes.String
+		}
+		d.UpdatedAt = &updatedAt
+		details[d.AccountID] = d
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read estate details: %w", err)
+	}
+
+	return details, nil
+}
+
+// estateConnections returns the sync connection of each synced account the user owns
+func (s *Service) estateConnections(ctx context.Context, userID string) (map[string]*EstateConnection, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT sa.local_account_id, c.provider, c.name, c.status, COALESCE(sa.last_sync_at, c.last_sync_at)
+		FROM synced_accounts sa
+		JOIN sync_credentials c ON c.id = sa.credential_id
+		WHERE c.user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync connections: %w", err)
+	}
+	defer rows.Close()
+
+	connections := make(map[string]*EstateConnection)
+	for rows.Next() {
+		var accountID string
+		conn := &EstateConnection{}
+		var lastSyncAt sql.NullTime
+		if err := rows.Scan(&accountID, &conn.Provider, &conn.Name, &conn.Status, &lastSyncAt); err != nil {
+			return nil, fmt.Errorf("failed to scan sync connection: %w", err)
+		}
+		if lastSyncAt.Valid {
+			conn.LastSyncAt = &lastSyncAt.Time
+		}
+		connections[accountID] = conn
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read sync connections: %w", err)
+	}
+
+	return connections, nil
+}
+
+// GetEstateReport lists every active account with its institution, latest value, estate
+// designations and sync connection, for an executor settling the estate
+func (s *Service) GetEstateReport(ctx context.Context) (*EstateReport, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	accounts, err := s.ListWithBalance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entities, err := s.ListEntities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entityNames := make(map[string]string, len(entities.Entities))
+	for _, entity := range entities.Entities {
+		entityNames[entity.ID] = entity.Name
+	}
+
+	accountIDs := make([]string, len(accounts.Accounts))
+	for i, acc := range accounts.Accounts {
+		accountIDs[i] = acc.ID
+	}
+	details, err := s.estateDetails(ctx, accountIDs)
+	if err != nil {
+		return nil, err
+	}
+	connections, err := s.estateConnections(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &EstateReport{
+		GeneratedAt: time.Now(),
+		Accounts:    make([]EstateAccount, 0, len(accounts.Accounts)),
+		ByCurrency:  accounts.ByCurrency,
+		Warnings:    make([]string, 0),
+	}
+	for _, acc := range accounts.Accounts {
+		entry := EstateAccount{
+			AccountID:        acc.ID,
+			Name:             acc.Name,
+			Type:             acc.Type,
+			Institution:      acc.Institution,
+			Currency:         acc.Currency,
+			ApproximateValue: acc.CurrentBalance,
+			ValueAsOf:        acc.BalanceDate,
+			ValueMasked:      acc.BalanceMasked,
+			Connection:       connections[acc.ID],
+			Estate:           EstateDetails{AccountID: acc.ID, Beneficiaries: []Beneficiary{}},
+		}
+		if acc.EntityID != nil {
+			if name, ok := entityNames[*acc.EntityID]; ok {
+				entry.Owner = &name
+			}
+		}
+		if d, ok := details[acc.ID]; ok {
+			entry.Estate = *d
+		}
+		if entry.Estate.IsRegistered && len(entry.Estate.Beneficiaries) == 0 && entry.Estate.SuccessorHolder == nil {
+			report.Warnings = append(report.Warnings,
+				fmt.Sprintf("%s is a registered account with no beneficiary or successor holder", acc.Name))
+		}
+		report.Accounts = append(report.Accounts, entry)
+	}
+	sort.SliceStable(report.Accounts, func(i, j int) bool {
+		return institutionName(report.Accounts[i].Institution) < institutionName(report.Accounts[j].Institution)
+	})
+
+	return report, nil
+}
+
+// institutionName returns an account's institution, or a placeholder when it isn't set
+func institutionName(institution *string) string {
+	if institution == nil || *institution == "" {
+		return "Unspecified institution"
+	}
+	return *institution
+}
+
+// RenderEstateReportPDF renders the estate report as a printable PDF for an executor
+func RenderEstateReportPDF(report *EstateReport) []byte {
+	doc := pdf.New("Estate Summary")
+	doc.Text(fmt.Sprintf("Generated %s. Values are the latest recorded balances and are approximate.",
+		report.GeneratedAt.Format("January 2, 2006")))
+
+	if len(report.Warnings) > 0 {
+		doc.Heading("Needs attention")
+		for _, warning := range report.Warnings {
+			doc.Text("- " + warning)
+		}
+	}
+
+	currencies := make([]string, 0, len(report.ByCurrency))
+	for currency := range report.ByCurrency {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	doc.Heading("Totals")
+	for _, currency := range currencies {
+		totals := report.ByCurrency[currency]
+		doc.Field(currency, fmt.Sprintf("assets %.2f, liabilities %.2f, net %.2f",
+			totals.Assets, totals.Liabilities, totals.NetWorth))
+	}
+
+	for _, acc := range report.Accounts {
+		doc.Heading(acc.Name)
+		doc.Field("Institution", institutionName(acc.Institution))
+		doc.Field("Type", string(acc.Type))
+		if acc.ApproximateValue != nil {
+			value := fmt.Sprintf("%.2f %s", *acc.ApproximateValue, acc.Currency)
+			if acc.ValueAsOf != nil && len(*acc.ValueAsOf) >= 10 {
+				value += " as of " + (*acc.ValueAsOf)[:10]
+			}
+			doc.Field("Approximate value", value)
+		} else if acc.ValueMasked {
+			doc.Field("Approximate value", "private")
+		} else {
+			doc.Field("Approximate value", "not recorded")
+		}
+		if acc.Owner != nil {
+			doc.Field("Owned by", *acc.Owner)
+		}
+
+		registration := "No"
+		if acc.Estate.IsRegistered {
+			registration = "Yes"
+			if acc.Estate.RegistrationType != nil {
+				registration += " (" + *acc.Estate.RegistrationType + ")"
+			}
+		}
+		doc.Field("Registered", registration)
+		if acc.Estate.SuccessorHolder != nil {
+			doc.Field("Successor holder", *acc.Estate.SuccessorHolder)
+		}
+		if len(acc.Estate.Beneficiaries) == 0 {
+			doc.Field("Beneficiaries", "none designated")
+		}
+		for _, b := range acc.Estate.Beneficiaries {
+			label := "Beneficiary"
+			if b.Contingent {
+				label = "Contingent beneficiary"
+			}
+			value := b.Name
+			if b.Relationship != "" {
+				value += ", " + b.Relationship
+			}
+			if b.Percentage != nil {
+				value += fmt.Sprintf(" (%.0f%%)", *b.Percentage)
+			}
+			doc.Field(label, value)
+		}
+		if acc.Connection != nil {
+			connection := fmt.Sprintf("%s via %s, %s", acc.Connection.Name, acc.Connection.Provider, acc.Connection.Status)
+			if acc.Connection.LastSyncAt != nil {
+				connection += ", last synced " + acc.Connection.LastSyncAt.Format("2006-01-02")
+			}
+			doc.Field("Synced connection", connection)
+		}
+		if acc.Estate.Notes != nil {
+			doc.Field("Notes", *acc.Estate.Notes)
+		}
+	}
+
+	return doc.Bytes()
+}
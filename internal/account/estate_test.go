@@ -0,0 +1,88 @@
+package account
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSetEstateDetails_ValidatesPrimaryPercentages(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-estate-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeBrokerage)
+	service := SetupAccountService(t, db)
+	sixty, thirty, hundred := 60.0, 30.0, 100.0
+
+	// Act
+	_, shortErr := service.SetEstateDetails(ctx, accountID, &SetEstateDetailsRequest{
+		Beneficiaries: []Beneficiary{{Name: "Alex", Percentage: &sixty}, {Name: "Sam", Percentage: &thirty}},
+	})
+	details, err := service.SetEstateDetails(ctx, accountID, &SetEstateDetailsRequest{
+		Beneficiaries: []Beneficiary{{Name: "Alex", Percentage: &hundred}, {Name: "Sam", Percentage: &hundred, Contingent: true}},
+	})
+
+	// Assert
+	if shortErr == nil {
+		t.Error("Expected error when primary percentages don't add up to 100")
+	}
+	if err != nil {
+		t.Fatalf("SetEstateDetails failed: %v", err)
+	}
+	stored, err := service.GetEstateDetails(ctx, accountID)
+	if err != nil {
+		t.Fatalf("GetEstateDetails failed: %v", err)
+	}
+	if len(details.Beneficiaries) != 2 || len(stored.Beneficiaries) != 2 || !stored.Beneficiaries[1].Contingent {
+		t.Errorf("Expected primary and contingent beneficiaries to be stored, got %+v", stored.Beneficiaries)
+	}
+}
+
+func TestGetEstateReport_IncludesDesignationsAndWarnings(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-estate-2"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	registeredID := CreateTestAccount(t, db, userID, AccountTypeBrokerage)
+	designatedID := CreateTestAccount(t, db, userID, AccountTypeBrokerage)
+	service := SetupAccountService(t, db)
+	registrationType := "RRSP"
+	if _, err := service.SetEstateDetails(ctx, registeredID, &SetEstateDetailsRequest{
+		IsRegistered: true, RegistrationType: &registrationType,
+	}); err != nil {
+		t.Fatalf("SetEstateDetails failed: %v", err)
+	}
+	if _, err := service.SetEstateDetails(ctx, designatedID, &SetEstateDetailsRequest{
+		IsRegistered: true, Beneficiaries: []Beneficiary{{Name: "Alex", Relationship: "spouse"}},
+	}); err != nil {
+		t.Fatalf("SetEstateDetails failed: %v", err)
+	}
+
+	// Act
+	report, err := service.GetEstateReport(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetEstateReport failed: %v", err)
+	}
+	if len(report.Accounts) != 2 {
+		t.Fatalf("Expected 2 accounts, got %d", len(report.Accounts))
+	}
+	if len(report.Warnings) != 1 {
+		t.Errorf("Expected 1 warning for the registered account without a beneficiary, got %v", report.Warnings)
+	}
+	for _, acc := range report.Accounts {
+		if acc.AccountID == designatedID && (len(acc.Estate.Beneficiaries) != 1 || acc.Estate.Beneficiaries[0].Name != "Alex") {
+			t.Errorf("Expected Alex as beneficiary, got %+v", acc.Estate.Beneficiaries)
+		}
+	}
+	if content := RenderEstateReportPDF(report); !bytes.HasPrefix(content, []byte("%PDF-")) {
+		t.Error("Expected the report to render as a PDF")
+	}
+}
@@ -0,0 +1,76 @@
+package account
+
+import (
+	"fmt"
+	"math"
+)
+
+// ExerciseSettlement breaks down how an exercise is paid for under its method. Cash exercises
+// pay the strike and withholding out of pocket. Cashless exercises surrender just enough
+// shares to cover both and keep the rest. Same-day sales sell every share and pay out what is
+// left after the strike and withholding.
+type ExerciseSettlement struct {
+	Method         ExerciseMethod `json:"method"`
+	ExerciseCost   float64        `json:"exercise_cost"`
+	WithholdingTax float64        `json:"withholding_tax"`
+	SharesSold     int            `json:"shares_sold"` // Surrendered or sold at the FMV
+	NetShares      int            `json:"net_shares"`  // Shares kept
+	SaleProceeds   float64        `json:"sale_proceeds"`
+	CashRequired   float64        `json:"cash_required"` // Paid out of pocket
+	CashReceived   float64        `json:"cash_received"` // Proceeds left after the strike and withholding
+}
+
+// validateWithholdingRate checks a withholding rate is a fraction of the taxable benefit
+func validateWithholdingRate(rate *float64) error {
+	if rate != nil && (*rate < 0 || *rate >= 1) {
+		return fmt.Errorf("withholding_rate must be at least 0 and below 1")
+	}
+	return nil
+}
+
+// SettleExercise works out the shares sold, shares kept and cash flows of exercising quantity
+// options at the strike price when the shares are worth fmv. A missing method is a cash
+// exercise. Shares are sold whole, so a cashless exercise rounds the shares surrendered up
+// and pays out the remainder.
+func SettleExercise(method *ExerciseMethod, quantity int, strikePrice, fmv, withholdingTax float64) ExerciseSettlement {
+	settlement := ExerciseSettlement{
+		Method:         ExerciseMethodCash,
+		ExerciseCost:   roundCents(float64(quantity) * strikePrice),
+		WithholdingTax: roundCents(withholdingTax),
+		NetShares:      quantity,
+	}
+	if method != nil {
+		settlement.Method = *method
+	}
+	owed := settlement.ExerciseCost + settlement.WithholdingTax
+
+	switch settlement.Method {
+	case ExerciseMethodCashless:
+		if fmv > 0 {
+			settlement.SharesSold = int(math.Ceil(owed/fmv - 1e-9))
+		}
+		if settlement.SharesSold > quantity || fmv <= 0 {
+			settlement.SharesSold = quantity
+		}
+	case ExerciseMethodSameDaySale:
+		settlement.SharesSold = quantity
+	}
+
+	settlement.NetShares = quantity - settlement.SharesSold
+	settlement.SaleProceeds = roundCents(float64(settlement.SharesSold) * fmv)
+	if net := settlement.SaleProceeds - owed; net >= 0 {
+		settlement.CashReceived = roundCents(net)
+	} else {
+		settlement.CashRequired = roundCents(-net)
+	}
+	return settlement
+}
+
+// settle fills in an exercise's settlement from its method and withholding rate
+func (e *EquityExercise) settle() {
+	withholdingTax := 0.0
+	if e.WithholdingRate != nil {
+		withholdingTax = e.TaxableBenefit * *e.WithholdingRate
+	}
+	e.Settlement = SettleExercise(e.ExerciseMethod, e.Quantity, e.StrikePrice, e.FMVAtExercise, withholdingTax)
+}
@@ -0,0 +1,115 @@
+package account
+
+import (
+	"context"
+	"sort"
+
+	"money/internal/civil"
+)
+
+// FMVChartPoint is the FMV in force and the shares vested so far on a date where either
+// changed
+type FMVChartPoint struct {
+	Date         Date     `json:"date"`
+	FMVPerShare  *float64 `json:"fmv_per_share,omitempty"` // Nil before the first FMV entry
+	VestedShares int      `json:"vested_shares"`           // Cumulative, across grants in the currency
+	SharesVested int      `json:"shares_vested"`           // Vesting on this date
+	VestedValue  float64  `json:"vested_value"`
+}
+
+// FMVChartSeries is the FMV and vesting history of the grants in one currency
+type FMVChartSeries struct {
+	Currency string          `json:"currency"`
+	Points   []FMVChartPoint `json:"points"`
+}
+
+// FMVChartResponse charts equity value growth per currency
+type FMVChartResponse struct {
+	AccountID string           `json:"account_id"`
+	Series    []FMVChartSeries `json:"series"`
+}
+
+// GetFMVChart returns, per currency, a point for every FMV change and vest date up to today
+// with the FMV in force and the cumulative shares vested, ending with today's point
+func (s *Service) GetFMVChart(ctx context.Context, accountID string) (*FMVChartResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	data, err := s.loadOptionsData(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildFMVChart(accountID, data, civil.TodayIn(ctx)), nil
+}
+
+// buildFMVChart joins an account's FMV history with its vesting events as of today
+func buildFMVChart(accountID string, data *optionsData, today Date) *FMVChartResponse {
+	// Shares vesting per currency and date
+	vests := make(map[string]map[Date]int)
+	dates := make(map[string]map[Date]bool)
+	addDate := func(currency string, date Date) {
+		if dates[currency] == nil {
+			dates[currency] = make(map[Date]bool)
+		}
+		dates[currency][date] = true
+	}
+
+	for _, entry := range data.fmvEntries {
+		if !entry.EffectiveDate.After(today.Time) {
+			addDate(entry.Currency, civil.DateOf(entry.EffectiveDate.Time))
+		}
+	}
+	for _, grant := range data.grants {
+		schedule, ok := data.schedules[grant.ID]
+		if !ok {
+			continue
+		}
+		currency := grant.Currency
+		if currency == "" {
+			currency = "USD"
+		}
+		for _, event := range computeVestingEventsAsOf(&grant, schedule, today.Time) {
+			if event.Status != VestingStatusVested {
+				continue
+			}
+			date := civil.DateOf(event.VestDate.Time)
+			if vests[currency] == nil {
+				vests[currency] = make(map[Date]int)
+			}
+			vests[currency][date] += event.Quantity
+			addDate(currency, date)
+		}
+	}
+
+	resp := &FMVChartResponse{
+		AccountID: accountID,
+		Series:    make([]FMVChartSeries, 0, len(dates)),
+	}
+	for currency, set := range dates {
+		set[today] = true
+		sorted := make([]Date, 0, len(set))
+		for date := range set {
+			sorted = append(sorted, date)
+		}
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j].Time) })
+
+		series := FMVChartSeries{Currency: currency, Points: make([]FMVChartPoint, 0, len(sorted))}
+		vested := 0
+		for _, date := range sorted {
+			point := FMVChartPoint{Date: date, SharesVested: vests[currency][date]}
+			vested += point.SharesVested
+			point.VestedShares = vested
+			if fmv, ok := fmvInEffect(data.fmvEntries, currency, date.Time); ok {
+				point.FMVPerShare = &fmv
+				point.VestedValue = roundCents(float64(vested) * fmv)
+			}
+			series.Points = append(series.Points, point)
+		}
+		resp.Series = append(resp.Series, series)
+	}
+
+	sort.Slice(resp.Series, func(i, j int) bool { return resp.Series[i].Currency < resp.Series[j].Currency })
+	return resp
+}
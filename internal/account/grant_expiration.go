@@ -0,0 +1,317 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/background"
+	"money/internal/civil"
+
+	"github.com/google/uuid"
+)
+
+// grantExpirationInterval is how often expired option grants are checked for
+const grantExpirationInterval = 24 * time.Hour
+
+// GrantExpiryReminderDays are how many days ahead of expiry reminders are raised, widest first
+var GrantExpiryReminderDays = []int{90, 30, 7}
+
+// GrantExpiration records the options of a grant that expired unexercised
+type GrantExpiration struct {
+	ID                 string    `json:"id"`
+	GrantID            string    `json:"grant_id"`
+	CompanyName        string    `json:"company_name"`
+	GrantType          GrantType `json:"grant_type"`
+	ExpirationDate     Date      `json:"expiration_date"`
+	SharesExpired      int       `json:"shares_expired"`
+	FMVAtExpiration    *float64  `json:"fmv_at_expiration,omitempty"`
+	IntrinsicValueLost float64   `json:"intrinsic_value_lost"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// GrantExpirationsResponse represents a list of grant expirations
+type GrantExpirationsResponse struct {
+	Expirations []GrantExpiration `json:"expirations"`
+}
+
+// GrantExpiryAlert describes vested options that expire soon unless exercised
+type GrantExpiryAlert struct {
+	GrantID           string    `json:"grant_id"`
+	AccountID         string    `json:"account_id"`
+	CompanyName       string    `json:"company_name"`
+	GrantType         GrantType `json:"grant_type"`
+	ExpirationDate    Date      `json:"expiration_date"`
+	DaysRemaining     int       `json:"days_remaining"`
+	ReminderDays      int       `json:"reminder_days"` // The narrowest reminder window the expiry falls in
+	UnexercisedShares int       `json:"unexercised_shares"`
+	IntrinsicValue    float64   `json:"intrinsic_value"`
+}
+
+// GrantExpiryAlertsResponse represents a list of grant expiry alerts
+type GrantExpiryAlertsResponse struct {
+	Alerts []GrantExpiryAlert `json:"alerts"`
+}
+
+// isExpiredOn reports whether an option grant has passed its expiration date. Options can
+// still be exercised on the expiration date itself.
+func (g *EquityGrant) isExpiredOn(date time.Time) bool {
+	if g.GrantType != GrantTypeISO && g.GrantType != GrantTypeNSO {
+		return false
+	}
+	return g.ExpirationDate != nil && date.After(g.ExpirationDate.Time)
+}
+
+// grantOnExpiry returns a grant's summary on its expiration date, the last day it can be exercised
+func grantOnExpiry(data *optionsData, grant *EquityGrant) *EquityGrantWithSummary {
+	summary := summarizeOptions(data, grant.ExpirationDate.Time)
+	for i := range summary.Grants {
+		if summary.Grants[i].ID == grant.ID {
+			return &summary.Grants[i]
+		}
+	}
+	return nil
+}
+
+// stockOptionAccountIDs returns the user's active stock option accounts
+func (s *Service) stockOptionAccountIDs(ctx context.Context) ([]string, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id FROM accounts WHERE user_id = $1 AND type = $2 AND is_active = 1
+	`, userID, AccountTypeStockOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock option accounts: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetExpiringGrants returns option grants across the user's accounts that expire within the
+// widest reminder window with vested shares still unexercised on the expiration date
+func (s *Service) GetExpiringGrants(ctx context.Context) (*GrantExpiryAlertsResponse, error) {
+	accountIDs, err := s.stockOptionAccountIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	today := civil.TodayIn(ctx)
+	alerts := make([]GrantExpiryAlert, 0)
+	for _, accountID := range accountIDs {
+		data, err := s.loadOptionsData(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, grantExpiryAlerts(accountID, data, today)...)
+	}
+
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].ExpirationDate.Before(alerts[j].ExpirationDate.Time)
+	})
+	return &GrantExpiryAlertsResponse{Alerts: alerts}, nil
+}
+
+// grantExpiryAlerts finds an account's option grants expiring within the reminder windows
+func grantExpiryAlerts(accountID string, data *optionsData, today Date) []GrantExpiryAlert {
+	alerts := make([]GrantExpiryAlert, 0)
+	for i := range data.grants {
+		grant := &data.grants[i]
+		if grant.GrantType != GrantTypeISO && grant.GrantType != GrantTypeNSO || grant.ExpirationDate == nil {
+			continue
+		}
+
+		days := int(grant.ExpirationDate.Time.Sub(today.Time).Hours() / 24)
+		if days < 0 || days > GrantExpiryReminderDays[0] {
+			continue
+		}
+
+		onExpiry := grantOnExpiry(data, grant)
+		if onExpiry == nil || onExpiry.VestedQuantity-onExpiry.ExercisedQuantity <= 0 {
+			continue
+		}
+
+		alert := GrantExpiryAlert{
+			GrantID:           grant.ID,
+			AccountID:         accountID,
+			CompanyName:       grant.CompanyName,
+			GrantType:         grant.GrantType,
+			ExpirationDate:    *grant.ExpirationDate,
+			DaysRemaining:     days,
+			UnexercisedShares: onExpiry.VestedQuantity - onExpiry.ExercisedQuantity,
+			IntrinsicValue:    roundCents(onExpiry.IntrinsicValue),
+		}
+		for _, window := range GrantExpiryReminderDays {
+			if days <= window {
+				alert.ReminderDays = window
+			}
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+// ProcessGrantExpirations records an expiration for each option grant of an account that has
+// passed its expiration date and has none yet. Returns the expirations recorded.
+func (s *Service) ProcessGrantExpirations(ctx context.Context, accountID string) (*GrantExpirationsResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	data, err := s.loadOptionsData(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	today := civil.TodayIn(ctx).Time
+	expirations := make([]GrantExpiration, 0)
+	for i := range data.grants {
+		grant := &data.grants[i]
+		if !grant.isExpiredOn(today) {
+			continue
+		}
+		onExpiry := grantOnExpiry(data, grant)
+		if onExpiry == nil {
+			continue
+		}
+
+		expiration := GrantExpiration{
+			ID:                 uuid.New().String(),
+			GrantID:            grant.ID,
+			CompanyName:        grant.CompanyName,
+			GrantType:          grant.GrantType,
+			ExpirationDate:     *grant.ExpirationDate,
+			SharesExpired:      onExpiry.VestedQuantity - onExpiry.ExercisedQuantity,
+			FMVAtExpiration:    onExpiry.CurrentFMV,
+			IntrinsicValueLost: roundCents(onExpiry.IntrinsicValue),
+			CreatedAt:          time.Now(),
+		}
+		result, err := s.db.ExecContext(ctx, `
+			INSERT INTO equity_grant_expirations (id, grant_id, expiration_date, shares_expired,
+				fmv_at_expiration, intrinsic_value_lost, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (grant_id) DO NOTHING
+		`, expiration.ID, expiration.GrantID, expiration.ExpirationDate, expiration.SharesExpired,
+			expiration.FMVAtExpiration, expiration.IntrinsicValueLost, expiration.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record grant expiration: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			expirations = append(expirations, expiration)
+		}
+	}
+
+	return &GrantExpirationsResponse{Expirations: expirations}, nil
+}
+
+// GetGrantExpirations lists the recorded expirations of an account's option grants
+func (s *Service) GetGrantExpirations(ctx context.Context, accountID string) (*GrantExpirationsResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT x.id, x.grant_id, g.company_name, g.grant_type, x.expiration_date, x.shares_expired,
+			x.fmv_at_expiration, x.intrinsic_value_lost, x.created_at
+		FROM equity_grant_expirations x
+		JOIN equity_grants g ON g.id = x.grant_id
+		WHERE g.account_id = $1
+		ORDER BY x.expiration_date DESC
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grant expirations: %w", err)
+	}
+	defer rows.Close()
+
+	expirations := make([]GrantExpiration, 0)
+	for rows.Next() {
+		var x GrantExpiration
+		if err := rows.Scan(&x.ID, &x.GrantID, &x.CompanyName, &x.GrantType, &x.ExpirationDate, &x.SharesExpired,
+			&x.FMVAtExpiration, &x.IntrinsicValueLost, &x.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan grant expiration: %w", err)
+		}
+		expirations = append(expirations, x)
+	}
+
+	return &GrantExpirationsResponse{Expirations: expirations}, rows.Err()
+}
+
+// RecordDueGrantExpirations records expirations for every account with an option grant past
+// its expiration date and not yet recorded. Failures are logged per account.
+func (s *Service) RecordDueGrantExpirations(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT eg.account_id, a.user_id
+		FROM equity_grants eg
+		JOIN accounts a ON a.id = eg.account_id
+		LEFT JOIN equity_grant_expirations x ON x.grant_id = eg.id
+		WHERE eg.grant_type IN ('iso', 'nso') AND eg.expiration_date IS NOT NULL
+			AND eg.expiration_date < $1 AND x.id IS NULL AND a.is_active
+	`, civil.TodayIn(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expired grants: %w", err)
+	}
+
+	type expiredAccount struct {
+		accountID string
+		userID    string
+	}
+	var accounts []expiredAccount
+	for rows.Next() {
+		var a expiredAccount
+		if err := rows.Scan(&a.accountID, &a.userID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan expired grant account: %w", err)
+		}
+		accounts = append(accounts, a)
+	}
+	rows.Close()
+
+	recorded := 0
+	for _, a := range accounts {
+		expirations, err := s.ProcessGrantExpirations(auth.WithUserID(ctx, a.userID), a.accountID)
+		if err != nil {
+			accountLog.Error("Grant expiration failed", "account_id", a.accountID, "error", err)
+			continue
+		}
+		recorded += len(expirations.Expirations)
+	}
+
+	return recorded, nil
+}
+
+// StartGrantExpirations records expired option grants daily until jobs drains
+func (s *Service) StartGrantExpirations(jobs *background.Group) {
+	jobs.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(grantExpirationInterval)
+		defer ticker.Stop()
+
+		for {
+			jobs.Exclusive(ctx, "grant_expirations", grantExpirationInterval, func(ctx context.Context) {
+				if recorded, err := s.RecordDueGrantExpirations(ctx); err != nil {
+					accountLog.Error("Grant expiration failed", "error", err)
+				} else if recorded > 0 {
+					accountLog.Info("Recorded grant expirations", "count", recorded)
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// maxBulkGrants caps how many grants can be created in one request
+const maxBulkGrants = 50
+
+// ErrBulkGrantsInvalid is returned when one or more grants in a bulk request fail validation
+var ErrBulkGrantsInvalid = errors.New("one or more grants are invalid")
+
+// BulkGrantInput is a grant with its vesting schedule and any historical exercises
+type BulkGrantInput struct {
+	CreateEquityGrantRequest
+	VestingSchedule *SetVestingScheduleRequest `json:"vesting_schedule,omitempty"`
+	Exercises       []RecordExerciseRequest    `json:"exercises,omitempty"`
+}
+
+// BulkCreateGrantsRequest represents a request to create several grants at once
+type BulkCreateGrantsRequest struct {
+	Grants []BulkGrantInput `json:"grants"`
+}
+
+// BulkGrantError describes a validation failure for one grant in a bulk request
+type BulkGrantError struct {
+	Index         int    `json:"index"`
+	ExerciseIndex *int   `json:"exercise_index,omitempty"`
+	Field         string `json:"field,omitempty"`
+	Message       string `json:"message"`
+}
+
+// BulkGrantResult is a created grant with its schedule and exercises
+type BulkGrantResult struct {
+	Grant           *EquityGrant     `json:"grant"`
+	VestingSchedule *VestingSchedule `json:"vesting_schedule,omitempty"`
+	Exercises       []EquityExercise `json:"exercises"`
+}
+
+// BulkCreateGrantsResponse reports the grants created, or why none were
+type BulkCreateGrantsResponse struct {
+	Created []BulkGrantResult `json:"created"`
+	Errors  []BulkGrantError  `json:"errors"`
+}
+
+// BulkCreateEquityGrants creates several grants with their vesting schedules and
+// historical exercises in a single transaction. Every grant is validated first; if any
+// fail, nothing is created and the response lists each problem with the grant's index,
+// alongside ErrBulkGrantsInvalid.
+func (s *Service) BulkCreateEquityGrants(ctx context.Context, accountID string, req *BulkCreateGrantsRequest) (*BulkCreateGrantsResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+	if len(req.Grants) == 0 {
+		return nil, fmt.Errorf("at least one grant is required")
+	}
+	if len(req.Grants) > maxBulkGrants {
+		return nil, fmt.Errorf("at most %d grants can be created at once", maxBulkGrants)
+	}
+
+	resp := &BulkCreateGrantsResponse{
+		Created: make([]BulkGrantResult, 0, len(req.Grants)),
+		Errors:  make([]BulkGrantError, 0),
+	}
+	for i := range req.Grants {
+		resp.Errors = append(resp.Errors, validateBulkGrant(i, &req.Grants[i])...)
+	}
+	if len(resp.Errors) > 0 {
+		return resp, ErrBulkGrantsInvalid
+	}
+
+	// FMV history fills in exercises recorded without an FMV, as RecordExercise does
+	var fmvEntries []FMVEntry
+	if fmvHistory, err := s.GetFMVHistory(ctx, accountID); err == nil {
+		fmvEntries = fmvHistory.Entries
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i := range req.Grants {
+		input := &req.Grants[i]
+
+		grant := newEquityGrant(accountID, &input.CreateEquityGrantRequest)
+		if err := insertEquityGrant(ctx, tx, grant); err != nil {
+			return nil, fmt.Errorf("grant %d: %w", i, err)
+		}
+		result := BulkGrantResult{Grant: grant, Exercises: make([]EquityExercise, 0, len(input.Exercises))}
+
+		if input.VestingSchedule != nil {
+			schedule := newVestingSchedule(grant.ID, input.VestingSchedule)
+			if err := upsertVestingSchedule(ctx, tx, schedule); err != nil {
+				return nil, fmt.Errorf("grant %d: %w", i, err)
+			}
+			result.VestingSchedule = schedule
+		}
+
+		for j := range input.Exercises {
+			exerciseReq := &input.Exercises[j]
+			fmvFromHistory := false
+			if exerciseReq.FMVAtExercise == 0 {
+				if fmv, ok := fmvInEffect(fmvEntries, grant.Currency, exerciseReq.ExerciseDate.Time); ok {
+					exerciseReq.FMVAtExercise = fmv
+					fmvFromHistory = true
+				}
+			}
+			exercise := newEquityExercise(grant.ID, *grant.StrikePrice, exerciseReq)
+			exercise.FMVFromHistory = fmvFromHistory
+			if err := insertEquityExercise(ctx, tx, exercise); err != nil {
+				return nil, fmt.Errorf("grant %d exercise %d: %w", i, j, err)
+			}
+			result.Exercises = append(result.Exercises, *exercise)
+		}
+
+		resp.Created = append(resp.Created, result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit bulk grant creation: %w", err)
+	}
+
+	return resp, nil
+}
+
+// validateBulkGrant checks one grant in a bulk request, returning every problem found
+func validateBulkGrant(index int, input *BulkGrantInput) []BulkGrantError {
+	var errs []BulkGrantError
+	fail := func(field, message string) {
+		errs = append(errs, BulkGrantError{Index: index, Field: field, Message: message})
+	}
+
+	switch input.GrantType {
+	case GrantTypeISO, GrantTypeNSO, GrantTypeRSU, GrantTypeRSA:
+	default:
+		fail("grant_type", fmt.Sprintf("unknown grant type %q", input.GrantType))
+	}
+	if input.GrantDate.IsZero() {
+		fail("grant_date", "grant_date is required")
+	}
+	if input.Quantity <= 0 {
+		fail("quantity", "quantity must be positive")
+	}
+	if input.CompanyName == "" {
+		fail("company_name", "company_name is required")
+	}
+	if err := validateEquityGrant(&input.CreateEquityGrantRequest); err != nil {
+		fail("strike_price", err.Error())
+	}
+
+	if input.VestingSchedule != nil {
+		if err := validateVestingSchedule(input.VestingSchedule); err != nil {
+			fail("vesting_schedule", err.Error())
+		}
+	}
+
+	if len(input.Exercises) == 0 {
+		return errs
+	}
+	if err := validateExercisable(input.GrantType, input.StrikePrice); err != nil {
+		fail("exercises", err.Error())
+		return errs
+	}
+
+	exercised := 0
+	for j, exercise := range input.Exercises {
+		failExercise := func(field, message string) {
+			errs = append(errs, BulkGrantError{Index: index, ExerciseIndex: &j, Field: field, Message: message})
+		}
+		if exercise.ExerciseDate.IsZero() {
+			failExercise("exercise_date", "exercise_date is required")
+		} else if !input.GrantDate.IsZero() && exercise.ExerciseDate.Before(input.GrantDate.Time) {
+			failExercise("exercise_date", "exercise_date is before the grant date")
+		}
+		if exercise.Quantity <= 0 {
+			failExercise("quantity", "quantity must be positive")
+		}
+		if err := validateWithholdingRate(exercise.WithholdingRate); err != nil {
+			failExercise("withholding_rate", err.Error())
+		}
+		exercised += exercise.Quantity
+	}
+	if input.Quantity > 0 && exercised > input.Quantity {
+		fail("exercises", fmt.Sprintf("exercised quantity %d exceeds granted quantity %d", exercised, input.Quantity))
+	}
+
+	return errs
+}
@@ -0,0 +1,351 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/balance"
+)
+
+// HELOC transaction types
+const (
+	HELOCDraw      = "draw"
+	HELOCRepayment = "repayment"
+	HELOCInterest  = "interest"
+)
+
+// HELOCDetails links a line of credit account to the property that secures it.
+// Available credit is derived from the property's value, the mortgage balance and the LTV limit.
+type HELOCDetails struct {
+	ID                string    `json:"id"`
+	AccountID         string    `json:"account_id"`
+	PropertyAccountID *string   `json:"property_account_id,omitempty"`
+	MortgageAccountID *string   `json:"mortgage_account_id,omitempty"`
+	MaxLTV            float64   `json:"max_ltv"`                // e.g. 0.80 for 80% combined loan-to-value
+	CreditLimit       *float64  `json:"credit_limit,omitempty"` // lender cap, if lower than the LTV limit
+	InterestRate      float64   `json:"interest_rate"`
+	Lender            string    `json:"lender,omitempty"`
+	Notes             string    `json:"notes,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// HELOCTransaction represents a draw, repayment or capitalized interest charge
+type HELOCTransaction struct {
+	ID              string    `json:"id"`
+	AccountID       string    `json:"account_id"`
+	TransactionDate Date      `json:"transaction_date"`
+	Type            string    `json:"type"` // draw, repayment, interest
+	Amount          float64   `json:"amount"`
+	BalanceAfter    float64   `json:"balance_after"` // amount owed after the transaction
+	Notes           string    `json:"notes,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// HELOCStatus is a HELOC's current credit position
+type HELOCStatus struct {
+	AccountID              string  `json:"account_id"`
+	PropertyValue          float64 `json:"property_value"`
+	MortgageBalance        float64 `json:"mortgage_balance"`
+	LTVLimit               float64 `json:"ltv_limit"`    // property value * max LTV - mortgage balance
+	CreditLimit            float64 `json:"credit_limit"` // lower of the LTV limit and the lender cap
+	Balance                float64 `json:"balance"`      // amount owed
+	AvailableCredit        float64 `json:"available_credit"`
+	AccruedInterest        float64 `json:"accrued_interest"`         // interest since the last transaction
+	MonthlyInterestPayment float64 `json:"monthly_interest_payment"` // interest-only payment at the current balance
+}
+
+// CreateHELOCDetailsRequest represents the request to create HELOC details
+type CreateHELOCDetailsRequest struct {
+	PropertyAccountID *string  `json:"property_account_id,omitempty"`
+	MortgageAccountID *string  `json:"mortgage_account_id,omitempty"`
+	MaxLTV            float64  `json:"max_ltv"`
+	CreditLimit       *float64 `json:"credit_limit,omitempty"`
+	InterestRate      float64  `json:"interest_rate"`
+	Lender            string   `json:"lender,omitempty"`
+	Notes             string   `json:"notes,omitempty"`
+}
+
+// CreateHELOCTransactionRequest represents the request to record a HELOC transaction
+type CreateHELOCTransactionRequest struct {
+	TransactionDate Date    `json:"transaction_date"`
+	Type            string  `json:"type"`
+	Amount          float64 `json:"amount"`
+	Notes           string  `json:"notes,omitempty"`
+}
+
+// HELOCTransactionsResponse represents the HELOC transactions list response
+type HELOCTransactionsResponse struct {
+	Transactions []HELOCTransaction `json:"transactions"`
+}
+
+// CreateHELOCDetails creates HELOC details for a line of credit account
+func (s *Service) CreateHELOCDetails(ctx context.Context, accountID string, req *CreateHELOCDetailsRequest) (*HELOCDetails, error) {
+	acc, err := s.Get(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("account not found")
+	}
+	if acc.Type != AccountTypeLineOfCredit {
+		return nil, fmt.Errorf("HELOC details can only be added to line of credit accounts")
+	}
+
+	if req.MaxLTV <= 0 || req.MaxLTV > 1 {
+		return nil, fmt.Errorf("max LTV must be between 0 and 1")
+	}
+	if req.InterestRate < 0 {
+		return nil, fmt.Errorf("interest rate cannot be negative")
+	}
+	if req.CreditLimit != nil && *req.CreditLimit < 0 {
+		return nil, fmt.Errorf("credit limit cannot be negative")
+	}
+	for _, linked := range []*string{req.PropertyAccountID, req.MortgageAccountID} {
+		if linked != nil {
+			if err := s.verifyAccountOwnership(ctx, *linked); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	id := uuid.New().String()
+	now := time.Now()
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO heloc_details (
+			id, account_id, property_account_id, mortgage_account_id,
+			max_ltv, credit_limit, interest_rate, lender, notes,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, id, accountID, req.PropertyAccountID, req.MortgageAccountID,
+		req.MaxLTV, req.CreditLimit, req.InterestRate, req.Lender, req.Notes,
+		now, now)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HELOC details: %w", err)
+	}
+
+	return &HELOCDetails{
+		ID:                id,
+		AccountID:         accountID,
+		PropertyAccountID: req.PropertyAccountID,
+		MortgageAccountID: req.MortgageAccountID,
+		MaxLTV:            req.MaxLTV,
+		CreditLimit:       req.CreditLimit,
+		InterestRate:      req.InterestRate,
+		Lender:            req.Lender,
+		Notes:             req.Notes,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}, nil
+}
+
+// GetHELOCDetails retrieves HELOC details for an account
+func (s *Service) GetHELOCDetails(ctx context.Context, accountID string) (*HELOCDetails, error) {
+	// Verify account ownership
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	var details HELOCDetails
+	var lender, notes sql.NullString
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, account_id, property_account_id, mortgage_account_id,
+			max_ltv, credit_limit, interest_rate, lender, notes,
+			created_at, updated_at
+		FROM heloc_details
+		WHERE account_id = $1
+	`, accountID).Scan(
+		&details.ID, &details.AccountID, &details.PropertyAccountID, &details.MortgageAccountID,
+		&details.MaxLTV, &details.CreditLimit, &details.InterestRate, &lender, &notes,
+		&details.CreatedAt, &details.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HELOC details: %w", err)
+	}
+	details.Lender = lender.String
+	details.Notes = notes.String
+
+	return &details, nil
+}
+
+// GetHELOCStatus computes the credit limit, balance and available credit for a HELOC
+func (s *Service) GetHELOCStatus(ctx context.Context, accountID string) (*HELOCStatus, error) {
+	details, err := s.GetHELOCDetails(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &HELOCStatus{AccountID: accountID}
+
+	if details.PropertyAccountID != nil {
+		status.PropertyValue = s.latestBalance(ctx, *details.PropertyAccountID)
+	}
+	if details.MortgageAccountID != nil {
+		// Mortgage balances are stored as negative amounts
+		status.MortgageBalance = math.Abs(s.latestBalance(ctx, *details.MortgageAccountID))
+	}
+
+	status.LTVLimit = math.Max(0, status.PropertyValue*details.MaxLTV-status.MortgageBalance)
+	status.CreditLimit = status.LTVLimit
+	if details.CreditLimit != nil && *details.CreditLimit < status.CreditLimit {
+		status.CreditLimit = *details.CreditLimit
+	}
+
+	balanceOwed, lastDate, err := s.helocBalance(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	status.Balance = balanceOwed
+	status.AvailableCredit = math.Max(0, status.CreditLimit-balanceOwed)
+
+	// Interest-only: simple daily interest on the drawn balance since the last transaction
+	if !lastDate.IsZero() && balanceOwed > 0 {
+		days := math.Floor(time.Since(lastDate).Hours() / 24)
+		if days > 0 {
+			status.AccruedInterest = math.Round(balanceOwed*details.InterestRate/365*days*100) / 100
+		}
+	}
+	status.MonthlyInterestPayment = math.Round(balanceOwed*details.InterestRate/12*100) / 100
+
+	return status, nil
+}
+
+// RecordHELOCTransaction records a draw, repayment or capitalized interest charge
+func (s *Service) RecordHELOCTransaction(ctx context.Context, accountID string, req *CreateHELOCTransactionRequest) (*HELOCTransaction, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+	if req.TransactionDate.IsZero() {
+		return nil, fmt.Errorf("transaction date is required")
+	}
+
+	status, err := s.GetHELOCStatus(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var balanceAfter float64
+	switch req.Type {
+	case HELOCDraw:
+		if req.Amount > status.AvailableCredit+0.005 {
+			return nil, fmt.Errorf("draw of %.2f exceeds available credit of %.2f", req.Amount, status.AvailableCredit)
+		}
+		balanceAfter = status.Balance + req.Amount
+	case HELOCInterest:
+		balanceAfter = status.Balance + req.Amount
+	case HELOCRepayment:
+		if req.Amount > status.Balance+0.005 {
+			return nil, fmt.Errorf("repayment of %.2f exceeds balance of %.2f", req.Amount, status.Balance)
+		}
+		balanceAfter = math.Max(0, status.Balance-req.Amount)
+	default:
+		return nil, fmt.Errorf("invalid HELOC transaction type: %s", req.Type)
+	}
+
+	id := uuid.New().String()
+	now := time.Now()
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO heloc_transactions (
+			id, account_id, transaction_date, type, amount, balance_after, notes, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, id, accountID, req.TransactionDate, req.Type, req.Amount, balanceAfter, req.Notes, now)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to record HELOC transaction: %w", err)
+	}
+
+	// Keep the account balance in step, stored as negative since it's a liability
+	_, err = s.balanceSvc.Create(ctx, &balance.CreateBalanceRequest{
+		AccountID: accountID,
+		Amount:    -balanceAfter,
+		Date:      req.TransactionDate.Time,
+		Notes:     "HELOC " + req.Type,
+	})
+	if err != nil {
+		// Log the error but don't fail the transaction recording
+		fmt.Printf("Warning: failed to create balance entry: %v\n", err)
+	}
+
+	return &HELOCTransaction{
+		ID:              id,
+		AccountID:       accountID,
+		TransactionDate: req.TransactionDate,
+		Type:            req.Type,
+		Amount:          req.Amount,
+		BalanceAfter:    balanceAfter,
+		Notes:           req.Notes,
+		CreatedAt:       now,
+	}, nil
+}
+
+// GetHELOCTransactions retrieves all transactions for a HELOC account
+func (s *Service) GetHELOCTransactions(ctx context.Context, accountID string) (*HELOCTransactionsResponse, error) {
+	// Verify account ownership
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, account_id, transaction_date, type, amount, balance_after, notes, created_at
+		FROM heloc_transactions
+		WHERE account_id = $1
+		ORDER BY transaction_date DESC, created_at DESC
+	`, accountID)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HELOC transactions: %w", err)
+	}
+	defer rows.Close()
+
+	transactions := make([]HELOCTransaction, 0)
+	for rows.Next() {
+		var t HELOCTransaction
+		var notes sql.NullString
+		err := rows.Scan(&t.ID, &t.AccountID, &t.TransactionDate, &t.Type, &t.Amount, &t.BalanceAfter, &notes, &t.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan HELOC transaction: %w", err)
+		}
+		t.Notes = notes.String
+		transactions = append(transactions, t)
+	}
+
+	return &HELOCTransactionsResponse{Transactions: transactions}, nil
+}
+
+// helocBalance returns the amount owed on a HELOC and the date of its last transaction
+func (s *Service) helocBalance(ctx context.Context, accountID string) (float64, time.Time, error) {
+	var balanceOwed float64
+	var lastDate Date
+	err := s.db.QueryRowContext(ctx, `
+		SELECT balance_after, transaction_date
+		FROM heloc_transactions
+		WHERE account_id = $1
+		ORDER BY transaction_date DESC, created_at DESC
+		LIMIT 1
+	`, accountID).Scan(&balanceOwed, &lastDate)
+	if err == sql.ErrNoRows {
+		return 0, time.Time{}, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to get HELOC balance: %w", err)
+	}
+	return balanceOwed, lastDate.Time, nil
+}
+
+// latestBalance returns an account's most recent recorded balance, or zero if none exist
+func (s *Service) latestBalance(ctx context.Context, accountID string) float64 {
+	var amount float64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT amount FROM balances WHERE account_id = $1 ORDER BY date DESC LIMIT 1
+	`, accountID).Scan(&amount)
+	if err != nil {
+		return 0
+	}
+	return amount
+}
@@ -0,0 +1,67 @@
+package account
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHELOC_AvailableCreditFromPropertyAndMortgage(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-heloc-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupAccountService(t, db)
+
+	propertyID := CreateTestAccount(t, db, userID, AccountTypeRealEstate)
+	CreateTestBalance(t, db, propertyID, 500000)
+	mortgageID := CreateTestAccount(t, db, userID, AccountTypeMortgage)
+	CreateTestBalance(t, db, mortgageID, -300000)
+	helocID := CreateTestAccount(t, db, userID, AccountTypeLineOfCredit)
+
+	_, err := service.CreateHELOCDetails(ctx, helocID, &CreateHELOCDetailsRequest{
+		PropertyAccountID: &propertyID,
+		MortgageAccountID: &mortgageID,
+		MaxLTV:            0.80,
+		InterestRate:      0.06,
+	})
+	if err != nil {
+		t.Fatalf("CreateHELOCDetails failed: %v", err)
+	}
+
+	// Act
+	_, err = service.RecordHELOCTransaction(ctx, helocID, &CreateHELOCTransactionRequest{
+		TransactionDate: Date{Time: time.Now()},
+		Type:            HELOCDraw,
+		Amount:          40000,
+	})
+	if err != nil {
+		t.Fatalf("RecordHELOCTransaction failed: %v", err)
+	}
+	status, err := service.GetHELOCStatus(ctx, helocID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetHELOCStatus failed: %v", err)
+	}
+	if status.CreditLimit != 100000 {
+		t.Errorf("Expected credit limit 100000, got %f", status.CreditLimit)
+	}
+	if status.Balance != 40000 || status.AvailableCredit != 60000 {
+		t.Errorf("Expected balance 40000 and 60000 available, got %f and %f", status.Balance, status.AvailableCredit)
+	}
+	if status.MonthlyInterestPayment != 200 {
+		t.Errorf("Expected interest-only payment of 200, got %f", status.MonthlyInterestPayment)
+	}
+
+	_, err = service.RecordHELOCTransaction(ctx, helocID, &CreateHELOCTransactionRequest{
+		TransactionDate: Date{Time: time.Now()},
+		Type:            HELOCDraw,
+		Amount:          70000,
+	})
+	if err == nil {
+		t.Error("Expected draw beyond available credit to fail")
+	}
+}
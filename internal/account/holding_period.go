@@ -0,0 +1,164 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"money/internal/auth"
+)
+
+// HoldingPeriodRule identifies a holding-period threshold for exercised shares
+type HoldingPeriodRule string
+
+const (
+	// HoldingPeriodRuleUSISO is the US ISO qualifying disposition threshold:
+	// 1 year from exercise and 2 years from grant, whichever is later
+	HoldingPeriodRuleUSISO HoldingPeriodRule = "us_iso_qualifying"
+	// HoldingPeriodRuleCADeduction is the 2-year threshold used for the
+	// Canadian stock option deduction (matches RecordSale's is_qualified)
+	HoldingPeriodRuleCADeduction HoldingPeriodRule = "ca_two_year"
+)
+
+// DefaultHoldingPeriodAlertDays is how far ahead thresholds are reported by default
+const DefaultHoldingPeriodAlertDays = 30
+
+// HoldingPeriodThreshold is a date on which exercised shares cross a holding-period rule
+type HoldingPeriodThreshold struct {
+	Rule          HoldingPeriodRule `json:"rule"`
+	ThresholdDate Date              `json:"threshold_date"`
+}
+
+// HoldingPeriodAlert describes exercised shares approaching a holding-period threshold
+type HoldingPeriodAlert struct {
+	AccountID     string            `json:"account_id"`
+	GrantID       string            `json:"grant_id"`
+	ExerciseID    string            `json:"exercise_id"`
+	CompanyName   string            `json:"company_name"`
+	GrantType     GrantType         `json:"grant_type"`
+	GrantDate     Date              `json:"grant_date"`
+	ExerciseDate  Date              `json:"exercise_date"`
+	Rule          HoldingPeriodRule `json:"rule"`
+	ThresholdDate Date              `json:"threshold_date"`
+	DaysRemaining int               `json:"days_remaining"`
+	SharesHeld    int               `json:"shares_held"`
+}
+
+// HoldingPeriodAlertsResponse represents a list of holding-period alerts
+type HoldingPeriodAlertsResponse struct {
+	Alerts []HoldingPeriodAlert `json:"alerts"`
+}
+
+// computeHoldingPeriodThresholds returns the holding-period thresholds that apply
+// to shares from an exercise (pure computation, no DB)
+func computeHoldingPeriodThresholds(grantType GrantType, grantDate, exerciseDate time.Time) []HoldingPeriodThreshold {
+	thresholds := make([]HoldingPeriodThreshold, 0, 2)
+
+	if grantType == GrantTypeISO {
+		threshold := exerciseDate.AddDate(1, 0, 0)
+		if fromGrant := grantDate.AddDate(2, 0, 0); fromGrant.After(threshold) {
+			threshold = fromGrant
+		}
+		thresholds = append(thresholds, HoldingPeriodThreshold{
+			Rule:          HoldingPeriodRuleUSISO,
+			ThresholdDate: Date{Time: threshold},
+		})
+	}
+
+	if grantType == GrantTypeISO || grantType == GrantTypeNSO {
+		thresholds = append(thresholds, HoldingPeriodThreshold{
+			Rule:          HoldingPeriodRuleCADeduction,
+			ThresholdDate: Date{Time: grantDate.AddDate(0, 0, 730)},
+		})
+	}
+
+	return thresholds
+}
+
+// GetHoldingPeriodAlerts returns thresholds crossed within the next `days` days for an account
+func (s *Service) GetHoldingPeriodAlerts(ctx context.Context, accountID string, days int) (*HoldingPeriodAlertsResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	alerts, err := s.holdingPeriodAlerts(ctx, "g.account_id = $1", accountID, days, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &HoldingPeriodAlertsResponse{Alerts: alerts}, nil
+}
+
+// GetAllHoldingPeriodAlerts returns upcoming thresholds across all of the user's accounts
+func (s *Service) GetAllHoldingPeriodAlerts(ctx context.Context, days int) (*HoldingPeriodAlertsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	alerts, err := s.holdingPeriodAlerts(ctx, "a.user_id = $1", userID, days, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &HoldingPeriodAlertsResponse{Alerts: alerts}, nil
+}
+
+// holdingPeriodAlerts loads exercises matching the filter and reports thresholds
+// falling between now and now + days for shares that have not been sold
+func (s *Service) holdingPeriodAlerts(ctx context.Context, filter string, arg string, days int, now time.Time) ([]HoldingPeriodAlert, error) {
+	if days <= 0 {
+		days = DefaultHoldingPeriodAlertDays
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id, e.grant_id, g.account_id, g.company_name, g.grant_type, g.grant_date,
+			e.exercise_date, e.quantity,
+			COALESCE((SELECT SUM(es.quantity) FROM equity_sales es WHERE es.exercise_id = e.id), 0)
+		FROM equity_exercises e
+		JOIN equity_grants g ON e.grant_id = g.id
+		JOIN accounts a ON g.account_id = a.id
+		WHERE `+filter, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exercises: %w", err)
+	}
+	defer rows.Close()
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	horizon := today.AddDate(0, 0, days)
+
+	alerts := make([]HoldingPeriodAlert, 0)
+	for rows.Next() {
+		var alert HoldingPeriodAlert
+		var quantity, sold int
+		if err := rows.Scan(
+			&alert.ExerciseID, &alert.GrantID, &alert.AccountID, &alert.CompanyName, &alert.GrantType,
+			&alert.GrantDate, &alert.ExerciseDate, &quantity, &sold,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan exercise: %w", err)
+		}
+
+		alert.SharesHeld = quantity - sold
+		if alert.SharesHeld <= 0 {
+			continue
+		}
+
+		for _, threshold := range computeHoldingPeriodThresholds(alert.GrantType, alert.GrantDate.Time, alert.ExerciseDate.Time) {
+			if threshold.ThresholdDate.Time.Before(today) || threshold.ThresholdDate.Time.After(horizon) {
+				continue
+			}
+			entry := alert
+			entry.Rule = threshold.Rule
+			entry.ThresholdDate = threshold.ThresholdDate
+			entry.DaysRemaining = int(threshold.ThresholdDate.Time.Sub(today).Hours() / 24)
+			alerts = append(alerts, entry)
+		}
+	}
+
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].ThresholdDate.Time.Before(alerts[j].ThresholdDate.Time)
+	})
+
+	return alerts, nil
+}
@@ -0,0 +1,158 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/civil"
+)
+
+// LiabilityInterest is the interest paid on one loan or mortgage in a calendar year
+type LiabilityInterest struct {
+	AccountID          string      `json:"account_id"`
+	AccountName        string      `json:"account_name"`
+	AccountType        AccountType `json:"account_type"`
+	Currency           Currency    `json:"currency"`
+	InterestPaid       float64     `json:"interest_paid"`       // From recorded payments
+	Payments           int         `json:"payments"`            // Recorded payments in the year
+	ProjectedRemaining float64     `json:"projected_remaining"` // Scheduled interest for the rest of the year
+	ProjectedTotal     float64     `json:"projected_total"`
+}
+
+// InterestPaidResponse summarizes interest paid per liability for a calendar year
+type InterestPaidResponse struct {
+	Year        int                 `json:"year"`
+	Liabilities []LiabilityInterest `json:"liabilities"`
+	Totals      map[string]float64  `json:"totals"` // Projected total by currency
+}
+
+// GetInterestPaid summarizes the interest paid on each loan and mortgage in a calendar
+// year from recorded payments. Scheduled payments after the latest recorded one, and after
+// today, project the rest of the year, which matters for deductible interest on investment
+// loans and rental mortgages.
+func (s *Service) GetInterestPaid(ctx context.Context, year int) (*InterestPaidResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, type, currency
+		FROM accounts
+		WHERE user_id = $1 AND type IN ($2, $3) AND is_active = true
+		ORDER BY name
+	`, userID, AccountTypeLoan, AccountTypeMortgage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get liabilities: %w", err)
+	}
+	var liabilities []LiabilityInterest
+	for rows.Next() {
+		var l LiabilityInterest
+		if err := rows.Scan(&l.AccountID, &l.AccountName, &l.AccountType, &l.Currency); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan liability: %w", err)
+		}
+		liabilities = append(liabilities, l)
+	}
+	rows.Close()
+
+	yearStart := civil.NewDate(year, time.January, 1)
+	yearEnd := civil.NewDate(year, time.December, 31)
+	today := civil.TodayIn(ctx)
+
+	resp := &InterestPaidResponse{
+		Year:        year,
+		Liabilities: make([]LiabilityInterest, 0, len(liabilities)),
+		Totals:      make(map[string]float64),
+	}
+	for _, l := range liabilities {
+		table := paymentTables[l.AccountType]
+
+		var latestPayment *Date
+		err := s.db.QueryRowContext(ctx, fmt.Sprintf(`
+			SELECT COALESCE(SUM(interest_amount), 0), COUNT(*)
+			FROM %s
+			WHERE account_id = $1 AND payment_date >= $2 AND payment_date <= $3
+		`, table), l.AccountID, yearStart, yearEnd).Scan(&l.InterestPaid, &l.Payments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum interest paid: %w", err)
+		}
+
+		var latest Date
+		err = s.db.QueryRowContext(ctx, fmt.Sprintf(`
+			SELECT payment_date FROM %s WHERE account_id = $1 ORDER BY payment_date DESC LIMIT 1
+		`, table), l.AccountID).Scan(&latest)
+		if err == nil {
+			latestPayment = &latest
+		}
+
+		schedule, err := s.liabilitySchedule(ctx, l.AccountID, l.AccountType)
+		if err != nil {
+			return nil, err
+		}
+		l.ProjectedRemaining = projectedInterest(schedule, yearStart, yearEnd, today, latestPayment)
+
+		l.InterestPaid = math.Round(l.InterestPaid*100) / 100
+		l.ProjectedRemaining = math.Round(l.ProjectedRemaining*100) / 100
+		l.ProjectedTotal = math.Round((l.InterestPaid+l.ProjectedRemaining)*100) / 100
+		resp.Totals[string(l.Currency)] += l.ProjectedTotal
+		resp.Liabilities = append(resp.Liabilities, l)
+	}
+
+	sort.SliceStable(resp.Liabilities, func(i, j int) bool {
+		return resp.Liabilities[i].ProjectedTotal > resp.Liabilities[j].ProjectedTotal
+	})
+	for currency, total := range resp.Totals {
+		resp.Totals[currency] = math.Round(total*100) / 100
+	}
+
+	return resp, nil
+}
+
+// liabilitySchedule returns a loan or mortgage's amortization schedule, or nil when its
+// details haven't been entered yet
+func (s *Service) liabilitySchedule(ctx context.Context, accountID string, accountType AccountType) ([]AmortizationEntry, error) {
+	var schedule *AmortizationScheduleResponse
+	var err error
+	switch accountType {
+	case AccountTypeLoan:
+		if _, detailsErr := s.GetLoanDetails(ctx, accountID); detailsErr != nil {
+			return nil, nil
+		}
+		schedule, err = s.GetLoanAmortizationSchedule(ctx, accountID)
+	case AccountTypeMortgage:
+		if _, detailsErr := s.GetMortgageDetails(ctx, accountID); detailsErr != nil {
+			return nil, nil
+		}
+		schedule, err = s.GetAmortizationSchedule(ctx, accountID)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return schedule.Schedule, nil
+}
+
+// projectedInterest sums the scheduled interest due in the year after both today and the
+// latest recorded payment
+func projectedInterest(schedule []AmortizationEntry, yearStart, yearEnd, today Date, latestPayment *Date) float64 {
+	after := today
+	if latestPayment != nil && latestPayment.After(after.Time) {
+		after = *latestPayment
+	}
+
+	total := 0.0
+	for _, entry := range schedule {
+		due := civil.DateOf(entry.PaymentDate)
+		if due.Before(yearStart.Time) || due.After(yearEnd.Time) || !due.After(after.Time) {
+			continue
+		}
+		total += entry.InterestAmount
+	}
+	return total
+}
@@ -2,6 +2,7 @@ package account
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"math"
 	"time"
@@ -44,6 +45,9 @@ type LoanPayment struct {
 	BalanceAfter    float64   `json:"balance_after"`
 	Notes           string    `json:"notes,omitempty"`
 	CreatedAt       time.Time `json:"created_at"`
+
+	AutoPosted          bool `json:"auto_posted"`          // Posted from the amortization schedule on its due date
+	NeedsReconciliation bool `json:"needs_reconciliation"` // A real payment on the due date differed from the schedule
 }
 
 // CreateLoanDetailsRequest represents the request to create loan details
@@ -191,15 +195,22 @@ func (s *Service) GetLoanAmortizationSchedule(ctx context.Context, accountID str
 		return nil, err
 	}
 
-	schedule := calculateLoanAmortizationSchedule(details)
+	// Student loans defer payments through the grace period and skip subsidized interest
+	student, err := getStudentLoanDetails(ctx, s.db, accountID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	schedule := calculateLoanAmortizationSchedule(details, student)
 
 	return &AmortizationScheduleResponse{
 		Schedule: schedule,
 	}, nil
 }
 
-// calculateLoanAmortizationSchedule generates the amortization schedule for a loan
-func calculateLoanAmortizationSchedule(details *LoanDetails) []AmortizationEntry {
+// calculateLoanAmortizationSchedule generates the amortization schedule for a loan.
+// Student loan terms are optional.
+func calculateLoanAmortizationSchedule(details *LoanDetails, student *StudentLoanDetails) []AmortizationEntry {
 	schedule := make([]AmortizationEntry, 0)
 
 	balance := details.OriginalAmount
@@ -209,12 +220,25 @@ func calculateLoanAmortizationSchedule(details *LoanDetails) []AmortizationEntry
 	periodsPerYear := getPeriodsPerYear(details.PaymentFrequency)
 	periodRate := details.InterestRate / float64(periodsPerYear)
 
+	// Unsubsidized interest accrued during the grace period is capitalized when repayment starts
+	accrued := 0.0
+	for student.InGracePeriod(currentDate) {
+		if !student.Subsidized(currentDate) {
+			accrued += balance * periodRate
+		}
+		currentDate = getNextPaymentDate(currentDate, details.PaymentFrequency)
+	}
+	balance += accrued
+
 	// Calculate total number of payments
 	totalPayments := int(math.Ceil(float64(details.TermMonths) / (12.0 / float64(periodsPerYear))))
 
 	for i := 1; i <= totalPayments && balance > 0.01; i++ {
 		// Calculate interest for this period
 		interestAmount := balance * periodRate
+		if student.Subsidized(currentDate) {
+			interestAmount = 0
+		}
 		principalAmount := details.PaymentAmount - interestAmount
 
 		// Handle final payment
@@ -247,13 +271,49 @@ func calculateLoanAmortizationSchedule(details *LoanDetails) []AmortizationEntry
 	return schedule
 }
 
-// RecordLoanPayment records an actual loan payment
+// RecordLoanPayment records an actual loan payment. A payment on a date that was
+// auto-posted replaces the auto-posted amounts rather than being recorded twice.
 func (s *Service) RecordLoanPayment(ctx context.Context, accountID string, req *CreateLoanPaymentRequest) (*LoanPayment, error) {
 	// Verify account ownership
 	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
 		return nil, err
 	}
 
+	reconciled, err := s.reconcileAutoPostedPayment(ctx, "loan_payments", accountID, paymentAmounts{
+		Date:      req.PaymentDate,
+		Payment:   req.PaymentAmount,
+		Principal: req.PrincipalAmount,
+		Interest:  req.InterestAmount,
+		Extra:     req.ExtraPayment,
+		Notes:     req.Notes,
+	})
+	if err == nil {
+		if err := s.SyncLoanBalance(ctx, accountID); err != nil {
+			fmt.Printf("Warning: failed to sync balance: %v\n", err)
+		}
+		return &LoanPayment{
+			ID:                  reconciled.ID,
+			AccountID:           accountID,
+			PaymentDate:         req.PaymentDate,
+			PaymentAmount:       req.PaymentAmount,
+			PrincipalAmount:     req.PrincipalAmount,
+			InterestAmount:      req.InterestAmount,
+			ExtraPayment:        req.ExtraPayment,
+			BalanceAfter:        reconciled.BalanceAfter,
+			Notes:               req.Notes,
+			CreatedAt:           reconciled.CreatedAt,
+			NeedsReconciliation: reconciled.NeedsReconciliation,
+		}, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return s.recordLoanPayment(ctx, accountID, req, false)
+}
+
+// recordLoanPayment inserts a loan payment and the resulting balance entry
+func (s *Service) recordLoanPayment(ctx context.Context, accountID string, req *CreateLoanPaymentRequest, autoPosted bool) (*LoanPayment, error) {
 	// Get current balance from either the last payment or the original loan amount
 	var currentBalance float64
 	err := s.db.QueryRowContext(ctx, `
@@ -278,11 +338,11 @@ func (s *Service) RecordLoanPayment(ctx context.Context, accountID string, req *
 		INSERT INTO loan_payments (
 			id, account_id, payment_date,
 			payment_amount, principal_amount, interest_amount, extra_payment,
-			balance_after, notes, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			balance_after, notes, created_at, auto_posted
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`, id, accountID, req.PaymentDate,
 		req.PaymentAmount, req.PrincipalAmount, req.InterestAmount, req.ExtraPayment,
-		balanceAfter, req.Notes, now)
+		balanceAfter, req.Notes, now, autoPosted)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to record payment: %w", err)
@@ -299,6 +359,7 @@ func (s *Service) RecordLoanPayment(ctx context.Context, accountID string, req *
 		BalanceAfter:    balanceAfter,
 		Notes:           req.Notes,
 		CreatedAt:       now,
+		AutoPosted:      autoPosted,
 	}
 
 	// Also create a balance entry so the loan balance appears in the accounts list
@@ -367,7 +428,7 @@ func (s *Service) GetLoanPayments(ctx context.Context, accountID string) (*LoanP
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT id, account_id, payment_date,
 			payment_amount, principal_amount, interest_amount, extra_payment,
-			balance_after, notes, created_at
+			balance_after, notes, created_at, auto_posted, needs_reconciliation
 		FROM loan_payments
 		WHERE account_id = $1
 		ORDER BY payment_date DESC
@@ -384,7 +445,7 @@ func (s *Service) GetLoanPayments(ctx context.Context, accountID string) (*LoanP
 		err := rows.Scan(
 			&payment.ID, &payment.AccountID, &payment.PaymentDate,
 			&payment.PaymentAmount, &payment.PrincipalAmount, &payment.InterestAmount, &payment.ExtraPayment,
-			&payment.BalanceAfter, &payment.Notes, &payment.CreatedAt,
+			&payment.BalanceAfter, &payment.Notes, &payment.CreatedAt, &payment.AutoPosted, &payment.NeedsReconciliation,
 		)
 		if err != nil {
 			continue
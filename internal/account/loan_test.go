@@ -1,6 +1,7 @@
 package account
 
 import (
+	"math"
 	"testing"
 	"time"
 )
@@ -422,3 +423,215 @@ func TestSyncLoanBalance_Success(t *testing.T) {
 		t.Fatalf("SyncLoanBalance failed: %v", err)
 	}
 }
+
+func TestAnalyzeRefinance_LowerRateBreaksEven(t *testing.T) {
+	// Arrange
+	details := &LoanDetails{
+		AccountID:        "loan-1",
+		InterestRate:     0.08,
+		PaymentAmount:    313.36,
+		PaymentFrequency: "monthly",
+	}
+	req := &RefinanceRequest{InterestRate: 0.04, TermMonths: 36, ClosingCosts: 200}
+
+	// Act
+	analysis, err := analyzeRefinance(details, 10000, req)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("analyzeRefinance failed: %v", err)
+	}
+	if math.Abs(analysis.NewPayment-295.24) > 0.01 {
+		t.Errorf("Expected new payment 295.24, got %.2f", analysis.NewPayment)
+	}
+	if analysis.InterestSavings <= 0 || analysis.NetSavings != analysis.InterestSavings-200 {
+		t.Errorf("Expected positive interest savings net of closing costs, got %.2f and %.2f",
+			analysis.InterestSavings, analysis.NetSavings)
+	}
+	if analysis.BreakEvenMonth == nil || *analysis.BreakEvenMonth < 2 || *analysis.BreakEvenMonth > 36 {
+		t.Errorf("Expected break-even within the term, got %v", analysis.BreakEvenMonth)
+	}
+}
+
+func TestRefinanceLoan_ReplacesDetailsAndKeepsHistory(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-loan-refinance-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeLoan)
+	service := SetupAccountService(t, db)
+
+	original, err := service.CreateLoanDetails(ctx, accountID, &CreateLoanDetailsRequest{
+		AccountID:        accountID,
+		OriginalAmount:   10000.00,
+		InterestRate:     0.08,
+		RateType:         "fixed",
+		StartDate:        Date{Time: time.Now().AddDate(-1, 0, 0)},
+		TermMonths:       36,
+		PaymentAmount:    313.36,
+		PaymentFrequency: "monthly",
+	})
+	if err != nil {
+		t.Fatalf("CreateLoanDetails failed: %v", err)
+	}
+
+	// Act
+	resp, err := service.RefinanceLoan(ctx, accountID, &RefinanceRequest{
+		InterestRate: 0.04,
+		TermMonths:   24,
+		ClosingCosts: 150,
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("RefinanceLoan failed: %v", err)
+	}
+	details, err := service.GetLoanDetails(ctx, accountID)
+	if err != nil {
+		t.Fatalf("GetLoanDetails failed: %v", err)
+	}
+	if details.ID != resp.LoanDetails.ID || details.InterestRate != 0.04 || details.TermMonths != 24 {
+		t.Errorf("Expected refinanced loan details, got %+v", details)
+	}
+	history, err := service.GetLoanRefinances(ctx, accountID)
+	if err != nil {
+		t.Fatalf("GetLoanRefinances failed: %v", err)
+	}
+	if len(history.Refinances) != 1 {
+		t.Fatalf("Expected 1 refinance, got %d", len(history.Refinances))
+	}
+	refinance := history.Refinances[0]
+	if refinance.PreviousLoanDetailsID != original.ID || refinance.NewLoanDetailsID != details.ID {
+		t.Errorf("Expected refinance to link old and new loan details, got %+v", refinance)
+	}
+	if refinance.PreviousInterestRate != 0.08 || refinance.ClosingCosts != 150 {
+		t.Errorf("Expected previous terms to be recorded, got %+v", refinance)
+	}
+}
+
+func TestGetLoanAmortizationSchedule_StudentLoanGracePeriod(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-loan-student-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeLoan)
+	service := SetupAccountService(t, db)
+
+	start := Date{Time: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	graceEnd := Date{Time: time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC)}
+	_, err := service.CreateLoanDetails(ctx, accountID, &CreateLoanDetailsRequest{
+		AccountID:        accountID,
+		OriginalAmount:   12000.00,
+		InterestRate:     0.06,
+		RateType:         "fixed",
+		StartDate:        start,
+		TermMonths:       60,
+		PaymentAmount:    250.00,
+		PaymentFrequency: "monthly",
+	})
+	if err != nil {
+		t.Fatalf("CreateLoanDetails failed: %v", err)
+	}
+
+	// Act
+	_, err = service.SetStudentLoanDetails(ctx, accountID, &SetStudentLoanDetailsRequest{
+		GracePeriodEnd: &graceEnd,
+		SubsidyPeriods: []StudentLoanSubsidyPeriod{
+			{StartDate: graceEnd, EndDate: Date{Time: time.Date(2024, time.September, 30, 0, 0, 0, 0, time.UTC)}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SetStudentLoanDetails failed: %v", err)
+	}
+	schedule, err := service.GetLoanAmortizationSchedule(ctx, accountID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetLoanAmortizationSchedule failed: %v", err)
+	}
+	first := schedule.Schedule[0]
+	if !first.PaymentDate.Equal(graceEnd.Time) {
+		t.Errorf("Expected first payment at the end of the grace period, got %v", first.PaymentDate)
+	}
+	// Six months of grace interest at 0.5% a month are capitalized
+	expectedBalance := 12000.00 + 6*60.00 - 250.00
+	if math.Abs(first.BalanceAfter-expectedBalance) > 0.01 {
+		t.Errorf("Expected balance %.2f after capitalizing grace interest, got %.2f", expectedBalance, first.BalanceAfter)
+	}
+	if first.InterestAmount != 0 {
+		t.Errorf("Expected no interest in a subsidized period, got %.2f", first.InterestAmount)
+	}
+	if schedule.Schedule[3].InterestAmount == 0 {
+		t.Error("Expected interest once the subsidy period ends")
+	}
+}
+
+func TestGetInterestPaid_SumsRecordedAndProjectedInterest(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-loan-interest-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeLoan)
+	service := SetupAccountService(t, db)
+
+	year := 2020
+	_, err := service.CreateLoanDetails(ctx, accountID, &CreateLoanDetailsRequest{
+		AccountID:        accountID,
+		OriginalAmount:   10000.00,
+		InterestRate:     0.06,
+		RateType:         "fixed",
+		StartDate:        Date{Time: time.Date(year-1, time.December, 1, 0, 0, 0, 0, time.UTC)},
+		TermMonths:       24,
+		PaymentAmount:    443.21,
+		PaymentFrequency: "monthly",
+	})
+	if err != nil {
+		t.Fatalf("CreateLoanDetails failed: %v", err)
+	}
+	for _, payment := range []struct {
+		date     time.Time
+		interest float64
+	}{
+		{time.Date(year-1, time.December, 1, 0, 0, 0, 0, time.UTC), 50.00},
+		{time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), 47.96},
+		{time.Date(year, time.February, 1, 0, 0, 0, 0, time.UTC), 45.91},
+	} {
+		if _, err := service.RecordLoanPayment(ctx, accountID, &CreateLoanPaymentRequest{
+			AccountID:       accountID,
+			PaymentDate:     Date{Time: payment.date},
+			PaymentAmount:   443.21,
+			PrincipalAmount: 443.21 - payment.interest,
+			InterestAmount:  payment.interest,
+		}); err != nil {
+			t.Fatalf("RecordLoanPayment failed: %v", err)
+		}
+	}
+
+	// Act
+	summary, err := service.GetInterestPaid(ctx, year)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetInterestPaid failed: %v", err)
+	}
+	if len(summary.Liabilities) != 1 {
+		t.Fatalf("Expected 1 liability, got %d", len(summary.Liabilities))
+	}
+	loan := summary.Liabilities[0]
+	if loan.Payments != 2 || math.Abs(loan.InterestPaid-93.87) > 0.001 {
+		t.Errorf("Expected 93.87 interest from 2 payments in %d, got %.2f from %d", year, loan.InterestPaid, loan.Payments)
+	}
+	// The year is over, so nothing is left to project
+	if loan.ProjectedRemaining != 0 || loan.ProjectedTotal != loan.InterestPaid {
+		t.Errorf("Expected no projected interest for a past year, got %.2f", loan.ProjectedRemaining)
+	}
+}
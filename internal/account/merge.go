@@ -0,0 +1,392 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/balance"
+)
+
+// accountTable describes a table holding per-account records
+type accountTable struct {
+	name     string
+	column   string   // column referencing the account
+	conflict []string // columns that must be unique per account; nil when rows never conflict
+	single   bool     // at most one row per account (detail records)
+}
+
+// accountTables lists every table with rows that belong to an account. Holdings are
+// handled separately because positions in the same symbol are combined on merge.
+var accountTables = []accountTable{
+	{name: "balances", column: "account_id", conflict: []string{"date"}},
+	{name: "transactions", column: "account_id"},
+	{name: "transfers", column: "from_account_id"},
+	{name: "transfers", column: "to_account_id"},
+	{name: "recurring_expenses", column: "account_id"},
+	{name: "scheduled_transactions", column: "account_id"},
+	{name: "sweep_rules", column: "from_account_id"},
+	{name: "sweep_rules", column: "to_account_id"},
+	{name: "bonus_plans", column: "account_id"},
+	{name: "mortgage_details", column: "account_id", single: true},
+	{name: "mortgage_payments", column: "account_id", conflict: []string{"payment_date"}},
+	{name: "loan_details", column: "account_id", single: true},
+	{name: "loan_payments", column: "account_id", conflict: []string{"payment_date"}},
+	{name: "loan_refinances", column: "account_id"},
+	{name: "student_loan_details", column: "account_id", single: true},
+	{name: "student_loan_subsidy_periods", column: "account_id"},
+	{name: "payment_auto_posting", column: "account_id", single: true},
+	{name: "account_documents", column: "account_id"},
+	{name: "account_estate_details", column: "account_id", single: true},
+	{name: "account_references", column: "account_id", single: true},
+	{name: "heloc_details", column: "account_id", single: true},
+	{name: "heloc_transactions", column: "account_id"},
+	{name: "heloc_details", column: "property_account_id"},
+	{name: "heloc_details", column: "mortgage_account_id"},
+	{name: "asset_details", column: "account_id", single: true},
+	{name: "asset_depreciation_entries", column: "account_id", conflict: []string{"entry_date"}},
+	{name: "equity_grants", column: "account_id"},
+	{name: "equity_sales", column: "account_id"},
+	{name: "fmv_history", column: "account_id", conflict: []string{"currency", "effective_date"}},
+	{name: "equity_recalculations", column: "account_id"},
+	{name: "in_kind_transfers", column: "from_account_id"},
+	{name: "in_kind_transfers", column: "to_account_id"},
+	{name: "reconciliations", column: "account_id"},
+	{name: "envelopes", column: "account_id", conflict: []string{"name"}},
+	{name: "envelope_movements", column: "account_id"},
+	{name: "sync_conflicts", column: "account_id"},
+	{name: "synced_accounts", column: "local_account_id"},
+}
+
+// MergeAccountsRequest represents a request to merge a duplicate account into a target
+type MergeAccountsRequest struct {
+	SourceAccountID string `json:"source_account_id"`
+}
+
+// MergeAccountsResponse reports what a merge moved
+type MergeAccountsResponse struct {
+	TargetAccountID string           `json:"target_account_id"`
+	SourceAccountID string           `json:"source_account_id"`
+	Moved           map[string]int64 `json:"moved"`
+	Skipped         map[string]int64 `json:"skipped"` // Source rows dropped because the target already had them
+	HoldingsMerged  int64            `json:"holdings_merged"`
+}
+
+// BulkDeleteRequest represents a request to delete several accounts
+type BulkDeleteRequest struct {
+	AccountIDs []string `json:"account_ids"`
+}
+
+// AccountDependencies counts the records that deleting an account removes
+type AccountDependencies struct {
+	AccountID   string           `json:"account_id"`
+	AccountName string           `json:"account_name"`
+	Records     map[string]int64 `json:"records"`
+	Total       int64            `json:"total"`
+}
+
+// BulkDeletePreviewResponse lists dependencies for each account in a bulk delete
+type BulkDeletePreviewResponse struct {
+	Accounts []AccountDependencies `json:"accounts"`
+	Total    int64                 `json:"total"`
+}
+
+// BulkDeleteResponse reports the outcome of a bulk delete
+type BulkDeleteResponse struct {
+	Deleted int                   `json:"deleted"`
+	Removed []AccountDependencies `json:"removed"`
+}
+
+// MergeAccounts moves all records from the source account into the target account and
+// deletes the source, in a single database transaction. Where both accounts have a record
+// for the same key (e.g. a balance on the same date), the target's record is kept.
+func (s *Service) MergeAccounts(ctx context.Context, targetID string, req *MergeAccountsRequest) (*MergeAccountsResponse, error) {
+	sourceID := req.SourceAccountID
+	if sourceID == "" {
+		return nil, fmt.Errorf("source account ID is required")
+	}
+	if sourceID == targetID {
+		return nil, fmt.Errorf("cannot merge an account into itself")
+	}
+	if err := s.verifyAccountOwnership(ctx, targetID); err != nil {
+		return nil, err
+	}
+	if err := s.verifyAccountOwnership(ctx, sourceID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	resp := &MergeAccountsResponse{
+		TargetAccountID: targetID,
+		SourceAccountID: sourceID,
+		Moved:           make(map[string]int64),
+		Skipped:         make(map[string]int64),
+	}
+
+	merged, moved, err := mergeHoldings(ctx, tx, targetID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	resp.HoldingsMerged = merged
+	resp.Moved["holdings"] = moved
+
+	for _, table := range accountTables {
+		skipped, err := dropConflictingRows(ctx, tx, table, targetID, sourceID)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := tx.ExecContext(ctx,
+			fmt.Sprintf("UPDATE %s SET %s = $1 WHERE %s = $2", table.name, table.column, table.column),
+			targetID, sourceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to move %s: %w", table.name, err)
+		}
+		moved, _ := result.RowsAffected()
+
+		resp.Moved[table.name] += moved
+		if skipped > 0 {
+			resp.Skipped[table.name] += skipped
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE accounts SET updated_at = $1 WHERE id = $2`, time.Now(), targetID); err != nil {
+		return nil, fmt.Errorf("failed to update target account: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM accounts WHERE id = $1`, sourceID); err != nil {
+		return nil, fmt.Errorf("failed to delete source account: %w", err)
+	}
+	if err := balance.InvalidateSummaries(ctx, tx, auth.GetUserID(ctx)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit merge: %w", err)
+	}
+
+	return resp, nil
+}
+
+// dropConflictingRows deletes source rows that would collide with target rows on the
+// table's unique key, returning how many were dropped
+func dropConflictingRows(ctx context.Context, tx *sql.Tx, table accountTable, targetID, sourceID string) (int64, error) {
+	var query string
+	switch {
+	case table.single:
+		query = fmt.Sprintf(`
+			DELETE FROM %[1]s
+			WHERE %[2]s = $1 AND EXISTS (SELECT 1 FROM %[1]s WHERE %[2]s = $2)
+		`, table.name, table.column)
+	case len(table.conflict) > 0:
+		matches := make([]string, len(table.conflict))
+		for i, col := range table.conflict {
+			matches[i] = fmt.Sprintf("t.%[1]s = %[2]s.%[1]s", col, table.name)
+		}
+		query = fmt.Sprintf(`
+			DELETE FROM %[1]s
+			WHERE %[2]s = $1 AND EXISTS (
+				SELECT 1 FROM %[1]s t WHERE t.%[2]s = $2 AND %[3]s
+			)
+		`, table.name, table.column, strings.Join(matches, " AND "))
+	default:
+		return 0, nil
+	}
+
+	result, err := tx.ExecContext(ctx, query, sourceID, targetID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve %s conflicts: %w", table.name, err)
+	}
+	return result.RowsAffected()
+}
+
+// mergeHoldings combines source positions into target positions in the same symbol and
+// moves the rest. Cost basis is per share, so a combined position takes the
+// quantity-weighted average of the two. It returns the number of combined and moved holdings.
+func mergeHoldings(ctx context.Context, tx *sql.Tx, targetID, sourceID string) (int64, int64, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT s.id, t.id, COALESCE(s.quantity, 0), s.cost_basis, COALESCE(t.quantity, 0), t.cost_basis
+		FROM holdings s
+		JOIN holdings t ON t.account_id = $1 AND t.symbol = s.symbol
+		WHERE s.account_id = $2 AND s.symbol IS NOT NULL
+	`, targetID, sourceID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to find overlapping holdings: %w", err)
+	}
+
+	type overlap struct {
+		sourceID, targetID       string
+		quantity, targetQuantity float64
+		cost, targetCost         *float64
+	}
+	var overlaps []overlap
+	for rows.Next() {
+		var o overlap
+		if err := rows.Scan(&o.sourceID, &o.targetID, &o.quantity, &o.cost, &o.targetQuantity, &o.targetCost); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("failed to scan holding: %w", err)
+		}
+		overlaps = append(overlaps, o)
+	}
+	rows.Close()
+
+	now := time.Now()
+	for _, o := range overlaps {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE holdings SET quantity = $1, cost_basis = $2, updated_at = $3 WHERE id = $4
+		`, o.targetQuantity+o.quantity, mergedCost(o.targetQuantity, o.targetCost, o.quantity, o.cost), now, o.targetID)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to combine holding: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE holding_transactions SET holding_id = $1 WHERE holding_id = $2`, o.targetID, o.sourceID); err != nil {
+			return 0, 0, fmt.Errorf("failed to move holding transactions: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM holdings WHERE id = $1`, o.sourceID); err != nil {
+			return 0, 0, fmt.Errorf("failed to remove combined holding: %w", err)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `UPDATE holdings SET account_id = $1 WHERE account_id = $2`, targetID, sourceID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to move holdings: %w", err)
+	}
+	moved, _ := result.RowsAffected()
+
+	return int64(len(overlaps)), moved, nil
+}
+
+// mergedCost returns the per-share cost of two combined positions, weighted by quantity.
+// A position without shares takes the other's cost; an unknown cost leaves the result unknown.
+func mergedCost(quantity float64, cost *float64, added float64, addedCost *float64) *float64 {
+	if quantity <= 0 {
+		return addedCost
+	}
+	if added <= 0 {
+		return cost
+	}
+	if cost == nil || addedCost == nil {
+		return nil
+	}
+	merged := (quantity**cost + added**addedCost) / (quantity + added)
+	return &merged
+}
+
+// accountDependencies counts the records that belong to an account
+func (s *Service) accountDependencies(ctx context.Context, q interface {
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}, accountID string) (*AccountDependencies, error) {
+	deps := &AccountDependencies{AccountID: accountID, Records: make(map[string]int64)}
+
+	if err := q.QueryRowContext(ctx, `SELECT name FROM accounts WHERE id = $1`, accountID).Scan(&deps.AccountName); err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	tables := append([]accountTable{{name: "holdings", column: "account_id"}}, accountTables...)
+	for _, table := range tables {
+		var count int64
+		err := q.QueryRowContext(ctx,
+			fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = $1", table.name, table.column), accountID,
+		).Scan(&count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s: %w", table.name, err)
+		}
+		if count > 0 {
+			deps.Records[table.name] += count
+			deps.Total += count
+		}
+	}
+
+	return deps, nil
+}
+
+// PreviewBulkDelete lists what deleting each account would remove
+func (s *Service) PreviewBulkDelete(ctx context.Context, req *BulkDeleteRequest) (*BulkDeletePreviewResponse, error) {
+	if len(req.AccountIDs) == 0 {
+		return nil, fmt.Errorf("at least one account ID is required")
+	}
+
+	resp := &BulkDeletePreviewResponse{Accounts: make([]AccountDependencies, 0, len(req.AccountIDs))}
+	for _, id := range req.AccountIDs {
+		if err := s.verifyAccountOwnership(ctx, id); err != nil {
+			return nil, fmt.Errorf("account %s: %w", id, err)
+		}
+		deps, err := s.accountDependencies(ctx, s.db, id)
+		if err != nil {
+			return nil, err
+		}
+		resp.Accounts = append(resp.Accounts, *deps)
+		resp.Total += deps.Total
+	}
+
+	return resp, nil
+}
+
+// BulkDelete deletes several accounts and everything that belongs to them. Either all
+// accounts are deleted or none are.
+func (s *Service) BulkDelete(ctx context.Context, req *BulkDeleteRequest) (*BulkDeleteResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if len(req.AccountIDs) == 0 {
+		return nil, fmt.Errorf("at least one account ID is required")
+	}
+	for _, id := range req.AccountIDs {
+		if err := s.verifyAccountOwnership(ctx, id); err != nil {
+			return nil, fmt.Errorf("account %s: %w", id, err)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	resp := &BulkDeleteResponse{Removed: make([]AccountDependencies, 0, len(req.AccountIDs))}
+	for _, id := range req.AccountIDs {
+		deps, err := s.accountDependencies(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		// Tables without foreign keys to accounts are cleaned up explicitly
+		cleanup := []string{
+			`DELETE FROM holding_transactions WHERE holding_id IN (SELECT id FROM holdings WHERE account_id = $1)`,
+			`DELETE FROM holdings WHERE account_id = $1`,
+			`DELETE FROM balances WHERE account_id = $1`,
+			`DELETE FROM sync_conflicts WHERE account_id = $1`,
+			`DELETE FROM synced_accounts WHERE local_account_id = $1`,
+			`UPDATE transactions SET account_id = NULL WHERE account_id = $1`,
+			`UPDATE recurring_expenses SET account_id = NULL WHERE account_id = $1`,
+		}
+		for _, query := range cleanup {
+			if _, err := tx.ExecContext(ctx, query, id); err != nil {
+				return nil, fmt.Errorf("failed to clean up account %s: %w", id, err)
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM accounts WHERE id = $1 AND user_id = $2`, id, userID); err != nil {
+			return nil, fmt.Errorf("failed to delete account %s: %w", id, err)
+		}
+
+		resp.Removed = append(resp.Removed, *deps)
+		resp.Deleted++
+	}
+	if err := balance.InvalidateSummaries(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit bulk delete: %w", err)
+	}
+
+	return resp, nil
+}
@@ -3,84 +3,18 @@ package account
 import (
 	"context"
 	"database/sql"
-	"database/sql/driver"
-	"encoding/json"
 	"fmt"
 	"math"
 	"time"
 
 	"money/internal/balance"
+	"money/internal/civil"
 
 	"github.com/google/uuid"
 )
 
 // Date represents a date without time, accepting YYYY-MM-DD format
-type Date struct {
-	time.Time
-}
-
-// UnmarshalJSON parses date from YYYY-MM-DD format
-func (d *Date) UnmarshalJSON(b []byte) error {
-	s := string(b)
-	// Remove quotes
-	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
-		s = s[1 : len(s)-1]
-	}
-	if s == "" || s == "null" {
-		return nil
-	}
-	t, err := time.Parse("2006-01-02", s)
-	if err != nil {
-		return err
-	}
-	d.Time = t
-	return nil
-}
-
-// MarshalJSON formats date as YYYY-MM-DD
-func (d Date) MarshalJSON() ([]byte, error) {
-	if d.Time.IsZero() {
-		return []byte("null"), nil
-	}
-	return json.Marshal(d.Time.Format("2006-01-02"))
-}
-
-// Value implements driver.Valuer for database storage
-func (d Date) Value() (driver.Value, error) {
-	if d.Time.IsZero() {
-		return nil, nil
-	}
-	return d.Time, nil
-}
-
-// Scan implements sql.Scanner for database retrieval
-func (d *Date) Scan(value interface{}) error {
-	if value == nil {
-		d.Time = time.Time{}
-		return nil
-	}
-	switch v := value.(type) {
-	case time.Time:
-		d.Time = v
-		return nil
-	case []byte:
-		t, err := time.Parse("2006-01-02", string(v))
-		if err != nil {
-			return err
-		}
-		d.Time = t
-		return nil
-	case string:
-		t, err := time.Parse("2006-01-02", v)
-		if err != nil {
-			return err
-		}
-		d.Time = t
-		return nil
-	default:
-		return fmt.Errorf("cannot scan %T into Date", value)
-	}
-}
+type Date = civil.Date
 
 // MortgageDetails represents detailed mortgage information
 type MortgageDetails struct {
@@ -121,6 +55,9 @@ type MortgagePayment struct {
 	BalanceAfter    float64   `json:"balance_after"`
 	Notes           string    `json:"notes,omitempty"`
 	CreatedAt       time.Time `json:"created_at"`
+
+	AutoPosted          bool `json:"auto_posted"`          // Posted from the amortization schedule on its due date
+	NeedsReconciliation bool `json:"needs_reconciliation"` // A real payment on the due date differed from the schedule
 }
 
 // AmortizationEntry represents a single entry in the amortization schedule
@@ -359,6 +296,23 @@ func calculateAmortizationSchedule(details *MortgageDetails) []AmortizationEntry
 	return schedule
 }
 
+// AmortizationFor returns the level payment and schedule of a loan that is not stored as an
+// account, with the same period arithmetic as mortgage schedules
+func AmortizationFor(principal, annualRate float64, amortizationMonths int, frequency string, start time.Time) (float64, []AmortizationEntry) {
+	periodsPerYear := getPeriodsPerYear(frequency)
+	payments := int(math.Ceil(float64(amortizationMonths) / (12.0 / float64(periodsPerYear))))
+	payment := AmortizedPayment(principal, annualRate, payments, periodsPerYear)
+	schedule := calculateAmortizationSchedule(&MortgageDetails{
+		OriginalAmount:     principal,
+		InterestRate:       annualRate,
+		StartDate:          Date{Time: start},
+		AmortizationMonths: amortizationMonths,
+		PaymentAmount:      payment,
+		PaymentFrequency:   frequency,
+	})
+	return payment, schedule
+}
+
 // getPeriodsPerYear returns the number of payment periods per year
 func getPeriodsPerYear(frequency string) int {
 	switch frequency {
@@ -391,13 +345,49 @@ func getNextPaymentDate(currentDate time.Time, frequency string) time.Time {
 	}
 }
 
-// RecordMortgagePayment records an actual mortgage payment
+// RecordMortgagePayment records an actual mortgage payment. A payment on a date that was
+// auto-posted replaces the auto-posted amounts rather than being recorded twice.
 func (s *Service) RecordMortgagePayment(ctx context.Context, accountID string, req *CreateMortgagePaymentRequest) (*MortgagePayment, error) {
 	// Verify account ownership
 	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
 		return nil, err
 	}
 
+	reconciled, err := s.reconcileAutoPostedPayment(ctx, "mortgage_payments", accountID, paymentAmounts{
+		Date:      req.PaymentDate,
+		Payment:   req.PaymentAmount,
+		Principal: req.PrincipalAmount,
+		Interest:  req.InterestAmount,
+		Extra:     req.ExtraPayment,
+		Notes:     req.Notes,
+	})
+	if err == nil {
+		if err := s.SyncMortgageBalance(ctx, accountID); err != nil {
+			fmt.Printf("Warning: failed to sync balance: %v\n", err)
+		}
+		return &MortgagePayment{
+			ID:                  reconciled.ID,
+			AccountID:           accountID,
+			PaymentDate:         req.PaymentDate,
+			PaymentAmount:       req.PaymentAmount,
+			PrincipalAmount:     req.PrincipalAmount,
+			InterestAmount:      req.InterestAmount,
+			ExtraPayment:        req.ExtraPayment,
+			BalanceAfter:        reconciled.BalanceAfter,
+			Notes:               req.Notes,
+			CreatedAt:           reconciled.CreatedAt,
+			NeedsReconciliation: reconciled.NeedsReconciliation,
+		}, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return s.recordMortgagePayment(ctx, accountID, req, false)
+}
+
+// recordMortgagePayment inserts a mortgage payment and the resulting balance entry
+func (s *Service) recordMortgagePayment(ctx context.Context, accountID string, req *CreateMortgagePaymentRequest, autoPosted bool) (*MortgagePayment, error) {
 	// Get current balance from either the last payment or the original mortgage amount
 	var currentBalance float64
 	err := s.db.QueryRowContext(ctx, `
@@ -422,11 +412,11 @@ func (s *Service) RecordMortgagePayment(ctx context.Context, accountID string, r
 		INSERT INTO mortgage_payments (
 			id, account_id, payment_date,
 			payment_amount, principal_amount, interest_amount, extra_payment,
-			balance_after, notes, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			balance_after, notes, created_at, auto_posted
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`, id, accountID, req.PaymentDate,
 		req.PaymentAmount, req.PrincipalAmount, req.InterestAmount, req.ExtraPayment,
-		balanceAfter, req.Notes, now)
+		balanceAfter, req.Notes, now, autoPosted)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to record payment: %w", err)
@@ -443,6 +433,7 @@ func (s *Service) RecordMortgagePayment(ctx context.Context, accountID string, r
 		BalanceAfter:    balanceAfter,
 		Notes:           req.Notes,
 		CreatedAt:       now,
+		AutoPosted:      autoPosted,
 	}
 
 	// Also create a balance entry so the mortgage balance appears in the accounts list
@@ -515,7 +506,7 @@ func (s *Service) GetMortgagePayments(ctx context.Context, accountID string) (*M
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT id, account_id, payment_date,
 			payment_amount, principal_amount, interest_amount, extra_payment,
-			balance_after, notes, created_at
+			balance_after, notes, created_at, auto_posted, needs_reconciliation
 		FROM mortgage_payments
 		WHERE account_id = $1
 		ORDER BY payment_date DESC
@@ -532,7 +523,7 @@ func (s *Service) GetMortgagePayments(ctx context.Context, accountID string) (*M
 		err := rows.Scan(
 			&payment.ID, &payment.AccountID, &payment.PaymentDate,
 			&payment.PaymentAmount, &payment.PrincipalAmount, &payment.InterestAmount, &payment.ExtraPayment,
-			&payment.BalanceAfter, &payment.Notes, &payment.CreatedAt,
+			&payment.BalanceAfter, &payment.Notes, &payment.CreatedAt, &payment.AutoPosted, &payment.NeedsReconciliation,
 		)
 		if err != nil {
 			continue
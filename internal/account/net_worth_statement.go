@@ -0,0 +1,103 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"money/internal/pdf"
+)
+
+// NetWorthStatement is a point-in-time list of assets and liabilities, the kind of
+// statement a lender asks for
+type NetWorthStatement struct {
+	GeneratedAt time.Time                          `json:"generated_at"`
+	Assets      []*AccountWithBalance              `json:"assets"`
+	Liabilities []*AccountWithBalance              `json:"liabilities"`
+	ByCurrency  map[string]*CurrencyBalanceSummary `json:"by_currency"`
+}
+
+// GetNetWorthStatement lists active accounts with their latest balances, split into
+// assets and liabilities and largest first. Accounts excluded from net worth are left out.
+func (s *Service) GetNetWorthStatement(ctx context.Context) (*NetWorthStatement, error) {
+	accounts, err := s.ListWithBalance(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &NetWorthStatement{
+		GeneratedAt: time.Now(),
+		Assets:      make([]*AccountWithBalance, 0),
+		Liabilities: make([]*AccountWithBalance, 0),
+		ByCurrency:  accounts.ByCurrency,
+	}
+	for _, acc := range accounts.Accounts {
+		if acc.ExcludeFromNetWorth {
+			continue
+		}
+		if acc.IsAsset {
+			statement.Assets = append(statement.Assets, acc)
+		} else {
+			statement.Liabilities = append(statement.Liabilities, acc)
+		}
+	}
+
+	largestFirst := func(list []*AccountWithBalance) {
+		sort.SliceStable(list, func(i, j int) bool {
+			return math.Abs(balanceOrZero(list[i])) > math.Abs(balanceOrZero(list[j]))
+		})
+	}
+	largestFirst(statement.Assets)
+	largestFirst(statement.Liabilities)
+
+	return statement, nil
+}
+
+// balanceOrZero returns an account's current balance, or zero when none is recorded
+func balanceOrZero(acc *AccountWithBalance) float64 {
+	if acc.CurrentBalance == nil {
+		return 0
+	}
+	return *acc.CurrentBalance
+}
+
+// RenderNetWorthStatementPDF renders the net worth statement as a printable PDF
+func RenderNetWorthStatementPDF(statement *NetWorthStatement) []byte {
+	doc := pdf.New("Statement of Net Worth")
+	doc.Text(fmt.Sprintf("As of %s. Balances are the latest recorded for each account.",
+		statement.GeneratedAt.Format("January 2, 2006")))
+
+	section := func(title string, accounts []*AccountWithBalance) {
+		doc.Heading(title)
+		if len(accounts) == 0 {
+			doc.Text("None")
+		}
+		for _, acc := range accounts {
+			value := "not recorded"
+			if acc.BalanceMasked {
+				value = "private"
+			} else if acc.CurrentBalance != nil {
+				value = fmt.Sprintf("%.2f %s", math.Abs(*acc.CurrentBalance), acc.Currency)
+			}
+			doc.Field(fmt.Sprintf("%s (%s)", acc.Name, institutionName(acc.Institution)), value)
+		}
+	}
+	section("Assets", statement.Assets)
+	section("Liabilities", statement.Liabilities)
+
+	currencies := make([]string, 0, len(statement.ByCurrency))
+	for currency := range statement.ByCurrency {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	doc.Heading("Net worth")
+	for _, currency := range currencies {
+		totals := statement.ByCurrency[currency]
+		doc.Field(currency, fmt.Sprintf("assets %.2f, liabilities %.2f, net %.2f",
+			totals.Assets, totals.Liabilities, totals.NetWorth))
+	}
+
+	return doc.Bytes()
+}
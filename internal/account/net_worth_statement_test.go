@@ -0,0 +1,39 @@
+package account
+
+import (
+	"testing"
+)
+
+func TestGetNetWorthStatement_SplitsAssetsAndLiabilitiesLargestFirst(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-net-worth-statement-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	smallID := CreateTestAccount(t, db, userID, AccountTypeChecking)
+	largeID := CreateTestAccount(t, db, userID, AccountTypeBrokerage)
+	loanID := CreateTestAccount(t, db, userID, AccountTypeLoan)
+	CreateTestBalance(t, db, smallID, 2500)
+	CreateTestBalance(t, db, largeID, 80000)
+	CreateTestBalance(t, db, loanID, -12000)
+	service := SetupAccountService(t, db)
+
+	// Act
+	statement, err := service.GetNetWorthStatement(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetNetWorthStatement failed: %v", err)
+	}
+	if len(statement.Assets) != 2 || statement.Assets[0].ID != largeID {
+		t.Fatalf("Expected 2 assets with the brokerage first, got %+v", statement.Assets)
+	}
+	if len(statement.Liabilities) != 1 || statement.Liabilities[0].ID != loanID {
+		t.Errorf("Expected the loan as the only liability, got %+v", statement.Liabilities)
+	}
+	if cad := statement.ByCurrency["CAD"]; cad == nil || cad.NetWorth != 70500 {
+		t.Errorf("Expected CAD net worth 70500, got %+v", cad)
+	}
+}
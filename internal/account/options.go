@@ -7,6 +7,9 @@ import (
 	"time"
 
 	"money/internal/auth"
+	"money/internal/civil"
+	"money/internal/database"
+	"money/internal/jurisdiction"
 
 	"github.com/google/uuid"
 )
@@ -53,6 +56,7 @@ type EquityGrant struct {
 	Currency       string     `json:"currency"`
 	GrantNumber    *string    `json:"grant_number,omitempty"`
 	Notes          *string    `json:"notes,omitempty"`
+	SellToCoverRate *float64  `json:"sell_to_cover_rate,omitempty"` // Share of each RSU vest sold to cover withholding
 	CreatedAt      time.Time  `json:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at"`
 }
@@ -63,6 +67,10 @@ type EquityGrantWithSummary struct {
 	VestedQuantity   int      `json:"vested_quantity"`
 	UnvestedQuantity int      `json:"unvested_quantity"`
 	ExercisedQuantity int     `json:"exercised_quantity"`
+	WithheldQuantity int      `json:"withheld_quantity"`   // Vested shares sold to cover withholding
+	NetVestedQuantity int     `json:"net_vested_quantity"` // Vested shares delivered after sell-to-cover
+	ExpiredQuantity  int      `json:"expired_quantity"`    // Options left unexercised at expiration
+	IsExpired        bool     `json:"is_expired"`
 	CurrentFMV       *float64 `json:"current_fmv,omitempty"`
 	VestedValue      float64  `json:"vested_value"`
 	UnvestedValue    float64  `json:"unvested_value"`
@@ -83,14 +91,17 @@ type VestingSchedule struct {
 
 // VestingEvent represents an actual vesting occurrence
 type VestingEvent struct {
-	ID        string        `json:"id"`
-	GrantID   string        `json:"grant_id"`
-	VestDate  Date          `json:"vest_date"`
-	Quantity  int           `json:"quantity"`
-	FMVAtVest float64       `json:"fmv_at_vest"`
-	Status    VestingStatus `json:"status"`
-	Notes     *string       `json:"notes,omitempty"`
-	CreatedAt time.Time     `json:"created_at"`
+	ID               string        `json:"id"`
+	GrantID          string        `json:"grant_id"`
+	VestDate         Date          `json:"vest_date"`
+	Quantity         int           `json:"quantity"`
+	FMVAtVest        float64       `json:"fmv_at_vest"`
+	Status           VestingStatus `json:"status"`
+	SharesWithheld   int           `json:"shares_withheld"`   // Sold at vest to cover withholding tax
+	NetQuantity      int           `json:"net_quantity"`      // Shares delivered after sell-to-cover
+	WithholdingValue float64       `json:"withholding_value"` // Shares withheld at the FMV at vest
+	Notes            *string       `json:"notes,omitempty"`
+	CreatedAt        time.Time     `json:"created_at"`
 }
 
 // EquityExercise represents an exercise of options
@@ -101,11 +112,15 @@ type EquityExercise struct {
 	Quantity       int             `json:"quantity"`
 	StrikePrice    float64         `json:"strike_price"`
 	FMVAtExercise  float64         `json:"fmv_at_exercise"`
+	FMVFromHistory bool            `json:"fmv_from_history"` // Looked up from FMV history, so later FMV edits are applied
 	ExerciseCost   float64         `json:"exercise_cost"`   // quantity * strike_price
 	TaxableBenefit float64         `json:"taxable_benefit"` // quantity * (fmv - strike)
 	ExerciseMethod *ExerciseMethod `json:"exercise_method,omitempty"`
-	Notes          *string         `json:"notes,omitempty"`
-	CreatedAt      time.Time       `json:"created_at"`
+	// Share of the taxable benefit withheld, paid from the sale on cashless and same-day exercises
+	WithholdingRate *float64           `json:"withholding_rate,omitempty"`
+	Notes           *string            `json:"notes,omitempty"`
+	Settlement      ExerciseSettlement `json:"settlement"`
+	CreatedAt       time.Time          `json:"created_at"`
 }
 
 // EquitySale represents a sale of shares
@@ -122,8 +137,12 @@ type EquitySale struct {
 	CapitalGain       float64   `json:"capital_gain"`
 	HoldingPeriodDays *int      `json:"holding_period_days,omitempty"`
 	IsQualified       *bool     `json:"is_qualified,omitempty"` // Canadian stock option deduction eligibility
+	Symbol            *string   `json:"symbol,omitempty"`       // Ticker, used to find replacement purchases in holdings
+	VestingEventID    *string   `json:"vesting_event_id,omitempty"` // Set on sales made automatically to cover withholding at vest
 	Notes             *string   `json:"notes,omitempty"`
 	CreatedAt         time.Time `json:"created_at"`
+
+	WashSale *WashSaleAdjustment `json:"wash_sale,omitempty"` // Set when a loss is denied by a repurchase
 }
 
 // FMVEntry represents a manual FMV entry
@@ -150,11 +169,14 @@ type CurrencySummary struct {
 
 // OptionsSummary provides a high-level overview of the options account
 type OptionsSummary struct {
+	AsOf              Date               `json:"as_of"`
 	TotalGrants       int                `json:"total_grants"`
 	TotalShares       int                `json:"total_shares"`
 	VestedShares      int                `json:"vested_shares"`
 	UnvestedShares    int                `json:"unvested_shares"`
 	ExercisedShares   int                `json:"exercised_shares"`
+	WithheldShares    int                `json:"withheld_shares"`
+	ExpiredShares     int                `json:"expired_shares"`
 	SoldShares        int                `json:"sold_shares"`
 	CurrentFMV        *float64           `json:"current_fmv,omitempty"`
 	VestedValue       float64            `json:"vested_value"`
@@ -173,6 +195,9 @@ type CurrencyTaxData struct {
 	StockOptionDeduction  float64 `json:"stock_option_deduction"`
 	QualifiedGains        float64 `json:"qualified_gains"`
 	NonQualifiedGains     float64 `json:"non_qualified_gains"`
+	DeniedLosses          float64 `json:"denied_losses"`
+	VestingIncome         float64 `json:"vesting_income"`
+	TaxWithheld           float64 `json:"tax_withheld"`
 	EstimatedTax          float64 `json:"estimated_tax"`
 }
 
@@ -184,8 +209,37 @@ type TaxSummary struct {
 	StockOptionDeduction  float64                    `json:"stock_option_deduction"`  // 50% of eligible benefit
 	QualifiedGains        float64                    `json:"qualified_gains"`         // Gains eligible for deduction
 	NonQualifiedGains     float64                    `json:"non_qualified_gains"`
+	DeniedLosses          float64                    `json:"denied_losses"`           // Wash-sale / superficial losses added back
+	VestingIncome         float64                    `json:"vesting_income"`          // RSU vests at FMV, taxed as employment income
+	TaxWithheld           float64                    `json:"tax_withheld"`            // Proceeds of sell-to-cover sales at vest
 	EstimatedTax          float64                    `json:"estimated_tax"`           // Rough estimate
 	ByCurrency            map[string]*CurrencyTaxData `json:"by_currency"`            // Per-currency breakdown
+	StartMonth            int                        `json:"start_month"`             // First month of the tax year
+	PeriodStart           Date                       `json:"period_start"`
+	PeriodEnd             Date                       `json:"period_end"`
+	IsPartial             bool                       `json:"is_partial"`              // Residency covers only part of the tax year
+	Prorations            []TaxProration             `json:"prorations,omitempty"`    // Exercises and vests split by residency
+}
+
+// TaxPeriod selects the dates a tax summary covers. The tax year starts on the first of
+// StartMonth in the summary's year. From and To narrow it to a partial year of residency,
+// such as the years of a move between countries.
+type TaxPeriod struct {
+	StartMonth int   // 1 for the calendar year; 0 for the jurisdiction's tax year
+	From       *Date // Residency start, nil for the start of the tax year
+	To         *Date // Residency end, nil for the end of the tax year
+}
+
+// TaxProration is an exercise or vest in a partial year, counted in the share of its
+// service period, from grant to vest, that fell within residency
+type TaxProration struct {
+	Kind       string  `json:"kind"` // exercise, vest
+	GrantID    string  `json:"grant_id"`
+	Date       Date    `json:"date"`
+	Currency   string  `json:"currency"`
+	FullAmount float64 `json:"full_amount"`
+	Factor     float64 `json:"factor"`
+	Amount     float64 `json:"amount"`
 }
 
 // Request/Response types
@@ -203,6 +257,7 @@ type CreateEquityGrantRequest struct {
 	Currency       string    `json:"currency"`
 	GrantNumber    *string   `json:"grant_number,omitempty"`
 	Notes          *string   `json:"notes,omitempty"`
+	SellToCoverRate *float64 `json:"sell_to_cover_rate,omitempty"`
 }
 
 // UpdateEquityGrantRequest represents the request to update a grant
@@ -217,6 +272,7 @@ type UpdateEquityGrantRequest struct {
 	Currency       *string    `json:"currency,omitempty"`
 	GrantNumber    *string    `json:"grant_number,omitempty"`
 	Notes          *string    `json:"notes,omitempty"`
+	SellToCoverRate *float64  `json:"sell_to_cover_rate,omitempty"`
 }
 
 // SetVestingScheduleRequest represents the request to set a vesting schedule
@@ -236,6 +292,7 @@ type RecordExerciseRequest struct {
 	Quantity       int             `json:"quantity"`
 	FMVAtExercise  float64         `json:"fmv_at_exercise"`
 	ExerciseMethod *ExerciseMethod `json:"exercise_method,omitempty"`
+	WithholdingRate *float64       `json:"withholding_rate,omitempty"`
 	Notes          *string         `json:"notes,omitempty"`
 }
 
@@ -245,6 +302,7 @@ type UpdateExerciseRequest struct {
 	Quantity       *int            `json:"quantity,omitempty"`
 	FMVAtExercise  *float64        `json:"fmv_at_exercise,omitempty"`
 	ExerciseMethod *ExerciseMethod `json:"exercise_method,omitempty"`
+	WithholdingRate *float64       `json:"withholding_rate,omitempty"`
 	Notes          *string         `json:"notes,omitempty"`
 }
 
@@ -257,6 +315,7 @@ type RecordSaleRequest struct {
 	Quantity   int      `json:"quantity"`
 	SalePrice  float64  `json:"sale_price"`
 	CostBasis  float64  `json:"cost_basis"`
+	Symbol     *string  `json:"symbol,omitempty"`
 	Notes      *string  `json:"notes,omitempty"`
 }
 
@@ -320,14 +379,30 @@ func (s *Service) CreateEquityGrant(ctx context.Context, accountID string, req *
 		return nil, err
 	}
 
-	// Validate grant type specific requirements
+	if err := validateEquityGrant(req); err != nil {
+		return nil, err
+	}
+
+	grant := newEquityGrant(accountID, req)
+	if err := insertEquityGrant(ctx, s.db, grant); err != nil {
+		return nil, err
+	}
+
+	return grant, nil
+}
+
+// validateEquityGrant checks grant type specific requirements
+func validateEquityGrant(req *CreateEquityGrantRequest) error {
 	if req.GrantType == GrantTypeISO || req.GrantType == GrantTypeNSO {
 		if req.StrikePrice == nil {
-			return nil, fmt.Errorf("strike_price is required for ISO/NSO grants")
+			return fmt.Errorf("strike_price is required for ISO/NSO grants")
 		}
 	}
+	return validateSellToCoverRate(req.GrantType, req.SellToCoverRate)
+}
 
-	id := uuid.New().String()
+// newEquityGrant builds a grant from a create request
+func newEquityGrant(accountID string, req *CreateEquityGrantRequest) *EquityGrant {
 	now := time.Now()
 
 	// Default currency to USD if not specified
@@ -336,22 +411,8 @@ func (s *Service) CreateEquityGrant(ctx context.Context, accountID string, req *
 		currency = "USD"
 	}
 
-	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO equity_grants (
-			id, account_id, grant_type, grant_date, quantity, strike_price,
-			fmv_at_grant, expiration_date, company_name, currency, grant_number, notes,
-			created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
-	`, id, accountID, req.GrantType, req.GrantDate, req.Quantity, req.StrikePrice,
-		req.FMVAtGrant, req.ExpirationDate, req.CompanyName, currency, req.GrantNumber, req.Notes,
-		now, now)
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to create equity grant: %w", err)
-	}
-
 	return &EquityGrant{
-		ID:             id,
+		ID:             uuid.New().String(),
 		AccountID:      accountID,
 		GrantType:      req.GrantType,
 		GrantDate:      req.GrantDate,
@@ -363,9 +424,28 @@ func (s *Service) CreateEquityGrant(ctx context.Context, accountID string, req *
 		Currency:       currency,
 		GrantNumber:    req.GrantNumber,
 		Notes:          req.Notes,
+		SellToCoverRate: req.SellToCoverRate,
 		CreatedAt:      now,
 		UpdatedAt:      now,
-	}, nil
+	}
+}
+
+// insertEquityGrant stores a new grant
+func insertEquityGrant(ctx context.Context, db database.Querier, grant *EquityGrant) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO equity_grants (
+			id, account_id, grant_type, grant_date, quantity, strike_price,
+			fmv_at_grant, expiration_date, company_name, currency, grant_number, notes,
+			sell_to_cover_rate, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`, grant.ID, grant.AccountID, grant.GrantType, grant.GrantDate, grant.Quantity, grant.StrikePrice,
+		grant.FMVAtGrant, grant.ExpirationDate, grant.CompanyName, grant.Currency, grant.GrantNumber, grant.Notes,
+		grant.SellToCoverRate, grant.CreatedAt, grant.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create equity grant: %w", err)
+	}
+	return nil
 }
 
 // GetEquityGrants retrieves all grants for an account
@@ -377,7 +457,7 @@ func (s *Service) GetEquityGrants(ctx context.Context, accountID string) (*Equit
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT id, account_id, grant_type, grant_date, quantity, strike_price,
 			fmv_at_grant, expiration_date, company_name, currency, grant_number, notes,
-			created_at, updated_at
+			sell_to_cover_rate, created_at, updated_at
 		FROM equity_grants
 		WHERE account_id = $1
 		ORDER BY grant_date DESC
@@ -394,7 +474,7 @@ func (s *Service) GetEquityGrants(ctx context.Context, accountID string) (*Equit
 		err := rows.Scan(
 			&grant.ID, &grant.AccountID, &grant.GrantType, &grant.GrantDate, &grant.Quantity,
 			&grant.StrikePrice, &grant.FMVAtGrant, &grant.ExpirationDate, &grant.CompanyName,
-			&grant.Currency, &grant.GrantNumber, &grant.Notes, &grant.CreatedAt, &grant.UpdatedAt,
+			&grant.Currency, &grant.GrantNumber, &grant.Notes, &grant.SellToCoverRate, &grant.CreatedAt, &grant.UpdatedAt,
 		)
 		if err != nil {
 			continue
@@ -416,7 +496,7 @@ func (s *Service) GetEquityGrant(ctx context.Context, grantID string) (*EquityGr
 	err := s.db.QueryRowContext(ctx, `
 		SELECT eg.id, eg.account_id, eg.grant_type, eg.grant_date, eg.quantity, eg.strike_price,
 			eg.fmv_at_grant, eg.expiration_date, eg.company_name, eg.currency, eg.grant_number, eg.notes,
-			eg.created_at, eg.updated_at
+			eg.sell_to_cover_rate, eg.created_at, eg.updated_at
 		FROM equity_grants eg
 		JOIN accounts a ON eg.account_id = a.id
 		WHERE eg.id = $1 AND a.user_id = $2
@@ -424,7 +504,7 @@ func (s *Service) GetEquityGrant(ctx context.Context, grantID string) (*EquityGr
 		&grant.ID, &grant.AccountID, &grant.GrantType, &grant.GrantDate, &grant.Quantity,
 		&grant.StrikePrice, &grant.FMVAtGrant, &grant.ExpirationDate, &grant.CompanyName,
 		&grant.Currency,
-		&grant.GrantNumber, &grant.Notes, &grant.CreatedAt, &grant.UpdatedAt,
+		&grant.GrantNumber, &grant.Notes, &grant.SellToCoverRate, &grant.CreatedAt, &grant.UpdatedAt,
 	)
 
 	if err != nil {
@@ -452,7 +532,9 @@ func (s *Service) UpdateEquityGrant(ctx context.Context, grantID string, req *Up
 	if req.Quantity != nil {
 		grant.Quantity = *req.Quantity
 	}
+	strikeChanged := false
 	if req.StrikePrice != nil {
+		strikeChanged = grant.StrikePrice == nil || *grant.StrikePrice != *req.StrikePrice
 		grant.StrikePrice = req.StrikePrice
 	}
 	if req.FMVAtGrant != nil {
@@ -473,22 +555,39 @@ func (s *Service) UpdateEquityGrant(ctx context.Context, grantID string, req *Up
 	if req.Notes != nil {
 		grant.Notes = req.Notes
 	}
+	if req.SellToCoverRate != nil {
+		// A zero rate turns sell-to-cover off
+		grant.SellToCoverRate = req.SellToCoverRate
+		if *req.SellToCoverRate == 0 {
+			grant.SellToCoverRate = nil
+		}
+	}
+	if err := validateSellToCoverRate(grant.GrantType, grant.SellToCoverRate); err != nil {
+		return nil, err
+	}
 
 	now := time.Now()
 	_, err = s.db.ExecContext(ctx, `
 		UPDATE equity_grants
 		SET grant_type = $2, grant_date = $3, quantity = $4, strike_price = $5,
 			fmv_at_grant = $6, expiration_date = $7, company_name = $8, currency = $9,
-			grant_number = $10, notes = $11, updated_at = $12
+			grant_number = $10, notes = $11, sell_to_cover_rate = $12, updated_at = $13
 		WHERE id = $1
 	`, grantID, grant.GrantType, grant.GrantDate, grant.Quantity, grant.StrikePrice,
 		grant.FMVAtGrant, grant.ExpirationDate, grant.CompanyName, grant.Currency,
-		grant.GrantNumber, grant.Notes, now)
+		grant.GrantNumber, grant.Notes, grant.SellToCoverRate, now)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to update equity grant: %w", err)
 	}
 
+	// Exercises priced at the old strike are recalculated in the background
+	if strikeChanged {
+		if err := queueEquityRecalc(ctx, s.db, grant.AccountID, Date{}, "strike price changed"); err != nil {
+			return nil, err
+		}
+	}
+
 	grant.UpdatedAt = now
 	return grant, nil
 }
@@ -519,31 +618,60 @@ func (s *Service) SetVestingSchedule(ctx context.Context, grantID string, req *S
 		return nil, err
 	}
 
-	// Validate schedule type specific requirements
+	if err := validateVestingSchedule(req); err != nil {
+		return nil, err
+	}
+
+	schedule := newVestingSchedule(grantID, req)
+
+	// Check if schedule exists
+	var existingID string
+	existingErr := s.db.QueryRowContext(ctx, `SELECT id FROM vesting_schedules WHERE grant_id = $1`, grantID).Scan(&existingID)
+	if existingErr == nil {
+		schedule.ID = existingID // Use existing ID for update
+	}
+
+	if err := upsertVestingSchedule(ctx, s.db, schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// validateVestingSchedule checks schedule type specific requirements
+func validateVestingSchedule(req *SetVestingScheduleRequest) error {
 	if req.ScheduleType == "time_based" {
 		if req.TotalVestingMonths == nil || *req.TotalVestingMonths <= 0 {
-			return nil, fmt.Errorf("total_vesting_months is required for time-based vesting")
+			return fmt.Errorf("total_vesting_months is required for time-based vesting")
 		}
 		if req.VestingFrequency == nil {
-			return nil, fmt.Errorf("vesting_frequency is required for time-based vesting")
+			return fmt.Errorf("vesting_frequency is required for time-based vesting")
 		}
 	} else if req.ScheduleType == "milestone" {
 		if req.MilestoneDescription == nil || *req.MilestoneDescription == "" {
-			return nil, fmt.Errorf("milestone_description is required for milestone-based vesting")
+			return fmt.Errorf("milestone_description is required for milestone-based vesting")
 		}
 	}
+	return nil
+}
 
-	id := uuid.New().String()
-	now := time.Now()
-
-	// Check if schedule exists
-	var existingID string
-	existingErr := s.db.QueryRowContext(ctx, `SELECT id FROM vesting_schedules WHERE grant_id = $1`, grantID).Scan(&existingID)
-	if existingErr == nil {
-		id = existingID // Use existing ID for update
+// newVestingSchedule builds a schedule for a grant from a request
+func newVestingSchedule(grantID string, req *SetVestingScheduleRequest) *VestingSchedule {
+	return &VestingSchedule{
+		ID:                   uuid.New().String(),
+		GrantID:              grantID,
+		ScheduleType:         req.ScheduleType,
+		CliffMonths:          req.CliffMonths,
+		TotalVestingMonths:   req.TotalVestingMonths,
+		VestingFrequency:     req.VestingFrequency,
+		MilestoneDescription: req.MilestoneDescription,
+		CreatedAt:            time.Now(),
 	}
+}
 
-	_, err = s.db.ExecContext(ctx, `
+// upsertVestingSchedule stores a grant's schedule, replacing any existing one
+func upsertVestingSchedule(ctx context.Context, db database.Querier, schedule *VestingSchedule) error {
+	_, err := db.ExecContext(ctx, `
 		INSERT INTO vesting_schedules (
 			id, grant_id, schedule_type, cliff_months, total_vesting_months,
 			vesting_frequency, milestone_description, created_at
@@ -554,23 +682,13 @@ func (s *Service) SetVestingSchedule(ctx context.Context, grantID string, req *S
 			total_vesting_months = excluded.total_vesting_months,
 			vesting_frequency = excluded.vesting_frequency,
 			milestone_description = excluded.milestone_description
-	`, id, grantID, req.ScheduleType, req.CliffMonths, req.TotalVestingMonths,
-		req.VestingFrequency, req.MilestoneDescription, now)
+	`, schedule.ID, schedule.GrantID, schedule.ScheduleType, schedule.CliffMonths, schedule.TotalVestingMonths,
+		schedule.VestingFrequency, schedule.MilestoneDescription, schedule.CreatedAt)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to set vesting schedule: %w", err)
+		return fmt.Errorf("failed to set vesting schedule: %w", err)
 	}
-
-	return &VestingSchedule{
-		ID:                   id,
-		GrantID:              grantID,
-		ScheduleType:         req.ScheduleType,
-		CliffMonths:          req.CliffMonths,
-		TotalVestingMonths:   req.TotalVestingMonths,
-		VestingFrequency:     req.VestingFrequency,
-		MilestoneDescription: req.MilestoneDescription,
-		CreatedAt:            now,
-	}, nil
+	return nil
 }
 
 // GetVestingSchedule retrieves the vesting schedule for a grant
@@ -600,8 +718,14 @@ func (s *Service) GetVestingSchedule(ctx context.Context, grantID string) (*Vest
 	return &schedule, nil
 }
 
-// computeVestingEvents generates vesting events from grant and schedule (pure computation, no DB)
-func computeVestingEvents(grant *EquityGrant, schedule *VestingSchedule) []VestingEvent {
+// computeVestingEvents generates vesting events with statuses as of today in the user's timezone
+func computeVestingEvents(ctx context.Context, grant *EquityGrant, schedule *VestingSchedule) []VestingEvent {
+	return computeVestingEventsAsOf(grant, schedule, civil.TodayIn(ctx).Time)
+}
+
+// computeVestingEventsAsOf generates vesting events with statuses evaluated at the given date.
+// Shares count as vested from the start of their vest date.
+func computeVestingEventsAsOf(grant *EquityGrant, schedule *VestingSchedule, asOf time.Time) []VestingEvent {
 	if schedule == nil || schedule.ScheduleType != "time_based" || schedule.TotalVestingMonths == nil {
 		return []VestingEvent{}
 	}
@@ -627,8 +751,6 @@ func computeVestingEvents(grant *EquityGrant, schedule *VestingSchedule) []Vesti
 	}
 
 	// Calculate shares for cliff and post-cliff vesting
-	now := time.Now()
-
 	var cliffShares int
 	var postCliffShares int
 	var postCliffEvents int
@@ -663,7 +785,7 @@ func computeVestingEvents(grant *EquityGrant, schedule *VestingSchedule) []Vesti
 		remainingShares -= cliffShares
 
 		status := VestingStatusPending
-		if cliffDate.Before(now) {
+		if !cliffDate.After(asOf) {
 			status = VestingStatusVested
 		}
 
@@ -675,6 +797,7 @@ func computeVestingEvents(grant *EquityGrant, schedule *VestingSchedule) []Vesti
 			FMVAtVest: grant.FMVAtGrant,
 			Status:    status,
 		})
+		withholdAtVest(grant, &events[len(events)-1])
 		period++
 	}
 
@@ -705,7 +828,7 @@ func computeVestingEvents(grant *EquityGrant, schedule *VestingSchedule) []Vesti
 			remainingShares -= vestShares
 
 			status := VestingStatusPending
-			if vestDate.Before(now) {
+			if !vestDate.After(asOf) {
 				status = VestingStatusVested
 			}
 
@@ -717,6 +840,7 @@ func computeVestingEvents(grant *EquityGrant, schedule *VestingSchedule) []Vesti
 				FMVAtVest: grant.FMVAtGrant,
 				Status:    status,
 			})
+			withholdAtVest(grant, &events[len(events)-1])
 			period++
 		}
 	}
@@ -724,6 +848,12 @@ func computeVestingEvents(grant *EquityGrant, schedule *VestingSchedule) []Vesti
 	return events
 }
 
+// VestingEventsFor returns the vesting events of a grant that is not stored, split into
+// shares the same way as stored grants
+func VestingEventsFor(grant *EquityGrant, schedule *VestingSchedule) []VestingEvent {
+	return computeVestingEventsAsOf(grant, schedule, grant.GrantDate.Time)
+}
+
 // GetVestingEvents computes vesting events for a grant based on its schedule
 func (s *Service) GetVestingEvents(ctx context.Context, grantID string) (*VestingEventsResponse, error) {
 	grant, err := s.GetEquityGrant(ctx, grantID)
@@ -737,7 +867,14 @@ func (s *Service) GetVestingEvents(ctx context.Context, grantID string) (*Vestin
 		return &VestingEventsResponse{Events: []VestingEvent{}}, nil
 	}
 
-	events := computeVestingEvents(grant, schedule)
+	events := computeVestingEvents(ctx, grant, schedule)
+
+	// Value each vest at the FMV in force on its vest date
+	fmvHistory, _ := s.GetFMVHistory(ctx, grant.AccountID)
+	if fmvHistory != nil {
+		applyFMVAtVest(events, fmvHistory.Entries, grant.Currency)
+	}
+
 	return &VestingEventsResponse{Events: events}, nil
 }
 
@@ -747,7 +884,7 @@ func (s *Service) GetUpcomingVestingEvents(ctx context.Context, accountID string
 		return nil, err
 	}
 
-	futureDate := time.Now().AddDate(0, 0, days)
+	futureDate := civil.TodayIn(ctx).AddDays(days).Time
 
 	// Get all grants for this account
 	grantsResp, err := s.GetEquityGrants(ctx, accountID)
@@ -763,7 +900,7 @@ func (s *Service) GetUpcomingVestingEvents(ctx context.Context, accountID string
 			continue // No schedule for this grant
 		}
 
-		events := computeVestingEvents(&grant, schedule)
+		events := computeVestingEvents(ctx, &grant, schedule)
 		for _, event := range events {
 			// Only include events up to futureDate
 			if !event.VestDate.Time.After(futureDate) {
@@ -784,50 +921,87 @@ func (s *Service) RecordExercise(ctx context.Context, grantID string, req *Recor
 		return nil, err
 	}
 
+	if err := validateExercisable(grant.GrantType, grant.StrikePrice); err != nil {
+		return nil, err
+	}
+	if err := validateWithholdingRate(req.WithholdingRate); err != nil {
+		return nil, err
+	}
+
+	// Default to the FMV in force on the exercise date when none is provided
+	fmvFromHistory := false
+	if req.FMVAtExercise == 0 {
+		fmvHistory, _ := s.GetFMVHistory(ctx, grant.AccountID)
+		if fmvHistory != nil {
+			if fmv, ok := fmvInEffect(fmvHistory.Entries, grant.Currency, req.ExerciseDate.Time); ok {
+				req.FMVAtExercise = fmv
+				fmvFromHistory = true
+			}
+		}
+	}
+
+	exercise := newEquityExercise(grantID, *grant.StrikePrice, req)
+	exercise.FMVFromHistory = fmvFromHistory
+	if err := insertEquityExercise(ctx, s.db, exercise); err != nil {
+		return nil, err
+	}
+
+	return exercise, nil
+}
+
+// validateExercisable checks that a grant is an option with a strike price
+func validateExercisable(grantType GrantType, strikePrice *float64) error {
 	// Verify this is an option (ISO/NSO)
-	if grant.GrantType != GrantTypeISO && grant.GrantType != GrantTypeNSO {
-		return nil, fmt.Errorf("exercises can only be recorded for ISO or NSO grants")
+	if grantType != GrantTypeISO && grantType != GrantTypeNSO {
+		return fmt.Errorf("exercises can only be recorded for ISO or NSO grants")
 	}
 
-	if grant.StrikePrice == nil {
-		return nil, fmt.Errorf("grant has no strike price")
+	if strikePrice == nil {
+		return fmt.Errorf("grant has no strike price")
 	}
+	return nil
+}
 
-	// Calculate exercise cost and taxable benefit
-	exerciseCost := float64(req.Quantity) * *grant.StrikePrice
-	taxableBenefit := float64(req.Quantity) * (req.FMVAtExercise - *grant.StrikePrice)
+// newEquityExercise builds an exercise, calculating its cost, taxable benefit and settlement
+func newEquityExercise(grantID string, strikePrice float64, req *RecordExerciseRequest) *EquityExercise {
+	exerciseCost := float64(req.Quantity) * strikePrice
+	taxableBenefit := float64(req.Quantity) * (req.FMVAtExercise - strikePrice)
 	if taxableBenefit < 0 {
 		taxableBenefit = 0
 	}
 
-	id := uuid.New().String()
-	now := time.Now()
+	exercise := &EquityExercise{
+		ID:              uuid.New().String(),
+		GrantID:         grantID,
+		ExerciseDate:    req.ExerciseDate,
+		Quantity:        req.Quantity,
+		StrikePrice:     strikePrice,
+		FMVAtExercise:   req.FMVAtExercise,
+		ExerciseCost:    exerciseCost,
+		TaxableBenefit:  taxableBenefit,
+		ExerciseMethod:  req.ExerciseMethod,
+		WithholdingRate: req.WithholdingRate,
+		Notes:           req.Notes,
+		CreatedAt:       time.Now(),
+	}
+	exercise.settle()
+	return exercise
+}
 
-	_, err = s.db.ExecContext(ctx, `
+// insertEquityExercise stores a new exercise
+func insertEquityExercise(ctx context.Context, db database.Querier, exercise *EquityExercise) error {
+	_, err := db.ExecContext(ctx, `
 		INSERT INTO equity_exercises (
 			id, grant_id, exercise_date, quantity, strike_price, fmv_at_exercise,
-			exercise_cost, taxable_benefit, exercise_method, notes, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-	`, id, grantID, req.ExerciseDate, req.Quantity, *grant.StrikePrice, req.FMVAtExercise,
-		exerciseCost, taxableBenefit, req.ExerciseMethod, req.Notes, now)
+			fmv_from_history, exercise_cost, taxable_benefit, exercise_method, withholding_rate, notes, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`, exercise.ID, exercise.GrantID, exercise.ExerciseDate, exercise.Quantity, exercise.StrikePrice, exercise.FMVAtExercise, exercise.FMVFromHistory,
+		exercise.ExerciseCost, exercise.TaxableBenefit, exercise.ExerciseMethod, exercise.WithholdingRate, exercise.Notes, exercise.CreatedAt)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to record exercise: %w", err)
+		return fmt.Errorf("failed to record exercise: %w", err)
 	}
-
-	return &EquityExercise{
-		ID:             id,
-		GrantID:        grantID,
-		ExerciseDate:   req.ExerciseDate,
-		Quantity:       req.Quantity,
-		StrikePrice:    *grant.StrikePrice,
-		FMVAtExercise:  req.FMVAtExercise,
-		ExerciseCost:   exerciseCost,
-		TaxableBenefit: taxableBenefit,
-		ExerciseMethod: req.ExerciseMethod,
-		Notes:          req.Notes,
-		CreatedAt:      now,
-	}, nil
+	return nil
 }
 
 // GetExercises retrieves all exercises for a grant
@@ -839,7 +1013,7 @@ func (s *Service) GetExercises(ctx context.Context, grantID string) (*ExercisesR
 
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT id, grant_id, exercise_date, quantity, strike_price, fmv_at_exercise,
-			exercise_cost, taxable_benefit, exercise_method, notes, created_at
+			fmv_from_history, exercise_cost, taxable_benefit, exercise_method, withholding_rate, notes, created_at
 		FROM equity_exercises
 		WHERE grant_id = $1
 		ORDER BY exercise_date DESC
@@ -855,12 +1029,13 @@ func (s *Service) GetExercises(ctx context.Context, grantID string) (*ExercisesR
 		var exercise EquityExercise
 		err := rows.Scan(
 			&exercise.ID, &exercise.GrantID, &exercise.ExerciseDate, &exercise.Quantity,
-			&exercise.StrikePrice, &exercise.FMVAtExercise, &exercise.ExerciseCost,
-			&exercise.TaxableBenefit, &exercise.ExerciseMethod, &exercise.Notes, &exercise.CreatedAt,
+			&exercise.StrikePrice, &exercise.FMVAtExercise, &exercise.FMVFromHistory, &exercise.ExerciseCost,
+			&exercise.TaxableBenefit, &exercise.ExerciseMethod, &exercise.WithholdingRate, &exercise.Notes, &exercise.CreatedAt,
 		)
 		if err != nil {
 			continue
 		}
+		exercise.settle()
 		exercises = append(exercises, exercise)
 	}
 
@@ -875,7 +1050,7 @@ func (s *Service) GetAllExercises(ctx context.Context, accountID string) (*Exerc
 
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT e.id, e.grant_id, e.exercise_date, e.quantity, e.strike_price, e.fmv_at_exercise,
-			e.exercise_cost, e.taxable_benefit, e.exercise_method, e.notes, e.created_at
+			e.fmv_from_history, e.exercise_cost, e.taxable_benefit, e.exercise_method, e.withholding_rate, e.notes, e.created_at
 		FROM equity_exercises e
 		JOIN equity_grants g ON e.grant_id = g.id
 		WHERE g.account_id = $1
@@ -892,12 +1067,13 @@ func (s *Service) GetAllExercises(ctx context.Context, accountID string) (*Exerc
 		var exercise EquityExercise
 		err := rows.Scan(
 			&exercise.ID, &exercise.GrantID, &exercise.ExerciseDate, &exercise.Quantity,
-			&exercise.StrikePrice, &exercise.FMVAtExercise, &exercise.ExerciseCost,
-			&exercise.TaxableBenefit, &exercise.ExerciseMethod, &exercise.Notes, &exercise.CreatedAt,
+			&exercise.StrikePrice, &exercise.FMVAtExercise, &exercise.FMVFromHistory, &exercise.ExerciseCost,
+			&exercise.TaxableBenefit, &exercise.ExerciseMethod, &exercise.WithholdingRate, &exercise.Notes, &exercise.CreatedAt,
 		)
 		if err != nil {
 			continue
 		}
+		exercise.settle()
 		exercises = append(exercises, exercise)
 	}
 
@@ -909,15 +1085,15 @@ func (s *Service) GetExercise(ctx context.Context, exerciseID string) (*EquityEx
 	var exercise EquityExercise
 	err := s.db.QueryRowContext(ctx, `
 		SELECT e.id, e.grant_id, e.exercise_date, e.quantity, e.strike_price, e.fmv_at_exercise,
-			e.exercise_cost, e.taxable_benefit, e.exercise_method, e.notes, e.created_at
+			e.fmv_from_history, e.exercise_cost, e.taxable_benefit, e.exercise_method, e.withholding_rate, e.notes, e.created_at
 		FROM equity_exercises e
 		JOIN equity_grants g ON e.grant_id = g.id
 		JOIN accounts a ON g.account_id = a.id
 		WHERE e.id = $1
 	`, exerciseID).Scan(
 		&exercise.ID, &exercise.GrantID, &exercise.ExerciseDate, &exercise.Quantity,
-		&exercise.StrikePrice, &exercise.FMVAtExercise, &exercise.ExerciseCost,
-		&exercise.TaxableBenefit, &exercise.ExerciseMethod, &exercise.Notes, &exercise.CreatedAt,
+		&exercise.StrikePrice, &exercise.FMVAtExercise, &exercise.FMVFromHistory, &exercise.ExerciseCost,
+		&exercise.TaxableBenefit, &exercise.ExerciseMethod, &exercise.WithholdingRate, &exercise.Notes, &exercise.CreatedAt,
 	)
 
 	if err != nil {
@@ -927,6 +1103,7 @@ func (s *Service) GetExercise(ctx context.Context, exerciseID string) (*EquityEx
 		return nil, fmt.Errorf("failed to get exercise: %w", err)
 	}
 
+	exercise.settle()
 	return &exercise, nil
 }
 
@@ -952,30 +1129,40 @@ func (s *Service) UpdateExercise(ctx context.Context, exerciseID string, req *Up
 		exercise.Quantity = *req.Quantity
 	}
 	if req.FMVAtExercise != nil {
+		// An FMV entered by hand is no longer kept in step with the FMV history
 		exercise.FMVAtExercise = *req.FMVAtExercise
+		exercise.FMVFromHistory = false
 	}
 	if req.ExerciseMethod != nil {
 		exercise.ExerciseMethod = req.ExerciseMethod
 	}
+	if req.WithholdingRate != nil {
+		if err := validateWithholdingRate(req.WithholdingRate); err != nil {
+			return nil, err
+		}
+		exercise.WithholdingRate = req.WithholdingRate
+	}
 	if req.Notes != nil {
 		exercise.Notes = req.Notes
 	}
 
 	// Recalculate exercise cost and taxable benefit
+	exercise.StrikePrice = *grant.StrikePrice
 	exercise.ExerciseCost = float64(exercise.Quantity) * *grant.StrikePrice
 	exercise.TaxableBenefit = float64(exercise.Quantity) * (exercise.FMVAtExercise - *grant.StrikePrice)
 	if exercise.TaxableBenefit < 0 {
 		exercise.TaxableBenefit = 0
 	}
+	exercise.settle()
 
 	// Update in database
 	_, err = s.db.ExecContext(ctx, `
 		UPDATE equity_exercises SET
-			exercise_date = $1, quantity = $2, fmv_at_exercise = $3,
-			exercise_cost = $4, taxable_benefit = $5, exercise_method = $6, notes = $7
-		WHERE id = $8
-	`, exercise.ExerciseDate, exercise.Quantity, exercise.FMVAtExercise,
-		exercise.ExerciseCost, exercise.TaxableBenefit, exercise.ExerciseMethod, exercise.Notes, exerciseID)
+			exercise_date = $1, quantity = $2, strike_price = $3, fmv_at_exercise = $4, fmv_from_history = $5,
+			exercise_cost = $6, taxable_benefit = $7, exercise_method = $8, withholding_rate = $9, notes = $10
+		WHERE id = $11
+	`, exercise.ExerciseDate, exercise.Quantity, exercise.StrikePrice, exercise.FMVAtExercise, exercise.FMVFromHistory,
+		exercise.ExerciseCost, exercise.TaxableBenefit, exercise.ExerciseMethod, exercise.WithholdingRate, exercise.Notes, exerciseID)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to update exercise: %w", err)
@@ -1032,16 +1219,16 @@ func (s *Service) RecordSale(ctx context.Context, accountID string, req *RecordS
 	_, err := s.db.ExecContext(ctx, `
 		INSERT INTO equity_sales (
 			id, account_id, grant_id, exercise_id, sale_date, quantity, sale_price,
-			total_proceeds, cost_basis, capital_gain, holding_period_days, is_qualified, notes, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			total_proceeds, cost_basis, capital_gain, holding_period_days, is_qualified, symbol, notes, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 	`, id, accountID, req.GrantID, req.ExerciseID, req.SaleDate, req.Quantity, req.SalePrice,
-		totalProceeds, req.CostBasis, capitalGain, holdingPeriodDays, isQualified, req.Notes, now)
+		totalProceeds, req.CostBasis, capitalGain, holdingPeriodDays, isQualified, req.Symbol, req.Notes, now)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to record sale: %w", err)
 	}
 
-	return &EquitySale{
+	sale := &EquitySale{
 		ID:                id,
 		AccountID:         accountID,
 		GrantID:           req.GrantID,
@@ -1054,9 +1241,21 @@ func (s *Service) RecordSale(ctx context.Context, accountID string, req *RecordS
 		CapitalGain:       capitalGain,
 		HoldingPeriodDays: holdingPeriodDays,
 		IsQualified:       isQualified,
+		Symbol:            req.Symbol,
 		Notes:             req.Notes,
 		CreatedAt:         now,
-	}, nil
+	}
+
+	// Flag losses made superficial by repurchases around the sale
+	checker, err := s.newWashSaleChecker(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if sale.WashSale, err = checker.check(ctx, sale); err != nil {
+		return nil, err
+	}
+
+	return sale, nil
 }
 
 // GetSales retrieves all sales for an account
@@ -1067,7 +1266,7 @@ func (s *Service) GetSales(ctx context.Context, accountID string) (*SalesRespons
 
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT id, account_id, grant_id, exercise_id, sale_date, quantity, sale_price,
-			total_proceeds, cost_basis, capital_gain, holding_period_days, is_qualified, notes, created_at
+			total_proceeds, cost_basis, capital_gain, holding_period_days, is_qualified, symbol, vesting_event_id, notes, created_at
 		FROM equity_sales
 		WHERE account_id = $1
 		ORDER BY sale_date DESC
@@ -1084,7 +1283,7 @@ func (s *Service) GetSales(ctx context.Context, accountID string) (*SalesRespons
 		err := rows.Scan(
 			&sale.ID, &sale.AccountID, &sale.GrantID, &sale.ExerciseID, &sale.SaleDate, &sale.Quantity,
 			&sale.SalePrice, &sale.TotalProceeds, &sale.CostBasis, &sale.CapitalGain,
-			&sale.HoldingPeriodDays, &sale.IsQualified, &sale.Notes, &sale.CreatedAt,
+			&sale.HoldingPeriodDays, &sale.IsQualified, &sale.Symbol, &sale.VestingEventID, &sale.Notes, &sale.CreatedAt,
 		)
 		if err != nil {
 			continue
@@ -1092,6 +1291,16 @@ func (s *Service) GetSales(ctx context.Context, accountID string) (*SalesRespons
 		sales = append(sales, sale)
 	}
 
+	checker, err := s.newWashSaleChecker(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sales {
+		if sales[i].WashSale, err = checker.check(ctx, &sales[i]); err != nil {
+			return nil, err
+		}
+	}
+
 	return &SalesResponse{Sales: sales}, nil
 }
 
@@ -1100,13 +1309,13 @@ func (s *Service) GetSale(ctx context.Context, saleID string) (*EquitySale, erro
 	var sale EquitySale
 	err := s.db.QueryRowContext(ctx, `
 		SELECT id, account_id, grant_id, exercise_id, sale_date, quantity, sale_price,
-			total_proceeds, cost_basis, capital_gain, holding_period_days, is_qualified, notes, created_at
+			total_proceeds, cost_basis, capital_gain, holding_period_days, is_qualified, symbol, vesting_event_id, notes, created_at
 		FROM equity_sales
 		WHERE id = $1
 	`, saleID).Scan(
 		&sale.ID, &sale.AccountID, &sale.GrantID, &sale.ExerciseID, &sale.SaleDate, &sale.Quantity,
 		&sale.SalePrice, &sale.TotalProceeds, &sale.CostBasis, &sale.CapitalGain,
-		&sale.HoldingPeriodDays, &sale.IsQualified, &sale.Notes, &sale.CreatedAt,
+		&sale.HoldingPeriodDays, &sale.IsQualified, &sale.Symbol, &sale.VestingEventID, &sale.Notes, &sale.CreatedAt,
 	)
 
 	if err != nil {
@@ -1221,6 +1430,11 @@ func (s *Service) RecordFMV(ctx context.Context, accountID string, req *RecordFM
 		return nil, fmt.Errorf("failed to record FMV: %w", err)
 	}
 
+	// Exercises that took their FMV from the history on or after this date are recalculated
+	if err := queueEquityRecalc(ctx, s.db, accountID, req.EffectiveDate, "FMV changed"); err != nil {
+		return nil, err
+	}
+
 	return &FMVEntry{
 		ID:            id,
 		AccountID:     accountID,
@@ -1257,10 +1471,13 @@ func (s *Service) GetFMVHistory(ctx context.Context, accountID string) (*FMVHist
 			&entry.ID, &entry.AccountID, &entry.Currency, &entry.EffectiveDate, &entry.FMVPerShare, &entry.Notes, &entry.CreatedAt,
 		)
 		if err != nil {
-			continue
+			return nil, fmt.Errorf("failed to scan FMV entry: %w", err)
 		}
 		entries = append(entries, entry)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read FMV history: %w", err)
+	}
 
 	return &FMVHistoryResponse{Entries: entries}, nil
 }
@@ -1323,35 +1540,152 @@ func (s *Service) GetCurrentFMVByCurrency(ctx context.Context, accountID string,
 	return &entry, nil
 }
 
+// fmvInEffect returns the FMV per share in force for a currency on the given date,
+// i.e. the entry with the latest effective date on or before that date
+func fmvInEffect(entries []FMVEntry, currency string, date time.Time) (float64, bool) {
+	if currency == "" {
+		currency = "USD"
+	}
+
+	var found *FMVEntry
+	for i := range entries {
+		entry := &entries[i]
+		if entry.Currency != currency || entry.EffectiveDate.Time.After(date) {
+			continue
+		}
+		if found == nil || entry.EffectiveDate.Time.After(found.EffectiveDate.Time) {
+			found = entry
+		}
+	}
+
+	if found == nil {
+		return 0, false
+	}
+	return found.FMVPerShare, true
+}
+
+// latestFMVInEffect returns the most recent FMV entry on or before the given date across all currencies
+func latestFMVInEffect(entries []FMVEntry, date time.Time) *FMVEntry {
+	var found *FMVEntry
+	for i := range entries {
+		entry := &entries[i]
+		if entry.EffectiveDate.Time.After(date) {
+			continue
+		}
+		if found == nil || entry.EffectiveDate.Time.After(found.EffectiveDate.Time) {
+			found = entry
+		}
+	}
+	return found
+}
+
+// applyFMVAtVest sets each event's FMV to the value in force on its vest date,
+// leaving the grant-date FMV in place when no entry precedes the vest
+func applyFMVAtVest(events []VestingEvent, entries []FMVEntry, currency string) {
+	for i := range events {
+		if fmv, ok := fmvInEffect(entries, currency, events[i].VestDate.Time); ok {
+			events[i].FMVAtVest = fmv
+			events[i].WithholdingValue = float64(events[i].SharesWithheld) * fmv
+		}
+	}
+}
+
 // Summary/Analytics Operations
 
 // GetOptionsSummary returns a high-level summary of the options account
 func (s *Service) GetOptionsSummary(ctx context.Context, accountID string) (*OptionsSummary, error) {
+	return s.GetOptionsSummaryAsOf(ctx, accountID, civil.TodayIn(ctx).Time)
+}
+
+// GetOptionsSummaryAsOf returns the options summary as it stood on the given date,
+// using the vesting status, exercises, sales and FMV in force on that date
+func (s *Service) GetOptionsSummaryAsOf(ctx context.Context, accountID string, asOf time.Time) (*OptionsSummary, error) {
 	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
 		return nil, err
 	}
 
+	data, err := s.loadOptionsData(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return summarizeOptions(data, asOf), nil
+}
+
+// optionsData holds an account's equity records, so summaries for many dates can be
+// computed without reloading them
+type optionsData struct {
+	grants     []EquityGrant
+	schedules  map[string]*VestingSchedule
+	exercises  map[string][]EquityExercise
+	sales      []EquitySale
+	fmvEntries []FMVEntry
+}
+
+// loadOptionsData reads the grants, schedules, exercises, sales and FMV history of an account
+func (s *Service) loadOptionsData(ctx context.Context, accountID string) (*optionsData, error) {
+	data := &optionsData{
+		schedules: make(map[string]*VestingSchedule),
+		exercises: make(map[string][]EquityExercise),
+	}
+
+	// Get FMV history for per-currency FMV lookup
+	fmvHistory, _ := s.GetFMVHistory(ctx, accountID)
+	if fmvHistory != nil {
+		data.fmvEntries = fmvHistory.Entries
+	}
+
+	// Get all grants
+	grantsResp, err := s.GetEquityGrants(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	data.grants = grantsResp.Grants
+
+	for _, grant := range data.grants {
+		if schedule, err := s.GetVestingSchedule(ctx, grant.ID); err == nil {
+			data.schedules[grant.ID] = schedule
+		}
+
+		// Get exercises for options
+		if grant.GrantType == GrantTypeISO || grant.GrantType == GrantTypeNSO {
+			if exercisesResp, err := s.GetExercises(ctx, grant.ID); err == nil {
+				data.exercises[grant.ID] = exercisesResp.Exercises
+			}
+		}
+	}
+
+	// Get sold shares
+	if salesResp, err := s.GetSales(ctx, accountID); err == nil {
+		data.sales = salesResp.Sales
+	}
+
+	return data, nil
+}
+
+// summarizeOptions computes the options summary as it stood on the given date
+func summarizeOptions(data *optionsData, asOf time.Time) *OptionsSummary {
 	summary := &OptionsSummary{
+		AsOf:        Date{Time: asOf},
 		ByGrantType: make(map[string]int),
 		ByCurrency:  make(map[string]*CurrencySummary),
 		Grants:      make([]EquityGrantWithSummary, 0),
 	}
 
-	// Get FMV history for per-currency FMV lookup
-	fmvHistory, _ := s.GetFMVHistory(ctx, accountID)
+	fmvEntries := data.fmvEntries
 	fmvByCurrency := make(map[string]float64)
-	if fmvHistory != nil {
-		for _, entry := range fmvHistory.Entries {
-			// Only store the first (most recent) FMV for each currency
-			if _, exists := fmvByCurrency[entry.Currency]; !exists {
-				fmvByCurrency[entry.Currency] = entry.FMVPerShare
-			}
+	for _, entry := range fmvEntries {
+		if _, exists := fmvByCurrency[entry.Currency]; exists {
+			continue
+		}
+		// Only store the FMV in force on the as-of date for each currency
+		if fmv, ok := fmvInEffect(fmvEntries, entry.Currency, asOf); ok {
+			fmvByCurrency[entry.Currency] = fmv
 		}
 	}
 
 	// Get current FMV (legacy - uses most recent regardless of currency)
-	currentFMV, _ := s.GetCurrentFMV(ctx, accountID)
-	if currentFMV != nil {
+	if currentFMV := latestFMVInEffect(fmvEntries, asOf); currentFMV != nil {
 		summary.CurrentFMV = &currentFMV.FMVPerShare
 	}
 
@@ -1370,13 +1704,12 @@ func (s *Service) GetOptionsSummary(ctx context.Context, accountID string) (*Opt
 		return summary.ByCurrency[currency]
 	}
 
-	// Get all grants
-	grantsResp, err := s.GetEquityGrants(ctx, accountID)
-	if err != nil {
-		return nil, err
-	}
+	for _, grant := range data.grants {
+		// Grants issued after the as-of date did not exist yet
+		if grant.GrantDate.Time.After(asOf) {
+			continue
+		}
 
-	for _, grant := range grantsResp.Grants {
 		grantSummary := EquityGrantWithSummary{
 			EquityGrant: grant,
 		}
@@ -1394,11 +1727,11 @@ func (s *Service) GetOptionsSummary(ctx context.Context, accountID string) (*Opt
 		summary.TotalShares += grant.Quantity
 
 		// Get vesting summary for this grant
-		eventsResp, err := s.GetVestingEvents(ctx, grant.ID)
-		if err == nil {
-			for _, event := range eventsResp.Events {
+		if schedule, ok := data.schedules[grant.ID]; ok {
+			for _, event := range computeVestingEventsAsOf(&grant, schedule, asOf) {
 				if event.Status == VestingStatusVested {
 					grantSummary.VestedQuantity += event.Quantity
+					grantSummary.WithheldQuantity += event.SharesWithheld
 				} else if event.Status == VestingStatusPending {
 					grantSummary.UnvestedQuantity += event.Quantity
 				}
@@ -1409,15 +1742,14 @@ func (s *Service) GetOptionsSummary(ctx context.Context, accountID string) (*Opt
 		if grantSummary.VestedQuantity == 0 && grantSummary.UnvestedQuantity == 0 {
 			grantSummary.UnvestedQuantity = grant.Quantity
 		}
+		grantSummary.NetVestedQuantity = grantSummary.VestedQuantity - grantSummary.WithheldQuantity
 
 		// Get exercised quantity for options
-		if grant.GrantType == GrantTypeISO || grant.GrantType == GrantTypeNSO {
-			exercisesResp, err := s.GetExercises(ctx, grant.ID)
-			if err == nil {
-				for _, exercise := range exercisesResp.Exercises {
-					grantSummary.ExercisedQuantity += exercise.Quantity
-				}
+		for _, exercise := range data.exercises[grant.ID] {
+			if exercise.ExerciseDate.Time.After(asOf) {
+				continue
 			}
+			grantSummary.ExercisedQuantity += exercise.Quantity
 		}
 
 		// Calculate values using currency-specific FMV
@@ -1441,14 +1773,26 @@ func (s *Service) GetOptionsSummary(ctx context.Context, accountID string) (*Opt
 				grantSummary.IntrinsicValue = float64(grantSummary.VestedQuantity-grantSummary.ExercisedQuantity) * intrinsicPerShare
 			}
 		} else {
-			// For RSU/RSA, intrinsic value is the full vested value
-			grantSummary.IntrinsicValue = grantSummary.VestedValue
+			// For RSU/RSA, intrinsic value is the vested shares left after sell-to-cover
+			grantSummary.IntrinsicValue = float64(grantSummary.NetVestedQuantity) * fmv
+		}
+
+		// Options left unexercised at expiration are worthless; only exercised shares keep value
+		if grant.isExpiredOn(asOf) {
+			grantSummary.IsExpired = true
+			grantSummary.ExpiredQuantity = grantSummary.VestedQuantity - grantSummary.ExercisedQuantity + grantSummary.UnvestedQuantity
+			grantSummary.UnvestedQuantity = 0
+			grantSummary.VestedValue = float64(grantSummary.ExercisedQuantity) * fmv
+			grantSummary.UnvestedValue = 0
+			grantSummary.IntrinsicValue = 0
 		}
 
 		// Aggregate to overall summary (mixed currencies - for backward compatibility)
 		summary.VestedShares += grantSummary.VestedQuantity
 		summary.UnvestedShares += grantSummary.UnvestedQuantity
 		summary.ExercisedShares += grantSummary.ExercisedQuantity
+		summary.WithheldShares += grantSummary.WithheldQuantity
+		summary.ExpiredShares += grantSummary.ExpiredQuantity
 		summary.VestedValue += grantSummary.VestedValue
 		summary.UnvestedValue += grantSummary.UnvestedValue
 		summary.TotalIntrinsicValue += grantSummary.IntrinsicValue
@@ -1463,26 +1807,71 @@ func (s *Service) GetOptionsSummary(ctx context.Context, accountID string) (*Opt
 		summary.Grants = append(summary.Grants, grantSummary)
 	}
 
-	// Get sold shares
-	salesResp, err := s.GetSales(ctx, accountID)
-	if err == nil {
-		for _, sale := range salesResp.Sales {
-			summary.SoldShares += sale.Quantity
+	for _, sale := range data.sales {
+		if sale.SaleDate.Time.After(asOf) {
+			continue
 		}
+		summary.SoldShares += sale.Quantity
 	}
 
-	return summary, nil
+	return summary
 }
 
-// GetTaxSummary returns tax planning information for a specific year
+// GetTaxSummary returns tax planning information for a specific calendar year
 func (s *Service) GetTaxSummary(ctx context.Context, accountID string, year int) (*TaxSummary, error) {
+	return s.GetTaxSummaryForPeriod(ctx, accountID, year, TaxPeriod{StartMonth: 1})
+}
+
+// GetTaxSummaryForPeriod returns tax planning information for the tax year starting in the
+// given year. In a partial year, sales count only within residency, while exercises and
+// vests anywhere in the tax year are prorated by the share of their service in residency.
+func (s *Service) GetTaxSummaryForPeriod(ctx context.Context, accountID string, year int, period TaxPeriod) (*TaxSummary, error) {
 	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
 		return nil, err
 	}
 
+	startMonth := period.StartMonth
+	if startMonth == 0 {
+		startMonth = int(jurisdiction.FromContext(ctx).TaxYearStartMonth)
+	}
+	if startMonth < 1 || startMonth > 12 {
+		return nil, fmt.Errorf("start month must be between 1 and 12")
+	}
+	yearStart := time.Date(year, time.Month(startMonth), 1, 0, 0, 0, 0, time.UTC)
+	yearEnd := yearStart.AddDate(1, 0, -1)
+	from, to := yearStart, yearEnd
+	if period.From != nil && period.From.After(from) {
+		from = period.From.Time
+	}
+	if period.To != nil && period.To.Before(to) {
+		to = period.To.Time
+	}
+	if from.After(to) {
+		return nil, fmt.Errorf("residency dates must overlap the tax year %s to %s",
+			yearStart.Format("2006-01-02"), yearEnd.Format("2006-01-02"))
+	}
+	partial := !from.Equal(yearStart) || !to.Equal(yearEnd)
+
 	summary := &TaxSummary{
-		Year:       year,
-		ByCurrency: make(map[string]*CurrencyTaxData),
+		Year:        year,
+		ByCurrency:  make(map[string]*CurrencyTaxData),
+		StartMonth:  startMonth,
+		PeriodStart: Date{Time: from},
+		PeriodEnd:   Date{Time: to},
+		IsPartial:   partial,
+	}
+
+	// prorate scales an exercise or vest by its service within residency in a partial year
+	prorate := func(kind, grantID string, date, serviceStart, serviceEnd time.Time, currency string, amount float64) float64 {
+		if !partial {
+			return amount
+		}
+		factor := serviceOverlap(serviceStart, serviceEnd, from, to)
+		summary.Prorations = append(summary.Prorations, TaxProration{
+			Kind: kind, GrantID: grantID, Date: Date{Time: date}, Currency: currency,
+			FullAmount: amount, Factor: factor, Amount: amount * factor,
+		})
+		return amount * factor
 	}
 
 	// Helper to get or create currency data
@@ -1496,14 +1885,17 @@ func (s *Service) GetTaxSummary(ctx context.Context, accountID string, year int)
 		return summary.ByCurrency[currency]
 	}
 
-	// Get exercises for the year with currency
+	// Get exercises for the tax year with currency. Dates are stored as Go time strings, which
+	// strftime can't parse, so they're compared on the leading YYYY-MM-DD.
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT ee.taxable_benefit, eg.grant_type, eg.currency
+		SELECT ee.taxable_benefit, eg.grant_type, eg.currency, eg.id, ee.exercise_date, eg.grant_date,
+			vs.total_vesting_months
 		FROM equity_exercises ee
 		JOIN equity_grants eg ON ee.grant_id = eg.id
+		LEFT JOIN vesting_schedules vs ON vs.grant_id = eg.id
 		WHERE eg.account_id = $1
-		AND strftime('%Y', ee.exercise_date) = $2
-	`, accountID, fmt.Sprintf("%d", year))
+		AND substr(ee.exercise_date, 1, 10) BETWEEN $2 AND $3
+	`, accountID, yearStart.Format("2006-01-02"), yearEnd.Format("2006-01-02"))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get exercises: %w", err)
@@ -1514,9 +1906,24 @@ func (s *Service) GetTaxSummary(ctx context.Context, accountID string, year int)
 		var taxableBenefit float64
 		var grantType string
 		var currency string
-		if err := rows.Scan(&taxableBenefit, &grantType, &currency); err != nil {
-			continue
+		var grantID string
+		var exerciseDate, grantDate Date
+		var vestingMonths sql.NullInt64
+		if err := rows.Scan(&taxableBenefit, &grantType, &currency, &grantID, &exerciseDate, &grantDate,
+			&vestingMonths); err != nil {
+			return nil, fmt.Errorf("failed to scan exercise: %w", err)
+		}
+		// The options were earned from grant until they vested, or until exercise if sooner
+		serviceEnd := exerciseDate.Time
+		if vestingMonths.Valid {
+			if vested := grantDate.AddDate(0, int(vestingMonths.Int64), 0); vested.Before(serviceEnd) {
+				serviceEnd = vested
+			}
 		}
+		if currency == "" {
+			currency = "USD"
+		}
+		taxableBenefit = prorate("exercise", grantID, exerciseDate.Time, grantDate.Time, serviceEnd, currency, taxableBenefit)
 		summary.TotalTaxableBenefit += taxableBenefit
 		currencyData := getCurrencyData(currency)
 		currencyData.TotalTaxableBenefit += taxableBenefit
@@ -1525,29 +1932,66 @@ func (s *Service) GetTaxSummary(ctx context.Context, accountID string, year int)
 	// Stock option deduction (50% for Canadian tax purposes)
 	summary.StockOptionDeduction = summary.TotalTaxableBenefit * 0.5
 
-	// Get sales for the year with currency
+	// Get sales within residency with currency
 	salesRows, err := s.db.QueryContext(ctx, `
-		SELECT es.capital_gain, es.is_qualified, COALESCE(eg.currency, 'USD') as currency
+		SELECT es.id, es.grant_id, es.exercise_id, es.sale_date, es.quantity, es.symbol,
+			es.total_proceeds, es.capital_gain, es.is_qualified, es.vesting_event_id,
+			COALESCE(eg.currency, 'USD') as currency
 		FROM equity_sales es
 		LEFT JOIN equity_grants eg ON es.grant_id = eg.id
 		WHERE es.account_id = $1
-		AND strftime('%Y', es.sale_date) = $2
-	`, accountID, fmt.Sprintf("%d", year))
+		AND substr(es.sale_date, 1, 10) BETWEEN $2 AND $3
+	`, accountID, from.Format("2006-01-02"), to.Format("2006-01-02"))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sales: %w", err)
 	}
 	defer salesRows.Close()
 
+	type yearSale struct {
+		sale     EquitySale
+		currency string
+	}
+	yearSales := make([]yearSale, 0)
 	for salesRows.Next() {
-		var capitalGain float64
-		var isQualified *bool
-		var currency string
-		if err := salesRows.Scan(&capitalGain, &isQualified, &currency); err != nil {
-			continue
+		var ys yearSale
+		if err := salesRows.Scan(&ys.sale.ID, &ys.sale.GrantID, &ys.sale.ExerciseID, &ys.sale.SaleDate, &ys.sale.Quantity,
+			&ys.sale.Symbol, &ys.sale.TotalProceeds, &ys.sale.CapitalGain, &ys.sale.IsQualified, &ys.sale.VestingEventID,
+			&ys.currency); err != nil {
+			return nil, fmt.Errorf("failed to scan sale: %w", err)
 		}
+		yearSales = append(yearSales, ys)
+	}
+	salesRows.Close()
+
+	checker, err := s.newWashSaleChecker(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ys := range yearSales {
+		currencyData := getCurrencyData(ys.currency)
+
+		// Shares sold at vest to cover withholding pay the tax on the vest
+		if ys.sale.VestingEventID != nil {
+			summary.TaxWithheld += ys.sale.TotalProceeds
+			currencyData.TaxWithheld += ys.sale.TotalProceeds
+		}
+
+		// Losses denied by a repurchase in the wash-sale window don't count against gains
+		capitalGain := ys.sale.CapitalGain
+		washSale, err := checker.check(ctx, &ys.sale)
+		if err != nil {
+			return nil, err
+		}
+		if washSale != nil {
+			capitalGain = washSale.AdjustedGain
+			summary.DeniedLosses += washSale.DeniedLoss
+			currencyData.DeniedLosses += washSale.DeniedLoss
+		}
+		isQualified := ys.sale.IsQualified
+
 		summary.TotalCapitalGains += capitalGain
-		currencyData := getCurrencyData(currency)
 		currencyData.TotalCapitalGains += capitalGain
 
 		if isQualified != nil && *isQualified {
@@ -1559,6 +2003,19 @@ func (s *Service) GetTaxSummary(ctx context.Context, accountID string, year int)
 		}
 	}
 
+	// RSU vests are employment income at the FMV on the vest date
+	vests, err := s.vestsBetween(ctx, accountID, yearStart, yearEnd)
+	if err != nil {
+		return nil, err
+	}
+	for _, vest := range vests {
+		income := float64(vest.event.Quantity) * vest.event.FMVAtVest
+		income = prorate("vest", vest.grant.ID, vest.event.VestDate.Time, vest.grant.GrantDate.Time,
+			vest.event.VestDate.Time, vest.currency, income)
+		summary.VestingIncome += income
+		getCurrencyData(vest.currency).VestingIncome += income
+	}
+
 	// Calculate per-currency estimates
 	for _, currencyData := range summary.ByCurrency {
 		currencyData.StockOptionDeduction = currencyData.TotalTaxableBenefit * 0.5
@@ -1575,6 +2032,28 @@ func (s *Service) GetTaxSummary(ctx context.Context, accountID string, year int)
 	return summary, nil
 }
 
+// serviceOverlap returns the share of the service period from start to end that fell
+// within residency. Service on a single day counts fully when that day is in residency.
+func serviceOverlap(start, end, from, to time.Time) float64 {
+	if !end.After(start) {
+		if start.Before(from) || start.After(to) {
+			return 0
+		}
+		return 1
+	}
+	overlapStart, overlapEnd := start, end
+	if from.After(overlapStart) {
+		overlapStart = from
+	}
+	if to.Before(overlapEnd) {
+		overlapEnd = to
+	}
+	if !overlapEnd.After(overlapStart) {
+		return 0
+	}
+	return overlapEnd.Sub(overlapStart).Hours() / end.Sub(start).Hours()
+}
+
 // GetVestedValue returns the vested value for net worth calculation
 func (s *Service) GetVestedValue(ctx context.Context, accountID string) (float64, error) {
 	summary, err := s.GetOptionsSummary(ctx, accountID)
@@ -1586,3 +2065,97 @@ func (s *Service) GetVestedValue(ctx context.Context, accountID string) (float64
 	// This represents the economic value of vested, unexercised options
 	return summary.TotalIntrinsicValue, nil
 }
+
+// maxVestedValuePoints caps the length of a vested value history
+const maxVestedValuePoints = 600
+
+// VestedValuePoint is the vested equity value at the end of a month
+type VestedValuePoint struct {
+	Date           Date               `json:"date"`
+	VestedShares   int                `json:"vested_shares"`
+	VestedValue    float64            `json:"vested_value"`
+	IntrinsicValue float64            `json:"intrinsic_value"`
+	ByCurrency     map[string]float64 `json:"by_currency"` // Intrinsic value per currency
+}
+
+// VestedValueHistoryResponse is a monthly series of vested equity value
+type VestedValueHistoryResponse struct {
+	AccountID string             `json:"account_id"`
+	Points    []VestedValuePoint `json:"points"`
+}
+
+// GetVestedValueHistory returns the vested value at the end of each month between from
+// and to, using the vesting events, exercises and FMV in force at each point, so equity
+// can contribute a history line to net worth. A zero from starts at the earliest grant;
+// a zero to ends today. The last point is always the to date itself.
+func (s *Service) GetVestedValueHistory(ctx context.Context, accountID string, from, to time.Time) (*VestedValueHistoryResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	data, err := s.loadOptionsData(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &VestedValueHistoryResponse{
+		AccountID: accountID,
+		Points:    make([]VestedValuePoint, 0),
+	}
+
+	if to.IsZero() {
+		to = civil.TodayIn(ctx).Time
+	}
+	if from.IsZero() {
+		for _, grant := range data.grants {
+			if from.IsZero() || grant.GrantDate.Time.Before(from) {
+				from = grant.GrantDate.Time
+			}
+		}
+		if from.IsZero() {
+			return resp, nil
+		}
+	}
+	if from.After(to) {
+		return nil, fmt.Errorf("from date must not be after to date")
+	}
+
+	for _, date := range monthEnds(civil.DateOf(from), civil.DateOf(to)) {
+		summary := summarizeOptions(data, date.Time)
+		point := VestedValuePoint{
+			Date:           date,
+			VestedShares:   summary.VestedShares,
+			VestedValue:    summary.VestedValue,
+			IntrinsicValue: summary.TotalIntrinsicValue,
+			ByCurrency:     make(map[string]float64, len(summary.ByCurrency)),
+		}
+		for currency, cs := range summary.ByCurrency {
+			point.ByCurrency[currency] = cs.TotalIntrinsicValue
+		}
+		resp.Points = append(resp.Points, point)
+	}
+
+	return resp, nil
+}
+
+// monthEnds returns the last day of each month from the month of from up to to, ending
+// with to itself, keeping at most the most recent maxVestedValuePoints dates
+func monthEnds(from, to Date) []Date {
+	dates := make([]Date, 0)
+	for month := civil.NewDate(from.Year(), from.Month(), 1); month.Before(to.Time); {
+		next := civil.NewDate(month.Year(), month.Month()+1, 1)
+		end := next.AddDays(-1)
+		if !end.Before(to.Time) {
+			break
+		}
+		dates = append(dates, end)
+		month = next
+	}
+	dates = append(dates, to)
+
+	if len(dates) > maxVestedValuePoints {
+		dates = dates[len(dates)-maxVestedValuePoints:]
+	}
+	return dates
+}
+
@@ -1,6 +1,8 @@
 package account
 
 import (
+	"errors"
+	"math"
 	"testing"
 	"time"
 )
@@ -280,6 +282,78 @@ func TestRecordSale_Success(t *testing.T) {
 	}
 }
 
+func TestRecordSale_LossDeniedByRepurchase(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-wash-sale-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeStockOptions)
+	service := SetupAccountService(t, db)
+
+	strikePrice := 10.00
+	grant, err := service.CreateEquityGrant(ctx, accountID, &CreateEquityGrantRequest{
+		AccountID:   accountID,
+		GrantType:   GrantTypeNSO,
+		GrantDate:   Date{Time: time.Now().AddDate(-2, 0, 0)},
+		Quantity:    1000,
+		StrikePrice: &strikePrice,
+		FMVAtGrant:  15.00,
+		CompanyName: "Test Corp",
+		Currency:    "USD",
+	})
+	if err != nil {
+		t.Fatalf("CreateEquityGrant failed: %v", err)
+	}
+
+	saleDate := time.Now().AddDate(0, 0, -20)
+	// Exercising 40 more shares 10 days after the sale repurchases the same stock
+	if _, err := service.RecordExercise(ctx, grant.ID, &RecordExerciseRequest{
+		GrantID:       grant.ID,
+		ExerciseDate:  Date{Time: saleDate.AddDate(0, 0, 10)},
+		Quantity:      40,
+		FMVAtExercise: 12.00,
+	}); err != nil {
+		t.Fatalf("RecordExercise failed: %v", err)
+	}
+
+	// Act: sell 100 shares at a 1000 loss
+	sale, err := service.RecordSale(ctx, accountID, &RecordSaleRequest{
+		AccountID: accountID,
+		GrantID:   &grant.ID,
+		SaleDate:  Date{Time: saleDate},
+		Quantity:  100,
+		SalePrice: 20.00,
+		CostBasis: 3000.00,
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("RecordSale failed: %v", err)
+	}
+	if sale.WashSale == nil {
+		t.Fatal("Expected the loss to be flagged as a wash sale")
+	}
+	if sale.WashSale.ReplacementShares != 40 {
+		t.Errorf("Expected 40 replacement shares, got %f", sale.WashSale.ReplacementShares)
+	}
+	// 40 of 100 shares replaced: 400 of the 1000 loss is denied
+	if sale.WashSale.DeniedLoss != 400 || sale.WashSale.AdjustedGain != -600 {
+		t.Errorf("Expected denied loss 400 and adjusted gain -600, got %.2f and %.2f",
+			sale.WashSale.DeniedLoss, sale.WashSale.AdjustedGain)
+	}
+
+	summary, err := service.GetTaxSummary(ctx, accountID, saleDate.Year())
+	if err != nil {
+		t.Fatalf("GetTaxSummary failed: %v", err)
+	}
+	if summary.DeniedLosses != 400 {
+		t.Errorf("Expected tax summary denied losses 400, got %.2f", summary.DeniedLosses)
+	}
+}
+
 func TestRecordSale_UnauthorizedAccess(t *testing.T) {
 	db := SetupTestDB(t)
 	defer CleanupTestDB(t, db)
@@ -444,3 +518,1050 @@ func TestGetOptionsSummary_Success(t *testing.T) {
 		t.Errorf("Expected 1500 total shares, got %d", summary.TotalShares)
 	}
 }
+
+func TestFMVInEffect_UsesEntryInForceOnDate(t *testing.T) {
+	// Arrange
+	entries := []FMVEntry{
+		{Currency: "USD", EffectiveDate: Date{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}, FMVPerShare: 10},
+		{Currency: "USD", EffectiveDate: Date{Time: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)}, FMVPerShare: 14},
+		{Currency: "CAD", EffectiveDate: Date{Time: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}, FMVPerShare: 19},
+	}
+
+	// Act
+	before, okBefore := fmvInEffect(entries, "USD", time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC))
+	mid, okMid := fmvInEffect(entries, "USD", time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC))
+	onDate, okOnDate := fmvInEffect(entries, "USD", time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+
+	// Assert
+	if okBefore {
+		t.Errorf("Expected no FMV before first entry, got %.2f", before)
+	}
+	if !okMid || mid != 10 {
+		t.Errorf("Expected FMV 10 on 2024-06-30, got %.2f (found=%v)", mid, okMid)
+	}
+	if !okOnDate || onDate != 14 {
+		t.Errorf("Expected FMV 14 on 2024-07-01, got %.2f (found=%v)", onDate, okOnDate)
+	}
+}
+
+func TestComputeVestingEventsAsOf_VestsOnVestDate(t *testing.T) {
+	// Arrange
+	cliff, total, frequency := 12, 24, "monthly"
+	grant := &EquityGrant{ID: "grant-1", GrantDate: Date{Time: time.Date(2023, 3, 15, 0, 0, 0, 0, time.UTC)}, Quantity: 2400}
+	schedule := &VestingSchedule{ScheduleType: "time_based", CliffMonths: &cliff, TotalVestingMonths: &total, VestingFrequency: &frequency}
+
+	// Act
+	dayBefore := computeVestingEventsAsOf(grant, schedule, time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC))
+	onCliff := computeVestingEventsAsOf(grant, schedule, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC))
+
+	// Assert
+	if dayBefore[0].Status != VestingStatusPending {
+		t.Errorf("Expected cliff to be pending the day before, got %s", dayBefore[0].Status)
+	}
+	if onCliff[0].Status != VestingStatusVested {
+		t.Errorf("Expected cliff to vest on its vest date, got %s", onCliff[0].Status)
+	}
+	if onCliff[1].Status != VestingStatusPending {
+		t.Errorf("Expected the next vest to be pending, got %s", onCliff[1].Status)
+	}
+}
+
+func TestGetOptionsSummaryAsOf_UsesHistoricalFMV(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-summary-asof-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeStockOptions)
+	service := SetupAccountService(t, db)
+
+	grant, err := service.CreateEquityGrant(ctx, accountID, &CreateEquityGrantRequest{
+		AccountID:   accountID,
+		GrantType:   GrantTypeRSU,
+		GrantDate:   Date{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		Quantity:    1200,
+		FMVAtGrant:  5.00,
+		CompanyName: "Test Corp",
+		Currency:    "USD",
+	})
+	if err != nil {
+		t.Fatalf("CreateEquityGrant failed: %v", err)
+	}
+
+	totalMonths := 12
+	frequency := "monthly"
+	if _, err := service.SetVestingSchedule(ctx, grant.ID, &SetVestingScheduleRequest{
+		ScheduleType:       "time_based",
+		TotalVestingMonths: &totalMonths,
+		VestingFrequency:   &frequency,
+	}); err != nil {
+		t.Fatalf("SetVestingSchedule failed: %v", err)
+	}
+
+	service.RecordFMV(ctx, accountID, &RecordFMVRequest{
+		Currency:      "USD",
+		EffectiveDate: Date{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		FMVPerShare:   10.00,
+	})
+	service.RecordFMV(ctx, accountID, &RecordFMVRequest{
+		Currency:      "USD",
+		EffectiveDate: Date{Time: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+		FMVPerShare:   20.00,
+	})
+
+	// Act
+	asOf := time.Date(2020, 6, 15, 0, 0, 0, 0, time.UTC)
+	summary, err := service.GetOptionsSummaryAsOf(ctx, accountID, asOf)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetOptionsSummaryAsOf failed: %v", err)
+	}
+	if summary.VestedShares != 500 {
+		t.Errorf("Expected 500 vested shares as of 2020-06-15, got %d", summary.VestedShares)
+	}
+	if summary.CurrentFMV == nil || *summary.CurrentFMV != 10.00 {
+		t.Errorf("Expected FMV 10.00 in force as of 2020-06-15, got %v", summary.CurrentFMV)
+	}
+	if summary.VestedValue != 5000 {
+		t.Errorf("Expected vested value 5000, got %.2f", summary.VestedValue)
+	}
+}
+
+func TestGetVestedValueHistory_MonthlyPoints(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-value-history-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeStockOptions)
+	service := SetupAccountService(t, db)
+
+	grant, err := service.CreateEquityGrant(ctx, accountID, &CreateEquityGrantRequest{
+		AccountID:   accountID,
+		GrantType:   GrantTypeRSU,
+		GrantDate:   Date{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		Quantity:    1200,
+		FMVAtGrant:  5.00,
+		CompanyName: "Test Corp",
+		Currency:    "USD",
+	})
+	if err != nil {
+		t.Fatalf("CreateEquityGrant failed: %v", err)
+	}
+
+	totalMonths := 12
+	frequency := "monthly"
+	if _, err := service.SetVestingSchedule(ctx, grant.ID, &SetVestingScheduleRequest{
+		ScheduleType:       "time_based",
+		TotalVestingMonths: &totalMonths,
+		VestingFrequency:   &frequency,
+	}); err != nil {
+		t.Fatalf("SetVestingSchedule failed: %v", err)
+	}
+
+	service.RecordFMV(ctx, accountID, &RecordFMVRequest{
+		Currency:      "USD",
+		EffectiveDate: Date{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		FMVPerShare:   10.00,
+	})
+	service.RecordFMV(ctx, accountID, &RecordFMVRequest{
+		Currency:      "USD",
+		EffectiveDate: Date{Time: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+		FMVPerShare:   20.00,
+	})
+
+	// Act
+	history, err := service.GetVestedValueHistory(ctx, accountID, time.Time{}, time.Date(2021, 1, 15, 0, 0, 0, 0, time.UTC))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetVestedValueHistory failed: %v", err)
+	}
+	if len(history.Points) != 13 {
+		t.Fatalf("Expected 12 month ends and the end date, got %d points", len(history.Points))
+	}
+	june := history.Points[5]
+	if june.Date.String() != "2020-06-30" || june.VestedValue != 5000 {
+		t.Errorf("Expected vested value 5000 on 2020-06-30, got %.2f on %s", june.VestedValue, june.Date)
+	}
+	last := history.Points[12]
+	if last.Date.String() != "2021-01-15" || last.VestedValue != 24000 {
+		t.Errorf("Expected vested value 24000 on 2021-01-15, got %.2f on %s", last.VestedValue, last.Date)
+	}
+	if last.ByCurrency["USD"] != 24000 {
+		t.Errorf("Expected USD intrinsic value 24000, got %.2f", last.ByCurrency["USD"])
+	}
+}
+
+func TestBuildFMVChart_OverlaysVestsOnFMVHistory(t *testing.T) {
+	// Arrange: 400 RSUs vesting quarterly over a year, FMV raised once mid-year
+	grant := EquityGrant{
+		ID:        "grant-chart",
+		GrantType: GrantTypeRSU,
+		GrantDate: Date{Time: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		Quantity:  400,
+		Currency:  "USD",
+	}
+	totalMonths := 12
+	frequency := "quarterly"
+	data := &optionsData{
+		grants: []EquityGrant{grant},
+		schedules: map[string]*VestingSchedule{grant.ID: {
+			ScheduleType:       "time_based",
+			TotalVestingMonths: &totalMonths,
+			VestingFrequency:   &frequency,
+		}},
+		fmvEntries: []FMVEntry{
+			{Currency: "USD", EffectiveDate: Date{Time: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}, FMVPerShare: 10},
+			{Currency: "USD", EffectiveDate: Date{Time: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)}, FMVPerShare: 15},
+		},
+	}
+
+	// Act
+	chart := buildFMVChart("account-chart", data, Date{Time: time.Date(2023, 8, 15, 0, 0, 0, 0, time.UTC)})
+
+	// Assert: FMV dates 01-01 and 06-01, vests 04-01 and 07-01, then today
+	if len(chart.Series) != 1 || chart.Series[0].Currency != "USD" {
+		t.Fatalf("Expected one USD series, got %+v", chart.Series)
+	}
+	points := chart.Series[0].Points
+	if len(points) != 5 {
+		t.Fatalf("Expected 5 points, got %d: %+v", len(points), points)
+	}
+	if points[1].Date.String() != "2023-04-01" || points[1].SharesVested != 100 || points[1].VestedValue != 1000 {
+		t.Errorf("Expected 100 shares worth 1000 vesting on 2023-04-01, got %+v", points[1])
+	}
+	last := points[4]
+	if last.Date.String() != "2023-08-15" || last.VestedShares != 200 || *last.FMVPerShare != 15 || last.VestedValue != 3000 {
+		t.Errorf("Expected 200 vested shares worth 3000 today, got %+v", last)
+	}
+}
+
+func TestBuildVestingTimeline_BucketsCliffAndMonthlyVests(t *testing.T) {
+	// Arrange: 4800 options over four years with a one-year cliff, and CAD RSUs without a schedule
+	strike := 5.0
+	iso := EquityGrant{
+		ID:          "grant-timeline-iso",
+		GrantType:   GrantTypeISO,
+		GrantDate:   Date{Time: time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		Quantity:    4800,
+		StrikePrice: &strike,
+		FMVAtGrant:  5,
+		Currency:    "USD",
+	}
+	rsu := EquityGrant{
+		ID:         "grant-timeline-rsu",
+		GrantType:  GrantTypeRSU,
+		GrantDate:  Date{Time: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		Quantity:   120,
+		FMVAtGrant: 20,
+		Currency:   "CAD",
+	}
+	cliffMonths, totalMonths := 12, 48
+	frequency := "monthly"
+	data := &optionsData{
+		grants: []EquityGrant{iso, rsu},
+		schedules: map[string]*VestingSchedule{iso.ID: {
+			ScheduleType:       "time_based",
+			CliffMonths:        &cliffMonths,
+			TotalVestingMonths: &totalMonths,
+			VestingFrequency:   &frequency,
+		}},
+		exercises: map[string][]EquityExercise{},
+		fmvEntries: []FMVEntry{
+			{Currency: "USD", EffectiveDate: Date{Time: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)}, FMVPerShare: 15},
+		},
+	}
+
+	// Act
+	timeline := buildVestingTimeline("account-timeline", data, Date{Time: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)}, 1)
+
+	// Assert
+	if len(timeline.Grants) != 2 || len(timeline.Grants[0].Months) != 12 {
+		t.Fatalf("Expected 12 months for each of 2 grants, got %+v", timeline.Grants)
+	}
+	options := timeline.Grants[0]
+	if options.ValuePerShare != 10 || options.CliffDate == nil || options.CliffDate.String() != "2027-01-15" ||
+		options.FullyVestedDate == nil || options.FullyVestedDate.String() != "2030-01-15" {
+		t.Errorf("Expected $10 a share with a 2027-01-15 cliff, fully vested 2030-01-15, got %+v", options)
+	}
+	if first := options.Months[0]; first.Month != "2026-10" || first.UnvestedShares != 4800 || first.VestedShares != 0 {
+		t.Errorf("Expected nothing vested in 2026-10, got %+v", first)
+	}
+	if cliff := options.Months[3]; cliff.Month != "2027-01" || !cliff.IsCliff || cliff.SharesVesting != 1200 || cliff.ValueVesting != 12000 {
+		t.Errorf("Expected the 1200 share cliff worth 12000 in 2027-01, got %+v", cliff)
+	}
+	if feb := options.Months[4]; feb.SharesVesting != 100 || feb.VestedShares != 1300 || feb.UnvestedShares != 3500 || feb.VestedValue != 13000 {
+		t.Errorf("Expected 100 more shares vesting in 2027-02, got %+v", feb)
+	}
+	if len(timeline.Totals) != 2 || timeline.Totals[0].Currency != "CAD" || timeline.Totals[0].Months[0].UnvestedShares != 120 {
+		t.Errorf("Expected CAD totals kept apart with 120 unvested RSUs, got %+v", timeline.Totals)
+	}
+	if usd := timeline.Totals[1]; usd.Months[3].SharesVesting != 1200 || !usd.Months[3].IsCliff {
+		t.Errorf("Expected the USD totals to show the cliff, got %+v", usd.Months[3])
+	}
+}
+
+func TestProcessGrantExpirations_ZeroesExpiredOptions(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange: a fully vested NSO that expired 10 days ago with 100 of 1000 options exercised
+	userID := "test-user-grant-expiry-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeStockOptions)
+	service := SetupAccountService(t, db)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	strikePrice := 10.00
+	expiration := Date{Time: today.AddDate(0, 0, -10)}
+	grant, err := service.CreateEquityGrant(ctx, accountID, &CreateEquityGrantRequest{
+		AccountID:      accountID,
+		GrantType:      GrantTypeNSO,
+		GrantDate:      Date{Time: today.AddDate(-5, 0, 0)},
+		Quantity:       1000,
+		StrikePrice:    &strikePrice,
+		FMVAtGrant:     10.00,
+		ExpirationDate: &expiration,
+		CompanyName:    "Test Corp",
+		Currency:       "USD",
+	})
+	if err != nil {
+		t.Fatalf("CreateEquityGrant failed: %v", err)
+	}
+	totalMonths := 48
+	frequency := "monthly"
+	if _, err := service.SetVestingSchedule(ctx, grant.ID, &SetVestingScheduleRequest{
+		ScheduleType:       "time_based",
+		TotalVestingMonths: &totalMonths,
+		VestingFrequency:   &frequency,
+	}); err != nil {
+		t.Fatalf("SetVestingSchedule failed: %v", err)
+	}
+	if _, err := service.RecordExercise(ctx, grant.ID, &RecordExerciseRequest{
+		GrantID:       grant.ID,
+		ExerciseDate:  Date{Time: today.AddDate(-1, 0, 0)},
+		Quantity:      100,
+		FMVAtExercise: 15.00,
+	}); err != nil {
+		t.Fatalf("RecordExercise failed: %v", err)
+	}
+	service.RecordFMV(ctx, accountID, &RecordFMVRequest{
+		Currency:      "USD",
+		EffectiveDate: Date{Time: today.AddDate(-2, 0, 0)},
+		FMVPerShare:   20.00,
+	})
+
+	// Act
+	summary, err := service.GetOptionsSummary(ctx, accountID)
+	if err != nil {
+		t.Fatalf("GetOptionsSummary failed: %v", err)
+	}
+	processed, err := service.ProcessGrantExpirations(ctx, accountID)
+	if err != nil {
+		t.Fatalf("ProcessGrantExpirations failed: %v", err)
+	}
+	again, err := service.ProcessGrantExpirations(ctx, accountID)
+	if err != nil {
+		t.Fatalf("ProcessGrantExpirations failed: %v", err)
+	}
+
+	// Assert
+	if !summary.Grants[0].IsExpired || summary.ExpiredShares != 900 || summary.TotalIntrinsicValue != 0 {
+		t.Errorf("Expected 900 expired options and no intrinsic value, got %d expired and %.2f",
+			summary.ExpiredShares, summary.TotalIntrinsicValue)
+	}
+	if len(processed.Expirations) != 1 {
+		t.Fatalf("Expected one expiration recorded, got %d", len(processed.Expirations))
+	}
+	if x := processed.Expirations[0]; x.SharesExpired != 900 || x.IntrinsicValueLost != 9000 {
+		t.Errorf("Expected 900 options worth 9000 expired, got %d worth %.2f", x.SharesExpired, x.IntrinsicValueLost)
+	}
+	if len(again.Expirations) != 0 {
+		t.Errorf("Expected the expiration to be recorded once, got %d more", len(again.Expirations))
+	}
+}
+
+func TestGrantExpiryAlerts_NarrowestReminderWindow(t *testing.T) {
+	// Arrange: vested ISOs expiring in 25 days
+	today := Date{Time: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+	strikePrice := 5.00
+	expiration := Date{Time: time.Date(2024, 6, 26, 0, 0, 0, 0, time.UTC)}
+	totalMonths := 12
+	frequency := "annually"
+	grant := EquityGrant{
+		ID:             "grant-expiring",
+		GrantType:      GrantTypeISO,
+		GrantDate:      Date{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		Quantity:       500,
+		StrikePrice:    &strikePrice,
+		FMVAtGrant:     5.00,
+		ExpirationDate: &expiration,
+		Currency:       "USD",
+	}
+	data := &optionsData{
+		grants: []EquityGrant{grant},
+		schedules: map[string]*VestingSchedule{grant.ID: {
+			ScheduleType:       "time_based",
+			TotalVestingMonths: &totalMonths,
+			VestingFrequency:   &frequency,
+		}},
+		exercises: map[string][]EquityExercise{},
+		fmvEntries: []FMVEntry{
+			{Currency: "USD", EffectiveDate: Date{Time: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}, FMVPerShare: 8},
+		},
+	}
+
+	// Act
+	alerts := grantExpiryAlerts("account-expiring", data, today)
+
+	// Assert
+	if len(alerts) != 1 {
+		t.Fatalf("Expected one alert, got %d", len(alerts))
+	}
+	if a := alerts[0]; a.DaysRemaining != 25 || a.ReminderDays != 30 || a.UnexercisedShares != 500 || a.IntrinsicValue != 1500 {
+		t.Errorf("Expected 500 options worth 1500 in the 30 day window, got %+v", a)
+	}
+}
+
+func TestComputeHoldingPeriodThresholds_ISO(t *testing.T) {
+	// Arrange
+	grantDate := time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC)
+	exerciseDate := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// Act
+	thresholds := computeHoldingPeriodThresholds(GrantTypeISO, grantDate, exerciseDate)
+
+	// Assert
+	if len(thresholds) != 2 {
+		t.Fatalf("Expected 2 thresholds for ISO, got %d", len(thresholds))
+	}
+	if thresholds[0].Rule != HoldingPeriodRuleUSISO {
+		t.Errorf("Expected first rule %s, got %s", HoldingPeriodRuleUSISO, thresholds[0].Rule)
+	}
+	// One year from exercise (2025-06-01) is later than two years from grant (2025-01-10)
+	if !thresholds[0].ThresholdDate.Equal(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected US threshold 2025-06-01, got %s", thresholds[0].ThresholdDate.Format("2006-01-02"))
+	}
+	if !thresholds[1].ThresholdDate.Equal(grantDate.AddDate(0, 0, 730)) {
+		t.Errorf("Expected Canadian threshold 730 days from grant, got %s", thresholds[1].ThresholdDate.Format("2006-01-02"))
+	}
+}
+
+func TestComputeHoldingPeriodThresholds_RSUHasNone(t *testing.T) {
+	// Act
+	thresholds := computeHoldingPeriodThresholds(GrantTypeRSU, time.Now(), time.Now())
+
+	// Assert
+	if len(thresholds) != 0 {
+		t.Errorf("Expected no thresholds for RSU, got %d", len(thresholds))
+	}
+}
+
+func TestGetHoldingPeriodAlerts_UpcomingThreshold(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-holding-period-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeStockOptions)
+	service := SetupAccountService(t, db)
+
+	// Grant two years ago less 10 days, so the Canadian threshold is 10 days out
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	strikePrice := 1.00
+	grant, err := service.CreateEquityGrant(ctx, accountID, &CreateEquityGrantRequest{
+		AccountID:   accountID,
+		GrantType:   GrantTypeNSO,
+		GrantDate:   Date{Time: today.AddDate(0, 0, -720)},
+		Quantity:    1000,
+		StrikePrice: &strikePrice,
+		FMVAtGrant:  1.00,
+		CompanyName: "Test Corp",
+		Currency:    "CAD",
+	})
+	if err != nil {
+		t.Fatalf("CreateEquityGrant failed: %v", err)
+	}
+
+	if _, err := service.RecordExercise(ctx, grant.ID, &RecordExerciseRequest{
+		ExerciseDate:  Date{Time: today.AddDate(0, -1, 0)},
+		Quantity:      400,
+		FMVAtExercise: 3.00,
+	}); err != nil {
+		t.Fatalf("RecordExercise failed: %v", err)
+	}
+
+	// Act
+	resp, err := service.GetHoldingPeriodAlerts(ctx, accountID, 30)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetHoldingPeriodAlerts failed: %v", err)
+	}
+	if len(resp.Alerts) != 1 {
+		t.Fatalf("Expected 1 alert, got %d", len(resp.Alerts))
+	}
+	alert := resp.Alerts[0]
+	if alert.Rule != HoldingPeriodRuleCADeduction {
+		t.Errorf("Expected rule %s, got %s", HoldingPeriodRuleCADeduction, alert.Rule)
+	}
+	if alert.DaysRemaining != 10 {
+		t.Errorf("Expected 10 days remaining, got %d", alert.DaysRemaining)
+	}
+	if alert.SharesHeld != 400 {
+		t.Errorf("Expected 400 shares held, got %d", alert.SharesHeld)
+	}
+}
+
+func TestBulkCreateEquityGrants_Success(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-bulk-grants-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeStockOptions)
+	service := SetupAccountService(t, db)
+
+	strikePrice := 2.00
+	totalMonths := 48
+	cliffMonths := 12
+	frequency := "monthly"
+	grantDate := Date{Time: time.Now().AddDate(-3, 0, 0)}
+	req := &BulkCreateGrantsRequest{Grants: []BulkGrantInput{
+		{
+			CreateEquityGrantRequest: CreateEquityGrantRequest{
+				GrantType:   GrantTypeISO,
+				GrantDate:   grantDate,
+				Quantity:    4800,
+				StrikePrice: &strikePrice,
+				CompanyName: "Test Corp",
+			},
+			VestingSchedule: &SetVestingScheduleRequest{
+				ScheduleType:       "time_based",
+				CliffMonths:        &cliffMonths,
+				TotalVestingMonths: &totalMonths,
+				VestingFrequency:   &frequency,
+			},
+			Exercises: []RecordExerciseRequest{
+				{ExerciseDate: Date{Time: time.Now().AddDate(-1, 0, 0)}, Quantity: 1000, FMVAtExercise: 12.00},
+			},
+		},
+		{
+			CreateEquityGrantRequest: CreateEquityGrantRequest{
+				GrantType:   GrantTypeRSU,
+				GrantDate:   grantDate,
+				Quantity:    500,
+				CompanyName: "Test Corp",
+			},
+		},
+	}}
+
+	// Act
+	resp, err := service.BulkCreateEquityGrants(ctx, accountID, req)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("BulkCreateEquityGrants failed: %v", err)
+	}
+	if len(resp.Created) != 2 {
+		t.Fatalf("Expected 2 grants created, got %d", len(resp.Created))
+	}
+	if resp.Created[0].VestingSchedule == nil {
+		t.Error("Expected vesting schedule on first grant")
+	}
+	if len(resp.Created[0].Exercises) != 1 || resp.Created[0].Exercises[0].TaxableBenefit != 10000.00 {
+		t.Errorf("Expected one exercise with taxable benefit 10000, got %+v", resp.Created[0].Exercises)
+	}
+	grants, err := service.GetEquityGrants(ctx, accountID)
+	if err != nil {
+		t.Fatalf("GetEquityGrants failed: %v", err)
+	}
+	if len(grants.Grants) != 2 {
+		t.Errorf("Expected 2 stored grants, got %d", len(grants.Grants))
+	}
+}
+
+func TestBulkCreateEquityGrants_InvalidItemCreatesNothing(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-bulk-grants-2"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeStockOptions)
+	service := SetupAccountService(t, db)
+
+	req := &BulkCreateGrantsRequest{Grants: []BulkGrantInput{
+		{
+			CreateEquityGrantRequest: CreateEquityGrantRequest{
+				GrantType:   GrantTypeRSU,
+				GrantDate:   Date{Time: time.Now()},
+				Quantity:    100,
+				CompanyName: "Test Corp",
+			},
+		},
+		{
+			// ISO without a strike price, exercising more than granted
+			CreateEquityGrantRequest: CreateEquityGrantRequest{
+				GrantType:   GrantTypeISO,
+				GrantDate:   Date{Time: time.Now()},
+				Quantity:    100,
+				CompanyName: "Test Corp",
+			},
+			Exercises: []RecordExerciseRequest{
+				{ExerciseDate: Date{Time: time.Now()}, Quantity: 200},
+			},
+		},
+	}}
+
+	// Act
+	resp, err := service.BulkCreateEquityGrants(ctx, accountID, req)
+
+	// Assert
+	if !errors.Is(err, ErrBulkGrantsInvalid) {
+		t.Fatalf("Expected ErrBulkGrantsInvalid, got %v", err)
+	}
+	if len(resp.Errors) == 0 {
+		t.Fatal("Expected validation errors")
+	}
+	for _, e := range resp.Errors {
+		if e.Index != 1 {
+			t.Errorf("Expected errors only for grant 1, got %+v", e)
+		}
+	}
+	grants, err := service.GetEquityGrants(ctx, accountID)
+	if err != nil {
+		t.Fatalf("GetEquityGrants failed: %v", err)
+	}
+	if len(grants.Grants) != 0 {
+		t.Errorf("Expected no grants stored, got %d", len(grants.Grants))
+	}
+}
+
+func TestRecordSellToCoverSales_WithholdsSharesAtVest(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-sell-to-cover-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeStockOptions)
+	service := SetupAccountService(t, db)
+
+	rate := 0.45
+	grant, err := service.CreateEquityGrant(ctx, accountID, &CreateEquityGrantRequest{
+		AccountID:       accountID,
+		GrantType:       GrantTypeRSU,
+		GrantDate:       Date{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		Quantity:        1200,
+		FMVAtGrant:      5.00,
+		CompanyName:     "Test Corp",
+		Currency:        "USD",
+		SellToCoverRate: &rate,
+	})
+	if err != nil {
+		t.Fatalf("CreateEquityGrant failed: %v", err)
+	}
+
+	totalMonths := 12
+	frequency := "monthly"
+	if _, err := service.SetVestingSchedule(ctx, grant.ID, &SetVestingScheduleRequest{
+		ScheduleType:       "time_based",
+		TotalVestingMonths: &totalMonths,
+		VestingFrequency:   &frequency,
+	}); err != nil {
+		t.Fatalf("SetVestingSchedule failed: %v", err)
+	}
+
+	service.RecordFMV(ctx, accountID, &RecordFMVRequest{
+		Currency:      "USD",
+		EffectiveDate: Date{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		FMVPerShare:   10.00,
+	})
+
+	// Act
+	recorded, err := service.RecordSellToCoverSales(ctx, accountID)
+	if err != nil {
+		t.Fatalf("RecordSellToCoverSales failed: %v", err)
+	}
+	again, err := service.RecordSellToCoverSales(ctx, accountID)
+	if err != nil {
+		t.Fatalf("RecordSellToCoverSales failed on rerun: %v", err)
+	}
+
+	// Assert
+	if len(recorded.Sales) != 12 {
+		t.Fatalf("Expected a sell-to-cover sale for each of 12 vests, got %d", len(recorded.Sales))
+	}
+	if len(again.Sales) != 0 {
+		t.Errorf("Expected no new sales on rerun, got %d", len(again.Sales))
+	}
+	sale := recorded.Sales[0]
+	if sale.Quantity != 45 || sale.TotalProceeds != 450 || sale.CapitalGain != 0 {
+		t.Errorf("Expected 45 shares sold for 450 with no gain, got %d shares for %.2f with gain %.2f",
+			sale.Quantity, sale.TotalProceeds, sale.CapitalGain)
+	}
+
+	events, err := service.GetVestingEvents(ctx, grant.ID)
+	if err != nil {
+		t.Fatalf("GetVestingEvents failed: %v", err)
+	}
+	if events.Events[0].SharesWithheld != 45 || events.Events[0].NetQuantity != 55 {
+		t.Errorf("Expected 45 shares withheld and 55 net, got %d and %d",
+			events.Events[0].SharesWithheld, events.Events[0].NetQuantity)
+	}
+
+	summary, err := service.GetOptionsSummary(ctx, accountID)
+	if err != nil {
+		t.Fatalf("GetOptionsSummary failed: %v", err)
+	}
+	if summary.WithheldShares != 540 || summary.Grants[0].NetVestedQuantity != 660 {
+		t.Errorf("Expected 540 shares withheld and 660 net vested, got %d and %d",
+			summary.WithheldShares, summary.Grants[0].NetVestedQuantity)
+	}
+
+	taxSummary, err := service.GetTaxSummary(ctx, accountID, 2020)
+	if err != nil {
+		t.Fatalf("GetTaxSummary failed: %v", err)
+	}
+	if taxSummary.VestingIncome != 11000 {
+		t.Errorf("Expected vesting income 11000 from 11 vests in 2020, got %.2f", taxSummary.VestingIncome)
+	}
+	if taxSummary.TaxWithheld != 4950 {
+		t.Errorf("Expected 4950 withheld through sell-to-cover in 2020, got %.2f", taxSummary.TaxWithheld)
+	}
+	if taxSummary.TotalCapitalGains != 0 {
+		t.Errorf("Expected no capital gains from sell-to-cover, got %.2f", taxSummary.TotalCapitalGains)
+	}
+}
+
+func TestGetTaxSummaryForPeriod_FiscalYearProratesPartialResidency(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange: 1200 RSUs vesting monthly through 2020 at $10, a move in on 2020-07-01 and
+	// a tax year running April to March
+	userID := "test-user-fiscal-tax-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeStockOptions)
+	service := SetupAccountService(t, db)
+
+	grant, err := service.CreateEquityGrant(ctx, accountID, &CreateEquityGrantRequest{
+		AccountID:   accountID,
+		GrantType:   GrantTypeRSU,
+		GrantDate:   Date{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		Quantity:    1200,
+		FMVAtGrant:  10.00,
+		CompanyName: "Test Corp",
+		Currency:    "USD",
+	})
+	if err != nil {
+		t.Fatalf("CreateEquityGrant failed: %v", err)
+	}
+	totalMonths := 12
+	frequency := "monthly"
+	if _, err := service.SetVestingSchedule(ctx, grant.ID, &SetVestingScheduleRequest{
+		ScheduleType:       "time_based",
+		TotalVestingMonths: &totalMonths,
+		VestingFrequency:   &frequency,
+	}); err != nil {
+		t.Fatalf("SetVestingSchedule failed: %v", err)
+	}
+	for _, saleDate := range []time.Time{
+		time.Date(2020, 6, 15, 0, 0, 0, 0, time.UTC),
+		time.Date(2020, 8, 15, 0, 0, 0, 0, time.UTC),
+	} {
+		if _, err := service.RecordSale(ctx, accountID, &RecordSaleRequest{
+			AccountID: accountID,
+			GrantID:   &grant.ID,
+			SaleDate:  Date{Time: saleDate},
+			Quantity:  10,
+			SalePrice: 15.00,
+			CostBasis: 100.00,
+		}); err != nil {
+			t.Fatalf("RecordSale failed: %v", err)
+		}
+	}
+	movedIn := Date{Time: time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)}
+
+	// Act
+	fiscal, err := service.GetTaxSummaryForPeriod(ctx, accountID, 2020, TaxPeriod{StartMonth: 4})
+	if err != nil {
+		t.Fatalf("GetTaxSummaryForPeriod failed: %v", err)
+	}
+	partial, err := service.GetTaxSummaryForPeriod(ctx, accountID, 2020, TaxPeriod{StartMonth: 4, From: &movedIn})
+	if err != nil {
+		t.Fatalf("GetTaxSummaryForPeriod failed: %v", err)
+	}
+
+	// Assert: April 2020 to January 2021 holds 10 of the 12 vests
+	if fiscal.VestingIncome != 10000 || fiscal.IsPartial || fiscal.PeriodEnd.Format("2006-01-02") != "2021-03-31" {
+		t.Errorf("Expected 10000 of vesting income to 2021-03-31, got %.2f to %s", fiscal.VestingIncome, fiscal.PeriodEnd.Format("2006-01-02"))
+	}
+	if fiscal.TotalCapitalGains != 100 {
+		t.Errorf("Expected both sales in the fiscal year, got gains of %.2f", fiscal.TotalCapitalGains)
+	}
+	if !partial.IsPartial || len(partial.Prorations) != 10 {
+		t.Fatalf("Expected 10 prorated vests in a partial year, got %d", len(partial.Prorations))
+	}
+	first, last := partial.Prorations[0], partial.Prorations[len(partial.Prorations)-1]
+	if first.Factor != 0 {
+		t.Errorf("Expected the April vest, earned before the move, to count nothing, got %.4f", first.Factor)
+	}
+	// The January 2021 vest was earned over 2020, 184 of its 366 days after the move
+	if math.Abs(last.Factor-184.0/366.0) > 1e-9 {
+		t.Errorf("Expected the January vest prorated by 184/366, got %.4f", last.Factor)
+	}
+	if partial.VestingIncome >= fiscal.VestingIncome || partial.VestingIncome <= 0 {
+		t.Errorf("Expected partial vesting income below the full year's, got %.2f", partial.VestingIncome)
+	}
+	if partial.TotalCapitalGains != 50 {
+		t.Errorf("Expected only the sale after the move, got gains of %.2f", partial.TotalCapitalGains)
+	}
+}
+
+func TestBuildTerminationScenario_VestsToLastDayAndCostsExercise(t *testing.T) {
+	// Arrange: 1200 ISOs at a $2 strike and 400 RSUs, both vesting quarterly over a year, FMV $10
+	strikePrice := 2.00
+	expiration := Date{Time: time.Date(2033, 1, 1, 0, 0, 0, 0, time.UTC)}
+	iso := EquityGrant{
+		ID:             "grant-iso",
+		GrantType:      GrantTypeISO,
+		GrantDate:      Date{Time: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		Quantity:       1200,
+		StrikePrice:    &strikePrice,
+		ExpirationDate: &expiration,
+		Currency:       "USD",
+	}
+	rsu := EquityGrant{
+		ID:        "grant-rsu",
+		GrantType: GrantTypeRSU,
+		GrantDate: Date{Time: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		Quantity:  400,
+		Currency:  "USD",
+	}
+	totalMonths := 12
+	frequency := "quarterly"
+	schedule := &VestingSchedule{ScheduleType: "time_based", TotalVestingMonths: &totalMonths, VestingFrequency: &frequency}
+	data := &optionsData{
+		grants:    []EquityGrant{rsu, iso},
+		schedules: map[string]*VestingSchedule{iso.ID: schedule, rsu.ID: schedule},
+		fmvEntries: []FMVEntry{
+			{Currency: "USD", EffectiveDate: Date{Time: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}, FMVPerShare: 10},
+		},
+	}
+	today := Date{Time: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)}
+	lastDay := Date{Time: time.Date(2023, 8, 15, 0, 0, 0, 0, time.UTC)}
+
+	// Act
+	scenario := buildTerminationScenario(data, today, lastDay, DefaultPostTerminationExerciseDays, 0.5, nil)
+
+	// Assert: the ISO sorts first with the only exercise deadline
+	if len(scenario.Grants) != 2 || scenario.Grants[0].GrantID != iso.ID {
+		t.Fatalf("Expected the ISO then the RSU, got %+v", scenario.Grants)
+	}
+	option := scenario.Grants[0]
+	if option.VestedShares != 600 || option.ForfeitedShares != 600 || option.ActionableShares != 600 {
+		t.Errorf("Expected 600 vested and 600 forfeited options, got %+v", option)
+	}
+	if option.ExerciseDeadline.String() != "2023-11-13" || option.ISOQualifyingDeadline.String() != "2023-11-13" {
+		t.Errorf("Expected both deadlines 90 days after leaving, got %s and %s", option.ExerciseDeadline, option.ISOQualifyingDeadline)
+	}
+	// 600 * $2 to exercise, (600 * $8) * 50% deduction * 50% rate in tax
+	if option.ExerciseCost != 1200 || option.TaxableBenefit != 4800 || option.EstimatedTax != 1200 {
+		t.Errorf("Expected 1200 cost, 4800 benefit and 1200 tax, got %.2f, %.2f and %.2f",
+			option.ExerciseCost, option.TaxableBenefit, option.EstimatedTax)
+	}
+
+	// 100 RSUs vest between today and the last day, taxed in full
+	units := scenario.Grants[1]
+	if units.ExerciseDeadline != nil || units.ActionableShares != 100 || units.EstimatedTax != 500 || units.VestedValue != 2000 {
+		t.Errorf("Expected 100 RSUs vesting before leaving with 500 tax, got %+v", units)
+	}
+
+	totals := scenario.ByCurrency["USD"]
+	if totals == nil || totals.CashNeeded != 2900 || totals.ForfeitedValue != 6800 {
+		t.Errorf("Expected 2900 cash needed and 6800 forfeited, got %+v", totals)
+	}
+}
+
+func TestSettleExercise_SellsSharesByMethod(t *testing.T) {
+	// Arrange: 1000 options at a $2 strike worth $10.50, a quarter of the 8500 benefit withheld
+	cashless, sameDay := ExerciseMethodCashless, ExerciseMethodSameDaySale
+	withholding := 8500 * 0.25
+
+	// Act
+	cash := SettleExercise(nil, 1000, 2, 10.5, withholding)
+	net := SettleExercise(&cashless, 1000, 2, 10.5, withholding)
+	sale := SettleExercise(&sameDay, 1000, 2, 10.5, withholding)
+
+	// Assert: 2000 strike plus 2125 withheld is owed each way
+	if cash.Method != ExerciseMethodCash || cash.CashRequired != 4125 || cash.NetShares != 1000 || cash.SharesSold != 0 {
+		t.Errorf("Expected 4125 paid in cash for 1000 shares, got %+v", cash)
+	}
+	// 4125 / 10.50 = 392.9, rounded up to whole shares with the remainder paid out
+	if net.SharesSold != 393 || net.NetShares != 607 || net.SaleProceeds != 4126.5 || net.CashReceived != 1.5 || net.CashRequired != 0 {
+		t.Errorf("Expected 393 shares surrendered and 607 kept, got %+v", net)
+	}
+	if sale.SharesSold != 1000 || sale.NetShares != 0 || sale.SaleProceeds != 10500 || sale.CashReceived != 6375 {
+		t.Errorf("Expected all 1000 shares sold for 6375 after costs, got %+v", sale)
+	}
+}
+
+func TestProcessEquityRecalcs_RepricesAfterFMVAndStrikeEdits(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-recalc-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeStockOptions)
+	service := SetupAccountService(t, db)
+
+	jan := Date{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	mar := Date{Time: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+	strike := 2.0
+	grant, err := service.CreateEquityGrant(ctx, accountID, &CreateEquityGrantRequest{
+		AccountID: accountID, GrantType: GrantTypeNSO, GrantDate: jan, Quantity: 1000,
+		StrikePrice: &strike, FMVAtGrant: 2, CompanyName: "Test Corp", Currency: "USD",
+	})
+	if err != nil {
+		t.Fatalf("CreateEquityGrant failed: %v", err)
+	}
+	if _, err := service.RecordFMV(ctx, accountID, &RecordFMVRequest{AccountID: accountID, Currency: "USD", EffectiveDate: jan, FMVPerShare: 10}); err != nil {
+		t.Fatalf("RecordFMV failed: %v", err)
+	}
+	looked, err := service.RecordExercise(ctx, grant.ID, &RecordExerciseRequest{GrantID: grant.ID, ExerciseDate: mar, Quantity: 100})
+	if err != nil {
+		t.Fatalf("RecordExercise failed: %v", err)
+	}
+	manual, err := service.RecordExercise(ctx, grant.ID, &RecordExerciseRequest{GrantID: grant.ID, ExerciseDate: mar, Quantity: 100, FMVAtExercise: 11})
+	if err != nil {
+		t.Fatalf("RecordExercise failed: %v", err)
+	}
+	sale, err := service.RecordSale(ctx, accountID, &RecordSaleRequest{
+		AccountID: accountID, GrantID: &grant.ID, ExerciseID: &looked.ID, SaleDate: mar, Quantity: 100, SalePrice: 15, CostBasis: 1000,
+	})
+	if err != nil {
+		t.Fatalf("RecordSale failed: %v", err)
+	}
+	if _, err := service.ProcessEquityRecalcs(ctx); err != nil {
+		t.Fatalf("ProcessEquityRecalcs failed: %v", err)
+	}
+
+	// Act
+	if _, err := service.RecordFMV(ctx, accountID, &RecordFMVRequest{AccountID: accountID, Currency: "USD", EffectiveDate: jan, FMVPerShare: 12}); err != nil {
+		t.Fatalf("RecordFMV failed: %v", err)
+	}
+	newStrike := 3.0
+	if _, err := service.UpdateEquityGrant(ctx, grant.ID, &UpdateEquityGrantRequest{StrikePrice: &newStrike}); err != nil {
+		t.Fatalf("UpdateEquityGrant failed: %v", err)
+	}
+	pending, err := service.GetEquityRecalculations(ctx, accountID)
+	if err != nil {
+		t.Fatalf("GetEquityRecalculations failed: %v", err)
+	}
+	changed, err := service.ProcessEquityRecalcs(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ProcessEquityRecalcs failed: %v", err)
+	}
+	if !pending.Pending {
+		t.Error("Expected a recalculation to be pending after the edits")
+	}
+	if changed != 3 {
+		t.Errorf("Expected 2 exercises and 1 sale recalculated, got %d", changed)
+	}
+	updated, _ := service.GetExercise(ctx, looked.ID)
+	if updated.FMVAtExercise != 12 || updated.TaxableBenefit != 900 || updated.ExerciseCost != 300 {
+		t.Errorf("Expected FMV 12, benefit 900 and cost 300, got %.2f, %.2f and %.2f",
+			updated.FMVAtExercise, updated.TaxableBenefit, updated.ExerciseCost)
+	}
+	kept, _ := service.GetExercise(ctx, manual.ID)
+	if kept.FMVAtExercise != 11 || kept.TaxableBenefit != 800 {
+		t.Errorf("Expected the hand-entered FMV kept at 11 with benefit 800, got %.2f and %.2f", kept.FMVAtExercise, kept.TaxableBenefit)
+	}
+	repriced, _ := service.GetSale(ctx, sale.ID)
+	if repriced.CostBasis != 1200 || repriced.CapitalGain != 300 {
+		t.Errorf("Expected cost basis 1200 and gain 300, got %.2f and %.2f", repriced.CostBasis, repriced.CapitalGain)
+	}
+	notes, _ := service.GetEquityRecalculations(ctx, accountID)
+	if notes.Pending || len(notes.Recalculations) != 3 {
+		t.Errorf("Expected 3 notes and nothing pending, got %d notes, pending %v", len(notes.Recalculations), notes.Pending)
+	}
+}
+
+func TestEstimateVestedSale_TaxesOptionSpreadAndRSUGain(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-vested-sale-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeStockOptions)
+	service := SetupAccountService(t, db)
+
+	strike := 4.00
+	grantDate := Date{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	totalMonths := 12
+	frequency := "monthly"
+	for _, req := range []*CreateEquityGrantRequest{
+		{AccountID: accountID, GrantType: GrantTypeRSU, GrantDate: grantDate, Quantity: 1200, FMVAtGrant: 5.00, CompanyName: "Test Corp", Currency: "USD"},
+		{AccountID: accountID, GrantType: GrantTypeNSO, GrantDate: grantDate, Quantity: 100, StrikePrice: &strike, FMVAtGrant: 4.00, CompanyName: "Test Corp", Currency: "USD"},
+	} {
+		grant, err := service.CreateEquityGrant(ctx, accountID, req)
+		if err != nil {
+			t.Fatalf("CreateEquityGrant failed: %v", err)
+		}
+		if _, err := service.SetVestingSchedule(ctx, grant.ID, &SetVestingScheduleRequest{
+			ScheduleType:       "time_based",
+			TotalVestingMonths: &totalMonths,
+			VestingFrequency:   &frequency,
+		}); err != nil {
+			t.Fatalf("SetVestingSchedule failed: %v", err)
+		}
+	}
+	if _, err := service.RecordFMV(ctx, accountID, &RecordFMVRequest{
+		Currency:      "USD",
+		EffectiveDate: grantDate,
+		FMVPerShare:   10.00,
+	}); err != nil {
+		t.Fatalf("RecordFMV failed: %v", err)
+	}
+
+	// Act
+	estimate, err := service.EstimateVestedSale(ctx, accountID, 0.5)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("EstimateVestedSale failed: %v", err)
+	}
+	// RSUs: 1200 * 10 = 12000, taxed on the 6000 gain since grant at half inclusion
+	// Options: 100 * (10 - 4) = 600, taxed after the 50% deduction
+	if estimate.VestedValue != 12600 {
+		t.Errorf("Expected vested value 12600, got %.2f", estimate.VestedValue)
+	}
+	if estimate.EstimatedTax != 1650 {
+		t.Errorf("Expected estimated tax 1650, got %.2f", estimate.EstimatedTax)
+	}
+	if estimate.AfterTaxProceeds != 10950 {
+		t.Errorf("Expected after-tax proceeds 10950, got %.2f", estimate.AfterTaxProceeds)
+	}
+}
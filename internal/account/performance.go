@@ -0,0 +1,358 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// AccountPerformance compares an account's time-weighted and money-weighted returns over a
+// period. TWR measures the investments, ignoring when money was added or taken out; MWR
+// measures the investor's own experience, so it rewards well-timed contributions.
+type AccountPerformance struct {
+	AccountID      string                  `json:"account_id"`
+	AccountName    string                  `json:"account_name"`
+	Currency       Currency                `json:"currency"`
+	From           Date                    `json:"from"` // Moved to the first balance when there is none before the period
+	To             Date                    `json:"to"`
+	OpeningBalance float64                 `json:"opening_balance"`
+	ClosingBalance float64                 `json:"closing_balance"`
+	NetFlows       float64                 `json:"net_flows"` // Contributions less withdrawals
+	Gain           float64                 `json:"gain"`      // Change in balance not explained by flows
+	TWR            *float64                `json:"twr"`       // Cumulative over the period
+	TWRAnnualized  *float64                `json:"twr_annualized,omitempty"`
+	MWR            *float64                `json:"mwr"` // Annualized internal rate of return
+	Months         []*PerformanceSubPeriod `json:"months"`
+	Notes          []string                `json:"notes"`
+}
+
+// PerformanceSubPeriod is one month of an account's performance. Its return is the Modified
+// Dietz return, which weights each flow by how much of the month it was invested for.
+type PerformanceSubPeriod struct {
+	From           Date     `json:"from"`
+	To             Date     `json:"to"`
+	OpeningBalance float64  `json:"opening_balance"`
+	ClosingBalance float64  `json:"closing_balance"`
+	NetFlows       float64  `json:"net_flows"`
+	Gain           float64  `json:"gain"`
+	Return         *float64 `json:"return"` // Nil when too little was invested to measure
+}
+
+// externalFlow is money put into (positive) or taken out of (negative) an account on a day
+type externalFlow struct {
+	date   time.Time
+	amount float64
+}
+
+// GetPerformance computes an account's time-weighted and money-weighted returns between
+// from and to from its balances and the contributions and withdrawals recorded against it.
+// Flows are identified as in GetBalanceChange, so interest, dividends and fees count as
+// returns rather than flows.
+func (s *Service) GetPerformance(ctx context.Context, accountID string, from, to time.Time) (*AccountPerformance, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("to must be after from")
+	}
+
+	acc, err := s.Get(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if !acc.IsAsset {
+		return nil, fmt.Errorf("performance is only available for asset accounts")
+	}
+
+	perf := &AccountPerformance{
+		AccountID:   acc.ID,
+		AccountName: acc.Name,
+		Currency:    acc.Currency,
+		To:          Date{Time: to},
+		Months:      make([]*PerformanceSubPeriod, 0),
+		Notes:       make([]string, 0),
+	}
+
+	// Without a balance before the period, it starts the day after the first balance in it
+	opening, hasOpening, err := s.balanceAsOf(ctx, accountID, "date < $2", from)
+	if err != nil {
+		return nil, err
+	}
+	if !hasOpening {
+		firstDate, firstAmount, found, err := s.firstBalanceBetween(ctx, accountID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("no balances recorded for the period")
+		}
+		opening = firstAmount
+		from = startOfDay(firstDate).AddDate(0, 0, 1)
+		if !to.After(from) {
+			return nil, fmt.Errorf("not enough balance history for the period")
+		}
+		perf.Notes = append(perf.Notes, "No balance before the period, so it starts from the first balance in it")
+	}
+	perf.From = Date{Time: from}
+	perf.OpeningBalance = roundCents(opening)
+
+	flows, err := s.externalFlows(ctx, accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	growth := 1.0
+	measured := true
+	for _, month := range monthlySubPeriods(from, to) {
+		sub := &PerformanceSubPeriod{From: Date{Time: month[0]}, To: Date{Time: month[1]}}
+		sub.OpeningBalance, _, err = s.balanceAsOf(ctx, accountID, "date < $2", month[0])
+		if err != nil {
+			return nil, err
+		}
+		if month[0].Equal(from) {
+			sub.OpeningBalance = opening
+		}
+		sub.ClosingBalance, _, err = s.balanceAsOf(ctx, accountID, "date <= $2", month[1])
+		if err != nil {
+			return nil, err
+		}
+
+		monthFlows := flowsBetween(flows, month[0], month[1])
+		for _, flow := range monthFlows {
+			sub.NetFlows += flow.amount
+		}
+		sub.Gain = sub.ClosingBalance - sub.OpeningBalance - sub.NetFlows
+		sub.Return = modifiedDietz(sub.OpeningBalance, sub.Gain, monthFlows, month[0], month[1])
+		if sub.Return != nil {
+			growth *= 1 + *sub.Return
+			rounded := roundRate(*sub.Return)
+			sub.Return = &rounded
+		} else if sub.OpeningBalance != 0 || sub.NetFlows != 0 {
+			measured = false
+		}
+
+		perf.NetFlows += sub.NetFlows
+		perf.ClosingBalance = sub.ClosingBalance
+		sub.OpeningBalance = roundCents(sub.OpeningBalance)
+		sub.ClosingBalance = roundCents(sub.ClosingBalance)
+		sub.NetFlows = roundCents(sub.NetFlows)
+		sub.Gain = roundCents(sub.Gain)
+		perf.Months = append(perf.Months, sub)
+	}
+	perf.Gain = roundCents(perf.ClosingBalance - opening - perf.NetFlows)
+	perf.ClosingBalance = roundCents(perf.ClosingBalance)
+	perf.NetFlows = roundCents(perf.NetFlows)
+
+	years := to.Sub(from).Hours() / 24 / 365.25
+	if measured {
+		twr := roundRate(growth - 1)
+		perf.TWR = &twr
+		if years >= 1 {
+			annualized := roundRate(math.Pow(growth, 1/years) - 1)
+			perf.TWRAnnualized = &annualized
+		}
+	} else {
+		perf.Notes = append(perf.Notes, "A month had too little invested to measure, so TWR is not available")
+	}
+
+	if mwr, ok := moneyWeightedReturn(opening, perf.ClosingBalance, flows, from, to); ok {
+		rounded := roundRate(mwr)
+		perf.MWR = &rounded
+	} else {
+		perf.Notes = append(perf.Notes, "MWR could not be solved for these flows")
+	}
+
+	return perf, nil
+}
+
+// firstBalanceBetween returns the account's earliest balance between from and to
+func (s *Service) firstBalanceBetween(ctx context.Context, accountID string, from, to time.Time) (time.Time, float64, bool, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT date, amount FROM balances
+		WHERE account_id = $1 AND date >= $2 AND date <= $3
+		ORDER BY date, created_at DESC
+		LIMIT 1
+	`, accountID, from, to)
+	if err != nil {
+		return time.Time{}, 0, false, fmt.Errorf("failed to get balance: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return time.Time{}, 0, false, rows.Err()
+	}
+	var date time.Time
+	var amount float64
+	if err := rows.Scan(&date, &amount); err != nil {
+		return time.Time{}, 0, false, fmt.Errorf("failed to scan balance: %w", err)
+	}
+	return date, amount, true, nil
+}
+
+// externalFlows returns the contributions and withdrawals recorded against the account
+// between from and to, oldest first. Transactions categorized as interest, dividends or fees
+// are returns on the account, not flows into or out of it.
+func (s *Service) externalFlows(ctx context.Context, accountID string, from, to time.Time) ([]externalFlow, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.date, COALESCE(sp.category, t.category, ''), COALESCE(sp.amount, t.amount)
+		FROM transactions t
+		LEFT JOIN transaction_splits sp ON sp.transaction_id = t.id
+		WHERE t.account_id = $1 AND t.date >= $2 AND t.date <= $3
+		  AND t.settled_transaction_id IS NULL
+	`, accountID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer rows.Close()
+
+	flows := make([]externalFlow, 0)
+	for rows.Next() {
+		var flow externalFlow
+		var category string
+		if err := rows.Scan(&flow.date, &category, &flow.amount); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		if categoryHasWord(category, "interest", "dividend", "dividends", "fee", "fees") {
+			continue
+		}
+		flows = append(flows, flow)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	holdingRows, err := s.db.QueryContext(ctx, `
+		SELECT ht.transaction_date, ht.type, ABS(ht.total_amount)
+		FROM holding_transactions ht
+		JOIN holdings h ON h.id = ht.holding_id
+		WHERE h.account_id = $1 AND ht.type IN ('deposit', 'withdrawal')
+		  AND ht.transaction_date >= $2 AND ht.transaction_date <= $3
+	`, accountID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holding transactions: %w", err)
+	}
+	defer holdingRows.Close()
+
+	for holdingRows.Next() {
+		var flow externalFlow
+		var txnType string
+		if err := holdingRows.Scan(&flow.date, &txnType, &flow.amount); err != nil {
+			return nil, fmt.Errorf("failed to scan holding transaction: %w", err)
+		}
+		if txnType == "withdrawal" {
+			flow.amount = -flow.amount
+		}
+		flows = append(flows, flow)
+	}
+	if err := holdingRows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(flows, func(i, j int) bool { return flows[i].date.Before(flows[j].date) })
+	return flows, nil
+}
+
+// monthlySubPeriods splits from to to into calendar months, the first and last clipped to
+// the period
+func monthlySubPeriods(from, to time.Time) [][2]time.Time {
+	periods := make([][2]time.Time, 0)
+	start := from
+	for start.Before(to) {
+		nextMonth := time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, start.Location())
+		end := nextMonth.Add(-time.Nanosecond)
+		if end.After(to) {
+			end = to
+		}
+		periods = append(periods, [2]time.Time{start, end})
+		start = nextMonth
+	}
+	return periods
+}
+
+// flowsBetween returns the flows dated from start to end
+func flowsBetween(flows []externalFlow, start, end time.Time) []externalFlow {
+	result := make([]externalFlow, 0)
+	for _, flow := range flows {
+		if !flow.date.Before(start) && !flow.date.After(end) {
+			result = append(result, flow)
+		}
+	}
+	return result
+}
+
+// modifiedDietz is the return of a sub-period with each flow weighted by the share of the
+// sub-period it was invested for. Returns nil when the weighted capital is not positive.
+func modifiedDietz(opening, gain float64, flows []externalFlow, start, end time.Time) *float64 {
+	days := end.Sub(start).Hours() / 24
+	capital := opening
+	for _, flow := range flows {
+		weight := 1.0
+		if days > 0 {
+			weight = end.Sub(startOfDay(flow.date)).Hours() / 24 / days
+			weight = math.Max(0, math.Min(1, weight))
+		}
+		capital += weight * flow.amount
+	}
+	if capital <= 0 {
+		return nil
+	}
+	result := gain / capital
+	return &result
+}
+
+// moneyWeightedReturn solves for the annual rate at which the opening balance and each
+// contribution, less withdrawals, grow into the closing balance. It reports false when the
+// flows have no single rate between -99% and 1000%.
+func moneyWeightedReturn(opening, closing float64, flows []externalFlow, from, to time.Time) (float64, bool) {
+	type dated struct {
+		years  float64
+		amount float64
+	}
+	yearsAt := func(t time.Time) float64 {
+		return t.Sub(from).Hours() / 24 / 365.25
+	}
+
+	// From the investor's side: money put in is paid out, money taken out and the closing
+	// balance are received
+	cashFlows := []dated{{0, -opening}}
+	for _, flow := range flows {
+		cashFlows = append(cashFlows, dated{yearsAt(flow.date), -flow.amount})
+	}
+	cashFlows = append(cashFlows, dated{yearsAt(to), closing})
+
+	npv := func(rate float64) float64 {
+		total := 0.0
+		for _, cf := range cashFlows {
+			total += cf.amount / math.Pow(1+rate, cf.years)
+		}
+		return total
+	}
+
+	low, high := -0.99, 10.0
+	npvLow, npvHigh := npv(low), npv(high)
+	if math.IsNaN(npvLow) || math.IsNaN(npvHigh) || npvLow*npvHigh > 0 {
+		return 0, false
+	}
+	for i := 0; i < 200; i++ {
+		mid := (low + high) / 2
+		npvMid := npv(mid)
+		if math.Abs(npvMid) < 1e-9 || high-low < 1e-10 {
+			return mid, true
+		}
+		if npvLow*npvMid < 0 {
+			high = mid
+		} else {
+			low, npvLow = mid, npvMid
+		}
+	}
+	return (low + high) / 2, true
+}
+
+// startOfDay truncates a time to midnight in its location
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// roundRate rounds a rate to a hundredth of a percent
+func roundRate(rate float64) float64 {
+	return math.Round(rate*10000) / 10000
+}
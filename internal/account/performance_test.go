@@ -0,0 +1,88 @@
+package account
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestGetPerformance_ChainsMonthlyReturnsAroundContributions(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-performance-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupAccountService(t, db)
+
+	accountID := CreateTestAccount(t, db, userID, AccountTypeTFSA)
+	from := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, time.March, 31, 23, 59, 59, 0, time.UTC)
+
+	insertBalance := func(amount float64, date time.Time) {
+		_, err := db.Exec(`
+			INSERT INTO balances (id, account_id, amount, date, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`, "test-balance-"+uuid.New().String(), accountID, amount, date, time.Now())
+		if err != nil {
+			t.Fatalf("Failed to insert balance: %v", err)
+		}
+	}
+	insertBalance(10000, from.AddDate(0, 0, -1))
+	insertBalance(11000, time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC))
+	insertBalance(16000, time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC))
+	insertBalance(17600, time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC))
+
+	defer db.Exec(`DELETE FROM transactions WHERE user_id = $1`, userID)
+	insertTransaction := func(amount float64, category string, date time.Time) {
+		_, err := db.Exec(`
+			INSERT INTO transactions (id, user_id, account_id, date, description, amount, currency, category)
+			VALUES ($1, $2, $3, $4, 'test', $5, 'CAD', $6)
+		`, "test-txn-"+uuid.New().String(), userID, accountID, date, amount, category)
+		if err != nil {
+			t.Fatalf("Failed to insert transaction: %v", err)
+		}
+	}
+	insertTransaction(5000, "Contribution", time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC))
+	insertTransaction(40, "Interest", time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC))
+
+	// Act
+	perf, err := service.GetPerformance(ctx, accountID, from, to)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetPerformance failed: %v", err)
+	}
+	if len(perf.Months) != 3 {
+		t.Fatalf("Expected 3 monthly sub-periods, got %d", len(perf.Months))
+	}
+	february := perf.Months[1]
+	if february.NetFlows != 5000 || february.Gain != 0 || february.Return == nil || *february.Return != 0 {
+		t.Errorf("Expected February's contribution to explain its change, got %+v", february)
+	}
+	if perf.NetFlows != 5000 || perf.Gain != 2600 {
+		t.Errorf("Expected net flows of 5000 and a gain of 2600 (interest is a return), got %+v", perf)
+	}
+	if perf.TWR == nil || *perf.TWR != 0.21 {
+		t.Errorf("Expected a TWR of 21%% from two 10%% months, got %v", perf.TWR)
+	}
+	if perf.MWR == nil || *perf.MWR <= 0 {
+		t.Errorf("Expected a positive MWR, got %v", perf.MWR)
+	}
+}
+
+func TestMoneyWeightedReturn_MatchesCompoundGrowth(t *testing.T) {
+	// Arrange
+	from := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(2 * 365.25 * 24 * time.Hour)
+
+	// Act
+	rate, ok := moneyWeightedReturn(1000, 1210, nil, from, to)
+
+	// Assert
+	if !ok || roundRate(rate) != 0.1 {
+		t.Errorf("Expected 10%% a year for 1000 growing to 1210 over two years, got %f (%v)", rate, ok)
+	}
+}
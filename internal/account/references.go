@@ -0,0 +1,327 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"money/internal/auth"
+	"money/internal/sync/encryption"
+)
+
+// maskedAccountNumber stands in for the digits that are never shown without a reveal
+const maskedAccountNumber = "••••"
+
+// ExternalReferences are the details needed to reach an account's institution. The
+// account number is stored encrypted and returned masked to its last four characters.
+type ExternalReferences struct {
+	AccountID      string     `json:"account_id"`
+	InstitutionURL *string    `json:"institution_url,omitempty"`
+	SupportPhone   *string    `json:"support_phone,omitempty"`
+	FraudPhone     *string    `json:"fraud_phone,omitempty"`
+	SupportEmail   *string    `json:"support_email,omitempty"`
+	AccountNumber  *string    `json:"account_number,omitempty"` // Masked unless revealed
+	Revealed       bool       `json:"revealed"`
+	Notes          *string    `json:"notes,omitempty"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+}
+
+// SetExternalReferencesRequest changes the fields that are set; an empty string clears one
+type SetExternalReferencesRequest struct {
+	InstitutionURL *string `json:"institution_url,omitempty"`
+	SupportPhone   *string `json:"support_phone,omitempty"`
+	FraudPhone     *string `json:"fraud_phone,omitempty"`
+	SupportEmail   *string `json:"support_email,omitempty"`
+	AccountNumber  *string `json:"account_number,omitempty"`
+	Notes          *string `json:"notes,omitempty"`
+}
+
+// ContactMethod is one way to reach the institution, with a link the UI can open
+type ContactMethod struct {
+	Kind  string `json:"kind"` // phone, fraud_phone, email, website
+	Label string `json:"label"`
+	Value string `json:"value"`
+	Link  string `json:"link"` // tel:, mailto: or https: URL
+}
+
+// ContactCard gathers what's needed to call about an account in one place: who to
+// contact, in the order to try them, and the account number to quote
+type ContactCard struct {
+	AccountID     string          `json:"account_id"`
+	AccountName   string          `json:"account_name"`
+	AccountType   AccountType     `json:"account_type"`
+	Institution   *string         `json:"institution,omitempty"`
+	AccountNumber *string         `json:"account_number,omitempty"` // Always masked
+	Contacts      []ContactMethod `json:"contacts"`
+	Notes         *string         `json:"notes,omitempty"`
+}
+
+// SetEncryption sets the service used to encrypt stored account numbers
+func (s *Service) SetEncryption(enc *encryption.Service) {
+	s.encryption = enc
+}
+
+// SetExternalReferences records how to reach an account's institution
+func (s *Service) SetExternalReferences(ctx context.Context, accountID string, req *SetExternalReferencesRequest) (*ExternalReferences, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	var encrypted []byte
+	var last4 sql.NullString
+	var institutionURL, supportPhone, fraudPhone, supportEmail, notes sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT institution_url, support_phone, fraud_phone, support_email, encrypted_account_number,
+			account_number_last4, notes
+		FROM account_references WHERE account_id = $1
+	`, accountID).Scan(&institutionURL, &supportPhone, &fraudPhone, &supportEmail, &encrypted, &last4, &notes)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get external references: %w", err)
+	}
+
+	for _, field := range []struct {
+		value    *string
+		target   *sql.NullString
+		validate func(string) (string, error)
+	}{
+		{req.InstitutionURL, &institutionURL, validateInstitutionURL},
+		{req.SupportPhone, &supportPhone, validatePhone},
+		{req.FraudPhone, &fraudPhone, validatePhone},
+		{req.SupportEmail, &supportEmail, validateSupportEmail},
+		{req.Notes, &notes, func(v string) (string, error) { return v, nil }},
+	} {
+		if field.value == nil {
+			continue
+		}
+		value := strings.TrimSpace(*field.value)
+		if value == "" {
+			*field.target = sql.NullString{}
+			continue
+		}
+		value, err := field.validate(value)
+		if err != nil {
+			return nil, err
+		}
+		*field.target = sql.NullString{String: value, Valid: true}
+	}
+
+	if req.AccountNumber != nil {
+		number := normalizeAccountNumber(*req.AccountNumber)
+		switch {
+		case number == "":
+			encrypted, last4 = nil, sql.NullString{}
+		case !isAlphanumeric(number) || len(number) < 4 || len(number) > 34:
+			return nil, fmt.Errorf("account number must be 4 to 34 letters or digits")
+		case s.encryption == nil:
+			return nil, fmt.Errorf("account number storage is not configured")
+		default:
+			encrypted, err = s.encryption.Encrypt(number)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt account number: %w", err)
+			}
+			last4 = sql.NullString{String: number[len(number)-4:], Valid: true}
+		}
+	}
+
+	now := time.Now()
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO account_references (account_id, institution_url, support_phone, fraud_phone, support_email,
+			encrypted_account_number, account_number_last4, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		ON CONFLICT (account_id) DO UPDATE SET
+			institution_url = excluded.institution_url,
+			support_phone = excluded.support_phone,
+			fraud_phone = excluded.fraud_phone,
+			support_email = excluded.support_email,
+			encrypted_account_number = excluded.encrypted_account_number,
+			account_number_last4 = excluded.account_number_last4,
+			notes = excluded.notes,
+			updated_at = excluded.updated_at
+	`, accountID, institutionURL, supportPhone, fraudPhone, supportEmail, encrypted, last4, notes, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save external references: %w", err)
+	}
+
+	return s.GetExternalReferences(ctx, accountID, false)
+}
+
+// GetExternalReferences returns how to reach an account's institution. The account number
+// is masked to its last four characters, or entirely for a private account while privacy
+// mode is on. Revealing it in full needs a fresh login.
+func (s *Service) GetExternalReferences(ctx context.Context, accountID string, reveal bool) (*ExternalReferences, error) {
+	account, err := s.Get(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if reveal && !auth.RecentlyAuthenticated(ctx) {
+		return nil, fmt.Errorf("log in again to reveal the account number")
+	}
+
+	refs := &ExternalReferences{AccountID: accountID}
+	var institutionURL, supportPhone, fraudPhone, supportEmail, last4, notes sql.NullString
+	var encrypted []byte
+	var updatedAt time.Time
+	err = s.db.QueryRowContext(ctx, `
+		SELECT institution_url, support_phone, fraud_phone, support_email, encrypted_account_number,
+			account_number_last4, notes, updated_at
+		FROM account_references WHERE account_id = $1
+	`, accountID).Scan(&institutionURL, &supportPhone, &fraudPhone, &supportEmail, &encrypted, &last4, &notes, &updatedAt)
+	if err == sql.ErrNoRows {
+		return refs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get external references: %w", err)
+	}
+
+	for _, field := range []struct {
+		value  sql.NullString
+		target **string
+	}{
+		{institutionURL, &refs.InstitutionURL},
+		{supportPhone, &refs.SupportPhone},
+		{fraudPhone, &refs.FraudPhone},
+		{supportEmail, &refs.SupportEmail},
+		{notes, &refs.Notes},
+	} {
+		if field.value.Valid {
+			value := field.value.String
+			*field.target = &value
+		}
+	}
+	refs.UpdatedAt = &updatedAt
+
+	switch {
+	case !last4.Valid:
+	case reveal && len(encrypted) > 0: // Restored exports keep only the last four
+		if s.encryption == nil {
+			return nil, fmt.Errorf("account number storage is not configured")
+		}
+		number, err := s.encryption.Decrypt(encrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt account number: %w", err)
+		}
+		refs.AccountNumber = &number
+		refs.Revealed = true
+	case account.IsPrivate && auth.MaskPrivate(ctx):
+		masked := maskedAccountNumber
+		refs.AccountNumber = &masked
+	default:
+		masked := maskedAccountNumber + last4.String
+		refs.AccountNumber = &masked
+	}
+	return refs, nil
+}
+
+// GetContactCard returns the account's contacts in the order to try them: support line,
+// support email, website, then the fraud line for escalation
+func (s *Service) GetContactCard(ctx context.Context, accountID string) (*ContactCard, error) {
+	account, err := s.Get(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := s.GetExternalReferences(ctx, accountID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	card := &ContactCard{
+		AccountID:     accountID,
+		AccountName:   account.Name,
+		AccountType:   account.Type,
+		Institution:   account.Institution,
+		AccountNumber: refs.AccountNumber,
+		Contacts:      []ContactMethod{},
+		Notes:         refs.Notes,
+	}
+	if refs.SupportPhone != nil {
+		card.Contacts = append(card.Contacts, ContactMethod{Kind: "phone", Label: "Customer support",
+			Value: *refs.SupportPhone, Link: telLink(*refs.SupportPhone)})
+	}
+	if refs.SupportEmail != nil {
+		card.Contacts = append(card.Contacts, ContactMethod{Kind: "email", Label: "Support email",
+			Value: *refs.SupportEmail, Link: "mailto:" + *refs.SupportEmail})
+	}
+	if refs.InstitutionURL != nil {
+		card.Contacts = append(card.Contacts, ContactMethod{Kind: "website", Label: "Website",
+			Value: *refs.InstitutionURL, Link: *refs.InstitutionURL})
+	}
+	if refs.FraudPhone != nil {
+		card.Contacts = append(card.Contacts, ContactMethod{Kind: "fraud_phone", Label: "Fraud and lost cards",
+			Value: *refs.FraudPhone, Link: telLink(*refs.FraudPhone)})
+	}
+	return card, nil
+}
+
+// validateInstitutionURL accepts http and https URLs with a host
+func validateInstitutionURL(value string) (string, error) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("institution URL must be an http or https URL")
+	}
+	return value, nil
+}
+
+// validatePhone accepts digits with the usual separators and an optional extension
+func validatePhone(value string) (string, error) {
+	digits := 0
+	for _, r := range value {
+		switch {
+		case unicode.IsDigit(r):
+			digits++
+		case strings.ContainsRune("+-(). x", r) || r == 'e' || r == 't':
+		default:
+			return "", fmt.Errorf("phone number %q contains invalid characters", value)
+		}
+	}
+	if digits < 3 || digits > 20 {
+		return "", fmt.Errorf("phone number %q must have 3 to 20 digits", value)
+	}
+	return value, nil
+}
+
+// validateSupportEmail accepts a bare email address
+func validateSupportEmail(value string) (string, error) {
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Address != value {
+		return "", fmt.Errorf("support email must be an email address")
+	}
+	return value, nil
+}
+
+// normalizeAccountNumber drops the spaces and dashes account numbers are often written with
+func normalizeAccountNumber(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, value)
+}
+
+// isAlphanumeric reports whether value holds only ASCII letters and digits
+func isAlphanumeric(value string) bool {
+	for _, r := range value {
+		if (r < '0' || r > '9') && (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+// telLink builds a tel: link from the number's digits, dropping any extension
+func telLink(phone string) string {
+	number, _, _ := strings.Cut(strings.ToLower(phone), "ext")
+	number, _, _ = strings.Cut(number, "x")
+	var b strings.Builder
+	for _, r := range number {
+		if unicode.IsDigit(r) || (r == '+' && b.Len() == 0) {
+			b.WriteRune(r)
+		}
+	}
+	return "tel:" + b.String()
+}
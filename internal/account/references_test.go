@@ -0,0 +1,109 @@
+package account
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/sync/encryption"
+)
+
+func TestExternalReferences_MasksAccountNumberUntilRevealed(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-references-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeChecking)
+	service := SetupAccountService(t, db)
+	enc, err := encryption.NewService(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	if err != nil {
+		t.Fatalf("Failed to create encryption service: %v", err)
+	}
+	service.SetEncryption(enc)
+	number, phone, fraud, site := "1234-5678 9012", "1 (800) 555-0100 ext 4", "+1 800 555 0199", "https://bank.example.com"
+
+	// Act
+	refs, err := service.SetExternalReferences(ctx, accountID, &SetExternalReferencesRequest{
+		AccountNumber:  &number,
+		SupportPhone:   &phone,
+		FraudPhone:     &fraud,
+		InstitutionURL: &site,
+	})
+	if err != nil {
+		t.Fatalf("SetExternalReferences failed: %v", err)
+	}
+	_, staleErr := service.GetExternalReferences(ctx, accountID, true)
+	revealed, err := service.GetExternalReferences(auth.WithAuthenticatedAt(ctx, time.Now()), accountID, true)
+	if err != nil {
+		t.Fatalf("GetExternalReferences failed: %v", err)
+	}
+	card, err := service.GetContactCard(ctx, accountID)
+	if err != nil {
+		t.Fatalf("GetContactCard failed: %v", err)
+	}
+
+	// Assert
+	if refs.AccountNumber == nil || *refs.AccountNumber != "••••9012" || refs.Revealed {
+		t.Errorf("Expected the account number masked to its last 4, got %v", refs.AccountNumber)
+	}
+	if staleErr == nil {
+		t.Error("Expected revealing without a recent login to fail")
+	}
+	if revealed.AccountNumber == nil || *revealed.AccountNumber != "123456789012" {
+		t.Errorf("Expected the decrypted account number, got %v", revealed.AccountNumber)
+	}
+	var stored []byte
+	if err := db.QueryRow(`SELECT encrypted_account_number FROM account_references WHERE account_id = $1`, accountID).Scan(&stored); err != nil {
+		t.Fatalf("Failed to read stored account number: %v", err)
+	}
+	if strings.Contains(string(stored), "123456789012") {
+		t.Error("Expected the account number to be stored encrypted")
+	}
+	if len(card.Contacts) != 3 || card.Contacts[0].Link != "tel:18005550100" || card.Contacts[2].Kind != "fraud_phone" {
+		t.Errorf("Expected support, website then fraud contacts, got %+v", card.Contacts)
+	}
+}
+
+func TestExternalReferences_PrivateAccountHidesLastFour(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-references-2"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeSavings)
+	service := SetupAccountService(t, db)
+	enc, err := encryption.NewService(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	if err != nil {
+		t.Fatalf("Failed to create encryption service: %v", err)
+	}
+	service.SetEncryption(enc)
+	number, badEmail := "987654321", "not an email"
+	if _, err := service.SetExternalReferences(ctx, accountID, &SetExternalReferencesRequest{AccountNumber: &number}); err != nil {
+		t.Fatalf("SetExternalReferences failed: %v", err)
+	}
+	if _, err := service.SetPrivacy(ctx, accountID, &SetAccountPrivacyRequest{IsPrivate: true}); err != nil {
+		t.Fatalf("SetPrivacy failed: %v", err)
+	}
+
+	// Act
+	masked, err := service.GetExternalReferences(auth.WithMaskPrivate(ctx), accountID, false)
+	if err != nil {
+		t.Fatalf("GetExternalReferences failed: %v", err)
+	}
+	_, emailErr := service.SetExternalReferences(ctx, accountID, &SetExternalReferencesRequest{SupportEmail: &badEmail})
+
+	// Assert
+	if masked.AccountNumber == nil || *masked.AccountNumber != "••••" {
+		t.Errorf("Expected the account number fully masked in privacy mode, got %v", masked.AccountNumber)
+	}
+	if emailErr == nil {
+		t.Error("Expected an invalid support email to be rejected")
+	}
+}
@@ -0,0 +1,401 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"money/internal/civil"
+
+	"github.com/google/uuid"
+)
+
+// maxLoanYears bounds payoff simulations for loans whose payment barely covers interest
+const maxLoanYears = 100
+
+// RefinanceRequest describes the proposed terms of a refinance
+type RefinanceRequest struct {
+	InterestRate     float64 `json:"interest_rate"`
+	TermMonths       int     `json:"term_months"`
+	ClosingCosts     float64 `json:"closing_costs"`
+	PaymentFrequency string  `json:"payment_frequency,omitempty"` // Defaults to the current loan's frequency
+	RateType         string  `json:"rate_type,omitempty"`         // Defaults to the current loan's rate type
+	Lender           string  `json:"lender,omitempty"`            // Defaults to the current lender
+	RefinanceDate    *Date   `json:"refinance_date,omitempty"`    // Defaults to today; used when committing
+	Notes            string  `json:"notes,omitempty"`
+}
+
+// RefinanceAnalysis compares refinancing a loan against keeping it
+type RefinanceAnalysis struct {
+	AccountID                string  `json:"account_id"`
+	CurrentBalance           float64 `json:"current_balance"`
+	CurrentPayment           float64 `json:"current_payment"`
+	CurrentPaymentFrequency  string  `json:"current_payment_frequency"`
+	CurrentRemainingPayments int     `json:"current_remaining_payments"`
+	CurrentRemainingInterest float64 `json:"current_remaining_interest"`
+	NewPayment               float64 `json:"new_payment"`
+	NewPaymentFrequency      string  `json:"new_payment_frequency"`
+	NewPayments              int     `json:"new_payments"`
+	NewTotalInterest         float64 `json:"new_total_interest"`
+	MonthlyPaymentChange     float64 `json:"monthly_payment_change"` // Negative when the refinance lowers payments
+	ClosingCosts             float64 `json:"closing_costs"`
+	InterestSavings          float64 `json:"interest_savings"` // Lifetime interest avoided, before closing costs
+	NetSavings               float64 `json:"net_savings"`      // Interest savings less closing costs
+	BreakEvenMonth           *int    `json:"break_even_month"` // Months until interest saved covers closing costs; nil if never
+}
+
+// LoanRefinance records the terms a loan had before it was refinanced
+type LoanRefinance struct {
+	ID                       string    `json:"id"`
+	AccountID                string    `json:"account_id"`
+	PreviousLoanDetailsID    string    `json:"previous_loan_details_id"`
+	NewLoanDetailsID         string    `json:"new_loan_details_id"`
+	RefinanceDate            Date      `json:"refinance_date"`
+	BalanceAtRefinance       float64   `json:"balance_at_refinance"`
+	ClosingCosts             float64   `json:"closing_costs"`
+	PreviousInterestRate     float64   `json:"previous_interest_rate"`
+	PreviousRateType         string    `json:"previous_rate_type"`
+	PreviousStartDate        Date      `json:"previous_start_date"`
+	PreviousTermMonths       int       `json:"previous_term_months"`
+	PreviousPaymentAmount    float64   `json:"previous_payment_amount"`
+	PreviousPaymentFrequency string    `json:"previous_payment_frequency"`
+	PreviousOriginalAmount   float64   `json:"previous_original_amount"`
+	PreviousLender           string    `json:"previous_lender,omitempty"`
+	PreviousMaturityDate     Date      `json:"previous_maturity_date"`
+	Notes                    string    `json:"notes,omitempty"`
+	CreatedAt                time.Time `json:"created_at"`
+}
+
+// RefinanceResponse is the outcome of committing a refinance
+type RefinanceResponse struct {
+	Analysis    *RefinanceAnalysis `json:"analysis"`
+	Refinance   *LoanRefinance     `json:"refinance"`
+	LoanDetails *LoanDetails       `json:"loan_details"`
+}
+
+// LoanRefinancesResponse lists a loan's refinance history
+type LoanRefinancesResponse struct {
+	Refinances []LoanRefinance `json:"refinances"`
+}
+
+// AnalyzeRefinance compares refinancing the outstanding loan balance on new terms against
+// keeping the current loan. Closing costs are paid up front rather than added to the balance.
+func (s *Service) AnalyzeRefinance(ctx context.Context, accountID string, req *RefinanceRequest) (*RefinanceAnalysis, error) {
+	details, err := s.GetLoanDetails(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := s.currentLoanBalance(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return analyzeRefinance(details, balance, req)
+}
+
+// analyzeRefinance compares the remaining cost of a loan with the cost of refinancing it
+func analyzeRefinance(details *LoanDetails, balance float64, req *RefinanceRequest) (*RefinanceAnalysis, error) {
+	if req.InterestRate < 0 {
+		return nil, fmt.Errorf("interest_rate must not be negative")
+	}
+	if req.TermMonths <= 0 {
+		return nil, fmt.Errorf("term_months must be positive")
+	}
+	if req.ClosingCosts < 0 {
+		return nil, fmt.Errorf("closing_costs must not be negative")
+	}
+	if balance <= 0 {
+		return nil, fmt.Errorf("loan has no outstanding balance to refinance")
+	}
+	switch req.PaymentFrequency {
+	case "", "weekly", "bi-weekly", "semi-monthly", "monthly":
+	default:
+		return nil, fmt.Errorf("invalid payment_frequency: %s", req.PaymentFrequency)
+	}
+	if req.RateType != "" && req.RateType != "fixed" && req.RateType != "variable" {
+		return nil, fmt.Errorf("invalid rate_type: %s", req.RateType)
+	}
+
+	frequency := req.PaymentFrequency
+	if frequency == "" {
+		frequency = details.PaymentFrequency
+	}
+	periodsPerYear := getPeriodsPerYear(frequency)
+	newPayments := int(math.Ceil(float64(req.TermMonths) * float64(periodsPerYear) / 12.0))
+	newPayment := AmortizedPayment(balance, req.InterestRate, newPayments, periodsPerYear)
+
+	currentCount, currentInterest, err := simulateLoanPayoff(balance, details.InterestRate, details.PaymentAmount, details.PaymentFrequency)
+	if err != nil {
+		return nil, err
+	}
+	newCount, newInterest, err := simulateLoanPayoff(balance, req.InterestRate, newPayment, frequency)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := &RefinanceAnalysis{
+		AccountID:                details.AccountID,
+		CurrentBalance:           balance,
+		CurrentPayment:           details.PaymentAmount,
+		CurrentPaymentFrequency:  details.PaymentFrequency,
+		CurrentRemainingPayments: currentCount,
+		CurrentRemainingInterest: sumAmounts(currentInterest),
+		NewPayment:               newPayment,
+		NewPaymentFrequency:      frequency,
+		NewPayments:              newCount,
+		NewTotalInterest:         sumAmounts(newInterest),
+		MonthlyPaymentChange: newPayment*float64(periodsPerYear)/12.0 -
+			details.PaymentAmount*float64(getPeriodsPerYear(details.PaymentFrequency))/12.0,
+		ClosingCosts: req.ClosingCosts,
+	}
+	analysis.InterestSavings = analysis.CurrentRemainingInterest - analysis.NewTotalInterest
+	analysis.NetSavings = analysis.InterestSavings - req.ClosingCosts
+
+	// Break even once the interest avoided so far covers the closing costs
+	saved := 0.0
+	for month := 0; month < len(currentInterest) || month < len(newInterest); month++ {
+		if month < len(currentInterest) {
+			saved += currentInterest[month]
+		}
+		if month < len(newInterest) {
+			saved -= newInterest[month]
+		}
+		if saved >= req.ClosingCosts {
+			breakEven := month + 1
+			analysis.BreakEvenMonth = &breakEven
+			break
+		}
+	}
+
+	return analysis, nil
+}
+
+// AmortizedPayment returns the level payment that pays off principal over the given
+// number of payments
+func AmortizedPayment(principal, annualRate float64, payments, periodsPerYear int) float64 {
+	if annualRate == 0 {
+		return principal / float64(payments)
+	}
+	periodRate := annualRate / float64(periodsPerYear)
+	return principal * periodRate / (1 - math.Pow(1+periodRate, -float64(payments)))
+}
+
+// simulateLoanPayoff pays a balance down with a fixed payment, returning the number of
+// payments and the interest paid in each month from now
+func simulateLoanPayoff(balance, annualRate, payment float64, frequency string) (int, []float64, error) {
+	periodsPerYear := getPeriodsPerYear(frequency)
+	periodRate := annualRate / float64(periodsPerYear)
+	if payment <= balance*periodRate {
+		return 0, nil, fmt.Errorf("payment of %.2f does not cover the interest on the loan", payment)
+	}
+
+	monthlyInterest := make([]float64, 0)
+	payments := 0
+	for balance > 0.01 && payments < maxLoanYears*periodsPerYear {
+		interest := balance * periodRate
+		balance -= math.Min(payment-interest, balance)
+
+		month := payments * 12 / periodsPerYear
+		for len(monthlyInterest) <= month {
+			monthlyInterest = append(monthlyInterest, 0)
+		}
+		monthlyInterest[month] += interest
+		payments++
+	}
+	return payments, monthlyInterest, nil
+}
+
+// sumAmounts adds up a slice of amounts
+func sumAmounts(amounts []float64) float64 {
+	total := 0.0
+	for _, amount := range amounts {
+		total += amount
+	}
+	return total
+}
+
+// currentLoanBalance returns the amount owed on a loan, from the last payment or the
+// original amount when none have been recorded
+func (s *Service) currentLoanBalance(ctx context.Context, accountID string) (float64, error) {
+	var balance float64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(
+			(SELECT balance_after FROM loan_payments WHERE account_id = $1 ORDER BY payment_date DESC, created_at DESC LIMIT 1),
+			-(SELECT original_amount FROM loan_details WHERE account_id = $1)
+		)
+	`, accountID).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current balance: %w", err)
+	}
+	// Loan balances are stored as negative amounts
+	return math.Abs(balance), nil
+}
+
+// RefinanceLoan commits a refinance: the current loan details are closed and recorded in
+// the refinance history, and new loan details for the outstanding balance replace them,
+// linked through the refinance record. Past payments stay with the account.
+func (s *Service) RefinanceLoan(ctx context.Context, accountID string, req *RefinanceRequest) (*RefinanceResponse, error) {
+	details, err := s.GetLoanDetails(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := s.currentLoanBalance(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	analysis, err := analyzeRefinance(details, balance, req)
+	if err != nil {
+		return nil, err
+	}
+
+	refinanceDate := civil.TodayIn(ctx)
+	if req.RefinanceDate != nil {
+		refinanceDate = *req.RefinanceDate
+	}
+	rateType := req.RateType
+	if rateType == "" {
+		rateType = details.RateType
+	}
+	lender := req.Lender
+	if lender == "" {
+		lender = details.Lender
+	}
+
+	now := time.Now()
+	newDetails := &LoanDetails{
+		ID:               uuid.New().String(),
+		AccountID:        accountID,
+		OriginalAmount:   balance,
+		InterestRate:     req.InterestRate,
+		RateType:         rateType,
+		StartDate:        refinanceDate,
+		TermMonths:       req.TermMonths,
+		PaymentAmount:    math.Round(analysis.NewPayment*100) / 100,
+		PaymentFrequency: analysis.NewPaymentFrequency,
+		PaymentDay:       details.PaymentDay,
+		LoanType:         details.LoanType,
+		Lender:           lender,
+		LoanNumber:       details.LoanNumber,
+		Purpose:          details.Purpose,
+		MaturityDate:     Date{Time: refinanceDate.AddDate(0, req.TermMonths, 0)},
+		Notes:            details.Notes,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	refinance := &LoanRefinance{
+		ID:                       uuid.New().String(),
+		AccountID:                accountID,
+		PreviousLoanDetailsID:    details.ID,
+		NewLoanDetailsID:         newDetails.ID,
+		RefinanceDate:            refinanceDate,
+		BalanceAtRefinance:       balance,
+		ClosingCosts:             req.ClosingCosts,
+		PreviousInterestRate:     details.InterestRate,
+		PreviousRateType:         details.RateType,
+		PreviousStartDate:        details.StartDate,
+		PreviousTermMonths:       details.TermMonths,
+		PreviousPaymentAmount:    details.PaymentAmount,
+		PreviousPaymentFrequency: details.PaymentFrequency,
+		PreviousOriginalAmount:   details.OriginalAmount,
+		PreviousLender:           details.Lender,
+		PreviousMaturityDate:     details.MaturityDate,
+		Notes:                    req.Notes,
+		CreatedAt:                now,
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO loan_refinances (
+			id, account_id, previous_loan_details_id, new_loan_details_id,
+			refinance_date, balance_at_refinance, closing_costs,
+			previous_interest_rate, previous_rate_type, previous_start_date, previous_term_months,
+			previous_payment_amount, previous_payment_frequency, previous_original_amount,
+			previous_lender, previous_maturity_date, notes, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	`, refinance.ID, accountID, refinance.PreviousLoanDetailsID, refinance.NewLoanDetailsID,
+		refinance.RefinanceDate, refinance.BalanceAtRefinance, refinance.ClosingCosts,
+		refinance.PreviousInterestRate, refinance.PreviousRateType, refinance.PreviousStartDate, refinance.PreviousTermMonths,
+		refinance.PreviousPaymentAmount, refinance.PreviousPaymentFrequency, refinance.PreviousOriginalAmount,
+		refinance.PreviousLender, refinance.PreviousMaturityDate, refinance.Notes, refinance.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record refinance: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM loan_details WHERE id = $1`, details.ID); err != nil {
+		return nil, fmt.Errorf("failed to close loan details: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO loan_details (
+			id, account_id, original_amount, interest_rate, rate_type,
+			start_date, term_months,
+			payment_amount, payment_frequency, payment_day,
+			loan_type, lender, loan_number, purpose, maturity_date, notes,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	`, newDetails.ID, accountID, newDetails.OriginalAmount, newDetails.InterestRate, newDetails.RateType,
+		newDetails.StartDate, newDetails.TermMonths,
+		newDetails.PaymentAmount, newDetails.PaymentFrequency, newDetails.PaymentDay,
+		newDetails.LoanType, newDetails.Lender, newDetails.LoanNumber, newDetails.Purpose, newDetails.MaturityDate, newDetails.Notes,
+		newDetails.CreatedAt, newDetails.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refinanced loan details: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit refinance: %w", err)
+	}
+
+	return &RefinanceResponse{
+		Analysis:    analysis,
+		Refinance:   refinance,
+		LoanDetails: newDetails,
+	}, nil
+}
+
+// GetLoanRefinances returns a loan's refinance history, most recent first
+func (s *Service) GetLoanRefinances(ctx context.Context, accountID string) (*LoanRefinancesResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, account_id, previous_loan_details_id, new_loan_details_id,
+			refinance_date, balance_at_refinance, closing_costs,
+			previous_interest_rate, previous_rate_type, previous_start_date, previous_term_months,
+			previous_payment_amount, previous_payment_frequency, previous_original_amount,
+			COALESCE(previous_lender, ''), previous_maturity_date, COALESCE(notes, ''), created_at
+		FROM loan_refinances
+		WHERE account_id = $1
+		ORDER BY refinance_date DESC, created_at DESC
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refinances: %w", err)
+	}
+	defer rows.Close()
+
+	refinances := make([]LoanRefinance, 0)
+	for rows.Next() {
+		var r LoanRefinance
+		if err := rows.Scan(
+			&r.ID, &r.AccountID, &r.PreviousLoanDetailsID, &r.NewLoanDetailsID,
+			&r.RefinanceDate, &r.BalanceAtRefinance, &r.ClosingCosts,
+			&r.PreviousInterestRate, &r.PreviousRateType, &r.PreviousStartDate, &r.PreviousTermMonths,
+			&r.PreviousPaymentAmount, &r.PreviousPaymentFrequency, &r.PreviousOriginalAmount,
+			&r.PreviousLender, &r.PreviousMaturityDate, &r.Notes, &r.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan refinance: %w", err)
+		}
+		refinances = append(refinances, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read refinances: %w", err)
+	}
+
+	return &LoanRefinancesResponse{Refinances: refinances}, nil
+}
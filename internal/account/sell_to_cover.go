@@ -0,0 +1,250 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/background"
+	"money/internal/civil"
+
+	"github.com/google/uuid"
+)
+
+// sellToCoverInterval is how often vested RSUs are checked for sell-to-cover sales
+const sellToCoverInterval = 24 * time.Hour
+
+// validateSellToCoverRate checks a withholding rate is a fraction of the vest on an RSU grant
+func validateSellToCoverRate(grantType GrantType, rate *float64) error {
+	if rate == nil {
+		return nil
+	}
+	if grantType != GrantTypeRSU {
+		return fmt.Errorf("sell_to_cover_rate is only supported for RSU grants")
+	}
+	if *rate < 0 || *rate >= 1 {
+		return fmt.Errorf("sell_to_cover_rate must be at least 0 and below 1")
+	}
+	return nil
+}
+
+// withholdAtVest sets the shares sold to cover withholding on a vest and the net shares
+// delivered. Brokerages sell whole shares, so the withheld count rounds up.
+func withholdAtVest(grant *EquityGrant, event *VestingEvent) {
+	event.NetQuantity = event.Quantity
+	if grant.GrantType != GrantTypeRSU || grant.SellToCoverRate == nil {
+		return
+	}
+
+	withheld := int(math.Ceil(float64(event.Quantity)**grant.SellToCoverRate - 1e-9))
+	if withheld > event.Quantity {
+		withheld = event.Quantity
+	}
+	event.SharesWithheld = withheld
+	event.NetQuantity = event.Quantity - withheld
+	event.WithholdingValue = float64(withheld) * event.FMVAtVest
+}
+
+// grantVest is a vesting event with the currency of its grant
+type grantVest struct {
+	grant    EquityGrant
+	event    VestingEvent
+	currency string
+}
+
+// vestedRSUEvents returns the RSU vests of an account on or before asOf, valued at the FMV
+// in force on each vest date
+func (s *Service) vestedRSUEvents(ctx context.Context, accountID string, asOf time.Time) ([]grantVest, error) {
+	grantsResp, err := s.GetEquityGrants(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var fmvEntries []FMVEntry
+	if fmvHistory, _ := s.GetFMVHistory(ctx, accountID); fmvHistory != nil {
+		fmvEntries = fmvHistory.Entries
+	}
+
+	vests := make([]grantVest, 0)
+	for _, grant := range grantsResp.Grants {
+		if grant.GrantType != GrantTypeRSU {
+			continue
+		}
+		schedule, err := s.GetVestingSchedule(ctx, grant.ID)
+		if err != nil {
+			continue // No schedule, nothing has vested
+		}
+
+		currency := grant.Currency
+		if currency == "" {
+			currency = "USD"
+		}
+		events := computeVestingEventsAsOf(&grant, schedule, asOf)
+		applyFMVAtVest(events, fmvEntries, currency)
+		for _, event := range events {
+			if event.Status == VestingStatusVested {
+				vests = append(vests, grantVest{grant: grant, event: event, currency: currency})
+			}
+		}
+	}
+
+	return vests, nil
+}
+
+// vestsBetween returns the RSU vests of an account from one date to another, inclusive
+func (s *Service) vestsBetween(ctx context.Context, accountID string, from, to time.Time) ([]grantVest, error) {
+	vests, err := s.vestedRSUEvents(ctx, accountID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	between := make([]grantVest, 0, len(vests))
+	for _, vest := range vests {
+		if !vest.event.VestDate.Before(from) {
+			between = append(between, vest)
+		}
+	}
+	return between, nil
+}
+
+// RecordSellToCoverSales records the sale of the shares withheld at each vested RSU event
+// of an account that has no sell-to-cover sale yet. The shares are sold at the FMV on the
+// vest date, which is also their cost basis, so the sale carries no capital gain.
+// Returns the sales recorded.
+func (s *Service) RecordSellToCoverSales(ctx context.Context, accountID string) (*SalesResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	vests, err := s.vestedRSUEvents(ctx, accountID, civil.TodayIn(ctx).Time)
+	if err != nil {
+		return nil, err
+	}
+
+	sales := make([]EquitySale, 0)
+	for _, vest := range vests {
+		if vest.event.SharesWithheld == 0 {
+			continue
+		}
+
+		sale, err := s.insertSellToCoverSale(ctx, accountID, &vest.grant, &vest.event)
+		if err != nil {
+			return nil, err
+		}
+		if sale != nil {
+			sales = append(sales, *sale)
+		}
+	}
+
+	return &SalesResponse{Sales: sales}, nil
+}
+
+// insertSellToCoverSale stores the sale of the shares withheld at a vest, returning nil
+// when the vest already has one
+func (s *Service) insertSellToCoverSale(ctx context.Context, accountID string, grant *EquityGrant, event *VestingEvent) (*EquitySale, error) {
+	holdingPeriodDays := 0
+	isQualified := false
+	notes := fmt.Sprintf("Sell-to-cover on %s vest", event.VestDate)
+
+	sale := &EquitySale{
+		ID:                uuid.New().String(),
+		AccountID:         accountID,
+		GrantID:           &grant.ID,
+		SaleDate:          event.VestDate,
+		Quantity:          event.SharesWithheld,
+		SalePrice:         event.FMVAtVest,
+		TotalProceeds:     event.WithholdingValue,
+		CostBasis:         event.WithholdingValue,
+		HoldingPeriodDays: &holdingPeriodDays,
+		IsQualified:       &isQualified,
+		VestingEventID:    &event.ID,
+		Notes:             &notes,
+		CreatedAt:         time.Now(),
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO equity_sales (
+			id, account_id, grant_id, sale_date, quantity, sale_price, total_proceeds, cost_basis,
+			capital_gain, holding_period_days, is_qualified, vesting_event_id, notes, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT DO NOTHING
+	`, sale.ID, sale.AccountID, sale.GrantID, sale.SaleDate, sale.Quantity, sale.SalePrice, sale.TotalProceeds,
+		sale.CostBasis, sale.CapitalGain, sale.HoldingPeriodDays, sale.IsQualified, sale.VestingEventID,
+		sale.Notes, sale.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record sell-to-cover sale: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, nil
+	}
+
+	return sale, nil
+}
+
+// RecordDueSellToCoverSales records sell-to-cover sales for every account with an RSU grant
+// that withholds shares at vest. Failures are logged per account so one bad grant doesn't
+// block the rest.
+func (s *Service) RecordDueSellToCoverSales(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT eg.account_id, a.user_id
+		FROM equity_grants eg
+		JOIN accounts a ON a.id = eg.account_id
+		WHERE eg.sell_to_cover_rate > 0 AND a.is_active
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get sell-to-cover accounts: %w", err)
+	}
+
+	type sellToCoverAccount struct {
+		accountID string
+		userID    string
+	}
+	var accounts []sellToCoverAccount
+	for rows.Next() {
+		var a sellToCoverAccount
+		if err := rows.Scan(&a.accountID, &a.userID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan sell-to-cover account: %w", err)
+		}
+		accounts = append(accounts, a)
+	}
+	rows.Close()
+
+	recorded := 0
+	for _, a := range accounts {
+		sales, err := s.RecordSellToCoverSales(auth.WithUserID(ctx, a.userID), a.accountID)
+		if err != nil {
+			accountLog.Error("Sell-to-cover failed", "account_id", a.accountID, "error", err)
+			continue
+		}
+		recorded += len(sales.Sales)
+	}
+
+	return recorded, nil
+}
+
+// StartSellToCover records sell-to-cover sales for new RSU vests daily until jobs drains
+func (s *Service) StartSellToCover(jobs *background.Group) {
+	jobs.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(sellToCoverInterval)
+		defer ticker.Stop()
+
+		for {
+			jobs.Exclusive(ctx, "sell_to_cover", sellToCoverInterval, func(ctx context.Context) {
+				if recorded, err := s.RecordDueSellToCoverSales(ctx); err != nil {
+					accountLog.Error("Sell-to-cover failed", "error", err)
+				} else if recorded > 0 {
+					accountLog.Info("Recorded sell-to-cover sales", "count", recorded)
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
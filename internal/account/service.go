@@ -11,6 +11,10 @@ import (
 	"github.com/google/uuid"
 	"money/internal/auth"
 	"money/internal/balance"
+	"money/internal/currency"
+	"money/internal/jurisdiction"
+	"money/internal/kvstore"
+	"money/internal/sync/encryption"
 )
 
 // Service provides account management functionality
@@ -18,6 +22,10 @@ type Service struct {
 	db         *sql.DB
 	balanceDB  *sql.DB
 	balanceSvc *balance.Service
+	encryption *encryption.Service // Encrypts stored account numbers; nil until set
+	rates      *currency.Service   // Cached exchange rates; nil reads them from the database
+
+	summaryCache kvstore.Store // Shared cache of built summaries; nil stores them in account_summaries
 }
 
 // NewService creates a new account service
@@ -72,8 +80,12 @@ type Account struct {
 	IsActive     bool        `json:"is_active"`
 	IsSynced     bool        `json:"is_synced"`               // true if managed by a connection
 	ConnectionID string      `json:"connection_id,omitempty"` // reference to Connection if synced
+	EntityID     *string     `json:"entity_id,omitempty"`     // owning entity, nil if owned personally
+	IsPrivate    bool        `json:"is_private"`              // balance masked while privacy mode is on
 	CreatedAt    time.Time   `json:"created_at"`
 	UpdatedAt    time.Time   `json:"updated_at"`
+
+	Exclusions
 }
 
 // CreateAccountRequest represents the request to create a new account
@@ -85,6 +97,8 @@ type CreateAccountRequest struct {
 	IsAsset      bool        `json:"is_asset"`
 	IsSynced     bool        `json:"is_synced,omitempty"`     // Optional: mark as synced account
 	ConnectionID string      `json:"connection_id,omitempty"` // Optional: connection reference
+	EntityID     *string     `json:"entity_id,omitempty"`     // Optional: owning entity
+	IsPrivate    bool        `json:"is_private,omitempty"`    // Optional: mask the balance in privacy mode
 }
 
 // UpdateAccountRequest represents the request to update an account
@@ -102,6 +116,27 @@ type AccountWithBalance struct {
 	Account
 	CurrentBalance *float64 `json:"current_balance,omitempty"`
 	BalanceDate    *string  `json:"balance_date,omitempty"`
+	BalanceMasked  bool     `json:"balance_masked,omitempty"` // Private account in privacy mode; left out of totals
+}
+
+// Exclusions leave an account out of parts of the user's picture, such as a business
+// account tracked but not personally owned. Each applies independently.
+type Exclusions struct {
+	ExcludeFromProjections bool `json:"exclude_from_projections"` // Not projected forward
+	ExcludeFromNetWorth    bool `json:"exclude_from_net_worth"`   // Left out of net worth totals and snapshots
+	ExcludeFromSpending    bool `json:"exclude_from_spending"`    // Transactions left out of spending analytics and budgets
+}
+
+// SetAccountExclusionsRequest changes the exclusions that are set
+type SetAccountExclusionsRequest struct {
+	ExcludeFromProjections *bool `json:"exclude_from_projections,omitempty"`
+	ExcludeFromNetWorth    *bool `json:"exclude_from_net_worth,omitempty"`
+	ExcludeFromSpending    *bool `json:"exclude_from_spending,omitempty"`
+}
+
+// SetAccountPrivacyRequest marks an account private or not
+type SetAccountPrivacyRequest struct {
+	IsPrivate bool `json:"is_private"`
 }
 
 // ListAccountsResponse represents the response for listing accounts
@@ -111,7 +146,9 @@ type ListAccountsResponse struct {
 
 // ListAccountsWithBalanceResponse represents the response for listing accounts with balances
 type ListAccountsWithBalanceResponse struct {
-	Accounts []*AccountWithBalance `json:"accounts"`
+	Accounts       []*AccountWithBalance              `json:"accounts"`
+	ByCurrency     map[string]*CurrencyBalanceSummary `json:"by_currency"`               // Balance totals per currency, never summed across currencies
+	ConvertedTotal *ConvertedTotal                    `json:"converted_total,omitempty"` // Set when a base currency is requested
 }
 
 // DeleteAccountResponse represents the response for deleting an account
@@ -121,12 +158,14 @@ type DeleteAccountResponse struct {
 
 // AccountSummary represents a summary of accounts
 type AccountSummary struct {
-	TotalAccounts     int            `json:"total_accounts"`
-	ActiveAccounts    int            `json:"active_accounts"`
-	AssetAccounts     int            `json:"asset_accounts"`
-	LiabilityAccounts int            `json:"liability_accounts"`
-	ByCurrency        map[string]int `json:"by_currency"`
-	ByType            map[string]int `json:"by_type"`
+	TotalAccounts      int                                `json:"total_accounts"`
+	ActiveAccounts     int                                `json:"active_accounts"`
+	AssetAccounts      int                                `json:"asset_accounts"`
+	LiabilityAccounts  int                                `json:"liability_accounts"`
+	ByCurrency         map[string]int                     `json:"by_currency"`
+	ByType             map[string]int                     `json:"by_type"`
+	BalancesByCurrency map[string]*CurrencyBalanceSummary `json:"balances_by_currency"`      // Active account balance totals per currency
+	ConvertedTotal     *ConvertedTotal                    `json:"converted_total,omitempty"` // Set when a base currency is requested
 }
 
 // verifyAccountOwnership checks if the account belongs to the authenticated user
@@ -160,6 +199,21 @@ func (s *Service) Create(ctx context.Context, req *CreateAccountRequest) (*Accou
 		return nil, fmt.Errorf("user not authenticated")
 	}
 
+	// Currency and the account types offered follow the user's jurisdiction. Synced accounts
+	// mirror whatever the institution holds, so they aren't restricted.
+	profile := jurisdiction.FromContext(ctx)
+	if req.Currency == "" {
+		req.Currency = Currency(profile.Currency)
+	}
+	switch req.Currency {
+	case CurrencyCAD, CurrencyUSD, CurrencyINR:
+	default:
+		return nil, fmt.Errorf("unsupported currency: %s", req.Currency)
+	}
+	if !req.IsSynced && !profile.OffersAccountType(string(req.Type)) {
+		return nil, fmt.Errorf("%s accounts are not offered in %s", req.Type, profile.Name)
+	}
+
 	account := &Account{
 		ID:           uuid.New().String(),
 		UserID:       userID,
@@ -171,24 +225,35 @@ func (s *Service) Create(ctx context.Context, req *CreateAccountRequest) (*Accou
 		IsActive:     true,
 		IsSynced:     req.IsSynced,
 		ConnectionID: req.ConnectionID,
+		EntityID:     req.EntityID,
+		IsPrivate:    req.IsPrivate,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
 
+	if req.EntityID != nil {
+		if _, err := s.GetEntity(ctx, *req.EntityID); err != nil {
+			return nil, err
+		}
+	}
+
 	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO accounts (id, user_id, name, type, currency, institution, is_asset, is_active, is_synced, connection_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-	`, account.ID, userID, req.Name, req.Type, req.Currency, req.Institution, req.IsAsset, true, req.IsSynced, req.ConnectionID, account.CreatedAt, account.UpdatedAt)
+		INSERT INTO accounts (id, user_id, name, type, currency, institution, is_asset, is_active, is_synced, connection_id, entity_id, is_private, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`, account.ID, userID, req.Name, req.Type, req.Currency, req.Institution, req.IsAsset, true, req.IsSynced, req.ConnectionID, req.EntityID, req.IsPrivate, account.CreatedAt, account.UpdatedAt)
 
 	if err != nil {
 		return nil, err
 	}
+	if err := balance.InvalidateSummaries(ctx, s.db, userID); err != nil {
+		return nil, err
+	}
 
 	return account, nil
 }
 
-// Summary returns a summary of all accounts
-func (s *Service) Summary(ctx context.Context) (*AccountSummary, error) {
+// buildSummary builds a summary of all accounts from the accounts and balances tables
+func (s *Service) buildSummary(ctx context.Context) (*AccountSummary, error) {
 	// TODO: Get user ID from auth context
 	userID := auth.GetUserID(ctx)
 	if userID == "" {
@@ -204,9 +269,9 @@ func (s *Service) Summary(ctx context.Context) (*AccountSummary, error) {
 	err := s.db.QueryRowContext(ctx, `
 		SELECT
 			COUNT(*) as total,
-			SUM(CASE WHEN is_active = 1 THEN 1 ELSE 0 END) as active,
-			SUM(CASE WHEN is_asset = 1 THEN 1 ELSE 0 END) as assets,
-			SUM(CASE WHEN is_asset = 0 THEN 1 ELSE 0 END) as liabilities
+			COALESCE(SUM(CASE WHEN is_active = 1 THEN 1 ELSE 0 END), 0) as active,
+			COALESCE(SUM(CASE WHEN is_asset = 1 THEN 1 ELSE 0 END), 0) as assets,
+			COALESCE(SUM(CASE WHEN is_asset = 0 THEN 1 ELSE 0 END), 0) as liabilities
 		FROM accounts
 		WHERE user_id = $1
 	`, userID).Scan(&summary.TotalAccounts, &summary.ActiveAccounts, &summary.AssetAccounts, &summary.LiabilityAccounts)
@@ -257,6 +322,12 @@ func (s *Service) Summary(ctx context.Context) (*AccountSummary, error) {
 		summary.ByType[accountType] = count
 	}
 
+	accounts, err := s.ListWithBalance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summary.BalancesByCurrency = accounts.ByCurrency
+
 	return summary, nil
 }
 
@@ -269,7 +340,8 @@ func (s *Service) List(ctx context.Context) (*ListAccountsResponse, error) {
 	}
 
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, user_id, name, type, currency, institution, is_asset, is_active, is_synced, connection_id, created_at, updated_at
+		SELECT id, user_id, name, type, currency, institution, is_asset, is_active, is_synced, connection_id, entity_id, is_private,
+		       exclude_from_projections, exclude_from_net_worth, exclude_from_spending, created_at, updated_at
 		FROM accounts
 		WHERE user_id = $1 AND is_active = true
 		ORDER BY created_at DESC
@@ -294,6 +366,11 @@ func (s *Service) List(ctx context.Context) (*ListAccountsResponse, error) {
 			&account.IsActive,
 			&account.IsSynced,
 			&connectionID,
+			&account.EntityID,
+			&account.IsPrivate,
+			&account.ExcludeFromProjections,
+			&account.ExcludeFromNetWorth,
+			&account.ExcludeFromSpending,
 			&account.CreatedAt,
 			&account.UpdatedAt,
 		)
@@ -319,7 +396,8 @@ func (s *Service) ListWithBalance(ctx context.Context) (*ListAccountsWithBalance
 
 	// First, get all accounts
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, user_id, name, type, currency, institution, is_asset, is_active, is_synced, connection_id, created_at, updated_at
+		SELECT id, user_id, name, type, currency, institution, is_asset, is_active, is_synced, connection_id, entity_id, is_private,
+		       exclude_from_projections, exclude_from_net_worth, exclude_from_spending, created_at, updated_at
 		FROM accounts
 		WHERE user_id = $1 AND is_active = true
 		ORDER BY created_at DESC
@@ -346,6 +424,11 @@ func (s *Service) ListWithBalance(ctx context.Context) (*ListAccountsWithBalance
 			&accountWithBalance.IsActive,
 			&accountWithBalance.IsSynced,
 			&connectionID,
+			&accountWithBalance.EntityID,
+			&accountWithBalance.IsPrivate,
+			&accountWithBalance.ExcludeFromProjections,
+			&accountWithBalance.ExcludeFromNetWorth,
+			&accountWithBalance.ExcludeFromSpending,
 			&accountWithBalance.CreatedAt,
 			&accountWithBalance.UpdatedAt,
 		)
@@ -382,8 +465,7 @@ func (s *Service) ListWithBalance(ctx context.Context) (*ListAccountsWithBalance
 
 		balanceRows, err := s.balanceDB.QueryContext(ctx, query, args...)
 		if err != nil {
-			// If there's an error fetching balances, just return accounts without balances
-			return &ListAccountsWithBalanceResponse{Accounts: accounts}, nil
+			return nil, fmt.Errorf("failed to get balances: %w", err)
 		}
 		defer balanceRows.Close()
 
@@ -398,7 +480,7 @@ func (s *Service) ListWithBalance(ctx context.Context) (*ListAccountsWithBalance
 			var amount float64
 			var date time.Time
 			if err := balanceRows.Scan(&accountID, &amount, &date); err != nil {
-				continue
+				return nil, fmt.Errorf("failed to scan balance: %w", err)
 			}
 			balanceMap[accountID] = balanceInfo{
 				amount: amount,
@@ -406,8 +488,13 @@ func (s *Service) ListWithBalance(ctx context.Context) (*ListAccountsWithBalance
 			}
 		}
 
-		// Attach balances to accounts
+		// Attach balances to accounts, except private ones while privacy mode masks them
+		mask := auth.MaskPrivate(ctx)
 		for _, account := range accounts {
+			if mask && account.IsPrivate {
+				account.BalanceMasked = true
+				continue
+			}
 			if balanceInfo, ok := balanceMap[account.ID]; ok {
 				account.CurrentBalance = &balanceInfo.amount
 				account.BalanceDate = &balanceInfo.date
@@ -415,7 +502,7 @@ func (s *Service) ListWithBalance(ctx context.Context) (*ListAccountsWithBalance
 		}
 	}
 
-	return &ListAccountsWithBalanceResponse{Accounts: accounts}, nil
+	return &ListAccountsWithBalanceResponse{Accounts: accounts, ByCurrency: summarizeByCurrency(accounts)}, nil
 }
 
 // Get retrieves a single account by ID
@@ -429,7 +516,8 @@ func (s *Service) Get(ctx context.Context, id string) (*Account, error) {
 	account := &Account{}
 	var connectionID *string
 	err := s.db.QueryRowContext(ctx, `
-		SELECT id, user_id, name, type, currency, institution, is_asset, is_active, is_synced, connection_id, created_at, updated_at
+		SELECT id, user_id, name, type, currency, institution, is_asset, is_active, is_synced, connection_id, entity_id, is_private,
+		       exclude_from_projections, exclude_from_net_worth, exclude_from_spending, created_at, updated_at
 		FROM accounts
 		WHERE id = $1 AND user_id = $2
 	`, id, userID).Scan(
@@ -443,6 +531,11 @@ func (s *Service) Get(ctx context.Context, id string) (*Account, error) {
 		&account.IsActive,
 		&account.IsSynced,
 		&connectionID,
+		&account.EntityID,
+		&account.IsPrivate,
+		&account.ExcludeFromProjections,
+		&account.ExcludeFromNetWorth,
+		&account.ExcludeFromSpending,
 		&account.CreatedAt,
 		&account.UpdatedAt,
 	)
@@ -471,6 +564,7 @@ func (s *Service) Update(ctx context.Context, id string, req *UpdateAccountReque
 	if err != nil {
 		return nil, err
 	}
+	before := *account
 
 	// Update only the fields that are provided
 	if req.Name != nil {
@@ -493,7 +587,7 @@ func (s *Service) Update(ctx context.Context, id string, req *UpdateAccountReque
 	}
 	account.UpdatedAt = time.Now()
 
-	_, err = s.db.ExecContext(ctx, `
+	result, err := s.db.ExecContext(ctx, `
 		UPDATE accounts
 		SET name = $1, type = $2, currency = $3, institution = $4, is_asset = $5, is_active = $6, updated_at = $7
 		WHERE id = $8 AND user_id = $9 AND is_synced = false
@@ -502,10 +596,102 @@ func (s *Service) Update(ctx context.Context, id string, req *UpdateAccountReque
 	if err != nil {
 		return nil, err
 	}
+	// Synced accounts are left untouched, so there's nothing to record for them
+	if updated, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if updated > 0 {
+		if err := recordAccountEdits(ctx, s.db, &before, account); err != nil {
+			return nil, err
+		}
+	}
+	if err := balance.InvalidateSummaries(ctx, s.db, userID); err != nil {
+		return nil, err
+	}
 
 	return account, nil
 }
 
+// SetPrivacy marks an account private, masking its balance while privacy mode is on. Synced
+// accounts can be marked too. Unmarking reveals the balance, so it needs a fresh login.
+func (s *Service) SetPrivacy(ctx context.Context, id string, req *SetAccountPrivacyRequest) (*Account, error) {
+	account, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if account.IsPrivate && !req.IsPrivate && auth.MaskPrivate(ctx) && !auth.RecentlyAuthenticated(ctx) {
+		return nil, fmt.Errorf("log in again to make a private account visible")
+	}
+	before := *account
+
+	account.IsPrivate = req.IsPrivate
+	account.UpdatedAt = time.Now()
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE accounts SET is_private = $1, updated_at = $2 WHERE id = $3 AND user_id = $4
+	`, account.IsPrivate, account.UpdatedAt, id, account.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update account privacy: %w", err)
+	}
+	if err := recordAccountEdits(ctx, s.db, &before, account); err != nil {
+		return nil, err
+	}
+	if err := balance.InvalidateSummaries(ctx, s.db, account.UserID); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// SetExclusions leaves an account out of projections, net worth or spending analytics.
+// Synced accounts can be marked too. Changing the net worth exclusion rebuilds the net
+// worth history so past snapshots agree with today's totals.
+func (s *Service) SetExclusions(ctx context.Context, id string, req *SetAccountExclusionsRequest) (*Account, error) {
+	account, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *account
+
+	netWorthChanged := req.ExcludeFromNetWorth != nil && *req.ExcludeFromNetWorth != account.ExcludeFromNetWorth
+	if req.ExcludeFromProjections != nil {
+		account.ExcludeFromProjections = *req.ExcludeFromProjections
+	}
+	if req.ExcludeFromNetWorth != nil {
+		account.ExcludeFromNetWorth = *req.ExcludeFromNetWorth
+	}
+	if req.ExcludeFromSpending != nil {
+		account.ExcludeFromSpending = *req.ExcludeFromSpending
+	}
+	account.UpdatedAt = time.Now()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE accounts
+		SET exclude_from_projections = $1, exclude_from_net_worth = $2, exclude_from_spending = $3, updated_at = $4
+		WHERE id = $5 AND user_id = $6
+	`, account.ExcludeFromProjections, account.ExcludeFromNetWorth, account.ExcludeFromSpending, account.UpdatedAt, id, account.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update account exclusions: %w", err)
+	}
+	if err := recordAccountEdits(ctx, tx, &before, account); err != nil {
+		return nil, err
+	}
+	if netWorthChanged {
+		if err := balance.QueueNetWorthRecompute(ctx, tx, account.UserID, time.Time{}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return account, nil
+}
+
 // Delete deletes an account (soft delete by setting is_active to false)
 func (s *Service) Delete(ctx context.Context, id string) (*DeleteAccountResponse, error) {
 	userID := auth.GetUserID(ctx)
@@ -530,6 +716,9 @@ func (s *Service) Delete(ctx context.Context, id string) (*DeleteAccountResponse
 	if rowsAffected == 0 {
 		return nil, fmt.Errorf("account not found or access denied")
 	}
+	if err := balance.InvalidateSummaries(ctx, s.db, userID); err != nil {
+		return nil, err
+	}
 
 	return &DeleteAccountResponse{Success: true}, nil
 }
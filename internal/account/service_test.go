@@ -1,7 +1,14 @@
 package account
 
 import (
+	"database/sql"
 	"testing"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/currency"
+	"money/internal/jurisdiction"
+	"money/internal/kvstore"
 )
 
 func TestCreate_Success(t *testing.T) {
@@ -45,6 +52,32 @@ func TestCreate_Success(t *testing.T) {
 	}
 }
 
+func TestCreate_FollowsJurisdiction(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-create-jurisdiction"
+	CreateTestUser(t, db, userID)
+	ctx := jurisdiction.WithProfile(CreateAuthContext(userID), jurisdiction.Lookup("US"))
+	service := SetupAccountService(t, db)
+
+	// Act
+	brokerage, err := service.Create(ctx, &CreateAccountRequest{Name: "Brokerage", Type: AccountTypeBrokerage, IsAsset: true})
+	_, tfsaErr := service.Create(ctx, &CreateAccountRequest{Name: "TFSA", Type: AccountTypeTFSA, IsAsset: true})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if brokerage.Currency != CurrencyUSD {
+		t.Errorf("Expected the US currency by default, got %s", brokerage.Currency)
+	}
+	if tfsaErr == nil {
+		t.Error("Expected a TFSA to be refused for a US user")
+	}
+}
+
 func TestCreate_Unauthenticated(t *testing.T) {
 	db := SetupTestDB(t)
 	defer CleanupTestDB(t, db)
@@ -236,6 +269,111 @@ func TestListWithBalance_Success(t *testing.T) {
 	}
 }
 
+func TestListWithBalance_MasksPrivateAccountsInPrivacyMode(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-balance-private"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupAccountService(t, db)
+
+	publicID := CreateTestAccount(t, db, userID, AccountTypeSavings)
+	CreateTestBalance(t, db, publicID, 1000)
+	privateID := CreateTestAccount(t, db, userID, AccountTypeSavings)
+	CreateTestBalance(t, db, privateID, 5000)
+	if _, err := service.SetPrivacy(ctx, privateID, &SetAccountPrivacyRequest{IsPrivate: true}); err != nil {
+		t.Fatalf("SetPrivacy failed: %v", err)
+	}
+
+	// Act
+	masked, err := service.ListWithBalance(auth.WithMaskPrivate(ctx))
+	if err != nil {
+		t.Fatalf("ListWithBalance failed: %v", err)
+	}
+	revealed, err := service.ListWithBalance(ctx)
+	if err != nil {
+		t.Fatalf("ListWithBalance failed: %v", err)
+	}
+
+	// Assert
+	for _, acc := range masked.Accounts {
+		if acc.ID == privateID && (!acc.BalanceMasked || acc.CurrentBalance != nil) {
+			t.Errorf("Expected the private account's balance to be masked, got %+v", acc)
+		}
+		if acc.ID == publicID && (acc.BalanceMasked || acc.CurrentBalance == nil) {
+			t.Errorf("Expected the public account's balance to be shown, got %+v", acc)
+		}
+	}
+	if totals := masked.ByCurrency["CAD"]; totals == nil || totals.Assets != 1000 || totals.Masked != 1 {
+		t.Errorf("Expected totals without the private account, got %+v", totals)
+	}
+	if totals := revealed.ByCurrency["CAD"]; totals == nil || totals.Assets != 6000 || totals.Masked != 0 {
+		t.Errorf("Expected totals with every account outside privacy mode, got %+v", totals)
+	}
+
+	// Unmarking while masked reveals the balance, so it needs a fresh login
+	if _, err := service.SetPrivacy(auth.WithMaskPrivate(ctx), privateID, &SetAccountPrivacyRequest{IsPrivate: false}); err == nil {
+		t.Error("Expected unmarking a private account in privacy mode to need a fresh login")
+	}
+	fresh := auth.WithAuthenticatedAt(auth.WithMaskPrivate(ctx), time.Now())
+	if _, err := service.SetPrivacy(fresh, privateID, &SetAccountPrivacyRequest{IsPrivate: false}); err != nil {
+		t.Errorf("Expected unmarking after a fresh login to succeed, got %v", err)
+	}
+}
+
+func TestSetExclusions_LeavesAccountOutOfNetWorthOnly(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-balance-excluded"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupAccountService(t, db)
+
+	personalID := CreateTestAccount(t, db, userID, AccountTypeSavings)
+	CreateTestBalance(t, db, personalID, 1000)
+	businessID := CreateTestAccount(t, db, userID, AccountTypeSavings)
+	CreateTestBalance(t, db, businessID, 5000)
+	exclude := true
+
+	// Act
+	updated, err := service.SetExclusions(ctx, businessID, &SetAccountExclusionsRequest{ExcludeFromNetWorth: &exclude})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("SetExclusions failed: %v", err)
+	}
+	if !updated.ExcludeFromNetWorth || updated.ExcludeFromProjections || updated.ExcludeFromSpending {
+		t.Errorf("Expected only the net worth exclusion to be set, got %+v", updated.Exclusions)
+	}
+	accounts, err := service.ListWithBalance(ctx)
+	if err != nil {
+		t.Fatalf("ListWithBalance failed: %v", err)
+	}
+	if totals := accounts.ByCurrency["CAD"]; totals == nil || totals.Assets != 1000 || totals.Excluded != 1 {
+		t.Errorf("Expected totals without the excluded account, got %+v", totals)
+	}
+	statement, err := service.GetNetWorthStatement(ctx)
+	if err != nil {
+		t.Fatalf("GetNetWorthStatement failed: %v", err)
+	}
+	for _, acc := range statement.Assets {
+		if acc.ID == businessID {
+			t.Error("Expected the excluded account to be left off the net worth statement")
+		}
+	}
+	var queued int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM net_worth_recompute_queue WHERE user_id = $1`, userID).Scan(&queued); err != nil {
+		t.Fatalf("Failed to read the recompute queue: %v", err)
+	}
+	if queued != 1 {
+		t.Errorf("Expected the net worth history to be queued for a rebuild, got %d", queued)
+	}
+}
+
 func TestUpdate_Success(t *testing.T) {
 	db := SetupTestDB(t)
 	defer CleanupTestDB(t, db)
@@ -387,3 +525,297 @@ func TestSummary_Success(t *testing.T) {
 		t.Errorf("Expected 3 active accounts, got %d", summary.ActiveAccounts)
 	}
 }
+
+func TestSummary_ServesMaterializedSummaryUntilAWriteInvalidatesIt(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-summary-materialized"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupAccountService(t, db)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeSavings)
+	CreateTestBalance(t, db, accountID, 1000)
+
+	if _, err := service.Summary(ctx); err != nil {
+		t.Fatalf("Summary failed: %v", err)
+	}
+	// Written behind the service's back, so only a rebuild would see it
+	CreateTestBalance(t, db, accountID, 2500)
+
+	// Act
+	cached, err := service.Summary(ctx)
+	if err != nil {
+		t.Fatalf("Summary failed: %v", err)
+	}
+	if _, err := service.Create(ctx, &CreateAccountRequest{Name: "Chequing", Type: AccountTypeChecking, Currency: CurrencyCAD, IsAsset: true}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	rebuilt, err := service.Summary(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Summary failed: %v", err)
+	}
+	if totals := cached.BalancesByCurrency["CAD"]; totals == nil || totals.Assets != 1000 {
+		t.Errorf("Expected the materialized summary to be served, got %+v", totals)
+	}
+	if rebuilt.TotalAccounts != 2 {
+		t.Errorf("Expected 2 accounts after the write, got %d", rebuilt.TotalAccounts)
+	}
+	if totals := rebuilt.BalancesByCurrency["CAD"]; totals == nil || totals.Assets != 2500 {
+		t.Errorf("Expected the rebuilt summary to use the latest balance, got %+v", totals)
+	}
+	var generation, built int
+	if err := db.QueryRow(`SELECT generation, built_generation FROM account_summaries WHERE user_id = $1`, userID).Scan(&generation, &built); err != nil {
+		t.Fatalf("Failed to read the materialized summary: %v", err)
+	}
+	if generation != built {
+		t.Errorf("Expected the stored summary to be current, got generation %d built from %d", generation, built)
+	}
+}
+
+func TestSummary_KeepsBuiltSummariesInTheSharedCacheWhenSet(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-summary-cache"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupAccountService(t, db)
+	service.SetSummaryCache(kvstore.NewMemory())
+	accountID := CreateTestAccount(t, db, userID, AccountTypeSavings)
+	CreateTestBalance(t, db, accountID, 1000)
+
+	if _, err := service.Summary(ctx); err != nil {
+		t.Fatalf("Summary failed: %v", err)
+	}
+	CreateTestBalance(t, db, accountID, 2500)
+
+	// Act
+	cached, err := service.Summary(ctx)
+	if err != nil {
+		t.Fatalf("Summary failed: %v", err)
+	}
+	if _, err := service.Delete(ctx, accountID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	rebuilt, err := service.Summary(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Summary failed: %v", err)
+	}
+	if totals := cached.BalancesByCurrency["CAD"]; totals == nil || totals.Assets != 1000 {
+		t.Errorf("Expected the cached summary to be served, got %+v", totals)
+	}
+	if rebuilt.TotalAccounts != 0 {
+		t.Errorf("Expected the delete to invalidate the cached summary, got %d accounts", rebuilt.TotalAccounts)
+	}
+	var stored sql.NullString
+	if err := db.QueryRow(`SELECT summary FROM account_summaries WHERE user_id = $1`, userID).Scan(&stored); err != nil {
+		t.Fatalf("Failed to read the summary generation: %v", err)
+	}
+	if stored.Valid {
+		t.Error("Expected the database to track only the generation while the cache holds summaries")
+	}
+}
+
+func TestMergeAccounts_MovesRecordsAndKeepsTargetOnConflict(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-merge-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupAccountService(t, db)
+	targetID := CreateTestAccount(t, db, userID, AccountTypeBrokerage)
+	sourceID := CreateTestAccount(t, db, userID, AccountTypeBrokerage)
+
+	for _, b := range []struct {
+		id, accountID, date string
+		amount              float64
+	}{
+		{"test-balance-merge-1", targetID, "2024-01-31", 1000},
+		{"test-balance-merge-2", sourceID, "2024-01-31", 999},
+		{"test-balance-merge-3", sourceID, "2024-02-29", 1100},
+	} {
+		if _, err := db.Exec(`INSERT INTO balances (id, account_id, amount, date) VALUES ($1, $2, $3, $4)`,
+			b.id, b.accountID, b.amount, b.date); err != nil {
+			t.Fatalf("Failed to create balance: %v", err)
+		}
+	}
+	for _, h := range []struct {
+		id, accountID  string
+		quantity, cost float64
+	}{
+		{"test-holding-merge-1", targetID, 10, 100},
+		{"test-holding-merge-2", sourceID, 5, 130},
+	} {
+		if _, err := db.Exec(`INSERT INTO holdings (id, account_id, type, symbol, quantity, cost_basis) VALUES ($1, $2, 'stock', 'VFV', $3, $4)`,
+			h.id, h.accountID, h.quantity, h.cost); err != nil {
+			t.Fatalf("Failed to create holding: %v", err)
+		}
+	}
+
+	// Act
+	resp, err := service.MergeAccounts(ctx, targetID, &MergeAccountsRequest{SourceAccountID: sourceID})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("MergeAccounts failed: %v", err)
+	}
+	if resp.Moved["balances"] != 1 || resp.Skipped["balances"] != 1 {
+		t.Errorf("Expected 1 balance moved and 1 skipped, got moved %d skipped %d", resp.Moved["balances"], resp.Skipped["balances"])
+	}
+	if resp.HoldingsMerged != 1 {
+		t.Errorf("Expected 1 holding combined, got %d", resp.HoldingsMerged)
+	}
+
+	var amount float64
+	if err := db.QueryRow(`SELECT amount FROM balances WHERE account_id = $1 AND date = '2024-01-31'`, targetID).Scan(&amount); err != nil {
+		t.Fatalf("Failed to read balance: %v", err)
+	}
+	if amount != 1000 {
+		t.Errorf("Expected target balance 1000 to be kept, got %.2f", amount)
+	}
+
+	var quantity, costBasis float64
+	if err := db.QueryRow(`SELECT quantity, cost_basis FROM holdings WHERE account_id = $1 AND symbol = 'VFV'`, targetID).Scan(&quantity, &costBasis); err != nil {
+		t.Fatalf("Failed to read holding: %v", err)
+	}
+	if quantity != 15 {
+		t.Errorf("Expected combined quantity 15, got %.2f", quantity)
+	}
+	if costBasis != 110 {
+		t.Errorf("Expected the quantity-weighted cost basis 110, got %.2f", costBasis)
+	}
+
+	if _, err := service.Get(ctx, sourceID); err == nil {
+		t.Error("Expected source account to be deleted")
+	}
+}
+
+func TestPreviewBulkDelete_CountsDependencies(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-bulk-delete-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupAccountService(t, db)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeSavings)
+	CreateTestBalance(t, db, accountID, 500)
+
+	// Act
+	preview, err := service.PreviewBulkDelete(ctx, &BulkDeleteRequest{AccountIDs: []string{accountID}})
+	if err != nil {
+		t.Fatalf("PreviewBulkDelete failed: %v", err)
+	}
+	deleted, err := service.BulkDelete(ctx, &BulkDeleteRequest{AccountIDs: []string{accountID}})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("BulkDelete failed: %v", err)
+	}
+	if len(preview.Accounts) != 1 || preview.Accounts[0].Records["balances"] != 1 {
+		t.Errorf("Expected preview to count 1 balance, got %+v", preview.Accounts)
+	}
+	if deleted.Deleted != 1 {
+		t.Errorf("Expected 1 account deleted, got %d", deleted.Deleted)
+	}
+
+	var remaining int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM balances WHERE account_id = $1`, accountID).Scan(&remaining); err != nil {
+		t.Fatalf("Failed to count balances: %v", err)
+	}
+	if remaining != 0 {
+		t.Errorf("Expected balances to be deleted, got %d", remaining)
+	}
+}
+
+func TestConvertCurrencyTotals_KeepsCurrenciesSeparate(t *testing.T) {
+	cad, usd, card := 1000.0, 500.0, -200.0
+	accounts := []*AccountWithBalance{
+		{Account: Account{Currency: CurrencyCAD, IsAsset: true}, CurrentBalance: &cad},
+		{Account: Account{Currency: CurrencyCAD, IsAsset: false}, CurrentBalance: &card},
+		{Account: Account{Currency: CurrencyUSD, IsAsset: true}, CurrentBalance: &usd},
+		{Account: Account{Currency: CurrencyINR, IsAsset: true}},
+	}
+
+	byCurrency := summarizeByCurrency(accounts)
+	if got := byCurrency["CAD"]; got.Assets != 1000 || got.Liabilities != 200 || got.NetWorth != 800 || got.Accounts != 2 {
+		t.Errorf("Unexpected CAD summary: %+v", got)
+	}
+	if got := byCurrency["USD"]; got.NetWorth != 500 {
+		t.Errorf("Expected USD net worth 500, got %.2f", got.NetWorth)
+	}
+
+	// Only the inverse CAD->USD rate is stored; INR has no rate at all
+	rates := map[string]map[string]float64{"CAD": {"USD": 0.8}}
+	total := convertCurrencyTotals(byCurrency, "CAD", rates)
+	if total.NetWorth != 1425 {
+		t.Errorf("Expected converted net worth 1425, got %.2f", total.NetWorth)
+	}
+	if len(total.MissingCurrencies) != 1 || total.MissingCurrencies[0] != "INR" {
+		t.Errorf("Expected INR to be reported missing, got %v", total.MissingCurrencies)
+	}
+}
+
+func TestConvertTotals_CachesRatesUntilEdited(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-fx-cache"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupAccountService(t, db)
+	rates := currency.NewService(db, nil)
+	service.SetExchangeRates(rates)
+	// Storing today's rates keeps the provider from being called
+	if _, err := rates.SetRates(ctx, &currency.SetRatesRequest{Rates: []currency.SetRateRequest{
+		{FromCurrency: currency.CurrencyUSD, ToCurrency: currency.CurrencyCAD, Rate: 1.35},
+		{FromCurrency: currency.CurrencyINR, ToCurrency: currency.CurrencyCAD, Rate: 0.016},
+	}}); err != nil {
+		t.Fatalf("SetRates failed: %v", err)
+	}
+	byCurrency := map[string]*CurrencyBalanceSummary{"USD": {Currency: "USD", Assets: 100, NetWorth: 100}}
+
+	// Act
+	first, err := service.ConvertTotals(ctx, byCurrency, "CAD")
+	if err != nil {
+		t.Fatalf("ConvertTotals failed: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE exchange_rates SET rate = 1.40 WHERE from_currency = 'USD' AND to_currency = 'CAD'`); err != nil {
+		t.Fatalf("Failed to change rate: %v", err)
+	}
+	cached, err := service.ConvertTotals(ctx, byCurrency, "CAD")
+	if err != nil {
+		t.Fatalf("ConvertTotals failed: %v", err)
+	}
+	if _, err := rates.SetRates(ctx, &currency.SetRatesRequest{Rates: []currency.SetRateRequest{
+		{FromCurrency: currency.CurrencyCAD, ToCurrency: currency.CurrencyUSD, Rate: 0.5},
+	}}); err != nil {
+		t.Fatalf("SetRates failed: %v", err)
+	}
+	edited, err := service.ConvertTotals(ctx, byCurrency, "CAD")
+	if err != nil {
+		t.Fatalf("ConvertTotals failed: %v", err)
+	}
+
+	// Assert
+	if first.NetWorth != 135 {
+		t.Errorf("Expected 135 CAD at the stored rate, got %.2f", first.NetWorth)
+	}
+	if cached.NetWorth != 135 {
+		t.Errorf("Expected the cached rate until invalidated, got %.2f", cached.NetWorth)
+	}
+	if edited.NetWorth != 200 {
+		t.Errorf("Expected the edited rate's inverse to apply straight away, got %.2f", edited.NetWorth)
+	}
+}
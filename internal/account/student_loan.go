@@ -0,0 +1,249 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Student loan repayment plans
+const (
+	RepaymentPlanStandard     = "standard"
+	RepaymentPlanIncomeDriven = "income_driven"
+	studentLoanType           = "student"
+)
+
+// StudentLoanDetails holds the terms specific to student loans, on top of LoanDetails
+type StudentLoanDetails struct {
+	ID                    string                     `json:"id"`
+	AccountID             string                     `json:"account_id"`
+	GracePeriodEnd        *Date                      `json:"grace_period_end,omitempty"`
+	RepaymentPlan         string                     `json:"repayment_plan"`                    // standard, income_driven
+	IncomeDrivenPercent   *float64                   `json:"income_driven_percent,omitempty"`   // e.g. 0.10 for 10% of income above the threshold
+	IncomeDrivenThreshold *float64                   `json:"income_driven_threshold,omitempty"` // Annual income exempt from payments
+	ForgivenessMonths     *int                       `json:"forgiveness_months,omitempty"`      // Qualifying payments until the balance is forgiven
+	QualifyingPayments    int                        `json:"qualifying_payments"`               // Qualifying payments already made
+	ForgivenessTaxable    bool                       `json:"forgiveness_taxable"`
+	SubsidyPeriods        []StudentLoanSubsidyPeriod `json:"subsidy_periods"`
+	CreatedAt             time.Time                  `json:"created_at"`
+	UpdatedAt             time.Time                  `json:"updated_at"`
+}
+
+// StudentLoanSubsidyPeriod is a period when the government pays the loan's interest
+type StudentLoanSubsidyPeriod struct {
+	ID          string `json:"id,omitempty"`
+	StartDate   Date   `json:"start_date"`
+	EndDate     Date   `json:"end_date"`
+	Description string `json:"description,omitempty"`
+}
+
+// SetStudentLoanDetailsRequest represents the request to set a loan's student loan terms.
+// Subsidy periods replace any recorded before.
+type SetStudentLoanDetailsRequest struct {
+	GracePeriodEnd        *Date                      `json:"grace_period_end,omitempty"`
+	RepaymentPlan         string                     `json:"repayment_plan"`
+	IncomeDrivenPercent   *float64                   `json:"income_driven_percent,omitempty"`
+	IncomeDrivenThreshold *float64                   `json:"income_driven_threshold,omitempty"`
+	ForgivenessMonths     *int                       `json:"forgiveness_months,omitempty"`
+	QualifyingPayments    int                        `json:"qualifying_payments"`
+	ForgivenessTaxable    bool                       `json:"forgiveness_taxable"`
+	SubsidyPeriods        []StudentLoanSubsidyPeriod `json:"subsidy_periods"`
+}
+
+// SetStudentLoanDetails records the student loan terms of a loan account and marks the
+// loan as a student loan
+func (s *Service) SetStudentLoanDetails(ctx context.Context, accountID string, req *SetStudentLoanDetailsRequest) (*StudentLoanDetails, error) {
+	// GetLoanDetails verifies ownership; student terms need the base loan
+	if _, err := s.GetLoanDetails(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	if req.RepaymentPlan == "" {
+		req.RepaymentPlan = RepaymentPlanStandard
+	}
+	if err := validateStudentLoanDetails(req); err != nil {
+		return nil, err
+	}
+
+	details := &StudentLoanDetails{
+		ID:                    uuid.New().String(),
+		AccountID:             accountID,
+		GracePeriodEnd:        req.GracePeriodEnd,
+		RepaymentPlan:         req.RepaymentPlan,
+		IncomeDrivenPercent:   req.IncomeDrivenPercent,
+		IncomeDrivenThreshold: req.IncomeDrivenThreshold,
+		ForgivenessMonths:     req.ForgivenessMonths,
+		QualifyingPayments:    req.QualifyingPayments,
+		ForgivenessTaxable:    req.ForgivenessTaxable,
+		SubsidyPeriods:        make([]StudentLoanSubsidyPeriod, 0, len(req.SubsidyPeriods)),
+		UpdatedAt:             time.Now(),
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO student_loan_details (
+			id, account_id, grace_period_end, repayment_plan,
+			income_driven_percent, income_driven_threshold,
+			forgiveness_months, qualifying_payments, forgiveness_taxable,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+		ON CONFLICT (account_id) DO UPDATE SET
+			grace_period_end = excluded.grace_period_end,
+			repayment_plan = excluded.repayment_plan,
+			income_driven_percent = excluded.income_driven_percent,
+			income_driven_threshold = excluded.income_driven_threshold,
+			forgiveness_months = excluded.forgiveness_months,
+			qualifying_payments = excluded.qualifying_payments,
+			forgiveness_taxable = excluded.forgiveness_taxable,
+			updated_at = excluded.updated_at
+		RETURNING id, created_at
+	`, details.ID, accountID, details.GracePeriodEnd, details.RepaymentPlan,
+		details.IncomeDrivenPercent, details.IncomeDrivenThreshold,
+		details.ForgivenessMonths, details.QualifyingPayments, details.ForgivenessTaxable,
+		details.UpdatedAt).Scan(&details.ID, &details.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set student loan details: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM student_loan_subsidy_periods WHERE account_id = $1`, accountID); err != nil {
+		return nil, fmt.Errorf("failed to replace subsidy periods: %w", err)
+	}
+	for _, period := range req.SubsidyPeriods {
+		period.ID = uuid.New().String()
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO student_loan_subsidy_periods (id, account_id, start_date, end_date, description, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, period.ID, accountID, period.StartDate, period.EndDate, period.Description, details.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add subsidy period: %w", err)
+		}
+		details.SubsidyPeriods = append(details.SubsidyPeriods, period)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE loan_details SET loan_type = $1, updated_at = $2 WHERE account_id = $3`,
+		studentLoanType, details.UpdatedAt, accountID); err != nil {
+		return nil, fmt.Errorf("failed to mark loan as student loan: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit student loan details: %w", err)
+	}
+
+	return details, nil
+}
+
+// validateStudentLoanDetails checks the repayment plan and subsidy periods
+func validateStudentLoanDetails(req *SetStudentLoanDetailsRequest) error {
+	switch req.RepaymentPlan {
+	case RepaymentPlanStandard:
+	case RepaymentPlanIncomeDriven:
+		if req.IncomeDrivenPercent == nil || *req.IncomeDrivenPercent <= 0 || *req.IncomeDrivenPercent > 1 {
+			return fmt.Errorf("income_driven_percent between 0 and 1 is required for income-driven repayment")
+		}
+	default:
+		return fmt.Errorf("invalid repayment_plan: %s", req.RepaymentPlan)
+	}
+	if req.ForgivenessMonths != nil && *req.ForgivenessMonths <= 0 {
+		return fmt.Errorf("forgiveness_months must be positive")
+	}
+	if req.QualifyingPayments < 0 {
+		return fmt.Errorf("qualifying_payments must not be negative")
+	}
+	for _, period := range req.SubsidyPeriods {
+		if period.EndDate.Before(period.StartDate.Time) {
+			return fmt.Errorf("subsidy period ending %s starts after it ends", period.EndDate)
+		}
+	}
+	return nil
+}
+
+// GetStudentLoanDetails retrieves the student loan terms of a loan account
+func (s *Service) GetStudentLoanDetails(ctx context.Context, accountID string) (*StudentLoanDetails, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	details, err := getStudentLoanDetails(ctx, s.db, accountID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("student loan details not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+// getStudentLoanDetails reads a loan's student terms, returning sql.ErrNoRows when it has none
+func getStudentLoanDetails(ctx context.Context, db *sql.DB, accountID string) (*StudentLoanDetails, error) {
+	var details StudentLoanDetails
+	err := db.QueryRowContext(ctx, `
+		SELECT id, account_id, grace_period_end, repayment_plan,
+			income_driven_percent, income_driven_threshold,
+			forgiveness_months, qualifying_payments, forgiveness_taxable,
+			created_at, updated_at
+		FROM student_loan_details
+		WHERE account_id = $1
+	`, accountID).Scan(
+		&details.ID, &details.AccountID, &details.GracePeriodEnd, &details.RepaymentPlan,
+		&details.IncomeDrivenPercent, &details.IncomeDrivenThreshold,
+		&details.ForgivenessMonths, &details.QualifyingPayments, &details.ForgivenessTaxable,
+		&details.CreatedAt, &details.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get student loan details: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, start_date, end_date, COALESCE(description, '')
+		FROM student_loan_subsidy_periods
+		WHERE account_id = $1
+		ORDER BY start_date
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subsidy periods: %w", err)
+	}
+	defer rows.Close()
+
+	details.SubsidyPeriods = make([]StudentLoanSubsidyPeriod, 0)
+	for rows.Next() {
+		var period StudentLoanSubsidyPeriod
+		if err := rows.Scan(&period.ID, &period.StartDate, &period.EndDate, &period.Description); err != nil {
+			return nil, fmt.Errorf("failed to scan subsidy period: %w", err)
+		}
+		details.SubsidyPeriods = append(details.SubsidyPeriods, period)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read subsidy periods: %w", err)
+	}
+
+	return &details, nil
+}
+
+// InGracePeriod reports whether payments are not yet due on the date
+func (d *StudentLoanDetails) InGracePeriod(date time.Time) bool {
+	return d != nil && d.GracePeriodEnd != nil && date.Before(d.GracePeriodEnd.Time)
+}
+
+// Subsidized reports whether the government pays the interest on the date
+func (d *StudentLoanDetails) Subsidized(date time.Time) bool {
+	if d == nil {
+		return false
+	}
+	for _, period := range d.SubsidyPeriods {
+		if !date.Before(period.StartDate.Time) && !date.After(period.EndDate.Time) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,227 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/kvstore"
+)
+
+// summaryCacheTTL bounds how long cached summaries are kept. Entries are keyed by
+// generation and day, so they're never served stale and only expire to free memory.
+const summaryCacheTTL = 24 * time.Hour
+
+// materializedSummaries are a user's dashboard summaries as stored in account_summaries
+// or the summary cache
+type materializedSummaries struct {
+	Summary             *AccountSummary        `json:"summary"`
+	MaskedSummary       *AccountSummary        `json:"masked_summary"` // Private balances masked, for privacy mode
+	AssetsSummary       *AssetsSummaryResponse `json:"assets_summary"`
+	MaskedAssetsSummary *AssetsSummaryResponse `json:"masked_assets_summary"` // Private accounts left out
+}
+
+// SetSummaryCache keeps built summaries in a store shared between instances rather than
+// in account_summaries, which then only tracks each user's generation
+func (s *Service) SetSummaryCache(store kvstore.Store) {
+	s.summaryCache = store
+}
+
+// Summary returns a summary of all accounts, read from the user's materialized summaries
+func (s *Service) Summary(ctx context.Context) (*AccountSummary, error) {
+	summaries, err := s.materializedSummaries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if auth.MaskPrivate(ctx) {
+		return summaries.MaskedSummary, nil
+	}
+	return summaries.Summary, nil
+}
+
+// GetAssetsSummary retrieves all assets with calculated current values, read from the
+// user's materialized summaries. Assets in private accounts are left out while privacy
+// mode masks them.
+func (s *Service) GetAssetsSummary(ctx context.Context) (*AssetsSummaryResponse, error) {
+	summaries, err := s.materializedSummaries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if auth.MaskPrivate(ctx) {
+		return summaries.MaskedAssetsSummary, nil
+	}
+	return summaries.AssetsSummary, nil
+}
+
+// RefreshSummaries rebuilds the user's materialized summaries unless they're current
+func (s *Service) RefreshSummaries(ctx context.Context, userID string) error {
+	_, err := s.materializedSummaries(auth.WithUserID(ctx, userID))
+	return err
+}
+
+// materializedSummaries reads the user's stored summaries in one query, rebuilding them
+// first when a write has invalidated them since they were built. Asset values depreciate
+// daily, so summaries built on an earlier day are rebuilt too.
+func (s *Service) materializedSummaries(ctx context.Context) (*materializedSummaries, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if s.summaryCache != nil {
+		return s.cachedSummaries(ctx, userID)
+	}
+
+	var generation, builtGeneration int64
+	var summary, maskedSummary, assetsSummary, maskedAssetsSummary sql.NullString
+	var refreshedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT generation, built_generation, summary, masked_summary, assets_summary, masked_assets_summary, refreshed_at
+		FROM account_summaries
+		WHERE user_id = $1
+	`, userID).Scan(&generation, &builtGeneration, &summary, &maskedSummary, &assetsSummary, &maskedAssetsSummary, &refreshedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get summaries: %w", err)
+	}
+
+	now := time.Now()
+	if err == nil && builtGeneration == generation && refreshedAt.Valid &&
+		refreshedAt.Time.In(now.Location()).Format("2006-01-02") == now.Format("2006-01-02") {
+		stored := &materializedSummaries{}
+		if err := json.Unmarshal([]byte(summary.String), &stored.Summary); err != nil {
+			return nil, fmt.Errorf("failed to decode summary: %w", err)
+		}
+		if err := json.Unmarshal([]byte(maskedSummary.String), &stored.MaskedSummary); err != nil {
+			return nil, fmt.Errorf("failed to decode summary: %w", err)
+		}
+		if err := json.Unmarshal([]byte(assetsSummary.String), &stored.AssetsSummary); err != nil {
+			return nil, fmt.Errorf("failed to decode assets summary: %w", err)
+		}
+		if err := json.Unmarshal([]byte(maskedAssetsSummary.String), &stored.MaskedAssetsSummary); err != nil {
+			return nil, fmt.Errorf("failed to decode assets summary: %w", err)
+		}
+		return stored, nil
+	}
+
+	if err == sql.ErrNoRows {
+		if generation, err = s.createSummaries(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	return s.rebuildSummaries(ctx, userID, generation, now)
+}
+
+// cachedSummaries reads the user's summaries from the summary cache under their current
+// generation, building and caching them on a miss
+func (s *Service) cachedSummaries(ctx context.Context, userID string) (*materializedSummaries, error) {
+	var generation int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT generation FROM account_summaries WHERE user_id = $1
+	`, userID).Scan(&generation)
+	if err == sql.ErrNoRows {
+		if generation, err = s.createSummaries(ctx, userID); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get summaries: %w", err)
+	}
+
+	key := fmt.Sprintf("account-summaries:%s:%d:%s", userID, generation, time.Now().Format("2006-01-02"))
+	if data, ok, err := s.summaryCache.Get(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to read cached summaries: %w", err)
+	} else if ok {
+		cached := &materializedSummaries{}
+		if err := json.Unmarshal(data, cached); err != nil {
+			return nil, fmt.Errorf("failed to decode cached summaries: %w", err)
+		}
+		return cached, nil
+	}
+
+	built, err := s.buildSummaries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(built)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode summaries: %w", err)
+	}
+	if err := s.summaryCache.Set(ctx, key, data, summaryCacheTTL); err != nil {
+		return nil, fmt.Errorf("failed to cache summaries: %w", err)
+	}
+	return built, nil
+}
+
+// createSummaries starts tracking the user's generation, returning the first one
+func (s *Service) createSummaries(ctx context.Context, userID string) (int64, error) {
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO account_summaries (user_id, generation) VALUES ($1, 1) ON CONFLICT (user_id) DO NOTHING
+	`, userID); err != nil {
+		return 0, fmt.Errorf("failed to create summaries: %w", err)
+	}
+	var generation int64
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT generation FROM account_summaries WHERE user_id = $1
+	`, userID).Scan(&generation); err != nil {
+		return 0, fmt.Errorf("failed to get summaries: %w", err)
+	}
+	return generation, nil
+}
+
+// buildSummaries builds the user's summaries from the raw tables
+func (s *Service) buildSummaries(ctx context.Context) (*materializedSummaries, error) {
+	built := &materializedSummaries{}
+	var err error
+	if built.Summary, err = s.buildSummary(context.WithValue(ctx, auth.MaskPrivateKey, false)); err != nil {
+		return nil, err
+	}
+	if built.MaskedSummary, err = s.buildSummary(auth.WithMaskPrivate(ctx)); err != nil {
+		return nil, err
+	}
+	if built.AssetsSummary, err = s.buildAssetsSummary(context.WithValue(ctx, auth.MaskPrivateKey, false)); err != nil {
+		return nil, err
+	}
+	if built.MaskedAssetsSummary, err = s.buildAssetsSummary(auth.WithMaskPrivate(ctx)); err != nil {
+		return nil, err
+	}
+	return built, nil
+}
+
+// rebuildSummaries builds the user's summaries and stores them as of generation. A write
+// landing mid-build bumps the generation, so the stale result isn't stored and the next
+// read builds again.
+func (s *Service) rebuildSummaries(ctx context.Context, userID string, generation int64, now time.Time) (*materializedSummaries, error) {
+	built, err := s.buildSummaries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := json.Marshal(built.Summary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode summary: %w", err)
+	}
+	maskedSummary, err := json.Marshal(built.MaskedSummary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode summary: %w", err)
+	}
+	assetsSummary, err := json.Marshal(built.AssetsSummary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode assets summary: %w", err)
+	}
+	maskedAssetsSummary, err := json.Marshal(built.MaskedAssetsSummary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode assets summary: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE account_summaries
+		SET summary = $1, masked_summary = $2, assets_summary = $3, masked_assets_summary = $4,
+			built_generation = $5, refreshed_at = $6
+		WHERE user_id = $7 AND generation = $5
+	`, string(summary), string(maskedSummary), string(assetsSummary), string(maskedAssetsSummary), generation, now, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save summaries: %w", err)
+	}
+	return built, nil
+}
@@ -0,0 +1,218 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"money/internal/civil"
+)
+
+// DefaultPostTerminationExerciseDays is the usual window to exercise vested options after leaving
+const DefaultPostTerminationExerciseDays = 90
+
+// isoQualifyingMonths is how long after leaving an ISO can be exercised and still be treated as an ISO
+const isoQualifyingMonths = 3
+
+// defaultTerminationMarginalRate matches the rough combined rate used by the tax summary
+const defaultTerminationMarginalRate = 0.50
+
+// stockOptionDeductionRate is the share of an option benefit deducted, as in the tax summary
+const stockOptionDeductionRate = 0.5
+
+// TerminationScenarioRequest describes a hypothetical departure from the company
+type TerminationScenarioRequest struct {
+	LastDay            Date     `json:"last_day"`
+	ExerciseWindowDays *int     `json:"exercise_window_days,omitempty"` // Defaults to 90
+	MarginalRate       *float64 `json:"marginal_rate,omitempty"`        // Defaults to 0.50
+	FMVPerShare        *float64 `json:"fmv_per_share,omitempty"`        // Defaults to the FMV in force today
+}
+
+// TerminationGrantOutcome is what happens to one grant on leaving
+type TerminationGrantOutcome struct {
+	GrantID         string    `json:"grant_id"`
+	CompanyName     string    `json:"company_name"`
+	GrantType       GrantType `json:"grant_type"`
+	Currency        string    `json:"currency"`
+	VestedShares    int       `json:"vested_shares"`    // Vested by the last day
+	ForfeitedShares int       `json:"forfeited_shares"` // Unvested on the last day
+	ExercisedShares int       `json:"exercised_shares"`
+	// Vested options still to exercise, or RSUs vesting between today and the last day
+	ActionableShares int     `json:"actionable_shares"`
+	FMVPerShare      float64 `json:"fmv_per_share"`
+	// Options only
+	ExerciseDeadline      *Date   `json:"exercise_deadline,omitempty"`
+	ISOQualifyingDeadline *Date   `json:"iso_qualifying_deadline,omitempty"` // Exercising later taxes the ISO as an NSO
+	ExerciseCost          float64 `json:"exercise_cost"`
+	TaxableBenefit        float64 `json:"taxable_benefit"`
+	EstimatedTax          float64 `json:"estimated_tax"`
+	VestedValue           float64 `json:"vested_value"`    // Vested shares kept, net of strike for options
+	ForfeitedValue        float64 `json:"forfeited_value"` // Unvested shares lost, net of strike for options
+	IsExpired             bool    `json:"is_expired"`      // Expired by the last day
+}
+
+// TerminationTotals sums grant outcomes in one currency
+type TerminationTotals struct {
+	Currency       string  `json:"currency"`
+	CashNeeded     float64 `json:"cash_needed"` // Exercise cost plus estimated tax
+	ExerciseCost   float64 `json:"exercise_cost"`
+	EstimatedTax   float64 `json:"estimated_tax"`
+	VestedValue    float64 `json:"vested_value"`
+	ForfeitedValue float64 `json:"forfeited_value"`
+}
+
+// TerminationScenario answers what leaving on a given day means for an equity account
+type TerminationScenario struct {
+	AccountID          string                        `json:"account_id"`
+	LastDay            Date                          `json:"last_day"`
+	ExerciseWindowDays int                           `json:"exercise_window_days"`
+	MarginalRate       float64                       `json:"marginal_rate"`
+	Grants             []TerminationGrantOutcome     `json:"grants"`
+	ByCurrency         map[string]*TerminationTotals `json:"by_currency"`
+}
+
+// GetTerminationScenario computes which shares vest by a hypothetical last day, the deadline
+// to exercise each option grant afterwards, and the cash and tax needed to keep the vested
+// equity. Option spreads are taxed at the marginal rate after the 50% stock option deduction;
+// RSUs still to vest before the last day are taxed in full as employment income.
+func (s *Service) GetTerminationScenario(ctx context.Context, accountID string, req *TerminationScenarioRequest) (*TerminationScenario, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	if req.LastDay.IsZero() {
+		return nil, fmt.Errorf("last_day is required")
+	}
+	windowDays := DefaultPostTerminationExerciseDays
+	if req.ExerciseWindowDays != nil {
+		if *req.ExerciseWindowDays < 0 {
+			return nil, fmt.Errorf("exercise_window_days must not be negative")
+		}
+		windowDays = *req.ExerciseWindowDays
+	}
+	marginalRate := defaultTerminationMarginalRate
+	if req.MarginalRate != nil {
+		if *req.MarginalRate < 0 || *req.MarginalRate >= 1 {
+			return nil, fmt.Errorf("marginal_rate must be at least 0 and below 1")
+		}
+		marginalRate = *req.MarginalRate
+	}
+	if req.FMVPerShare != nil && *req.FMVPerShare < 0 {
+		return nil, fmt.Errorf("fmv_per_share must not be negative")
+	}
+
+	data, err := s.loadOptionsData(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	scenario := buildTerminationScenario(data, civil.TodayIn(ctx), req.LastDay, windowDays, marginalRate, req.FMVPerShare)
+	scenario.AccountID = accountID
+	return scenario, nil
+}
+
+// buildTerminationScenario works out each grant's outcome on leaving on lastDay
+func buildTerminationScenario(data *optionsData, today, lastDay Date, windowDays int, marginalRate float64, fmvOverride *float64) *TerminationScenario {
+	scenario := &TerminationScenario{
+		LastDay:            lastDay,
+		ExerciseWindowDays: windowDays,
+		MarginalRate:       marginalRate,
+		Grants:             make([]TerminationGrantOutcome, 0),
+		ByCurrency:         make(map[string]*TerminationTotals),
+	}
+
+	onLastDay := summarizeOptions(data, lastDay.Time)
+	vestedToday := make(map[string]int)
+	for _, grant := range summarizeOptions(data, today.Time).Grants {
+		vestedToday[grant.ID] = grant.VestedQuantity
+	}
+
+	for _, grant := range onLastDay.Grants {
+		currency := grant.Currency
+		if currency == "" {
+			currency = "USD"
+		}
+		fmv := grant.FMVAtGrant
+		if current, ok := fmvInEffect(data.fmvEntries, currency, today.Time); ok {
+			fmv = current
+		}
+		if fmvOverride != nil {
+			fmv = *fmvOverride
+		}
+
+		outcome := TerminationGrantOutcome{
+			GrantID:         grant.ID,
+			CompanyName:     grant.CompanyName,
+			GrantType:       grant.GrantType,
+			Currency:        currency,
+			VestedShares:    grant.VestedQuantity,
+			ForfeitedShares: grant.Quantity - grant.VestedQuantity,
+			ExercisedShares: grant.ExercisedQuantity,
+			FMVPerShare:     fmv,
+			IsExpired:       grant.IsExpired,
+		}
+
+		if grant.StrikePrice != nil {
+			spread := fmv - *grant.StrikePrice
+			if spread < 0 {
+				spread = 0
+			}
+			if !grant.IsExpired {
+				outcome.ActionableShares = grant.VestedQuantity - grant.ExercisedQuantity
+				deadline := lastDay.AddDays(windowDays)
+				if grant.ExpirationDate != nil && grant.ExpirationDate.Before(deadline.Time) {
+					deadline = *grant.ExpirationDate
+				}
+				outcome.ExerciseDeadline = &deadline
+				if grant.GrantType == GrantTypeISO {
+					isoDeadline := civil.DateOf(lastDay.AddDate(0, isoQualifyingMonths, 0))
+					if isoDeadline.After(deadline.Time) {
+						isoDeadline = deadline
+					}
+					outcome.ISOQualifyingDeadline = &isoDeadline
+				}
+			}
+			outcome.ExerciseCost = roundCents(float64(outcome.ActionableShares) * *grant.StrikePrice)
+			outcome.TaxableBenefit = roundCents(float64(outcome.ActionableShares) * spread)
+			outcome.EstimatedTax = roundCents(outcome.TaxableBenefit * (1 - stockOptionDeductionRate) * marginalRate)
+			outcome.VestedValue = roundCents(float64(outcome.ActionableShares) * spread)
+			outcome.ForfeitedValue = roundCents(float64(outcome.ForfeitedShares) * spread)
+		} else {
+			outcome.ActionableShares = grant.VestedQuantity - vestedToday[grant.ID]
+			outcome.TaxableBenefit = roundCents(float64(outcome.ActionableShares) * fmv)
+			outcome.EstimatedTax = roundCents(outcome.TaxableBenefit * marginalRate)
+			outcome.VestedValue = roundCents(float64(grant.NetVestedQuantity) * fmv)
+			outcome.ForfeitedValue = roundCents(float64(outcome.ForfeitedShares) * fmv)
+		}
+
+		totals, ok := scenario.ByCurrency[currency]
+		if !ok {
+			totals = &TerminationTotals{Currency: currency}
+			scenario.ByCurrency[currency] = totals
+		}
+		totals.ExerciseCost += outcome.ExerciseCost
+		totals.EstimatedTax += outcome.EstimatedTax
+		totals.VestedValue += outcome.VestedValue
+		totals.ForfeitedValue += outcome.ForfeitedValue
+
+		scenario.Grants = append(scenario.Grants, outcome)
+	}
+
+	for _, totals := range scenario.ByCurrency {
+		totals.ExerciseCost = roundCents(totals.ExerciseCost)
+		totals.EstimatedTax = roundCents(totals.EstimatedTax)
+		totals.CashNeeded = roundCents(totals.ExerciseCost + totals.EstimatedTax)
+		totals.VestedValue = roundCents(totals.VestedValue)
+		totals.ForfeitedValue = roundCents(totals.ForfeitedValue)
+	}
+
+	// Soonest deadline first; RSUs have none and go last
+	sort.SliceStable(scenario.Grants, func(i, j int) bool {
+		a, b := scenario.Grants[i].ExerciseDeadline, scenario.Grants[j].ExerciseDeadline
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(b.Time)
+	})
+	return scenario
+}
@@ -86,25 +86,37 @@ func CleanupTestDB(t *testing.T, db *sql.DB) {
 
 	// Clean up test data in reverse dependency order
 	tables := []string{
+		"account_summaries",
+		"account_edits",
+		"notifications",
+		"dashboard_layouts",
+		"credit_scores",
+		"credit_score_settings",
 		"asset_depreciation_entries",
 		"mortgage_payments",
 		"loan_payments",
+		"heloc_transactions",
+		"heloc_details",
 		"asset_details",
 		"mortgage_details",
 		"loan_details",
+		"holdings",
 		"balances",
 		"accounts",
+		"entities",
 		"users",
 	}
 
 	for _, table := range tables {
 		var query string
 		switch table {
-		case "balances", "asset_depreciation_entries", "mortgage_payments", "loan_payments",
-			"asset_details", "mortgage_details", "loan_details":
+		case "balances", "holdings", "asset_depreciation_entries", "mortgage_payments", "loan_payments",
+			"heloc_transactions", "heloc_details", "asset_details", "mortgage_details", "loan_details":
 			query = fmt.Sprintf("DELETE FROM %s WHERE account_id LIKE 'test-%%'", table)
 		case "accounts":
 			query = fmt.Sprintf("DELETE FROM %s WHERE id LIKE 'test-%%' OR user_id LIKE 'test-%%'", table)
+		case "account_summaries", "account_edits", "notifications", "dashboard_layouts", "credit_scores", "credit_score_settings", "entities":
+			query = fmt.Sprintf("DELETE FROM %s WHERE user_id LIKE 'test-%%'", table)
 		case "users":
 			query = fmt.Sprintf("DELETE FROM %s WHERE id LIKE 'test-%%'", table)
 		default:
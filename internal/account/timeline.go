@@ -0,0 +1,347 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// TimelineEventType tags what happened in an account timeline entry
+type TimelineEventType string
+
+const (
+	TimelineAccountCreated TimelineEventType = "account_created"
+	TimelineDetailEdited   TimelineEventType = "detail_edited"
+	TimelineBalance        TimelineEventType = "balance"
+	TimelinePayment        TimelineEventType = "payment" // Mortgage or loan payment
+	TimelineHELOC          TimelineEventType = "heloc_transaction"
+	TimelineTransaction    TimelineEventType = "transaction"
+	TimelineSync           TimelineEventType = "sync"
+)
+
+const (
+	defaultTimelineLimit = 50
+	maxTimelineLimit     = 200
+)
+
+// TimelineEvent is one thing that happened to an account. Amount is left out for private
+// accounts while privacy mode is on.
+type TimelineEvent struct {
+	ID         string            `json:"id"`
+	Type       TimelineEventType `json:"type"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	Summary    string            `json:"summary"`
+	Amount     *float64          `json:"amount,omitempty"`
+	Details    map[string]any    `json:"details,omitempty"`
+}
+
+// TimelineRequest pages through a timeline, newest first
+type TimelineRequest struct {
+	Types  []TimelineEventType // Only these types; all when empty
+	Limit  int                 // Defaults to 50, at most 200
+	Offset int
+}
+
+// AccountTimeline is a page of an account's timeline
+type AccountTimeline struct {
+	AccountID string          `json:"account_id"`
+	Events    []TimelineEvent `json:"events"`
+	Total     int             `json:"total"` // Events across every page
+	Limit     int             `json:"limit"`
+	Offset    int             `json:"offset"`
+	HasMore   bool            `json:"has_more"`
+	Masked    bool            `json:"masked,omitempty"` // Amounts hidden by privacy mode
+}
+
+// IsValidTimelineEventType reports whether t is a timeline event type
+func IsValidTimelineEventType(t TimelineEventType) bool {
+	switch t {
+	case TimelineAccountCreated, TimelineDetailEdited, TimelineBalance, TimelinePayment,
+		TimelineHELOC, TimelineTransaction, TimelineSync:
+		return true
+	}
+	return false
+}
+
+// GetTimeline merges everything recorded against an account into one chronological
+// timeline: its creation and detail edits, balances, mortgage and loan payments, HELOC
+// draws and repayments, transactions and provider syncs
+func (s *Service) GetTimeline(ctx context.Context, accountID string, req TimelineRequest) (*AccountTimeline, error) {
+	acc, err := s.Get(ctx, accountID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("account not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = defaultTimelineLimit
+	}
+	if req.Limit > maxTimelineLimit {
+		req.Limit = maxTimelineLimit
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+	wanted := make(map[TimelineEventType]bool, len(req.Types))
+	for _, t := range req.Types {
+		if !IsValidTimelineEventType(t) {
+			return nil, fmt.Errorf("invalid timeline event type: %s", t)
+		}
+		wanted[t] = true
+	}
+	include := func(t TimelineEventType) bool { return len(wanted) == 0 || wanted[t] }
+
+	events := []TimelineEvent{}
+	if include(TimelineAccountCreated) {
+		events = append(events, TimelineEvent{
+			ID:         acc.ID,
+			Type:       TimelineAccountCreated,
+			OccurredAt: acc.CreatedAt,
+			Summary:    fmt.Sprintf("Account %s created", acc.Name),
+		})
+	}
+	for _, source := range timelineSources {
+		if !include(source.eventType) {
+			continue
+		}
+		loaded, err := database.All(ctx, s.db, source.query, source.scan, accountID)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, loaded...)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].OccurredAt.Equal(events[j].OccurredAt) {
+			return events[i].OccurredAt.After(events[j].OccurredAt)
+		}
+		return events[i].ID > events[j].ID
+	})
+
+	timeline := &AccountTimeline{
+		AccountID: acc.ID,
+		Total:     len(events),
+		Limit:     req.Limit,
+		Offset:    req.Offset,
+		Masked:    acc.IsPrivate && auth.MaskPrivate(ctx),
+	}
+	end := min(req.Offset+req.Limit, len(events))
+	if req.Offset < len(events) {
+		timeline.Events = events[req.Offset:end]
+	} else {
+		timeline.Events = []TimelineEvent{}
+	}
+	timeline.HasMore = end < len(events)
+	if timeline.Masked {
+		for i := range timeline.Events {
+			timeline.Events[i].Amount = nil
+		}
+	}
+	return timeline, nil
+}
+
+// timelineSource loads one kind of timeline event for an account, bound to $1
+type timelineSource struct {
+	eventType TimelineEventType
+	query     database.Query
+	scan      database.ScanFunc[TimelineEvent]
+}
+
+var timelineSources = []timelineSource{
+	{TimelineDetailEdited, database.Query{
+		Name: "account timeline edits",
+		SQL:  `SELECT id, field, old_value, new_value, edited_at FROM account_edits WHERE account_id = $1`,
+	}, scanTimelineEdit},
+	{TimelineBalance, database.Query{
+		Name: "account timeline balances",
+		SQL:  `SELECT id, amount, date, notes, source, is_opening FROM balances WHERE account_id = $1`,
+	}, scanTimelineBalance},
+	{TimelinePayment, database.Query{
+		Name: "account timeline mortgage payments",
+		SQL: `
+			SELECT id, payment_date, payment_amount, principal_amount, interest_amount, COALESCE(extra_payment, 0), balance_after
+			FROM mortgage_payments WHERE account_id = $1
+		`,
+	}, scanTimelinePayment},
+	{TimelinePayment, database.Query{
+		Name: "account timeline loan payments",
+		SQL: `
+			SELECT id, payment_date, payment_amount, principal_amount, interest_amount, COALESCE(extra_payment, 0), balance_after
+			FROM loan_payments WHERE account_id = $1
+		`,
+	}, scanTimelinePayment},
+	{TimelineHELOC, database.Query{
+		Name: "account timeline HELOC transactions",
+		SQL:  `SELECT id, transaction_date, type, amount, balance_after FROM heloc_transactions WHERE account_id = $1`,
+	}, scanTimelineHELOC},
+	// Pending transactions that have since settled are left out in favour of the posted one
+	{TimelineTransaction, database.Query{
+		Name: "account timeline transactions",
+		SQL: `
+			SELECT id, date, description, amount, currency, category, status FROM transactions
+			WHERE account_id = $1 AND settled_transaction_id IS NULL
+		`,
+	}, scanTimelineTransaction},
+	// Syncs still waiting to start have nothing to show yet
+	{TimelineSync, database.Query{
+		Name: "account timeline syncs",
+		SQL: `
+			SELECT j.id, j.type, j.status, COALESCE(j.completed_at, j.started_at, j.created_at), j.error_message,
+			       j.items_created, j.items_updated
+			FROM sync_jobs j
+			JOIN synced_accounts sa ON sa.id = j.synced_account_id
+			WHERE sa.local_account_id = $1 AND j.status <> 'pending'
+		`,
+	}, scanTimelineSync},
+}
+
+func scanTimelineEdit(row database.Scanner) (TimelineEvent, error) {
+	var id, field string
+	var oldValue, newValue sql.NullString
+	var editedAt time.Time
+	if err := row.Scan(&id, &field, &oldValue, &newValue, &editedAt); err != nil {
+		return TimelineEvent{}, err
+	}
+	return TimelineEvent{
+		ID:         id,
+		Type:       TimelineDetailEdited,
+		OccurredAt: editedAt,
+		Summary:    fmt.Sprintf("Changed %s from %q to %q", field, oldValue.String, newValue.String),
+		Details:    map[string]any{"field": field, "old_value": oldValue.String, "new_value": newValue.String},
+	}, nil
+}
+
+func scanTimelineBalance(row database.Scanner) (TimelineEvent, error) {
+	var id, source string
+	var amount float64
+	var date time.Time
+	var notes sql.NullString
+	var isOpening bool
+	if err := row.Scan(&id, &amount, &date, &notes, &source, &isOpening); err != nil {
+		return TimelineEvent{}, err
+	}
+	summary := "Balance recorded"
+	switch {
+	case isOpening:
+		summary = "Opening balance recorded"
+	case source == "sync":
+		summary = "Balance synced"
+	}
+	details := map[string]any{"source": source}
+	if notes.Valid && notes.String != "" {
+		details["notes"] = notes.String
+	}
+	return TimelineEvent{ID: id, Type: TimelineBalance, OccurredAt: date, Summary: summary, Amount: &amount, Details: details}, nil
+}
+
+func scanTimelinePayment(row database.Scanner) (TimelineEvent, error) {
+	var id string
+	var date time.Time
+	var amount, principal, interest, extra, balanceAfter float64
+	if err := row.Scan(&id, &date, &amount, &principal, &interest, &extra, &balanceAfter); err != nil {
+		return TimelineEvent{}, err
+	}
+	return TimelineEvent{
+		ID:         id,
+		Type:       TimelinePayment,
+		OccurredAt: date,
+		Summary:    "Payment made",
+		Amount:     &amount,
+		Details: map[string]any{
+			"principal": principal, "interest": interest, "extra_payment": extra, "balance_after": balanceAfter,
+		},
+	}, nil
+}
+
+func scanTimelineHELOC(row database.Scanner) (TimelineEvent, error) {
+	var id, kind string
+	var date time.Time
+	var amount, balanceAfter float64
+	if err := row.Scan(&id, &date, &kind, &amount, &balanceAfter); err != nil {
+		return TimelineEvent{}, err
+	}
+	return TimelineEvent{
+		ID:         id,
+		Type:       TimelineHELOC,
+		OccurredAt: date,
+		Summary:    "HELOC " + kind,
+		Amount:     &amount,
+		Details:    map[string]any{"kind": kind, "balance_after": balanceAfter},
+	}, nil
+}
+
+func scanTimelineTransaction(row database.Scanner) (TimelineEvent, error) {
+	var id, description, currency, status string
+	var date time.Time
+	var amount float64
+	var category sql.NullString
+	if err := row.Scan(&id, &date, &description, &amount, &currency, &category, &status); err != nil {
+		return TimelineEvent{}, err
+	}
+	details := map[string]any{"currency": currency, "status": status}
+	if category.Valid {
+		details["category"] = category.String
+	}
+	return TimelineEvent{ID: id, Type: TimelineTransaction, OccurredAt: date, Summary: description, Amount: &amount, Details: details}, nil
+}
+
+func scanTimelineSync(row database.Scanner) (TimelineEvent, error) {
+	var id, jobType, status string
+	var occurredAt time.Time
+	var errorMessage sql.NullString
+	var created, updated int
+	if err := row.Scan(&id, &jobType, &status, &occurredAt, &errorMessage, &created, &updated); err != nil {
+		return TimelineEvent{}, err
+	}
+	details := map[string]any{"job_type": jobType, "status": status, "items_created": created, "items_updated": updated}
+	if errorMessage.Valid && errorMessage.String != "" {
+		details["error"] = errorMessage.String
+	}
+	return TimelineEvent{ID: id, Type: TimelineSync, OccurredAt: occurredAt, Summary: "Sync " + status, Details: details}, nil
+}
+
+// recordAccountEdits records each detail that differs between before and after
+func recordAccountEdits(ctx context.Context, db database.Querier, before, after *Account) error {
+	type edit struct{ field, oldValue, newValue string }
+	institution := func(a *Account) string {
+		if a.Institution == nil {
+			return ""
+		}
+		return *a.Institution
+	}
+	candidates := []edit{
+		{"name", before.Name, after.Name},
+		{"type", string(before.Type), string(after.Type)},
+		{"currency", string(before.Currency), string(after.Currency)},
+		{"institution", institution(before), institution(after)},
+		{"is_asset", strconv.FormatBool(before.IsAsset), strconv.FormatBool(after.IsAsset)},
+		{"is_active", strconv.FormatBool(before.IsActive), strconv.FormatBool(after.IsActive)},
+		{"is_private", strconv.FormatBool(before.IsPrivate), strconv.FormatBool(after.IsPrivate)},
+		{"exclude_from_projections", strconv.FormatBool(before.ExcludeFromProjections), strconv.FormatBool(after.ExcludeFromProjections)},
+		{"exclude_from_net_worth", strconv.FormatBool(before.ExcludeFromNetWorth), strconv.FormatBool(after.ExcludeFromNetWorth)},
+		{"exclude_from_spending", strconv.FormatBool(before.ExcludeFromSpending), strconv.FormatBool(after.ExcludeFromSpending)},
+	}
+	for _, e := range candidates {
+		if e.oldValue == e.newValue {
+			continue
+		}
+		_, err := db.ExecContext(ctx, `
+			INSERT INTO account_edits (id, user_id, account_id, field, old_value, new_value, edited_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, uuid.New().String(), after.UserID, after.ID, e.field, e.oldValue, e.newValue, after.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to record account edit: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package account
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetTimeline_MergesEventsNewestFirst(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-timeline-1"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeChecking)
+	service := SetupAccountService(t, db)
+
+	now := time.Now().UTC()
+	_, err := db.Exec(`
+		INSERT INTO balances (id, account_id, amount, date, created_at)
+		VALUES ($1, $2, 1000, $3, $3)
+	`, "test-balance-timeline-1", accountID, now.Add(-48*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to insert balance: %v", err)
+	}
+	_, err = db.Exec(`
+		INSERT INTO transactions (id, user_id, account_id, date, description, amount, currency, category)
+		VALUES ($1, $2, $3, $4, 'Groceries', -42.50, 'CAD', 'Food')
+	`, "test-txn-timeline-1", userID, accountID, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to insert transaction: %v", err)
+	}
+	name := "Everyday Chequing"
+	if _, err := service.Update(ctx, accountID, &UpdateAccountRequest{Name: &name}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	// Act
+	timeline, err := service.GetTimeline(ctx, accountID, TimelineRequest{})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetTimeline failed: %v", err)
+	}
+	want := []TimelineEventType{TimelineDetailEdited, TimelineAccountCreated, TimelineTransaction, TimelineBalance}
+	if len(timeline.Events) != len(want) {
+		t.Fatalf("Expected %d events, got %d: %+v", len(want), len(timeline.Events), timeline.Events)
+	}
+	for i, eventType := range want {
+		if timeline.Events[i].Type != eventType {
+			t.Errorf("Expected event %d to be %s, got %s", i, eventType, timeline.Events[i].Type)
+		}
+	}
+	edit := timeline.Events[0]
+	if edit.Details["field"] != "name" || edit.Details["new_value"] != name {
+		t.Errorf("Expected the name edit to be recorded, got %+v", edit.Details)
+	}
+	if txn := timeline.Events[2]; txn.Amount == nil || *txn.Amount != -42.50 {
+		t.Errorf("Expected the transaction amount -42.50, got %v", txn.Amount)
+	}
+	if timeline.Total != 4 || timeline.HasMore {
+		t.Errorf("Expected 4 events on one page, got total %d has_more %v", timeline.Total, timeline.HasMore)
+	}
+}
+
+func TestGetTimeline_FiltersAndPages(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-timeline-2"
+	CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	accountID := CreateTestAccount(t, db, userID, AccountTypeSavings)
+	service := SetupAccountService(t, db)
+
+	now := time.Now().UTC()
+	for i, id := range []string{"test-balance-timeline-a", "test-balance-timeline-b", "test-balance-timeline-c"} {
+		_, err := db.Exec(`
+			INSERT INTO balances (id, account_id, amount, date, created_at)
+			VALUES ($1, $2, $3, $4, $4)
+		`, id, accountID, float64(100*(i+1)), now.AddDate(0, 0, -i))
+		if err != nil {
+			t.Fatalf("Failed to insert balance: %v", err)
+		}
+	}
+
+	// Act
+	first, err := service.GetTimeline(ctx, accountID, TimelineRequest{Types: []TimelineEventType{TimelineBalance}, Limit: 2})
+	if err != nil {
+		t.Fatalf("GetTimeline failed: %v", err)
+	}
+	second, err := service.GetTimeline(ctx, accountID, TimelineRequest{Types: []TimelineEventType{TimelineBalance}, Limit: 2, Offset: 2})
+	if err != nil {
+		t.Fatalf("GetTimeline failed: %v", err)
+	}
+
+	// Assert
+	if first.Total != 3 || len(first.Events) != 2 || !first.HasMore {
+		t.Errorf("Expected the first page to hold 2 of 3 balances, got %d of %d (has_more %v)", len(first.Events), first.Total, first.HasMore)
+	}
+	if len(first.Events) > 0 && first.Events[0].ID != "test-balance-timeline-a" {
+		t.Errorf("Expected the newest balance first, got %s", first.Events[0].ID)
+	}
+	if len(second.Events) != 1 || second.HasMore {
+		t.Errorf("Expected the last page to hold 1 balance, got %d (has_more %v)", len(second.Events), second.HasMore)
+	}
+	for _, event := range append(first.Events, second.Events...) {
+		if event.Type != TimelineBalance {
+			t.Errorf("Expected only balances, got %s", event.Type)
+		}
+	}
+}
+
+func TestGetTimeline_UnauthorizedAccess(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	owner := "test-user-owner-timeline-1"
+	attacker := "test-user-attacker-timeline-1"
+	CreateTestUser(t, db, owner)
+	CreateTestUser(t, db, attacker)
+	accountID := CreateTestAccount(t, db, owner, AccountTypeChecking)
+	service := SetupAccountService(t, db)
+
+	// Act
+	_, err := service.GetTimeline(CreateAuthContext(attacker), accountID, TimelineRequest{})
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error reading another user's timeline")
+	}
+}
@@ -0,0 +1,71 @@
+package account
+
+import (
+	"context"
+	"fmt"
+
+	"money/internal/civil"
+)
+
+// DefaultVestedSaleMarginalRate is the marginal rate assumed for a sale when none is given,
+// the same rough combined rate as the termination scenario
+const DefaultVestedSaleMarginalRate = defaultTerminationMarginalRate
+
+// capitalGainsInclusionRate is the share of a capital gain that is taxable
+const capitalGainsInclusionRate = 0.5
+
+// VestedSaleEstimate is what selling all of an equity account's vested shares today would
+// bring in after tax
+type VestedSaleEstimate struct {
+	AccountID        string  `json:"account_id"`
+	VestedValue      float64 `json:"vested_value"` // As valued for net worth, net of strike for options
+	EstimatedTax     float64 `json:"estimated_tax"`
+	AfterTaxProceeds float64 `json:"after_tax_proceeds"`
+	MarginalRate     float64 `json:"marginal_rate"`
+}
+
+// TaxRate returns the estimated tax as a share of the vested value
+func (e *VestedSaleEstimate) TaxRate() float64 {
+	if e.VestedValue <= 0 {
+		return 0
+	}
+	return e.EstimatedTax / e.VestedValue
+}
+
+// EstimateVestedSale estimates the tax on selling an account's vested equity at today's
+// FMV. Unexercised options are exercised and sold the same day, taxing the spread at the
+// marginal rate after the 50% stock option deduction. RSU and RSA shares were taxed as
+// employment income when they vested, so only the gain since is taxed, as a capital gain;
+// the FMV at grant stands in for the FMV at each vest.
+func (s *Service) EstimateVestedSale(ctx context.Context, accountID string, marginalRate float64) (*VestedSaleEstimate, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+	if marginalRate < 0 || marginalRate >= 1 {
+		return nil, fmt.Errorf("marginal_rate must be at least 0 and below 1")
+	}
+
+	data, err := s.loadOptionsData(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &VestedSaleEstimate{AccountID: accountID, MarginalRate: marginalRate}
+	for _, grant := range summarizeOptions(data, civil.TodayIn(ctx).Time).Grants {
+		estimate.VestedValue += grant.IntrinsicValue
+		if grant.StrikePrice != nil {
+			estimate.EstimatedTax += grant.IntrinsicValue * (1 - stockOptionDeductionRate) * marginalRate
+			continue
+		}
+		if grant.CurrentFMV == nil || *grant.CurrentFMV <= grant.FMVAtGrant {
+			continue
+		}
+		gain := float64(grant.NetVestedQuantity) * (*grant.CurrentFMV - grant.FMVAtGrant)
+		estimate.EstimatedTax += gain * capitalGainsInclusionRate * marginalRate
+	}
+
+	estimate.VestedValue = roundCents(estimate.VestedValue)
+	estimate.EstimatedTax = roundCents(estimate.EstimatedTax)
+	estimate.AfterTaxProceeds = roundCents(estimate.VestedValue - estimate.EstimatedTax)
+	return estimate, nil
+}
@@ -0,0 +1,211 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"money/internal/civil"
+)
+
+// Timeline length in years when none is asked for, and the most that can be
+const (
+	DefaultVestingTimelineYears = 4
+	MaxVestingTimelineYears     = 10
+)
+
+// VestingTimelineMonth is one month of a vesting timeline. Share counts are as they stand at
+// the end of the month and add up to the grant: vested and not yet exercised, exercised,
+// still to vest, and expired unexercised. Values use today's FMV, net of the strike price
+// for options, so the months are comparable.
+type VestingTimelineMonth struct {
+	Month           string  `json:"month"` // YYYY-MM
+	SharesVesting   int     `json:"shares_vesting"`
+	ValueVesting    float64 `json:"value_vesting"`
+	IsCliff         bool    `json:"is_cliff,omitempty"` // A grant's cliff vests this month
+	VestedShares    int     `json:"vested_shares"`
+	ExercisedShares int     `json:"exercised_shares"`
+	UnvestedShares  int     `json:"unvested_shares"`
+	ExpiredShares   int     `json:"expired_shares,omitempty"`
+	VestedValue     float64 `json:"vested_value"`
+	UnvestedValue   float64 `json:"unvested_value"`
+}
+
+// VestingTimelineGrant is the monthly vesting timeline of one grant
+type VestingTimelineGrant struct {
+	GrantID         string                 `json:"grant_id"`
+	GrantType       GrantType              `json:"grant_type"`
+	CompanyName     string                 `json:"company_name"`
+	Currency        string                 `json:"currency"`
+	ValuePerShare   float64                `json:"value_per_share"`             // FMV, less the strike for options
+	CliffDate       *Date                  `json:"cliff_date,omitempty"`        // Nil without a cliff
+	FullyVestedDate *Date                  `json:"fully_vested_date,omitempty"` // Nil without a time-based schedule
+	Months          []VestingTimelineMonth `json:"months"`
+}
+
+// VestingTimelineTotal adds up the grants in one currency month by month
+type VestingTimelineTotal struct {
+	Currency string                 `json:"currency"`
+	Months   []VestingTimelineMonth `json:"months"`
+}
+
+// VestingTimelineResponse buckets an account's vesting into months from the current one,
+// per grant and per currency, ready to chart
+type VestingTimelineResponse struct {
+	AccountID string                 `json:"account_id"`
+	Years     int                    `json:"years"`
+	Grants    []VestingTimelineGrant `json:"grants"`
+	Totals    []VestingTimelineTotal `json:"totals"` // Never summed across currencies
+}
+
+// GetVestingTimeline returns how many shares vest each month, and what they're worth, over
+// the next years starting with the current month. Zero years uses the default.
+func (s *Service) GetVestingTimeline(ctx context.Context, accountID string, years int) (*VestingTimelineResponse, error) {
+	if years == 0 {
+		years = DefaultVestingTimelineYears
+	}
+	if years < 1 || years > MaxVestingTimelineYears {
+		return nil, fmt.Errorf("years must be between 1 and %d", MaxVestingTimelineYears)
+	}
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	data, err := s.loadOptionsData(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildVestingTimeline(accountID, data, civil.TodayIn(ctx), years), nil
+}
+
+// buildVestingTimeline buckets each grant's vesting events into months from today's month
+func buildVestingTimeline(accountID string, data *optionsData, today Date, years int) *VestingTimelineResponse {
+	resp := &VestingTimelineResponse{
+		AccountID: accountID,
+		Years:     years,
+		Grants:    make([]VestingTimelineGrant, 0, len(data.grants)),
+		Totals:    make([]VestingTimelineTotal, 0),
+	}
+
+	first := civil.NewDate(today.Year(), today.Month(), 1)
+	monthEnds := make([]Date, years*12)
+	for i := range monthEnds {
+		monthEnds[i] = civil.NewDate(first.Year(), first.Month()+time.Month(i+1), 1).AddDays(-1)
+	}
+
+	totals := make(map[string][]VestingTimelineMonth)
+	for i := range data.grants {
+		grant := &data.grants[i]
+		currency := grant.Currency
+		if currency == "" {
+			currency = "USD"
+		}
+
+		var events []VestingEvent
+		if schedule, ok := data.schedules[grant.ID]; ok {
+			events = computeVestingEventsAsOf(grant, schedule, today.Time)
+		}
+		timeline := VestingTimelineGrant{
+			GrantID:       grant.ID,
+			GrantType:     grant.GrantType,
+			CompanyName:   grant.CompanyName,
+			Currency:      currency,
+			ValuePerShare: roundCents(timelineValuePerShare(grant, data.fmvEntries, today)),
+			Months:        make([]VestingTimelineMonth, len(monthEnds)),
+		}
+		if len(events) > 0 {
+			last := events[len(events)-1].VestDate
+			timeline.FullyVestedDate = &last
+			if schedule := data.schedules[grant.ID]; schedule.CliffMonths != nil && *schedule.CliffMonths > 0 {
+				cliff := events[0].VestDate
+				timeline.CliffDate = &cliff
+			}
+		}
+
+		for m, end := range monthEnds {
+			timeline.Months[m] = grantTimelineMonth(grant, events, data.exercises[grant.ID], timeline, end)
+		}
+		resp.Grants = append(resp.Grants, timeline)
+
+		if totals[currency] == nil {
+			totals[currency] = make([]VestingTimelineMonth, len(monthEnds))
+			for m, end := range monthEnds {
+				totals[currency][m].Month = end.Format("2006-01")
+			}
+		}
+		for m, month := range timeline.Months {
+			total := &totals[currency][m]
+			total.SharesVesting += month.SharesVesting
+			total.ValueVesting = roundCents(total.ValueVesting + month.ValueVesting)
+			total.IsCliff = total.IsCliff || month.IsCliff
+			total.VestedShares += month.VestedShares
+			total.ExercisedShares += month.ExercisedShares
+			total.UnvestedShares += month.UnvestedShares
+			total.ExpiredShares += month.ExpiredShares
+			total.VestedValue = roundCents(total.VestedValue + month.VestedValue)
+			total.UnvestedValue = roundCents(total.UnvestedValue + month.UnvestedValue)
+		}
+	}
+
+	for currency, months := range totals {
+		resp.Totals = append(resp.Totals, VestingTimelineTotal{Currency: currency, Months: months})
+	}
+	sort.Slice(resp.Totals, func(i, j int) bool { return resp.Totals[i].Currency < resp.Totals[j].Currency })
+	return resp
+}
+
+// grantTimelineMonth is where a grant stands at the end of a month and what vested during it
+func grantTimelineMonth(grant *EquityGrant, events []VestingEvent, exercises []EquityExercise, timeline VestingTimelineGrant, end Date) VestingTimelineMonth {
+	month := VestingTimelineMonth{Month: end.Format("2006-01")}
+	// Grants issued later don't exist yet
+	if grant.GrantDate.After(end.Time) {
+		return month
+	}
+
+	vested := 0
+	for i, event := range events {
+		if event.VestDate.After(end.Time) {
+			continue
+		}
+		vested += event.Quantity
+		if event.VestDate.Year() == end.Year() && event.VestDate.Month() == end.Month() {
+			month.SharesVesting += event.Quantity
+			month.IsCliff = month.IsCliff || (i == 0 && timeline.CliffDate != nil)
+		}
+	}
+	for _, exercise := range exercises {
+		if !exercise.ExerciseDate.After(end.Time) {
+			month.ExercisedShares += exercise.Quantity
+		}
+	}
+	month.VestedShares = vested - month.ExercisedShares
+	month.UnvestedShares = grant.Quantity - vested
+
+	// Options left unexercised at expiration lapse, vested or not
+	if grant.isExpiredOn(end.Time) {
+		month.ExpiredShares = month.VestedShares + month.UnvestedShares
+		month.VestedShares, month.UnvestedShares = 0, 0
+		month.SharesVesting = 0
+	}
+
+	month.ValueVesting = roundCents(float64(month.SharesVesting) * timeline.ValuePerShare)
+	month.VestedValue = roundCents(float64(month.VestedShares) * timeline.ValuePerShare)
+	month.UnvestedValue = roundCents(float64(month.UnvestedShares) * timeline.ValuePerShare)
+	return month
+}
+
+// timelineValuePerShare is what a share of the grant is worth at today's FMV: the FMV for
+// RSUs and the spread over the strike, never negative, for options
+func timelineValuePerShare(grant *EquityGrant, entries []FMVEntry, today Date) float64 {
+	fmv := grant.FMVAtGrant
+	if current, ok := fmvInEffect(entries, grant.Currency, today.Time); ok {
+		fmv = current
+	}
+	if grant.StrikePrice != nil {
+		return math.Max(0, fmv-*grant.StrikePrice)
+	}
+	return fmv
+}
@@ -0,0 +1,204 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"money/internal/auth"
+)
+
+// WashSaleWindowDays is how many days before or after a loss sale a repurchase of the same
+// shares denies the loss (the US wash-sale rule and the Canadian superficial loss rule)
+const WashSaleWindowDays = 30
+
+// AcquisitionSource identifies how replacement shares were acquired
+type AcquisitionSource string
+
+const (
+	AcquisitionSourceExercise   AcquisitionSource = "exercise"
+	AcquisitionSourceVest       AcquisitionSource = "vest"
+	AcquisitionSourceHoldingBuy AcquisitionSource = "holding_buy"
+)
+
+// ReplacementPurchase is an acquisition of shares that can make a loss superficial
+type ReplacementPurchase struct {
+	Source     AcquisitionSource `json:"source"`
+	Date       Date              `json:"date"`
+	Quantity   float64           `json:"quantity"`
+	GrantID    *string           `json:"grant_id,omitempty"`
+	ExerciseID *string           `json:"exercise_id,omitempty"`
+	HoldingID  *string           `json:"holding_id,omitempty"`
+}
+
+// WashSaleAdjustment reports the part of a sale's loss denied by repurchases in the window
+type WashSaleAdjustment struct {
+	ReplacementShares float64                `json:"replacement_shares"`
+	DeniedLoss        float64                `json:"denied_loss"`   // Positive amount of loss disallowed
+	AdjustedGain      float64                `json:"adjusted_gain"` // Capital gain after adding back the denied loss
+	Replacements      []*ReplacementPurchase `json:"replacements"`
+}
+
+// listAccountAcquisitions lists the exercises and RSU/RSA vests in an equity account
+func (s *Service) listAccountAcquisitions(ctx context.Context, accountID string) ([]*ReplacementPurchase, error) {
+	acquisitions := make([]*ReplacementPurchase, 0)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ee.id, ee.grant_id, ee.exercise_date, ee.quantity
+		FROM equity_exercises ee
+		JOIN equity_grants eg ON eg.id = ee.grant_id
+		WHERE eg.account_id = $1
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exercises: %w", err)
+	}
+	for rows.Next() {
+		var exerciseID, grantID string
+		a := &ReplacementPurchase{Source: AcquisitionSourceExercise}
+		if err := rows.Scan(&exerciseID, &grantID, &a.Date, &a.Quantity); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan exercise: %w", err)
+		}
+		a.ExerciseID, a.GrantID = &exerciseID, &grantID
+		acquisitions = append(acquisitions, a)
+	}
+	rows.Close()
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT ve.grant_id, ve.vest_date, ve.quantity
+		FROM vesting_events ve
+		JOIN equity_grants eg ON eg.id = ve.grant_id
+		WHERE eg.account_id = $1 AND ve.status = 'vested' AND eg.grant_type IN ('rsu', 'rsa')
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vesting events: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var grantID string
+		a := &ReplacementPurchase{Source: AcquisitionSourceVest}
+		if err := rows.Scan(&grantID, &a.Date, &a.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to scan vesting event: %w", err)
+		}
+		a.GrantID = &grantID
+		acquisitions = append(acquisitions, a)
+	}
+
+	return acquisitions, rows.Err()
+}
+
+// listHoldingPurchases lists buys of a symbol across all of the user's holdings, including
+// synced brokerage accounts
+func (s *Service) listHoldingPurchases(ctx context.Context, symbol string) ([]*ReplacementPurchase, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ht.holding_id, ht.transaction_date, ht.quantity
+		FROM holding_transactions ht
+		JOIN holdings h ON h.id = ht.holding_id
+		JOIN accounts a ON a.id = h.account_id
+		WHERE a.user_id = $1 AND UPPER(h.symbol) = $2 AND ht.type = 'buy' AND ht.quantity > 0
+	`, auth.GetUserID(ctx), strings.ToUpper(symbol))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holding purchases: %w", err)
+	}
+	defer rows.Close()
+
+	purchases := make([]*ReplacementPurchase, 0)
+	for rows.Next() {
+		var holdingID string
+		a := &ReplacementPurchase{Source: AcquisitionSourceHoldingBuy}
+		if err := rows.Scan(&holdingID, &a.Date, &a.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to scan holding purchase: %w", err)
+		}
+		a.HoldingID = &holdingID
+		purchases = append(purchases, a)
+	}
+
+	return purchases, rows.Err()
+}
+
+// washSaleChecker detects wash sales for an account, loading acquisitions once
+type washSaleChecker struct {
+	svc          *Service
+	accountID    string
+	acquisitions []*ReplacementPurchase
+	bySymbol     map[string][]*ReplacementPurchase
+}
+
+// newWashSaleChecker loads the account's acquisitions for wash-sale checks
+func (s *Service) newWashSaleChecker(ctx context.Context, accountID string) (*washSaleChecker, error) {
+	acquisitions, err := s.listAccountAcquisitions(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return &washSaleChecker{
+		svc:          s,
+		accountID:    accountID,
+		acquisitions: acquisitions,
+		bySymbol:     make(map[string][]*ReplacementPurchase),
+	}, nil
+}
+
+// check returns the wash-sale adjustment for a sale, or nil when none applies
+func (c *washSaleChecker) check(ctx context.Context, sale *EquitySale) (*WashSaleAdjustment, error) {
+	if sale.CapitalGain >= 0 {
+		return nil, nil
+	}
+
+	candidates := c.acquisitions
+	if sale.Symbol != nil && *sale.Symbol != "" {
+		symbol := strings.ToUpper(*sale.Symbol)
+		purchases, ok := c.bySymbol[symbol]
+		if !ok {
+			var err error
+			purchases, err = c.svc.listHoldingPurchases(ctx, symbol)
+			if err != nil {
+				return nil, err
+			}
+			c.bySymbol[symbol] = purchases
+		}
+		candidates = append(append([]*ReplacementPurchase{}, c.acquisitions...), purchases...)
+	}
+
+	return washSaleAdjustment(sale, candidates), nil
+}
+
+// washSaleAdjustment denies the share of a sale's loss covered by replacement shares acquired
+// within the window. Shares from the sale's own grant acquired on or before the sale are the
+// shares being sold, not replacements.
+func washSaleAdjustment(sale *EquitySale, acquisitions []*ReplacementPurchase) *WashSaleAdjustment {
+	if sale.CapitalGain >= 0 || sale.Quantity <= 0 {
+		return nil
+	}
+
+	windowStart := sale.SaleDate.Time.AddDate(0, 0, -WashSaleWindowDays)
+	windowEnd := sale.SaleDate.Time.AddDate(0, 0, WashSaleWindowDays)
+
+	adjustment := &WashSaleAdjustment{Replacements: make([]*ReplacementPurchase, 0)}
+	for _, a := range acquisitions {
+		if a.Date.Time.Before(windowStart) || a.Date.Time.After(windowEnd) {
+			continue
+		}
+		if sale.ExerciseID != nil && a.ExerciseID != nil && *a.ExerciseID == *sale.ExerciseID {
+			continue
+		}
+		if sale.GrantID != nil && a.GrantID != nil && *a.GrantID == *sale.GrantID && !a.Date.Time.After(sale.SaleDate.Time) {
+			continue
+		}
+		adjustment.ReplacementShares += a.Quantity
+		adjustment.Replacements = append(adjustment.Replacements, a)
+	}
+	if adjustment.ReplacementShares <= 0 {
+		return nil
+	}
+
+	sort.Slice(adjustment.Replacements, func(i, j int) bool {
+		return adjustment.Replacements[i].Date.Time.Before(adjustment.Replacements[j].Date.Time)
+	})
+
+	deniedShare := math.Min(adjustment.ReplacementShares, float64(sale.Quantity)) / float64(sale.Quantity)
+	adjustment.DeniedLoss = math.Round(-sale.CapitalGain*deniedShare*100) / 100
+	adjustment.AdjustedGain = math.Round((sale.CapitalGain+adjustment.DeniedLoss)*100) / 100
+	return adjustment
+}
@@ -0,0 +1,119 @@
+package advisor
+
+import "time"
+
+// Status is where a grant is in its lifecycle
+type Status string
+
+const (
+	StatusPending Status = "pending" // Invite sent, not yet accepted
+	StatusActive  Status = "active"
+	StatusRevoked Status = "revoked"
+)
+
+// InviteExpiryDays is how long an invite can be accepted for
+const InviteExpiryDays = 14
+
+// MaxCommentLength caps the length of an advisor comment
+const MaxCommentLength = 5000
+
+// Grant gives an advisor delegated access to selected modules of the owner's data
+type Grant struct {
+	ID            string     `json:"id"`
+	OwnerUserID   string     `json:"owner_user_id"`
+	AdvisorEmail  string     `json:"advisor_email"`
+	AdvisorUserID *string    `json:"advisor_user_id,omitempty"`
+	Access        string     `json:"access"` // read or comment
+	Modules       []string   `json:"modules"`
+	Status        Status     `json:"status"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	AcceptedAt    *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// InviteAdvisorRequest represents the request to invite an advisor
+type InviteAdvisorRequest struct {
+	Email     string     `json:"email"`
+	Access    string     `json:"access"`
+	Modules   []string   `json:"modules"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // When access ends; nil until revoked
+}
+
+// InviteAdvisorResponse returns a new grant with its invite token, which is only shown once
+type InviteAdvisorResponse struct {
+	Grant
+	InviteToken string `json:"invite_token"`
+}
+
+// UpdateGrantRequest represents the request to change what an advisor can see
+type UpdateGrantRequest struct {
+	Access    *string    `json:"access,omitempty"`
+	Modules   *[]string  `json:"modules,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// AcceptInviteRequest represents an advisor accepting an invite
+type AcceptInviteRequest struct {
+	Token string `json:"token"`
+}
+
+// ListGrantsResponse lists grants
+type ListGrantsResponse struct {
+	Grants []*Grant `json:"grants"`
+}
+
+// Client is a user who gave the current user advisor access
+type Client struct {
+	GrantID   string     `json:"grant_id"`
+	UserID    string     `json:"user_id"` // Send as X-Acting-For to act for this client
+	Name      string     `json:"name"`
+	Email     string     `json:"email"`
+	Access    string     `json:"access"`
+	Modules   []string   `json:"modules"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ListClientsResponse lists an advisor's clients
+type ListClientsResponse struct {
+	Clients []*Client `json:"clients"`
+}
+
+// AccessLogEntry is one request an advisor made with delegated access
+type AccessLogEntry struct {
+	ID         string    `json:"id"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	ClientIP   *string   `json:"client_ip,omitempty"`
+	Allowed    bool      `json:"allowed"`
+	AccessedAt time.Time `json:"accessed_at"`
+}
+
+// AccessLogResponse lists a grant's access log, newest first
+type AccessLogResponse struct {
+	Entries []*AccessLogEntry `json:"entries"`
+}
+
+// Comment is a note an advisor left for the owner
+type Comment struct {
+	ID           string    `json:"id"`
+	GrantID      string    `json:"grant_id"`
+	AdvisorEmail string    `json:"advisor_email"`
+	Module       string    `json:"module"`
+	EntityID     *string   `json:"entity_id,omitempty"`
+	Body         string    `json:"body"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CreateCommentRequest represents an advisor leaving a comment
+type CreateCommentRequest struct {
+	Module   string  `json:"module"`
+	EntityID *string `json:"entity_id,omitempty"`
+	Body     string  `json:"body"`
+}
+
+// ListCommentsResponse lists advisor comments, newest first
+type ListCommentsResponse struct {
+	Comments []*Comment `json:"comments"`
+}
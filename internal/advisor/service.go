@@ -0,0 +1,532 @@
+// Package advisor lets a user invite a financial advisor with read-only or comment access
+// to selected parts of their data. The advisor signs in as themselves and acts for the
+// owner through auth.DelegationMiddleware, which this service backs.
+package advisor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"money/internal/auth"
+
+	"github.com/google/uuid"
+)
+
+// Service provides advisor access functionality
+type Service struct {
+	db *sql.DB
+}
+
+// NewService creates a new advisor access service
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// ownerID returns the authenticated user, refusing delegates so an advisor can't manage
+// the owner's grants
+func ownerID(ctx context.Context) (string, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return "", fmt.Errorf("user not authenticated")
+	}
+	if auth.GetDelegate(ctx) != "" {
+		return "", fmt.Errorf("delegated access can't manage advisor grants")
+	}
+	return userID, nil
+}
+
+// validateAccess checks an access level and module list
+func validateAccess(access string, modules []string) error {
+	if access != auth.AccessRead && access != auth.AccessComment {
+		return fmt.Errorf("access must be %s or %s", auth.AccessRead, auth.AccessComment)
+	}
+	if len(modules) == 0 {
+		return fmt.Errorf("at least one module is required")
+	}
+	for _, module := range modules {
+		if !auth.IsDelegableModule(module) {
+			return fmt.Errorf("unknown module: %s", module)
+		}
+	}
+	return nil
+}
+
+// hashToken returns the stored hash of an invite token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// InviteAdvisor creates a pending grant and returns the invite token to send the advisor
+func (s *Service) InviteAdvisor(ctx context.Context, req *InviteAdvisorRequest) (*InviteAdvisorResponse, error) {
+	userID, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, fmt.Errorf("a valid advisor email is required")
+	}
+	if err := validateAccess(req.Access, req.Modules); err != nil {
+		return nil, err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("expires_at must be in the future")
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate invite token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	modulesJSON, err := json.Marshal(req.Modules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal modules: %w", err)
+	}
+
+	now := time.Now()
+	grant := Grant{
+		ID:           uuid.New().String(),
+		OwnerUserID:  userID,
+		AdvisorEmail: email,
+		Access:       req.Access,
+		Modules:      req.Modules,
+		Status:       StatusPending,
+		ExpiresAt:    req.ExpiresAt,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO advisor_grants (id, owner_user_id, advisor_email, access, modules, invite_token_hash,
+			status, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+	`, grant.ID, userID, email, grant.Access, string(modulesJSON), hashToken(token), grant.Status, grant.ExpiresAt, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create advisor invite: %w", err)
+	}
+
+	return &InviteAdvisorResponse{Grant: grant, InviteToken: token}, nil
+}
+
+// grantSelect selects grants in the column order scanGrant reads
+const grantSelect = `
+	SELECT id, owner_user_id, advisor_email, advisor_user_id, access, modules, status,
+		expires_at, accepted_at, revoked_at, created_at, updated_at
+	FROM advisor_grants
+`
+
+// scanner is satisfied by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanGrant reads a grant row selected with grantSelect
+func scanGrant(row scanner) (*Grant, error) {
+	g := &Grant{}
+	var advisorUserID sql.NullString
+	var modulesJSON string
+	var expiresAt, acceptedAt, revokedAt sql.NullTime
+	err := row.Scan(&g.ID, &g.OwnerUserID, &g.AdvisorEmail, &advisorUserID, &g.Access, &modulesJSON, &g.Status,
+		&expiresAt, &acceptedAt, &revokedAt, &g.CreatedAt, &g.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan advisor grant: %w", err)
+	}
+	if err := json.Unmarshal([]byte(modulesJSON), &g.Modules); err != nil {
+		return nil, fmt.Errorf("failed to decode modules: %w", err)
+	}
+	if advisorUserID.Valid {
+		g.AdvisorUserID = &advisorUserID.String
+	}
+	if expiresAt.Valid {
+		g.ExpiresAt = &expiresAt.Time
+	}
+	if acceptedAt.Valid {
+		g.AcceptedAt = &acceptedAt.Time
+	}
+	if revokedAt.Valid {
+		g.RevokedAt = &revokedAt.Time
+	}
+	return g, nil
+}
+
+// ListGrants returns the advisors the user has invited, newest first
+func (s *Service) ListGrants(ctx context.Context) (*ListGrantsResponse, error) {
+	userID, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, grantSelect+`
+		WHERE owner_user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list advisor grants: %w", err)
+	}
+	defer rows.Close()
+
+	grants := make([]*Grant, 0)
+	for rows.Next() {
+		grant, err := scanGrant(rows)
+		if err != nil {
+			return nil, err
+		}
+		grants = append(grants, grant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read advisor grants: %w", err)
+	}
+
+	return &ListGrantsResponse{Grants: grants}, nil
+}
+
+// getOwnedGrant returns one of the user's grants
+func (s *Service) getOwnedGrant(ctx context.Context, userID, id string) (*Grant, error) {
+	grant, err := scanGrant(s.db.QueryRowContext(ctx, grantSelect+`
+		WHERE id = $1 AND owner_user_id = $2
+	`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("advisor grant not found")
+	}
+	return grant, err
+}
+
+// UpdateGrant changes an advisor's access level, modules or end date
+func (s *Service) UpdateGrant(ctx context.Context, id string, req *UpdateGrantRequest) (*Grant, error) {
+	userID, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	grant, err := s.getOwnedGrant(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if grant.Status == StatusRevoked {
+		return nil, fmt.Errorf("advisor grant is revoked")
+	}
+
+	if req.Access != nil {
+		grant.Access = *req.Access
+	}
+	if req.Modules != nil {
+		grant.Modules = *req.Modules
+	}
+	if req.ExpiresAt != nil {
+		grant.ExpiresAt = req.ExpiresAt
+	}
+	if err := validateAccess(grant.Access, grant.Modules); err != nil {
+		return nil, err
+	}
+
+	modulesJSON, err := json.Marshal(grant.Modules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal modules: %w", err)
+	}
+	grant.UpdatedAt = time.Now()
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE advisor_grants SET access = $1, modules = $2, expires_at = $3, updated_at = $4
+		WHERE id = $5 AND owner_user_id = $6
+	`, grant.Access, string(modulesJSON), grant.ExpiresAt, grant.UpdatedAt, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update advisor grant: %w", err)
+	}
+
+	return grant, nil
+}
+
+// RevokeGrant ends an advisor's access, or cancels a pending invite
+func (s *Service) RevokeGrant(ctx context.Context, id string) error {
+	userID, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE advisor_grants SET status = $1, revoked_at = $2, updated_at = $2
+		WHERE id = $3 AND owner_user_id = $4 AND status != $1
+	`, StatusRevoked, now, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke advisor grant: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("advisor grant not found")
+	}
+
+	return nil
+}
+
+// AcceptInvite links a pending invite to the signed-in advisor. The advisor's account email
+// must match the address the invite was sent to.
+func (s *Service) AcceptInvite(ctx context.Context, req *AcceptInviteRequest) (*Grant, error) {
+	advisorID, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	grant, err := scanGrant(s.db.QueryRowContext(ctx, grantSelect+`
+		WHERE invite_token_hash = $1 AND status = $2
+	`, hashToken(req.Token), StatusPending))
+	if err == sql.ErrNoRows || (err == nil && time.Since(grant.CreatedAt) > InviteExpiryDays*24*time.Hour) {
+		return nil, fmt.Errorf("invite is invalid or has expired")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if grant.OwnerUserID == advisorID {
+		return nil, fmt.Errorf("you can't accept your own invite")
+	}
+
+	var email string
+	if err := s.db.QueryRowContext(ctx, `SELECT email FROM users WHERE id = $1`, advisorID).Scan(&email); err != nil {
+		return nil, fmt.Errorf("failed to get advisor email: %w", err)
+	}
+	if !strings.EqualFold(email, grant.AdvisorEmail) {
+		return nil, fmt.Errorf("invite was sent to a different email address")
+	}
+
+	now := time.Now()
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE advisor_grants SET advisor_user_id = $1, status = $2, accepted_at = $3, updated_at = $3
+		WHERE id = $4
+	`, advisorID, StatusActive, now, grant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept invite: %w", err)
+	}
+
+	grant.AdvisorUserID = &advisorID
+	grant.Status = StatusActive
+	grant.AcceptedAt = &now
+	grant.UpdatedAt = now
+	return grant, nil
+}
+
+// ListClients returns the users who gave the signed-in user active advisor access
+func (s *Service) ListClients(ctx context.Context) (*ListClientsResponse, error) {
+	advisorID, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT g.id, u.id, COALESCE(u.name, ''), u.email, g.access, g.modules, g.expires_at
+		FROM advisor_grants g
+		JOIN users u ON u.id = g.owner_user_id
+		WHERE g.advisor_user_id = $1 AND g.status = $2 AND (g.expires_at IS NULL OR g.expires_at > $3)
+		ORDER BY u.email
+	`, advisorID, StatusActive, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clients: %w", err)
+	}
+	defer rows.Close()
+
+	clients := make([]*Client, 0)
+	for rows.Next() {
+		c := &Client{}
+		var modulesJSON string
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&c.GrantID, &c.UserID, &c.Name, &c.Email, &c.Access, &modulesJSON, &expiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan client: %w", err)
+		}
+		if err := json.Unmarshal([]byte(modulesJSON), &c.Modules); err != nil {
+			return nil, fmt.Errorf("failed to decode modules: %w", err)
+		}
+		if expiresAt.Valid {
+			c.ExpiresAt = &expiresAt.Time
+		}
+		clients = append(clients, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read clients: %w", err)
+	}
+
+	return &ListClientsResponse{Clients: clients}, nil
+}
+
+// activeGrant returns the active, unexpired grant from owner to advisor
+func (s *Service) activeGrant(ctx context.Context, advisorID, ownerUserID string) (*Grant, error) {
+	grant, err := scanGrant(s.db.QueryRowContext(ctx, grantSelect+`
+		WHERE advisor_user_id = $1 AND owner_user_id = $2 AND status = $3
+	`, advisorID, ownerUserID, StatusActive))
+	if err == sql.ErrNoRows || (err == nil && grant.ExpiresAt != nil && !time.Now().Before(*grant.ExpiresAt)) {
+		return nil, fmt.Errorf("no active advisor grant from %s to %s", ownerUserID, advisorID)
+	}
+	return grant, err
+}
+
+// VerifyDelegation implements auth.DelegationVerifier
+func (s *Service) VerifyDelegation(ctx context.Context, delegateID, ownerUserID string) (*auth.Delegation, error) {
+	grant, err := s.activeGrant(ctx, delegateID, ownerUserID)
+	if err != nil {
+		return nil, err
+	}
+	return &auth.Delegation{ID: grant.ID, Access: grant.Access, Modules: grant.Modules}, nil
+}
+
+// RecordDelegatedAccess implements auth.DelegationVerifier
+func (s *Service) RecordDelegatedAccess(ctx context.Context, delegationID, method, path, clientIP string, allowed bool) error {
+	var ip *string
+	if clientIP != "" {
+		ip = &clientIP
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO advisor_access_log (id, grant_id, method, path, client_ip, allowed, accessed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, uuid.New().String(), delegationID, method, path, ip, allowed, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record delegated access: %w", err)
+	}
+	return nil
+}
+
+// GetAccessLog returns the requests an advisor made under a grant, newest first
+func (s *Service) GetAccessLog(ctx context.Context, id string) (*AccessLogResponse, error) {
+	userID, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.getOwnedGrant(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, method, path, client_ip, allowed, accessed_at
+		FROM advisor_access_log
+		WHERE grant_id = $1
+		ORDER BY accessed_at DESC
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]*AccessLogEntry, 0)
+	for rows.Next() {
+		e := &AccessLogEntry{}
+		var clientIP sql.NullString
+		if err := rows.Scan(&e.ID, &e.Method, &e.Path, &clientIP, &e.Allowed, &e.AccessedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan access log entry: %w", err)
+		}
+		if clientIP.Valid {
+			e.ClientIP = &clientIP.String
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read access log: %w", err)
+	}
+
+	return &AccessLogResponse{Entries: entries}, nil
+}
+
+// CreateComment records a comment from an advisor acting for the owner with comment access
+func (s *Service) CreateComment(ctx context.Context, req *CreateCommentRequest) (*Comment, error) {
+	userID := auth.GetUserID(ctx)
+	advisorID := auth.GetDelegate(ctx)
+	if userID == "" || advisorID == "" {
+		return nil, fmt.Errorf("only an advisor acting for a client can comment")
+	}
+
+	grant, err := s.activeGrant(ctx, advisorID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if grant.Access != auth.AccessComment {
+		return nil, fmt.Errorf("advisor grant doesn't allow comments")
+	}
+	granted := false
+	for _, module := range grant.Modules {
+		granted = granted || module == req.Module
+	}
+	if !granted {
+		return nil, fmt.Errorf("advisor grant doesn't include module %s", req.Module)
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" || len(body) > MaxCommentLength {
+		return nil, fmt.Errorf("comment must be between 1 and %d characters", MaxCommentLength)
+	}
+
+	comment := &Comment{
+		ID:           uuid.New().String(),
+		GrantID:      grant.ID,
+		AdvisorEmail: grant.AdvisorEmail,
+		Module:       req.Module,
+		EntityID:     req.EntityID,
+		Body:         body,
+		CreatedAt:    time.Now(),
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO advisor_comments (id, grant_id, module, entity_id, body, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, comment.ID, comment.GrantID, comment.Module, comment.EntityID, comment.Body, comment.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	return comment, nil
+}
+
+// ListComments returns advisor comments, newest first. The owner sees every advisor's
+// comments; an advisor acting for the owner sees only their own.
+func (s *Service) ListComments(ctx context.Context) (*ListCommentsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	query := `
+		SELECT c.id, c.grant_id, g.advisor_email, c.module, c.entity_id, c.body, c.created_at
+		FROM advisor_comments c
+		JOIN advisor_grants g ON g.id = c.grant_id
+		WHERE g.owner_user_id = $1
+	`
+	args := []interface{}{userID}
+	if advisorID := auth.GetDelegate(ctx); advisorID != "" {
+		query += ` AND g.advisor_user_id = $2`
+		args = append(args, advisorID)
+	}
+	query += ` ORDER BY c.created_at DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	defer rows.Close()
+
+	comments := make([]*Comment, 0)
+	for rows.Next() {
+		c := &Comment{}
+		var entityID sql.NullString
+		if err := rows.Scan(&c.ID, &c.GrantID, &c.AdvisorEmail, &c.Module, &entityID, &c.Body, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		if entityID.Valid {
+			c.EntityID = &entityID.String
+		}
+		comments = append(comments, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read comments: %w", err)
+	}
+
+	return &ListCommentsResponse{Comments: comments}, nil
+}
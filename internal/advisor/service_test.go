@@ -0,0 +1,173 @@
+package advisor
+
+import (
+	"testing"
+
+	"money/internal/account"
+	"money/internal/auth"
+)
+
+func setupService(t *testing.T) (*Service, func()) {
+	t.Helper()
+	db := account.SetupTestDB(t)
+	return NewService(db), func() { account.CleanupTestDB(t, db) }
+}
+
+func TestAcceptInvite_GrantsDelegation(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	ownerID := "test-user-advisor-owner-1"
+	advisorID := "test-user-advisor-1"
+	account.CreateTestUser(t, service.db, ownerID)
+	account.CreateTestUser(t, service.db, advisorID)
+	invite, err := service.InviteAdvisor(account.CreateAuthContext(ownerID), &InviteAdvisorRequest{
+		Email:   advisorID + "@TEST.com",
+		Access:  auth.AccessRead,
+		Modules: []string{auth.ModuleAccounts, auth.ModuleHoldings},
+	})
+	if err != nil {
+		t.Fatalf("InviteAdvisor failed: %v", err)
+	}
+	if _, err := service.VerifyDelegation(account.CreateAuthContext(advisorID), advisorID, ownerID); err == nil {
+		t.Error("Expected a pending invite not to grant access")
+	}
+
+	// Act
+	grant, err := service.AcceptInvite(account.CreateAuthContext(advisorID), &AcceptInviteRequest{Token: invite.InviteToken})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("AcceptInvite failed: %v", err)
+	}
+	if grant.Status != StatusActive {
+		t.Errorf("Expected status active, got %s", grant.Status)
+	}
+	delegation, err := service.VerifyDelegation(account.CreateAuthContext(advisorID), advisorID, ownerID)
+	if err != nil {
+		t.Fatalf("VerifyDelegation failed: %v", err)
+	}
+	if delegation.ID != invite.ID || len(delegation.Modules) != 2 {
+		t.Errorf("Expected the accepted grant with two modules, got %+v", delegation)
+	}
+	clients, err := service.ListClients(account.CreateAuthContext(advisorID))
+	if err != nil {
+		t.Fatalf("ListClients failed: %v", err)
+	}
+	if len(clients.Clients) != 1 || clients.Clients[0].UserID != ownerID {
+		t.Errorf("Expected the owner as the only client, got %+v", clients.Clients)
+	}
+}
+
+func TestAcceptInvite_RejectsOtherEmail(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	ownerID := "test-user-advisor-owner-2"
+	otherID := "test-user-advisor-2"
+	account.CreateTestUser(t, service.db, ownerID)
+	account.CreateTestUser(t, service.db, otherID)
+	invite, err := service.InviteAdvisor(account.CreateAuthContext(ownerID), &InviteAdvisorRequest{
+		Email:   "planner@example.com",
+		Access:  auth.AccessRead,
+		Modules: []string{auth.ModuleAccounts},
+	})
+	if err != nil {
+		t.Fatalf("InviteAdvisor failed: %v", err)
+	}
+
+	// Act
+	_, err = service.AcceptInvite(account.CreateAuthContext(otherID), &AcceptInviteRequest{Token: invite.InviteToken})
+
+	// Assert
+	if err == nil {
+		t.Error("Expected error when the invite was sent to a different email")
+	}
+}
+
+func TestRevokeGrant_EndsDelegation(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	ownerID := "test-user-advisor-owner-3"
+	advisorID := "test-user-advisor-3"
+	grantID := acceptedGrant(t, service, ownerID, advisorID, auth.AccessRead)
+	if err := service.RecordDelegatedAccess(account.CreateAuthContext(advisorID), grantID, "GET", "/api/accounts", "10.0.0.1", true); err != nil {
+		t.Fatalf("RecordDelegatedAccess failed: %v", err)
+	}
+
+	// Act
+	err := service.RevokeGrant(account.CreateAuthContext(ownerID), grantID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("RevokeGrant failed: %v", err)
+	}
+	if _, err := service.VerifyDelegation(account.CreateAuthContext(advisorID), advisorID, ownerID); err == nil {
+		t.Error("Expected a revoked grant not to grant access")
+	}
+	log, err := service.GetAccessLog(account.CreateAuthContext(ownerID), grantID)
+	if err != nil {
+		t.Fatalf("GetAccessLog failed: %v", err)
+	}
+	if len(log.Entries) != 1 || log.Entries[0].Path != "/api/accounts" {
+		t.Errorf("Expected the recorded access to survive revocation, got %+v", log.Entries)
+	}
+}
+
+func TestCreateComment_RequiresCommentAccess(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	readOwner, commentOwner := "test-user-advisor-owner-4", "test-user-advisor-owner-5"
+	advisorID := "test-user-advisor-4"
+	acceptedGrant(t, service, readOwner, advisorID, auth.AccessRead)
+	acceptedGrant(t, service, commentOwner, advisorID, auth.AccessComment)
+	req := &CreateCommentRequest{Module: auth.ModuleAccounts, Body: "Consider consolidating these"}
+
+	// Act
+	_, readErr := service.CreateComment(auth.WithDelegate(account.CreateAuthContext(readOwner), advisorID), req)
+	comment, commentErr := service.CreateComment(auth.WithDelegate(account.CreateAuthContext(commentOwner), advisorID), req)
+
+	// Assert
+	if readErr == nil {
+		t.Error("Expected error commenting with read-only access")
+	}
+	if commentErr != nil {
+		t.Fatalf("CreateComment failed: %v", commentErr)
+	}
+	comments, err := service.ListComments(account.CreateAuthContext(commentOwner))
+	if err != nil {
+		t.Fatalf("ListComments failed: %v", err)
+	}
+	if len(comments.Comments) != 1 || comments.Comments[0].ID != comment.ID {
+		t.Errorf("Expected the owner to see the comment, got %+v", comments.Comments)
+	}
+	if _, err := service.CreateComment(auth.WithDelegate(account.CreateAuthContext(commentOwner), advisorID),
+		&CreateCommentRequest{Module: auth.ModuleCredit, Body: "Not granted"}); err == nil {
+		t.Error("Expected error commenting on a module that wasn't granted")
+	}
+}
+
+// acceptedGrant invites an advisor and accepts the invite, returning the grant ID
+func acceptedGrant(t *testing.T, service *Service, ownerID, advisorID, access string) string {
+	t.Helper()
+	account.CreateTestUser(t, service.db, ownerID)
+	account.CreateTestUser(t, service.db, advisorID)
+	invite, err := service.InviteAdvisor(account.CreateAuthContext(ownerID), &InviteAdvisorRequest{
+		Email:   advisorID + "@test.com",
+		Access:  access,
+		Modules: []string{auth.ModuleAccounts},
+	})
+	if err != nil {
+		t.Fatalf("InviteAdvisor failed: %v", err)
+	}
+	if _, err := service.AcceptInvite(account.CreateAuthContext(advisorID), &AcceptInviteRequest{Token: invite.InviteToken}); err != nil {
+		t.Fatalf("AcceptInvite failed: %v", err)
+	}
+	return invite.ID
+}
@@ -0,0 +1,455 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/transaction"
+
+	"github.com/google/uuid"
+)
+
+// AnomalyKind identifies what an anomaly detector found
+type AnomalyKind string
+
+const (
+	AnomalyBalanceDrop     AnomalyKind = "balance_drop"
+	AnomalyDuplicateCharge AnomalyKind = "duplicate_charge"
+	AnomalySpendingSpike   AnomalyKind = "spending_spike"
+)
+
+// AnomalyStatus is where an anomaly is in review
+type AnomalyStatus string
+
+const (
+	AnomalyStatusOpen         AnomalyStatus = "open"
+	AnomalyStatusAcknowledged AnomalyStatus = "acknowledged"
+	AnomalyStatusDismissed    AnomalyStatus = "dismissed"
+)
+
+// Detection thresholds
+const (
+	anomalyLookbackDays      = 30  // Only changes and charges this recent are flagged
+	balanceHistoryChanges    = 12  // Trailing balance changes a change is compared against
+	minBalanceHistoryChanges = 5   // Fewer changes than this aren't enough to call anything unusual
+	balanceDropZScore        = 3.0 // Standard deviations below the trailing mean change
+	balanceDropMinFraction   = 0.2 // Drops smaller than this share of the balance are ignored
+	duplicateWindowDays      = 3   // Matching charges this close together look like duplicates
+	spendingHistoryMonths    = 6   // Months a category's spending is compared against
+	spendingSpikeZScore      = 2.0
+	spendingSpikeMinRatio    = 1.5 // Spending must also be this multiple of the average
+	spendingSpikeMinAmount   = 50  // Ignore spikes smaller than this over the average
+)
+
+// Anomaly is an unusual balance change or spending pattern
+type Anomaly struct {
+	ID          string          `json:"id"`
+	AccountID   *string         `json:"account_id,omitempty"`
+	Kind        AnomalyKind     `json:"kind"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Amount      float64         `json:"amount"`
+	Baseline    *float64        `json:"baseline,omitempty"`
+	ObservedOn  time.Time       `json:"observed_on"`
+	Details     json.RawMessage `json:"details,omitempty"`
+	Status      AnomalyStatus   `json:"status"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// ListAnomaliesResponse lists anomalies
+type ListAnomaliesResponse struct {
+	Anomalies []Anomaly `json:"anomalies"`
+}
+
+// DetectAnomaliesResponse reports how many new anomalies a detection run found
+type DetectAnomaliesResponse struct {
+	Detected int `json:"detected"`
+}
+
+// anomalyFinding is a detector result before it is stored
+type anomalyFinding struct {
+	AccountID   *string
+	Kind        AnomalyKind
+	Fingerprint string
+	Title       string
+	Description string
+	Amount      float64
+	Baseline    *float64
+	ObservedOn  time.Time
+	Details     interface{}
+}
+
+// balanceSeries is an asset account's balance history
+type balanceSeries struct {
+	accountID string
+	name      string
+	currency  string
+	dates     []time.Time
+	amounts   []float64
+}
+
+// DetectAnomalies runs every detector for the current user and stores new findings.
+// Findings already stored, including dismissed ones, are not raised again.
+func (s *Service) DetectAnomalies(ctx context.Context) (*DetectAnomaliesResponse, error) {
+	return s.detectAnomalies(ctx, time.Now())
+}
+
+// detectAnomalies runs the detectors as of now
+func (s *Service) detectAnomalies(ctx context.Context, now time.Time) (*DetectAnomaliesResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	since := now.AddDate(0, 0, -anomalyLookbackDays)
+
+	series, err := s.getAssetBalanceSeries(ctx, userID, since.AddDate(0, -6, 0))
+	if err != nil {
+		return nil, err
+	}
+	var findings []anomalyFinding
+	for _, account := range series {
+		findings = append(findings, findBalanceDrops(account, since)...)
+	}
+
+	from := since.AddDate(0, 0, -duplicateWindowDays)
+	transactions, err := s.transactionSvc.ListTransactions(ctx, transaction.ListTransactionsFilter{From: &from, To: &now})
+	if err != nil {
+		return nil, err
+	}
+	findings = append(findings, findDuplicateCharges(transactions.Transactions, since)...)
+
+	historyStart := time.Date(now.Year(), now.Month()-spendingHistoryMonths, 1, 0, 0, 0, 0, now.Location())
+	lines, err := s.transactionSvc.GetCategoryLines(ctx, &historyStart, &now)
+	if err != nil {
+		return nil, err
+	}
+	findings = append(findings, findSpendingSpikes(lines, now)...)
+
+	detected := 0
+	for _, finding := range findings {
+		created, err := s.storeAnomaly(ctx, userID, finding)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			detected++
+		}
+	}
+
+	return &DetectAnomaliesResponse{Detected: detected}, nil
+}
+
+// storeAnomaly saves a finding unless one with the same fingerprint already exists
+func (s *Service) storeAnomaly(ctx context.Context, userID string, finding anomalyFinding) (bool, error) {
+	var details *string
+	if finding.Details != nil {
+		data, err := json.Marshal(finding.Details)
+		if err != nil {
+			return false, fmt.Errorf("failed to encode anomaly details: %w", err)
+		}
+		encoded := string(data)
+		details = &encoded
+	}
+
+	now := time.Now()
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO anomalies (
+			id, user_id, account_id, kind, fingerprint, title, description, amount, baseline,
+			observed_on, details, status, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $13)
+		ON CONFLICT (user_id, fingerprint) DO NOTHING
+	`, uuid.New().String(), userID, finding.AccountID, finding.Kind, finding.Fingerprint, finding.Title,
+		finding.Description, roundCents(finding.Amount), finding.Baseline, finding.ObservedOn, details,
+		AnomalyStatusOpen, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to store anomaly: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// ListAnomalies lists the user's anomalies, newest first, optionally by status
+func (s *Service) ListAnomalies(ctx context.Context, status AnomalyStatus) (*ListAnomaliesResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, account_id, kind, title, description, amount, baseline, observed_on, details,
+			status, created_at, updated_at
+		FROM anomalies
+		WHERE user_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY observed_on DESC, created_at DESC
+	`, userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list anomalies: %w", err)
+	}
+	defer rows.Close()
+
+	anomalies := make([]Anomaly, 0)
+	for rows.Next() {
+		var a Anomaly
+		var details *string
+		if err := rows.Scan(&a.ID, &a.AccountID, &a.Kind, &a.Title, &a.Description, &a.Amount, &a.Baseline,
+			&a.ObservedOn, &details, &a.Status, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan anomaly: %w", err)
+		}
+		if details != nil {
+			a.Details = json.RawMessage(*details)
+		}
+		anomalies = append(anomalies, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list anomalies: %w", err)
+	}
+
+	return &ListAnomaliesResponse{Anomalies: anomalies}, nil
+}
+
+// AcknowledgeAnomaly marks an anomaly as seen and expected
+func (s *Service) AcknowledgeAnomaly(ctx context.Context, id string) error {
+	return s.setAnomalyStatus(ctx, id, AnomalyStatusAcknowledged)
+}
+
+// DismissAnomaly marks an anomaly as a false alarm
+func (s *Service) DismissAnomaly(ctx context.Context, id string) error {
+	return s.setAnomalyStatus(ctx, id, AnomalyStatusDismissed)
+}
+
+// setAnomalyStatus moves one of the user's anomalies to a review status
+func (s *Service) setAnomalyStatus(ctx context.Context, id string, status AnomalyStatus) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE anomalies SET status = $1, updated_at = $2 WHERE id = $3 AND user_id = $4
+	`, status, time.Now(), id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update anomaly: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("anomaly not found")
+	}
+	return nil
+}
+
+// getAssetBalanceSeries loads balance history since from for the user's active asset accounts
+func (s *Service) getAssetBalanceSeries(ctx context.Context, userID string, from time.Time) ([]*balanceSeries, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.id, a.name, a.currency, b.date, b.amount
+		FROM accounts a
+		JOIN balances b ON b.account_id = a.id
+		WHERE a.user_id = $1 AND a.is_asset = true AND a.is_active = true AND b.date >= $2
+		ORDER BY a.id, b.date
+	`, userID, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+	defer rows.Close()
+
+	var series []*balanceSeries
+	var current *balanceSeries
+	for rows.Next() {
+		var id, name, currency string
+		var date time.Time
+		var amount float64
+		if err := rows.Scan(&id, &name, &currency, &date, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan balance: %w", err)
+		}
+		if current == nil || current.accountID != id {
+			current = &balanceSeries{accountID: id, name: name, currency: currency}
+			series = append(series, current)
+		}
+		current.dates = append(current.dates, date)
+		current.amounts = append(current.amounts, amount)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read balances: %w", err)
+	}
+
+	return series, nil
+}
+
+// findBalanceDrops flags balance changes since the given date that fall well below the
+// account's trailing changes and take a large share of the balance
+func findBalanceDrops(series *balanceSeries, since time.Time) []anomalyFinding {
+	var findings []anomalyFinding
+	changes := make([]float64, 0, len(series.amounts))
+	for i := 1; i < len(series.amounts); i++ {
+		previous := series.amounts[i-1]
+		change := series.amounts[i] - previous
+		history := changes
+		if len(history) > balanceHistoryChanges {
+			history = history[len(history)-balanceHistoryChanges:]
+		}
+		changes = append(changes, change)
+
+		if series.dates[i].Before(since) || change >= 0 || previous <= 0 {
+			continue
+		}
+		if len(history) < minBalanceHistoryChanges || -change < previous*balanceDropMinFraction {
+			continue
+		}
+		mean, stdDev := meanStdDev(history)
+		if stdDev > 0 && (change-mean)/stdDev > -balanceDropZScore {
+			continue
+		}
+
+		accountID := series.accountID
+		findings = append(findings, anomalyFinding{
+			AccountID:   &accountID,
+			Kind:        AnomalyBalanceDrop,
+			Fingerprint: fmt.Sprintf("%s:%s:%s", AnomalyBalanceDrop, series.accountID, series.dates[i].Format("2006-01-02")),
+			Title:       fmt.Sprintf("%s dropped %.0f%%", series.name, -change/previous*100),
+			Description: fmt.Sprintf("The balance fell from %.2f to %.2f %s on %s, unlike its recent changes.",
+				previous, series.amounts[i], series.currency, series.dates[i].Format("2006-01-02")),
+			Amount:     change,
+			Baseline:   floatPtr(roundCents(mean)),
+			ObservedOn: series.dates[i],
+		})
+	}
+	return findings
+}
+
+// findDuplicateCharges flags money-out transactions since the given date that match an
+// earlier one on the same account by amount and description within a few days
+func findDuplicateCharges(transactions []transaction.Transaction, since time.Time) []anomalyFinding {
+	charges := make([]transaction.Transaction, 0, len(transactions))
+	for _, t := range transactions {
+		if t.Amount < 0 && t.TransferID == nil {
+			charges = append(charges, t)
+		}
+	}
+	sort.SliceStable(charges, func(i, j int) bool { return charges[i].Date.Before(charges[j].Date) })
+
+	key := func(t transaction.Transaction) string {
+		account := ""
+		if t.AccountID != nil {
+			account = *t.AccountID
+		}
+		return fmt.Sprintf("%s|%s|%.2f|%s", account, t.Currency, t.Amount, strings.ToLower(strings.TrimSpace(t.Description)))
+	}
+
+	var findings []anomalyFinding
+	window := time.Duration(duplicateWindowDays) * 24 * time.Hour
+	for i, later := range charges {
+		if later.Date.Before(since) {
+			continue
+		}
+		for j := i - 1; j >= 0; j-- {
+			earlier := charges[j]
+			if later.Date.Sub(earlier.Date) > window {
+				break
+			}
+			if key(earlier) != key(later) {
+				continue
+			}
+			findings = append(findings, anomalyFinding{
+				AccountID:   later.AccountID,
+				Kind:        AnomalyDuplicateCharge,
+				Fingerprint: fmt.Sprintf("%s:%s:%s", AnomalyDuplicateCharge, earlier.ID, later.ID),
+				Title:       fmt.Sprintf("Possible duplicate charge: %s", later.Description),
+				Description: fmt.Sprintf("%.2f %s was charged on %s and again on %s.",
+					-later.Amount, later.Currency, earlier.Date.Format("2006-01-02"), later.Date.Format("2006-01-02")),
+				Amount:     later.Amount,
+				ObservedOn: later.Date,
+				Details:    map[string][]string{"transaction_ids": {earlier.ID, later.ID}},
+			})
+			break
+		}
+	}
+	return findings
+}
+
+// findSpendingSpikes flags categories whose spending this month is well above their
+// average over the previous months
+func findSpendingSpikes(lines []transaction.CategoryLine, now time.Time) []anomalyFinding {
+	type key struct{ category, currency string }
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	monthIndex := func(date time.Time) int {
+		return (now.Year()-date.Year())*12 + int(now.Month()-date.Month())
+	}
+
+	// Spending per category by months ago, 0 being this month
+	spending := make(map[key][]float64)
+	for _, line := range lines {
+		if line.Amount >= 0 {
+			continue
+		}
+		months := monthIndex(line.Date)
+		if months < 0 || months > spendingHistoryMonths {
+			continue
+		}
+		k := key{line.Category, line.Currency}
+		if spending[k] == nil {
+			spending[k] = make([]float64, spendingHistoryMonths+1)
+		}
+		spending[k][months] += -line.Amount
+	}
+
+	var findings []anomalyFinding
+	for k, months := range spending {
+		current := months[0]
+		mean, stdDev := meanStdDev(months[1:])
+		if mean <= 0 || current < mean*spendingSpikeMinRatio || current-mean < spendingSpikeMinAmount {
+			continue
+		}
+		if stdDev > 0 && (current-mean)/stdDev < spendingSpikeZScore {
+			continue
+		}
+
+		findings = append(findings, anomalyFinding{
+			Kind:        AnomalySpendingSpike,
+			Fingerprint: fmt.Sprintf("%s:%s:%s:%s", AnomalySpendingSpike, k.category, k.currency, monthStart.Format("2006-01")),
+			Title:       fmt.Sprintf("Spending on %s is %.1fx the usual", k.category, current/mean),
+			Description: fmt.Sprintf("%.2f %s spent on %s this month against a %d-month average of %.2f.",
+				current, k.currency, k.category, spendingHistoryMonths, mean),
+			Amount:     current,
+			Baseline:   floatPtr(roundCents(mean)),
+			ObservedOn: monthStart,
+		})
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Fingerprint < findings[j].Fingerprint })
+	return findings
+}
+
+// meanStdDev returns the mean and population standard deviation of values
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+// floatPtr returns a pointer to v
+func floatPtr(v float64) *float64 {
+	return &v
+}
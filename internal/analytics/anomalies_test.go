@@ -0,0 +1,88 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"money/internal/transaction"
+)
+
+func TestFindBalanceDrops_FlagsDropAgainstTrailingChanges(t *testing.T) {
+	// Arrange
+	series := &balanceSeries{accountID: "acc-1", name: "Chequing", currency: "CAD"}
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	amount := 10000.0
+	for i := 0; i < 8; i++ {
+		series.dates = append(series.dates, start.AddDate(0, 0, i*7))
+		series.amounts = append(series.amounts, amount)
+		amount += 100
+	}
+	dropDate := start.AddDate(0, 0, 8*7)
+	series.dates = append(series.dates, dropDate)
+	series.amounts = append(series.amounts, amount-6000)
+
+	// Act
+	findings := findBalanceDrops(series, start.AddDate(0, 0, 30))
+
+	// Assert
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 balance drop, got %d", len(findings))
+	}
+	if findings[0].Kind != AnomalyBalanceDrop || !findings[0].ObservedOn.Equal(dropDate) {
+		t.Errorf("Expected a balance drop on %s, got %+v", dropDate, findings[0])
+	}
+	if findings[0].Amount != -5900 {
+		t.Errorf("Expected a change of -5900, got %.2f", findings[0].Amount)
+	}
+}
+
+func TestFindDuplicateCharges_MatchesWithinWindow(t *testing.T) {
+	// Arrange
+	accountID := "acc-1"
+	day := time.Date(2025, time.March, 10, 0, 0, 0, 0, time.UTC)
+	transactions := []transaction.Transaction{
+		{ID: "t1", AccountID: &accountID, Date: day, Description: "Streaming Plus", Amount: -15.99, Currency: "CAD"},
+		{ID: "t2", AccountID: &accountID, Date: day.AddDate(0, 0, 1), Description: "streaming plus ", Amount: -15.99, Currency: "CAD"},
+		{ID: "t3", AccountID: &accountID, Date: day.AddDate(0, 0, 30), Description: "Streaming Plus", Amount: -15.99, Currency: "CAD"},
+		{ID: "t4", AccountID: &accountID, Date: day.AddDate(0, 0, 1), Description: "Payroll", Amount: 2500, Currency: "CAD"},
+	}
+
+	// Act
+	findings := findDuplicateCharges(transactions, day)
+
+	// Assert
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 duplicate charge, got %d", len(findings))
+	}
+	if findings[0].Fingerprint != "duplicate_charge:t1:t2" {
+		t.Errorf("Expected t1 and t2 to be paired, got %s", findings[0].Fingerprint)
+	}
+}
+
+func TestFindSpendingSpikes_ComparesAgainstTrailingAverage(t *testing.T) {
+	// Arrange
+	now := time.Date(2025, time.July, 20, 0, 0, 0, 0, time.UTC)
+	var lines []transaction.CategoryLine
+	for months := 1; months <= spendingHistoryMonths; months++ {
+		date := time.Date(2025, time.July-time.Month(months), 5, 0, 0, 0, 0, time.UTC)
+		lines = append(lines,
+			transaction.CategoryLine{Date: date, Category: "dining", Amount: -200 - float64(months), Currency: "CAD"},
+			transaction.CategoryLine{Date: date, Category: "groceries", Amount: -600, Currency: "CAD"},
+		)
+	}
+	lines = append(lines,
+		transaction.CategoryLine{Date: now, Category: "dining", Amount: -900, Currency: "CAD"},
+		transaction.CategoryLine{Date: now, Category: "groceries", Amount: -640, Currency: "CAD"},
+	)
+
+	// Act
+	findings := findSpendingSpikes(lines, now)
+
+	// Assert
+	if len(findings) != 1 {
+		t.Fatalf("Expected only dining to spike, got %+v", findings)
+	}
+	if findings[0].Amount != 900 || findings[0].Baseline == nil || *findings[0].Baseline != 203.5 {
+		t.Errorf("Expected 900 against a 203.50 average, got %+v", findings[0])
+	}
+}
@@ -0,0 +1,180 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/transaction"
+)
+
+// kpiWindows are the trailing windows, in months, that KPIs are reported over
+var kpiWindows = []int{1, 3, 12}
+
+// liquidAccountTypes are the account types whose balances count toward the cash runway
+var liquidAccountTypes = map[string]bool{
+	"checking": true,
+	"savings":  true,
+	"cash":     true,
+}
+
+// KPIResponse is the dashboard header KPIs over each trailing window
+type KPIResponse struct {
+	AsOf         time.Time   `json:"as_of"`
+	LiquidAssets float64     `json:"liquid_assets"` // Checking, savings and cash balances today
+	Windows      []KPIWindow `json:"windows"`
+}
+
+// KPIWindow is the cash flow KPIs over the trailing months. Ratios are fractions of income
+// and are omitted when there was no income in the window.
+type KPIWindow struct {
+	Months                  int       `json:"months"`
+	From                    time.Time `json:"from"`
+	To                      time.Time `json:"to"`
+	Income                  float64   `json:"income"`                   // Money in, excluding transfers and investment accounts
+	Expenses                float64   `json:"expenses"`                 // Money out, excluding transfers and investment accounts
+	Savings                 float64   `json:"savings"`                  // Income - Expenses
+	InvestmentContributions float64   `json:"investment_contributions"` // Net money moved into investment accounts
+	DebtPayments            float64   `json:"debt_payments"`            // Mortgage, loan and HELOC repayments
+	MonthlyIncome           float64   `json:"monthly_income"`
+	MonthlyExpenses         float64   `json:"monthly_expenses"`
+	SavingsRate             *float64  `json:"savings_rate,omitempty"`    // Savings / Income
+	ExpenseRatio            *float64  `json:"expense_ratio,omitempty"`   // Expenses / Income
+	DebtToIncome            *float64  `json:"debt_to_income,omitempty"`  // DebtPayments / Income
+	InvestmentRate          *float64  `json:"investment_rate,omitempty"` // InvestmentContributions / Income
+	RunwayMonths            *float64  `json:"runway_months,omitempty"`   // LiquidAssets / MonthlyExpenses; omitted without expenses
+}
+
+// kpiFlows are the actual amounts recorded in a window
+type kpiFlows struct {
+	income, expenses, investment, debtPayments float64
+}
+
+// GetKPIs computes savings rate, expense ratio, debt-to-income, investment rate and liquid
+// runway from actual transactions and payments over trailing 1, 3 and 12 month windows
+func (s *Service) GetKPIs(ctx context.Context) (*KPIResponse, error) {
+	return s.getKPIs(ctx, time.Now())
+}
+
+func (s *Service) getKPIs(ctx context.Context, now time.Time) (*KPIResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	longest := kpiWindows[len(kpiWindows)-1]
+	earliest := now.AddDate(0, -longest, 0)
+
+	accounts, err := s.getAccountBalances(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	accountTypes := make(map[string]string, len(accounts))
+	resp := &KPIResponse{AsOf: now, Windows: make([]KPIWindow, 0, len(kpiWindows))}
+	for _, acc := range accounts {
+		accountTypes[acc.id] = acc.accountType
+		if liquidAccountTypes[acc.accountType] {
+			resp.LiquidAssets += acc.balanceAsOf(now)
+		}
+	}
+	resp.LiquidAssets = roundCents(resp.LiquidAssets)
+
+	transactions, err := s.transactionSvc.ListTransactions(ctx, transaction.ListTransactionsFilter{From: &earliest, To: &now})
+	if err != nil {
+		return nil, err
+	}
+	transactionAccounts := make(map[string]string, len(transactions.Transactions))
+	for _, t := range transactions.Transactions {
+		if t.AccountID != nil {
+			transactionAccounts[t.ID] = *t.AccountID
+		}
+	}
+	lines, err := s.transactionSvc.GetCategoryLines(ctx, &earliest, &now)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, months := range kpiWindows {
+		from := now.AddDate(0, -months, 0)
+		var flows kpiFlows
+
+		for _, line := range lines {
+			if line.Date.Before(from) || investmentAccountTypes[accountTypes[transactionAccounts[line.TransactionID]]] {
+				continue
+			}
+			if line.Amount > 0 {
+				flows.income += line.Amount
+			} else {
+				flows.expenses -= line.Amount
+			}
+		}
+		// Contributions include transfers in, which category lines leave out
+		for _, t := range transactions.Transactions {
+			if t.AccountID != nil && !t.Date.Before(from) && investmentAccountTypes[accountTypes[*t.AccountID]] {
+				flows.investment += t.Amount
+			}
+		}
+		flows.debtPayments, err = s.getDebtPayments(ctx, userID, from, now)
+		if err != nil {
+			return nil, err
+		}
+
+		window := newKPIWindow(months, flows, resp.LiquidAssets)
+		window.From = from
+		window.To = now
+		resp.Windows = append(resp.Windows, window)
+	}
+
+	return resp, nil
+}
+
+// getDebtPayments totals scheduled and extra payments on mortgages and loans and HELOC
+// repayments in the period
+func (s *Service) getDebtPayments(ctx context.Context, userID string, from, to time.Time) (float64, error) {
+	queries := []string{
+		`SELECT p.payment_date, p.payment_amount + COALESCE(p.extra_payment, 0) FROM mortgage_payments p JOIN accounts a ON a.id = p.account_id WHERE a.user_id = $1`,
+		`SELECT p.payment_date, p.payment_amount + COALESCE(p.extra_payment, 0) FROM loan_payments p JOIN accounts a ON a.id = p.account_id WHERE a.user_id = $1`,
+		`SELECT t.transaction_date, t.amount FROM heloc_transactions t JOIN accounts a ON a.id = t.account_id WHERE a.user_id = $1 AND t.type = 'repayment'`,
+	}
+	total := 0.0
+	for _, query := range queries {
+		amount, err := s.sumInPeriod(ctx, query, userID, from, to)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get debt payments: %w", err)
+		}
+		total += amount
+	}
+	return roundCents(total), nil
+}
+
+// newKPIWindow derives the window's monthly averages and ratios from its flows
+func newKPIWindow(months int, flows kpiFlows, liquidAssets float64) KPIWindow {
+	w := KPIWindow{
+		Months:                  months,
+		Income:                  roundCents(flows.income),
+		Expenses:                roundCents(flows.expenses),
+		Savings:                 roundCents(flows.income - flows.expenses),
+		InvestmentContributions: roundCents(flows.investment),
+		DebtPayments:            roundCents(flows.debtPayments),
+		MonthlyIncome:           roundCents(flows.income / float64(months)),
+		MonthlyExpenses:         roundCents(flows.expenses / float64(months)),
+	}
+
+	if w.Income > 0 {
+		w.SavingsRate = ratio(w.Savings, w.Income)
+		w.ExpenseRatio = ratio(w.Expenses, w.Income)
+		w.DebtToIncome = ratio(w.DebtPayments, w.Income)
+		w.InvestmentRate = ratio(w.InvestmentContributions, w.Income)
+	}
+	if w.MonthlyExpenses > 0 {
+		w.RunwayMonths = ratio(liquidAssets, w.MonthlyExpenses)
+	}
+	return w
+}
+
+// ratio returns numerator / denominator rounded to four decimal places
+func ratio(numerator, denominator float64) *float64 {
+	r := roundCents(numerator/denominator*100) / 100
+	return &r
+}
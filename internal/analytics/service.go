@@ -0,0 +1,375 @@
+// Package analytics compiles cross-cutting reports from accounts, income, and transactions.
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/income"
+	"money/internal/inflation"
+	"money/internal/transaction"
+)
+
+// Service provides analytics functionality
+type Service struct {
+	db             *sql.DB
+	incomeSvc      *income.Service
+	transactionSvc *transaction.Service
+	inflationSvc   *inflation.Service
+}
+
+// NewService creates a new analytics service
+func NewService(db *sql.DB, incomeSvc *income.Service, transactionSvc *transaction.Service, inflationSvc *inflation.Service) *Service {
+	return &Service{
+		db:             db,
+		incomeSvc:      incomeSvc,
+		transactionSvc: transactionSvc,
+		inflationSvc:   inflationSvc,
+	}
+}
+
+// topExpenseCategoryCount is how many expense categories the year review lists
+const topExpenseCategoryCount = 5
+
+// investmentAccountTypes are the account types whose growth counts as investment return
+var investmentAccountTypes = map[string]bool{
+	"brokerage": true,
+	"tfsa":      true,
+	"rrsp":      true,
+	"crypto":    true,
+}
+
+// YearReview gathers everything needed for an annual review page
+type YearReview struct {
+	Year                 int                `json:"year"`
+	From                 time.Time          `json:"from"`
+	To                   time.Time          `json:"to"` // End of year, or today for the current year
+	Income               IncomeReview       `json:"income"`
+	Spending             float64            `json:"spending"`               // Total money out across categorized transactions
+	SavingsRate          *float64           `json:"savings_rate,omitempty"` // (net income - spending) / net income
+	NetWorth             NetWorthReview     `json:"net_worth"`
+	Investments          InvestmentReview   `json:"investments"`
+	DebtInterest         DebtInterestReview `json:"debt_interest"`
+	TopExpenseCategories []ExpenseCategory  `json:"top_expense_categories"`
+	EquityCompensation   EquityReview       `json:"equity_compensation"`
+}
+
+// IncomeReview summarizes the year's income and tax
+type IncomeReview struct {
+	GrossIncome      float64 `json:"gross_income"`
+	TotalTax         float64 `json:"total_tax"`
+	NetIncome        float64 `json:"net_income"`
+	EffectiveTaxRate float64 `json:"effective_tax_rate"`
+}
+
+// NetWorthReview compares net worth at the start and end of the year
+type NetWorthReview struct {
+	Start         float64  `json:"start"`
+	End           float64  `json:"end"`
+	Change        float64  `json:"change"`
+	ChangePercent *float64 `json:"change_percent,omitempty"`
+}
+
+// InvestmentReview is the growth of investment accounts net of contributions
+type InvestmentReview struct {
+	StartValue       float64  `json:"start_value"`
+	EndValue         float64  `json:"end_value"`
+	NetContributions float64  `json:"net_contributions"` // Money moved in less money moved out
+	Gain             float64  `json:"gain"`              // EndValue - StartValue - NetContributions
+	ReturnPercent    *float64 `json:"return_percent,omitempty"`
+}
+
+// DebtInterestReview is the interest paid on debt during the year
+type DebtInterestReview struct {
+	Mortgage float64 `json:"mortgage"`
+	Loan     float64 `json:"loan"`
+	HELOC    float64 `json:"heloc"`
+	Total    float64 `json:"total"`
+}
+
+// ExpenseCategory is money spent in a category, as a positive amount
+type ExpenseCategory struct {
+	Category string  `json:"category"`
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+	Count    int     `json:"count"`
+}
+
+// EquityReview is equity compensation realized during the year
+type EquityReview struct {
+	ExerciseBenefit float64 `json:"exercise_benefit"` // Taxable benefit on options exercised
+	SaleProceeds    float64 `json:"sale_proceeds"`
+	CapitalGains    float64 `json:"capital_gains"`
+	SharesSold      int     `json:"shares_sold"`
+}
+
+// GetYearReview compiles income, savings, net worth, investment, debt, spending, and equity
+// figures for a calendar year
+func (s *Service) GetYearReview(ctx context.Context, year int) (*YearReview, error) {
+	return s.getYearReview(ctx, year, time.Now())
+}
+
+// getYearReview builds the review as of now, capping the current year at today
+func (s *Service) getYearReview(ctx context.Context, year int, now time.Time) (*YearReview, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	from := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0).Add(-time.Nanosecond)
+	if from.After(now) {
+		return nil, fmt.Errorf("year %d has not started", year)
+	}
+	if to.After(now) {
+		to = now
+	}
+
+	review := &YearReview{Year: year, From: from, To: to}
+
+	summary, err := s.incomeSvc.GetAnnualSummary(ctx, year)
+	if err != nil {
+		return nil, err
+	}
+	review.Income = IncomeReview{
+		GrossIncome:      roundCents(summary.TotalGrossIncome + summary.StockOptionsBenefit),
+		TotalTax:         roundCents(summary.TotalTax),
+		NetIncome:        roundCents(summary.NetIncome),
+		EffectiveTaxRate: summary.EffectiveTaxRate,
+	}
+
+	spending, err := s.transactionSvc.GetCategorySpending(ctx, &from, &to)
+	if err != nil {
+		return nil, err
+	}
+	review.TopExpenseCategories = topExpenseCategories(spending.Categories, topExpenseCategoryCount)
+	for _, c := range spending.Categories {
+		if c.Amount < 0 {
+			review.Spending -= c.Amount
+		}
+	}
+	review.Spending = roundCents(review.Spending)
+	if review.Income.NetIncome > 0 {
+		rate := (review.Income.NetIncome - review.Spending) / review.Income.NetIncome
+		review.SavingsRate = &rate
+	}
+
+	accounts, err := s.getAccountBalances(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// Net worth at the start of the year is the balance recorded before January 1st
+	start := from.Add(-time.Nanosecond)
+	investmentIDs := make(map[string]bool)
+	for _, acc := range accounts {
+		startBalance := acc.balanceAsOf(start)
+		endBalance := acc.balanceAsOf(to)
+		review.NetWorth.Start += startBalance
+		review.NetWorth.End += endBalance
+		if investmentAccountTypes[acc.accountType] {
+			investmentIDs[acc.id] = true
+			review.Investments.StartValue += startBalance
+			review.Investments.EndValue += endBalance
+		}
+	}
+	review.NetWorth.Start = roundCents(review.NetWorth.Start)
+	review.NetWorth.End = roundCents(review.NetWorth.End)
+	review.NetWorth.Change = roundCents(review.NetWorth.End - review.NetWorth.Start)
+	if review.NetWorth.Start != 0 {
+		pct := review.NetWorth.Change / math.Abs(review.NetWorth.Start) * 100
+		review.NetWorth.ChangePercent = &pct
+	}
+
+	transactions, err := s.transactionSvc.ListTransactions(ctx, transaction.ListTransactionsFilter{From: &from, To: &to})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range transactions.Transactions {
+		if t.AccountID != nil && investmentIDs[*t.AccountID] {
+			review.Investments.NetContributions += t.Amount
+		}
+	}
+	inv := &review.Investments
+	inv.StartValue = roundCents(inv.StartValue)
+	inv.EndValue = roundCents(inv.EndValue)
+	inv.NetContributions = roundCents(inv.NetContributions)
+	inv.Gain = roundCents(inv.EndValue - inv.StartValue - inv.NetContributions)
+	inv.ReturnPercent = modifiedDietzReturn(inv.StartValue, inv.NetContributions, inv.Gain)
+
+	review.DebtInterest, err = s.getDebtInterest(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	review.EquityCompensation, err = s.getEquityRealized(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// accountHistory is an account's balance history, oldest first
+type accountHistory struct {
+	id          string
+	accountType string
+	dates       []time.Time
+	amounts     []float64
+}
+
+// balanceAsOf returns the latest balance on or before date, or zero before the first balance
+func (a *accountHistory) balanceAsOf(date time.Time) float64 {
+	balance := 0.0
+	for i, d := range a.dates {
+		if d.After(date) {
+			break
+		}
+		balance = a.amounts[i]
+	}
+	return balance
+}
+
+// getAccountBalances loads balance history for the user's accounts counted in net worth. Liability
+// balances are stored as negative amounts, so they can be summed directly into net worth.
+func (s *Service) getAccountBalances(ctx context.Context, userID string) ([]*accountHistory, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.id, a.type, b.date, b.amount
+		FROM accounts a
+		JOIN balances b ON b.account_id = a.id
+		WHERE a.user_id = $1 AND a.exclude_from_net_worth = 0
+		ORDER BY a.id, b.date
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []*accountHistory
+	var current *accountHistory
+	for rows.Next() {
+		var id, accountType string
+		var date time.Time
+		var amount float64
+		if err := rows.Scan(&id, &accountType, &date, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan balance: %w", err)
+		}
+		if current == nil || current.id != id {
+			current = &accountHistory{id: id, accountType: accountType}
+			accounts = append(accounts, current)
+		}
+		current.dates = append(current.dates, date)
+		current.amounts = append(current.amounts, amount)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read balances: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// getDebtInterest totals interest recorded on mortgage, loan, and HELOC accounts in the period
+func (s *Service) getDebtInterest(ctx context.Context, userID string, from, to time.Time) (DebtInterestReview, error) {
+	var review DebtInterestReview
+
+	sources := []struct {
+		query  string
+		target *float64
+	}{
+		{`SELECT COALESCE(SUM(p.interest_amount), 0) FROM mortgage_payments p JOIN accounts a ON a.id = p.account_id WHERE a.user_id = $1 AND p.payment_date >= $2 AND p.payment_date <= $3`, &review.Mortgage},
+		{`SELECT COALESCE(SUM(p.interest_amount), 0) FROM loan_payments p JOIN accounts a ON a.id = p.account_id WHERE a.user_id = $1 AND p.payment_date >= $2 AND p.payment_date <= $3`, &review.Loan},
+		{`SELECT COALESCE(SUM(t.amount), 0) FROM heloc_transactions t JOIN accounts a ON a.id = t.account_id WHERE a.user_id = $1 AND t.type = 'interest' AND t.transaction_date >= $2 AND t.transaction_date <= $3`, &review.HELOC},
+	}
+	for _, source := range sources {
+		total, err := s.sumInPeriod(ctx, source.query, userID, from, to)
+		if err != nil {
+			return review, fmt.Errorf("failed to get interest paid: %w", err)
+		}
+		*source.target = total
+	}
+	review.Total = roundCents(review.Mortgage + review.Loan + review.HELOC)
+
+	return review, nil
+}
+
+// getEquityRealized totals option exercise benefits and share sales in the period
+func (s *Service) getEquityRealized(ctx context.Context, userID string, from, to time.Time) (EquityReview, error) {
+	var review EquityReview
+
+	benefit, err := s.sumInPeriod(ctx, `
+		SELECT COALESCE(SUM(e.taxable_benefit), 0)
+		FROM equity_exercises e
+		JOIN equity_grants g ON g.id = e.grant_id
+		JOIN accounts a ON a.id = g.account_id
+		WHERE a.user_id = $1 AND e.exercise_date >= $2 AND e.exercise_date <= $3
+	`, userID, from, to)
+	if err != nil {
+		return review, fmt.Errorf("failed to get exercises: %w", err)
+	}
+	review.ExerciseBenefit = benefit
+
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(s.quantity), 0), COALESCE(SUM(s.total_proceeds), 0), COALESCE(SUM(s.capital_gain), 0)
+		FROM equity_sales s
+		JOIN accounts a ON a.id = s.account_id
+		WHERE a.user_id = $1 AND s.sale_date >= $2 AND s.sale_date <= $3
+	`, userID, from, to).Scan(&review.SharesSold, &review.SaleProceeds, &review.CapitalGains)
+	if err != nil {
+		return review, fmt.Errorf("failed to get equity sales: %w", err)
+	}
+	review.SaleProceeds = roundCents(review.SaleProceeds)
+	review.CapitalGains = roundCents(review.CapitalGains)
+
+	return review, nil
+}
+
+// sumInPeriod runs a query summing amounts for the user ($1) dated from $2 through $3
+func (s *Service) sumInPeriod(ctx context.Context, query, userID string, from, to time.Time) (float64, error) {
+	var total float64
+	if err := s.db.QueryRowContext(ctx, query, userID, from, to).Scan(&total); err != nil {
+		return 0, err
+	}
+	return roundCents(total), nil
+}
+
+// topExpenseCategories returns the categories with the most money out, largest first
+func topExpenseCategories(categories []transaction.CategoryTotal, limit int) []ExpenseCategory {
+	expenses := make([]ExpenseCategory, 0)
+	for _, c := range categories {
+		if c.Amount >= 0 {
+			continue
+		}
+		expenses = append(expenses, ExpenseCategory{
+			Category: c.Category,
+			Currency: c.Currency,
+			Amount:   -c.Amount,
+			Count:    c.Count,
+		})
+	}
+	sort.SliceStable(expenses, func(i, j int) bool {
+		return expenses[i].Amount > expenses[j].Amount
+	})
+	if len(expenses) > limit {
+		expenses = expenses[:limit]
+	}
+	return expenses
+}
+
+// modifiedDietzReturn approximates the period return assuming contributions arrived mid-period
+func modifiedDietzReturn(startValue, netContributions, gain float64) *float64 {
+	invested := startValue + netContributions/2
+	if invested <= 0 {
+		return nil
+	}
+	pct := gain / invested * 100
+	return &pct
+}
+
+// roundCents rounds an amount to two decimal places
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
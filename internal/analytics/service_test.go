@@ -0,0 +1,89 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+
+	"money/internal/transaction"
+)
+
+func TestTopExpenseCategories_LargestSpendFirst(t *testing.T) {
+	// Arrange
+	categories := []transaction.CategoryTotal{
+		{Category: "groceries", Currency: "CAD", Amount: -4200, Count: 52},
+		{Category: "salary", Currency: "CAD", Amount: 90000, Count: 24},
+		{Category: "rent", Currency: "CAD", Amount: -24000, Count: 12},
+		{Category: "travel", Currency: "CAD", Amount: -3100, Count: 4},
+	}
+
+	// Act
+	top := topExpenseCategories(categories, 2)
+
+	// Assert
+	if len(top) != 2 {
+		t.Fatalf("Expected 2 categories, got %d", len(top))
+	}
+	if top[0].Category != "rent" || top[0].Amount != 24000 || top[1].Category != "groceries" {
+		t.Errorf("Expected rent then groceries as positive amounts, got %+v", top)
+	}
+}
+
+func TestModifiedDietzReturn_CountsHalfOfContributions(t *testing.T) {
+	// 100k start, 20k contributed, 11k gain: 11000 / (100000 + 10000) = 10%
+	pct := modifiedDietzReturn(100000, 20000, 11000)
+	if pct == nil || math.Abs(*pct-10) > 1e-9 {
+		t.Errorf("Expected 10%% return, got %v", pct)
+	}
+
+	if modifiedDietzReturn(0, 0, 0) != nil {
+		t.Error("Expected no return without invested capital")
+	}
+}
+
+func TestNewKPIWindow_RatiosAndRunway(t *testing.T) {
+	// Arrange
+	flows := kpiFlows{income: 30000, expenses: 21000, investment: 4500, debtPayments: 6000}
+
+	// Act
+	w := newKPIWindow(3, flows, 35000)
+
+	// Assert
+	if w.Savings != 9000 || w.MonthlyExpenses != 7000 {
+		t.Errorf("Expected savings 9000 and monthly expenses 7000, got %+v", w)
+	}
+	if w.SavingsRate == nil || *w.SavingsRate != 0.3 || w.ExpenseRatio == nil || *w.ExpenseRatio != 0.7 {
+		t.Errorf("Expected savings rate 0.3 and expense ratio 0.7, got %v and %v", w.SavingsRate, w.ExpenseRatio)
+	}
+	if w.DebtToIncome == nil || *w.DebtToIncome != 0.2 || w.InvestmentRate == nil || *w.InvestmentRate != 0.15 {
+		t.Errorf("Expected debt-to-income 0.2 and investment rate 0.15, got %v and %v", w.DebtToIncome, w.InvestmentRate)
+	}
+	if w.RunwayMonths == nil || *w.RunwayMonths != 5 {
+		t.Errorf("Expected 5 months of runway, got %v", w.RunwayMonths)
+	}
+
+	empty := newKPIWindow(1, kpiFlows{}, 1000)
+	if empty.SavingsRate != nil || empty.RunwayMonths != nil {
+		t.Errorf("Expected no ratios without income or expenses, got %+v", empty)
+	}
+}
+
+func TestSpendingGrowthYears_DeflatesByInflation(t *testing.T) {
+	// Arrange
+	spending := []float64{40000, 42000, 42000}
+	rates := map[int]float64{2023: 0.05}
+
+	// Act
+	years := spendingGrowthYears(2022, spending, rates)
+
+	// Assert
+	if len(years) != 3 || years[0].NominalGrowth != nil {
+		t.Fatalf("Expected three years with no growth for the first, got %+v", years)
+	}
+	// 5% more spending in a year of 5% inflation is flat in real terms
+	if g := years[1]; g.NominalGrowth == nil || *g.NominalGrowth != 0.05 || g.RealGrowth == nil || *g.RealGrowth != 0 {
+		t.Errorf("Expected 5%% nominal and 0%% real growth in 2023, got %+v", g)
+	}
+	if g := years[2]; g.NominalGrowth == nil || *g.NominalGrowth != 0 || g.RealGrowth != nil {
+		t.Errorf("Expected flat nominal growth and no real growth without 2024 inflation, got %+v", g)
+	}
+}
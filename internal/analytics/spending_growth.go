@@ -0,0 +1,91 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/inflation"
+)
+
+// defaultSpendingGrowthYears is how many complete years the spending growth report covers
+const defaultSpendingGrowthYears = 5
+
+// SpendingGrowthResponse compares each year's spending growth with CPI inflation
+type SpendingGrowthResponse struct {
+	Region inflation.Region     `json:"region"`
+	Years  []SpendingGrowthYear `json:"years"` // Oldest first
+}
+
+// SpendingGrowthYear is one calendar year's spending against the year before. Growth rates are
+// omitted for the first year, and the real rate also when that year's inflation isn't known.
+type SpendingGrowthYear struct {
+	Year          int      `json:"year"`
+	Spending      float64  `json:"spending"`                 // Total money out across categorized transactions
+	NominalGrowth *float64 `json:"nominal_growth,omitempty"` // Change in spending from the year before
+	Inflation     *float64 `json:"inflation,omitempty"`      // CPI inflation over the year
+	RealGrowth    *float64 `json:"real_growth,omitempty"`    // Nominal growth with inflation taken out
+}
+
+// GetSpendingGrowth reports nominal and real spending growth over the last complete years,
+// deflated by the region's CPI
+func (s *Service) GetSpendingGrowth(ctx context.Context, region inflation.Region, years int) (*SpendingGrowthResponse, error) {
+	if auth.GetUserID(ctx) == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if years <= 0 {
+		years = defaultSpendingGrowthYears
+	}
+
+	rates, err := s.inflationSvc.AnnualInflation(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	// One extra year so the oldest reported year has a growth rate
+	lastYear := time.Now().Year() - 1
+	spending := make([]float64, 0, years+1)
+	for year := lastYear - years; year <= lastYear; year++ {
+		from := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := from.AddDate(1, 0, 0).Add(-time.Nanosecond)
+		resp, err := s.transactionSvc.GetCategorySpending(ctx, &from, &to)
+		if err != nil {
+			return nil, err
+		}
+		total := 0.0
+		for _, c := range resp.Categories {
+			if c.Amount < 0 {
+				total -= c.Amount
+			}
+		}
+		spending = append(spending, roundCents(total))
+	}
+
+	return &SpendingGrowthResponse{
+		Region: region,
+		Years:  spendingGrowthYears(lastYear-years, spending, rates)[1:],
+	}, nil
+}
+
+// spendingGrowthYears pairs consecutive years of spending, starting at firstYear, with that
+// year's inflation. Real growth is (1 + nominal) / (1 + inflation) - 1.
+func spendingGrowthYears(firstYear int, spending []float64, rates map[int]float64) []SpendingGrowthYear {
+	result := make([]SpendingGrowthYear, 0, len(spending))
+	for i, amount := range spending {
+		year := SpendingGrowthYear{Year: firstYear + i, Spending: amount}
+		if rate, ok := rates[year.Year]; ok {
+			year.Inflation = &rate
+		}
+		if i > 0 && spending[i-1] > 0 {
+			nominal := roundCents((amount/spending[i-1]-1)*100) / 100
+			year.NominalGrowth = &nominal
+			if year.Inflation != nil {
+				realGrowth := roundCents(((1+nominal)/(1+*year.Inflation)-1)*100) / 100
+				year.RealGrowth = &realGrowth
+			}
+		}
+		result = append(result, year)
+	}
+	return result
+}
@@ -0,0 +1,427 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+)
+
+// serviceKeyPrefixLength is how much of a key is kept to identify it in listings
+const serviceKeyPrefixLength = 12
+
+// ServiceKey is an API key that lets automation call this server on a user's behalf
+type ServiceKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	KeyPrefix  string     `json:"key_prefix"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	IsActive   bool       `json:"is_active"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RotatedAt  *time.Time `json:"rotated_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// ServiceKeySecretResponse returns a key with its secret, which is only shown once
+type ServiceKeySecretResponse struct {
+	ServiceKey
+	Key string `json:"key"`
+}
+
+// CreateServiceKeyRequest represents a request to create a service key
+type CreateServiceKeyRequest struct {
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// UpdateServiceKeyRequest represents a request to update a service key's restrictions
+type UpdateServiceKeyRequest struct {
+	Name       *string    `json:"name,omitempty"`
+	AllowedIPs *[]string  `json:"allowed_ips,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// ListServiceKeysResponse represents a list of service keys
+type ListServiceKeysResponse struct {
+	Keys []ServiceKey `json:"keys"`
+}
+
+// generateServiceKey creates a new random key secret
+func generateServiceKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return auth.ServiceKeyPrefix + hex.EncodeToString(b), nil
+}
+
+// hashServiceKey returns the stored hash of a key secret
+func hashServiceKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// validateScopes checks that scopes are known and non-empty
+func validateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if scope != auth.ScopeRead && scope != auth.ScopeWrite && scope != auth.ScopePush {
+			return fmt.Errorf("invalid scope: %s", scope)
+		}
+	}
+	return nil
+}
+
+// validateAllowedIPs checks that each allowlist entry is an IP address or CIDR range
+func validateAllowedIPs(entries []string) error {
+	for _, entry := range entries {
+		if strings.Contains(entry, "/") {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return fmt.Errorf("invalid CIDR range: %s", entry)
+			}
+			continue
+		}
+		if net.ParseIP(entry) == nil {
+			return fmt.Errorf("invalid IP address: %s", entry)
+		}
+	}
+	return nil
+}
+
+// ipAllowed reports whether an address matches the allowlist; an empty list allows any address
+func ipAllowed(allowed []string, ip string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+
+	for _, entry := range allowed {
+		if strings.Contains(entry, "/") {
+			if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(addr) {
+				return true
+			}
+			continue
+		}
+		if allowedAddr := net.ParseIP(entry); allowedAddr != nil && allowedAddr.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateServiceKey creates a service key and returns its secret
+func (s *Service) CreateServiceKey(ctx context.Context, req *CreateServiceKeyRequest) (*ServiceKeySecretResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	if req.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if err := validateScopes(req.Scopes); err != nil {
+		return nil, err
+	}
+	if err := validateAllowedIPs(req.AllowedIPs); err != nil {
+		return nil, err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("expiration must be in the future")
+	}
+
+	key, err := generateServiceKey()
+	if err != nil {
+		return nil, err
+	}
+
+	allowedIPs := req.AllowedIPs
+	if allowedIPs == nil {
+		allowedIPs = []string{}
+	}
+	scopesJSON, _ := json.Marshal(req.Scopes)
+	allowedJSON, _ := json.Marshal(allowedIPs)
+
+	id := uuid.New().String()
+	now := time.Now()
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO service_api_keys (
+			id, user_id, name, key_prefix, key_hash, scopes, allowed_ips, expires_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, id, userID, req.Name, key[:serviceKeyPrefixLength], hashServiceKey(key),
+		string(scopesJSON), string(allowedJSON), req.ExpiresAt, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create service key: %w", err)
+	}
+
+	created, err := s.GetServiceKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ServiceKeySecretResponse{ServiceKey: *created, Key: key}, nil
+}
+
+// scanServiceKey scans a service key row
+func scanServiceKey(row interface{ Scan(...interface{}) error }) (*ServiceKey, error) {
+	var key ServiceKey
+	var scopesJSON, allowedJSON string
+	err := row.Scan(
+		&key.ID, &key.Name, &key.KeyPrefix, &scopesJSON, &allowedJSON, &key.ExpiresAt,
+		&key.IsActive, &key.LastUsedAt, &key.RotatedAt, &key.CreatedAt, &key.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(scopesJSON), &key.Scopes); err != nil {
+		return nil, fmt.Errorf("failed to decode scopes: %w", err)
+	}
+	if err := json.Unmarshal([]byte(allowedJSON), &key.AllowedIPs); err != nil {
+		return nil, fmt.Errorf("failed to decode allowed IPs: %w", err)
+	}
+	return &key, nil
+}
+
+// GetServiceKey returns one of the current user's service keys
+func (s *Service) GetServiceKey(ctx context.Context, id string) (*ServiceKey, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	key, err := scanServiceKey(s.db.QueryRowContext(ctx, `
+		SELECT id, name, key_prefix, scopes, allowed_ips, expires_at, is_active, last_used_at, rotated_at, created_at, updated_at
+		FROM service_api_keys
+		WHERE id = $1 AND user_id = $2
+	`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("service key not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service key: %w", err)
+	}
+
+	return key, nil
+}
+
+// ListServiceKeys lists the current user's service keys
+func (s *Service) ListServiceKeys(ctx context.Context) (*ListServiceKeysResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, key_prefix, scopes, allowed_ips, expires_at, is_active, last_used_at, rotated_at, created_at, updated_at
+		FROM service_api_keys
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]ServiceKey, 0)
+	for rows.Next() {
+		key, err := scanServiceKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan service key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list service keys: %w", err)
+	}
+
+	return &ListServiceKeysResponse{Keys: keys}, nil
+}
+
+// UpdateServiceKey changes a service key's name, IP allowlist or expiration
+func (s *Service) UpdateServiceKey(ctx context.Context, id string, req *UpdateServiceKeyRequest) (*ServiceKey, error) {
+	existing, err := s.GetServiceKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	name := existing.Name
+	if req.Name != nil && *req.Name != "" {
+		name = *req.Name
+	}
+	allowedIPs := existing.AllowedIPs
+	if req.AllowedIPs != nil {
+		if err := validateAllowedIPs(*req.AllowedIPs); err != nil {
+			return nil, err
+		}
+		allowedIPs = *req.AllowedIPs
+		if allowedIPs == nil {
+			allowedIPs = []string{}
+		}
+	}
+	expiresAt := existing.ExpiresAt
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			return nil, fmt.Errorf("expiration must be in the future")
+		}
+		expiresAt = req.ExpiresAt
+	}
+	allowedJSON, _ := json.Marshal(allowedIPs)
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE service_api_keys
+		SET name = $1, allowed_ips = $2, expires_at = $3, updated_at = $4
+		WHERE id = $5
+	`, name, string(allowedJSON), expiresAt, time.Now(), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update service key: %w", err)
+	}
+
+	return s.GetServiceKey(ctx, id)
+}
+
+// RotateServiceKey issues a new secret for a key, keeping its ID, scopes and restrictions.
+// The previous secret stops working immediately.
+func (s *Service) RotateServiceKey(ctx context.Context, id string) (*ServiceKeySecretResponse, error) {
+	if _, err := s.GetServiceKey(ctx, id); err != nil {
+		return nil, err
+	}
+
+	key, err := generateServiceKey()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE service_api_keys
+		SET key_prefix = $1, key_hash = $2, rotated_at = $3, updated_at = $3
+		WHERE id = $4
+	`, key[:serviceKeyPrefixLength], hashServiceKey(key), now, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate service key: %w", err)
+	}
+
+	rotated, err := s.GetServiceKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ServiceKeySecretResponse{ServiceKey: *rotated, Key: key}, nil
+}
+
+// RevokeServiceKey deactivates a service key while keeping its usage history
+func (s *Service) RevokeServiceKey(ctx context.Context, id string) (*ServiceKey, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE service_api_keys SET is_active = 0, updated_at = $1 WHERE id = $2 AND user_id = $3
+	`, time.Now(), id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke service key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("service key not found")
+	}
+
+	return s.GetServiceKey(ctx, id)
+}
+
+// DeleteServiceKey removes a service key
+func (s *Service) DeleteServiceKey(ctx context.Context, id string) (*DeleteResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM service_api_keys WHERE id = $1 AND user_id = $2
+	`, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete service key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("service key not found")
+	}
+
+	return &DeleteResponse{Success: true}, nil
+}
+
+// VerifyAPIKey implements auth.APIKeyVerifier. It checks that the key exists, is active,
+// has not expired and is used from an allowed address.
+func (s *Service) VerifyAPIKey(ctx context.Context, key, clientIP string) (*auth.VerifiedKey, error) {
+	var id, userID, scopesJSON, allowedJSON string
+	var expiresAt sql.NullTime
+	var isActive bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, user_id, scopes, allowed_ips, expires_at, is_active
+		FROM service_api_keys
+		WHERE key_hash = $1
+	`, hashServiceKey(key)).Scan(&id, &userID, &scopesJSON, &allowedJSON, &expiresAt, &isActive)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("unknown API key")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+
+	if !isActive {
+		return nil, fmt.Errorf("API key %s is revoked", id)
+	}
+	if expiresAt.Valid && time.Now().After(expiresAt.Time) {
+		return nil, fmt.Errorf("API key %s expired at %s", id, expiresAt.Time.Format(time.RFC3339))
+	}
+
+	var allowedIPs, scopes []string
+	if err := json.Unmarshal([]byte(allowedJSON), &allowedIPs); err != nil {
+		return nil, fmt.Errorf("failed to decode allowed IPs: %w", err)
+	}
+	if !ipAllowed(allowedIPs, clientIP) {
+		return nil, fmt.Errorf("API key %s used from disallowed address %s", id, clientIP)
+	}
+	if err := json.Unmarshal([]byte(scopesJSON), &scopes); err != nil {
+		return nil, fmt.Errorf("failed to decode scopes: %w", err)
+	}
+
+	// Recorded inline so the write finishes with the request, before shutdown closes the
+	// database. A failure is logged rather than refusing the key.
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE service_api_keys SET last_used_at = $1 WHERE id = $2
+	`, time.Now(), id); err != nil {
+		log.Printf("ERROR: failed to record service key use: id=%s error=%v", id, err)
+	}
+
+	return &auth.VerifiedKey{ID: id, UserID: userID, Scopes: scopes}, nil
+}
@@ -0,0 +1,380 @@
+package apikeys
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+	"money/internal/background"
+)
+
+// DefaultUsageRetention is how long usage entries are kept when no retention is configured
+const DefaultUsageRetention = 90 * 24 * time.Hour
+
+// DefaultUsageWindowDays is the usage window returned when none is requested
+const DefaultUsageWindowDays = 30
+
+// Thresholds for flagging anomalous usage in the last 24 hours
+const (
+	anomalyMinRequests    = 10
+	anomalyErrorRate      = 0.5
+	anomalyVolumeFactor   = 5.0
+	anomalyMaxDistinctIPs = 5
+)
+
+// UsageEntry is a single logged request made with a provider or service key
+type UsageEntry struct {
+	ID         string    `json:"id"`
+	APIKeyID   string    `json:"api_key_id"`
+	Endpoint   string    `json:"endpoint"`
+	IPAddress  *string   `json:"ip_address,omitempty"`
+	StatusCode int       `json:"status_code"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// UsageSummary aggregates usage over a window
+type UsageSummary struct {
+	TotalRequests int `json:"total_requests"`
+	ErrorRequests int `json:"error_requests"`
+	DistinctIPs   int `json:"distinct_ips"`
+	Last24Hours   int `json:"last_24_hours"`
+}
+
+// UsageAnomaly describes a suspicious usage pattern
+type UsageAnomaly struct {
+	Kind    string `json:"kind"` // error_rate, volume_spike, many_ips
+	Message string `json:"message"`
+}
+
+// UsageResponse is the usage log for a key with its summary and detected anomalies.
+// Provider is empty for service keys.
+type UsageResponse struct {
+	Provider  string         `json:"provider,omitempty"`
+	APIKeyID  string         `json:"api_key_id"`
+	IsActive  bool           `json:"is_active"`
+	Days      int            `json:"days"`
+	Summary   UsageSummary   `json:"summary"`
+	Anomalies []UsageAnomaly `json:"anomalies"`
+	Entries   []UsageEntry   `json:"entries"`
+}
+
+// RecordUsage logs a request made with the current user's key for a provider.
+// Failures are logged rather than returned so usage tracking never breaks a request.
+func (s *Service) RecordUsage(ctx context.Context, provider, endpoint string, statusCode int) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return
+	}
+
+	var keyID string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id FROM api_keys WHERE user_id = $1 AND provider = $2
+	`, userID, provider).Scan(&keyID)
+	if err != nil {
+		log.Printf("ERROR: failed to resolve API key for usage: provider=%s error=%v", provider, err)
+		return
+	}
+
+	s.recordKeyUsage(ctx, keyID, userID, endpoint, statusCode)
+}
+
+// RecordServiceKeyUsage implements auth.APIKeyVerifier. It logs a request made with a
+// service key; failures are logged rather than returned.
+func (s *Service) RecordServiceKeyUsage(ctx context.Context, keyID, userID, endpoint string, statusCode int) {
+	s.insertUsage(ctx, nil, &keyID, userID, endpoint, statusCode)
+}
+
+// recordKeyUsage logs a request made with a known provider key
+func (s *Service) recordKeyUsage(ctx context.Context, keyID, userID, endpoint string, statusCode int) {
+	s.insertUsage(ctx, &keyID, nil, userID, endpoint, statusCode)
+}
+
+// insertUsage logs a request made with either a provider key or a service key
+func (s *Service) insertUsage(ctx context.Context, apiKeyID, serviceKeyID *string, userID, endpoint string, statusCode int) {
+	var ip *string
+	if clientIP := auth.GetClientIP(ctx); clientIP != "" {
+		ip = &clientIP
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO api_key_usage (id, api_key_id, service_key_id, user_id, endpoint, ip_address, status_code, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, uuid.New().String(), apiKeyID, serviceKeyID, userID, endpoint, ip, statusCode, time.Now())
+	if err != nil {
+		log.Printf("ERROR: failed to record API key usage: api_key_id=%v service_key_id=%v error=%v",
+			deref(apiKeyID), deref(serviceKeyID), err)
+	}
+}
+
+// deref returns the string a pointer holds, or an empty string for nil
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// GetUsage returns the usage log for the user's key for a provider over the last n days
+func (s *Service) GetUsage(ctx context.Context, provider string, days int) (*UsageResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	if !IsValidProvider(provider) {
+		return nil, fmt.Errorf("invalid provider: %s", provider)
+	}
+	if days <= 0 {
+		days = DefaultUsageWindowDays
+	}
+
+	var keyID string
+	var isActive bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, is_active FROM api_keys WHERE user_id = $1 AND provider = $2
+	`, userID, provider).Scan(&keyID, &isActive)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("API key not found for provider: %s", provider)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	usage, err := s.keyUsage(ctx, keyID, days)
+	if err != nil {
+		return nil, err
+	}
+	usage.Provider = provider
+	usage.IsActive = isActive
+	return usage, nil
+}
+
+// GetServiceKeyUsage returns the usage log for one of the user's service keys over the
+// last n days
+func (s *Service) GetServiceKeyUsage(ctx context.Context, id string, days int) (*UsageResponse, error) {
+	key, err := s.GetServiceKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if days <= 0 {
+		days = DefaultUsageWindowDays
+	}
+
+	usage, err := s.keyUsage(ctx, key.ID, days)
+	if err != nil {
+		return nil, err
+	}
+	usage.IsActive = key.IsActive
+	return usage, nil
+}
+
+// keyUsage summarizes and lists a provider or service key's usage over the last n days
+func (s *Service) keyUsage(ctx context.Context, keyID string, days int) (*UsageResponse, error) {
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -days)
+	dayAgo := now.Add(-24 * time.Hour)
+
+	var summary UsageSummary
+	var recentErrors, recentIPs int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*),
+			COALESCE(SUM(CASE WHEN status_code = 0 OR status_code >= 400 THEN 1 ELSE 0 END), 0),
+			COUNT(DISTINCT ip_address),
+			COALESCE(SUM(CASE WHEN created_at > $3 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN created_at > $3 AND (status_code = 0 OR status_code >= 400) THEN 1 ELSE 0 END), 0),
+			COUNT(DISTINCT CASE WHEN created_at > $3 THEN ip_address END)
+		FROM api_key_usage
+		WHERE (api_key_id = $1 OR service_key_id = $1) AND created_at >= $2
+	`, keyID, cutoff, dayAgo).Scan(&summary.TotalRequests, &summary.ErrorRequests, &summary.DistinctIPs,
+		&summary.Last24Hours, &recentErrors, &recentIPs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize API key usage: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, COALESCE(api_key_id, service_key_id), endpoint, ip_address, status_code, created_at
+		FROM api_key_usage
+		WHERE (api_key_id = $1 OR service_key_id = $1) AND created_at >= $2
+		ORDER BY created_at DESC
+	`, keyID, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key usage: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]UsageEntry, 0)
+	for rows.Next() {
+		var entry UsageEntry
+		if err := rows.Scan(&entry.ID, &entry.APIKeyID, &entry.Endpoint, &entry.IPAddress, &entry.StatusCode, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan API key usage: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get API key usage: %w", err)
+	}
+
+	return &UsageResponse{
+		APIKeyID:  keyID,
+		Days:      days,
+		Summary:   summary,
+		Anomalies: detectAnomalies(summary, recentErrors, recentIPs, days),
+		Entries:   entries,
+	}, nil
+}
+
+// detectAnomalies flags anomalous patterns in the last 24 hours from the window's summary
+// and the errors and distinct IPs seen in the last 24 hours
+func detectAnomalies(summary UsageSummary, recentErrors, recentIPs, days int) []UsageAnomaly {
+	anomalies := make([]UsageAnomaly, 0)
+
+	if summary.Last24Hours >= anomalyMinRequests {
+		errorRate := float64(recentErrors) / float64(summary.Last24Hours)
+		if errorRate >= anomalyErrorRate {
+			anomalies = append(anomalies, UsageAnomaly{
+				Kind:    "error_rate",
+				Message: fmt.Sprintf("%.0f%% of requests in the last 24 hours failed", errorRate*100),
+			})
+		}
+
+		if days > 1 {
+			baseline := float64(summary.TotalRequests-summary.Last24Hours) / float64(days-1)
+			if float64(summary.Last24Hours) > anomalyVolumeFactor*baseline {
+				anomalies = append(anomalies, UsageAnomaly{
+					Kind:    "volume_spike",
+					Message: fmt.Sprintf("%d requests in the last 24 hours vs a daily average of %.1f", summary.Last24Hours, baseline),
+				})
+			}
+		}
+	}
+
+	if recentIPs > anomalyMaxDistinctIPs {
+		anomalies = append(anomalies, UsageAnomaly{
+			Kind:    "many_ips",
+			Message: fmt.Sprintf("requests came from %d different IP addresses in the last 24 hours", recentIPs),
+		})
+	}
+
+	return anomalies
+}
+
+// ExportUsageCSV returns the usage log for a provider's key as CSV
+func (s *Service) ExportUsageCSV(ctx context.Context, provider string, days int) ([]byte, error) {
+	usage, err := s.GetUsage(ctx, provider, days)
+	if err != nil {
+		return nil, err
+	}
+	return usageCSV(usage.Entries)
+}
+
+// ExportServiceKeyUsageCSV returns the usage log for one of the user's service keys as CSV
+func (s *Service) ExportServiceKeyUsageCSV(ctx context.Context, id string, days int) ([]byte, error) {
+	usage, err := s.GetServiceKeyUsage(ctx, id, days)
+	if err != nil {
+		return nil, err
+	}
+	return usageCSV(usage.Entries)
+}
+
+// usageCSV writes usage entries as CSV
+func usageCSV(entries []UsageEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write([]string{"timestamp", "endpoint", "ip_address", "status_code"}); err != nil {
+		return nil, fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, entry := range entries {
+		ip := ""
+		if entry.IPAddress != nil {
+			ip = *entry.IPAddress
+		}
+		record := []string{
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			entry.Endpoint,
+			ip,
+			strconv.Itoa(entry.StatusCode),
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RevokeAPIKey deactivates the user's key for a provider while keeping its usage history
+func (s *Service) RevokeAPIKey(ctx context.Context, provider string) (*APIKeyStatusResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	if !IsValidProvider(provider) {
+		return nil, fmt.Errorf("invalid provider: %s", provider)
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE api_keys SET is_active = 0, updated_at = $1 WHERE user_id = $2 AND provider = $3
+	`, time.Now(), userID, provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("API key not found for provider: %s", provider)
+	}
+
+	return s.GetAPIKeyStatus(ctx, provider)
+}
+
+// PurgeUsage deletes usage entries older than the retention period
+func (s *Service) PurgeUsage(ctx context.Context, retention time.Duration) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM api_key_usage WHERE created_at < $1
+	`, time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge API key usage: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// StartUsageRetention purges expired usage entries daily until jobs drains
+func (s *Service) StartUsageRetention(jobs *background.Group, retention time.Duration) {
+	jobs.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+
+		for {
+			jobs.Exclusive(ctx, "api_key_usage_retention", 24*time.Hour, func(ctx context.Context) {
+				if purged, err := s.PurgeUsage(ctx, retention); err != nil {
+					log.Printf("ERROR: API key usage retention failed: %v", err)
+				} else if purged > 0 {
+					log.Printf("INFO: purged %d expired API key usage entries", purged)
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
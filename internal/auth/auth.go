@@ -18,6 +18,24 @@ type AuthProvider interface {
 	RegisterRoutes(r chi.Router)
 }
 
+// VerifiedKey is a service API key that passed verification
+type VerifiedKey struct {
+	ID     string
+	UserID string
+	Scopes []string
+}
+
+// APIKeyVerifier validates service API keys presented as bearer tokens and logs the
+// requests made with them
+type APIKeyVerifier interface {
+	// VerifyAPIKey validates a key for a request from clientIP
+	VerifyAPIKey(ctx context.Context, key, clientIP string) (*VerifiedKey, error)
+
+	// RecordServiceKeyUsage logs a request made with a verified key. The context carries
+	// the client IP.
+	RecordServiceKeyUsage(ctx context.Context, keyID, userID, endpoint string, statusCode int)
+}
+
 // Claims represents JWT claims
 type Claims struct {
 	UserID    string `json:"user_id"`
@@ -1,11 +1,17 @@
 package auth
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type contextKey string
 
 const UserIDKey contextKey = "user_id"
 
+// ClientIPKey holds the address of the client that made the request
+const ClientIPKey contextKey = "client_ip"
+
 // GetUserID extracts the user ID from the request context
 func GetUserID(ctx context.Context) string {
 	userID, _ := ctx.Value(UserIDKey).(string)
@@ -16,3 +22,81 @@ func GetUserID(ctx context.Context) string {
 func WithUserID(ctx context.Context, userID string) context.Context {
 	return context.WithValue(ctx, UserIDKey, userID)
 }
+
+// GetClientIP extracts the client IP address from the request context
+func GetClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(ClientIPKey).(string)
+	return ip
+}
+
+// WithClientIP adds the client IP address to the context
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, ClientIPKey, ip)
+}
+
+// DelegateKey holds the user acting for the context's user through delegated access
+const DelegateKey contextKey = "delegate_id"
+
+// GetDelegate extracts the delegate acting for the user, empty when the user acts directly
+func GetDelegate(ctx context.Context) string {
+	delegateID, _ := ctx.Value(DelegateKey).(string)
+	return delegateID
+}
+
+// WithDelegate records that a delegate is acting for the context's user
+func WithDelegate(ctx context.Context, delegateID string) context.Context {
+	return context.WithValue(ctx, DelegateKey, delegateID)
+}
+
+// AuthenticatedAtKey holds when the request's login token was issued
+const AuthenticatedAtKey contextKey = "authenticated_at"
+
+// ReauthWindow is how long after logging in a session counts as freshly authenticated
+const ReauthWindow = 5 * time.Minute
+
+// GetAuthenticatedAt extracts when the user last logged in, zero for API keys
+func GetAuthenticatedAt(ctx context.Context) time.Time {
+	at, _ := ctx.Value(AuthenticatedAtKey).(time.Time)
+	return at
+}
+
+// WithAuthenticatedAt records when the user last logged in
+func WithAuthenticatedAt(ctx context.Context, at time.Time) context.Context {
+	return context.WithValue(ctx, AuthenticatedAtKey, at)
+}
+
+// RecentlyAuthenticated reports whether the user logged in within the reauthentication window,
+// as sensitive actions require
+func RecentlyAuthenticated(ctx context.Context) bool {
+	at := GetAuthenticatedAt(ctx)
+	return !at.IsZero() && time.Since(at) <= ReauthWindow
+}
+
+// ServiceKeyAuthKey marks requests authenticated with a service API key
+const ServiceKeyAuthKey contextKey = "service_key_auth"
+
+// IsServiceKey reports whether the request was authenticated with a service API key rather
+// than an interactive session
+func IsServiceKey(ctx context.Context) bool {
+	viaKey, _ := ctx.Value(ServiceKeyAuthKey).(bool)
+	return viaKey
+}
+
+// WithServiceKey records that the request was authenticated with a service API key
+func WithServiceKey(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ServiceKeyAuthKey, true)
+}
+
+// MaskPrivateKey marks requests whose responses mask private account balances
+const MaskPrivateKey contextKey = "mask_private"
+
+// MaskPrivate reports whether private account balances are masked for the request
+func MaskPrivate(ctx context.Context) bool {
+	mask, _ := ctx.Value(MaskPrivateKey).(bool)
+	return mask
+}
+
+// WithMaskPrivate masks private account balances for the rest of the request
+func WithMaskPrivate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, MaskPrivateKey, true)
+}
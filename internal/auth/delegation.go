@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// ActingForHeader names the user a delegate wants to act for
+const ActingForHeader = "X-Acting-For"
+
+// Delegated access levels
+const (
+	AccessRead    = "read"
+	AccessComment = "comment" // Read, plus leaving comments for the owner
+)
+
+// Modules a delegate can be granted, each covering a set of top-level API routes
+const (
+	ModuleAccounts     = "accounts"
+	ModuleBalances     = "balances"
+	ModuleHoldings     = "holdings"
+	ModuleTransactions = "transactions"
+	ModuleProjections  = "projections"
+	ModuleIncome       = "income"
+	ModuleAnalytics    = "analytics"
+	ModuleCredit       = "credit"
+)
+
+// moduleRoutes maps the first segment of an API path to the module it belongs to. Routes
+// not listed here, such as API keys, sync credentials and data export, are never reachable
+// through delegated access.
+var moduleRoutes = map[string]string{
+	"accounts":                ModuleAccounts,
+	"accounts-with-balance":   ModuleAccounts,
+	"summary":                 ModuleAccounts,
+	"assets":                  ModuleAccounts,
+	"liabilities":             ModuleAccounts,
+	"documents":               ModuleAccounts,
+	"reports":                 ModuleAccounts,
+	"entities":                ModuleAccounts,
+	"account-balances":        ModuleBalances,
+	"account-reconciliations": ModuleBalances,
+	"reconciliations":         ModuleBalances,
+	"account-envelopes":       ModuleBalances,
+	"envelopes":               ModuleBalances,
+	"balances":                ModuleBalances,
+	"account-holdings":        ModuleHoldings,
+	"allocation":              ModuleHoldings,
+	"holdings":                ModuleHoldings,
+	"risk":                    ModuleHoldings,
+	"transactions":            ModuleTransactions,
+	"recurring-expenses":      ModuleTransactions,
+	"scheduled-transactions":  ModuleTransactions,
+	"transfers":               ModuleTransactions,
+	"sweeps":                  ModuleTransactions,
+	"iou":                     ModuleTransactions,
+	"projects":                ModuleTransactions,
+	"projections":             ModuleProjections,
+	"income":                  ModuleIncome,
+	"analytics":               ModuleAnalytics,
+	"anomalies":               ModuleAnalytics,
+	"credit-scores":           ModuleCredit,
+}
+
+// privateRoutes are routes within a delegable module that are still never reachable through
+// delegated access, keyed by their first two path segments. Report schedules hold the
+// owner's upload destinations and credentials.
+var privateRoutes = map[string]bool{
+	"reports/schedules": true,
+}
+
+// IsDelegableModule reports whether a module can be granted to a delegate
+func IsDelegableModule(module string) bool {
+	for _, m := range moduleRoutes {
+		if m == module {
+			return true
+		}
+	}
+	return false
+}
+
+// Delegation is an active grant letting a delegate act for the owning user
+type Delegation struct {
+	ID      string
+	Access  string
+	Modules []string
+}
+
+// DelegationVerifier checks delegated access and records it in the owner's audit trail
+type DelegationVerifier interface {
+	// VerifyDelegation returns the active grant from ownerID to delegateID
+	VerifyDelegation(ctx context.Context, delegateID, ownerID string) (*Delegation, error)
+
+	// RecordDelegatedAccess logs a delegated request, whether or not it was allowed
+	RecordDelegatedAccess(ctx context.Context, delegationID, method, path, clientIP string, allowed bool) error
+}
+
+// apiVersionSegment matches the version in versioned API paths such as /api/v2/accounts
+var apiVersionSegment = regexp.MustCompile(`^v[0-9]+$`)
+
+// delegationAllows reports whether a grant permits the request. Reference data such as
+// exchange rates is always readable, and comment access may also post advisor comments.
+func delegationAllows(d *Delegation, method, path string) bool {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api"), "/"), "/")
+	if len(segments) > 1 && apiVersionSegment.MatchString(segments[0]) {
+		segments = segments[1:]
+	}
+	first := segments[0]
+	read := method == http.MethodGet || method == http.MethodHead
+
+	if first == "advisors" && len(segments) > 1 && segments[1] == "comments" {
+		return read || (method == http.MethodPost && d.Access == AccessComment)
+	}
+	if first == "currency" {
+		return read
+	}
+
+	if len(segments) > 1 && privateRoutes[first+"/"+segments[1]] {
+		return false
+	}
+
+	module, ok := moduleRoutes[first]
+	if !ok || !read {
+		return false
+	}
+	for _, granted := range d.Modules {
+		if granted == module {
+			return true
+		}
+	}
+	return false
+}
+
+// DelegationMiddleware lets an authenticated user act for another user who granted them
+// access, named by the X-Acting-For header. The request then runs as the owner with the
+// delegate recorded in the context, and every attempt is written to the owner's audit
+// trail. It must run after AuthMiddleware.
+func DelegationMiddleware(verifier DelegationVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ownerID := r.Header.Get(ActingForHeader)
+			delegateID := GetUserID(r.Context())
+			if ownerID == "" || ownerID == delegateID {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			delegation, err := verifier.VerifyDelegation(r.Context(), delegateID, ownerID)
+			if err != nil {
+				log.Printf("Delegated access denied: %v", err)
+				http.Error(w, `{"error":"forbidden","message":"no delegated access to this user"}`, http.StatusForbidden)
+				return
+			}
+
+			allowed := delegationAllows(delegation, r.Method, r.URL.Path)
+			if err := verifier.RecordDelegatedAccess(r.Context(), delegation.ID, r.Method, r.URL.Path, GetClientIP(r.Context()), allowed); err != nil {
+				log.Printf("Failed to record delegated access: %v", err)
+				http.Error(w, `{"error":"internal","message":"failed to record delegated access"}`, http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				http.Error(w, `{"error":"forbidden","message":"delegated access does not permit this request"}`, http.StatusForbidden)
+				return
+			}
+
+			ctx := WithUserID(r.Context(), ownerID)
+			ctx = WithDelegate(ctx, delegateID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -81,3 +81,17 @@ func VerifyJWT(tokenString string, secret []byte) (*Claims, error) {
 		ExpiresAt: int64(exp),
 	}, nil
 }
+
+// tokenIssuedAt reads when a token was issued, zero when it is not a JWT with an iat claim.
+// The signature is not checked, so it is only for tokens the provider has already verified.
+func tokenIssuedAt(tokenString string) time.Time {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, claims); err != nil {
+		return time.Time{}
+	}
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return time.Time{}
+	}
+	return time.Unix(int64(iat), 0)
+}
@@ -1,13 +1,57 @@
 package auth
 
 import (
+	"context"
 	"log"
+	"net"
 	"net/http"
 	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// ServiceKeyPrefix marks bearer tokens that are service API keys rather than JWTs
+const ServiceKeyPrefix = "mny_"
+
+// Service key scopes
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopePush  = "push" // Only pushing balances and transactions for review
 )
 
-// AuthMiddleware creates middleware that validates authentication tokens
-func AuthMiddleware(provider AuthProvider) func(http.Handler) http.Handler {
+// PushPath is the route a push-scoped key may call, relative to the API version root
+const PushPath = "/statements/push"
+
+// clientIP returns the request's client address without the port
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// scopeAllows reports whether the scopes permit the request
+func scopeAllows(scopes []string, r *http.Request) bool {
+	for _, scope := range scopes {
+		if scope == ScopeWrite {
+			return true
+		}
+		if scope == ScopeRead && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+			return true
+		}
+		if scope == ScopePush && r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, PushPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthMiddleware creates middleware that validates authentication tokens.
+// Tokens with the service key prefix are checked by keyVerifier when it is set, and every
+// request made with one is logged with its response status.
+func AuthMiddleware(provider AuthProvider, keyVerifier APIKeyVerifier) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Extract Bearer token from Authorization header
@@ -29,21 +73,86 @@ func AuthMiddleware(provider AuthProvider) func(http.Handler) http.Handler {
 				return
 			}
 
-			// Verify token using provider
-			userID, err := provider.VerifyToken(r.Context(), token)
-			if err != nil {
-				log.Printf("Token verification failed: %v", err)
-				http.Error(w, `{"error":"unauthorized","message":"invalid token"}`, http.StatusUnauthorized)
-				return
+			ip := clientIP(r)
+
+			var userID string
+			var authenticatedAt time.Time
+			var key *VerifiedKey
+			if keyVerifier != nil && strings.HasPrefix(token, ServiceKeyPrefix) {
+				// Verify service API key (allowlist, expiry and scopes)
+				verified, err := keyVerifier.VerifyAPIKey(r.Context(), token, ip)
+				if err != nil {
+					log.Printf("API key verification failed: %v", err)
+					http.Error(w, `{"error":"unauthorized","message":"invalid API key"}`, http.StatusUnauthorized)
+					return
+				}
+				ctx := WithClientIP(r.Context(), ip)
+				if !scopeAllows(verified.Scopes, r) {
+					http.Error(w, `{"error":"forbidden","message":"API key scope does not permit this request"}`, http.StatusForbidden)
+					keyVerifier.RecordServiceKeyUsage(ctx, verified.ID, verified.UserID, r.URL.Path, http.StatusForbidden)
+					return
+				}
+				userID = verified.UserID
+				key = verified
+			} else {
+				// Verify token using provider
+				tokenUserID, err := provider.VerifyToken(r.Context(), token)
+				if err != nil {
+					log.Printf("Token verification failed: %v", err)
+					http.Error(w, `{"error":"unauthorized","message":"invalid token"}`, http.StatusUnauthorized)
+					return
+				}
+				userID = tokenUserID
+				authenticatedAt = tokenIssuedAt(token)
 			}
 
-			// Add user_id to context
+			// Add user_id and client IP to context
 			ctx := WithUserID(r.Context(), userID)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			ctx = WithClientIP(ctx, ip)
+			if !authenticatedAt.IsZero() {
+				ctx = WithAuthenticatedAt(ctx, authenticatedAt)
+			}
+			if key == nil {
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			// Log the request against the key once the response status is known. The
+			// client may already be gone, so the write doesn't use its cancellation.
+			ctx = WithServiceKey(ctx)
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			keyVerifier.RecordServiceKeyUsage(context.WithoutCancel(ctx), key.ID, key.UserID, r.URL.Path, status)
 		})
 	}
 }
 
+// ClientIPMiddleware records the request's client address in the context, for public routes
+// that run without AuthMiddleware
+func ClientIPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithClientIP(r.Context(), clientIP(r))))
+	})
+}
+
+// RequireSession refuses requests authenticated with a service API key, whatever its scopes.
+// Key management, delegation, sharing, hooks, upload destinations, account deletion, complete
+// exports and administration sit behind it, so a leaked key can't mint or keep itself alive,
+// hand out access to the data or destroy it.
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if IsServiceKey(r.Context()) {
+			http.Error(w, `{"error":"forbidden","message":"API keys cannot make this request; sign in instead"}`, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // DemoModeMiddleware overrides the user context to demo user when X-Demo-Mode header is present
 // This middleware should be placed after AuthMiddleware to ensure authentication is validated first
 func DemoModeMiddleware(demoUserID string) func(http.Handler) http.Handler {
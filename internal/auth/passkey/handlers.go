@@ -1,6 +1,7 @@
 package passkey
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -14,8 +15,40 @@ import (
 	"github.com/go-webauthn/webauthn/webauthn"
 )
 
-// Session store for WebAuthn challenges (in-memory for simplicity)
-var sessionStore = make(map[string]*webauthn.SessionData)
+// ceremonyTTL is how long a registration or login may take between begin and finish
+const ceremonyTTL = 5 * time.Minute
+
+// ceremonyKey names the pending WebAuthn ceremony of a user in the session store
+func ceremonyKey(userID string) string {
+	return "passkey-ceremony:" + userID
+}
+
+// saveCeremony keeps a ceremony's challenge for its finish request, which may reach
+// another instance
+func (p *PasskeyAuthProvider) saveCeremony(ctx context.Context, userID string, sessionData *webauthn.SessionData) error {
+	data, err := json.Marshal(sessionData)
+	if err != nil {
+		return fmt.Errorf("failed to encode passkey session: %w", err)
+	}
+	return p.sessions.Set(ctx, ceremonyKey(userID), data, ceremonyTTL)
+}
+
+// takeCeremony returns and removes a user's pending ceremony, so a challenge is only
+// answered once. It returns nil when none is pending or it expired.
+func (p *PasskeyAuthProvider) takeCeremony(ctx context.Context, userID string) (*webauthn.SessionData, error) {
+	data, ok, err := p.sessions.Get(ctx, ceremonyKey(userID))
+	if err != nil || !ok {
+		return nil, err
+	}
+	if err := p.sessions.Delete(ctx, ceremonyKey(userID)); err != nil {
+		return nil, err
+	}
+	var sessionData webauthn.SessionData
+	if err := json.Unmarshal(data, &sessionData); err != nil {
+		return nil, fmt.Errorf("failed to decode passkey session: %w", err)
+	}
+	return &sessionData, nil
+}
 
 // handleStatus checks if registration is needed
 func (p *PasskeyAuthProvider) handleStatus(w http.ResponseWriter, r *http.Request) {
@@ -79,7 +112,11 @@ func (p *PasskeyAuthProvider) handleRegistrationBegin(w http.ResponseWriter, r *
 	}
 
 	// Store session data
-	sessionStore[SingleUserID] = sessionData
+	if err := p.saveCeremony(ctx, SingleUserID, sessionData); err != nil {
+		log.Printf("Error storing session: %v", err)
+		http.Error(w, `{"error":"internal_error"}`, http.StatusInternalServerError)
+		return
+	}
 
 	// Return options to client
 	w.Header().Set("Content-Type", "application/json")
@@ -104,12 +141,16 @@ func (p *PasskeyAuthProvider) handleRegistrationFinish(w http.ResponseWriter, r
 	}
 
 	// Get session data
-	sessionData, ok := sessionStore[SingleUserID]
-	if !ok {
+	sessionData, err := p.takeCeremony(ctx, SingleUserID)
+	if err != nil {
+		log.Printf("Error getting session: %v", err)
+		http.Error(w, `{"error":"internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	if sessionData == nil {
 		http.Error(w, `{"error":"session_not_found"}`, http.StatusBadRequest)
 		return
 	}
-	delete(sessionStore, SingleUserID)
 
 	// Create WebAuthn user with no credentials
 	webAuthnUser := &WebAuthnUser{
@@ -219,7 +260,11 @@ func (p *PasskeyAuthProvider) handleLoginBegin(w http.ResponseWriter, r *http.Re
 	}
 
 	// Store session data
-	sessionStore[SingleUserID] = sessionData
+	if err := p.saveCeremony(ctx, SingleUserID, sessionData); err != nil {
+		log.Printf("Error storing session: %v", err)
+		http.Error(w, `{"error":"internal_error"}`, http.StatusInternalServerError)
+		return
+	}
 
 	// Return options to client
 	w.Header().Set("Content-Type", "application/json")
@@ -244,12 +289,16 @@ func (p *PasskeyAuthProvider) handleLoginFinish(w http.ResponseWriter, r *http.R
 	}
 
 	// Get session data
-	sessionData, ok := sessionStore[SingleUserID]
-	if !ok {
+	sessionData, err := p.takeCeremony(ctx, SingleUserID)
+	if err != nil {
+		log.Printf("Error getting session: %v", err)
+		http.Error(w, `{"error":"internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	if sessionData == nil {
 		http.Error(w, `{"error":"session_not_found"}`, http.StatusBadRequest)
 		return
 	}
-	delete(sessionStore, SingleUserID)
 
 	// Get credentials
 	dbCredentials, err := p.credRepo.GetByUserID(ctx, SingleUserID)
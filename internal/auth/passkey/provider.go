@@ -8,6 +8,7 @@ import (
 	"os"
 
 	"money/internal/auth"
+	"money/internal/kvstore"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-webauthn/webauthn/webauthn"
@@ -24,6 +25,7 @@ type PasskeyAuthProvider struct {
 	userRepo    *auth.UserRepository
 	sessionRepo *auth.SessionRepository
 	credRepo    *CredentialRepository
+	sessions    kvstore.Store // Pending WebAuthn ceremonies
 }
 
 // NewPasskeyAuthProvider creates a new passkey auth provider
@@ -68,9 +70,16 @@ func NewPasskeyAuthProvider(db *sql.DB) (*PasskeyAuthProvider, error) {
 		userRepo:    auth.NewUserRepository(db),
 		sessionRepo: auth.NewSessionRepository(db),
 		credRepo:    NewCredentialRepository(db),
+		sessions:    kvstore.NewMemory(),
 	}, nil
 }
 
+// SetSessionStore keeps pending WebAuthn ceremonies in a store shared between instances,
+// so a login begun on one instance can finish on another
+func (p *PasskeyAuthProvider) SetSessionStore(store kvstore.Store) {
+	p.sessions = store
+}
+
 // Initialize sets up the auth provider
 func (p *PasskeyAuthProvider) Initialize(ctx context.Context) error {
 	// Create default user if doesn't exist
@@ -0,0 +1,79 @@
+// Package background tracks the goroutines that outlive a request, such as syncs and
+// schedulers, so shutdown can stop them and wait for them to finish.
+package background
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Group runs background work under a context that is cancelled when the group drains
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	draining bool
+	running  int
+	wg       sync.WaitGroup
+	locker   Locker
+}
+
+// NewGroup creates a new background group
+func NewGroup() *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{ctx: ctx, cancel: cancel}
+}
+
+// Go runs fn on its own goroutine. Work handed over once the group is draining is not
+// started and Go returns false; callers leave enough state behind to pick it up on the
+// next start.
+func (g *Group) Go(fn func(ctx context.Context)) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.draining {
+		return false
+	}
+	g.wg.Add(1)
+	g.running++
+	go func() {
+		defer g.wg.Done()
+		defer func() {
+			g.mu.Lock()
+			g.running--
+			g.mu.Unlock()
+		}()
+		fn(g.ctx)
+	}()
+	return true
+}
+
+// Running returns how many pieces of work have started and not yet returned
+func (g *Group) Running() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.running
+}
+
+// Drain cancels the running work and waits for it to return, giving up when ctx expires
+func (g *Group) Drain(ctx context.Context) error {
+	g.mu.Lock()
+	g.draining = true
+	g.mu.Unlock()
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background work still running: %w", ctx.Err())
+	}
+}
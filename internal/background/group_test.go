@@ -0,0 +1,79 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDrain_WaitsForWorkToStop(t *testing.T) {
+	// Arrange
+	g := NewGroup()
+	stopped := make(chan struct{})
+	g.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		close(stopped)
+	})
+
+	// Act
+	err := g.Drain(context.Background())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Error("Expected Drain to return only after the work stopped")
+	}
+	if g.Go(func(ctx context.Context) {}) {
+		t.Error("Expected work handed over while draining to be refused")
+	}
+}
+
+func TestDrain_GivesUpAtDeadline(t *testing.T) {
+	// Arrange
+	g := NewGroup()
+	release := make(chan struct{})
+	defer close(release)
+	g.Go(func(ctx context.Context) {
+		<-release
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	// Act
+	err := g.Drain(ctx)
+
+	// Assert
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the drain to time out, got %v", err)
+	}
+}
+
+func TestRunning_CountsWorkInFlight(t *testing.T) {
+	// Arrange
+	g := NewGroup()
+	release := make(chan struct{})
+	done := make(chan struct{})
+	g.Go(func(ctx context.Context) {
+		<-release
+		close(done)
+	})
+
+	// Act & Assert
+	if running := g.Running(); running != 1 {
+		t.Fatalf("Expected 1 running, got %d", running)
+	}
+	close(release)
+	<-done
+	if err := g.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	if running := g.Running(); running != 0 {
+		t.Errorf("Expected nothing running after it returned, got %d", running)
+	}
+}
@@ -0,0 +1,154 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"money/internal/logger"
+)
+
+var backgroundLog = logger.Module("background")
+
+// ErrLeaseHeld is returned by TakeLease while another instance holds the lease
+var ErrLeaseHeld = errors.New("lease is held by another instance")
+
+// Locker hands out named leases shared between server instances, so scheduled work runs
+// on one instance at a time
+type Locker interface {
+	// Acquire takes or renews the lease on name for ttl, reporting false while another
+	// instance holds it
+	Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error)
+
+	// Release gives up the lease on name if this instance holds it
+	Release(ctx context.Context, name string) error
+}
+
+// SetLocker makes Exclusive and TakeLease coordinate with other instances through l
+func (g *Group) SetLocker(l Locker) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.locker = l
+}
+
+// Exclusive runs fn only if this instance holds the lease on name, reporting whether it
+// ran. Schedulers call it on every tick with ttl set to their interval: the lease isn't
+// released when fn returns, so the instance that ran keeps renewing it on its own ticks
+// and the others only take over once it stops. A long run renews the lease as it goes
+// and has its context cancelled if the lease is lost. Without a locker fn always runs.
+func (g *Group) Exclusive(ctx context.Context, name string, ttl time.Duration, fn func(ctx context.Context)) bool {
+	g.mu.Lock()
+	locker := g.locker
+	g.mu.Unlock()
+	if locker == nil {
+		fn(ctx)
+		return true
+	}
+
+	held, err := locker.Acquire(ctx, name, ttl)
+	if err != nil {
+		if ctx.Err() == nil {
+			backgroundLog.Error("Failed to acquire job lease", "job", name, "error", err)
+		}
+		return false
+	}
+	if !held {
+		return false
+	}
+	renewWhile(ctx, locker, name, ttl, fn)
+	return true
+}
+
+// renewWhile runs fn while renewing the lease on name, which is already held, every half
+// ttl. fn has its context cancelled if the lease is lost.
+func renewWhile(ctx context.Context, locker Locker, name string, ttl time.Duration, fn func(ctx context.Context)) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		ticker := time.NewTicker(ttl / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				held, err := locker.Acquire(runCtx, name, ttl)
+				if runCtx.Err() != nil {
+					return
+				}
+				if err != nil || !held {
+					backgroundLog.Warn("Lost job lease, stopping run", "job", name, "error", err)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	fn(runCtx)
+	cancel()
+	<-renewed
+}
+
+// Lease is a lease taken for one run of one-off work
+type Lease struct {
+	group  *Group
+	locker Locker // Nil when the group has no locker
+	name   string
+	ttl    time.Duration
+}
+
+// TakeLease takes the lease on name for one run of one-off work, such as a sync of one
+// connection, returning ErrLeaseHeld while another instance holds it. Unlike Exclusive the
+// lease is taken before the work starts, so callers can refuse a duplicate at once, and it
+// is released when the work returns so the next run can start on any instance. Without a
+// locker every lease is granted.
+func (g *Group) TakeLease(ctx context.Context, name string, ttl time.Duration) (*Lease, error) {
+	g.mu.Lock()
+	locker := g.locker
+	g.mu.Unlock()
+
+	lease := &Lease{group: g, locker: locker, name: name, ttl: ttl}
+	if locker == nil {
+		return lease, nil
+	}
+	held, err := locker.Acquire(ctx, name, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !held {
+		return nil, ErrLeaseHeld
+	}
+	return lease, nil
+}
+
+// Go runs fn on its own goroutine in the group under the lease, renewing it as fn runs and
+// releasing it when fn returns. When the group is draining fn doesn't start, the lease is
+// released and Go returns false.
+func (l *Lease) Go(fn func(ctx context.Context)) bool {
+	started := l.group.Go(func(ctx context.Context) {
+		// Released even when shutdown cancelled the run, so another instance can resume it
+		defer l.Release(context.WithoutCancel(ctx))
+		if l.locker == nil {
+			fn(ctx)
+			return
+		}
+		renewWhile(ctx, l.locker, l.name, l.ttl, fn)
+	})
+	if !started {
+		l.Release(context.Background())
+	}
+	return started
+}
+
+// Release gives up the lease early, for work that won't run after all
+func (l *Lease) Release(ctx context.Context) {
+	if l.locker == nil {
+		return
+	}
+	if err := l.locker.Release(ctx, l.name); err != nil {
+		backgroundLog.Error("Failed to release job lease", "job", l.name, "error", err)
+	}
+}
@@ -0,0 +1,154 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLocker grants each lease to the first group to ask until the test hands it to another
+type fakeLocker struct {
+	mu      sync.Mutex
+	holders map[string]*Group
+}
+
+func (l *fakeLocker) forGroup(g *Group) Locker {
+	return &groupLocker{locks: l, group: g}
+}
+
+func (l *fakeLocker) handOver(name string, to *Group) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.holders[name] = to
+}
+
+// groupLocker is one instance's view of a fakeLocker
+type groupLocker struct {
+	locks *fakeLocker
+	group *Group
+}
+
+func (l *groupLocker) Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	l.locks.mu.Lock()
+	defer l.locks.mu.Unlock()
+	if holder, ok := l.locks.holders[name]; ok && holder != l.group {
+		return false, nil
+	}
+	l.locks.holders[name] = l.group
+	return true, nil
+}
+
+func (l *groupLocker) Release(ctx context.Context, name string) error {
+	l.locks.mu.Lock()
+	defer l.locks.mu.Unlock()
+	if l.locks.holders[name] == l.group {
+		delete(l.locks.holders, name)
+	}
+	return nil
+}
+
+func TestExclusive_RunsOnOneInstance(t *testing.T) {
+	// Arrange
+	locks := &fakeLocker{holders: map[string]*Group{}}
+	first, second := NewGroup(), NewGroup()
+	first.SetLocker(locks.forGroup(first))
+	second.SetLocker(locks.forGroup(second))
+	ctx := context.Background()
+	runs := map[string]int{}
+	run := func(instance string) func(ctx context.Context) {
+		return func(ctx context.Context) { runs[instance]++ }
+	}
+
+	// Act
+	for i := 0; i < 3; i++ {
+		first.Exclusive(ctx, "snapshots", time.Minute, run("first"))
+		second.Exclusive(ctx, "snapshots", time.Minute, run("second"))
+	}
+
+	// Assert
+	if runs["first"] != 3 || runs["second"] != 0 {
+		t.Errorf("Expected every run on the instance holding the lease, got %v", runs)
+	}
+}
+
+func TestExclusive_CancelsTheRunWhenTheLeaseIsLost(t *testing.T) {
+	// Arrange
+	locks := &fakeLocker{holders: map[string]*Group{}}
+	g := NewGroup()
+	g.SetLocker(locks.forGroup(g))
+	started := make(chan struct{})
+	var stopped error
+
+	// Act
+	ran := make(chan bool)
+	go func() {
+		ran <- g.Exclusive(context.Background(), "integrity", 20*time.Millisecond, func(ctx context.Context) {
+			close(started)
+			select {
+			case <-ctx.Done():
+				stopped = ctx.Err()
+			case <-time.After(time.Second):
+			}
+		})
+	}()
+	<-started
+	locks.handOver("integrity", NewGroup())
+
+	// Assert
+	if !<-ran {
+		t.Fatal("Expected the run to have started")
+	}
+	if stopped == nil {
+		t.Error("Expected the run to be cancelled once another instance took the lease")
+	}
+}
+
+func TestExclusive_RunsWithoutALocker(t *testing.T) {
+	// Arrange
+	g := NewGroup()
+	ran := false
+
+	// Act
+	ok := g.Exclusive(context.Background(), "snapshots", time.Minute, func(ctx context.Context) { ran = true })
+
+	// Assert
+	if !ok || !ran {
+		t.Error("Expected work to run when no locker is set")
+	}
+}
+
+func TestTakeLease_RefusedElsewhereUntilTheRunEnds(t *testing.T) {
+	// Arrange
+	locks := &fakeLocker{holders: map[string]*Group{}}
+	first, second := NewGroup(), NewGroup()
+	first.SetLocker(locks.forGroup(first))
+	second.SetLocker(locks.forGroup(second))
+	ctx := context.Background()
+	lease, err := first.TakeLease(ctx, "sync:conn-1", time.Minute)
+	if err != nil {
+		t.Fatalf("Expected the first instance to take the lease, got %v", err)
+	}
+	running, finish := make(chan struct{}), make(chan struct{})
+
+	// Act
+	lease.Go(func(ctx context.Context) {
+		close(running)
+		<-finish
+	})
+	<-running
+	_, errWhileRunning := second.TakeLease(ctx, "sync:conn-1", time.Minute)
+	close(finish)
+	first.Drain(context.Background())
+	again, errAfterRun := second.TakeLease(ctx, "sync:conn-1", time.Minute)
+
+	// Assert
+	if !errors.Is(errWhileRunning, ErrLeaseHeld) {
+		t.Errorf("Expected ErrLeaseHeld while the run is going, got %v", errWhileRunning)
+	}
+	if errAfterRun != nil {
+		t.Fatalf("Expected the lease to be free once the run ended, got %v", errAfterRun)
+	}
+	again.Release(ctx)
+}
@@ -0,0 +1,446 @@
+package balance
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+)
+
+// Envelope is a virtual partition of an account's balance set aside for a purpose.
+// Envelopes never create balances of their own, so net worth is unaffected.
+type Envelope struct {
+	ID           string     `json:"id"`
+	AccountID    string     `json:"account_id"`
+	Name         string     `json:"name"`
+	Allocated    float64    `json:"allocated"`
+	TargetAmount *float64   `json:"target_amount,omitempty"`
+	Notes        *string    `json:"notes,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+}
+
+// EnvelopeMovement records money moved between unallocated cash and envelopes
+type EnvelopeMovement struct {
+	ID             string    `json:"id"`
+	AccountID      string    `json:"account_id"`
+	FromEnvelopeID *string   `json:"from_envelope_id,omitempty"` // nil means unallocated cash
+	ToEnvelopeID   *string   `json:"to_envelope_id,omitempty"`   // nil means unallocated cash
+	Amount         float64   `json:"amount"`
+	Notes          *string   `json:"notes,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CreateEnvelopeRequest represents the request to create an envelope on an account
+type CreateEnvelopeRequest struct {
+	Name         string   `json:"name"`
+	TargetAmount *float64 `json:"target_amount,omitempty"`
+	Notes        *string  `json:"notes,omitempty"`
+	Allocate     float64  `json:"allocate,omitempty"` // Optional amount to move in from unallocated cash
+}
+
+// AllocateEnvelopeRequest moves money between unallocated cash and an envelope.
+// A positive amount allocates into the envelope, a negative amount releases from it.
+type AllocateEnvelopeRequest struct {
+	Amount float64 `json:"amount"`
+	Notes  string  `json:"notes,omitempty"`
+}
+
+// EnvelopeTransferRequest moves money from one envelope to another on the same account
+type EnvelopeTransferRequest struct {
+	FromEnvelopeID string  `json:"from_envelope_id"`
+	ToEnvelopeID   string  `json:"to_envelope_id"`
+	Amount         float64 `json:"amount"`
+	Notes          string  `json:"notes,omitempty"`
+}
+
+// EnvelopeSummary shows how an account's current balance is split across envelopes
+type EnvelopeSummary struct {
+	AccountID      string      `json:"account_id"`
+	AccountName    string      `json:"account_name"`
+	Currency       string      `json:"currency"`
+	Balance        float64     `json:"balance"`         // Latest recorded balance
+	TotalAllocated float64     `json:"total_allocated"` // Sum of all envelopes
+	Unallocated    float64     `json:"unallocated"`     // Balance not assigned to any envelope
+	OverAllocated  bool        `json:"over_allocated"`  // true when the balance dropped below what is allocated
+	Envelopes      []*Envelope `json:"envelopes"`
+}
+
+// ListEnvelopeSummariesResponse represents envelope summaries across the user's accounts
+type ListEnvelopeSummariesResponse struct {
+	Summaries        []*EnvelopeSummary `json:"summaries"`
+	TotalAllocated   map[string]float64 `json:"total_allocated"`   // By currency
+	TotalUnallocated map[string]float64 `json:"total_unallocated"` // By currency
+}
+
+// ListEnvelopeMovementsResponse represents an account's envelope movement history
+type ListEnvelopeMovementsResponse struct {
+	Movements []*EnvelopeMovement `json:"movements"`
+}
+
+// CreateEnvelope adds an envelope to an account, optionally funding it from unallocated cash
+func (s *Service) CreateEnvelope(ctx context.Context, accountID string, req *CreateEnvelopeRequest) (*Envelope, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if req.TargetAmount != nil && *req.TargetAmount < 0 {
+		return nil, fmt.Errorf("target amount cannot be negative")
+	}
+	if req.Allocate < 0 {
+		return nil, fmt.Errorf("initial allocation cannot be negative")
+	}
+
+	now := time.Now()
+	env := &Envelope{
+		ID:           uuid.New().String(),
+		AccountID:    accountID,
+		Name:         name,
+		TargetAmount: req.TargetAmount,
+		Notes:        req.Notes,
+		CreatedAt:    now,
+		UpdatedAt:    &now,
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO envelopes (id, account_id, name, allocated, target_amount, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, 0, $4, $5, $6, $6)
+	`, env.ID, accountID, name, req.TargetAmount, req.Notes, now)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return nil, fmt.Errorf("an envelope named %q already exists on this account", name)
+		}
+		return nil, fmt.Errorf("failed to create envelope: %w", err)
+	}
+
+	if req.Allocate > 0 {
+		return s.AllocateEnvelope(ctx, env.ID, &AllocateEnvelopeRequest{Amount: req.Allocate, Notes: "Initial allocation"})
+	}
+
+	return env, nil
+}
+
+// GetEnvelope retrieves an envelope by ID
+func (s *Service) GetEnvelope(ctx context.Context, id string) (*Envelope, error) {
+	env, err := scanEnvelope(s.db.QueryRowContext(ctx, `
+		SELECT id, account_id, name, allocated, target_amount, notes, created_at, updated_at
+		FROM envelopes
+		WHERE id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("envelope not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get envelope: %w", err)
+	}
+
+	if err := s.verifyAccountOwnership(ctx, env.AccountID); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+// AllocateEnvelope moves money from unallocated cash into an envelope, or back out of it.
+// Allocations cannot exceed the account's unallocated cash and envelopes cannot go negative.
+func (s *Service) AllocateEnvelope(ctx context.Context, id string, req *AllocateEnvelopeRequest) (*Envelope, error) {
+	if req.Amount == 0 {
+		return nil, fmt.Errorf("amount must not be zero")
+	}
+
+	env, err := s.GetEnvelope(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Amount > 0 {
+		summary, err := s.GetEnvelopeSummary(ctx, env.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		if roundCents(req.Amount) > roundCents(summary.Unallocated) {
+			return nil, fmt.Errorf("insufficient unallocated cash: %.2f available", math.Max(summary.Unallocated, 0))
+		}
+	} else if roundCents(-req.Amount) > roundCents(env.Allocated) {
+		return nil, fmt.Errorf("cannot release %.2f from envelope holding %.2f", -req.Amount, env.Allocated)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE envelopes SET allocated = $1, updated_at = $2 WHERE id = $3
+	`, roundCents(env.Allocated+req.Amount), now, id); err != nil {
+		return nil, fmt.Errorf("failed to update envelope: %w", err)
+	}
+
+	movement := &EnvelopeMovement{
+		AccountID: env.AccountID,
+		Amount:    math.Abs(req.Amount),
+		Notes:     optionalNotes(req.Notes),
+		CreatedAt: now,
+	}
+	if req.Amount > 0 {
+		movement.ToEnvelopeID = &id
+	} else {
+		movement.FromEnvelopeID = &id
+	}
+	if err := recordEnvelopeMovement(ctx, tx, movement); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit allocation: %w", err)
+	}
+
+	return s.GetEnvelope(ctx, id)
+}
+
+// TransferBetweenEnvelopes moves allocated money between two envelopes on the same account
+func (s *Service) TransferBetweenEnvelopes(ctx context.Context, req *EnvelopeTransferRequest) (*EnvelopeMovement, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+	if req.FromEnvelopeID == req.ToEnvelopeID {
+		return nil, fmt.Errorf("cannot transfer an envelope to itself")
+	}
+
+	from, err := s.GetEnvelope(ctx, req.FromEnvelopeID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.GetEnvelope(ctx, req.ToEnvelopeID)
+	if err != nil {
+		return nil, err
+	}
+	if from.AccountID != to.AccountID {
+		return nil, fmt.Errorf("envelopes must belong to the same account")
+	}
+	if roundCents(req.Amount) > roundCents(from.Allocated) {
+		return nil, fmt.Errorf("insufficient funds in envelope %q: %.2f available", from.Name, from.Allocated)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE envelopes SET allocated = $1, updated_at = $2 WHERE id = $3
+	`, roundCents(from.Allocated-req.Amount), now, from.ID); err != nil {
+		return nil, fmt.Errorf("failed to update envelope: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE envelopes SET allocated = $1, updated_at = $2 WHERE id = $3
+	`, roundCents(to.Allocated+req.Amount), now, to.ID); err != nil {
+		return nil, fmt.Errorf("failed to update envelope: %w", err)
+	}
+	movement := &EnvelopeMovement{
+		AccountID:      from.AccountID,
+		FromEnvelopeID: &from.ID,
+		ToEnvelopeID:   &to.ID,
+		Amount:         req.Amount,
+		Notes:          optionalNotes(req.Notes),
+		CreatedAt:      now,
+	}
+	if err := recordEnvelopeMovement(ctx, tx, movement); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit envelope transfer: %w", err)
+	}
+
+	return movement, nil
+}
+
+// DeleteEnvelope removes an envelope, returning whatever it held to unallocated cash
+func (s *Service) DeleteEnvelope(ctx context.Context, id string) error {
+	if _, err := s.GetEnvelope(ctx, id); err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM envelopes WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete envelope: %w", err)
+	}
+	return nil
+}
+
+// GetEnvelopeSummary splits an account's latest balance into envelopes and unallocated cash
+func (s *Service) GetEnvelopeSummary(ctx context.Context, accountID string) (*EnvelopeSummary, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	summary := &EnvelopeSummary{AccountID: accountID, Envelopes: make([]*Envelope, 0)}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT name, currency FROM accounts WHERE id = $1
+	`, accountID).Scan(&summary.AccountName, &summary.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	balances, err := s.GetAccountBalances(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+	if latest := balanceAsOf(balances.Balances, time.Now()); latest != nil {
+		summary.Balance = latest.Amount
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, account_id, name, allocated, target_amount, notes, created_at, updated_at
+		FROM envelopes
+		WHERE account_id = $1
+		ORDER BY name
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get envelopes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		env, err := scanEnvelope(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan envelope: %w", err)
+		}
+		summary.Envelopes = append(summary.Envelopes, env)
+		summary.TotalAllocated += env.Allocated
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate envelopes: %w", err)
+	}
+
+	summary.TotalAllocated = roundCents(summary.TotalAllocated)
+	summary.Unallocated = roundCents(summary.Balance - summary.TotalAllocated)
+	summary.OverAllocated = summary.Unallocated < 0
+
+	return summary, nil
+}
+
+// ListEnvelopeSummaries returns envelope summaries for every account that has envelopes
+func (s *Service) ListEnvelopeSummaries(ctx context.Context) (*ListEnvelopeSummariesResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT a.id
+		FROM accounts a
+		JOIN envelopes e ON e.account_id = a.id
+		WHERE a.user_id = $1
+		ORDER BY a.name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts with envelopes: %w", err)
+	}
+
+	var accountIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		accountIDs = append(accountIDs, id)
+	}
+	rows.Close()
+
+	resp := &ListEnvelopeSummariesResponse{
+		Summaries:        make([]*EnvelopeSummary, 0, len(accountIDs)),
+		TotalAllocated:   make(map[string]float64),
+		TotalUnallocated: make(map[string]float64),
+	}
+	for _, id := range accountIDs {
+		summary, err := s.GetEnvelopeSummary(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		resp.Summaries = append(resp.Summaries, summary)
+		resp.TotalAllocated[summary.Currency] = roundCents(resp.TotalAllocated[summary.Currency] + summary.TotalAllocated)
+		resp.TotalUnallocated[summary.Currency] = roundCents(resp.TotalUnallocated[summary.Currency] + summary.Unallocated)
+	}
+
+	return resp, nil
+}
+
+// GetEnvelopeMovements returns the allocation and transfer history for an account's envelopes
+func (s *Service) GetEnvelopeMovements(ctx context.Context, accountID string) (*ListEnvelopeMovementsResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, account_id, from_envelope_id, to_envelope_id, amount, notes, created_at
+		FROM envelope_movements
+		WHERE account_id = $1
+		ORDER BY created_at DESC
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get envelope movements: %w", err)
+	}
+	defer rows.Close()
+
+	movements := make([]*EnvelopeMovement, 0)
+	for rows.Next() {
+		m := &EnvelopeMovement{}
+		if err := rows.Scan(&m.ID, &m.AccountID, &m.FromEnvelopeID, &m.ToEnvelopeID, &m.Amount, &m.Notes, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan envelope movement: %w", err)
+		}
+		movements = append(movements, m)
+	}
+
+	return &ListEnvelopeMovementsResponse{Movements: movements}, nil
+}
+
+// recordEnvelopeMovement writes a movement to the envelope history, filling in its ID
+func recordEnvelopeMovement(ctx context.Context, tx *sql.Tx, m *EnvelopeMovement) error {
+	m.ID = uuid.New().String()
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO envelope_movements (id, account_id, from_envelope_id, to_envelope_id, amount, notes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, m.ID, m.AccountID, m.FromEnvelopeID, m.ToEnvelopeID, m.Amount, m.Notes, m.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record envelope movement: %w", err)
+	}
+	return nil
+}
+
+// optionalNotes returns nil for empty notes so they are stored as NULL
+func optionalNotes(notes string) *string {
+	if notes == "" {
+		return nil
+	}
+	return &notes
+}
+
+// roundCents rounds an amount to two decimal places
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// scanEnvelope scans an envelope row
+func scanEnvelope(row interface{ Scan(...interface{}) error }) (*Envelope, error) {
+	env := &Envelope{}
+	err := row.Scan(&env.ID, &env.AccountID, &env.Name, &env.Allocated, &env.TargetAmount, &env.Notes, &env.CreatedAt, &env.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return env, nil
+}
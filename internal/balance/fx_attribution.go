@@ -0,0 +1,231 @@
+package balance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NetWorthAttribution splits one month's change in net worth, converted into a base
+// currency, into the change in each currency's own terms and the effect of exchange rates
+// moving. LocalChange + FXImpact = Change.
+type NetWorthAttribution struct {
+	Month             string                 `json:"month"` // YYYY-MM
+	OpeningNetWorth   float64                `json:"opening_net_worth"`
+	ClosingNetWorth   float64                `json:"closing_net_worth"`
+	Change            float64                `json:"change"`
+	LocalChange       float64                `json:"local_change"` // Market movement and flows, valued at the closing rate
+	FXImpact          float64                `json:"fx_impact"`    // Revaluation of the opening net worth as rates moved
+	ByCurrency        []*CurrencyAttribution `json:"by_currency"`
+	MissingCurrencies []string               `json:"missing_currencies,omitempty"` // Currencies left out for lack of an exchange rate
+}
+
+// CurrencyAttribution is one currency's part in a month's change. Net worth is in the
+// currency itself; LocalChange and FXImpact are in the base currency.
+type CurrencyAttribution struct {
+	Currency        string  `json:"currency"`
+	OpeningNetWorth float64 `json:"opening_net_worth"`
+	ClosingNetWorth float64 `json:"closing_net_worth"`
+	OpeningRate     float64 `json:"opening_rate"`
+	ClosingRate     float64 `json:"closing_rate"`
+	LocalChange     float64 `json:"local_change"`
+	FXImpact        float64 `json:"fx_impact"`
+}
+
+// datedRate is an exchange rate as of a date
+type datedRate struct {
+	date time.Time
+	rate float64
+}
+
+// rateHistory holds the stored exchange rates into a base currency by source currency,
+// oldest first
+type rateHistory map[string][]datedRate
+
+// rateOn returns the latest rate from currency into the base on or before date
+func (h rateHistory) rateOn(currency string, date time.Time) (float64, bool) {
+	rates := h[currency]
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].date.After(date) })
+	if i == 0 {
+		return 0, false
+	}
+	return rates[i-1].rate, true
+}
+
+// loadRateHistory loads every stored rate into baseCurrency, falling back to the inverse
+// of the opposite rate on days that only have that
+func (s *Service) loadRateHistory(ctx context.Context, baseCurrency string) (rateHistory, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT from_currency, to_currency, rate, date FROM exchange_rates
+		WHERE from_currency = $1 OR to_currency = $1
+	`, baseCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	type key struct {
+		currency string
+		day      string
+	}
+	direct := make(map[key]datedRate)
+	inverse := make(map[key]datedRate)
+	for rows.Next() {
+		var from, to string
+		var rate float64
+		var date time.Time
+		if err := rows.Scan(&from, &to, &rate, &date); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange rate: %w", err)
+		}
+		if rate <= 0 {
+			continue
+		}
+		if to == baseCurrency {
+			direct[key{from, snapshotDate(date)}] = datedRate{date: date, rate: rate}
+		} else {
+			inverse[key{to, snapshotDate(date)}] = datedRate{date: date, rate: 1 / rate}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for k, r := range inverse {
+		if _, ok := direct[k]; !ok {
+			direct[k] = r
+		}
+	}
+
+	history := make(rateHistory)
+	for k, r := range direct {
+		history[k.currency] = append(history[k.currency], r)
+	}
+	for _, rates := range history {
+		sort.Slice(rates, func(i, j int) bool { return rates[i].date.Before(rates[j].date) })
+	}
+	return history, nil
+}
+
+// attributeFXChanges walks the snapshots month by month, carrying each currency's latest
+// net worth forward, and attributes each month's converted change to local movement and
+// exchange rates. Each month is valued at the rates on its last day, or today for the
+// current month. The first month has nothing to compare against and is left out.
+func attributeFXChanges(snapshots []*NetWorthSnapshot, baseCurrency string, rates rateHistory, today time.Time) []*NetWorthAttribution {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	// Net worth by currency at the end of each month that has snapshots
+	monthEnds := make(map[string]map[string]float64)
+	months := make([]string, 0)
+	for _, snapshot := range snapshots {
+		month := snapshot.Date[:7]
+		if _, ok := monthEnds[month]; !ok {
+			monthEnds[month] = make(map[string]float64)
+			months = append(months, month)
+		}
+		monthEnds[month][snapshot.Currency] = snapshot.NetWorth
+	}
+	sort.Strings(months)
+
+	first, err := time.Parse("2006-01", months[0])
+	if err != nil {
+		return nil
+	}
+	last, err := time.Parse("2006-01", months[len(months)-1])
+	if err != nil {
+		return nil
+	}
+
+	attributions := make([]*NetWorthAttribution, 0)
+	opening := monthEnds[months[0]]
+	openingDate := monthEnd(first, today)
+	for month := first.AddDate(0, 1, 0); !month.After(last); month = month.AddDate(0, 1, 0) {
+		closing := make(map[string]float64, len(opening))
+		for currency, netWorth := range opening {
+			closing[currency] = netWorth
+		}
+		for currency, netWorth := range monthEnds[month.Format("2006-01")] {
+			closing[currency] = netWorth
+		}
+		closingDate := monthEnd(month, today)
+
+		attributions = append(attributions, attributeMonth(month.Format("2006-01"), opening, closing, func(currency string, date time.Time) (float64, bool) {
+			if currency == baseCurrency {
+				return 1, true
+			}
+			return rates.rateOn(currency, date)
+		}, openingDate, closingDate))
+
+		opening, openingDate = closing, closingDate
+	}
+	return attributions
+}
+
+// attributeMonth attributes the change between two months' net worth by currency. For a
+// currency going from N0 to N1 while its rate went from r0 to r1, the converted change
+// N1*r1 - N0*r0 is split into (N1-N0)*r1 locally and N0*(r1-r0) from exchange rates.
+func attributeMonth(month string, opening, closing map[string]float64, rateOn func(string, time.Time) (float64, bool), openingDate, closingDate time.Time) *NetWorthAttribution {
+	currencies := make([]string, 0, len(closing))
+	for currency := range closing {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	attribution := &NetWorthAttribution{Month: month, ByCurrency: make([]*CurrencyAttribution, 0, len(currencies))}
+	for _, currency := range currencies {
+		closingRate, hasClosing := rateOn(currency, closingDate)
+		openingRate, hasOpening := rateOn(currency, openingDate)
+		if !hasClosing {
+			attribution.MissingCurrencies = append(attribution.MissingCurrencies, currency)
+			continue
+		}
+		// A rate first stored after the opening date can't show a move, so the opening is
+		// valued at the closing rate
+		if !hasOpening {
+			openingRate = closingRate
+		}
+
+		c := &CurrencyAttribution{
+			Currency:        currency,
+			OpeningNetWorth: opening[currency],
+			ClosingNetWorth: closing[currency],
+			OpeningRate:     openingRate,
+			ClosingRate:     closingRate,
+			LocalChange:     roundCents((closing[currency] - opening[currency]) * closingRate),
+			FXImpact:        roundCents(opening[currency] * (closingRate - openingRate)),
+		}
+		attribution.ByCurrency = append(attribution.ByCurrency, c)
+		attribution.OpeningNetWorth += opening[currency] * openingRate
+		attribution.ClosingNetWorth += closing[currency] * closingRate
+		attribution.LocalChange += c.LocalChange
+		attribution.FXImpact += c.FXImpact
+	}
+
+	attribution.OpeningNetWorth = roundCents(attribution.OpeningNetWorth)
+	attribution.ClosingNetWorth = roundCents(attribution.ClosingNetWorth)
+	attribution.LocalChange = roundCents(attribution.LocalChange)
+	attribution.FXImpact = roundCents(attribution.FXImpact)
+	attribution.Change = roundCents(attribution.LocalChange + attribution.FXImpact)
+	return attribution
+}
+
+// monthEnd returns the last day of the month, or today if the month hasn't ended
+func monthEnd(month, today time.Time) time.Time {
+	end := time.Date(month.Year(), month.Month()+1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)
+	if end.After(today) {
+		return today
+	}
+	return end
+}
+
+// ValidBaseCurrency normalizes a base currency and checks it is supported
+func ValidBaseCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(currency)
+	switch currency {
+	case "CAD", "USD", "INR":
+		return currency, nil
+	}
+	return "", fmt.Errorf("invalid base currency: %s", currency)
+}
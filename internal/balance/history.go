@@ -0,0 +1,174 @@
+package balance
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Aggregation groups balance history into periods
+type Aggregation string
+
+const (
+	AggregationNone  Aggregation = ""
+	AggregationMonth Aggregation = "month"
+	AggregationYear  Aggregation = "year"
+)
+
+// periodLengths maps each aggregation to the length of the date prefix that names its
+// period. Dates are stored as Go time strings, which SQLite's date functions can't parse,
+// but their leading YYYY-MM-DD sorts and groups correctly.
+var periodLengths = map[Aggregation]int{
+	AggregationMonth: len("2006-01"),
+	AggregationYear:  len("2006"),
+}
+
+// BalanceHistoryRequest filters and optionally aggregates an account's balance history
+type BalanceHistoryRequest struct {
+	From      *time.Time  `json:"from,omitempty"` // Inclusive
+	To        *time.Time  `json:"to,omitempty"`   // Inclusive
+	Aggregate Aggregation `json:"aggregate,omitempty"`
+
+	// BaseCurrency attributes monthly net worth changes to FX moves; net worth history only
+	BaseCurrency string `json:"base_currency,omitempty"`
+}
+
+// BalancePeriod summarizes the balances recorded within one period
+type BalancePeriod struct {
+	Period    string  `json:"period"` // YYYY-MM or YYYY
+	Open      float64 `json:"open"`   // Earliest balance in the period
+	Close     float64 `json:"close"`  // Latest balance in the period
+	Min       float64 `json:"min"`
+	Max       float64 `json:"max"`
+	Count     int     `json:"count"`
+	FirstDate string  `json:"first_date"`
+	LastDate  string  `json:"last_date"`
+}
+
+// BalanceHistoryResponse is either the raw balances in range or their aggregated periods
+type BalanceHistoryResponse struct {
+	AccountID string           `json:"account_id"`
+	Aggregate Aggregation      `json:"aggregate,omitempty"`
+	Balances  []*Balance       `json:"balances,omitempty"`
+	Periods   []*BalancePeriod `json:"periods,omitempty"`
+}
+
+// GetBalanceHistory returns an account's balances between optional from/to dates. With an
+// aggregation set, balances are grouped into periods in SQL so long histories stay small.
+func (s *Service) GetBalanceHistory(ctx context.Context, accountID string, req *BalanceHistoryRequest) (*BalanceHistoryResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	if req.From != nil && req.To != nil && req.To.Before(*req.From) {
+		return nil, fmt.Errorf("to date must be on or after from date")
+	}
+
+	from, to := "", ""
+	if req.From != nil {
+		from = req.From.Format("2006-01-02")
+	}
+	if req.To != nil {
+		to = req.To.Format("2006-01-02")
+	}
+
+	response := &BalanceHistoryResponse{AccountID: accountID, Aggregate: req.Aggregate}
+
+	if req.Aggregate == AggregationNone {
+		balances, err := s.listBalancesInRange(ctx, accountID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		response.Balances = balances
+		return response, nil
+	}
+
+	length, ok := periodLengths[req.Aggregate]
+	if !ok {
+		return nil, fmt.Errorf("invalid aggregate: %s", req.Aggregate)
+	}
+
+	periods, err := s.aggregateBalances(ctx, accountID, from, to, length)
+	if err != nil {
+		return nil, err
+	}
+	response.Periods = periods
+	return response, nil
+}
+
+// listBalancesInRange lists balances between from and to (YYYY-MM-DD, empty for open-ended)
+func (s *Service) listBalancesInRange(ctx context.Context, accountID, from, to string) ([]*Balance, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, account_id, amount, date, notes, source, is_opening, created_at, updated_at
+		FROM balances
+		WHERE account_id = $1
+		  AND ($2 = '' OR substr(date, 1, 10) >= $2)
+		  AND ($3 = '' OR substr(date, 1, 10) <= $3)
+		ORDER BY date DESC
+	`, accountID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make([]*Balance, 0)
+	for rows.Next() {
+		balance := &Balance{}
+		err := rows.Scan(
+			&balance.ID,
+			&balance.AccountID,
+			&balance.Amount,
+			&balance.Date,
+			&balance.Notes,
+			&balance.Source,
+			&balance.IsOpening,
+			&balance.CreatedAt,
+			&balance.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan balance: %w", err)
+		}
+		balances = append(balances, balance)
+	}
+
+	return balances, rows.Err()
+}
+
+// aggregateBalances groups balances into periods named by a date prefix of the given length,
+// taking the open and close from the earliest and latest balance of each period
+func (s *Service) aggregateBalances(ctx context.Context, accountID, from, to string, length int) ([]*BalancePeriod, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT period,
+		       MIN(open_amount), MIN(close_amount),
+		       MIN(amount), MAX(amount), COUNT(*),
+		       MIN(day), MAX(day)
+		FROM (
+			SELECT substr(date, 1, $4) AS period,
+			       substr(date, 1, 10) AS day,
+			       amount,
+			       FIRST_VALUE(amount) OVER (PARTITION BY substr(date, 1, $4) ORDER BY date ASC) AS open_amount,
+			       FIRST_VALUE(amount) OVER (PARTITION BY substr(date, 1, $4) ORDER BY date DESC) AS close_amount
+			FROM balances
+			WHERE account_id = $1
+			  AND ($2 = '' OR substr(date, 1, 10) >= $2)
+			  AND ($3 = '' OR substr(date, 1, 10) <= $3)
+		)
+		GROUP BY period
+		ORDER BY period ASC
+	`, accountID, from, to, length)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate balances: %w", err)
+	}
+	defer rows.Close()
+
+	periods := make([]*BalancePeriod, 0)
+	for rows.Next() {
+		p := &BalancePeriod{}
+		if err := rows.Scan(&p.Period, &p.Open, &p.Close, &p.Min, &p.Max, &p.Count, &p.FirstDate, &p.LastDate); err != nil {
+			return nil, fmt.Errorf("failed to scan balance period: %w", err)
+		}
+		periods = append(periods, p)
+	}
+
+	return periods, rows.Err()
+}
@@ -0,0 +1,415 @@
+package balance
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/background"
+	"money/internal/database"
+)
+
+// netWorthRecomputeInterval is how often queued net worth rebuilds are processed
+const netWorthRecomputeInterval = 15 * time.Second
+
+// NetWorthSnapshot is a user's net worth in one currency at the end of a day
+type NetWorthSnapshot struct {
+	Date        string    `json:"date"` // YYYY-MM-DD
+	Currency    string    `json:"currency"`
+	Assets      float64   `json:"assets"`
+	Liabilities float64   `json:"liabilities"` // Positive amount owed
+	NetWorth    float64   `json:"net_worth"`
+	ComputedAt  time.Time `json:"computed_at"`
+}
+
+// NetWorthHistoryResponse is the stored snapshot history. RecomputePending is set while a
+// rebuild is queued, so clients know the history is about to change.
+type NetWorthHistoryResponse struct {
+	Snapshots        []*NetWorthSnapshot    `json:"snapshots"`
+	RecomputePending bool                   `json:"recompute_pending"`
+	LastEventID      int64                  `json:"last_event_id"`           // Poll events after this ID to learn of rebuilds
+	BaseCurrency     string                 `json:"base_currency,omitempty"` // Set when attribution was requested
+	Attribution      []*NetWorthAttribution `json:"attribution,omitempty"`   // Month-over-month change split into local and FX movement
+}
+
+// NetWorthEvent records a finished rebuild. Clients holding a chart that covers FromDate
+// or later should refetch the history.
+type NetWorthEvent struct {
+	ID        int64     `json:"id"`
+	FromDate  string    `json:"from_date"`
+	Snapshots int       `json:"snapshots"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListNetWorthEventsResponse represents a list of rebuild events
+type ListNetWorthEventsResponse struct {
+	Events []*NetWorthEvent `json:"events"`
+}
+
+// RecomputeNetWorthRequest queues a rebuild of snapshots from a date, or of the whole
+// history when no date is given
+type RecomputeNetWorthRequest struct {
+	From *time.Time `json:"from,omitempty"`
+}
+
+// RecomputeNetWorthResponse reports the queued rebuild
+type RecomputeNetWorthResponse struct {
+	Queued   bool   `json:"queued"`
+	FromDate string `json:"from_date"`
+}
+
+// snapshotDate names the day a balance date falls on, matching the date prefix stored in
+// the balances table. The zero time rebuilds the whole history.
+func snapshotDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// QueueNetWorthRecompute queues a rebuild of the user's snapshots on or after from, within
+// the caller's transaction so the rebuild is only queued if the balance change commits
+func QueueNetWorthRecompute(ctx context.Context, db database.Querier, userID string, from time.Time) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO net_worth_recompute_queue (user_id, from_date, requested_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			from_date = MIN(net_worth_recompute_queue.from_date, excluded.from_date),
+			requested_at = excluded.requested_at
+	`, userID, snapshotDate(from), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to queue net worth recompute: %w", err)
+	}
+	// The balance change also dates the user's materialized summaries
+	return InvalidateSummaries(ctx, db, userID)
+}
+
+// QueueAccountNetWorthRecompute queues a rebuild for the owner of an account whose balance
+// on from changed
+func QueueAccountNetWorthRecompute(ctx context.Context, db database.Querier, accountID string, from time.Time) error {
+	var userID string
+	err := db.QueryRowContext(ctx, `SELECT user_id FROM accounts WHERE id = $1`, accountID).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find account owner: %w", err)
+	}
+	return QueueNetWorthRecompute(ctx, db, userID, from)
+}
+
+// GetNetWorthHistory returns the user's stored snapshots between optional from/to dates.
+// With a base currency, each month's change is also attributed to local movement and FX.
+func (s *Service) GetNetWorthHistory(ctx context.Context, req *BalanceHistoryRequest) (*NetWorthHistoryResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	baseCurrency := ""
+	if req.BaseCurrency != "" {
+		var err error
+		if baseCurrency, err = ValidBaseCurrency(req.BaseCurrency); err != nil {
+			return nil, err
+		}
+	}
+
+	from, to := "", ""
+	if req.From != nil {
+		from = snapshotDate(*req.From)
+	}
+	if req.To != nil {
+		to = snapshotDate(*req.To)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT date, currency, assets, liabilities, net_worth, computed_at
+		FROM net_worth_snapshots
+		WHERE user_id = $1
+		  AND ($2 = '' OR date >= $2)
+		  AND ($3 = '' OR date <= $3)
+		ORDER BY date, currency
+	`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get net worth history: %w", err)
+	}
+	defer rows.Close()
+
+	response := &NetWorthHistoryResponse{Snapshots: make([]*NetWorthSnapshot, 0)}
+	for rows.Next() {
+		snapshot := &NetWorthSnapshot{}
+		err := rows.Scan(&snapshot.Date, &snapshot.Currency, &snapshot.Assets, &snapshot.Liabilities,
+			&snapshot.NetWorth, &snapshot.ComputedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan net worth snapshot: %w", err)
+		}
+		response.Snapshots = append(response.Snapshots, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM net_worth_recompute_queue WHERE user_id = $1),
+		       COALESCE((SELECT MAX(id) FROM net_worth_events WHERE user_id = $1), 0)
+	`, userID).Scan(&response.RecomputePending, &response.LastEventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get net worth recompute state: %w", err)
+	}
+
+	if baseCurrency != "" {
+		rates, err := s.loadRateHistory(ctx, baseCurrency)
+		if err != nil {
+			return nil, err
+		}
+		response.BaseCurrency = baseCurrency
+		response.Attribution = attributeFXChanges(response.Snapshots, baseCurrency, rates, time.Now())
+	}
+
+	return response, nil
+}
+
+// ListNetWorthEvents lists the user's rebuild events after the given event ID, oldest first
+func (s *Service) ListNetWorthEvents(ctx context.Context, afterID int64) (*ListNetWorthEventsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, from_date, snapshots, created_at
+		FROM net_worth_events
+		WHERE user_id = $1 AND id > $2
+		ORDER BY id
+		LIMIT 100
+	`, userID, afterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list net worth events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]*NetWorthEvent, 0)
+	for rows.Next() {
+		event := &NetWorthEvent{}
+		if err := rows.Scan(&event.ID, &event.FromDate, &event.Snapshots, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan net worth event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return &ListNetWorthEventsResponse{Events: events}, rows.Err()
+}
+
+// RequestNetWorthRecompute queues a rebuild of the current user's snapshots
+func (s *Service) RequestNetWorthRecompute(ctx context.Context, req *RecomputeNetWorthRequest) (*RecomputeNetWorthResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	var from time.Time
+	if req.From != nil {
+		from = *req.From
+	}
+	if err := QueueNetWorthRecompute(ctx, s.db, userID, from); err != nil {
+		return nil, err
+	}
+	return &RecomputeNetWorthResponse{Queued: true, FromDate: snapshotDate(from)}, nil
+}
+
+// ProcessNetWorthRecomputes rebuilds the snapshots of every queued user and returns how
+// many rebuilds ran. A request queued while its rebuild was running stays queued for the
+// next pass.
+func (s *Service) ProcessNetWorthRecomputes(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id, from_date, CAST(requested_at AS TEXT) FROM net_worth_recompute_queue ORDER BY requested_at
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list queued net worth recomputes: %w", err)
+	}
+	// requested_at is read back as the stored text so the dequeue matches it exactly
+	type queued struct {
+		userID, from, requestedAt string
+	}
+	var pending []queued
+	for rows.Next() {
+		var q queued
+		if err := rows.Scan(&q.userID, &q.from, &q.requestedAt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan queued net worth recompute: %w", err)
+		}
+		pending = append(pending, q)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, q := range pending {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := s.recomputeNetWorth(ctx, q.userID, q.from, q.requestedAt); err != nil {
+			log.Printf("ERROR: net worth recompute failed: user_id=%s from=%s error=%v", q.userID, q.from, err)
+			continue
+		}
+		processed++
+
+		// Rebuild the summaries the same writes invalidated, so the dashboard reads them ready
+		if s.refreshSummaries != nil {
+			if err := s.refreshSummaries(ctx, q.userID); err != nil {
+				log.Printf("ERROR: summary refresh failed: user_id=%s error=%v", q.userID, err)
+			}
+		}
+	}
+	return processed, nil
+}
+
+// StartNetWorthRecompute processes queued snapshot rebuilds until jobs drains
+func (s *Service) StartNetWorthRecompute(jobs *background.Group) {
+	jobs.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(netWorthRecomputeInterval)
+		defer ticker.Stop()
+
+		for {
+			jobs.Exclusive(ctx, "net_worth_recompute", netWorthRecomputeInterval, func(ctx context.Context) {
+				if _, err := s.ProcessNetWorthRecomputes(ctx); err != nil {
+					log.Printf("ERROR: net worth recompute failed: %v", err)
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// recomputeNetWorth replaces the user's snapshots on or after from with ones rebuilt from
+// the balance history, records an event for clients and dequeues the request, all in one
+// transaction
+func (s *Service) recomputeNetWorth(ctx context.Context, userID, from, requestedAt string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	snapshots, err := buildNetWorthSnapshots(ctx, tx, userID, from)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM net_worth_snapshots WHERE user_id = $1 AND date >= $2
+	`, userID, from); err != nil {
+		return fmt.Errorf("failed to clear net worth snapshots: %w", err)
+	}
+
+	now := time.Now()
+	for _, snapshot := range snapshots {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO net_worth_snapshots (user_id, date, currency, assets, liabilities, net_worth, computed_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, userID, snapshot.Date, snapshot.Currency, snapshot.Assets, snapshot.Liabilities, snapshot.NetWorth, now)
+		if err != nil {
+			return fmt.Errorf("failed to save net worth snapshot: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO net_worth_events (user_id, from_date, snapshots, created_at) VALUES ($1, $2, $3, $4)
+	`, userID, from, len(snapshots), now); err != nil {
+		return fmt.Errorf("failed to record net worth event: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM net_worth_recompute_queue WHERE user_id = $1 AND requested_at = $2
+	`, userID, requestedAt); err != nil {
+		return fmt.Errorf("failed to dequeue net worth recompute: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// buildNetWorthSnapshots replays the user's balance history, carrying each account's
+// latest balance forward, and returns a snapshot per currency for every day on or after
+// from that a balance was recorded
+func buildNetWorthSnapshots(ctx context.Context, db database.Querier, userID, from string) ([]*NetWorthSnapshot, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT a.id, a.currency, a.is_asset, substr(b.date, 1, 10) AS day, b.amount
+		FROM accounts a
+		JOIN balances b ON b.account_id = a.id
+		WHERE a.user_id = $1 AND a.exclude_from_net_worth = 0
+		ORDER BY day, a.id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance history: %w", err)
+	}
+	defer rows.Close()
+
+	type position struct {
+		currency string
+		isAsset  bool
+		amount   float64
+	}
+	positions := make(map[string]*position)
+	snapshots := make([]*NetWorthSnapshot, 0)
+
+	// snapshotDay totals the carried-forward balances at the end of a day
+	snapshotDay := func(day string) {
+		byCurrency := make(map[string]*NetWorthSnapshot)
+		for _, p := range positions {
+			snapshot, ok := byCurrency[p.currency]
+			if !ok {
+				snapshot = &NetWorthSnapshot{Date: day, Currency: p.currency}
+				byCurrency[p.currency] = snapshot
+			}
+			if p.isAsset {
+				snapshot.Assets += p.amount
+			} else {
+				snapshot.Liabilities += math.Abs(p.amount)
+			}
+		}
+		currencies := make([]string, 0, len(byCurrency))
+		for currency := range byCurrency {
+			currencies = append(currencies, currency)
+		}
+		sort.Strings(currencies)
+		for _, currency := range currencies {
+			snapshot := byCurrency[currency]
+			snapshot.Assets = math.Round(snapshot.Assets*100) / 100
+			snapshot.Liabilities = math.Round(snapshot.Liabilities*100) / 100
+			snapshot.NetWorth = math.Round((snapshot.Assets-snapshot.Liabilities)*100) / 100
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	currentDay := ""
+	for rows.Next() {
+		var accountID, currency, day string
+		var isAsset bool
+		var amount float64
+		if err := rows.Scan(&accountID, &currency, &isAsset, &day, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan balance: %w", err)
+		}
+		if day != currentDay && currentDay >= from && currentDay != "" {
+			snapshotDay(currentDay)
+		}
+		currentDay = day
+		positions[accountID] = &position{currency: currency, isAsset: isAsset, amount: amount}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read balance history: %w", err)
+	}
+	if currentDay != "" && currentDay >= from {
+		snapshotDay(currentDay)
+	}
+
+	return snapshots, nil
+}
@@ -0,0 +1,203 @@
+package balance
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// OpeningBalanceRequest sets the balance an account's history starts from
+type OpeningBalanceRequest struct {
+	Amount float64   `json:"amount"`
+	Date   time.Time `json:"date"`
+	Notes  string    `json:"notes,omitempty"`
+}
+
+// CorrectionRequest records a known historical balance after later balances exist
+type CorrectionRequest struct {
+	Amount float64   `json:"amount"`
+	Date   time.Time `json:"date"`
+	Notes  string    `json:"notes,omitempty"`
+}
+
+// CorrectionResponse is the corrected balance and the reconciliations recomputed from it
+type CorrectionResponse struct {
+	Balance         *Balance          `json:"balance"`
+	WasUpdate       bool              `json:"was_update"`
+	PreviousAmount  *float64          `json:"previous_amount,omitempty"`
+	Reconciliations []*Reconciliation `json:"reconciliations"` // reconciliations whose recorded balance changed
+}
+
+// openingBalance returns the account's opening balance, if one is set
+func openingBalance(balances []*Balance) *Balance {
+	for _, b := range balances {
+		if b.IsOpening {
+			return b
+		}
+	}
+	return nil
+}
+
+// SetOpeningBalance records the balance an account started with. The opening balance must be
+// the earliest entry in the account's history; an earlier previous opening balance is replaced.
+func (s *Service) SetOpeningBalance(ctx context.Context, accountID string, req *OpeningBalanceRequest) (*CorrectionResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	if req.Date.IsZero() {
+		return nil, fmt.Errorf("date is required")
+	}
+
+	balances, err := s.GetAccountBalances(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+	for _, b := range balances.Balances {
+		if b.Date.Before(req.Date) && !b.IsOpening {
+			return nil, fmt.Errorf("opening balance must be on or before the earliest balance (%s)", b.Date.Format("2006-01-02"))
+		}
+	}
+
+	notes := req.Notes
+	if notes == "" {
+		notes = "Opening balance"
+	}
+	resp, err := s.Create(ctx, &CreateBalanceRequest{
+		AccountID: accountID,
+		Amount:    req.Amount,
+		Date:      req.Date,
+		Notes:     notes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save opening balance: %w", err)
+	}
+
+	if previous := openingBalance(balances.Balances); previous != nil && previous.Date.Before(req.Date) {
+		// The old opening balance would sit before the new one; drop it rather than leave a stray entry
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM balances WHERE id = $1`, previous.ID); err != nil {
+			return nil, fmt.Errorf("failed to remove previous opening balance: %w", err)
+		}
+		if err := QueueAccountNetWorthRecompute(ctx, s.db, accountID, previous.Date); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE balances SET is_opening = CASE WHEN id = $1 THEN 1 ELSE 0 END
+		WHERE account_id = $2
+	`, resp.Balance.ID, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark opening balance: %w", err)
+	}
+	resp.Balance.IsOpening = true
+
+	return s.finishCorrection(ctx, accountID, resp)
+}
+
+// CorrectBalance inserts or replaces a backdated balance and recomputes the reconciliations
+// that depend on it, so historical charts and statement checks reflect the corrected value
+func (s *Service) CorrectBalance(ctx context.Context, accountID string, req *CorrectionRequest) (*CorrectionResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	if req.Date.IsZero() {
+		return nil, fmt.Errorf("date is required")
+	}
+	if req.Date.After(time.Now()) {
+		return nil, fmt.Errorf("correction date cannot be in the future")
+	}
+
+	balances, err := s.GetAccountBalances(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+	if opening := openingBalance(balances.Balances); opening != nil && req.Date.Before(opening.Date) {
+		return nil, fmt.Errorf("correction date is before the opening balance (%s)", opening.Date.Format("2006-01-02"))
+	}
+
+	notes := req.Notes
+	if notes == "" {
+		notes = "Balance correction"
+	}
+	resp, err := s.Create(ctx, &CreateBalanceRequest{
+		AccountID: accountID,
+		Amount:    req.Amount,
+		Date:      req.Date,
+		Notes:     notes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save correction: %w", err)
+	}
+
+	return s.finishCorrection(ctx, accountID, resp)
+}
+
+// finishCorrection recomputes dependent reconciliations after a historical balance changed
+func (s *Service) finishCorrection(ctx context.Context, accountID string, resp *CreateBalanceResponse) (*CorrectionResponse, error) {
+	recomputed, err := s.recomputeReconciliations(ctx, accountID, resp.Balance.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CorrectionResponse{
+		Balance:         resp.Balance,
+		WasUpdate:       resp.WasUpdate,
+		PreviousAmount:  resp.PreviousAmount,
+		Reconciliations: recomputed,
+	}, nil
+}
+
+// recomputeReconciliations refreshes the recorded balance of open and matched reconciliations
+// dated on or after from. Adjusted reconciliations are left alone as they are already resolved.
+func (s *Service) recomputeReconciliations(ctx context.Context, accountID string, from time.Time) ([]*Reconciliation, error) {
+	balances, err := s.GetAccountBalances(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+	history, err := s.GetReconciliations(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	recomputed := make([]*Reconciliation, 0)
+	for _, rec := range history.Reconciliations {
+		if rec.Status == ReconciliationStatusAdjusted || rec.StatementDate.Before(from) {
+			continue
+		}
+
+		recorded := balanceAsOf(balances.Balances, rec.StatementDate)
+		if recorded == nil {
+			continue
+		}
+		if rec.RecordedBalance != nil && *rec.RecordedBalance == recorded.Amount && rec.RecordedBalanceDate != nil && rec.RecordedBalanceDate.Equal(recorded.Date) {
+			continue
+		}
+
+		rec.RecordedBalance = &recorded.Amount
+		rec.RecordedBalanceDate = &recorded.Date
+		rec.Discrepancy = math.Round((rec.StatementBalance-recorded.Amount)*100) / 100
+		rec.Status = ReconciliationStatusDiscrepancy
+		rec.ResolvedAt = nil
+		if math.Abs(rec.Discrepancy) < reconciliationTolerance {
+			rec.Discrepancy = 0
+			rec.Status = ReconciliationStatusMatched
+			rec.ResolvedAt = &now
+		}
+
+		_, err := s.db.ExecContext(ctx, `
+			UPDATE reconciliations
+			SET recorded_balance = $1, recorded_balance_date = $2, discrepancy = $3, status = $4, resolved_at = $5
+			WHERE id = $6
+		`, rec.RecordedBalance, rec.RecordedBalanceDate, rec.Discrepancy, rec.Status, rec.ResolvedAt, rec.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recompute reconciliation: %w", err)
+		}
+		recomputed = append(recomputed, rec)
+	}
+
+	return recomputed, nil
+}
@@ -0,0 +1,83 @@
+package balance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/civil"
+)
+
+// QuickUpdateRequest sets today's balance for each account, keyed by account ID
+type QuickUpdateRequest struct {
+	Balances map[string]float64 `json:"balances"`
+}
+
+// QuickUpdateResponse is the user's net worth once the balances are recorded. The snapshots
+// are computed on the spot rather than waiting for the background rebuild.
+type QuickUpdateResponse struct {
+	Date              civil.Date          `json:"date"`
+	Updated           int                 `json:"updated"`
+	NetWorth          []*NetWorthSnapshot `json:"net_worth"`                    // One per currency
+	BaseCurrency      string              `json:"base_currency,omitempty"`      // Set when a base currency was requested
+	TotalNetWorth     *float64            `json:"total_net_worth,omitempty"`    // All currencies converted into the base currency
+	MissingCurrencies []string            `json:"missing_currencies,omitempty"` // Left out of the total for lack of an exchange rate
+}
+
+// QuickUpdate records today's balance for each account in one transaction and returns the
+// resulting net worth, converted into baseCurrency when one is given
+func (s *Service) QuickUpdate(ctx context.Context, req *QuickUpdateRequest, baseCurrency string) (*QuickUpdateResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	accountIDs := make([]string, 0, len(req.Balances))
+	for accountID := range req.Balances {
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Strings(accountIDs)
+	updates := make([]*BalanceUpdate, 0, len(accountIDs))
+	for _, accountID := range accountIDs {
+		updates = append(updates, &BalanceUpdate{AccountID: accountID, Amount: req.Balances[accountID]})
+	}
+
+	today := civil.TodayIn(ctx)
+	if _, err := s.saveBalances(ctx, today.Time, updates); err != nil {
+		return nil, err
+	}
+
+	// Today has a balance now, so the rebuild from today yields exactly today's snapshots
+	snapshots, err := buildNetWorthSnapshots(ctx, s.db, userID, today.String())
+	if err != nil {
+		return nil, err
+	}
+	resp := &QuickUpdateResponse{Date: today, Updated: len(updates), NetWorth: snapshots}
+
+	if baseCurrency != "" {
+		rates, err := s.loadRateHistory(ctx, baseCurrency)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		total := 0.0
+		for _, snapshot := range snapshots {
+			rate, ok := 1.0, true
+			if snapshot.Currency != baseCurrency {
+				rate, ok = rates.rateOn(snapshot.Currency, now)
+			}
+			if !ok {
+				resp.MissingCurrencies = append(resp.MissingCurrencies, snapshot.Currency)
+				continue
+			}
+			total += snapshot.NetWorth * rate
+		}
+		total = roundCents(total)
+		resp.BaseCurrency = baseCurrency
+		resp.TotalNetWorth = &total
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,237 @@
+package balance
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+)
+
+// ReconciliationStatus represents the outcome of a statement reconciliation
+type ReconciliationStatus string
+
+const (
+	ReconciliationStatusMatched     ReconciliationStatus = "matched"
+	ReconciliationStatusDiscrepancy ReconciliationStatus = "discrepancy"
+	ReconciliationStatusAdjusted    ReconciliationStatus = "adjusted"
+)
+
+// reconciliationTolerance is the largest difference treated as a match (rounding)
+const reconciliationTolerance = 0.005
+
+// Reconciliation compares a statement balance against recorded balances
+type Reconciliation struct {
+	ID                  string               `json:"id"`
+	AccountID           string               `json:"account_id"`
+	StatementDate       time.Time            `json:"statement_date"`
+	StatementBalance    float64              `json:"statement_balance"`
+	RecordedBalance     *float64             `json:"recorded_balance,omitempty"`
+	RecordedBalanceDate *time.Time           `json:"recorded_balance_date,omitempty"`
+	Discrepancy         float64              `json:"discrepancy"`
+	Status              ReconciliationStatus `json:"status"`
+	AdjustmentBalanceID *string              `json:"adjustment_balance_id,omitempty"`
+	Notes               *string              `json:"notes,omitempty"`
+	CreatedAt           time.Time            `json:"created_at"`
+	ResolvedAt          *time.Time           `json:"resolved_at,omitempty"`
+}
+
+// ReconcileRequest represents a statement to reconcile against an account
+type ReconcileRequest struct {
+	StatementDate    time.Time `json:"statement_date"`
+	StatementBalance float64   `json:"statement_balance"`
+	Notes            *string   `json:"notes,omitempty"`
+}
+
+// ListReconciliationsResponse represents an account's reconciliation history
+type ListReconciliationsResponse struct {
+	Reconciliations []*Reconciliation `json:"reconciliations"`
+}
+
+// verifyAccountOwnership checks that the account belongs to the current user
+func (s *Service) verifyAccountOwnership(ctx context.Context, accountID string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	var ownerID string
+	err := s.db.QueryRowContext(ctx, "SELECT user_id FROM accounts WHERE id = $1", accountID).Scan(&ownerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("account not found")
+		}
+		return fmt.Errorf("failed to verify account ownership: %w", err)
+	}
+
+	if ownerID != userID {
+		return fmt.Errorf("access denied: account does not belong to user")
+	}
+
+	return nil
+}
+
+// balanceAsOf returns the latest balance dated on or before the given date
+func balanceAsOf(balances []*Balance, date time.Time) *Balance {
+	var found *Balance
+	for _, b := range balances {
+		if b.Date.After(date) {
+			continue
+		}
+		if found == nil || b.Date.After(found.Date) {
+			found = b
+		}
+	}
+	return found
+}
+
+// Reconcile compares a statement balance with the recorded balance on the statement date
+// and stores the result in the account's reconciliation history
+func (s *Service) Reconcile(ctx context.Context, accountID string, req *ReconcileRequest) (*Reconciliation, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	if req.StatementDate.IsZero() {
+		return nil, fmt.Errorf("statement date is required")
+	}
+
+	balances, err := s.GetAccountBalances(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+
+	rec := &Reconciliation{
+		ID:               uuid.New().String(),
+		AccountID:        accountID,
+		StatementDate:    req.StatementDate,
+		StatementBalance: req.StatementBalance,
+		Discrepancy:      req.StatementBalance,
+		Status:           ReconciliationStatusDiscrepancy,
+		Notes:            req.Notes,
+		CreatedAt:        time.Now(),
+	}
+
+	if recorded := balanceAsOf(balances.Balances, req.StatementDate); recorded != nil {
+		rec.RecordedBalance = &recorded.Amount
+		rec.RecordedBalanceDate = &recorded.Date
+		rec.Discrepancy = math.Round((req.StatementBalance-recorded.Amount)*100) / 100
+	}
+
+	if math.Abs(rec.Discrepancy) < reconciliationTolerance && rec.RecordedBalance != nil {
+		rec.Discrepancy = 0
+		rec.Status = ReconciliationStatusMatched
+		rec.ResolvedAt = &rec.CreatedAt
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO reconciliations (
+			id, account_id, statement_date, statement_balance, recorded_balance, recorded_balance_date,
+			discrepancy, status, notes, created_at, resolved_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, rec.ID, rec.AccountID, rec.StatementDate, rec.StatementBalance, rec.RecordedBalance, rec.RecordedBalanceDate,
+		rec.Discrepancy, rec.Status, rec.Notes, rec.CreatedAt, rec.ResolvedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save reconciliation: %w", err)
+	}
+
+	return rec, nil
+}
+
+// GetReconciliations returns the reconciliation history for an account
+func (s *Service) GetReconciliations(ctx context.Context, accountID string) (*ListReconciliationsResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, account_id, statement_date, statement_balance, recorded_balance, recorded_balance_date,
+			discrepancy, status, adjustment_balance_id, notes, created_at, resolved_at
+		FROM reconciliations
+		WHERE account_id = $1
+		ORDER BY statement_date DESC, created_at DESC
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reconciliations: %w", err)
+	}
+	defer rows.Close()
+
+	reconciliations := make([]*Reconciliation, 0)
+	for rows.Next() {
+		rec, err := scanReconciliation(rows)
+		if err != nil {
+			return nil, err
+		}
+		reconciliations = append(reconciliations, rec)
+	}
+
+	return &ListReconciliationsResponse{Reconciliations: reconciliations}, nil
+}
+
+// PostAdjustment records the statement balance on the statement date to clear a discrepancy
+func (s *Service) PostAdjustment(ctx context.Context, reconciliationID string) (*Reconciliation, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, account_id, statement_date, statement_balance, recorded_balance, recorded_balance_date,
+			discrepancy, status, adjustment_balance_id, notes, created_at, resolved_at
+		FROM reconciliations
+		WHERE id = $1
+	`, reconciliationID)
+	rec, err := scanReconciliation(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("reconciliation not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyAccountOwnership(ctx, rec.AccountID); err != nil {
+		return nil, err
+	}
+
+	if rec.Status != ReconciliationStatusDiscrepancy {
+		return nil, fmt.Errorf("reconciliation has no open discrepancy")
+	}
+
+	resp, err := s.Create(ctx, &CreateBalanceRequest{
+		AccountID: rec.AccountID,
+		Amount:    rec.StatementBalance,
+		Date:      rec.StatementDate,
+		Notes:     fmt.Sprintf("Reconciliation adjustment (%+.2f)", rec.Discrepancy),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to post adjustment: %w", err)
+	}
+
+	now := time.Now()
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE reconciliations
+		SET status = $1, adjustment_balance_id = $2, resolved_at = $3
+		WHERE id = $4
+	`, ReconciliationStatusAdjusted, resp.Balance.ID, now, rec.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update reconciliation: %w", err)
+	}
+
+	rec.Status = ReconciliationStatusAdjusted
+	rec.AdjustmentBalanceID = &resp.Balance.ID
+	rec.ResolvedAt = &now
+
+	return rec, nil
+}
+
+// scanReconciliation scans a reconciliation row
+func scanReconciliation(row interface{ Scan(...interface{}) error }) (*Reconciliation, error) {
+	rec := &Reconciliation{}
+	err := row.Scan(
+		&rec.ID, &rec.AccountID, &rec.StatementDate, &rec.StatementBalance, &rec.RecordedBalance,
+		&rec.RecordedBalanceDate, &rec.Discrepancy, &rec.Status, &rec.AdjustmentBalanceID, &rec.Notes,
+		&rec.CreatedAt, &rec.ResolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
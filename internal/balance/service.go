@@ -7,12 +7,16 @@ import (
 	"log"
 	"time"
 
+	"money/internal/civil"
+	"money/internal/database"
+
 	"github.com/google/uuid"
 )
 
 // Service provides balance management functionality
 type Service struct {
-	db *sql.DB
+	db               *sql.DB
+	refreshSummaries func(ctx context.Context, userID string) error // Rebuilds a user's materialized summaries; nil until set
 }
 
 // NewService creates a new balance service
@@ -20,14 +24,25 @@ func NewService(db *sql.DB) *Service {
 	return &Service{db: db}
 }
 
+// Source represents where a balance entry came from
+type Source string
+
+const (
+	SourceManual Source = "manual"
+	SourceSync   Source = "sync"
+)
+
 // Balance represents a balance entry for an account
 type Balance struct {
-	ID        string    `json:"id"`
-	AccountID string    `json:"account_id"`
-	Amount    float64   `json:"amount"`
-	Date      time.Time `json:"date"`
-	Notes     *string   `json:"notes,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        string     `json:"id"`
+	AccountID string     `json:"account_id"`
+	Amount    float64    `json:"amount"`
+	Date      time.Time  `json:"date"`
+	Notes     *string    `json:"notes,omitempty"`
+	Source    Source     `json:"source"`
+	IsOpening bool       `json:"is_opening"` // true for the balance that starts the account's history
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
 }
 
 // CreateBalanceRequest represents the request to create a new balance entry
@@ -36,12 +51,14 @@ type CreateBalanceRequest struct {
 	Amount    float64   `json:"amount"`
 	Date      time.Time `json:"date"`
 	Notes     string    `json:"notes,omitempty"`
+	Source    Source    `json:"-"` // Set by the sync service; API requests are always manual
 }
 
 // CreateBalanceResponse represents the response from creating a balance
 type CreateBalanceResponse struct {
-	Balance   *Balance `json:"balance"`
-	WasUpdate bool     `json:"was_update"` // true if existing record was updated, false if new record created
+	Balance        *Balance `json:"balance"`
+	WasUpdate      bool     `json:"was_update"`                // true if existing record was updated, false if new record created
+	PreviousAmount *float64 `json:"previous_amount,omitempty"` // amount before the update, when WasUpdate is true
 }
 
 // UpdateBalanceRequest represents the request to update a balance entry
@@ -95,8 +112,11 @@ func (s *Service) BulkImport(ctx context.Context, req *BulkImportRequest) (*Bulk
 		_, err := s.db.ExecContext(ctx, `
 			INSERT INTO balances (account_id, amount, date, notes, created_at)
 			VALUES ($1, $2, $3, $4, $5)
-		`, entry.AccountID, entry.Amount, entry.Date, entry.Notes, time.Now())
+		`, entry.AccountID, entry.Amount, civil.DateFor(ctx, entry.Date), entry.Notes, time.Now())
 
+		if err == nil {
+			err = QueueAccountNetWorthRecompute(ctx, s.db, entry.AccountID, entry.Date)
+		}
 		if err != nil {
 			response.Failed++
 			response.Errors = append(response.Errors, err.Error())
@@ -113,7 +133,7 @@ func (s *Service) GetAccountBalances(ctx context.Context, accountID string) (*Li
 	// TODO: Verify user owns the account
 
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, account_id, amount, date, notes, created_at
+		SELECT id, account_id, amount, date, notes, source, is_opening, created_at, updated_at
 		FROM balances
 		WHERE account_id = $1
 		ORDER BY date DESC
@@ -132,7 +152,10 @@ func (s *Service) GetAccountBalances(ctx context.Context, accountID string) (*Li
 			&balance.Amount,
 			&balance.Date,
 			&balance.Notes,
+			&balance.Source,
+			&balance.IsOpening,
 			&balance.CreatedAt,
+			&balance.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -145,25 +168,41 @@ func (s *Service) GetAccountBalances(ctx context.Context, accountID string) (*Li
 
 // Create creates a new balance entry
 func (s *Service) Create(ctx context.Context, req *CreateBalanceRequest) (*CreateBalanceResponse, error) {
+	return s.CreateTx(ctx, s.db, req)
+}
+
+// CreateTx creates or updates a balance entry within the caller's transaction
+func (s *Service) CreateTx(ctx context.Context, db database.Querier, req *CreateBalanceRequest) (*CreateBalanceResponse, error) {
 	// TODO: Verify user owns the account
 
+	// Balances are kept per calendar day in the user's timezone, so entries made at
+	// different times of the same day update one record
+	req.Date = civil.DateFor(ctx, req.Date).Time
+
 	var notes *string
 	if req.Notes != "" {
 		notes = &req.Notes
 	}
+	source := req.Source
+	if source == "" {
+		source = SourceManual
+	}
+	now := time.Now()
 	balance := &Balance{
 		AccountID: req.AccountID,
 		Amount:    req.Amount,
 		Date:      req.Date,
 		Notes:     notes,
-		CreatedAt: time.Now(),
+		Source:    source,
+		CreatedAt: now,
+		UpdatedAt: &now,
 	}
 
 	// Check if balance already exists for this date
 	var existingID string
 	var existingAmount float64
 	wasUpdate := false
-	existingErr := s.db.QueryRowContext(ctx, `
+	existingErr := db.QueryRowContext(ctx, `
 		SELECT id, amount FROM balances WHERE account_id = $1 AND date = $2
 	`, req.AccountID, req.Date).Scan(&existingID, &existingAmount)
 
@@ -175,17 +214,19 @@ func (s *Service) Create(ctx context.Context, req *CreateBalanceRequest) (*Creat
 	newID := uuid.New().String()
 
 	// SQLite-compatible upsert: INSERT with ON CONFLICT
-	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO balances (id, account_id, amount, date, notes, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO balances (id, account_id, amount, date, notes, source, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		ON CONFLICT (account_id, date) DO UPDATE SET
 			amount = excluded.amount,
-			notes = excluded.notes
-	`, newID, req.AccountID, req.Amount, req.Date, req.Notes, balance.CreatedAt)
+			notes = excluded.notes,
+			source = excluded.source,
+			updated_at = excluded.updated_at
+	`, newID, req.AccountID, req.Amount, req.Date, req.Notes, source, balance.CreatedAt, now)
 
 	if err == nil {
 		// Fetch the actual ID (might be the existing one if it was an update)
-		s.db.QueryRowContext(ctx, `SELECT id FROM balances WHERE account_id = $1 AND date = $2`, req.AccountID, req.Date).Scan(&balance.ID)
+		db.QueryRowContext(ctx, `SELECT id FROM balances WHERE account_id = $1 AND date = $2`, req.AccountID, req.Date).Scan(&balance.ID)
 	}
 
 	if err != nil {
@@ -194,10 +235,20 @@ func (s *Service) Create(ctx context.Context, req *CreateBalanceRequest) (*Creat
 		return nil, err
 	}
 
-	return &CreateBalanceResponse{
+	// Snapshots from this date on include the balance
+	if err := QueueAccountNetWorthRecompute(ctx, db, req.AccountID, req.Date); err != nil {
+		return nil, err
+	}
+
+	resp := &CreateBalanceResponse{
 		Balance:   balance,
 		WasUpdate: wasUpdate,
-	}, nil
+	}
+	if wasUpdate {
+		resp.PreviousAmount = &existingAmount
+	}
+
+	return resp, nil
 }
 
 // Get retrieves a single balance entry by ID
@@ -206,7 +257,7 @@ func (s *Service) Get(ctx context.Context, id string) (*Balance, error) {
 
 	balance := &Balance{}
 	err := s.db.QueryRowContext(ctx, `
-		SELECT id, account_id, amount, date, notes, created_at
+		SELECT id, account_id, amount, date, notes, source, is_opening, created_at, updated_at
 		FROM balances
 		WHERE id = $1
 	`, id).Scan(
@@ -215,7 +266,10 @@ func (s *Service) Get(ctx context.Context, id string) (*Balance, error) {
 		&balance.Amount,
 		&balance.Date,
 		&balance.Notes,
+		&balance.Source,
+		&balance.IsOpening,
 		&balance.CreatedAt,
+		&balance.UpdatedAt,
 	)
 
 	if err != nil {
@@ -235,27 +289,42 @@ func (s *Service) Update(ctx context.Context, id string, req *UpdateBalanceReque
 		return nil, err
 	}
 
+	// Snapshots from the earlier of the old and new dates change
+	recomputeFrom := balance.Date
+
 	// Update only the fields that are provided
 	if req.Amount != nil {
 		balance.Amount = *req.Amount
 	}
 	if req.Date != nil {
-		balance.Date = *req.Date
+		balance.Date = civil.DateFor(ctx, *req.Date).Time
 	}
 	if req.Notes != nil {
 		balance.Notes = req.Notes
 	}
 
+	// Edits through the API are manual corrections, even on synced balances
+	now := time.Now()
+	balance.Source = SourceManual
+	balance.UpdatedAt = &now
+
 	_, err = s.db.ExecContext(ctx, `
 		UPDATE balances
-		SET amount = $1, date = $2, notes = $3
-		WHERE id = $4
-	`, balance.Amount, balance.Date, balance.Notes, id)
+		SET amount = $1, date = $2, notes = $3, source = $4, updated_at = $5
+		WHERE id = $6
+	`, balance.Amount, balance.Date, balance.Notes, balance.Source, now, id)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if balance.Date.Before(recomputeFrom) {
+		recomputeFrom = balance.Date
+	}
+	if err := QueueAccountNetWorthRecompute(ctx, s.db, balance.AccountID, recomputeFrom); err != nil {
+		return nil, err
+	}
+
 	return balance, nil
 }
 
@@ -263,7 +332,15 @@ func (s *Service) Update(ctx context.Context, id string, req *UpdateBalanceReque
 func (s *Service) Delete(ctx context.Context, id string) (*DeleteBalanceResponse, error) {
 	// TODO: Verify user owns the account associated with this balance
 
-	_, err := s.db.ExecContext(ctx, `
+	balance, err := s.Get(ctx, id)
+	if err == sql.ErrNoRows {
+		return &DeleteBalanceResponse{Success: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
 		DELETE FROM balances
 		WHERE id = $1
 	`, id)
@@ -272,5 +349,9 @@ func (s *Service) Delete(ctx context.Context, id string) (*DeleteBalanceResponse
 		return nil, err
 	}
 
+	if err := QueueAccountNetWorthRecompute(ctx, s.db, balance.AccountID, balance.Date); err != nil {
+		return nil, err
+	}
+
 	return &DeleteBalanceResponse{Success: true}, nil
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -16,6 +17,7 @@ import (
 	_ "modernc.org/sqlite"
 
 	"money/internal/auth"
+	"money/internal/civil"
 )
 
 var (
@@ -81,11 +83,13 @@ func CleanupTestDB(t *testing.T, db *sql.DB) {
 	t.Helper()
 
 	// Clean up test data
-	tables := []string{"balances", "accounts", "users"}
+	tables := []string{"net_worth_events", "net_worth_recompute_queue", "net_worth_snapshots", "envelope_movements", "envelopes", "reconciliations", "balances", "accounts", "users"}
 	for _, table := range tables {
 		var query string
-		if table == "balances" {
-			query = "DELETE FROM balances WHERE account_id LIKE 'test-%'"
+		if strings.HasPrefix(table, "net_worth_") {
+			query = fmt.Sprintf("DELETE FROM %s WHERE user_id LIKE 'test-%%'", table)
+		} else if table == "balances" || table == "reconciliations" || table == "envelopes" || table == "envelope_movements" {
+			query = fmt.Sprintf("DELETE FROM %s WHERE account_id LIKE 'test-%%'", table)
 		} else {
 			query = fmt.Sprintf("DELETE FROM %s WHERE id LIKE 'test-%%'", table)
 		}
@@ -360,3 +364,517 @@ func TestCreate_WithNotes(t *testing.T) {
 		t.Errorf("Expected notes '%s', got '%s'", expectedNotes, *resp.Balance.Notes)
 	}
 }
+
+func TestCreate_UpsertReturnsPreviousAmount(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-create-previous-amount-1"
+	CreateTestUser(t, db, userID)
+	accountID := CreateTestAccount(t, db, userID)
+	service := NewService(db)
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	first, err := service.Create(context.Background(), &CreateBalanceRequest{AccountID: accountID, Amount: 100.00, Date: date})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// Act
+	second, err := service.Create(context.Background(), &CreateBalanceRequest{AccountID: accountID, Amount: 250.00, Date: date})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if first.PreviousAmount != nil {
+		t.Errorf("Expected no previous amount for new balance, got %v", *first.PreviousAmount)
+	}
+	if !second.WasUpdate {
+		t.Error("Expected second create to update existing balance")
+	}
+	if second.PreviousAmount == nil || *second.PreviousAmount != 100.00 {
+		t.Errorf("Expected previous amount 100.00, got %v", second.PreviousAmount)
+	}
+}
+
+func TestUpdate_MarksSyncedBalanceAsManual(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-update-source-1"
+	CreateTestUser(t, db, userID)
+	accountID := CreateTestAccount(t, db, userID)
+	service := NewService(db)
+
+	created, err := service.Create(context.Background(), &CreateBalanceRequest{
+		AccountID: accountID,
+		Amount:    100.00,
+		Date:      time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Source:    SourceSync,
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created.Balance.Source != SourceSync {
+		t.Fatalf("Expected source %s, got %s", SourceSync, created.Balance.Source)
+	}
+
+	// Act
+	amount := 120.00
+	_, err = service.Update(context.Background(), created.Balance.ID, &UpdateBalanceRequest{Amount: &amount})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	updated, err := service.Get(context.Background(), created.Balance.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if updated.Source != SourceManual {
+		t.Errorf("Expected source %s after manual edit, got %s", SourceManual, updated.Source)
+	}
+	if updated.UpdatedAt == nil {
+		t.Error("Expected updated_at to be set")
+	}
+}
+
+func TestReconcile_DiscrepancyThenAdjustment(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-reconcile-1"
+	CreateTestUser(t, db, userID)
+	accountID := CreateTestAccount(t, db, userID)
+	service := NewService(db)
+	ctx := CreateAuthContext(userID)
+
+	_, err := service.Create(ctx, &CreateBalanceRequest{
+		AccountID: accountID,
+		Amount:    1000.00,
+		Date:      time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	statementDate := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	// Act
+	rec, err := service.Reconcile(ctx, accountID, &ReconcileRequest{StatementDate: statementDate, StatementBalance: 1012.50})
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	adjusted, err := service.PostAdjustment(ctx, rec.ID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("PostAdjustment failed: %v", err)
+	}
+	if rec.Status != ReconciliationStatusDiscrepancy || rec.Discrepancy != 12.50 {
+		t.Errorf("Expected discrepancy of 12.50, got status %s discrepancy %f", rec.Status, rec.Discrepancy)
+	}
+	if adjusted.Status != ReconciliationStatusAdjusted || adjusted.AdjustmentBalanceID == nil {
+		t.Errorf("Expected adjusted reconciliation with adjustment balance, got %+v", adjusted)
+	}
+
+	again, err := service.Reconcile(ctx, accountID, &ReconcileRequest{StatementDate: statementDate, StatementBalance: 1012.50})
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if again.Status != ReconciliationStatusMatched {
+		t.Errorf("Expected matched after adjustment, got %s", again.Status)
+	}
+
+	history, err := service.GetReconciliations(ctx, accountID)
+	if err != nil {
+		t.Fatalf("GetReconciliations failed: %v", err)
+	}
+	if len(history.Reconciliations) != 2 {
+		t.Errorf("Expected 2 reconciliations in history, got %d", len(history.Reconciliations))
+	}
+}
+
+func TestCorrectBalance_RecomputesReconciliations(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-correction-1"
+	CreateTestUser(t, db, userID)
+	accountID := CreateTestAccount(t, db, userID)
+	service := NewService(db)
+	ctx := CreateAuthContext(userID)
+
+	_, err := service.SetOpeningBalance(ctx, accountID, &OpeningBalanceRequest{
+		Amount: 500.00,
+		Date:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("SetOpeningBalance failed: %v", err)
+	}
+	rec, err := service.Reconcile(ctx, accountID, &ReconcileRequest{
+		StatementDate:    time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		StatementBalance: 750.00,
+	})
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	// Act
+	resp, err := service.CorrectBalance(ctx, accountID, &CorrectionRequest{
+		Amount: 750.00,
+		Date:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CorrectBalance failed: %v", err)
+	}
+	if rec.Status != ReconciliationStatusDiscrepancy {
+		t.Errorf("Expected discrepancy before correction, got %s", rec.Status)
+	}
+	if len(resp.Reconciliations) != 1 || resp.Reconciliations[0].Status != ReconciliationStatusMatched {
+		t.Errorf("Expected reconciliation to be recomputed as matched, got %+v", resp.Reconciliations)
+	}
+
+	_, err = service.CorrectBalance(ctx, accountID, &CorrectionRequest{
+		Amount: 100.00,
+		Date:   time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err == nil {
+		t.Error("Expected correction before the opening balance to fail")
+	}
+
+	balances, err := service.GetAccountBalances(ctx, accountID)
+	if err != nil {
+		t.Fatalf("GetAccountBalances failed: %v", err)
+	}
+	opening := balances.Balances[len(balances.Balances)-1]
+	if !opening.IsOpening || opening.Amount != 500.00 {
+		t.Errorf("Expected earliest balance to be the opening balance, got %+v", opening)
+	}
+}
+
+func TestEnvelopes_AllocateTransferAndUnallocated(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-envelopes-1"
+	CreateTestUser(t, db, userID)
+	accountID := CreateTestAccount(t, db, userID)
+	service := NewService(db)
+	ctx := CreateAuthContext(userID)
+
+	_, err := service.Create(ctx, &CreateBalanceRequest{
+		AccountID: accountID,
+		Amount:    1000.00,
+		Date:      time.Now().AddDate(0, 0, -1),
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	rent, err := service.CreateEnvelope(ctx, accountID, &CreateEnvelopeRequest{Name: "Rent", Allocate: 600})
+	if err != nil {
+		t.Fatalf("CreateEnvelope failed: %v", err)
+	}
+	travel, err := service.CreateEnvelope(ctx, accountID, &CreateEnvelopeRequest{Name: "Travel"})
+	if err != nil {
+		t.Fatalf("CreateEnvelope failed: %v", err)
+	}
+
+	// Act
+	_, overErr := service.AllocateEnvelope(ctx, travel.ID, &AllocateEnvelopeRequest{Amount: 500})
+	_, transferErr := service.TransferBetweenEnvelopes(ctx, &EnvelopeTransferRequest{
+		FromEnvelopeID: rent.ID,
+		ToEnvelopeID:   travel.ID,
+		Amount:         150,
+	})
+	summary, err := service.GetEnvelopeSummary(ctx, accountID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetEnvelopeSummary failed: %v", err)
+	}
+	if overErr == nil {
+		t.Error("Expected allocation beyond unallocated cash to fail")
+	}
+	if transferErr != nil {
+		t.Fatalf("TransferBetweenEnvelopes failed: %v", transferErr)
+	}
+	if summary.TotalAllocated != 600 || summary.Unallocated != 400 {
+		t.Errorf("Expected 600 allocated and 400 unallocated, got %.2f and %.2f", summary.TotalAllocated, summary.Unallocated)
+	}
+	if len(summary.Envelopes) != 2 || summary.Envelopes[0].Name != "Rent" || summary.Envelopes[0].Allocated != 450 || summary.Envelopes[1].Allocated != 150 {
+		t.Errorf("Unexpected envelopes after transfer: %+v", summary.Envelopes)
+	}
+}
+
+func TestGetBalanceHistory_AggregatesByMonthWithinRange(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	userID := "test-user-balance-history"
+	CreateTestUser(t, db, userID)
+	accountID := CreateTestAccount(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := NewService(db)
+
+	entries := []struct {
+		date   string
+		amount float64
+	}{
+		{"2024-01-05", 100},
+		{"2024-01-20", 50},
+		{"2024-01-31", 80},
+		{"2024-02-10", 120},
+		{"2024-03-01", 200},
+	}
+	for _, e := range entries {
+		date, _ := time.Parse("2006-01-02", e.date)
+		if _, err := service.Create(ctx, &CreateBalanceRequest{AccountID: accountID, Amount: e.amount, Date: date}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	from, _ := time.Parse("2006-01-02", "2024-01-01")
+	to, _ := time.Parse("2006-01-02", "2024-02-29")
+	resp, err := service.GetBalanceHistory(ctx, accountID, &BalanceHistoryRequest{From: &from, To: &to, Aggregate: AggregationMonth})
+	if err != nil {
+		t.Fatalf("GetBalanceHistory failed: %v", err)
+	}
+	if len(resp.Periods) != 2 {
+		t.Fatalf("Expected 2 periods, got %d", len(resp.Periods))
+	}
+
+	jan := resp.Periods[0]
+	if jan.Period != "2024-01" || jan.Open != 100 || jan.Close != 80 || jan.Min != 50 || jan.Max != 100 || jan.Count != 3 {
+		t.Errorf("Unexpected January period: %+v", jan)
+	}
+
+	raw, err := service.GetBalanceHistory(ctx, accountID, &BalanceHistoryRequest{From: &to})
+	if err != nil {
+		t.Fatalf("GetBalanceHistory failed: %v", err)
+	}
+	if len(raw.Balances) != 1 || raw.Balances[0].Amount != 200 {
+		t.Errorf("Expected only the March balance after the from date, got %d balances", len(raw.Balances))
+	}
+}
+
+func TestProcessNetWorthRecomputes_RebuildsAfterBackdatedBalance(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-net-worth-recompute"
+	CreateTestUser(t, db, userID)
+	checkingID := CreateTestAccount(t, db, userID)
+	savingsID := CreateTestAccount(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := NewService(db)
+
+	create := func(accountID, date string, amount float64) {
+		d, _ := time.Parse("2006-01-02", date)
+		if _, err := service.Create(ctx, &CreateBalanceRequest{AccountID: accountID, Amount: amount, Date: d}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	create(checkingID, "2024-01-05", 100)
+	create(checkingID, "2024-03-01", 200)
+	if _, err := service.ProcessNetWorthRecomputes(ctx); err != nil {
+		t.Fatalf("ProcessNetWorthRecomputes failed: %v", err)
+	}
+	before, err := service.GetNetWorthHistory(ctx, &BalanceHistoryRequest{})
+	if err != nil {
+		t.Fatalf("GetNetWorthHistory failed: %v", err)
+	}
+
+	// Act
+	create(savingsID, "2024-02-01", 150)
+	pending, err := service.GetNetWorthHistory(ctx, &BalanceHistoryRequest{})
+	if err != nil {
+		t.Fatalf("GetNetWorthHistory failed: %v", err)
+	}
+	if _, err := service.ProcessNetWorthRecomputes(ctx); err != nil {
+		t.Fatalf("ProcessNetWorthRecomputes failed: %v", err)
+	}
+	after, err := service.GetNetWorthHistory(ctx, &BalanceHistoryRequest{})
+	if err != nil {
+		t.Fatalf("GetNetWorthHistory failed: %v", err)
+	}
+
+	// Assert
+	if len(before.Snapshots) != 2 || before.RecomputePending {
+		t.Fatalf("Expected 2 snapshots and nothing pending before the edit, got %+v", before)
+	}
+	if !pending.RecomputePending {
+		t.Error("Expected a recompute to be pending after the backdated balance")
+	}
+	if len(after.Snapshots) != 3 || after.RecomputePending {
+		t.Fatalf("Expected 3 snapshots after the rebuild, got %d", len(after.Snapshots))
+	}
+	if march := after.Snapshots[2]; march.Date != "2024-03-01" || march.NetWorth != 350 {
+		t.Errorf("Expected the backdated savings balance carried into March, got %+v", march)
+	}
+
+	events, err := service.ListNetWorthEvents(ctx, before.LastEventID)
+	if err != nil {
+		t.Fatalf("ListNetWorthEvents failed: %v", err)
+	}
+	if len(events.Events) != 1 || events.Events[0].FromDate != "2024-02-01" || events.Events[0].Snapshots != 2 {
+		t.Errorf("Expected one event rebuilding from the backdated date, got %+v", events.Events)
+	}
+}
+
+func TestAttributeFXChanges_SplitsLocalAndFXMovement(t *testing.T) {
+	// Arrange
+	snapshots := []*NetWorthSnapshot{
+		{Date: "2024-01-31", Currency: "CAD", NetWorth: 1000},
+		{Date: "2024-01-31", Currency: "INR", NetWorth: 5000},
+		{Date: "2024-01-31", Currency: "USD", NetWorth: 1000},
+		{Date: "2024-02-20", Currency: "USD", NetWorth: 1100},
+	}
+	rates := rateHistory{"USD": {
+		{date: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), rate: 1.30},
+		{date: time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC), rate: 1.40},
+	}}
+	today := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// Act
+	attributions := attributeFXChanges(snapshots, "CAD", rates, today)
+
+	// Assert
+	if len(attributions) != 1 {
+		t.Fatalf("Expected one month of attribution, got %d", len(attributions))
+	}
+	feb := attributions[0]
+	if feb.Month != "2024-02" || feb.OpeningNetWorth != 2300 || feb.ClosingNetWorth != 2540 {
+		t.Errorf("Expected February to go from 2300 to 2540, got %+v", feb)
+	}
+	if feb.LocalChange != 140 || feb.FXImpact != 100 || feb.Change != 240 {
+		t.Errorf("Expected 140 local change and 100 from FX, got %+v", feb)
+	}
+	if len(feb.MissingCurrencies) != 1 || feb.MissingCurrencies[0] != "INR" {
+		t.Errorf("Expected INR reported missing for lack of a rate, got %v", feb.MissingCurrencies)
+	}
+	if len(feb.ByCurrency) != 2 || feb.ByCurrency[1].Currency != "USD" || feb.ByCurrency[1].OpeningRate != 1.30 || feb.ByCurrency[1].ClosingRate != 1.40 {
+		t.Errorf("Expected USD valued at 1.30 then 1.40, got %+v", feb.ByCurrency)
+	}
+}
+
+func TestStaleness_FlagsAccountsPastThresholdUntilUpdated(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-staleness"
+	CreateTestUser(t, db, userID)
+	defer db.Exec(`DELETE FROM balance_reminder_settings WHERE user_id = $1`, userID)
+	service := NewService(db)
+	ctx := CreateAuthContext(userID)
+
+	fresh := CreateTestAccount(t, db, userID)
+	stale := CreateTestAccount(t, db, userID)
+	never := CreateTestAccount(t, db, userID)
+	today := civil.TodayIn(ctx).Time
+	for _, b := range []struct {
+		accountID string
+		daysAgo   int
+	}{{fresh, 3}, {stale, 20}} {
+		if _, err := service.Create(ctx, &CreateBalanceRequest{AccountID: b.accountID, Amount: 100, Date: today.AddDate(0, 0, -b.daysAgo)}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	threshold := 14
+	if _, err := service.UpdateReminderSettings(ctx, &UpdateReminderSettingsRequest{StaleAfterDays: &threshold}); err != nil {
+		t.Fatalf("UpdateReminderSettings failed: %v", err)
+	}
+
+	// Act
+	resp, err := service.GetStaleness(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetStaleness failed: %v", err)
+	}
+	if resp.StaleCount != 2 {
+		t.Fatalf("Expected 2 stale accounts, got %d", resp.StaleCount)
+	}
+	if resp.Accounts[0].AccountID != never || resp.Accounts[1].AccountID != stale || resp.Accounts[2].AccountID != fresh {
+		t.Errorf("Expected accounts stalest first")
+	}
+	if days := resp.Accounts[1].DaysSinceUpdate; days == nil || *days != 20 {
+		t.Errorf("Expected 20 days since update, got %v", days)
+	}
+
+	// A longer threshold on the account itself keeps it current
+	longer := 30
+	if err := service.SetStaleAfter(ctx, stale, &SetStaleAfterRequest{StaleAfterDays: &longer}); err != nil {
+		t.Fatalf("SetStaleAfter failed: %v", err)
+	}
+	updated, err := service.BulkUpdate(ctx, &BulkUpdateRequest{Updates: []*BalanceUpdate{{AccountID: never, Amount: 50}}})
+	if err != nil {
+		t.Fatalf("BulkUpdate failed: %v", err)
+	}
+	if updated.Staleness.StaleCount != 0 {
+		t.Errorf("Expected no stale accounts after the update, got %d", updated.Staleness.StaleCount)
+	}
+
+	// Another user's account can't be updated, and nothing is saved
+	if _, err := service.BulkUpdate(ctx, &BulkUpdateRequest{Updates: []*BalanceUpdate{
+		{AccountID: fresh, Amount: 75},
+		{AccountID: "test-account-other-user", Amount: 1},
+	}}); err == nil {
+		t.Error("Expected bulk update with an unknown account to fail")
+	}
+	balances, err := service.GetAccountBalances(ctx, fresh)
+	if err != nil {
+		t.Fatalf("GetAccountBalances failed: %v", err)
+	}
+	if balances.Balances[0].Amount != 100 {
+		t.Errorf("Expected the failed bulk update to be rolled back, got %.2f", balances.Balances[0].Amount)
+	}
+}
+
+func TestQuickUpdate_ReturnsNewNetWorth(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-quick-update"
+	CreateTestUser(t, db, userID)
+	service := NewService(db)
+	ctx := CreateAuthContext(userID)
+
+	savings := CreateTestAccount(t, db, userID)
+	chequing := CreateTestAccount(t, db, userID)
+	if _, err := service.Create(ctx, &CreateBalanceRequest{AccountID: chequing, Amount: 900, Date: time.Now().AddDate(0, 0, -10)}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// Act
+	resp, err := service.QuickUpdate(ctx, &QuickUpdateRequest{Balances: map[string]float64{savings: 2500}}, "CAD")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("QuickUpdate failed: %v", err)
+	}
+	if resp.Updated != 1 || resp.Date != civil.TodayIn(ctx) {
+		t.Errorf("Expected 1 balance updated today, got %d on %s", resp.Updated, resp.Date)
+	}
+	if len(resp.NetWorth) != 1 || resp.NetWorth[0].NetWorth != 3400 {
+		t.Fatalf("Expected net worth 3400 carrying the older chequing balance forward, got %+v", resp.NetWorth)
+	}
+	if resp.TotalNetWorth == nil || *resp.TotalNetWorth != 3400 {
+		t.Errorf("Expected total net worth 3400 CAD, got %v", resp.TotalNetWorth)
+	}
+
+	if _, err := service.QuickUpdate(ctx, &QuickUpdateRequest{}, ""); err == nil {
+		t.Error("Expected an empty quick update to fail")
+	}
+}
@@ -0,0 +1,294 @@
+package balance
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/civil"
+)
+
+// DefaultStaleAfterDays is how long a manual account can go without a balance before it is
+// stale, unless the user or the account sets another threshold
+const DefaultStaleAfterDays = 30
+
+// ReminderSettings controls the reminders to update stale manual accounts
+type ReminderSettings struct {
+	Enabled        bool `json:"enabled"`
+	StaleAfterDays int  `json:"stale_after_days"` // Applies to accounts without their own threshold
+}
+
+// UpdateReminderSettingsRequest changes the reminder settings. Omitted fields are left unchanged.
+type UpdateReminderSettingsRequest struct {
+	Enabled        *bool `json:"enabled,omitempty"`
+	StaleAfterDays *int  `json:"stale_after_days,omitempty"`
+}
+
+// SetStaleAfterRequest sets an account's own staleness threshold; null reverts to the user's
+type SetStaleAfterRequest struct {
+	StaleAfterDays *int `json:"stale_after_days"`
+}
+
+// AccountStaleness reports how long ago a manual account's balance was last updated
+type AccountStaleness struct {
+	AccountID       string      `json:"account_id"`
+	AccountName     string      `json:"account_name"`
+	AccountType     string      `json:"account_type"`
+	Currency        string      `json:"currency"`
+	LastBalance     *float64    `json:"last_balance,omitempty"`
+	LastBalanceDate *civil.Date `json:"last_balance_date,omitempty"`
+	DaysSinceUpdate *int        `json:"days_since_update,omitempty"` // Nil when the account has no balance yet
+	StaleAfterDays  int         `json:"stale_after_days"`
+	IsStale         bool        `json:"is_stale"`
+}
+
+// StalenessResponse lists the user's manual accounts, stalest first
+type StalenessResponse struct {
+	Accounts   []*AccountStaleness `json:"accounts"`
+	StaleCount int                 `json:"stale_count"`
+	Settings   *ReminderSettings   `json:"settings"`
+}
+
+// BalanceUpdate is one account's new balance in a bulk update
+type BalanceUpdate struct {
+	AccountID string  `json:"account_id"`
+	Amount    float64 `json:"amount"`
+	Notes     string  `json:"notes,omitempty"`
+}
+
+// BulkUpdateRequest records new balances for several accounts at once. The date defaults to today.
+type BulkUpdateRequest struct {
+	Date    *time.Time       `json:"date,omitempty"`
+	Updates []*BalanceUpdate `json:"updates"`
+}
+
+// BulkUpdateResponse is the recorded balances and the accounts' staleness afterwards
+type BulkUpdateResponse struct {
+	Balances  []*CreateBalanceResponse `json:"balances"`
+	Staleness *StalenessResponse       `json:"staleness"`
+}
+
+// GetReminderSettings returns the user's stale balance reminder settings
+func (s *Service) GetReminderSettings(ctx context.Context) (*ReminderSettings, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	settings := &ReminderSettings{Enabled: true, StaleAfterDays: DefaultStaleAfterDays}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT reminders_enabled, stale_after_days FROM balance_reminder_settings WHERE user_id = $1
+	`, userID).Scan(&settings.Enabled, &settings.StaleAfterDays)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get balance reminder settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// UpdateReminderSettings changes the user's stale balance reminder settings
+func (s *Service) UpdateReminderSettings(ctx context.Context, req *UpdateReminderSettingsRequest) (*ReminderSettings, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	settings, err := s.GetReminderSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Enabled != nil {
+		settings.Enabled = *req.Enabled
+	}
+	if req.StaleAfterDays != nil {
+		if *req.StaleAfterDays <= 0 {
+			return nil, fmt.Errorf("stale_after_days must be positive")
+		}
+		settings.StaleAfterDays = *req.StaleAfterDays
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO balance_reminder_settings (user_id, reminders_enabled, stale_after_days, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+			reminders_enabled = excluded.reminders_enabled,
+			stale_after_days = excluded.stale_after_days,
+			updated_at = excluded.updated_at
+	`, userID, settings.Enabled, settings.StaleAfterDays, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to save balance reminder settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// SetStaleAfter sets how long an account can go without a balance before it is stale
+func (s *Service) SetStaleAfter(ctx context.Context, accountID string, req *SetStaleAfterRequest) error {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return err
+	}
+	if req.StaleAfterDays != nil && *req.StaleAfterDays <= 0 {
+		return fmt.Errorf("stale_after_days must be positive")
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE accounts SET stale_after_days = $1, updated_at = $2 WHERE id = $3
+	`, req.StaleAfterDays, time.Now(), accountID)
+	if err != nil {
+		return fmt.Errorf("failed to update account: %w", err)
+	}
+
+	return nil
+}
+
+// GetStaleness reports how long each active manual account has gone without a balance.
+// Synced accounts are kept current by their connection and are left out.
+func (s *Service) GetStaleness(ctx context.Context) (*StalenessResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	settings, err := s.GetReminderSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.id, a.name, a.type, a.currency, a.stale_after_days, b.amount, b.date
+		FROM accounts a
+		LEFT JOIN balances b ON b.id = (
+			SELECT id FROM balances WHERE account_id = a.id ORDER BY date DESC LIMIT 1
+		)
+		WHERE a.user_id = $1 AND a.is_active = 1 AND a.is_synced = 0
+		ORDER BY a.name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	defer rows.Close()
+
+	today := civil.TodayIn(ctx)
+	resp := &StalenessResponse{Accounts: make([]*AccountStaleness, 0), Settings: settings}
+	for rows.Next() {
+		a := &AccountStaleness{StaleAfterDays: settings.StaleAfterDays}
+		var staleAfter sql.NullInt64
+		var lastDate sql.NullString
+		if err := rows.Scan(&a.AccountID, &a.AccountName, &a.AccountType, &a.Currency, &staleAfter,
+			&a.LastBalance, &lastDate); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		if staleAfter.Valid {
+			a.StaleAfterDays = int(staleAfter.Int64)
+		}
+
+		// An account that has never had a balance is as stale as it gets
+		a.IsStale = true
+		if lastDate.Valid {
+			date, err := civil.Parse(lastDate.String)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse balance date: %w", err)
+			}
+			days := int(today.Sub(date.Time).Hours() / 24)
+			a.LastBalanceDate = &date
+			a.DaysSinceUpdate = &days
+			a.IsStale = days >= a.StaleAfterDays
+		}
+		if a.IsStale {
+			resp.StaleCount++
+		}
+		resp.Accounts = append(resp.Accounts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sortStalestFirst(resp.Accounts)
+	return resp, nil
+}
+
+// sortStalestFirst orders accounts without a balance first, then by days since their last update
+func sortStalestFirst(accounts []*AccountStaleness) {
+	sort.SliceStable(accounts, func(i, j int) bool {
+		a, b := accounts[i].DaysSinceUpdate, accounts[j].DaysSinceUpdate
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return *a > *b
+	})
+}
+
+// BulkUpdate records new balances for several of the user's accounts in one transaction, so
+// a round of updates is saved completely or not at all
+func (s *Service) BulkUpdate(ctx context.Context, req *BulkUpdateRequest) (*BulkUpdateResponse, error) {
+	date := civil.TodayIn(ctx).Time
+	if req.Date != nil {
+		date = *req.Date
+	}
+
+	balances, err := s.saveBalances(ctx, date, req.Updates)
+	if err != nil {
+		return nil, err
+	}
+
+	staleness, err := s.GetStaleness(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &BulkUpdateResponse{Balances: balances, Staleness: staleness}, nil
+}
+
+// saveBalances records the updates on date in one transaction, checking that the user owns
+// every account
+func (s *Service) saveBalances(ctx context.Context, date time.Time, updates []*BalanceUpdate) ([]*CreateBalanceResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("at least one update is required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	balances := make([]*CreateBalanceResponse, 0, len(updates))
+	seen := make(map[string]bool, len(updates))
+	for _, update := range updates {
+		if seen[update.AccountID] {
+			return nil, fmt.Errorf("account %s is updated more than once", update.AccountID)
+		}
+		seen[update.AccountID] = true
+
+		var ownerID string
+		err := tx.QueryRowContext(ctx, `SELECT user_id FROM accounts WHERE id = $1`, update.AccountID).Scan(&ownerID)
+		if err == sql.ErrNoRows || (err == nil && ownerID != userID) {
+			return nil, fmt.Errorf("account not found: %s", update.AccountID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify account ownership: %w", err)
+		}
+
+		created, err := s.CreateTx(ctx, tx, &CreateBalanceRequest{
+			AccountID: update.AccountID,
+			Amount:    update.Amount,
+			Date:      date,
+			Notes:     update.Notes,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to save balance for account %s: %w", update.AccountID, err)
+		}
+		balances = append(balances, created)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return balances, nil
+}
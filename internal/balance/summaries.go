@@ -0,0 +1,28 @@
+package balance
+
+import (
+	"context"
+	"fmt"
+
+	"money/internal/database"
+)
+
+// InvalidateSummaries marks the user's materialized dashboard summaries stale, within the
+// caller's transaction so they're only invalidated if the write commits. The next read or
+// net worth recompute rebuilds them.
+func InvalidateSummaries(ctx context.Context, db database.Querier, userID string) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO account_summaries (user_id, generation) VALUES ($1, 1)
+		ON CONFLICT (user_id) DO UPDATE SET generation = account_summaries.generation + 1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate summaries: %w", err)
+	}
+	return nil
+}
+
+// SetSummaryRefresher sets what rebuilds a user's materialized summaries once their net
+// worth recompute has run, after the balance writes that invalidated them
+func (s *Service) SetSummaryRefresher(refresh func(ctx context.Context, userID string) error) {
+	s.refreshSummaries = refresh
+}
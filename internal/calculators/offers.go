@@ -0,0 +1,243 @@
+package calculators
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"money/internal/account"
+)
+
+// defaultOfferScenarios are used when the request names no company growth scenarios
+var defaultOfferScenarios = []GrowthScenario{
+	{Name: "down", AnnualGrowth: -0.20},
+	{Name: "flat", AnnualGrowth: 0},
+	{Name: "up", AnnualGrowth: 0.25},
+}
+
+// OfferEquity is the equity grant in an offer
+type OfferEquity struct {
+	GrantType        account.GrantType `json:"grant_type"` // rsu, rsa, iso or nso
+	Shares           int               `json:"shares"`
+	FMVPerShare      float64           `json:"fmv_per_share"`          // Value of a share when granted
+	StrikePrice      float64           `json:"strike_price,omitempty"` // Options only
+	CliffMonths      int               `json:"cliff_months"`
+	VestingMonths    int               `json:"vesting_months"`    // Defaults to 48
+	VestingFrequency string            `json:"vesting_frequency"` // monthly, quarterly (default) or annually
+}
+
+// Offer is a hypothetical compensation package
+type Offer struct {
+	Name         string       `json:"name"`
+	Salary       float64      `json:"salary"`
+	AnnualRaise  float64      `json:"annual_raise"` // As a fraction, applied from the second year
+	BonusRate    float64      `json:"bonus_rate"`   // Target bonus as a fraction of salary
+	SigningBonus float64      `json:"signing_bonus"`
+	Equity       *OfferEquity `json:"equity,omitempty"`
+}
+
+// GrowthScenario is how fast the company's share value grows each year
+type GrowthScenario struct {
+	Name         string  `json:"name"`
+	AnnualGrowth float64 `json:"annual_growth"` // As a fraction
+}
+
+// OfferComparisonRequest compares two offers over the same years and scenarios
+type OfferComparisonRequest struct {
+	Offers    []Offer          `json:"offers"`    // Exactly two
+	Years     int              `json:"years"`     // Defaults to 4
+	Scenarios []GrowthScenario `json:"scenarios"` // Defaults to down, flat and up
+}
+
+// OfferYear is an offer's pre-tax compensation in one year from the start date
+type OfferYear struct {
+	Year         int     `json:"year"`
+	Salary       float64 `json:"salary"`
+	Bonus        float64 `json:"bonus"` // Target bonus, plus the signing bonus in the first year
+	SharesVested int     `json:"shares_vested"`
+	EquityValue  float64 `json:"equity_value"` // Vested shares at the scenario's FMV on their vest dates, less any strike
+	Total        float64 `json:"total"`
+}
+
+// OfferProjection is one offer's compensation under a scenario
+type OfferProjection struct {
+	Name  string      `json:"name"`
+	Years []OfferYear `json:"years"`
+	Total float64     `json:"total"`
+}
+
+// ScenarioComparison puts both offers side by side under one scenario
+type ScenarioComparison struct {
+	Scenario     string            `json:"scenario"`
+	AnnualGrowth float64           `json:"annual_growth"`
+	Offers       []OfferProjection `json:"offers"`
+	Better       string            `json:"better"` // Name of the offer worth more, or equal
+	Difference   float64           `json:"difference"`
+}
+
+// OfferComparisonResult compares the offers under every scenario
+type OfferComparisonResult struct {
+	Years     int                  `json:"years"`
+	Scenarios []ScenarioComparison `json:"scenarios"`
+}
+
+// CompareOffers projects the pre-tax compensation of two offers year by year. Grants vest
+// with the same share split as stored grants, and each vest is valued at the grant FMV
+// grown at the scenario's rate to the vest date.
+func (s *Service) CompareOffers(req *OfferComparisonRequest) (*OfferComparisonResult, error) {
+	if len(req.Offers) != 2 {
+		return nil, fmt.Errorf("exactly two offers are required")
+	}
+	for i := range req.Offers {
+		if err := validateOffer(&req.Offers[i], i); err != nil {
+			return nil, err
+		}
+	}
+	years := req.Years
+	if years == 0 {
+		years = 4
+	}
+	if years < 0 || years > maxCalculatorYear {
+		return nil, fmt.Errorf("years must be between 1 and %d", maxCalculatorYear)
+	}
+	scenarios := req.Scenarios
+	if len(scenarios) == 0 {
+		scenarios = defaultOfferScenarios
+	}
+	for _, scenario := range scenarios {
+		if strings.TrimSpace(scenario.Name) == "" {
+			return nil, fmt.Errorf("scenario name is required")
+		}
+		if err := validateRate(scenario.AnnualGrowth); err != nil {
+			return nil, fmt.Errorf("scenario %s: annual_growth must be a fraction between -1 and 1", scenario.Name)
+		}
+	}
+
+	start := time.Now().UTC().Truncate(24 * time.Hour)
+	result := &OfferComparisonResult{Years: years, Scenarios: make([]ScenarioComparison, 0, len(scenarios))}
+	for _, scenario := range scenarios {
+		comparison := ScenarioComparison{Scenario: scenario.Name, AnnualGrowth: scenario.AnnualGrowth}
+		for _, offer := range req.Offers {
+			comparison.Offers = append(comparison.Offers, projectOffer(&offer, years, scenario.AnnualGrowth, start))
+		}
+		first, second := comparison.Offers[0], comparison.Offers[1]
+		comparison.Difference = roundCents(math.Abs(first.Total - second.Total))
+		switch {
+		case comparison.Difference < 0.01:
+			comparison.Better = "equal"
+		case first.Total > second.Total:
+			comparison.Better = first.Name
+		default:
+			comparison.Better = second.Name
+		}
+		result.Scenarios = append(result.Scenarios, comparison)
+	}
+	return result, nil
+}
+
+// validateOffer checks an offer and fills in its defaults
+func validateOffer(offer *Offer, index int) error {
+	if strings.TrimSpace(offer.Name) == "" {
+		offer.Name = fmt.Sprintf("Offer %d", index+1)
+	}
+	if offer.Salary < 0 || offer.SigningBonus < 0 || offer.BonusRate < 0 {
+		return fmt.Errorf("%s: salary, bonus rate and signing bonus must not be negative", offer.Name)
+	}
+	if err := validateRate(offer.AnnualRaise); err != nil {
+		return fmt.Errorf("%s: annual_raise must be a fraction between -1 and 1", offer.Name)
+	}
+
+	equity := offer.Equity
+	if equity == nil {
+		return nil
+	}
+	switch equity.GrantType {
+	case account.GrantTypeRSU, account.GrantTypeRSA, account.GrantTypeISO, account.GrantTypeNSO:
+	default:
+		return fmt.Errorf("%s: invalid grant type: %s", offer.Name, equity.GrantType)
+	}
+	if equity.Shares <= 0 || equity.FMVPerShare < 0 || equity.StrikePrice < 0 {
+		return fmt.Errorf("%s: shares must be positive and prices must not be negative", offer.Name)
+	}
+	if equity.VestingMonths == 0 {
+		equity.VestingMonths = 48
+	}
+	if equity.VestingMonths < 0 || equity.VestingMonths > maxCalculatorYear*12 {
+		return fmt.Errorf("%s: vesting_months must be between 1 and %d", offer.Name, maxCalculatorYear*12)
+	}
+	if equity.CliffMonths < 0 || equity.CliffMonths > equity.VestingMonths {
+		return fmt.Errorf("%s: cliff_months must be between 0 and vesting_months", offer.Name)
+	}
+	switch equity.VestingFrequency {
+	case "":
+		equity.VestingFrequency = "quarterly"
+	case "monthly", "quarterly", "annually":
+	default:
+		return fmt.Errorf("%s: invalid vesting frequency: %s", offer.Name, equity.VestingFrequency)
+	}
+	return nil
+}
+
+// projectOffer totals an offer's salary, bonus and vested equity for each year from start
+func projectOffer(offer *Offer, years int, growth float64, start time.Time) OfferProjection {
+	projection := OfferProjection{Name: offer.Name, Years: make([]OfferYear, years)}
+	salary := offer.Salary
+	for i := range projection.Years {
+		if i > 0 {
+			salary *= 1 + offer.AnnualRaise
+		}
+		year := &projection.Years[i]
+		year.Year = i + 1
+		year.Salary = salary
+		year.Bonus = salary * offer.BonusRate
+		if i == 0 {
+			year.Bonus += offer.SigningBonus
+		}
+	}
+
+	if equity := offer.Equity; equity != nil {
+		grant := &account.EquityGrant{
+			GrantType:  equity.GrantType,
+			GrantDate:  account.Date{Time: start},
+			Quantity:   equity.Shares,
+			FMVAtGrant: equity.FMVPerShare,
+		}
+		schedule := &account.VestingSchedule{
+			ScheduleType:       "time_based",
+			CliffMonths:        &equity.CliffMonths,
+			TotalVestingMonths: &equity.VestingMonths,
+			VestingFrequency:   &equity.VestingFrequency,
+		}
+		for _, event := range account.VestingEventsFor(grant, schedule) {
+			months := monthsBetween(start, event.VestDate.Time)
+			index := (months - 1) / 12
+			if months <= 0 || index >= years {
+				continue
+			}
+			fmv := equity.FMVPerShare * math.Pow(1+growth, float64(months)/12)
+			perShare := fmv
+			if equity.GrantType == account.GrantTypeISO || equity.GrantType == account.GrantTypeNSO {
+				perShare = math.Max(fmv-equity.StrikePrice, 0)
+			}
+			projection.Years[index].SharesVested += event.Quantity
+			projection.Years[index].EquityValue += float64(event.Quantity) * perShare
+		}
+	}
+
+	for i := range projection.Years {
+		year := &projection.Years[i]
+		year.Total = roundCents(year.Salary + year.Bonus + year.EquityValue)
+		year.Salary = roundCents(year.Salary)
+		year.Bonus = roundCents(year.Bonus)
+		year.EquityValue = roundCents(year.EquityValue)
+		projection.Total += year.Total
+	}
+	projection.Total = roundCents(projection.Total)
+	return projection
+}
+
+// monthsBetween counts whole calendar months from start to date
+func monthsBetween(start, date time.Time) int {
+	return (date.Year()-start.Year())*12 + int(date.Month()) - int(start.Month())
+}
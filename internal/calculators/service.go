@@ -0,0 +1,323 @@
+// Package calculators implements stateless financial calculators on top of the same
+// amortization, tax and growth math the account, income and projection engines use.
+package calculators
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"money/internal/account"
+	"money/internal/income"
+	"money/internal/projections"
+)
+
+// Canadian mortgage qualification rules: housing costs within GDS of gross income, all debt
+// within TDS, at the greater of the contract rate plus the buffer and the floor rate
+const (
+	maxGDSRatio      = 0.39
+	maxTDSRatio      = 0.44
+	stressTestBuffer = 0.02
+	stressTestFloor  = 0.0525
+)
+
+// maxCalculatorYear caps the horizon of any calculation
+const maxCalculatorYear = 100
+
+// Service provides the calculators. None of them read or store user data.
+type Service struct {
+	incomeSvc *income.Service
+}
+
+// NewService creates a new calculators service
+func NewService(incomeSvc *income.Service) *Service {
+	return &Service{incomeSvc: incomeSvc}
+}
+
+// LoanPaymentRequest describes a loan to amortize
+type LoanPaymentRequest struct {
+	Principal          float64 `json:"principal"`
+	AnnualRate         float64 `json:"annual_rate"`         // As a fraction, e.g. 0.05
+	AmortizationMonths int     `json:"amortization_months"` // Length of the repayment
+	PaymentFrequency   string  `json:"payment_frequency"`   // weekly, bi-weekly, semi-monthly or monthly (default)
+	IncludeSchedule    bool    `json:"include_schedule"`
+}
+
+// LoanPaymentResult is a loan's level payment and what it costs over its life
+type LoanPaymentResult struct {
+	Payment       float64                     `json:"payment"`
+	Payments      int                         `json:"payments"`
+	TotalPaid     float64                     `json:"total_paid"`
+	TotalInterest float64                     `json:"total_interest"`
+	Schedule      []account.AmortizationEntry `json:"schedule,omitempty"`
+}
+
+// AffordabilityRequest describes a household applying for a mortgage
+type AffordabilityRequest struct {
+	AnnualIncome       float64 `json:"annual_income"` // Gross household income
+	MonthlyDebts       float64 `json:"monthly_debts"` // Other debt payments, such as car loans
+	DownPayment        float64 `json:"down_payment"`
+	AnnualRate         float64 `json:"annual_rate"`         // Contract rate, as a fraction
+	AmortizationMonths int     `json:"amortization_months"` // Defaults to 25 years
+	MonthlyPropertyTax float64 `json:"monthly_property_tax"`
+	MonthlyHeating     float64 `json:"monthly_heating"`
+}
+
+// AffordabilityResult is the largest mortgage the household qualifies for
+type AffordabilityResult struct {
+	QualifyingRate    float64 `json:"qualifying_rate"`     // Stress-tested rate the payment is qualified at
+	MaxMonthlyPayment float64 `json:"max_monthly_payment"` // At the qualifying rate
+	MaxMortgage       float64 `json:"max_mortgage"`
+	MaxPurchasePrice  float64 `json:"max_purchase_price"`
+	ContractPayment   float64 `json:"contract_payment"` // Monthly payment on the max mortgage at the contract rate
+	LimitedBy         string  `json:"limited_by"`       // gds or tds
+}
+
+// CompoundGrowthRequest describes savings growing with regular contributions
+type CompoundGrowthRequest struct {
+	InitialBalance      float64 `json:"initial_balance"`
+	MonthlyContribution float64 `json:"monthly_contribution"`
+	AnnualRate          float64 `json:"annual_rate"` // As a fraction
+	Years               int     `json:"years"`
+}
+
+// GrowthYear is the balance at the end of a year
+type GrowthYear struct {
+	Year          int     `json:"year"`
+	Balance       float64 `json:"balance"`
+	Contributions float64 `json:"contributions"` // Including the initial balance
+	Growth        float64 `json:"growth"`
+}
+
+// CompoundGrowthResult is the balance after the requested years
+type CompoundGrowthResult struct {
+	FinalBalance       float64      `json:"final_balance"`
+	TotalContributions float64      `json:"total_contributions"`
+	TotalGrowth        float64      `json:"total_growth"`
+	Years              []GrowthYear `json:"years"`
+}
+
+// RRSPvsTFSARequest compares putting the same pre-tax dollars into an RRSP or a TFSA
+type RRSPvsTFSARequest struct {
+	AnnualIncome     float64 `json:"annual_income"`     // Employment income today
+	Contribution     float64 `json:"contribution"`      // Pre-tax dollars set aside
+	RetirementIncome float64 `json:"retirement_income"` // Other taxable income while withdrawing
+	Years            int     `json:"years"`             // Until withdrawal
+	WithdrawalYears  int     `json:"withdrawal_years"`  // Years the RRSP is drawn down over, defaults to 1
+	AnnualRate       float64 `json:"annual_rate"`       // As a fraction
+	TaxYear          int     `json:"tax_year"`          // Tax rules to use, defaults to this year
+}
+
+// RRSPvsTFSAResult shows where the contribution ends up worth more after tax
+type RRSPvsTFSAResult struct {
+	RRSPContribution       float64 `json:"rrsp_contribution"`
+	RRSPRefund             float64 `json:"rrsp_refund"`
+	TFSAContribution       float64 `json:"tfsa_contribution"` // The contribution less the tax paid on it
+	CurrentMarginalRate    float64 `json:"current_marginal_rate"`
+	RRSPFutureValue        float64 `json:"rrsp_future_value"`
+	RRSPWithdrawalTax      float64 `json:"rrsp_withdrawal_tax"`
+	RRSPAfterTax           float64 `json:"rrsp_after_tax"`
+	RetirementMarginalRate float64 `json:"retirement_marginal_rate"`
+	TFSAFutureValue        float64 `json:"tfsa_future_value"`
+	Better                 string  `json:"better"` // rrsp, tfsa or equal
+	Difference             float64 `json:"difference"`
+}
+
+// LoanPayment amortizes a loan with the schedule math used for mortgage accounts
+func (s *Service) LoanPayment(req *LoanPaymentRequest) (*LoanPaymentResult, error) {
+	if req.Principal <= 0 {
+		return nil, fmt.Errorf("principal must be positive")
+	}
+	if err := validateRate(req.AnnualRate); err != nil {
+		return nil, err
+	}
+	if req.AmortizationMonths <= 0 || req.AmortizationMonths > maxCalculatorYear*12 {
+		return nil, fmt.Errorf("amortization_months must be between 1 and %d", maxCalculatorYear*12)
+	}
+	frequency := req.PaymentFrequency
+	switch frequency {
+	case "":
+		frequency = "monthly"
+	case "weekly", "bi-weekly", "semi-monthly", "monthly":
+	default:
+		return nil, fmt.Errorf("invalid payment frequency: %s", frequency)
+	}
+
+	payment, schedule := account.AmortizationFor(req.Principal, req.AnnualRate, req.AmortizationMonths, frequency, time.Now().UTC())
+	result := &LoanPaymentResult{
+		Payment:  roundCents(payment),
+		Payments: len(schedule),
+	}
+	for _, entry := range schedule {
+		result.TotalPaid += entry.PaymentAmount
+		result.TotalInterest += entry.InterestAmount
+	}
+	result.TotalPaid = roundCents(result.TotalPaid)
+	result.TotalInterest = roundCents(result.TotalInterest)
+	if req.IncludeSchedule {
+		result.Schedule = schedule
+	}
+	return result, nil
+}
+
+// MortgageAffordability finds the largest mortgage whose payment, at the stress-tested rate,
+// keeps housing costs within GDS and all debt within TDS of gross income
+func (s *Service) MortgageAffordability(req *AffordabilityRequest) (*AffordabilityResult, error) {
+	if req.AnnualIncome <= 0 {
+		return nil, fmt.Errorf("annual_income must be positive")
+	}
+	if req.MonthlyDebts < 0 || req.DownPayment < 0 || req.MonthlyPropertyTax < 0 || req.MonthlyHeating < 0 {
+		return nil, fmt.Errorf("debts, down payment, property tax and heating must not be negative")
+	}
+	if err := validateRate(req.AnnualRate); err != nil {
+		return nil, err
+	}
+	months := req.AmortizationMonths
+	if months == 0 {
+		months = 25 * 12
+	}
+	if months < 0 || months > maxCalculatorYear*12 {
+		return nil, fmt.Errorf("amortization_months must be between 1 and %d", maxCalculatorYear*12)
+	}
+
+	monthlyIncome := req.AnnualIncome / 12
+	housing := req.MonthlyPropertyTax + req.MonthlyHeating
+	gdsPayment := monthlyIncome*maxGDSRatio - housing
+	tdsPayment := monthlyIncome*maxTDSRatio - housing - req.MonthlyDebts
+
+	result := &AffordabilityResult{
+		QualifyingRate: math.Max(req.AnnualRate+stressTestBuffer, stressTestFloor),
+		LimitedBy:      "gds",
+	}
+	maxPayment := gdsPayment
+	if tdsPayment < gdsPayment {
+		maxPayment = tdsPayment
+		result.LimitedBy = "tds"
+	}
+	if maxPayment > 0 {
+		// Payments scale with principal, so the payment on one dollar gives the principal
+		perDollar := account.AmortizedPayment(1, result.QualifyingRate, months, 12)
+		result.MaxMonthlyPayment = roundCents(maxPayment)
+		result.MaxMortgage = roundCents(maxPayment / perDollar)
+		result.ContractPayment = roundCents(account.AmortizedPayment(result.MaxMortgage, req.AnnualRate, months, 12))
+	}
+	result.MaxPurchasePrice = roundCents(result.MaxMortgage + req.DownPayment)
+	return result, nil
+}
+
+// CompoundGrowth grows a balance month by month as projections grow investment accounts,
+// adding each month's contribution after that month's growth
+func (s *Service) CompoundGrowth(req *CompoundGrowthRequest) (*CompoundGrowthResult, error) {
+	if req.InitialBalance < 0 || req.MonthlyContribution < 0 {
+		return nil, fmt.Errorf("initial_balance and monthly_contribution must not be negative")
+	}
+	if err := validateRate(req.AnnualRate); err != nil {
+		return nil, err
+	}
+	if req.Years <= 0 || req.Years > maxCalculatorYear {
+		return nil, fmt.Errorf("years must be between 1 and %d", maxCalculatorYear)
+	}
+
+	balance := req.InitialBalance
+	contributions := req.InitialBalance
+	result := &CompoundGrowthResult{Years: make([]GrowthYear, 0, req.Years)}
+	for year := 1; year <= req.Years; year++ {
+		for month := 0; month < 12; month++ {
+			balance = projections.MonthlyGrowth(balance, req.AnnualRate) + req.MonthlyContribution
+			contributions += req.MonthlyContribution
+		}
+		result.Years = append(result.Years, GrowthYear{
+			Year:          year,
+			Balance:       roundCents(balance),
+			Contributions: roundCents(contributions),
+			Growth:        roundCents(balance - contributions),
+		})
+	}
+	result.FinalBalance = roundCents(balance)
+	result.TotalContributions = roundCents(contributions)
+	result.TotalGrowth = roundCents(balance - contributions)
+	return result, nil
+}
+
+// RRSPvsTFSA compares the same pre-tax contribution in each account. The RRSP takes all of it
+// and refunds the tax it saves today, then is taxed on the way out; the TFSA takes what is
+// left after tax and is never taxed again. The refund is not assumed to be reinvested.
+func (s *Service) RRSPvsTFSA(req *RRSPvsTFSARequest) (*RRSPvsTFSAResult, error) {
+	if req.AnnualIncome <= 0 {
+		return nil, fmt.Errorf("annual_income must be positive")
+	}
+	if req.Contribution <= 0 || req.Contribution > req.AnnualIncome {
+		return nil, fmt.Errorf("contribution must be positive and at most annual_income")
+	}
+	if req.RetirementIncome < 0 {
+		return nil, fmt.Errorf("retirement_income must not be negative")
+	}
+	if err := validateRate(req.AnnualRate); err != nil {
+		return nil, err
+	}
+	if req.Years < 0 || req.Years > maxCalculatorYear {
+		return nil, fmt.Errorf("years must be between 0 and %d", maxCalculatorYear)
+	}
+	withdrawalYears := req.WithdrawalYears
+	if withdrawalYears == 0 {
+		withdrawalYears = 1
+	}
+	if withdrawalYears < 0 || withdrawalYears > maxCalculatorYear {
+		return nil, fmt.Errorf("withdrawal_years must be between 1 and %d", maxCalculatorYear)
+	}
+	taxYear := req.TaxYear
+	if taxYear == 0 {
+		taxYear = time.Now().Year()
+	}
+
+	// CPP and EI are owed on employment income either way, so only income tax differs
+	before := s.incomeSvc.CalculateIncomeTax(req.AnnualIncome, req.AnnualIncome, taxYear)
+	after := s.incomeSvc.CalculateIncomeTax(req.AnnualIncome-req.Contribution, req.AnnualIncome, taxYear)
+	refund := before.TotalTax - after.TotalTax
+
+	growth := math.Pow(1+req.AnnualRate, float64(req.Years))
+	rrspValue := req.Contribution * growth
+	tfsaValue := (req.Contribution - refund) * growth
+
+	// The RRSP is drawn down in equal amounts on top of the other retirement income
+	yearly := rrspValue / float64(withdrawalYears)
+	base := s.incomeSvc.CalculateIncomeTax(req.RetirementIncome, 0, taxYear)
+	withdrawing := s.incomeSvc.CalculateIncomeTax(req.RetirementIncome+yearly, 0, taxYear)
+	withdrawalTax := (withdrawing.TotalTax - base.TotalTax) * float64(withdrawalYears)
+	rrspAfterTax := rrspValue - withdrawalTax
+
+	result := &RRSPvsTFSAResult{
+		RRSPContribution:       roundCents(req.Contribution),
+		RRSPRefund:             roundCents(refund),
+		TFSAContribution:       roundCents(req.Contribution - refund),
+		CurrentMarginalRate:    before.MarginalTaxRate,
+		RRSPFutureValue:        roundCents(rrspValue),
+		RRSPWithdrawalTax:      roundCents(withdrawalTax),
+		RRSPAfterTax:           roundCents(rrspAfterTax),
+		RetirementMarginalRate: withdrawing.MarginalTaxRate,
+		TFSAFutureValue:        roundCents(tfsaValue),
+		Difference:             roundCents(math.Abs(rrspAfterTax - tfsaValue)),
+	}
+	switch {
+	case result.Difference < 0.01:
+		result.Better = "equal"
+	case rrspAfterTax > tfsaValue:
+		result.Better = "rrsp"
+	default:
+		result.Better = "tfsa"
+	}
+	return result, nil
+}
+
+// validateRate rejects rates outside -100% to 100% a year
+func validateRate(rate float64) error {
+	if math.IsNaN(rate) || rate <= -1 || rate > 1 {
+		return fmt.Errorf("annual_rate must be a fraction between -1 and 1")
+	}
+	return nil
+}
+
+// roundCents rounds an amount to the cent
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
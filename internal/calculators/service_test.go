@@ -0,0 +1,163 @@
+package calculators
+
+import (
+	"math"
+	"testing"
+
+	"money/internal/income"
+)
+
+func newTestService() *Service {
+	// The calculators never touch the database
+	return NewService(income.NewService(nil))
+}
+
+func TestLoanPayment_AmortizesToZero(t *testing.T) {
+	// Arrange
+	service := newTestService()
+
+	// Act
+	result, err := service.LoanPayment(&LoanPaymentRequest{Principal: 100000, AnnualRate: 0.06, AmortizationMonths: 120})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("LoanPayment failed: %v", err)
+	}
+	if result.Payment != 1110.21 {
+		t.Errorf("Expected a monthly payment of 1110.21, got %.2f", result.Payment)
+	}
+	if result.Payments != 120 {
+		t.Errorf("Expected 120 payments, got %d", result.Payments)
+	}
+	if math.Abs(result.TotalPaid-result.TotalInterest-100000) > 1 {
+		t.Errorf("Expected principal repaid in full, paid %.2f with %.2f interest", result.TotalPaid, result.TotalInterest)
+	}
+	if result.Schedule != nil {
+		t.Error("Expected no schedule unless asked for")
+	}
+}
+
+func TestMortgageAffordability_StressTestsAndCapsByTDS(t *testing.T) {
+	// Arrange
+	service := newTestService()
+	req := &AffordabilityRequest{
+		AnnualIncome:       120000,
+		MonthlyDebts:       1000,
+		DownPayment:        100000,
+		AnnualRate:         0.045,
+		MonthlyPropertyTax: 300,
+		MonthlyHeating:     100,
+	}
+
+	// Act
+	result, err := service.MortgageAffordability(req)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("MortgageAffordability failed: %v", err)
+	}
+	if result.QualifyingRate != 0.065 {
+		t.Errorf("Expected qualification at the contract rate plus 2%%, got %v", result.QualifyingRate)
+	}
+	// GDS allows 3900 - 400 = 3500, TDS allows 4400 - 400 - 1000 = 3000
+	if result.LimitedBy != "tds" || result.MaxMonthlyPayment != 3000 {
+		t.Errorf("Expected TDS to cap the payment at 3000, got %s %.2f", result.LimitedBy, result.MaxMonthlyPayment)
+	}
+	if result.MaxMortgage < 440000 || result.MaxMortgage > 450000 {
+		t.Errorf("Expected a maximum mortgage near 445k, got %.2f", result.MaxMortgage)
+	}
+	if result.MaxPurchasePrice != 544308.08 || result.ContractPayment >= 3000 {
+		t.Errorf("Expected the down payment added and a contract payment below the qualifying one, got %+v", result)
+	}
+}
+
+func TestCompoundGrowth_MatchesAnnualRate(t *testing.T) {
+	// Arrange
+	service := newTestService()
+
+	// Act
+	result, err := service.CompoundGrowth(&CompoundGrowthRequest{InitialBalance: 1000, AnnualRate: 0.10, Years: 2})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CompoundGrowth failed: %v", err)
+	}
+	if result.FinalBalance != 1210 || len(result.Years) != 2 || result.Years[0].Balance != 1100 {
+		t.Errorf("Expected 1100 after a year and 1210 after two, got %+v", result)
+	}
+}
+
+func TestRRSPvsTFSA_FavorsRRSPWhenRetirementBracketIsLower(t *testing.T) {
+	// Arrange
+	service := newTestService()
+	req := &RRSPvsTFSARequest{
+		AnnualIncome:     150000,
+		Contribution:     10000,
+		RetirementIncome: 30000,
+		Years:            20,
+		WithdrawalYears:  10,
+		AnnualRate:       0.05,
+		TaxYear:          2025,
+	}
+
+	// Act
+	result, err := service.RRSPvsTFSA(req)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("RRSPvsTFSA failed: %v", err)
+	}
+	if result.RRSPRefund <= 0 || result.TFSAContribution != result.RRSPContribution-result.RRSPRefund {
+		t.Errorf("Expected the TFSA to receive the contribution less its tax, got %+v", result)
+	}
+	if result.Better != "rrsp" || result.RRSPAfterTax <= result.TFSAFutureValue {
+		t.Errorf("Expected the RRSP to win with a lower retirement bracket, got %+v", result)
+	}
+}
+
+func TestCompareOffers_ValuesVestsUnderEachScenario(t *testing.T) {
+	// Arrange
+	service := newTestService()
+	req := &OfferComparisonRequest{
+		Offers: []Offer{
+			{Name: "Startup", Salary: 150000, Equity: &OfferEquity{
+				GrantType: "iso", Shares: 400000, FMVPerShare: 2, StrikePrice: 2, CliffMonths: 12, VestingFrequency: "monthly",
+			}},
+			{Name: "BigCo", Salary: 180000, BonusRate: 0.1, SigningBonus: 20000, Equity: &OfferEquity{
+				GrantType: "rsu", Shares: 1000, FMVPerShare: 100, CliffMonths: 12, VestingFrequency: "quarterly",
+			}},
+		},
+		Scenarios: []GrowthScenario{{Name: "flat"}, {Name: "rocket", AnnualGrowth: 1}},
+	}
+
+	// Act
+	result, err := service.CompareOffers(req)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CompareOffers failed: %v", err)
+	}
+	flat := result.Scenarios[0]
+	startup, bigCo := flat.Offers[0], flat.Offers[1]
+	if len(startup.Years) != 4 || startup.Years[0].SharesVested != 100000 || startup.Years[0].EquityValue != 0 {
+		t.Errorf("Expected the cliff to vest a quarter of the options at no spread when flat, got %+v", startup.Years[0])
+	}
+	if bigCo.Years[0].Bonus != 38000 || bigCo.Years[0].EquityValue != 25000 || bigCo.Years[0].Total != 243000 {
+		t.Errorf("Expected salary, bonus with signing bonus and the cliff vest in year one, got %+v", bigCo.Years[0])
+	}
+	if flat.Better != "BigCo" {
+		t.Errorf("Expected BigCo to win when flat, got %s", flat.Better)
+	}
+	rocket := result.Scenarios[1]
+	// Options vesting at the end of year one are worth the doubled FMV less the strike
+	if rocket.Offers[0].Years[0].EquityValue != 200000 {
+		t.Errorf("Expected 200000 of option spread in year one, got %.2f", rocket.Offers[0].Years[0].EquityValue)
+	}
+	if rocket.Better != "Startup" {
+		t.Errorf("Expected the startup to win when the company doubles yearly, got %s", rocket.Better)
+	}
+
+	if _, err := service.CompareOffers(&OfferComparisonRequest{Offers: req.Offers[:1]}); err == nil {
+		t.Error("Expected an error comparing a single offer")
+	}
+}
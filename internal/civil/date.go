@@ -0,0 +1,126 @@
+// Package civil provides calendar dates that don't depend on time of day or timezone,
+// and the user's timezone used to decide which date an instant falls on.
+package civil
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Layout is the YYYY-MM-DD format dates are exchanged in
+const Layout = "2006-01-02"
+
+// Date is a calendar date, held as midnight UTC so dates compare and store consistently
+// whatever timezone the server or user is in
+type Date struct {
+	time.Time
+}
+
+// NewDate returns the given calendar date
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// DateOf returns the calendar date of t in t's own location
+func DateOf(t time.Time) Date {
+	return NewDate(t.Year(), t.Month(), t.Day())
+}
+
+// DateIn returns the calendar date the instant t falls on in loc
+func DateIn(t time.Time, loc *time.Location) Date {
+	return DateOf(t.In(loc))
+}
+
+// Today returns the current date in loc
+func Today(loc *time.Location) Date {
+	return DateIn(time.Now(), loc)
+}
+
+// Parse parses a YYYY-MM-DD date. Longer values such as stored timestamps are cut to
+// their date part.
+func Parse(s string) (Date, error) {
+	if len(s) > len(Layout) {
+		s = s[:len(Layout)]
+	}
+	t, err := time.Parse(Layout, s)
+	if err != nil {
+		return Date{}, err
+	}
+	return Date{Time: t}, nil
+}
+
+// String formats the date as YYYY-MM-DD
+func (d Date) String() string {
+	return d.Time.Format(Layout)
+}
+
+// AddDays returns the date n days later
+func (d Date) AddDays(n int) Date {
+	return Date{Time: d.Time.AddDate(0, 0, n)}
+}
+
+// UnmarshalJSON parses date from YYYY-MM-DD format
+func (d *Date) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	// Remove quotes
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	if s == "" || s == "null" {
+		return nil
+	}
+	t, err := time.Parse(Layout, s)
+	if err != nil {
+		return err
+	}
+	d.Time = t
+	return nil
+}
+
+// MarshalJSON formats date as YYYY-MM-DD
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.Time.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.Time.Format(Layout))
+}
+
+// Value implements driver.Valuer for database storage
+func (d Date) Value() (driver.Value, error) {
+	if d.Time.IsZero() {
+		return nil, nil
+	}
+	return d.Time, nil
+}
+
+// Scan implements sql.Scanner for database retrieval. Stored timestamps are reduced to
+// their calendar date.
+func (d *Date) Scan(value interface{}) error {
+	if value == nil {
+		d.Time = time.Time{}
+		return nil
+	}
+	switch v := value.(type) {
+	case time.Time:
+		d.Time = DateOf(v).Time
+		return nil
+	case []byte:
+		parsed, err := Parse(string(v))
+		if err != nil {
+			return err
+		}
+		*d = parsed
+		return nil
+	case string:
+		parsed, err := Parse(v)
+		if err != nil {
+			return err
+		}
+		*d = parsed
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Date", value)
+	}
+}
@@ -0,0 +1,57 @@
+package civil
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDateFor_UsesUserTimezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	toronto, err := time.LoadLocation("America/Toronto")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		loc  *time.Location
+		at   time.Time
+		want string
+	}{
+		// Local midnight in Tokyo is the previous day in UTC
+		{"tokyo midnight", tokyo, time.Date(2024, 1, 14, 15, 0, 0, 0, time.UTC), "2024-01-15"},
+		// Evening in Toronto is already the next day in UTC
+		{"toronto evening", toronto, time.Date(2024, 1, 16, 2, 30, 0, 0, time.UTC), "2024-01-15"},
+		// A plain date sent as UTC midnight keeps its day
+		{"plain date", toronto, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), "2024-01-15"},
+	}
+	for _, c := range cases {
+		got := DateFor(WithLocation(context.Background(), c.loc), c.at)
+		if got.String() != c.want {
+			t.Errorf("%s: expected %s, got %s", c.name, c.want, got)
+		}
+	}
+}
+
+func TestDate_ScanNormalizesStoredValues(t *testing.T) {
+	est := time.FixedZone("EST", -5*3600)
+	values := []interface{}{
+		"2024-01-15",
+		"2024-01-15 00:00:00 +0000 UTC",
+		[]byte("2024-01-15"),
+		time.Date(2024, 1, 15, 19, 30, 0, 0, est),
+	}
+	for _, v := range values {
+		var d Date
+		if err := d.Scan(v); err != nil {
+			t.Fatalf("Scan(%v) failed: %v", v, err)
+		}
+		if !d.Time.Equal(NewDate(2024, 1, 15).Time) {
+			t.Errorf("Scan(%v) = %v, want 2024-01-15 UTC", v, d.Time)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package civil
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+type contextKey struct{}
+
+// defaultLocation applies to requests without a user timezone
+var defaultLocation atomic.Pointer[time.Location]
+
+// SetDefaultLocation sets the timezone used when the user hasn't chosen one
+func SetDefaultLocation(loc *time.Location) {
+	defaultLocation.Store(loc)
+}
+
+// DefaultLocation returns the timezone used when the user hasn't chosen one, UTC unless set
+func DefaultLocation() *time.Location {
+	if loc := defaultLocation.Load(); loc != nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// WithLocation attaches the user's timezone to the context
+func WithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, contextKey{}, loc)
+}
+
+// Location returns the user's timezone from the context, or the default
+func Location(ctx context.Context) *time.Location {
+	if loc, ok := ctx.Value(contextKey{}).(*time.Location); ok && loc != nil {
+		return loc
+	}
+	return DefaultLocation()
+}
+
+// TodayIn returns the current date in the user's timezone
+func TodayIn(ctx context.Context) Date {
+	return Today(Location(ctx))
+}
+
+// DateFor returns the date a client-supplied time refers to. Exact UTC midnights are
+// taken as plain dates; other instants are placed on the calendar in the user's timezone,
+// so a date picked at local midnight doesn't shift a day when converted to UTC.
+func DateFor(ctx context.Context, t time.Time) Date {
+	if _, offset := t.Zone(); offset == 0 && t.Equal(DateOf(t).Time) {
+		return DateOf(t)
+	}
+	return DateIn(t, Location(ctx))
+}
@@ -0,0 +1,89 @@
+package credit
+
+import (
+	"time"
+
+	"money/internal/civil"
+)
+
+// Bureau is the credit bureau that reported a score
+type Bureau string
+
+const (
+	BureauEquifax    Bureau = "equifax"
+	BureauTransUnion Bureau = "transunion"
+	BureauExperian   Bureau = "experian"
+	BureauOther      Bureau = "other"
+)
+
+// validBureaus lists the bureaus a score may be recorded against
+var validBureaus = map[Bureau]bool{
+	BureauEquifax:    true,
+	BureauTransUnion: true,
+	BureauExperian:   true,
+	BureauOther:      true,
+}
+
+// Score range accepted across FICO and Canadian bureau scales
+const (
+	MinScore = 300
+	MaxScore = 900
+)
+
+// ReminderIntervalDays is how long after the latest entry a reminder to update is due
+const ReminderIntervalDays = 30
+
+// Score is a credit score the user entered
+type Score struct {
+	ID         string     `json:"id"`
+	Score      int        `json:"score"`
+	Bureau     Bureau     `json:"bureau"`
+	RecordedOn civil.Date `json:"recorded_on"`
+	Notes      *string    `json:"notes,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// CreateScoreRequest represents the request to record a credit score
+type CreateScoreRequest struct {
+	Score      int         `json:"score"`
+	Bureau     Bureau      `json:"bureau"`
+	RecordedOn *civil.Date `json:"recorded_on,omitempty"` // Defaults to today
+	Notes      *string     `json:"notes,omitempty"`
+}
+
+// ListScoresResponse lists credit scores, newest first
+type ListScoresResponse struct {
+	Scores []*Score `json:"scores"`
+}
+
+// ChartPoint is one score on a bureau's chart line
+type ChartPoint struct {
+	Date  civil.Date `json:"date"`
+	Score int        `json:"score"`
+}
+
+// ChartSeries is the score history for one bureau, oldest first
+type ChartSeries struct {
+	Bureau Bureau       `json:"bureau"`
+	Points []ChartPoint `json:"points"`
+	Latest int          `json:"latest"`
+	Change int          `json:"change"` // Latest score minus the previous one
+}
+
+// ChartResponse is credit score history shaped for charting
+type ChartResponse struct {
+	Series []ChartSeries `json:"series"`
+}
+
+// ReminderSettings controls the monthly reminder to enter a new score
+type ReminderSettings struct {
+	Enabled     bool        `json:"enabled"`
+	LastEntryOn *civil.Date `json:"last_entry_on,omitempty"`
+	NextDueOn   *civil.Date `json:"next_due_on,omitempty"`
+}
+
+// SetReminderSettingsRequest represents the request to turn reminders on or off
+type SetReminderSettingsRequest struct {
+	Enabled bool `json:"enabled"`
+}
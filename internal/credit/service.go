@@ -0,0 +1,228 @@
+// Package credit tracks the credit scores a user enters by hand so credit health sits
+// alongside their debts.
+package credit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/civil"
+
+	"github.com/google/uuid"
+)
+
+// Service provides credit score functionality
+type Service struct {
+	db *sql.DB
+}
+
+// NewService creates a new credit score service
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// CreateScore records a credit score
+func (s *Service) CreateScore(ctx context.Context, req *CreateScoreRequest) (*Score, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	if req.Score < MinScore || req.Score > MaxScore {
+		return nil, fmt.Errorf("score must be between %d and %d", MinScore, MaxScore)
+	}
+	if !validBureaus[req.Bureau] {
+		return nil, fmt.Errorf("unknown bureau: %s", req.Bureau)
+	}
+	recordedOn := civil.TodayIn(ctx)
+	if req.RecordedOn != nil {
+		recordedOn = *req.RecordedOn
+	}
+	if recordedOn.After(civil.TodayIn(ctx).Time) {
+		return nil, fmt.Errorf("recorded_on can't be in the future")
+	}
+
+	now := time.Now()
+	score := &Score{
+		ID:         uuid.New().String(),
+		Score:      req.Score,
+		Bureau:     req.Bureau,
+		RecordedOn: recordedOn,
+		Notes:      req.Notes,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO credit_scores (id, user_id, score, bureau, recorded_on, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, score.ID, userID, score.Score, score.Bureau, score.RecordedOn, score.Notes, score.CreatedAt, score.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credit score: %w", err)
+	}
+
+	return score, nil
+}
+
+// ListScores returns the user's credit score history, newest first, optionally for one bureau
+func (s *Service) ListScores(ctx context.Context, bureau Bureau) (*ListScoresResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	query := `
+		SELECT id, score, bureau, recorded_on, notes, created_at, updated_at
+		FROM credit_scores
+		WHERE user_id = $1
+	`
+	args := []interface{}{userID}
+	if bureau != "" {
+		query += ` AND bureau = $2`
+		args = append(args, bureau)
+	}
+	query += ` ORDER BY recorded_on DESC, created_at DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credit scores: %w", err)
+	}
+	defer rows.Close()
+
+	scores := make([]*Score, 0)
+	for rows.Next() {
+		var score Score
+		var notes sql.NullString
+		if err := rows.Scan(&score.ID, &score.Score, &score.Bureau, &score.RecordedOn, &notes,
+			&score.CreatedAt, &score.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan credit score: %w", err)
+		}
+		if notes.Valid {
+			score.Notes = &notes.String
+		}
+		scores = append(scores, &score)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read credit scores: %w", err)
+	}
+
+	return &ListScoresResponse{Scores: scores}, nil
+}
+
+// DeleteScore removes a credit score entry
+func (s *Service) DeleteScore(ctx context.Context, id string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM credit_scores WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete credit score: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("credit score not found")
+	}
+
+	return nil
+}
+
+// GetChart returns the score history as one line per bureau
+func (s *Service) GetChart(ctx context.Context) (*ChartResponse, error) {
+	history, err := s.ListScores(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return buildChart(history.Scores), nil
+}
+
+// buildChart groups scores into an oldest-first series per bureau
+func buildChart(scores []*Score) *ChartResponse {
+	byBureau := make(map[Bureau][]ChartPoint)
+	for _, score := range scores {
+		byBureau[score.Bureau] = append(byBureau[score.Bureau], ChartPoint{Date: score.RecordedOn, Score: score.Score})
+	}
+
+	resp := &ChartResponse{Series: make([]ChartSeries, 0, len(byBureau))}
+	for bureau, points := range byBureau {
+		sort.SliceStable(points, func(i, j int) bool { return points[i].Date.Before(points[j].Date.Time) })
+		series := ChartSeries{Bureau: bureau, Points: points, Latest: points[len(points)-1].Score}
+		if len(points) > 1 {
+			series.Change = series.Latest - points[len(points)-2].Score
+		}
+		resp.Series = append(resp.Series, series)
+	}
+	sort.Slice(resp.Series, func(i, j int) bool { return resp.Series[i].Bureau < resp.Series[j].Bureau })
+
+	return resp
+}
+
+// GetReminderSettings returns whether monthly update reminders are on and when the next is due
+func (s *Service) GetReminderSettings(ctx context.Context) (*ReminderSettings, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	settings := &ReminderSettings{}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT reminders_enabled FROM credit_score_settings WHERE user_id = $1
+	`, userID).Scan(&settings.Enabled)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get credit score settings: %w", err)
+	}
+
+	var lastEntry sql.NullString
+	err = s.db.QueryRowContext(ctx, `
+		SELECT MAX(recorded_on) FROM credit_scores WHERE user_id = $1
+	`, userID).Scan(&lastEntry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest credit score: %w", err)
+	}
+	if lastEntry.Valid {
+		last, err := civil.Parse(lastEntry.String)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse latest credit score date: %w", err)
+		}
+		settings.LastEntryOn = &last
+	}
+
+	if settings.Enabled {
+		next := civil.TodayIn(ctx)
+		if settings.LastEntryOn != nil {
+			next = settings.LastEntryOn.AddDays(ReminderIntervalDays)
+		}
+		settings.NextDueOn = &next
+	}
+
+	return settings, nil
+}
+
+// SetReminderSettings turns monthly update reminders on or off
+func (s *Service) SetReminderSettings(ctx context.Context, req *SetReminderSettingsRequest) (*ReminderSettings, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO credit_score_settings (user_id, reminders_enabled, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			reminders_enabled = excluded.reminders_enabled,
+			updated_at = excluded.updated_at
+	`, userID, req.Enabled, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to save credit score settings: %w", err)
+	}
+
+	return s.GetReminderSettings(ctx)
+}
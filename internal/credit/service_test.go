@@ -0,0 +1,108 @@
+package credit
+
+import (
+	"testing"
+
+	"money/internal/account"
+	"money/internal/civil"
+)
+
+func setupService(t *testing.T) (*Service, func()) {
+	t.Helper()
+	db := account.SetupTestDB(t)
+	return NewService(db), func() { account.CleanupTestDB(t, db) }
+}
+
+func TestCreateScore_ValidatesRangeAndBureau(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	ctx := account.CreateAuthContext("test-user-credit-validate-1")
+
+	// Act
+	_, lowErr := service.CreateScore(ctx, &CreateScoreRequest{Score: 250, Bureau: BureauEquifax})
+	_, bureauErr := service.CreateScore(ctx, &CreateScoreRequest{Score: 700, Bureau: "moodys"})
+	score, err := service.CreateScore(ctx, &CreateScoreRequest{Score: 742, Bureau: BureauTransUnion})
+
+	// Assert
+	if lowErr == nil {
+		t.Error("Expected error for a score below the minimum")
+	}
+	if bureauErr == nil {
+		t.Error("Expected error for an unknown bureau")
+	}
+	if err != nil {
+		t.Fatalf("CreateScore failed: %v", err)
+	}
+	if !score.RecordedOn.Equal(civil.TodayIn(ctx).Time) {
+		t.Errorf("Expected recorded_on to default to today, got %s", score.RecordedOn)
+	}
+}
+
+func TestGetChart_SeriesPerBureauOldestFirst(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	ctx := account.CreateAuthContext("test-user-credit-chart-1")
+	today := civil.TodayIn(ctx)
+	for _, req := range []CreateScoreRequest{
+		{Score: 720, Bureau: BureauEquifax, RecordedOn: datePtr(today.AddDays(-60))},
+		{Score: 735, Bureau: BureauEquifax, RecordedOn: datePtr(today)},
+		{Score: 710, Bureau: BureauEquifax, RecordedOn: datePtr(today.AddDays(-30))},
+		{Score: 690, Bureau: BureauTransUnion, RecordedOn: datePtr(today.AddDays(-10))},
+	} {
+		if _, err := service.CreateScore(ctx, &req); err != nil {
+			t.Fatalf("CreateScore failed: %v", err)
+		}
+	}
+
+	// Act
+	chart, err := service.GetChart(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetChart failed: %v", err)
+	}
+	if len(chart.Series) != 2 || chart.Series[0].Bureau != BureauEquifax {
+		t.Fatalf("Expected equifax and transunion series, got %+v", chart.Series)
+	}
+	equifax := chart.Series[0]
+	if len(equifax.Points) != 3 || equifax.Points[0].Score != 720 || equifax.Points[2].Score != 735 {
+		t.Errorf("Expected equifax points oldest first, got %+v", equifax.Points)
+	}
+	if equifax.Latest != 735 || equifax.Change != 25 {
+		t.Errorf("Expected latest 735 up 25, got %d (%+d)", equifax.Latest, equifax.Change)
+	}
+}
+
+func TestGetReminderSettings_NextDueAfterLatestEntry(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	ctx := account.CreateAuthContext("test-user-credit-reminder-1")
+	today := civil.TodayIn(ctx)
+	if _, err := service.CreateScore(ctx, &CreateScoreRequest{Score: 760, Bureau: BureauExperian, RecordedOn: datePtr(today.AddDays(-12))}); err != nil {
+		t.Fatalf("CreateScore failed: %v", err)
+	}
+
+	// Act
+	settings, err := service.SetReminderSettings(ctx, &SetReminderSettingsRequest{Enabled: true})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("SetReminderSettings failed: %v", err)
+	}
+	if !settings.Enabled || settings.NextDueOn == nil {
+		t.Fatalf("Expected reminders to be enabled with a due date, got %+v", settings)
+	}
+	if want := today.AddDays(ReminderIntervalDays - 12); !settings.NextDueOn.Equal(want.Time) {
+		t.Errorf("Expected next reminder on %s, got %s", want, settings.NextDueOn)
+	}
+}
+
+func datePtr(d civil.Date) *civil.Date {
+	return &d
+}
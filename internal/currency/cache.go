@@ -0,0 +1,153 @@
+package currency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"money/internal/logger"
+)
+
+const (
+	// rateCacheTTL is how long loaded rates are served without checking for newer ones
+	rateCacheTTL = 15 * time.Minute
+	// rateCacheMaxStale is how long expired rates are still served while a refresh runs.
+	// Older rates are reloaded before answering.
+	rateCacheMaxStale = 24 * time.Hour
+)
+
+// rateCache holds the latest rates in memory so summaries don't query them on every call
+type rateCache struct {
+	mu         sync.Mutex
+	rates      *LatestRatesResponse
+	loadedAt   time.Time
+	refreshing bool
+	generation int // Bumped on invalidation so a refresh begun earlier doesn't store old rates
+	now        func() time.Time
+}
+
+// cacheState is how usable the cached rates are
+type cacheState int
+
+const (
+	cacheMissing cacheState = iota
+	cacheFresh
+	cacheStale
+)
+
+// lookup returns the cached rates and whether they're fresh, stale or missing
+func (c *rateCache) lookup() (*LatestRatesResponse, cacheState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	age := c.now().Sub(c.loadedAt)
+	switch {
+	case c.rates == nil || age > rateCacheTTL+rateCacheMaxStale:
+		return nil, cacheMissing
+	case age > rateCacheTTL:
+		return c.rates, cacheStale
+	}
+	return c.rates, cacheFresh
+}
+
+// store saves rates loaded at the given generation, unless they were invalidated since
+func (c *rateCache) store(rates *LatestRatesResponse, generation int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	c.rates = rates
+	c.loadedAt = c.now()
+}
+
+// invalidate drops the cached rates so the next lookup reloads them
+func (c *rateCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rates = nil
+	c.generation++
+}
+
+// currentGeneration returns the generation a load started now would store under
+func (c *rateCache) currentGeneration() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// beginRefresh claims the single refresh slot, returning the generation to store under
+func (c *rateCache) beginRefresh() (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.refreshing {
+		return 0, false
+	}
+	c.refreshing = true
+	return c.generation, true
+}
+
+func (c *rateCache) endRefresh() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshing = false
+}
+
+// GetLatestRates returns the latest exchange rates for every currency pair from the
+// in-process cache. Fresh rates are returned as is; expired ones are returned while a
+// refresh from the provider runs in the background; missing or very old ones are loaded
+// before answering. The result is shared, so callers must not modify it.
+func (s *Service) GetLatestRates(ctx context.Context) (*LatestRatesResponse, error) {
+	rates, state := s.cache.lookup()
+	switch state {
+	case cacheFresh:
+		return rates, nil
+	case cacheStale:
+		s.revalidate()
+		return rates, nil
+	}
+
+	return s.refreshRates(ctx, s.cache.currentGeneration())
+}
+
+// InvalidateRates drops the cached rates, for when stored rates change outside a refresh
+func (s *Service) InvalidateRates() {
+	s.cache.invalidate()
+}
+
+// revalidate refreshes expired rates in the background unless a refresh is already running.
+// Without a background group the refresh runs inline; the caller already has the stale rates.
+func (s *Service) revalidate() {
+	generation, ok := s.cache.beginRefresh()
+	if !ok {
+		return
+	}
+	refresh := func(ctx context.Context) {
+		defer s.cache.endRefresh()
+		if _, err := s.refreshRates(ctx, generation); err != nil && ctx.Err() == nil {
+			logger.Warn("Failed to refresh exchange rates", "error", err)
+		}
+	}
+	if s.jobs == nil {
+		refresh(context.Background())
+		return
+	}
+	if !s.jobs.Go(refresh) {
+		s.cache.endRefresh()
+	}
+}
+
+// refreshRates fetches today's rates from the provider when they're missing, then loads
+// the latest stored rates into the cache
+func (s *Service) refreshRates(ctx context.Context, generation int) (*LatestRatesResponse, error) {
+	if err := s.syncRates(ctx); err != nil {
+		// Stored rates are still usable; the next refresh retries the provider
+		logger.Warn("Failed to sync exchange rates", "error", err)
+	}
+	rates, err := s.loadRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.store(rates, generation)
+	return rates, nil
+}
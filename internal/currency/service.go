@@ -10,16 +10,22 @@ import (
 	"net/http"
 	"strconv"
 	"time"
+
+	"money/internal/background"
+
+	"github.com/google/uuid"
 )
 
 // Service provides currency exchange rate functionality
 type Service struct {
-	db *sql.DB
+	db    *sql.DB
+	jobs  *background.Group // Runs background rate refreshes; nil refreshes inline
+	cache *rateCache
 }
 
 // NewService creates a new currency service
-func NewService(db *sql.DB) *Service {
-	return &Service{db: db}
+func NewService(db *sql.DB, jobs *background.Group) *Service {
+	return &Service{db: db, jobs: jobs, cache: &rateCache{now: time.Now}}
 }
 
 // Currency represents supported currencies
@@ -62,23 +68,76 @@ type CurrencyCode struct {
 	Value string `json:"Value"`
 }
 
-// GetLatestRates retrieves the latest exchange rates for all currency pairs
-// If no rates exist for today, it will automatically sync from CBSA API
-func (s *Service) GetLatestRates(ctx context.Context) (*LatestRatesResponse, error) {
-	// Check if we have any rates for today
-	today := time.Now().Truncate(24 * time.Hour)
-	var todayCount int
-	err := s.db.QueryRowContext(ctx, `
-		SELECT COUNT(*)
-		FROM exchange_rates
-		WHERE date = $1
-	`, today).Scan(&todayCount)
+// SetRateRequest is a manually entered exchange rate
+type SetRateRequest struct {
+	FromCurrency Currency   `json:"from_currency"`
+	ToCurrency   Currency   `json:"to_currency"`
+	Rate         float64    `json:"rate"`
+	Date         *time.Time `json:"date,omitempty"` // Defaults to today
+}
+
+// SetRatesRequest enters or imports exchange rates
+type SetRatesRequest struct {
+	Rates []SetRateRequest `json:"rates"`
+}
 
-	// If no rates for today, sync them
-	if err == nil && todayCount == 0 {
-		_ = s.syncRates(ctx)
+// SetRates stores manually entered or imported rates, each with its inverse, replacing any
+// rate already stored for the pair on that date. The rate cache is invalidated so
+// conversions use them straight away.
+func (s *Service) SetRates(ctx context.Context, req *SetRatesRequest) (*LatestRatesResponse, error) {
+	if len(req.Rates) == 0 {
+		return nil, fmt.Errorf("at least one rate is required")
+	}
+	for _, r := range req.Rates {
+		for _, c := range []Currency{r.FromCurrency, r.ToCurrency} {
+			if c != CurrencyCAD && c != CurrencyUSD && c != CurrencyINR {
+				return nil, fmt.Errorf("invalid currency: %s", c)
+			}
+		}
+		if r.FromCurrency == r.ToCurrency {
+			return nil, fmt.Errorf("rate must be between two different currencies")
+		}
+		if r.Rate <= 0 {
+			return nil, fmt.Errorf("rate must be positive")
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, r := range req.Rates {
+		date := now.Truncate(24 * time.Hour)
+		if r.Date != nil {
+			date = r.Date.Truncate(24 * time.Hour)
+		}
+		for _, pair := range []struct {
+			from, to Currency
+			rate     float64
+		}{{r.FromCurrency, r.ToCurrency, r.Rate}, {r.ToCurrency, r.FromCurrency, 1 / r.Rate}} {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO exchange_rates (id, from_currency, to_currency, rate, date, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (from_currency, to_currency, date) DO UPDATE SET rate = excluded.rate
+			`, uuid.New().String(), pair.from, pair.to, pair.rate, date, now)
+			if err != nil {
+				return nil, fmt.Errorf("failed to save exchange rate: %w", err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit exchange rates: %w", err)
 	}
 
+	s.InvalidateRates()
+	return s.GetLatestRates(ctx)
+}
+
+// loadRates reads the latest stored rate for each currency pair
+func (s *Service) loadRates(ctx context.Context) (*LatestRatesResponse, error) {
 	// SQLite compatible: using subquery instead of DISTINCT ON
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT e1.from_currency, e1.to_currency, e1.rate, e1.date
@@ -103,7 +162,7 @@ func (s *Service) GetLatestRates(ctx context.Context) (*LatestRatesResponse, err
 		var date time.Time
 
 		if err := rows.Scan(&fromCurrency, &toCurrency, &rate, &date); err != nil {
-			continue
+			return nil, fmt.Errorf("failed to scan exchange rate: %w", err)
 		}
 
 		if rates[fromCurrency] == nil {
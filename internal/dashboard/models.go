@@ -0,0 +1,93 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WidgetType identifies a dashboard widget the SPA knows how to render
+type WidgetType string
+
+const (
+	WidgetNetWorth          WidgetType = "net_worth"
+	WidgetNetWorthHistory   WidgetType = "net_worth_history"
+	WidgetAccountList       WidgetType = "account_list"
+	WidgetAssetAllocation   WidgetType = "asset_allocation"
+	WidgetHoldings          WidgetType = "holdings"
+	WidgetCashFlow          WidgetType = "cash_flow"
+	WidgetRecurringExpenses WidgetType = "recurring_expenses"
+	WidgetDebtPayoff        WidgetType = "debt_payoff"
+	WidgetMortgageSummary   WidgetType = "mortgage_summary"
+	WidgetLoanSummary       WidgetType = "loan_summary"
+	WidgetEquitySummary     WidgetType = "equity_summary"
+	WidgetVestingSchedule   WidgetType = "vesting_schedule"
+	WidgetProjections       WidgetType = "projections"
+	WidgetNotifications     WidgetType = "notifications"
+	WidgetSyncStatus        WidgetType = "sync_status"
+)
+
+// knownWidgetTypes lists every widget type a layout may contain
+var knownWidgetTypes = map[WidgetType]bool{
+	WidgetNetWorth:          true,
+	WidgetNetWorthHistory:   true,
+	WidgetAccountList:       true,
+	WidgetAssetAllocation:   true,
+	WidgetHoldings:          true,
+	WidgetCashFlow:          true,
+	WidgetRecurringExpenses: true,
+	WidgetDebtPayoff:        true,
+	WidgetMortgageSummary:   true,
+	WidgetLoanSummary:       true,
+	WidgetEquitySummary:     true,
+	WidgetVestingSchedule:   true,
+	WidgetProjections:       true,
+	WidgetNotifications:     true,
+	WidgetSyncStatus:        true,
+}
+
+// Persona is a starting layout tailored to a kind of user
+type Persona string
+
+const (
+	PersonaInvestor    Persona = "investor"
+	PersonaDebtPayoff  Persona = "debt_payoff"
+	PersonaEquityHeavy Persona = "equity_heavy"
+	PersonaCustom      Persona = "custom"
+)
+
+// DefaultPersona is used for users who have never saved a layout
+const DefaultPersona = PersonaInvestor
+
+// MaxWidgetsPerLayout caps the number of widgets a layout may contain
+const MaxWidgetsPerLayout = 50
+
+// Widget is a single dashboard widget. Widgets render in list order.
+type Widget struct {
+	ID       string          `json:"id"`
+	Type     WidgetType      `json:"type"`
+	Settings json.RawMessage `json:"settings,omitempty"` // Widget-specific JSON object
+}
+
+// Layout is a user's dashboard configuration
+type Layout struct {
+	Persona   Persona    `json:"persona"`
+	Widgets   []Widget   `json:"widgets"`
+	IsDefault bool       `json:"is_default"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// SaveLayoutRequest represents the request to save a dashboard layout
+type SaveLayoutRequest struct {
+	Persona Persona  `json:"persona,omitempty"`
+	Widgets []Widget `json:"widgets"`
+}
+
+// ResetLayoutRequest represents the request to reset to a persona's default layout
+type ResetLayoutRequest struct {
+	Persona Persona `json:"persona"`
+}
+
+// DefaultLayoutsResponse lists the default layout for each persona
+type DefaultLayoutsResponse struct {
+	Layouts map[Persona]*Layout `json:"layouts"`
+}
@@ -0,0 +1,204 @@
+// Package dashboard persists each user's dashboard layout so it follows them across devices.
+package dashboard
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"money/internal/auth"
+)
+
+// Service provides dashboard layout functionality
+type Service struct {
+	db *sql.DB
+}
+
+// NewService creates a new dashboard service
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// defaultWidgets returns the starting widgets for a persona
+func defaultWidgets(persona Persona) ([]WidgetType, bool) {
+	switch persona {
+	case PersonaInvestor:
+		return []WidgetType{
+			WidgetNetWorth, WidgetNetWorthHistory, WidgetAssetAllocation,
+			WidgetHoldings, WidgetProjections, WidgetAccountList, WidgetNotifications,
+		}, true
+	case PersonaDebtPayoff:
+		return []WidgetType{
+			WidgetNetWorth, WidgetDebtPayoff, WidgetMortgageSummary, WidgetLoanSummary,
+			WidgetCashFlow, WidgetRecurringExpenses, WidgetNotifications,
+		}, true
+	case PersonaEquityHeavy:
+		return []WidgetType{
+			WidgetNetWorth, WidgetEquitySummary, WidgetVestingSchedule,
+			WidgetAssetAllocation, WidgetProjections, WidgetNotifications,
+		}, true
+	}
+	return nil, false
+}
+
+// DefaultLayout returns the default layout for a persona
+func DefaultLayout(persona Persona) (*Layout, error) {
+	types, ok := defaultWidgets(persona)
+	if !ok {
+		return nil, fmt.Errorf("unknown persona: %s", persona)
+	}
+
+	widgets := make([]Widget, len(types))
+	for i, t := range types {
+		widgets[i] = Widget{ID: string(t), Type: t}
+	}
+
+	return &Layout{
+		Persona:   persona,
+		Widgets:   widgets,
+		IsDefault: true,
+	}, nil
+}
+
+// DefaultLayouts returns the default layout for every persona
+func (s *Service) DefaultLayouts() *DefaultLayoutsResponse {
+	layouts := make(map[Persona]*Layout)
+	for _, persona := range []Persona{PersonaInvestor, PersonaDebtPayoff, PersonaEquityHeavy} {
+		layout, _ := DefaultLayout(persona)
+		layouts[persona] = layout
+	}
+	return &DefaultLayoutsResponse{Layouts: layouts}
+}
+
+// validateWidgets checks widgets against the known widget types
+func validateWidgets(widgets []Widget) error {
+	if len(widgets) > MaxWidgetsPerLayout {
+		return fmt.Errorf("layout has %d widgets, maximum is %d", len(widgets), MaxWidgetsPerLayout)
+	}
+
+	seen := make(map[string]bool)
+	for i, widget := range widgets {
+		if widget.ID == "" {
+			return fmt.Errorf("widget %d: id is required", i)
+		}
+		if seen[widget.ID] {
+			return fmt.Errorf("widget %d: duplicate id %q", i, widget.ID)
+		}
+		seen[widget.ID] = true
+
+		if !knownWidgetTypes[widget.Type] {
+			return fmt.Errorf("widget %d: unknown widget type %q", i, widget.Type)
+		}
+
+		if len(widget.Settings) > 0 {
+			var settings map[string]interface{}
+			if err := json.Unmarshal(widget.Settings, &settings); err != nil {
+				return fmt.Errorf("widget %d: settings must be a JSON object", i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetLayout returns the current user's layout, or the default layout if none is saved
+func (s *Service) GetLayout(ctx context.Context) (*Layout, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	var persona Persona
+	var widgetsJSON string
+	var updatedAt time.Time
+	err := s.db.QueryRowContext(ctx, `
+		SELECT persona, widgets, updated_at
+		FROM dashboard_layouts
+		WHERE user_id = $1
+	`, userID).Scan(&persona, &widgetsJSON, &updatedAt)
+	if err == sql.ErrNoRows {
+		return DefaultLayout(DefaultPersona)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dashboard layout: %w", err)
+	}
+
+	var widgets []Widget
+	if err := json.Unmarshal([]byte(widgetsJSON), &widgets); err != nil {
+		return nil, fmt.Errorf("failed to decode dashboard layout: %w", err)
+	}
+
+	return &Layout{
+		Persona:   persona,
+		Widgets:   widgets,
+		UpdatedAt: &updatedAt,
+	}, nil
+}
+
+// SaveLayout validates and stores the current user's layout
+func (s *Service) SaveLayout(ctx context.Context, req *SaveLayoutRequest) (*Layout, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	if err := validateWidgets(req.Widgets); err != nil {
+		return nil, err
+	}
+
+	persona := req.Persona
+	if persona == "" {
+		persona = PersonaCustom
+	}
+	if _, ok := defaultWidgets(persona); !ok && persona != PersonaCustom {
+		return nil, fmt.Errorf("unknown persona: %s", persona)
+	}
+
+	widgets := req.Widgets
+	if widgets == nil {
+		widgets = []Widget{}
+	}
+	widgetsJSON, err := json.Marshal(widgets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode dashboard layout: %w", err)
+	}
+
+	now := time.Now()
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO dashboard_layouts (user_id, persona, widgets, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+			persona = excluded.persona,
+			widgets = excluded.widgets,
+			updated_at = excluded.updated_at
+	`, userID, persona, string(widgetsJSON), now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save dashboard layout: %w", err)
+	}
+
+	return &Layout{
+		Persona:   persona,
+		Widgets:   widgets,
+		UpdatedAt: &now,
+	}, nil
+}
+
+// ResetLayout replaces the current user's layout with a persona's default layout
+func (s *Service) ResetLayout(ctx context.Context, req *ResetLayoutRequest) (*Layout, error) {
+	persona := req.Persona
+	if persona == "" {
+		persona = DefaultPersona
+	}
+
+	layout, err := DefaultLayout(persona)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.SaveLayout(ctx, &SaveLayoutRequest{
+		Persona: persona,
+		Widgets: layout.Widgets,
+	})
+}
@@ -0,0 +1,90 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"testing"
+
+	"money/internal/account"
+)
+
+func setupService(t *testing.T) (*Service, func()) {
+	t.Helper()
+	db := account.SetupTestDB(t)
+	return NewService(db), func() { account.CleanupTestDB(t, db) }
+}
+
+func TestGetLayout_DefaultsToInvestorPersona(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	ctx := account.CreateAuthContext("test-user-dashboard-default-1")
+
+	// Act
+	layout, err := service.GetLayout(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetLayout failed: %v", err)
+	}
+	if !layout.IsDefault || layout.Persona != PersonaInvestor {
+		t.Errorf("Expected default investor layout, got persona %s (default %v)", layout.Persona, layout.IsDefault)
+	}
+	if len(layout.Widgets) == 0 {
+		t.Error("Expected default layout to contain widgets")
+	}
+}
+
+func TestSaveLayout_PersistsOrderAndSettings(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	ctx := account.CreateAuthContext("test-user-dashboard-save-1")
+	req := &SaveLayoutRequest{
+		Widgets: []Widget{
+			{ID: "debt", Type: WidgetDebtPayoff},
+			{ID: "nw", Type: WidgetNetWorthHistory, Settings: json.RawMessage(`{"range":"1y"}`)},
+		},
+	}
+
+	// Act
+	_, err := service.SaveLayout(ctx, req)
+	if err != nil {
+		t.Fatalf("SaveLayout failed: %v", err)
+	}
+	layout, err := service.GetLayout(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetLayout failed: %v", err)
+	}
+	if layout.IsDefault || layout.Persona != PersonaCustom {
+		t.Errorf("Expected saved custom layout, got persona %s (default %v)", layout.Persona, layout.IsDefault)
+	}
+	if len(layout.Widgets) != 2 || layout.Widgets[0].ID != "debt" || layout.Widgets[1].ID != "nw" {
+		t.Fatalf("Expected widgets in saved order, got %+v", layout.Widgets)
+	}
+	if string(layout.Widgets[1].Settings) != `{"range":"1y"}` {
+		t.Errorf("Expected settings to round-trip, got %s", layout.Widgets[1].Settings)
+	}
+}
+
+func TestSaveLayout_RejectsUnknownWidgetType(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	ctx := account.CreateAuthContext("test-user-dashboard-invalid-1")
+	req := &SaveLayoutRequest{
+		Widgets: []Widget{{ID: "x", Type: "crypto_ticker"}},
+	}
+
+	// Act
+	_, err := service.SaveLayout(ctx, req)
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected error for unknown widget type, got nil")
+	}
+}
@@ -0,0 +1,203 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+)
+
+// AnonymizedUserID replaces the user ID in anonymized exports
+const AnonymizedUserID = "anonymized-user"
+
+// Bounds of the random factor amounts are scaled by in an anonymized export
+const (
+	minAnonymizeScale = 0.5
+	maxAnonymizeScale = 2.0
+)
+
+// anonymizedLabels replace free-text names; the same original always gets the same label
+// within an export, so rows that shared an institution still do
+var anonymizedLabels = map[string]string{
+	"institution":         "Institution %d",
+	"lender":              "Lender %d",
+	"name":                "Name %d",
+	"email":               "user%d@example.com",
+	"provider_account_id": "provider-account-%d",
+}
+
+// tableNameLabels name the rows of tables with a name column
+var tableNameLabels = map[string]string{
+	"accounts":             "Account %d",
+	"recurring_expenses":   "Expense %d",
+	"projection_scenarios": "Scenario %d",
+	"sync_credentials":     "Connection %d",
+}
+
+// strippedColumns hold personal details with no bearing on calculations
+var strippedColumns = map[string]bool{
+	"notes":                true,
+	"body":                 true,
+	"tags":                 true,
+	"description":          true,
+	"purpose":              true,
+	"property_address":     true,
+	"property_city":        true,
+	"property_postal_code": true,
+	"mortgage_number":      true,
+	"loan_number":          true,
+	"last_sync_error":      true,
+	"destination":          true,
+	"type_specific_data":   true,
+}
+
+// jsonColumns hold JSON documents whose amounts are scaled too
+var jsonColumns = map[string]bool{
+	"config": true,
+}
+
+// anonymizer rewrites exported tables so they can be shared for reproducing bugs. Every
+// amount and quantity is multiplied by one random factor, so balances, payments and
+// holdings keep their proportions and totals still add up, while prices, rates, dates and
+// IDs are left alone.
+type anonymizer struct {
+	scale  float64
+	labels map[string]map[string]string // Column to original to replacement
+}
+
+// newAnonymizer picks the scale factor from seed, so the same seed reproduces the same export
+func newAnonymizer(seed uint64) *anonymizer {
+	r := rand.New(rand.NewPCG(seed, seed>>32|1))
+	return &anonymizer{
+		scale:  minAnonymizeScale + r.Float64()*(maxAnonymizeScale-minAnonymizeScale),
+		labels: make(map[string]map[string]string),
+	}
+}
+
+// isAmountKey reports whether a column or JSON field holds money or a unit count. Holding
+// cost_basis is per share, like a price, so it is left unscaled.
+func isAmountKey(key string) bool {
+	switch key {
+	case "amount", "quantity", "extra_payment", "purchase_price",
+		"accumulated_depreciation", "up_to_income", "extra_debt_payments":
+		return true
+	}
+	for _, suffix := range []string{"_amount", "_salary", "_expenses", "_value", "_after"} {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// table anonymizes the rows of an exported table
+func (a *anonymizer) table(name string, data []byte) ([]byte, error) {
+	var rows []map[string]any
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	for _, row := range rows {
+		if err := a.row(name, row); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(rows)
+}
+
+func (a *anonymizer) row(table string, row map[string]any) error {
+	for key, value := range row {
+		if value == nil {
+			continue
+		}
+		switch {
+		case key == "user_id":
+			row[key] = AnonymizedUserID
+		case strippedColumns[key]:
+			row[key] = nil
+		case key == "name" && tableNameLabels[table] != "":
+			if s, ok := value.(string); ok && s != "" {
+				row[key] = a.label(table+".name", tableNameLabels[table], s)
+			}
+		case anonymizedLabels[key] != "":
+			if s, ok := value.(string); ok && s != "" {
+				row[key] = a.label(key, anonymizedLabels[key], s)
+			}
+		case jsonColumns[key]:
+			s, ok := value.(string)
+			if !ok {
+				continue
+			}
+			var doc any
+			if err := json.Unmarshal([]byte(s), &doc); err != nil {
+				// Not JSON we can walk, so it can't be vouched for
+				row[key] = nil
+				continue
+			}
+			encoded, err := json.Marshal(a.walk(key, doc, false))
+			if err != nil {
+				return fmt.Errorf("failed to encode %s: %w", key, err)
+			}
+			row[key] = string(encoded)
+		default:
+			row[key] = a.value(key, value, isAmountKey(key))
+		}
+	}
+	return nil
+}
+
+// walk anonymizes a JSON document the way row does a table. Everything under an amount key
+// is scaled, such as the per-account values of extra_debt_payments.
+func (a *anonymizer) walk(key string, doc any, amount bool) any {
+	switch v := doc.(type) {
+	case map[string]any:
+		for childKey, child := range v {
+			if strippedColumns[childKey] {
+				delete(v, childKey)
+				continue
+			}
+			v[childKey] = a.walk(childKey, child, amount || isAmountKey(childKey))
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = a.walk(key, child, amount)
+		}
+		return v
+	case string:
+		if anonymizedLabels[key] != "" && v != "" {
+			return a.label(key, anonymizedLabels[key], v)
+		}
+		return v
+	}
+	return a.value(key, doc, amount)
+}
+
+// value scales a number when it's an amount and leaves anything else as is. Money is
+// rounded to the cent and quantities to the precision they're stored at.
+func (a *anonymizer) value(key string, value any, amount bool) any {
+	n, ok := value.(float64)
+	if !ok || !amount {
+		return value
+	}
+	precision := 100.0
+	if key == "quantity" {
+		precision = 1e8
+	}
+	return math.Round(n*a.scale*precision) / precision
+}
+
+// label returns the replacement for an original value, numbering them in the order first seen
+func (a *anonymizer) label(column, format, original string) string {
+	labels, ok := a.labels[column]
+	if !ok {
+		labels = make(map[string]string)
+		a.labels[column] = labels
+	}
+	if label, ok := labels[original]; ok {
+		return label
+	}
+	label := fmt.Sprintf(format, len(labels)+1)
+	labels[original] = label
+	return label
+}
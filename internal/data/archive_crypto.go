@@ -0,0 +1,110 @@
+package data
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// encryptedArchiveMagic starts every passphrase-encrypted archive, followed by a format
+// version byte, the key derivation salt, the GCM nonce and the sealed ZIP
+var encryptedArchiveMagic = []byte("MONEYENC")
+
+const (
+	encryptedArchiveVersion = 1
+	archiveSaltSize         = 16
+
+	// MinPassphraseLength is the shortest passphrase accepted for encrypting an export
+	MinPassphraseLength = 12
+)
+
+// Argon2id parameters for version 1 archives
+const (
+	archiveKDFTime    = 3
+	archiveKDFMemory  = 64 * 1024 // KiB
+	archiveKDFThreads = 4
+	archiveKeySize    = 32
+)
+
+// ErrWrongPassphrase is returned when an archive can't be decrypted with the passphrase,
+// either because it is wrong or because the archive was altered
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted archive")
+
+// IsEncryptedArchive reports whether an archive was encrypted with a passphrase
+func IsEncryptedArchive(archive []byte) bool {
+	return bytes.HasPrefix(archive, encryptedArchiveMagic)
+}
+
+// archiveGCM derives the archive key from a passphrase and salt
+func archiveGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(passphrase), salt, archiveKDFTime, archiveKDFMemory, archiveKDFThreads, archiveKeySize)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptArchive encrypts an export archive with a key derived from the passphrase using
+// Argon2id, sealed with AES-256-GCM. The header is authenticated along with the archive.
+func EncryptArchive(archive []byte, passphrase string) ([]byte, error) {
+	if len(passphrase) < MinPassphraseLength {
+		return nil, fmt.Errorf("passphrase must be at least %d characters", MinPassphraseLength)
+	}
+
+	salt := make([]byte, archiveSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	gcm, err := archiveGCM(passphrase, salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	header := append(append([]byte{}, encryptedArchiveMagic...), encryptedArchiveVersion)
+	header = append(header, salt...)
+	header = append(header, nonce...)
+	return gcm.Seal(header, nonce, archive, header), nil
+}
+
+// DecryptArchive decrypts an archive produced by EncryptArchive
+func DecryptArchive(encrypted []byte, passphrase string) ([]byte, error) {
+	if !IsEncryptedArchive(encrypted) {
+		return nil, fmt.Errorf("archive is not encrypted")
+	}
+	versionAt := len(encryptedArchiveMagic)
+	if len(encrypted) <= versionAt || encrypted[versionAt] != encryptedArchiveVersion {
+		return nil, fmt.Errorf("unsupported encrypted archive version")
+	}
+
+	saltAt := versionAt + 1
+	if len(encrypted) < saltAt+archiveSaltSize {
+		return nil, ErrWrongPassphrase
+	}
+	salt := encrypted[saltAt : saltAt+archiveSaltSize]
+	gcm, err := archiveGCM(passphrase, salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	nonceAt := saltAt + archiveSaltSize
+	sealedAt := nonceAt + gcm.NonceSize()
+	if len(encrypted) < sealedAt+gcm.Overhead() {
+		return nil, ErrWrongPassphrase
+	}
+	header, nonce := encrypted[:sealedAt], encrypted[nonceAt:sealedAt]
+	archive, err := gcm.Open(nil, nonce, encrypted[sealedAt:], header)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return archive, nil
+}
@@ -0,0 +1,58 @@
+package data
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestEncryptArchive_RoundTrip tests that an encrypted archive decrypts to the original
+func TestEncryptArchive_RoundTrip(t *testing.T) {
+	archive := CreateValidTestArchive(t, "test-user-encrypt")
+	passphrase := "correct horse battery staple"
+
+	encrypted, err := EncryptArchive(archive, passphrase)
+	if err != nil {
+		t.Fatalf("EncryptArchive failed: %v", err)
+	}
+	if !IsEncryptedArchive(encrypted) || IsEncryptedArchive(archive) {
+		t.Error("Expected only the encrypted archive to be detected as encrypted")
+	}
+	if bytes.Contains(encrypted, []byte("manifest.json")) {
+		t.Error("Expected the encrypted archive not to contain plaintext file names")
+	}
+
+	decrypted, err := DecryptArchive(encrypted, passphrase)
+	if err != nil {
+		t.Fatalf("DecryptArchive failed: %v", err)
+	}
+	if !bytes.Equal(decrypted, archive) {
+		t.Error("Expected the decrypted archive to match the original")
+	}
+}
+
+// TestDecryptArchive_RejectsWrongPassphraseAndTampering tests authentication failures
+func TestDecryptArchive_RejectsWrongPassphraseAndTampering(t *testing.T) {
+	archive := CreateValidTestArchive(t, "test-user-encrypt")
+	encrypted, err := EncryptArchive(archive, "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("EncryptArchive failed: %v", err)
+	}
+
+	if _, err := DecryptArchive(encrypted, "wrong horse battery staple"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase for a wrong passphrase, got %v", err)
+	}
+
+	tampered := append([]byte{}, encrypted...)
+	tampered[len(tampered)-1] ^= 0xff
+	if _, err := DecryptArchive(tampered, "correct horse battery staple"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase for a tampered archive, got %v", err)
+	}
+}
+
+// TestEncryptArchive_ShortPassphrase tests the minimum passphrase length
+func TestEncryptArchive_ShortPassphrase(t *testing.T) {
+	if _, err := EncryptArchive([]byte("archive"), "short"); err == nil {
+		t.Error("Expected error for a short passphrase")
+	}
+}
@@ -0,0 +1,155 @@
+package data
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// archiveMigration upgrades the tables of an archive from one export version to the next
+type archiveMigration struct {
+	from    string
+	to      string
+	upgrade func(tables map[string][]byte) error
+}
+
+// archiveMigrations upgrade old archives step by step to the current ExportVersion. When
+// the export format changes, bump ExportVersion and add a step from the previous version
+// here, so existing backups stay restorable.
+var archiveMigrations = []archiveMigration{
+	{from: "1.0", to: "2.0", upgrade: upgradeCalendarDates},
+}
+
+// v1DateFields lists the calendar date fields that version 1.0 wrote as full timestamps
+var v1DateFields = map[string][]string{
+	"balances":                   {"date"},
+	"holdings":                   {"purchase_date"},
+	"holding_transactions":       {"transaction_date"},
+	"mortgage_details":           {"start_date", "renewal_date", "maturity_date"},
+	"mortgage_payments":          {"payment_date"},
+	"loan_details":               {"start_date", "maturity_date"},
+	"loan_payments":              {"payment_date"},
+	"asset_details":              {"purchase_date"},
+	"asset_depreciation_entries": {"entry_date"},
+}
+
+// upgradeCalendarDates converts 1.0 timestamps such as 2024-01-15T00:00:00Z to the
+// YYYY-MM-DD dates 2.0 uses
+func upgradeCalendarDates(tables map[string][]byte) error {
+	for table, fields := range v1DateFields {
+		tableData, ok := tables[table]
+		if !ok {
+			continue
+		}
+
+		var rows []map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(tableData))
+		decoder.UseNumber()
+		if err := decoder.Decode(&rows); err != nil {
+			return fmt.Errorf("failed to parse %s JSON: %w", table, err)
+		}
+		for _, row := range rows {
+			for _, field := range fields {
+				if value, ok := row[field].(string); ok && len(value) > len("2006-01-02") {
+					row[field] = value[:len("2006-01-02")]
+				}
+			}
+		}
+
+		upgraded, err := json.Marshal(rows)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", table, err)
+		}
+		tables[table] = upgraded
+	}
+	return nil
+}
+
+// UpgradeArchive migrates an archive written by an older export version to the current
+// one, returning it with warnings describing what changed. Current archives, and archives
+// too broken to read, are returned unchanged for validation to report on. Archives from
+// an unknown or newer version return an error.
+func (s *ImportService) UpgradeArchive(archive []byte) ([]byte, []string, error) {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return archive, nil, nil
+	}
+	manifestFile := findFile(reader, "manifest.json")
+	if manifestFile == nil {
+		return archive, nil, nil
+	}
+	manifestData, err := readZipFile(manifestFile)
+	if err != nil {
+		return archive, nil, nil
+	}
+	var manifest ExportManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return archive, nil, nil
+	}
+	if manifest.Version == ExportVersion {
+		return archive, nil, nil
+	}
+
+	var steps []archiveMigration
+	for version := manifest.Version; version != ExportVersion; {
+		found := false
+		for _, migration := range archiveMigrations {
+			if migration.from == version {
+				steps = append(steps, migration)
+				version = migration.to
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("export version %s is not supported (current version is %s)", manifest.Version, ExportVersion)
+		}
+	}
+
+	// Read the tables, checking checksums now since the rebuilt manifest will match
+	var warnings []string
+	tables := make(map[string][]byte)
+	for _, file := range reader.File {
+		if file.Name == "manifest.json" || !strings.HasSuffix(file.Name, ".json") {
+			continue
+		}
+		tableData, err := readZipFile(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+		tableName := strings.TrimSuffix(file.Name, ".json")
+		if metadata, ok := manifest.Tables[tableName]; ok {
+			hash := sha256.Sum256(tableData)
+			if hex.EncodeToString(hash[:]) != metadata.Checksum {
+				warnings = append(warnings, fmt.Sprintf("Checksum mismatch for %s", tableName))
+			}
+		}
+		tables[tableName] = tableData
+	}
+
+	for _, step := range steps {
+		if err := step.upgrade(tables); err != nil {
+			return nil, nil, fmt.Errorf("failed to upgrade archive from %s to %s: %w", step.from, step.to, err)
+		}
+		warnings = append(warnings, fmt.Sprintf("Upgraded archive from export version %s to %s", step.from, step.to))
+	}
+
+	exporter := &ExportService{}
+	upgradedManifest := exporter.createManifest(manifest.UserID, tables)
+	upgradedManifest.AppVersion = manifest.AppVersion
+	upgradedManifest.ExportedAt = manifest.ExportedAt
+	upgradedManifestData, err := json.MarshalIndent(upgradedManifest, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	upgraded, err := exporter.createZipArchive(upgradedManifestData, tables)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return upgraded, warnings, nil
+}
@@ -0,0 +1,84 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// createV1Archive creates an archive as export version 1.0 wrote it, with calendar dates
+// as full timestamps
+func createV1Archive(t *testing.T, userID string) []byte {
+	t.Helper()
+
+	tables := make(map[string][]byte)
+	for _, table := range []string{
+		"holdings", "holding_transactions", "mortgage_details", "mortgage_payments",
+		"loan_details", "loan_payments", "asset_details", "asset_depreciation_entries",
+		"recurring_expenses", "projection_scenarios", "equity_grants", "vesting_schedules",
+		"fmv_history", "equity_exercises", "equity_sales",
+	} {
+		tables[table] = []byte("[]")
+	}
+	tables["accounts"] = []byte(`[{"id":"test-acc-v1","user_id":"` + userID + `","name":"Old Account","type":"checking",` +
+		`"currency":"CAD","institution":null,"is_asset":true,"is_active":true,` +
+		`"created_at":"2023-05-01T12:00:00Z","updated_at":"2023-05-01T12:00:00Z"}]`)
+	tables["balances"] = []byte(`[{"id":"test-bal-v1","account_id":"test-acc-v1","amount":1234.56,` +
+		`"date":"2024-01-15T00:00:00Z","notes":null,"created_at":"2024-01-15T08:30:00Z"}]`)
+
+	manifest := CreateTestManifest(userID, tables)
+	manifest.Version = "1.0"
+	manifestData, _ := json.Marshal(manifest)
+	return CreateTestZip(t, manifestData, tables)
+}
+
+// TestImportData_UpgradesV1Archive tests that a 1.0 archive is migrated and imported
+func TestImportData_UpgradesV1Archive(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	service := NewImportService(db)
+	userID := "test-upgrade-user"
+
+	result, err := service.ImportData(context.Background(), userID, createV1Archive(t, userID), ImportOptions{Mode: "merge"})
+	if err != nil {
+		t.Fatalf("ImportData failed: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("Expected successful import, got errors: %v", result.Errors)
+	}
+
+	upgraded := false
+	for _, warning := range result.Warnings {
+		upgraded = upgraded || strings.Contains(warning, "from export version 1.0 to 2.0")
+	}
+	if !upgraded {
+		t.Errorf("Expected an upgrade warning, got %v", result.Warnings)
+	}
+
+	var date string
+	if err := db.QueryRow("SELECT date FROM balances WHERE id = 'test-bal-v1'").Scan(&date); err != nil {
+		t.Fatalf("Failed to query balance: %v", err)
+	}
+	if !strings.HasPrefix(date, "2024-01-15") {
+		t.Errorf("Expected balance date 2024-01-15, got %s", date)
+	}
+}
+
+// TestValidateArchive_UnsupportedVersion tests that archives from unknown versions are rejected
+func TestValidateArchive_UnsupportedVersion(t *testing.T) {
+	tables := map[string][]byte{"accounts": []byte("[]")}
+	manifest := CreateTestManifest("test-user", tables)
+	manifest.Version = "99.0"
+	manifestData, _ := json.Marshal(manifest)
+	archive := CreateTestZip(t, manifestData, tables)
+
+	result, err := (&ImportService{}).ValidateArchive(archive)
+	if err != nil {
+		t.Fatalf("ValidateArchive failed: %v", err)
+	}
+	if result.Valid {
+		t.Error("Expected an archive from an unknown version to be invalid")
+	}
+}
@@ -0,0 +1,391 @@
+package data
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"money/internal/civil"
+)
+
+// AuditExportFormat is the file format of an audit log export
+type AuditExportFormat string
+
+const (
+	AuditFormatCSV   AuditExportFormat = "csv"
+	AuditFormatJSONL AuditExportFormat = "jsonl"
+)
+
+// Audit log sources, one per table that records access or changes
+const (
+	AuditSourceAPIKey   = "api_key_usage"
+	AuditSourceAdvisor  = "advisor_access"
+	AuditSourceShare    = "share_access"
+	AuditSourceSync     = "sync_change"
+	AuditSourceChecksum = "checksum" // Closes every export with the record count
+)
+
+// maxAuditExportDays caps the range of a single export
+const maxAuditExportDays = 366
+
+// auditCSVHeader lists the CSV columns in the order AuditRecord.fields returns them
+var auditCSVHeader = []string{"occurred_at", "source", "id", "actor", "action", "target", "ip_address", "outcome", "chain"}
+
+// AuditRecord is one entry of an audit log export. Chain is an HMAC over the previous
+// record's chain and this record, so a removed, reordered or edited record breaks every
+// chain after it.
+type AuditRecord struct {
+	OccurredAt time.Time `json:"occurred_at"`
+	Source     string    `json:"source"`
+	ID         string    `json:"id"`
+	Actor      string    `json:"actor"`
+	Action     string    `json:"action"`
+	Target     string    `json:"target"`
+	IPAddress  string    `json:"ip_address"`
+	Outcome    string    `json:"outcome"`
+	Chain      string    `json:"chain"`
+}
+
+// AuditExportRequest selects the logs to export. Dates are inclusive.
+type AuditExportRequest struct {
+	From   civil.Date
+	To     civil.Date
+	Format AuditExportFormat
+}
+
+// AuditExportSummary describes a finished export
+type AuditExportSummary struct {
+	Records int    `json:"records"` // Log entries, not counting the closing checksum record
+	Chain   string `json:"chain"`   // Chain of the closing checksum record
+}
+
+// AuditVerification is the result of checking an export against the server's key
+type AuditVerification struct {
+	Valid     bool   `json:"valid"`
+	Records   int    `json:"records"`
+	BrokenAt  int    `json:"broken_at,omitempty"` // 1-based record where the chain first fails
+	Complete  bool   `json:"complete"`            // Ends with a checksum record matching the count
+	LastChain string `json:"last_chain,omitempty"`
+}
+
+// AuditExportService exports a user's audit and access logs for external archiving
+type AuditExportService struct {
+	db  *sql.DB
+	key []byte
+}
+
+// NewAuditExportService creates a new audit export service. Chains are keyed with a key
+// derived from the instance secret, so only this instance can produce or verify them.
+func NewAuditExportService(db *sql.DB, secret string) *AuditExportService {
+	key := sha256.Sum256([]byte("moneyy-audit-export:" + secret))
+	return &AuditExportService{db: db, key: key[:]}
+}
+
+// ParseAuditExportFormat parses a format name, defaulting to CSV
+func ParseAuditExportFormat(value string) (AuditExportFormat, error) {
+	switch AuditExportFormat(strings.ToLower(value)) {
+	case "", AuditFormatCSV:
+		return AuditFormatCSV, nil
+	case AuditFormatJSONL:
+		return AuditFormatJSONL, nil
+	default:
+		return "", fmt.Errorf("format must be csv or jsonl")
+	}
+}
+
+// Validate checks the date range of an export request
+func (r *AuditExportRequest) Validate() error {
+	if r.From.IsZero() || r.To.IsZero() {
+		return fmt.Errorf("from and to are required")
+	}
+	if r.To.Before(r.From.Time) {
+		return fmt.Errorf("to must not be before from")
+	}
+	if r.To.Sub(r.From.Time) > maxAuditExportDays*24*time.Hour {
+		return fmt.Errorf("an export covers at most %d days", maxAuditExportDays)
+	}
+	return nil
+}
+
+// Export writes the user's audit records between two dates to w, oldest first, each
+// chained to the one before, and closes with a checksum record holding the count
+func (s *AuditExportService) Export(ctx context.Context, userID string, req *AuditExportRequest, w io.Writer) (*AuditExportSummary, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	records, err := s.loadAuditRecords(ctx, userID, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := newAuditWriter(w, req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := s.seed(userID, req.From, req.To)
+	for i := range records {
+		chain = s.link(chain, &records[i])
+		if err := writer.write(&records[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	closing := AuditRecord{
+		OccurredAt: time.Now().UTC().Truncate(time.Second),
+		Source:     AuditSourceChecksum,
+		Outcome:    strconv.Itoa(len(records)),
+	}
+	chain = s.link(chain, &closing)
+	if err := writer.write(&closing); err != nil {
+		return nil, err
+	}
+	if err := writer.flush(); err != nil {
+		return nil, err
+	}
+
+	return &AuditExportSummary{Records: len(records), Chain: chain}, nil
+}
+
+// Verify checks an export produced by Export for the same user and range
+func (s *AuditExportService) Verify(userID string, req *AuditExportRequest, r io.Reader) (*AuditVerification, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	records, err := readAuditRecords(r, req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AuditVerification{Valid: true}
+	chain := s.seed(userID, req.From, req.To)
+	for i := range records {
+		record := records[i]
+		claimed := record.Chain
+		chain = s.link(chain, &record)
+		if !hmac.Equal([]byte(chain), []byte(claimed)) {
+			result.Valid = false
+			result.BrokenAt = i + 1
+			return result, nil
+		}
+		result.LastChain = chain
+		if record.Source == AuditSourceChecksum {
+			result.Complete = i == len(records)-1 && record.Outcome == strconv.Itoa(i)
+			continue
+		}
+		result.Records++
+	}
+	result.Valid = result.Complete
+	return result, nil
+}
+
+// seed starts the chain from the export's owner and range, so records can't be moved
+// between exports
+func (s *AuditExportService) seed(userID string, from, to civil.Date) string {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%s\n%s\n%s", userID, from.String(), to.String())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// link sets a record's chain from the previous chain and returns it
+func (s *AuditExportService) link(prev string, record *AuditRecord) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(prev))
+	for _, field := range record.fields()[:len(auditCSVHeader)-1] {
+		mac.Write([]byte{0})
+		mac.Write([]byte(field))
+	}
+	record.Chain = hex.EncodeToString(mac.Sum(nil))
+	return record.Chain
+}
+
+// fields returns the record as CSV fields, in auditCSVHeader order
+func (r *AuditRecord) fields() []string {
+	return []string{
+		r.OccurredAt.UTC().Format(time.RFC3339Nano),
+		r.Source,
+		r.ID,
+		r.Actor,
+		r.Action,
+		r.Target,
+		r.IPAddress,
+		r.Outcome,
+		r.Chain,
+	}
+}
+
+// loadAuditRecords reads every audit source for the user within the dates, oldest first
+func (s *AuditExportService) loadAuditRecords(ctx context.Context, userID string, from, to civil.Date) ([]AuditRecord, error) {
+	sources := []struct {
+		name  string
+		query string
+	}{
+		{AuditSourceAPIKey, `
+			SELECT u.id, u.created_at, COALESCE('api_key:' || u.api_key_id, 'service_key:' || u.service_key_id), 'request', u.endpoint,
+				COALESCE(u.ip_address, ''), CAST(u.status_code AS TEXT)
+			FROM api_key_usage u
+			WHERE u.user_id = $1`},
+		{AuditSourceAdvisor, `
+			SELECT l.id, l.accessed_at, g.advisor_email, l.method, l.path,
+				COALESCE(l.client_ip, ''), CASE WHEN l.allowed THEN 'allowed' ELSE 'denied' END
+			FROM advisor_access_log l
+			JOIN advisor_grants g ON g.id = l.grant_id
+			WHERE g.owner_user_id = $1`},
+		{AuditSourceShare, `
+			SELECT a.id, a.accessed_at, 'share:' || a.share_id, 'view', r.report,
+				COALESCE(a.ip_address, ''), CASE WHEN a.granted THEN 'granted' ELSE 'denied' END
+			FROM report_share_accesses a
+			JOIN report_shares r ON r.id = a.share_id
+			WHERE r.user_id = $1`},
+		{AuditSourceSync, `
+			SELECT c.id, c.created_at, 'sync:' || c.sync_job_id, c.change_type,
+				c.entity_type || ':' || c.label || '.' || c.field, '', ''
+			FROM sync_job_changes c
+			JOIN sync_jobs j ON j.id = c.sync_job_id
+			JOIN synced_accounts sa ON sa.id = j.synced_account_id
+			JOIN sync_credentials sc ON sc.id = sa.credential_id
+			WHERE sc.user_id = $1`},
+	}
+
+	// Timestamps are compared in Go; stored formats vary between writers
+	start := from.Time
+	end := to.AddDays(1).Time
+	records := make([]AuditRecord, 0)
+	for _, source := range sources {
+		rows, err := s.db.QueryContext(ctx, source.query, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s log: %w", source.name, err)
+		}
+		for rows.Next() {
+			record := AuditRecord{Source: source.name}
+			if err := rows.Scan(&record.ID, &record.OccurredAt, &record.Actor, &record.Action, &record.Target,
+				&record.IPAddress, &record.Outcome); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan %s log: %w", source.name, err)
+			}
+			if record.OccurredAt.Before(start) || !record.OccurredAt.Before(end) {
+				continue
+			}
+			record.OccurredAt = record.OccurredAt.UTC()
+			records = append(records, record)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if !a.OccurredAt.Equal(b.OccurredAt) {
+			return a.OccurredAt.Before(b.OccurredAt)
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.ID < b.ID
+	})
+	return records, nil
+}
+
+// auditWriter writes records in one export format
+type auditWriter struct {
+	write func(*AuditRecord) error
+	flush func() error
+}
+
+// newAuditWriter returns a writer for the format, writing the CSV header straight away
+func newAuditWriter(w io.Writer, format AuditExportFormat) (*auditWriter, error) {
+	switch format {
+	case AuditFormatJSONL:
+		buffered := bufio.NewWriter(w)
+		encoder := json.NewEncoder(buffered)
+		return &auditWriter{
+			write: func(record *AuditRecord) error {
+				if err := encoder.Encode(record); err != nil {
+					return fmt.Errorf("failed to write audit record: %w", err)
+				}
+				return nil
+			},
+			flush: buffered.Flush,
+		}, nil
+	case AuditFormatCSV, "":
+		writer := csv.NewWriter(w)
+		if err := writer.Write(auditCSVHeader); err != nil {
+			return nil, fmt.Errorf("failed to write CSV header: %w", err)
+		}
+		return &auditWriter{
+			write: func(record *AuditRecord) error {
+				if err := writer.Write(record.fields()); err != nil {
+					return fmt.Errorf("failed to write audit record: %w", err)
+				}
+				return nil
+			},
+			flush: func() error {
+				writer.Flush()
+				return writer.Error()
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("format must be csv or jsonl")
+	}
+}
+
+// readAuditRecords parses an export back into records
+func readAuditRecords(r io.Reader, format AuditExportFormat) ([]AuditRecord, error) {
+	records := make([]AuditRecord, 0)
+	switch format {
+	case AuditFormatJSONL:
+		decoder := json.NewDecoder(r)
+		for {
+			var record AuditRecord
+			if err := decoder.Decode(&record); err == io.EOF {
+				return records, nil
+			} else if err != nil {
+				return nil, fmt.Errorf("invalid audit export: %w", err)
+			}
+			records = append(records, record)
+		}
+	case AuditFormatCSV, "":
+		rows, err := csv.NewReader(r).ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid audit export: %w", err)
+		}
+		if len(rows) == 0 || strings.Join(rows[0], ",") != strings.Join(auditCSVHeader, ",") {
+			return nil, fmt.Errorf("invalid audit export: missing header")
+		}
+		for _, row := range rows[1:] {
+			occurredAt, err := time.Parse(time.RFC3339Nano, row[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid audit export: %w", err)
+			}
+			records = append(records, AuditRecord{
+				OccurredAt: occurredAt,
+				Source:     row[1],
+				ID:         row[2],
+				Actor:      row[3],
+				Action:     row[4],
+				Target:     row[5],
+				IPAddress:  row[6],
+				Outcome:    row[7],
+				Chain:      row[8],
+			})
+		}
+		return records, nil
+	default:
+		return nil, fmt.Errorf("format must be csv or jsonl")
+	}
+}
@@ -0,0 +1,93 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"money/internal/civil"
+)
+
+// TestAuditExport_ChainsRecordsAndDetectsTampering tests exporting share link accesses and
+// verifying the export before and after an edit
+func TestAuditExport_ChainsRecordsAndDetectsTampering(t *testing.T) {
+	db := SetupTestDB(t)
+	ctx := context.Background()
+
+	// Arrange
+	userID := "test-audit-export-user"
+	createTestUser(t, db, userID)
+	defer NewDeletionService(db).PurgeUser(ctx, userID)
+
+	now := time.Now().UTC()
+	if _, err := db.Exec(`
+		INSERT INTO report_shares (id, user_id, report, token_hash, expires_at)
+		VALUES ('test-audit-share', $1, 'net_worth', 'test-audit-token', $2)
+	`, userID, now.Add(24*time.Hour)); err != nil {
+		t.Fatalf("Failed to create share: %v", err)
+	}
+	for i, accessedAt := range []time.Time{now.Add(-2 * time.Hour), now.Add(-time.Hour), now.AddDate(0, 0, -10)} {
+		if _, err := db.Exec(`
+			INSERT INTO report_share_accesses (id, share_id, accessed_at, ip_address, granted)
+			VALUES ($1, 'test-audit-share', $2, '203.0.113.7', $3)
+		`, "test-audit-access-"+string(rune('a'+i)), accessedAt, i == 0); err != nil {
+			t.Fatalf("Failed to create share access: %v", err)
+		}
+	}
+
+	service := NewAuditExportService(db, "test-secret")
+	today := civil.DateOf(now)
+	req := &AuditExportRequest{From: today.AddDays(-1), To: today, Format: AuditFormatCSV}
+
+	// Act
+	var buf bytes.Buffer
+	summary, err := service.Export(ctx, userID, req, &buf)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if summary.Records != 2 {
+		t.Fatalf("Expected the 2 accesses in range, got %d", summary.Records)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[1], now.Add(-2*time.Hour).Format("2006-01-02T15")) {
+		t.Fatalf("Expected header, 2 records oldest first and a checksum, got %q", lines)
+	}
+
+	result, err := service.Verify(userID, req, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !result.Valid || !result.Complete || result.Records != 2 || result.LastChain != summary.Chain {
+		t.Errorf("Expected the untouched export to verify, got %+v", result)
+	}
+
+	tampered := strings.Replace(buf.String(), ",granted,", ",denied,", 1)
+	result, err = service.Verify(userID, req, strings.NewReader(tampered))
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if result.Valid || result.BrokenAt != 1 {
+		t.Errorf("Expected the edited record to break the chain, got %+v", result)
+	}
+
+	truncated := strings.Join(lines[:3], "\n") + "\n"
+	result, err = service.Verify(userID, req, strings.NewReader(truncated))
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if result.Valid || result.Complete {
+		t.Errorf("Expected an export without its checksum record to be incomplete, got %+v", result)
+	}
+
+	result, err = NewAuditExportService(db, "other-secret").Verify(userID, req, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if result.Valid {
+		t.Error("Expected an export to fail verification under another instance's key")
+	}
+}
@@ -0,0 +1,79 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"money/internal/database"
+)
+
+// CompleteExportVersion is the format version of the complete export
+const CompleteExportVersion = "1.0"
+
+// ExportComplete returns every row stored for a user, table by table
+func (s *ExportService) ExportComplete(ctx context.Context, userID string) (*CompleteExport, error) {
+	ctx, cancel := database.WithOperationTimeout(ctx)
+	defer cancel()
+
+	export := &CompleteExport{
+		Version:    CompleteExportVersion,
+		ExportedAt: time.Now(),
+		UserID:     userID,
+		Tables:     make(map[string][]map[string]interface{}, len(userTables)),
+	}
+	for _, table := range userTables {
+		rows, err := s.exportTableRows(ctx, table, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", table.name, err)
+		}
+		export.Tables[table.name] = rows
+	}
+
+	return export, nil
+}
+
+// exportTableRows reads a user's rows from a table as column-to-value maps
+func (s *ExportService) exportTableRows(ctx context.Context, table userTable, userID string) ([]map[string]interface{}, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT * FROM "+table.name+" WHERE "+table.scope, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	omit := make(map[string]bool, len(table.omit))
+	for _, column := range table.omit {
+		omit[column] = true
+	}
+
+	records := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		record := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if omit[column] {
+				continue
+			}
+			// TEXT columns such as JSON documents can come back as bytes
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			record[column] = values[i]
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
@@ -0,0 +1,176 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/auth/passkey"
+	"money/internal/background"
+)
+
+// DeletionGraceDays is how long a user has to cancel an account deletion before their
+// data is purged
+const DeletionGraceDays = 30
+
+// ErrDeletionRefused is returned when the caller may not schedule the account's deletion
+var ErrDeletionRefused = errors.New("account deletion refused")
+
+// DeletionService handles account closure: scheduling, cancelling and purging user data
+type DeletionService struct {
+	db *sql.DB
+}
+
+// NewDeletionService creates a new deletion service
+func NewDeletionService(db *sql.DB) *DeletionService {
+	return &DeletionService{db: db}
+}
+
+// RequestDeletion schedules all of a user's data for deletion once the grace window ends.
+// The user confirms by entering their account's email from a recent login; API keys and
+// the demo account can't request it. Requesting again keeps the original schedule.
+func (s *DeletionService) RequestDeletion(ctx context.Context, userID string, req *RequestDeletionRequest) (*DeletionRequest, error) {
+	if userID == passkey.DemoUserID {
+		return nil, fmt.Errorf("%w: the demo account can't be deleted", ErrDeletionRefused)
+	}
+	if auth.IsServiceKey(ctx) {
+		return nil, fmt.Errorf("%w: API keys can't delete an account", ErrDeletionRefused)
+	}
+	if !auth.RecentlyAuthenticated(ctx) {
+		return nil, fmt.Errorf("%w: log in again to delete your account", ErrDeletionRefused)
+	}
+
+	var email string
+	err := s.db.QueryRowContext(ctx, `SELECT email FROM users WHERE id = $1`, userID).Scan(&email)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !strings.EqualFold(strings.TrimSpace(req.ConfirmEmail), email) {
+		return nil, fmt.Errorf("confirmation email does not match the account")
+	}
+
+	now := time.Now()
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO account_deletion_requests (user_id, requested_at, purge_after)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO NOTHING
+	`, userID, now, now.AddDate(0, 0, DeletionGraceDays))
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule account deletion: %w", err)
+	}
+
+	return s.GetDeletionRequest(ctx, userID)
+}
+
+// GetDeletionRequest returns the user's scheduled deletion
+func (s *DeletionService) GetDeletionRequest(ctx context.Context, userID string) (*DeletionRequest, error) {
+	req := &DeletionRequest{}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT requested_at, purge_after FROM account_deletion_requests WHERE user_id = $1
+	`, userID).Scan(&req.RequestedAt, &req.PurgeAfter)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no account deletion scheduled")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account deletion: %w", err)
+	}
+	return req, nil
+}
+
+// CancelDeletion cancels a scheduled deletion during the grace window
+func (s *DeletionService) CancelDeletion(ctx context.Context, userID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM account_deletion_requests WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel account deletion: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no account deletion scheduled")
+	}
+	return nil
+}
+
+// PurgeUser deletes every row stored for a user, including the user itself
+func (s *DeletionService) PurgeUser(ctx context.Context, userID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range userTables {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table.name+" WHERE "+table.scope, userID); err != nil {
+			return fmt.Errorf("delete %s failed: %w", table.name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit deletes: %w", err)
+	}
+	return nil
+}
+
+// PurgeDue purges every user whose grace window has ended and returns how many were purged
+func (s *DeletionService) PurgeDue(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id FROM account_deletion_requests WHERE purge_after <= $1
+	`, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to list due account deletions: %w", err)
+	}
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan account deletion: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read account deletions: %w", err)
+	}
+
+	for i, userID := range userIDs {
+		if err := s.PurgeUser(ctx, userID); err != nil {
+			return i, fmt.Errorf("failed to purge user %s: %w", userID, err)
+		}
+	}
+	return len(userIDs), nil
+}
+
+// StartPurging purges users whose grace window has ended, hourly until jobs drains
+func (s *DeletionService) StartPurging(jobs *background.Group) {
+	jobs.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			jobs.Exclusive(ctx, "account_deletion_purge", time.Hour, func(ctx context.Context) {
+				if purged, err := s.PurgeDue(ctx); err != nil {
+					log.Printf("ERROR: account deletion purge failed: %v", err)
+				} else if purged > 0 {
+					log.Printf("INFO: purged data for %d deleted accounts", purged)
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
@@ -0,0 +1,191 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/auth/passkey"
+)
+
+// createTestUser creates a user row for tests that need one
+func createTestUser(t *testing.T, db *sql.DB, userID string) {
+	t.Helper()
+	_, err := db.Exec(`
+		INSERT INTO users (id, email, created_at, updated_at)
+		VALUES ($1, $2, $3, $3)
+	`, userID, userID+"@test.com", time.Now())
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+}
+
+// TestUserTables_CoverSchema fails when a table is added without deciding how it is
+// exported and purged
+func TestUserTables_CoverSchema(t *testing.T) {
+	db := SetupTestDB(t)
+
+	listed := make(map[string]bool, len(userTables))
+	for _, table := range userTables {
+		listed[table.name] = true
+	}
+
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`)
+	if err != nil {
+		t.Fatalf("Failed to list tables: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("Failed to scan table name: %v", err)
+		}
+		if !listed[name] && !sharedTables[name] {
+			t.Errorf("Table %s is neither a user table nor a shared table", name)
+		}
+	}
+}
+
+// TestPurgeUser_DeletesAllUserData tests that purging removes the user's rows and no one else's
+func TestPurgeUser_DeletesAllUserData(t *testing.T) {
+	db := SetupTestDB(t)
+	ctx := context.Background()
+
+	userID := "test-purge-user"
+	otherID := "test-purge-other"
+	createTestUser(t, db, userID)
+	accountID := CreateTestAccount(t, db, userID)
+	CreateTestBalance(t, db, accountID)
+	otherAccountID := CreateTestAccount(t, db, otherID)
+	CreateTestBalance(t, db, otherAccountID)
+
+	service := NewDeletionService(db)
+	if err := service.PurgeUser(ctx, userID); err != nil {
+		t.Fatalf("PurgeUser failed: %v", err)
+	}
+
+	for _, table := range userTables {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM "+table.name+" WHERE "+table.scope, userID).Scan(&count); err != nil {
+			t.Fatalf("Failed to count %s: %v", table.name, err)
+		}
+		if count != 0 {
+			t.Errorf("Expected no %s rows after purge, got %d", table.name, count)
+		}
+	}
+	var otherBalances int
+	db.QueryRow("SELECT COUNT(*) FROM balances WHERE account_id = $1", otherAccountID).Scan(&otherBalances)
+	if otherBalances != 1 {
+		t.Errorf("Expected the other user's balance to survive, got %d", otherBalances)
+	}
+}
+
+// TestRequestDeletion_GraceWindow tests scheduling, confirming and cancelling a deletion
+func TestRequestDeletion_GraceWindow(t *testing.T) {
+	db := SetupTestDB(t)
+	ctx := auth.WithAuthenticatedAt(context.Background(), time.Now())
+
+	userID := "test-deletion-user"
+	createTestUser(t, db, userID)
+	service := NewDeletionService(db)
+	defer service.PurgeUser(ctx, userID)
+
+	if _, err := service.RequestDeletion(ctx, userID, &RequestDeletionRequest{ConfirmEmail: "someone@else.com"}); err == nil {
+		t.Error("Expected error when the confirmation email doesn't match")
+	}
+
+	deletion, err := service.RequestDeletion(ctx, userID, &RequestDeletionRequest{ConfirmEmail: userID + "@TEST.com"})
+	if err != nil {
+		t.Fatalf("RequestDeletion failed: %v", err)
+	}
+	if days := deletion.PurgeAfter.Sub(deletion.RequestedAt).Hours() / 24; days < DeletionGraceDays-1 {
+		t.Errorf("Expected a %d day grace window, got %.1f days", DeletionGraceDays, days)
+	}
+
+	purged, err := service.PurgeDue(ctx)
+	if err != nil {
+		t.Fatalf("PurgeDue failed: %v", err)
+	}
+	if purged != 0 {
+		t.Errorf("Expected nothing purged during the grace window, got %d", purged)
+	}
+
+	if err := service.CancelDeletion(ctx, userID); err != nil {
+		t.Fatalf("CancelDeletion failed: %v", err)
+	}
+	if _, err := service.GetDeletionRequest(ctx, userID); err == nil {
+		t.Error("Expected no deletion scheduled after cancelling")
+	}
+}
+
+// TestRequestDeletion_RefusedWithoutAFreshLogin tests the callers that can't schedule a deletion
+func TestRequestDeletion_RefusedWithoutAFreshLogin(t *testing.T) {
+	// Arrange
+	db := SetupTestDB(t)
+	userID := "test-deletion-refused"
+	createTestUser(t, db, userID)
+	service := NewDeletionService(db)
+	fresh := auth.WithAuthenticatedAt(context.Background(), time.Now())
+	cases := []struct {
+		name   string
+		ctx    context.Context
+		userID string
+	}{
+		{"stale login", auth.WithAuthenticatedAt(context.Background(), time.Now().Add(-auth.ReauthWindow-time.Minute)), userID},
+		{"service key", auth.WithServiceKey(fresh), userID},
+		{"demo account", fresh, passkey.DemoUserID},
+	}
+
+	for _, tc := range cases {
+		// Act
+		_, err := service.RequestDeletion(tc.ctx, tc.userID, &RequestDeletionRequest{ConfirmEmail: tc.userID + "@test.com"})
+
+		// Assert
+		if !errors.Is(err, ErrDeletionRefused) {
+			t.Errorf("%s: expected the deletion to be refused, got %v", tc.name, err)
+		}
+	}
+	if _, err := service.GetDeletionRequest(context.Background(), userID); err == nil {
+		t.Error("Expected no deletion scheduled")
+	}
+}
+
+// TestExportComplete_IncludesAllTablesWithoutSecrets tests the complete export's contents
+func TestExportComplete_IncludesAllTablesWithoutSecrets(t *testing.T) {
+	db := SetupTestDB(t)
+	ctx := context.Background()
+
+	userID := "test-complete-export-user"
+	createTestUser(t, db, userID)
+	defer NewDeletionService(db).PurgeUser(ctx, userID)
+	CreateTestAccount(t, db, userID)
+	_, err := db.Exec(`
+		INSERT INTO sessions (id, user_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)
+	`, "test-session-1", userID, "secret-hash", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	export, err := NewExportService(db).ExportComplete(ctx, userID)
+	if err != nil {
+		t.Fatalf("ExportComplete failed: %v", err)
+	}
+
+	if len(export.Tables) != len(userTables) {
+		t.Errorf("Expected %d tables, got %d", len(userTables), len(export.Tables))
+	}
+	if len(export.Tables["accounts"]) != 1 || len(export.Tables["users"]) != 1 {
+		t.Errorf("Expected the user's account and user row, got %d and %d",
+			len(export.Tables["accounts"]), len(export.Tables["users"]))
+	}
+	sessions := export.Tables["sessions"]
+	if len(sessions) != 1 {
+		t.Fatalf("Expected 1 session, got %d", len(sessions))
+	}
+	if _, ok := sessions[0]["token_hash"]; ok {
+		t.Error("Expected the session token hash to be left out")
+	}
+}
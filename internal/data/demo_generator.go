@@ -0,0 +1,229 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/balance"
+	"money/internal/logger"
+)
+
+// demoHistoryMonths is how many months of balance history the generated dataset covers
+const demoHistoryMonths = 24
+
+// demoAccount describes a generated demo account and how its balance moved over time
+type demoAccount struct {
+	id            string
+	name          string
+	accountType   string
+	currency      string
+	institution   string
+	isAsset       bool
+	balance       float64 // Current balance; liabilities are negative
+	monthlyGrowth float64 // Compound monthly change used to walk the balance back in time
+	volatility    float64 // Share of the balance that swings month to month
+}
+
+// demoAccounts is the generated dataset's accounts
+var demoAccounts = []demoAccount{
+	{"demo-acc-td-checking", "TD Checking Account", "checking", "CAD", "TD Bank", true, 5200, 0.002, 0.15},
+	{"demo-acc-tangerine-savings", "Tangerine Savings Account", "savings", "CAD", "Tangerine", true, 18500, 0.012, 0.01},
+	{"demo-acc-wealthsimple-tfsa", "Wealthsimple TFSA", "tfsa", "CAD", "Wealthsimple", true, 64000, 0.011, 0.03},
+	{"demo-acc-questrade-rrsp", "Questrade RRSP", "rrsp", "CAD", "Questrade", true, 92000, 0.009, 0.03},
+	{"demo-acc-techcorp-options", "TechCorp Stock Options", "stock_options", "USD", "TechCorp Inc", true, 0, 0, 0},
+	{"demo-acc-toronto-condo", "Toronto Condo", "real_estate", "CAD", "", true, 720000, 0.003, 0},
+	{"demo-acc-td-mortgage", "TD Mortgage", "mortgage", "CAD", "TD Bank", false, -410000, -0.0025, 0},
+	{"demo-acc-td-credit-card", "TD Credit Card", "credit_card", "CAD", "TD Bank", false, -1800, 0, 0.35},
+}
+
+// demoHoldings is the generated dataset's investment positions
+var demoHoldings = []struct {
+	id        string
+	accountID string
+	symbol    string
+	quantity  float64
+	costBasis float64
+}{
+	{"demo-hold-tfsa-1", "demo-acc-wealthsimple-tfsa", "VFV.TO", 320, 105.40},
+	{"demo-hold-tfsa-2", "demo-acc-wealthsimple-tfsa", "XEQT.TO", 900, 27.80},
+	{"demo-hold-rrsp-1", "demo-acc-questrade-rrsp", "VCN.TO", 1100, 41.20},
+	{"demo-hold-rrsp-2", "demo-acc-questrade-rrsp", "ZAG.TO", 1600, 13.90},
+}
+
+// hasDemoArchive reports whether the embedded CSV demo archive is usable
+func (s *DemoService) hasDemoArchive() bool {
+	rows, err := s.readCSV("accounts.csv")
+	return err == nil && len(rows) > 0
+}
+
+// GenerateDemoData builds a demo dataset for the user programmatically, with balance
+// history and equity grants dated relative to today. Does nothing if the user has data.
+func (s *DemoService) GenerateDemoData(ctx context.Context, userID string) error {
+	hasData, err := s.HasDemoData(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if hasData {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := generateDemoDataset(ctx, tx, userID, time.Now().UTC()); err != nil {
+		return err
+	}
+	if err := balance.InvalidateSummaries(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+
+	return nil
+}
+
+// RegenerateDemoData deletes the user's demo data and generates a fresh dataset
+func (s *DemoService) RegenerateDemoData(ctx context.Context, userID string) error {
+	if err := s.ClearDemoData(ctx, userID); err != nil {
+		return err
+	}
+	return s.GenerateDemoData(ctx, userID)
+}
+
+// EnsureDemoData generates the demo dataset the first time it's needed in this process
+func (s *DemoService) EnsureDemoData(ctx context.Context, userID string) error {
+	s.provisionMu.Lock()
+	defer s.provisionMu.Unlock()
+
+	if s.provisioned {
+		return nil
+	}
+	if err := s.GenerateDemoData(ctx, userID); err != nil {
+		return err
+	}
+	s.provisioned = true
+	return nil
+}
+
+// ProvisionMiddleware generates the demo dataset on the first request made as the demo
+// user. It must run after the demo mode middleware has switched the user.
+func (s *DemoService) ProvisionMiddleware(demoUserID string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if auth.GetUserID(r.Context()) == demoUserID {
+				if err := s.EnsureDemoData(r.Context(), demoUserID); err != nil {
+					logger.Error("Failed to provision demo data", "error", err)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// generateDemoDataset inserts accounts with monthly balance history, holdings and a stock
+// option grant with its vesting schedule and FMV history
+func generateDemoDataset(ctx context.Context, tx *sql.Tx, userID string, now time.Time) error {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	for _, a := range demoAccounts {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO accounts (id, user_id, name, type, currency, institution, is_asset, is_active, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			a.id, userID, a.name, a.accountType, a.currency, nullStr(a.institution), a.isAsset, true,
+			today.AddDate(0, -demoHistoryMonths, 0), now)
+		if err != nil {
+			return fmt.Errorf("accounts: row %v: %w", a.id, err)
+		}
+
+		if a.balance == 0 {
+			continue
+		}
+		for i, monthsAgo := 0, demoHistoryMonths-1; monthsAgo >= 0; i, monthsAgo = i+1, monthsAgo-1 {
+			// Month-start balances, ending with one recorded today
+			date := time.Date(today.Year(), today.Month()-time.Month(monthsAgo), 1, 0, 0, 0, 0, time.UTC)
+			if monthsAgo == 0 {
+				date = today
+			}
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO balances (id, account_id, amount, date, created_at)
+				VALUES ($1, $2, $3, $4, $5)`,
+				fmt.Sprintf("demo-bal-%s-%d", a.id[len("demo-acc-"):], i+1), a.id,
+				demoBalance(a, monthsAgo), date, now)
+			if err != nil {
+				return fmt.Errorf("balances: account %v: %w", a.id, err)
+			}
+		}
+	}
+
+	for _, h := range demoHoldings {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO holdings (id, account_id, type, symbol, quantity, cost_basis, purchase_date, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			h.id, h.accountID, "etf", h.symbol, h.quantity, h.costBasis,
+			today.AddDate(0, -demoHistoryMonths, 0), now, now)
+		if err != nil {
+			return fmt.Errorf("holdings: row %v: %w", h.id, err)
+		}
+	}
+
+	return generateDemoGrant(ctx, tx, today, now)
+}
+
+// demoBalance walks an account's current balance back the given number of months, with a
+// deterministic swing so charts don't look like straight lines
+func demoBalance(a demoAccount, monthsAgo int) float64 {
+	amount := a.balance / math.Pow(1+a.monthlyGrowth, float64(monthsAgo))
+	if monthsAgo > 0 {
+		amount *= 1 + a.volatility*math.Sin(float64(monthsAgo)*1.7)
+	}
+	return math.Round(amount*100) / 100
+}
+
+// generateDemoGrant inserts an ISO grant issued 30 months ago vesting over four years
+// with a one-year cliff, and quarterly FMV history since the grant
+func generateDemoGrant(ctx context.Context, tx *sql.Tx, today, now time.Time) error {
+	const accountID = "demo-acc-techcorp-options"
+	grantDate := today.AddDate(0, -30, 0)
+	grantDay := grantDate.Format("2006-01-02")
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO equity_grants (id, account_id, grant_type, grant_date, quantity, strike_price, fmv_at_grant, currency, expiration_date, company_name, grant_number, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+		"demo-grant-iso-1", accountID, "iso", grantDay, 10000, 15.00, 15.00, "USD",
+		grantDate.AddDate(10, 0, 0).Format("2006-01-02"), "TechCorp Inc", "ISO-001", "Initial hire grant", grantDate, now)
+	if err != nil {
+		return fmt.Errorf("equity_grants: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO vesting_schedules (id, grant_id, schedule_type, cliff_months, total_vesting_months, vesting_frequency, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		"demo-vest-sched-1", "demo-grant-iso-1", "time_based", 12, 48, "monthly", grantDate)
+	if err != nil {
+		return fmt.Errorf("vesting_schedules: %w", err)
+	}
+
+	fmv := 15.00
+	for i, date := 0, grantDate; !date.After(today); i, date = i+1, date.AddDate(0, 3, 0) {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO fmv_history (id, account_id, currency, effective_date, fmv_per_share, notes, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			fmt.Sprintf("demo-fmv-%d", i+1), accountID, "USD", date.Format("2006-01-02"), fmv, "Quarterly 409A valuation", now)
+		if err != nil {
+			return fmt.Errorf("fmv_history: %w", err)
+		}
+		fmv = math.Round(fmv*1.08*100) / 100
+	}
+
+	return nil
+}
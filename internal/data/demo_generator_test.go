@@ -0,0 +1,54 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestRegenerateDemoData_BuildsHistoryEndingToday tests that the generated dataset is dated
+// relative to today and can be regenerated in place
+func TestRegenerateDemoData_BuildsHistoryEndingToday(t *testing.T) {
+	db := SetupTestDB(t)
+	ctx := context.Background()
+	userID := "test-user-demo-generate"
+	service := NewDemoService(db)
+
+	if err := service.EnsureDemoData(ctx, userID); err != nil {
+		t.Fatalf("EnsureDemoData failed: %v", err)
+	}
+	if err := service.RegenerateDemoData(ctx, userID); err != nil {
+		t.Fatalf("RegenerateDemoData failed: %v", err)
+	}
+
+	var accounts int
+	if err := db.QueryRow("SELECT COUNT(*) FROM accounts WHERE user_id = $1", userID).Scan(&accounts); err != nil {
+		t.Fatalf("Failed to count accounts: %v", err)
+	}
+	if accounts != len(demoAccounts) {
+		t.Errorf("Expected %d accounts, got %d", len(demoAccounts), accounts)
+	}
+
+	var balances int
+	var latest string
+	err := db.QueryRow(`
+		SELECT COUNT(*), MAX(substr(date, 1, 10)) FROM balances WHERE account_id = 'demo-acc-td-checking'
+	`).Scan(&balances, &latest)
+	if err != nil {
+		t.Fatalf("Failed to read balances: %v", err)
+	}
+	if balances != demoHistoryMonths {
+		t.Errorf("Expected %d months of balances, got %d", demoHistoryMonths, balances)
+	}
+	if today := time.Now().UTC().Format("2006-01-02"); latest != today {
+		t.Errorf("Expected latest balance dated %s, got %s", today, latest)
+	}
+
+	var fmvEntries int
+	if err := db.QueryRow("SELECT COUNT(*) FROM fmv_history WHERE account_id = 'demo-acc-techcorp-options'").Scan(&fmvEntries); err != nil {
+		t.Fatalf("Failed to count FMV history: %v", err)
+	}
+	if fmvEntries < 10 {
+		t.Errorf("Expected quarterly FMV history since the grant, got %d entries", fmvEntries)
+	}
+}
@@ -9,7 +9,10 @@ import (
 	"io"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"money/internal/balance"
 )
 
 //go:embed demo_data/*.csv
@@ -18,6 +21,9 @@ var demoDataFS embed.FS
 // DemoService handles demo data operations
 type DemoService struct {
 	db *sql.DB
+
+	provisionMu sync.Mutex
+	provisioned bool // Demo data has been generated on demand in this process
 }
 
 // NewDemoService creates a new demo service
@@ -46,6 +52,11 @@ func (s *DemoService) SeedDemoData(ctx context.Context, userID string) error {
 		return nil
 	}
 
+	// Fall back to a generated dataset when the embedded archive is missing
+	if !s.hasDemoArchive() {
+		return s.GenerateDemoData(ctx, userID)
+	}
+
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to start transaction: %w", err)
@@ -110,6 +121,9 @@ func (s *DemoService) SeedDemoData(ctx context.Context, userID string) error {
 	if err := s.importTaxConfigurations(ctx, tx, userID); err != nil {
 		return fmt.Errorf("tax_configurations: %w", err)
 	}
+	if err := balance.InvalidateSummaries(ctx, tx, userID); err != nil {
+		return err
+	}
 
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit: %w", err)
@@ -149,6 +163,7 @@ func demoDeleteQueries() []struct {
 		{"accounts", "DELETE FROM accounts WHERE user_id = $1 OR id LIKE 'demo-acc-%'"},
 		{"projection_scenarios", "DELETE FROM projection_scenarios WHERE user_id = $1 OR id LIKE 'demo-scenario-%'"},
 		{"recurring_expenses", "DELETE FROM recurring_expenses WHERE user_id = $1 OR id LIKE 'demo-expense-%'"},
+		{"account_summaries", "DELETE FROM account_summaries WHERE user_id = $1"},
 	}
 }
 
@@ -9,11 +9,14 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
+
+	"money/internal/database"
 )
 
 const (
-	ExportVersion = "1.0"
+	ExportVersion = "2.0" // Bump with a step in archiveMigrations when the format changes
 	AppVersion    = "1.0.0"
 )
 
@@ -29,6 +32,55 @@ func NewExportService(db *sql.DB) *ExportService {
 
 // ExportData creates a ZIP archive with all user data
 func (s *ExportService) ExportData(ctx context.Context, userID string) ([]byte, error) {
+	tables, err := s.exportTables(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.archive(s.createManifest(userID, tables), tables)
+}
+
+// ExportAnonymized creates a ZIP archive of the user's data with personal details removed, for
+// sharing a dataset that reproduces a bug. Amounts and quantities are scaled by a random
+// factor picked from seed, names and institutions are replaced, and notes are dropped. The
+// archive imports like any other.
+func (s *ExportService) ExportAnonymized(ctx context.Context, userID string, seed uint64) ([]byte, error) {
+	tables, err := s.exportTables(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	a := newAnonymizer(seed)
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	// Labels are numbered in the order seen, so tables are visited in a fixed order
+	sort.Strings(names)
+	for _, name := range names {
+		if tables[name], err = a.table(name, tables[name]); err != nil {
+			return nil, fmt.Errorf("failed to anonymize: %w", err)
+		}
+	}
+
+	manifest := s.createManifest(AnonymizedUserID, tables)
+	manifest.Anonymized = true
+	return s.archive(manifest, tables)
+}
+
+// archive writes the manifest and tables to a ZIP archive
+func (s *ExportService) archive(manifest ExportManifest, tables map[string][]byte) ([]byte, error) {
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	return s.createZipArchive(manifestData, tables)
+}
+
+// exportTables reads every table of the user's data as JSON
+func (s *ExportService) exportTables(ctx context.Context, userID string) (map[string][]byte, error) {
+	ctx, cancel := database.WithOperationTimeout(ctx)
+	defer cancel()
+
 	// Export all tables
 	accounts, err := s.exportAccounts(ctx, userID)
 	if err != nil {
@@ -103,7 +155,6 @@ func (s *ExportService) ExportData(ctx context.Context, userID string) ([]byte,
 		return nil, fmt.Errorf("failed to export synced accounts: %w", err)
 	}
 
-	// Create manifest
 	tables := map[string][]byte{
 		"accounts":                   accounts,
 		"balances":                   balances,
@@ -120,15 +171,7 @@ func (s *ExportService) ExportData(ctx context.Context, userID string) ([]byte,
 		"sync_credentials":           syncCredentials,
 		"synced_accounts":            syncedAccounts,
 	}
-
-	manifest := s.createManifest(userID, tables)
-	manifestData, err := json.MarshalIndent(manifest, "", "  ")
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
-	}
-
-	// Create ZIP archive
-	return s.createZipArchive(manifestData, tables)
+	return tables, nil
 }
 
 // exportAccounts exports all accounts for a user
@@ -234,7 +277,7 @@ func (s *ExportService) exportHoldings(ctx context.Context, userID string) ([]by
 func (s *ExportService) exportHoldingTransactions(ctx context.Context, userID string) ([]byte, error) {
 	query := `
 		SELECT ht.id, ht.holding_id, ht.type, ht.quantity, ht.price, ht.total_amount,
-		       ht.transaction_date, ht.notes, ht.created_at
+		       ht.currency, ht.transaction_date, ht.notes, ht.created_at
 		FROM holding_transactions ht
 		JOIN holdings h ON ht.holding_id = h.id
 		JOIN accounts a ON h.account_id = a.id
@@ -253,7 +296,7 @@ func (s *ExportService) exportHoldingTransactions(ctx context.Context, userID st
 		var tx HoldingTransaction
 		err := rows.Scan(
 			&tx.ID, &tx.HoldingID, &tx.Type, &tx.Quantity, &tx.Price, &tx.TotalAmount,
-			&tx.TransactionDate, &tx.Notes, &tx.CreatedAt,
+			&tx.Currency, &tx.TransactionDate, &tx.Notes, &tx.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -307,3 +307,137 @@ func TestCreateManifest(t *testing.T) {
 }
 
 // Note: Helper functions are now in test_helpers.go
+
+// TestExportAnonymized_ScalesAmountsAndReplacesNames tests that an anonymized export keeps
+// the data's shape while hiding the user's details, reproducibly for a seed
+func TestExportAnonymized_ScalesAmountsAndReplacesNames(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+	ctx := context.Background()
+
+	// Arrange
+	userID := "test-anonymize-user"
+	accountID := CreateTestAccount(t, db, userID)
+	CreateTestBalance(t, db, accountID)
+	if _, err := db.ExecContext(ctx, `UPDATE accounts SET name = 'Joint chequing', institution = 'Big Bank' WHERE id = $1`, accountID); err != nil {
+		t.Fatalf("Failed to name account: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE balances SET notes = 'Paycheque from Acme' WHERE account_id = $1`, accountID); err != nil {
+		t.Fatalf("Failed to annotate balance: %v", err)
+	}
+	service := NewExportService(db)
+
+	// Act
+	archive, err := service.ExportAnonymized(ctx, userID, 42)
+	if err != nil {
+		t.Fatalf("ExportAnonymized failed: %v", err)
+	}
+	again, err := service.ExportAnonymized(ctx, userID, 42)
+	if err != nil {
+		t.Fatalf("ExportAnonymized failed: %v", err)
+	}
+
+	// Assert
+	read := func(archive []byte, name string, v any) []byte {
+		t.Helper()
+		reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			t.Fatalf("Failed to open ZIP archive: %v", err)
+		}
+		file := findFile(reader, name)
+		if file == nil {
+			t.Fatalf("%s not found in archive", name)
+		}
+		content, err := readZipFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		if err := json.Unmarshal(content, v); err != nil {
+			t.Fatalf("Failed to parse %s: %v", name, err)
+		}
+		return content
+	}
+
+	var manifest ExportManifest
+	read(archive, "manifest.json", &manifest)
+	if !manifest.Anonymized || manifest.UserID != AnonymizedUserID {
+		t.Errorf("Expected an anonymized manifest, got %+v", manifest)
+	}
+
+	var accounts []Account
+	first := read(archive, "accounts.json", &accounts)
+	if len(accounts) != 1 || accounts[0].Name != "Account 1" || accounts[0].Institution == nil || *accounts[0].Institution != "Institution 1" {
+		t.Errorf("Expected the account's name and institution replaced, got %+v", accounts)
+	}
+	if accounts[0].ID != accountID {
+		t.Errorf("Expected IDs kept so rows still relate, got %s", accounts[0].ID)
+	}
+
+	var balances []Balance
+	read(archive, "balances.json", &balances)
+	if len(balances) != 1 || balances[0].Notes != nil {
+		t.Fatalf("Expected the balance's notes stripped, got %+v", balances)
+	}
+	if scale := balances[0].Amount / 1000.50; scale < minAnonymizeScale || scale > maxAnonymizeScale || balances[0].Amount == 1000.50 {
+		t.Errorf("Expected the balance scaled by a factor between %v and %v, got %v", minAnonymizeScale, maxAnonymizeScale, balances[0].Amount)
+	}
+
+	var repeated []Account
+	if second := read(again, "accounts.json", &repeated); !bytes.Equal(first, second) {
+		t.Error("Expected the same seed to produce the same export")
+	}
+}
+
+// TestAnonymizer_ScalesAmountsInsideProjectionConfig tests that amounts in a scenario's
+// config are scaled while rates are kept
+func TestAnonymizer_ScalesAmountsInsideProjectionConfig(t *testing.T) {
+	// Arrange
+	a := newAnonymizer(7)
+	row := map[string]any{
+		"name":   "Retire at 55",
+		"config": `{"annual_salary":100000,"annual_salary_growth":0.03,"extra_debt_payments":{"acc-1":500},"events":[{"name":"Buy cottage","description":"Muskoka"}]}`,
+	}
+
+	// Act
+	if err := a.row("projection_scenarios", row); err != nil {
+		t.Fatalf("row failed: %v", err)
+	}
+
+	// Assert
+	var config map[string]any
+	if err := json.Unmarshal([]byte(row["config"].(string)), &config); err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	if config["annual_salary"] == 100000.0 || config["annual_salary_growth"] != 0.03 {
+		t.Errorf("Expected the salary scaled and its growth rate kept, got %v", config)
+	}
+	if config["extra_debt_payments"].(map[string]any)["acc-1"] == 500.0 {
+		t.Errorf("Expected extra debt payments scaled, got %v", config["extra_debt_payments"])
+	}
+	event := config["events"].([]any)[0].(map[string]any)
+	if event["name"] != "Name 1" || event["description"] != nil {
+		t.Errorf("Expected the event renamed and its description dropped, got %v", event)
+	}
+	if row["name"] != "Scenario 1" {
+		t.Errorf("Expected the scenario renamed, got %v", row["name"])
+	}
+}
+
+func TestAnonymizer_KeepsPerShareCostBasis(t *testing.T) {
+	// Arrange
+	a := newAnonymizer(11)
+	row := map[string]any{"quantity": 10.0, "cost_basis": 45.5}
+
+	// Act
+	if err := a.row("holdings", row); err != nil {
+		t.Fatalf("row failed: %v", err)
+	}
+
+	// Assert
+	if row["quantity"] == 10.0 {
+		t.Errorf("Expected the quantity scaled, got %v", row["quantity"])
+	}
+	if row["cost_basis"] != 45.5 {
+		t.Errorf("Expected the per-share cost basis kept like a price, got %v", row["cost_basis"])
+	}
+}
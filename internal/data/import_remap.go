@@ -0,0 +1,88 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// importReferences lists, for each archive table, the fields holding IDs of records in
+// other archive tables, and which table each refers to
+var importReferences = map[string]map[string]string{
+	"balances":                   {"account_id": "accounts"},
+	"holdings":                   {"account_id": "accounts"},
+	"holding_transactions":       {"holding_id": "holdings"},
+	"mortgage_details":           {"account_id": "accounts"},
+	"mortgage_payments":          {"account_id": "accounts"},
+	"loan_details":               {"account_id": "accounts"},
+	"loan_payments":              {"account_id": "accounts"},
+	"asset_details":              {"account_id": "accounts"},
+	"asset_depreciation_entries": {"account_id": "accounts"},
+	"synced_accounts":            {"credential_id": "sync_credentials", "local_account_id": "accounts"},
+	"equity_grants":              {"account_id": "accounts"},
+	"vesting_schedules":          {"grant_id": "equity_grants"},
+	"fmv_history":                {"account_id": "accounts"},
+	"equity_exercises":           {"grant_id": "equity_grants"},
+	"equity_sales":               {"grant_id": "equity_grants", "exercise_id": "equity_exercises"},
+}
+
+// remapIDs gives every record in the archive data a new ID and rewrites the references
+// between them, so an archive can be imported next to existing data without its IDs
+// colliding. It returns the rewritten data and each table's old to new ID mapping.
+// A user has a single sync credential, so the archive's credential maps onto the user's
+// existing one when there is one. References to records outside the archive are kept.
+func remapIDs(data map[string][]byte, existingCredentialID string) (map[string][]byte, map[string]map[string]string, error) {
+	records := make(map[string][]map[string]interface{}, len(data))
+	idMap := make(map[string]map[string]string, len(data))
+
+	// Assign new IDs to every record first, so references can point to any table
+	for table, tableData := range data {
+		var rows []map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(tableData))
+		decoder.UseNumber()
+		if err := decoder.Decode(&rows); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s JSON: %w", table, err)
+		}
+		records[table] = rows
+
+		mapping := make(map[string]string, len(rows))
+		for _, row := range rows {
+			oldID, ok := row["id"].(string)
+			if !ok || oldID == "" {
+				continue
+			}
+			newID := uuid.New().String()
+			if table == "sync_credentials" && existingCredentialID != "" {
+				newID = existingCredentialID
+			}
+			mapping[oldID] = newID
+			row["id"] = newID
+		}
+		idMap[table] = mapping
+	}
+
+	remapped := make(map[string][]byte, len(records))
+	for table, rows := range records {
+		for field, refTable := range importReferences[table] {
+			for _, row := range rows {
+				oldID, ok := row[field].(string)
+				if !ok {
+					continue
+				}
+				if newID, ok := idMap[refTable][oldID]; ok {
+					row[field] = newID
+				}
+			}
+		}
+
+		tableData, err := json.Marshal(rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal %s: %w", table, err)
+		}
+		remapped[table] = tableData
+	}
+
+	return remapped, idMap, nil
+}
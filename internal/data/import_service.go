@@ -10,6 +10,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"time"
+
+	"money/internal/balance"
+	"money/internal/database"
 )
 
 // ImportService handles data import operations
@@ -30,6 +34,15 @@ func (s *ImportService) ValidateArchive(archive []byte) (*ValidationResult, erro
 		Warnings: []string{},
 	}
 
+	// Bring archives from older export versions up to date before checking them
+	archive, upgradeWarnings, err := s.UpgradeArchive(archive)
+	if err != nil {
+		result.Valid = false
+		result.Errors = append(result.Errors, err.Error())
+		return result, nil
+	}
+	result.Warnings = append(result.Warnings, upgradeWarnings...)
+
 	// Open ZIP archive
 	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
 	if err != nil {
@@ -63,12 +76,6 @@ func (s *ImportService) ValidateArchive(archive []byte) (*ValidationResult, erro
 
 	result.Manifest = &manifest
 
-	// Validate version compatibility
-	if manifest.Version != ExportVersion {
-		result.Warnings = append(result.Warnings,
-			fmt.Sprintf("Export version mismatch: expected %s, got %s", ExportVersion, manifest.Version))
-	}
-
 	// Validate each table file (optional tables like exchange_rates and synced_accounts are allowed but not required)
 	expectedTables := []string{
 		"accounts", "balances", "holdings", "holding_transactions",
@@ -77,11 +84,19 @@ func (s *ImportService) ValidateArchive(archive []byte) (*ValidationResult, erro
 		"projection_scenarios", "equity_grants", "vesting_schedules", "fmv_history",
 		"equity_exercises", "equity_sales",
 	}
+	// ExportData doesn't write the equity tables, so they are only checked when present
+	optionalTables := map[string]bool{
+		"equity_grants": true, "vesting_schedules": true, "fmv_history": true,
+		"equity_exercises": true, "equity_sales": true,
+	}
 
 	for _, tableName := range expectedTables {
 		fileName := fmt.Sprintf("%s.json", tableName)
 		file := findFile(reader, fileName)
 		if file == nil {
+			if optionalTables[tableName] {
+				continue
+			}
 			result.Valid = false
 			result.Errors = append(result.Errors, fmt.Sprintf("Missing file: %s", fileName))
 			continue
@@ -126,6 +141,15 @@ func (s *ImportService) ValidateArchive(archive []byte) (*ValidationResult, erro
 
 // ImportData imports data from archive with specified options
 func (s *ImportService) ImportData(ctx context.Context, userID string, archive []byte, opts ImportOptions) (*ImportResult, error) {
+	// Upgrade archives from older export versions so they import like current ones
+	archive, upgradeWarnings, err := s.UpgradeArchive(archive)
+	if err != nil {
+		return &ImportResult{
+			Success: false,
+			Errors:  []ImportError{{Message: err.Error()}},
+		}, nil
+	}
+
 	// Validate archive first
 	validation, err := s.ValidateArchive(archive)
 	if err != nil {
@@ -145,7 +169,7 @@ func (s *ImportService) ImportData(ctx context.Context, userID string, archive [
 	if opts.ValidateOnly {
 		return &ImportResult{
 			Success:  true,
-			Warnings: validation.Warnings,
+			Warnings: append(upgradeWarnings, validation.Warnings...),
 		}, nil
 	}
 
@@ -161,7 +185,7 @@ func (s *ImportService) ImportData(ctx context.Context, userID string, archive [
 		return nil, fmt.Errorf("import failed: %w", err)
 	}
 
-	result.Warnings = validation.Warnings
+	result.Warnings = append(upgradeWarnings, validation.Warnings...)
 	return result, nil
 }
 
@@ -205,6 +229,11 @@ func (s *ImportService) importInTransaction(ctx context.Context, userID string,
 		Errors:  []ImportError{},
 	}
 
+	// Bound the transaction so a slow import can't hold its connection indefinitely.
+	// Registered before the commit below, so it's cancelled only after the commit.
+	ctx, cancel := database.WithOperationTimeout(ctx)
+	defer cancel()
+
 	// Start transaction
 	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelSerializable,
@@ -226,6 +255,20 @@ func (s *ImportService) importInTransaction(ctx context.Context, userID string,
 		}
 	}()
 
+	if opts.RemapIDs {
+		var existingCredentialID string
+		err := tx.QueryRowContext(ctx, `SELECT id FROM sync_credentials WHERE user_id = $1`, userID).Scan(&existingCredentialID)
+		if err != nil && err != sql.ErrNoRows {
+			result.Success = false
+			return nil, fmt.Errorf("failed to check sync credential: %w", err)
+		}
+		data, result.IDMap, err = remapIDs(data, existingCredentialID)
+		if err != nil {
+			result.Success = false
+			return nil, fmt.Errorf("failed to remap IDs: %w", err)
+		}
+	}
+
 	// Import tables in dependency order
 	tables := []struct {
 		name       string
@@ -254,6 +297,13 @@ func (s *ImportService) importInTransaction(ctx context.Context, userID string,
 	}
 
 	for _, table := range tables {
+		// A cancelled request has already rolled the transaction back, so stop rather than
+		// report every remaining row as failed
+		if err := ctx.Err(); err != nil {
+			result.Success = false
+			return nil, fmt.Errorf("import cancelled: %w", err)
+		}
+
 		if tableData, ok := data[table.name]; ok {
 			summary, err := table.importFunc(ctx, tx, userID, tableData, opts.Mode)
 			if err != nil {
@@ -276,11 +326,21 @@ func (s *ImportService) importInTransaction(ctx context.Context, userID string,
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		result.Success = false
+		return nil, fmt.Errorf("import cancelled: %w", err)
+	}
+
 	// If we had any errors, fail the whole import
 	if len(result.Errors) > 0 {
 		result.Success = false
 	}
 
+	if err := balance.InvalidateSummaries(ctx, tx, userID); err != nil {
+		result.Success = false
+		return nil, err
+	}
+
 	return result, nil
 }
 
@@ -347,6 +407,7 @@ func (s *ImportService) importBalances(ctx context.Context, tx *sql.Tx, userID s
 	}
 
 	summary := ImportTableSummary{}
+	var earliest *time.Time
 
 	for _, bal := range balances {
 		query := `
@@ -372,6 +433,16 @@ func (s *ImportService) importBalances(ctx context.Context, tx *sql.Tx, userID s
 		} else {
 			summary.Updated++
 		}
+		if earliest == nil || bal.Date.Time.Before(*earliest) {
+			earliest = &bal.Date.Time
+		}
+	}
+
+	// Imported history may predate existing snapshots
+	if earliest != nil {
+		if err := balance.QueueNetWorthRecompute(ctx, tx, userID, *earliest); err != nil {
+			return summary, err
+		}
 	}
 
 	return summary, nil
@@ -434,20 +505,21 @@ func (s *ImportService) importHoldingTransactions(ctx context.Context, tx *sql.T
 
 	for _, ht := range transactions {
 		query := `
-			INSERT INTO holding_transactions (id, holding_id, type, quantity, price, total_amount, transaction_date, notes, created_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			INSERT INTO holding_transactions (id, holding_id, type, quantity, price, total_amount, currency, transaction_date, notes, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 			ON CONFLICT (id) DO UPDATE SET
 				type = EXCLUDED.type,
 				quantity = EXCLUDED.quantity,
 				price = EXCLUDED.price,
 				total_amount = EXCLUDED.total_amount,
+				currency = EXCLUDED.currency,
 				transaction_date = EXCLUDED.transaction_date,
 				notes = EXCLUDED.notes
 		`
 
 		result, err := tx.ExecContext(ctx, query,
 			ht.ID, ht.HoldingID, ht.Type, ht.Quantity, ht.Price,
-			ht.TotalAmount, ht.TransactionDate, ht.Notes, ht.CreatedAt,
+			ht.TotalAmount, ht.Currency, ht.TransactionDate, ht.Notes, ht.CreatedAt,
 		)
 		if err != nil {
 			summary.Errors++
@@ -1259,8 +1331,10 @@ func (s *ImportService) importSyncedAccounts(ctx context.Context, tx *sql.Tx, us
 		// sync_credentials should have been imported in previous step
 		var credExists bool
 		checkQuery := `SELECT EXISTS(SELECT 1 FROM sync_credentials WHERE id = $1 AND user_id = $2)`
-		err := tx.QueryRowContext(ctx, checkQuery, sa.CredentialID, userID).Scan(&credExists)
-		if err != nil || !credExists {
+		if err := tx.QueryRowContext(ctx, checkQuery, sa.CredentialID, userID).Scan(&credExists); err != nil {
+			return summary, fmt.Errorf("failed to check sync credential: %w", err)
+		}
+		if !credExists {
 			// Skip this synced_account if credentials don't exist (shouldn't happen in normal flow)
 			summary.Skipped++
 			continue
@@ -1269,8 +1343,10 @@ func (s *ImportService) importSyncedAccounts(ctx context.Context, tx *sql.Tx, us
 		// Verify local account exists
 		var accountExists bool
 		checkAccQuery := `SELECT EXISTS(SELECT 1 FROM accounts WHERE id = $1 AND user_id = $2)`
-		err = tx.QueryRowContext(ctx, checkAccQuery, sa.LocalAccountID, userID).Scan(&accountExists)
-		if err != nil || !accountExists {
+		if err := tx.QueryRowContext(ctx, checkAccQuery, sa.LocalAccountID, userID).Scan(&accountExists); err != nil {
+			return summary, fmt.Errorf("failed to check account: %w", err)
+		}
+		if !accountExists {
 			summary.Skipped++
 			continue
 		}
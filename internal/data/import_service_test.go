@@ -5,9 +5,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
+
+	"money/internal/civil"
 )
 
 // TestValidateArchive_ValidArchive tests validation of a valid archive
@@ -94,6 +97,22 @@ func TestValidateArchive_MissingRequiredFile(t *testing.T) {
 	}
 }
 
+// TestValidateArchive_MissingEquityFile tests that equity tables, which ExportData doesn't write, are optional
+func TestValidateArchive_MissingEquityFile(t *testing.T) {
+	// Create archive without equity_grants.json
+	archive := createArchiveMissingFile(t, "equity_grants.json")
+
+	service := &ImportService{}
+	result, err := service.ValidateArchive(archive)
+	if err != nil {
+		t.Fatalf("ValidateArchive failed: %v", err)
+	}
+
+	if !result.Valid {
+		t.Errorf("Expected archive without equity files to be valid, got errors: %v", result.Errors)
+	}
+}
+
 // TestValidateArchive_InvalidJSON tests validation with invalid JSON
 func TestValidateArchive_InvalidJSON(t *testing.T) {
 	// Create archive with invalid JSON in accounts.json
@@ -192,6 +211,32 @@ func TestImportData_Success(t *testing.T) {
 	}
 }
 
+// TestImportData_Cancelled tests that a cancelled import stops and writes nothing
+func TestImportData_Cancelled(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	service := NewImportService(db)
+	userID := "test-import-cancel-user"
+	archive := CreateValidTestArchive(t, userID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := service.ImportData(ctx, userID, archive, ImportOptions{Mode: "merge"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected a cancellation error, got result %+v and error %v", result, err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM accounts WHERE user_id = $1", userID).Scan(&count); err != nil {
+		t.Fatalf("Failed to query accounts: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected no accounts from a cancelled import, got %d", count)
+	}
+}
+
 // TestImportData_ValidateOnly tests validation without importing
 func TestImportData_ValidateOnly(t *testing.T) {
 	db := SetupTestDB(t)
@@ -319,6 +364,56 @@ func TestImportData_UserIDOverride(t *testing.T) {
 	}
 }
 
+// TestImportData_RemapIDs tests that remapped imports don't collide and keep references intact
+func TestImportData_RemapIDs(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	service := NewImportService(db)
+	userID := "test-remap-user"
+	ctx := context.Background()
+	archive := createValidTestArchive(t)
+	opts := ImportOptions{Mode: "merge", RemapIDs: true}
+
+	// Import the same archive twice
+	var accountIDs []string
+	for i := 0; i < 2; i++ {
+		result, err := service.ImportData(ctx, userID, archive, opts)
+		if err != nil {
+			t.Fatalf("ImportData failed: %v", err)
+		}
+		if !result.Success {
+			t.Fatalf("Expected successful import, got errors: %v", result.Errors)
+		}
+		newID, ok := result.IDMap["accounts"]["test-acc-1"]
+		if !ok || newID == "test-acc-1" {
+			t.Fatalf("Expected test-acc-1 to be remapped, got %v", result.IDMap["accounts"])
+		}
+		accountIDs = append(accountIDs, newID)
+
+		// The balance must follow its account to the new ID
+		var balanceAccountID string
+		err = db.QueryRow("SELECT account_id FROM balances WHERE id = $1", result.IDMap["balances"]["test-bal-1"]).Scan(&balanceAccountID)
+		if err != nil {
+			t.Fatalf("Failed to query remapped balance: %v", err)
+		}
+		if balanceAccountID != newID {
+			t.Errorf("Expected balance to reference account %s, got %s", newID, balanceAccountID)
+		}
+	}
+
+	if accountIDs[0] == accountIDs[1] {
+		t.Error("Expected each import to create its own account")
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM accounts WHERE user_id = $1", userID).Scan(&count); err != nil {
+		t.Fatalf("Failed to query accounts: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 accounts after importing twice, got %d", count)
+	}
+}
+
 // TestImportData_ErrorHandling tests that errors are properly reported
 func TestImportData_ErrorHandling(t *testing.T) {
 	db := SetupTestDB(t)
@@ -650,7 +745,7 @@ func createArchiveWithInvalidReferences(t *testing.T) []byte {
 			ID:        "test-bal-1",
 			AccountID: "non-existent-account",
 			Amount:    1000.00,
-			Date:      time.Now(),
+			Date:      civil.DateOf(time.Now()),
 			CreatedAt: time.Now(),
 		},
 	}
@@ -0,0 +1,276 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"money/internal/background"
+	"money/internal/database"
+)
+
+// maxIntegrityRowIDs caps the example rows listed for each issue
+const maxIntegrityRowIDs = 20
+
+// IntegrityService scans the whole instance for data the application wouldn't have written
+// itself: rows left behind by a deleted parent, negative share counts and stored totals that
+// disagree with their inputs. Legacy databases and imports run with foreign keys off are the
+// usual source. Only fixes that can't lose information are applied automatically.
+type IntegrityService struct {
+	db *sql.DB
+}
+
+// NewIntegrityService creates a new integrity service
+func NewIntegrityService(db *sql.DB) *IntegrityService {
+	return &IntegrityService{db: db}
+}
+
+// integrityCheck finds rows of one table with one problem. where selects the rows; fix, when
+// set, repairs them and is safe to run without review.
+type integrityCheck struct {
+	kind   string
+	table  string
+	detail string
+	where  string
+	repair string
+	fix    string
+}
+
+// Scopes naming a parent table: "<column> IN (SELECT id FROM <parent> WHERE ..." or "<column> = $1"
+var (
+	parentScope = regexp.MustCompile(`^(\w+) IN \(SELECT id FROM (\w+) WHERE`)
+	userScope   = regexp.MustCompile(`^(\w+) = \$1$`)
+)
+
+// orphanChecks finds rows whose parent is gone, derived from how userTables scopes each table
+// to its owner. Parents come before their children, so a repair removing orphaned holdings
+// also removes the transactions of those holdings.
+func orphanChecks() []integrityCheck {
+	var checks []integrityCheck
+	for i := len(userTables) - 1; i >= 0; i-- {
+		table := userTables[i]
+		var column, parent string
+		if strings.Contains(table.scope, " OR ") {
+			// Rows reachable from more than one parent aren't checked
+			continue
+		}
+		if m := parentScope.FindStringSubmatch(table.scope); m != nil {
+			column, parent = m[1], m[2]
+		} else if m := userScope.FindStringSubmatch(table.scope); m != nil && m[1] != "id" {
+			column, parent = m[1], "users"
+		} else {
+			continue
+		}
+		where := fmt.Sprintf("%s IS NOT NULL AND %s NOT IN (SELECT id FROM %s)", column, column, parent)
+		checks = append(checks, integrityCheck{
+			kind:   IntegrityOrphanedRows,
+			table:  table.name,
+			detail: fmt.Sprintf("%s refers to a row missing from %s", column, parent),
+			where:  where,
+			repair: "Delete the rows; nothing can reach them",
+			fix:    "DELETE FROM " + table.name + " WHERE " + where,
+		})
+	}
+	return checks
+}
+
+// valueChecks find negative quantities and stored totals that disagree with their inputs.
+// Totals are compared to the cent.
+var valueChecks = []integrityCheck{
+	{
+		kind: IntegrityNegativeQuantity, table: "holdings",
+		detail: "quantity is negative",
+		where:  "type != 'cash' AND quantity < 0",
+		repair: "Review the holding's transactions; a sell was likely recorded twice or a buy is missing",
+	},
+	{
+		kind: IntegrityNegativeQuantity, table: "equity_grants",
+		detail: "quantity is negative",
+		where:  "quantity < 0",
+		repair: "Correct the grant's share count from the grant agreement",
+	},
+	{
+		kind: IntegrityNegativeQuantity, table: "vesting_events",
+		detail: "quantity is negative",
+		where:  "quantity < 0",
+		repair: "Regenerate the grant's vesting schedule",
+	},
+	{
+		kind: IntegrityNegativeQuantity, table: "equity_exercises",
+		detail: "quantity is negative",
+		where:  "quantity < 0",
+		repair: "Correct or delete the exercise",
+	},
+	{
+		kind: IntegrityNegativeQuantity, table: "equity_sales",
+		detail: "quantity is negative",
+		where:  "quantity < 0",
+		repair: "Correct or delete the sale",
+	},
+	{
+		kind: IntegrityChecksumMismatch, table: "equity_exercises",
+		detail: "exercise_cost isn't quantity × strike_price",
+		where:  "ABS(exercise_cost - quantity * strike_price) >= 0.01",
+		repair: "Recompute exercise_cost from the quantity and strike price",
+		fix:    "UPDATE equity_exercises SET exercise_cost = ROUND(quantity * strike_price, 2) WHERE ABS(exercise_cost - quantity * strike_price) >= 0.01",
+	},
+	{
+		kind: IntegrityChecksumMismatch, table: "equity_exercises",
+		detail: "taxable_benefit isn't quantity × (fmv_at_exercise − strike_price)",
+		where:  "ABS(taxable_benefit - MAX(0, quantity * (fmv_at_exercise - strike_price))) >= 0.01",
+		repair: "Recompute taxable_benefit from the quantity, FMV and strike price",
+		fix: "UPDATE equity_exercises SET taxable_benefit = ROUND(MAX(0, quantity * (fmv_at_exercise - strike_price)), 2) " +
+			"WHERE ABS(taxable_benefit - MAX(0, quantity * (fmv_at_exercise - strike_price))) >= 0.01",
+	},
+	{
+		kind: IntegrityChecksumMismatch, table: "equity_sales",
+		detail: "total_proceeds isn't quantity × sale_price",
+		where:  "ABS(total_proceeds - quantity * sale_price) >= 0.01",
+		repair: "Recompute total_proceeds from the quantity and sale price",
+		fix:    "UPDATE equity_sales SET total_proceeds = ROUND(quantity * sale_price, 2) WHERE ABS(total_proceeds - quantity * sale_price) >= 0.01",
+	},
+	{
+		kind: IntegrityChecksumMismatch, table: "equity_sales",
+		detail: "capital_gain isn't proceeds − cost_basis",
+		where:  "ABS(capital_gain - (quantity * sale_price - cost_basis)) >= 0.01",
+		repair: "Recompute capital_gain from the proceeds and cost basis",
+		fix: "UPDATE equity_sales SET capital_gain = ROUND(quantity * sale_price - cost_basis, 2) " +
+			"WHERE ABS(capital_gain - (quantity * sale_price - cost_basis)) >= 0.01",
+	},
+}
+
+// integrityChecks returns every check in the order repairs are applied
+func integrityChecks() []integrityCheck {
+	return append(orphanChecks(), valueChecks...)
+}
+
+// Check scans the instance and returns the issues found with their repair plan
+func (s *IntegrityService) Check(ctx context.Context) (*IntegrityReport, error) {
+	ctx, cancel := database.WithOperationTimeout(ctx)
+	defer cancel()
+
+	report := &IntegrityReport{CheckedAt: time.Now(), Issues: []IntegrityIssue{}}
+	for _, check := range integrityChecks() {
+		issue, err := s.run(ctx, check)
+		if err != nil {
+			return nil, err
+		}
+		if issue != nil {
+			report.Issues = append(report.Issues, *issue)
+		}
+	}
+	return report, nil
+}
+
+// Repair applies every safe fix in one transaction and returns the issues left afterwards.
+// Issues needing review are reported but left alone.
+func (s *IntegrityService) Repair(ctx context.Context) (*IntegrityReport, error) {
+	ctx, cancel := database.WithOperationTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	fixed := 0
+	for _, check := range integrityChecks() {
+		if check.fix == "" {
+			continue
+		}
+		result, err := tx.ExecContext(ctx, check.fix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to repair %s: %w", check.table, err)
+		}
+		n, _ := result.RowsAffected()
+		if n > 0 {
+			log.Printf("INFO: integrity repair fixed %d rows in %s: %s", n, check.table, check.detail)
+		}
+		fixed += int(n)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit repair: %w", err)
+	}
+
+	report, err := s.Check(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.Fixed = fixed
+	return report, nil
+}
+
+// run finds the rows failing a check, nil when there are none
+func (s *IntegrityService) run(ctx context.Context, check integrityCheck) (*IntegrityIssue, error) {
+	columns, err := tableColumns(ctx, s.db, check.table)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, nil
+	}
+	id := "rowid"
+	if columns["id"] {
+		id = "id"
+	}
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+check.table+" WHERE "+check.where).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to check %s: %w", check.table, err)
+	}
+	if count == 0 {
+		return nil, nil
+	}
+
+	rowIDs, err := database.All(ctx, s.db, database.Query{
+		Name: "integrity_rows",
+		SQL:  fmt.Sprintf("SELECT CAST(%s AS TEXT) FROM %s WHERE %s ORDER BY 1 LIMIT %d", id, check.table, check.where, maxIntegrityRowIDs),
+	}, database.Strings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s rows: %w", check.table, err)
+	}
+
+	return &IntegrityIssue{
+		Kind:   check.kind,
+		Table:  check.table,
+		Detail: check.detail,
+		Count:  count,
+		RowIDs: rowIDs,
+		Repair: check.repair,
+		Safe:   check.fix != "",
+	}, nil
+}
+
+// StartIntegrityChecks checks the instance daily and logs what it finds until jobs drains.
+// Nothing is repaired without an administrator asking.
+func (s *IntegrityService) StartIntegrityChecks(jobs *background.Group) {
+	jobs.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+
+		for {
+			jobs.Exclusive(ctx, "integrity_checks", 24*time.Hour, func(ctx context.Context) {
+				if report, err := s.Check(ctx); err != nil {
+					if ctx.Err() == nil {
+						log.Printf("ERROR: integrity check failed: %v", err)
+					}
+				} else {
+					for _, issue := range report.Issues {
+						log.Printf("WARN: integrity check found %d rows in %s where %s", issue.Count, issue.Table, issue.Detail)
+					}
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
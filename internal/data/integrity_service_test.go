@@ -0,0 +1,84 @@
+package data
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// findIssue returns the issue of a kind in a table that lists the row
+func findIssue(report *IntegrityReport, kind, table, rowID string) *IntegrityIssue {
+	for i, issue := range report.Issues {
+		if issue.Kind == kind && issue.Table == table && slices.Contains(issue.RowIDs, rowID) {
+			return &report.Issues[i]
+		}
+	}
+	return nil
+}
+
+// TestIntegrity_RepairsOnlySafeIssues tests that repair fixes orphans and totals but leaves
+// negative quantities for review
+func TestIntegrity_RepairsOnlySafeIssues(t *testing.T) {
+	db := SetupTestDB(t)
+	ctx := context.Background()
+
+	// Arrange
+	userID := "test-integrity-user"
+	createTestUser(t, db, userID)
+	defer NewDeletionService(db).PurgeUser(ctx, userID)
+	accountID := CreateTestAccount(t, db, userID)
+	now := time.Now()
+	for _, stmt := range []string{
+		`INSERT INTO holdings (id, account_id, type, symbol, quantity) VALUES ('int-holding', $1, 'stock', 'VFV', -5)`,
+		`INSERT INTO holding_transactions (id, holding_id, type, quantity, transaction_date) VALUES ('int-orphan', 'int-missing-holding', 'buy', 1, $2)`,
+		`INSERT INTO equity_grants (id, account_id, grant_type, grant_date, quantity, strike_price, fmv_at_grant, company_name)
+			VALUES ('int-grant', $1, 'iso', $2, 1000, 2, 2, 'Acme')`,
+		`INSERT INTO equity_exercises (id, grant_id, exercise_date, quantity, strike_price, fmv_at_exercise, exercise_cost, taxable_benefit)
+			VALUES ('int-exercise', 'int-grant', $2, 100, 2, 10, 999, 800)`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt, accountID, now); err != nil {
+			t.Fatalf("Failed to set up data: %v", err)
+		}
+	}
+	service := NewIntegrityService(db)
+
+	report, err := service.Check(ctx)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if issue := findIssue(report, IntegrityOrphanedRows, "holding_transactions", "int-orphan"); issue == nil || !issue.Safe {
+		t.Errorf("Expected the orphaned transaction as a safe issue, got %+v", report.Issues)
+	}
+	if issue := findIssue(report, IntegrityNegativeQuantity, "holdings", "int-holding"); issue == nil || issue.Safe {
+		t.Errorf("Expected the negative holding as an issue to review, got %+v", report.Issues)
+	}
+	if issue := findIssue(report, IntegrityChecksumMismatch, "equity_exercises", "int-exercise"); issue == nil {
+		t.Errorf("Expected the exercise cost mismatch, got %+v", report.Issues)
+	}
+
+	// Act
+	repaired, err := service.Repair(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Repair failed: %v", err)
+	}
+	if repaired.Fixed < 2 {
+		t.Errorf("Expected at least the orphan and the exercise cost fixed, got %d", repaired.Fixed)
+	}
+	if findIssue(repaired, IntegrityOrphanedRows, "holding_transactions", "int-orphan") != nil ||
+		findIssue(repaired, IntegrityChecksumMismatch, "equity_exercises", "int-exercise") != nil {
+		t.Errorf("Expected the safe issues to be repaired, got %+v", repaired.Issues)
+	}
+	if findIssue(repaired, IntegrityNegativeQuantity, "holdings", "int-holding") == nil {
+		t.Errorf("Expected the negative holding to be left for review, got %+v", repaired.Issues)
+	}
+	var cost float64
+	if err := db.QueryRowContext(ctx, `SELECT exercise_cost FROM equity_exercises WHERE id = 'int-exercise'`).Scan(&cost); err != nil {
+		t.Fatalf("Failed to read exercise: %v", err)
+	}
+	if cost != 200 {
+		t.Errorf("Expected the exercise cost recomputed to 200, got %v", cost)
+	}
+}
@@ -1,14 +1,19 @@
 package data
 
-import "time"
+import (
+	"time"
+
+	"money/internal/civil"
+)
 
 // ExportManifest represents the metadata for an export archive
 type ExportManifest struct {
-	Version    string                    `json:"version"`
-	AppVersion string                    `json:"app_version"`
-	ExportedAt time.Time                 `json:"exported_at"`
-	UserID     string                    `json:"user_id"`
-	Tables     map[string]TableMetadata  `json:"tables"`
+	Version    string                   `json:"version"`
+	AppVersion string                   `json:"app_version"`
+	ExportedAt time.Time                `json:"exported_at"`
+	UserID     string                   `json:"user_id"`
+	Tables     map[string]TableMetadata `json:"tables"`
+	Anonymized bool                     `json:"anonymized,omitempty"` // Personal details were removed and amounts scaled
 }
 
 // TableMetadata represents metadata for a single table in the export
@@ -17,10 +22,18 @@ type TableMetadata struct {
 	Checksum string `json:"checksum"`
 }
 
+// ExportRequest represents the optional options for an export
+type ExportRequest struct {
+	Passphrase string  `json:"passphrase,omitempty"` // Encrypts the archive when set
+	Anonymize  bool    `json:"anonymize,omitempty"`  // Removes personal details for sharing in a bug report
+	Seed       *uint64 `json:"seed,omitempty"`       // Picks the anonymized scale factor; random when unset
+}
+
 // ImportOptions represents options for importing data
 type ImportOptions struct {
 	Mode         string `json:"mode"` // "merge", "replace", "skip_existing"
 	ValidateOnly bool   `json:"validate_only"`
+	RemapIDs     bool   `json:"remap_ids"` // Give imported records new IDs instead of upserting by the archive's
 }
 
 // ImportResult represents the result of an import operation
@@ -29,6 +42,7 @@ type ImportResult struct {
 	Summary  map[string]ImportTableSummary  `json:"summary"`
 	Errors   []ImportError                  `json:"errors"`
 	Warnings []string                       `json:"warnings"`
+	IDMap    map[string]map[string]string   `json:"id_map,omitempty"` // Table to old to new ID, when IDs were remapped
 }
 
 // ImportTableSummary represents statistics for a single table import
@@ -70,28 +84,28 @@ type Account struct {
 
 // Balance represents a balance record
 type Balance struct {
-	ID        string    `json:"id"`
-	AccountID string    `json:"account_id"`
-	Amount    float64   `json:"amount"`
-	Date      time.Time `json:"date"`
-	Notes     *string   `json:"notes"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        string     `json:"id"`
+	AccountID string     `json:"account_id"`
+	Amount    float64    `json:"amount"`
+	Date      civil.Date `json:"date"`
+	Notes     *string    `json:"notes"`
+	CreatedAt time.Time  `json:"created_at"`
 }
 
 // Holding represents a holding record
 type Holding struct {
-	ID           string     `json:"id"`
-	AccountID    string     `json:"account_id"`
-	Type         string     `json:"type"`
-	Symbol       *string    `json:"symbol"`
-	Quantity     *float64   `json:"quantity"`
-	CostBasis    *float64   `json:"cost_basis"`
-	Currency     *string    `json:"currency"`
-	Amount       *float64   `json:"amount"`
-	PurchaseDate *time.Time `json:"purchase_date"`
-	Notes        *string    `json:"notes"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at"`
+	ID           string      `json:"id"`
+	AccountID    string      `json:"account_id"`
+	Type         string      `json:"type"`
+	Symbol       *string     `json:"symbol"`
+	Quantity     *float64    `json:"quantity"`
+	CostBasis    *float64    `json:"cost_basis"`
+	Currency     *string     `json:"currency"`
+	Amount       *float64    `json:"amount"`
+	PurchaseDate *civil.Date `json:"purchase_date"`
+	Notes        *string     `json:"notes"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
 }
 
 // HoldingTransaction represents a holding transaction record
@@ -102,50 +116,51 @@ type HoldingTransaction struct {
 	Quantity        *float64   `json:"quantity"`
 	Price           *float64   `json:"price"`
 	TotalAmount     *float64   `json:"total_amount"`
-	TransactionDate time.Time  `json:"transaction_date"`
+	Currency        *string    `json:"currency,omitempty"`
+	TransactionDate civil.Date `json:"transaction_date"`
 	Notes           *string    `json:"notes"`
 	CreatedAt       time.Time  `json:"created_at"`
 }
 
 // MortgageDetails represents mortgage details record
 type MortgageDetails struct {
-	ID                 string     `json:"id"`
-	AccountID          string     `json:"account_id"`
-	OriginalAmount     float64    `json:"original_amount"`
-	InterestRate       float64    `json:"interest_rate"`
-	RateType           string     `json:"rate_type"`
-	StartDate          time.Time  `json:"start_date"`
-	TermMonths         int        `json:"term_months"`
-	AmortizationMonths int        `json:"amortization_months"`
-	PaymentAmount      float64    `json:"payment_amount"`
-	PaymentFrequency   string     `json:"payment_frequency"`
-	PaymentDay         *int       `json:"payment_day"`
-	PropertyAddress    *string    `json:"property_address"`
-	PropertyCity       *string    `json:"property_city"`
-	PropertyProvince   *string    `json:"property_province"`
-	PropertyPostalCode *string    `json:"property_postal_code"`
-	PropertyValue      *float64   `json:"property_value"`
-	RenewalDate        *time.Time `json:"renewal_date"`
-	MaturityDate       time.Time  `json:"maturity_date"`
-	Lender             *string    `json:"lender"`
-	MortgageNumber     *string    `json:"mortgage_number"`
-	Notes              *string    `json:"notes"`
-	CreatedAt          time.Time  `json:"created_at"`
-	UpdatedAt          time.Time  `json:"updated_at"`
+	ID                 string      `json:"id"`
+	AccountID          string      `json:"account_id"`
+	OriginalAmount     float64     `json:"original_amount"`
+	InterestRate       float64     `json:"interest_rate"`
+	RateType           string      `json:"rate_type"`
+	StartDate          civil.Date  `json:"start_date"`
+	TermMonths         int         `json:"term_months"`
+	AmortizationMonths int         `json:"amortization_months"`
+	PaymentAmount      float64     `json:"payment_amount"`
+	PaymentFrequency   string      `json:"payment_frequency"`
+	PaymentDay         *int        `json:"payment_day"`
+	PropertyAddress    *string     `json:"property_address"`
+	PropertyCity       *string     `json:"property_city"`
+	PropertyProvince   *string     `json:"property_province"`
+	PropertyPostalCode *string     `json:"property_postal_code"`
+	PropertyValue      *float64    `json:"property_value"`
+	RenewalDate        *civil.Date `json:"renewal_date"`
+	MaturityDate       civil.Date  `json:"maturity_date"`
+	Lender             *string     `json:"lender"`
+	MortgageNumber     *string     `json:"mortgage_number"`
+	Notes              *string     `json:"notes"`
+	CreatedAt          time.Time   `json:"created_at"`
+	UpdatedAt          time.Time   `json:"updated_at"`
 }
 
 // MortgagePayment represents a mortgage payment record
 type MortgagePayment struct {
-	ID              string    `json:"id"`
-	AccountID       string    `json:"account_id"`
-	PaymentDate     time.Time `json:"payment_date"`
-	PaymentAmount   float64   `json:"payment_amount"`
-	PrincipalAmount float64   `json:"principal_amount"`
-	InterestAmount  float64   `json:"interest_amount"`
-	ExtraPayment    *float64  `json:"extra_payment"`
-	BalanceAfter    float64   `json:"balance_after"`
-	Notes           *string   `json:"notes"`
-	CreatedAt       time.Time `json:"created_at"`
+	ID              string     `json:"id"`
+	AccountID       string     `json:"account_id"`
+	PaymentDate     civil.Date `json:"payment_date"`
+	PaymentAmount   float64    `json:"payment_amount"`
+	PrincipalAmount float64    `json:"principal_amount"`
+	InterestAmount  float64    `json:"interest_amount"`
+	ExtraPayment    *float64   `json:"extra_payment"`
+	BalanceAfter    float64    `json:"balance_after"`
+	Notes           *string    `json:"notes"`
+	CreatedAt       time.Time  `json:"created_at"`
 }
 
 // LoanDetails represents loan details record
@@ -155,7 +170,7 @@ type LoanDetails struct {
 	OriginalAmount   float64    `json:"original_amount"`
 	InterestRate     float64    `json:"interest_rate"`
 	RateType         string     `json:"rate_type"`
-	StartDate        time.Time  `json:"start_date"`
+	StartDate        civil.Date `json:"start_date"`
 	TermMonths       int        `json:"term_months"`
 	PaymentAmount    float64    `json:"payment_amount"`
 	PaymentFrequency string     `json:"payment_frequency"`
@@ -164,7 +179,7 @@ type LoanDetails struct {
 	Lender           *string    `json:"lender"`
 	LoanNumber       *string    `json:"loan_number"`
 	Purpose          *string    `json:"purpose"`
-	MaturityDate     time.Time  `json:"maturity_date"`
+	MaturityDate     civil.Date `json:"maturity_date"`
 	Notes            *string    `json:"notes"`
 	CreatedAt        time.Time  `json:"created_at"`
 	UpdatedAt        time.Time  `json:"updated_at"`
@@ -172,44 +187,44 @@ type LoanDetails struct {
 
 // LoanPayment represents a loan payment record
 type LoanPayment struct {
-	ID              string    `json:"id"`
-	AccountID       string    `json:"account_id"`
-	PaymentDate     time.Time `json:"payment_date"`
-	PaymentAmount   float64   `json:"payment_amount"`
-	PrincipalAmount float64   `json:"principal_amount"`
-	InterestAmount  float64   `json:"interest_amount"`
-	ExtraPayment    *float64  `json:"extra_payment"`
-	BalanceAfter    float64   `json:"balance_after"`
-	Notes           *string   `json:"notes"`
-	CreatedAt       time.Time `json:"created_at"`
+	ID              string     `json:"id"`
+	AccountID       string     `json:"account_id"`
+	PaymentDate     civil.Date `json:"payment_date"`
+	PaymentAmount   float64    `json:"payment_amount"`
+	PrincipalAmount float64    `json:"principal_amount"`
+	InterestAmount  float64    `json:"interest_amount"`
+	ExtraPayment    *float64   `json:"extra_payment"`
+	BalanceAfter    float64    `json:"balance_after"`
+	Notes           *string    `json:"notes"`
+	CreatedAt       time.Time  `json:"created_at"`
 }
 
 // AssetDetails represents asset details record
 type AssetDetails struct {
-	ID                 string    `json:"id"`
-	AccountID          string    `json:"account_id"`
-	AssetType          string    `json:"asset_type"`
-	PurchasePrice      float64   `json:"purchase_price"`
-	PurchaseDate       time.Time `json:"purchase_date"`
-	DepreciationMethod string    `json:"depreciation_method"`
-	UsefulLifeYears    *int      `json:"useful_life_years"`
-	SalvageValue       *float64  `json:"salvage_value"`
-	DepreciationRate   *float64  `json:"depreciation_rate"`
-	TypeSpecificData   *string   `json:"type_specific_data"` // JSONB stored as string
-	Notes              *string   `json:"notes"`
-	CreatedAt          time.Time `json:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at"`
+	ID                 string     `json:"id"`
+	AccountID          string     `json:"account_id"`
+	AssetType          string     `json:"asset_type"`
+	PurchasePrice      float64    `json:"purchase_price"`
+	PurchaseDate       civil.Date `json:"purchase_date"`
+	DepreciationMethod string     `json:"depreciation_method"`
+	UsefulLifeYears    *int       `json:"useful_life_years"`
+	SalvageValue       *float64   `json:"salvage_value"`
+	DepreciationRate   *float64   `json:"depreciation_rate"`
+	TypeSpecificData   *string    `json:"type_specific_data"` // JSONB stored as string
+	Notes              *string    `json:"notes"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
 }
 
 // AssetDepreciationEntry represents an asset depreciation entry
 type AssetDepreciationEntry struct {
-	ID                      string    `json:"id"`
-	AccountID               string    `json:"account_id"`
-	EntryDate               time.Time `json:"entry_date"`
-	CurrentValue            float64   `json:"current_value"`
-	AccumulatedDepreciation float64   `json:"accumulated_depreciation"`
-	Notes                   *string   `json:"notes"`
-	CreatedAt               time.Time `json:"created_at"`
+	ID                      string     `json:"id"`
+	AccountID               string     `json:"account_id"`
+	EntryDate               civil.Date `json:"entry_date"`
+	CurrentValue            float64    `json:"current_value"`
+	AccumulatedDepreciation float64    `json:"accumulated_depreciation"`
+	Notes                   *string    `json:"notes"`
+	CreatedAt               time.Time  `json:"created_at"`
 }
 
 // RecurringExpense represents a recurring expense record
@@ -358,3 +373,95 @@ type EquitySale struct {
 	Notes         *string   `json:"notes"`
 	CreatedAt     time.Time `json:"created_at"`
 }
+
+// CompleteExport is a machine-readable copy of everything stored for a user, including
+// tables the ZIP archive doesn't carry. Secret columns such as token hashes and encrypted
+// credentials are left out.
+type CompleteExport struct {
+	Version    string                              `json:"version"`
+	ExportedAt time.Time                           `json:"exported_at"`
+	UserID     string                              `json:"user_id"`
+	Tables     map[string][]map[string]interface{} `json:"tables"`
+}
+
+// DeletionRequest is a scheduled closure of a user's account
+type DeletionRequest struct {
+	RequestedAt time.Time `json:"requested_at"`
+	PurgeAfter  time.Time `json:"purge_after"` // When all of the user's data is deleted
+}
+
+// RequestDeletionRequest confirms a request to delete all of a user's data
+type RequestDeletionRequest struct {
+	ConfirmEmail string `json:"confirm_email"` // Must match the account's email
+}
+
+// DataSnapshot fingerprints every table holding a user's data at a point in time
+type DataSnapshot struct {
+	ID      string                      `json:"id,omitempty"` // Empty for the live data
+	TakenAt time.Time                   `json:"taken_at"`
+	Tables  map[string]TableFingerprint `json:"tables,omitempty"`
+}
+
+// TableFingerprint is the number of a user's rows in a table and a checksum over their
+// contents, which changes whenever any row is added, edited or removed
+type TableFingerprint struct {
+	Rows     int    `json:"rows"`
+	Checksum string `json:"checksum"`
+}
+
+// SnapshotRef identifies one side of a snapshot diff
+type SnapshotRef struct {
+	ID      string    `json:"id,omitempty"` // Empty when diffing against the live data
+	TakenAt time.Time `json:"taken_at"`
+}
+
+// SnapshotDiff is what changed in a user's data between two snapshots
+type SnapshotDiff struct {
+	From            SnapshotRef `json:"from"`
+	To              SnapshotRef `json:"to"`
+	Tables          []TableDiff `json:"tables"` // Only the tables that changed
+	UnchangedTables int         `json:"unchanged_tables"`
+	RecentChanges   []RowChange `json:"recent_changes"` // Newest first
+}
+
+// TableDiff is a table whose rows changed between two snapshots. Edits show up as a changed
+// table with no row delta.
+type TableDiff struct {
+	Table      string `json:"table"`
+	RowsBefore int    `json:"rows_before"`
+	RowsAfter  int    `json:"rows_after"`
+	RowDelta   int    `json:"row_delta"`
+}
+
+// RowChange is a row created or updated between two snapshots
+type RowChange struct {
+	Table  string    `json:"table"`
+	ID     string    `json:"id,omitempty"`
+	Change string    `json:"change"` // created or updated
+	At     time.Time `json:"at"`
+}
+
+// Kinds of problem the integrity checker finds
+const (
+	IntegrityOrphanedRows     = "orphaned_rows"     // Rows whose parent row no longer exists
+	IntegrityNegativeQuantity = "negative_quantity" // Share or unit counts below zero
+	IntegrityChecksumMismatch = "checksum_mismatch" // Stored totals that disagree with the values they're computed from
+)
+
+// IntegrityIssue is one problem found by an integrity check, with the plan to repair it
+type IntegrityIssue struct {
+	Kind   string   `json:"kind"`
+	Table  string   `json:"table"`
+	Detail string   `json:"detail"`
+	Count  int      `json:"count"`
+	RowIDs []string `json:"row_ids"` // Up to maxIntegrityRowIDs of the affected rows
+	Repair string   `json:"repair"`  // What the automatic fix does, or what to review by hand
+	Safe   bool     `json:"safe"`    // Repaired automatically when fixing
+}
+
+// IntegrityReport is the result of checking or repairing the instance's data
+type IntegrityReport struct {
+	CheckedAt time.Time        `json:"checked_at"`
+	Issues    []IntegrityIssue `json:"issues"`
+	Fixed     int              `json:"fixed"` // Rows repaired, for a repair run
+}
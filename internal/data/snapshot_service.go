@@ -0,0 +1,405 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"money/internal/background"
+	"money/internal/database"
+)
+
+// SnapshotRetentionDays is how long daily data snapshots are kept
+const SnapshotRetentionDays = 90
+
+// maxRecentChanges caps the rows a snapshot diff lists as changed
+const maxRecentChanges = 200
+
+// ErrNoSnapshot is returned when no snapshot was taken at or before the requested time
+var ErrNoSnapshot = errors.New("no snapshot taken at or before the requested time")
+
+// SnapshotService records per-table fingerprints of a user's data and diffs them, so a
+// self-hoster can see which tables changed between two points in time without querying
+// the database directly
+type SnapshotService struct {
+	db *sql.DB
+}
+
+// NewSnapshotService creates a new snapshot service
+func NewSnapshotService(db *sql.DB) *SnapshotService {
+	return &SnapshotService{db: db}
+}
+
+// snapshotTables are the user tables a snapshot covers; the snapshots themselves are left out
+func snapshotTables() []userTable {
+	tables := make([]userTable, 0, len(userTables))
+	for _, table := range userTables {
+		if table.name != "data_snapshots" {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// TakeSnapshot fingerprints every table holding the user's data and stores the result
+func (s *SnapshotService) TakeSnapshot(ctx context.Context, userID string) (*DataSnapshot, error) {
+	snapshot, err := s.fingerprint(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.ID = uuid.New().String()
+
+	tables, err := json.Marshal(snapshot.Tables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO data_snapshots (id, user_id, taken_at, tables) VALUES ($1, $2, $3, $4)
+	`, snapshot.ID, userID, snapshot.TakenAt, string(tables))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// fingerprint counts and checksums the user's rows in each table as they are now
+func (s *SnapshotService) fingerprint(ctx context.Context, userID string) (*DataSnapshot, error) {
+	ctx, cancel := database.WithOperationTimeout(ctx)
+	defer cancel()
+
+	snapshot := &DataSnapshot{TakenAt: time.Now(), Tables: make(map[string]TableFingerprint)}
+	for _, table := range snapshotTables() {
+		fingerprint, err := s.fingerprintTable(ctx, table, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fingerprint %s: %w", table.name, err)
+		}
+		snapshot.Tables[table.name] = *fingerprint
+	}
+	return snapshot, nil
+}
+
+// fingerprintTable hashes the user's rows in a table independently of their order. Secret
+// columns are left out like in the complete export.
+func (s *SnapshotService) fingerprintTable(ctx context.Context, table userTable, userID string) (*TableFingerprint, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT * FROM "+table.name+" WHERE "+table.scope, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	omit := make(map[string]bool, len(table.omit))
+	for _, column := range table.omit {
+		omit[column] = true
+	}
+
+	encoded := make([]string, 0)
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		kept := make([]interface{}, 0, len(columns))
+		for i, column := range columns {
+			if omit[column] {
+				continue
+			}
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			kept = append(kept, values[i])
+		}
+		row, err := json.Marshal(kept)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, string(row))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(encoded)
+	hash := sha256.New()
+	for _, row := range encoded {
+		hash.Write([]byte(row))
+		hash.Write([]byte{'\n'})
+	}
+	return &TableFingerprint{Rows: len(encoded), Checksum: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// ListSnapshots returns the user's stored snapshots, newest first, without their tables
+func (s *SnapshotService) ListSnapshots(ctx context.Context, userID string) ([]*DataSnapshot, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, taken_at FROM data_snapshots WHERE user_id = $1 ORDER BY taken_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := make([]*DataSnapshot, 0)
+	for rows.Next() {
+		snapshot := &DataSnapshot{}
+		if err := rows.Scan(&snapshot.ID, &snapshot.TakenAt); err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+// snapshotAsOf returns the latest snapshot taken at or before t
+func (s *SnapshotService) snapshotAsOf(ctx context.Context, userID string, t time.Time) (*DataSnapshot, error) {
+	snapshot := &DataSnapshot{}
+	var tables string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, taken_at, tables FROM data_snapshots
+		WHERE user_id = $1 AND taken_at <= $2
+		ORDER BY taken_at DESC LIMIT 1
+	`, userID, t).Scan(&snapshot.ID, &snapshot.TakenAt, &tables)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoSnapshot
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+	if err := json.Unmarshal([]byte(tables), &snapshot.Tables); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// Diff compares the snapshot in effect at from with the one in effect at to, or with the
+// data as it is now when to is nil, and lists the rows created or updated in between
+func (s *SnapshotService) Diff(ctx context.Context, userID string, from time.Time, to *time.Time) (*SnapshotDiff, error) {
+	before, err := s.snapshotAsOf(ctx, userID, from)
+	if err != nil {
+		return nil, err
+	}
+	var after *DataSnapshot
+	if to != nil {
+		after, err = s.snapshotAsOf(ctx, userID, *to)
+	} else {
+		after, err = s.fingerprint(ctx, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	diff := diffSnapshots(before, after)
+	diff.RecentChanges, err = s.recentChanges(ctx, userID, before.TakenAt, after.TakenAt)
+	if err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// diffSnapshots lists the tables whose rows changed between two snapshots
+func diffSnapshots(before, after *DataSnapshot) *SnapshotDiff {
+	diff := &SnapshotDiff{
+		From:          SnapshotRef{ID: before.ID, TakenAt: before.TakenAt},
+		To:            SnapshotRef{ID: after.ID, TakenAt: after.TakenAt},
+		Tables:        make([]TableDiff, 0),
+		RecentChanges: make([]RowChange, 0),
+	}
+
+	names := make(map[string]bool, len(after.Tables))
+	for name := range before.Tables {
+		names[name] = true
+	}
+	for name := range after.Tables {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		b, a := before.Tables[name], after.Tables[name]
+		if b.Checksum == a.Checksum {
+			diff.UnchangedTables++
+			continue
+		}
+		diff.Tables = append(diff.Tables, TableDiff{
+			Table:      name,
+			RowsBefore: b.Rows,
+			RowsAfter:  a.Rows,
+			RowDelta:   a.Rows - b.Rows,
+		})
+	}
+	return diff
+}
+
+// recentChanges lists the user's rows whose created_at or updated_at falls within the window,
+// newest first. Rows deleted in the window leave no trace and show up only in the row counts.
+func (s *SnapshotService) recentChanges(ctx context.Context, userID string, from, to time.Time) ([]RowChange, error) {
+	changes := make([]RowChange, 0)
+	for _, table := range snapshotTables() {
+		columns, err := tableColumns(ctx, s.db, table.name)
+		if err != nil {
+			return nil, err
+		}
+		if !columns["created_at"] {
+			continue
+		}
+		id := "''"
+		if columns["id"] {
+			id = "CAST(id AS TEXT)"
+		}
+		updated := "created_at"
+		if columns["updated_at"] {
+			updated = "updated_at"
+		}
+
+		rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+			SELECT %s, created_at, %s FROM %s
+			WHERE (%s) AND ((created_at > $2 AND created_at <= $3) OR (%s > $2 AND %s <= $3))
+			ORDER BY %s DESC LIMIT %d
+		`, id, updated, table.name, table.scope, updated, updated, updated, maxRecentChanges), userID, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list changes to %s: %w", table.name, err)
+		}
+		for rows.Next() {
+			var rowID string
+			var createdValue, updatedValue interface{}
+			if err := rows.Scan(&rowID, &createdValue, &updatedValue); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan change to %s: %w", table.name, err)
+			}
+			createdAt, updatedAt := timestampValue(createdValue), timestampValue(updatedValue)
+			change := RowChange{Table: table.name, ID: rowID, Change: "updated", At: updatedAt}
+			if createdAt.After(from) {
+				change.Change = "created"
+				if updatedAt.Before(createdAt) {
+					change.At = createdAt
+				}
+			}
+			changes = append(changes, change)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read changes to %s: %w", table.name, err)
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].At.After(changes[j].At) })
+	if len(changes) > maxRecentChanges {
+		changes = changes[:maxRecentChanges]
+	}
+	return changes, nil
+}
+
+// timestampValue reads a timestamp column, which comes back as a time for DATETIME columns
+// and as text for columns declared otherwise
+func timestampValue(value interface{}) time.Time {
+	switch v := value.(type) {
+	case time.Time:
+		return v
+	case []byte:
+		return timestampValue(string(v))
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// tableColumns returns the names of a table's columns
+func tableColumns(ctx context.Context, db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, `SELECT name FROM pragma_table_info($1)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan column of %s: %w", table, err)
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// SnapshotAll takes a snapshot for every user and drops snapshots past the retention window.
+// It returns how many snapshots were taken.
+func (s *SnapshotService) SnapshotAll(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM users`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan user: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read users: %w", err)
+	}
+
+	for i, userID := range userIDs {
+		if _, err := s.TakeSnapshot(ctx, userID); err != nil {
+			return i, fmt.Errorf("failed to snapshot user %s: %w", userID, err)
+		}
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -SnapshotRetentionDays)
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM data_snapshots WHERE taken_at < $1`, cutoff); err != nil {
+		return len(userIDs), fmt.Errorf("failed to prune snapshots: %w", err)
+	}
+	return len(userIDs), nil
+}
+
+// StartSnapshots snapshots every user's data daily until jobs drains
+func (s *SnapshotService) StartSnapshots(jobs *background.Group) {
+	jobs.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+
+		for {
+			jobs.Exclusive(ctx, "data_snapshots", 24*time.Hour, func(ctx context.Context) {
+				if taken, err := s.SnapshotAll(ctx); err != nil {
+					log.Printf("ERROR: data snapshots failed: %v", err)
+				} else if taken > 0 {
+					log.Printf("INFO: took data snapshots for %d users", taken)
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package data
+
+import (
+	"context"
+	"testing"
+)
+
+// TestSnapshotDiff_ReportsChangedTablesAndRows tests diffing a snapshot against the live data
+func TestSnapshotDiff_ReportsChangedTablesAndRows(t *testing.T) {
+	db := SetupTestDB(t)
+	ctx := context.Background()
+
+	// Arrange
+	userID := "test-snapshot-user"
+	createTestUser(t, db, userID)
+	defer NewDeletionService(db).PurgeUser(ctx, userID)
+	accountID := CreateTestAccount(t, db, userID)
+	service := NewSnapshotService(db)
+
+	snapshot, err := service.TakeSnapshot(ctx, userID)
+	if err != nil {
+		t.Fatalf("TakeSnapshot failed: %v", err)
+	}
+	if snapshot.Tables["accounts"].Rows != 1 {
+		t.Fatalf("Expected the snapshot to count 1 account, got %+v", snapshot.Tables["accounts"])
+	}
+	balanceID := CreateTestBalance(t, db, accountID)
+
+	// Act
+	diff, err := service.Diff(ctx, userID, snapshot.TakenAt, nil)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if len(diff.Tables) != 1 || diff.Tables[0].Table != "balances" || diff.Tables[0].RowDelta != 1 {
+		t.Errorf("Expected only balances to change by one row, got %+v", diff.Tables)
+	}
+	if len(diff.RecentChanges) != 1 || diff.RecentChanges[0].ID != balanceID || diff.RecentChanges[0].Change != "created" {
+		t.Errorf("Expected the new balance as the only recent change, got %+v", diff.RecentChanges)
+	}
+
+	if _, err := service.Diff(ctx, userID, snapshot.TakenAt.AddDate(0, 0, -1), nil); err != ErrNoSnapshot {
+		t.Errorf("Expected ErrNoSnapshot before the first snapshot, got %v", err)
+	}
+}
@@ -18,6 +18,8 @@ import (
 	"github.com/golang-migrate/migrate/v4/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "modernc.org/sqlite"
+
+	"money/internal/civil"
 )
 
 // TestDB holds the SQLite test database connection
@@ -345,7 +347,7 @@ func CreateValidTestArchive(t *testing.T, userID string) []byte {
 			ID:        "test-bal-1",
 			AccountID: "test-acc-1",
 			Amount:    1000.00,
-			Date:      time.Now(),
+			Date:      civil.DateOf(time.Now()),
 			CreatedAt: time.Now(),
 		},
 	}
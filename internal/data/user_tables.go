@@ -0,0 +1,150 @@
+package data
+
+// Scopes selecting a user's rows, with $1 bound to the user ID
+const (
+	scopeUser         = "user_id = $1"
+	scopeAccounts     = "account_id IN (SELECT id FROM accounts WHERE user_id = $1)"
+	scopeHoldings     = "holding_id IN (SELECT id FROM holdings WHERE " + scopeAccounts + ")"
+	scopeEquityGrants = "grant_id IN (SELECT id FROM equity_grants WHERE " + scopeAccounts + ")"
+	scopeCredentials  = "credential_id IN (SELECT id FROM sync_credentials WHERE user_id = $1)"
+	scopeSynced       = "synced_account_id IN (SELECT id FROM synced_accounts WHERE " + scopeCredentials + ")"
+	scopeAdvisors     = "grant_id IN (SELECT id FROM advisor_grants WHERE owner_user_id = $1)"
+)
+
+// userTable is a table holding user data, with the rows belonging to a user selected by scope
+type userTable struct {
+	name  string
+	scope string
+	omit  []string // Secret columns left out of the complete export
+}
+
+// userTables lists every table holding user data, children before the tables they reference
+// so rows can be deleted in order. Exchange rates, CPI readings, market data and global
+// feature flags are shared reference data and not listed. A new table holding user data
+// must be added here or TestUserTables_CoverSchema fails.
+var userTables = []userTable{
+	{name: "advisor_access_log", scope: scopeAdvisors},
+	{name: "advisor_comments", scope: scopeAdvisors},
+	{name: "advisor_grants", scope: "owner_user_id = $1", omit: []string{"invite_token_hash"}},
+	{name: "report_share_accesses", scope: "share_id IN (SELECT id FROM report_shares WHERE user_id = $1)"},
+	{name: "report_shares", scope: scopeUser, omit: []string{"token_hash"}},
+	{name: "report_schedules", scope: scopeUser, omit: []string{"encrypted_secret", "encrypted_passphrase"}},
+	{name: "hooks", scope: scopeUser, omit: []string{"encrypted_secret"}},
+	{name: "api_key_usage", scope: scopeUser},
+	{name: "api_keys", scope: scopeUser, omit: []string{"encrypted_api_key"}},
+	{name: "service_api_keys", scope: scopeUser, omit: []string{"key_hash"}},
+	{name: "sync_outbox", scope: scopeUser},
+	{name: "sync_job_changes", scope: "sync_job_id IN (SELECT id FROM sync_jobs WHERE " + scopeSynced + ")"},
+	{name: "sync_conflicts", scope: scopeSynced},
+	{name: "sync_jobs", scope: scopeSynced},
+	{name: "skipped_provider_accounts", scope: scopeCredentials},
+	{name: "synced_accounts", scope: scopeCredentials + " OR local_account_id IN (SELECT id FROM accounts WHERE user_id = $1)"},
+	{name: "sync_credentials", scope: scopeUser, omit: []string{
+		"encrypted_username", "encrypted_password", "encrypted_access_token", "encrypted_refresh_token", "encrypted_otp_claim",
+	}},
+	{name: "holding_journal_entries", scope: scopeHoldings},
+	{name: "holding_price_alerts", scope: scopeHoldings},
+	{name: "holding_transactions", scope: scopeHoldings},
+	{name: "holdings", scope: scopeAccounts},
+	{name: "cost_basis_lots", scope: scopeAccounts},
+	{name: "in_kind_transfer_legs", scope: "transfer_id IN (SELECT id FROM in_kind_transfers WHERE user_id = $1)"},
+	{name: "in_kind_transfers", scope: scopeUser},
+	{name: "target_allocations", scope: scopeUser},
+	{name: "allocation_settings", scope: scopeUser},
+	{name: "risk_settings", scope: scopeUser},
+	{name: "statement_import_lines", scope: "import_id IN (SELECT id FROM statement_imports WHERE user_id = $1)"},
+	{name: "statement_imports", scope: scopeUser},
+	{name: "project_planned_expenses", scope: "project_id IN (SELECT id FROM projects WHERE user_id = $1)"},
+	{name: "project_transactions", scope: "project_id IN (SELECT id FROM projects WHERE user_id = $1)"},
+	{name: "projects", scope: scopeUser},
+	{name: "iou_entries", scope: "person_id IN (SELECT id FROM iou_people WHERE user_id = $1)"},
+	{name: "iou_people", scope: scopeUser},
+	{name: "transaction_splits", scope: "transaction_id IN (SELECT id FROM transactions WHERE user_id = $1)"},
+	{name: "transactions", scope: scopeUser},
+	{name: "category_budgets", scope: scopeUser},
+	{name: "categories", scope: scopeUser},
+	{name: "entity_transactions", scope: scopeUser},
+	{name: "sweep_runs", scope: "rule_id IN (SELECT id FROM sweep_rules WHERE user_id = $1)"},
+	{name: "sweep_rules", scope: scopeUser},
+	{name: "transfers", scope: scopeUser},
+	{name: "scheduled_transactions", scope: scopeUser},
+	{name: "self_employment_tax_settings", scope: scopeUser},
+	{name: "envelope_movements", scope: scopeAccounts},
+	{name: "envelopes", scope: scopeAccounts},
+	{name: "equity_recalculations", scope: scopeAccounts},
+	{name: "equity_recalc_queue", scope: scopeAccounts},
+	{name: "equity_sales", scope: scopeAccounts},
+	{name: "vesting_events", scope: scopeEquityGrants},
+	{name: "vesting_schedules", scope: scopeEquityGrants},
+	{name: "equity_grant_expirations", scope: scopeEquityGrants},
+	{name: "equity_exercises", scope: scopeEquityGrants},
+	{name: "equity_grants", scope: scopeAccounts},
+	{name: "fmv_history", scope: scopeAccounts},
+	{name: "account_documents", scope: scopeAccounts},
+	{name: "account_edits", scope: scopeUser},
+	{name: "account_estate_details", scope: scopeAccounts},
+	{name: "account_references", scope: scopeAccounts, omit: []string{"encrypted_account_number"}},
+	{name: "anomalies", scope: scopeUser},
+	{name: "asset_depreciation_entries", scope: scopeAccounts},
+	{name: "asset_details", scope: scopeAccounts},
+	{name: "heloc_transactions", scope: scopeAccounts},
+	{name: "heloc_details", scope: scopeAccounts},
+	{name: "loan_payments", scope: scopeAccounts},
+	{name: "loan_refinances", scope: scopeAccounts},
+	{name: "loan_details", scope: scopeAccounts},
+	{name: "mortgage_payments", scope: scopeAccounts},
+	{name: "mortgage_details", scope: scopeAccounts},
+	{name: "student_loan_subsidy_periods", scope: scopeAccounts},
+	{name: "student_loan_details", scope: scopeAccounts},
+	{name: "employer_match_vesting", scope: scopeAccounts},
+	{name: "employer_match_details", scope: scopeAccounts},
+	{name: "payment_auto_posting", scope: scopeAccounts},
+	{name: "account_summaries", scope: scopeUser},
+	{name: "net_worth_events", scope: scopeUser},
+	{name: "net_worth_recompute_queue", scope: scopeUser},
+	{name: "net_worth_snapshots", scope: scopeUser},
+	{name: "reconciliations", scope: scopeAccounts},
+	{name: "balances", scope: scopeAccounts},
+	{name: "accounts", scope: scopeUser},
+	{name: "entities", scope: scopeUser},
+	{name: "tax_installment_payments", scope: "installment_id IN (SELECT id FROM tax_installments WHERE user_id = $1)"},
+	{name: "tax_installments", scope: scopeUser},
+	{name: "tax_configurations", scope: scopeUser},
+	{name: "bonus_payouts", scope: "plan_id IN (SELECT id FROM bonus_plans WHERE user_id = $1)"},
+	{name: "bonus_plans", scope: scopeUser},
+	{name: "paystubs", scope: scopeUser},
+	{name: "income_records", scope: scopeUser},
+	{name: "annual_income_summaries", scope: scopeUser},
+	{name: "credit_scores", scope: scopeUser},
+	{name: "credit_score_settings", scope: scopeUser},
+	{name: "balance_reminder_settings", scope: scopeUser},
+	{name: "projection_scenario_results", scope: scopeUser},
+	{name: "projection_scenarios", scope: scopeUser},
+	{name: "recurring_expenses", scope: scopeUser},
+	{name: "dashboard_layouts", scope: scopeUser},
+	{name: "notifications", scope: scopeUser},
+	{name: "notification_channels", scope: scopeUser},
+	{name: "notification_deliveries", scope: scopeUser},
+	{name: "notification_preferences", scope: scopeUser},
+	{name: "data_snapshots", scope: scopeUser},
+	{name: "user_feature_flags", scope: scopeUser},
+	{name: "account_deletion_requests", scope: scopeUser},
+	{name: "webauthn_credentials", scope: scopeUser, omit: []string{"credential_id", "public_key", "aaguid"}},
+	{name: "sessions", scope: scopeUser, omit: []string{"token_hash"}},
+	{name: "users", scope: "id = $1"},
+}
+
+// sharedTables hold reference data that doesn't belong to any user
+var sharedTables = map[string]bool{
+	"exchange_rates":    true,
+	"cpi_observations":  true,
+	"market_data":       true,
+	"quote_history":     true,
+	"securities":        true,
+	"feature_flags":     true,
+	"runtime_settings":  true,
+	"schema_migrations": true,
+	"backfill_progress": true,
+	"backups":           true,
+	"job_locks":         true,
+}
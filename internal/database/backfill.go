@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"money/internal/background"
+	"money/internal/logger"
+)
+
+// defaultBackfillBatchSize keeps each batch's write transaction short
+const defaultBackfillBatchSize = 500
+
+// Backfill fills a column or table added by an expand migration in small batches, so the
+// server keeps serving while it runs. Progress is saved after every batch and a restarted
+// server resumes where it stopped.
+type Backfill struct {
+	Name      string // Unique; progress is tracked under it
+	BatchSize int    // Rows per batch, defaults to 500
+	// Batch handles up to limit rows after cursor inside tx and returns the cursor to resume
+	// from and how many rows it handled. Handling fewer than limit rows ends the backfill.
+	Batch func(ctx context.Context, tx *sql.Tx, cursor string, limit int) (next string, processed int, err error)
+}
+
+// BackfillProgress is how far a backfill has got
+type BackfillProgress struct {
+	Name        string     `json:"name"`
+	Cursor      string     `json:"cursor"`
+	Processed   int        `json:"processed"`
+	Done        bool       `json:"done"`
+	StartedAt   time.Time  `json:"started_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// StartBackfills runs the backfills one after another in the background. Finished ones
+// are skipped, so entries can stay registered until their contract migration ships.
+func (m *Manager) StartBackfills(jobs *background.Group, backfills []Backfill) {
+	if len(backfills) == 0 {
+		return
+	}
+	jobs.Go(func(ctx context.Context) {
+		for _, b := range backfills {
+			progress, err := RunBackfill(ctx, m.db, b)
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.Error("Backfill failed", "name", b.Name, "error", err)
+				}
+				return
+			}
+			logger.Info("Backfill complete", "name", b.Name, "processed", progress.Processed)
+		}
+	})
+}
+
+// RunBackfill runs a backfill to completion, resuming from its saved cursor. Each batch
+// and its progress commit together, so a batch is never applied twice.
+func RunBackfill(ctx context.Context, db *sql.DB, b Backfill) (*BackfillProgress, error) {
+	if b.Name == "" || b.Batch == nil {
+		return nil, fmt.Errorf("backfill needs a name and a batch function")
+	}
+	batchSize := b.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBackfillBatchSize
+	}
+
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO backfill_progress (name) VALUES ($1) ON CONFLICT (name) DO NOTHING
+	`, b.Name); err != nil {
+		return nil, fmt.Errorf("failed to start backfill: %w", err)
+	}
+
+	for {
+		progress, err := GetBackfill(ctx, db, b.Name)
+		if err != nil {
+			return nil, err
+		}
+		if progress.Done {
+			return progress, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+		if err := runBackfillBatch(ctx, db, b, progress, batchSize); err != nil {
+			return progress, err
+		}
+	}
+}
+
+// runBackfillBatch applies one batch and saves the cursor it reached
+func runBackfillBatch(ctx context.Context, db *sql.DB, b Backfill, progress *BackfillProgress, batchSize int) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin backfill batch: %w", err)
+	}
+	defer tx.Rollback()
+
+	next, processed, err := b.Batch(ctx, tx, progress.Cursor, batchSize)
+	if err != nil {
+		return fmt.Errorf("backfill %s failed after %d rows: %w", b.Name, progress.Processed, err)
+	}
+	done := processed < batchSize
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE backfill_progress
+		SET cursor = $1, processed = processed + $2, done = $3, updated_at = datetime('now'),
+			completed_at = CASE WHEN $3 THEN datetime('now') ELSE NULL END
+		WHERE name = $4
+	`, next, processed, done, b.Name); err != nil {
+		return fmt.Errorf("failed to save backfill progress: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit backfill batch: %w", err)
+	}
+	return nil
+}
+
+// GetBackfill returns a backfill's progress
+func GetBackfill(ctx context.Context, db *sql.DB, name string) (*BackfillProgress, error) {
+	progress := &BackfillProgress{}
+	var completedAt sql.NullTime
+	err := db.QueryRowContext(ctx, `
+		SELECT name, cursor, processed, done, started_at, updated_at, completed_at
+		FROM backfill_progress WHERE name = $1
+	`, name).Scan(&progress.Name, &progress.Cursor, &progress.Processed, &progress.Done,
+		&progress.StartedAt, &progress.UpdatedAt, &completedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("backfill not found: %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backfill progress: %w", err)
+	}
+	if completedAt.Valid {
+		progress.CompletedAt = &completedAt.Time
+	}
+	return progress, nil
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestRunBackfill_ResumesFromSavedCursor(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	migration, err := os.ReadFile("../../migrations/074_backfill_progress.up.sql")
+	if err != nil {
+		t.Fatalf("Failed to read migration: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, string(migration)); err != nil {
+		t.Fatalf("Failed to apply migration: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `CREATE TABLE items (id INTEGER PRIMARY KEY, doubled INTEGER)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	for i := 1; i <= 7; i++ {
+		if _, err := db.ExecContext(ctx, `INSERT INTO items (id) VALUES ($1)`, i); err != nil {
+			t.Fatalf("Failed to insert row: %v", err)
+		}
+	}
+
+	batches := 0
+	failAt := 2
+	b := Backfill{
+		Name:      "items_doubled",
+		BatchSize: 3,
+		Batch: func(ctx context.Context, tx *sql.Tx, cursor string, limit int) (string, int, error) {
+			batches++
+			if batches == failAt {
+				return "", 0, fmt.Errorf("interrupted")
+			}
+			after, _ := strconv.Atoi(cursor)
+			rows, err := tx.QueryContext(ctx, `SELECT id FROM items WHERE id > $1 ORDER BY id LIMIT $2`, after, limit)
+			if err != nil {
+				return "", 0, err
+			}
+			var ids []int
+			for rows.Next() {
+				var id int
+				if err := rows.Scan(&id); err != nil {
+					rows.Close()
+					return "", 0, err
+				}
+				ids = append(ids, id)
+			}
+			rows.Close()
+			for _, id := range ids {
+				if _, err := tx.ExecContext(ctx, `UPDATE items SET doubled = id * 2 WHERE id = $1`, id); err != nil {
+					return "", 0, err
+				}
+				after = id
+			}
+			return strconv.Itoa(after), len(ids), nil
+		},
+	}
+
+	if _, err := RunBackfill(ctx, db, b); err == nil || !strings.Contains(err.Error(), "after 3 rows") {
+		t.Fatalf("Expected the second batch to fail after 3 rows, got %v", err)
+	}
+
+	failAt = 0
+	progress, err := RunBackfill(ctx, db, b)
+	if err != nil {
+		t.Fatalf("RunBackfill failed: %v", err)
+	}
+
+	if !progress.Done || progress.Processed != 7 || progress.CompletedAt == nil {
+		t.Errorf("Expected 7 rows processed and done, got %+v", progress)
+	}
+	var missing int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM items WHERE doubled IS NULL OR doubled != id * 2`).Scan(&missing); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if missing != 0 {
+		t.Errorf("Expected every row backfilled once, %d are not", missing)
+	}
+
+	batches = 0
+	if _, err := RunBackfill(ctx, db, b); err != nil || batches != 0 {
+		t.Errorf("Expected a finished backfill to be skipped, ran %d batches (err %v)", batches, err)
+	}
+}
+
+func TestCheckMigrations_BlocksPendingContract(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	files := map[string]string{
+		"001_init.up.sql":         "-- Initial schema (SQLite)\nCREATE TABLE a (id TEXT);\n",
+		"002_add_b.up.sql":        "-- Add b (SQLite)\nALTER TABLE a ADD COLUMN b TEXT;\n",
+		"003_drop_old.up.sql":     "-- Drop the old column (SQLite)\n-- migrate:contract\nALTER TABLE a DROP COLUMN id;\n",
+		"003_drop_old.down.sql":   "-- migrate:contract\n",
+		"004_not_contract.up.sql": "-- Add c (SQLite)\nALTER TABLE a ADD COLUMN c TEXT;\n-- migrate:contract\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write migration: %v", err)
+		}
+	}
+	if err := checkMigrations(ctx, db, dir, false); err != nil {
+		t.Errorf("Expected a fresh database to apply anything, got %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `CREATE TABLE schema_migrations (version INTEGER, dirty BOOLEAN)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		version       int
+		dirty         bool
+		allowContract bool
+		wantErr       string
+	}{
+		{"contract pending", 1, false, false, "003_drop_old"},
+		{"contract allowed", 1, false, true, ""},
+		{"contract applied", 3, false, false, ""},
+		{"dirty", 3, true, true, "fixed by hand"},
+		{"newer schema", 5, false, true, "newer release"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := db.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+				t.Fatalf("Failed to reset version: %v", err)
+			}
+			if _, err := db.ExecContext(ctx, `INSERT INTO schema_migrations VALUES ($1, $2)`, tt.version, tt.dirty); err != nil {
+				t.Fatalf("Failed to set version: %v", err)
+			}
+
+			err := checkMigrations(ctx, db, dir, tt.allowContract)
+
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if err != nil && strings.Contains(err.Error(), "004_not_contract") {
+				t.Errorf("Expected a marker after the SQL to be ignored, got %v", err)
+			}
+		})
+	}
+}
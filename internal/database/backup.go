@@ -0,0 +1,273 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"money/internal/sync/encryption"
+)
+
+// backupMagic starts every backup file so restore can reject anything else
+const backupMagic = "MONEYYBK1\n"
+
+// backupTimeLayout names backup files so they sort oldest first
+const backupTimeLayout = "20060102-150405"
+
+// BackupInfo describes a backup file
+type BackupInfo struct {
+	Name          string    `json:"name"`
+	Path          string    `json:"path"`
+	Size          int64     `json:"size_bytes"`
+	SchemaVersion uint      `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Retention decides which backups are kept. The newest Keep backups are kept unless older
+// than MaxAge; the most recent backup is always kept.
+type Retention struct {
+	Keep   int
+	MaxAge time.Duration // Zero keeps backups regardless of age
+}
+
+// RestoreResult describes a restored database
+type RestoreResult struct {
+	SchemaVersion uint   `json:"schema_version"`
+	PreviousPath  string `json:"previous_path,omitempty"` // Where the replaced database was moved
+}
+
+// Backup writes an encrypted, consistent snapshot of the database to dir and applies the
+// retention policy. The snapshot is taken with VACUUM INTO, so the server can keep running.
+func (m *Manager) Backup(ctx context.Context, dir string, enc *encryption.Service, retention Retention) (*BackupInfo, []string, error) {
+	return backup(ctx, m.db, dir, enc, retention, time.Now().UTC())
+}
+
+func backup(ctx context.Context, db *sql.DB, dir string, enc *encryption.Service, retention Retention, now time.Time) (*BackupInfo, []string, error) {
+	version, dirty, err := schemaVersion(ctx, db)
+	if err != nil {
+		return nil, nil, err
+	}
+	if dirty {
+		return nil, nil, fmt.Errorf("migration %d failed part way; fix it before backing up", version)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	snapshot := filepath.Join(dir, fmt.Sprintf(".snapshot-%d.db", now.UnixNano()))
+	defer os.Remove(snapshot)
+	if _, err := db.ExecContext(ctx, `VACUUM INTO '`+strings.ReplaceAll(snapshot, "'", "''")+`'`); err != nil {
+		return nil, nil, fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	data, err := os.ReadFile(snapshot)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	sealed, err := enc.Encrypt(string(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt backup: %w", err)
+	}
+
+	info := &BackupInfo{
+		Name:          fmt.Sprintf("moneyy-%s-v%03d.db.enc", now.Format(backupTimeLayout), version),
+		SchemaVersion: version,
+		CreatedAt:     now,
+	}
+	info.Path = filepath.Join(dir, info.Name)
+	tmp := info.Path + ".tmp"
+	if err := os.WriteFile(tmp, append([]byte(backupMagic), sealed...), 0600); err != nil {
+		os.Remove(tmp)
+		return nil, nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := os.Rename(tmp, info.Path); err != nil {
+		os.Remove(tmp)
+		return nil, nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	info.Size = int64(len(backupMagic) + len(sealed))
+
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO backups (name, size_bytes, schema_version, created_at) VALUES ($1, $2, $3, $4)
+	`, info.Name, info.Size, info.SchemaVersion, info.CreatedAt); err != nil {
+		return nil, nil, fmt.Errorf("failed to record backup: %w", err)
+	}
+
+	pruned, err := pruneBackups(ctx, db, dir, retention, now)
+	return info, pruned, err
+}
+
+// ListBackups returns the backups in dir, newest first
+func ListBackups(dir string) ([]BackupInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var backups []BackupInfo
+	for _, entry := range entries {
+		info, ok := parseBackupName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if fi, err := entry.Info(); err == nil {
+			info.Size = fi.Size()
+		}
+		info.Path = filepath.Join(dir, info.Name)
+		backups = append(backups, info)
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+// parseBackupName reads the time and schema version from a backup file name
+func parseBackupName(name string) (BackupInfo, bool) {
+	rest, ok := strings.CutPrefix(name, "moneyy-")
+	if !ok {
+		return BackupInfo{}, false
+	}
+	rest, ok = strings.CutSuffix(rest, ".db.enc")
+	if !ok {
+		return BackupInfo{}, false
+	}
+	stamp, ver, ok := strings.Cut(rest, "-v")
+	if !ok {
+		return BackupInfo{}, false
+	}
+	created, err := time.Parse(backupTimeLayout, stamp)
+	if err != nil {
+		return BackupInfo{}, false
+	}
+	version, err := strconv.ParseUint(ver, 10, 0)
+	if err != nil {
+		return BackupInfo{}, false
+	}
+	return BackupInfo{Name: name, SchemaVersion: uint(version), CreatedAt: created}, true
+}
+
+// pruneBackups removes the backups the retention policy no longer keeps
+func pruneBackups(ctx context.Context, db *sql.DB, dir string, retention Retention, now time.Time) ([]string, error) {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []string
+	for i, b := range backups {
+		if i == 0 {
+			continue
+		}
+		tooMany := retention.Keep > 0 && i >= retention.Keep
+		tooOld := retention.MaxAge > 0 && now.Sub(b.CreatedAt) > retention.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := os.Remove(b.Path); err != nil && !os.IsNotExist(err) {
+			return pruned, fmt.Errorf("failed to remove backup %s: %w", b.Name, err)
+		}
+		if _, err := db.ExecContext(ctx, `UPDATE backups SET pruned_at = $1 WHERE name = $2`, now, b.Name); err != nil {
+			return pruned, fmt.Errorf("failed to record pruned backup: %w", err)
+		}
+		pruned = append(pruned, b.Name)
+	}
+	return pruned, nil
+}
+
+// LastBackup returns when the most recent backup was taken, nil if there has been none
+func LastBackup(ctx context.Context, db *sql.DB) (*time.Time, error) {
+	var last time.Time
+	err := db.QueryRowContext(ctx, `SELECT created_at FROM backups ORDER BY created_at DESC LIMIT 1`).Scan(&last)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last backup: %w", err)
+	}
+	return &last, nil
+}
+
+// RestoreBackup replaces the database at dbPath with a backup. The backup is decrypted and
+// checked before anything is touched: it must pass SQLite's integrity check, have no
+// half-applied migration, and be at a schema version this release's migrations know, so
+// the server can migrate it forward on start. The replaced database is kept alongside.
+// The server must be stopped while restoring.
+func RestoreBackup(ctx context.Context, file, dbPath, migrationsDir string, enc *encryption.Service) (*RestoreResult, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	sealed, ok := strings.CutPrefix(string(data), backupMagic)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a backup file", file)
+	}
+	plain, err := enc.Decrypt([]byte(sealed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt backup; check ENC_MASTER_KEY matches the one it was taken with: %w", err)
+	}
+
+	staged := dbPath + ".restore"
+	if err := os.WriteFile(staged, []byte(plain), 0600); err != nil {
+		return nil, fmt.Errorf("failed to stage backup: %w", err)
+	}
+	version, err := verifyBackup(ctx, staged, migrationsDir)
+	if err != nil {
+		os.Remove(staged)
+		return nil, err
+	}
+
+	result := &RestoreResult{SchemaVersion: version}
+	if _, err := os.Stat(dbPath); err == nil {
+		result.PreviousPath = fmt.Sprintf("%s.pre-restore-%s", dbPath, time.Now().UTC().Format(backupTimeLayout))
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			if err := os.Rename(dbPath+suffix, result.PreviousPath+suffix); err != nil && !os.IsNotExist(err) {
+				os.Remove(staged)
+				return nil, fmt.Errorf("failed to move the current database aside: %w", err)
+			}
+		}
+	}
+	if err := os.Rename(staged, dbPath); err != nil {
+		return nil, fmt.Errorf("failed to put the restored database in place: %w", err)
+	}
+	return result, nil
+}
+
+// verifyBackup checks a decrypted backup can be restored and returns its schema version
+func verifyBackup(ctx context.Context, path, migrationsDir string) (uint, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer db.Close()
+
+	var integrity string
+	if err := db.QueryRowContext(ctx, `PRAGMA integrity_check`).Scan(&integrity); err != nil {
+		return 0, fmt.Errorf("backup is not a readable database: %w", err)
+	}
+	if integrity != "ok" {
+		return 0, fmt.Errorf("backup failed its integrity check: %s", integrity)
+	}
+
+	version, dirty, err := schemaVersion(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("backup was taken with migration %d half applied", version)
+	}
+	migrations, err := readMigrations(migrationsDir)
+	if err != nil {
+		return 0, err
+	}
+	var latest uint
+	for _, migration := range migrations {
+		latest = max(latest, migration.Version)
+	}
+	if version > latest {
+		return 0, fmt.Errorf("backup is at schema version %d but this release only knows up to %d; restore with a newer release", version, latest)
+	}
+	return version, nil
+}
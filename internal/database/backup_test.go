@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"money/internal/sync/encryption"
+)
+
+func newTestEncryption(t *testing.T, key string) *encryption.Service {
+	t.Helper()
+	enc, err := encryption.NewService(base64.StdEncoding.EncodeToString([]byte(strings.Repeat(key, 32))))
+	if err != nil {
+		t.Fatalf("Failed to create encryption service: %v", err)
+	}
+	return enc
+}
+
+// openBackupTestDB returns a database at schema version 77 with the backups table and a note
+func openBackupTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db := openTestDB(t)
+	migration, err := os.ReadFile("../../migrations/077_backups.up.sql")
+	if err != nil {
+		t.Fatalf("Failed to read migration: %v", err)
+	}
+	for _, stmt := range []string{
+		string(migration),
+		`CREATE TABLE schema_migrations (version INTEGER, dirty BOOLEAN)`,
+		`INSERT INTO schema_migrations VALUES (77, false)`,
+		`CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)`,
+		`INSERT INTO notes (body) VALUES ('kept')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up database: %v", err)
+		}
+	}
+	return db
+}
+
+// writeTestMigrations writes a migrations directory whose latest version is latest
+func writeTestMigrations(t *testing.T, latest int) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, version := range []int{1, latest} {
+		name := filepath.Join(dir, fmt.Sprintf("%03d_step.up.sql", version))
+		if err := os.WriteFile(name, []byte("-- Step (SQLite)\n"), 0644); err != nil {
+			t.Fatalf("Failed to write migration: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestBackup_AppliesRetention(t *testing.T) {
+	// Arrange
+	db := openBackupTestDB(t)
+	ctx := context.Background()
+	enc := newTestEncryption(t, "k")
+	dir := t.TempDir()
+	start := time.Date(2026, time.March, 1, 3, 0, 0, 0, time.UTC)
+	retention := Retention{Keep: 3, MaxAge: 10 * 24 * time.Hour}
+
+	// Act: a backup a day for five days, then one a month later
+	var pruned []string
+	for day := 0; day < 5; day++ {
+		_, p, err := backup(ctx, db, dir, enc, retention, start.AddDate(0, 0, day))
+		if err != nil {
+			t.Fatalf("Backup failed: %v", err)
+		}
+		pruned = append(pruned, p...)
+	}
+	if len(pruned) != 2 {
+		t.Errorf("Expected the two oldest of five backups to be pruned, got %v", pruned)
+	}
+	latest, pruned, err := backup(ctx, db, dir, enc, retention, start.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	// Assert: the backups older than the max age go, even within the keep count
+	backups, err := ListBackups(dir)
+	if err != nil {
+		t.Fatalf("Failed to list backups: %v", err)
+	}
+	if len(backups) != 1 || backups[0].Name != latest.Name {
+		t.Errorf("Expected only %s to remain, got %+v", latest.Name, backups)
+	}
+	if len(pruned) != 3 {
+		t.Errorf("Expected three expired backups to be pruned, got %v", pruned)
+	}
+	if latest.SchemaVersion != 77 || !strings.HasSuffix(latest.Name, "-v077.db.enc") {
+		t.Errorf("Expected the backup to record schema version 77, got %+v", latest)
+	}
+	var recorded, marked int
+	if err := db.QueryRow(`SELECT COUNT(*), COUNT(pruned_at) FROM backups`).Scan(&recorded, &marked); err != nil {
+		t.Fatalf("Failed to count backups: %v", err)
+	}
+	if recorded != 6 || marked != 5 {
+		t.Errorf("Expected 6 recorded backups with 5 pruned, got %d and %d", recorded, marked)
+	}
+	last, err := LastBackup(ctx, db)
+	if err != nil {
+		t.Fatalf("Failed to get last backup: %v", err)
+	}
+	if last == nil || !last.Equal(latest.CreatedAt) {
+		t.Errorf("Expected the last backup at %v, got %v", latest.CreatedAt, last)
+	}
+}
+
+func TestRestoreBackup_VerifiesBeforeReplacing(t *testing.T) {
+	db := openBackupTestDB(t)
+	ctx := context.Background()
+	enc := newTestEncryption(t, "k")
+	dir := t.TempDir()
+	info, _, err := backup(ctx, db, dir, enc, Retention{}, time.Now().UTC())
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	data, err := os.ReadFile(info.Path)
+	if err != nil {
+		t.Fatalf("Failed to read backup: %v", err)
+	}
+	tampered := filepath.Join(dir, "tampered.db.enc")
+	data[len(data)-1] ^= 0xff
+	if err := os.WriteFile(tampered, data, 0600); err != nil {
+		t.Fatalf("Failed to write tampered backup: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		file       string
+		enc        *encryption.Service
+		migrations int
+		wantErr    string
+	}{
+		{"restores", info.Path, enc, 80, ""},
+		{"wrong key", info.Path, newTestEncryption(t, "x"), 80, "ENC_MASTER_KEY"},
+		{"tampered", tampered, enc, 80, "decrypt"},
+		{"newer schema", info.Path, enc, 76, "newer release"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			dbPath := filepath.Join(t.TempDir(), "moneyy.db")
+			if err := os.WriteFile(dbPath, []byte("current"), 0600); err != nil {
+				t.Fatalf("Failed to write database: %v", err)
+			}
+
+			// Act
+			result, err := RestoreBackup(ctx, tt.file, dbPath, writeTestMigrations(t, tt.migrations), tt.enc)
+
+			// Assert
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if current, _ := os.ReadFile(dbPath); string(current) != "current" {
+					t.Error("Expected a rejected backup to leave the database untouched")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Restore failed: %v", err)
+			}
+			if previous, _ := os.ReadFile(result.PreviousPath); string(previous) != "current" {
+				t.Errorf("Expected the replaced database to be kept at %s", result.PreviousPath)
+			}
+			restored, err := sql.Open("sqlite", dbPath)
+			if err != nil {
+				t.Fatalf("Failed to open restored database: %v", err)
+			}
+			defer restored.Close()
+			var body string
+			if err := restored.QueryRow(`SELECT body FROM notes`).Scan(&body); err != nil || body != "kept" {
+				t.Errorf("Expected the restored database to hold the backed up note, got %q (err %v)", body, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// contractMarker flags an up migration that removes or renames schema the previous release
+// still uses. Changes are shipped expand first (add, backfill, switch reads), then contract
+// in a later release once no instance runs the old code.
+const contractMarker = "-- migrate:contract"
+
+// migrationFile is an up migration shipped with this release
+type migrationFile struct {
+	Version  uint
+	Name     string
+	Contract bool
+}
+
+// CheckMigrations refuses to let the server start against a schema it can't safely
+// migrate: a dirty database, one migrated by a newer release, or pending contract
+// migrations that haven't been allowed. A fresh database may apply anything.
+func (m *Manager) CheckMigrations(allowContract bool) error {
+	return checkMigrations(context.Background(), m.db, "migrations", allowContract)
+}
+
+func checkMigrations(ctx context.Context, db *sql.DB, dir string, allowContract bool) error {
+	version, dirty, err := schemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("migration %d failed part way and must be fixed by hand before starting", version)
+	}
+
+	migrations, err := readMigrations(dir)
+	if err != nil {
+		return err
+	}
+	var latest uint
+	for _, migration := range migrations {
+		latest = max(latest, migration.Version)
+	}
+	if version > latest {
+		return fmt.Errorf("database is at schema version %d but this release only knows up to %d; run a newer release", version, latest)
+	}
+	if version == 0 || allowContract {
+		return nil
+	}
+
+	var blocked []string
+	for _, migration := range migrations {
+		if migration.Version > version && migration.Contract {
+			blocked = append(blocked, migration.Name)
+		}
+	}
+	if len(blocked) > 0 {
+		return fmt.Errorf("pending contract migrations %s drop schema older instances may still use; "+
+			"set MIGRATE_ALLOW_CONTRACT=true once every instance runs this release", strings.Join(blocked, ", "))
+	}
+	return nil
+}
+
+// schemaVersion returns the applied version, zero for a database never migrated
+func schemaVersion(ctx context.Context, db *sql.DB) (uint, bool, error) {
+	var exists int
+	if err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'
+	`).Scan(&exists); err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if exists == 0 {
+		return 0, false, nil
+	}
+
+	var version uint
+	var dirty bool
+	err := db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// readMigrations lists the up migrations in dir, oldest first
+func readMigrations(dir string) ([]migrationFile, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no migrations found in %s", dir)
+	}
+
+	var migrations []migrationFile
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".up.sql")
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		contract, err := isContract(file)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migrationFile{Version: uint(version), Name: name, Contract: contract})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// isContract reports whether the migration's leading comments carry the contract marker
+func isContract(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read migration: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "--") {
+			return false, nil
+		}
+		if line == contractMarker {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
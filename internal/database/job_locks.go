@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobLocker leases background jobs to one server instance at a time through the
+// job_locks table, so instances sharing a database don't run the same scheduler twice
+type JobLocker struct {
+	db     *sql.DB
+	holder string
+	now    func() time.Time
+}
+
+// NewJobLocker creates a locker holding leases under a name unique to this process
+func NewJobLocker(db *sql.DB) *JobLocker {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return &JobLocker{db: db, holder: host + "-" + uuid.New().String()[:8], now: time.Now}
+}
+
+// Holder returns the name this instance's leases are held under
+func (l *JobLocker) Holder() string {
+	return l.holder
+}
+
+// Acquire takes the lease on name for ttl if it's free or has expired, or extends it if
+// this instance already holds it. The check and the write are one statement, so two
+// instances racing for an expired lease can't both win.
+func (l *JobLocker) Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	now := l.now().UnixMilli()
+	result, err := l.db.ExecContext(ctx, `
+		INSERT INTO job_locks (name, holder, acquired_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name) DO UPDATE SET
+			acquired_at = CASE WHEN job_locks.holder = excluded.holder THEN job_locks.acquired_at ELSE excluded.acquired_at END,
+			holder = excluded.holder,
+			expires_at = excluded.expires_at
+		WHERE job_locks.holder = excluded.holder OR job_locks.expires_at <= $3
+	`, name, l.holder, now, now+ttl.Milliseconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease on %s: %w", name, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease on %s: %w", name, err)
+	}
+	return affected > 0, nil
+}
+
+// Release gives up the lease on name if this instance holds it, so the next run can take it
+// without waiting for it to expire
+func (l *JobLocker) Release(ctx context.Context, name string) error {
+	if _, err := l.db.ExecContext(ctx, `
+		DELETE FROM job_locks WHERE name = $1 AND holder = $2
+	`, name, l.holder); err != nil {
+		return fmt.Errorf("failed to release lease on %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestJobLocker_OneHolderAtATimeUntilTheLeaseExpires(t *testing.T) {
+	// Arrange
+	db := openTestDB(t)
+	ctx := context.Background()
+	migration, err := os.ReadFile("../../migrations/082_job_locks.up.sql")
+	if err != nil {
+		t.Fatalf("Failed to read migration: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, string(migration)); err != nil {
+		t.Fatalf("Failed to apply migration: %v", err)
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	first, second := NewJobLocker(db), NewJobLocker(db)
+	first.now, second.now = clock, clock
+
+	// Act
+	firstTook, err := first.Acquire(ctx, "snapshots", time.Hour)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	secondTook, _ := second.Acquire(ctx, "snapshots", time.Hour)
+	otherJob, _ := second.Acquire(ctx, "integrity", time.Hour)
+	now = now.Add(30 * time.Minute)
+	firstRenewed, _ := first.Acquire(ctx, "snapshots", time.Hour)
+	now = now.Add(59 * time.Minute)
+	secondBeforeExpiry, _ := second.Acquire(ctx, "snapshots", time.Hour)
+	now = now.Add(time.Minute)
+	secondAfterExpiry, _ := second.Acquire(ctx, "snapshots", time.Hour)
+	firstAfterTakeover, _ := first.Acquire(ctx, "snapshots", time.Hour)
+
+	// Assert
+	if !firstTook || secondTook {
+		t.Errorf("Expected only the first instance to take a free lease, got %v and %v", firstTook, secondTook)
+	}
+	if !otherJob {
+		t.Error("Expected leases on different jobs to be independent")
+	}
+	if !firstRenewed || secondBeforeExpiry {
+		t.Errorf("Expected the holder to renew and keep its lease, got renewed %v, taken %v", firstRenewed, secondBeforeExpiry)
+	}
+	if !secondAfterExpiry || firstAfterTakeover {
+		t.Errorf("Expected another instance to take over an expired lease, got %v and %v", secondAfterExpiry, firstAfterTakeover)
+	}
+	var holder string
+	if err := db.QueryRowContext(ctx, `SELECT holder FROM job_locks WHERE name = 'snapshots'`).Scan(&holder); err != nil {
+		t.Fatalf("Failed to read lease: %v", err)
+	}
+	if holder != second.Holder() {
+		t.Errorf("Expected the lease held by %s, got %s", second.Holder(), holder)
+	}
+}
+
+func TestJobLocker_ReleaseFreesOnlyItsOwnLease(t *testing.T) {
+	// Arrange
+	db := openTestDB(t)
+	ctx := context.Background()
+	migration, err := os.ReadFile("../../migrations/082_job_locks.up.sql")
+	if err != nil {
+		t.Fatalf("Failed to read migration: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, string(migration)); err != nil {
+		t.Fatalf("Failed to apply migration: %v", err)
+	}
+	first, second := NewJobLocker(db), NewJobLocker(db)
+	if took, err := first.Acquire(ctx, "sync:conn-1", time.Hour); err != nil || !took {
+		t.Fatalf("Expected the first instance to take the lease, got %v, %v", took, err)
+	}
+
+	// Act
+	if err := second.Release(ctx, "sync:conn-1"); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	heldAfterOtherRelease, _ := second.Acquire(ctx, "sync:conn-1", time.Hour)
+	if err := first.Release(ctx, "sync:conn-1"); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	tookAfterRelease, _ := second.Acquire(ctx, "sync:conn-1", time.Hour)
+
+	// Assert
+	if heldAfterOtherRelease {
+		t.Error("Expected a release by another instance to leave the lease alone")
+	}
+	if !tookAfterRelease {
+		t.Error("Expected the lease to be free as soon as its holder released it")
+	}
+}
@@ -22,10 +22,43 @@ type Manager struct {
 	db *sql.DB
 }
 
+// Options configures the database connection, pool and query timeouts
+type Options struct {
+	Path            string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration // Zero keeps connections indefinitely
+	ConnMaxIdleTime time.Duration // Zero keeps idle connections indefinitely
+	// BusyTimeout is how long SQLite waits on a locked database before failing
+	BusyTimeout time.Duration
+	// StatementTimeout bounds single queries run with WithStatementTimeout
+	StatementTimeout time.Duration
+	// OperationTimeout bounds multi-query operations run with WithOperationTimeout
+	OperationTimeout time.Duration
+}
+
+// OptionsFromEnv reads database options from environment variables
+func OptionsFromEnv() Options {
+	return Options{
+		Path:             env.Get("DB_PATH", "data/moneyy.db"),
+		MaxOpenConns:     env.GetInt("DB_MAX_OPEN_CONNS", 10),
+		MaxIdleConns:     env.GetInt("DB_MAX_IDLE_CONNS", 5),
+		ConnMaxLifetime:  time.Duration(env.GetInt("DB_CONN_MAX_LIFETIME_MINUTES", 0)) * time.Minute,
+		ConnMaxIdleTime:  time.Duration(env.GetInt("DB_CONN_MAX_IDLE_TIME_MINUTES", 0)) * time.Minute,
+		BusyTimeout:      time.Duration(env.GetInt("DB_BUSY_TIMEOUT_MS", 5000)) * time.Millisecond,
+		StatementTimeout: time.Duration(env.GetInt("DB_STATEMENT_TIMEOUT_SECONDS", 30)) * time.Second,
+		OperationTimeout: time.Duration(env.GetInt("DB_OPERATION_TIMEOUT_SECONDS", 120)) * time.Second,
+	}
+}
+
 // NewManager creates a new database manager from environment variables
 func NewManager() (*Manager, error) {
-	// Get database path from environment
-	dbPath := env.Get("DB_PATH", "data/moneyy.db")
+	return NewManagerWithOptions(OptionsFromEnv())
+}
+
+// NewManagerWithOptions creates a new database manager with the given options
+func NewManagerWithOptions(opts Options) (*Manager, error) {
+	dbPath := opts.Path
 
 	// Ensure the directory exists
 	dir := filepath.Dir(dbPath)
@@ -36,7 +69,8 @@ func NewManager() (*Manager, error) {
 	}
 
 	// Build SQLite connection string with pragmas for better performance
-	dsn := fmt.Sprintf("%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(ON)", dbPath)
+	dsn := fmt.Sprintf("%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(%d)&_pragma=foreign_keys(ON)",
+		dbPath, opts.BusyTimeout.Milliseconds())
 
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
@@ -44,15 +78,15 @@ func NewManager() (*Manager, error) {
 	}
 
 	// Configure connection pool (SQLite with WAL can handle concurrent readers)
-	maxOpenConns := env.GetInt("DB_MAX_OPEN_CONNS", 10)
-	maxIdleConns := env.GetInt("DB_MAX_IDLE_CONNS", 5)
-	connMaxLifetime := time.Duration(env.GetInt("DB_CONN_MAX_LIFETIME_MINUTES", 0)) * time.Minute
-
-	db.SetMaxOpenConns(maxOpenConns)
-	db.SetMaxIdleConns(maxIdleConns)
-	if connMaxLifetime > 0 {
-		db.SetConnMaxLifetime(connMaxLifetime)
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxIdleConns)
+	if opts.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
+	if opts.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
 	}
+	SetTimeouts(opts.StatementTimeout, opts.OperationTimeout)
 
 	// Verify connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -64,7 +98,9 @@ func NewManager() (*Manager, error) {
 	}
 
 	m := &Manager{db: db}
-	logger.Info("Connected to database", "path", dbPath)
+	logger.Info("Connected to database", "path", dbPath,
+		"max_open_conns", opts.MaxOpenConns, "max_idle_conns", opts.MaxIdleConns,
+		"statement_timeout", opts.StatementTimeout, "operation_timeout", opts.OperationTimeout)
 
 	return m, nil
 }
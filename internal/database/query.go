@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Querier is satisfied by *sql.DB, *sql.Tx and *sql.Conn
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Scanner is a single result row, satisfied by *sql.Row and *sql.Rows
+type Scanner interface {
+	Scan(dest ...any) error
+}
+
+// Query is a named SQL statement. Modules declare their queries as package-level values
+// next to the scan function for their result type, so errors name the failing query. A
+// module's reads go through a repository type holding a Querier, with one typed method per
+// query built on All and One, so services never scan rows by hand.
+type Query struct {
+	Name string
+	SQL  string
+}
+
+// ScanFunc reads one row into a value
+type ScanFunc[T any] func(row Scanner) (T, error)
+
+// All runs a query and scans every row. A row that fails to scan fails the whole query
+// rather than being skipped, so corrupt data surfaces instead of silently disappearing.
+func All[T any](ctx context.Context, db Querier, q Query, scan ScanFunc[T], args ...any) ([]T, error) {
+	rows, err := db.QueryContext(ctx, q.SQL, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: query failed: %w", q.Name, err)
+	}
+	defer rows.Close()
+
+	results := make([]T, 0)
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row %d: %w", q.Name, len(results)+1, err)
+		}
+		results = append(results, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read rows: %w", q.Name, err)
+	}
+	return results, nil
+}
+
+// One runs a query expected to return a single row. sql.ErrNoRows is returned unwrapped
+// so callers can map it to a not found error.
+func One[T any](ctx context.Context, db Querier, q Query, scan ScanFunc[T], args ...any) (T, error) {
+	item, err := scan(db.QueryRowContext(ctx, q.SQL, args...))
+	if err == sql.ErrNoRows {
+		return item, err
+	}
+	if err != nil {
+		return item, fmt.Errorf("%s: failed to scan row: %w", q.Name, err)
+	}
+	return item, nil
+}
+
+// Strings scans rows holding a single text column
+func Strings(row Scanner) (string, error) {
+	var s string
+	err := row.Scan(&s)
+	return s, err
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestAll_SurfacesScanErrors(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, `CREATE TABLE amounts (amount)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO amounts VALUES (1.5), (2.5)`); err != nil {
+		t.Fatalf("Failed to insert rows: %v", err)
+	}
+
+	q := Query{Name: "amounts", SQL: `SELECT amount FROM amounts ORDER BY rowid`}
+	scanAmount := func(row Scanner) (float64, error) {
+		var amount float64
+		err := row.Scan(&amount)
+		return amount, err
+	}
+
+	amounts, err := All(ctx, db, q, scanAmount)
+	if err != nil {
+		t.Fatalf("All failed: %v", err)
+	}
+	if len(amounts) != 2 || amounts[1] != 2.5 {
+		t.Errorf("Expected [1.5 2.5], got %v", amounts)
+	}
+
+	// A corrupt row fails the query instead of being skipped
+	if _, err := db.ExecContext(ctx, `INSERT INTO amounts VALUES ('not a number')`); err != nil {
+		t.Fatalf("Failed to insert row: %v", err)
+	}
+	_, err = All(ctx, db, q, scanAmount)
+	if err == nil || !strings.Contains(err.Error(), "amounts: failed to scan row 3") {
+		t.Errorf("Expected a scan error naming the query and row, got %v", err)
+	}
+
+	if _, err := One(ctx, db, Query{Name: "none", SQL: `SELECT amount FROM amounts WHERE 0`}, scanAmount); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows, got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Timeouts applied by WithStatementTimeout and WithOperationTimeout, set from Options when
+// the manager connects. Zero disables the deadline.
+var (
+	statementTimeout atomic.Int64
+	operationTimeout atomic.Int64
+)
+
+// SetTimeouts sets the deadlines for single statements and for long-running operations
+func SetTimeouts(statement, operation time.Duration) {
+	statementTimeout.Store(int64(statement))
+	operationTimeout.Store(int64(operation))
+}
+
+// WithStatementTimeout bounds a single query so a slow statement releases its connection
+func WithStatementTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, time.Duration(statementTimeout.Load()))
+}
+
+// WithOperationTimeout bounds a multi-query operation such as an import, export or
+// projection, so it can't hold pool connections indefinitely
+func WithOperationTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, time.Duration(operationTimeout.Load()))
+}
+
+// withTimeout applies the timeout unless it's disabled or the context already ends sooner
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
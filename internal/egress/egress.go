@@ -0,0 +1,108 @@
+// Package egress makes outbound HTTP requests to addresses users supply, such as hook
+// endpoints and report destinations. Requests may only reach public internet addresses:
+// the check runs when each connection is dialled, after DNS resolution, so a hostname that
+// resolves to a loopback, private or link-local address (including cloud metadata
+// endpoints) is refused, and redirects are never followed.
+package egress
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned when a request would reach a non-public address
+var ErrBlockedAddress = errors.New("destination address is not public")
+
+// ErrRedirect is returned instead of following a redirect
+var ErrRedirect = errors.New("redirects are not followed")
+
+// blockedNetworks are special-purpose ranges not covered by the net.IP predicates
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // This network
+	"100.64.0.0/10", // Carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // Benchmarking
+	"240.0.0.0/4",   // Reserved
+	"64:ff9b::/96",  // NAT64, which can reach IPv4 ranges above
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// IsPublic reports whether an address is a public unicast address
+func IsPublic(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// control refuses connections to non-public addresses once the address is resolved
+func control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	if !IsPublic(net.ParseIP(host)) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+	return nil
+}
+
+// NewClient returns an HTTP client that only connects to public addresses and refuses
+// redirects. Environment proxies are ignored, since they would be dialled instead of the
+// destination.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: control}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return ErrRedirect
+		},
+	}
+}
+
+// CheckURL requires an absolute https URL. Its host is checked again when the request is
+// made, since DNS may answer differently by then.
+func CheckURL(value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme != "https" || parsed.Hostname() == "" {
+		return fmt.Errorf("url must be an https URL")
+	}
+	if parsed.User != nil {
+		return fmt.Errorf("url must not contain credentials")
+	}
+	if ip := net.ParseIP(parsed.Hostname()); ip != nil && !IsPublic(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, ip)
+	}
+	if parsed.Hostname() == "localhost" {
+		return fmt.Errorf("%w: localhost", ErrBlockedAddress)
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+// Package flags implements feature flags for rolling out experimental modules.
+package flags
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/logger"
+)
+
+// Known flag keys
+const (
+	FlagMonteCarlo = "monte_carlo"
+	FlagGraphQL    = "graphql"
+)
+
+// Definition describes a feature flag and its behaviour when the instance hasn't configured it
+type Definition struct {
+	Key            string `json:"key"`
+	Description    string `json:"description"`
+	Default        bool   `json:"default"`
+	UserToggleable bool   `json:"user_toggleable"` // Users may opt in or out themselves
+}
+
+// Definitions lists every supported flag
+var Definitions = []Definition{
+	{Key: FlagMonteCarlo, Description: "Monte Carlo simulations in projections", UserToggleable: true},
+	{Key: FlagGraphQL, Description: "GraphQL API endpoint"},
+}
+
+// Source explains which setting decided a flag's value
+type Source string
+
+const (
+	SourceDefault  Source = "default"
+	SourceInstance Source = "instance"
+	SourceUser     Source = "user"
+)
+
+// Flag is a feature flag evaluated for the current user
+type Flag struct {
+	Definition
+	Enabled        bool   `json:"enabled"`
+	Source         Source `json:"source"`
+	RolloutPercent *int   `json:"rollout_percent,omitempty"` // Set when the instance configures the flag
+}
+
+// ListFlagsResponse represents the response for listing flags
+type ListFlagsResponse struct {
+	Flags []*Flag `json:"flags"`
+}
+
+// SetUserFlagRequest opts the user in or out of a flag
+type SetUserFlagRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// instanceSetting is an instance-wide flag setting
+type instanceSetting struct {
+	enabled        bool
+	rolloutPercent int
+}
+
+// Service provides feature flag functionality
+type Service struct {
+	db *sql.DB
+}
+
+// NewService creates a new feature flag service
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// definition looks up a flag definition by key
+func definition(key string) (Definition, bool) {
+	for _, d := range Definitions {
+		if d.Key == key {
+			return d, true
+		}
+	}
+	return Definition{}, false
+}
+
+// ApplyInstanceFlags stores instance-wide settings from a spec such as
+// "monte_carlo=on,graphql=25" where each value is on, off or a rollout percentage.
+// Flags not named in the spec keep their stored settings.
+func (s *Service) ApplyInstanceFlags(ctx context.Context, spec string) error {
+	settings, err := parseInstanceFlags(spec)
+	if err != nil {
+		return err
+	}
+
+	for key, setting := range settings {
+		_, err := s.db.ExecContext(ctx, `
+			INSERT INTO feature_flags (key, enabled, rollout_percent, updated_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (key) DO UPDATE SET
+				enabled = excluded.enabled,
+				rollout_percent = excluded.rollout_percent,
+				updated_at = excluded.updated_at
+		`, key, setting.enabled, setting.rolloutPercent, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to save feature flag %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// ValidateInstanceFlags checks a spec accepted by ApplyInstanceFlags without storing it
+func ValidateInstanceFlags(spec string) error {
+	_, err := parseInstanceFlags(spec)
+	return err
+}
+
+// parseInstanceFlags reads an instance flag spec, rejecting unknown flags and bad values
+func parseInstanceFlags(spec string) (map[string]instanceSetting, error) {
+	settings := make(map[string]instanceSetting)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(entry, "=")
+		key, value = strings.TrimSpace(key), strings.ToLower(strings.TrimSpace(value))
+		if !ok {
+			return nil, fmt.Errorf("invalid feature flag setting: %s", entry)
+		}
+		if _, known := definition(key); !known {
+			return nil, fmt.Errorf("unknown feature flag: %s", key)
+		}
+
+		setting := instanceSetting{enabled: true, rolloutPercent: 100}
+		switch value {
+		case "on", "true":
+		case "off", "false":
+			setting.enabled = false
+		default:
+			percent, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+			if err != nil || percent < 0 || percent > 100 {
+				return nil, fmt.Errorf("invalid value for feature flag %s: %s", key, value)
+			}
+			setting.rolloutPercent = percent
+		}
+		settings[key] = setting
+	}
+	return settings, nil
+}
+
+// List evaluates every flag for the authenticated user
+func (s *Service) List(ctx context.Context) (*ListFlagsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	instance, err := s.instanceSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.userOverrides(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := make([]*Flag, 0, len(Definitions))
+	for _, d := range Definitions {
+		flags = append(flags, evaluate(d, userID, instance, overrides))
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+
+	return &ListFlagsResponse{Flags: flags}, nil
+}
+
+// IsEnabled reports whether a flag is on for the authenticated user. Unknown flags and
+// lookup failures count as off.
+func (s *Service) IsEnabled(ctx context.Context, key string) bool {
+	d, ok := definition(key)
+	if !ok {
+		return false
+	}
+	userID := auth.GetUserID(ctx)
+
+	instance, err := s.instanceSettings(ctx)
+	if err != nil {
+		logger.Error("Failed to load feature flags", "error", err)
+		return false
+	}
+	overrides := map[string]bool{}
+	if userID != "" {
+		if overrides, err = s.userOverrides(ctx, userID); err != nil {
+			logger.Error("Failed to load user feature flags", "error", err)
+			return false
+		}
+	}
+
+	return evaluate(d, userID, instance, overrides).Enabled
+}
+
+// SetUserFlag opts the authenticated user in or out of a user-toggleable flag
+func (s *Service) SetUserFlag(ctx context.Context, key string, req *SetUserFlagRequest) (*Flag, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	d, ok := definition(key)
+	if !ok {
+		return nil, fmt.Errorf("unknown feature flag: %s", key)
+	}
+	if !d.UserToggleable {
+		return nil, fmt.Errorf("feature flag %s is managed by the instance", key)
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_feature_flags (user_id, flag_key, enabled, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, flag_key) DO UPDATE SET
+			enabled = excluded.enabled,
+			updated_at = excluded.updated_at
+	`, userID, key, req.Enabled, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to save feature flag: %w", err)
+	}
+
+	return &Flag{Definition: d, Enabled: req.Enabled, Source: SourceUser}, nil
+}
+
+// ClearUserFlag removes the authenticated user's override so the instance setting applies
+func (s *Service) ClearUserFlag(ctx context.Context, key string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM user_feature_flags WHERE user_id = $1 AND flag_key = $2
+	`, userID, key)
+	if err != nil {
+		return fmt.Errorf("failed to clear feature flag: %w", err)
+	}
+	return nil
+}
+
+// RequireFlag responds 404 to requests from users who don't have the flag enabled, so
+// experimental routes stay hidden until they're rolled out
+func (s *Service) RequireFlag(key string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !s.IsEnabled(r.Context(), key) {
+				http.NotFound(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// instanceSettings loads the instance-wide flag settings
+func (s *Service) instanceSettings(ctx context.Context) (map[string]instanceSetting, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT key, enabled, rollout_percent FROM feature_flags`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feature flags: %w", err)
+	}
+	defer rows.Close()
+
+	settings := make(map[string]instanceSetting)
+	for rows.Next() {
+		var key string
+		var setting instanceSetting
+		if err := rows.Scan(&key, &setting.enabled, &setting.rolloutPercent); err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag: %w", err)
+		}
+		settings[key] = setting
+	}
+	return settings, rows.Err()
+}
+
+// userOverrides loads a user's flag overrides
+func (s *Service) userOverrides(ctx context.Context, userID string) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT flag_key, enabled FROM user_feature_flags WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user feature flags: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := make(map[string]bool)
+	for rows.Next() {
+		var key string
+		var enabled bool
+		if err := rows.Scan(&key, &enabled); err != nil {
+			return nil, fmt.Errorf("failed to scan user feature flag: %w", err)
+		}
+		overrides[key] = enabled
+	}
+	return overrides, rows.Err()
+}
+
+// evaluate decides a flag for a user: a user override wins for toggleable flags, then the
+// instance setting with its rollout percentage, then the flag's default
+func evaluate(d Definition, userID string, instance map[string]instanceSetting, overrides map[string]bool) *Flag {
+	flag := &Flag{Definition: d, Enabled: d.Default, Source: SourceDefault}
+
+	setting, configured := instance[d.Key]
+	if configured {
+		percent := setting.rolloutPercent
+		flag.RolloutPercent = &percent
+		flag.Enabled = setting.enabled && rolloutBucket(d.Key, userID) < percent
+		flag.Source = SourceInstance
+	}
+
+	if enabled, ok := overrides[d.Key]; ok && d.UserToggleable {
+		flag.Enabled = enabled
+		flag.Source = SourceUser
+	}
+	return flag
+}
+
+// rolloutBucket places a user in a stable 0-99 bucket per flag, so raising a rollout
+// percentage only ever adds users
+func rolloutBucket(key, userID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key + ":" + userID))
+	return int(h.Sum32() % 100)
+}
@@ -0,0 +1,173 @@
+package flags
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "modernc.org/sqlite"
+
+	"money/internal/auth"
+)
+
+var (
+	sharedDB     *sql.DB
+	sharedDBOnce sync.Once
+	sharedDBErr  error
+)
+
+func getSharedDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	sharedDBOnce.Do(func() {
+		tempDir, err := os.MkdirTemp("", "moneyy-flags-test-*")
+		if err != nil {
+			sharedDBErr = fmt.Errorf("failed to create temp dir: %w", err)
+			return
+		}
+
+		dbPath := filepath.Join(tempDir, "test.db")
+		dsn := fmt.Sprintf("%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(ON)", dbPath)
+
+		db, err := sql.Open("sqlite", dsn)
+		if err != nil {
+			sharedDBErr = fmt.Errorf("failed to open database: %w", err)
+			return
+		}
+
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+
+		if err := db.Ping(); err != nil {
+			sharedDBErr = fmt.Errorf("failed to ping database: %w", err)
+			return
+		}
+
+		if err := runMigrations(db); err != nil {
+			db.Close()
+			sharedDBErr = fmt.Errorf("failed to run migrations: %w", err)
+			return
+		}
+
+		sharedDB = db
+	})
+
+	if sharedDBErr != nil {
+		t.Fatalf("Failed to setup shared database: %v", sharedDBErr)
+	}
+
+	return sharedDB
+}
+
+func runMigrations(db *sql.DB) error {
+	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+	if err != nil {
+		return err
+	}
+
+	migrationsPath, err := findMigrationsDir()
+	if err != nil {
+		return err
+	}
+
+	m, err := migrate.NewWithDatabaseInstance(
+		fmt.Sprintf("file://%s", migrationsPath),
+		"sqlite3", driver)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return err
+	}
+
+	return nil
+}
+
+func findMigrationsDir() (string, error) {
+	currentDir, err := filepath.Abs(".")
+	if err != nil {
+		return "", err
+	}
+
+	for i := 0; i < 10; i++ {
+		migrationsPath := filepath.Join(currentDir, "migrations")
+		files, err := filepath.Glob(filepath.Join(migrationsPath, "*.sql"))
+		if err == nil && len(files) > 0 {
+			return migrationsPath, nil
+		}
+
+		if _, err := os.Stat(filepath.Join(currentDir, "go.mod")); err == nil {
+			migrationsPath := filepath.Join(currentDir, "migrations")
+			files, _ := filepath.Glob(filepath.Join(migrationsPath, "*.sql"))
+			if len(files) > 0 {
+				return migrationsPath, nil
+			}
+		}
+
+		currentDir = filepath.Join(currentDir, "..")
+	}
+	return "", fmt.Errorf("migrations directory not found")
+}
+
+func setupTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	return getSharedDB(t)
+}
+
+func cleanupTestDB(t *testing.T, db *sql.DB) {
+	t.Helper()
+	_, _ = db.Exec("DELETE FROM user_feature_flags WHERE user_id LIKE 'test-%'")
+	_, _ = db.Exec("DELETE FROM feature_flags")
+}
+
+func TestIsEnabled_InstanceRolloutAndUserOverride(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	service := NewService(db)
+	ctx := auth.WithUserID(context.Background(), "test-user-flags-1")
+	if service.IsEnabled(ctx, FlagMonteCarlo) {
+		t.Fatal("Expected monte_carlo to be off by default")
+	}
+
+	// Act
+	if err := service.ApplyInstanceFlags(context.Background(), "monte_carlo=100, graphql=off"); err != nil {
+		t.Fatalf("ApplyInstanceFlags failed: %v", err)
+	}
+	enabledByRollout := service.IsEnabled(ctx, FlagMonteCarlo)
+	if _, err := service.SetUserFlag(ctx, FlagMonteCarlo, &SetUserFlagRequest{Enabled: false}); err != nil {
+		t.Fatalf("SetUserFlag failed: %v", err)
+	}
+	list, err := service.List(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if !enabledByRollout {
+		t.Error("Expected a 100% rollout to enable monte_carlo")
+	}
+	if service.IsEnabled(ctx, FlagMonteCarlo) {
+		t.Error("Expected the user's opt-out to disable monte_carlo")
+	}
+	for _, flag := range list.Flags {
+		if flag.Key == FlagMonteCarlo && flag.Source != SourceUser {
+			t.Errorf("Expected monte_carlo to come from the user override, got %s", flag.Source)
+		}
+	}
+	if _, err := service.SetUserFlag(ctx, FlagGraphQL, &SetUserFlagRequest{Enabled: true}); err == nil {
+		t.Error("Expected instance-managed graphql flag to reject user overrides")
+	}
+	if err := service.ApplyInstanceFlags(context.Background(), "unknown=on"); err == nil {
+		t.Error("Expected an unknown flag to be rejected")
+	}
+}
@@ -0,0 +1,486 @@
+package holdings
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+)
+
+// AlertCondition is the price condition a holding alert watches for
+type AlertCondition string
+
+const (
+	AlertConditionPriceAbove AlertCondition = "price_above"
+	AlertConditionPriceBelow AlertCondition = "price_below"
+	AlertConditionDailyMove  AlertCondition = "daily_move" // Absolute move from the previous close, in percent
+)
+
+// PriceAlert is a rule that raises a notification when a holding's quote crosses a threshold
+type PriceAlert struct {
+	ID              string         `json:"id"`
+	HoldingID       string         `json:"holding_id"`
+	Condition       AlertCondition `json:"condition"`
+	Threshold       float64        `json:"threshold"`
+	IsActive        bool           `json:"is_active"`
+	Notes           *string        `json:"notes,omitempty"`
+	LastTriggeredAt *time.Time     `json:"last_triggered_at,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+// CreatePriceAlertRequest represents the request to create a price alert
+type CreatePriceAlertRequest struct {
+	Condition AlertCondition `json:"condition"`
+	Threshold float64        `json:"threshold"`
+	Notes     string         `json:"notes,omitempty"`
+}
+
+// UpdatePriceAlertRequest represents the request to update a price alert
+type UpdatePriceAlertRequest struct {
+	Threshold *float64 `json:"threshold,omitempty"`
+	IsActive  *bool    `json:"is_active,omitempty"`
+	Notes     *string  `json:"notes,omitempty"`
+}
+
+// ListPriceAlertsResponse represents the response for listing a holding's price alerts
+type ListPriceAlertsResponse struct {
+	Alerts []*PriceAlert `json:"alerts"`
+}
+
+// Quote is the latest known price for a symbol, stored in market_data
+type Quote struct {
+	Symbol        string    `json:"symbol"`
+	Price         float64   `json:"price"`
+	Currency      Currency  `json:"currency"`
+	PreviousClose *float64  `json:"previous_close,omitempty"`
+	Source        *string   `json:"source,omitempty"`
+	QuotedAt      time.Time `json:"quoted_at"`
+}
+
+// QuotePoint is a symbol's closing price on a day, stored in quote_history
+type QuotePoint struct {
+	Date  time.Time `json:"date"`
+	Price float64   `json:"price"`
+}
+
+// RecordQuoteRequest represents a price update from a quote refresh
+type RecordQuoteRequest struct {
+	Price         float64    `json:"price"`
+	Currency      Currency   `json:"currency"`
+	PreviousClose *float64   `json:"previous_close,omitempty"`
+	Source        string     `json:"source,omitempty"`
+	QuotedAt      *time.Time `json:"quoted_at,omitempty"` // Defaults to now
+
+	// Optional security metadata the price provider reports alongside the quote
+	Name       *string     `json:"name,omitempty"`
+	Exchange   *string     `json:"exchange,omitempty"`
+	AssetClass *AssetClass `json:"asset_class,omitempty"`
+	Sector     *string     `json:"sector,omitempty"`
+}
+
+// TriggeredPriceAlert is an alert whose condition is met by its symbol's latest quote
+type TriggeredPriceAlert struct {
+	Alert         *PriceAlert `json:"alert"`
+	Symbol        string      `json:"symbol"`
+	AccountID     string      `json:"account_id"`
+	Price         float64     `json:"price"`
+	ChangePercent *float64    `json:"change_percent,omitempty"` // Move from the previous close
+	QuotedAt      time.Time   `json:"quoted_at"`
+}
+
+// ListTriggeredPriceAlertsResponse represents the alerts triggered by the latest quotes
+type ListTriggeredPriceAlertsResponse struct {
+	Alerts []*TriggeredPriceAlert `json:"alerts"`
+}
+
+// verifyHoldingOwnership checks that the holding belongs to one of the user's accounts
+func (s *Service) verifyHoldingOwnership(ctx context.Context, holdingID string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM holdings h
+			JOIN accounts a ON a.id = h.account_id
+			WHERE h.id = $1 AND a.user_id = $2
+		)
+	`, holdingID, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to verify holding ownership: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("holding not found")
+	}
+	return nil
+}
+
+// validateAlertThreshold checks an alert's condition and threshold
+func validateAlertThreshold(condition AlertCondition, threshold float64) error {
+	switch condition {
+	case AlertConditionPriceAbove, AlertConditionPriceBelow, AlertConditionDailyMove:
+	default:
+		return fmt.Errorf("invalid alert condition: %s", condition)
+	}
+	if threshold <= 0 {
+		return fmt.Errorf("threshold must be positive")
+	}
+	return nil
+}
+
+// CreatePriceAlert adds a price alert rule to a security holding
+func (s *Service) CreatePriceAlert(ctx context.Context, holdingID string, req *CreatePriceAlertRequest) (*PriceAlert, error) {
+	if err := s.verifyHoldingOwnership(ctx, holdingID); err != nil {
+		return nil, err
+	}
+	if err := validateAlertThreshold(req.Condition, req.Threshold); err != nil {
+		return nil, err
+	}
+
+	var symbol sql.NullString
+	if err := s.db.QueryRowContext(ctx, `SELECT symbol FROM holdings WHERE id = $1`, holdingID).Scan(&symbol); err != nil {
+		return nil, fmt.Errorf("failed to get holding: %w", err)
+	}
+	if !symbol.Valid || symbol.String == "" {
+		return nil, fmt.Errorf("price alerts require a holding with a symbol")
+	}
+
+	now := time.Now()
+	alert := &PriceAlert{
+		ID:        uuid.New().String(),
+		HoldingID: holdingID,
+		Condition: req.Condition,
+		Threshold: req.Threshold,
+		IsActive:  true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.Notes != "" {
+		alert.Notes = &req.Notes
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO holding_price_alerts (id, holding_id, condition, threshold, is_active, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, alert.ID, alert.HoldingID, alert.Condition, alert.Threshold, alert.IsActive, alert.Notes, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create price alert: %w", err)
+	}
+
+	return alert, nil
+}
+
+// ListPriceAlerts lists a holding's price alert rules
+func (s *Service) ListPriceAlerts(ctx context.Context, holdingID string) (*ListPriceAlertsResponse, error) {
+	if err := s.verifyHoldingOwnership(ctx, holdingID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, holding_id, condition, threshold, is_active, notes, last_triggered_at, created_at, updated_at
+		FROM holding_price_alerts
+		WHERE holding_id = $1
+		ORDER BY created_at
+	`, holdingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list price alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := make([]*PriceAlert, 0)
+	for rows.Next() {
+		alert, err := scanPriceAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan price alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return &ListPriceAlertsResponse{Alerts: alerts}, rows.Err()
+}
+
+// getPriceAlert retrieves a single price alert belonging to the holding
+func (s *Service) getPriceAlert(ctx context.Context, holdingID, alertID string) (*PriceAlert, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, holding_id, condition, threshold, is_active, notes, last_triggered_at, created_at, updated_at
+		FROM holding_price_alerts
+		WHERE id = $1 AND holding_id = $2
+	`, alertID, holdingID)
+
+	alert, err := scanPriceAlert(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("price alert not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price alert: %w", err)
+	}
+	return alert, nil
+}
+
+// UpdatePriceAlert changes a price alert's threshold, notes or active state
+func (s *Service) UpdatePriceAlert(ctx context.Context, holdingID, alertID string, req *UpdatePriceAlertRequest) (*PriceAlert, error) {
+	if err := s.verifyHoldingOwnership(ctx, holdingID); err != nil {
+		return nil, err
+	}
+
+	alert, err := s.getPriceAlert(ctx, holdingID, alertID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Threshold != nil {
+		if err := validateAlertThreshold(alert.Condition, *req.Threshold); err != nil {
+			return nil, err
+		}
+		alert.Threshold = *req.Threshold
+	}
+	if req.IsActive != nil {
+		alert.IsActive = *req.IsActive
+	}
+	if req.Notes != nil {
+		alert.Notes = req.Notes
+	}
+	alert.UpdatedAt = time.Now()
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE holding_price_alerts
+		SET threshold = $1, is_active = $2, notes = $3, updated_at = $4
+		WHERE id = $5
+	`, alert.Threshold, alert.IsActive, alert.Notes, alert.UpdatedAt, alertID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update price alert: %w", err)
+	}
+
+	return alert, nil
+}
+
+// DeletePriceAlert removes a price alert rule
+func (s *Service) DeletePriceAlert(ctx context.Context, holdingID, alertID string) error {
+	if err := s.verifyHoldingOwnership(ctx, holdingID); err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM holding_price_alerts WHERE id = $1 AND holding_id = $2
+	`, alertID, holdingID)
+	if err != nil {
+		return fmt.Errorf("failed to delete price alert: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("price alert not found")
+	}
+
+	return nil
+}
+
+// RecordQuote stores the latest price for a symbol. Alerts are evaluated against the
+// stored quote by the notification checks.
+func (s *Service) RecordQuote(ctx context.Context, symbol string, req *RecordQuoteRequest) (*Quote, error) {
+	if auth.GetUserID(ctx) == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	if req.Price <= 0 {
+		return nil, fmt.Errorf("price must be positive")
+	}
+	if req.PreviousClose != nil && *req.PreviousClose <= 0 {
+		return nil, fmt.Errorf("previous close must be positive")
+	}
+	if req.Currency != CurrencyCAD && req.Currency != CurrencyUSD && req.Currency != CurrencyINR {
+		return nil, fmt.Errorf("invalid currency: %s", req.Currency)
+	}
+
+	quote := &Quote{
+		Symbol:        symbol,
+		Price:         req.Price,
+		Currency:      req.Currency,
+		PreviousClose: req.PreviousClose,
+		QuotedAt:      time.Now(),
+	}
+	if req.Source != "" {
+		quote.Source = &req.Source
+	}
+	if req.QuotedAt != nil {
+		quote.QuotedAt = *req.QuotedAt
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO market_data (id, symbol, price, currency, previous_close, last_updated, source, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (symbol) DO UPDATE SET
+			price = excluded.price,
+			currency = excluded.currency,
+			previous_close = excluded.previous_close,
+			last_updated = excluded.last_updated,
+			source = excluded.source
+	`, uuid.New().String(), quote.Symbol, quote.Price, quote.Currency, quote.PreviousClose, quote.QuotedAt,
+		quote.Source, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to record quote: %w", err)
+	}
+
+	// The last quote of a day stands as its close in the history used for volatility
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO quote_history (symbol, quote_date, price, currency)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (symbol, quote_date) DO UPDATE SET
+			price = excluded.price,
+			currency = excluded.currency
+	`, quote.Symbol, quoteDate(quote.QuotedAt), quote.Price, quote.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record quote history: %w", err)
+	}
+
+	err = s.UpsertSecurityTx(ctx, s.db, quote.Symbol, &SecurityUpdate{
+		Name:       req.Name,
+		Exchange:   req.Exchange,
+		Currency:   &quote.Currency,
+		AssetClass: req.AssetClass,
+		Sector:     req.Sector,
+	}, SecuritySourceQuote)
+	if err != nil {
+		return nil, err
+	}
+
+	return quote, nil
+}
+
+// quoteDate is the UTC day a quote was taken on
+func quoteDate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// GetQuoteHistory returns a symbol's daily closes on or after since, oldest first
+func (s *Service) GetQuoteHistory(ctx context.Context, symbol string, since time.Time) ([]QuotePoint, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT quote_date, price
+		FROM quote_history
+		WHERE symbol = $1 AND quote_date >= $2
+		ORDER BY quote_date
+	`, strings.ToUpper(strings.TrimSpace(symbol)), quoteDate(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quote history: %w", err)
+	}
+	defer rows.Close()
+
+	points := make([]QuotePoint, 0)
+	for rows.Next() {
+		var point QuotePoint
+		if err := rows.Scan(&point.Date, &point.Price); err != nil {
+			return nil, fmt.Errorf("failed to scan quote history: %w", err)
+		}
+		points = append(points, point)
+	}
+
+	return points, rows.Err()
+}
+
+// GetTriggeredPriceAlerts evaluates the user's active price alerts against the latest quotes
+func (s *Service) GetTriggeredPriceAlerts(ctx context.Context) (*ListTriggeredPriceAlertsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT pa.id, pa.holding_id, pa.condition, pa.threshold, pa.is_active, pa.notes, pa.last_triggered_at,
+		       pa.created_at, pa.updated_at,
+		       h.account_id, q.symbol, q.price, q.previous_close, q.last_updated
+		FROM holding_price_alerts pa
+		JOIN holdings h ON h.id = pa.holding_id
+		JOIN accounts a ON a.id = h.account_id
+		JOIN market_data q ON q.symbol = UPPER(h.symbol)
+		WHERE a.user_id = $1 AND pa.is_active = 1
+		ORDER BY pa.created_at
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price alerts: %w", err)
+	}
+
+	triggered := make([]*TriggeredPriceAlert, 0)
+	for rows.Next() {
+		alert := &PriceAlert{}
+		t := &TriggeredPriceAlert{Alert: alert}
+		var previousClose *float64
+		if err := rows.Scan(&alert.ID, &alert.HoldingID, &alert.Condition, &alert.Threshold, &alert.IsActive,
+			&alert.Notes, &alert.LastTriggeredAt, &alert.CreatedAt, &alert.UpdatedAt,
+			&t.AccountID, &t.Symbol, &t.Price, &previousClose, &t.QuotedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan price alert: %w", err)
+		}
+
+		quote := &Quote{Symbol: t.Symbol, Price: t.Price, PreviousClose: previousClose, QuotedAt: t.QuotedAt}
+		fired, change := evaluatePriceAlert(alert, quote)
+		if !fired {
+			continue
+		}
+		t.ChangePercent = change
+		triggered = append(triggered, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &ListTriggeredPriceAlertsResponse{Alerts: triggered}, nil
+}
+
+// MarkPriceAlertTriggered records when an alert last raised a notification
+func (s *Service) MarkPriceAlertTriggered(ctx context.Context, alertID string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE holding_price_alerts SET last_triggered_at = $1 WHERE id = $2
+	`, at, alertID)
+	if err != nil {
+		return fmt.Errorf("failed to mark price alert triggered: %w", err)
+	}
+	return nil
+}
+
+// evaluatePriceAlert reports whether the quote meets the alert's condition, along with the
+// percent move from the previous close when one is known
+func evaluatePriceAlert(alert *PriceAlert, quote *Quote) (bool, *float64) {
+	var change *float64
+	if quote.PreviousClose != nil && *quote.PreviousClose > 0 {
+		pct := math.Round((quote.Price-*quote.PreviousClose) / *quote.PreviousClose * 10000) / 100
+		change = &pct
+	}
+
+	switch alert.Condition {
+	case AlertConditionPriceAbove:
+		return quote.Price >= alert.Threshold, change
+	case AlertConditionPriceBelow:
+		return quote.Price <= alert.Threshold, change
+	case AlertConditionDailyMove:
+		return change != nil && math.Abs(*change) >= alert.Threshold, change
+	}
+	return false, change
+}
+
+// scanPriceAlert scans a price alert row
+func scanPriceAlert(row interface{ Scan(...interface{}) error }) (*PriceAlert, error) {
+	alert := &PriceAlert{}
+	err := row.Scan(&alert.ID, &alert.HoldingID, &alert.Condition, &alert.Threshold, &alert.IsActive,
+		&alert.Notes, &alert.LastTriggeredAt, &alert.CreatedAt, &alert.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
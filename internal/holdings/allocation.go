@@ -0,0 +1,320 @@
+package holdings
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+)
+
+// AssetClass groups holding types for allocation targets
+type AssetClass string
+
+const (
+	AssetClassEquity      AssetClass = "equity"
+	AssetClassFixedIncome AssetClass = "fixed_income"
+	AssetClassCash        AssetClass = "cash"
+	AssetClassCrypto      AssetClass = "crypto"
+	AssetClassOther       AssetClass = "other"
+)
+
+// DefaultDriftBandPercent is how far an asset class may drift from its target before alerting
+const DefaultDriftBandPercent = 5.0
+
+// holdingAssetClasses maps holding types to the asset class they count towards
+var holdingAssetClasses = map[HoldingType]AssetClass{
+	HoldingTypeStock:      AssetClassEquity,
+	HoldingTypeETF:        AssetClassEquity,
+	HoldingTypeMutualFund: AssetClassEquity,
+	HoldingTypeOption:     AssetClassEquity,
+	HoldingTypeBond:       AssetClassFixedIncome,
+	HoldingTypeCash:       AssetClassCash,
+	HoldingTypeCrypto:     AssetClassCrypto,
+	HoldingTypeOther:      AssetClassOther,
+}
+
+// AssetClassOf returns the asset class a holding type counts towards
+func AssetClassOf(holdingType HoldingType) AssetClass {
+	if class, ok := holdingAssetClasses[holdingType]; ok {
+		return class
+	}
+	return AssetClassOther
+}
+
+// TargetAllocation is the share of the portfolio an asset class should make up
+type TargetAllocation struct {
+	AssetClass    AssetClass `json:"asset_class"`
+	TargetPercent float64    `json:"target_percent"`
+}
+
+// AllocationTargets is a user's target allocation and drift band
+type AllocationTargets struct {
+	Targets          []*TargetAllocation `json:"targets"`
+	DriftBandPercent float64             `json:"drift_band_percent"`
+}
+
+// SaveAllocationTargetsRequest replaces a user's target allocation. Targets must sum to 100.
+type SaveAllocationTargetsRequest struct {
+	Targets          []*TargetAllocation `json:"targets"`
+	DriftBandPercent *float64            `json:"drift_band_percent,omitempty"` // Defaults to the current band
+}
+
+// AssetClassDrift compares an asset class's current share with its target
+type AssetClassDrift struct {
+	AssetClass     AssetClass `json:"asset_class"`
+	CurrentValue   float64    `json:"current_value"`
+	CurrentPercent float64    `json:"current_percent"`
+	TargetPercent  float64    `json:"target_percent"`
+	DriftPercent   float64    `json:"drift_percent"` // Current - target, in percentage points
+	OutOfBand      bool       `json:"out_of_band"`
+}
+
+// SuggestedTrade is a buy or sell that moves an asset class back to its target
+type SuggestedTrade struct {
+	AssetClass AssetClass `json:"asset_class"`
+	Action     string     `json:"action"` // buy or sell
+	Amount     float64    `json:"amount"`
+}
+
+// AllocationDrift reports how far the portfolio has drifted from its target allocation
+type AllocationDrift struct {
+	TotalValue       float64            `json:"total_value"`
+	DriftBandPercent float64            `json:"drift_band_percent"`
+	OutOfBand        bool               `json:"out_of_band"` // Any asset class drifted past the band
+	Classes          []*AssetClassDrift `json:"classes"`
+	Trades           []*SuggestedTrade  `json:"trades"`
+}
+
+// GetAllocationTargets returns the user's target allocation, empty if none is set
+func (s *Service) GetAllocationTargets(ctx context.Context) (*AllocationTargets, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	targets := &AllocationTargets{
+		Targets:          make([]*TargetAllocation, 0),
+		DriftBandPercent: DefaultDriftBandPercent,
+	}
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT drift_band_percent FROM allocation_settings WHERE user_id = $1
+	`, userID).Scan(&targets.DriftBandPercent)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get allocation settings: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT asset_class, target_percent FROM target_allocations
+		WHERE user_id = $1
+		ORDER BY asset_class
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get target allocations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		target := &TargetAllocation{}
+		if err := rows.Scan(&target.AssetClass, &target.TargetPercent); err != nil {
+			return nil, fmt.Errorf("failed to scan target allocation: %w", err)
+		}
+		targets.Targets = append(targets.Targets, target)
+	}
+
+	return targets, rows.Err()
+}
+
+// SaveAllocationTargets replaces the user's target allocation
+func (s *Service) SaveAllocationTargets(ctx context.Context, req *SaveAllocationTargetsRequest) (*AllocationTargets, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	total := 0.0
+	seen := make(map[AssetClass]bool)
+	for _, target := range req.Targets {
+		switch target.AssetClass {
+		case AssetClassEquity, AssetClassFixedIncome, AssetClassCash, AssetClassCrypto, AssetClassOther:
+		default:
+			return nil, fmt.Errorf("invalid asset class: %s", target.AssetClass)
+		}
+		if seen[target.AssetClass] {
+			return nil, fmt.Errorf("duplicate target for asset class %s", target.AssetClass)
+		}
+		if target.TargetPercent < 0 || target.TargetPercent > 100 {
+			return nil, fmt.Errorf("target percent must be between 0 and 100")
+		}
+		seen[target.AssetClass] = true
+		total += target.TargetPercent
+	}
+	if len(req.Targets) > 0 && math.Abs(total-100) > 0.01 {
+		return nil, fmt.Errorf("target percentages must sum to 100, got %.2f", total)
+	}
+	if req.DriftBandPercent != nil && *req.DriftBandPercent <= 0 {
+		return nil, fmt.Errorf("drift band must be positive")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM target_allocations WHERE user_id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("failed to clear target allocations: %w", err)
+	}
+	for _, target := range req.Targets {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO target_allocations (id, user_id, asset_class, target_percent, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, uuid.New().String(), userID, target.AssetClass, target.TargetPercent, now, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save target allocation: %w", err)
+		}
+	}
+
+	if req.DriftBandPercent != nil {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO allocation_settings (user_id, drift_band_percent, updated_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id) DO UPDATE SET
+				drift_band_percent = excluded.drift_band_percent,
+				updated_at = excluded.updated_at
+		`, userID, *req.DriftBandPercent, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save allocation settings: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return s.GetAllocationTargets(ctx)
+}
+
+// GetAllocationDrift measures the user's holdings against their target allocation. Holdings
+// are valued at the latest recorded quote, falling back to cost basis.
+func (s *Service) GetAllocationDrift(ctx context.Context) (*AllocationDrift, error) {
+	targets, err := s.GetAllocationTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := s.assetClassValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return computeAllocationDrift(values, targets), nil
+}
+
+// GetAssetClassValues totals the current value of the user's holdings by asset class
+func (s *Service) GetAssetClassValues(ctx context.Context) (map[AssetClass]float64, error) {
+	return s.assetClassValues(ctx)
+}
+
+// assetClassValues totals the current value of the user's holdings in active accounts by asset class
+func (s *Service) assetClassValues(ctx context.Context) (map[AssetClass]float64, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT h.type, h.quantity, h.cost_basis, h.amount, q.price, sec.asset_class
+		FROM holdings h
+		JOIN accounts a ON a.id = h.account_id
+		LEFT JOIN market_data q ON q.symbol = UPPER(h.symbol)
+		LEFT JOIN securities sec ON sec.symbol = UPPER(h.symbol)
+		WHERE a.user_id = $1 AND a.is_active = 1
+	`, auth.GetUserID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holdings: %w", err)
+	}
+	defer rows.Close()
+
+	values := make(map[AssetClass]float64)
+	for rows.Next() {
+		var holdingType HoldingType
+		var quantity, costBasis, amount, price *float64
+		var securityClass *AssetClass
+		if err := rows.Scan(&holdingType, &quantity, &costBasis, &amount, &price, &securityClass); err != nil {
+			return nil, fmt.Errorf("failed to scan holding: %w", err)
+		}
+
+		value := 0.0
+		switch {
+		case holdingType == HoldingTypeCash && amount != nil:
+			value = *amount
+		case quantity != nil && price != nil:
+			value = *quantity * *price
+		case quantity != nil && costBasis != nil:
+			value = *quantity * *costBasis
+		}
+
+		values[HoldingAssetClass(holdingType, securityClass)] += value
+	}
+
+	return values, rows.Err()
+}
+
+// computeAllocationDrift compares asset class values with their targets and suggests the
+// trades that would bring every class back to target
+func computeAllocationDrift(values map[AssetClass]float64, targets *AllocationTargets) *AllocationDrift {
+	drift := &AllocationDrift{
+		DriftBandPercent: targets.DriftBandPercent,
+		Classes:          make([]*AssetClassDrift, 0),
+		Trades:           make([]*SuggestedTrade, 0),
+	}
+
+	for _, value := range values {
+		drift.TotalValue += value
+	}
+	drift.TotalValue = math.Round(drift.TotalValue*100) / 100
+	if len(targets.Targets) == 0 || drift.TotalValue <= 0 {
+		return drift
+	}
+
+	targetPercents := make(map[AssetClass]float64)
+	for _, target := range targets.Targets {
+		targetPercents[target.AssetClass] = target.TargetPercent
+	}
+	// Classes held without a target have an implicit target of zero
+	for class := range values {
+		if _, ok := targetPercents[class]; !ok && values[class] != 0 {
+			targetPercents[class] = 0
+		}
+	}
+
+	for class, targetPercent := range targetPercents {
+		currentPercent := values[class] / drift.TotalValue * 100
+		classDrift := &AssetClassDrift{
+			AssetClass:     class,
+			CurrentValue:   math.Round(values[class]*100) / 100,
+			CurrentPercent: math.Round(currentPercent*100) / 100,
+			TargetPercent:  targetPercent,
+			DriftPercent:   math.Round((currentPercent-targetPercent)*100) / 100,
+		}
+		classDrift.OutOfBand = math.Abs(currentPercent-targetPercent) > targets.DriftBandPercent
+		if classDrift.OutOfBand {
+			drift.OutOfBand = true
+		}
+		drift.Classes = append(drift.Classes, classDrift)
+
+		amount := math.Round((targetPercent/100*drift.TotalValue-values[class])*100) / 100
+		switch {
+		case amount >= 0.01:
+			drift.Trades = append(drift.Trades, &SuggestedTrade{AssetClass: class, Action: "buy", Amount: amount})
+		case amount <= -0.01:
+			drift.Trades = append(drift.Trades, &SuggestedTrade{AssetClass: class, Action: "sell", Amount: -amount})
+		}
+	}
+
+	sort.Slice(drift.Classes, func(i, j int) bool { return drift.Classes[i].AssetClass < drift.Classes[j].AssetClass })
+	sort.Slice(drift.Trades, func(i, j int) bool { return drift.Trades[i].AssetClass < drift.Trades[j].AssetClass })
+	return drift
+}
@@ -0,0 +1,701 @@
+package holdings
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/database"
+)
+
+// Broker is a brokerage whose CSV exports can be imported as holding transactions
+type Broker string
+
+const (
+	BrokerWealthsimple Broker = "wealthsimple" // Monthly statement or activity export
+	BrokerQuestrade    Broker = "questrade"    // Account activity export saved as CSV
+	BrokerIBKR         Broker = "ibkr"         // Interactive Brokers activity statement CSV
+)
+
+// TransactionType is the kind of a holding transaction
+type TransactionType string
+
+const (
+	TransactionTypeBuy        TransactionType = "buy"
+	TransactionTypeSell       TransactionType = "sell"
+	TransactionTypeDividend   TransactionType = "dividend"
+	TransactionTypeDeposit    TransactionType = "deposit"
+	TransactionTypeWithdrawal TransactionType = "withdrawal"
+	TransactionTypeFee        TransactionType = "fee"
+	TransactionTypeInterest   TransactionType = "interest"
+)
+
+// BrokerTransaction is a row of a broker export. Quantities and amounts are positive, with
+// the type giving the direction. Amount is the cash that moved, commissions included.
+type BrokerTransaction struct {
+	Line        int             `json:"line"`
+	Date        time.Time       `json:"date"`
+	Type        TransactionType `json:"type"`
+	Symbol      string          `json:"symbol,omitempty"` // Empty for cash movements
+	Quantity    float64         `json:"quantity,omitempty"`
+	Price       float64         `json:"price,omitempty"`
+	Amount      float64         `json:"amount"`
+	Currency    string          `json:"currency"`
+	Description string          `json:"description,omitempty"`
+}
+
+// BrokerImportSkip is an export row that wasn't imported, and why
+type BrokerImportSkip struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// ParsedBrokerCSV is the transactions read from a broker export
+type ParsedBrokerCSV struct {
+	Transactions []*BrokerTransaction `json:"transactions"`
+	Skipped      []BrokerImportSkip   `json:"skipped"`
+}
+
+// BrokerImportResponse reports what a broker import stored
+type BrokerImportResponse struct {
+	Broker          Broker             `json:"broker"`
+	Imported        int                `json:"imported"`
+	Duplicates      int                `json:"duplicates"`       // Rows already imported from an earlier file
+	HoldingsCreated []string           `json:"holdings_created"` // Symbols the account had no holding for
+	Skipped         []BrokerImportSkip `json:"skipped"`
+}
+
+// ParseBroker validates a broker name
+func ParseBroker(value string) (Broker, error) {
+	switch broker := Broker(strings.ToLower(strings.TrimSpace(value))); broker {
+	case BrokerWealthsimple, BrokerQuestrade, BrokerIBKR:
+		return broker, nil
+	default:
+		return "", fmt.Errorf("unsupported broker: %s (expected wealthsimple, questrade or ibkr)", value)
+	}
+}
+
+// ParseBrokerCSV reads the transactions from a broker's CSV export
+func ParseBrokerCSV(broker Broker, r io.Reader) (*ParsedBrokerCSV, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	switch broker {
+	case BrokerWealthsimple:
+		return parseWealthsimpleCSV(reader)
+	case BrokerQuestrade:
+		return parseQuestradeCSV(reader)
+	case BrokerIBKR:
+		return parseIBKRCSV(reader)
+	default:
+		return nil, fmt.Errorf("unsupported broker: %s", broker)
+	}
+}
+
+// csvColumns indexes a header row by lower-cased column name
+type csvColumns map[string]int
+
+func newCSVColumns(header []string) csvColumns {
+	columns := make(csvColumns, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	return columns
+}
+
+// field returns a record's trimmed value for a column, or "" when it has none
+func (c csvColumns) field(record []string, name string) string {
+	i, ok := c[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// require fails when any of the columns is missing
+func (c csvColumns) require(names ...string) error {
+	for _, name := range names {
+		if _, ok := c[name]; !ok {
+			return fmt.Errorf("CSV is missing a %q column", name)
+		}
+	}
+	return nil
+}
+
+// parseBrokerNumber reads an amount, allowing currency symbols, thousands separators and
+// accounting-style parentheses for negatives. An empty value is zero.
+func parseBrokerNumber(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")")
+	value = strings.Trim(value, "()")
+	value = strings.NewReplacer("$", "", ",", "", " ", "").Replace(value)
+	if value == "" || value == "-" || value == "--" {
+		return 0, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	if negative {
+		number = -number
+	}
+	return number, nil
+}
+
+// parseBrokerDate reads the date at the start of a date or date-time value
+func parseBrokerDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if len(value) >= len("2006-01-02") {
+		if date, err := time.Parse("2006-01-02", value[:len("2006-01-02")]); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// wealthsimpleTypes maps Wealthsimple transaction codes to transaction types
+var wealthsimpleTypes = map[string]TransactionType{
+	"BUY":    TransactionTypeBuy,
+	"SELL":   TransactionTypeSell,
+	"DIV":    TransactionTypeDividend,
+	"CONT":   TransactionTypeDeposit,
+	"DEP":    TransactionTypeDeposit,
+	"TRFIN":  TransactionTypeDeposit,
+	"WD":     TransactionTypeWithdrawal,
+	"WDL":    TransactionTypeWithdrawal,
+	"TRFOUT": TransactionTypeWithdrawal,
+	"FEE":    TransactionTypeFee,
+	"NRT":    TransactionTypeFee, // Non-resident tax withheld from a dividend
+	"INT":    TransactionTypeInterest,
+}
+
+// wealthsimpleShares finds the share count in a trade description such as
+// "AAPL - Apple Inc.: Bought 10.0000 shares (executed at 2024-01-02)"
+var wealthsimpleShares = regexp.MustCompile(`(?i)\b(?:bought|sold)\s+([\d.,]+)\s+shares?`)
+
+// parseWealthsimpleCSV reads a Wealthsimple export with date, transaction, description,
+// amount and currency columns. The symbol and share count come from the description.
+func parseWealthsimpleCSV(reader *csv.Reader) (*ParsedBrokerCSV, error) {
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := newCSVColumns(header)
+	if err := columns.require("date", "transaction", "description", "amount"); err != nil {
+		return nil, err
+	}
+
+	parsed := &ParsedBrokerCSV{Transactions: make([]*BrokerTransaction, 0), Skipped: make([]BrokerImportSkip, 0)}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		code := strings.ToUpper(columns.field(record, "transaction"))
+		txnType, ok := wealthsimpleTypes[code]
+		if !ok {
+			parsed.Skipped = append(parsed.Skipped, BrokerImportSkip{Line: line, Reason: fmt.Sprintf("unsupported transaction %q", code)})
+			continue
+		}
+		date, err := parseBrokerDate(columns.field(record, "date"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		amount, err := parseBrokerNumber(columns.field(record, "amount"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		currency := strings.ToUpper(columns.field(record, "currency"))
+		if currency == "" {
+			currency = string(CurrencyCAD)
+		}
+
+		txn := &BrokerTransaction{
+			Line:        line,
+			Date:        date,
+			Type:        txnType,
+			Amount:      math.Abs(amount),
+			Currency:    currency,
+			Description: columns.field(record, "description"),
+		}
+		switch txnType {
+		case TransactionTypeBuy, TransactionTypeSell, TransactionTypeDividend:
+			symbol, _, found := strings.Cut(txn.Description, " - ")
+			if !found || strings.TrimSpace(symbol) == "" {
+				parsed.Skipped = append(parsed.Skipped, BrokerImportSkip{Line: line, Reason: "no symbol in description"})
+				continue
+			}
+			txn.Symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		}
+		if txnType == TransactionTypeBuy || txnType == TransactionTypeSell {
+			match := wealthsimpleShares.FindStringSubmatch(txn.Description)
+			if match == nil {
+				parsed.Skipped = append(parsed.Skipped, BrokerImportSkip{Line: line, Reason: "no share count in description"})
+				continue
+			}
+			quantity, err := parseBrokerNumber(match[1])
+			if err != nil || quantity <= 0 {
+				parsed.Skipped = append(parsed.Skipped, BrokerImportSkip{Line: line, Reason: "invalid share count"})
+				continue
+			}
+			txn.Quantity = quantity
+			txn.Price = txn.Amount / quantity
+		}
+		parsed.Transactions = append(parsed.Transactions, txn)
+	}
+	return parsed, nil
+}
+
+// parseQuestradeCSV reads a Questrade activity export. Activity Type groups the rows;
+// Action tells buys from sells.
+func parseQuestradeCSV(reader *csv.Reader) (*ParsedBrokerCSV, error) {
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := newCSVColumns(header)
+	if err := columns.require("transaction date", "action", "symbol", "quantity", "price", "net amount", "currency", "activity type"); err != nil {
+		return nil, err
+	}
+
+	parsed := &ParsedBrokerCSV{Transactions: make([]*BrokerTransaction, 0), Skipped: make([]BrokerImportSkip, 0)}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		date, err := parseBrokerDate(columns.field(record, "transaction date"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		netAmount, err := parseBrokerNumber(columns.field(record, "net amount"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		quantity, err := parseBrokerNumber(columns.field(record, "quantity"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		price, err := parseBrokerNumber(columns.field(record, "price"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		txn := &BrokerTransaction{
+			Line:        line,
+			Date:        date,
+			Amount:      math.Abs(netAmount),
+			Currency:    strings.ToUpper(columns.field(record, "currency")),
+			Description: columns.field(record, "description"),
+		}
+		activity := strings.ToLower(columns.field(record, "activity type"))
+		action := strings.ToLower(columns.field(record, "action"))
+		switch {
+		case activity == "trades" && action == "buy":
+			txn.Type = TransactionTypeBuy
+		case activity == "trades" && action == "sell":
+			txn.Type = TransactionTypeSell
+		case activity == "dividends":
+			txn.Type = TransactionTypeDividend
+		case activity == "deposits":
+			txn.Type = TransactionTypeDeposit
+		case activity == "withdrawals":
+			txn.Type = TransactionTypeWithdrawal
+		case activity == "fees and rebates" && netAmount < 0:
+			txn.Type = TransactionTypeFee
+		case activity == "interest" && netAmount > 0:
+			txn.Type = TransactionTypeInterest
+		case activity == "interest":
+			txn.Type = TransactionTypeFee // Margin interest charged
+		default:
+			parsed.Skipped = append(parsed.Skipped, BrokerImportSkip{
+				Line:   line,
+				Reason: fmt.Sprintf("unsupported activity %q (%s)", columns.field(record, "activity type"), columns.field(record, "action")),
+			})
+			continue
+		}
+
+		if txn.Type == TransactionTypeBuy || txn.Type == TransactionTypeSell || txn.Type == TransactionTypeDividend {
+			txn.Symbol = strings.ToUpper(columns.field(record, "symbol"))
+			if txn.Symbol == "" {
+				parsed.Skipped = append(parsed.Skipped, BrokerImportSkip{Line: line, Reason: "no symbol"})
+				continue
+			}
+		}
+		if txn.Type == TransactionTypeBuy || txn.Type == TransactionTypeSell {
+			txn.Quantity = math.Abs(quantity)
+			txn.Price = price
+			if txn.Quantity == 0 {
+				parsed.Skipped = append(parsed.Skipped, BrokerImportSkip{Line: line, Reason: "no quantity"})
+				continue
+			}
+		}
+		parsed.Transactions = append(parsed.Transactions, txn)
+	}
+	return parsed, nil
+}
+
+// parseIBKRCSV reads an Interactive Brokers activity statement. The file holds a section
+// per topic, each with its own Header row; trades, dividends, withholding tax, deposits and
+// withdrawals, fees and interest are imported and the rest ignored.
+func parseIBKRCSV(reader *csv.Reader) (*ParsedBrokerCSV, error) {
+	parsed := &ParsedBrokerCSV{Transactions: make([]*BrokerTransaction, 0), Skipped: make([]BrokerImportSkip, 0)}
+	sections := make(map[string]csvColumns)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) < 2 {
+			continue
+		}
+		section, kind, fields := strings.TrimPrefix(record[0], "\ufeff"), record[1], record[2:]
+		if kind == "Header" {
+			sections[section] = newCSVColumns(fields)
+			continue
+		}
+		columns, ok := sections[section]
+		if kind != "Data" || !ok {
+			continue
+		}
+
+		var txn *BrokerTransaction
+		var skip string
+		switch section {
+		case "Trades":
+			txn, skip, err = ibkrTrade(columns, fields)
+		case "Dividends", "Withholding Tax", "Deposits & Withdrawals", "Fees", "Interest":
+			txn, skip, err = ibkrCashRow(section, columns, fields)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if skip != "" {
+			parsed.Skipped = append(parsed.Skipped, BrokerImportSkip{Line: line, Reason: skip})
+			continue
+		}
+		if txn != nil {
+			txn.Line = line
+			parsed.Transactions = append(parsed.Transactions, txn)
+		}
+	}
+	if _, ok := sections["Trades"]; !ok && len(parsed.Transactions) == 0 {
+		return nil, fmt.Errorf("not an Interactive Brokers activity statement")
+	}
+	return parsed, nil
+}
+
+// ibkrTrade reads an order row of the Trades section. Quantity is negative for sells;
+// proceeds and commission are signed cash flows.
+func ibkrTrade(columns csvColumns, fields []string) (*BrokerTransaction, string, error) {
+	if discriminator := columns.field(fields, "datadiscriminator"); discriminator != "" && discriminator != "Order" {
+		return nil, "", nil // Subtotal and total rows
+	}
+	if asset := columns.field(fields, "asset category"); asset != "Stocks" {
+		return nil, fmt.Sprintf("unsupported asset category %q", asset), nil
+	}
+
+	date, err := parseBrokerDate(columns.field(fields, "date/time"))
+	if err != nil {
+		return nil, "", err
+	}
+	quantity, err := parseBrokerNumber(columns.field(fields, "quantity"))
+	if err != nil {
+		return nil, "", err
+	}
+	price, err := parseBrokerNumber(columns.field(fields, "t. price"))
+	if err != nil {
+		return nil, "", err
+	}
+	proceeds, err := parseBrokerNumber(columns.field(fields, "proceeds"))
+	if err != nil {
+		return nil, "", err
+	}
+	commission, err := parseBrokerNumber(columns.field(fields, "comm/fee"))
+	if err != nil {
+		return nil, "", err
+	}
+	if quantity == 0 {
+		return nil, "no quantity", nil
+	}
+
+	txn := &BrokerTransaction{
+		Date:     date,
+		Symbol:   strings.ToUpper(columns.field(fields, "symbol")),
+		Quantity: math.Abs(quantity),
+		Price:    price,
+		Currency: strings.ToUpper(columns.field(fields, "currency")),
+		// Commissions are negative, so they add to the cost of a buy and reduce a sale's proceeds
+		Amount: math.Abs(proceeds + commission),
+	}
+	txn.Type = TransactionTypeBuy
+	if quantity < 0 {
+		txn.Type = TransactionTypeSell
+	}
+	return txn, "", nil
+}
+
+// ibkrCashRow reads a row of a section listing cash amounts by currency, date and description
+func ibkrCashRow(section string, columns csvColumns, fields []string) (*BrokerTransaction, string, error) {
+	currency := columns.field(fields, "currency")
+	if currency == "" || strings.HasPrefix(currency, "Total") {
+		return nil, "", nil // Section and per-currency totals
+	}
+
+	dateValue := columns.field(fields, "date")
+	if dateValue == "" {
+		dateValue = columns.field(fields, "settle date")
+	}
+	date, err := parseBrokerDate(dateValue)
+	if err != nil {
+		return nil, "", err
+	}
+	amount, err := parseBrokerNumber(columns.field(fields, "amount"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	txn := &BrokerTransaction{
+		Date:        date,
+		Amount:      math.Abs(amount),
+		Currency:    strings.ToUpper(currency),
+		Description: columns.field(fields, "description"),
+	}
+	switch {
+	case section == "Dividends" && amount > 0:
+		txn.Type = TransactionTypeDividend
+	case section == "Dividends":
+		return nil, "dividend reversal", nil
+	case section == "Withholding Tax":
+		txn.Type = TransactionTypeFee
+	case section == "Deposits & Withdrawals" && amount >= 0:
+		txn.Type = TransactionTypeDeposit
+	case section == "Deposits & Withdrawals":
+		txn.Type = TransactionTypeWithdrawal
+	case section == "Interest" && amount > 0:
+		txn.Type = TransactionTypeInterest
+	default:
+		txn.Type = TransactionTypeFee
+	}
+
+	// Dividend and withholding descriptions start with the symbol, e.g. "AAPL(US0378331005) Cash Dividend"
+	if section == "Dividends" || section == "Withholding Tax" {
+		symbol, _, found := strings.Cut(txn.Description, "(")
+		if !found || strings.TrimSpace(symbol) == "" {
+			return nil, "no symbol in description", nil
+		}
+		txn.Symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	}
+	return txn, "", nil
+}
+
+// brokerImportHash fingerprints an imported row. The occurrence count keeps identical rows in
+// one file apart, such as two fills at the same price, while re-importing the file matches
+// each of them again.
+func brokerImportHash(broker Broker, txn *BrokerTransaction, occurrence int) string {
+	key := fmt.Sprintf("%s|%s|%s|%s|%.8f|%.2f|%s|%s|%d",
+		broker, txn.Date.Format("2006-01-02"), txn.Type, txn.Symbol, txn.Quantity, txn.Amount,
+		txn.Currency, txn.Description, occurrence)
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ImportBrokerTransactions stores parsed broker rows as holding transactions on an account.
+// Trades and dividends attach to the symbol's holding and cash movements to the account's
+// cash holding in the row's currency; missing holdings are created, with a new security
+// holding's quantity and average cost taken from the imported trades. Rows imported before
+// are skipped.
+func (s *Service) ImportBrokerTransactions(ctx context.Context, accountID string, broker Broker, parsed *ParsedBrokerCSV) (*BrokerImportResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+	if len(parsed.Transactions) == 0 {
+		return nil, fmt.Errorf("the file has no transactions to import")
+	}
+
+	resp := &BrokerImportResponse{
+		Broker:          broker,
+		HoldingsCreated: make([]string, 0),
+		Skipped:         append(make([]BrokerImportSkip, 0, len(parsed.Skipped)), parsed.Skipped...),
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	holdingIDs := make(map[string]string)      // Symbol, or "cash:" and the currency, to holding ID
+	created := make(map[string]*openingTrades) // Holdings created by this import, by symbol
+	occurrences := make(map[string]int)
+	now := time.Now()
+	for _, txn := range parsed.Transactions {
+		switch Currency(txn.Currency) {
+		case CurrencyCAD, CurrencyUSD, CurrencyINR:
+		default:
+			resp.Skipped = append(resp.Skipped, BrokerImportSkip{Line: txn.Line, Reason: fmt.Sprintf("unsupported currency %q", txn.Currency)})
+			continue
+		}
+
+		holdingID, isNew, err := s.importHolding(ctx, tx, accountID, txn, holdingIDs)
+		if err != nil {
+			return nil, err
+		}
+		if isNew && txn.Symbol != "" {
+			created[txn.Symbol] = &openingTrades{}
+			resp.HoldingsCreated = append(resp.HoldingsCreated, txn.Symbol)
+		}
+
+		base := brokerImportHash(broker, txn, 0)
+		occurrences[base]++
+		hash := brokerImportHash(broker, txn, occurrences[base])
+
+		var quantity, price *float64
+		if txn.Type == TransactionTypeBuy || txn.Type == TransactionTypeSell {
+			quantity, price = &txn.Quantity, &txn.Price
+		}
+		var notes *string
+		if txn.Description != "" {
+			notes = &txn.Description
+		}
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO holding_transactions (
+				id, holding_id, type, quantity, price, total_amount, currency,
+				transaction_date, notes, import_hash, created_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			ON CONFLICT (holding_id, import_hash) DO NOTHING
+		`, uuid.New().String(), holdingID, txn.Type, quantity, price, txn.Amount, txn.Currency,
+			txn.Date, notes, hash, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import line %d: %w", txn.Line, err)
+		}
+		if rows, err := result.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		} else if rows == 0 {
+			resp.Duplicates++
+			continue
+		}
+		resp.Imported++
+
+		if trades, ok := created[txn.Symbol]; ok {
+			trades.add(txn)
+		}
+	}
+
+	for symbol, trades := range created {
+		if err := trades.apply(ctx, tx, holdingIDs[symbol], now); err != nil {
+			return nil, fmt.Errorf("failed to set the opening position for %s: %w", symbol, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return resp, nil
+}
+
+// importHolding finds the holding a row belongs to, creating it when the account has none.
+// It reports whether the holding was created by this import.
+func (s *Service) importHolding(ctx context.Context, db database.Querier, accountID string, txn *BrokerTransaction, holdingIDs map[string]string) (string, bool, error) {
+	key := txn.Symbol
+	if key == "" {
+		key = "cash:" + txn.Currency
+	}
+	if id, ok := holdingIDs[key]; ok {
+		return id, false, nil
+	}
+
+	var id string
+	var err error
+	if txn.Symbol != "" {
+		err = db.QueryRowContext(ctx, `
+			SELECT id FROM holdings WHERE account_id = $1 AND UPPER(symbol) = $2
+		`, accountID, txn.Symbol).Scan(&id)
+	} else {
+		err = db.QueryRowContext(ctx, `
+			SELECT id FROM holdings WHERE account_id = $1 AND type = $2 AND currency = $3
+			ORDER BY created_at LIMIT 1
+		`, accountID, HoldingTypeCash, txn.Currency).Scan(&id)
+	}
+	if err == nil {
+		holdingIDs[key] = id
+		return id, false, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", false, fmt.Errorf("failed to find holding: %w", err)
+	}
+
+	req := &CreateHoldingRequest{AccountID: accountID, Notes: "Created by a broker import"}
+	zero := 0.0
+	if txn.Symbol != "" {
+		symbol := txn.Symbol
+		req.Type, req.Symbol, req.Quantity = HoldingTypeStock, &symbol, &zero
+	} else {
+		currency := txn.Currency
+		req.Type, req.Currency, req.Amount = HoldingTypeCash, &currency, &zero
+	}
+	created, err := s.CreateTx(ctx, db, req)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to create holding: %w", err)
+	}
+	holdingIDs[key] = created.Holding.ID
+	return created.Holding.ID, true, nil
+}
+
+// openingTrades totals the imported trades of a holding the import created
+type openingTrades struct {
+	bought, sold, cost float64
+}
+
+func (t *openingTrades) add(txn *BrokerTransaction) {
+	switch txn.Type {
+	case TransactionTypeBuy:
+		t.bought += txn.Quantity
+		t.cost += txn.Amount
+	case TransactionTypeSell:
+		t.sold += txn.Quantity
+	}
+}
+
+// apply sets the holding's quantity to the net shares traded and its cost basis to the
+// average purchase price
+func (t *openingTrades) apply(ctx context.Context, db database.Querier, holdingID string, now time.Time) error {
+	quantity := math.Max(t.bought-t.sold, 0)
+	var costBasis *float64
+	if t.bought > 0 {
+		average := t.cost / t.bought
+		costBasis = &average
+	}
+	_, err := db.ExecContext(ctx, `
+		UPDATE holdings SET quantity = $1, cost_basis = $2, updated_at = $3 WHERE id = $4
+	`, quantity, costBasis, now, holdingID)
+	return err
+}
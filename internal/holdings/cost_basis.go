@@ -0,0 +1,371 @@
+package holdings
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+)
+
+// CostBasisLot is a historical purchase lot imported for a position opened before sync
+type CostBasisLot struct {
+	ID           string     `json:"id"`
+	AccountID    string     `json:"account_id"`
+	Symbol       string     `json:"symbol"`
+	Quantity     float64    `json:"quantity"`
+	TotalCost    float64    `json:"total_cost"`
+	CostPerShare float64    `json:"cost_per_share"`
+	AcquiredDate *time.Time `json:"acquired_date,omitempty"`
+	Notes        *string    `json:"notes,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// CostBasisLotInput is one lot in a cost basis import. Either TotalCost or CostPerShare
+// (the adjusted cost base per share) must be set.
+type CostBasisLotInput struct {
+	Symbol       string   `json:"symbol"`
+	Quantity     float64  `json:"quantity"`
+	TotalCost    *float64 `json:"total_cost,omitempty"`
+	CostPerShare *float64 `json:"cost_per_share,omitempty"`
+	AcquiredDate *string  `json:"acquired_date,omitempty"` // YYYY-MM-DD
+	Notes        string   `json:"notes,omitempty"`
+}
+
+// ImportCostBasisRequest represents a bulk import of historical lots
+type ImportCostBasisRequest struct {
+	Lots    []*CostBasisLotInput `json:"lots"`
+	Replace bool                 `json:"replace"` // Replace previously imported lots for the imported symbols
+}
+
+// OpeningPosition is the position and average cost built from a symbol's imported lots
+type OpeningPosition struct {
+	Symbol       string  `json:"symbol"`
+	Quantity     float64 `json:"quantity"`
+	TotalCost    float64 `json:"total_cost"`
+	CostPerShare float64 `json:"cost_per_share"`
+	Lots         int     `json:"lots"`
+}
+
+// ImportCostBasisResponse represents the result of a cost basis import
+type ImportCostBasisResponse struct {
+	Imported  int                `json:"imported"`
+	Positions []*OpeningPosition `json:"positions"` // Opening positions for the imported symbols
+}
+
+// CostBasisResponse lists an account's imported lots and the opening positions they form
+type CostBasisResponse struct {
+	Lots      []*CostBasisLot    `json:"lots"`
+	Positions []*OpeningPosition `json:"positions"`
+}
+
+// verifyAccountOwnership checks if the account belongs to the authenticated user
+func (s *Service) verifyAccountOwnership(ctx context.Context, accountID string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM accounts WHERE id = $1 AND user_id = $2)
+	`, accountID, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to verify account ownership: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("account not found")
+	}
+	return nil
+}
+
+// ParseCostBasisCSV reads lots from CSV with a header row. Recognised columns are symbol,
+// quantity, total_cost, cost_per_share (or acb), acquired_date and notes.
+func ParseCostBasisCSV(r io.Reader) ([]*CostBasisLotInput, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "acb" {
+			name = "cost_per_share"
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["symbol"]; !ok {
+		return nil, fmt.Errorf("CSV is missing a symbol column")
+	}
+	if _, ok := columns["quantity"]; !ok {
+		return nil, fmt.Errorf("CSV is missing a quantity column")
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	parseAmount := func(value string) (*float64, error) {
+		if value == "" {
+			return nil, nil
+		}
+		amount, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+		if err != nil {
+			return nil, err
+		}
+		return &amount, nil
+	}
+
+	lots := make([]*CostBasisLotInput, 0)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		lot := &CostBasisLotInput{Symbol: field(record, "symbol"), Notes: field(record, "notes")}
+		quantity, err := parseAmount(field(record, "quantity"))
+		if err != nil || quantity == nil {
+			return nil, fmt.Errorf("line %d: invalid quantity", line)
+		}
+		lot.Quantity = *quantity
+		if lot.TotalCost, err = parseAmount(field(record, "total_cost")); err != nil {
+			return nil, fmt.Errorf("line %d: invalid total cost", line)
+		}
+		if lot.CostPerShare, err = parseAmount(field(record, "cost_per_share")); err != nil {
+			return nil, fmt.Errorf("line %d: invalid cost per share", line)
+		}
+		if date := field(record, "acquired_date"); date != "" {
+			lot.AcquiredDate = &date
+		}
+		lots = append(lots, lot)
+	}
+
+	return lots, nil
+}
+
+// ImportCostBasis stores historical lots for an account and sets each imported symbol's
+// holding to the resulting opening position's average cost. Holdings that don't exist yet
+// are created from the lots. Synced holdings keep the imported cost basis.
+func (s *Service) ImportCostBasis(ctx context.Context, accountID string, req *ImportCostBasisRequest) (*ImportCostBasisResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+	if len(req.Lots) == 0 {
+		return nil, fmt.Errorf("at least one lot is required")
+	}
+
+	lots := make([]*CostBasisLot, 0, len(req.Lots))
+	symbols := make(map[string]bool)
+	for i, input := range req.Lots {
+		lot, err := newCostBasisLot(accountID, input)
+		if err != nil {
+			return nil, fmt.Errorf("lot %d: %w", i+1, err)
+		}
+		lots = append(lots, lot)
+		symbols[lot.Symbol] = true
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if req.Replace {
+		for symbol := range symbols {
+			if _, err := tx.ExecContext(ctx, `
+				DELETE FROM cost_basis_lots WHERE account_id = $1 AND symbol = $2
+			`, accountID, symbol); err != nil {
+				return nil, fmt.Errorf("failed to clear lots for %s: %w", symbol, err)
+			}
+		}
+	}
+
+	for _, lot := range lots {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO cost_basis_lots (id, account_id, symbol, quantity, total_cost, acquired_date, notes, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, lot.ID, lot.AccountID, lot.Symbol, lot.Quantity, lot.TotalCost, lot.AcquiredDate, lot.Notes, lot.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import lot for %s: %w", lot.Symbol, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	positions, err := s.GetOpeningPositions(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ImportCostBasisResponse{Imported: len(lots), Positions: make([]*OpeningPosition, 0)}
+	for _, position := range positions {
+		if !symbols[position.Symbol] {
+			continue
+		}
+		if err := s.applyOpeningPosition(ctx, accountID, position); err != nil {
+			return nil, err
+		}
+		response.Positions = append(response.Positions, position)
+	}
+
+	return response, nil
+}
+
+// newCostBasisLot validates an imported lot
+func newCostBasisLot(accountID string, input *CostBasisLotInput) (*CostBasisLot, error) {
+	symbol := strings.ToUpper(strings.TrimSpace(input.Symbol))
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	if input.Quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be positive")
+	}
+
+	lot := &CostBasisLot{
+		ID:        uuid.New().String(),
+		AccountID: accountID,
+		Symbol:    symbol,
+		Quantity:  input.Quantity,
+		CreatedAt: time.Now(),
+	}
+	switch {
+	case input.TotalCost != nil:
+		lot.TotalCost = *input.TotalCost
+	case input.CostPerShare != nil:
+		lot.TotalCost = math.Round(*input.CostPerShare*input.Quantity*100) / 100
+	default:
+		return nil, fmt.Errorf("total cost or cost per share is required")
+	}
+	if lot.TotalCost < 0 {
+		return nil, fmt.Errorf("cost must not be negative")
+	}
+	lot.CostPerShare = lot.TotalCost / lot.Quantity
+
+	if input.AcquiredDate != nil && *input.AcquiredDate != "" {
+		date, err := time.Parse("2006-01-02", *input.AcquiredDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid acquired date: %w", err)
+		}
+		lot.AcquiredDate = &date
+	}
+	if input.Notes != "" {
+		lot.Notes = &input.Notes
+	}
+
+	return lot, nil
+}
+
+// applyOpeningPosition sets the symbol's holding to the opening position's average cost,
+// creating the holding when the account doesn't have one yet
+func (s *Service) applyOpeningPosition(ctx context.Context, accountID string, position *OpeningPosition) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE holdings SET cost_basis = $1, updated_at = $2
+		WHERE account_id = $3 AND UPPER(symbol) = $4
+	`, position.CostPerShare, time.Now(), accountID, position.Symbol)
+	if err != nil {
+		return fmt.Errorf("failed to update holding cost basis: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows > 0 {
+		return err
+	}
+
+	symbol, quantity, costBasis := position.Symbol, position.Quantity, position.CostPerShare
+	_, err = s.Create(ctx, &CreateHoldingRequest{
+		AccountID: accountID,
+		Type:      HoldingTypeStock,
+		Symbol:    &symbol,
+		Quantity:  &quantity,
+		CostBasis: &costBasis,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create holding for %s: %w", symbol, err)
+	}
+	return nil
+}
+
+// GetCostBasis lists an account's imported lots and the opening positions they form
+func (s *Service) GetCostBasis(ctx context.Context, accountID string) (*CostBasisResponse, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, account_id, symbol, quantity, total_cost, acquired_date, notes, created_at
+		FROM cost_basis_lots
+		WHERE account_id = $1
+		ORDER BY symbol, acquired_date
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cost basis lots: %w", err)
+	}
+	defer rows.Close()
+
+	lots := make([]*CostBasisLot, 0)
+	for rows.Next() {
+		lot := &CostBasisLot{}
+		if err := rows.Scan(&lot.ID, &lot.AccountID, &lot.Symbol, &lot.Quantity, &lot.TotalCost,
+			&lot.AcquiredDate, &lot.Notes, &lot.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan cost basis lot: %w", err)
+		}
+		lot.CostPerShare = lot.TotalCost / lot.Quantity
+		lots = append(lots, lot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &CostBasisResponse{Lots: lots, Positions: openingPositions(lots)}, nil
+}
+
+// GetOpeningPositions returns the opening position for each symbol with imported lots
+func (s *Service) GetOpeningPositions(ctx context.Context, accountID string) ([]*OpeningPosition, error) {
+	resp, err := s.GetCostBasis(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Positions, nil
+}
+
+// openingPositions totals lots per symbol into an average-cost position
+func openingPositions(lots []*CostBasisLot) []*OpeningPosition {
+	bySymbol := make(map[string]*OpeningPosition)
+	for _, lot := range lots {
+		position, ok := bySymbol[lot.Symbol]
+		if !ok {
+			position = &OpeningPosition{Symbol: lot.Symbol}
+			bySymbol[lot.Symbol] = position
+		}
+		position.Quantity += lot.Quantity
+		position.TotalCost += lot.TotalCost
+		position.Lots++
+	}
+
+	positions := make([]*OpeningPosition, 0, len(bySymbol))
+	for _, position := range bySymbol {
+		position.TotalCost = math.Round(position.TotalCost*100) / 100
+		position.CostPerShare = math.Round(position.TotalCost/position.Quantity*10000) / 10000
+		positions = append(positions, position)
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i].Symbol < positions[j].Symbol })
+	return positions
+}
@@ -0,0 +1,455 @@
+package holdings
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+)
+
+// JournalEntryKind is what a holding journal entry records
+type JournalEntryKind string
+
+const (
+	JournalEntryThesis JournalEntryKind = "thesis" // Why the position was opened
+	JournalEntryNote   JournalEntryKind = "note"
+	JournalEntryReview JournalEntryKind = "review" // A later look back at the thesis
+)
+
+// Conviction ratings run from 1 (low) to 5 (high)
+const (
+	minConviction = 1
+	maxConviction = 5
+)
+
+// JournalEntry is a dated note on a holding, kept so investment decisions can be reviewed
+// against how the position went afterwards
+type JournalEntry struct {
+	ID           string           `json:"id"`
+	HoldingID    string           `json:"holding_id"`
+	EntryDate    time.Time        `json:"entry_date"`
+	Kind         JournalEntryKind `json:"kind"`
+	Body         string           `json:"body"`
+	Conviction   *int             `json:"conviction,omitempty"`
+	Tags         []string         `json:"tags"`
+	PriceAtEntry *float64         `json:"price_at_entry,omitempty"` // Latest quote when the entry was written
+	ReviewOn     *time.Time       `json:"review_on,omitempty"`
+	ReviewedAt   *time.Time       `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+
+	// ChangeSinceEntry is the percent move from PriceAtEntry to the latest quote
+	ChangeSinceEntry *float64 `json:"change_since_entry,omitempty"`
+}
+
+// CreateJournalEntryRequest represents the request to add a journal entry to a holding
+type CreateJournalEntryRequest struct {
+	EntryDate  *string          `json:"entry_date,omitempty"` // Defaults to today
+	Kind       JournalEntryKind `json:"kind,omitempty"`       // Defaults to note
+	Body       string           `json:"body"`
+	Conviction *int             `json:"conviction,omitempty"`
+	Tags       []string         `json:"tags,omitempty"`
+	ReviewOn   *string          `json:"review_on,omitempty"`
+}
+
+// UpdateJournalEntryRequest represents the request to update a journal entry. Setting
+// Reviewed marks the entry's review reminder done.
+type UpdateJournalEntryRequest struct {
+	Body       *string   `json:"body,omitempty"`
+	Conviction *int      `json:"conviction,omitempty"`
+	Tags       *[]string `json:"tags,omitempty"`
+	ReviewOn   *string   `json:"review_on,omitempty"` // Empty clears the reminder
+	Reviewed   *bool     `json:"reviewed,omitempty"`
+}
+
+// HoldingPerformance is where a holding stands at its latest quote
+type HoldingPerformance struct {
+	Symbol         *string    `json:"symbol,omitempty"`
+	Quantity       *float64   `json:"quantity,omitempty"`
+	CostBasis      *float64   `json:"cost_basis,omitempty"`
+	CurrentPrice   *float64   `json:"current_price,omitempty"`
+	QuotedAt       *time.Time `json:"quoted_at,omitempty"`
+	MarketValue    *float64   `json:"market_value,omitempty"`
+	UnrealizedGain *float64   `json:"unrealized_gain,omitempty"`
+	ReturnPercent  *float64   `json:"return_percent,omitempty"`
+}
+
+// ListJournalEntriesResponse lists a holding's journal, newest first, with its performance
+type ListJournalEntriesResponse struct {
+	Performance *HoldingPerformance `json:"performance"`
+	Entries     []*JournalEntry     `json:"entries"`
+}
+
+// DueJournalReview is a journal entry whose review date has arrived
+type DueJournalReview struct {
+	Entry     *JournalEntry `json:"entry"`
+	AccountID string        `json:"account_id"`
+	Symbol    *string       `json:"symbol,omitempty"`
+}
+
+// ListDueJournalReviewsResponse represents the journal entries due for review
+type ListDueJournalReviewsResponse struct {
+	Reviews []*DueJournalReview `json:"reviews"`
+}
+
+// validateConviction checks a conviction rating is on the 1 to 5 scale
+func validateConviction(conviction *int) error {
+	if conviction != nil && (*conviction < minConviction || *conviction > maxConviction) {
+		return fmt.Errorf("conviction must be between %d and %d", minConviction, maxConviction)
+	}
+	return nil
+}
+
+// parseJournalDate parses a YYYY-MM-DD date
+func parseJournalDate(field, value string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %s", field, value)
+	}
+	return t, nil
+}
+
+// normalizeTags trims and lowercases tags, dropping blanks and duplicates
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// changePercent is the percent move from one price to another, nil when either is unknown
+func changePercent(from, to *float64) *float64 {
+	if from == nil || to == nil || *from <= 0 {
+		return nil
+	}
+	pct := math.Round((*to-*from) / *from * 10000) / 100
+	return &pct
+}
+
+// latestPrice returns the holding's latest quote, nil when it has no symbol or no quote
+func (s *Service) latestPrice(ctx context.Context, holdingID string) (*float64, *time.Time, error) {
+	var price float64
+	var quotedAt time.Time
+	err := s.db.QueryRowContext(ctx, `
+		SELECT q.price, q.last_updated
+		FROM holdings h
+		JOIN market_data q ON q.symbol = UPPER(h.symbol)
+		WHERE h.id = $1
+	`, holdingID).Scan(&price, &quotedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get latest quote: %w", err)
+	}
+	return &price, &quotedAt, nil
+}
+
+// CreateJournalEntry adds a dated entry to a holding's journal, recording the latest quote
+// so the entry can later be compared with how the position moved
+func (s *Service) CreateJournalEntry(ctx context.Context, holdingID string, req *CreateJournalEntryRequest) (*JournalEntry, error) {
+	if err := s.verifyHoldingOwnership(ctx, holdingID); err != nil {
+		return nil, err
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return nil, fmt.Errorf("body is required")
+	}
+	kind := req.Kind
+	if kind == "" {
+		kind = JournalEntryNote
+	}
+	switch kind {
+	case JournalEntryThesis, JournalEntryNote, JournalEntryReview:
+	default:
+		return nil, fmt.Errorf("invalid journal entry kind: %s", kind)
+	}
+	if err := validateConviction(req.Conviction); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entry := &JournalEntry{
+		ID:         uuid.New().String(),
+		HoldingID:  holdingID,
+		EntryDate:  quoteDate(now),
+		Kind:       kind,
+		Body:       body,
+		Conviction: req.Conviction,
+		Tags:       normalizeTags(req.Tags),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if req.EntryDate != nil {
+		date, err := parseJournalDate("entry date", *req.EntryDate)
+		if err != nil {
+			return nil, err
+		}
+		entry.EntryDate = date
+	}
+	if req.ReviewOn != nil && *req.ReviewOn != "" {
+		date, err := parseJournalDate("review date", *req.ReviewOn)
+		if err != nil {
+			return nil, err
+		}
+		entry.ReviewOn = &date
+	}
+
+	price, _, err := s.latestPrice(ctx, holdingID)
+	if err != nil {
+		return nil, err
+	}
+	entry.PriceAtEntry = price
+
+	tagsJSON, _ := json.Marshal(entry.Tags)
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO holding_journal_entries (
+			id, holding_id, entry_date, kind, body, conviction, tags, price_at_entry, review_on, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, entry.ID, entry.HoldingID, entry.EntryDate, entry.Kind, entry.Body, entry.Conviction, string(tagsJSON),
+		entry.PriceAtEntry, entry.ReviewOn, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create journal entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// ListJournalEntries lists a holding's journal newest first, with each entry's move since it
+// was written and the holding's current performance
+func (s *Service) ListJournalEntries(ctx context.Context, holdingID string) (*ListJournalEntriesResponse, error) {
+	if err := s.verifyHoldingOwnership(ctx, holdingID); err != nil {
+		return nil, err
+	}
+
+	perf := &HoldingPerformance{}
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT symbol, quantity, cost_basis FROM holdings WHERE id = $1
+	`, holdingID).Scan(&perf.Symbol, &perf.Quantity, &perf.CostBasis); err != nil {
+		return nil, fmt.Errorf("failed to get holding: %w", err)
+	}
+	price, quotedAt, err := s.latestPrice(ctx, holdingID)
+	if err != nil {
+		return nil, err
+	}
+	perf.CurrentPrice, perf.QuotedAt = price, quotedAt
+	if price != nil && perf.Quantity != nil {
+		value := math.Round(*perf.Quantity**price*100) / 100
+		perf.MarketValue = &value
+		// Cost basis is per share, so the position cost is scaled by quantity
+		if perf.CostBasis != nil && *perf.CostBasis > 0 {
+			cost := *perf.Quantity * *perf.CostBasis
+			gain := math.Round((value-cost)*100) / 100
+			perf.UnrealizedGain = &gain
+			perf.ReturnPercent = changePercent(&cost, &value)
+		}
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, holding_id, entry_date, kind, body, conviction, tags, price_at_entry, review_on, reviewed_at,
+		       created_at, updated_at
+		FROM holding_journal_entries
+		WHERE holding_id = $1
+		ORDER BY entry_date DESC, created_at DESC
+	`, holdingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journal entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]*JournalEntry, 0)
+	for rows.Next() {
+		entry, err := scanJournalEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan journal entry: %w", err)
+		}
+		entry.ChangeSinceEntry = changePercent(entry.PriceAtEntry, price)
+		entries = append(entries, entry)
+	}
+
+	return &ListJournalEntriesResponse{Performance: perf, Entries: entries}, rows.Err()
+}
+
+// getJournalEntry retrieves a single journal entry belonging to the holding
+func (s *Service) getJournalEntry(ctx context.Context, holdingID, entryID string) (*JournalEntry, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, holding_id, entry_date, kind, body, conviction, tags, price_at_entry, review_on, reviewed_at,
+		       created_at, updated_at
+		FROM holding_journal_entries
+		WHERE id = $1 AND holding_id = $2
+	`, entryID, holdingID)
+
+	entry, err := scanJournalEntry(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("journal entry not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get journal entry: %w", err)
+	}
+	return entry, nil
+}
+
+// UpdateJournalEntry edits a journal entry, reschedules its review or marks it reviewed.
+// The entry date, kind and price at entry stay as written.
+func (s *Service) UpdateJournalEntry(ctx context.Context, holdingID, entryID string, req *UpdateJournalEntryRequest) (*JournalEntry, error) {
+	if err := s.verifyHoldingOwnership(ctx, holdingID); err != nil {
+		return nil, err
+	}
+
+	entry, err := s.getJournalEntry(ctx, holdingID, entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if req.Body != nil {
+		body := strings.TrimSpace(*req.Body)
+		if body == "" {
+			return nil, fmt.Errorf("body is required")
+		}
+		entry.Body = body
+	}
+	if req.Conviction != nil {
+		if err := validateConviction(req.Conviction); err != nil {
+			return nil, err
+		}
+		entry.Conviction = req.Conviction
+	}
+	if req.Tags != nil {
+		entry.Tags = normalizeTags(*req.Tags)
+	}
+	if req.ReviewOn != nil {
+		entry.ReviewOn = nil
+		if *req.ReviewOn != "" {
+			date, err := parseJournalDate("review date", *req.ReviewOn)
+			if err != nil {
+				return nil, err
+			}
+			entry.ReviewOn = &date
+		}
+		// A new review date starts a new reminder
+		entry.ReviewedAt = nil
+	}
+	if req.Reviewed != nil {
+		entry.ReviewedAt = nil
+		if *req.Reviewed {
+			entry.ReviewedAt = &now
+		}
+	}
+	entry.UpdatedAt = now
+
+	tagsJSON, _ := json.Marshal(entry.Tags)
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE holding_journal_entries
+		SET body = $1, conviction = $2, tags = $3, review_on = $4, reviewed_at = $5, updated_at = $6
+		WHERE id = $7
+	`, entry.Body, entry.Conviction, string(tagsJSON), entry.ReviewOn, entry.ReviewedAt, entry.UpdatedAt, entryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update journal entry: %w", err)
+	}
+
+	price, _, err := s.latestPrice(ctx, holdingID)
+	if err != nil {
+		return nil, err
+	}
+	entry.ChangeSinceEntry = changePercent(entry.PriceAtEntry, price)
+	return entry, nil
+}
+
+// DeleteJournalEntry removes a journal entry
+func (s *Service) DeleteJournalEntry(ctx context.Context, holdingID, entryID string) error {
+	if err := s.verifyHoldingOwnership(ctx, holdingID); err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM holding_journal_entries WHERE id = $1 AND holding_id = $2
+	`, entryID, holdingID)
+	if err != nil {
+		return fmt.Errorf("failed to delete journal entry: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("journal entry not found")
+	}
+
+	return nil
+}
+
+// GetDueJournalReviews returns the user's journal entries whose review date is on or before
+// asOf and that haven't been reviewed, with each entry's move since it was written
+func (s *Service) GetDueJournalReviews(ctx context.Context, asOf time.Time) (*ListDueJournalReviewsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT j.id, j.holding_id, j.entry_date, j.kind, j.body, j.conviction, j.tags, j.price_at_entry, j.review_on,
+		       j.reviewed_at, j.created_at, j.updated_at,
+		       h.account_id, h.symbol, q.price
+		FROM holding_journal_entries j
+		JOIN holdings h ON h.id = j.holding_id
+		JOIN accounts a ON a.id = h.account_id
+		LEFT JOIN market_data q ON q.symbol = UPPER(h.symbol)
+		WHERE a.user_id = $1 AND j.review_on IS NOT NULL AND j.review_on <= $2 AND j.reviewed_at IS NULL
+		ORDER BY j.review_on
+	`, userID, quoteDate(asOf))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due journal reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := make([]*DueJournalReview, 0)
+	for rows.Next() {
+		entry := &JournalEntry{}
+		review := &DueJournalReview{Entry: entry}
+		var tagsJSON string
+		var price *float64
+		if err := rows.Scan(&entry.ID, &entry.HoldingID, &entry.EntryDate, &entry.Kind, &entry.Body, &entry.Conviction,
+			&tagsJSON, &entry.PriceAtEntry, &entry.ReviewOn, &entry.ReviewedAt, &entry.CreatedAt, &entry.UpdatedAt,
+			&review.AccountID, &review.Symbol, &price); err != nil {
+			return nil, fmt.Errorf("failed to scan journal entry: %w", err)
+		}
+		if err := json.Unmarshal([]byte(tagsJSON), &entry.Tags); err != nil {
+			return nil, fmt.Errorf("failed to decode tags: %w", err)
+		}
+		entry.ChangeSinceEntry = changePercent(entry.PriceAtEntry, price)
+		reviews = append(reviews, review)
+	}
+
+	return &ListDueJournalReviewsResponse{Reviews: reviews}, rows.Err()
+}
+
+// scanJournalEntry scans a journal entry row
+func scanJournalEntry(row interface{ Scan(...interface{}) error }) (*JournalEntry, error) {
+	entry := &JournalEntry{}
+	var tagsJSON string
+	err := row.Scan(&entry.ID, &entry.HoldingID, &entry.EntryDate, &entry.Kind, &entry.Body, &entry.Conviction,
+		&tagsJSON, &entry.PriceAtEntry, &entry.ReviewOn, &entry.ReviewedAt, &entry.CreatedAt, &entry.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(tagsJSON), &entry.Tags); err != nil {
+		return nil, fmt.Errorf("failed to decode tags: %w", err)
+	}
+	return entry, nil
+}
@@ -0,0 +1,213 @@
+package holdings
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+	"money/internal/civil"
+)
+
+// positionTolerance is the share difference below which a position counts as reconciled,
+// absorbing rounding in fractional shares
+const positionTolerance = 0.0001
+
+// PositionDiscrepancy is a holding whose quantity doesn't match its recorded transactions
+type PositionDiscrepancy struct {
+	HoldingID        string  `json:"holding_id"`
+	AccountID        string  `json:"account_id"`
+	Symbol           string  `json:"symbol"`
+	HeldQuantity     float64 `json:"held_quantity"`     // Quantity on the holding, as last synced
+	RecordedQuantity float64 `json:"recorded_quantity"` // Net of the recorded transactions
+	Difference       float64 `json:"difference"`        // Held - recorded; positive means trades are missing
+	Transactions     int     `json:"transactions"`
+}
+
+// PositionReconciliation compares an account's positions with their recorded transactions.
+// Holdings without any recorded transactions have nothing to reconcile against and are only
+// counted.
+type PositionReconciliation struct {
+	AccountID     string                 `json:"account_id"`
+	Synced        bool                   `json:"synced"` // The account's holdings come from a provider sync
+	CheckedAt     time.Time              `json:"checked_at"`
+	Reconciled    int                    `json:"reconciled"`
+	Untracked     int                    `json:"untracked"` // Holdings with no recorded transactions
+	Discrepancies []*PositionDiscrepancy `json:"discrepancies"`
+}
+
+// AdjustPositionsRequest records adjustments for discrepancies. An empty list adjusts every
+// discrepancy in the account.
+type AdjustPositionsRequest struct {
+	HoldingIDs []string `json:"holding_ids,omitempty"`
+}
+
+// AdjustPositionsResponse reports the adjustments recorded and the reconciliation after them
+type AdjustPositionsResponse struct {
+	Adjusted       int                     `json:"adjusted"`
+	Reconciliation *PositionReconciliation `json:"reconciliation"`
+}
+
+// positionQuantitySQL nets a holding's recorded transactions into a share count. Buys and
+// splits add shares, sells remove them, and transfers carry their own sign so a transfer out
+// or a reconciliation adjustment can reduce the position.
+const positionQuantitySQL = `
+	COALESCE(SUM(CASE ht.type
+		WHEN 'buy' THEN ABS(ht.quantity)
+		WHEN 'split' THEN ht.quantity
+		WHEN 'sell' THEN -ABS(ht.quantity)
+		WHEN 'transfer' THEN ht.quantity
+		ELSE 0
+	END), 0)`
+
+// ReconcilePositions compares each security holding's quantity in an account with the net
+// of its recorded buys, sells, splits and transfers
+func (s *Service) ReconcilePositions(ctx context.Context, accountID string) (*PositionReconciliation, error) {
+	if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	resp := &PositionReconciliation{
+		AccountID:     accountID,
+		CheckedAt:     time.Now(),
+		Discrepancies: make([]*PositionDiscrepancy, 0),
+	}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM synced_accounts WHERE local_account_id = $1)
+	`, accountID).Scan(&resp.Synced)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check account sync: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT h.id, h.symbol, COALESCE(h.quantity, 0), `+positionQuantitySQL+`,
+		       COUNT(ht.id)
+		FROM holdings h
+		LEFT JOIN holding_transactions ht
+		  ON ht.holding_id = h.id AND ht.type IN ('buy', 'sell', 'split', 'transfer')
+		WHERE h.account_id = $1 AND h.type != $2
+		GROUP BY h.id, h.symbol, h.quantity
+		ORDER BY h.symbol
+	`, accountID, HoldingTypeCash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile positions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		d := &PositionDiscrepancy{AccountID: accountID}
+		if err := rows.Scan(&d.HoldingID, &d.Symbol, &d.HeldQuantity, &d.RecordedQuantity, &d.Transactions); err != nil {
+			return nil, fmt.Errorf("failed to scan position: %w", err)
+		}
+		switch {
+		case d.Transactions == 0:
+			resp.Untracked++
+		case math.Abs(d.HeldQuantity-d.RecordedQuantity) < positionTolerance:
+			resp.Reconciled++
+		default:
+			d.Difference = math.Round((d.HeldQuantity-d.RecordedQuantity)*1e8) / 1e8
+			resp.Discrepancies = append(resp.Discrepancies, d)
+		}
+	}
+	return resp, rows.Err()
+}
+
+// ReconcileSyncedPositions reconciles the positions of every synced account the user has
+func (s *Service) ReconcileSyncedPositions(ctx context.Context) ([]*PositionReconciliation, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT a.id FROM accounts a
+		JOIN synced_accounts sa ON sa.local_account_id = a.id
+		WHERE a.user_id = $1
+		ORDER BY a.id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list synced accounts: %w", err)
+	}
+	var accountIDs []string
+	for rows.Next() {
+		var accountID string
+		if err := rows.Scan(&accountID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan synced account: %w", err)
+		}
+		accountIDs = append(accountIDs, accountID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read synced accounts: %w", err)
+	}
+
+	reconciliations := make([]*PositionReconciliation, 0, len(accountIDs))
+	for _, accountID := range accountIDs {
+		reconciliation, err := s.ReconcilePositions(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+		reconciliations = append(reconciliations, reconciliation)
+	}
+	return reconciliations, nil
+}
+
+// AdjustPositions records a transfer for each selected discrepancy so the recorded
+// transactions net to the held quantity. The holding itself is left as synced.
+func (s *Service) AdjustPositions(ctx context.Context, accountID string, req *AdjustPositionsRequest) (*AdjustPositionsResponse, error) {
+	before, err := s.ReconcilePositions(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool, len(req.HoldingIDs))
+	for _, id := range req.HoldingIDs {
+		selected[id] = true
+	}
+	discrepancies := make(map[string]*PositionDiscrepancy, len(before.Discrepancies))
+	for _, d := range before.Discrepancies {
+		discrepancies[d.HoldingID] = d
+	}
+	for id := range selected {
+		if discrepancies[id] == nil {
+			return nil, fmt.Errorf("holding %s has no discrepancy to adjust", id)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	adjusted := 0
+	now := time.Now()
+	today := civil.TodayIn(ctx)
+	for _, d := range before.Discrepancies {
+		if len(selected) > 0 && !selected[d.HoldingID] {
+			continue
+		}
+		notes := fmt.Sprintf("Reconciliation adjustment: held %g, recorded %g", d.HeldQuantity, d.RecordedQuantity)
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO holding_transactions (id, holding_id, type, quantity, transaction_date, notes, created_at)
+			VALUES ($1, $2, 'transfer', $3, $4, $5, $6)
+		`, uuid.New().String(), d.HoldingID, d.Difference, today, notes, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record adjustment for %s: %w", d.Symbol, err)
+		}
+		adjusted++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	after, err := s.ReconcilePositions(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return &AdjustPositionsResponse{Adjusted: adjusted, Reconciliation: after}, nil
+}
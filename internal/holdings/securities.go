@@ -0,0 +1,271 @@
+package holdings
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/database"
+)
+
+// Where a security's metadata last came from. Manual edits are kept over sync and quotes.
+const (
+	SecuritySourceSync   = "sync"
+	SecuritySourceQuote  = "quote"
+	SecuritySourceManual = "manual"
+)
+
+// Security is the descriptive metadata of a symbol, shared by every holding of it
+type Security struct {
+	Symbol     string      `json:"symbol"`
+	Name       *string     `json:"name,omitempty"`
+	Exchange   *string     `json:"exchange,omitempty"`
+	Currency   *Currency   `json:"currency,omitempty"`
+	AssetClass *AssetClass `json:"asset_class,omitempty"` // Overrides the holding type's class when set
+	Sector     *string     `json:"sector,omitempty"`
+	Source     *string     `json:"source,omitempty"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// SecurityUpdate is the metadata a provider or the user knows about a symbol. Empty fields
+// leave what is stored alone.
+type SecurityUpdate struct {
+	Name       *string     `json:"name,omitempty"`
+	Exchange   *string     `json:"exchange,omitempty"`
+	Currency   *Currency   `json:"currency,omitempty"`
+	AssetClass *AssetClass `json:"asset_class,omitempty"`
+	Sector     *string     `json:"sector,omitempty"`
+}
+
+// ListSecuritiesResponse represents the securities of the symbols the user holds
+type ListSecuritiesResponse struct {
+	Securities []*Security `json:"securities"`
+}
+
+// validate trims the update's text fields and checks its currency and asset class
+func (u *SecurityUpdate) validate() error {
+	for _, field := range []**string{&u.Name, &u.Exchange, &u.Sector} {
+		if *field == nil {
+			continue
+		}
+		trimmed := strings.TrimSpace(**field)
+		if trimmed == "" {
+			*field = nil
+			continue
+		}
+		*field = &trimmed
+	}
+	if u.Currency != nil {
+		switch *u.Currency {
+		case CurrencyCAD, CurrencyUSD, CurrencyINR:
+		default:
+			return fmt.Errorf("invalid currency: %s", *u.Currency)
+		}
+	}
+	if u.AssetClass != nil {
+		switch *u.AssetClass {
+		case AssetClassEquity, AssetClassFixedIncome, AssetClassCash, AssetClassCrypto, AssetClassOther:
+		default:
+			return fmt.Errorf("invalid asset class: %s", *u.AssetClass)
+		}
+	}
+	return nil
+}
+
+// UpsertSecurityTx merges provider metadata into a symbol's security. Fields the user edited
+// by hand are only filled in when still empty, so a sync never overwrites a correction.
+func (s *Service) UpsertSecurityTx(ctx context.Context, db database.Querier, symbol string, update *SecurityUpdate, source string) error {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return fmt.Errorf("symbol is required")
+	}
+	if err := update.validate(); err != nil {
+		return err
+	}
+	if update.Name == nil && update.Exchange == nil && update.Currency == nil && update.AssetClass == nil && update.Sector == nil {
+		return nil
+	}
+
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO securities (symbol, name, exchange, currency, asset_class, sector, source, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (symbol) DO UPDATE SET
+			name = CASE WHEN securities.source = 'manual' THEN COALESCE(securities.name, excluded.name)
+				ELSE COALESCE(excluded.name, securities.name) END,
+			exchange = CASE WHEN securities.source = 'manual' THEN COALESCE(securities.exchange, excluded.exchange)
+				ELSE COALESCE(excluded.exchange, securities.exchange) END,
+			currency = CASE WHEN securities.source = 'manual' THEN COALESCE(securities.currency, excluded.currency)
+				ELSE COALESCE(excluded.currency, securities.currency) END,
+			asset_class = CASE WHEN securities.source = 'manual' THEN COALESCE(securities.asset_class, excluded.asset_class)
+				ELSE COALESCE(excluded.asset_class, securities.asset_class) END,
+			sector = CASE WHEN securities.source = 'manual' THEN COALESCE(securities.sector, excluded.sector)
+				ELSE COALESCE(excluded.sector, securities.sector) END,
+			source = CASE WHEN securities.source = 'manual' THEN securities.source ELSE excluded.source END,
+			updated_at = excluded.updated_at
+	`, symbol, update.Name, update.Exchange, update.Currency, update.AssetClass, update.Sector, source, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save security: %w", err)
+	}
+	return nil
+}
+
+// UpdateSecurity applies the user's own corrections to a symbol's metadata. Edited fields
+// are kept over whatever sync or quote refreshes report afterwards.
+func (s *Service) UpdateSecurity(ctx context.Context, symbol string, update *SecurityUpdate) (*Security, error) {
+	if auth.GetUserID(ctx) == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if err := update.validate(); err != nil {
+		return nil, err
+	}
+
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+
+	// Securities are shared, so only a holder of the symbol may correct it
+	var held bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM holdings h
+			JOIN accounts a ON a.id = h.account_id
+			WHERE a.user_id = $1 AND UPPER(h.symbol) = $2
+		)
+	`, auth.GetUserID(ctx), symbol).Scan(&held)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify holding: %w", err)
+	}
+	if !held {
+		return nil, fmt.Errorf("security not found")
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO securities (symbol, name, exchange, currency, asset_class, sector, source, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (symbol) DO UPDATE SET
+			name = COALESCE(excluded.name, securities.name),
+			exchange = COALESCE(excluded.exchange, securities.exchange),
+			currency = COALESCE(excluded.currency, securities.currency),
+			asset_class = COALESCE(excluded.asset_class, securities.asset_class),
+			sector = COALESCE(excluded.sector, securities.sector),
+			source = excluded.source,
+			updated_at = excluded.updated_at
+	`, symbol, update.Name, update.Exchange, update.Currency, update.AssetClass, update.Sector,
+		SecuritySourceManual, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to update security: %w", err)
+	}
+
+	return s.GetSecurity(ctx, symbol)
+}
+
+// GetSecurity returns a symbol's metadata
+func (s *Service) GetSecurity(ctx context.Context, symbol string) (*Security, error) {
+	if auth.GetUserID(ctx) == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	security, err := scanSecurity(s.db.QueryRowContext(ctx, `
+		SELECT symbol, name, exchange, currency, asset_class, sector, source, updated_at
+		FROM securities
+		WHERE symbol = $1
+	`, strings.ToUpper(strings.TrimSpace(symbol))))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("security not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get security: %w", err)
+	}
+	return security, nil
+}
+
+// ListSecurities returns the metadata of the symbols held in the user's accounts
+func (s *Service) ListSecurities(ctx context.Context) (*ListSecuritiesResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT sec.symbol, sec.name, sec.exchange, sec.currency, sec.asset_class, sec.sector, sec.source, sec.updated_at
+		FROM securities sec
+		WHERE sec.symbol IN (
+			SELECT UPPER(h.symbol) FROM holdings h
+			JOIN accounts a ON a.id = h.account_id
+			WHERE a.user_id = $1 AND h.symbol IS NOT NULL
+		)
+		ORDER BY sec.symbol
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list securities: %w", err)
+	}
+	defer rows.Close()
+
+	securities := make([]*Security, 0)
+	for rows.Next() {
+		security, err := scanSecurity(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan security: %w", err)
+		}
+		securities = append(securities, security)
+	}
+
+	return &ListSecuritiesResponse{Securities: securities}, rows.Err()
+}
+
+// attachSecurities links each of an account's security holdings to its symbol's security
+func (s *Service) attachSecurities(ctx context.Context, holdings []*Holding) error {
+	bySymbol := make(map[string][]*Holding)
+	for _, holding := range holdings {
+		if holding.Symbol != nil {
+			symbol := strings.ToUpper(*holding.Symbol)
+			bySymbol[symbol] = append(bySymbol[symbol], holding)
+		}
+	}
+	if len(bySymbol) == 0 {
+		return nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT symbol, name, exchange, currency, asset_class, sector, source, updated_at
+		FROM securities
+		WHERE symbol IN (
+			SELECT UPPER(h.symbol) FROM holdings h WHERE h.account_id = $1 AND h.symbol IS NOT NULL
+		)
+	`, holdings[0].AccountID)
+	if err != nil {
+		return fmt.Errorf("failed to get securities: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		security, err := scanSecurity(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan security: %w", err)
+		}
+		for _, holding := range bySymbol[security.Symbol] {
+			holding.Security = security
+		}
+	}
+	return rows.Err()
+}
+
+// scanSecurity reads a securities row
+func scanSecurity(row database.Scanner) (*Security, error) {
+	security := &Security{}
+	err := row.Scan(&security.Symbol, &security.Name, &security.Exchange, &security.Currency,
+		&security.AssetClass, &security.Sector, &security.Source, &security.UpdatedAt)
+	return security, err
+}
+
+// HoldingAssetClass is the class a holding counts towards: its security's when set, else its type's
+func HoldingAssetClass(holdingType HoldingType, securityClass *AssetClass) AssetClass {
+	if securityClass != nil && *securityClass != "" {
+		return *securityClass
+	}
+	return AssetClassOf(holdingType)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"money/internal/database"
 )
 
 // Service provides holdings management functionality
@@ -62,6 +63,9 @@ type Holding struct {
 	Notes        *string    `json:"notes,omitempty"`
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Security is the symbol's entry in the security master, when there is one
+	Security *Security `json:"security,omitempty"`
 }
 
 // CreateHoldingRequest represents the request to create a new holding
@@ -109,6 +113,11 @@ type DeleteHoldingResponse struct {
 
 // Create creates a new holding
 func (s *Service) Create(ctx context.Context, req *CreateHoldingRequest) (*CreateHoldingResponse, error) {
+	return s.CreateTx(ctx, s.db, req)
+}
+
+// CreateTx creates or updates a holding within the caller's transaction
+func (s *Service) CreateTx(ctx context.Context, db database.Querier, req *CreateHoldingRequest) (*CreateHoldingResponse, error) {
 	// TODO: Verify user owns the account
 
 	var notes *string
@@ -148,7 +157,7 @@ func (s *Service) Create(ctx context.Context, req *CreateHoldingRequest) (*Creat
 	var existingID string
 	wasUpdate := false
 	if req.Symbol != nil {
-		existingErr := s.db.QueryRowContext(ctx, `
+		existingErr := db.QueryRowContext(ctx, `
 			SELECT id FROM holdings WHERE account_id = $1 AND symbol = $2
 		`, req.AccountID, req.Symbol).Scan(&existingID)
 		if existingErr == nil {
@@ -157,7 +166,7 @@ func (s *Service) Create(ctx context.Context, req *CreateHoldingRequest) (*Creat
 		}
 	}
 
-	_, err := s.db.ExecContext(ctx, `
+	_, err := db.ExecContext(ctx, `
 		INSERT INTO holdings (
 			id, account_id, type, symbol, quantity, cost_basis,
 			currency, amount, purchase_date, notes, created_at, updated_at
@@ -165,11 +174,18 @@ func (s *Service) Create(ctx context.Context, req *CreateHoldingRequest) (*Creat
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		ON CONFLICT (account_id, symbol) DO UPDATE SET
 			quantity = excluded.quantity,
-			cost_basis = excluded.cost_basis,
-			notes = excluded.notes,
+			-- Imported cost basis lots take precedence over the cost reported by sync
+			cost_basis = CASE
+				WHEN EXISTS (
+					SELECT 1 FROM cost_basis_lots l
+					WHERE l.account_id = excluded.account_id AND l.symbol = UPPER(excluded.symbol)
+				) THEN holdings.cost_basis
+				ELSE excluded.cost_basis
+			END,
+			notes = COALESCE(excluded.notes, holdings.notes),
 			updated_at = excluded.updated_at
 	`, holding.ID, req.AccountID, req.Type, req.Symbol, req.Quantity, req.CostBasis,
-		holding.Currency, req.Amount, purchaseDate, req.Notes,
+		holding.Currency, req.Amount, purchaseDate, notes,
 		holding.CreatedAt, holding.UpdatedAt)
 
 	if err != nil {
@@ -221,6 +237,13 @@ func (s *Service) GetAccountHoldings(ctx context.Context, accountID string) (*Li
 		}
 		holdings = append(holdings, holding)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.attachSecurities(ctx, holdings); err != nil {
+		return nil, err
+	}
 
 	return &ListHoldingsResponse{Holdings: holdings}, nil
 }
@@ -299,9 +322,14 @@ func (s *Service) Update(ctx context.Context, id string, req *UpdateHoldingReque
 
 // Delete deletes a holding
 func (s *Service) Delete(ctx context.Context, id string) (*DeleteHoldingResponse, error) {
+	return s.DeleteTx(ctx, s.db, id)
+}
+
+// DeleteTx deletes a holding within the caller's transaction
+func (s *Service) DeleteTx(ctx context.Context, db database.Querier, id string) (*DeleteHoldingResponse, error) {
 	// TODO: Verify user owns the account associated with this holding
 
-	_, err := s.db.ExecContext(ctx, `
+	_, err := db.ExecContext(ctx, `
 		DELETE FROM holdings
 		WHERE id = $1
 	`, id)
@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -14,6 +16,8 @@ import (
 	"github.com/golang-migrate/migrate/v4/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "modernc.org/sqlite"
+
+	"money/internal/auth"
 )
 
 var (
@@ -123,6 +127,14 @@ func setupTestDB(t *testing.T) *sql.DB {
 
 func cleanupTestDB(t *testing.T, db *sql.DB) {
 	t.Helper()
+	_, _ = db.Exec("DELETE FROM holding_journal_entries WHERE holding_id IN (SELECT id FROM holdings WHERE account_id LIKE 'test-%')")
+	_, _ = db.Exec("DELETE FROM holding_price_alerts WHERE holding_id IN (SELECT id FROM holdings WHERE account_id LIKE 'test-%')")
+	_, _ = db.Exec("DELETE FROM market_data WHERE symbol LIKE 'TEST%'")
+	_, _ = db.Exec("DELETE FROM securities WHERE symbol LIKE 'TEST%'")
+	_, _ = db.Exec("DELETE FROM cost_basis_lots WHERE account_id LIKE 'test-%'")
+	_, _ = db.Exec("DELETE FROM holding_transactions WHERE holding_id IN (SELECT id FROM holdings WHERE account_id LIKE 'test-%')")
+	_, _ = db.Exec("DELETE FROM in_kind_transfer_legs WHERE transfer_id IN (SELECT id FROM in_kind_transfers WHERE user_id LIKE 'test-%')")
+	_, _ = db.Exec("DELETE FROM in_kind_transfers WHERE user_id LIKE 'test-%'")
 	_, _ = db.Exec("DELETE FROM holdings WHERE id LIKE 'test-%' OR account_id LIKE 'test-%'")
 	_, _ = db.Exec("DELETE FROM accounts WHERE id LIKE 'test-%' OR user_id LIKE 'test-%'")
 	_, _ = db.Exec("DELETE FROM users WHERE id LIKE 'test-%'")
@@ -452,3 +464,562 @@ func TestDelete_Success(t *testing.T) {
 		t.Error("Expected error when getting deleted holding")
 	}
 }
+
+func TestGetTriggeredPriceAlerts_EvaluatesLatestQuote(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-holdings-alerts"
+	createTestUser(t, db, userID)
+	accountID := createTestAccount(t, db, userID)
+	ctx := auth.WithUserID(context.Background(), userID)
+	service := NewService(db)
+
+	symbol := "TESTALRT"
+	quantity := 10.0
+	resp, err := service.Create(ctx, &CreateHoldingRequest{AccountID: accountID, Type: HoldingTypeStock, Symbol: &symbol, Quantity: &quantity})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	holdingID := resp.Holding.ID
+
+	above, err := service.CreatePriceAlert(ctx, holdingID, &CreatePriceAlertRequest{Condition: AlertConditionPriceAbove, Threshold: 150})
+	if err != nil {
+		t.Fatalf("CreatePriceAlert failed: %v", err)
+	}
+	if _, err := service.CreatePriceAlert(ctx, holdingID, &CreatePriceAlertRequest{Condition: AlertConditionPriceBelow, Threshold: 100}); err != nil {
+		t.Fatalf("CreatePriceAlert failed: %v", err)
+	}
+	move, err := service.CreatePriceAlert(ctx, holdingID, &CreatePriceAlertRequest{Condition: AlertConditionDailyMove, Threshold: 10})
+	if err != nil {
+		t.Fatalf("CreatePriceAlert failed: %v", err)
+	}
+
+	// Act
+	previousClose := 140.0
+	if _, err := service.RecordQuote(ctx, "testalrt", &RecordQuoteRequest{Price: 160, Currency: CurrencyUSD, PreviousClose: &previousClose}); err != nil {
+		t.Fatalf("RecordQuote failed: %v", err)
+	}
+	triggered, err := service.GetTriggeredPriceAlerts(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetTriggeredPriceAlerts failed: %v", err)
+	}
+	if len(triggered.Alerts) != 2 {
+		t.Fatalf("Expected 2 triggered alerts, got %d", len(triggered.Alerts))
+	}
+	ids := map[string]bool{triggered.Alerts[0].Alert.ID: true, triggered.Alerts[1].Alert.ID: true}
+	if !ids[above.ID] || !ids[move.ID] {
+		t.Errorf("Expected the price-above and daily-move alerts to trigger")
+	}
+	if change := triggered.Alerts[0].ChangePercent; change == nil || *change != 14.29 {
+		t.Errorf("Expected a 14.29%% move, got %v", change)
+	}
+}
+
+func TestSecurities_QuoteMetadataDrivesAllocation(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-holdings-securities"
+	createTestUser(t, db, userID)
+	accountID := createTestAccount(t, db, userID)
+	ctx := auth.WithUserID(context.Background(), userID)
+	service := NewService(db)
+
+	symbol := "testbnd"
+	quantity := 10.0
+	if _, err := service.Create(ctx, &CreateHoldingRequest{AccountID: accountID, Type: HoldingTypeETF, Symbol: &symbol, Quantity: &quantity}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// Act
+	name, exchange, fixedIncome := "Test Aggregate Bond ETF", "TSX", AssetClassFixedIncome
+	if _, err := service.RecordQuote(ctx, symbol, &RecordQuoteRequest{
+		Price: 25, Currency: CurrencyCAD, Name: &name, Exchange: &exchange, AssetClass: &fixedIncome,
+	}); err != nil {
+		t.Fatalf("RecordQuote failed: %v", err)
+	}
+	values, err := service.GetAssetClassValues(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetAssetClassValues failed: %v", err)
+	}
+	if values[AssetClassFixedIncome] != 250 || values[AssetClassEquity] != 0 {
+		t.Errorf("Expected the ETF to count as fixed income, got %v", values)
+	}
+
+	list, err := service.GetAccountHoldings(ctx, accountID)
+	if err != nil {
+		t.Fatalf("GetAccountHoldings failed: %v", err)
+	}
+	security := list.Holdings[0].Security
+	if security == nil || security.Name == nil || *security.Name != name || security.Currency == nil || *security.Currency != CurrencyCAD {
+		t.Fatalf("Expected the holding to link its security, got %+v", security)
+	}
+	if list.Holdings[0].Notes != nil {
+		t.Errorf("Expected the name to stay out of the holding's notes")
+	}
+
+	// A manual correction outlives later provider metadata
+	equity, sector := AssetClassEquity, "Fixed Income"
+	if _, err := service.UpdateSecurity(ctx, symbol, &SecurityUpdate{Sector: &sector}); err != nil {
+		t.Fatalf("UpdateSecurity failed: %v", err)
+	}
+	renamed := "Renamed By Provider"
+	if err := service.UpsertSecurityTx(ctx, db, symbol, &SecurityUpdate{Name: &renamed, AssetClass: &equity}, SecuritySourceSync); err != nil {
+		t.Fatalf("UpsertSecurityTx failed: %v", err)
+	}
+	security, err = service.GetSecurity(ctx, symbol)
+	if err != nil {
+		t.Fatalf("GetSecurity failed: %v", err)
+	}
+	if *security.Name != name || *security.AssetClass != AssetClassFixedIncome || *security.Sector != sector {
+		t.Errorf("Expected the manually edited security to keep its metadata, got %+v", security)
+	}
+}
+
+func TestImportCostBasis_SetsOpeningPositionKeptBySync(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-holdings-cost-basis"
+	createTestUser(t, db, userID)
+	accountID := createTestAccount(t, db, userID)
+	ctx := auth.WithUserID(context.Background(), userID)
+	service := NewService(db)
+
+	lots, err := ParseCostBasisCSV(strings.NewReader(
+		"Symbol,Quantity,Total_Cost,Acquired_Date\n" +
+			"testcb,10,1000,2019-03-01\n" +
+			"TESTCB,30,\"4,400\",2020-06-15\n"))
+	if err != nil {
+		t.Fatalf("ParseCostBasisCSV failed: %v", err)
+	}
+
+	// Act
+	resp, err := service.ImportCostBasis(ctx, accountID, &ImportCostBasisRequest{Lots: lots})
+	if err != nil {
+		t.Fatalf("ImportCostBasis failed: %v", err)
+	}
+
+	// Sync later reports the broker's average cost, which misses the transferred-in lots
+	symbol := "TESTCB"
+	quantity := 40.0
+	syncedCost := 150.0
+	synced, err := service.Create(ctx, &CreateHoldingRequest{AccountID: accountID, Type: HoldingTypeStock, Symbol: &symbol, Quantity: &quantity, CostBasis: &syncedCost})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	holding, err := service.Get(ctx, synced.Holding.ID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if resp.Imported != 2 || len(resp.Positions) != 1 {
+		t.Fatalf("Expected 2 lots in 1 position, got %d lots in %d positions", resp.Imported, len(resp.Positions))
+	}
+	position := resp.Positions[0]
+	if position.Quantity != 40 || position.TotalCost != 5400 || position.CostPerShare != 135 {
+		t.Errorf("Expected 40 shares costing 5400 (135/share), got %+v", position)
+	}
+	if !synced.WasUpdate {
+		t.Error("Expected sync to update the holding created by the import")
+	}
+	if holding.CostBasis == nil || *holding.CostBasis != 135 {
+		t.Errorf("Expected imported cost basis 135 to be kept, got %v", holding.CostBasis)
+	}
+}
+
+func TestComputeAllocationDrift_FlagsClassesOutsideBand(t *testing.T) {
+	values := map[AssetClass]float64{
+		AssetClassEquity:      7000,
+		AssetClassFixedIncome: 2000,
+		AssetClassCash:        1000,
+	}
+	targets := &AllocationTargets{
+		Targets: []*TargetAllocation{
+			{AssetClass: AssetClassEquity, TargetPercent: 60},
+			{AssetClass: AssetClassFixedIncome, TargetPercent: 40},
+		},
+		DriftBandPercent: 5,
+	}
+
+	drift := computeAllocationDrift(values, targets)
+
+	if !drift.OutOfBand {
+		t.Fatal("Expected the portfolio to be out of band")
+	}
+	if len(drift.Classes) != 3 {
+		t.Fatalf("Expected 3 asset classes, got %d", len(drift.Classes))
+	}
+	// Cash has no target, so it should be sold down entirely
+	expected := map[AssetClass]SuggestedTrade{
+		AssetClassCash:        {Action: "sell", Amount: 1000},
+		AssetClassEquity:      {Action: "sell", Amount: 1000},
+		AssetClassFixedIncome: {Action: "buy", Amount: 2000},
+	}
+	for _, trade := range drift.Trades {
+		want := expected[trade.AssetClass]
+		if trade.Action != want.Action || trade.Amount != want.Amount {
+			t.Errorf("Unexpected %s trade: %s %.2f", trade.AssetClass, trade.Action, trade.Amount)
+		}
+	}
+	if len(drift.Trades) != 3 {
+		t.Errorf("Expected 3 trades, got %d", len(drift.Trades))
+	}
+}
+
+func TestParseBrokerCSV_Questrade(t *testing.T) {
+	// Arrange
+	csv := "Transaction Date,Settlement Date,Action,Symbol,Description,Quantity,Price,Gross Amount,Commission,Net Amount,Currency,Account #,Activity Type,Account Type\n" +
+		"2024-01-02 12:00:00 AM,2024-01-04 12:00:00 AM,Buy,VFV.TO,VANGUARD S&P 500,10,100.00,-1000.00,-4.95,-1004.95,CAD,123,Trades,TFSA\n" +
+		"2024-02-15 12:00:00 AM,2024-02-15 12:00:00 AM,DIV,AAPL,APPLE INC CASH DIV,0,0,12.00,0,12.00,USD,123,Dividends,TFSA\n" +
+		"2024-03-01 12:00:00 AM,2024-03-01 12:00:00 AM,FCH,,ECN FEE,0,0,-1.50,0,-1.50,CAD,123,Fees and rebates,TFSA\n" +
+		"2024-03-05 12:00:00 AM,2024-03-05 12:00:00 AM,FXT,,CURRENCY CONVERSION,0,0,100.00,0,100.00,USD,123,Other,TFSA\n"
+
+	// Act
+	parsed, err := ParseBrokerCSV(BrokerQuestrade, strings.NewReader(csv))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ParseBrokerCSV failed: %v", err)
+	}
+	if len(parsed.Transactions) != 3 || len(parsed.Skipped) != 1 || parsed.Skipped[0].Line != 5 {
+		t.Fatalf("Expected 3 transactions and line 5 skipped, got %+v and %+v", parsed.Transactions, parsed.Skipped)
+	}
+	buy := parsed.Transactions[0]
+	if buy.Type != TransactionTypeBuy || buy.Symbol != "VFV.TO" || buy.Quantity != 10 || buy.Amount != 1004.95 || buy.Currency != "CAD" {
+		t.Errorf("Expected a buy of 10 VFV.TO costing 1004.95 CAD, got %+v", buy)
+	}
+	if div := parsed.Transactions[1]; div.Type != TransactionTypeDividend || div.Symbol != "AAPL" || div.Currency != "USD" {
+		t.Errorf("Expected a USD dividend from AAPL, got %+v", div)
+	}
+	if fee := parsed.Transactions[2]; fee.Type != TransactionTypeFee || fee.Symbol != "" || fee.Amount != 1.5 {
+		t.Errorf("Expected a 1.50 cash fee, got %+v", fee)
+	}
+}
+
+func TestParseBrokerCSV_IBKRActivityStatement(t *testing.T) {
+	// Arrange
+	csv := "Statement,Header,Field Name,Field Value\n" +
+		"Statement,Data,Period,\"January 1, 2024 - January 31, 2024\"\n" +
+		"Trades,Header,DataDiscriminator,Asset Category,Currency,Symbol,Date/Time,Quantity,T. Price,C. Price,Proceeds,Comm/Fee,Basis,Realized P/L,MTM P/L,Code\n" +
+		"Trades,Data,Order,Stocks,USD,AAPL,\"2024-01-10, 10:30:00\",5,185.00,186.00,-925.00,-1.00,926.00,0,5,O\n" +
+		"Trades,Data,Order,Stocks,USD,MSFT,\"2024-01-12, 11:00:00\",-2,390.00,391.00,780.00,-1.00,-700.00,79,2,C\n" +
+		"Trades,SubTotal,,Stocks,USD,AAPL,,5,,,-925.00,-1.00,926.00,0,5,\n" +
+		"Dividends,Header,Currency,Date,Description,Amount\n" +
+		"Dividends,Data,USD,2024-01-15,AAPL(US0378331005) Cash Dividend USD 0.24 per Share (Ordinary Dividend),1.20\n" +
+		"Dividends,Data,Total,,,1.20\n" +
+		"Deposits & Withdrawals,Header,Currency,Settle Date,Description,Amount\n" +
+		"Deposits & Withdrawals,Data,USD,2024-01-02,Electronic Fund Transfer,2000\n"
+
+	// Act
+	parsed, err := ParseBrokerCSV(BrokerIBKR, strings.NewReader(csv))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ParseBrokerCSV failed: %v", err)
+	}
+	if len(parsed.Transactions) != 4 || len(parsed.Skipped) != 0 {
+		t.Fatalf("Expected 4 transactions, got %+v and skipped %+v", parsed.Transactions, parsed.Skipped)
+	}
+	if buy := parsed.Transactions[0]; buy.Type != TransactionTypeBuy || buy.Amount != 926 || buy.Date.Format("2006-01-02") != "2024-01-10" {
+		t.Errorf("Expected a buy costing 926 with commission on 2024-01-10, got %+v", buy)
+	}
+	if sell := parsed.Transactions[1]; sell.Type != TransactionTypeSell || sell.Quantity != 2 || sell.Amount != 779 {
+		t.Errorf("Expected a sale of 2 shares netting 779, got %+v", sell)
+	}
+	if div := parsed.Transactions[2]; div.Type != TransactionTypeDividend || div.Symbol != "AAPL" || div.Amount != 1.2 {
+		t.Errorf("Expected a 1.20 AAPL dividend, got %+v", div)
+	}
+	if dep := parsed.Transactions[3]; dep.Type != TransactionTypeDeposit || dep.Amount != 2000 {
+		t.Errorf("Expected a 2000 deposit, got %+v", dep)
+	}
+}
+
+func TestImportBrokerTransactions_CreatesHoldingsAndSkipsReimports(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-holdings-broker-import"
+	createTestUser(t, db, userID)
+	accountID := createTestAccount(t, db, userID)
+	ctx := auth.WithUserID(context.Background(), userID)
+	service := NewService(db)
+
+	csv := "date,transaction,description,amount,balance,currency\n" +
+		"2024-01-02,CONT,Contribution (executed at 2024-01-02),5000.00,5000.00,CAD\n" +
+		"2024-01-03,BUY,\"TESTBI - Test Co.: Bought 10.0000 shares (executed at 2024-01-03)\",-1500.00,3500.00,CAD\n" +
+		"2024-01-03,BUY,\"TESTBI - Test Co.: Bought 10.0000 shares (executed at 2024-01-03)\",-1500.00,2000.00,CAD\n" +
+		"2024-02-01,SELL,\"TESTBI - Test Co.: Sold 5.0000 shares (executed at 2024-02-01)\",800.00,2800.00,CAD\n" +
+		"2024-02-10,LOAN,Stock lending,0.00,2800.00,CAD\n" +
+		"2024-02-15,DIV,TESTBI - Test Co.: Cash dividend distribution,10.00,2810.00,EUR\n"
+	parse := func() *ParsedBrokerCSV {
+		parsed, err := ParseBrokerCSV(BrokerWealthsimple, strings.NewReader(csv))
+		if err != nil {
+			t.Fatalf("ParseBrokerCSV failed: %v", err)
+		}
+		return parsed
+	}
+
+	// Act
+	first, err := service.ImportBrokerTransactions(ctx, accountID, BrokerWealthsimple, parse())
+	if err != nil {
+		t.Fatalf("ImportBrokerTransactions failed: %v", err)
+	}
+	second, err := service.ImportBrokerTransactions(ctx, accountID, BrokerWealthsimple, parse())
+	if err != nil {
+		t.Fatalf("Second ImportBrokerTransactions failed: %v", err)
+	}
+
+	// Assert
+	if first.Imported != 4 || first.Duplicates != 0 || len(first.HoldingsCreated) != 1 || first.HoldingsCreated[0] != "TESTBI" {
+		t.Errorf("Expected 4 rows imported and TESTBI created, got %+v", first)
+	}
+	// The stock lending row and the EUR dividend are skipped
+	if len(first.Skipped) != 2 {
+		t.Errorf("Expected 2 skipped rows, got %+v", first.Skipped)
+	}
+	if second.Imported != 0 || second.Duplicates != 4 {
+		t.Errorf("Expected the re-import to find 4 duplicates, got %+v", second)
+	}
+
+	holdings, err := service.GetAccountHoldings(ctx, accountID)
+	if err != nil {
+		t.Fatalf("GetAccountHoldings failed: %v", err)
+	}
+	var stock, cash *Holding
+	for _, h := range holdings.Holdings {
+		if h.Type == HoldingTypeCash {
+			cash = h
+		} else {
+			stock = h
+		}
+	}
+	if stock == nil || *stock.Quantity != 15 || stock.CostBasis == nil || *stock.CostBasis != 150 {
+		t.Errorf("Expected 15 TESTBI shares at a 150 average cost, got %+v", stock)
+	}
+	if cash == nil || cash.Currency == nil || *cash.Currency != CurrencyCAD {
+		t.Errorf("Expected a CAD cash holding for the contribution, got %+v", cash)
+	}
+}
+
+func TestReconcilePositions_FlagsAndAdjustsMissingTrades(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-holdings-reconcile"
+	createTestUser(t, db, userID)
+	accountID := createTestAccount(t, db, userID)
+	ctx := auth.WithUserID(context.Background(), userID)
+	service := NewService(db)
+
+	tracked, untracked := "TESTRC", "TESTUN"
+	held, other := 10.0, 5.0
+	created, err := service.Create(ctx, &CreateHoldingRequest{AccountID: accountID, Type: HoldingTypeStock, Symbol: &tracked, Quantity: &held})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := service.Create(ctx, &CreateHoldingRequest{AccountID: accountID, Type: HoldingTypeStock, Symbol: &untracked, Quantity: &other}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	_, err = db.Exec(`
+		INSERT INTO holding_transactions (id, holding_id, type, quantity, price, transaction_date, created_at)
+		VALUES ('test-reconcile-buy', $1, 'buy', 6, 100, '2024-01-02', CURRENT_TIMESTAMP)
+	`, created.Holding.ID)
+	if err != nil {
+		t.Fatalf("Failed to record buy: %v", err)
+	}
+
+	// Act
+	before, err := service.ReconcilePositions(ctx, accountID)
+	if err != nil {
+		t.Fatalf("ReconcilePositions failed: %v", err)
+	}
+	adjusted, err := service.AdjustPositions(ctx, accountID, &AdjustPositionsRequest{})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("AdjustPositions failed: %v", err)
+	}
+	if before.Untracked != 1 || len(before.Discrepancies) != 1 {
+		t.Fatalf("Expected 1 untracked holding and 1 discrepancy, got %+v", before)
+	}
+	if d := before.Discrepancies[0]; d.Symbol != tracked || d.RecordedQuantity != 6 || d.Difference != 4 {
+		t.Errorf("Expected TESTRC to be 4 shares short of its transactions, got %+v", d)
+	}
+	if adjusted.Adjusted != 1 || len(adjusted.Reconciliation.Discrepancies) != 0 || adjusted.Reconciliation.Reconciled != 1 {
+		t.Errorf("Expected the adjustment to reconcile TESTRC, got %+v", adjusted.Reconciliation)
+	}
+}
+
+func TestCreateInKindTransfer_MovesSharesLotsAndCost(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-holdings-in-kind"
+	createTestUser(t, db, userID)
+	fromID := createTestAccount(t, db, userID)
+	toID := createTestAccount(t, db, userID)
+	ctx := auth.WithUserID(context.Background(), userID)
+	service := NewService(db)
+
+	lots, err := ParseCostBasisCSV(strings.NewReader(
+		"Symbol,Quantity,Total_Cost,Acquired_Date\n" +
+			"TESTIK,10,1000,2019-03-01\n" +
+			"TESTIK,30,4400,2020-06-15\n"))
+	if err != nil {
+		t.Fatalf("ParseCostBasisCSV failed: %v", err)
+	}
+	_, err = service.ImportCostBasis(ctx, fromID, &ImportCostBasisRequest{Lots: lots})
+	if err != nil {
+		t.Fatalf("ImportCostBasis failed: %v", err)
+	}
+	symbol, quantity, cost := "TESTIK", 10.0, 200.0
+	if _, err := service.Create(ctx, &CreateHoldingRequest{AccountID: toID, Type: HoldingTypeStock, Symbol: &symbol, Quantity: &quantity, CostBasis: &cost}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// Act
+	transfer, err := service.CreateInKindTransfer(ctx, &CreateInKindTransferRequest{
+		FromAccountID: fromID,
+		ToAccountID:   toID,
+		Date:          "2024-05-01",
+		Legs:          []*InKindTransferLegInput{{Symbol: "testik", Quantity: 25}},
+	})
+	if err != nil {
+		t.Fatalf("CreateInKindTransfer failed: %v", err)
+	}
+	_, overdrawn := service.CreateInKindTransfer(ctx, &CreateInKindTransferRequest{
+		FromAccountID: fromID,
+		ToAccountID:   toID,
+		Date:          "2024-05-02",
+		Legs:          []*InKindTransferLegInput{{Symbol: "TESTIK", Quantity: 16}},
+	})
+
+	// Assert
+	if overdrawn == nil {
+		t.Error("Expected transferring more shares than remain to fail")
+	}
+	if len(transfer.Legs) != 1 || transfer.Legs[0].LotsMoved != 2 {
+		t.Fatalf("Expected one leg moving 2 lots, got %+v", transfer.Legs)
+	}
+	source, _ := service.GetAccountHoldings(ctx, fromID)
+	if *source.Holdings[0].Quantity != 15 || *source.Holdings[0].CostBasis != 135 {
+		t.Errorf("Expected 15 shares left at 135, got %v at %v", *source.Holdings[0].Quantity, *source.Holdings[0].CostBasis)
+	}
+	destination, _ := service.GetAccountHoldings(ctx, toID)
+	// (10 * 200 + 25 * 135) / 35
+	if *destination.Holdings[0].Quantity != 35 || math.Abs(*destination.Holdings[0].CostBasis-153.5714) > 0.001 {
+		t.Errorf("Expected 35 shares at 153.57, got %v at %v", *destination.Holdings[0].Quantity, *destination.Holdings[0].CostBasis)
+	}
+	fromLots, _ := service.GetCostBasis(ctx, fromID)
+	toLots, _ := service.GetCostBasis(ctx, toID)
+	if len(fromLots.Lots) != 1 || fromLots.Lots[0].Quantity != 15 || fromLots.Lots[0].TotalCost != 2200 {
+		t.Errorf("Expected 15 shares costing 2200 left in the split lot, got %+v", fromLots.Lots)
+	}
+	if len(toLots.Lots) != 2 || toLots.Positions[0].Quantity != 25 || toLots.Positions[0].TotalCost != 3200 {
+		t.Errorf("Expected 25 shares costing 3200 moved in 2 lots, got %+v", toLots.Positions)
+	}
+	list, _ := service.ListInKindTransfers(ctx)
+	if len(list.Transfers) != 1 || len(list.Transfers[0].Legs) != 1 {
+		t.Errorf("Expected the failed transfer to leave only one recorded, got %d", len(list.Transfers))
+	}
+}
+
+func TestJournalEntries_TrackMoveSinceEntryAndDueReviews(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-holdings-journal"
+	createTestUser(t, db, userID)
+	accountID := createTestAccount(t, db, userID)
+	ctx := auth.WithUserID(context.Background(), userID)
+	service := NewService(db)
+
+	symbol := "TESTJRNL"
+	quantity := 10.0
+	costBasis := 100.0
+	resp, err := service.Create(ctx, &CreateHoldingRequest{AccountID: accountID, Type: HoldingTypeStock, Symbol: &symbol,
+		Quantity: &quantity, CostBasis: &costBasis})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	holdingID := resp.Holding.ID
+	if _, err := service.RecordQuote(ctx, symbol, &RecordQuoteRequest{Price: 100, Currency: CurrencyUSD}); err != nil {
+		t.Fatalf("RecordQuote failed: %v", err)
+	}
+
+	conviction := 4
+	entryDate, reviewOn := "2026-01-15", "2026-04-15"
+	thesis, err := service.CreateJournalEntry(ctx, holdingID, &CreateJournalEntryRequest{
+		EntryDate: &entryDate, Kind: JournalEntryThesis, Body: "Margins expanding", Conviction: &conviction,
+		Tags: []string{" Growth", "growth", "moat "}, ReviewOn: &reviewOn,
+	})
+	if err != nil {
+		t.Fatalf("CreateJournalEntry failed: %v", err)
+	}
+	invalid := 6
+	if _, err := service.CreateJournalEntry(ctx, holdingID, &CreateJournalEntryRequest{Body: "Too sure", Conviction: &invalid}); err == nil {
+		t.Error("Expected a conviction outside 1-5 to be rejected")
+	}
+
+	// Act
+	if _, err := service.RecordQuote(ctx, symbol, &RecordQuoteRequest{Price: 125, Currency: CurrencyUSD}); err != nil {
+		t.Fatalf("RecordQuote failed: %v", err)
+	}
+	journal, err := service.ListJournalEntries(ctx, holdingID)
+	if err != nil {
+		t.Fatalf("ListJournalEntries failed: %v", err)
+	}
+	due, err := service.GetDueJournalReviews(ctx, time.Date(2026, 4, 15, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetDueJournalReviews failed: %v", err)
+	}
+	reviewed := true
+	if _, err := service.UpdateJournalEntry(ctx, holdingID, thesis.ID, &UpdateJournalEntryRequest{Reviewed: &reviewed}); err != nil {
+		t.Fatalf("UpdateJournalEntry failed: %v", err)
+	}
+	dueAfterReview, err := service.GetDueJournalReviews(ctx, time.Date(2026, 4, 15, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetDueJournalReviews failed: %v", err)
+	}
+
+	// Assert
+	if len(journal.Entries) != 1 {
+		t.Fatalf("Expected 1 journal entry, got %d", len(journal.Entries))
+	}
+	entry := journal.Entries[0]
+	if len(entry.Tags) != 2 || entry.Tags[0] != "growth" || entry.Tags[1] != "moat" {
+		t.Errorf("Expected tags [growth moat], got %v", entry.Tags)
+	}
+	if entry.PriceAtEntry == nil || *entry.PriceAtEntry != 100 {
+		t.Errorf("Expected a price at entry of 100, got %v", entry.PriceAtEntry)
+	}
+	if entry.ChangeSinceEntry == nil || *entry.ChangeSinceEntry != 25 {
+		t.Errorf("Expected a 25%% move since entry, got %v", entry.ChangeSinceEntry)
+	}
+	if gain := journal.Performance.UnrealizedGain; gain == nil || *gain != 250 {
+		t.Errorf("Expected an unrealized gain of 250, got %v", gain)
+	}
+	if percent := journal.Performance.ReturnPercent; percent == nil || *percent != 25 {
+		t.Errorf("Expected a 25%% return on the position cost, got %v", percent)
+	}
+	if len(due.Reviews) != 1 || due.Reviews[0].Entry.ID != thesis.ID {
+		t.Errorf("Expected the thesis to be due for review, got %d reviews", len(due.Reviews))
+	}
+	if len(dueAfterReview.Reviews) != 0 {
+		t.Errorf("Expected no reviews due after marking it reviewed, got %d", len(dueAfterReview.Reviews))
+	}
+}
@@ -0,0 +1,397 @@
+package holdings
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+)
+
+// InKindTransfer moves positions between two of the user's investment accounts without a
+// sale. Shares leave the source at their average cost and arrive carrying it, so no capital
+// gain is realized and the ACB across both accounts is unchanged.
+type InKindTransfer struct {
+	ID            string               `json:"id"`
+	FromAccountID string               `json:"from_account_id"`
+	ToAccountID   string               `json:"to_account_id"`
+	Date          time.Time            `json:"date"`
+	Notes         *string              `json:"notes,omitempty"`
+	Legs          []*InKindTransferLeg `json:"legs"`
+	CreatedAt     time.Time            `json:"created_at"`
+}
+
+// InKindTransferLeg is one symbol moved by an in-kind transfer
+type InKindTransferLeg struct {
+	Symbol       string   `json:"symbol"`
+	Quantity     float64  `json:"quantity"`
+	CostPerShare *float64 `json:"cost_per_share,omitempty"` // Source average cost, nil when unknown
+	LotsMoved    int      `json:"lots_moved"`               // Imported cost basis lots moved or split
+}
+
+// InKindTransferLegInput is a symbol and quantity to move
+type InKindTransferLegInput struct {
+	Symbol   string  `json:"symbol"`
+	Quantity float64 `json:"quantity"`
+}
+
+// CreateInKindTransferRequest represents a request to move positions between accounts
+type CreateInKindTransferRequest struct {
+	FromAccountID string                    `json:"from_account_id"`
+	ToAccountID   string                    `json:"to_account_id"`
+	Date          string                    `json:"date"` // YYYY-MM-DD
+	Legs          []*InKindTransferLegInput `json:"legs"`
+	Notes         string                    `json:"notes,omitempty"`
+}
+
+// ListInKindTransfersResponse represents a list of in-kind transfers, newest first
+type ListInKindTransfersResponse struct {
+	Transfers []*InKindTransfer `json:"transfers"`
+}
+
+// transferPosition is the state of a holding an in-kind transfer moves shares out of or into
+type transferPosition struct {
+	id           string
+	holdingType  HoldingType
+	quantity     float64
+	costBasis    *float64
+	currency     *Currency
+	purchaseDate *time.Time
+	tracked      bool // Has recorded transactions that reconciliation checks it against
+}
+
+// CreateInKindTransfer moves each leg's shares from the source holding to the destination
+// holding in one transaction. The destination's average cost blends in the moved shares at
+// the source's cost, imported lots move oldest first, and both holdings record a transfer
+// when their transactions are tracked. A source position emptied by the transfer is kept at
+// zero so its history stays attached.
+func (s *Service) CreateInKindTransfer(ctx context.Context, req *CreateInKindTransferRequest) (*InKindTransfer, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if req.FromAccountID == "" || req.ToAccountID == "" {
+		return nil, fmt.Errorf("from_account_id and to_account_id are required")
+	}
+	if req.FromAccountID == req.ToAccountID {
+		return nil, fmt.Errorf("cannot transfer to the same account")
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date: %w", err)
+	}
+	if len(req.Legs) == 0 {
+		return nil, fmt.Errorf("at least one leg is required")
+	}
+	seen := make(map[string]bool, len(req.Legs))
+	for i, leg := range req.Legs {
+		leg.Symbol = strings.ToUpper(strings.TrimSpace(leg.Symbol))
+		if leg.Symbol == "" {
+			return nil, fmt.Errorf("leg %d: symbol is required", i+1)
+		}
+		if leg.Quantity <= 0 {
+			return nil, fmt.Errorf("leg %d: quantity must be positive", i+1)
+		}
+		if seen[leg.Symbol] {
+			return nil, fmt.Errorf("leg %d: %s is listed more than once", i+1, leg.Symbol)
+		}
+		seen[leg.Symbol] = true
+	}
+	for _, accountID := range []string{req.FromAccountID, req.ToAccountID} {
+		if err := s.verifyAccountOwnership(ctx, accountID); err != nil {
+			return nil, err
+		}
+	}
+
+	transfer := &InKindTransfer{
+		ID:            uuid.New().String(),
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Date:          date,
+		Legs:          make([]*InKindTransferLeg, 0, len(req.Legs)),
+		CreatedAt:     time.Now(),
+	}
+	if req.Notes != "" {
+		transfer.Notes = &req.Notes
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO in_kind_transfers (id, user_id, from_account_id, to_account_id, transfer_date, notes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, transfer.ID, userID, transfer.FromAccountID, transfer.ToAccountID, transfer.Date, transfer.Notes, transfer.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create in-kind transfer: %w", err)
+	}
+
+	for _, input := range req.Legs {
+		leg, err := moveInKind(ctx, tx, transfer, input)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", input.Symbol, err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO in_kind_transfer_legs (id, transfer_id, symbol, quantity, cost_per_share, lots_moved)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, uuid.New().String(), transfer.ID, leg.Symbol, leg.Quantity, leg.CostPerShare, leg.LotsMoved)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record transfer leg for %s: %w", leg.Symbol, err)
+		}
+		transfer.Legs = append(transfer.Legs, leg)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return transfer, nil
+}
+
+// moveInKind moves one leg's shares, average cost and lots from the source to the destination
+func moveInKind(ctx context.Context, tx *sql.Tx, transfer *InKindTransfer, input *InKindTransferLegInput) (*InKindTransferLeg, error) {
+	source, err := transferPositionOf(ctx, tx, transfer.FromAccountID, input.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, fmt.Errorf("not held in the source account")
+	}
+	if input.Quantity > source.quantity+positionTolerance {
+		return nil, fmt.Errorf("only %g shares held, cannot transfer %g", source.quantity, input.Quantity)
+	}
+	destination, err := transferPositionOf(ctx, tx, transfer.ToAccountID, input.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	leg := &InKindTransferLeg{Symbol: input.Symbol, Quantity: input.Quantity, CostPerShare: source.costBasis}
+	now := time.Now()
+
+	remaining := math.Max(source.quantity-input.Quantity, 0)
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE holdings SET quantity = $1, updated_at = $2 WHERE id = $3
+	`, remaining, now, source.id); err != nil {
+		return nil, fmt.Errorf("failed to reduce source holding: %w", err)
+	}
+
+	if destination == nil {
+		// A new position arrives with the source's average cost and original purchase date
+		destination = &transferPosition{id: uuid.New().String(), tracked: source.tracked}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO holdings (
+				id, account_id, type, symbol, quantity, cost_basis, currency, purchase_date, created_at, updated_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`, destination.id, transfer.ToAccountID, source.holdingType, input.Symbol, input.Quantity,
+			source.costBasis, source.currency, source.purchaseDate, now, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create destination holding: %w", err)
+		}
+	} else {
+		costBasis := blendedCost(destination.quantity, destination.costBasis, input.Quantity, source.costBasis)
+		_, err := tx.ExecContext(ctx, `
+			UPDATE holdings SET quantity = $1, cost_basis = $2, updated_at = $3 WHERE id = $4
+		`, destination.quantity+input.Quantity, costBasis, now, destination.id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add to destination holding: %w", err)
+		}
+	}
+
+	if leg.LotsMoved, err = moveLots(ctx, tx, transfer, input.Symbol, input.Quantity); err != nil {
+		return nil, err
+	}
+
+	// Transfers carry their own sign, so reconciliation nets them like buys and sells
+	sides := []struct {
+		position *transferPosition
+		quantity float64
+		notes    string
+	}{
+		{source, -input.Quantity, "In-kind transfer out"},
+		{destination, input.Quantity, "In-kind transfer in"},
+	}
+	for _, side := range sides {
+		if !side.position.tracked {
+			continue
+		}
+		var total *float64
+		if source.costBasis != nil {
+			cost := math.Round(*source.costBasis*side.quantity*100) / 100
+			total = &cost
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO holding_transactions (id, holding_id, type, quantity, price, total_amount, transaction_date, notes, created_at)
+			VALUES ($1, $2, 'transfer', $3, $4, $5, $6, $7, $8)
+		`, uuid.New().String(), side.position.id, side.quantity, source.costBasis, total, transfer.Date, side.notes, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record transfer transaction: %w", err)
+		}
+	}
+
+	return leg, nil
+}
+
+// transferPositionOf loads an account's holding of a symbol, nil when it has none
+func transferPositionOf(ctx context.Context, tx *sql.Tx, accountID, symbol string) (*transferPosition, error) {
+	position := &transferPosition{}
+	var quantity sql.NullFloat64
+	err := tx.QueryRowContext(ctx, `
+		SELECT h.id, h.type, h.quantity, h.cost_basis, h.currency, h.purchase_date,
+			EXISTS (SELECT 1 FROM holding_transactions ht WHERE ht.holding_id = h.id)
+		FROM holdings h
+		WHERE h.account_id = $1 AND UPPER(h.symbol) = $2
+	`, accountID, symbol).Scan(&position.id, &position.holdingType, &quantity, &position.costBasis,
+		&position.currency, &position.purchaseDate, &position.tracked)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holding: %w", err)
+	}
+	position.quantity = quantity.Float64
+	return position, nil
+}
+
+// blendedCost is the average cost per share after adding shares at another average cost.
+// The result is unknown when either side's cost is, unless the existing position was empty.
+func blendedCost(quantity float64, cost *float64, added float64, addedCost *float64) *float64 {
+	if quantity <= 0 {
+		return addedCost
+	}
+	if cost == nil || addedCost == nil {
+		return nil
+	}
+	blended := (quantity**cost + added**addedCost) / (quantity + added)
+	return &blended
+}
+
+// moveLots moves the source's imported lots of a symbol to the destination, oldest first,
+// splitting the last lot when only part of it is transferred. Acquisition dates and costs
+// move unchanged, so the lot history survives the transfer.
+func moveLots(ctx context.Context, tx *sql.Tx, transfer *InKindTransfer, symbol string, quantity float64) (int, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, quantity, total_cost FROM cost_basis_lots
+		WHERE account_id = $1 AND symbol = $2
+		ORDER BY acquired_date, created_at
+	`, transfer.FromAccountID, symbol)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get cost basis lots: %w", err)
+	}
+	type lot struct {
+		id              string
+		quantity, total float64
+	}
+	var lots []lot
+	for rows.Next() {
+		var l lot
+		if err := rows.Scan(&l.id, &l.quantity, &l.total); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan cost basis lot: %w", err)
+		}
+		lots = append(lots, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	moved := 0
+	notes := "Transferred in kind"
+	for _, l := range lots {
+		if quantity <= positionTolerance {
+			break
+		}
+		if l.quantity <= quantity+positionTolerance {
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE cost_basis_lots SET account_id = $1 WHERE id = $2
+			`, transfer.ToAccountID, l.id); err != nil {
+				return 0, fmt.Errorf("failed to move cost basis lot: %w", err)
+			}
+			quantity -= l.quantity
+			moved++
+			continue
+		}
+
+		// Split the lot, leaving the untransferred shares and their share of the cost behind
+		movedCost := math.Round(l.total*quantity/l.quantity*100) / 100
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE cost_basis_lots SET quantity = $1, total_cost = $2 WHERE id = $3
+		`, l.quantity-quantity, l.total-movedCost, l.id); err != nil {
+			return 0, fmt.Errorf("failed to split cost basis lot: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO cost_basis_lots (id, account_id, symbol, quantity, total_cost, acquired_date, notes, created_at)
+			SELECT $1, $2, symbol, $3, $4, acquired_date, COALESCE(notes, $5), $6 FROM cost_basis_lots WHERE id = $7
+		`, uuid.New().String(), transfer.ToAccountID, quantity, movedCost, notes, time.Now(), l.id); err != nil {
+			return 0, fmt.Errorf("failed to move split cost basis lot: %w", err)
+		}
+		quantity = 0
+		moved++
+	}
+	return moved, nil
+}
+
+// ListInKindTransfers lists the user's in-kind transfers with their legs
+func (s *Service) ListInKindTransfers(ctx context.Context) (*ListInKindTransfersResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, from_account_id, to_account_id, transfer_date, notes, created_at
+		FROM in_kind_transfers
+		WHERE user_id = $1
+		ORDER BY transfer_date DESC, created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list in-kind transfers: %w", err)
+	}
+	defer rows.Close()
+
+	transfers := make([]*InKindTransfer, 0)
+	byID := make(map[string]*InKindTransfer)
+	for rows.Next() {
+		t := &InKindTransfer{Legs: make([]*InKindTransferLeg, 0)}
+		if err := rows.Scan(&t.ID, &t.FromAccountID, &t.ToAccountID, &t.Date, &t.Notes, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan in-kind transfer: %w", err)
+		}
+		transfers = append(transfers, t)
+		byID[t.ID] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	legRows, err := s.db.QueryContext(ctx, `
+		SELECT l.transfer_id, l.symbol, l.quantity, l.cost_per_share, l.lots_moved
+		FROM in_kind_transfer_legs l
+		JOIN in_kind_transfers t ON t.id = l.transfer_id
+		WHERE t.user_id = $1
+		ORDER BY l.symbol
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list in-kind transfer legs: %w", err)
+	}
+	defer legRows.Close()
+
+	for legRows.Next() {
+		var transferID string
+		leg := &InKindTransferLeg{}
+		if err := legRows.Scan(&transferID, &leg.Symbol, &leg.Quantity, &leg.CostPerShare, &leg.LotsMoved); err != nil {
+			return nil, fmt.Errorf("failed to scan in-kind transfer leg: %w", err)
+		}
+		if t := byID[transferID]; t != nil {
+			t.Legs = append(t.Legs, leg)
+		}
+	}
+
+	return &ListInKindTransfersResponse{Transfers: transfers}, legRows.Err()
+}
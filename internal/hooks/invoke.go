@@ -0,0 +1,298 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"money/internal/sync"
+	"money/internal/transaction"
+)
+
+// maxResponseBytes bounds how much of a hook's response is read
+const maxResponseBytes = 1 << 20
+
+// Request headers sent with every call. The signature is the hex HMAC-SHA256 of the body
+// keyed with the hook's secret, so receivers can check the call came from this instance.
+const (
+	headerEvent     = "X-Moneyy-Event"
+	headerHookID    = "X-Moneyy-Hook-ID"
+	headerSignature = "X-Moneyy-Signature"
+)
+
+// ValidationRequest is the body sent to transaction.validate hooks
+type ValidationRequest struct {
+	Event       Event                       `json:"event"`
+	UserID      string                      `json:"user_id"`
+	Transaction *transaction.NewTransaction `json:"transaction"` // Its source says which path is creating it
+}
+
+// ValidationResponse is a transaction.validate hook's answer. A 2xx response without a body
+// accepts the transaction.
+type ValidationResponse struct {
+	Allow  *bool  `json:"allow,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// SyncedTransaction is a transaction written by a sync, as sent to sync.completed hooks
+type SyncedTransaction struct {
+	ID          string    `json:"id"`
+	Date        time.Time `json:"date"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"`
+	Currency    string    `json:"currency"`
+	Category    *string   `json:"category,omitempty"`
+	Notes       *string   `json:"notes,omitempty"`
+}
+
+// SyncCompletedRequest is the body sent to sync.completed hooks
+type SyncCompletedRequest struct {
+	Event        Event               `json:"event"`
+	UserID       string              `json:"user_id"`
+	AccountID    string              `json:"account_id"`
+	SyncJobID    *string             `json:"sync_job_id,omitempty"`
+	ItemsCreated int                 `json:"items_created"`
+	ItemsUpdated int                 `json:"items_updated"`
+	Transactions []SyncedTransaction `json:"transactions"`
+}
+
+// Enrichment changes one synced transaction; fields left nil are kept
+type Enrichment struct {
+	ID       string  `json:"id"`
+	Category *string `json:"category,omitempty"`
+	Notes    *string `json:"notes,omitempty"`
+}
+
+// SyncCompletedResponse is a sync.completed hook's answer
+type SyncCompletedResponse struct {
+	Transactions []Enrichment `json:"transactions,omitempty"`
+}
+
+// activeHooks returns the active hooks for an event that apply to the user: their own
+// hooks, then instance hooks
+func (s *Service) activeHooks(ctx context.Context, userID string, event Event) ([]*Hook, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+hookColumns+` FROM hooks
+		WHERE event = $1 AND is_active = true AND (user_id = $2 OR user_id IS NULL)
+		ORDER BY user_id IS NULL, created_at
+	`, event, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load hooks: %w", err)
+	}
+	defer rows.Close()
+
+	var hooks []*Hook
+	for rows.Next() {
+		hook, err := scanHook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan hook: %w", err)
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+// call posts body to a hook within its timeout and decodes a JSON answer into out, then
+// records the outcome on the hook. A non-2xx status is a failure.
+func (s *Service) call(ctx context.Context, hook *Hook, body interface{}, out interface{}) error {
+	err := s.send(ctx, hook, body, out)
+
+	status, lastError := "ok", sql.NullString{}
+	if err != nil {
+		status, lastError = "failed", sql.NullString{String: err.Error(), Valid: true}
+	}
+	// Recorded on the caller's context, which outlives the hook's own deadline
+	if _, recordErr := s.db.ExecContext(ctx, `
+		UPDATE hooks SET last_called_at = $1, last_status = $2, last_error = $3 WHERE id = $4
+	`, time.Now(), status, lastError, hook.ID); recordErr != nil {
+		hooksLog.Printf("ERROR: failed to record hook call: hook_id=%s error=%v", hook.ID, recordErr)
+	}
+	return err
+}
+
+// send performs one signed call to a hook
+func (s *Service) send(ctx context.Context, hook *Hook, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	secret, err := s.encryption.Decrypt(hook.secret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(hook.TimeoutMS)*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(headerEvent, string(hook.Event))
+	req.Header.Set(headerHookID, hook.ID)
+	req.Header.Set(headerSignature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("hook returned status %d", resp.StatusCode)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// ValidateTransaction asks the user's transaction.validate hooks whether a transaction may
+// be written, whether it is created by hand, imported, posted on schedule, transferred or
+// synced. Any hook answering allow false rejects it. A hook that fails is skipped under the
+// ignore policy and rejects the transaction under the fail policy.
+func (s *Service) ValidateTransaction(ctx context.Context, t *transaction.NewTransaction) error {
+	hooks, err := s.activeHooks(ctx, t.UserID, EventTransactionValidate)
+	if err != nil {
+		return err
+	}
+
+	body := &ValidationRequest{Event: EventTransactionValidate, UserID: t.UserID, Transaction: t}
+	for _, hook := range hooks {
+		var answer ValidationResponse
+		if err := s.call(ctx, hook, body, &answer); err != nil {
+			if hook.FailurePolicy == FailureFail {
+				return fmt.Errorf("%w: hook %s failed: %v", transaction.ErrRejected, hook.Name, err)
+			}
+			hooksLog.Printf("WARN: ignoring failed validation hook: hook_id=%s error=%v", hook.ID, err)
+			continue
+		}
+		if answer.Allow != nil && !*answer.Allow {
+			reason := answer.Reason
+			if reason == "" {
+				reason = "no reason given"
+			}
+			return fmt.Errorf("%w by hook %s: %s", transaction.ErrRejected, hook.Name, reason)
+		}
+	}
+	return nil
+}
+
+// EnrichSync sends the transactions a sync wrote to the user's sync.completed hooks and
+// applies the categories and notes they return. It is an outbox subscriber: a hook that
+// fails under the fail policy returns the error so the event is retried.
+func (s *Service) EnrichSync(ctx context.Context, event *sync.OutboxEvent) error {
+	var synced sync.AccountSyncedPayload
+	if err := json.Unmarshal(event.Payload, &synced); err != nil {
+		return fmt.Errorf("failed to decode account synced event: %w", err)
+	}
+
+	hooks, err := s.activeHooks(ctx, event.UserID, EventSyncCompleted)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+
+	transactions, err := s.syncedTransactions(ctx, event.UserID, synced.AccountID, event.SyncJobID)
+	if err != nil {
+		return err
+	}
+	body := &SyncCompletedRequest{
+		Event:        EventSyncCompleted,
+		UserID:       event.UserID,
+		AccountID:    synced.AccountID,
+		SyncJobID:    event.SyncJobID,
+		ItemsCreated: synced.ItemsCreated,
+		ItemsUpdated: synced.ItemsUpdated,
+		Transactions: transactions,
+	}
+
+	// Hooks only enrich the transactions they were sent
+	sent := make(map[string]bool, len(transactions))
+	for _, t := range transactions {
+		sent[t.ID] = true
+	}
+
+	for _, hook := range hooks {
+		var answer SyncCompletedResponse
+		if err := s.call(ctx, hook, body, &answer); err != nil {
+			if hook.FailurePolicy == FailureFail {
+				return fmt.Errorf("sync hook %s failed: %w", hook.Name, err)
+			}
+			hooksLog.Printf("WARN: ignoring failed sync hook: hook_id=%s error=%v", hook.ID, err)
+			continue
+		}
+
+		for _, enrichment := range answer.Transactions {
+			if !sent[enrichment.ID] || (enrichment.Category == nil && enrichment.Notes == nil) {
+				continue
+			}
+			_, err := s.transactions.SetDetails(ctx, enrichment.ID, &transaction.SetDetailsRequest{
+				Category: enrichment.Category,
+				Notes:    enrichment.Notes,
+			})
+			if err != nil {
+				hooksLog.Printf("WARN: failed to apply hook enrichment: hook_id=%s transaction_id=%s error=%v",
+					hook.ID, enrichment.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// syncedTransactions returns the provider transactions in an account written since the
+// sync job started. Without a job every synced transaction in the account is returned.
+func (s *Service) syncedTransactions(ctx context.Context, userID, accountID string, jobID *string) ([]SyncedTransaction, error) {
+	var since time.Time
+	if jobID != nil {
+		err := s.db.QueryRowContext(ctx, `
+			SELECT COALESCE(started_at, created_at) FROM sync_jobs WHERE id = $1
+		`, *jobID).Scan(&since)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to read sync job: %w", err)
+		}
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, date, description, amount, currency, category, notes, created_at
+		FROM transactions
+		WHERE user_id = $1 AND account_id = $2 AND provider_activity_id IS NOT NULL
+		ORDER BY date, id
+	`, userID, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load synced transactions: %w", err)
+	}
+	defer rows.Close()
+
+	transactions := make([]SyncedTransaction, 0)
+	for rows.Next() {
+		var t SyncedTransaction
+		var createdAt time.Time
+		if err := rows.Scan(&t.ID, &t.Date, &t.Description, &t.Amount, &t.Currency, &t.Category, &t.Notes, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan synced transaction: %w", err)
+		}
+		// Compared in Go since the two timestamps may be stored in different formats
+		if createdAt.Before(since) {
+			continue
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
@@ -0,0 +1,383 @@
+// Package hooks lets self-hosters extend the app without forking it. A hook is an HTTP
+// endpoint called at a fixed point: before a transaction is created, to accept or refuse
+// it, and after a sync commits, to enrich the synced transactions. Users register hooks for
+// their own data; administrators register instance hooks that run for every user.
+package hooks
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/egress"
+	"money/internal/logger"
+	"money/internal/sync/encryption"
+	"money/internal/transaction"
+
+	"github.com/google/uuid"
+)
+
+var hooksLog = logger.Module("hooks")
+
+// Event is the point at which a hook is called
+type Event string
+
+const (
+	EventTransactionValidate Event = "transaction.validate" // Before a transaction is created
+	EventSyncCompleted       Event = "sync.completed"       // After a synced account's data is committed
+)
+
+// validEvents lists the events a hook may be registered for
+var validEvents = map[Event]bool{
+	EventTransactionValidate: true,
+	EventSyncCompleted:       true,
+}
+
+// FailurePolicy decides what happens when a hook can't be reached, times out or answers
+// with an error status
+type FailurePolicy string
+
+const (
+	// FailureIgnore logs the failure and carries on as if the hook had accepted
+	FailureIgnore FailurePolicy = "ignore"
+	// FailureFail refuses the transaction, or retries the sync enrichment with the outbox
+	FailureFail FailurePolicy = "fail"
+)
+
+// Hook timeouts in milliseconds
+const (
+	DefaultTimeoutMS = 5000
+	MaxTimeoutMS     = 30000
+	minTimeoutMS     = 100
+)
+
+// Scope selects whose hooks are managed
+type Scope string
+
+const (
+	ScopeUser     Scope = "user"     // The authenticated user's hooks
+	ScopeInstance Scope = "instance" // Hooks run for every user, managed by administrators
+)
+
+// Hook is a registered HTTP callback. The signing secret is only returned when the hook
+// is created.
+type Hook struct {
+	ID            string        `json:"id"`
+	Instance      bool          `json:"instance"` // Runs for every user
+	Name          string        `json:"name"`
+	Event         Event         `json:"event"`
+	URL           string        `json:"url"`
+	TimeoutMS     int           `json:"timeout_ms"`
+	FailurePolicy FailurePolicy `json:"failure_policy"`
+	IsActive      bool          `json:"is_active"`
+	LastCalledAt  *time.Time    `json:"last_called_at,omitempty"`
+	LastStatus    *string       `json:"last_status,omitempty"` // ok or failed
+	LastError     *string       `json:"last_error,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+
+	userID string
+	secret []byte // Sealed signing secret
+}
+
+// HookSecretResponse returns a new hook with the secret its requests are signed with
+type HookSecretResponse struct {
+	Hook
+	Secret string `json:"secret"`
+}
+
+// CreateHookRequest registers a hook
+type CreateHookRequest struct {
+	Name          string        `json:"name"`
+	Event         Event         `json:"event"`
+	URL           string        `json:"url"`
+	TimeoutMS     int           `json:"timeout_ms,omitempty"`     // Defaults to 5000
+	FailurePolicy FailurePolicy `json:"failure_policy,omitempty"` // Defaults to ignore
+}
+
+// UpdateHookRequest changes a hook; fields left nil are kept
+type UpdateHookRequest struct {
+	Name          *string        `json:"name,omitempty"`
+	URL           *string        `json:"url,omitempty"`
+	TimeoutMS     *int           `json:"timeout_ms,omitempty"`
+	FailurePolicy *FailurePolicy `json:"failure_policy,omitempty"`
+	IsActive      *bool          `json:"is_active,omitempty"`
+}
+
+// ListHooksResponse lists hooks, oldest first
+type ListHooksResponse struct {
+	Hooks []*Hook `json:"hooks"`
+}
+
+// Service manages hooks and calls them
+type Service struct {
+	db           *sql.DB
+	encryption   *encryption.Service
+	transactions *transaction.Service
+	client       *http.Client
+}
+
+// NewService creates a new hook service. Signing secrets are encrypted with enc, and sync
+// hooks enrich transactions through transactions.
+func NewService(db *sql.DB, enc *encryption.Service, transactions *transaction.Service) *Service {
+	// Each call sets its own deadline from the hook's timeout. Hooks may only reach public
+	// addresses, so a URL can't be aimed at the instance's own network.
+	return &Service{db: db, encryption: enc, transactions: transactions, client: egress.NewClient(0)}
+}
+
+// owner returns the user_id stored for hooks in the scope, NULL for instance hooks
+func owner(ctx context.Context, scope Scope) (sql.NullString, error) {
+	switch scope {
+	case ScopeInstance:
+		return sql.NullString{}, nil
+	case ScopeUser:
+		userID := auth.GetUserID(ctx)
+		if userID == "" {
+			return sql.NullString{}, fmt.Errorf("user not authenticated")
+		}
+		return sql.NullString{String: userID, Valid: true}, nil
+	default:
+		return sql.NullString{}, fmt.Errorf("invalid hook scope: %s", scope)
+	}
+}
+
+// ownerClause matches hooks in the scope, with $n bound to the owner
+func ownerClause(userID sql.NullString, n int) string {
+	if !userID.Valid {
+		return fmt.Sprintf("user_id IS NULL AND $%d IS NULL", n)
+	}
+	return fmt.Sprintf("user_id = $%d", n)
+}
+
+// checkTimeout bounds a hook timeout
+func checkTimeout(ms int) error {
+	if ms < minTimeoutMS || ms > MaxTimeoutMS {
+		return fmt.Errorf("timeout_ms must be between %d and %d", minTimeoutMS, MaxTimeoutMS)
+	}
+	return nil
+}
+
+// checkFailurePolicy rejects unknown failure policies
+func checkFailurePolicy(policy FailurePolicy) error {
+	if policy != FailureIgnore && policy != FailureFail {
+		return fmt.Errorf("invalid failure_policy: %s", policy)
+	}
+	return nil
+}
+
+// generateSecret creates a new signing secret
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateHook registers a hook in the scope and returns the secret its requests are signed with
+func (s *Service) CreateHook(ctx context.Context, scope Scope, req *CreateHookRequest) (*HookSecretResponse, error) {
+	userID, err := owner(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if !validEvents[req.Event] {
+		return nil, fmt.Errorf("invalid event: %s", req.Event)
+	}
+	if err := egress.CheckURL(req.URL); err != nil {
+		return nil, err
+	}
+	if req.TimeoutMS == 0 {
+		req.TimeoutMS = DefaultTimeoutMS
+	}
+	if err := checkTimeout(req.TimeoutMS); err != nil {
+		return nil, err
+	}
+	if req.FailurePolicy == "" {
+		req.FailurePolicy = FailureIgnore
+	}
+	if err := checkFailurePolicy(req.FailurePolicy); err != nil {
+		return nil, err
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.encryption.Encrypt(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+
+	now := time.Now()
+	hook := Hook{
+		ID:            uuid.New().String(),
+		Instance:      !userID.Valid,
+		Name:          name,
+		Event:         req.Event,
+		URL:           req.URL,
+		TimeoutMS:     req.TimeoutMS,
+		FailurePolicy: req.FailurePolicy,
+		IsActive:      true,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO hooks (id, user_id, name, event, url, encrypted_secret, timeout_ms, failure_policy, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, true, $9, $9)
+	`, hook.ID, userID, hook.Name, hook.Event, hook.URL, sealed, hook.TimeoutMS, hook.FailurePolicy, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create hook: %w", err)
+	}
+
+	return &HookSecretResponse{Hook: hook, Secret: secret}, nil
+}
+
+// hookColumns are the columns read into a Hook
+const hookColumns = `id, user_id, name, event, url, encrypted_secret, timeout_ms, failure_policy, is_active,
+		last_called_at, last_status, last_error, created_at, updated_at`
+
+// scanHook reads a row selected with hookColumns
+func scanHook(row interface{ Scan(...any) error }) (*Hook, error) {
+	hook := &Hook{}
+	var userID sql.NullString
+	err := row.Scan(&hook.ID, &userID, &hook.Name, &hook.Event, &hook.URL, &hook.secret, &hook.TimeoutMS,
+		&hook.FailurePolicy, &hook.IsActive, &hook.LastCalledAt, &hook.LastStatus, &hook.LastError,
+		&hook.CreatedAt, &hook.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	hook.userID = userID.String
+	hook.Instance = !userID.Valid
+	return hook, nil
+}
+
+// ListHooks returns the hooks in the scope, oldest first
+func (s *Service) ListHooks(ctx context.Context, scope Scope) (*ListHooksResponse, error) {
+	userID, err := owner(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+hookColumns+` FROM hooks
+		WHERE `+ownerClause(userID, 1)+`
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hooks: %w", err)
+	}
+	defer rows.Close()
+
+	hooks := make([]*Hook, 0)
+	for rows.Next() {
+		hook, err := scanHook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan hook: %w", err)
+		}
+		hooks = append(hooks, hook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read hooks: %w", err)
+	}
+
+	return &ListHooksResponse{Hooks: hooks}, nil
+}
+
+// getHook returns a hook in the scope
+func (s *Service) getHook(ctx context.Context, userID sql.NullString, id string) (*Hook, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+hookColumns+` FROM hooks
+		WHERE id = $1 AND `+ownerClause(userID, 2), id, userID)
+	hook, err := scanHook(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("hook not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hook: %w", err)
+	}
+	return hook, nil
+}
+
+// UpdateHook changes a hook in the scope
+func (s *Service) UpdateHook(ctx context.Context, scope Scope, id string, req *UpdateHookRequest) (*Hook, error) {
+	userID, err := owner(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	hook, err := s.getHook(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("name is required")
+		}
+		hook.Name = name
+	}
+	if req.URL != nil {
+		if err := egress.CheckURL(*req.URL); err != nil {
+			return nil, err
+		}
+		hook.URL = *req.URL
+	}
+	if req.TimeoutMS != nil {
+		if err := checkTimeout(*req.TimeoutMS); err != nil {
+			return nil, err
+		}
+		hook.TimeoutMS = *req.TimeoutMS
+	}
+	if req.FailurePolicy != nil {
+		if err := checkFailurePolicy(*req.FailurePolicy); err != nil {
+			return nil, err
+		}
+		hook.FailurePolicy = *req.FailurePolicy
+	}
+	if req.IsActive != nil {
+		hook.IsActive = *req.IsActive
+	}
+	hook.UpdatedAt = time.Now()
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE hooks SET name = $1, url = $2, timeout_ms = $3, failure_policy = $4, is_active = $5, updated_at = $6
+		WHERE id = $7
+	`, hook.Name, hook.URL, hook.TimeoutMS, hook.FailurePolicy, hook.IsActive, hook.UpdatedAt, hook.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update hook: %w", err)
+	}
+	return hook, nil
+}
+
+// DeleteHook removes a hook in the scope
+func (s *Service) DeleteHook(ctx context.Context, scope Scope, id string) error {
+	userID, err := owner(ctx, scope)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM hooks WHERE id = $1 AND `+ownerClause(userID, 2), id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete hook: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("hook not found")
+	}
+	return nil
+}
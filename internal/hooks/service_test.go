@@ -0,0 +1,248 @@
+package hooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"money/internal/account"
+	"money/internal/sync"
+	"money/internal/sync/encryption"
+	"money/internal/transaction"
+)
+
+func setupService(t *testing.T) (*Service, func()) {
+	t.Helper()
+	db := account.SetupTestDB(t)
+	enc, err := encryption.NewService(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	if err != nil {
+		t.Fatalf("Failed to create encryption service: %v", err)
+	}
+	return NewService(db, enc, transaction.NewService(db)), func() {
+		_, _ = db.Exec(`DELETE FROM hooks WHERE user_id IS NULL`)
+		account.CleanupTestDB(t, db)
+	}
+}
+
+// createHook registers a hook for a public-looking URL, then points it and the service's
+// client at the loopback test server, which real hooks are never allowed to reach
+func createHook(t *testing.T, service *Service, server *httptest.Server, ctx context.Context, scope Scope, req *CreateHookRequest) (*HookSecretResponse, error) {
+	t.Helper()
+	req.URL = "https://hooks.example.com/" + strings.ToLower(req.Name)
+	created, err := service.CreateHook(ctx, scope, req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := service.db.Exec(`UPDATE hooks SET url = $1 WHERE id = $2`, server.URL, created.ID); err != nil {
+		t.Fatalf("Failed to point hook at test server: %v", err)
+	}
+	service.client = server.Client()
+	return created, nil
+}
+
+func TestValidateTransaction_HookRejects(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	var signature, body string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body, signature = string(data), r.Header.Get(headerSignature)
+		json.NewEncoder(w).Encode(ValidationResponse{Allow: new(bool), Reason: "over the limit"})
+	}))
+	defer server.Close()
+
+	userID := "test-user-hooks-1"
+	account.CreateTestUser(t, service.db, userID)
+	ctx := account.CreateAuthContext(userID)
+	created, err := createHook(t, service, server, ctx, ScopeUser, &CreateHookRequest{Name: "Limit", Event: EventTransactionValidate})
+	if err != nil {
+		t.Fatalf("CreateHook failed: %v", err)
+	}
+
+	// Act
+	err = service.ValidateTransaction(ctx, &transaction.NewTransaction{UserID: userID, Description: "TV", Amount: -2500, Currency: "CAD"})
+
+	// Assert
+	if !errors.Is(err, transaction.ErrRejected) || !strings.Contains(err.Error(), "over the limit") {
+		t.Fatalf("Expected the hook to reject the transaction with its reason, got %v", err)
+	}
+	mac := hmac.New(sha256.New, []byte(created.Secret))
+	mac.Write([]byte(body))
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); signature != want {
+		t.Errorf("Expected signature %s, got %s", want, signature)
+	}
+	hooks, err := service.ListHooks(ctx, ScopeUser)
+	if err != nil {
+		t.Fatalf("ListHooks failed: %v", err)
+	}
+	if len(hooks.Hooks) != 1 || hooks.Hooks[0].LastStatus == nil || *hooks.Hooks[0].LastStatus != "ok" {
+		t.Errorf("Expected the call to be recorded as ok, got %+v", hooks.Hooks)
+	}
+}
+
+func TestValidateTransaction_FailurePolicy(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	userID := "test-user-hooks-2"
+	account.CreateTestUser(t, service.db, userID)
+	ctx := account.CreateAuthContext(userID)
+	req := &transaction.NewTransaction{UserID: userID, Description: "Coffee", Amount: -4, Currency: "CAD"}
+	hook, err := createHook(t, service, server, ctx, ScopeUser, &CreateHookRequest{Name: "Slow", Event: EventTransactionValidate, TimeoutMS: 100})
+	if err != nil {
+		t.Fatalf("CreateHook failed: %v", err)
+	}
+
+	// Act
+	ignored := service.ValidateTransaction(ctx, req)
+	policy := FailureFail
+	if _, err := service.UpdateHook(ctx, ScopeUser, hook.ID, &UpdateHookRequest{FailurePolicy: &policy}); err != nil {
+		t.Fatalf("UpdateHook failed: %v", err)
+	}
+	failed := service.ValidateTransaction(ctx, req)
+
+	// Assert
+	if ignored != nil {
+		t.Errorf("Expected a timed out hook to be ignored, got %v", ignored)
+	}
+	if !errors.Is(failed, transaction.ErrRejected) {
+		t.Errorf("Expected a timed out hook to reject under the fail policy, got %v", failed)
+	}
+}
+
+func TestValidateTransaction_InstanceHooksApplyToEveryUser(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	calls := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	userID := "test-user-hooks-3"
+	account.CreateTestUser(t, service.db, userID)
+	_, err := createHook(t, service, server, context.Background(), ScopeInstance, &CreateHookRequest{
+		Name: "Policy", Event: EventTransactionValidate, FailurePolicy: FailureFail,
+	})
+	if err != nil {
+		t.Fatalf("CreateHook failed: %v", err)
+	}
+
+	// Act
+	err = service.ValidateTransaction(context.Background(), &transaction.NewTransaction{UserID: userID, Description: "Rent", Amount: -1800, Currency: "CAD"})
+
+	// Assert
+	if calls != 1 {
+		t.Errorf("Expected the instance hook to be called once, got %d", calls)
+	}
+	if !errors.Is(err, transaction.ErrRejected) {
+		t.Errorf("Expected the failing instance hook to reject the transaction, got %v", err)
+	}
+	userHooks, err := service.ListHooks(account.CreateAuthContext(userID), ScopeUser)
+	if err != nil {
+		t.Fatalf("ListHooks failed: %v", err)
+	}
+	if len(userHooks.Hooks) != 0 {
+		t.Errorf("Expected instance hooks to be left out of the user's hooks, got %d", len(userHooks.Hooks))
+	}
+}
+
+func TestCreateHook_RejectsInvalidRequests(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	userID := "test-user-hooks-4"
+	account.CreateTestUser(t, service.db, userID)
+	ctx := account.CreateAuthContext(userID)
+	requests := map[string]*CreateHookRequest{
+		"missing name":  {Event: EventTransactionValidate, URL: "https://example.com/hook"},
+		"unknown event": {Name: "Hook", Event: "account.deleted", URL: "https://example.com/hook"},
+		"bad url":       {Name: "Hook", Event: EventSyncCompleted, URL: "ftp://example.com/hook"},
+		"plain http":    {Name: "Hook", Event: EventSyncCompleted, URL: "http://example.com/hook"},
+		"internal":      {Name: "Hook", Event: EventSyncCompleted, URL: "https://169.254.169.254/latest/meta-data"},
+		"long timeout":  {Name: "Hook", Event: EventSyncCompleted, URL: "https://example.com/hook", TimeoutMS: MaxTimeoutMS + 1},
+		"bad policy":    {Name: "Hook", Event: EventSyncCompleted, URL: "https://example.com/hook", FailurePolicy: "retry"},
+	}
+
+	for name, req := range requests {
+		// Act
+		_, err := service.CreateHook(ctx, ScopeUser, req)
+
+		// Assert
+		if err == nil {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+}
+
+func TestEnrichSync_AppliesCategories(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req SyncCompletedRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		var answer SyncCompletedResponse
+		for _, t := range req.Transactions {
+			category := "Groceries"
+			answer.Transactions = append(answer.Transactions, Enrichment{ID: t.ID, Category: &category})
+		}
+		answer.Transactions = append(answer.Transactions, Enrichment{ID: "test-txn-hooks-other", Notes: new(string)})
+		json.NewEncoder(w).Encode(answer)
+	}))
+	defer server.Close()
+
+	userID := "test-user-hooks-5"
+	account.CreateTestUser(t, service.db, userID)
+	ctx := account.CreateAuthContext(userID)
+	accountID := account.CreateTestAccount(t, service.db, userID, account.AccountTypeChecking)
+	_, err := service.db.Exec(`
+		INSERT INTO transactions (id, user_id, account_id, date, description, amount, currency, provider_activity_id)
+		VALUES ('test-txn-hooks-1', $1, $2, $3, 'LOBLAWS #123', -85.20, 'CAD', 'activity-1')
+	`, userID, accountID, time.Now().UTC())
+	if err != nil {
+		t.Fatalf("Failed to insert transaction: %v", err)
+	}
+	if _, err := createHook(t, service, server, ctx, ScopeUser, &CreateHookRequest{Name: "Categorize", Event: EventSyncCompleted}); err != nil {
+		t.Fatalf("CreateHook failed: %v", err)
+	}
+	payload, _ := json.Marshal(sync.AccountSyncedPayload{AccountID: accountID, ItemsCreated: 1})
+
+	// Act
+	err = service.EnrichSync(ctx, &sync.OutboxEvent{UserID: userID, Type: sync.EventAccountSynced, Payload: payload})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("EnrichSync failed: %v", err)
+	}
+	var category string
+	if err := service.db.QueryRow(`SELECT category FROM transactions WHERE id = 'test-txn-hooks-1'`).Scan(&category); err != nil {
+		t.Fatalf("Failed to read transaction: %v", err)
+	}
+	if category != "Groceries" {
+		t.Errorf("Expected the hook's category to be applied, got %q", category)
+	}
+}
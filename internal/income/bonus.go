@@ -0,0 +1,527 @@
+package income
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+)
+
+// BonusPlanType distinguishes cash bonuses from deferred profit sharing
+type BonusPlanType string
+
+const (
+	BonusPlanBonus BonusPlanType = "bonus" // Paid in cash and taxed as employment income
+	BonusPlanDPSP  BonusPlanType = "dpsp"  // Paid into a deferred profit sharing plan, taxed on withdrawal
+)
+
+// BonusPlan is a recurring annual bonus or DPSP contribution targeted as a share of salary
+type BonusPlan struct {
+	ID                string         `json:"id"`
+	Name              string         `json:"name"`
+	Employer          string         `json:"employer"`
+	PlanType          BonusPlanType  `json:"plan_type"`
+	TargetPercent     float64        `json:"target_percent"` // e.g. 0.15 for 15% of base salary
+	PayoutMonth       int            `json:"payout_month"`   // 1-12
+	Confidence        float64        `json:"confidence"`     // Expected share of the target actually paid, 0-1
+	Currency          Currency       `json:"currency"`
+	AccountID         *string        `json:"account_id,omitempty"` // Account DPSP contributions are paid into
+	IsActive          bool           `json:"is_active"`
+	Notes             *string        `json:"notes,omitempty"`
+	Payouts           []*BonusPayout `json:"payouts"`
+	AverageAttainment *float64       `json:"average_attainment,omitempty"` // Mean payout as a share of target, where the base salary is known
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+}
+
+// ExpectedPayout is the payout expected on an annual salary, discounted by the plan's confidence
+func (p *BonusPlan) ExpectedPayout(annualSalary float64) float64 {
+	return annualSalary * p.TargetPercent * p.Confidence
+}
+
+// BonusPayout is an actual payout under a bonus plan
+type BonusPayout struct {
+	ID             string    `json:"id"`
+	PlanID         string    `json:"plan_id"`
+	PayoutDate     time.Time `json:"payout_date"`
+	Amount         float64   `json:"amount"`
+	BaseSalary     *float64  `json:"base_salary,omitempty"`
+	Attainment     *float64  `json:"attainment,omitempty"` // Amount as a share of the target on the base salary
+	IncomeRecordID *string   `json:"income_record_id,omitempty"`
+	Notes          *string   `json:"notes,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CreateBonusPlanRequest represents a request to create a bonus plan
+type CreateBonusPlanRequest struct {
+	Name          string        `json:"name"`
+	Employer      string        `json:"employer"`
+	PlanType      BonusPlanType `json:"plan_type"`
+	TargetPercent float64       `json:"target_percent"`
+	PayoutMonth   int           `json:"payout_month"`
+	Confidence    *float64      `json:"confidence,omitempty"` // Defaults to 1
+	Currency      Currency      `json:"currency"`
+	AccountID     *string       `json:"account_id,omitempty"`
+	Notes         *string       `json:"notes,omitempty"`
+}
+
+// UpdateBonusPlanRequest represents a request to update a bonus plan
+type UpdateBonusPlanRequest struct {
+	Name          *string   `json:"name,omitempty"`
+	Employer      *string   `json:"employer,omitempty"`
+	TargetPercent *float64  `json:"target_percent,omitempty"`
+	PayoutMonth   *int      `json:"payout_month,omitempty"`
+	Confidence    *float64  `json:"confidence,omitempty"`
+	Currency      *Currency `json:"currency,omitempty"`
+	AccountID     *string   `json:"account_id,omitempty"` // Empty string clears the account
+	IsActive      *bool     `json:"is_active,omitempty"`
+	Notes         *string   `json:"notes,omitempty"`
+}
+
+// RecordBonusPayoutRequest represents a request to record a payout under a plan
+type RecordBonusPayoutRequest struct {
+	PayoutDate string   `json:"payout_date"` // YYYY-MM-DD
+	Amount     float64  `json:"amount"`
+	BaseSalary *float64 `json:"base_salary,omitempty"`
+	Notes      *string  `json:"notes,omitempty"`
+}
+
+// ListBonusPlansResponse is the response for listing bonus plans
+type ListBonusPlansResponse struct {
+	Plans []*BonusPlan `json:"plans"`
+}
+
+// CreateBonusPlan creates a bonus or DPSP plan
+func (s *Service) CreateBonusPlan(ctx context.Context, req *CreateBonusPlanRequest) (*BonusPlan, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	now := time.Now()
+	plan := &BonusPlan{
+		ID:            uuid.New().String(),
+		Name:          strings.TrimSpace(req.Name),
+		Employer:      strings.TrimSpace(req.Employer),
+		PlanType:      req.PlanType,
+		TargetPercent: req.TargetPercent,
+		PayoutMonth:   req.PayoutMonth,
+		Confidence:    1,
+		Currency:      req.Currency,
+		AccountID:     req.AccountID,
+		IsActive:      true,
+		Notes:         req.Notes,
+		Payouts:       make([]*BonusPayout, 0),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if req.Confidence != nil {
+		plan.Confidence = *req.Confidence
+	}
+	if plan.Currency == "" {
+		plan.Currency = CurrencyCAD
+	}
+	if err := s.validateBonusPlan(ctx, userID, plan); err != nil {
+		return nil, err
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO bonus_plans (id, user_id, name, employer, plan_type, target_percent, payout_month, confidence,
+			currency, account_id, is_active, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`, plan.ID, userID, plan.Name, plan.Employer, plan.PlanType, plan.TargetPercent, plan.PayoutMonth,
+		plan.Confidence, plan.Currency, plan.AccountID, plan.IsActive, plan.Notes, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bonus plan: %w", err)
+	}
+
+	return plan, nil
+}
+
+// validateBonusPlan checks a plan's fields and that its DPSP account belongs to the user
+func (s *Service) validateBonusPlan(ctx context.Context, userID string, plan *BonusPlan) error {
+	if plan.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if plan.Employer == "" {
+		return fmt.Errorf("employer is required")
+	}
+	if plan.PlanType != BonusPlanBonus && plan.PlanType != BonusPlanDPSP {
+		return fmt.Errorf("invalid plan type: %s", plan.PlanType)
+	}
+	if plan.TargetPercent <= 0 || plan.TargetPercent > 10 {
+		return fmt.Errorf("target_percent must be a share of salary above 0, e.g. 0.15 for 15%%")
+	}
+	if plan.PayoutMonth < 1 || plan.PayoutMonth > 12 {
+		return fmt.Errorf("payout_month must be between 1 and 12")
+	}
+	if plan.Confidence < 0 || plan.Confidence > 1 {
+		return fmt.Errorf("confidence must be between 0 and 1")
+	}
+	if plan.Currency != CurrencyCAD && plan.Currency != CurrencyUSD && plan.Currency != CurrencyINR {
+		return fmt.Errorf("invalid currency: %s", plan.Currency)
+	}
+
+	if plan.AccountID != nil {
+		var exists bool
+		err := s.db.QueryRowContext(ctx, `
+			SELECT EXISTS(SELECT 1 FROM accounts WHERE id = $1 AND user_id = $2)
+		`, *plan.AccountID, userID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to verify account: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("account not found")
+		}
+	}
+	return nil
+}
+
+// ListBonusPlans lists the user's bonus plans with their payouts
+func (s *Service) ListBonusPlans(ctx context.Context) (*ListBonusPlansResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, employer, plan_type, target_percent, payout_month, confidence, currency,
+		       account_id, is_active, notes, created_at, updated_at
+		FROM bonus_plans
+		WHERE user_id = $1
+		ORDER BY name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bonus plans: %w", err)
+	}
+
+	plans := make([]*BonusPlan, 0)
+	for rows.Next() {
+		plan, err := scanBonusPlan(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list bonus plans: %w", err)
+	}
+
+	for _, plan := range plans {
+		if err := s.loadBonusPayouts(ctx, plan); err != nil {
+			return nil, err
+		}
+	}
+
+	return &ListBonusPlansResponse{Plans: plans}, nil
+}
+
+// GetBonusPlan retrieves a bonus plan with its payouts
+func (s *Service) GetBonusPlan(ctx context.Context, id string) (*BonusPlan, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	plan, err := scanBonusPlan(s.db.QueryRowContext(ctx, `
+		SELECT id, name, employer, plan_type, target_percent, payout_month, confidence, currency,
+		       account_id, is_active, notes, created_at, updated_at
+		FROM bonus_plans
+		WHERE id = $1 AND user_id = $2
+	`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("bonus plan not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.loadBonusPayouts(ctx, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// UpdateBonusPlan updates a bonus plan. The plan type can't change once payouts are recorded
+// against it, so it isn't updatable.
+func (s *Service) UpdateBonusPlan(ctx context.Context, id string, req *UpdateBonusPlanRequest) (*BonusPlan, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	plan, err := s.GetBonusPlan(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		plan.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Employer != nil {
+		plan.Employer = strings.TrimSpace(*req.Employer)
+	}
+	if req.TargetPercent != nil {
+		plan.TargetPercent = *req.TargetPercent
+	}
+	if req.PayoutMonth != nil {
+		plan.PayoutMonth = *req.PayoutMonth
+	}
+	if req.Confidence != nil {
+		plan.Confidence = *req.Confidence
+	}
+	if req.Currency != nil {
+		plan.Currency = *req.Currency
+	}
+	if req.AccountID != nil {
+		plan.AccountID = req.AccountID
+		if *req.AccountID == "" {
+			plan.AccountID = nil
+		}
+	}
+	if req.IsActive != nil {
+		plan.IsActive = *req.IsActive
+	}
+	if req.Notes != nil {
+		plan.Notes = req.Notes
+	}
+	if err := s.validateBonusPlan(ctx, userID, plan); err != nil {
+		return nil, err
+	}
+
+	plan.UpdatedAt = time.Now()
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE bonus_plans
+		SET name = $1, employer = $2, target_percent = $3, payout_month = $4, confidence = $5, currency = $6,
+		    account_id = $7, is_active = $8, notes = $9, updated_at = $10
+		WHERE id = $11 AND user_id = $12
+	`, plan.Name, plan.Employer, plan.TargetPercent, plan.PayoutMonth, plan.Confidence, plan.Currency,
+		plan.AccountID, plan.IsActive, plan.Notes, plan.UpdatedAt, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update bonus plan: %w", err)
+	}
+
+	setAttainment(plan)
+	return plan, nil
+}
+
+// DeleteBonusPlan deletes a bonus plan, its payouts and the income records they created
+func (s *Service) DeleteBonusPlan(ctx context.Context, id string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM income_records
+		WHERE user_id = $1 AND id IN (
+			SELECT bp.income_record_id FROM bonus_payouts bp
+			JOIN bonus_plans p ON p.id = bp.plan_id
+			WHERE p.id = $2 AND p.user_id = $1
+		)
+	`, userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete income records: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM bonus_plans WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete bonus plan: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("bonus plan not found")
+	}
+
+	return tx.Commit()
+}
+
+// RecordBonusPayout records a payout under a plan. A cash bonus is also recorded as one-time
+// employment income; DPSP contributions aren't taxed until withdrawn, so they aren't.
+func (s *Service) RecordBonusPayout(ctx context.Context, planID string, req *RecordBonusPayoutRequest) (*BonusPayout, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	plan, err := s.GetBonusPlan(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	payoutDate, err := time.Parse("2006-01-02", req.PayoutDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payout_date: %s", req.PayoutDate)
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	if req.BaseSalary != nil && *req.BaseSalary <= 0 {
+		return nil, fmt.Errorf("base_salary must be positive")
+	}
+
+	now := time.Now()
+	payout := &BonusPayout{
+		ID:         uuid.New().String(),
+		PlanID:     planID,
+		PayoutDate: payoutDate,
+		Amount:     roundToCents(req.Amount),
+		BaseSalary: req.BaseSalary,
+		Notes:      req.Notes,
+		CreatedAt:  now,
+	}
+	payout.Attainment = bonusAttainment(plan, payout)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if plan.PlanType == BonusPlanBonus {
+		recordID := uuid.New().String()
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO income_records (id, user_id, source, category, amount, currency, frequency, tax_year, date_received, description, is_taxable, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, true, $11, $12)
+		`, recordID, userID, plan.Employer, CategoryEmployment, payout.Amount, plan.Currency, FrequencyOneTime,
+			payoutDate.Year(), req.PayoutDate, plan.Name, now, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create income record: %w", err)
+		}
+		payout.IncomeRecordID = &recordID
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO bonus_payouts (id, plan_id, payout_date, amount, base_salary, income_record_id, notes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, payout.ID, payout.PlanID, payout.PayoutDate, payout.Amount, payout.BaseSalary, payout.IncomeRecordID,
+		payout.Notes, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record bonus payout: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return payout, nil
+}
+
+// DeleteBonusPayout deletes a payout and the income record it created
+func (s *Service) DeleteBonusPayout(ctx context.Context, id string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	var recordID sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT bp.income_record_id FROM bonus_payouts bp
+		JOIN bonus_plans p ON p.id = bp.plan_id
+		WHERE bp.id = $1 AND p.user_id = $2
+	`, id, userID).Scan(&recordID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("bonus payout not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get bonus payout: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM bonus_payouts WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete bonus payout: %w", err)
+	}
+	if recordID.Valid {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM income_records WHERE id = $1 AND user_id = $2`, recordID.String, userID); err != nil {
+			return fmt.Errorf("failed to delete income record: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// loadBonusPayouts reads a plan's payouts, oldest first, and its average attainment
+func (s *Service) loadBonusPayouts(ctx context.Context, plan *BonusPlan) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, plan_id, payout_date, amount, base_salary, income_record_id, notes, created_at
+		FROM bonus_payouts
+		WHERE plan_id = $1
+		ORDER BY payout_date
+	`, plan.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list bonus payouts: %w", err)
+	}
+	defer rows.Close()
+
+	plan.Payouts = make([]*BonusPayout, 0)
+	for rows.Next() {
+		var p BonusPayout
+		if err := rows.Scan(&p.ID, &p.PlanID, &p.PayoutDate, &p.Amount, &p.BaseSalary, &p.IncomeRecordID,
+			&p.Notes, &p.CreatedAt); err != nil {
+			return fmt.Errorf("failed to scan bonus payout: %w", err)
+		}
+		plan.Payouts = append(plan.Payouts, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list bonus payouts: %w", err)
+	}
+
+	setAttainment(plan)
+	return nil
+}
+
+// setAttainment sets each payout's attainment against the plan's target and their average
+func setAttainment(plan *BonusPlan) {
+	plan.AverageAttainment = nil
+	total, counted := 0.0, 0
+	for _, p := range plan.Payouts {
+		p.Attainment = bonusAttainment(plan, p)
+		if p.Attainment != nil {
+			total += *p.Attainment
+			counted++
+		}
+	}
+	if counted > 0 {
+		average := total / float64(counted)
+		plan.AverageAttainment = &average
+	}
+}
+
+// bonusAttainment is a payout as a share of the plan's target on its base salary, or nil
+// when the base salary isn't known
+func bonusAttainment(plan *BonusPlan, payout *BonusPayout) *float64 {
+	if payout.BaseSalary == nil || *payout.BaseSalary <= 0 || plan.TargetPercent <= 0 {
+		return nil
+	}
+	attainment := payout.Amount / (*payout.BaseSalary * plan.TargetPercent)
+	return &attainment
+}
+
+// scanBonusPlan scans a bonus plan row
+func scanBonusPlan(row interface{ Scan(...interface{}) error }) (*BonusPlan, error) {
+	var plan BonusPlan
+	err := row.Scan(&plan.ID, &plan.Name, &plan.Employer, &plan.PlanType, &plan.TargetPercent, &plan.PayoutMonth,
+		&plan.Confidence, &plan.Currency, &plan.AccountID, &plan.IsActive, &plan.Notes, &plan.CreatedAt, &plan.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan bonus plan: %w", err)
+	}
+	plan.Payouts = make([]*BonusPayout, 0)
+	return &plan, nil
+}
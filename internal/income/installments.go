@@ -0,0 +1,355 @@
+package income
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+)
+
+// Jurisdiction identifies the tax authority an installment schedule is paid to
+type Jurisdiction string
+
+const (
+	JurisdictionCRA Jurisdiction = "cra"
+	JurisdictionIRS Jurisdiction = "irs"
+)
+
+// InstallmentStatus describes how far an installment has been paid
+type InstallmentStatus string
+
+const (
+	InstallmentStatusUpcoming InstallmentStatus = "upcoming"
+	InstallmentStatusPartial  InstallmentStatus = "partial"
+	InstallmentStatusPaid     InstallmentStatus = "paid"
+	InstallmentStatusOverdue  InstallmentStatus = "overdue"
+)
+
+// Installments are only required once net tax owing crosses these thresholds
+const (
+	CRAInstallmentThreshold = 3000.0
+	IRSInstallmentThreshold = 1000.0
+)
+
+// DefaultInstallmentReminderDays is how far ahead of a due date reminders are raised
+const DefaultInstallmentReminderDays = 14
+
+// TaxInstallment is one quarterly estimated tax payment
+type TaxInstallment struct {
+	ID           string            `json:"id"`
+	TaxYear      int               `json:"tax_year"`
+	Jurisdiction Jurisdiction      `json:"jurisdiction"`
+	Quarter      int               `json:"quarter"`
+	DueDate      time.Time         `json:"due_date"`
+	AmountDue    float64           `json:"amount_due"`
+	AmountPaid   float64           `json:"amount_paid"`
+	Remaining    float64           `json:"remaining"`
+	Status       InstallmentStatus `json:"status"`
+}
+
+// InstallmentPayment records money paid toward an installment
+type InstallmentPayment struct {
+	ID            string    `json:"id"`
+	InstallmentID string    `json:"installment_id"`
+	Amount        float64   `json:"amount"`
+	PaidDate      time.Time `json:"paid_date"`
+	Notes         *string   `json:"notes,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// GenerateInstallmentsRequest represents a request to build a year's installment schedule
+type GenerateInstallmentsRequest struct {
+	Jurisdiction Jurisdiction `json:"jurisdiction"` // cra or irs, defaulting to the user's jurisdiction
+}
+
+// RecordInstallmentPaymentRequest represents a payment made toward an installment
+type RecordInstallmentPaymentRequest struct {
+	Amount   float64   `json:"amount"`
+	PaidDate time.Time `json:"paid_date"`
+	Notes    *string   `json:"notes,omitempty"`
+}
+
+// InstallmentSchedule is a year's quarterly installments for one jurisdiction
+type InstallmentSchedule struct {
+	TaxYear        int               `json:"tax_year"`
+	Jurisdiction   Jurisdiction      `json:"jurisdiction"`
+	ProjectedTax   float64           `json:"projected_tax"` // Total tax on projected income for the year
+	TaxWithheld    float64           `json:"tax_withheld"`  // Tax expected to be withheld at source on employment income
+	NetTaxOwing    float64           `json:"net_tax_owing"` // Tax left to pay through installments
+	Required       bool              `json:"required"`      // Whether net tax owing crosses the installment threshold
+	TotalPaid      float64           `json:"total_paid"`
+	TotalRemaining float64           `json:"total_remaining"`
+	Installments   []*TaxInstallment `json:"installments"`
+}
+
+// UpcomingInstallmentsResponse lists unpaid installments due soon
+type UpcomingInstallmentsResponse struct {
+	Installments []*TaxInstallment `json:"installments"`
+}
+
+// installmentDueDates returns the quarterly due dates for a tax year, moved off weekends and
+// bank holidays.
+// CRA installments fall in the tax year; the IRS's fourth payment is due the following January.
+func installmentDueDates(year int, jurisdiction Jurisdiction) [4]time.Time {
+	var dates [4]time.Time
+	switch jurisdiction {
+	case JurisdictionIRS:
+		dates = [4]time.Time{
+			time.Date(year, time.April, 15, 0, 0, 0, 0, time.UTC),
+			time.Date(year, time.June, 15, 0, 0, 0, 0, time.UTC),
+			time.Date(year, time.September, 15, 0, 0, 0, 0, time.UTC),
+			time.Date(year+1, time.January, 15, 0, 0, 0, 0, time.UTC),
+		}
+	default:
+		dates = [4]time.Time{
+			time.Date(year, time.March, 15, 0, 0, 0, 0, time.UTC),
+			time.Date(year, time.June, 15, 0, 0, 0, 0, time.UTC),
+			time.Date(year, time.September, 15, 0, 0, 0, 0, time.UTC),
+			time.Date(year, time.December, 15, 0, 0, 0, 0, time.UTC),
+		}
+	}
+
+	for i, d := range dates {
+		dates[i] = nextBusinessDay(jurisdiction, d)
+	}
+	return dates
+}
+
+// GenerateInstallmentSchedule splits projected net tax owing for the year into four quarterly
+// installments. Regenerating updates the amounts due and keeps payments already recorded.
+func (s *Service) GenerateInstallmentSchedule(ctx context.Context, year int, req *GenerateInstallmentsRequest) (*InstallmentSchedule, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	jurisdiction := req.Jurisdiction
+	if jurisdiction == "" {
+		var err error
+		if jurisdiction, err = defaultInstallmentJurisdiction(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if jurisdiction != JurisdictionCRA && jurisdiction != JurisdictionIRS {
+		return nil, fmt.Errorf("invalid jurisdiction: %s", jurisdiction)
+	}
+
+	projected, withheld, err := s.projectTaxOwing(ctx, year)
+	if err != nil {
+		return nil, err
+	}
+	perQuarter := roundToCents(math.Max(0, projected-withheld) / 4)
+
+	now := time.Now()
+	for i, dueDate := range installmentDueDates(year, jurisdiction) {
+		_, err := s.db.ExecContext(ctx, `
+			INSERT INTO tax_installments (id, user_id, tax_year, jurisdiction, quarter, due_date, amount_due, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+			ON CONFLICT (user_id, tax_year, jurisdiction, quarter) DO UPDATE SET
+				due_date = excluded.due_date,
+				amount_due = excluded.amount_due,
+				updated_at = excluded.updated_at
+		`, uuid.New().String(), userID, year, jurisdiction, i+1, dueDate, perQuarter, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save installment: %w", err)
+		}
+	}
+
+	return s.GetInstallmentSchedule(ctx, year, jurisdiction)
+}
+
+// GetInstallmentSchedule returns a year's installments with payments applied
+func (s *Service) GetInstallmentSchedule(ctx context.Context, year int, jurisdiction Jurisdiction) (*InstallmentSchedule, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if jurisdiction == "" {
+		var err error
+		if jurisdiction, err = defaultInstallmentJurisdiction(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	projected, withheld, err := s.projectTaxOwing(ctx, year)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := &InstallmentSchedule{
+		TaxYear:      year,
+		Jurisdiction: jurisdiction,
+		ProjectedTax: roundToCents(projected),
+		TaxWithheld:  roundToCents(withheld),
+		NetTaxOwing:  roundToCents(math.Max(0, projected-withheld)),
+		Installments: make([]*TaxInstallment, 0, 4),
+	}
+	threshold := CRAInstallmentThreshold
+	if jurisdiction == JurisdictionIRS {
+		threshold = IRSInstallmentThreshold
+	}
+	schedule.Required = schedule.NetTaxOwing > threshold
+
+	installments, err := s.listInstallments(ctx, `
+		WHERE i.user_id = $1 AND i.tax_year = $2 AND i.jurisdiction = $3
+	`, userID, year, jurisdiction)
+	if err != nil {
+		return nil, err
+	}
+	for _, inst := range installments {
+		schedule.TotalPaid += inst.AmountPaid
+		schedule.TotalRemaining += inst.Remaining
+	}
+	schedule.TotalPaid = roundToCents(schedule.TotalPaid)
+	schedule.TotalRemaining = roundToCents(schedule.TotalRemaining)
+	schedule.Installments = installments
+
+	return schedule, nil
+}
+
+// RecordInstallmentPayment records a payment made toward an installment
+func (s *Service) RecordInstallmentPayment(ctx context.Context, installmentID string, req *RecordInstallmentPaymentRequest) (*InstallmentPayment, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM tax_installments WHERE id = $1 AND user_id = $2)
+	`, installmentID, userID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify installment: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("installment not found")
+	}
+
+	now := time.Now()
+	payment := &InstallmentPayment{
+		ID:            uuid.New().String(),
+		InstallmentID: installmentID,
+		Amount:        req.Amount,
+		PaidDate:      req.PaidDate,
+		Notes:         req.Notes,
+		CreatedAt:     now,
+	}
+	if payment.PaidDate.IsZero() {
+		payment.PaidDate = now
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO tax_installment_payments (id, installment_id, amount, paid_date, notes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, payment.ID, installmentID, payment.Amount, payment.PaidDate, payment.Notes, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record installment payment: %w", err)
+	}
+
+	return payment, nil
+}
+
+// GetUpcomingInstallments returns unpaid installments due within the given number of days,
+// including any already overdue
+func (s *Service) GetUpcomingInstallments(ctx context.Context, days int) (*UpcomingInstallmentsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	installments, err := s.listInstallments(ctx, `WHERE i.user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().AddDate(0, 0, days)
+	upcoming := make([]*TaxInstallment, 0)
+	for _, inst := range installments {
+		if inst.Status != InstallmentStatusPaid && !inst.DueDate.After(cutoff) {
+			upcoming = append(upcoming, inst)
+		}
+	}
+
+	return &UpcomingInstallmentsResponse{Installments: upcoming}, nil
+}
+
+// projectTaxOwing returns the year's projected total tax and the part withheld at source.
+// Employment income is assumed to have its tax, CPP, and EI withheld by the employer, except
+// that imported paystubs replace the estimate with what was actually withheld on the pay they
+// cover; self-employed CPP on business income is added since nobody withholds it.
+func (s *Service) projectTaxOwing(ctx context.Context, year int) (float64, float64, error) {
+	summary, err := s.GetAnnualSummary(ctx, year)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	taxConfig, err := s.GetTaxConfig(ctx, year)
+	if err != nil {
+		taxConfig = s.defaultTaxConfig(ctx, year)
+	}
+
+	withheld := s.calculateTaxes(summary.EmploymentIncome, summary.EmploymentIncome, taxConfig).TotalTax
+	paystubWithheld, paystubGross, err := s.paystubWithholding(ctx, year)
+	if err != nil {
+		return 0, 0, err
+	}
+	if paystubGross > 0 {
+		uncovered := 0.0
+		if summary.EmploymentIncome > paystubGross {
+			uncovered = withheld * (summary.EmploymentIncome - paystubGross) / summary.EmploymentIncome
+		}
+		withheld = paystubWithheld + uncovered
+	}
+	other := summary.TotalTaxableIncome - summary.BusinessIncome
+	selfEmployed := s.estimateSelfEmploymentTax(other, summary.EmploymentIncome, summary.BusinessIncome, taxConfig)
+
+	return summary.TotalTax + selfEmployed.CPPContribution, withheld, nil
+}
+
+// listInstallments loads installments matching the where clause with their payment totals
+func (s *Service) listInstallments(ctx context.Context, where string, args ...interface{}) ([]*TaxInstallment, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT i.id, i.tax_year, i.jurisdiction, i.quarter, i.due_date, i.amount_due,
+			COALESCE((SELECT SUM(p.amount) FROM tax_installment_payments p WHERE p.installment_id = i.id), 0)
+		FROM tax_installments i
+		`+where+`
+		ORDER BY i.due_date
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get installments: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	installments := make([]*TaxInstallment, 0)
+	for rows.Next() {
+		inst := &TaxInstallment{}
+		if err := rows.Scan(&inst.ID, &inst.TaxYear, &inst.Jurisdiction, &inst.Quarter, &inst.DueDate,
+			&inst.AmountDue, &inst.AmountPaid); err != nil {
+			return nil, fmt.Errorf("failed to scan installment: %w", err)
+		}
+		inst.AmountPaid = roundToCents(inst.AmountPaid)
+		inst.Remaining = roundToCents(math.Max(0, inst.AmountDue-inst.AmountPaid))
+
+		switch {
+		case inst.Remaining == 0:
+			inst.Status = InstallmentStatusPaid
+		case inst.DueDate.Before(now):
+			inst.Status = InstallmentStatusOverdue
+		case inst.AmountPaid > 0:
+			inst.Status = InstallmentStatusPartial
+		default:
+			inst.Status = InstallmentStatusUpcoming
+		}
+		installments = append(installments, inst)
+	}
+
+	return installments, nil
+}
@@ -0,0 +1,85 @@
+package income
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"money/internal/civil"
+	"money/internal/jurisdiction"
+)
+
+// The tax engine has two payroll contribution slots, named for CPP and EI; other
+// jurisdictions map their payroll taxes onto them.
+
+// US federal brackets for 2025, single filer, on income after the standard deduction
+var usFederalBrackets = []TaxBracket{
+	{UpToIncome: 11925, Rate: 0.10},
+	{UpToIncome: 48475, Rate: 0.12},
+	{UpToIncome: 103350, Rate: 0.22},
+	{UpToIncome: 197300, Rate: 0.24},
+	{UpToIncome: 250525, Rate: 0.32},
+	{UpToIncome: 626350, Rate: 0.35},
+	{UpToIncome: 0, Rate: 0.37}, // 0 means unlimited
+}
+
+// UK income tax bands for 2025/26, on income after the personal allowance
+var ukBrackets = []TaxBracket{
+	{UpToIncome: 37700, Rate: 0.20},
+	{UpToIncome: 112570, Rate: 0.40},
+	{UpToIncome: 0, Rate: 0.45}, // 0 means unlimited
+}
+
+// defaultTaxConfig returns a year's default tax configuration for the user's jurisdiction
+func (s *Service) defaultTaxConfig(ctx context.Context, year int) *TaxConfiguration {
+	switch jurisdiction.FromContext(ctx).Code {
+	case jurisdiction.US:
+		// Social Security in the CPP slot and uncapped Medicare in the EI slot. State tax
+		// varies too much to default, so provincial brackets are left for the user to set.
+		return &TaxConfiguration{
+			TaxYear:                   year,
+			Jurisdiction:              jurisdiction.US,
+			FederalBrackets:           usFederalBrackets,
+			ProvincialBrackets:        []TaxBracket{},
+			CPPRate:                   0.062,
+			CPPMaxPensionableEarnings: 176100,
+			EIRate:                    0.0145,
+			BasicPersonalAmount:       15750, // Standard deduction
+			FieldSources:              make(FieldSources),
+		}
+	case jurisdiction.UK:
+		// Class 1 National Insurance between the primary threshold and upper earnings limit.
+		// The 2% rate above the limit isn't modelled.
+		return &TaxConfiguration{
+			TaxYear:                   year,
+			Jurisdiction:              jurisdiction.UK,
+			FederalBrackets:           ukBrackets,
+			ProvincialBrackets:        []TaxBracket{},
+			CPPRate:                   0.08,
+			CPPMaxPensionableEarnings: 50270,
+			CPPBasicExemption:         12570,
+			BasicPersonalAmount:       12570, // Personal allowance
+			FieldSources:              make(FieldSources),
+		}
+	}
+	return s.getDefaultTaxConfig(year)
+}
+
+// defaultInstallmentJurisdiction is the tax authority the user pays installments to
+func defaultInstallmentJurisdiction(ctx context.Context) (Jurisdiction, error) {
+	profile := jurisdiction.FromContext(ctx)
+	switch profile.TaxAuthority {
+	case string(JurisdictionCRA), string(JurisdictionIRS):
+		return Jurisdiction(profile.TaxAuthority), nil
+	}
+	return "", fmt.Errorf("quarterly installments are not supported for %s", profile.Name)
+}
+
+// nextBusinessDay moves a due date off the weekends and bank holidays of the authority's country
+func nextBusinessDay(authority Jurisdiction, date time.Time) time.Time {
+	profile := jurisdiction.Lookup(string(jurisdiction.CA))
+	if authority == JurisdictionIRS {
+		profile = jurisdiction.Lookup(string(jurisdiction.US))
+	}
+	return profile.NextBusinessDay(civil.DateOf(date)).Time
+}
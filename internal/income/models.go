@@ -2,6 +2,9 @@ package income
 
 import (
 	"time"
+
+	"money/internal/account"
+	"money/internal/jurisdiction"
 )
 
 // IncomeCategory represents types of income
@@ -70,21 +73,22 @@ type FieldSources map[string]FieldSource
 
 // TaxConfiguration represents per-user tax settings per year
 type TaxConfiguration struct {
-	ID                        string       `json:"id"`
-	UserID                    string       `json:"user_id"`
-	TaxYear                   int          `json:"tax_year"`
-	Province                  string       `json:"province"`
-	FederalBrackets           []TaxBracket `json:"federal_brackets"`
-	ProvincialBrackets        []TaxBracket `json:"provincial_brackets"`
-	CPPRate                   float64      `json:"cpp_rate"`
-	CPPMaxPensionableEarnings float64      `json:"cpp_max_pensionable_earnings"`
-	CPPBasicExemption         float64      `json:"cpp_basic_exemption"`
-	EIRate                    float64      `json:"ei_rate"`
-	EIMaxInsurableEarnings    float64      `json:"ei_max_insurable_earnings"`
-	BasicPersonalAmount       float64      `json:"basic_personal_amount"`
-	FieldSources              FieldSources `json:"field_sources"`
-	CreatedAt                 time.Time    `json:"created_at"`
-	UpdatedAt                 time.Time    `json:"updated_at"`
+	ID                        string            `json:"id"`
+	UserID                    string            `json:"user_id"`
+	TaxYear                   int               `json:"tax_year"`
+	Province                  string            `json:"province"`
+	Jurisdiction              jurisdiction.Code `json:"jurisdiction"` // Not stored; follows the user's jurisdiction profile
+	FederalBrackets           []TaxBracket      `json:"federal_brackets"`
+	ProvincialBrackets        []TaxBracket      `json:"provincial_brackets"`
+	CPPRate                   float64           `json:"cpp_rate"`
+	CPPMaxPensionableEarnings float64           `json:"cpp_max_pensionable_earnings"`
+	CPPBasicExemption         float64           `json:"cpp_basic_exemption"`
+	EIRate                    float64           `json:"ei_rate"`
+	EIMaxInsurableEarnings    float64           `json:"ei_max_insurable_earnings"`
+	BasicPersonalAmount       float64           `json:"basic_personal_amount"`
+	FieldSources              FieldSources      `json:"field_sources"`
+	CreatedAt                 time.Time         `json:"created_at"`
+	UpdatedAt                 time.Time         `json:"updated_at"`
 }
 
 // AnnualIncomeSummary represents pre-computed annual totals
@@ -215,6 +219,8 @@ type CalculateExerciseTaxRequest struct {
 	StrikePrice   float64 `json:"strike_price"`
 	FMVAtExercise float64 `json:"fmv_at_exercise"`
 	MarginalRate  float64 `json:"marginal_rate"` // Combined federal + provincial rate
+	// Cash when omitted; cashless and same-day sales sell shares to cover the strike and the estimated tax
+	ExerciseMethod *account.ExerciseMethod `json:"exercise_method,omitempty"`
 }
 
 // ExerciseTaxResult represents the result of exercise tax calculation
@@ -227,6 +233,8 @@ type ExerciseTaxResult struct {
 	StockOptionDeduction float64 `json:"stock_option_deduction"`
 	NetTaxable           float64 `json:"net_taxable"`
 	EstimatedTax         float64 `json:"estimated_tax"`
+
+	Settlement account.ExerciseSettlement `json:"settlement"`
 }
 
 // CalculateSaleTaxRequest represents a request to calculate sale tax
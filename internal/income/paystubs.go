@@ -0,0 +1,527 @@
+package income
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+)
+
+// PaystubFormat names the payroll system a paystub export came from
+type PaystubFormat string
+
+const (
+	PaystubFormatADP     PaystubFormat = "adp"
+	PaystubFormatWorkday PaystubFormat = "workday"
+	PaystubFormatGeneric PaystubFormat = "generic"
+)
+
+// Paystub is one pay period's earnings, deductions and withholdings
+type Paystub struct {
+	ID                    string        `json:"id"`
+	Employer              string        `json:"employer"`
+	PayDate               time.Time     `json:"pay_date"`
+	PeriodStart           *time.Time    `json:"period_start,omitempty"`
+	PeriodEnd             *time.Time    `json:"period_end,omitempty"`
+	Currency              Currency      `json:"currency"`
+	GrossPay              float64       `json:"gross_pay"` // All earnings, including RSU income
+	IncomeTax             float64       `json:"income_tax"`
+	CPP                   float64       `json:"cpp"`
+	EI                    float64       `json:"ei"`
+	OtherDeductions       float64       `json:"other_deductions"`
+	EmployerContributions float64       `json:"employer_contributions"`
+	RSUIncome             float64       `json:"rsu_income"`
+	RSUWithholding        float64       `json:"rsu_withholding"`
+	NetPay                float64       `json:"net_pay"`
+	Source                PaystubFormat `json:"source"`
+	IncomeRecordID        *string       `json:"income_record_id,omitempty"`
+	CreatedAt             time.Time     `json:"created_at"`
+}
+
+// Withheld is the tax, CPP and EI withheld at source, including on vested shares
+func (p *Paystub) Withheld() float64 {
+	return p.IncomeTax + p.CPP + p.EI + p.RSUWithholding
+}
+
+// ImportPaystubsResponse reports the paystubs read from an export
+type ImportPaystubsResponse struct {
+	Format   PaystubFormat `json:"format"`
+	DryRun   bool          `json:"dry_run"`
+	Imported int           `json:"imported"`
+	Skipped  int           `json:"skipped"` // Already imported for the same employer and pay date
+	Paystubs []*Paystub    `json:"paystubs"`
+}
+
+// ListPaystubsResponse is the response for listing paystubs
+type ListPaystubsResponse struct {
+	Paystubs []*Paystub `json:"paystubs"`
+}
+
+// PaystubYearSummary totals a tax year's paystubs
+type PaystubYearSummary struct {
+	TaxYear               int     `json:"tax_year"`
+	Paystubs              int     `json:"paystubs"`
+	GrossPay              float64 `json:"gross_pay"`
+	IncomeTax             float64 `json:"income_tax"`
+	CPP                   float64 `json:"cpp"`
+	EI                    float64 `json:"ei"`
+	OtherDeductions       float64 `json:"other_deductions"`
+	EmployerContributions float64 `json:"employer_contributions"`
+	RSUIncome             float64 `json:"rsu_income"`
+	RSUWithholding        float64 `json:"rsu_withholding"`
+	NetPay                float64 `json:"net_pay"`
+	TotalWithheld         float64 `json:"total_withheld"`
+}
+
+// paystubColumns maps normalized export headers, lowercase letters and digits only, to
+// paystub fields. ADP and Workday label the same amounts differently.
+var paystubColumns = map[string]string{
+	"employer":                     "employer",
+	"company":                      "employer",
+	"companyname":                  "employer",
+	"paydate":                      "pay_date",
+	"checkdate":                    "pay_date",
+	"paymentdate":                  "pay_date",
+	"periodstart":                  "period_start",
+	"periodbeginning":              "period_start",
+	"periodbegin":                  "period_start",
+	"periodstartdate":              "period_start",
+	"periodend":                    "period_end",
+	"periodending":                 "period_end",
+	"periodenddate":                "period_end",
+	"currency":                     "currency",
+	"grosspay":                     "gross_pay",
+	"gross":                        "gross_pay",
+	"totalgross":                   "gross_pay",
+	"grossamount":                  "gross_pay",
+	"incometax":                    "income_tax",
+	"federalincometax":             "federal_tax",
+	"federaltax":                   "federal_tax",
+	"provincialincometax":          "provincial_tax",
+	"provincialtax":                "provincial_tax",
+	"cpp":                          "cpp",
+	"cppcontributions":             "cpp",
+	"cppqpp":                       "cpp",
+	"canadapensionplan":            "cpp",
+	"ei":                           "ei",
+	"eipremiums":                   "ei",
+	"employmentinsurance":          "ei",
+	"otherdeductions":              "other_deductions",
+	"totaldeductions":              "total_deductions",
+	"employercontributions":        "employer_contributions",
+	"employerbenefits":             "employer_contributions",
+	"employerpaidbenefits":         "employer_contributions",
+	"employerrrsp":                 "employer_contributions",
+	"employercontributionsrrsp":    "employer_contributions",
+	"employercontributionspension": "employer_contributions",
+	"rsuincome":                    "rsu_income",
+	"stockincome":                  "rsu_income",
+	"restrictedstockunits":         "rsu_income",
+	"rsuwithholding":               "rsu_withholding",
+	"rsutaxoffset":                 "rsu_withholding",
+	"stockwithholding":             "rsu_withholding",
+	"taxwithheldonstock":           "rsu_withholding",
+	"netpay":                       "net_pay",
+	"netamount":                    "net_pay",
+	"takehomepay":                  "net_pay",
+}
+
+// paystubDateLayouts are the date formats payroll exports use
+var paystubDateLayouts = []string{"2006-01-02", "01/02/2006", "1/2/2006", "2006/01/02", "Jan 2, 2006", "January 2, 2006", "02-Jan-2006"}
+
+// ParsePaystubsCSV reads paystubs from an ADP or Workday export, one pay period per row.
+// The format is detected from the headers when empty. Split federal and provincial tax
+// columns are added together, and a total deductions column leaves whatever tax, CPP and EI
+// don't account for as other deductions. Rows without an employer column take employer.
+func ParsePaystubsCSV(r io.Reader, format PaystubFormat, employer string) (PaystubFormat, []*Paystub, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int)
+	detected := PaystubFormatGeneric
+	for i, name := range header {
+		key := normalizePaystubHeader(name)
+		switch key {
+		case "checkdate", "periodbeginning", "rsutaxoffset":
+			detected = PaystubFormatADP
+		case "paymentdate", "periodbegin", "canadapensionplan":
+			if detected == PaystubFormatGeneric {
+				detected = PaystubFormatWorkday
+			}
+		}
+		if field, ok := paystubColumns[key]; ok {
+			if _, seen := columns[field]; !seen {
+				columns[field] = i
+			}
+		}
+	}
+	if format == "" {
+		format = detected
+	}
+	if format != PaystubFormatADP && format != PaystubFormatWorkday && format != PaystubFormatGeneric {
+		return "", nil, fmt.Errorf("unsupported paystub format: %s", format)
+	}
+	for _, required := range []string{"pay_date", "gross_pay"} {
+		if _, ok := columns[required]; !ok {
+			return "", nil, fmt.Errorf("CSV is missing a %s column", strings.ReplaceAll(required, "_", " "))
+		}
+	}
+	if _, ok := columns["employer"]; !ok && employer == "" {
+		return "", nil, fmt.Errorf("CSV has no employer column; pass the employer")
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	paystubs := make([]*Paystub, 0)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		amounts := make(map[string]float64)
+		for _, name := range []string{"gross_pay", "income_tax", "federal_tax", "provincial_tax", "cpp", "ei",
+			"other_deductions", "total_deductions", "employer_contributions", "rsu_income", "rsu_withholding", "net_pay"} {
+			value, err := parsePaystubAmount(field(record, name))
+			if err != nil {
+				return "", nil, fmt.Errorf("line %d: invalid %s", line, strings.ReplaceAll(name, "_", " "))
+			}
+			amounts[name] = value
+		}
+
+		payDate, ok := parsePaystubDate(field(record, "pay_date"))
+		if !ok {
+			return "", nil, fmt.Errorf("line %d: invalid pay date %q", line, field(record, "pay_date"))
+		}
+		p := &Paystub{
+			Employer:              field(record, "employer"),
+			PayDate:               payDate,
+			Currency:              Currency(strings.ToUpper(field(record, "currency"))),
+			GrossPay:              amounts["gross_pay"],
+			IncomeTax:             roundToCents(amounts["income_tax"] + amounts["federal_tax"] + amounts["provincial_tax"]),
+			CPP:                   amounts["cpp"],
+			EI:                    amounts["ei"],
+			OtherDeductions:       amounts["other_deductions"],
+			EmployerContributions: amounts["employer_contributions"],
+			RSUIncome:             amounts["rsu_income"],
+			RSUWithholding:        amounts["rsu_withholding"],
+			NetPay:                amounts["net_pay"],
+			Source:                format,
+		}
+		if p.Employer == "" {
+			p.Employer = employer
+		}
+		if p.Currency == "" {
+			p.Currency = CurrencyCAD
+		}
+		if start, ok := parsePaystubDate(field(record, "period_start")); ok {
+			p.PeriodStart = &start
+		}
+		if end, ok := parsePaystubDate(field(record, "period_end")); ok {
+			p.PeriodEnd = &end
+		}
+		if _, ok := columns["other_deductions"]; !ok && amounts["total_deductions"] > 0 {
+			p.OtherDeductions = roundToCents(math.Max(0, amounts["total_deductions"]-p.IncomeTax-p.CPP-p.EI))
+		}
+		if _, ok := columns["net_pay"]; !ok {
+			p.NetPay = roundToCents(p.GrossPay - p.RSUIncome - p.IncomeTax - p.CPP - p.EI - p.OtherDeductions)
+		}
+		if p.GrossPay <= 0 {
+			return "", nil, fmt.Errorf("line %d: gross pay must be greater than zero", line)
+		}
+		paystubs = append(paystubs, p)
+	}
+	return format, paystubs, nil
+}
+
+// ImportPaystubs records paystubs from a payroll export, each as a one-time employment
+// income record for its gross pay. A paystub already imported for the same employer and pay
+// date is skipped, so overlapping exports can be imported again. A dry run parses and
+// reports without saving.
+func (s *Service) ImportPaystubs(ctx context.Context, r io.Reader, format PaystubFormat, employer string, dryRun bool) (*ImportPaystubsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	format, paystubs, err := ParsePaystubsCSV(r, format, employer)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ImportPaystubsResponse{Format: format, DryRun: dryRun, Paystubs: make([]*Paystub, 0, len(paystubs))}
+	for _, p := range paystubs {
+		if p.Currency != CurrencyCAD && p.Currency != CurrencyUSD && p.Currency != CurrencyINR {
+			return nil, fmt.Errorf("invalid currency: %s", p.Currency)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	seen := make(map[string]bool)
+	for _, p := range paystubs {
+		key := p.Employer + "|" + p.PayDate.Format("2006-01-02")
+		if seen[key] {
+			resp.Skipped++
+			continue
+		}
+		seen[key] = true
+
+		var exists bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS(SELECT 1 FROM paystubs WHERE user_id = $1 AND employer = $2 AND pay_date = $3)
+		`, userID, p.Employer, p.PayDate).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check existing paystubs: %w", err)
+		}
+		if exists {
+			resp.Skipped++
+			continue
+		}
+
+		p.ID = uuid.New().String()
+		p.CreatedAt = now
+		resp.Imported++
+		resp.Paystubs = append(resp.Paystubs, p)
+		if dryRun {
+			continue
+		}
+
+		recordID := uuid.New().String()
+		dateReceived := p.PayDate.Format("2006-01-02")
+		description := "Paystub"
+		if p.PeriodStart != nil && p.PeriodEnd != nil {
+			description = fmt.Sprintf("Paystub for %s to %s", p.PeriodStart.Format("2006-01-02"), p.PeriodEnd.Format("2006-01-02"))
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO income_records (id, user_id, source, category, amount, currency, frequency, tax_year, date_received, description, is_taxable, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, true, $11, $12)
+		`, recordID, userID, p.Employer, CategoryEmployment, p.GrossPay, p.Currency, FrequencyOneTime,
+			p.PayDate.Year(), dateReceived, description, now, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create income record: %w", err)
+		}
+		p.IncomeRecordID = &recordID
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO paystubs (id, user_id, employer, pay_date, period_start, period_end, currency, gross_pay,
+				income_tax, cpp, ei, other_deductions, employer_contributions, rsu_income, rsu_withholding,
+				net_pay, source, income_record_id, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		`, p.ID, userID, p.Employer, p.PayDate, p.PeriodStart, p.PeriodEnd, p.Currency, p.GrossPay,
+			p.IncomeTax, p.CPP, p.EI, p.OtherDeductions, p.EmployerContributions, p.RSUIncome, p.RSUWithholding,
+			p.NetPay, p.Source, p.IncomeRecordID, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save paystub: %w", err)
+		}
+	}
+
+	if dryRun {
+		return resp, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return resp, nil
+}
+
+// ListPaystubs lists the user's paystubs for a tax year, newest first
+func (s *Service) ListPaystubs(ctx context.Context, year int) (*ListPaystubsResponse, error) {
+	paystubs, err := s.listPaystubs(ctx, year)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(paystubs)-1; i < j; i, j = i+1, j-1 {
+		paystubs[i], paystubs[j] = paystubs[j], paystubs[i]
+	}
+	return &ListPaystubsResponse{Paystubs: paystubs}, nil
+}
+
+// GetPaystubSummary totals a tax year's paystubs
+func (s *Service) GetPaystubSummary(ctx context.Context, year int) (*PaystubYearSummary, error) {
+	paystubs, err := s.listPaystubs(ctx, year)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &PaystubYearSummary{TaxYear: year, Paystubs: len(paystubs)}
+	for _, p := range paystubs {
+		summary.GrossPay += p.GrossPay
+		summary.IncomeTax += p.IncomeTax
+		summary.CPP += p.CPP
+		summary.EI += p.EI
+		summary.OtherDeductions += p.OtherDeductions
+		summary.EmployerContributions += p.EmployerContributions
+		summary.RSUIncome += p.RSUIncome
+		summary.RSUWithholding += p.RSUWithholding
+		summary.NetPay += p.NetPay
+		summary.TotalWithheld += p.Withheld()
+	}
+	for _, total := range []*float64{&summary.GrossPay, &summary.IncomeTax, &summary.CPP, &summary.EI,
+		&summary.OtherDeductions, &summary.EmployerContributions, &summary.RSUIncome, &summary.RSUWithholding,
+		&summary.NetPay, &summary.TotalWithheld} {
+		*total = roundToCents(*total)
+	}
+	return summary, nil
+}
+
+// DeletePaystub deletes a paystub and the income record it created
+func (s *Service) DeletePaystub(ctx context.Context, id string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	var recordID sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT income_record_id FROM paystubs WHERE id = $1 AND user_id = $2
+	`, id, userID).Scan(&recordID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("paystub not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get paystub: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM paystubs WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete paystub: %w", err)
+	}
+	if recordID.Valid {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM income_records WHERE id = $1 AND user_id = $2`, recordID.String, userID); err != nil {
+			return fmt.Errorf("failed to delete income record: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// paystubWithholding returns what the year's paystubs withheld and the gross pay they cover
+func (s *Service) paystubWithholding(ctx context.Context, year int) (float64, float64, error) {
+	paystubs, err := s.listPaystubs(ctx, year)
+	if err != nil {
+		return 0, 0, err
+	}
+	var withheld, gross float64
+	for _, p := range paystubs {
+		withheld += p.Withheld()
+		gross += p.GrossPay
+	}
+	return withheld, gross, nil
+}
+
+// listPaystubs lists the user's paystubs paid in a tax year, oldest first
+func (s *Service) listPaystubs(ctx context.Context, year int) ([]*Paystub, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	start := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, employer, pay_date, period_start, period_end, currency, gross_pay, income_tax, cpp, ei,
+		       other_deductions, employer_contributions, rsu_income, rsu_withholding, net_pay, source,
+		       income_record_id, created_at
+		FROM paystubs
+		WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list paystubs: %w", err)
+	}
+	defer rows.Close()
+
+	paystubs := make([]*Paystub, 0)
+	for rows.Next() {
+		var p Paystub
+		err := rows.Scan(&p.ID, &p.Employer, &p.PayDate, &p.PeriodStart, &p.PeriodEnd, &p.Currency, &p.GrossPay,
+			&p.IncomeTax, &p.CPP, &p.EI, &p.OtherDeductions, &p.EmployerContributions, &p.RSUIncome,
+			&p.RSUWithholding, &p.NetPay, &p.Source, &p.IncomeRecordID, &p.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan paystub: %w", err)
+		}
+		if p.PayDate.Before(start) || !p.PayDate.Before(start.AddDate(1, 0, 0)) {
+			continue
+		}
+		paystubs = append(paystubs, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list paystubs: %w", err)
+	}
+
+	sort.Slice(paystubs, func(i, j int) bool {
+		return paystubs[i].PayDate.Before(paystubs[j].PayDate)
+	})
+	return paystubs, nil
+}
+
+// normalizePaystubHeader keeps only the lowercase letters and digits of a header, so
+// "Federal Income Tax", "federal_income_tax" and "FederalIncomeTax" match
+func normalizePaystubHeader(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimPrefix(name, "\ufeff")) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// parsePaystubAmount reads an amount that may use thousands separators, a currency symbol,
+// or parentheses for negatives. Deductions are taken as positive whatever their sign.
+func parsePaystubAmount(value string) (float64, error) {
+	value = strings.NewReplacer(",", "", "$", "", "(", "", ")", "", " ", "").Replace(value)
+	if value == "" || value == "-" {
+		return 0, nil
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	return roundToCents(math.Abs(amount)), nil
+}
+
+func parsePaystubDate(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range paystubDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
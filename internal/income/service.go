@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"money/internal/account"
 	"money/internal/auth"
+	"money/internal/jurisdiction"
 )
 
 // Service provides income management functionality
@@ -326,7 +328,7 @@ func (s *Service) GetAnnualSummary(ctx context.Context, year int) (*AnnualIncome
 	taxConfig, err := s.GetTaxConfig(ctx, year)
 	if err != nil {
 		// Use defaults if no config exists
-		taxConfig = s.getDefaultTaxConfig(year)
+		taxConfig = s.defaultTaxConfig(ctx, year)
 	}
 
 	// Calculate taxes
@@ -466,28 +468,29 @@ func (s *Service) SaveTaxConfig(ctx context.Context, req *SaveTaxConfigRequest)
 		return nil, fmt.Errorf("failed to marshal provincial brackets: %w", err)
 	}
 
-	// Use defaults for optional fields
-	cppRate := 0.0595
+	// Use the jurisdiction's defaults for optional fields
+	defaults := s.defaultTaxConfig(ctx, req.TaxYear)
+	cppRate := defaults.CPPRate
 	if req.CPPRate != nil {
 		cppRate = *req.CPPRate
 	}
-	cppMaxPensionable := 68500.0
+	cppMaxPensionable := defaults.CPPMaxPensionableEarnings
 	if req.CPPMaxPensionableEarnings != nil {
 		cppMaxPensionable = *req.CPPMaxPensionableEarnings
 	}
-	cppBasicExemption := 3500.0
+	cppBasicExemption := defaults.CPPBasicExemption
 	if req.CPPBasicExemption != nil {
 		cppBasicExemption = *req.CPPBasicExemption
 	}
-	eiRate := 0.0163
+	eiRate := defaults.EIRate
 	if req.EIRate != nil {
 		eiRate = *req.EIRate
 	}
-	eiMaxInsurable := 63200.0
+	eiMaxInsurable := defaults.EIMaxInsurableEarnings
 	if req.EIMaxInsurableEarnings != nil {
 		eiMaxInsurable = *req.EIMaxInsurableEarnings
 	}
-	basicPersonalAmount := 15705.0
+	basicPersonalAmount := defaults.BasicPersonalAmount
 	if req.BasicPersonalAmount != nil {
 		basicPersonalAmount = *req.BasicPersonalAmount
 	}
@@ -536,6 +539,15 @@ func (s *Service) SaveTaxConfig(ctx context.Context, req *SaveTaxConfigRequest)
 // Helper functions
 
 func (s *Service) convertToAnnualAmount(amount float64, frequency IncomeFrequency) float64 {
+	return annualAmount(amount, frequency)
+}
+
+// AnnualAmount is the record's amount over a full year at its frequency
+func (r *IncomeRecord) AnnualAmount() float64 {
+	return annualAmount(r.Amount, r.Frequency)
+}
+
+func annualAmount(amount float64, frequency IncomeFrequency) float64 {
 	switch frequency {
 	case FrequencyOneTime:
 		return amount
@@ -566,10 +578,12 @@ func (s *Service) getStockOptionsBenefit(ctx context.Context, userID string, yea
 	return benefit
 }
 
+// getDefaultTaxConfig returns the Canadian (Ontario) defaults
 func (s *Service) getDefaultTaxConfig(year int) *TaxConfiguration {
 	return &TaxConfiguration{
 		TaxYear:                   year,
 		Province:                  "ON",
+		Jurisdiction:              jurisdiction.CA,
 		FederalBrackets:           defaultFederalBrackets,
 		ProvincialBrackets:        defaultProvincialBrackets,
 		CPPRate:                   0.0595,
@@ -583,15 +597,22 @@ func (s *Service) getDefaultTaxConfig(year int) *TaxConfiguration {
 }
 
 func (s *Service) calculateTaxes(totalTaxableIncome, employmentIncome float64, config *TaxConfiguration) *TaxBreakdown {
-	// Calculate federal tax using progressive brackets
-	federalTax := s.calculateProgressiveTax(totalTaxableIncome, config.FederalBrackets)
+	// The basic personal amount is either credited at the jurisdiction's rate (Canada) or
+	// deducted from income, like the US standard deduction and UK personal allowance
+	bracketIncome := totalTaxableIncome
+	federalCredit := 0.0
+	if rate := jurisdiction.Lookup(string(config.Jurisdiction)).PersonalAmountCreditRate; rate > 0 {
+		federalCredit = config.BasicPersonalAmount * rate
+	} else {
+		bracketIncome = math.Max(0, totalTaxableIncome-config.BasicPersonalAmount)
+	}
 
-	// Apply basic personal amount credit (15% of basic personal amount)
-	federalCredit := config.BasicPersonalAmount * 0.15
+	// Calculate federal tax using progressive brackets
+	federalTax := s.calculateProgressiveTax(bracketIncome, config.FederalBrackets)
 	federalTax = math.Max(0, federalTax-federalCredit)
 
 	// Calculate provincial tax using progressive brackets
-	provincialTax := s.calculateProgressiveTax(totalTaxableIncome, config.ProvincialBrackets)
+	provincialTax := s.calculateProgressiveTax(bracketIncome, config.ProvincialBrackets)
 
 	// Calculate CPP contribution (only on employment income)
 	cppContribution := 0.0
@@ -600,10 +621,13 @@ func (s *Service) calculateTaxes(totalTaxableIncome, employmentIncome float64, c
 		cppContribution = pensionableEarnings * config.CPPRate
 	}
 
-	// Calculate EI contribution (only on employment income)
+	// Calculate EI contribution (only on employment income); a zero maximum means uncapped
 	eiContribution := 0.0
 	if employmentIncome > 0 {
-		insurableEarnings := math.Min(employmentIncome, config.EIMaxInsurableEarnings)
+		insurableEarnings := employmentIncome
+		if config.EIMaxInsurableEarnings > 0 {
+			insurableEarnings = math.Min(employmentIncome, config.EIMaxInsurableEarnings)
+		}
 		eiContribution = insurableEarnings * config.EIRate
 	}
 
@@ -616,8 +640,8 @@ func (s *Service) calculateTaxes(totalTaxableIncome, employmentIncome float64, c
 	}
 
 	// Calculate marginal tax rate (combined federal + provincial at highest bracket)
-	marginalTaxRate := s.getMarginalRate(totalTaxableIncome, config.FederalBrackets) +
-		s.getMarginalRate(totalTaxableIncome, config.ProvincialBrackets)
+	marginalTaxRate := s.getMarginalRate(bracketIncome, config.FederalBrackets) +
+		s.getMarginalRate(bracketIncome, config.ProvincialBrackets)
 
 	return &TaxBreakdown{
 		FederalTax:       federalTax,
@@ -692,6 +716,12 @@ const (
 	StockOptionDeductionRate = 0.5
 )
 
+// CalculateIncomeTax estimates tax on income with a year's default tax configuration. It reads
+// no user data, so calculators can use it for hypothetical incomes.
+func (s *Service) CalculateIncomeTax(taxableIncome, employmentIncome float64, year int) *TaxBreakdown {
+	return s.calculateTaxes(taxableIncome, employmentIncome, s.getDefaultTaxConfig(year))
+}
+
 // CalculateExerciseTax calculates the tax impact of exercising stock options
 // This uses proper decimal arithmetic by working with integers where possible
 func (s *Service) CalculateExerciseTax(req *CalculateExerciseTaxRequest) *ExerciseTaxResult {
@@ -724,6 +754,8 @@ func (s *Service) CalculateExerciseTax(req *CalculateExerciseTaxRequest) *Exerci
 		StockOptionDeduction: math.Round(stockOptionDeduction*100) / 100,
 		NetTaxable:           math.Round(netTaxable*100) / 100,
 		EstimatedTax:         math.Round(estimatedTax*100) / 100,
+		// The estimated tax is withheld, so cashless and same-day exercises sell shares for it too
+		Settlement: account.SettleExercise(req.ExerciseMethod, req.Quantity, req.StrikePrice, req.FMVAtExercise, estimatedTax),
 	}
 }
 
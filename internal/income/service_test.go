@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -16,6 +18,8 @@ import (
 	_ "modernc.org/sqlite"
 
 	"money/internal/auth"
+	"money/internal/civil"
+	"money/internal/jurisdiction"
 )
 
 var (
@@ -125,8 +129,12 @@ func setupTestDB(t *testing.T) *sql.DB {
 
 func cleanupTestDB(t *testing.T, db *sql.DB) {
 	t.Helper()
+	_, _ = db.Exec("DELETE FROM paystubs WHERE user_id LIKE 'test-%'")
+	_, _ = db.Exec("DELETE FROM bonus_plans WHERE user_id LIKE 'test-%'")
 	_, _ = db.Exec("DELETE FROM income_records WHERE id LIKE 'test-%' OR user_id LIKE 'test-%'")
 	_, _ = db.Exec("DELETE FROM tax_configurations WHERE id LIKE 'test-%' OR user_id LIKE 'test-%'")
+	_, _ = db.Exec("DELETE FROM self_employment_tax_settings WHERE user_id LIKE 'test-%'")
+	_, _ = db.Exec("DELETE FROM tax_installments WHERE user_id LIKE 'test-%'")
 	_, _ = db.Exec("DELETE FROM users WHERE id LIKE 'test-%'")
 }
 
@@ -502,3 +510,298 @@ func TestGetAnnualSummary_Success(t *testing.T) {
 		t.Errorf("Expected total gross income 61000, got %f", summary.TotalGrossIncome)
 	}
 }
+
+func TestGetSelfEmploymentTaxStatus_WarnsWithoutSetAside(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-self-employment-1"
+	createTestUser(t, db, userID)
+	ctx := createAuthContext(userID)
+	service := NewService(db)
+
+	year := time.Now().Year() - 1
+	received := fmt.Sprintf("%d-06-01", year)
+	_, err := service.CreateIncomeRecord(ctx, &CreateIncomeRecordRequest{
+		Source:       "Consulting",
+		Category:     CategoryBusiness,
+		Amount:       50000.00,
+		Currency:     CurrencyCAD,
+		Frequency:    FrequencyOneTime,
+		TaxYear:      year,
+		DateReceived: &received,
+	})
+	if err != nil {
+		t.Fatalf("CreateIncomeRecord failed: %v", err)
+	}
+
+	// Act
+	status, err := service.GetSelfEmploymentTaxStatus(ctx, year)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetSelfEmploymentTaxStatus failed: %v", err)
+	}
+	// Both CPP shares: (50000 - 3500) * 0.0595 * 2
+	if status.Estimate.CPPContribution != 5533.50 {
+		t.Errorf("Expected CPP contribution 5533.50, got %.2f", status.Estimate.CPPContribution)
+	}
+	if status.BusinessIncomeToDate != 50000.00 || status.EstimatedTaxToDate != status.Estimate.TotalTax {
+		t.Errorf("Expected the full year's tax to be due for a past year, got %.2f of %.2f", status.EstimatedTaxToDate, status.Estimate.TotalTax)
+	}
+	if !status.IsBehind || status.SetAsideSource != SetAsideSourceNone || status.Warning == nil {
+		t.Errorf("Expected a warning with no set-aside designated, got %+v", status)
+	}
+}
+
+func TestGenerateInstallmentSchedule_AppliesPayments(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-installments-1"
+	createTestUser(t, db, userID)
+	ctx := createAuthContext(userID)
+	service := NewService(db)
+
+	year := time.Now().Year() + 1
+	_, err := service.CreateIncomeRecord(ctx, &CreateIncomeRecordRequest{
+		Source:    "Rental property",
+		Category:  CategoryRental,
+		Amount:    40000.00,
+		Currency:  CurrencyCAD,
+		Frequency: FrequencyAnnually,
+		TaxYear:   year,
+	})
+	if err != nil {
+		t.Fatalf("CreateIncomeRecord failed: %v", err)
+	}
+
+	// Act
+	schedule, err := service.GenerateInstallmentSchedule(ctx, year, &GenerateInstallmentsRequest{})
+	if err != nil {
+		t.Fatalf("GenerateInstallmentSchedule failed: %v", err)
+	}
+	first := schedule.Installments[0]
+	_, err = service.RecordInstallmentPayment(ctx, first.ID, &RecordInstallmentPaymentRequest{
+		Amount:   first.AmountDue,
+		PaidDate: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("RecordInstallmentPayment failed: %v", err)
+	}
+	regenerated, err := service.GenerateInstallmentSchedule(ctx, year, &GenerateInstallmentsRequest{})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GenerateInstallmentSchedule failed: %v", err)
+	}
+	if len(regenerated.Installments) != 4 || !regenerated.Required {
+		t.Fatalf("Expected 4 required installments, got %d (required %v)", len(regenerated.Installments), regenerated.Required)
+	}
+	if regenerated.Installments[0].DueDate.Month() != time.March || regenerated.Installments[3].DueDate.Month() != time.December {
+		t.Errorf("Expected CRA due dates from March to December, got %v to %v", regenerated.Installments[0].DueDate, regenerated.Installments[3].DueDate)
+	}
+	if regenerated.Installments[0].Status != InstallmentStatusPaid || regenerated.Installments[1].Status != InstallmentStatusUpcoming {
+		t.Errorf("Expected first installment paid and second upcoming, got %s and %s",
+			regenerated.Installments[0].Status, regenerated.Installments[1].Status)
+	}
+	if regenerated.TotalPaid != first.AmountDue {
+		t.Errorf("Expected total paid %.2f, got %.2f", first.AmountDue, regenerated.TotalPaid)
+	}
+}
+
+func TestDefaultTaxConfig_FollowsJurisdiction(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-jurisdiction-1"
+	createTestUser(t, db, userID)
+	ctx := createAuthContext(userID)
+	service := NewService(db)
+	usCtx := jurisdiction.WithProfile(ctx, jurisdiction.Lookup("US"))
+	ukCtx := jurisdiction.WithProfile(ctx, jurisdiction.Lookup("UK"))
+	year := time.Now().Year() + 1
+
+	// Act
+	us := service.calculateTaxes(100000, 100000, service.defaultTaxConfig(usCtx, year))
+	uk := service.calculateTaxes(50000, 50000, service.defaultTaxConfig(ukCtx, year))
+	schedule, err := service.GenerateInstallmentSchedule(usCtx, year, &GenerateInstallmentsRequest{})
+	if err != nil {
+		t.Fatalf("GenerateInstallmentSchedule failed: %v", err)
+	}
+	_, ukErr := service.GenerateInstallmentSchedule(ukCtx, year, &GenerateInstallmentsRequest{})
+
+	// Assert
+	// The standard deduction leaves 84,250 in the brackets: 1,192.50 + 4,386 + 7,870.50
+	if math.Abs(us.FederalTax-13449) > 0.01 || math.Abs(us.CPPContribution-6200) > 0.01 || math.Abs(us.EIContribution-1450) > 0.01 {
+		t.Errorf("Expected US federal tax 13449, Social Security 6200 and Medicare 1450, got %+v", us)
+	}
+	// The personal allowance leaves 37,430 at the basic rate; NI is 8% above 12,570
+	if math.Abs(uk.FederalTax-7486) > 0.01 || math.Abs(uk.CPPContribution-2994.40) > 0.01 {
+		t.Errorf("Expected UK income tax 7486 and NI 2994.40, got %+v", uk)
+	}
+	if schedule.Jurisdiction != JurisdictionIRS || schedule.Installments[3].DueDate.Year() != year+1 {
+		t.Errorf("Expected IRS installments for a US user, got %s", schedule.Jurisdiction)
+	}
+	for _, inst := range schedule.Installments {
+		if !jurisdiction.Lookup("US").IsBusinessDay(civil.DateOf(inst.DueDate)) {
+			t.Errorf("Expected installment due %s to be a US business day", inst.DueDate.Format("2006-01-02"))
+		}
+	}
+	if ukErr == nil {
+		t.Error("Expected quarterly installments to be refused for a UK user")
+	}
+}
+
+func TestImportPaystubs_RecordsIncomeAndWithholding(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-paystubs-1"
+	createTestUser(t, db, userID)
+	ctx := createAuthContext(userID)
+	service := NewService(db)
+
+	year := time.Now().Year() + 1
+	export := fmt.Sprintf(`Company,Check Date,Period Beginning,Period Ending,Gross Pay,Federal Income Tax,Provincial Income Tax,CPP Contributions,EI Premiums,Total Deductions,RSU Income,RSU Tax Offset,Net Pay
+Acme Corp,01/15/%[1]d,01/01/%[1]d,01/15/%[1]d,"5,000.00",700.00,300.00,250.00,80.00,"1,430.00",0,0,"3,570.00"
+Acme Corp,01/31/%[1]d,01/16/%[1]d,01/31/%[1]d,"9,000.00",700.00,300.00,250.00,80.00,"1,430.00","4,000.00","1,800.00","3,570.00"
+`, year)
+
+	// Act
+	imported, err := service.ImportPaystubs(ctx, strings.NewReader(export), "", "", false)
+	if err != nil {
+		t.Fatalf("ImportPaystubs failed: %v", err)
+	}
+	again, err := service.ImportPaystubs(ctx, strings.NewReader(export), "", "", false)
+	if err != nil {
+		t.Fatalf("ImportPaystubs failed: %v", err)
+	}
+
+	// Assert
+	if imported.Format != PaystubFormatADP || imported.Imported != 2 {
+		t.Fatalf("Expected 2 ADP paystubs imported, got %d as %s", imported.Imported, imported.Format)
+	}
+	if again.Imported != 0 || again.Skipped != 2 {
+		t.Errorf("Expected the second import to skip both paystubs, got %d imported and %d skipped", again.Imported, again.Skipped)
+	}
+	first := imported.Paystubs[0]
+	if first.IncomeTax != 1000 || first.OtherDeductions != 100 {
+		t.Errorf("Expected 1000 income tax and 100 other deductions, got %.2f and %.2f", first.IncomeTax, first.OtherDeductions)
+	}
+
+	records, err := service.ListIncomeRecords(ctx, &ListIncomeRecordsRequest{Year: &year})
+	if err != nil {
+		t.Fatalf("ListIncomeRecords failed: %v", err)
+	}
+	if len(records.Records) != 2 {
+		t.Errorf("Expected an income record per paystub, got %d", len(records.Records))
+	}
+
+	summary, err := service.GetPaystubSummary(ctx, year)
+	if err != nil {
+		t.Fatalf("GetPaystubSummary failed: %v", err)
+	}
+	if summary.GrossPay != 14000 || summary.RSUWithholding != 1800 || summary.TotalWithheld != 4460 {
+		t.Errorf("Expected 14000 gross, 1800 RSU withholding and 4460 withheld, got %+v", summary)
+	}
+
+	schedule, err := service.GetInstallmentSchedule(ctx, year, "")
+	if err != nil {
+		t.Fatalf("GetInstallmentSchedule failed: %v", err)
+	}
+	if schedule.TaxWithheld != 4460 {
+		t.Errorf("Expected withholding from the paystubs, got %.2f", schedule.TaxWithheld)
+	}
+}
+
+func TestBonusPlan_PayoutsRecordIncomeAndAttainment(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-bonus-1"
+	createTestUser(t, db, userID)
+	ctx := createAuthContext(userID)
+	service := NewService(db)
+
+	confidence := 0.8
+	bonusPlan, err := service.CreateBonusPlan(ctx, &CreateBonusPlanRequest{
+		Name:          "Annual bonus",
+		Employer:      "Acme Corp",
+		PlanType:      BonusPlanBonus,
+		TargetPercent: 0.15,
+		PayoutMonth:   3,
+		Confidence:    &confidence,
+	})
+	if err != nil {
+		t.Fatalf("CreateBonusPlan failed: %v", err)
+	}
+	dpspPlan, err := service.CreateBonusPlan(ctx, &CreateBonusPlanRequest{
+		Name:          "Profit sharing",
+		Employer:      "Acme Corp",
+		PlanType:      BonusPlanDPSP,
+		TargetPercent: 0.05,
+		PayoutMonth:   12,
+	})
+	if err != nil {
+		t.Fatalf("CreateBonusPlan failed: %v", err)
+	}
+
+	// Act
+	baseSalary := 100000.0
+	payout, err := service.RecordBonusPayout(ctx, bonusPlan.ID, &RecordBonusPayoutRequest{
+		PayoutDate: "2024-03-15",
+		Amount:     12000,
+		BaseSalary: &baseSalary,
+	})
+	if err != nil {
+		t.Fatalf("RecordBonusPayout failed: %v", err)
+	}
+	dpspPayout, err := service.RecordBonusPayout(ctx, dpspPlan.ID, &RecordBonusPayoutRequest{
+		PayoutDate: "2024-12-20",
+		Amount:     5000,
+	})
+	if err != nil {
+		t.Fatalf("RecordBonusPayout failed: %v", err)
+	}
+
+	// Assert
+	if payout.IncomeRecordID == nil {
+		t.Fatal("Expected the cash bonus to be recorded as income")
+	}
+	if dpspPayout.IncomeRecordID != nil {
+		t.Error("Expected no income record for a DPSP contribution")
+	}
+
+	plan, err := service.GetBonusPlan(ctx, bonusPlan.ID)
+	if err != nil {
+		t.Fatalf("GetBonusPlan failed: %v", err)
+	}
+	if len(plan.Payouts) != 1 || plan.AverageAttainment == nil || *plan.AverageAttainment != 0.8 {
+		t.Errorf("Expected one payout at 80%% of target, got %d payouts with attainment %v", len(plan.Payouts), plan.AverageAttainment)
+	}
+	if expected := plan.ExpectedPayout(100000); expected != 12000 {
+		t.Errorf("Expected a payout of 12000 on 100000 at 80%% confidence, got %.2f", expected)
+	}
+
+	year := 2024
+	records, err := service.ListIncomeRecords(ctx, &ListIncomeRecordsRequest{Year: &year})
+	if err != nil {
+		t.Fatalf("ListIncomeRecords failed: %v", err)
+	}
+	if len(records.Records) != 1 || records.Records[0].Amount != 12000 {
+		t.Errorf("Expected one 12000 income record, got %+v", records.Records)
+	}
+
+	if err := service.DeleteBonusPayout(ctx, payout.ID); err != nil {
+		t.Fatalf("DeleteBonusPayout failed: %v", err)
+	}
+	if _, err := service.GetIncomeRecord(ctx, *payout.IncomeRecordID); err == nil {
+		t.Error("Expected the payout's income record to be deleted with it")
+	}
+}
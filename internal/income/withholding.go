@@ -0,0 +1,288 @@
+package income
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"money/internal/auth"
+)
+
+// SetAsideSource identifies where money set aside for self-employment tax is tracked
+type SetAsideSource string
+
+const (
+	SetAsideSourceNone     SetAsideSource = "none"
+	SetAsideSourceAccount  SetAsideSource = "account"
+	SetAsideSourceEnvelope SetAsideSource = "envelope"
+)
+
+// setAsideTolerance is the shortfall ignored before warning, so rounding doesn't raise alerts
+const setAsideTolerance = 1.0
+
+// SelfEmploymentTaxSettings designates where tax on self-employment income is set aside
+type SelfEmploymentTaxSettings struct {
+	UserID             string    `json:"user_id"`
+	SetAsideAccountID  *string   `json:"set_aside_account_id,omitempty"`
+	SetAsideEnvelopeID *string   `json:"set_aside_envelope_id,omitempty"` // Takes precedence over the account balance
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// SaveSelfEmploymentTaxSettingsRequest represents a request to designate the set-aside account or envelope
+type SaveSelfEmploymentTaxSettingsRequest struct {
+	SetAsideAccountID  *string `json:"set_aside_account_id,omitempty"`
+	SetAsideEnvelopeID *string `json:"set_aside_envelope_id,omitempty"`
+}
+
+// SelfEmploymentTaxEstimate is the tax attributable to business income on top of other income
+type SelfEmploymentTaxEstimate struct {
+	IncomeTax       float64 `json:"income_tax"`       // Federal and provincial tax added by the business income
+	CPPContribution float64 `json:"cpp_contribution"` // Both employee and employer CPP shares on business income
+	TotalTax        float64 `json:"total_tax"`
+	EffectiveRate   float64 `json:"effective_rate"` // TotalTax as a share of business income
+}
+
+// SelfEmploymentTaxStatus compares estimated tax on self-employment income with what has been set aside
+type SelfEmploymentTaxStatus struct {
+	TaxYear              int                       `json:"tax_year"`
+	BusinessIncome       float64                   `json:"business_income"`         // Annualized business income for the year
+	BusinessIncomeToDate float64                   `json:"business_income_to_date"` // Business income earned so far this year
+	Estimate             SelfEmploymentTaxEstimate `json:"estimate"`                // Tax on the full year's business income
+	EstimatedTaxToDate   float64                   `json:"estimated_tax_to_date"`   // Tax on business income earned so far
+	SetAside             float64                   `json:"set_aside"`
+	SetAsideSource       SetAsideSource            `json:"set_aside_source"`
+	Shortfall            float64                   `json:"shortfall"` // EstimatedTaxToDate - SetAside, floored at zero
+	IsBehind             bool                      `json:"is_behind"`
+	Warning              *string                   `json:"warning,omitempty"`
+}
+
+// GetSelfEmploymentTaxSettings returns where the user sets aside self-employment tax
+func (s *Service) GetSelfEmploymentTaxSettings(ctx context.Context) (*SelfEmploymentTaxSettings, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	settings := &SelfEmploymentTaxSettings{UserID: userID}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT set_aside_account_id, set_aside_envelope_id, updated_at
+		FROM self_employment_tax_settings
+		WHERE user_id = $1
+	`, userID).Scan(&settings.SetAsideAccountID, &settings.SetAsideEnvelopeID, &settings.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get self-employment tax settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// SaveSelfEmploymentTaxSettings designates the account or envelope that holds self-employment tax
+func (s *Service) SaveSelfEmploymentTaxSettings(ctx context.Context, req *SaveSelfEmploymentTaxSettingsRequest) (*SelfEmploymentTaxSettings, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	if req.SetAsideAccountID != nil {
+		var exists bool
+		err := s.db.QueryRowContext(ctx, `
+			SELECT EXISTS(SELECT 1 FROM accounts WHERE id = $1 AND user_id = $2)
+		`, *req.SetAsideAccountID, userID).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify account: %w", err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("account not found")
+		}
+	}
+	if req.SetAsideEnvelopeID != nil {
+		var exists bool
+		err := s.db.QueryRowContext(ctx, `
+			SELECT EXISTS(
+				SELECT 1 FROM envelopes e JOIN accounts a ON a.id = e.account_id
+				WHERE e.id = $1 AND a.user_id = $2
+			)
+		`, *req.SetAsideEnvelopeID, userID).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify envelope: %w", err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("envelope not found")
+		}
+	}
+
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO self_employment_tax_settings (user_id, set_aside_account_id, set_aside_envelope_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+			set_aside_account_id = excluded.set_aside_account_id,
+			set_aside_envelope_id = excluded.set_aside_envelope_id,
+			updated_at = excluded.updated_at
+	`, userID, req.SetAsideAccountID, req.SetAsideEnvelopeID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save self-employment tax settings: %w", err)
+	}
+
+	return &SelfEmploymentTaxSettings{
+		UserID:             userID,
+		SetAsideAccountID:  req.SetAsideAccountID,
+		SetAsideEnvelopeID: req.SetAsideEnvelopeID,
+		UpdatedAt:          now,
+	}, nil
+}
+
+// GetSelfEmploymentTaxStatus estimates tax owed on the year's business income and checks
+// whether the designated set-aside keeps up with the tax on income earned so far
+func (s *Service) GetSelfEmploymentTaxStatus(ctx context.Context, year int) (*SelfEmploymentTaxStatus, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	records, err := s.ListIncomeRecords(ctx, &ListIncomeRecordsRequest{Year: &year})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	elapsed := yearElapsedFraction(year, now)
+
+	var employment, business, businessToDate, otherTaxable float64
+	for _, record := range records.Records {
+		annualAmount := s.convertToAnnualAmount(record.Amount, record.Frequency)
+		switch {
+		case record.Category == CategoryBusiness:
+			business += annualAmount
+			businessToDate += incomeToDate(record, annualAmount, elapsed, now)
+		case record.IsTaxable:
+			otherTaxable += annualAmount
+		}
+		if record.Category == CategoryEmployment {
+			employment += annualAmount
+		}
+	}
+	otherTaxable += s.getStockOptionsBenefit(ctx, userID, year)
+
+	taxConfig, err := s.GetTaxConfig(ctx, year)
+	if err != nil {
+		taxConfig = s.defaultTaxConfig(ctx, year)
+	}
+
+	status := &SelfEmploymentTaxStatus{
+		TaxYear:              year,
+		BusinessIncome:       business,
+		BusinessIncomeToDate: businessToDate,
+		Estimate:             *s.estimateSelfEmploymentTax(otherTaxable, employment, business, taxConfig),
+	}
+	status.EstimatedTaxToDate = roundToCents(status.Estimate.EffectiveRate * businessToDate)
+
+	settings, err := s.GetSelfEmploymentTaxSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status.SetAside, status.SetAsideSource, err = s.getSetAsideAmount(ctx, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	status.Shortfall = roundToCents(math.Max(0, status.EstimatedTaxToDate-status.SetAside))
+	if status.Shortfall > setAsideTolerance {
+		status.IsBehind = true
+		warning := fmt.Sprintf("Set-aside of %.2f is %.2f behind the %.2f estimated tax on %.2f of self-employment income earned so far",
+			status.SetAside, status.Shortfall, status.EstimatedTaxToDate, businessToDate)
+		if status.SetAsideSource == SetAsideSourceNone {
+			warning = fmt.Sprintf("No account or envelope is designated for self-employment tax; %.2f is estimated to be owed so far", status.EstimatedTaxToDate)
+		}
+		status.Warning = &warning
+	}
+
+	return status, nil
+}
+
+// estimateSelfEmploymentTax computes the tax added by business income on top of other taxable
+// income. Self-employed workers pay both the employee and employer CPP shares.
+func (s *Service) estimateSelfEmploymentTax(otherTaxable, employment, business float64, config *TaxConfiguration) *SelfEmploymentTaxEstimate {
+	estimate := &SelfEmploymentTaxEstimate{}
+	if business <= 0 {
+		return estimate
+	}
+
+	without := s.calculateTaxes(otherTaxable, 0, config)
+	with := s.calculateTaxes(otherTaxable+business, 0, config)
+	estimate.IncomeTax = with.FederalTax + with.ProvincialTax - without.FederalTax - without.ProvincialTax
+
+	// CPP on business income only covers earnings employment hasn't already used up
+	pensionable := func(earnings float64) float64 {
+		return math.Max(0, math.Min(earnings, config.CPPMaxPensionableEarnings)-config.CPPBasicExemption)
+	}
+	estimate.CPPContribution = (pensionable(employment+business) - pensionable(employment)) * config.CPPRate * 2
+
+	estimate.IncomeTax = roundToCents(estimate.IncomeTax)
+	estimate.CPPContribution = roundToCents(estimate.CPPContribution)
+	estimate.TotalTax = roundToCents(estimate.IncomeTax + estimate.CPPContribution)
+	estimate.EffectiveRate = estimate.TotalTax / business
+
+	return estimate
+}
+
+// getSetAsideAmount reads the amount held in the designated envelope or account
+func (s *Service) getSetAsideAmount(ctx context.Context, settings *SelfEmploymentTaxSettings) (float64, SetAsideSource, error) {
+	if settings.SetAsideEnvelopeID != nil {
+		var allocated float64
+		err := s.db.QueryRowContext(ctx, `
+			SELECT allocated FROM envelopes WHERE id = $1
+		`, *settings.SetAsideEnvelopeID).Scan(&allocated)
+		if err != nil && err != sql.ErrNoRows {
+			return 0, SetAsideSourceNone, fmt.Errorf("failed to get set-aside envelope: %w", err)
+		}
+		return allocated, SetAsideSourceEnvelope, nil
+	}
+
+	if settings.SetAsideAccountID != nil {
+		var amount float64
+		err := s.db.QueryRowContext(ctx, `
+			SELECT amount FROM balances WHERE account_id = $1 ORDER BY date DESC LIMIT 1
+		`, *settings.SetAsideAccountID).Scan(&amount)
+		if err != nil && err != sql.ErrNoRows {
+			return 0, SetAsideSourceNone, fmt.Errorf("failed to get set-aside balance: %w", err)
+		}
+		return amount, SetAsideSourceAccount, nil
+	}
+
+	return 0, SetAsideSourceNone, nil
+}
+
+// yearElapsedFraction returns how much of the tax year has passed, between 0 and 1
+func yearElapsedFraction(year int, now time.Time) float64 {
+	start := time.Date(year, 1, 1, 0, 0, 0, 0, now.Location())
+	end := start.AddDate(1, 0, 0)
+	switch {
+	case now.Before(start):
+		return 0
+	case !now.Before(end):
+		return 1
+	}
+	return now.Sub(start).Hours() / end.Sub(start).Hours()
+}
+
+// incomeToDate returns the part of a record's annual amount earned by now. One-time income
+// counts once received; recurring income accrues evenly over the year.
+func incomeToDate(record IncomeRecord, annualAmount, elapsed float64, now time.Time) float64 {
+	if record.Frequency != FrequencyOneTime {
+		return annualAmount * elapsed
+	}
+	if record.DateReceived != nil && len(*record.DateReceived) >= 10 {
+		if received, err := time.Parse("2006-01-02", (*record.DateReceived)[:10]); err == nil && received.After(now) {
+			return 0
+		}
+	}
+	return annualAmount
+}
+
+// roundToCents rounds an amount to two decimal places
+func roundToCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,287 @@
+// Package inflation fetches consumer price index data from national statistics agencies and
+// derives the inflation rates used to default expense growth and to report real spending.
+package inflation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"money/internal/background"
+	"money/internal/logger"
+)
+
+// inflationLog logs for the inflation module
+var inflationLog = logger.Module("inflation")
+
+// Region is the economy a CPI series measures
+type Region string
+
+const (
+	RegionCanada       Region = "CA"
+	RegionUnitedStates Region = "US"
+)
+
+// ErrNoData is returned when not enough CPI readings are stored to compute a rate
+var ErrNoData = errors.New("not enough CPI data")
+
+// Observation is a CPI reading for a month
+type Observation struct {
+	Region Region  `json:"region"`
+	Month  string  `json:"month"` // YYYY-MM
+	Index  float64 `json:"index"`
+	Source string  `json:"source"`
+}
+
+// Source fetches recent CPI readings for one region
+type Source interface {
+	Name() string
+	Region() Region
+	Fetch(ctx context.Context, client *http.Client) ([]Observation, error)
+}
+
+// Rate is an inflation rate between two CPI readings
+type Rate struct {
+	Region Region  `json:"region"`
+	Rate   float64 `json:"rate"` // e.g. 0.031 for 3.1%
+	From   string  `json:"from"` // YYYY-MM
+	To     string  `json:"to"`   // YYYY-MM, the latest reading used
+	Source string  `json:"source"`
+}
+
+// RefreshResponse reports what a refresh stored per region
+type RefreshResponse struct {
+	Stored map[Region]int    `json:"stored"`
+	Errors map[Region]string `json:"errors,omitempty"`
+}
+
+// ObservationsResponse lists a region's stored CPI readings, oldest first
+type ObservationsResponse struct {
+	Region       Region        `json:"region"`
+	Observations []Observation `json:"observations"`
+}
+
+// Service provides CPI data and inflation rates
+type Service struct {
+	db      *sql.DB
+	client  *http.Client
+	sources map[Region]Source
+}
+
+// NewService creates an inflation service reading Canadian CPI from Statistics Canada and US
+// CPI from the Bureau of Labor Statistics
+func NewService(db *sql.DB, blsAPIKey string) *Service {
+	return NewServiceWithSources(db, NewStatCanSource(""), NewBLSSource("", blsAPIKey))
+}
+
+// NewServiceWithSources creates an inflation service with the given sources, one per region
+func NewServiceWithSources(db *sql.DB, sources ...Source) *Service {
+	s := &Service{
+		db:      db,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		sources: make(map[Region]Source, len(sources)),
+	}
+	for _, source := range sources {
+		s.sources[source.Region()] = source
+	}
+	return s
+}
+
+// ParseRegion normalizes a region code and checks a source covers it
+func (s *Service) ParseRegion(value string) (Region, error) {
+	region := Region(strings.ToUpper(value))
+	if _, ok := s.sources[region]; !ok {
+		return "", fmt.Errorf("unsupported CPI region: %s", value)
+	}
+	return region, nil
+}
+
+// Refresh fetches the latest readings from every source and stores them. A failing source
+// is reported without stopping the others.
+func (s *Service) Refresh(ctx context.Context) (*RefreshResponse, error) {
+	resp := &RefreshResponse{Stored: make(map[Region]int), Errors: make(map[Region]string)}
+	for region, source := range s.sources {
+		observations, err := source.Fetch(ctx, s.client)
+		if err != nil {
+			inflationLog.Warn("CPI fetch failed", "source", source.Name(), "error", err)
+			resp.Errors[region] = err.Error()
+			continue
+		}
+		stored, err := s.store(ctx, observations)
+		if err != nil {
+			return nil, err
+		}
+		resp.Stored[region] = stored
+	}
+	if len(resp.Errors) == 0 {
+		resp.Errors = nil
+	}
+	return resp, nil
+}
+
+// store upserts readings, since agencies revise recent months
+func (s *Service) store(ctx context.Context, observations []Observation) (int, error) {
+	now := time.Now()
+	for _, o := range observations {
+		_, err := s.db.ExecContext(ctx, `
+			INSERT INTO cpi_observations (region, month, index_value, source, fetched_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (region, month) DO UPDATE SET
+				index_value = excluded.index_value,
+				source = excluded.source,
+				fetched_at = excluded.fetched_at
+		`, o.Region, o.Month, o.Index, o.Source, now)
+		if err != nil {
+			return 0, fmt.Errorf("failed to store CPI observation: %w", err)
+		}
+	}
+	return len(observations), nil
+}
+
+// GetObservations returns the stored readings for a region, oldest first
+func (s *Service) GetObservations(ctx context.Context, region Region) (*ObservationsResponse, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT region, month, index_value, source FROM cpi_observations
+		WHERE region = $1
+		ORDER BY month
+	`, region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CPI observations: %w", err)
+	}
+	defer rows.Close()
+
+	resp := &ObservationsResponse{Region: region, Observations: make([]Observation, 0)}
+	for rows.Next() {
+		var o Observation
+		if err := rows.Scan(&o.Region, &o.Month, &o.Index, &o.Source); err != nil {
+			return nil, fmt.Errorf("failed to scan CPI observation: %w", err)
+		}
+		resp.Observations = append(resp.Observations, o)
+	}
+	return resp, rows.Err()
+}
+
+// TrailingInflation returns the year-over-year inflation to the latest stored reading
+func (s *Service) TrailingInflation(ctx context.Context, region Region) (*Rate, error) {
+	resp, err := s.GetObservations(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	return trailingRate(resp.Observations)
+}
+
+// AnnualInflation returns each calendar year's inflation, measured as the change in the
+// average index from the year before. Only years with all twelve months on both sides count.
+func (s *Service) AnnualInflation(ctx context.Context, region Region) (map[int]float64, error) {
+	resp, err := s.GetObservations(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	return annualRates(resp.Observations), nil
+}
+
+// trailingRate compares the latest reading with the one twelve months earlier
+func trailingRate(observations []Observation) (*Rate, error) {
+	if len(observations) == 0 {
+		return nil, ErrNoData
+	}
+	byMonth := make(map[string]Observation, len(observations))
+	latest := observations[0]
+	for _, o := range observations {
+		byMonth[o.Month] = o
+		if o.Month > latest.Month {
+			latest = o
+		}
+	}
+
+	to, err := time.Parse("2006-01", latest.Month)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CPI month %q: %w", latest.Month, err)
+	}
+	from := to.AddDate(-1, 0, 0).Format("2006-01")
+	base, ok := byMonth[from]
+	if !ok {
+		return nil, ErrNoData
+	}
+	return &Rate{
+		Region: latest.Region,
+		Rate:   roundRate(latest.Index/base.Index - 1),
+		From:   from,
+		To:     latest.Month,
+		Source: latest.Source,
+	}, nil
+}
+
+// annualRates computes each complete year's change in average index over the year before
+func annualRates(observations []Observation) map[int]float64 {
+	type yearTotal struct {
+		sum    float64
+		months int
+	}
+	years := make(map[int]*yearTotal)
+	for _, o := range observations {
+		month, err := time.Parse("2006-01", o.Month)
+		if err != nil {
+			continue
+		}
+		total, ok := years[month.Year()]
+		if !ok {
+			total = &yearTotal{}
+			years[month.Year()] = total
+		}
+		total.sum += o.Index
+		total.months++
+	}
+
+	complete := make([]int, 0, len(years))
+	for year, total := range years {
+		if total.months == 12 {
+			complete = append(complete, year)
+		}
+	}
+	sort.Ints(complete)
+
+	rates := make(map[int]float64)
+	for _, year := range complete {
+		previous, ok := years[year-1]
+		if !ok || previous.months != 12 {
+			continue
+		}
+		rates[year] = roundRate(years[year].sum/previous.sum - 1)
+	}
+	return rates
+}
+
+// roundRate rounds a rate to four decimal places, a hundredth of a percent
+func roundRate(rate float64) float64 {
+	return math.Round(rate*10000) / 10000
+}
+
+// Start refreshes CPI data immediately and then on every interval until jobs drains. New
+// readings are published monthly, so a daily refresh picks them up promptly.
+func (s *Service) Start(jobs *background.Group, interval time.Duration) {
+	jobs.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			jobs.Exclusive(ctx, "cpi_refresh", interval, func(ctx context.Context) {
+				if _, err := s.Refresh(ctx); err != nil {
+					inflationLog.Error("CPI refresh failed", "error", err)
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package inflation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// monthlyObservations builds consecutive monthly readings from the first month of startYear
+func monthlyObservations(startYear int, values ...float64) []Observation {
+	observations := make([]Observation, 0, len(values))
+	for i, value := range values {
+		observations = append(observations, Observation{
+			Region: RegionCanada,
+			Month:  fmt.Sprintf("%d-%02d", startYear+i/12, i%12+1),
+			Index:  value,
+			Source: "statcan",
+		})
+	}
+	return observations
+}
+
+func TestTrailingRate_ComparesWithTwelveMonthsEarlier(t *testing.T) {
+	// Arrange: 100 in January 2023 rising by 0.25 a month to 103.25 in February 2024
+	values := make([]float64, 14)
+	for i := range values {
+		values[i] = 100 + float64(i)*0.25
+	}
+	observations := monthlyObservations(2023, values...)
+
+	// Act
+	rate, err := trailingRate(observations)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("trailingRate failed: %v", err)
+	}
+	// 103.25 / 100.25 - 1
+	if rate.From != "2023-02" || rate.To != "2024-02" || rate.Rate != 0.0299 {
+		t.Errorf("Expected 2.99%% from 2023-02 to 2024-02, got %+v", rate)
+	}
+
+	if _, err := trailingRate(observations[:6]); err != ErrNoData {
+		t.Errorf("Expected ErrNoData without a reading a year back, got %v", err)
+	}
+}
+
+func TestAnnualRates_OnlyCompleteYears(t *testing.T) {
+	// Arrange: 2022 averages 100, 2023 averages 104, 2024 has three months
+	values := make([]float64, 0, 27)
+	for i := 0; i < 12; i++ {
+		values = append(values, 100)
+	}
+	for i := 0; i < 12; i++ {
+		values = append(values, 104)
+	}
+	values = append(values, 106, 106, 106)
+
+	// Act
+	rates := annualRates(monthlyObservations(2022, values...))
+
+	// Assert
+	if len(rates) != 1 || rates[2023] != 0.04 {
+		t.Errorf("Expected only 2023 at 4%%, got %v", rates)
+	}
+}
+
+func TestStatCanSource_Fetch(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/getDataFromVectorsAndLatestNPeriods" || r.Method != http.MethodPost {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		fmt.Fprint(w, `[{"status":"SUCCESS","object":{"vectorDataPoint":[
+			{"refPer":"2024-01-01","value":158.3},
+			{"refPer":"2024-02-01","value":null},
+			{"refPer":"2024-03-01","value":159.8}
+		]}}]`)
+	}))
+	defer server.Close()
+
+	// Act
+	observations, err := NewStatCanSource(server.URL).Fetch(context.Background(), server.Client())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(observations) != 2 || observations[0].Month != "2024-01" || observations[1].Index != 159.8 {
+		t.Errorf("Expected January and March 2024 readings, got %+v", observations)
+	}
+}
+
+func TestBLSSource_Fetch(t *testing.T) {
+	// Arrange
+	var request map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		fmt.Fprint(w, `{"status":"REQUEST_SUCCEEDED","Results":{"series":[{"seriesID":"CUUR0000SA0","data":[
+			{"year":"2024","period":"M02","value":"310.326"},
+			{"year":"2023","period":"M13","value":"304.702"},
+			{"year":"2023","period":"M10","value":"-"}
+		]}]}}`)
+	}))
+	defer server.Close()
+
+	// Act
+	observations, err := NewBLSSource(server.URL, "secret").Fetch(context.Background(), server.Client())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if request["registrationkey"] != "secret" {
+		t.Errorf("Expected the API key to be sent, got %v", request)
+	}
+	if len(observations) != 1 || observations[0].Month != "2024-02" || observations[0].Index != 310.326 {
+		t.Errorf("Expected only the February 2024 reading, got %+v", observations)
+	}
+}
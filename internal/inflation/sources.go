@@ -0,0 +1,193 @@
+package inflation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// historyYears is how many years of readings a refresh fetches, enough for the annual
+// rates reports compare spending against
+const historyYears = 10
+
+// postJSON posts a JSON body and decodes the JSON response into out
+func postJSON(ctx context.Context, client *http.Client, url string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// StatCanSource reads the all-items consumer price index for Canada (table 18-10-0004-01,
+// not seasonally adjusted) from the Statistics Canada Web Data Service
+type StatCanSource struct {
+	baseURL string
+}
+
+const (
+	statCanBaseURL = "https://www150.statcan.gc.ca/t1/wds/rest"
+	statCanVector  = 41690973
+)
+
+// NewStatCanSource creates a Statistics Canada source. An empty baseURL uses the public service.
+func NewStatCanSource(baseURL string) *StatCanSource {
+	if baseURL == "" {
+		baseURL = statCanBaseURL
+	}
+	return &StatCanSource{baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (s *StatCanSource) Name() string   { return "statcan" }
+func (s *StatCanSource) Region() Region { return RegionCanada }
+
+// statCanResponse is the Web Data Service reply to getDataFromVectorsAndLatestNPeriods
+type statCanResponse []struct {
+	Status string `json:"status"`
+	Object struct {
+		VectorDataPoint []struct {
+			RefPer string   `json:"refPer"` // YYYY-MM-DD, the first of the month
+			Value  *float64 `json:"value"`
+		} `json:"vectorDataPoint"`
+	} `json:"object"`
+}
+
+// Fetch returns the latest readings
+func (s *StatCanSource) Fetch(ctx context.Context, client *http.Client) ([]Observation, error) {
+	request := []map[string]int{{"vectorId": statCanVector, "latestN": historyYears * 12}}
+	var resp statCanResponse
+	if err := postJSON(ctx, client, s.baseURL+"/getDataFromVectorsAndLatestNPeriods", request, &resp); err != nil {
+		return nil, fmt.Errorf("statistics canada: %w", err)
+	}
+	if len(resp) == 0 || resp[0].Status != "SUCCESS" {
+		return nil, fmt.Errorf("statistics canada: vector %d not returned", statCanVector)
+	}
+
+	observations := make([]Observation, 0, len(resp[0].Object.VectorDataPoint))
+	for _, point := range resp[0].Object.VectorDataPoint {
+		if point.Value == nil || len(point.RefPer) < len("2006-01") {
+			continue
+		}
+		observations = append(observations, Observation{
+			Region: RegionCanada,
+			Month:  point.RefPer[:len("2006-01")],
+			Index:  *point.Value,
+			Source: s.Name(),
+		})
+	}
+	return observations, nil
+}
+
+// BLSSource reads the consumer price index for all urban consumers (series CUUR0000SA0, not
+// seasonally adjusted) from the Bureau of Labor Statistics public API
+type BLSSource struct {
+	baseURL string
+	apiKey  string
+}
+
+const (
+	blsBaseURL = "https://api.bls.gov/publicAPI/v2"
+	blsSeries  = "CUUR0000SA0"
+)
+
+// NewBLSSource creates a Bureau of Labor Statistics source. An empty baseURL uses the public
+// API; the API key is optional but raises the daily request limit.
+func NewBLSSource(baseURL, apiKey string) *BLSSource {
+	if baseURL == "" {
+		baseURL = blsBaseURL
+	}
+	return &BLSSource{baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey}
+}
+
+func (s *BLSSource) Name() string   { return "bls" }
+func (s *BLSSource) Region() Region { return RegionUnitedStates }
+
+// blsResponse is the timeseries data API reply
+type blsResponse struct {
+	Status  string   `json:"status"`
+	Message []string `json:"message"`
+	Results struct {
+		Series []struct {
+			SeriesID string `json:"seriesID"`
+			Data     []struct {
+				Year   string `json:"year"`
+				Period string `json:"period"` // M01-M12, or M13 for the annual average
+				Value  string `json:"value"`
+			} `json:"data"`
+		} `json:"series"`
+	} `json:"Results"`
+}
+
+// Fetch returns the readings for the last ten calendar years
+func (s *BLSSource) Fetch(ctx context.Context, client *http.Client) ([]Observation, error) {
+	now := time.Now()
+	request := map[string]interface{}{
+		"seriesid":  []string{blsSeries},
+		"startyear": strconv.Itoa(now.Year() - historyYears + 1),
+		"endyear":   strconv.Itoa(now.Year()),
+	}
+	if s.apiKey != "" {
+		request["registrationkey"] = s.apiKey
+	}
+
+	var resp blsResponse
+	if err := postJSON(ctx, client, s.baseURL+"/timeseries/data/", request, &resp); err != nil {
+		return nil, fmt.Errorf("bls: %w", err)
+	}
+	if resp.Status != "REQUEST_SUCCEEDED" {
+		return nil, fmt.Errorf("bls: %s %s", resp.Status, strings.Join(resp.Message, "; "))
+	}
+
+	observations := make([]Observation, 0)
+	for _, series := range resp.Results.Series {
+		if series.SeriesID != blsSeries {
+			continue
+		}
+		for _, point := range series.Data {
+			month, err := strconv.Atoi(strings.TrimPrefix(point.Period, "M"))
+			if err != nil || month < 1 || month > 12 {
+				continue
+			}
+			value, err := strconv.ParseFloat(point.Value, 64)
+			if err != nil {
+				// Readings the agency couldn't collect are published as "-"
+				continue
+			}
+			observations = append(observations, Observation{
+				Region: RegionUnitedStates,
+				Month:  fmt.Sprintf("%s-%02d", point.Year, month),
+				Index:  value,
+				Source: s.Name(),
+			})
+		}
+	}
+	return observations, nil
+}
@@ -0,0 +1,210 @@
+package jurisdiction
+
+import (
+	"sort"
+	"time"
+
+	"money/internal/civil"
+)
+
+// Holiday is a day banks are closed
+type Holiday struct {
+	Name string     `json:"name"`
+	Date civil.Date `json:"date"` // The day it's observed, after any weekend substitution
+}
+
+// holidayRule places a holiday in a year, or reports that it isn't held that year
+type holidayRule struct {
+	name string
+	date func(year int) (civil.Date, bool)
+}
+
+// calendar is a jurisdiction's bank holidays and how weekend holidays are substituted
+type calendar struct {
+	rules []holidayRule
+	// observe moves a holiday falling on a weekend to the weekday it's observed on, or
+	// reports that it isn't observed
+	observe func(d civil.Date) (civil.Date, bool)
+}
+
+// Payments Canada clearing holidays
+var canadaCalendar = calendar{
+	rules: []holidayRule{
+		{"New Year's Day", fixed(time.January, 1)},
+		{"Good Friday", easter(-2)},
+		{"Victoria Day", mondayBefore(time.May, 25)},
+		{"Canada Day", fixed(time.July, 1)},
+		{"Civic Holiday", nthWeekday(time.August, time.Monday, 1)},
+		{"Labour Day", nthWeekday(time.September, time.Monday, 1)},
+		{"National Day for Truth and Reconciliation", since(2021, fixed(time.September, 30))},
+		{"Thanksgiving", nthWeekday(time.October, time.Monday, 2)},
+		{"Remembrance Day", fixed(time.November, 11)},
+		{"Christmas Day", fixed(time.December, 25)},
+		{"Boxing Day", fixed(time.December, 26)},
+	},
+	observe: nextMonday,
+}
+
+// Federal Reserve holidays. The Fed doesn't close the Friday before a Saturday holiday.
+var unitedStatesCalendar = calendar{
+	rules: []holidayRule{
+		{"New Year's Day", fixed(time.January, 1)},
+		{"Martin Luther King Jr. Day", nthWeekday(time.January, time.Monday, 3)},
+		{"Washington's Birthday", nthWeekday(time.February, time.Monday, 3)},
+		{"Memorial Day", nthWeekday(time.May, time.Monday, -1)},
+		{"Juneteenth", since(2022, fixed(time.June, 19))},
+		{"Independence Day", fixed(time.July, 4)},
+		{"Labor Day", nthWeekday(time.September, time.Monday, 1)},
+		{"Columbus Day", nthWeekday(time.October, time.Monday, 2)},
+		{"Veterans Day", fixed(time.November, 11)},
+		{"Thanksgiving Day", nthWeekday(time.November, time.Thursday, 4)},
+		{"Christmas Day", fixed(time.December, 25)},
+	},
+	observe: func(d civil.Date) (civil.Date, bool) {
+		switch d.Weekday() {
+		case time.Saturday:
+			return d, false
+		case time.Sunday:
+			return d.AddDays(1), true
+		}
+		return d, true
+	},
+}
+
+// England and Wales bank holidays. One-off holidays such as coronations aren't included.
+var englandCalendar = calendar{
+	rules: []holidayRule{
+		{"New Year's Day", fixed(time.January, 1)},
+		{"Good Friday", easter(-2)},
+		{"Easter Monday", easter(1)},
+		{"Early May bank holiday", nthWeekday(time.May, time.Monday, 1)},
+		{"Spring bank holiday", nthWeekday(time.May, time.Monday, -1)},
+		{"Summer bank holiday", nthWeekday(time.August, time.Monday, -1)},
+		{"Christmas Day", fixed(time.December, 25)},
+		{"Boxing Day", fixed(time.December, 26)},
+	},
+	observe: nextMonday,
+}
+
+// Holidays returns the year's bank holidays in date order. A holiday substituted onto a
+// day already taken moves to the next free weekday, so Christmas and Boxing Day on a
+// weekend are observed on the Monday and Tuesday.
+func (p *Profile) Holidays(year int) []Holiday {
+	var holidays []Holiday
+	taken := make(map[string]bool)
+	for _, rule := range p.calendar.rules {
+		d, ok := rule.date(year)
+		if !ok {
+			continue
+		}
+		observed, ok := p.calendar.observe(d)
+		if !ok {
+			continue
+		}
+		for taken[observed.String()] || isWeekend(observed) {
+			observed = observed.AddDays(1)
+		}
+		taken[observed.String()] = true
+		holidays = append(holidays, Holiday{Name: rule.name, Date: observed})
+	}
+	sort.Slice(holidays, func(i, j int) bool { return holidays[i].Date.Before(holidays[j].Date.Time) })
+	return holidays
+}
+
+// IsBusinessDay reports whether banks are open on the date
+func (p *Profile) IsBusinessDay(d civil.Date) bool {
+	if isWeekend(d) {
+		return false
+	}
+	for _, h := range p.Holidays(d.Year()) {
+		if h.Date.Equal(d.Time) {
+			return false
+		}
+	}
+	return true
+}
+
+// NextBusinessDay returns the date itself when banks are open, otherwise the next day they are.
+// Payments due on a weekend or holiday are processed then.
+func (p *Profile) NextBusinessDay(d civil.Date) civil.Date {
+	for !p.IsBusinessDay(d) {
+		d = d.AddDays(1)
+	}
+	return d
+}
+
+func isWeekend(d civil.Date) bool {
+	return d.Weekday() == time.Saturday || d.Weekday() == time.Sunday
+}
+
+// nextMonday observes weekend holidays on the following Monday
+func nextMonday(d civil.Date) (civil.Date, bool) {
+	for isWeekend(d) {
+		d = d.AddDays(1)
+	}
+	return d, true
+}
+
+func fixed(month time.Month, day int) func(int) (civil.Date, bool) {
+	return func(year int) (civil.Date, bool) {
+		return civil.NewDate(year, month, day), true
+	}
+}
+
+// nthWeekday is the nth weekday of the month; n of -1 is the last
+func nthWeekday(month time.Month, weekday time.Weekday, n int) func(int) (civil.Date, bool) {
+	return func(year int) (civil.Date, bool) {
+		if n < 0 {
+			d := civil.NewDate(year, month+1, 1).AddDays(-1)
+			for d.Weekday() != weekday {
+				d = d.AddDays(-1)
+			}
+			return d, true
+		}
+		d := civil.NewDate(year, month, 1)
+		for d.Weekday() != weekday {
+			d = d.AddDays(1)
+		}
+		return d.AddDays(7 * (n - 1)), true
+	}
+}
+
+// mondayBefore is the last Monday before the given day
+func mondayBefore(month time.Month, day int) func(int) (civil.Date, bool) {
+	return func(year int) (civil.Date, bool) {
+		d := civil.NewDate(year, month, day).AddDays(-1)
+		for d.Weekday() != time.Monday {
+			d = d.AddDays(-1)
+		}
+		return d, true
+	}
+}
+
+// easter is offset days from Easter Sunday
+func easter(offset int) func(int) (civil.Date, bool) {
+	return func(year int) (civil.Date, bool) {
+		// Anonymous Gregorian algorithm
+		a := year % 19
+		b, c := year/100, year%100
+		d, e := b/4, b%4
+		f := (b + 8) / 25
+		g := (b - f + 1) / 3
+		h := (19*a + b - d - g + 15) % 30
+		i, k := c/4, c%4
+		l := (32 + 2*e + 2*i - h - k) % 7
+		m := (a + 11*h + 22*l) / 451
+		month := (h + l - 7*m + 114) / 31
+		day := (h+l-7*m+114)%31 + 1
+		return civil.NewDate(year, time.Month(month), day).AddDays(offset), true
+	}
+}
+
+// since holds a holiday only from the year it was first observed
+func since(first int, rule func(int) (civil.Date, bool)) func(int) (civil.Date, bool) {
+	return func(year int) (civil.Date, bool) {
+		if year < first {
+			return civil.Date{}, false
+		}
+		return rule(year)
+	}
+}
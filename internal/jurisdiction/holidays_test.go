@@ -0,0 +1,78 @@
+package jurisdiction
+
+import (
+	"testing"
+	"time"
+
+	"money/internal/civil"
+)
+
+func TestHolidays_ObservesWeekendHolidays(t *testing.T) {
+	tests := []struct {
+		name string
+		code Code
+		year int
+		want map[string]string
+	}{
+		{"Canada 2027", CA, 2027, map[string]string{
+			"Good Friday":    "2027-03-26",
+			"Victoria Day":   "2027-05-24",
+			"Canada Day":     "2027-07-01",
+			"Christmas Day":  "2027-12-27", // Saturday, observed Monday
+			"Boxing Day":     "2027-12-28", // Sunday, observed Tuesday
+			"Thanksgiving":   "2027-10-11",
+			"Labour Day":     "2027-09-06",
+			"New Year's Day": "2027-01-01",
+		}},
+		{"United States 2026", US, 2026, map[string]string{
+			"Independence Day": "", // Saturday, not observed by the Fed
+			"Memorial Day":     "2026-05-25",
+			"Thanksgiving Day": "2026-11-26",
+			"Juneteenth":       "2026-06-19",
+		}},
+		{"United Kingdom 2026", UK, 2026, map[string]string{
+			"Easter Monday":       "2026-04-06",
+			"Spring bank holiday": "2026-05-25",
+			"Boxing Day":          "2026-12-28", // Saturday, observed Monday
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Lookup(string(tt.code))
+
+			got := make(map[string]string)
+			for _, h := range p.Holidays(tt.year) {
+				got[h.Name] = h.Date.String()
+			}
+
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("Expected %s on %q, got %q", name, want, got[name])
+				}
+			}
+		})
+	}
+}
+
+func TestNextBusinessDay_SkipsWeekendsAndHolidays(t *testing.T) {
+	ca, us := Lookup("ca"), Lookup("us")
+
+	// Good Friday 2026 is April 3; the following Monday is a Canadian business day
+	if got := ca.NextBusinessDay(civil.NewDate(2026, time.April, 3)); got.String() != "2026-04-06" {
+		t.Errorf("Expected Good Friday to roll to Monday in Canada, got %s", got)
+	}
+	// US banks are open on Good Friday
+	if got := us.NextBusinessDay(civil.NewDate(2026, time.April, 3)); got.String() != "2026-04-03" {
+		t.Errorf("Expected Good Friday to be a US business day, got %s", got)
+	}
+	// Labor Day weekend: Saturday through Monday are closed
+	if got := us.NextBusinessDay(civil.NewDate(2026, time.September, 5)); got.String() != "2026-09-08" {
+		t.Errorf("Expected Labor Day weekend to roll to Tuesday, got %s", got)
+	}
+	if _, err := Get("fr"); err == nil {
+		t.Error("Expected an unsupported jurisdiction to be rejected")
+	}
+	if p, err := Get("gb"); err != nil || p.Code != UK {
+		t.Errorf("Expected GB to resolve to the UK profile, got %v (err %v)", p, err)
+	}
+}
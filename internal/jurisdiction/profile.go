@@ -0,0 +1,157 @@
+// Package jurisdiction describes the country a user lives and files taxes in, and the
+// defaults that follow from it: account types, tax rules, contribution limits, currency and
+// the holiday calendar payments are scheduled around.
+package jurisdiction
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Code identifies a jurisdiction
+type Code string
+
+const (
+	CA Code = "CA"
+	US Code = "US"
+	UK Code = "UK"
+)
+
+// DefaultCode applies to users who haven't chosen a jurisdiction
+const DefaultCode = CA
+
+// ContributionLimit is the annual limit on a tax-advantaged plan
+type ContributionLimit struct {
+	Plan        string  `json:"plan"`
+	Name        string  `json:"name"`
+	AccountType string  `json:"account_type,omitempty"` // Account type contributions are tracked in, when there is one
+	Year        int     `json:"year"`
+	AnnualLimit float64 `json:"annual_limit"`
+	IncomeRate  float64 `json:"income_rate,omitempty"` // Share of earned income the limit is capped at, when it scales with income
+}
+
+// Profile is a jurisdiction's defaults
+type Profile struct {
+	Code     Code   `json:"code"`
+	Name     string `json:"name"`
+	Currency string `json:"currency"`
+	// TaxAuthority is cra, irs or hmrc
+	TaxAuthority      string     `json:"tax_authority"`
+	TaxYearStartMonth time.Month `json:"tax_year_start_month"`
+	TaxYearStartDay   int        `json:"tax_year_start_day"`
+	// PersonalAmountCreditRate is the rate the tax-free personal amount is credited at.
+	// Zero means the amount is deducted from taxable income instead.
+	PersonalAmountCreditRate float64             `json:"personal_amount_credit_rate"`
+	AccountTypes             []string            `json:"account_types"`
+	ContributionLimits       []ContributionLimit `json:"contribution_limits"`
+
+	calendar calendar
+}
+
+// commonAccountTypes are offered everywhere; registered plans are added per jurisdiction
+var commonAccountTypes = []string{
+	"checking", "savings", "cash", "brokerage", "crypto", "real_estate", "vehicle", "collectible",
+	"credit_card", "loan", "mortgage", "line_of_credit", "stock_options", "other",
+}
+
+var profiles = map[Code]*Profile{
+	CA: {
+		Code:                     CA,
+		Name:                     "Canada",
+		Currency:                 "CAD",
+		TaxAuthority:             "cra",
+		TaxYearStartMonth:        time.January,
+		TaxYearStartDay:          1,
+		PersonalAmountCreditRate: 0.15,
+		AccountTypes:             append([]string{"tfsa", "rrsp"}, commonAccountTypes...),
+		ContributionLimits: []ContributionLimit{
+			{Plan: "tfsa", Name: "Tax-Free Savings Account", AccountType: "tfsa", Year: 2025, AnnualLimit: 7000},
+			{Plan: "rrsp", Name: "Registered Retirement Savings Plan", AccountType: "rrsp", Year: 2025, AnnualLimit: 32490, IncomeRate: 0.18},
+			{Plan: "fhsa", Name: "First Home Savings Account", Year: 2025, AnnualLimit: 8000},
+		},
+		calendar: canadaCalendar,
+	},
+	US: {
+		Code:              US,
+		Name:              "United States",
+		Currency:          "USD",
+		TaxAuthority:      "irs",
+		TaxYearStartMonth: time.January,
+		TaxYearStartDay:   1,
+		AccountTypes:      commonAccountTypes,
+		ContributionLimits: []ContributionLimit{
+			{Plan: "401k", Name: "401(k) employee deferral", Year: 2025, AnnualLimit: 23500},
+			{Plan: "ira", Name: "Traditional or Roth IRA", Year: 2025, AnnualLimit: 7000},
+			{Plan: "hsa", Name: "Health Savings Account (self-only)", Year: 2025, AnnualLimit: 4300},
+		},
+		calendar: unitedStatesCalendar,
+	},
+	UK: {
+		Code:              UK,
+		Name:              "United Kingdom",
+		Currency:          "GBP",
+		TaxAuthority:      "hmrc",
+		TaxYearStartMonth: time.April,
+		TaxYearStartDay:   6,
+		AccountTypes:      commonAccountTypes,
+		ContributionLimits: []ContributionLimit{
+			{Plan: "isa", Name: "Individual Savings Account", Year: 2025, AnnualLimit: 20000},
+			{Plan: "lisa", Name: "Lifetime ISA (counts toward the ISA limit)", Year: 2025, AnnualLimit: 4000},
+			{Plan: "pension", Name: "Pension annual allowance", Year: 2025, AnnualLimit: 60000, IncomeRate: 1},
+		},
+		calendar: englandCalendar,
+	},
+}
+
+// Get returns the profile for a code such as "us"; GB is accepted for the UK
+func Get(code string) (*Profile, error) {
+	c := Code(strings.ToUpper(strings.TrimSpace(code)))
+	if c == "GB" {
+		c = UK
+	}
+	p, ok := profiles[c]
+	if !ok {
+		return nil, fmt.Errorf("unsupported jurisdiction: %s", code)
+	}
+	return p, nil
+}
+
+// Lookup returns the profile for a stored code, falling back to the default
+func Lookup(code string) *Profile {
+	if p, err := Get(code); err == nil {
+		return p
+	}
+	return profiles[DefaultCode]
+}
+
+// List returns every supported profile
+func List() []*Profile {
+	return []*Profile{profiles[CA], profiles[US], profiles[UK]}
+}
+
+// OffersAccountType reports whether accounts of the type can be opened in the jurisdiction
+func (p *Profile) OffersAccountType(accountType string) bool {
+	for _, t := range p.AccountTypes {
+		if t == accountType {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// WithProfile attaches the user's jurisdiction to the context
+func WithProfile(ctx context.Context, p *Profile) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the user's jurisdiction from the context, or the default
+func FromContext(ctx context.Context) *Profile {
+	if p, ok := ctx.Value(contextKey{}).(*Profile); ok && p != nil {
+		return p
+	}
+	return profiles[DefaultCode]
+}
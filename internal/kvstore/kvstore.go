@@ -0,0 +1,37 @@
+// Package kvstore holds short-lived state that every server instance must see: passkey
+// ceremony sessions, rate limit counters and cached dashboard summaries. A single instance
+// keeps it in memory; instances behind a load balancer share it through Redis.
+package kvstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Store is a key-value store with expiring entries
+type Store interface {
+	// Get returns the value stored under key, and false when there is none or it expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key until ttl passes; zero keeps it until it's deleted
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes key, if it exists
+	Delete(ctx context.Context, key string) error
+	// Incr adds one to the counter under key and returns the new count. A counter starts
+	// at zero and expires ttl after it was created, however often it's incremented.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+}
+
+// Open returns the store a URL names: in memory when it's empty, or Redis for a
+// redis:// or rediss:// URL
+func Open(rawURL string) (Store, error) {
+	switch {
+	case rawURL == "":
+		return NewMemory(), nil
+	case strings.HasPrefix(rawURL, "redis://"), strings.HasPrefix(rawURL, "rediss://"):
+		return NewRedis(rawURL)
+	default:
+		return nil, fmt.Errorf("unsupported store URL, expected redis:// or rediss://")
+	}
+}
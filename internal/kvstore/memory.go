@@ -0,0 +1,113 @@
+package kvstore
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// memorySweepEvery is how many writes pass between sweeps of expired entries
+const memorySweepEvery = 1024
+
+// memoryEntry is a stored value and when it expires, zero for never
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// Memory is a Store held in the process, for a single instance
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	writes  int
+	now     func() time.Time
+}
+
+// NewMemory creates an empty in-memory store
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]memoryEntry), now: time.Now}
+}
+
+// Get returns the value stored under key
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.live(key)
+	if !ok {
+		return nil, false, nil
+	}
+	return append([]byte(nil), entry.value...), true, nil
+}
+
+// Set stores value under key until ttl passes
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.put(key, memoryEntry{value: append([]byte(nil), value...), expires: m.expiry(ttl)})
+	return nil
+}
+
+// Delete removes key
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+// Incr adds one to the counter under key, keeping the expiry it was created with
+func (m *Memory) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.live(key)
+	if !ok {
+		entry = memoryEntry{expires: m.expiry(ttl)}
+	}
+	count, _ := strconv.ParseInt(string(entry.value), 10, 64)
+	count++
+	entry.value = []byte(strconv.FormatInt(count, 10))
+	m.put(key, entry)
+	return count, nil
+}
+
+// live returns the entry under key unless it has expired
+func (m *Memory) live(key string) (memoryEntry, bool) {
+	entry, ok := m.entries[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+	if !entry.expires.IsZero() && !m.now().Before(entry.expires) {
+		delete(m.entries, key)
+		return memoryEntry{}, false
+	}
+	return entry, true
+}
+
+// put stores an entry, sweeping out expired entries every so often so keys that are never
+// read again don't accumulate
+func (m *Memory) put(key string, entry memoryEntry) {
+	m.entries[key] = entry
+	m.writes++
+	if m.writes%memorySweepEvery != 0 {
+		return
+	}
+	now := m.now()
+	for k, e := range m.entries {
+		if !e.expires.IsZero() && !now.Before(e.expires) {
+			delete(m.entries, k)
+		}
+	}
+}
+
+// expiry is when an entry written now with ttl expires
+func (m *Memory) expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return m.now().Add(ttl)
+}
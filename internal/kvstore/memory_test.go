@@ -0,0 +1,65 @@
+package kvstore
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemory_ExpiresEntriesAfterTheirTTL(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemory()
+	store.now = func() time.Time { return now }
+
+	if err := store.Set(ctx, "ceremony", []byte("challenge"), time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := store.Set(ctx, "forever", []byte("kept"), 0); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// Act
+	before, okBefore, _ := store.Get(ctx, "ceremony")
+	now = now.Add(time.Minute)
+	_, okAfter, _ := store.Get(ctx, "ceremony")
+	kept, okKept, _ := store.Get(ctx, "forever")
+
+	// Assert
+	if !okBefore || string(before) != "challenge" {
+		t.Errorf("Expected the value before it expired, got %q", before)
+	}
+	if okAfter {
+		t.Error("Expected the value to be gone once its TTL passed")
+	}
+	if !okKept || string(kept) != "kept" {
+		t.Errorf("Expected a value without a TTL to be kept, got %q", kept)
+	}
+}
+
+func TestMemory_IncrKeepsTheWindowOfTheFirstIncrement(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemory()
+	store.now = func() time.Time { return now }
+
+	// Act
+	first, _ := store.Incr(ctx, "login:10.0.0.1", time.Minute)
+	now = now.Add(40 * time.Second)
+	second, _ := store.Incr(ctx, "login:10.0.0.1", time.Minute)
+	now = now.Add(20 * time.Second)
+	reset, err := store.Incr(ctx, "login:10.0.0.1", time.Minute)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Incr failed: %v", err)
+	}
+	if first != 1 || second != 2 {
+		t.Errorf("Expected counts 1 and 2 within the window, got %d and %d", first, second)
+	}
+	if reset != 1 {
+		t.Errorf("Expected the counter to restart once the first window ended, got %d", reset)
+	}
+}
@@ -0,0 +1,214 @@
+package kvstore
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis serves the commands the store sends from an in-memory store, recording them
+type fakeRedis struct {
+	listener net.Listener
+	store    *Memory
+	password string
+
+	mu       sync.Mutex
+	commands []string
+}
+
+func startFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	f := &fakeRedis{listener: listener, store: NewMemory(), password: password}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		reply, err := readReply(reader)
+		if err != nil {
+			return
+		}
+		items := reply.([]interface{})
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i] = string(item.([]byte))
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, args[0])
+		f.mu.Unlock()
+
+		if args[0] == "AUTH" {
+			authed = args[len(args)-1] == f.password
+			if !authed {
+				fmt.Fprint(conn, "-WRONGPASS invalid password\r\n")
+				continue
+			}
+			fmt.Fprint(conn, "+OK\r\n")
+			continue
+		}
+		if !authed {
+			fmt.Fprint(conn, "-NOAUTH Authentication required\r\n")
+			continue
+		}
+		fmt.Fprint(conn, f.execute(args))
+	}
+}
+
+func (f *fakeRedis) execute(args []string) string {
+	ctx := context.Background()
+	switch args[0] {
+	case "GET":
+		value, ok, _ := f.store.Get(ctx, args[1])
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		var ttl time.Duration
+		nx := false
+		for i := 3; i < len(args); i++ {
+			switch args[i] {
+			case "PX":
+				ms, _ := strconv.Atoi(args[i+1])
+				ttl = time.Duration(ms) * time.Millisecond
+				i++
+			case "NX":
+				nx = true
+			}
+		}
+		if _, ok, _ := f.store.Get(ctx, args[1]); ok && nx {
+			return "$-1\r\n"
+		}
+		f.store.Set(ctx, args[1], []byte(args[2]), ttl)
+		return "+OK\r\n"
+	case "DEL":
+		f.store.Delete(ctx, args[1])
+		return ":1\r\n"
+	case "INCR":
+		count, _ := f.store.Incr(ctx, args[1], 0)
+		return fmt.Sprintf(":%d\r\n", count)
+	case "SELECT":
+		return "+OK\r\n"
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func (f *fakeRedis) sent() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return strings.Join(f.commands, " ")
+}
+
+func TestRedis_StoresAndCountsThroughTheServer(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	server := startFakeRedis(t, "hunter2")
+	store, err := NewRedis("redis://:hunter2@" + server.listener.Addr().String() + "/2")
+	if err != nil {
+		t.Fatalf("NewRedis failed: %v", err)
+	}
+
+	// Act
+	setErr := store.Set(ctx, "summary", []byte("{\"total\":3}\r\n"), time.Minute)
+	value, found, getErr := store.Get(ctx, "summary")
+	_, missing, _ := store.Get(ctx, "absent")
+	first, _ := store.Incr(ctx, "login:10.0.0.1", time.Minute)
+	second, incrErr := store.Incr(ctx, "login:10.0.0.1", time.Minute)
+	delErr := store.Delete(ctx, "summary")
+	_, foundAfterDelete, _ := store.Get(ctx, "summary")
+
+	// Assert
+	for _, err := range []error{setErr, getErr, incrErr, delErr} {
+		if err != nil {
+			t.Fatalf("Command failed: %v", err)
+		}
+	}
+	if !found || string(value) != "{\"total\":3}\r\n" {
+		t.Errorf("Expected the stored value back byte for byte, got %q", value)
+	}
+	if missing || foundAfterDelete {
+		t.Error("Expected missing and deleted keys to be reported as not found")
+	}
+	if first != 1 || second != 2 {
+		t.Errorf("Expected counts 1 and 2, got %d and %d", first, second)
+	}
+	if sent := server.sent(); !strings.HasPrefix(sent, "AUTH SELECT SET") {
+		t.Errorf("Expected the connection to authenticate and select the database first, got %s", sent)
+	}
+}
+
+func TestRedis_ReportsAuthenticationFailure(t *testing.T) {
+	// Arrange
+	server := startFakeRedis(t, "hunter2")
+	store, err := NewRedis("redis://:wrong@" + server.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("NewRedis failed: %v", err)
+	}
+
+	// Act
+	_, _, err = store.Get(context.Background(), "summary")
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("Expected the server's authentication error, got %v", err)
+	}
+}
+
+func TestOpen_SelectsTheStoreFromTheURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{url: "", want: "*kvstore.Memory"},
+		{url: "redis://localhost:6379/0", want: "*kvstore.Redis"},
+		{url: "rediss://:secret@cache.internal", want: "*kvstore.Redis"},
+		{url: "memcached://localhost", wantErr: true},
+		{url: "redis://localhost/db", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			// Act
+			store, err := Open(tt.url)
+
+			// Assert
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error for %q", tt.url)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			if got := fmt.Sprintf("%T", store); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
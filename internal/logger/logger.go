@@ -4,33 +4,33 @@ import (
 	"context"
 	"log/slog"
 	"os"
+	"strings"
+	"sync"
 
 	"money/internal/env"
 )
 
 var defaultLogger *slog.Logger
 
+var (
+	// baseHandler writes every level; loggers filter with their own level
+	baseHandler slog.Handler
+	globalLevel = slog.LevelInfo
+	// moduleLevels overrides the global level for named modules
+	moduleLevels = map[string]slog.Level{}
+
+	modulesMu sync.Mutex
+	modules   = map[string]*Logger{}
+)
+
 // Init initializes the global logger from environment variables
 func Init() {
 	logLevel := env.Get("LOG_LEVEL", "info")
 	logFormat := env.Get("LOG_FORMAT", "json")
 
-	var level slog.Level
-	switch logLevel {
-	case "debug":
-		level = slog.LevelDebug
-	case "info":
-		level = slog.LevelInfo
-	case "warn":
-		level = slog.LevelWarn
-	case "error":
-		level = slog.LevelError
-	default:
-		level = slog.LevelInfo
-	}
-
 	opts := &slog.HandlerOptions{
-		Level: level,
+		Level:       slog.LevelDebug,
+		ReplaceAttr: redactAttr,
 	}
 
 	var handler slog.Handler
@@ -40,8 +40,103 @@ func Init() {
 		handler = slog.NewTextHandler(os.Stdout, opts)
 	}
 
-	defaultLogger = slog.New(handler)
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+
+	baseHandler = handler
+	globalLevel = parseLevel(logLevel)
+	moduleLevels = parseModuleLevels(env.Get("LOG_MODULE_LEVELS", ""))
+
+	defaultLogger = slog.New(&levelHandler{Handler: handler, level: globalLevel})
 	slog.SetDefault(defaultLogger)
+
+	for _, m := range modules {
+		m.rebuild()
+	}
+}
+
+// SetLevel changes the global level while running. Modules with their own level keep it.
+func SetLevel(name string) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+
+	globalLevel = parseLevel(name)
+	if baseHandler != nil {
+		defaultLogger = slog.New(&levelHandler{Handler: baseHandler, level: globalLevel})
+		slog.SetDefault(defaultLogger)
+	}
+	for _, m := range modules {
+		m.rebuild()
+	}
+}
+
+// SetModuleLevels replaces the per-module levels while running, using the same spec as
+// LOG_MODULE_LEVELS
+func SetModuleLevels(spec string) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+
+	moduleLevels = parseModuleLevels(spec)
+	for _, m := range modules {
+		m.rebuild()
+	}
+}
+
+// ValidLevel reports whether a level name is recognised rather than falling back to info
+func ValidLevel(name string) bool {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug", "info", "warn", "error":
+		return true
+	}
+	return false
+}
+
+// parseLevel converts a level name, falling back to info
+func parseLevel(name string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug
+	case "info":
+		return slog.LevelInfo
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// parseModuleLevels reads a spec such as "sync=debug,handlers=warn"
+func parseModuleLevels(spec string) map[string]slog.Level {
+	levels := make(map[string]slog.Level)
+	for _, entry := range strings.Split(spec, ",") {
+		module, level, ok := strings.Cut(entry, "=")
+		module = strings.TrimSpace(module)
+		if !ok || module == "" {
+			continue
+		}
+		levels[module] = parseLevel(level)
+	}
+	return levels
+}
+
+// levelHandler drops records below its level
+type levelHandler struct {
+	slog.Handler
+	level slog.Level
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithGroup(name), level: h.level}
 }
 
 // Get returns the default logger
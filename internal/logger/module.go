@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+)
+
+// Logger logs for a named module whose level can be set separately through
+// LOG_MODULE_LEVELS, e.g. "sync=debug,projections=warn,handlers=info"
+type Logger struct {
+	module string
+	slog   atomic.Pointer[slog.Logger]
+}
+
+// Module returns the logger for a module. It's safe to call before Init, so packages can
+// hold their logger in a package-level variable.
+func Module(name string) *Logger {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+
+	if m, ok := modules[name]; ok {
+		return m
+	}
+	m := &Logger{module: name}
+	m.rebuild()
+	modules[name] = m
+	return m
+}
+
+// rebuild applies the current handler and levels. Callers hold modulesMu.
+func (l *Logger) rebuild() {
+	if baseHandler == nil {
+		l.slog.Store(slog.Default().With("module", l.module))
+		return
+	}
+	level, ok := moduleLevels[l.module]
+	if !ok {
+		level = globalLevel
+	}
+	handler := &levelHandler{Handler: baseHandler, level: level}
+	l.slog.Store(slog.New(handler).With("module", l.module))
+}
+
+// Slog returns the module's underlying structured logger
+func (l *Logger) Slog() *slog.Logger {
+	return l.slog.Load()
+}
+
+// Enabled reports whether the module logs at the level, so callers can skip expensive work
+func (l *Logger) Enabled(level slog.Level) bool {
+	return l.Slog().Enabled(context.Background(), level)
+}
+
+// Debug logs a debug message
+func (l *Logger) Debug(msg string, args ...any) {
+	l.Slog().Debug(msg, args...)
+}
+
+// Info logs an info message
+func (l *Logger) Info(msg string, args ...any) {
+	l.Slog().Info(msg, args...)
+}
+
+// Warn logs a warning message
+func (l *Logger) Warn(msg string, args ...any) {
+	l.Slog().Warn(msg, args...)
+}
+
+// Error logs an error message
+func (l *Logger) Error(msg string, args ...any) {
+	l.Slog().Error(msg, args...)
+}
+
+// Printf logs a formatted message, taking the level from a leading "DEBUG:", "INFO:",
+// "WARN:", "ERROR:" or "PANIC:" prefix. Messages without one are logged at info.
+func (l *Logger) Printf(format string, args ...any) {
+	level := slog.LevelInfo
+	for prefix, prefixLevel := range printfLevels {
+		if rest, ok := strings.CutPrefix(format, prefix); ok {
+			format, level = strings.TrimSpace(rest), prefixLevel
+			break
+		}
+	}
+	if !l.Enabled(level) {
+		return
+	}
+	l.Slog().Log(context.Background(), level, fmt.Sprintf(format, args...))
+}
+
+// printfLevels maps Printf message prefixes to levels
+var printfLevels = map[string]slog.Level{
+	"DEBUG:": slog.LevelDebug,
+	"INFO:":  slog.LevelInfo,
+	"WARN:":  slog.LevelWarn,
+	"ERROR:": slog.LevelError,
+	"PANIC:": slog.LevelError,
+}
@@ -0,0 +1,157 @@
+package logger
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces sensitive values in log output
+const Redacted = "[REDACTED]"
+
+// sensitiveWords mark a field, attribute or query parameter name as holding a secret
+// wherever they appear in it, e.g. access_token or x-api-key
+var sensitiveWords = []string{
+	"password", "passcode", "secret", "token", "authorization", "cookie", "apikey", "api_key", "signature",
+}
+
+// sensitiveNames are secrets only as a whole name, since they're common name suffixes
+var sensitiveNames = []string{"key", "code", "otp", "pin", "session"}
+
+// sensitivePathSegments are path segments followed by a secret, e.g. the token in
+// /api/shared/{token}
+var sensitivePathSegments = []string{"shared"}
+
+const sensitivePattern = `[a-z0-9_\-]*(?:password|passcode|secret|token|authorization|apikey|api_key|signature)[a-z0-9_\-]*`
+
+var (
+	// queryPairPattern matches key=value pairs inside free text
+	queryPairPattern = regexp.MustCompile(`(?i)(\b` + sensitivePattern + `=)([^\s&,"}]+)`)
+	// jsonPairPattern matches "key": "value" pairs inside free text
+	jsonPairPattern = regexp.MustCompile(`(?i)("` + sensitivePattern + `"\s*:\s*)("(?:[^"\\]|\\.)*"|[^\s,}\]]+)`)
+	// bearerPattern matches bearer credentials in header dumps
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[a-z0-9\-._~+/]+=*`)
+	// jwtPattern matches JSON web tokens wherever they appear
+	jwtPattern = regexp.MustCompile(`eyJ[a-zA-Z0-9_\-]+\.[a-zA-Z0-9_\-]+\.[a-zA-Z0-9_\-]*`)
+)
+
+// IsSensitiveKey reports whether a field or parameter name holds a secret
+func IsSensitiveKey(name string) bool {
+	name = strings.ReplaceAll(strings.ToLower(name), "-", "_")
+	for _, word := range sensitiveWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	for _, sensitive := range sensitiveNames {
+		if name == sensitive {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactString masks secrets embedded in free text
+func RedactString(s string) string {
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = bearerPattern.ReplaceAllString(s, "${1}"+Redacted)
+	s = queryPairPattern.ReplaceAllString(s, "${1}"+Redacted)
+	return jsonPairPattern.ReplaceAllString(s, "${1}\""+Redacted+"\"")
+}
+
+// RedactURL returns the URL's path and query with secrets in the path and sensitive query
+// values masked
+func RedactURL(u *url.URL) string {
+	path := RedactString(redactPath(u.EscapedPath()))
+	if u.RawQuery == "" {
+		return path
+	}
+
+	query := u.Query()
+	for name, values := range query {
+		for i := range values {
+			if IsSensitiveKey(name) {
+				values[i] = Redacted
+			} else {
+				values[i] = RedactString(values[i])
+			}
+		}
+	}
+	// Encode escapes the brackets; keep them readable
+	return path + "?" + strings.ReplaceAll(query.Encode(), url.QueryEscape(Redacted), Redacted)
+}
+
+// redactPath masks the segment after each sensitive path segment
+func redactPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i := 0; i < len(segments)-1; i++ {
+		for _, sensitive := range sensitivePathSegments {
+			if segments[i] == sensitive && segments[i+1] != "" {
+				segments[i+1] = Redacted
+				i++
+				break
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// RedactJSON masks sensitive fields in a JSON document. Input that isn't valid JSON is
+// redacted as free text.
+func RedactJSON(body []byte) string {
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return RedactString(string(body))
+	}
+	redacted, err := json.Marshal(redactValue(doc))
+	if err != nil {
+		return Redacted
+	}
+	return string(redacted)
+}
+
+// redactValue walks a decoded JSON value masking sensitive fields
+func redactValue(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for k, field := range value {
+			if IsSensitiveKey(k) {
+				value[k] = Redacted
+			} else {
+				value[k] = redactValue(field)
+			}
+		}
+		return value
+	case []any:
+		for i := range value {
+			value[i] = redactValue(value[i])
+		}
+		return value
+	case string:
+		return RedactString(value)
+	default:
+		return v
+	}
+}
+
+// redactAttr masks sensitive attributes and secrets in messages as records are written
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() == slog.KindGroup {
+		return a
+	}
+	if a.Key != slog.MessageKey && IsSensitiveKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, RedactString(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, RedactString(err.Error()))
+		}
+	}
+	return a
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRedactURL_MasksSensitiveQueryValues(t *testing.T) {
+	u, _ := url.Parse("/api/sync/callback?code=abc123&state=xyz&access_token=secret-value&year=2024")
+
+	got := RedactURL(u)
+	for _, secret := range []string{"abc123", "secret-value"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("Expected %q to be redacted from %s", secret, got)
+		}
+	}
+	for _, kept := range []string{"state=xyz", "year=2024", "code=" + Redacted} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("Expected %s to contain %q", got, kept)
+		}
+	}
+}
+
+func TestRedactURL_MasksShareTokenInPath(t *testing.T) {
+	u, _ := url.Parse("/api/shared/s3cr3t-share-token?format=pdf")
+
+	got := RedactURL(u)
+	if strings.Contains(got, "s3cr3t-share-token") {
+		t.Errorf("Expected the share token to be redacted from %s", got)
+	}
+	if want := "/api/shared/" + Redacted + "?format=pdf"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestRedactJSON_MasksNestedFields(t *testing.T) {
+	body := `{"email":"a@example.com","password":"hunter2","credentials":{"refresh_token":"r-1","otp":"123456"},"accounts":[{"api_key":"k-1","name":"TFSA"}]}`
+
+	got := RedactJSON([]byte(body))
+	for _, secret := range []string{"hunter2", "r-1", "123456", "k-1"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("Expected %q to be redacted from %s", secret, got)
+		}
+	}
+	if !strings.Contains(got, `"name":"TFSA"`) {
+		t.Errorf("Expected non-sensitive fields to be kept, got %s", got)
+	}
+}
+
+func TestRedactString_MasksSecretsInText(t *testing.T) {
+	text := `request failed: Authorization: Bearer abc.def token=t0ken {"access_token": "xyz"} jwt eyJhbGciOi.eyJzdWIiOi.c2ln status=401`
+
+	got := RedactString(text)
+	for _, secret := range []string{"abc.def", "t0ken", "xyz", "eyJhbGciOi"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("Expected %q to be redacted from %s", secret, got)
+		}
+	}
+	if !strings.Contains(got, "status=401") {
+		t.Errorf("Expected non-sensitive pairs to be kept, got %s", got)
+	}
+}
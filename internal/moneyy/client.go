@@ -19,6 +19,7 @@ type Client struct {
 	httpClient *http.Client
 	baseURL    string
 	apiKey     string
+	lastStatus int
 }
 
 // NewClient creates a new Moneyy API client
@@ -32,6 +33,11 @@ func NewClient(apiKey string) *Client {
 	}
 }
 
+// LastStatus returns the HTTP status of the most recent request, or 0 if it never completed
+func (c *Client) LastStatus() int {
+	return c.lastStatus
+}
+
 // APITaxBracket represents a tax bracket from the Moneyy API
 type APITaxBracket struct {
 	Min  float64  `json:"min"`
@@ -160,6 +166,7 @@ func (c *Client) GetTaxBrackets(country string, year int, region string) (*APITa
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer resp.Body.Close()
+	c.lastStatus = resp.StatusCode
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -230,6 +237,7 @@ func (c *Client) GetTaxParams(country string, year int, region string) (*APITaxP
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer resp.Body.Close()
+	c.lastStatus = resp.StatusCode
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -61,6 +61,8 @@ func (s *Service) FetchTaxBrackets(ctx context.Context, country string, year int
 	// Create client and fetch brackets
 	client := NewClient(apiKey)
 	apiResponse, err := client.GetTaxBrackets(country, year, region)
+	s.apiKeysSvc.RecordUsage(ctx, apikeys.ProviderMoneyy,
+		fmt.Sprintf("GET /api/v1/tax-brackets/%s/%d/%s", country, year, region), client.LastStatus())
 	if err != nil {
 		return nil, err
 	}
@@ -80,6 +82,8 @@ func (s *Service) FetchTaxParams(ctx context.Context, country string, year int,
 	// Create client and fetch params
 	client := NewClient(apiKey)
 	apiResponse, err := client.GetTaxParams(country, year, region)
+	s.apiKeysSvc.RecordUsage(ctx, apikeys.ProviderMoneyy,
+		fmt.Sprintf("GET /api/v1/tax-params/%s/%d/%s", country, year, region), client.LastStatus())
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,55 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"money/internal/balance"
+)
+
+// checkStaleBalances reminds the user to update manual accounts that have gone longer than
+// their threshold without a balance, listing them so they can be updated in one go. It
+// notifies at most once a week so a standing reminder doesn't repeat daily.
+func (s *Service) checkStaleBalances(ctx context.Context) (int, error) {
+	resp, err := s.balanceSvc.GetStaleness(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if !resp.Settings.Enabled || resp.StaleCount == 0 {
+		return 0, nil
+	}
+
+	stale := make([]*balance.AccountStaleness, 0, resp.StaleCount)
+	names := make([]string, 0, resp.StaleCount)
+	for _, a := range resp.Accounts {
+		if !a.IsStale {
+			continue
+		}
+		stale = append(stale, a)
+		if a.DaysSinceUpdate == nil {
+			names = append(names, a.AccountName+" (never updated)")
+		} else {
+			names = append(names, fmt.Sprintf("%s (%d days)", a.AccountName, *a.DaysSinceUpdate))
+		}
+	}
+
+	title := "1 account needs a balance update"
+	if len(stale) > 1 {
+		title = fmt.Sprintf("%d accounts need a balance update", len(stale))
+	}
+
+	year, week := time.Now().ISOWeek()
+	ok, err := s.Create(ctx, &CreateNotificationRequest{
+		Type:      TypeStaleBalances,
+		Title:     title,
+		Message:   fmt.Sprintf("These accounts haven't been updated in a while: %s.", strings.Join(names, ", ")),
+		DedupeKey: fmt.Sprintf("%s:%d-W%02d", TypeStaleBalances, year, week),
+		Payload:   stale,
+	})
+	if err != nil || !ok {
+		return 0, err
+	}
+	return 1, nil
+}
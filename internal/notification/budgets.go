@@ -0,0 +1,69 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"money/internal/transaction"
+)
+
+// NotifyBudgetThresholds checks the budgets of the month a transaction posted in. It is
+// registered with the transaction service, so alerts arrive as spending is recorded.
+// Transactions in past months are ignored so backfilling history doesn't raise old alerts.
+func (s *Service) NotifyBudgetThresholds(ctx context.Context, t *transaction.Transaction) error {
+	now := time.Now()
+	if t.Date.Year() != now.Year() || t.Date.Month() != now.Month() {
+		return nil
+	}
+	_, err := s.checkBudgetThresholds(ctx, now)
+	return err
+}
+
+// checkBudgetThresholds notifies about the highest alert threshold each budget has reached
+// this month. Notifications are deduplicated per budget, month and threshold, so each
+// threshold is announced once a month however often spending is checked.
+func (s *Service) checkBudgetThresholds(ctx context.Context, now time.Time) (int, error) {
+	month := now.Format("2006-01")
+	resp, err := s.transactionSvc.GetBudgetProgress(ctx, month)
+	if err != nil {
+		return 0, err
+	}
+
+	entityType := "budget"
+	created := 0
+	for _, progress := range resp.Budgets {
+		if len(progress.ThresholdsReached) == 0 {
+			continue
+		}
+		threshold := progress.ThresholdsReached[len(progress.ThresholdsReached)-1]
+		budget := progress.Budget
+
+		title := fmt.Sprintf("%s budget %g%% used", budget.Category, threshold)
+		if threshold >= 100 {
+			title = fmt.Sprintf("%s budget reached", budget.Category)
+		}
+		if progress.Overspent {
+			title = fmt.Sprintf("%s budget exceeded", budget.Category)
+		}
+
+		ok, err := s.Create(ctx, &CreateNotificationRequest{
+			Type:  TypeBudgetThreshold,
+			Title: title,
+			Message: fmt.Sprintf("You've spent %.2f %s of the %.2f available in your %s budget for %s.",
+				progress.Spent, budget.Currency, progress.Available, budget.Category, month),
+			EntityType: &entityType,
+			EntityID:   &budget.ID,
+			DedupeKey:  fmt.Sprintf("%s:%s:%s:%g", TypeBudgetThreshold, budget.ID, month, threshold),
+			Payload:    progress.BudgetMonth,
+		})
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+
+	return created, nil
+}
@@ -0,0 +1,434 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"money/internal/account"
+	"money/internal/analytics"
+	"money/internal/civil"
+	"money/internal/holdings"
+	"money/internal/income"
+)
+
+// holdingPeriodRuleNames maps holding-period rules to user-facing descriptions
+var holdingPeriodRuleNames = map[account.HoldingPeriodRule]string{
+	account.HoldingPeriodRuleUSISO:       "the US ISO qualifying disposition threshold",
+	account.HoldingPeriodRuleCADeduction: "the 2-year Canadian stock option deduction threshold",
+}
+
+// checkHoldingPeriods notifies about exercised shares about to cross a holding-period threshold
+func (s *Service) checkHoldingPeriods(ctx context.Context) (int, error) {
+	resp, err := s.accountSvc.GetAllHoldingPeriodAlerts(ctx, account.DefaultHoldingPeriodAlertDays)
+	if err != nil {
+		return 0, err
+	}
+
+	entityType := "equity_exercise"
+	created := 0
+	for _, alert := range resp.Alerts {
+		exerciseID := alert.ExerciseID
+		dueDate := alert.ThresholdDate.Time
+
+		ok, err := s.Create(ctx, &CreateNotificationRequest{
+			Type:  TypeHoldingPeriod,
+			Title: fmt.Sprintf("%s shares reach a holding-period threshold soon", alert.CompanyName),
+			Message: fmt.Sprintf("%d shares exercised on %s cross %s on %s (%d days). Selling before then may be a disqualifying disposition.",
+				alert.SharesHeld, alert.ExerciseDate.Format("2006-01-02"), holdingPeriodRuleNames[alert.Rule],
+				alert.ThresholdDate.Format("2006-01-02"), alert.DaysRemaining),
+			EntityType: &entityType,
+			EntityID:   &exerciseID,
+			DedupeKey:  fmt.Sprintf("%s:%s:%s", TypeHoldingPeriod, alert.ExerciseID, alert.Rule),
+			DueDate:    &dueDate,
+		})
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+
+	return created, nil
+}
+
+// checkGrantExpiry reminds about vested options that expire unexercised soon. Each grant
+// notifies once as its expiry enters each reminder window.
+func (s *Service) checkGrantExpiry(ctx context.Context) (int, error) {
+	resp, err := s.accountSvc.GetExpiringGrants(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	entityType := "equity_grant"
+	created := 0
+	for _, alert := range resp.Alerts {
+		grantID := alert.GrantID
+		dueDate := alert.ExpirationDate.Time
+
+		ok, err := s.Create(ctx, &CreateNotificationRequest{
+			Type:  TypeGrantExpiry,
+			Title: fmt.Sprintf("%s options expire in %d days", alert.CompanyName, alert.DaysRemaining),
+			Message: fmt.Sprintf("%d vested %s options expire on %s with %.2f of intrinsic value. Exercise them before then or they are lost.",
+				alert.UnexercisedShares, strings.ToUpper(string(alert.GrantType)), alert.ExpirationDate.Format("2006-01-02"),
+				alert.IntrinsicValue),
+			EntityType: &entityType,
+			EntityID:   &grantID,
+			DedupeKey:  fmt.Sprintf("%s:%s:%d", TypeGrantExpiry, alert.GrantID, alert.ReminderDays),
+			DueDate:    &dueDate,
+		})
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+
+	return created, nil
+}
+
+// jurisdictionNames maps installment jurisdictions to user-facing names
+var jurisdictionNames = map[income.Jurisdiction]string{
+	income.JurisdictionCRA: "CRA",
+	income.JurisdictionIRS: "IRS",
+}
+
+// checkTaxInstallments reminds about unpaid quarterly tax installments ahead of their due date
+func (s *Service) checkTaxInstallments(ctx context.Context) (int, error) {
+	resp, err := s.incomeSvc.GetUpcomingInstallments(ctx, income.DefaultInstallmentReminderDays)
+	if err != nil {
+		return 0, err
+	}
+
+	entityType := "tax_installment"
+	created := 0
+	for _, inst := range resp.Installments {
+		if inst.Remaining <= 0 {
+			continue
+		}
+		installmentID := inst.ID
+		dueDate := inst.DueDate
+
+		ok, err := s.Create(ctx, &CreateNotificationRequest{
+			Type:  TypeTaxInstallment,
+			Title: fmt.Sprintf("%s Q%d %d tax installment due %s", jurisdictionNames[inst.Jurisdiction], inst.Quarter, inst.TaxYear, inst.DueDate.Format("Jan 2")),
+			Message: fmt.Sprintf("%.2f of the %.2f installment is still unpaid and due on %s.",
+				inst.Remaining, inst.AmountDue, inst.DueDate.Format("2006-01-02")),
+			EntityType: &entityType,
+			EntityID:   &installmentID,
+			DedupeKey:  fmt.Sprintf("%s:%s:%s", TypeTaxInstallment, inst.ID, inst.DueDate.Format("2006-01-02")),
+			DueDate:    &dueDate,
+		})
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+
+	return created, nil
+}
+
+// checkSelfEmploymentTax warns when money set aside for self-employment tax falls behind the
+// estimate. It notifies at most once a month so a standing shortfall doesn't repeat daily.
+func (s *Service) checkSelfEmploymentTax(ctx context.Context) (int, error) {
+	now := time.Now()
+	status, err := s.incomeSvc.GetSelfEmploymentTaxStatus(ctx, now.Year())
+	if err != nil {
+		return 0, err
+	}
+	if !status.IsBehind || status.Warning == nil {
+		return 0, nil
+	}
+
+	ok, err := s.Create(ctx, &CreateNotificationRequest{
+		Type:      TypeSelfEmploymentTax,
+		Title:     fmt.Sprintf("Self-employment tax set-aside is %.2f behind", status.Shortfall),
+		Message:   *status.Warning,
+		DedupeKey: fmt.Sprintf("%s:%d:%s", TypeSelfEmploymentTax, status.TaxYear, now.Format("2006-01")),
+	})
+	if err != nil || !ok {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// alertConditionDescriptions describes each price alert condition for a notification title
+var alertConditionDescriptions = map[holdings.AlertCondition]string{
+	holdings.AlertConditionPriceAbove: "rose above %.2f",
+	holdings.AlertConditionPriceBelow: "fell below %.2f",
+	holdings.AlertConditionDailyMove:  "moved more than %.2f%% today",
+}
+
+// checkPriceAlerts notifies about holdings whose latest quote meets a price alert rule. Each
+// alert notifies at most once per quote day.
+func (s *Service) checkPriceAlerts(ctx context.Context) (int, error) {
+	resp, err := s.holdingsSvc.GetTriggeredPriceAlerts(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	entityType := "holding"
+	created := 0
+	for _, triggered := range resp.Alerts {
+		alert := triggered.Alert
+		holdingID := alert.HoldingID
+
+		message := fmt.Sprintf("%s last traded at %.2f as of %s.", triggered.Symbol, triggered.Price,
+			triggered.QuotedAt.Format("2006-01-02 15:04"))
+		if triggered.ChangePercent != nil {
+			message = fmt.Sprintf("%s last traded at %.2f (%+.2f%% from the previous close) as of %s.", triggered.Symbol,
+				triggered.Price, *triggered.ChangePercent, triggered.QuotedAt.Format("2006-01-02 15:04"))
+		}
+
+		ok, err := s.Create(ctx, &CreateNotificationRequest{
+			Type:       TypePriceAlert,
+			Title:      triggered.Symbol + " " + fmt.Sprintf(alertConditionDescriptions[alert.Condition], alert.Threshold),
+			Message:    message,
+			EntityType: &entityType,
+			EntityID:   &holdingID,
+			DedupeKey:  fmt.Sprintf("%s:%s:%s", TypePriceAlert, alert.ID, triggered.QuotedAt.Format("2006-01-02")),
+		})
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			if err := s.holdingsSvc.MarkPriceAlertTriggered(ctx, alert.ID, time.Now()); err != nil {
+				return created, err
+			}
+			created++
+		}
+	}
+
+	return created, nil
+}
+
+// checkJournalReviews reminds about holding journal entries whose review date has arrived,
+// with how the position moved since the entry was written. Each entry notifies once per
+// review date, so rescheduling a review reminds again.
+func (s *Service) checkJournalReviews(ctx context.Context) (int, error) {
+	resp, err := s.holdingsSvc.GetDueJournalReviews(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	entityType := "holding"
+	created := 0
+	for _, review := range resp.Reviews {
+		entry := review.Entry
+		holdingID := entry.HoldingID
+		dueDate := *entry.ReviewOn
+
+		name := "your holding"
+		if review.Symbol != nil {
+			name = *review.Symbol
+		}
+		message := fmt.Sprintf("Revisit the %s you wrote on %s.", entry.Kind, entry.EntryDate.Format("2006-01-02"))
+		if entry.ChangeSinceEntry != nil {
+			message = fmt.Sprintf("Revisit the %s you wrote on %s; the price has moved %+.2f%% since.", entry.Kind,
+				entry.EntryDate.Format("2006-01-02"), *entry.ChangeSinceEntry)
+		}
+
+		ok, err := s.Create(ctx, &CreateNotificationRequest{
+			Type:       TypeJournalReview,
+			Title:      "Time to review your notes on " + name,
+			Message:    message,
+			EntityType: &entityType,
+			EntityID:   &holdingID,
+			DedupeKey:  fmt.Sprintf("%s:%s:%s", TypeJournalReview, entry.ID, dueDate.Format("2006-01-02")),
+			DueDate:    &dueDate,
+		})
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+
+	return created, nil
+}
+
+// checkAllocationDrift warns when any asset class drifts past the user's band around its
+// target allocation, attaching the trades that would rebalance. It notifies at most once a
+// week so a standing drift doesn't repeat daily.
+func (s *Service) checkAllocationDrift(ctx context.Context) (int, error) {
+	drift, err := s.holdingsSvc.GetAllocationDrift(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if !drift.OutOfBand {
+		return 0, nil
+	}
+
+	var worst *holdings.AssetClassDrift
+	for _, class := range drift.Classes {
+		if class.OutOfBand && (worst == nil || math.Abs(class.DriftPercent) > math.Abs(worst.DriftPercent)) {
+			worst = class
+		}
+	}
+
+	year, week := time.Now().ISOWeek()
+	ok, err := s.Create(ctx, &CreateNotificationRequest{
+		Type:  TypeAllocationDrift,
+		Title: "Portfolio has drifted from its target allocation",
+		Message: fmt.Sprintf("%s is at %.2f%% against a %.2f%% target, outside the %.2f%% band. %d trades would rebalance the portfolio.",
+			worst.AssetClass, worst.CurrentPercent, worst.TargetPercent, drift.DriftBandPercent, len(drift.Trades)),
+		DedupeKey: fmt.Sprintf("%s:%d-W%02d", TypeAllocationDrift, year, week),
+		Payload:   drift,
+	})
+	if err != nil || !ok {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// checkPositionMismatches warns when a synced account's holding quantities no longer match
+// the recorded transactions, naming the symbols to review. It notifies at most once a week so
+// an unresolved mismatch doesn't repeat daily.
+func (s *Service) checkPositionMismatches(ctx context.Context) (int, error) {
+	reconciliations, err := s.holdingsSvc.ReconcileSyncedPositions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	discrepancies := make([]*holdings.PositionDiscrepancy, 0)
+	symbols := make([]string, 0)
+	for _, r := range reconciliations {
+		for _, d := range r.Discrepancies {
+			discrepancies = append(discrepancies, d)
+			symbols = append(symbols, fmt.Sprintf("%s (%+g shares)", d.Symbol, d.Difference))
+		}
+	}
+	if len(discrepancies) == 0 {
+		return 0, nil
+	}
+
+	title := "1 position doesn't match its transactions"
+	if len(discrepancies) > 1 {
+		title = fmt.Sprintf("%d positions don't match their transactions", len(discrepancies))
+	}
+
+	year, week := time.Now().ISOWeek()
+	ok, err := s.Create(ctx, &CreateNotificationRequest{
+		Type:  TypePositionMismatch,
+		Title: title,
+		Message: fmt.Sprintf("Synced quantities differ from the recorded buys and sells for %s. Import the missing trades or record an adjustment.",
+			strings.Join(symbols, ", ")),
+		DedupeKey: fmt.Sprintf("%s:%d-W%02d", TypePositionMismatch, year, week),
+		Payload:   discrepancies,
+	})
+	if err != nil || !ok {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// checkDocumentExpiry reminds about account documents nearing their expiry or renewal date.
+// Each document notifies once per due date, so a renewed document with a new date notifies again.
+func (s *Service) checkDocumentExpiry(ctx context.Context) (int, error) {
+	resp, err := s.accountSvc.GetExpiringDocuments(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	entityType := "account_document"
+	created := 0
+	for _, doc := range resp.Documents {
+		documentID := doc.ID
+		dueDate := doc.DueDate.Time
+
+		title := fmt.Sprintf("%s is due for %s on %s", doc.Name, doc.DueReason, doc.DueDate.Format("Jan 2"))
+		if doc.DaysRemaining < 0 {
+			title = fmt.Sprintf("%s is past its %s date", doc.Name, doc.DueReason)
+		}
+		ok, err := s.Create(ctx, &CreateNotificationRequest{
+			Type:  TypeDocumentExpiry,
+			Title: title,
+			Message: fmt.Sprintf("The %s document for %s has its %s date on %s.",
+				doc.Name, doc.AccountName, doc.DueReason, doc.DueDate.Format("2006-01-02")),
+			EntityType: &entityType,
+			EntityID:   &documentID,
+			DedupeKey:  fmt.Sprintf("%s:%s:%s", TypeDocumentExpiry, doc.ID, doc.DueDate),
+			DueDate:    &dueDate,
+		})
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+
+	return created, nil
+}
+
+// checkAnomalies runs anomaly detection and notifies about each open anomaly once
+func (s *Service) checkAnomalies(ctx context.Context) (int, error) {
+	if _, err := s.analyticsSvc.DetectAnomalies(ctx); err != nil {
+		return 0, err
+	}
+	resp, err := s.analyticsSvc.ListAnomalies(ctx, analytics.AnomalyStatusOpen)
+	if err != nil {
+		return 0, err
+	}
+
+	entityType := "anomaly"
+	created := 0
+	for _, anomaly := range resp.Anomalies {
+		anomalyID := anomaly.ID
+
+		ok, err := s.Create(ctx, &CreateNotificationRequest{
+			Type:       TypeAnomaly,
+			Title:      anomaly.Title,
+			Message:    anomaly.Description,
+			EntityType: &entityType,
+			EntityID:   &anomalyID,
+			DedupeKey:  fmt.Sprintf("%s:%s", TypeAnomaly, anomaly.ID),
+		})
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+
+	return created, nil
+}
+
+// checkCreditScoreReminder reminds users who opted in to enter a new credit score once the
+// latest one is a month old. It notifies at most once a month.
+func (s *Service) checkCreditScoreReminder(ctx context.Context) (int, error) {
+	settings, err := s.creditSvc.GetReminderSettings(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if !settings.Enabled || settings.NextDueOn == nil || settings.NextDueOn.After(time.Now()) {
+		return 0, nil
+	}
+
+	message := "You haven't recorded a credit score yet. Add your latest score to start tracking it."
+	if settings.LastEntryOn != nil {
+		message = fmt.Sprintf("Your latest credit score is from %s. Add this month's score to keep the history current.",
+			settings.LastEntryOn)
+	}
+	dueDate := settings.NextDueOn.Time
+
+	ok, err := s.Create(ctx, &CreateNotificationRequest{
+		Type:      TypeCreditScore,
+		Title:     "Time to update your credit score",
+		Message:   message,
+		DedupeKey: fmt.Sprintf("%s:%s", TypeCreditScore, civil.TodayIn(ctx).Format("2006-01")),
+		DueDate:   &dueDate,
+	})
+	if err != nil || !ok {
+		return 0, err
+	}
+	return 1, nil
+}
@@ -0,0 +1,628 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+	"money/internal/background"
+	"money/internal/civil"
+	"money/internal/egress"
+	"money/internal/logger"
+)
+
+// dispatchInterval is how often pending notifications are delivered to external channels
+const dispatchInterval = 5 * time.Minute
+
+// Channel is a way a notification reaches the user
+type Channel string
+
+const (
+	ChannelInApp   Channel = "in_app"
+	ChannelEmail   Channel = "email"
+	ChannelWebhook Channel = "webhook"
+)
+
+// Sender delivers messages on an external channel
+type Sender interface {
+	Send(ctx context.Context, to string, message *Message) error
+}
+
+// Message is what a sender delivers: a single notification, or a digest of several
+type Message struct {
+	Subject       string          `json:"subject"`
+	Body          string          `json:"body"`
+	IsDigest      bool            `json:"is_digest"`
+	Notifications []*Notification `json:"notifications"`
+}
+
+// DeliveryPreferences controls how a user's notifications are delivered outside the app.
+// Types without chosen channels are shown in-app only.
+type DeliveryPreferences struct {
+	DigestEnabled bool               `json:"digest_enabled"`        // Batch external deliveries into one message a day
+	DigestHour    int                `json:"digest_hour"`           // Local hour the digest goes out
+	QuietStart    *string            `json:"quiet_start,omitempty"` // HH:MM local
+	QuietEnd      *string            `json:"quiet_end,omitempty"`
+	Email         *string            `json:"email,omitempty"`
+	WebhookURL    *string            `json:"webhook_url,omitempty"`
+	Channels      map[Type][]Channel `json:"channels"`
+}
+
+// UpdateDeliveryPreferencesRequest changes the fields that are set. Empty strings clear
+// quiet hours and addresses. Channels replace those of the listed types; an empty list
+// returns a type to in-app only.
+type UpdateDeliveryPreferencesRequest struct {
+	DigestEnabled *bool              `json:"digest_enabled,omitempty"`
+	DigestHour    *int               `json:"digest_hour,omitempty"`
+	QuietStart    *string            `json:"quiet_start,omitempty"`
+	QuietEnd      *string            `json:"quiet_end,omitempty"`
+	Email         *string            `json:"email,omitempty"`
+	WebhookURL    *string            `json:"webhook_url,omitempty"`
+	Channels      map[Type][]Channel `json:"channels,omitempty"`
+}
+
+// Delivery records a message sent, or attempted, on an external channel
+type Delivery struct {
+	ID            string    `json:"id"`
+	Channel       Channel   `json:"channel"`
+	Notifications int       `json:"notifications"`
+	IsDigest      bool      `json:"is_digest"`
+	Status        string    `json:"status"` // sent, failed
+	Error         *string   `json:"error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ListDeliveriesResponse represents the user's recent deliveries, newest first
+type ListDeliveriesResponse struct {
+	Deliveries []*Delivery `json:"deliveries"`
+}
+
+// SetSender registers the sender used for an external channel. Notifications routed to a
+// channel without a sender are logged as failed deliveries.
+func (s *Service) SetSender(channel Channel, sender Sender) {
+	s.senders[channel] = sender
+}
+
+// GetDeliveryPreferences returns the current user's delivery preferences
+func (s *Service) GetDeliveryPreferences(ctx context.Context) (*DeliveryPreferences, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	return s.deliveryPreferences(ctx, userID)
+}
+
+// UpdateDeliveryPreferences changes the current user's delivery preferences
+func (s *Service) UpdateDeliveryPreferences(ctx context.Context, req *UpdateDeliveryPreferencesRequest) (*DeliveryPreferences, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	prefs, err := s.deliveryPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DigestEnabled != nil {
+		prefs.DigestEnabled = *req.DigestEnabled
+	}
+	if req.DigestHour != nil {
+		if *req.DigestHour < 0 || *req.DigestHour > 23 {
+			return nil, fmt.Errorf("digest hour must be between 0 and 23")
+		}
+		prefs.DigestHour = *req.DigestHour
+	}
+	for _, field := range []struct {
+		value  *string
+		target **string
+	}{{req.QuietStart, &prefs.QuietStart}, {req.QuietEnd, &prefs.QuietEnd}} {
+		if field.value == nil {
+			continue
+		}
+		if *field.value == "" {
+			*field.target = nil
+			continue
+		}
+		if _, err := parseClock(*field.value); err != nil {
+			return nil, err
+		}
+		value := *field.value
+		*field.target = &value
+	}
+	if (prefs.QuietStart == nil) != (prefs.QuietEnd == nil) {
+		return nil, fmt.Errorf("quiet hours need both a start and an end")
+	}
+	if req.Email != nil {
+		prefs.Email = nil
+		if email := strings.TrimSpace(*req.Email); email != "" {
+			if !strings.Contains(email, "@") {
+				return nil, fmt.Errorf("invalid email address")
+			}
+			prefs.Email = &email
+		}
+	}
+	if req.WebhookURL != nil {
+		prefs.WebhookURL = nil
+		if webhookURL := strings.TrimSpace(*req.WebhookURL); webhookURL != "" {
+			if err := egress.CheckURL(webhookURL); err != nil {
+				return nil, fmt.Errorf("webhook URL: %w", err)
+			}
+			prefs.WebhookURL = &webhookURL
+		}
+	}
+	for notificationType, channels := range req.Channels {
+		if notificationType == "" {
+			return nil, fmt.Errorf("notification type is required")
+		}
+		chosen := make([]Channel, 0, len(channels))
+		for _, channel := range channels {
+			switch channel {
+			case ChannelInApp, ChannelEmail, ChannelWebhook:
+			default:
+				return nil, fmt.Errorf("invalid channel: %s", channel)
+			}
+			if !containsChannel(chosen, channel) {
+				chosen = append(chosen, channel)
+			}
+		}
+		if len(chosen) == 0 {
+			delete(prefs.Channels, notificationType)
+			continue
+		}
+		prefs.Channels[notificationType] = chosen
+	}
+	for _, channels := range prefs.Channels {
+		for _, channel := range channels {
+			if channel == ChannelEmail && prefs.Email == nil {
+				return nil, fmt.Errorf("an email address is required for the email channel")
+			}
+			if channel == ChannelWebhook && prefs.WebhookURL == nil {
+				return nil, fmt.Errorf("a webhook URL is required for the webhook channel")
+			}
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO notification_preferences (user_id, digest_enabled, digest_hour, quiet_start, quiet_end, email, webhook_url, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id) DO UPDATE SET
+			digest_enabled = excluded.digest_enabled,
+			digest_hour = excluded.digest_hour,
+			quiet_start = excluded.quiet_start,
+			quiet_end = excluded.quiet_end,
+			email = excluded.email,
+			webhook_url = excluded.webhook_url,
+			updated_at = excluded.updated_at
+	`, userID, prefs.DigestEnabled, prefs.DigestHour, prefs.QuietStart, prefs.QuietEnd, prefs.Email, prefs.WebhookURL, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to save delivery preferences: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM notification_channels WHERE user_id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("failed to clear notification channels: %w", err)
+	}
+	for notificationType, channels := range prefs.Channels {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO notification_channels (user_id, type, channels) VALUES ($1, $2, $3)
+		`, userID, notificationType, joinChannels(channels))
+		if err != nil {
+			return nil, fmt.Errorf("failed to save notification channels: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return prefs, nil
+}
+
+// ListDeliveries returns the current user's recent external deliveries
+func (s *Service) ListDeliveries(ctx context.Context) (*ListDeliveriesResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, channel, notifications, is_digest, status, error, created_at
+		FROM notification_deliveries
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 100
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*Delivery, 0)
+	for rows.Next() {
+		d := &Delivery{}
+		if err := rows.Scan(&d.ID, &d.Channel, &d.Notifications, &d.IsDigest, &d.Status, &d.Error, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return &ListDeliveriesResponse{Deliveries: deliveries}, rows.Err()
+}
+
+// Dispatch delivers every user's pending notifications to their external channels and
+// returns how many messages were sent. Users in quiet hours, or waiting for their digest
+// hour, keep their notifications pending for a later pass.
+func (s *Service) Dispatch(ctx context.Context, now time.Time) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT user_id FROM notifications WHERE dispatched_at IS NULL AND channels != ''
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pending deliveries: %w", err)
+	}
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan pending delivery: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			break
+		}
+		n, err := s.dispatchUser(ctx, userID, now)
+		if err != nil {
+			logger.Warn("Notification dispatch failed", "user_id", userID, "error", err)
+			continue
+		}
+		sent += n
+	}
+	return sent, nil
+}
+
+// StartDispatch delivers pending notifications on every dispatch interval until jobs drains
+func (s *Service) StartDispatch(jobs *background.Group) {
+	jobs.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(dispatchInterval)
+		defer ticker.Stop()
+
+		for {
+			jobs.Exclusive(ctx, "notification_dispatch", dispatchInterval, func(ctx context.Context) {
+				if _, err := s.Dispatch(ctx, time.Now()); err != nil {
+					logger.Error("Notification dispatch failed", "error", err)
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// dispatchUser delivers a user's pending notifications, one message per notification or a
+// single digest per channel, and marks them dispatched whether or not delivery succeeded.
+// Failures are kept in the delivery log rather than retried.
+func (s *Service) dispatchUser(ctx context.Context, userID string, now time.Time) (int, error) {
+	prefs, err := s.deliveryPreferences(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	local := now.In(s.userLocation(ctx, userID))
+	if prefs.inQuietHours(local) {
+		return 0, nil
+	}
+	today := civil.DateOf(local).String()
+	if prefs.DigestEnabled {
+		lastDigest, err := s.lastDigestDate(ctx, userID)
+		if err != nil {
+			return 0, err
+		}
+		if local.Hour() < prefs.DigestHour || lastDigest == today {
+			return 0, nil
+		}
+	}
+
+	pending, err := s.pendingDeliveries(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	byChannel := make(map[Channel][]*Notification)
+	ids := make([]interface{}, 0, len(pending))
+	for _, p := range pending {
+		for _, channel := range p.channels {
+			byChannel[channel] = append(byChannel[channel], p.notification)
+		}
+		ids = append(ids, p.notification.ID)
+	}
+
+	sent := 0
+	for _, channel := range []Channel{ChannelEmail, ChannelWebhook} {
+		notifications := byChannel[channel]
+		if len(notifications) == 0 {
+			continue
+		}
+		var messages []*Message
+		if prefs.DigestEnabled {
+			messages = []*Message{digestMessage(notifications)}
+		} else {
+			for _, n := range notifications {
+				messages = append(messages, &Message{Subject: n.Title, Body: n.Message, Notifications: []*Notification{n}})
+			}
+		}
+		for _, message := range messages {
+			sendErr := s.send(ctx, channel, prefs, message)
+			if err := s.recordDelivery(ctx, userID, channel, message, sendErr, now); err != nil {
+				return sent, err
+			}
+			if sendErr == nil {
+				sent++
+			}
+		}
+	}
+
+	placeholders := make([]string, len(ids))
+	for i := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE notifications SET dispatched_at = $1 WHERE id IN (`+strings.Join(placeholders, ", ")+`)
+	`, append([]interface{}{now}, ids...)...)
+	if err != nil {
+		return sent, fmt.Errorf("failed to mark notifications dispatched: %w", err)
+	}
+	if prefs.DigestEnabled {
+		if _, err := s.db.ExecContext(ctx, `
+			UPDATE notification_preferences SET last_digest_date = $1 WHERE user_id = $2
+		`, today, userID); err != nil {
+			return sent, fmt.Errorf("failed to record digest: %w", err)
+		}
+	}
+	return sent, nil
+}
+
+// send delivers a message on a channel to the address the user set for it
+func (s *Service) send(ctx context.Context, channel Channel, prefs *DeliveryPreferences, message *Message) error {
+	sender := s.senders[channel]
+	if sender == nil {
+		return fmt.Errorf("%s delivery is not configured on this server", channel)
+	}
+	var to *string
+	switch channel {
+	case ChannelEmail:
+		to = prefs.Email
+	case ChannelWebhook:
+		to = prefs.WebhookURL
+	}
+	if to == nil {
+		return fmt.Errorf("no %s address set", channel)
+	}
+	return sender.Send(ctx, *to, message)
+}
+
+// recordDelivery logs a delivery attempt and its outcome
+func (s *Service) recordDelivery(ctx context.Context, userID string, channel Channel, message *Message, sendErr error, now time.Time) error {
+	status := "sent"
+	var errMessage *string
+	if sendErr != nil {
+		status = "failed"
+		text := sendErr.Error()
+		errMessage = &text
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO notification_deliveries (id, user_id, channel, notifications, is_digest, status, error, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, uuid.New().String(), userID, channel, len(message.Notifications), message.IsDigest, status, errMessage, now)
+	if err != nil {
+		return fmt.Errorf("failed to record delivery: %w", err)
+	}
+	return nil
+}
+
+// digestMessage combines notifications into one message, oldest first
+func digestMessage(notifications []*Notification) *Message {
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].CreatedAt.Before(notifications[j].CreatedAt)
+	})
+	var body strings.Builder
+	for _, n := range notifications {
+		fmt.Fprintf(&body, "- %s: %s\n", n.Title, n.Message)
+	}
+	subject := "1 new notification"
+	if len(notifications) > 1 {
+		subject = fmt.Sprintf("%d new notifications", len(notifications))
+	}
+	return &Message{Subject: subject, Body: body.String(), IsDigest: true, Notifications: notifications}
+}
+
+// pendingDelivery is an undispatched notification and the external channels it goes to
+type pendingDelivery struct {
+	notification *Notification
+	channels     []Channel
+}
+
+// pendingDeliveries loads a user's notifications still to be delivered, oldest first
+func (s *Service) pendingDeliveries(ctx context.Context, userID string) ([]*pendingDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, type, title, message, entity_type, entity_id, due_date, created_at, channels
+		FROM notifications
+		WHERE user_id = $1 AND dispatched_at IS NULL AND channels != ''
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending notifications: %w", err)
+	}
+	defer rows.Close()
+
+	pending := make([]*pendingDelivery, 0)
+	for rows.Next() {
+		n := &Notification{}
+		var channels string
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.Title, &n.Message, &n.EntityType, &n.EntityID,
+			&n.DueDate, &n.CreatedAt, &channels); err != nil {
+			return nil, fmt.Errorf("failed to scan pending notification: %w", err)
+		}
+		pending = append(pending, &pendingDelivery{notification: n, channels: splitChannels(channels)})
+	}
+	return pending, rows.Err()
+}
+
+// deliveryPreferences loads a user's delivery preferences, defaulting to in-app only
+func (s *Service) deliveryPreferences(ctx context.Context, userID string) (*DeliveryPreferences, error) {
+	prefs := &DeliveryPreferences{DigestHour: 8, Channels: make(map[Type][]Channel)}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT digest_enabled, digest_hour, quiet_start, quiet_end, email, webhook_url
+		FROM notification_preferences
+		WHERE user_id = $1
+	`, userID).Scan(&prefs.DigestEnabled, &prefs.DigestHour, &prefs.QuietStart, &prefs.QuietEnd, &prefs.Email, &prefs.WebhookURL)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get delivery preferences: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT type, channels FROM notification_channels WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification channels: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var notificationType Type
+		var channels string
+		if err := rows.Scan(&notificationType, &channels); err != nil {
+			return nil, fmt.Errorf("failed to scan notification channels: %w", err)
+		}
+		prefs.Channels[notificationType] = splitChannels(channels)
+	}
+	return prefs, rows.Err()
+}
+
+// routeNotification returns whether a new notification of a type is listed in the app and
+// the external channels it is still to be delivered to
+func (s *Service) routeNotification(ctx context.Context, userID string, notificationType Type) (bool, string, error) {
+	var channels string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT channels FROM notification_channels WHERE user_id = $1 AND type = $2
+	`, userID, notificationType).Scan(&channels)
+	if err == sql.ErrNoRows {
+		return true, "", nil
+	}
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get notification channels: %w", err)
+	}
+
+	inApp := false
+	external := make([]Channel, 0)
+	for _, channel := range splitChannels(channels) {
+		if channel == ChannelInApp {
+			inApp = true
+			continue
+		}
+		external = append(external, channel)
+	}
+	return inApp, joinChannels(external), nil
+}
+
+// lastDigestDate returns the local date of the user's last digest, empty when none was sent
+func (s *Service) lastDigestDate(ctx context.Context, userID string) (string, error) {
+	var date sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT last_digest_date FROM notification_preferences WHERE user_id = $1
+	`, userID).Scan(&date)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to get last digest: %w", err)
+	}
+	return date.String, nil
+}
+
+// userLocation returns the user's timezone, falling back to the instance default
+func (s *Service) userLocation(ctx context.Context, userID string) *time.Location {
+	var timezone sql.NullString
+	if err := s.db.QueryRowContext(ctx, `SELECT timezone FROM users WHERE id = $1`, userID).Scan(&timezone); err == nil && timezone.String != "" {
+		if loc, err := time.LoadLocation(timezone.String); err == nil {
+			return loc
+		}
+	}
+	return civil.DefaultLocation()
+}
+
+// inQuietHours reports whether a local time falls in the user's quiet hours. Quiet hours
+// that end earlier than they start run over midnight.
+func (p *DeliveryPreferences) inQuietHours(local time.Time) bool {
+	if p.QuietStart == nil || p.QuietEnd == nil {
+		return false
+	}
+	start, err := parseClock(*p.QuietStart)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(*p.QuietEnd)
+	if err != nil || start == end {
+		return false
+	}
+	minute := local.Hour()*60 + local.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// parseClock reads an HH:MM time of day as minutes after midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// splitChannels reads a stored comma separated channel list
+func splitChannels(value string) []Channel {
+	channels := make([]Channel, 0)
+	for _, part := range strings.Split(value, ",") {
+		if part != "" {
+			channels = append(channels, Channel(part))
+		}
+	}
+	return channels
+}
+
+// containsChannel reports whether a channel is in a list
+func containsChannel(channels []Channel, channel Channel) bool {
+	for _, c := range channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// joinChannels stores a channel list as comma separated text
+func joinChannels(channels []Channel) string {
+	parts := make([]string, len(channels))
+	for i, channel := range channels {
+		parts[i] = string(channel)
+	}
+	return strings.Join(parts, ",")
+}
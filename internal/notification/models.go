@@ -0,0 +1,71 @@
+package notification
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Type identifies what raised a notification
+type Type string
+
+const (
+	TypeHoldingPeriod     Type = "holding_period"
+	TypeSelfEmploymentTax Type = "self_employment_tax"
+	TypeTaxInstallment    Type = "tax_installment"
+	TypePriceAlert        Type = "price_alert"
+	TypeAllocationDrift   Type = "allocation_drift"
+	TypeDocumentExpiry    Type = "document_expiry"
+	TypeAnomaly           Type = "anomaly"
+	TypeCreditScore       Type = "credit_score"
+	TypeSyncConflict      Type = "sync_conflict"
+	TypeBudgetThreshold   Type = "budget_threshold"
+	TypeStaleBalances     Type = "stale_balances"
+	TypePositionMismatch  Type = "position_mismatch"
+	TypeGrantExpiry       Type = "grant_expiry"
+	TypeJournalReview     Type = "journal_review"
+)
+
+// Notification represents a message for a user
+type Notification struct {
+	ID         string          `json:"id"`
+	UserID     string          `json:"user_id"`
+	Type       Type            `json:"type"`
+	Title      string          `json:"title"`
+	Message    string          `json:"message"`
+	EntityType *string         `json:"entity_type,omitempty"`
+	EntityID   *string         `json:"entity_id,omitempty"`
+	DueDate    *time.Time      `json:"due_date,omitempty"`
+	Payload    json.RawMessage `json:"payload,omitempty"` // Structured details, e.g. suggested trades
+	IsRead     bool            `json:"is_read"`
+	ReadAt     *time.Time      `json:"read_at,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// CreateNotificationRequest represents a notification to raise for a user.
+// DedupeKey identifies the underlying condition so repeated checks don't notify twice.
+type CreateNotificationRequest struct {
+	Type       Type
+	Title      string
+	Message    string
+	EntityType *string
+	EntityID   *string
+	DedupeKey  string
+	DueDate    *time.Time
+	Payload    interface{} // Marshalled to JSON when set
+}
+
+// ListNotificationsResponse represents a list of notifications
+type ListNotificationsResponse struct {
+	Notifications []*Notification `json:"notifications"`
+	UnreadCount   int             `json:"unread_count"`
+}
+
+// RefreshResponse reports how many new notifications a refresh raised
+type RefreshResponse struct {
+	Created int `json:"created"`
+}
+
+// DeleteResponse represents a successful delete response
+type DeleteResponse struct {
+	Success bool `json:"success"`
+}
@@ -0,0 +1,55 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+
+	"money/internal/database"
+)
+
+// repository reads the notification tables. Each method runs one named query and returns
+// typed rows, and a row that fails to scan fails the call instead of being skipped.
+type repository struct {
+	db database.Querier
+}
+
+// notificationsQuery lists a user's in-app notifications newest first, optionally only unread ones
+var notificationsQuery = database.Query{
+	Name: "notifications",
+	SQL: `
+		SELECT id, user_id, type, title, message, entity_type, entity_id, due_date, payload, is_read, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1 AND in_app = 1 AND ($2 = 0 OR is_read = 0)
+		ORDER BY created_at DESC
+	`,
+}
+
+// scanNotification reads a row selected by notificationsQuery
+func scanNotification(row database.Scanner) (*Notification, error) {
+	n := &Notification{}
+	var payload *string
+	if err := row.Scan(&n.ID, &n.UserID, &n.Type, &n.Title, &n.Message, &n.EntityType, &n.EntityID,
+		&n.DueDate, &payload, &n.IsRead, &n.ReadAt, &n.CreatedAt); err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		n.Payload = json.RawMessage(*payload)
+	}
+	return n, nil
+}
+
+// notifications lists a user's in-app notifications newest first
+func (r repository) notifications(ctx context.Context, userID string, unreadOnly bool) ([]*Notification, error) {
+	return database.All(ctx, r.db, notificationsQuery, scanNotification, userID, unreadOnly)
+}
+
+// accountOwnersQuery lists every user that owns accounts
+var accountOwnersQuery = database.Query{
+	Name: "account owners",
+	SQL:  `SELECT DISTINCT user_id FROM accounts`,
+}
+
+// accountOwners returns the IDs of every user that owns accounts
+func (r repository) accountOwners(ctx context.Context) ([]string, error) {
+	return database.All(ctx, r.db, accountOwnersQuery, database.Strings)
+}
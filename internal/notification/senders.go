@@ -0,0 +1,83 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"money/internal/egress"
+)
+
+// WebhookSender posts messages as JSON to the user's webhook URL
+type WebhookSender struct {
+	client *http.Client
+}
+
+// NewWebhookSender creates a webhook sender that only reaches public addresses
+func NewWebhookSender() *WebhookSender {
+	return &WebhookSender{client: egress.NewClient(10 * time.Second)}
+}
+
+// Send posts the message to the URL, failing on any non-2xx response
+func (w *WebhookSender) Send(ctx context.Context, to string, message *Message) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, to, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// EmailSender sends messages as plain text email through an SMTP relay
+type EmailSender struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewEmailSender creates an email sender for an SMTP relay. Authentication is only used
+// when a username is given.
+func NewEmailSender(host string, port int, username, password, from string) *EmailSender {
+	sender := &EmailSender{addr: net.JoinHostPort(host, fmt.Sprint(port)), from: from}
+	if username != "" {
+		sender.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return sender
+}
+
+// Send emails the message to the address
+func (e *EmailSender) Send(ctx context.Context, to string, message *Message) error {
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid email address")
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(message.Subject))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(message.Body)
+
+	if err := smtp.SendMail(e.addr, e.auth, e.from, []string{to}, msg.Bytes()); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
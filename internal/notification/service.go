@@ -0,0 +1,303 @@
+// Package notification stores user notifications and runs the checks that raise them.
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/account"
+	"money/internal/analytics"
+	"money/internal/auth"
+	"money/internal/background"
+	"money/internal/balance"
+	"money/internal/credit"
+	"money/internal/holdings"
+	"money/internal/income"
+	"money/internal/logger"
+	"money/internal/transaction"
+)
+
+// Service provides notification functionality
+type Service struct {
+	db             *sql.DB
+	accountSvc     *account.Service
+	balanceSvc     *balance.Service
+	incomeSvc      *income.Service
+	holdingsSvc    *holdings.Service
+	analyticsSvc   *analytics.Service
+	creditSvc      *credit.Service
+	transactionSvc *transaction.Service
+	senders        map[Channel]Sender
+	repo           repository
+}
+
+// NewService creates a new notification service
+func NewService(db *sql.DB, accountSvc *account.Service, balanceSvc *balance.Service, incomeSvc *income.Service, holdingsSvc *holdings.Service, analyticsSvc *analytics.Service, creditSvc *credit.Service, transactionSvc *transaction.Service) *Service {
+	return &Service{
+		db:             db,
+		accountSvc:     accountSvc,
+		balanceSvc:     balanceSvc,
+		incomeSvc:      incomeSvc,
+		holdingsSvc:    holdingsSvc,
+		analyticsSvc:   analyticsSvc,
+		creditSvc:      creditSvc,
+		transactionSvc: transactionSvc,
+		senders:        make(map[Channel]Sender),
+		repo:           repository{db: db},
+	}
+}
+
+// Create raises a notification for the current user. It returns false without error
+// when a notification with the same dedupe key already exists.
+func (s *Service) Create(ctx context.Context, req *CreateNotificationRequest) (bool, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return false, fmt.Errorf("user not authenticated")
+	}
+
+	if req.DedupeKey == "" {
+		return false, fmt.Errorf("dedupe key is required")
+	}
+
+	var payload *string
+	if req.Payload != nil {
+		data, err := json.Marshal(req.Payload)
+		if err != nil {
+			return false, fmt.Errorf("failed to encode notification payload: %w", err)
+		}
+		encoded := string(data)
+		payload = &encoded
+	}
+
+	// The user's channels for the type decide whether it is listed in the app and where the
+	// dispatcher delivers it
+	inApp, channels, err := s.routeNotification(ctx, userID, req.Type)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO notifications (id, user_id, type, title, message, entity_type, entity_id, dedupe_key, due_date, payload, in_app, channels, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (user_id, dedupe_key) DO NOTHING
+	`, uuid.New().String(), userID, req.Type, req.Title, req.Message, req.EntityType, req.EntityID,
+		req.DedupeKey, req.DueDate, payload, inApp, channels, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// List returns the current user's in-app notifications, newest first
+func (s *Service) List(ctx context.Context, unreadOnly bool) (*ListNotificationsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	notifications, err := s.repo.notifications(ctx, userID, unreadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+
+	unreadCount := 0
+	for _, n := range notifications {
+		if !n.IsRead {
+			unreadCount++
+		}
+	}
+
+	return &ListNotificationsResponse{
+		Notifications: notifications,
+		UnreadCount:   unreadCount,
+	}, nil
+}
+
+// MarkRead marks a single notification as read
+func (s *Service) MarkRead(ctx context.Context, id string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE notifications SET is_read = 1, read_at = $1
+		WHERE id = $2 AND user_id = $3
+	`, time.Now(), id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification read: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("notification not found")
+	}
+
+	return nil
+}
+
+// MarkAllRead marks all of the current user's notifications as read
+func (s *Service) MarkAllRead(ctx context.Context) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE notifications SET is_read = 1, read_at = $1
+		WHERE user_id = $2 AND is_read = 0
+	`, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a notification
+func (s *Service) Delete(ctx context.Context, id string) (*DeleteResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM notifications WHERE id = $1 AND user_id = $2
+	`, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete notification: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("notification not found")
+	}
+
+	return &DeleteResponse{Success: true}, nil
+}
+
+// Refresh runs all notification checks for the current user
+func (s *Service) Refresh(ctx context.Context) (*RefreshResponse, error) {
+	created, err := s.checkHoldingPeriods(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	taxCreated, err := s.checkSelfEmploymentTax(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	installmentsCreated, err := s.checkTaxInstallments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	priceAlertsCreated, err := s.checkPriceAlerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	driftCreated, err := s.checkAllocationDrift(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	documentsCreated, err := s.checkDocumentExpiry(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	anomaliesCreated, err := s.checkAnomalies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	creditCreated, err := s.checkCreditScoreReminder(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	budgetsCreated, err := s.checkBudgetThresholds(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	staleCreated, err := s.checkStaleBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	positionsCreated, err := s.checkPositionMismatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	expiryCreated, err := s.checkGrantExpiry(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewsCreated, err := s.checkJournalReviews(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RefreshResponse{Created: created + taxCreated + installmentsCreated + priceAlertsCreated + driftCreated +
+		documentsCreated + anomaliesCreated + creditCreated + budgetsCreated + staleCreated + positionsCreated +
+		expiryCreated + reviewsCreated}, nil
+}
+
+// RefreshAll runs notification checks for every user that owns accounts
+func (s *Service) RefreshAll(ctx context.Context) error {
+	userIDs, err := s.repo.accountOwners(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+
+	for _, userID := range userIDs {
+		if _, err := s.Refresh(auth.WithUserID(ctx, userID)); err != nil {
+			logger.Warn("Notification refresh failed", "user_id", userID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// Start runs RefreshAll immediately and then on every interval until jobs drains
+func (s *Service) Start(jobs *background.Group, interval time.Duration) {
+	jobs.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			jobs.Exclusive(ctx, "notification_checks", interval, func(ctx context.Context) {
+				if err := s.RefreshAll(ctx); err != nil {
+					logger.Error("Notification checks failed", "error", err)
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
@@ -0,0 +1,387 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"money/internal/account"
+	"money/internal/analytics"
+	"money/internal/balance"
+	"money/internal/credit"
+	"money/internal/holdings"
+	"money/internal/income"
+	"money/internal/inflation"
+	"money/internal/sync"
+	"money/internal/transaction"
+)
+
+func setupService(t *testing.T) (*Service, func()) {
+	t.Helper()
+	db := account.SetupTestDB(t)
+	accountSvc := account.NewService(db, db, balance.NewService(db))
+	incomeSvc := income.NewService(db)
+	transactionSvc := transaction.NewService(db)
+	analyticsSvc := analytics.NewService(db, incomeSvc, transactionSvc, inflation.NewService(db, ""))
+	return NewService(db, accountSvc, balance.NewService(db), incomeSvc, holdings.NewService(db), analyticsSvc, credit.NewService(db), transactionSvc), func() { account.CleanupTestDB(t, db) }
+}
+
+func TestCreate_DedupesByKey(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	userID := "test-user-notification-1"
+	ctx := account.CreateAuthContext(userID)
+	req := &CreateNotificationRequest{
+		Type:      TypeHoldingPeriod,
+		Title:     "Threshold soon",
+		Message:   "Shares cross a threshold soon",
+		DedupeKey: "holding_period:ex-1:ca_two_year",
+	}
+
+	// Act
+	first, err := service.Create(ctx, req)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	second, err := service.Create(ctx, req)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// Assert
+	if !first {
+		t.Error("Expected first create to insert a notification")
+	}
+	if second {
+		t.Error("Expected duplicate create to be ignored")
+	}
+
+	resp, err := service.List(ctx, true)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(resp.Notifications) != 1 || resp.UnreadCount != 1 {
+		t.Errorf("Expected 1 unread notification, got %d (unread %d)", len(resp.Notifications), resp.UnreadCount)
+	}
+}
+
+func TestList_UnreadOnlySkipsReadNotifications(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	ctx := account.CreateAuthContext("test-user-notification-unread")
+	for _, key := range []string{"holding_period:ex-3:ca_two_year", "holding_period:ex-4:ca_two_year"} {
+		if _, err := service.Create(ctx, &CreateNotificationRequest{
+			Type: TypeHoldingPeriod, Title: "Threshold soon", Message: "Shares cross a threshold soon", DedupeKey: key,
+		}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	all, err := service.List(ctx, false)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if err := service.MarkRead(ctx, all.Notifications[0].ID); err != nil {
+		t.Fatalf("MarkRead failed: %v", err)
+	}
+
+	// Act
+	unread, err := service.List(ctx, true)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(unread.Notifications) != 1 || unread.Notifications[0].ID != all.Notifications[1].ID || unread.UnreadCount != 1 {
+		t.Errorf("Expected only the unread notification, got %d (unread %d)", len(unread.Notifications), unread.UnreadCount)
+	}
+}
+
+func TestMarkRead_OtherUserDenied(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	ownerCtx := account.CreateAuthContext("test-user-notification-owner")
+	otherCtx := account.CreateAuthContext("test-user-notification-other")
+	service.Create(ownerCtx, &CreateNotificationRequest{
+		Type:      TypeHoldingPeriod,
+		Title:     "Threshold soon",
+		Message:   "Shares cross a threshold soon",
+		DedupeKey: "holding_period:ex-2:ca_two_year",
+	})
+	resp, _ := service.List(ownerCtx, false)
+	if len(resp.Notifications) != 1 {
+		t.Fatalf("Expected 1 notification, got %d", len(resp.Notifications))
+	}
+
+	// Act
+	err := service.MarkRead(otherCtx, resp.Notifications[0].ID)
+
+	// Assert
+	if err == nil {
+		t.Error("Expected error marking another user's notification read")
+	}
+}
+
+func TestNotifySyncConflict_DedupesRedelivery(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	userID := "test-user-notification-sync"
+	ctx := account.CreateAuthContext(userID)
+	payload, _ := json.Marshal(&sync.ConflictFlaggedPayload{
+		ConflictID:     "conflict-1",
+		AccountID:      "account-1",
+		BalanceDate:    "2026-03-01",
+		ManualAmount:   1000,
+		ProviderAmount: 1200,
+	})
+	event := &sync.OutboxEvent{ID: "event-1", UserID: userID, Type: sync.EventConflictFlagged, Payload: payload}
+
+	// Act
+	if err := service.NotifySyncConflict(ctx, event); err != nil {
+		t.Fatalf("NotifySyncConflict failed: %v", err)
+	}
+	if err := service.NotifySyncConflict(ctx, event); err != nil {
+		t.Fatalf("NotifySyncConflict redelivery failed: %v", err)
+	}
+
+	// Assert
+	resp, err := service.List(ctx, false)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(resp.Notifications) != 1 {
+		t.Fatalf("Expected 1 notification, got %d", len(resp.Notifications))
+	}
+	if resp.Notifications[0].Type != TypeSyncConflict {
+		t.Errorf("Expected type %s, got %s", TypeSyncConflict, resp.Notifications[0].Type)
+	}
+}
+
+func TestNotifyBudgetThresholds_OncePerThresholdPerMonth(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	userID := "test-user-notification-budget"
+	ctx := account.CreateAuthContext(userID)
+	account.CreateTestUser(t, service.db, userID)
+	defer service.db.Exec(`DELETE FROM categories WHERE user_id = $1`, userID)
+	defer service.db.Exec(`DELETE FROM transactions WHERE user_id = $1`, userID)
+
+	transactions := service.transactionSvc
+	transactions.OnPosted(service.NotifyBudgetThresholds)
+	category, err := transactions.CreateCategory(ctx, &transaction.CategoryRequest{Name: "Groceries"})
+	if err != nil {
+		t.Fatalf("CreateCategory failed: %v", err)
+	}
+	if _, err := transactions.CreateCategoryBudget(ctx, &transaction.CategoryBudgetRequest{
+		CategoryID:      category.ID,
+		Amount:          400,
+		Currency:        "CAD",
+		AlertThresholds: []float64{100, 75},
+	}); err != nil {
+		t.Fatalf("CreateCategoryBudget failed: %v", err)
+	}
+	spend := func(amount float64) {
+		if _, err := transactions.CreateTransaction(ctx, &transaction.CreateTransactionRequest{
+			Date:        time.Now(),
+			Description: "Grocery run",
+			Amount:      -amount,
+			Currency:    "CAD",
+			CategoryID:  &category.ID,
+		}); err != nil {
+			t.Fatalf("CreateTransaction failed: %v", err)
+		}
+	}
+
+	// Act
+	spend(250)
+	spend(60)
+	spend(20)
+	spend(100)
+	spend(30)
+
+	// Assert
+	resp, err := service.List(ctx, false)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(resp.Notifications) != 2 {
+		t.Fatalf("Expected one notification at 75%% and one over budget, got %d", len(resp.Notifications))
+	}
+	titles := map[string]bool{}
+	for _, n := range resp.Notifications {
+		if n.Type != TypeBudgetThreshold {
+			t.Errorf("Expected type %s, got %s", TypeBudgetThreshold, n.Type)
+		}
+		titles[n.Title] = true
+	}
+	if !titles["Groceries budget 75% used"] || !titles["Groceries budget exceeded"] {
+		t.Errorf("Unexpected notification titles: %v", titles)
+	}
+}
+
+func TestCheckStaleBalances_OncePerWeek(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	userID := "test-user-notification-stale"
+	ctx := account.CreateAuthContext(userID)
+	account.CreateTestUser(t, service.db, userID)
+	account.CreateTestAccount(t, service.db, userID, account.AccountTypeSavings)
+	current := account.CreateTestAccount(t, service.db, userID, account.AccountTypeChecking)
+	account.CreateTestBalance(t, service.db, current, 500)
+
+	// Act
+	created, err := service.checkStaleBalances(ctx)
+	if err != nil {
+		t.Fatalf("checkStaleBalances failed: %v", err)
+	}
+	again, err := service.checkStaleBalances(ctx)
+	if err != nil {
+		t.Fatalf("checkStaleBalances repeat failed: %v", err)
+	}
+
+	// Assert
+	if created != 1 || again != 0 {
+		t.Fatalf("Expected one reminder a week, got %d then %d", created, again)
+	}
+	resp, err := service.List(ctx, false)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(resp.Notifications) != 1 || resp.Notifications[0].Title != "1 account needs a balance update" {
+		t.Errorf("Unexpected notifications: %+v", resp.Notifications)
+	}
+}
+
+type recordingSender struct {
+	messages []*Message
+}
+
+func (r *recordingSender) Send(ctx context.Context, to string, message *Message) error {
+	r.messages = append(r.messages, message)
+	return nil
+}
+
+func TestDispatch_HoldsDuringQuietHoursThenSendsDigest(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	userID := "test-user-notification-delivery"
+	ctx := account.CreateAuthContext(userID)
+	sender := &recordingSender{}
+	service.SetSender(ChannelWebhook, sender)
+	digest := true
+	hour := 8
+	quietStart, quietEnd := "22:00", "07:00"
+	webhook := "https://example.com/hook"
+	email := "me@example.com"
+	_, err := service.UpdateDeliveryPreferences(ctx, &UpdateDeliveryPreferencesRequest{
+		DigestEnabled: &digest,
+		DigestHour:    &hour,
+		QuietStart:    &quietStart,
+		QuietEnd:      &quietEnd,
+		Email:         &email,
+		WebhookURL:    &webhook,
+		Channels: map[Type][]Channel{
+			TypePriceAlert:  {ChannelInApp, ChannelWebhook},
+			TypeCreditScore: {ChannelEmail},
+		},
+	})
+	if err != nil {
+		t.Fatalf("UpdateDeliveryPreferences failed: %v", err)
+	}
+	for i, notificationType := range []Type{TypePriceAlert, TypePriceAlert, TypeCreditScore} {
+		if _, err := service.Create(ctx, &CreateNotificationRequest{
+			Type:      notificationType,
+			Title:     "Alert",
+			Message:   "Something happened",
+			DedupeKey: fmt.Sprintf("delivery:%d", i),
+		}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	// Act
+	quiet, err := service.Dispatch(context.Background(), time.Date(2026, 3, 2, 23, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	morning, err := service.Dispatch(context.Background(), time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	again, err := service.Dispatch(context.Background(), time.Date(2026, 3, 3, 10, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+
+	// Assert
+	if quiet != 0 {
+		t.Errorf("Expected nothing sent during quiet hours, got %d", quiet)
+	}
+	if morning != 1 || len(sender.messages) != 1 {
+		t.Fatalf("Expected 1 webhook digest, got %d sent and %d messages", morning, len(sender.messages))
+	}
+	if !sender.messages[0].IsDigest || len(sender.messages[0].Notifications) != 2 {
+		t.Errorf("Expected a digest of 2 notifications, got %+v", sender.messages[0])
+	}
+	if again != 0 {
+		t.Errorf("Expected one digest per day, got %d more", again)
+	}
+	deliveries, err := service.ListDeliveries(ctx)
+	if err != nil {
+		t.Fatalf("ListDeliveries failed: %v", err)
+	}
+	statuses := make(map[Channel]string)
+	for _, d := range deliveries.Deliveries {
+		statuses[d.Channel] = d.Status
+	}
+	if statuses[ChannelWebhook] != "sent" || statuses[ChannelEmail] != "failed" {
+		t.Errorf("Expected webhook sent and unconfigured email failed, got %v", statuses)
+	}
+	resp, err := service.List(ctx, false)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(resp.Notifications) != 2 {
+		t.Errorf("Expected the email-only notification hidden in-app, got %d notifications", len(resp.Notifications))
+	}
+}
+
+func TestUpdateDeliveryPreferences_RejectsInternalWebhooks(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	userID := "test-user-notification-webhook"
+	ctx := account.CreateAuthContext(userID)
+	urls := []string{
+		"http://example.com/hook",
+		"https://169.254.169.254/latest/meta-data",
+		"https://localhost/hook",
+		"https://10.0.0.5/hook",
+	}
+
+	for _, webhook := range urls {
+		// Act
+		_, err := service.UpdateDeliveryPreferences(ctx, &UpdateDeliveryPreferencesRequest{WebhookURL: &webhook})
+
+		// Assert
+		if err == nil {
+			t.Errorf("Expected webhook URL %s to be rejected", webhook)
+		}
+	}
+}
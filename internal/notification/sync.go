@@ -0,0 +1,30 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"money/internal/sync"
+)
+
+// NotifySyncConflict raises a notification for a balance conflict a sync flagged for
+// review. It is subscribed to the sync outbox, so it only runs once the sync committed.
+func (s *Service) NotifySyncConflict(ctx context.Context, event *sync.OutboxEvent) error {
+	var conflict sync.ConflictFlaggedPayload
+	if err := json.Unmarshal(event.Payload, &conflict); err != nil {
+		return fmt.Errorf("failed to decode conflict event: %w", err)
+	}
+
+	entityType := "sync_conflict"
+	_, err := s.Create(ctx, &CreateNotificationRequest{
+		Type:  TypeSyncConflict,
+		Title: "A synced balance conflicts with your manual entry",
+		Message: fmt.Sprintf("The provider reported %.2f for %s, but you entered %.2f. Review the conflict to choose which to keep.",
+			conflict.ProviderAmount, conflict.BalanceDate, conflict.ManualAmount),
+		EntityType: &entityType,
+		EntityID:   &conflict.ConflictID,
+		DedupeKey:  fmt.Sprintf("%s:%s:%.2f", TypeSyncConflict, conflict.ConflictID, conflict.ProviderAmount),
+	})
+	return err
+}
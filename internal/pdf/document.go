@@ -0,0 +1,180 @@
+// Package pdf writes simple text-only PDF documents, enough for printable reports
+// without pulling in a rendering library, and reads the text back out of PDFs such as
+// bank statements.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Page geometry in points, US Letter with one-inch margins
+const (
+	pageWidth    = 612
+	pageHeight   = 792
+	margin       = 72
+	bodySize     = 10
+	headingSize  = 14
+	titleSize    = 18
+	lineSpacing  = 1.4
+	avgCharWidth = 0.5 // Approximate Helvetica glyph width as a share of the font size
+)
+
+// line is one line of text placed on a page
+type line struct {
+	text string
+	x    float64
+	y    float64
+	size float64
+	bold bool
+}
+
+// Document is a PDF being built up line by line. Text flows down the page and onto new
+// pages as needed.
+type Document struct {
+	title string
+	pages [][]line
+	y     float64
+}
+
+// New creates an empty document with the given title
+func New(title string) *Document {
+	d := &Document{title: title}
+	d.newPage()
+	d.write(title, margin, titleSize, true)
+	return d
+}
+
+// newPage starts a new page at the top margin
+func (d *Document) newPage() {
+	d.pages = append(d.pages, nil)
+	d.y = pageHeight - margin
+}
+
+// write places text at x on the next line, breaking to a new page when full
+func (d *Document) write(text string, x, size float64, bold bool) {
+	height := size * lineSpacing
+	if d.y-height < margin {
+		d.newPage()
+	}
+	d.y -= height
+	page := len(d.pages) - 1
+	d.pages[page] = append(d.pages[page], line{text: text, x: x, y: d.y, size: size, bold: bold})
+}
+
+// Heading adds a bold section heading with space above it
+func (d *Document) Heading(text string) {
+	d.Space()
+	d.write(text, margin, headingSize, true)
+}
+
+// Text adds a paragraph, wrapped to the page width
+func (d *Document) Text(text string) {
+	for _, wrapped := range wrap(text, pageWidth-2*margin, bodySize) {
+		d.write(wrapped, margin, bodySize, false)
+	}
+}
+
+// Field adds a "label: value" line, indented under the current heading
+func (d *Document) Field(label, value string) {
+	for _, wrapped := range wrap(fmt.Sprintf("%s: %s", label, value), pageWidth-2*margin-12, bodySize) {
+		d.write(wrapped, margin+12, bodySize, false)
+	}
+}
+
+// Space adds a blank line
+func (d *Document) Space() {
+	d.y -= bodySize * lineSpacing
+}
+
+// wrap splits text into lines that fit within width at the given font size
+func wrap(text string, width, size float64) []string {
+	maxChars := int(width / (size * avgCharWidth))
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	current := words[0]
+	for _, word := range words[1:] {
+		if len(current)+1+len(word) > maxChars {
+			lines = append(lines, current)
+			current = word
+			continue
+		}
+		current += " " + word
+	}
+	return append(lines, current)
+}
+
+// escape makes text safe inside a PDF string literal. Characters outside printable ASCII
+// aren't in the standard font encoding and are replaced.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Bytes renders the document as a PDF file
+func (d *Document) Bytes() []byte {
+	// Objects: 1 catalog, 2 page tree, 3 regular font, 4 bold font, then a page and
+	// content stream per page
+	var objects []string
+	objects = append(objects, "<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, lines := range d.pages {
+		var content bytes.Buffer
+		for _, l := range lines {
+			font := "F1"
+			if l.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, l.size, l.x, l.y, escape(l.text))
+		}
+		fmt.Fprintf(&content, "BT /F1 8 Tf %d %d Td (Page %d of %d) Tj ET\n", margin, margin/2, i+1, len(d.pages))
+
+		objects = append(objects, fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i))
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+	objects = append(objects, fmt.Sprintf("<< /Title (%s) /Producer (Money) >>", escape(d.title)))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(objects)+1, len(objects), xref)
+
+	return out.Bytes()
+}
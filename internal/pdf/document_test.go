@@ -0,0 +1,58 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestBytes_WritesValidStructure(t *testing.T) {
+	// Arrange
+	doc := New("Estate summary (draft)")
+	doc.Heading("Accounts")
+	doc.Field("Institution", "Bank of Nowhere")
+
+	// Act
+	out := doc.Bytes()
+
+	// Assert
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatal("Expected PDF header and trailer")
+	}
+	if !bytes.Contains(out, []byte(`(Estate summary \(draft\)) Tj`)) {
+		t.Error("Expected parentheses in text to be escaped")
+	}
+
+	// startxref must point at the xref table
+	var offset int
+	tail := out[bytes.LastIndex(out, []byte("startxref\n")):]
+	if _, err := fmt.Sscanf(string(tail), "startxref\n%d", &offset); err != nil {
+		t.Fatalf("Failed to read startxref: %v", err)
+	}
+	if !bytes.HasPrefix(out[offset:], []byte("xref\n")) {
+		t.Errorf("Expected startxref %d to point at the xref table", offset)
+	}
+}
+
+func TestText_BreaksOntoNewPages(t *testing.T) {
+	// Arrange
+	doc := New("Long report")
+
+	// Act
+	for i := 0; i < 120; i++ {
+		doc.Text(strings.Repeat("word ", 30))
+	}
+
+	// Assert
+	if len(doc.pages) < 2 {
+		t.Fatalf("Expected text to flow onto more pages, got %d", len(doc.pages))
+	}
+	for _, page := range doc.pages {
+		for _, l := range page {
+			if l.y < margin {
+				t.Fatalf("Line placed below the bottom margin at %.2f", l.y)
+			}
+		}
+	}
+}
@@ -0,0 +1,458 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// lineTolerance is how far apart, in points, text can sit vertically and still be read as
+// one line, so columns drawn separately join up into a row
+const lineTolerance = 2.0
+
+// textRun is text shown at one position on a page
+type textRun struct {
+	x, y float64
+	text strings.Builder
+}
+
+// ExtractText reads the text of a PDF as lines, page by page from top to bottom, with the
+// pieces of each line joined left to right. It understands uncompressed and
+// Flate-compressed content streams using simple font encodings, which covers the
+// statements most banks generate; text drawn as images or in embedded CID fonts is lost.
+func ExtractText(data []byte) ([]string, error) {
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return nil, fmt.Errorf("not a PDF file")
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return nil, fmt.Errorf("encrypted PDFs are not supported")
+	}
+
+	var lines []string
+	for _, content := range contentStreams(data) {
+		lines = append(lines, pageLines(content)...)
+	}
+	return lines, nil
+}
+
+// contentStreams returns the decoded streams that draw text, in file order
+func contentStreams(data []byte) [][]byte {
+	var streams [][]byte
+	offset := 0
+	for {
+		start := bytes.Index(data[offset:], []byte("stream"))
+		if start < 0 {
+			return streams
+		}
+		start += offset
+		// Skip "endstream" and the keyword appearing inside other tokens
+		if start >= 3 && string(data[start-3:start]) == "end" {
+			offset = start + len("stream")
+			continue
+		}
+
+		dictStart := bytes.LastIndex(data[:start], []byte("obj"))
+		if dictStart < 0 {
+			dictStart = 0
+		}
+		dict := data[dictStart:start]
+
+		body := start + len("stream")
+		if body < len(data) && data[body] == '\r' {
+			body++
+		}
+		if body < len(data) && data[body] == '\n' {
+			body++
+		}
+		end := bytes.Index(data[body:], []byte("endstream"))
+		if end < 0 {
+			return streams
+		}
+		raw := bytes.TrimRight(data[body:body+end], "\r\n")
+		offset = body + end + len("endstream")
+
+		if bytes.Contains(dict, []byte("/Image")) || bytes.Contains(dict, []byte("/Length1")) ||
+			bytes.Contains(dict, []byte("/Length2")) || bytes.Contains(dict, []byte("/FontFile")) {
+			continue
+		}
+		decoded := raw
+		if bytes.Contains(dict, []byte("/Filter")) {
+			if !bytes.Contains(dict, []byte("/FlateDecode")) {
+				continue
+			}
+			inflated, err := inflate(raw)
+			if err != nil {
+				continue
+			}
+			decoded = inflated
+		}
+		if bytes.Contains(decoded, []byte("BT")) && bytes.Contains(decoded, []byte("ET")) {
+			streams = append(streams, decoded)
+		}
+	}
+}
+
+// inflate decompresses a Flate-encoded stream
+func inflate(raw []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// pageLines interprets a content stream's text operators and returns its text as lines
+func pageLines(content []byte) []string {
+	var runs []*textRun
+	var current *textRun
+	var operands []string
+	// Line start and leading as set by the text positioning operators
+	var lineX, lineY, leading float64
+
+	newRun := func() {
+		current = nil
+	}
+	show := func(text string) {
+		if current == nil {
+			current = &textRun{x: lineX, y: lineY}
+			runs = append(runs, current)
+		}
+		current.text.WriteString(text)
+	}
+	number := func(i int) float64 {
+		if i < 0 || i >= len(operands) {
+			return 0
+		}
+		v, _ := strconv.ParseFloat(operands[i], 64)
+		return v
+	}
+	last := func(n int) int { return len(operands) - n }
+
+	s := &scanner{data: content}
+	for {
+		token, kind := s.next()
+		if kind == tokenEOF {
+			break
+		}
+		if kind != tokenOperator {
+			operands = append(operands, token)
+			continue
+		}
+
+		switch token {
+		case "BT":
+			lineX, lineY = 0, 0
+			newRun()
+		case "Tm":
+			lineX, lineY = number(last(2)), number(last(1))
+			newRun()
+		case "Td":
+			lineX += number(last(2))
+			lineY += number(last(1))
+			newRun()
+		case "TD":
+			leading = -number(last(1))
+			lineX += number(last(2))
+			lineY += number(last(1))
+			newRun()
+		case "TL":
+			leading = number(last(1))
+		case "T*":
+			lineY -= leading
+			newRun()
+		case "Tj":
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "'", "\"":
+			lineY -= leading
+			newRun()
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "TJ":
+			show(s.array)
+		case "BI":
+			s.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+
+	return joinRuns(runs)
+}
+
+// joinRuns groups runs sharing a baseline into lines, top to bottom and left to right
+func joinRuns(runs []*textRun) []string {
+	sort.SliceStable(runs, func(i, j int) bool {
+		if math.Abs(runs[i].y-runs[j].y) > lineTolerance {
+			return runs[i].y > runs[j].y
+		}
+		return runs[i].x < runs[j].x
+	})
+
+	var lines []string
+	var parts []string
+	lineY := math.Inf(1)
+	flush := func() {
+		if line := strings.Join(strings.Fields(strings.Join(parts, " ")), " "); line != "" {
+			lines = append(lines, line)
+		}
+		parts = parts[:0]
+	}
+	for _, run := range runs {
+		if math.Abs(run.y-lineY) > lineTolerance {
+			flush()
+			lineY = run.y
+		}
+		parts = append(parts, run.text.String())
+	}
+	flush()
+	return lines
+}
+
+// Token kinds read from a content stream
+const (
+	tokenEOF = iota
+	tokenOperand
+	tokenOperator
+)
+
+// scanner tokenizes a content stream. Strings are returned decoded; the text of the most
+// recent array, with wide gaps read as spaces, is kept for the TJ operator.
+type scanner struct {
+	data  []byte
+	pos   int
+	array string
+}
+
+func (s *scanner) next() (string, int) {
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		switch {
+		case isSpace(c):
+			s.pos++
+		case c == '%':
+			for s.pos < len(s.data) && s.data[s.pos] != '\n' && s.data[s.pos] != '\r' {
+				s.pos++
+			}
+		case c == '(':
+			return s.literal(), tokenOperand
+		case c == '<' && s.pos+1 < len(s.data) && s.data[s.pos+1] == '<':
+			s.skipDict()
+			return "", tokenOperand
+		case c == '<':
+			return s.hex(), tokenOperand
+		case c == '[':
+			s.pos++
+			s.array = s.readArray()
+			return "", tokenOperand
+		case c == '/':
+			start := s.pos
+			s.pos++
+			for s.pos < len(s.data) && !isSpace(s.data[s.pos]) && !isDelimiter(s.data[s.pos]) {
+				s.pos++
+			}
+			return string(s.data[start:s.pos]), tokenOperand
+		default:
+			start := s.pos
+			for s.pos < len(s.data) && !isSpace(s.data[s.pos]) && !isDelimiter(s.data[s.pos]) {
+				s.pos++
+			}
+			if s.pos == start {
+				// A stray delimiter such as ']' or '>'
+				s.pos++
+				continue
+			}
+			token := string(s.data[start:s.pos])
+			if _, err := strconv.ParseFloat(token, 64); err == nil {
+				return token, tokenOperand
+			}
+			if token == "true" || token == "false" || token == "null" {
+				return token, tokenOperand
+			}
+			return token, tokenOperator
+		}
+	}
+	return "", tokenEOF
+}
+
+// readArray reads up to the closing bracket, keeping the text of its strings. Offsets of
+// more than a fifth of an em between strings are gaps between words.
+func (s *scanner) readArray() string {
+	var b strings.Builder
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		switch {
+		case c == ']':
+			s.pos++
+			return b.String()
+		case c == '(':
+			b.WriteString(s.literal())
+		case c == '<':
+			b.WriteString(s.hex())
+		case isSpace(c):
+			s.pos++
+		default:
+			start := s.pos
+			for s.pos < len(s.data) && !isSpace(s.data[s.pos]) && !isDelimiter(s.data[s.pos]) {
+				s.pos++
+			}
+			if s.pos == start {
+				s.pos++
+				continue
+			}
+			if offset, err := strconv.ParseFloat(string(s.data[start:s.pos]), 64); err == nil && offset < -200 {
+				b.WriteByte(' ')
+			}
+		}
+	}
+	return b.String()
+}
+
+// literal reads a (string), handling nested parentheses and escapes
+func (s *scanner) literal() string {
+	s.pos++ // (
+	var b strings.Builder
+	depth := 1
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		s.pos++
+		switch c {
+		case '(':
+			depth++
+			b.WriteByte(c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return latin1(b.String())
+			}
+			b.WriteByte(c)
+		case '\\':
+			if s.pos >= len(s.data) {
+				break
+			}
+			e := s.data[s.pos]
+			s.pos++
+			switch e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'b', 'f':
+			case '\r':
+				if s.pos < len(s.data) && s.data[s.pos] == '\n' {
+					s.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					value := int(e - '0')
+					for i := 0; i < 2 && s.pos < len(s.data) && s.data[s.pos] >= '0' && s.data[s.pos] <= '7'; i++ {
+						value = value*8 + int(s.data[s.pos]-'0')
+						s.pos++
+					}
+					b.WriteByte(byte(value))
+				} else {
+					b.WriteByte(e)
+				}
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return latin1(b.String())
+}
+
+// hex reads a <hex string>
+func (s *scanner) hex() string {
+	s.pos++ // <
+	var digits []byte
+	for s.pos < len(s.data) && s.data[s.pos] != '>' {
+		if !isSpace(s.data[s.pos]) {
+			digits = append(digits, s.data[s.pos])
+		}
+		s.pos++
+	}
+	s.pos++ // >
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	decoded := make([]byte, 0, len(digits)/2)
+	for i := 0; i < len(digits); i += 2 {
+		v, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			return ""
+		}
+		decoded = append(decoded, byte(v))
+	}
+	return latin1(string(decoded))
+}
+
+// skipDict skips a << dictionary >>, which only appears in content streams as an operand
+// to marked content operators
+func (s *scanner) skipDict() {
+	depth := 0
+	for s.pos+1 < len(s.data) {
+		switch {
+		case s.data[s.pos] == '<' && s.data[s.pos+1] == '<':
+			depth++
+			s.pos += 2
+		case s.data[s.pos] == '>' && s.data[s.pos+1] == '>':
+			depth--
+			s.pos += 2
+			if depth == 0 {
+				return
+			}
+		case s.data[s.pos] == '(':
+			s.literal()
+		default:
+			s.pos++
+		}
+	}
+	s.pos = len(s.data)
+}
+
+// skipInlineImage skips an inline image's binary data, which runs from ID to EI
+func (s *scanner) skipInlineImage() {
+	start := bytes.Index(s.data[s.pos:], []byte("ID"))
+	if start < 0 {
+		s.pos = len(s.data)
+		return
+	}
+	end := bytes.Index(s.data[s.pos+start:], []byte("EI"))
+	if end < 0 {
+		s.pos = len(s.data)
+		return
+	}
+	s.pos += start + end + len("EI")
+}
+
+// latin1 reads string bytes as Latin-1, close enough to the standard encodings for the
+// characters statements use
+func latin1(raw string) string {
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		b.WriteRune(rune(raw[i]))
+	}
+	return b.String()
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == 0
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExtractText_ReadsGeneratedDocument(t *testing.T) {
+	// Arrange
+	doc := New("Statement (January)")
+	doc.Heading("Transactions")
+	doc.Field("Opening balance", "1,000.00")
+
+	// Act
+	lines, err := ExtractText(doc.Bytes())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ExtractText failed: %v", err)
+	}
+	want := []string{"Statement (January)", "Transactions", "Opening balance: 1,000.00", "Page 1 of 1"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected %q, got %q", want, lines)
+	}
+}
+
+func TestExtractText_JoinsColumnsFromCompressedStream(t *testing.T) {
+	// Arrange: columns drawn right to left on one baseline, and a TJ array with a word gap
+	content := "BT /F1 9 Tf 1 0 0 1 400 700 Tm (3,200.00) Tj ET\n" +
+		"BT /F1 9 Tf 1 0 0 1 72 700.5 Tm (Jan 15) Tj 60 0 Td [(PAYROLL)-300(DEP\\117SIT)] TJ ET\n" +
+		"BT /F1 9 Tf 72 680 Td 12 TL (Closing balance) Tj T* <3132332E3435> Tj ET\n"
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	w.Write([]byte(content))
+	w.Close()
+
+	var file bytes.Buffer
+	file.WriteString("%PDF-1.4\n")
+	fmt.Fprintf(&file, "4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", compressed.Len())
+	file.Write(compressed.Bytes())
+	file.WriteString("\nendstream\nendobj\n%%EOF\n")
+
+	// Act
+	lines, err := ExtractText(file.Bytes())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ExtractText failed: %v", err)
+	}
+	want := []string{"Jan 15 PAYROLL DEPOSIT 3,200.00", "Closing balance", "123.45"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected %q, got %q", want, lines)
+	}
+}
+
+func TestExtractText_RejectsEncrypted(t *testing.T) {
+	// Act
+	_, err := ExtractText([]byte("%PDF-1.4\ntrailer << /Encrypt 5 0 R >>\n%%EOF\n"))
+
+	// Assert
+	if err == nil {
+		t.Error("Expected encrypted PDFs to be rejected")
+	}
+}
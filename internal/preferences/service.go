@@ -0,0 +1,246 @@
+// Package preferences stores per-user settings such as the timezone dates are shown in and
+// the jurisdiction that picks tax, account and calendar defaults.
+package preferences
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/civil"
+	"money/internal/jurisdiction"
+	"money/internal/logger"
+)
+
+// Preferences represents the user's settings
+type Preferences struct {
+	// Timezone is an IANA name such as America/Toronto. Empty means the instance default.
+	Timezone          string `json:"timezone"`
+	EffectiveTimezone string `json:"effective_timezone"`
+	// PrivacyMode masks the balances of private accounts until revealed after logging in again
+	PrivacyMode bool `json:"privacy_mode"`
+	// Jurisdiction is the country the user files taxes in: CA, US or UK
+	Jurisdiction jurisdiction.Code `json:"jurisdiction"`
+}
+
+// UpdatePreferencesRequest represents the request to update preferences
+type UpdatePreferencesRequest struct {
+	Timezone     *string `json:"timezone,omitempty"` // Empty string clears it
+	PrivacyMode  *bool   `json:"privacy_mode,omitempty"`
+	Jurisdiction *string `json:"jurisdiction,omitempty"`
+}
+
+// JurisdictionDetails is a jurisdiction profile with its bank holidays for a year
+type JurisdictionDetails struct {
+	*jurisdiction.Profile
+	Holidays []jurisdiction.Holiday `json:"holidays"`
+}
+
+// userSettings are a user's stored preferences
+type userSettings struct {
+	timezone     string
+	privacyMode  bool
+	jurisdiction *jurisdiction.Profile
+}
+
+// RevealParam is the query parameter that reveals private balances in privacy mode
+const RevealParam = "reveal"
+
+// Service provides user preference functionality
+type Service struct {
+	db *sql.DB
+}
+
+// NewService creates a new preferences service
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// Get returns the authenticated user's preferences
+func (s *Service) Get(ctx context.Context) (*Preferences, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	settings, err := s.settings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPreferences(settings), nil
+}
+
+// GetJurisdiction returns the authenticated user's jurisdiction profile with its bank
+// holidays for a year
+func (s *Service) GetJurisdiction(ctx context.Context, year int) (*JurisdictionDetails, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	settings, err := s.settings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &JurisdictionDetails{Profile: settings.jurisdiction, Holidays: settings.jurisdiction.Holidays(year)}, nil
+}
+
+// Update changes the authenticated user's preferences
+func (s *Service) Update(ctx context.Context, req *UpdatePreferencesRequest) (*Preferences, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	settings, err := s.settings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.PrivacyMode != nil {
+		// Leaving privacy mode reveals everything, so it needs a fresh login like a reveal does
+		if settings.privacyMode && !*req.PrivacyMode && !auth.RecentlyAuthenticated(ctx) {
+			return nil, fmt.Errorf("log in again to turn off privacy mode")
+		}
+		settings.privacyMode = *req.PrivacyMode
+	}
+	if req.Timezone != nil {
+		settings.timezone = *req.Timezone
+		if settings.timezone != "" {
+			if _, err := time.LoadLocation(settings.timezone); err != nil {
+				return nil, fmt.Errorf("invalid timezone: %s", settings.timezone)
+			}
+		}
+	}
+	if req.Jurisdiction != nil {
+		profile, err := jurisdiction.Get(*req.Jurisdiction)
+		if err != nil {
+			return nil, err
+		}
+		settings.jurisdiction = profile
+	}
+
+	var stored sql.NullString
+	if settings.timezone != "" {
+		stored = sql.NullString{String: settings.timezone, Valid: true}
+	}
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE users SET timezone = $1, privacy_mode = $2, jurisdiction = $3, updated_at = $4 WHERE id = $5
+	`, stored, settings.privacyMode, settings.jurisdiction.Code, time.Now(), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update preferences: %w", err)
+	}
+
+	return newPreferences(settings), nil
+}
+
+// LocationMiddleware attaches the user's timezone and jurisdiction to the request context so
+// services place dates on the user's calendar and apply the user's tax and account defaults.
+// It must run after authentication.
+func (s *Service) LocationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := auth.GetUserID(r.Context())
+		if userID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		settings, err := s.settings(r.Context(), userID)
+		if err != nil {
+			logger.Warn("Failed to load user timezone", "error", err)
+		}
+		ctx := jurisdiction.WithProfile(r.Context(), settings.jurisdiction)
+		if loc := loadLocation(settings.timezone); loc != nil {
+			ctx = civil.WithLocation(ctx, loc)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// PrivacyMiddleware masks private account balances for users in privacy mode. A request
+// with ?reveal=true sees them only when the user logged in within the reauthentication
+// window; otherwise it is refused so the client can prompt for a login. Delegates are
+// always refused, since their own login says nothing about the owner. It must run after
+// authentication and delegation.
+func (s *Service) PrivacyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := auth.GetUserID(r.Context())
+		if userID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		settings, err := s.settings(r.Context(), userID)
+		if err != nil {
+			// Fail closed: mask rather than reveal when the setting can't be read
+			logger.Warn("Failed to load privacy mode", "error", err)
+			settings.privacyMode = true
+		}
+		if !settings.privacyMode {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.URL.Query().Get(RevealParam) == "true" {
+			if auth.GetDelegate(r.Context()) != "" {
+				http.Error(w, `{"error":"forbidden","message":"delegated access cannot reveal private balances"}`, http.StatusForbidden)
+				return
+			}
+			if auth.RecentlyAuthenticated(r.Context()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			http.Error(w, `{"error":"reauthentication_required","message":"log in again to reveal private balances"}`, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithMaskPrivate(r.Context())))
+	})
+}
+
+// settings loads a user's stored timezone name, privacy mode and jurisdiction. The
+// jurisdiction is always set, falling back to the default.
+func (s *Service) settings(ctx context.Context, userID string) (userSettings, error) {
+	var timezone sql.NullString
+	var privacyMode bool
+	var code string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT timezone, privacy_mode, jurisdiction FROM users WHERE id = $1
+	`, userID).Scan(&timezone, &privacyMode, &code)
+	if err == sql.ErrNoRows {
+		return userSettings{jurisdiction: jurisdiction.Lookup("")}, nil
+	}
+	if err != nil {
+		return userSettings{jurisdiction: jurisdiction.Lookup("")}, fmt.Errorf("failed to get preferences: %w", err)
+	}
+	return userSettings{timezone: timezone.String, privacyMode: privacyMode, jurisdiction: jurisdiction.Lookup(code)}, nil
+}
+
+// newPreferences builds the preferences response for the stored settings
+func newPreferences(settings userSettings) *Preferences {
+	effective := civil.DefaultLocation().String()
+	if loc := loadLocation(settings.timezone); loc != nil {
+		effective = loc.String()
+	}
+	return &Preferences{
+		Timezone:          settings.timezone,
+		EffectiveTimezone: effective,
+		PrivacyMode:       settings.privacyMode,
+		Jurisdiction:      settings.jurisdiction.Code,
+	}
+}
+
+// loadLocation resolves a timezone name, returning nil for empty or unknown names
+func loadLocation(timezone string) *time.Location {
+	if timezone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
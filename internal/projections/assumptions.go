@@ -0,0 +1,310 @@
+package projections
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"money/internal/auth"
+	"money/internal/civil"
+	"money/internal/database"
+	"money/internal/inflation"
+)
+
+// AssumptionPreset names a starting point for suggested projection assumptions
+type AssumptionPreset string
+
+const (
+	PresetConservative AssumptionPreset = "conservative"
+	PresetBalanced     AssumptionPreset = "balanced"
+	PresetAggressive   AssumptionPreset = "aggressive"
+)
+
+// maxHistoryWeight caps how far an account's own history can pull a suggestion off the preset
+const maxHistoryWeight = 0.5
+
+// historyYearsForFullWeight is how many years of balances earn the full history weight
+const historyYearsForFullWeight = 5.0
+
+// salaryHistoryYears is how many past tax years of employment income are considered
+const salaryHistoryYears = 5
+
+// presetAssumptions holds a preset's returns by account type, salary growth and inflation
+type presetAssumptions struct {
+	returns      map[string]float64
+	appreciation map[string]float64
+	salaryGrowth float64
+	inflation    float64
+}
+
+// assumptionPresets are deliberately plain long-run figures, nominal and before fees
+var assumptionPresets = map[AssumptionPreset]presetAssumptions{
+	PresetConservative: {
+		returns:      map[string]float64{"tfsa": 0.04, "rrsp": 0.04, "brokerage": 0.04, "crypto": 0.02, "savings": 0.015},
+		appreciation: map[string]float64{"real_estate": 0.02, "vehicle": -0.20, "collectible": 0.0},
+		salaryGrowth: 0.02,
+		inflation:    0.03,
+	},
+	PresetBalanced: {
+		returns:      map[string]float64{"tfsa": 0.06, "rrsp": 0.06, "brokerage": 0.06, "crypto": 0.05, "savings": 0.025},
+		appreciation: map[string]float64{"real_estate": 0.03, "vehicle": -0.15, "collectible": 0.01},
+		salaryGrowth: 0.03,
+		inflation:    0.025,
+	},
+	PresetAggressive: {
+		returns:      map[string]float64{"tfsa": 0.08, "rrsp": 0.08, "brokerage": 0.08, "crypto": 0.10, "savings": 0.03},
+		appreciation: map[string]float64{"real_estate": 0.04, "vehicle": -0.10, "collectible": 0.02},
+		salaryGrowth: 0.04,
+		inflation:    0.02,
+	},
+}
+
+// AssumptionsRequest asks for suggested assumptions
+type AssumptionsRequest struct {
+	Preset    AssumptionPreset `json:"preset"`               // Defaults to balanced
+	CPIRegion string           `json:"cpi_region,omitempty"` // CA or US: suggest that region's trailing CPI as inflation
+}
+
+// HistoricalGrowth is the annualized balance growth of the accounts of one type
+type HistoricalGrowth struct {
+	AccountType string  `json:"account_type"`
+	Accounts    int     `json:"accounts"`
+	Years       float64 `json:"years"`  // Longest balance history among the accounts
+	Rate        float64 `json:"rate"`   // Annualized, contributions included
+	Weight      float64 `json:"weight"` // Share of the suggestion taken from history
+}
+
+// AssumptionSuggestion is a set of projection assumptions to start a scenario from. Rates use
+// the same fields as Config so they can be copied over as they are.
+type AssumptionSuggestion struct {
+	Preset              AssumptionPreset   `json:"preset"`
+	InvestmentReturns   map[string]float64 `json:"investment_returns"`
+	AssetAppreciation   map[string]float64 `json:"asset_appreciation"`
+	AnnualSalaryGrowth  float64            `json:"annual_salary_growth"`
+	InflationRate       float64            `json:"inflation_rate"`
+	AnnualExpenseGrowth float64            `json:"annual_expense_growth"`
+	History             []HistoricalGrowth `json:"history"`
+	SalaryGrowthHistory *float64           `json:"salary_growth_history,omitempty"` // Annualized employment income growth
+	InflationSource     string             `json:"inflation_source"`                // preset or the CPI source used
+	Notes               []string           `json:"notes"`
+}
+
+// balancePoint is one dated balance of an account
+type balancePoint struct {
+	date   civil.Date
+	amount float64
+}
+
+// SuggestAssumptions suggests projection assumptions from a preset, nudged toward the user's
+// own history. Account balance growth includes contributions, so history only moves a return
+// part of the way and never outside the range spanned by the conservative and aggressive
+// presets. Salary growth does the same with the employment income recorded in past tax years.
+func (s *Service) SuggestAssumptions(ctx context.Context, req *AssumptionsRequest) (*AssumptionSuggestion, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	preset := AssumptionPreset(strings.ToLower(string(req.Preset)))
+	if preset == "" {
+		preset = PresetBalanced
+	}
+	base, ok := assumptionPresets[preset]
+	if !ok {
+		return nil, fmt.Errorf("preset must be conservative, balanced or aggressive")
+	}
+
+	history, err := s.balanceHistory(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestion := buildAssumptions(preset, base, history)
+
+	if salaryGrowth, ok := s.salaryGrowthHistory(ctx); ok {
+		suggestion.SalaryGrowthHistory = &salaryGrowth
+		suggestion.AnnualSalaryGrowth = roundRate(blendWithinPresets(base.salaryGrowth, salaryGrowth, maxHistoryWeight,
+			assumptionPresets[PresetConservative].salaryGrowth, assumptionPresets[PresetAggressive].salaryGrowth))
+		suggestion.Notes = append(suggestion.Notes, "Salary growth is blended with your recorded employment income")
+	}
+
+	if req.CPIRegion != "" {
+		region, err := s.inflationSvc.ParseRegion(req.CPIRegion)
+		if err != nil {
+			return nil, err
+		}
+		rate, err := s.inflationSvc.TrailingInflation(ctx, region)
+		if err != nil && err != inflation.ErrNoData {
+			return nil, err
+		}
+		if rate != nil {
+			suggestion.InflationRate = rate.Rate
+			suggestion.AnnualExpenseGrowth = rate.Rate
+			suggestion.InflationSource = rate.Source
+		} else {
+			suggestion.Notes = append(suggestion.Notes, "No CPI readings stored yet, using the preset inflation")
+		}
+	}
+
+	return suggestion, nil
+}
+
+// buildAssumptions blends a preset with historical balance growth by account type
+func buildAssumptions(preset AssumptionPreset, base presetAssumptions, history map[string][][]balancePoint) *AssumptionSuggestion {
+	suggestion := &AssumptionSuggestion{
+		Preset:              preset,
+		InvestmentReturns:   make(map[string]float64, len(base.returns)),
+		AssetAppreciation:   make(map[string]float64, len(base.appreciation)),
+		AnnualSalaryGrowth:  base.salaryGrowth,
+		InflationRate:       base.inflation,
+		AnnualExpenseGrowth: base.inflation,
+		History:             make([]HistoricalGrowth, 0),
+		InflationSource:     "preset",
+		Notes:               make([]string, 0),
+	}
+	for accountType, rate := range base.returns {
+		suggestion.InvestmentReturns[accountType] = rate
+	}
+	for accountType, rate := range base.appreciation {
+		suggestion.AssetAppreciation[accountType] = rate
+	}
+
+	low, high := assumptionPresets[PresetConservative], assumptionPresets[PresetAggressive]
+	for accountType, accounts := range history {
+		growth := typeGrowth(accountType, accounts)
+		if growth == nil {
+			continue
+		}
+
+		if rate, ok := base.returns[accountType]; ok {
+			suggestion.InvestmentReturns[accountType] = roundRate(blendWithinPresets(rate, growth.Rate, growth.Weight,
+				low.returns[accountType], high.returns[accountType]))
+		} else if rate, ok := base.appreciation[accountType]; ok {
+			suggestion.AssetAppreciation[accountType] = roundRate(blendWithinPresets(rate, growth.Rate, growth.Weight,
+				low.appreciation[accountType], high.appreciation[accountType]))
+		} else {
+			continue
+		}
+		suggestion.History = append(suggestion.History, *growth)
+	}
+	sort.Slice(suggestion.History, func(i, j int) bool {
+		return suggestion.History[i].AccountType < suggestion.History[j].AccountType
+	})
+
+	if len(suggestion.History) == 0 {
+		suggestion.Notes = append(suggestion.Notes, "Not enough balance history yet, returns are the preset's")
+	}
+	return suggestion
+}
+
+// typeGrowth averages the annualized growth of the accounts of one type with at least a
+// year of positive balances. Returns nil when none qualify.
+func typeGrowth(accountType string, accounts [][]balancePoint) *HistoricalGrowth {
+	var total, longest float64
+	count := 0
+	for _, points := range accounts {
+		if len(points) < 2 {
+			continue
+		}
+		first, last := points[0], points[len(points)-1]
+		years := last.date.Sub(first.date.Time).Hours() / 24 / 365.25
+		if years < 1 || first.amount <= 0 || last.amount <= 0 {
+			continue
+		}
+		total += math.Pow(last.amount/first.amount, 1/years) - 1
+		count++
+		if years > longest {
+			longest = years
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+
+	years := math.Round(longest*10) / 10
+	return &HistoricalGrowth{
+		AccountType: accountType,
+		Accounts:    count,
+		Years:       years,
+		Rate:        roundRate(total / float64(count)),
+		Weight:      roundRate(maxHistoryWeight * math.Min(years/historyYearsForFullWeight, 1)),
+	}
+}
+
+// blendWithinPresets moves a preset rate toward history by weight, kept between low and high
+func blendWithinPresets(rate, historical, weight, low, high float64) float64 {
+	if low > high {
+		low, high = high, low
+	}
+	blended := rate*(1-weight) + historical*weight
+	return math.Max(low, math.Min(high, blended))
+}
+
+// roundRate rounds a rate to a hundredth of a percent
+func roundRate(rate float64) float64 {
+	return math.Round(rate*10000) / 10000
+}
+
+// balanceHistory returns the dated balances of the user's active asset accounts, grouped by
+// account type and then by account, oldest first
+func (s *Service) balanceHistory(ctx context.Context, userID string) (map[string][][]balancePoint, error) {
+	ctx, cancel := database.WithStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.accountDB.QueryContext(ctx, `
+		SELECT a.id, a.type, b.date, b.amount
+		FROM balances b
+		JOIN accounts a ON a.id = b.account_id
+		WHERE a.user_id = $1 AND a.is_active = 1 AND a.is_asset = 1 AND a.exclude_from_projections = 0
+		ORDER BY a.id, b.date
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance history: %w", err)
+	}
+	defer rows.Close()
+
+	byAccount := make(map[string][]balancePoint)
+	accountTypes := make(map[string]string)
+	for rows.Next() {
+		var accountID, accountType string
+		var point balancePoint
+		if err := rows.Scan(&accountID, &accountType, &point.date, &point.amount); err != nil {
+			return nil, fmt.Errorf("failed to scan balance: %w", err)
+		}
+		byAccount[accountID] = append(byAccount[accountID], point)
+		accountTypes[accountID] = accountType
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	history := make(map[string][][]balancePoint)
+	for accountID, points := range byAccount {
+		history[accountTypes[accountID]] = append(history[accountTypes[accountID]], points)
+	}
+	return history, nil
+}
+
+// salaryGrowthHistory annualizes employment income growth between the earliest and latest of
+// the past tax years with any recorded
+func (s *Service) salaryGrowthHistory(ctx context.Context) (float64, bool) {
+	lastYear := civil.TodayIn(ctx).Year() - 1
+	firstYear, latestYear := 0, 0
+	var first, latest float64
+	for year := lastYear - salaryHistoryYears + 1; year <= lastYear; year++ {
+		summary, err := s.incomeSvc.GetAnnualSummary(ctx, year)
+		if err != nil || summary.EmploymentIncome <= 0 {
+			continue
+		}
+		if firstYear == 0 {
+			firstYear, first = year, summary.EmploymentIncome
+		}
+		latestYear, latest = year, summary.EmploymentIncome
+	}
+	if firstYear == 0 || latestYear == firstYear {
+		return 0, false
+	}
+	return roundRate(math.Pow(latest/first, 1/float64(latestYear-firstYear)) - 1), true
+}
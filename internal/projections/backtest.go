@@ -0,0 +1,126 @@
+package projections
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"money/internal/holdings"
+)
+
+// BacktestConfig replays historical market returns in place of the expected investment
+// returns, starting from StartYear, to show how the plan would have fared through that
+// stretch of history
+type BacktestConfig struct {
+	StartYear       int      `json:"start_year"`                 // First historical year replayed, 1928-2023
+	StockAllocation *float64 `json:"stock_allocation,omitempty"` // Share in stocks; defaults to the equity share of current holdings
+	BondReturn      *float64 `json:"bond_return,omitempty"`      // Fixed annual return on the non-stock share, default 4%
+}
+
+// BacktestSummary describes the history a projection replayed. Years past the end of the
+// historical series fall back to the expected returns.
+type BacktestSummary struct {
+	StartYear       int             `json:"start_year"`
+	EndYear         int             `json:"end_year"` // Last historical year replayed
+	StockAllocation float64         `json:"stock_allocation"`
+	BondReturn      float64         `json:"bond_return"`
+	Years           []*BacktestYear `json:"years"`
+}
+
+// BacktestYear is the return replayed for one year of the projection
+type BacktestYear struct {
+	ProjectionYear  int     `json:"projection_year"` // 1-based year of the projection
+	Year            int     `json:"year"`            // Historical year its returns come from
+	StockReturn     float64 `json:"stock_return"`
+	PortfolioReturn float64 `json:"portfolio_return"` // Blended with the bond share, applied to investment accounts
+}
+
+// backtestPlan holds the blended annual returns a backtest replays, one per projection year
+type backtestPlan struct {
+	summary *BacktestSummary
+	returns []float64
+}
+
+// returnFor returns the historical annual return for a projection month, if history
+// reaches that far. A nil plan has none.
+func (p *backtestPlan) returnFor(month int) (float64, bool) {
+	if p == nil {
+		return 0, false
+	}
+	year := month / 12
+	if year >= len(p.returns) {
+		return 0, false
+	}
+	return p.returns[year], true
+}
+
+// prepareBacktest validates a backtest and lines up the historical returns for each year of
+// the projection horizon
+func (s *Service) prepareBacktest(ctx context.Context, cfg *BacktestConfig, horizonYears int) (*backtestPlan, error) {
+	lastYear := historicalFirstYear + len(historicalStockReturns) - 1
+	if cfg.StartYear < historicalFirstYear || cfg.StartYear > lastYear {
+		return nil, fmt.Errorf("backtest start_year must be between %d and %d", historicalFirstYear, lastYear)
+	}
+
+	stockAllocation := 1.0
+	if cfg.StockAllocation != nil {
+		stockAllocation = *cfg.StockAllocation
+	} else {
+		current, err := s.currentStockAllocation(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if current != nil {
+			stockAllocation = *current
+		}
+	}
+	if stockAllocation < 0 || stockAllocation > 1 {
+		return nil, fmt.Errorf("stock_allocation must be between 0 and 1")
+	}
+	bondReturn := DefaultBondReturn
+	if cfg.BondReturn != nil {
+		bondReturn = *cfg.BondReturn
+	}
+
+	years := min(horizonYears, lastYear-cfg.StartYear+1)
+	plan := &backtestPlan{
+		summary: &BacktestSummary{
+			StartYear:       cfg.StartYear,
+			EndYear:         cfg.StartYear + years - 1,
+			StockAllocation: stockAllocation,
+			BondReturn:      bondReturn,
+			Years:           make([]*BacktestYear, 0, years),
+		},
+		returns: make([]float64, 0, years),
+	}
+	for i := 0; i < years; i++ {
+		stockReturn := historicalStockReturns[cfg.StartYear-historicalFirstYear+i]
+		blended := stockAllocation*stockReturn + (1-stockAllocation)*bondReturn
+		plan.returns = append(plan.returns, blended)
+		plan.summary.Years = append(plan.summary.Years, &BacktestYear{
+			ProjectionYear:  i + 1,
+			Year:            cfg.StartYear + i,
+			StockReturn:     stockReturn,
+			PortfolioReturn: math.Round(blended*10000) / 10000,
+		})
+	}
+	return plan, nil
+}
+
+// currentStockAllocation is the share of the user's holdings in equities, or nil when they
+// have no holdings to go by
+func (s *Service) currentStockAllocation(ctx context.Context) (*float64, error) {
+	values, err := s.holdingsSvc.GetAssetClassValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	if total <= 0 {
+		return nil, nil
+	}
+	share := values[holdings.AssetClassEquity] / total
+	return &share, nil
+}
@@ -0,0 +1,46 @@
+package projections
+
+import (
+	"context"
+	"time"
+
+	"money/internal/income"
+)
+
+// getBonusPlans fetches the user's active bonus and DPSP plans
+func (s *Service) getBonusPlans(ctx context.Context) ([]*income.BonusPlan, error) {
+	resp, err := s.incomeSvc.ListBonusPlans(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := make([]*income.BonusPlan, 0, len(resp.Plans))
+	for _, plan := range resp.Plans {
+		if plan.IsActive {
+			plans = append(plans, plan)
+		}
+	}
+	return plans, nil
+}
+
+// findBonusPlansForMonth returns the plans paying out in the month of date, skipping any
+// whose payout for that month has already been recorded
+func findBonusPlansForMonth(plans []*income.BonusPlan, date time.Time) []*income.BonusPlan {
+	var due []*income.BonusPlan
+	for _, plan := range plans {
+		if time.Month(plan.PayoutMonth) != date.Month() {
+			continue
+		}
+		paid := false
+		for _, payout := range plan.Payouts {
+			if isSameMonth(payout.PayoutDate, date) {
+				paid = true
+				break
+			}
+		}
+		if !paid {
+			due = append(due, plan)
+		}
+	}
+	return due
+}
@@ -1,10 +1,17 @@
 package projections
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
 	"testing"
 	"time"
 
 	"money/internal/account"
+	"money/internal/civil"
+	"money/internal/income"
+	"money/internal/transaction"
 )
 
 func TestCalculateProjection_BasicScenario(t *testing.T) {
@@ -79,6 +86,31 @@ func TestCalculateProjection_Unauthenticated(t *testing.T) {
 	}
 }
 
+func TestCalculateProjection_StopsWhenCancelled(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-projection-cancel"
+	service := SetupProjectionService(t, db)
+	CreateTestAccountForProjection(t, db, userID, account.AccountTypeSavings, 10000.00)
+	config := DefaultTestConfig()
+	config.TimeHorizonYears = 50
+	ctx, cancel := context.WithCancel(CreateAuthContext(userID))
+	cancel()
+
+	// Act
+	result, err := service.CalculateProjection(ctx, &ProjectionRequest{Config: config})
+
+	// Assert
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected a cancellation error, got %v", err)
+	}
+	if result != nil {
+		t.Error("Expected no result for a cancelled projection")
+	}
+}
+
 func TestCalculateProjection_WithMortgage(t *testing.T) {
 	db := SetupTestDB(t)
 	defer CleanupTestDB(t, db)
@@ -1110,3 +1142,649 @@ func TestCalculateProjection_LongTermProjection(t *testing.T) {
 			initialNetWorth, finalNetWorth)
 	}
 }
+
+// createTestEquityAccount creates a stock options account holding an RSU grant of 1200
+// shares granted six months ago that vests monthly over a year at an FMV of 10
+func createTestEquityAccount(t *testing.T, db *sql.DB, ctx context.Context, userID string) string {
+	t.Helper()
+
+	accountID := account.CreateTestAccount(t, db, userID, account.AccountTypeStockOptions)
+	if _, err := db.Exec(`UPDATE accounts SET is_asset = 1 WHERE id = $1`, accountID); err != nil {
+		t.Fatalf("Failed to mark equity account as asset: %v", err)
+	}
+
+	accountSvc := account.SetupAccountService(t, db)
+	today := civil.TodayIn(ctx)
+	grant, err := accountSvc.CreateEquityGrant(ctx, accountID, &account.CreateEquityGrantRequest{
+		GrantType:   account.GrantTypeRSU,
+		GrantDate:   account.Date{Time: today.AddDate(0, -6, 0)},
+		Quantity:    1200,
+		FMVAtGrant:  10.00,
+		CompanyName: "Test Corp",
+		Currency:    "USD",
+	})
+	if err != nil {
+		t.Fatalf("CreateEquityGrant failed: %v", err)
+	}
+
+	totalMonths := 12
+	frequency := "monthly"
+	if _, err := accountSvc.SetVestingSchedule(ctx, grant.ID, &account.SetVestingScheduleRequest{
+		ScheduleType:       "time_based",
+		TotalVestingMonths: &totalMonths,
+		VestingFrequency:   &frequency,
+	}); err != nil {
+		t.Fatalf("SetVestingSchedule failed: %v", err)
+	}
+
+	return accountID
+}
+
+func TestCalculateProjection_UnvestedEquityAddsToNetWorth(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-calc-equity-1"
+	account.CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupProjectionService(t, db)
+	createTestEquityAccount(t, db, ctx, userID)
+
+	noGrowth := 0.0
+	vestedOnly := DefaultTestConfig()
+	vestedOnly.TimeHorizonYears = 2
+	vestedOnly.Equity = &EquityConfig{FMVGrowth: &noGrowth}
+	withUnvested := DefaultTestConfig()
+	withUnvested.TimeHorizonYears = 2
+	withUnvested.Equity = &EquityConfig{IncludeUnvested: true, FMVGrowth: &noGrowth}
+
+	// Act
+	vestedResult, err := service.CalculateProjection(ctx, &ProjectionRequest{Config: vestedOnly})
+	if err != nil {
+		t.Fatalf("CalculateProjection failed: %v", err)
+	}
+	unvestedResult, err := service.CalculateProjection(ctx, &ProjectionRequest{Config: withUnvested})
+	if err != nil {
+		t.Fatalf("CalculateProjection failed: %v", err)
+	}
+
+	// Assert
+	// 600 vested shares are valued today; the other 600 vest over the next six months
+	if vestedResult.Assets[0].Value < 6000 {
+		t.Errorf("Expected vested equity of at least 6000 in starting assets, got %.2f", vestedResult.Assets[0].Value)
+	}
+	last := len(unvestedResult.NetWorth) - 1
+	difference := unvestedResult.NetWorth[last].Value - vestedResult.NetWorth[last].Value
+	if math.Abs(difference-6000) > 0.01 {
+		t.Errorf("Expected unvested equity to add 6000 to net worth, got %.2f", difference)
+	}
+}
+
+func TestCalculateProjection_EquitySoldOnVestCountsAsIncome(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-calc-equity-2"
+	account.CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupProjectionService(t, db)
+	createTestEquityAccount(t, db, ctx, userID)
+
+	noGrowth := 0.0
+	config := DefaultTestConfig()
+	config.TimeHorizonYears = 1
+	config.Equity = &EquityConfig{IncludeUnvested: true, FMVGrowth: &noGrowth, SellOnVest: true}
+
+	// Act
+	result, err := service.CalculateProjection(ctx, &ProjectionRequest{Config: config})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CalculateProjection failed: %v", err)
+	}
+	totalVested := 0.0
+	for _, point := range result.CashFlow {
+		totalVested += point.EquityVested
+		if point.Income < point.EquityVested {
+			t.Errorf("Expected vest proceeds to be counted in income, got %+v", point)
+		}
+	}
+	if math.Abs(totalVested-6000) > 0.01 {
+		t.Errorf("Expected 6000 of equity to vest, got %.2f", totalVested)
+	}
+}
+
+func TestCalculateProjection_SellVestedEquityPaysProceedsIntoTarget(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-calc-equity-3"
+	account.CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupProjectionService(t, db)
+	equityID := createTestEquityAccount(t, db, ctx, userID)
+	targetID := CreateTestAccountForProjection(t, db, userID, account.AccountTypeSavings, 1000)
+
+	accountSvc := account.SetupAccountService(t, db)
+	if _, err := accountSvc.RecordFMV(ctx, equityID, &account.RecordFMVRequest{
+		Currency:      "USD",
+		EffectiveDate: account.Date{Time: civil.TodayIn(ctx).AddDate(0, -6, 0)},
+		FMVPerShare:   20.00,
+	}); err != nil {
+		t.Fatalf("RecordFMV failed: %v", err)
+	}
+
+	saleDate := civil.TodayIn(ctx).AddDate(0, 3, 0)
+	baseline := DefaultTestConfig()
+	baseline.TimeHorizonYears = 1
+	withSale := DefaultTestConfig()
+	withSale.TimeHorizonYears = 1
+	withSale.Events = []Event{{
+		ID:          "sell-equity",
+		Type:        EventSellVestedEquity,
+		Date:        saleDate,
+		Description: "Sell vested RSUs for a down payment",
+		Parameters:  EventParameters{AccountID: equityID, TargetAccountID: targetID},
+	}}
+
+	// Act
+	baselineResult, err := service.CalculateProjection(ctx, &ProjectionRequest{Config: baseline})
+	if err != nil {
+		t.Fatalf("CalculateProjection failed: %v", err)
+	}
+	result, err := service.CalculateProjection(ctx, &ProjectionRequest{Config: withSale})
+	if err != nil {
+		t.Fatalf("CalculateProjection failed: %v", err)
+	}
+
+	// Assert
+	// The vested RSUs gained 10 a share since grant, taxed at half inclusion and a 50% rate
+	month := 3
+	point := result.CashFlow[month]
+	sold := point.EquitySold + point.EquitySaleTax
+	if sold <= 0 {
+		t.Fatalf("Expected the sale to be recorded in month %d, got %+v", month, point)
+	}
+	if taxRate := point.EquitySaleTax / sold; math.Abs(taxRate-0.125) > 0.0001 {
+		t.Errorf("Expected a tax rate of 12.5%% on the sale, got %.4f", taxRate)
+	}
+	if point.Income != baselineResult.CashFlow[month].Income {
+		t.Errorf("Expected proceeds paid into the target not to count as income, got %.2f vs %.2f",
+			point.Income, baselineResult.CashFlow[month].Income)
+	}
+	if equity := result.AssetBreakdown[month].Assets[string(account.AccountTypeStockOptions)]; equity != 0 {
+		t.Errorf("Expected the equity account to be sold down to 0, got %.2f", equity)
+	}
+	added := result.AssetBreakdown[month].Assets[string(account.AccountTypeSavings)] -
+		baselineResult.AssetBreakdown[month].Assets[string(account.AccountTypeSavings)]
+	if added < point.EquitySold-0.01 {
+		t.Errorf("Expected the target to receive the %.2f proceeds, got %.2f", point.EquitySold, added)
+	}
+}
+
+func TestCalculateProjection_StudentLoanForgiveness(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-calc-student-loan-1"
+	account.CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupProjectionService(t, db)
+	accountSvc := account.SetupAccountService(t, db)
+
+	loanID := account.CreateTestAccount(t, db, userID, account.AccountTypeLoan)
+	if _, err := accountSvc.CreateLoanDetails(ctx, loanID, &account.CreateLoanDetailsRequest{
+		AccountID:        loanID,
+		OriginalAmount:   30000.00,
+		InterestRate:     0.05,
+		RateType:         "fixed",
+		StartDate:        account.Date{Time: time.Now().AddDate(-10, 0, 0)},
+		TermMonths:       240,
+		PaymentAmount:    200.00,
+		PaymentFrequency: "monthly",
+	}); err != nil {
+		t.Fatalf("CreateLoanDetails failed: %v", err)
+	}
+	percent, forgivenessMonths := 0.10, 120
+	if _, err := accountSvc.SetStudentLoanDetails(ctx, loanID, &account.SetStudentLoanDetailsRequest{
+		RepaymentPlan:       account.RepaymentPlanIncomeDriven,
+		IncomeDrivenPercent: &percent,
+		ForgivenessMonths:   &forgivenessMonths,
+		QualifyingPayments:  114,
+		ForgivenessTaxable:  true,
+	}); err != nil {
+		t.Fatalf("SetStudentLoanDetails failed: %v", err)
+	}
+
+	config := DefaultTestConfig()
+	config.TimeHorizonYears = 1
+
+	// Act
+	result, err := service.CalculateProjection(ctx, &ProjectionRequest{Config: config})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CalculateProjection failed: %v", err)
+	}
+	if len(result.LoanForgiveness) != 1 {
+		t.Fatalf("Expected 1 loan forgiveness, got %d", len(result.LoanForgiveness))
+	}
+	forgiven := result.LoanForgiveness[0]
+	if forgiven.AccountID != loanID || !forgiven.Taxable || forgiven.Amount < 29000 {
+		t.Errorf("Expected most of the balance to be forgiven, got %+v", forgiven)
+	}
+	last := result.DebtPayoff[len(result.DebtPayoff)-1]
+	if last.Debts[loanID] != 0 {
+		t.Errorf("Expected no balance after forgiveness, got %.2f", last.Debts[loanID])
+	}
+}
+
+func TestCalculateProjection_EmployerMatch(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-calc-employer-match-1"
+	account.CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupProjectionService(t, db)
+	accountSvc := account.SetupAccountService(t, db)
+
+	rrspID := CreateTestAccountForProjection(t, db, userID, account.AccountTypeRRSP, 10000.00)
+	if _, err := accountSvc.SetEmployerMatch(ctx, rrspID, &account.SetEmployerMatchRequest{
+		EmployeeContributionRate: 0.05,
+		MatchRate:                0.5,
+		EmploymentStartDate:      &account.Date{Time: time.Now().AddDate(0, -6, 0)},
+		EmployerContributions:    1000,
+		VestingSchedule:          []account.EmployerMatchStep{{YearsOfService: 2, VestedPercent: 1}},
+	}); err != nil {
+		t.Fatalf("SetEmployerMatch failed: %v", err)
+	}
+
+	config := DefaultTestConfig()
+	config.TimeHorizonYears = 1
+	config.AnnualSalary = 120000
+	config.AnnualSalaryGrowth = 0
+
+	// Act
+	result, err := service.CalculateProjection(ctx, &ProjectionRequest{Config: config})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CalculateProjection failed: %v", err)
+	}
+	first := result.CashFlow[0]
+	if math.Abs(first.Payroll-500) > 0.01 || math.Abs(first.EmployerMatch-250) > 0.01 {
+		t.Errorf("Expected 500 payroll contributions and a 250 match, got %+v", first)
+	}
+	if len(result.UnvestedMatch) != len(result.CashFlow) {
+		t.Fatalf("Expected an unvested point per month, got %d", len(result.UnvestedMatch))
+	}
+	if unvested := result.UnvestedMatch[0].Value; math.Abs(unvested-1250) > 0.01 {
+		t.Errorf("Expected 1250 unvested in the first month, got %.2f", unvested)
+	}
+}
+
+func TestSimulatePurchase_FinancedPurchase(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-what-if-1"
+	account.CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupProjectionService(t, db)
+
+	checkingID := CreateTestAccountForProjection(t, db, userID, account.AccountTypeChecking, 30000.00)
+	CreateTestAccountForProjection(t, db, userID, account.AccountTypeTFSA, 20000.00)
+
+	config := DefaultTestConfig()
+	req := &WhatIfRequest{
+		Description:      "New car",
+		Amount:           25000,
+		FundingAccountID: checkingID,
+		Financing:        &WhatIfFinancing{DownPayment: 5000, AnnualRate: 0.06, TermMonths: 48},
+		Config:           config,
+	}
+
+	// Act
+	result, err := service.SimulatePurchase(ctx, req)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("SimulatePurchase failed: %v", err)
+	}
+	if result.Upfront != 5000 || result.Financed != 20000 || result.MonthlyPayment != 469.70 {
+		t.Errorf("Expected 5000 down and 20000 financed at 469.70/month, got %+v", result)
+	}
+	if result.PurchaseMonth.Change != -5000 {
+		t.Errorf("Expected the purchase month's cash flow to drop by the down payment, got %+v", result.PurchaseMonth)
+	}
+	coverage := result.EmergencyFund
+	if coverage.LiquidBefore != 30000 || coverage.LiquidAfter != 25000 {
+		t.Errorf("Expected liquid balances to fall from 30000 to 25000, got %+v", coverage)
+	}
+	if coverage.MonthsBefore == nil || coverage.MonthsAfter == nil || *coverage.MonthsAfter >= *coverage.MonthsBefore {
+		t.Errorf("Expected the purchase to shorten emergency fund coverage, got %+v", coverage)
+	}
+	if result.EndNetWorth.Change >= 0 || len(result.NetWorthDelta) != config.TimeHorizonYears {
+		t.Errorf("Expected lower projected net worth each year, got %+v and %d yearly points", result.EndNetWorth, len(result.NetWorthDelta))
+	}
+	if len(config.Events) != 0 {
+		t.Errorf("Expected the caller's config to be left untouched, got %d events", len(config.Events))
+	}
+}
+
+func TestCalculateProjection_RateShocks(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-rate-shock-1"
+	account.CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupProjectionService(t, db)
+
+	CreateTestAccountForProjection(t, db, userID, account.AccountTypeTFSA, 50000.00)
+	mortgageID := CreateTestMortgageForProjection(t, db, userID)
+	if _, err := db.Exec(`UPDATE mortgage_details SET rate_type = 'variable' WHERE account_id = $1`, mortgageID); err != nil {
+		t.Fatalf("Failed to make mortgage variable: %v", err)
+	}
+
+	baselineConfig := DefaultTestConfig()
+	baselineConfig.TimeHorizonYears = 1
+	shockedConfig := DefaultTestConfig()
+	shockedConfig.TimeHorizonYears = 1
+	shockedConfig.RateShocks = []RateShock{
+		{Date: time.Now().AddDate(0, 6, 0), BorrowingBps: 150, ReturnBps: -100},
+		{Date: time.Now().AddDate(0, 6, 0), BorrowingBps: 50},
+	}
+
+	// Act
+	baseline, err := service.CalculateProjection(ctx, &ProjectionRequest{Config: baselineConfig})
+	if err != nil {
+		t.Fatalf("CalculateProjection failed: %v", err)
+	}
+	shocked, err := service.CalculateProjection(ctx, &ProjectionRequest{Config: shockedConfig})
+	if err != nil {
+		t.Fatalf("CalculateProjection failed: %v", err)
+	}
+
+	// Assert
+	if cost := shocked.CashFlow[5].RateShockCost; cost != 0 {
+		t.Errorf("Expected no rate shock cost before the shock, got %.2f", cost)
+	}
+	// Shocks add up to +2% on the balance owed going into the month
+	wantCost := shocked.DebtPayoff[5].Debts[mortgageID] * 0.02 / 12
+	if cost := shocked.CashFlow[6].RateShockCost; math.Abs(cost-wantCost) > 0.01 {
+		t.Errorf("Expected a rate shock cost of %.2f, got %.2f", wantCost, cost)
+	}
+	if diff := shocked.CashFlow[6].Expenses - baseline.CashFlow[6].Expenses; math.Abs(diff-wantCost) > 0.01 {
+		t.Errorf("Expected expenses to rise by the rate shock cost, got %.2f", diff)
+	}
+	last := len(shocked.DebtPayoff) - 1
+	if math.Abs(shocked.DebtPayoff[last].TotalDebt-baseline.DebtPayoff[last].TotalDebt) > 0.01 {
+		t.Errorf("Expected the mortgage to pay down on schedule, got %.2f vs %.2f",
+			shocked.DebtPayoff[last].TotalDebt, baseline.DebtPayoff[last].TotalDebt)
+	}
+	if shocked.AssetBreakdown[last].Assets["tfsa"] >= baseline.AssetBreakdown[last].Assets["tfsa"] {
+		t.Errorf("Expected lower returns to shrink the TFSA, got %.2f vs %.2f",
+			shocked.AssetBreakdown[last].Assets["tfsa"], baseline.AssetBreakdown[last].Assets["tfsa"])
+	}
+}
+
+func TestCalculateProjection_BacktestReplaysHistoricalReturns(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-backtest-1"
+	account.CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupProjectionService(t, db)
+
+	CreateTestAccountForProjection(t, db, userID, account.AccountTypeTFSA, 50000.00)
+
+	allStocks := 1.0
+	config := DefaultTestConfig()
+	config.TimeHorizonYears = 3
+	config.SavingsAllocation = map[string]float64{}
+	config.Backtest = &BacktestConfig{StartYear: 2022, StockAllocation: &allStocks}
+
+	// Act
+	resp, err := service.CalculateProjection(ctx, &ProjectionRequest{Config: config})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CalculateProjection failed: %v", err)
+	}
+	if resp.Backtest == nil || resp.Backtest.EndYear != 2023 || len(resp.Backtest.Years) != 2 {
+		t.Fatalf("Expected 2022 and 2023 to be replayed, got %+v", resp.Backtest)
+	}
+	// 2022's -18.04% over the first twelve months, then 2023's +26.06%
+	if got, want := resp.AssetBreakdown[11].Assets["tfsa"], 50000*(1-0.1804); math.Abs(got-want) > 1 {
+		t.Errorf("Expected the TFSA at %.2f after 2022, got %.2f", want, got)
+	}
+	if got, want := resp.AssetBreakdown[23].Assets["tfsa"], 50000*(1-0.1804)*1.2606; math.Abs(got-want) > 1 {
+		t.Errorf("Expected the TFSA at %.2f after 2023, got %.2f", want, got)
+	}
+	// History runs out after 2023, so the third year earns the expected 7%
+	if got, want := resp.AssetBreakdown[35].Assets["tfsa"], 50000*(1-0.1804)*1.2606*1.07; math.Abs(got-want) > 1 {
+		t.Errorf("Expected the TFSA at %.2f after the third year, got %.2f", want, got)
+	}
+
+	config.Backtest = &BacktestConfig{StartYear: 1900}
+	if _, err := service.CalculateProjection(ctx, &ProjectionRequest{Config: config}); err == nil {
+		t.Error("Expected a start year before the historical series to fail")
+	}
+}
+
+func TestCalculateProjection_ExpensesGrowWithTrailingCPI(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+	defer db.Exec("DELETE FROM cpi_observations WHERE region = 'CA'")
+
+	// Arrange
+	userID := "test-user-cpi-growth-1"
+	account.CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupProjectionService(t, db)
+
+	CreateTestAccountForProjection(t, db, userID, account.AccountTypeSavings, 50000.00)
+	for month, index := range map[string]float64{"2024-06": 160.0, "2025-06": 164.8} {
+		_, err := db.Exec(`
+			INSERT INTO cpi_observations (region, month, index_value, source, fetched_at)
+			VALUES ('CA', $1, $2, 'statcan', CURRENT_TIMESTAMP)
+		`, month, index)
+		if err != nil {
+			t.Fatalf("Failed to insert CPI observation: %v", err)
+		}
+	}
+
+	config := DefaultTestConfig()
+	config.TimeHorizonYears = 2
+	config.MonthlyExpenses = 3000.00
+	config.CPIRegion = "ca"
+
+	// Act
+	resp, err := service.CalculateProjection(ctx, &ProjectionRequest{Config: config})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CalculateProjection failed: %v", err)
+	}
+	if resp.ExpenseGrowth == nil || resp.ExpenseGrowth.Rate != 0.03 || resp.ExpenseGrowth.To != "2025-06" {
+		t.Fatalf("Expected 3%% trailing CPI inflation to June 2025, got %+v", resp.ExpenseGrowth)
+	}
+	// Expenses a year in are 3% higher rather than the configured 2%
+	if got, want := resp.CashFlow[12].Expenses/resp.CashFlow[0].Expenses, 1.03; math.Abs(got-want) > 1e-9 {
+		t.Errorf("Expected expenses to grow by %.2f over the first year, got %.4f", want, got)
+	}
+
+	config.CPIRegion = "UK"
+	if _, err := service.CalculateProjection(ctx, &ProjectionRequest{Config: config}); err == nil {
+		t.Error("Expected an unsupported CPI region to fail")
+	}
+}
+
+func TestCalculateProjection_SweepRulesMoveSurplusCash(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-calc-sweep-1"
+	account.CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupProjectionService(t, db)
+
+	checkingID := CreateTestAccountForProjection(t, db, userID, account.AccountTypeChecking, 10000.00)
+	tfsaID := CreateTestAccountForProjection(t, db, userID, account.AccountTypeTFSA, 0)
+	_, err := service.transactionSvc.CreateSweepRule(ctx, &transaction.CreateSweepRuleRequest{
+		FromAccountID: checkingID,
+		ToAccountID:   tfsaID,
+		Threshold:     2000,
+	})
+	if err != nil {
+		t.Fatalf("CreateSweepRule failed: %v", err)
+	}
+
+	config := DefaultTestConfig()
+	config.TimeHorizonYears = 1
+
+	// Act
+	result, err := service.CalculateProjection(ctx, &ProjectionRequest{Config: config})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CalculateProjection failed: %v", err)
+	}
+	if swept := result.CashFlow[0].Swept; swept != 8000 {
+		t.Errorf("Expected 8000 swept in the first month, got %.2f", swept)
+	}
+	if tfsa := result.AssetBreakdown[0].Assets["tfsa"]; tfsa < 8000 {
+		t.Errorf("Expected the swept cash in the TFSA, got %.2f", tfsa)
+	}
+}
+
+func TestCalculateProjection_BonusPlansPayExpectedBonus(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-calc-bonus-1"
+	account.CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupProjectionService(t, db)
+
+	CreateTestAccountForProjection(t, db, userID, account.AccountTypeChecking, 10000.00)
+	dpspID := CreateTestAccountForProjection(t, db, userID, account.AccountTypeTFSA, 0)
+
+	payoutMonth := civil.TodayIn(ctx).Time.AddDate(0, 2, 0).Month()
+	confidence := 0.5
+	if _, err := service.incomeSvc.CreateBonusPlan(ctx, &income.CreateBonusPlanRequest{
+		Name:          "Annual bonus",
+		Employer:      "Acme Corp",
+		PlanType:      income.BonusPlanBonus,
+		TargetPercent: 0.10,
+		PayoutMonth:   int(payoutMonth),
+		Confidence:    &confidence,
+	}); err != nil {
+		t.Fatalf("CreateBonusPlan failed: %v", err)
+	}
+	if _, err := service.incomeSvc.CreateBonusPlan(ctx, &income.CreateBonusPlanRequest{
+		Name:          "Profit sharing",
+		Employer:      "Acme Corp",
+		PlanType:      income.BonusPlanDPSP,
+		TargetPercent: 0.05,
+		PayoutMonth:   int(payoutMonth),
+		AccountID:     &dpspID,
+	}); err != nil {
+		t.Fatalf("CreateBonusPlan failed: %v", err)
+	}
+
+	config := DefaultTestConfig()
+	config.TimeHorizonYears = 1
+
+	// Act
+	result, err := service.CalculateProjection(ctx, &ProjectionRequest{Config: config})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CalculateProjection failed: %v", err)
+	}
+	if result.CashFlow[1].Bonus != 0 || result.CashFlow[1].DPSP != 0 {
+		t.Errorf("Expected nothing paid outside the payout month, got bonus %.2f and DPSP %.2f",
+			result.CashFlow[1].Bonus, result.CashFlow[1].DPSP)
+	}
+
+	salary := config.AnnualSalary * math.Pow(1+config.AnnualSalaryGrowth, 2.0/12.0)
+	// Half the 10% target, taxed at the 20% federal and 9.15% provincial marginal rates
+	expectedBonus := salary * 0.10 * 0.5 * (1 - 0.20 - 0.0915)
+	if bonus := result.CashFlow[2].Bonus; math.Abs(bonus-expectedBonus) > 0.01 {
+		t.Errorf("Expected an after-tax bonus of %.2f, got %.2f", expectedBonus, bonus)
+	}
+	if dpsp := result.CashFlow[2].DPSP; math.Abs(dpsp-salary*0.05) > 0.01 {
+		t.Errorf("Expected a DPSP contribution of %.2f, got %.2f", salary*0.05, dpsp)
+	}
+}
+
+func TestAnalyzeSensitivity_RanksInputsByNetWorthSwing(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-sensitivity-1"
+	account.CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupProjectionService(t, db)
+
+	CreateTestAccountForProjection(t, db, userID, account.AccountTypeTFSA, 700000.00)
+
+	config := DefaultTestConfig()
+	config.TimeHorizonYears = 10
+	retirement := time.Now().AddDate(5, 0, 0)
+	req := &SensitivityRequest{Config: config, RetirementDate: &retirement}
+
+	// Act
+	result, err := service.AnalyzeSensitivity(ctx, req)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("AnalyzeSensitivity failed: %v", err)
+	}
+	if len(result.Factors) != 3 {
+		t.Fatalf("Expected 3 factors, got %d", len(result.Factors))
+	}
+	factors := make(map[string]SensitivityFactor)
+	for i, factor := range result.Factors {
+		factors[factor.Input] = factor
+		if i > 0 && factor.Swing > result.Factors[i-1].Swing {
+			t.Errorf("Expected factors ranked by swing, got %.2f after %.2f", factor.Swing, result.Factors[i-1].Swing)
+		}
+	}
+	returns := factors["investment_returns"]
+	if returns.Decrease.NetWorthChange >= 0 || returns.Increase.NetWorthChange <= 0 {
+		t.Errorf("Expected higher returns to raise net worth, got %+v", returns)
+	}
+	expenses := factors["monthly_expenses"]
+	if expenses.Decrease.NetWorthChange <= 0 || expenses.Increase.NetWorthChange >= 0 {
+		t.Errorf("Expected higher expenses to lower net worth, got %+v", expenses)
+	}
+	retire := factors["retirement_date"]
+	if retire.Decrease.NetWorthChange >= 0 || retire.Increase.NetWorthChange <= 0 {
+		t.Errorf("Expected retiring later to raise net worth, got %+v", retire)
+	}
+	if result.Baseline.FIREDate == nil {
+		t.Fatal("Expected the baseline to reach its FIRE target")
+	}
+	if shift := returns.Increase.FIREMonthsShift; shift == nil || *shift > 0 {
+		t.Errorf("Expected higher returns to bring FIRE forward or keep it, got %v", shift)
+	}
+	if len(config.Events) != 0 || len(config.RateShocks) != 0 {
+		t.Errorf("Expected the caller's config to be left untouched")
+	}
+}
@@ -0,0 +1,288 @@
+package projections
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"money/internal/civil"
+)
+
+// DrawdownMethod selects how return sequences are generated for a drawdown simulation
+type DrawdownMethod string
+
+const (
+	// DrawdownHistorical replays every historical start year in order, wrapping around the series
+	DrawdownHistorical DrawdownMethod = "historical"
+	// DrawdownBootstrap samples annual returns from the series with replacement
+	DrawdownBootstrap DrawdownMethod = "bootstrap"
+)
+
+// Drawdown simulation defaults and limits
+const (
+	DefaultDrawdownYears       = 30
+	DefaultDrawdownSimulations = 1000
+	MaxDrawdownSimulations     = 10000
+	DefaultWithdrawalInflation = 0.02
+	DefaultBondReturn          = 0.04
+)
+
+// historicalFirstYear is the first year of historicalStockReturns
+const historicalFirstYear = 1928
+
+// historicalStockReturns are annual S&P 500 total returns (dividends reinvested), 1928-2023
+var historicalStockReturns = []float64{
+	0.4381, -0.0830, -0.2512, -0.4384, -0.0864, 0.4998, -0.0119, 0.4674, 0.3194, -0.3534, // 1928-1937
+	0.2928, -0.0110, -0.1067, -0.1277, 0.1917, 0.2506, 0.1903, 0.3582, -0.0843, 0.0520, // 1938-1947
+	0.0570, 0.1830, 0.3081, 0.2368, 0.1815, -0.0121, 0.5256, 0.3260, 0.0744, -0.1046, // 1948-1957
+	0.4372, 0.1206, 0.0034, 0.2664, -0.0881, 0.2261, 0.1642, 0.1240, -0.0997, 0.2380, // 1958-1967
+	0.1081, -0.0824, 0.0356, 0.1422, 0.1876, -0.1431, -0.2590, 0.3700, 0.2383, -0.0698, // 1968-1977
+	0.0651, 0.1852, 0.3174, -0.0470, 0.2042, 0.2234, 0.0615, 0.3124, 0.1849, 0.0581, // 1978-1987
+	0.1654, 0.3148, -0.0306, 0.3023, 0.0749, 0.0997, 0.0133, 0.3720, 0.2268, 0.3310, // 1988-1997
+	0.2834, 0.2089, -0.0903, -0.1185, -0.2197, 0.2836, 0.1074, 0.0483, 0.1561, 0.0548, // 1998-2007
+	-0.3655, 0.2594, 0.1482, 0.0210, 0.1589, 0.3215, 0.1352, 0.0138, 0.1177, 0.2161, // 2008-2017
+	-0.0423, 0.3121, 0.1802, 0.2847, -0.1804, 0.2606, // 2018-2023
+}
+
+// DrawdownRequest describes a retirement drawdown plan to stress-test
+type DrawdownRequest struct {
+	StartingPortfolio   *float64       `json:"starting_portfolio,omitempty"`   // Defaults to current investment account balances
+	AnnualWithdrawal    float64        `json:"annual_withdrawal"`              // First-year withdrawal in today's dollars
+	WithdrawalInflation *float64       `json:"withdrawal_inflation,omitempty"` // Annual increase in withdrawals, default 2%
+	Years               int            `json:"years"`                          // Length of retirement, default 30
+	Method              DrawdownMethod `json:"method"`                         // historical (default) or bootstrap
+	Simulations         int            `json:"simulations,omitempty"`          // Bootstrap runs, default 1000
+	StockAllocation     *float64       `json:"stock_allocation,omitempty"`     // Share held in stocks, default 1.0
+	BondReturn          *float64       `json:"bond_return,omitempty"`          // Fixed annual return on the non-stock share, default 4%
+	ReturnSeries        []float64      `json:"return_series,omitempty"`        // Custom annual stock returns instead of the built-in history
+	Seed                *int64         `json:"seed,omitempty"`                 // Fixes bootstrap sampling for repeatable results
+}
+
+// DrawdownPath is the outcome of a single simulated retirement
+type DrawdownPath struct {
+	StartYear     *int    `json:"start_year,omitempty"`     // Historical start year, for the historical method
+	EndingBalance float64 `json:"ending_balance"`           // Portfolio left after the final year
+	DepletionYear *int    `json:"depletion_year,omitempty"` // Retirement year (1-based) the portfolio ran out
+	LowestBalance float64 `json:"lowest_balance"`           // Smallest year-end balance along the path
+}
+
+// DrawdownResponse summarizes a drawdown simulation
+type DrawdownResponse struct {
+	Method                   DrawdownMethod     `json:"method"`
+	StartingPortfolio        float64            `json:"starting_portfolio"`
+	InitialWithdrawalRate    float64            `json:"initial_withdrawal_rate"`
+	Years                    int                `json:"years"`
+	Simulations              int                `json:"simulations"`
+	SuccessProbability       float64            `json:"success_probability"`                 // Share of paths that never ran out
+	WorstCaseDepletionYear   *int               `json:"worst_case_depletion_year,omitempty"` // Earliest retirement year any path ran out
+	WorstCaseDepletionDate   *time.Time         `json:"worst_case_depletion_date,omitempty"` // Calendar date of the worst case, counting from today
+	EndingBalancePercentiles map[string]float64 `json:"ending_balance_percentiles"`          // p10, p50, p90
+	WorstPath                *DrawdownPath      `json:"worst_path"`
+}
+
+// investmentAccountTypes are the account types counted as the drawdown portfolio by default
+var investmentAccountTypes = map[string]bool{
+	"brokerage": true,
+	"tfsa":      true,
+	"rrsp":      true,
+	"crypto":    true,
+	"savings":   true,
+}
+
+// SimulateDrawdown stress-tests a retirement withdrawal plan against historical or
+// bootstrapped return sequences, reporting how often it survives and how early it can fail
+func (s *Service) SimulateDrawdown(ctx context.Context, req *DrawdownRequest) (*DrawdownResponse, error) {
+	starting := 0.0
+	if req.StartingPortfolio != nil {
+		starting = *req.StartingPortfolio
+	} else {
+		accounts, err := s.getCurrentAccounts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, acc := range accounts {
+			if acc.IsAsset && investmentAccountTypes[acc.Type] {
+				starting += acc.Balance
+			}
+		}
+	}
+
+	return simulateDrawdown(req, starting, civil.TodayIn(ctx).Time)
+}
+
+// simulateDrawdown runs the simulation for a known starting portfolio
+func simulateDrawdown(req *DrawdownRequest, starting float64, now time.Time) (*DrawdownResponse, error) {
+	if starting <= 0 {
+		return nil, fmt.Errorf("starting portfolio must be greater than zero")
+	}
+	if req.AnnualWithdrawal <= 0 {
+		return nil, fmt.Errorf("annual withdrawal must be greater than zero")
+	}
+
+	years := req.Years
+	if years <= 0 {
+		years = DefaultDrawdownYears
+	}
+	if years > 100 {
+		return nil, fmt.Errorf("years must be 100 or less")
+	}
+
+	inflation := DefaultWithdrawalInflation
+	if req.WithdrawalInflation != nil {
+		inflation = *req.WithdrawalInflation
+	}
+	stockAllocation := 1.0
+	if req.StockAllocation != nil {
+		stockAllocation = *req.StockAllocation
+	}
+	if stockAllocation < 0 || stockAllocation > 1 {
+		return nil, fmt.Errorf("stock allocation must be between 0 and 1")
+	}
+	bondReturn := DefaultBondReturn
+	if req.BondReturn != nil {
+		bondReturn = *req.BondReturn
+	}
+
+	series := historicalStockReturns
+	customSeries := len(req.ReturnSeries) > 0
+	if customSeries {
+		series = req.ReturnSeries
+	}
+	returns := make([]float64, len(series))
+	for i, r := range series {
+		returns[i] = stockAllocation*r + (1-stockAllocation)*bondReturn
+	}
+
+	method := req.Method
+	if method == "" {
+		method = DrawdownHistorical
+	}
+
+	var paths []DrawdownPath
+	switch method {
+	case DrawdownHistorical:
+		// Start in every year of the series, wrapping around so late start years still run the full retirement
+		for start := range returns {
+			sequence := make([]float64, years)
+			for y := 0; y < years; y++ {
+				sequence[y] = returns[(start+y)%len(returns)]
+			}
+			path := runDrawdownPath(sequence, starting, req.AnnualWithdrawal, inflation)
+			if !customSeries {
+				startYear := historicalFirstYear + start
+				path.StartYear = &startYear
+			}
+			paths = append(paths, path)
+		}
+	case DrawdownBootstrap:
+		simulations := req.Simulations
+		if simulations <= 0 {
+			simulations = DefaultDrawdownSimulations
+		}
+		if simulations > MaxDrawdownSimulations {
+			return nil, fmt.Errorf("simulations must be %d or less", MaxDrawdownSimulations)
+		}
+		seed := now.UnixNano()
+		if req.Seed != nil {
+			seed = *req.Seed
+		}
+		rng := rand.New(rand.NewSource(seed))
+		sequence := make([]float64, years)
+		for i := 0; i < simulations; i++ {
+			for y := range sequence {
+				sequence[y] = returns[rng.Intn(len(returns))]
+			}
+			paths = append(paths, runDrawdownPath(sequence, starting, req.AnnualWithdrawal, inflation))
+		}
+	default:
+		return nil, fmt.Errorf("unknown drawdown method: %s", method)
+	}
+
+	resp := &DrawdownResponse{
+		Method:                   method,
+		StartingPortfolio:        starting,
+		InitialWithdrawalRate:    req.AnnualWithdrawal / starting,
+		Years:                    years,
+		Simulations:              len(paths),
+		EndingBalancePercentiles: make(map[string]float64),
+	}
+
+	successes := 0
+	endings := make([]float64, len(paths))
+	for i := range paths {
+		path := &paths[i]
+		endings[i] = path.EndingBalance
+		if path.DepletionYear == nil {
+			successes++
+		}
+		if isWorseDrawdownPath(path, resp.WorstPath) {
+			resp.WorstPath = path
+		}
+	}
+	resp.SuccessProbability = float64(successes) / float64(len(paths))
+
+	if resp.WorstPath != nil && resp.WorstPath.DepletionYear != nil {
+		resp.WorstCaseDepletionYear = resp.WorstPath.DepletionYear
+		depletionDate := now.AddDate(*resp.WorstPath.DepletionYear, 0, 0)
+		resp.WorstCaseDepletionDate = &depletionDate
+	}
+
+	sort.Float64s(endings)
+	resp.EndingBalancePercentiles["p10"] = percentile(endings, 0.10)
+	resp.EndingBalancePercentiles["p50"] = percentile(endings, 0.50)
+	resp.EndingBalancePercentiles["p90"] = percentile(endings, 0.90)
+
+	return resp, nil
+}
+
+// runDrawdownPath withdraws at the start of each year and then applies that year's return
+func runDrawdownPath(returns []float64, starting, withdrawal, inflation float64) DrawdownPath {
+	balance := starting
+	path := DrawdownPath{LowestBalance: starting}
+
+	for y, r := range returns {
+		balance -= withdrawal * math.Pow(1+inflation, float64(y))
+		if balance <= 0 {
+			depletionYear := y + 1
+			path.DepletionYear = &depletionYear
+			path.LowestBalance = 0
+			return path
+		}
+		balance *= 1 + r
+		if balance < path.LowestBalance {
+			path.LowestBalance = balance
+		}
+	}
+
+	path.EndingBalance = balance
+	return path
+}
+
+// isWorseDrawdownPath orders paths by earliest depletion, then lowest ending balance
+func isWorseDrawdownPath(path, worst *DrawdownPath) bool {
+	if worst == nil {
+		return true
+	}
+	if path.DepletionYear != nil && worst.DepletionYear != nil {
+		return *path.DepletionYear < *worst.DepletionYear
+	}
+	if path.DepletionYear != nil || worst.DepletionYear != nil {
+		return path.DepletionYear != nil
+	}
+	return path.EndingBalance < worst.EndingBalance
+}
+
+// percentile returns the value at p (0-1) of sorted values using nearest rank
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
@@ -0,0 +1,62 @@
+package projections
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSimulateDrawdown_HistoricalSequences(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	req := &DrawdownRequest{AnnualWithdrawal: 40000, Years: 30}
+
+	// Act
+	resp, err := simulateDrawdown(req, 1000000, now)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("simulateDrawdown failed: %v", err)
+	}
+	if resp.Simulations != len(historicalStockReturns) {
+		t.Errorf("Expected one path per historical start year (%d), got %d", len(historicalStockReturns), resp.Simulations)
+	}
+	if resp.SuccessProbability <= 0.5 || resp.SuccessProbability > 1 {
+		t.Errorf("Expected a 4%% withdrawal rate to usually succeed, got %.2f", resp.SuccessProbability)
+	}
+	if resp.WorstPath == nil || resp.WorstPath.StartYear == nil {
+		t.Fatalf("Expected worst path with a historical start year, got %+v", resp.WorstPath)
+	}
+}
+
+func TestSimulateDrawdown_ReportsDepletionYear(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	zero := 0.0
+	seed := int64(42)
+	req := &DrawdownRequest{
+		AnnualWithdrawal:    250000,
+		WithdrawalInflation: &zero,
+		Years:               10,
+		Method:              DrawdownBootstrap,
+		Simulations:         200,
+		ReturnSeries:        []float64{0},
+		Seed:                &seed,
+	}
+
+	// Act
+	resp, err := simulateDrawdown(req, 1000000, now)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("simulateDrawdown failed: %v", err)
+	}
+	if resp.SuccessProbability != 0 {
+		t.Errorf("Expected every path to fail, got success probability %.2f", resp.SuccessProbability)
+	}
+	if resp.WorstCaseDepletionYear == nil || *resp.WorstCaseDepletionYear != 4 {
+		t.Errorf("Expected depletion in year 4, got %v", resp.WorstCaseDepletionYear)
+	}
+	if resp.WorstCaseDepletionDate == nil || resp.WorstCaseDepletionDate.Year() != 2028 {
+		t.Errorf("Expected depletion date in 2028, got %v", resp.WorstCaseDepletionDate)
+	}
+}
@@ -0,0 +1,152 @@
+package projections
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"money/internal/account"
+)
+
+// EquityConfig controls how equity compensation accounts are projected
+type EquityConfig struct {
+	IncludeUnvested bool     `json:"include_unvested"`     // Add scheduled future vests to net worth as they vest
+	FMVGrowth       *float64 `json:"fmv_growth,omitempty"` // Annual FMV growth; defaults to the account's growth rate
+	SellOnVest      bool     `json:"sell_on_vest"`         // Sell shares as they vest and count the proceeds as income
+}
+
+// equityVest is a scheduled future vest of an equity grant
+type equityVest struct {
+	AccountID   string
+	Date        time.Time
+	Quantity    int
+	FMV         float64 // FMV per share today
+	StrikePrice float64 // Zero for RSUs and RSAs
+}
+
+// value returns the vest's value at the projected FMV, net of any strike price
+func (v equityVest) value(growthRate, yearsElapsed float64) float64 {
+	fmv := v.FMV * math.Pow(1+growthRate, yearsElapsed)
+	return float64(v.Quantity) * math.Max(0, fmv-v.StrikePrice)
+}
+
+// prepareEquity values equity accounts without a recorded balance at their vested value
+// and, when unvested equity is included, returns the scheduled future vests
+func (s *Service) prepareEquity(ctx context.Context, accounts []AccountData, equity *EquityConfig, today time.Time) ([]equityVest, error) {
+	var vests []equityVest
+	for i := range accounts {
+		acc := &accounts[i]
+		if acc.Type != string(account.AccountTypeStockOptions) {
+			continue
+		}
+
+		if acc.Balance == 0 {
+			vested, err := s.accountSvc.GetVestedValue(ctx, acc.ID)
+			if err != nil {
+				return nil, err
+			}
+			acc.Balance = vested
+		}
+
+		if !equity.IncludeUnvested {
+			continue
+		}
+		summary, err := s.accountSvc.GetOptionsSummary(ctx, acc.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, grant := range summary.Grants {
+			events, err := s.accountSvc.GetVestingEvents(ctx, grant.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			fmv := grant.FMVAtGrant
+			if grant.CurrentFMV != nil {
+				fmv = *grant.CurrentFMV
+			}
+			strike := 0.0
+			if grant.StrikePrice != nil {
+				strike = *grant.StrikePrice
+			}
+
+			for _, event := range events.Events {
+				if event.Status != account.VestingStatusPending || !event.VestDate.After(today) {
+					continue
+				}
+				vests = append(vests, equityVest{
+					AccountID:   acc.ID,
+					Date:        event.VestDate.Time,
+					Quantity:    event.Quantity,
+					FMV:         fmv,
+					StrikePrice: strike,
+				})
+			}
+		}
+	}
+	return vests, nil
+}
+
+// findVestsForMonth returns the vests falling in the same month as date
+func findVestsForMonth(vests []equityVest, date time.Time) []equityVest {
+	var monthVests []equityVest
+	for _, vest := range vests {
+		if isSameMonth(vest.Date, date) {
+			monthVests = append(monthVests, vest)
+		}
+	}
+	return monthVests
+}
+
+// equitySaleTaxRates estimates the tax on each planned sale of vested equity as a share of
+// the value sold, keyed by event ID. Equity accounts sold from that have no recorded balance
+// are valued at their vested value, as when equity is configured. Sales that can't be
+// estimated are logged and left out.
+func (s *Service) equitySaleTaxRates(ctx context.Context, events []Event, accounts []AccountData) map[string]float64 {
+	rates := make(map[string]float64)
+	for _, event := range events {
+		if event.Type != EventSellVestedEquity {
+			continue
+		}
+		acc := findAccount(accounts, event.Parameters.AccountID)
+		if acc == nil || acc.Type != string(account.AccountTypeStockOptions) {
+			projectionsLog.Warn("Equity sale account not found", "event_id", event.ID, "account_id", event.Parameters.AccountID)
+			continue
+		}
+
+		marginalRate := account.DefaultVestedSaleMarginalRate
+		if event.Parameters.MarginalRate != nil {
+			marginalRate = *event.Parameters.MarginalRate
+		}
+		estimate, err := s.accountSvc.EstimateVestedSale(ctx, acc.ID, marginalRate)
+		if err != nil {
+			projectionsLog.Warn("Failed to estimate equity sale", "event_id", event.ID, "error", err)
+			continue
+		}
+		if acc.Balance == 0 {
+			acc.Balance = estimate.VestedValue
+		}
+		rates[event.ID] = estimate.TaxRate()
+	}
+	return rates
+}
+
+// sellVestedEquity sells the event's portion of the equity account's projected balance,
+// paying the proceeds after tax into the target account. It returns the proceeds and tax,
+// and whether the proceeds were paid into an account rather than left as income.
+func sellVestedEquity(event Event, taxRate float64, accountBalances map[string]float64) (proceeds, tax float64, deposited bool) {
+	portion := event.Parameters.SellPortion
+	if portion <= 0 || portion > 1 {
+		portion = 1
+	}
+	sold := math.Max(0, accountBalances[event.Parameters.AccountID]) * portion
+	accountBalances[event.Parameters.AccountID] -= sold
+
+	tax = sold * taxRate
+	proceeds = sold - tax
+	if _, ok := accountBalances[event.Parameters.TargetAccountID]; ok && event.Parameters.TargetAccountID != "" {
+		accountBalances[event.Parameters.TargetAccountID] += proceeds
+		return proceeds, tax, true
+	}
+	return proceeds, tax, false
+}
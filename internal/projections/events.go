@@ -16,6 +16,7 @@ const (
 	EventSalaryChange       EventType = "salary_change"
 	EventExpenseLevelChange EventType = "expense_level_change"
 	EventSavingsRateChange  EventType = "savings_rate_change"
+	EventSellVestedEquity   EventType = "sell_vested_equity"
 )
 
 // Event represents a financial event that occurs during the projection
@@ -46,6 +47,11 @@ type EventParameters struct {
 	NewExpenseGrowth  float64 `json:"new_expense_growth,omitempty"`
 	NewSavingsRate    float64 `json:"new_savings_rate,omitempty"`
 	Reason            string  `json:"reason,omitempty"`
+
+	// Equity sales; AccountID is the equity account sold from
+	TargetAccountID string   `json:"target_account_id,omitempty"` // Receives the proceeds; counted as income when empty
+	SellPortion     float64  `json:"sell_portion,omitempty"`      // Share of the vested equity sold, 0 to 1; all of it when 0
+	MarginalRate    *float64 `json:"marginal_rate,omitempty"`     // Defaults to the equity module's rate
 }
 
 // ProjectionState holds the current state of projection parameters that can be modified by events
@@ -0,0 +1,54 @@
+package projections
+
+import (
+	"math"
+	"time"
+
+	"money/internal/account"
+)
+
+// RateShock shifts interest rates from a date onward to stress-test a projection.
+// Shocks are cumulative: a later shock adds to every shock before it.
+type RateShock struct {
+	Date         time.Time `json:"date"`          // Month the shift takes effect; zero applies from the start
+	BorrowingBps float64   `json:"borrowing_bps"` // Shift to variable mortgage, loan and HELOC rates, e.g. 200 for +2%
+	ReturnBps    float64   `json:"return_bps"`    // Shift to expected investment returns, e.g. -150 for -1.5%
+}
+
+// rateShiftsAt returns the total borrowing and return shifts, as decimals, in effect in
+// the month of date
+func rateShiftsAt(shocks []RateShock, date time.Time) (borrowing, returns float64) {
+	for _, shock := range shocks {
+		if shock.Date.After(date) && !isSameMonth(shock.Date, date) {
+			continue
+		}
+		borrowing += shock.BorrowingBps / 10000
+		returns += shock.ReturnBps / 10000
+	}
+	return borrowing, returns
+}
+
+// shockedRate applies a borrowing shift to a variable rate. Fixed rates are locked in for
+// the projection, and a shifted rate never goes below zero.
+func shockedRate(rate float64, rateType string, shift float64) float64 {
+	if rateType != "variable" {
+		return rate
+	}
+	return math.Max(0, rate+shift)
+}
+
+// usesInvestmentReturn reports whether an account grows at an expected investment return,
+// following the same precedence as accountGrowthRate
+func usesInvestmentReturn(acc *AccountData, config *Config) bool {
+	if acc.ExpectedReturn != nil {
+		return true
+	}
+	if acc.ExpectedAppreciation != nil {
+		return false
+	}
+	if acc.Type == string(account.AccountTypeStockOptions) && config.Equity != nil && config.Equity.FMVGrowth != nil {
+		return false
+	}
+	_, ok := config.InvestmentReturns[acc.Type]
+	return ok
+}
@@ -0,0 +1,175 @@
+package projections
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"money/internal/auth"
+)
+
+// ScenarioResult is the compact outcome of running one saved scenario
+type ScenarioResult struct {
+	ScenarioID       string     `json:"scenario_id"`
+	Name             string     `json:"name"`
+	IsDefault        bool       `json:"is_default"`
+	TimeHorizonYears int        `json:"time_horizon_years"`
+	StartNetWorth    float64    `json:"start_net_worth"`
+	EndNetWorth      float64    `json:"end_net_worth"`
+	NetWorthChange   float64    `json:"net_worth_change"`
+	EndAssets        float64    `json:"end_assets"`
+	EndLiabilities   float64    `json:"end_liabilities"`
+	DebtFreeDate     *time.Time `json:"debt_free_date,omitempty"` // Nil when debt-free already or never within the horizon
+	Error            *string    `json:"error,omitempty"`          // Set when the scenario failed to run
+	RunAt            time.Time  `json:"run_at"`
+}
+
+// ScenarioComparison lists the latest results of a user's saved scenarios side by side
+type ScenarioComparison struct {
+	Results        []*ScenarioResult `json:"results"`
+	BestScenarioID *string           `json:"best_scenario_id,omitempty"` // Highest ending net worth
+}
+
+// RunAllScenarios projects every saved scenario of the user, stores each result as the
+// scenario's latest and returns them compared. Meant to be triggered on a schedule with a
+// service API key so saved projections stay fresh without calculating them on demand. A
+// scenario that fails to run keeps its error in place of figures and doesn't stop the rest.
+func (s *Service) RunAllScenarios(ctx context.Context) (*ScenarioComparison, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	scenarios, err := s.ListScenarios(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	runAt := time.Now()
+	results := make([]*ScenarioResult, 0, len(scenarios.Scenarios))
+	for _, scenario := range scenarios.Scenarios {
+		result := &ScenarioResult{
+			ScenarioID: scenario.ID,
+			Name:       scenario.Name,
+			IsDefault:  scenario.IsDefault,
+			RunAt:      runAt,
+		}
+
+		if scenario.Config == nil {
+			message := "scenario has no config"
+			result.Error = &message
+		} else {
+			result.TimeHorizonYears = scenario.Config.TimeHorizonYears
+			projection, err := s.CalculateProjection(ctx, &ProjectionRequest{Config: scenario.Config})
+			if err != nil {
+				projectionsLog.Warn("Scenario run failed", "scenario_id", scenario.ID, "error", err)
+				message := err.Error()
+				result.Error = &message
+			} else {
+				summarizeProjection(projection, result)
+			}
+		}
+
+		_, err := s.accountDB.ExecContext(ctx, `
+			INSERT INTO projection_scenario_results (scenario_id, user_id, time_horizon_years, start_net_worth,
+				end_net_worth, end_assets, end_liabilities, debt_free_date, error, run_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (scenario_id) DO UPDATE SET
+				time_horizon_years = excluded.time_horizon_years,
+				start_net_worth = excluded.start_net_worth,
+				end_net_worth = excluded.end_net_worth,
+				end_assets = excluded.end_assets,
+				end_liabilities = excluded.end_liabilities,
+				debt_free_date = excluded.debt_free_date,
+				error = excluded.error,
+				run_at = excluded.run_at
+		`, result.ScenarioID, userID, result.TimeHorizonYears, result.StartNetWorth, result.EndNetWorth,
+			result.EndAssets, result.EndLiabilities, result.DebtFreeDate, result.Error, result.RunAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save scenario result: %w", err)
+		}
+
+		results = append(results, result)
+	}
+
+	return compareScenarios(results), nil
+}
+
+// GetScenarioComparison returns the latest stored result of each saved scenario, without
+// running any projections. Scenarios never run are left out.
+func (s *Service) GetScenarioComparison(ctx context.Context) (*ScenarioComparison, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.accountDB.QueryContext(ctx, `
+		SELECT r.scenario_id, p.name, p.is_default, r.time_horizon_years, r.start_net_worth, r.end_net_worth,
+			r.end_assets, r.end_liabilities, r.debt_free_date, r.error, r.run_at
+		FROM projection_scenario_results r
+		JOIN projection_scenarios p ON p.id = r.scenario_id
+		WHERE r.user_id = $1
+		ORDER BY p.is_default DESC, p.updated_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scenario results: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]*ScenarioResult, 0)
+	for rows.Next() {
+		var r ScenarioResult
+		if err := rows.Scan(&r.ScenarioID, &r.Name, &r.IsDefault, &r.TimeHorizonYears, &r.StartNetWorth, &r.EndNetWorth,
+			&r.EndAssets, &r.EndLiabilities, &r.DebtFreeDate, &r.Error, &r.RunAt); err != nil {
+			return nil, fmt.Errorf("failed to scan scenario result: %w", err)
+		}
+		r.NetWorthChange = r.EndNetWorth - r.StartNetWorth
+		results = append(results, &r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return compareScenarios(results), nil
+}
+
+// summarizeProjection reduces a projection to its starting and ending figures
+func summarizeProjection(projection *ProjectionResponse, result *ScenarioResult) {
+	if n := len(projection.NetWorth); n > 0 {
+		result.StartNetWorth = projection.NetWorth[0].Value
+		result.EndNetWorth = projection.NetWorth[n-1].Value
+		result.NetWorthChange = result.EndNetWorth - result.StartNetWorth
+	}
+	if n := len(projection.Assets); n > 0 {
+		result.EndAssets = projection.Assets[n-1].Value
+	}
+	if n := len(projection.Liabilities); n > 0 {
+		result.EndLiabilities = projection.Liabilities[n-1].Value
+	}
+
+	if len(projection.DebtPayoff) == 0 || projection.DebtPayoff[0].TotalDebt <= 0.005 {
+		return
+	}
+	for _, point := range projection.DebtPayoff {
+		if point.TotalDebt <= 0.005 {
+			date := point.Date
+			result.DebtFreeDate = &date
+			return
+		}
+	}
+}
+
+// compareScenarios picks the scenario ending with the highest net worth
+func compareScenarios(results []*ScenarioResult) *ScenarioComparison {
+	comparison := &ScenarioComparison{Results: results}
+	var best *ScenarioResult
+	for _, result := range results {
+		if result.Error == nil && (best == nil || result.EndNetWorth > best.EndNetWorth) {
+			best = result
+		}
+	}
+	if best != nil {
+		comparison.BestScenarioID = &best.ScenarioID
+	}
+	return comparison
+}
@@ -485,3 +485,98 @@ func TestConfig_MarshalUnmarshal(t *testing.T) {
 		t.Errorf("Expected %d events, got %d", len(config.Events), len(decoded.Events))
 	}
 }
+
+func TestRunAllScenarios_StoresComparison(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange: the same savings under a cautious and an optimistic scenario
+	userID := "test-user-run-scenarios-1"
+	account.CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupProjectionService(t, db)
+	CreateTestAccountForProjection(t, db, userID, account.AccountTypeSavings, 50000)
+
+	cautious := DefaultTestConfig()
+	cautious.InvestmentReturns = map[string]float64{"savings": 0.01}
+	optimistic := DefaultTestConfig()
+	optimistic.InvestmentReturns = map[string]float64{"savings": 0.08}
+	service.CreateScenario(ctx, &CreateScenarioRequest{Name: "Cautious", Config: cautious, IsDefault: true})
+	best, _ := service.CreateScenario(ctx, &CreateScenarioRequest{Name: "Optimistic", Config: optimistic})
+
+	// Act
+	run, err := service.RunAllScenarios(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("RunAllScenarios failed: %v", err)
+	}
+	if len(run.Results) != 2 || run.BestScenarioID == nil || *run.BestScenarioID != best.ID {
+		t.Fatalf("Expected 2 results with the optimistic scenario best, got %+v", run)
+	}
+	for _, result := range run.Results {
+		if result.Error != nil || result.StartNetWorth < 50000 || result.NetWorthChange <= 0 {
+			t.Errorf("Expected net worth to grow from 50000, got %+v", result)
+		}
+	}
+
+	stored, err := service.GetScenarioComparison(ctx)
+	if err != nil {
+		t.Fatalf("GetScenarioComparison failed: %v", err)
+	}
+	if len(stored.Results) != 2 || stored.Results[0].Name != "Cautious" || *stored.BestScenarioID != best.ID {
+		t.Errorf("Expected the stored results with the default first, got %+v", stored.Results)
+	}
+	if stored.Results[1].EndNetWorth != run.Results[1].EndNetWorth || stored.Results[1].NetWorthChange != run.Results[1].NetWorthChange {
+		t.Errorf("Expected stored figures to match the run, got %+v", stored.Results[1])
+	}
+}
+
+func TestSuggestAssumptions_BlendsHistoryWithinPresets(t *testing.T) {
+	db := SetupTestDB(t)
+	defer CleanupTestDB(t, db)
+
+	// Arrange: a TFSA that doubled over three years, well above any preset
+	userID := "test-user-assumptions-1"
+	account.CreateTestUser(t, db, userID)
+	ctx := CreateAuthContext(userID)
+	service := SetupProjectionService(t, db)
+
+	tfsaID := CreateTestAccountForProjection(t, db, userID, account.AccountTypeTFSA, 20000)
+	if _, err := db.Exec(`
+		INSERT INTO balances (id, account_id, amount, date, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, "test-balance-assumptions-old", tfsaID, 10000, time.Now().AddDate(-3, 0, 0), time.Now()); err != nil {
+		t.Fatalf("Failed to create old balance: %v", err)
+	}
+
+	// Act
+	suggestion, err := service.SuggestAssumptions(ctx, &AssumptionsRequest{Preset: "Balanced"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("SuggestAssumptions failed: %v", err)
+	}
+	if suggestion.Preset != PresetBalanced {
+		t.Errorf("Expected balanced preset, got %s", suggestion.Preset)
+	}
+	if len(suggestion.History) != 1 || suggestion.History[0].AccountType != "tfsa" {
+		t.Fatalf("Expected TFSA history, got %+v", suggestion.History)
+	}
+	if suggestion.History[0].Rate < 0.25 || suggestion.History[0].Weight != 0.3 {
+		t.Errorf("Expected ~26%% growth at 0.3 weight, got %+v", suggestion.History[0])
+	}
+	if suggestion.InvestmentReturns["tfsa"] != 0.08 {
+		t.Errorf("Expected TFSA return capped at the aggressive 0.08, got %v", suggestion.InvestmentReturns["tfsa"])
+	}
+	if suggestion.InvestmentReturns["brokerage"] != 0.06 {
+		t.Errorf("Expected brokerage to keep the balanced 0.06, got %v", suggestion.InvestmentReturns["brokerage"])
+	}
+	if suggestion.InflationRate != 0.025 || suggestion.InflationSource != "preset" {
+		t.Errorf("Expected preset inflation, got %v from %s", suggestion.InflationRate, suggestion.InflationSource)
+	}
+
+	if _, err := service.SuggestAssumptions(ctx, &AssumptionsRequest{Preset: "reckless"}); err == nil {
+		t.Error("Expected an unknown preset to be rejected")
+	}
+}
@@ -0,0 +1,222 @@
+package projections
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Perturbations applied by the sensitivity analysis, one input at a time
+const (
+	sensitivityReturnBps   = 100 // ±1% on every expected investment return
+	sensitivityExpenses    = 500 // ±$500 a month of expenses
+	sensitivityRetireYears = 1   // ±1 year on the retirement date
+)
+
+// retirementEventID identifies the salary change retireAt adds
+const retirementEventID = "sensitivity_retirement"
+
+// DefaultWithdrawalRate is the safe withdrawal rate the FIRE target is based on
+const DefaultWithdrawalRate = 0.04
+
+// SensitivityRequest asks how much each projection assumption moves the outcome
+type SensitivityRequest struct {
+	Config         *Config    `json:"config,omitempty"`          // Defaults to the default scenario's config
+	RetirementDate *time.Time `json:"retirement_date,omitempty"` // Salary stops here; nil leaves retirement out of the analysis
+	WithdrawalRate float64    `json:"withdrawal_rate,omitempty"` // Defaults to DefaultWithdrawalRate
+}
+
+// SensitivityOutcome is where a projection ends up and when it reaches financial independence
+type SensitivityOutcome struct {
+	EndNetWorth float64    `json:"end_net_worth"`
+	FIREDate    *time.Time `json:"fire_date,omitempty"` // Nil when the FIRE target isn't reached within the horizon
+
+	// Differences from the baseline, set on perturbed outcomes
+	NetWorthChange  float64 `json:"net_worth_change"`
+	FIREMonthsShift *int    `json:"fire_months_shift,omitempty"` // Positive is later; nil when either date is missing
+}
+
+// SensitivityFactor is the effect of moving one input down and up
+type SensitivityFactor struct {
+	Input    string             `json:"input"`
+	Label    string             `json:"label"`
+	Decrease SensitivityOutcome `json:"decrease"`
+	Increase SensitivityOutcome `json:"increase"`
+	Swing    float64            `json:"swing"` // Spread of end net worth between the two
+}
+
+// SensitivityResponse ranks the inputs by how much they move end net worth, largest
+// first, ready to draw as a tornado chart
+type SensitivityResponse struct {
+	Baseline       SensitivityOutcome  `json:"baseline"`
+	WithdrawalRate float64             `json:"withdrawal_rate"`
+	Factors        []SensitivityFactor `json:"factors"`
+}
+
+// sensitivityInput perturbs a copy of the config in one direction, sign being -1 or 1
+type sensitivityInput struct {
+	input   string
+	label   string
+	perturb func(config *Config, sign float64)
+}
+
+// defaultConfig returns config, or the default scenario's config when it's nil
+func (s *Service) defaultConfig(ctx context.Context, config *Config) (*Config, error) {
+	if config != nil {
+		return config, nil
+	}
+	scenarios, err := s.ListScenarios(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(scenarios.Scenarios) == 0 || !scenarios.Scenarios[0].IsDefault {
+		return nil, fmt.Errorf("config is required when there is no default scenario")
+	}
+	return scenarios.Scenarios[0].Config, nil
+}
+
+// AnalyzeSensitivity projects the user's finances once as configured and then with each
+// assumption moved down and up on its own: investment returns by 1%, monthly expenses by
+// $500 and, when a retirement date is given, retirement by a year. Each run reports the
+// change in final net worth and in the date net worth first covers a year of expenses at
+// the withdrawal rate.
+func (s *Service) AnalyzeSensitivity(ctx context.Context, req *SensitivityRequest) (*SensitivityResponse, error) {
+	config, err := s.defaultConfig(ctx, req.Config)
+	if err != nil {
+		return nil, err
+	}
+	withdrawalRate := req.WithdrawalRate
+	if withdrawalRate == 0 {
+		withdrawalRate = DefaultWithdrawalRate
+	}
+	if withdrawalRate < 0 || withdrawalRate > 1 {
+		return nil, fmt.Errorf("withdrawal_rate must be between 0 and 1")
+	}
+	if req.RetirementDate != nil && req.RetirementDate.IsZero() {
+		req.RetirementDate = nil
+	}
+
+	inputs := []sensitivityInput{
+		{
+			input: "investment_returns",
+			label: fmt.Sprintf("Investment returns ±%d%%", sensitivityReturnBps/100),
+			perturb: func(config *Config, sign float64) {
+				config.RateShocks = append(config.RateShocks, RateShock{ReturnBps: sign * sensitivityReturnBps})
+			},
+		},
+		{
+			input: "monthly_expenses",
+			label: fmt.Sprintf("Monthly expenses ±$%d", sensitivityExpenses),
+			perturb: func(config *Config, sign float64) {
+				config.MonthlyExpenses = math.Max(0, config.MonthlyExpenses+sign*sensitivityExpenses)
+			},
+		},
+	}
+	if req.RetirementDate != nil {
+		inputs = append(inputs, sensitivityInput{
+			input: "retirement_date",
+			label: fmt.Sprintf("Retirement ±%d year", sensitivityRetireYears),
+			perturb: func(config *Config, sign float64) {
+				retireAt(config, req.RetirementDate.AddDate(int(sign)*sensitivityRetireYears, 0, 0))
+			},
+		})
+	}
+
+	run := func(perturb func(config *Config)) (SensitivityOutcome, error) {
+		copied, err := copyConfig(config)
+		if err != nil {
+			return SensitivityOutcome{}, err
+		}
+		if req.RetirementDate != nil {
+			retireAt(copied, *req.RetirementDate)
+		}
+		if perturb != nil {
+			perturb(copied)
+		}
+		projection, err := s.CalculateProjection(ctx, &ProjectionRequest{Config: copied})
+		if err != nil {
+			return SensitivityOutcome{}, err
+		}
+		return projectionOutcome(projection, withdrawalRate), nil
+	}
+
+	baseline, err := run(nil)
+	if err != nil {
+		return nil, err
+	}
+	resp := &SensitivityResponse{Baseline: baseline, WithdrawalRate: withdrawalRate, Factors: make([]SensitivityFactor, 0, len(inputs))}
+	for _, in := range inputs {
+		factor := SensitivityFactor{Input: in.input, Label: in.label}
+		for _, sign := range []float64{-1, 1} {
+			outcome, err := run(func(config *Config) { in.perturb(config, sign) })
+			if err != nil {
+				return nil, err
+			}
+			outcome.NetWorthChange = roundCents(outcome.EndNetWorth - baseline.EndNetWorth)
+			outcome.FIREMonthsShift = monthsBetween(baseline.FIREDate, outcome.FIREDate)
+			if sign < 0 {
+				factor.Decrease = outcome
+			} else {
+				factor.Increase = outcome
+			}
+		}
+		factor.Swing = roundCents(math.Abs(factor.Increase.EndNetWorth - factor.Decrease.EndNetWorth))
+		resp.Factors = append(resp.Factors, factor)
+	}
+
+	sort.SliceStable(resp.Factors, func(i, j int) bool { return resp.Factors[i].Swing > resp.Factors[j].Swing })
+	return resp, nil
+}
+
+// retireAt stops the salary from the month of date, replacing any retirement set before.
+// Salary changes the config already schedules after retirement are dropped so they can't
+// restart it.
+func retireAt(config *Config, date time.Time) {
+	events := config.Events[:0]
+	for _, event := range config.Events {
+		if event.ID == retirementEventID || (event.Type == EventSalaryChange && !event.Date.Before(date)) {
+			continue
+		}
+		events = append(events, event)
+	}
+	config.Events = append(events, Event{
+		ID:          retirementEventID,
+		Type:        EventSalaryChange,
+		Date:        date,
+		Description: "Retirement",
+		Parameters:  EventParameters{NewSalary: 0, Reason: "retirement"},
+	})
+}
+
+// projectionOutcome reads the final net worth of a projection and the first month net worth
+// covers that month's yearly expenses at the withdrawal rate
+func projectionOutcome(projection *ProjectionResponse, withdrawalRate float64) SensitivityOutcome {
+	var outcome SensitivityOutcome
+	if n := len(projection.NetWorth); n > 0 {
+		outcome.EndNetWorth = roundCents(projection.NetWorth[n-1].Value)
+	}
+	for i, point := range projection.CashFlow {
+		if i >= len(projection.NetWorth) {
+			break
+		}
+		target := point.Expenses * 12 / withdrawalRate
+		if target > 0 && projection.NetWorth[i].Value >= target {
+			date := projection.NetWorth[i].Date
+			outcome.FIREDate = &date
+			break
+		}
+	}
+	return outcome
+}
+
+// monthsBetween is the number of calendar months from one date to another, nil when either
+// is missing
+func monthsBetween(from, to *time.Time) *int {
+	if from == nil || to == nil {
+		return nil
+	}
+	months := (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+	return &months
+}
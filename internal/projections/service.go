@@ -11,15 +11,27 @@ import (
 	"github.com/google/uuid"
 	"money/internal/account"
 	"money/internal/auth"
+	"money/internal/civil"
+	"money/internal/database"
+	"money/internal/holdings"
+	"money/internal/income"
+	"money/internal/inflation"
+	"money/internal/logger"
 	"money/internal/transaction"
 )
 
+// projectionsLog logs for the projections module
+var projectionsLog = logger.Module("projections")
+
 // Service implements financial projection and forecasting functionality
 type Service struct {
 	accountDB       *sql.DB
 	transactionDB   *sql.DB
 	accountSvc      *account.Service
 	transactionSvc  *transaction.Service
+	holdingsSvc     *holdings.Service
+	inflationSvc    *inflation.Service
+	incomeSvc       *income.Service
 }
 
 // NewService creates a new projections service
@@ -28,12 +40,18 @@ func NewService(
 	transactionDB *sql.DB,
 	accountSvc *account.Service,
 	transactionSvc *transaction.Service,
+	holdingsSvc *holdings.Service,
+	inflationSvc *inflation.Service,
+	incomeSvc *income.Service,
 ) *Service {
 	return &Service{
 		accountDB:      accountDB,
 		transactionDB:  transactionDB,
 		accountSvc:     accountSvc,
 		transactionSvc: transactionSvc,
+		holdingsSvc:    holdingsSvc,
+		inflationSvc:   inflationSvc,
+		incomeSvc:      incomeSvc,
 	}
 }
 
@@ -58,12 +76,16 @@ type Config struct {
 	ProvincialTaxBrackets []TaxBracket       `json:"provincial_tax_brackets"` // Provincial/state progressive tax brackets
 	MonthlyExpenses       float64            `json:"monthly_expenses"`        // Base monthly expenses
 	AnnualExpenseGrowth   float64            `json:"annual_expense_growth"`   // e.g., 0.02 for 2%
+	CPIRegion             string             `json:"cpi_region,omitempty"`    // CA or US: grow expenses at that region's trailing CPI inflation instead
 	MonthlySavingsRate    float64            `json:"monthly_savings_rate"`    // % of net income to save (e.g., 0.2 for 20%)
 	InvestmentReturns     map[string]float64 `json:"investment_returns"`      // Expected annual returns by account type
 	ExtraDebtPayments     map[string]float64 `json:"extra_debt_payments"`     // Extra monthly principal by account ID
 	AssetAppreciation     map[string]float64 `json:"asset_appreciation"`      // Annual appreciation rate by account type
 	SavingsAllocation     map[string]float64 `json:"savings_allocation"`      // How to allocate monthly savings by account type
 	Events                []Event            `json:"events"`                  // Timeline events
+	Equity                *EquityConfig      `json:"equity,omitempty"`        // Equity compensation; nil leaves equity accounts at their balance
+	RateShocks            []RateShock        `json:"rate_shocks,omitempty"`   // Interest rate shifts to stress-test against
+	Backtest              *BacktestConfig    `json:"backtest,omitempty"`      // Replays historical returns instead of the expected ones
 }
 
 // TaxBracket represents a progressive tax bracket
@@ -79,12 +101,16 @@ type ProjectionRequest struct {
 
 // ProjectionResponse represents the calculated projection data
 type ProjectionResponse struct {
-	NetWorth       []DataPoint            `json:"net_worth"`
-	Assets         []DataPoint            `json:"assets"`
-	Liabilities    []DataPoint            `json:"liabilities"`
-	CashFlow       []CashFlowPoint        `json:"cash_flow"`
-	AssetBreakdown []AssetBreakdownPoint  `json:"asset_breakdown"`
-	DebtPayoff     []DebtPayoffPoint      `json:"debt_payoff"`
+	NetWorth        []DataPoint            `json:"net_worth"`
+	Assets          []DataPoint            `json:"assets"`
+	Liabilities     []DataPoint            `json:"liabilities"`
+	CashFlow        []CashFlowPoint        `json:"cash_flow"`
+	AssetBreakdown  []AssetBreakdownPoint  `json:"asset_breakdown"`
+	DebtPayoff      []DebtPayoffPoint      `json:"debt_payoff"`
+	LoanForgiveness []LoanForgivenessPoint `json:"loan_forgiveness,omitempty"`
+	UnvestedMatch   []DataPoint            `json:"unvested_employer_match,omitempty"` // Employer money that would be forfeited on leaving
+	Backtest        *BacktestSummary       `json:"backtest,omitempty"`                // History replayed, when backtesting
+	ExpenseGrowth   *inflation.Rate        `json:"expense_growth_cpi,omitempty"`      // CPI inflation expenses grew at, when cpi_region is set
 }
 
 // DataPoint represents a single point in time for a metric
@@ -95,10 +121,20 @@ type DataPoint struct {
 
 // CashFlowPoint represents income and expenses at a point in time
 type CashFlowPoint struct {
-	Date     time.Time `json:"date"`
-	Income   float64   `json:"income"`
-	Expenses float64   `json:"expenses"`
-	Net      float64   `json:"net"`
+	Date          time.Time `json:"date"`
+	Income        float64   `json:"income"`
+	Expenses      float64   `json:"expenses"`
+	Net           float64   `json:"net"`
+	CreditDrawn   float64   `json:"credit_drawn,omitempty"`          // Shortfall covered by HELOC draws, not counted as income
+	EquityVested  float64   `json:"equity_vested,omitempty"`         // Value of equity vesting this month, included in income when sold on vest
+	Payroll       float64   `json:"payroll_contributions,omitempty"` // Employee contributions to matched plans, deducted from income
+	EmployerMatch float64   `json:"employer_match,omitempty"`        // Employer contributions to matched plans
+	RateShockCost float64   `json:"rate_shock_cost,omitempty"`       // Extra interest on variable-rate debt from rate shocks, included in expenses
+	Swept         float64   `json:"swept,omitempty"`                 // Cash moved into investment accounts by sweep rules
+	Bonus         float64   `json:"bonus,omitempty"`                 // Expected bonus after tax, included in income
+	DPSP          float64   `json:"dpsp_contributions,omitempty"`    // Expected profit sharing paid into DPSP accounts
+	EquitySold    float64   `json:"equity_sold,omitempty"`           // After-tax proceeds of planned equity sales
+	EquitySaleTax float64   `json:"equity_sale_tax,omitempty"`       // Estimated tax on planned equity sales
 }
 
 // AssetBreakdownPoint represents asset composition at a point in time
@@ -114,6 +150,14 @@ type DebtPayoffPoint struct {
 	TotalDebt float64            `json:"total_debt"`
 }
 
+// LoanForgivenessPoint records a student loan balance forgiven under its repayment program
+type LoanForgivenessPoint struct {
+	Date      time.Time `json:"date"`
+	AccountID string    `json:"account_id"`
+	Amount    float64   `json:"amount"`
+	Taxable   bool      `json:"taxable"`
+}
+
 // CreateScenarioRequest represents a request to create a projection scenario
 type CreateScenarioRequest struct {
 	Name      string  `json:"name"`
@@ -140,17 +184,21 @@ type DeleteScenarioResponse struct {
 
 // Helper types for querying account data
 type AccountData struct {
-	ID       string
-	Type     string
-	IsAsset  bool
-	Balance  float64
-	Currency string
+	ID                   string
+	Type                 string
+	IsAsset              bool
+	Balance              float64
+	Currency             string
+	ExpectedReturn       *float64                      // Per-account override of InvestmentReturns
+	ExpectedAppreciation *float64                      // Per-account override of AssetAppreciation
+	EmployerMatch        *account.EmployerMatchDetails // Employer matching on retirement accounts
 }
 
 type MortgageData struct {
 	AccountID        string
 	CurrentBalance   float64
 	InterestRate     float64
+	RateType         string // fixed, variable
 	PaymentAmount    float64
 	PaymentFrequency string
 }
@@ -159,8 +207,21 @@ type LoanData struct {
 	AccountID        string
 	CurrentBalance   float64
 	InterestRate     float64
+	RateType         string // fixed, variable
 	PaymentAmount    float64
 	PaymentFrequency string
+	LoanType         string
+	Student          *account.StudentLoanDetails // Grace, subsidy and repayment program terms of student loans
+}
+
+type HELOCData struct {
+	AccountID         string
+	PropertyAccountID *string
+	MortgageAccountID *string
+	MaxLTV            float64
+	CreditLimit       *float64
+	InterestRate      float64
+	CurrentBalance    float64 // Amount owed
 }
 
 type RecurringExpense struct {
@@ -172,6 +233,10 @@ type RecurringExpense struct {
 
 // CalculateProjection calculates financial projections based on configuration
 func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionRequest) (*ProjectionResponse, error) {
+	// Bound the whole calculation so a slow projection can't tie up the pool
+	ctx, cancel := database.WithOperationTimeout(ctx)
+	defer cancel()
+
 	config := req.Config
 
 	// Calculate total monthly expenses from all sources
@@ -180,7 +245,7 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 	// Get recurring expenses
 	recurringTotal, err := s.getRecurringExpensesTotal(ctx)
 	if err != nil {
-		fmt.Printf("Warning: Failed to get recurring expenses: %v\n", err)
+		projectionsLog.Warn("Failed to get recurring expenses", "error", err)
 		recurringTotal = 0 // Continue without recurring expenses
 	}
 
@@ -191,12 +256,30 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 	totalMonthlyExpenses := additionalExpenses + recurringTotal
 	config.MonthlyExpenses = totalMonthlyExpenses
 
+	// Projections run from today on the user's calendar, so month boundaries line up
+	// with event dates whatever timezone the server runs in
+	today := civil.TodayIn(ctx).Time
+
 	// Calculate projection end date
-	endDate := time.Now().AddDate(config.TimeHorizonYears, 0, 0)
+	endDate := today.AddDate(config.TimeHorizonYears, 0, 0)
 
 	// Expand recurring events into individual occurrences
 	config.Events = expandRecurringEvents(config.Events, endDate)
 
+	// Scheduled transactions are one-time income or expenses on their dates
+	scheduledEvents, err := s.getScheduledTransactionEvents(ctx)
+	if err != nil {
+		projectionsLog.Warn("Failed to get scheduled transactions", "error", err)
+	}
+	config.Events = append(config.Events, scheduledEvents...)
+
+	// Outstanding planned project expenses are still to be paid
+	projectEvents, err := s.getProjectEvents(ctx, today)
+	if err != nil {
+		projectionsLog.Warn("Failed to get planned project expenses", "error", err)
+	}
+	config.Events = append(config.Events, projectEvents...)
+
 	// Sort events by date
 	sortEvents(config.Events)
 
@@ -217,6 +300,57 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 		return nil, err
 	}
 
+	helocs, err := s.getHELOCDetails(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sweep rules move month-end cash surpluses into investment accounts
+	sweepRules, err := s.getSweepRules(ctx)
+	if err != nil {
+		projectionsLog.Warn("Failed to get sweep rules", "error", err)
+	}
+
+	// Bonus plans pay their expected share of the target once a year
+	bonusPlans, err := s.getBonusPlans(ctx)
+	if err != nil {
+		projectionsLog.Warn("Failed to get bonus plans", "error", err)
+	}
+
+	var vests []equityVest
+	if config.Equity != nil {
+		vests, err = s.prepareEquity(ctx, accounts, config.Equity, today)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Planned sales of vested equity pay their after-tax proceeds into a target account
+	equitySaleRates := s.equitySaleTaxRates(ctx, config.Events, accounts)
+
+	// Trailing CPI inflation replaces the configured expense growth when available
+	var expenseGrowth *inflation.Rate
+	if config.CPIRegion != "" {
+		region, err := s.inflationSvc.ParseRegion(config.CPIRegion)
+		if err != nil {
+			return nil, err
+		}
+		expenseGrowth, err = s.inflationSvc.TrailingInflation(ctx, region)
+		if err != nil {
+			projectionsLog.Warn("Trailing inflation unavailable, using configured expense growth", "region", region, "error", err)
+		} else {
+			config.AnnualExpenseGrowth = expenseGrowth.Rate
+		}
+	}
+
+	var backtest *backtestPlan
+	if config.Backtest != nil {
+		backtest, err = s.prepareBacktest(ctx, config.Backtest, config.TimeHorizonYears)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Initialize response
 	response := &ProjectionResponse{
 		NetWorth:       make([]DataPoint, 0),
@@ -226,9 +360,13 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 		AssetBreakdown: make([]AssetBreakdownPoint, 0),
 		DebtPayoff:     make([]DebtPayoffPoint, 0),
 	}
+	if backtest != nil {
+		response.Backtest = backtest.summary
+	}
+	response.ExpenseGrowth = expenseGrowth
 
 	// Calculate projections month by month
-	startDate := time.Now()
+	startDate := today
 	totalMonths := config.TimeHorizonYears * 12
 
 	// Initialize projection state
@@ -249,10 +387,37 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 		debtBalances[l.AccountID] = l.CurrentBalance
 	}
 
+	// Qualifying payments made toward student loan forgiveness
+	qualifyingPayments := make(map[string]int)
+	for _, l := range loans {
+		if l.Student != nil {
+			qualifyingPayments[l.AccountID] = l.Student.QualifyingPayments
+		}
+	}
+
+	// HELOCs are tracked separately: they are a source of liquidity, not scheduled debt
+	helocBalances := make(map[string]float64)
+	for _, h := range helocs {
+		helocBalances[h.AccountID] = h.CurrentBalance
+	}
+
+	// Employer money contributed to matched plans, for tracking what is still unvested
+	employerContributed := make(map[string]float64)
+	for _, acc := range accounts {
+		if acc.EmployerMatch != nil {
+			employerContributed[acc.ID] = acc.EmployerMatch.EmployerContributions
+		}
+	}
+
 	// Note: Other liability accounts (credit cards, lines of credit, etc.)
 	// are tracked in accountBalances and will be included as static liabilities
 
 	for month := 0; month <= totalMonths; month++ {
+		// Stop once the caller gives up, such as when the client disconnects
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		currentDate := startDate.AddDate(0, month, 0)
 		yearsElapsed := float64(month) / 12.0
 
@@ -262,11 +427,25 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 		eventExpense := 0.0
 		if len(monthEvents) > 0 {
 		}
+		equitySold, equitySaleTax := 0.0, 0.0
 		for _, event := range monthEvents {
+			if event.Type == EventSellVestedEquity {
+				taxRate, ok := equitySaleRates[event.ID]
+				if !ok {
+					continue // Logged when the sale was estimated
+				}
+				proceeds, tax, deposited := sellVestedEquity(event, taxRate, accountBalances)
+				equitySold += proceeds
+				equitySaleTax += tax
+				if !deposited {
+					eventIncome += proceeds
+				}
+				continue
+			}
 			income, expense, err := applyEvent(event, state, currentDate, debtBalances, mortgages, loans)
 			if err != nil {
 				// Log error but continue processing
-				fmt.Printf("Error applying event %s: %v\n", event.ID, err)
+				projectionsLog.Error("Failed to apply event", "event_id", event.ID, "error", err)
 			}
 			eventIncome += income
 			eventExpense += expense
@@ -284,15 +463,61 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 		annualNetSalary := annualGrossSalary - annualTax
 		monthlyNetIncome := annualNetSalary / 12.0
 
+		// Employee contributions to matched plans come out of the paycheque, and the
+		// employer match is paid into the account on top
+		payrollContributions := 0.0
+		employerMatch := 0.0
+		for _, acc := range accounts {
+			if acc.EmployerMatch == nil {
+				continue
+			}
+			employee, employer := acc.EmployerMatch.MonthlyContributions(annualGrossSalary)
+			accountBalances[acc.ID] += employee + employer
+			employerContributed[acc.ID] += employer
+			payrollContributions += employee
+			employerMatch += employer
+		}
+		monthlyNetIncome -= payrollContributions
+
+		// Expected bonuses are taxed at the marginal rate on top of salary; DPSP
+		// contributions go straight into their account untaxed
+		bonus, dpsp := 0.0, 0.0
+		for _, plan := range findBonusPlansForMonth(bonusPlans, currentDate) {
+			expected := plan.ExpectedPayout(annualGrossSalary)
+			if plan.PlanType == income.BonusPlanDPSP {
+				if plan.AccountID != nil {
+					if _, ok := accountBalances[*plan.AccountID]; ok {
+						accountBalances[*plan.AccountID] += expected
+						dpsp += expected
+					}
+				}
+				continue
+			}
+			bonus += expected
+		}
+		if bonus > 0 {
+			withBonus := annualGrossSalary + bonus
+			bonusTax := s.calculateTax(withBonus, config.FederalTaxBrackets) + s.calculateTax(withBonus, config.ProvincialTaxBrackets) - annualTax
+			bonus -= bonusTax
+			eventIncome += bonus
+		}
+
 		// Calculate expenses for this month (using state which may have been updated by events)
 		expenses := state.MonthlyExpenses * math.Pow(1+state.AnnualExpenseGrowth, yearsElapsed)
 
+		// Rate shocks raise the payments on variable-rate debt by the extra interest, so
+		// the balance pays down on schedule and the shock shows up as a cost
+		borrowingShift, returnShift := rateShiftsAt(config.RateShocks, currentDate)
+		rateShockCost := 0.0
+
 		// Calculate total monthly debt payments (mortgages + loans)
 		totalDebtPayments := 0.0
 		for _, m := range mortgages {
 			if balance, exists := debtBalances[m.AccountID]; exists && balance > 0 {
 				monthlyPayment := convertToMonthlyPayment(m.PaymentAmount, m.PaymentFrequency)
-				payment := monthlyPayment
+				shockCost := balance * (shockedRate(m.InterestRate, m.RateType, borrowingShift) - m.InterestRate) / 12.0
+				rateShockCost += shockCost
+				payment := monthlyPayment + shockCost
 				// Add extra payment if configured
 				if extra, ok := config.ExtraDebtPayments[m.AccountID]; ok {
 					payment += extra
@@ -302,8 +527,13 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 		}
 		for _, l := range loans {
 			if balance, exists := debtBalances[l.AccountID]; exists && balance > 0 {
-				monthlyPayment := convertToMonthlyPayment(l.PaymentAmount, l.PaymentFrequency)
+				monthlyPayment := loanMonthlyPayment(l, currentDate, annualGrossSalary)
 				payment := monthlyPayment
+				if monthlyPayment > 0 && !l.Student.Subsidized(currentDate) {
+					shockCost := balance * (shockedRate(l.InterestRate, l.RateType, borrowingShift) - l.InterestRate) / 12.0
+					rateShockCost += shockCost
+					payment += shockCost
+				}
 				if extra, ok := config.ExtraDebtPayments[l.AccountID]; ok {
 					payment += extra
 				}
@@ -311,9 +541,32 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 			}
 		}
 
+		// HELOCs are interest-only, at a variable rate
+		for _, h := range helocs {
+			if balance := helocBalances[h.AccountID]; balance > 0 {
+				rate := shockedRate(h.InterestRate, "variable", borrowingShift)
+				rateShockCost += balance * (rate - h.InterestRate) / 12.0
+				totalDebtPayments += balance * rate / 12.0
+			}
+		}
+
 		// Add debt payments to expenses
 		expenses += totalDebtPayments
 
+		// Value equity vesting this month: sold on vest it's income, otherwise it's added
+		// to the equity account and grows with the FMV
+		equityVested := 0.0
+		for _, vest := range findVestsForMonth(vests, currentDate) {
+			value := vest.value(accountGrowthRate(findAccount(accounts, vest.AccountID), config), yearsElapsed)
+			equityVested += value
+			if !config.Equity.SellOnVest {
+				accountBalances[vest.AccountID] += value
+			}
+		}
+		if config.Equity != nil && config.Equity.SellOnVest {
+			eventIncome += equityVested
+		}
+
 		// Add event-based income and expenses
 		expenses += eventExpense
 		totalMonthlyIncome := monthlyNetIncome + eventIncome
@@ -323,6 +576,7 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 
 		// Handle negative cash flow (expenses exceed income)
 		// This happens when extra debt payments or large expenses occur
+		creditDrawn := 0.0
 		if netCashFlow < 0 {
 			shortfall := -netCashFlow
 
@@ -345,6 +599,20 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 				}
 			}
 
+			// Draw on available HELOC credit for whatever the assets couldn't cover
+			remaining := shortfall - totalWithdrawn
+			for _, h := range helocs {
+				if remaining <= 0 {
+					break
+				}
+				draw := math.Min(remaining, helocAvailableCredit(h, helocBalances, accountBalances, debtBalances))
+				if draw > 0 {
+					helocBalances[h.AccountID] += draw
+					creditDrawn += draw
+					remaining -= draw
+				}
+			}
+
 			// Update net cash flow to reflect that we covered the shortfall
 			netCashFlow = 0
 		}
@@ -364,12 +632,25 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 			nonInvestedCash = 0
 		}
 
+		// Sweep last month's surplus cash before this month's growth
+		swept := applySweeps(sweepRules, accountBalances)
+
 		// Record cash flow
 		response.CashFlow = append(response.CashFlow, CashFlowPoint{
-			Date:     currentDate,
-			Income:   totalMonthlyIncome,
-			Expenses: expenses,
-			Net:      netCashFlow,
+			Date:          currentDate,
+			Income:        totalMonthlyIncome,
+			Expenses:      expenses,
+			Net:           netCashFlow,
+			CreditDrawn:   creditDrawn,
+			EquityVested:  equityVested,
+			Payroll:       payrollContributions,
+			EmployerMatch: employerMatch,
+			RateShockCost: rateShockCost,
+			Swept:         swept,
+			Bonus:         bonus,
+			DPSP:          dpsp,
+			EquitySold:    equitySold,
+			EquitySaleTax: equitySaleTax,
 		})
 
 		// Update asset balances with returns
@@ -384,15 +665,15 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 
 			if acc.IsAsset {
 				// Apply investment returns or asset appreciation
-				var growthRate float64
-				if returnRate, ok := config.InvestmentReturns[string(acc.Type)]; ok {
-					growthRate = returnRate
-				} else if apprRate, ok := config.AssetAppreciation[string(acc.Type)]; ok {
-					growthRate = apprRate
+				growthRate := accountGrowthRate(acc, config)
+				if usesInvestmentReturn(acc, config) {
+					if historical, ok := backtest.returnFor(month); ok {
+						growthRate = historical
+					}
+					growthRate += returnShift
 				}
 
-				monthlyReturn := math.Pow(1+growthRate, 1.0/12.0) - 1
-				accountBalances[accountID] = balance * (1 + monthlyReturn)
+				accountBalances[accountID] = MonthlyGrowth(balance, growthRate)
 
 				// Add savings allocation
 				if alloc, ok := config.SavingsAllocation[string(acc.Type)]; ok && savings > 0 {
@@ -458,11 +739,12 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 				if balance > 0 {
 					// Calculate monthly payment components
 					// InterestRate is stored as decimal (e.g., 0.04 for 4%)
-					monthlyRate := m.InterestRate / 12.0
+					rate := shockedRate(m.InterestRate, m.RateType, borrowingShift)
+					monthlyRate := rate / 12.0
 
 					// Convert payment to monthly equivalent based on frequency
 					monthlyPayment := convertToMonthlyPayment(m.PaymentAmount, m.PaymentFrequency)
-					payment := monthlyPayment
+					payment := monthlyPayment + balance*(rate-m.InterestRate)/12.0
 
 					// Add extra payment if configured
 					if extra, ok := config.ExtraDebtPayments[m.AccountID]; ok {
@@ -500,17 +782,25 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 
 				if balance > 0 {
 					// InterestRate is stored as decimal (e.g., 0.04 for 4%)
-					monthlyRate := l.InterestRate / 12.0
+					rate := shockedRate(l.InterestRate, l.RateType, borrowingShift)
+					monthlyRate := rate / 12.0
 
 					// Convert payment to monthly equivalent based on frequency
-					monthlyPayment := convertToMonthlyPayment(l.PaymentAmount, l.PaymentFrequency)
+					monthlyPayment := loanMonthlyPayment(l, currentDate, annualGrossSalary)
 					payment := monthlyPayment
+					if monthlyPayment > 0 && !l.Student.Subsidized(currentDate) {
+						payment += balance * (rate - l.InterestRate) / 12.0
+					}
 
 					if extra, ok := config.ExtraDebtPayments[l.AccountID]; ok {
 						payment += extra
 					}
 
+					// The government pays the interest on subsidized student loans
 					interest := balance * monthlyRate
+					if l.Student.Subsidized(currentDate) {
+						interest = 0
+					}
 					principal := payment - interest
 
 					var newBalance float64
@@ -526,6 +816,20 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 						}
 					}
 
+					// Student loans on a forgiveness program are forgiven after enough qualifying payments
+					if l.Student != nil && l.Student.ForgivenessMonths != nil && !l.Student.InGracePeriod(currentDate) {
+						qualifyingPayments[l.AccountID]++
+						if qualifyingPayments[l.AccountID] >= *l.Student.ForgivenessMonths && newBalance > 0 {
+							response.LoanForgiveness = append(response.LoanForgiveness, LoanForgivenessPoint{
+								Date:      currentDate,
+								AccountID: l.AccountID,
+								Amount:    newBalance,
+								Taxable:   l.Student.ForgivenessTaxable,
+							})
+							newBalance = 0
+						}
+					}
+
 					debtBalances[l.AccountID] = newBalance
 					liabilityTotal += newBalance
 					debtBreakdown[l.AccountID] = newBalance
@@ -533,6 +837,25 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 			}
 		}
 
+		// Add HELOC balances
+		for _, h := range helocs {
+			if balance := helocBalances[h.AccountID]; balance > 0 {
+				liabilityTotal += balance
+				debtBreakdown[h.AccountID] = balance
+			}
+		}
+
+		// Unvested employer money is part of the account balance but is flagged separately
+		if len(employerContributed) > 0 {
+			unvested := 0.0
+			for _, acc := range accounts {
+				if acc.EmployerMatch != nil {
+					unvested += employerContributed[acc.ID] * (1 - acc.EmployerMatch.VestedPercentOn(currentDate))
+				}
+			}
+			response.UnvestedMatch = append(response.UnvestedMatch, DataPoint{Date: currentDate, Value: unvested})
+		}
+
 		// Calculate net worth
 		netWorth := assetTotal - liabilityTotal
 
@@ -563,6 +886,27 @@ func (s *Service) CalculateProjection(ctx context.Context, req *ProjectionReques
 	return response, nil
 }
 
+// accountGrowthRate returns the annual growth rate for an asset account. Per-account
+// overrides take precedence over the config's type-level returns and appreciation.
+func accountGrowthRate(acc *AccountData, config *Config) float64 {
+	if acc.ExpectedReturn != nil {
+		return *acc.ExpectedReturn
+	}
+	if acc.ExpectedAppreciation != nil {
+		return *acc.ExpectedAppreciation
+	}
+	if acc.Type == string(account.AccountTypeStockOptions) && config.Equity != nil && config.Equity.FMVGrowth != nil {
+		return *config.Equity.FMVGrowth
+	}
+	if returnRate, ok := config.InvestmentReturns[string(acc.Type)]; ok {
+		return returnRate
+	}
+	if apprRate, ok := config.AssetAppreciation[string(acc.Type)]; ok {
+		return apprRate
+	}
+	return 0
+}
+
 // getCurrentAccounts fetches current accounts and their balances
 func (s *Service) getCurrentAccounts(ctx context.Context) ([]AccountData, error) {
 	userID := auth.GetUserID(ctx)
@@ -570,11 +914,14 @@ func (s *Service) getCurrentAccounts(ctx context.Context) ([]AccountData, error)
 		return nil, fmt.Errorf("user not authenticated")
 	}
 
+	ctx, cancel := database.WithStatementTimeout(ctx)
+	defer cancel()
+
 	// First get all active accounts
 	rows, err := s.accountDB.QueryContext(ctx, `
-		SELECT id, type, is_asset, currency
+		SELECT id, type, is_asset, currency, expected_return, expected_appreciation
 		FROM accounts
-		WHERE is_active = true AND user_id = $1
+		WHERE is_active = true AND exclude_from_projections = false AND user_id = $1
 	`, userID)
 	if err != nil {
 		return nil, err
@@ -585,7 +932,7 @@ func (s *Service) getCurrentAccounts(ctx context.Context) ([]AccountData, error)
 	accountIDs := make([]string, 0)
 	for rows.Next() {
 		var acc AccountData
-		err := rows.Scan(&acc.ID, &acc.Type, &acc.IsAsset, &acc.Currency)
+		err := rows.Scan(&acc.ID, &acc.Type, &acc.IsAsset, &acc.Currency, &acc.ExpectedReturn, &acc.ExpectedAppreciation)
 		if err != nil {
 			return nil, err
 		}
@@ -603,30 +950,71 @@ func (s *Service) getCurrentAccounts(ctx context.Context) ([]AccountData, error)
 				SELECT MAX(b2.date) FROM balances b2 WHERE b2.account_id = b1.account_id
 			)
 		`)
-		if err == nil {
-			defer balanceRows.Close()
-
-			balanceMap := make(map[string]float64)
-			for balanceRows.Next() {
-				var accountID string
-				var amount float64
-				if err := balanceRows.Scan(&accountID, &amount); err == nil {
-					balanceMap[accountID] = amount
-				}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get balances: %w", err)
+		}
+		defer balanceRows.Close()
+
+		balanceMap := make(map[string]float64)
+		for balanceRows.Next() {
+			var accountID string
+			var amount float64
+			if err := balanceRows.Scan(&accountID, &amount); err != nil {
+				return nil, fmt.Errorf("failed to scan balance: %w", err)
 			}
+			balanceMap[accountID] = amount
+		}
 
-			// Apply balances to accounts
-			for i := range accounts {
-				if balance, ok := balanceMap[accounts[i].ID]; ok {
-					accounts[i].Balance = balance
-				}
+		// Apply balances to accounts
+		for i := range accounts {
+			if balance, ok := balanceMap[accounts[i].ID]; ok {
+				accounts[i].Balance = balance
 			}
 		}
 	}
 
+	if err := s.loadEmployerMatches(ctx, userID, accounts); err != nil {
+		return nil, err
+	}
+
 	return accounts, nil
 }
 
+// loadEmployerMatches attaches employer matching terms to the accounts that have them
+func (s *Service) loadEmployerMatches(ctx context.Context, userID string, accounts []AccountData) error {
+	rows, err := s.accountDB.QueryContext(ctx, `
+		SELECT em.account_id
+		FROM employer_match_details em
+		JOIN accounts a ON a.id = em.account_id
+		WHERE a.user_id = $1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get employer matches: %w", err)
+	}
+	matched := make(map[string]bool)
+	for rows.Next() {
+		var accountID string
+		if err := rows.Scan(&accountID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan employer match: %w", err)
+		}
+		matched[accountID] = true
+	}
+	rows.Close()
+
+	for i := range accounts {
+		if !matched[accounts[i].ID] {
+			continue
+		}
+		match, err := s.accountSvc.GetEmployerMatch(ctx, accounts[i].ID)
+		if err != nil {
+			return err
+		}
+		accounts[i].EmployerMatch = match
+	}
+	return nil
+}
+
 // getMortgageDetails fetches mortgage details and current balances
 func (s *Service) getMortgageDetails(ctx context.Context) ([]MortgageData, error) {
 	userID := auth.GetUserID(ctx)
@@ -634,8 +1022,11 @@ func (s *Service) getMortgageDetails(ctx context.Context) ([]MortgageData, error
 		return nil, fmt.Errorf("user not authenticated")
 	}
 
+	ctx, cancel := database.WithStatementTimeout(ctx)
+	defer cancel()
+
 	rows, err := s.accountDB.QueryContext(ctx, `
-		SELECT m.account_id, m.original_amount, m.interest_rate, m.payment_amount, m.payment_frequency,
+		SELECT m.account_id, m.original_amount, m.interest_rate, m.rate_type, m.payment_amount, m.payment_frequency,
 		       COALESCE((
 		           SELECT balance_after
 		           FROM mortgage_payments mp
@@ -645,7 +1036,7 @@ func (s *Service) getMortgageDetails(ctx context.Context) ([]MortgageData, error
 		       ), m.original_amount) as current_balance
 		FROM mortgage_details m
 		JOIN accounts a ON m.account_id = a.id
-		WHERE a.user_id = $1 AND a.is_active = true
+		WHERE a.user_id = $1 AND a.is_active = true AND a.exclude_from_projections = false
 	`, userID)
 	if err != nil {
 		return nil, err
@@ -656,7 +1047,7 @@ func (s *Service) getMortgageDetails(ctx context.Context) ([]MortgageData, error
 	for rows.Next() {
 		var m MortgageData
 		var originalAmount float64
-		err := rows.Scan(&m.AccountID, &originalAmount, &m.InterestRate, &m.PaymentAmount, &m.PaymentFrequency, &m.CurrentBalance)
+		err := rows.Scan(&m.AccountID, &originalAmount, &m.InterestRate, &m.RateType, &m.PaymentAmount, &m.PaymentFrequency, &m.CurrentBalance)
 		if err != nil {
 			return nil, err
 		}
@@ -673,8 +1064,11 @@ func (s *Service) getLoanDetails(ctx context.Context) ([]LoanData, error) {
 		return nil, fmt.Errorf("user not authenticated")
 	}
 
+	ctx, cancel := database.WithStatementTimeout(ctx)
+	defer cancel()
+
 	rows, err := s.accountDB.QueryContext(ctx, `
-		SELECT l.account_id, l.original_amount, l.interest_rate, l.payment_amount, l.payment_frequency,
+		SELECT l.account_id, l.original_amount, l.interest_rate, l.rate_type, l.payment_amount, l.payment_frequency, COALESCE(l.loan_type, ''),
 		       COALESCE((
 		           SELECT balance_after
 		           FROM loan_payments lp
@@ -684,7 +1078,7 @@ func (s *Service) getLoanDetails(ctx context.Context) ([]LoanData, error) {
 		       ), l.original_amount) as current_balance
 		FROM loan_details l
 		JOIN accounts a ON l.account_id = a.id
-		WHERE a.user_id = $1 AND a.is_active = true
+		WHERE a.user_id = $1 AND a.is_active = true AND a.exclude_from_projections = false
 	`, userID)
 	if err != nil {
 		return nil, err
@@ -695,16 +1089,197 @@ func (s *Service) getLoanDetails(ctx context.Context) ([]LoanData, error) {
 	for rows.Next() {
 		var l LoanData
 		var originalAmount float64
-		err := rows.Scan(&l.AccountID, &originalAmount, &l.InterestRate, &l.PaymentAmount, &l.PaymentFrequency, &l.CurrentBalance)
+		err := rows.Scan(&l.AccountID, &originalAmount, &l.InterestRate, &l.RateType, &l.PaymentAmount, &l.PaymentFrequency, &l.LoanType, &l.CurrentBalance)
 		if err != nil {
 			return nil, err
 		}
 		loans = append(loans, l)
 	}
+	rows.Close()
+
+	for i := range loans {
+		if loans[i].LoanType != "student" {
+			continue
+		}
+		// Student loans without recorded terms are projected like any other loan
+		if student, err := s.accountSvc.GetStudentLoanDetails(ctx, loans[i].AccountID); err == nil {
+			loans[i].Student = student
+		}
+	}
 
 	return loans, nil
 }
 
+// loanMonthlyPayment returns a loan's monthly payment for the month. Student loans pay
+// nothing during their grace period, and on income-driven plans pay a share of income
+// above the threshold, up to the standard payment.
+func loanMonthlyPayment(l LoanData, date time.Time, annualGrossIncome float64) float64 {
+	payment := convertToMonthlyPayment(l.PaymentAmount, l.PaymentFrequency)
+	if l.Student == nil {
+		return payment
+	}
+	if l.Student.InGracePeriod(date) {
+		return 0
+	}
+	if l.Student.RepaymentPlan == account.RepaymentPlanIncomeDriven && l.Student.IncomeDrivenPercent != nil {
+		threshold := 0.0
+		if l.Student.IncomeDrivenThreshold != nil {
+			threshold = *l.Student.IncomeDrivenThreshold
+		}
+		incomeDriven := math.Max(0, annualGrossIncome-threshold) * *l.Student.IncomeDrivenPercent / 12.0
+		return math.Min(payment, incomeDriven)
+	}
+	return payment
+}
+
+// getHELOCDetails fetches HELOC details and the amount currently owed on each
+func (s *Service) getHELOCDetails(ctx context.Context) ([]HELOCData, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	ctx, cancel := database.WithStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.accountDB.QueryContext(ctx, `
+		SELECT h.account_id, h.property_account_id, h.mortgage_account_id, h.max_ltv, h.credit_limit, h.interest_rate,
+		       COALESCE((
+		           SELECT balance_after
+		           FROM heloc_transactions ht
+		           WHERE ht.account_id = h.account_id
+		           ORDER BY transaction_date DESC, created_at DESC
+		           LIMIT 1
+		       ), 0) as current_balance
+		FROM heloc_details h
+		JOIN accounts a ON h.account_id = a.id
+		WHERE a.user_id = $1 AND a.is_active = true AND a.exclude_from_projections = false
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var helocs []HELOCData
+	for rows.Next() {
+		var h HELOCData
+		err := rows.Scan(&h.AccountID, &h.PropertyAccountID, &h.MortgageAccountID, &h.MaxLTV, &h.CreditLimit, &h.InterestRate, &h.CurrentBalance)
+		if err != nil {
+			return nil, err
+		}
+		helocs = append(helocs, h)
+	}
+
+	return helocs, nil
+}
+
+// helocAvailableCredit returns the undrawn credit on a HELOC given the projected property
+// value and mortgage balance, capped by the lender's credit limit
+func helocAvailableCredit(h HELOCData, helocBalances, accountBalances, debtBalances map[string]float64) float64 {
+	limit := 0.0
+	if h.PropertyAccountID != nil {
+		limit = accountBalances[*h.PropertyAccountID] * h.MaxLTV
+	}
+	if h.MortgageAccountID != nil {
+		if balance, ok := debtBalances[*h.MortgageAccountID]; ok {
+			limit -= math.Abs(balance)
+		} else {
+			limit -= math.Abs(accountBalances[*h.MortgageAccountID])
+		}
+	}
+	if h.CreditLimit != nil && *h.CreditLimit < limit {
+		limit = *h.CreditLimit
+	}
+	return math.Max(0, limit-helocBalances[h.AccountID])
+}
+
+// excludedAccounts returns the IDs of the user's accounts excluded from projections
+func (s *Service) excludedAccounts(ctx context.Context) (map[string]bool, error) {
+	rows, err := s.accountDB.QueryContext(ctx, `
+		SELECT id FROM accounts WHERE user_id = $1 AND exclude_from_projections = true
+	`, auth.GetUserID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get excluded accounts: %w", err)
+	}
+	defer rows.Close()
+
+	excluded := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan excluded account: %w", err)
+		}
+		excluded[id] = true
+	}
+	return excluded, rows.Err()
+}
+
+// getScheduledTransactionEvents converts pending scheduled transactions into one-time events,
+// leaving out those on accounts excluded from projections
+func (s *Service) getScheduledTransactionEvents(ctx context.Context) ([]Event, error) {
+	resp, err := s.transactionSvc.ListScheduledTransactions(ctx, transaction.ScheduledStatusScheduled)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded, err := s.excludedAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(resp.ScheduledTransactions))
+	for _, st := range resp.ScheduledTransactions {
+		if st.AccountID != nil && excluded[*st.AccountID] {
+			continue
+		}
+		event := Event{
+			ID:          "scheduled-" + st.ID,
+			Date:        st.ScheduledDate,
+			Description: st.Description,
+		}
+		if st.Amount >= 0 {
+			event.Type = EventOneTimeIncome
+			event.Parameters.Amount = st.Amount
+		} else {
+			event.Type = EventOneTimeExpense
+			event.Parameters.Amount = -st.Amount
+		}
+		if st.Category != nil {
+			event.Parameters.Category = *st.Category
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// getProjectEvents converts the outstanding planned expenses of active projects into one-time
+// expenses. Overdue ones are expected this month.
+func (s *Service) getProjectEvents(ctx context.Context, today time.Time) ([]Event, error) {
+	planned, err := s.transactionSvc.ListOutstandingPlannedExpenses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(planned))
+	for _, pe := range planned {
+		date := pe.Date
+		if date.Before(today) {
+			date = today
+		}
+		event := Event{
+			ID:          "project-" + pe.ID,
+			Type:        EventOneTimeExpense,
+			Date:        date,
+			Description: pe.Description,
+		}
+		event.Parameters.Amount = pe.Amount
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
 // getRecurringExpensesTotal fetches and calculates monthly total from recurring expenses
 func (s *Service) getRecurringExpensesTotal(ctx context.Context) (float64, error) {
 	userID := auth.GetUserID(ctx)
@@ -712,6 +1287,9 @@ func (s *Service) getRecurringExpensesTotal(ctx context.Context) (float64, error
 		return 0, fmt.Errorf("user not authenticated")
 	}
 
+	ctx, cancel := database.WithStatementTimeout(ctx)
+	defer cancel()
+
 	// Query the transaction database
 	resp, err := s.transactionDB.QueryContext(ctx, `
 		SELECT amount, frequency
@@ -997,6 +1575,12 @@ func isSameMonth(date1, date2 time.Time) bool {
 	return date1.Year() == date2.Year() && date1.Month() == date2.Month()
 }
 
+// MonthlyGrowth grows a balance by one month of an annual rate, compounded monthly so that
+// twelve months make up the annual rate
+func MonthlyGrowth(balance, annualRate float64) float64 {
+	return balance * math.Pow(1+annualRate, 1.0/12.0)
+}
+
 // convertToMonthlyPayment converts a payment amount based on frequency to monthly equivalent
 func convertToMonthlyPayment(paymentAmount float64, frequency string) float64 {
 	switch frequency {
@@ -0,0 +1,46 @@
+package projections
+
+import (
+	"context"
+
+	"money/internal/transaction"
+)
+
+// getSweepRules fetches the user's active sweep rules
+func (s *Service) getSweepRules(ctx context.Context) ([]transaction.SweepRule, error) {
+	resp, err := s.transactionSvc.ListSweepRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]transaction.SweepRule, 0, len(resp.Rules))
+	for _, rule := range resp.Rules {
+		if rule.IsActive {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// applySweeps moves whatever each rule's source account holds above its threshold into the
+// destination account, as the sweep would at month end, and returns the total moved
+func applySweeps(rules []transaction.SweepRule, accountBalances map[string]float64) float64 {
+	swept := 0.0
+	for _, rule := range rules {
+		balance, ok := accountBalances[rule.FromAccountID]
+		if !ok {
+			continue
+		}
+		if _, ok := accountBalances[rule.ToAccountID]; !ok {
+			continue
+		}
+		surplus := balance - rule.Threshold
+		if surplus <= 0 || surplus < rule.MinAmount {
+			continue
+		}
+		accountBalances[rule.FromAccountID] -= surplus
+		accountBalances[rule.ToAccountID] += surplus
+		swept += surplus
+	}
+	return swept
+}
@@ -10,6 +10,9 @@ import (
 
 	"money/internal/account"
 	"money/internal/auth"
+	"money/internal/holdings"
+	"money/internal/income"
+	"money/internal/inflation"
 	"money/internal/transaction"
 )
 
@@ -23,12 +26,22 @@ func SetupTestDB(t *testing.T) *sql.DB {
 func CleanupTestDB(t *testing.T, db *sql.DB) {
 	t.Helper()
 
-	// Clean projection scenarios
-	_, err := db.Exec("DELETE FROM projection_scenarios WHERE user_id LIKE 'test-%'")
+	// Clean projection scenario results and scenarios
+	_, err := db.Exec("DELETE FROM projection_scenario_results WHERE user_id LIKE 'test-%'")
+	if err != nil {
+		t.Logf("Warning: failed to clean projection_scenario_results: %v", err)
+	}
+	_, err = db.Exec("DELETE FROM projection_scenarios WHERE user_id LIKE 'test-%'")
 	if err != nil {
 		t.Logf("Warning: failed to clean projection_scenarios: %v", err)
 	}
 
+	// Clean bonus plans; payouts cascade
+	_, err = db.Exec("DELETE FROM bonus_plans WHERE user_id LIKE 'test-%'")
+	if err != nil {
+		t.Logf("Warning: failed to clean bonus_plans: %v", err)
+	}
+
 	account.CleanupTestDB(t, db)
 }
 
@@ -39,7 +52,7 @@ func SetupProjectionService(t *testing.T, db *sql.DB) *Service {
 	accountSvc := account.SetupAccountService(t, db)
 	transactionSvc := transaction.NewService(db)
 
-	return NewService(db, db, accountSvc, transactionSvc)
+	return NewService(db, db, accountSvc, transactionSvc, holdings.NewService(db), inflation.NewService(db, ""), income.NewService(db))
 }
 
 // CreateAuthContext creates a context with user ID for testing
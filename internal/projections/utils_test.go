@@ -309,3 +309,46 @@ func TestIsSameMonth_MonthBoundaries(t *testing.T) {
 		})
 	}
 }
+
+func TestAccountGrowthRate_OverrideTakesPrecedence(t *testing.T) {
+	config := &Config{
+		InvestmentReturns: map[string]float64{"brokerage": 0.06},
+	}
+	override := 0.09
+	growth := &AccountData{ID: "growth", Type: "brokerage", ExpectedReturn: &override}
+	income := &AccountData{ID: "income", Type: "brokerage"}
+
+	if rate := accountGrowthRate(growth, config); rate != 0.09 {
+		t.Errorf("Expected override rate 0.09, got %.4f", rate)
+	}
+	if rate := accountGrowthRate(income, config); rate != 0.06 {
+		t.Errorf("Expected type-level rate 0.06, got %.4f", rate)
+	}
+}
+
+func TestHELOCAvailableCredit_DerivedFromPropertyAndMortgage(t *testing.T) {
+	propertyID := "property"
+	mortgageID := "mortgage"
+	heloc := HELOCData{
+		AccountID:         "heloc",
+		PropertyAccountID: &propertyID,
+		MortgageAccountID: &mortgageID,
+		MaxLTV:            0.80,
+	}
+	accountBalances := map[string]float64{propertyID: 500000}
+	debtBalances := map[string]float64{mortgageID: -300000}
+	helocBalances := map[string]float64{"heloc": 25000}
+
+	// 500k * 80% - 300k mortgage - 25k drawn
+	available := helocAvailableCredit(heloc, helocBalances, accountBalances, debtBalances)
+	if available != 75000 {
+		t.Errorf("Expected 75000 available, got %.2f", available)
+	}
+
+	limit := 50000.0
+	heloc.CreditLimit = &limit
+	available = helocAvailableCredit(heloc, helocBalances, accountBalances, debtBalances)
+	if available != 25000 {
+		t.Errorf("Expected lender cap to limit available credit to 25000, got %.2f", available)
+	}
+}
@@ -0,0 +1,265 @@
+package projections
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"money/internal/civil"
+)
+
+// liquidAccountTypes are the account types counted as the emergency fund
+var liquidAccountTypes = map[string]bool{
+	"checking": true,
+	"savings":  true,
+	"cash":     true,
+}
+
+// WhatIfRequest describes a hypothetical purchase to test against the user's finances.
+// Nothing is saved; the purchase only exists for the simulation.
+type WhatIfRequest struct {
+	Description      string           `json:"description,omitempty"`
+	Amount           float64          `json:"amount"`              // Purchase price
+	Date             time.Time        `json:"date"`                // Defaults to today
+	FundingAccountID string           `json:"funding_account_id"`  // Account the upfront payment comes from
+	Financing        *WhatIfFinancing `json:"financing,omitempty"` // nil pays the full price upfront
+	Config           *Config          `json:"config,omitempty"`    // Defaults to the default scenario's config
+}
+
+// WhatIfFinancing is a loan taking on part of a purchase
+type WhatIfFinancing struct {
+	DownPayment float64 `json:"down_payment"` // Paid upfront from the funding account
+	AnnualRate  float64 `json:"annual_rate"`  // e.g. 0.069 for 6.9%
+	TermMonths  int     `json:"term_months"`
+}
+
+// WhatIfResponse compares the user's finances with and without the purchase
+type WhatIfResponse struct {
+	Upfront          float64          `json:"upfront"`                     // Paid from the funding account on the purchase date
+	Financed         float64          `json:"financed,omitempty"`          // Borrowed
+	MonthlyPayment   float64          `json:"monthly_payment,omitempty"`   // Loan payment from the month after the purchase
+	TotalInterest    float64          `json:"total_interest,omitempty"`    // Interest paid over the loan term
+	PurchaseMonth    WhatIfCashFlow   `json:"purchase_month"`              // Cash flow in the month of the purchase
+	EmergencyFund    WhatIfCoverage   `json:"emergency_fund"`              // Months of expenses liquid accounts cover
+	EndNetWorth      WhatIfComparison `json:"end_net_worth"`               // Net worth at the end of the projection
+	NetWorthDelta    []DataPoint      `json:"net_worth_delta"`             // Yearly difference in projected net worth
+	FundingShortfall float64          `json:"funding_shortfall,omitempty"` // Upfront amount the funding account can't cover
+}
+
+// WhatIfCashFlow is income less expenses in a month, before and after the purchase
+type WhatIfCashFlow struct {
+	Date     time.Time `json:"date"`
+	Baseline float64   `json:"baseline"`
+	WhatIf   float64   `json:"what_if"`
+	Change   float64   `json:"change"`
+}
+
+// WhatIfCoverage is how many months of expenses liquid balances cover, before and after
+type WhatIfCoverage struct {
+	LiquidBefore float64  `json:"liquid_before"`
+	LiquidAfter  float64  `json:"liquid_after"`
+	MonthsBefore *float64 `json:"months_before,omitempty"` // Omitted without monthly expenses
+	MonthsAfter  *float64 `json:"months_after,omitempty"`
+}
+
+// WhatIfComparison is a projected figure with and without the purchase
+type WhatIfComparison struct {
+	Baseline float64 `json:"baseline"`
+	WhatIf   float64 `json:"what_if"`
+	Change   float64 `json:"change"`
+}
+
+// SimulatePurchase projects the user's finances with and without a hypothetical purchase.
+// The upfront payment is a one-time expense on the purchase date and loan payments are
+// monthly expenses over the term, so the projection funds them as it would any expense.
+func (s *Service) SimulatePurchase(ctx context.Context, req *WhatIfRequest) (*WhatIfResponse, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+	today := civil.TodayIn(ctx).Time
+	if req.Date.IsZero() {
+		req.Date = today
+	}
+	if req.Date.Before(today) && !isSameMonth(req.Date, today) {
+		return nil, fmt.Errorf("date must not be in a past month")
+	}
+
+	config, err := s.defaultConfig(ctx, req.Config)
+	if err != nil {
+		return nil, err
+	}
+	if req.Date.After(today.AddDate(config.TimeHorizonYears, 0, 0)) {
+		return nil, fmt.Errorf("date is beyond the projection horizon")
+	}
+
+	accounts, err := s.getCurrentAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	funding := findAccount(accounts, req.FundingAccountID)
+	if funding == nil {
+		return nil, fmt.Errorf("funding account not found")
+	}
+
+	resp := &WhatIfResponse{Upfront: req.Amount}
+	description := req.Description
+	if description == "" {
+		description = "Hypothetical purchase"
+	}
+	var events []Event
+	if f := req.Financing; f != nil {
+		if f.DownPayment < 0 || f.DownPayment > req.Amount {
+			return nil, fmt.Errorf("down_payment must be between 0 and the purchase amount")
+		}
+		if f.AnnualRate < 0 {
+			return nil, fmt.Errorf("annual_rate must not be negative")
+		}
+		if f.TermMonths <= 0 {
+			return nil, fmt.Errorf("term_months must be greater than zero")
+		}
+		resp.Upfront = f.DownPayment
+		resp.Financed = req.Amount - f.DownPayment
+		payment := amortizedPayment(resp.Financed, f.AnnualRate, f.TermMonths)
+		resp.MonthlyPayment = roundCents(payment)
+		resp.TotalInterest = roundCents(payment*float64(f.TermMonths) - resp.Financed)
+
+		for i := 1; i <= f.TermMonths && resp.Financed > 0; i++ {
+			events = append(events, Event{
+				ID:          fmt.Sprintf("what_if_payment_%d", i),
+				Type:        EventOneTimeExpense,
+				Date:        req.Date.AddDate(0, i, 0),
+				Description: description + " payment",
+				Parameters:  EventParameters{Amount: payment, AccountID: req.FundingAccountID},
+			})
+		}
+	}
+	if resp.Upfront > 0 {
+		events = append(events, Event{
+			ID:          "what_if_purchase",
+			Type:        EventOneTimeExpense,
+			Date:        req.Date,
+			Description: description,
+			Parameters:  EventParameters{Amount: resp.Upfront, AccountID: req.FundingAccountID},
+		})
+	}
+
+	baselineConfig, err := copyConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	whatIfConfig, err := copyConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	whatIfConfig.Events = append(whatIfConfig.Events, events...)
+
+	baseline, err := s.CalculateProjection(ctx, &ProjectionRequest{Config: baselineConfig})
+	if err != nil {
+		return nil, err
+	}
+	whatIf, err := s.CalculateProjection(ctx, &ProjectionRequest{Config: whatIfConfig})
+	if err != nil {
+		return nil, err
+	}
+
+	// Cash flow before any shortfall is covered from savings, so the purchase shows in full
+	for i, point := range baseline.CashFlow {
+		if isSameMonth(point.Date, req.Date) && i < len(whatIf.CashFlow) {
+			after := whatIf.CashFlow[i]
+			resp.PurchaseMonth = WhatIfCashFlow{
+				Date:     point.Date,
+				Baseline: roundCents(point.Income - point.Expenses),
+				WhatIf:   roundCents(after.Income - after.Expenses),
+			}
+			resp.PurchaseMonth.Change = roundCents(resp.PurchaseMonth.WhatIf - resp.PurchaseMonth.Baseline)
+			break
+		}
+	}
+
+	if funding.IsAsset && resp.Upfront > funding.Balance {
+		resp.FundingShortfall = roundCents(resp.Upfront - math.Max(funding.Balance, 0))
+	}
+	monthlyExpenses := 0.0
+	if len(baseline.CashFlow) > 0 {
+		monthlyExpenses = baseline.CashFlow[0].Expenses
+	}
+	resp.EmergencyFund = emergencyCoverage(accounts, funding, resp.Upfront, monthlyExpenses, resp.MonthlyPayment)
+
+	last := len(baseline.NetWorth) - 1
+	if last >= 0 && last < len(whatIf.NetWorth) {
+		resp.EndNetWorth = WhatIfComparison{
+			Baseline: roundCents(baseline.NetWorth[last].Value),
+			WhatIf:   roundCents(whatIf.NetWorth[last].Value),
+		}
+		resp.EndNetWorth.Change = roundCents(resp.EndNetWorth.WhatIf - resp.EndNetWorth.Baseline)
+	}
+	resp.NetWorthDelta = make([]DataPoint, 0)
+	for i := 12; i < len(baseline.NetWorth) && i < len(whatIf.NetWorth); i += 12 {
+		resp.NetWorthDelta = append(resp.NetWorthDelta, DataPoint{
+			Date:  baseline.NetWorth[i].Date,
+			Value: roundCents(whatIf.NetWorth[i].Value - baseline.NetWorth[i].Value),
+		})
+	}
+
+	return resp, nil
+}
+
+// emergencyCoverage compares the months of expenses liquid balances cover before the
+// purchase with after it, once the upfront payment has left a liquid funding account and
+// the loan payment has been added to monthly expenses
+func emergencyCoverage(accounts []AccountData, funding *AccountData, upfront, monthlyExpenses, monthlyPayment float64) WhatIfCoverage {
+	var coverage WhatIfCoverage
+	for _, acc := range accounts {
+		if acc.IsAsset && liquidAccountTypes[acc.Type] {
+			coverage.LiquidBefore += acc.Balance
+		}
+	}
+	coverage.LiquidAfter = coverage.LiquidBefore
+	if funding.IsAsset && liquidAccountTypes[funding.Type] {
+		coverage.LiquidAfter -= upfront
+	}
+	coverage.LiquidBefore = roundCents(coverage.LiquidBefore)
+	coverage.LiquidAfter = roundCents(coverage.LiquidAfter)
+
+	if monthlyExpenses > 0 {
+		before := math.Round(coverage.LiquidBefore/monthlyExpenses*10) / 10
+		coverage.MonthsBefore = &before
+	}
+	if monthlyExpenses+monthlyPayment > 0 {
+		after := math.Round(math.Max(coverage.LiquidAfter, 0)/(monthlyExpenses+monthlyPayment)*10) / 10
+		coverage.MonthsAfter = &after
+	}
+	return coverage
+}
+
+// amortizedPayment returns the fixed monthly payment that repays principal over the term
+func amortizedPayment(principal, annualRate float64, months int) float64 {
+	if principal <= 0 || months <= 0 {
+		return 0
+	}
+	rate := annualRate / 12
+	if rate == 0 {
+		return principal / float64(months)
+	}
+	return principal * rate / (1 - math.Pow(1+rate, -float64(months)))
+}
+
+// copyConfig deep-copies a config, since calculating a projection modifies its config
+func copyConfig(config *Config) (*Config, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	var copied Config
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	return &copied, nil
+}
+
+// roundCents rounds an amount to two decimal places
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
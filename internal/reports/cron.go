@@ -0,0 +1,169 @@
+package reports
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxCronSearch bounds how far ahead Next looks for a matching time, so a schedule that can
+// never fire, such as February 30th, doesn't loop forever
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+// cronField is the range of values one field of a cron expression can take
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 6}, // Sunday is 0; 7 is accepted as Sunday too
+}
+
+// Cron is a parsed five-field cron expression: minute, hour, day of month, month and day
+// of week. Fields take *, a value, a range such as 1-5, a step such as */15 or 1-30/2, and
+// lists of those separated by commas. As in cron, when both day fields are restricted a
+// day matching either one matches.
+type Cron struct {
+	minutes, hours, days, months, weekdays uint64 // Bit n is set when value n matches
+	anyDay, anyWeekday                     bool
+}
+
+// ParseCron parses a five-field cron expression
+func ParseCron(expr string) (*Cron, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression must have %d fields, got %d", len(cronFields), len(parts))
+	}
+
+	var sets [5]uint64
+	for i, part := range parts {
+		set, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+	// Sunday may be written as 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+
+	return &Cron{
+		minutes:    sets[0],
+		hours:      sets[1],
+		days:       sets[2],
+		months:     sets[3],
+		weekdays:   sets[4],
+		anyDay:     parts[2] == "*",
+		anyWeekday: parts[4] == "*",
+	}, nil
+}
+
+// parseCronField parses one field into the set of values it matches
+func parseCronField(part string, field cronField) (uint64, error) {
+	upper := field.max
+	if field.name == "day of week" {
+		upper = 7
+	}
+
+	var set uint64
+	for _, item := range strings.Split(part, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid %s step: %s", field.name, item)
+			}
+			step = n
+		}
+
+		lo, hi := field.min, upper
+		switch {
+		case rangePart == "*":
+			if field.name == "day of week" {
+				hi = field.max
+			}
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			a, errA := strconv.Atoi(from)
+			b, errB := strconv.Atoi(to)
+			if errA != nil || errB != nil || a > b {
+				return 0, fmt.Errorf("invalid %s range: %s", field.name, item)
+			}
+			lo, hi = a, b
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid %s: %s", field.name, item)
+			}
+			lo, hi = n, n
+			if hasStep {
+				hi = upper
+			}
+		}
+		if lo < field.min || hi > upper {
+			return 0, fmt.Errorf("%s must be between %d and %d: %s", field.name, field.min, field.max, item)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// single reports whether a field matches exactly one value
+func single(set uint64) bool {
+	return set != 0 && set&(set-1) == 0
+}
+
+// dayMatches reports whether t falls on a day the expression runs
+func (c *Cron) dayMatches(t time.Time) bool {
+	day := c.days&(1<<t.Day()) != 0
+	weekday := c.weekdays&(1<<int(t.Weekday())) != 0
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	}
+	return day || weekday
+}
+
+// Next returns the first time after t the expression matches, in t's location, or the zero
+// time when it never does
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	limit := t.Add(maxCronSearch)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for t.Before(limit) {
+		if c.months&(1<<int(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hours&(1<<t.Hour()) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minutes&(1<<t.Minute()) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
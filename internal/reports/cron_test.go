@@ -0,0 +1,86 @@
+package reports
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext_MatchesScheduleInLocation(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	if err != nil {
+		t.Skip("timezone data unavailable")
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "daily at a set time",
+			expr: "30 6 * * *",
+			from: time.Date(2026, 3, 10, 7, 0, 0, 0, toronto),
+			want: time.Date(2026, 3, 11, 6, 30, 0, 0, toronto),
+		},
+		{
+			name: "first of the month",
+			expr: "0 8 1 * *",
+			from: time.Date(2026, 1, 31, 9, 0, 0, 0, toronto),
+			want: time.Date(2026, 2, 1, 8, 0, 0, 0, toronto),
+		},
+		{
+			name: "Sunday written as 7",
+			expr: "0 9 * * 7",
+			from: time.Date(2026, 3, 9, 0, 0, 0, 0, toronto), // Monday
+			want: time.Date(2026, 3, 15, 9, 0, 0, 0, toronto),
+		},
+		{
+			name: "either day field matches",
+			expr: "0 0 15 * 1",
+			from: time.Date(2026, 3, 10, 0, 0, 0, 0, toronto), // Tuesday
+			want: time.Date(2026, 3, 15, 0, 0, 0, 0, toronto),
+		},
+		{
+			name: "stepped hours",
+			expr: "0 */6 * * *",
+			from: time.Date(2026, 3, 10, 13, 0, 0, 0, toronto),
+			want: time.Date(2026, 3, 10, 18, 0, 0, 0, toronto),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			cron, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron failed: %v", err)
+			}
+
+			// Act
+			got := cron.Next(tt.from)
+
+			// Assert
+			if !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCron_RejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "0 24 * * *", "0 0 0 * *", "0 0 * 13 *", "0 0 * * 8", "*/0 * * * *", "5-1 * * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("Expected error for %q", expr)
+		}
+	}
+
+	// February 30th parses but never comes
+	cron, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("ParseCron failed: %v", err)
+	}
+	if next := cron.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); !next.IsZero() {
+		t.Errorf("Expected no next run, got %s", next)
+	}
+}
@@ -0,0 +1,185 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// File is a generated report ready to deliver
+type File struct {
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+// uploadTimeout bounds a single upload, generous enough for a full export
+const uploadTimeout = 2 * time.Minute
+
+// uploader delivers a file to a destination. secret is the destination's decrypted
+// credential: the S3 secret access key or the WebDAV password.
+type uploader func(ctx context.Context, client *http.Client, dest *Destination, secret string, file *File) error
+
+// uploadS3 puts the file into an S3 bucket, or any S3-compatible store, signing the
+// request with AWS Signature Version 4. Path-style URLs are used so custom endpoints such
+// as MinIO work without DNS set up per bucket.
+func uploadS3(ctx context.Context, client *http.Client, dest *Destination, secret string, file *File) error {
+	endpoint := dest.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", dest.Region)
+	}
+	key := strings.TrimPrefix(strings.TrimSuffix(dest.Prefix, "/")+"/"+file.Name, "/")
+	target := strings.TrimSuffix(endpoint, "/") + "/" + dest.Bucket + "/" + key
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(file.Content))
+	if err != nil {
+		return fmt.Errorf("failed to build S3 request: %w", err)
+	}
+	req.Header.Set("Content-Type", file.ContentType)
+	signS3(req, file.Content, dest.Region, dest.AccessKeyID, secret, time.Now().UTC())
+
+	return send(client, req, "S3")
+}
+
+// signS3 adds AWS Signature Version 4 headers to a request for the s3 service
+func signS3(req *http.Request, payload []byte, region, accessKeyID, secret string, now time.Time) {
+	payloadHash := sha256.Sum256(payload)
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"content-type:" + req.Header.Get("Content-Type"),
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + req.Header.Get("X-Amz-Content-Sha256"),
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonical))
+
+	scope := day + "/" + region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := []byte("AWS4" + secret)
+	for _, part := range []string{day, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uploadWebDAV puts the file into a WebDAV collection, such as a Nextcloud folder
+func uploadWebDAV(ctx context.Context, client *http.Client, dest *Destination, secret string, file *File) error {
+	target := strings.TrimSuffix(dest.URL, "/") + "/" + url.PathEscape(file.Name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(file.Content))
+	if err != nil {
+		return fmt.Errorf("failed to build WebDAV request: %w", err)
+	}
+	req.Header.Set("Content-Type", file.ContentType)
+	if dest.Username != "" {
+		req.SetBasicAuth(dest.Username, secret)
+	}
+
+	return send(client, req, "WebDAV")
+}
+
+// send runs an upload request, failing on any non-2xx response
+func send(client *http.Client, req *http.Request, kind string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s upload failed: %w", kind, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s upload returned status %d", kind, resp.StatusCode)
+	}
+	return nil
+}
+
+// Mailer sends reports as email attachments through an SMTP relay
+type Mailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewMailer creates a mailer for an SMTP relay. Authentication is only used when a
+// username is given.
+func NewMailer(host string, port int, username, password, from string) *Mailer {
+	mailer := &Mailer{addr: net.JoinHostPort(host, fmt.Sprint(port)), from: from}
+	if username != "" {
+		mailer.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return mailer
+}
+
+// Send emails the file as an attachment to the address
+func (m *Mailer) Send(to, subject string, file *File) error {
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid email address")
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	fmt.Fprintf(&body, "From: %s\r\n", m.from)
+	fmt.Fprintf(&body, "To: %s\r\n", to)
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&body, "MIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=%s\r\n\r\n", writer.Boundary())
+
+	text, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+	fmt.Fprintf(text, "Your scheduled report %s is attached.\r\n", file.Name)
+
+	attachment, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {file.ContentType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": file.Name})},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(file.Content)
+	for len(encoded) > 76 {
+		fmt.Fprintf(attachment, "%s\r\n", encoded[:76])
+		encoded = encoded[76:]
+	}
+	fmt.Fprintf(attachment, "%s\r\n", encoded)
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{to}, body.Bytes()); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,702 @@
+// Package reports delivers generated reports and exports to a destination each user sets
+// up, such as an S3 bucket, a WebDAV folder or an email inbox, on a cron schedule.
+package reports
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/background"
+	"money/internal/civil"
+	"money/internal/egress"
+	"money/internal/logger"
+	"money/internal/sync/encryption"
+
+	"github.com/google/uuid"
+)
+
+var reportsLog = logger.Module("reports")
+
+// deliveryInterval is how often due schedules are looked for. Schedules run at most
+// hourly, so a minute's delay is the most a delivery waits.
+const deliveryInterval = time.Minute
+
+// Report identifies what a schedule delivers
+type Report string
+
+const (
+	ReportEstate   Report = "estate"    // Estate summary PDF
+	ReportNetWorth Report = "net_worth" // Net worth statement PDF
+	ReportExport   Report = "export"    // Full data export archive
+)
+
+// DestinationType is where a schedule delivers to
+type DestinationType string
+
+const (
+	DestinationS3     DestinationType = "s3"
+	DestinationWebDAV DestinationType = "webdav"
+	DestinationEmail  DestinationType = "email"
+)
+
+// DeliveryStatus is the outcome of a schedule's last run
+type DeliveryStatus string
+
+const (
+	StatusDelivered DeliveryStatus = "delivered"
+	StatusFailed    DeliveryStatus = "failed"
+)
+
+// Generator produces a report for the user in ctx
+type Generator func(ctx context.Context) (*File, error)
+
+// Destination is where a report is delivered. Credentials are stored encrypted apart from
+// it and never returned.
+type Destination struct {
+	Type DestinationType `json:"type"`
+
+	// S3 and S3-compatible stores
+	Bucket      string `json:"bucket,omitempty"`
+	Region      string `json:"region,omitempty"`
+	Endpoint    string `json:"endpoint,omitempty"` // Defaults to AWS for the region
+	Prefix      string `json:"prefix,omitempty"`   // Folder within the bucket
+	AccessKeyID string `json:"access_key_id,omitempty"`
+
+	// WebDAV
+	URL      string `json:"url,omitempty"` // Collection the file is put into
+	Username string `json:"username,omitempty"`
+
+	// Email
+	Email string `json:"email,omitempty"`
+}
+
+// Schedule delivers a report to a destination whenever its cron expression matches
+type Schedule struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Report      Report          `json:"report"`
+	Cron        string          `json:"cron"`
+	Timezone    string          `json:"timezone"`
+	Destination Destination     `json:"destination"`
+	Encrypted   bool            `json:"encrypted"` // Files are encrypted with a passphrase before delivery
+	IsActive    bool            `json:"is_active"`
+	NextRunAt   *time.Time      `json:"next_run_at,omitempty"`
+	LastRunAt   *time.Time      `json:"last_run_at,omitempty"`
+	LastStatus  *DeliveryStatus `json:"last_status,omitempty"`
+	LastError   *string         `json:"last_error,omitempty"`
+	LastFile    *string         `json:"last_file,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// CreateScheduleRequest represents the request to create a delivery schedule
+type CreateScheduleRequest struct {
+	Name        string      `json:"name"`
+	Report      Report      `json:"report"`
+	Cron        string      `json:"cron"`
+	Timezone    string      `json:"timezone,omitempty"` // Defaults to the user's timezone
+	Destination Destination `json:"destination"`
+	Secret      string      `json:"secret,omitempty"`     // S3 secret access key or WebDAV password
+	Passphrase  string      `json:"passphrase,omitempty"` // Encrypts each file, as an encrypted export does
+}
+
+// UpdateScheduleRequest changes the fields that are set. An empty passphrase stops
+// encrypting files.
+type UpdateScheduleRequest struct {
+	Name        *string      `json:"name,omitempty"`
+	Cron        *string      `json:"cron,omitempty"`
+	Timezone    *string      `json:"timezone,omitempty"`
+	Destination *Destination `json:"destination,omitempty"`
+	Secret      *string      `json:"secret,omitempty"`
+	Passphrase  *string      `json:"passphrase,omitempty"`
+	IsActive    *bool        `json:"is_active,omitempty"`
+}
+
+// ListSchedulesResponse represents the user's delivery schedules
+type ListSchedulesResponse struct {
+	Schedules []*Schedule `json:"schedules"`
+}
+
+// Service manages report delivery schedules and runs them
+type Service struct {
+	db         *sql.DB
+	encryption *encryption.Service
+	generators map[Report]Generator
+	uploaders  map[DestinationType]uploader
+	mailer     *Mailer
+	client     *http.Client
+	encrypt    func(content []byte, passphrase string) ([]byte, error)
+}
+
+// NewService creates a new report delivery service. Destination credentials and
+// passphrases are encrypted with enc.
+func NewService(db *sql.DB, enc *encryption.Service) *Service {
+	return &Service{
+		db:         db,
+		encryption: enc,
+		generators: make(map[Report]Generator),
+		uploaders: map[DestinationType]uploader{
+			DestinationS3:     uploadS3,
+			DestinationWebDAV: uploadWebDAV,
+		},
+		client: egress.NewClient(uploadTimeout),
+	}
+}
+
+// SetGenerator sets how a report is produced. Reports without a generator can't be scheduled.
+func (s *Service) SetGenerator(report Report, generator Generator) {
+	s.generators[report] = generator
+}
+
+// SetMailer enables delivery by email
+func (s *Service) SetMailer(mailer *Mailer) {
+	s.mailer = mailer
+}
+
+// SetFileEncryption sets how files of schedules with a passphrase are encrypted
+func (s *Service) SetFileEncryption(encrypt func(content []byte, passphrase string) ([]byte, error)) {
+	s.encrypt = encrypt
+}
+
+// parseSchedule checks a cron expression and timezone. Schedules run at most hourly, so
+// the minute field must name a single minute.
+func parseSchedule(expr, timezone string) (*Cron, *time.Location, error) {
+	cron, err := ParseCron(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !single(cron.minutes) {
+		return nil, nil, fmt.Errorf("schedules run at most hourly; the minute field must be a single minute")
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid timezone: %s", timezone)
+	}
+	if cron.Next(time.Now().In(loc)).IsZero() {
+		return nil, nil, fmt.Errorf("cron expression never matches")
+	}
+	return cron, loc, nil
+}
+
+// validateDestination checks a destination has what its type needs. hasSecret tells
+// whether a credential is set or already stored.
+func (s *Service) validateDestination(dest *Destination, hasSecret bool) error {
+	checkURL := func(field, value string) error {
+		if err := egress.CheckURL(value); err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+		return nil
+	}
+
+	switch dest.Type {
+	case DestinationS3:
+		if dest.Bucket == "" || dest.Region == "" || dest.AccessKeyID == "" {
+			return fmt.Errorf("bucket, region and access_key_id are required for S3")
+		}
+		if strings.ContainsAny(dest.Bucket, "/?#") {
+			return fmt.Errorf("invalid bucket name: %s", dest.Bucket)
+		}
+		if !hasSecret {
+			return fmt.Errorf("secret is required for S3")
+		}
+		if dest.Endpoint != "" {
+			return checkURL("endpoint", dest.Endpoint)
+		}
+	case DestinationWebDAV:
+		if err := checkURL("url", dest.URL); err != nil {
+			return err
+		}
+	case DestinationEmail:
+		if s.mailer == nil {
+			return fmt.Errorf("email delivery is not configured on this server")
+		}
+		if _, err := mail.ParseAddress(dest.Email); err != nil || strings.ContainsAny(dest.Email, "\r\n") {
+			return fmt.Errorf("invalid email address: %s", dest.Email)
+		}
+	default:
+		return fmt.Errorf("invalid destination type: %s", dest.Type)
+	}
+	return nil
+}
+
+// sealSecret encrypts a credential or passphrase, nil when it's empty
+func (s *Service) sealSecret(secret string) ([]byte, error) {
+	if secret == "" {
+		return nil, nil
+	}
+	sealed, err := s.encryption.Encrypt(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	return sealed, nil
+}
+
+// CreateSchedule sets up a report to be delivered on a cron schedule
+func (s *Service) CreateSchedule(ctx context.Context, req *CreateScheduleRequest) (*Schedule, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if _, ok := s.generators[req.Report]; !ok {
+		return nil, fmt.Errorf("invalid report: %s", req.Report)
+	}
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = civil.Location(ctx).String()
+	}
+	cron, loc, err := parseSchedule(req.Cron, timezone)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateDestination(&req.Destination, req.Secret != ""); err != nil {
+		return nil, err
+	}
+	if req.Passphrase != "" && s.encrypt == nil {
+		return nil, fmt.Errorf("file encryption is not configured on this server")
+	}
+	secret, err := s.sealSecret(req.Secret)
+	if err != nil {
+		return nil, err
+	}
+	passphrase, err := s.sealSecret(req.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	next := cron.Next(now.In(loc))
+	schedule := &Schedule{
+		ID:          uuid.New().String(),
+		Name:        name,
+		Report:      req.Report,
+		Cron:        strings.Join(strings.Fields(req.Cron), " "),
+		Timezone:    timezone,
+		Destination: req.Destination,
+		Encrypted:   passphrase != nil,
+		IsActive:    true,
+		NextRunAt:   &next,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	destination, _ := json.Marshal(schedule.Destination)
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO report_schedules (
+			id, user_id, name, report, cron, timezone, destination_type, destination, encrypted_secret,
+			encrypted_passphrase, is_active, next_run_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`, schedule.ID, userID, schedule.Name, schedule.Report, schedule.Cron, schedule.Timezone, schedule.Destination.Type,
+		string(destination), secret, passphrase, schedule.IsActive, next.UTC(), now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schedule: %w", err)
+	}
+
+	return schedule, nil
+}
+
+// scheduleColumns are the columns scanSchedule reads, in order
+const scheduleColumns = `id, name, report, cron, timezone, destination, encrypted_passphrase IS NOT NULL, is_active,
+	next_run_at, last_run_at, last_status, last_error, last_file, created_at, updated_at`
+
+// ListSchedules lists the user's delivery schedules
+func (s *Service) ListSchedules(ctx context.Context) (*ListSchedulesResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM report_schedules
+		WHERE user_id = $1
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := make([]*Schedule, 0)
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schedule: %w", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return &ListSchedulesResponse{Schedules: schedules}, rows.Err()
+}
+
+// GetSchedule retrieves one of the user's delivery schedules
+func (s *Service) GetSchedule(ctx context.Context, id string) (*Schedule, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM report_schedules
+		WHERE id = $1 AND user_id = $2
+	`, id, userID)
+
+	schedule, err := scanSchedule(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("schedule not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// UpdateSchedule changes a delivery schedule. Changing the cron expression or timezone, or
+// reactivating the schedule, works out its next run afresh.
+func (s *Service) UpdateSchedule(ctx context.Context, id string, req *UpdateScheduleRequest) (*Schedule, error) {
+	schedule, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var hasSecret bool
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT encrypted_secret IS NOT NULL FROM report_schedules WHERE id = $1
+	`, id).Scan(&hasSecret); err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("name is required")
+		}
+		schedule.Name = name
+	}
+	reschedule := false
+	if req.Cron != nil {
+		schedule.Cron = strings.Join(strings.Fields(*req.Cron), " ")
+		reschedule = true
+	}
+	if req.Timezone != nil {
+		schedule.Timezone = *req.Timezone
+		reschedule = true
+	}
+	if req.IsActive != nil {
+		reschedule = reschedule || (*req.IsActive && !schedule.IsActive)
+		schedule.IsActive = *req.IsActive
+	}
+	cron, loc, err := parseSchedule(schedule.Cron, schedule.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	if reschedule {
+		next := cron.Next(time.Now().In(loc))
+		schedule.NextRunAt = &next
+	}
+
+	secretChanged := req.Secret != nil
+	if req.Destination != nil {
+		// Credentials belong to a destination, so a new type needs a new secret
+		if req.Destination.Type != schedule.Destination.Type && !secretChanged {
+			hasSecret = false
+		}
+		schedule.Destination = *req.Destination
+	}
+	if secretChanged {
+		hasSecret = *req.Secret != ""
+	}
+	if err := s.validateDestination(&schedule.Destination, hasSecret); err != nil {
+		return nil, err
+	}
+
+	updates := []string{"name = $1", "cron = $2", "timezone = $3", "destination_type = $4", "destination = $5",
+		"is_active = $6", "next_run_at = $7", "updated_at = $8"}
+	destination, _ := json.Marshal(schedule.Destination)
+	schedule.UpdatedAt = time.Now()
+	var nextRun *time.Time
+	if schedule.NextRunAt != nil {
+		utc := schedule.NextRunAt.UTC()
+		nextRun = &utc
+	}
+	args := []interface{}{schedule.Name, schedule.Cron, schedule.Timezone, schedule.Destination.Type, string(destination),
+		schedule.IsActive, nextRun, schedule.UpdatedAt}
+	if secretChanged || (req.Destination != nil && !hasSecret) {
+		secret := ""
+		if req.Secret != nil {
+			secret = *req.Secret
+		}
+		sealed, err := s.sealSecret(secret)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, sealed)
+		updates = append(updates, fmt.Sprintf("encrypted_secret = $%d", len(args)))
+	}
+	if req.Passphrase != nil {
+		if *req.Passphrase != "" && s.encrypt == nil {
+			return nil, fmt.Errorf("file encryption is not configured on this server")
+		}
+		sealed, err := s.sealSecret(*req.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, sealed)
+		updates = append(updates, fmt.Sprintf("encrypted_passphrase = $%d", len(args)))
+		schedule.Encrypted = sealed != nil
+	}
+	args = append(args, id)
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE report_schedules SET `+strings.Join(updates, ", ")+fmt.Sprintf(` WHERE id = $%d`, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update schedule: %w", err)
+	}
+
+	return schedule, nil
+}
+
+// DeleteSchedule removes a delivery schedule
+func (s *Service) DeleteSchedule(ctx context.Context, id string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM report_schedules WHERE id = $1 AND user_id = $2
+	`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("schedule not found")
+	}
+
+	return nil
+}
+
+// RunSchedule delivers a schedule's report now, outside its schedule, and returns the
+// schedule with the outcome. A failed delivery isn't an error; it's recorded as the last
+// status. The next scheduled run is unchanged.
+func (s *Service) RunSchedule(ctx context.Context, id string) (*Schedule, error) {
+	if _, err := s.GetSchedule(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := s.run(ctx, id, time.Now(), false); err != nil {
+		return nil, err
+	}
+	return s.GetSchedule(ctx, id)
+}
+
+// DeliverDue runs every active schedule whose next run has come, returning how many
+// delivered. Each schedule runs for its owner and moves on to its following run whatever
+// the outcome, so a failing destination doesn't retry every minute.
+func (s *Service) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id FROM report_schedules
+		WHERE is_active = 1 AND next_run_at IS NOT NULL AND next_run_at <= $1
+		ORDER BY next_run_at
+	`, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to list due schedules: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan due schedule: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := s.run(ctx, id, now, true); err != nil {
+			reportsLog.Warn("Report delivery failed", "schedule_id", id, "error", err)
+			continue
+		}
+		var status DeliveryStatus
+		if err := s.db.QueryRowContext(ctx, `SELECT last_status FROM report_schedules WHERE id = $1`, id).Scan(&status); err == nil && status == StatusDelivered {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// StartDeliveries delivers due schedules every minute until jobs drains
+func (s *Service) StartDeliveries(jobs *background.Group) {
+	jobs.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(deliveryInterval)
+		defer ticker.Stop()
+
+		for {
+			jobs.Exclusive(ctx, "report_deliveries", deliveryInterval, func(ctx context.Context) {
+				if _, err := s.DeliverDue(ctx, time.Now()); err != nil && ctx.Err() == nil {
+					reportsLog.Error("Report deliveries failed", "error", err)
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// run generates and delivers a schedule's report as its owner and records the outcome.
+// advance moves the schedule on to its next run after now. Errors are returned only when
+// the schedule can't be read or updated; delivery failures are recorded on it.
+func (s *Service) run(ctx context.Context, id string, now time.Time, advance bool) error {
+	var userID, report, cronExpr, timezone, destinationJSON string
+	var secret, passphrase []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT user_id, report, cron, timezone, destination, encrypted_secret, encrypted_passphrase
+		FROM report_schedules WHERE id = $1
+	`, id).Scan(&userID, &report, &cronExpr, &timezone, &destinationJSON, &secret, &passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to get schedule: %w", err)
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = civil.DefaultLocation()
+	}
+	userCtx := civil.WithLocation(auth.WithUserID(ctx, userID), loc)
+
+	file, deliverErr := s.deliver(userCtx, Report(report), destinationJSON, secret, passphrase, now.In(loc))
+
+	status, lastError := StatusDelivered, (*string)(nil)
+	var lastFile *string
+	if deliverErr != nil {
+		status = StatusFailed
+		message := deliverErr.Error()
+		lastError = &message
+	} else {
+		lastFile = &file.Name
+	}
+
+	updates := `last_run_at = $1, last_status = $2, last_error = $3, last_file = COALESCE($4, last_file)`
+	args := []interface{}{now, status, lastError, lastFile}
+	if advance {
+		var next *time.Time
+		if cron, err := ParseCron(cronExpr); err == nil {
+			if t := cron.Next(now.In(loc)); !t.IsZero() {
+				utc := t.UTC()
+				next = &utc
+			}
+		}
+		updates += `, next_run_at = $5`
+		args = append(args, next)
+	}
+	args = append(args, id)
+	if _, err := s.db.ExecContext(ctx, `UPDATE report_schedules SET `+updates+fmt.Sprintf(` WHERE id = $%d`, len(args)), args...); err != nil {
+		return fmt.Errorf("failed to record delivery: %w", err)
+	}
+	if deliverErr != nil {
+		reportsLog.Warn("Report delivery failed", "schedule_id", id, "user_id", userID, "error", deliverErr)
+	}
+	return nil
+}
+
+// deliver generates a report and sends it to the destination
+func (s *Service) deliver(ctx context.Context, report Report, destinationJSON string, secret, passphrase []byte, now time.Time) (*File, error) {
+	var dest Destination
+	if err := json.Unmarshal([]byte(destinationJSON), &dest); err != nil {
+		return nil, fmt.Errorf("failed to read destination: %w", err)
+	}
+	generate, ok := s.generators[report]
+	if !ok {
+		return nil, fmt.Errorf("report %s is no longer available", report)
+	}
+
+	file, err := generate(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate report: %w", err)
+	}
+	// Name files by the schedule's local time so runs sort and never overwrite each other
+	ext := ""
+	if i := strings.LastIndex(file.Name, "."); i >= 0 {
+		ext = file.Name[i:]
+	}
+	file.Name = fmt.Sprintf("moneyy-%s-%s%s", strings.ReplaceAll(string(report), "_", "-"), now.Format("2006-01-02T15-04"), ext)
+
+	if passphrase != nil {
+		plain, err := s.encryption.Decrypt(passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt passphrase: %w", err)
+		}
+		if s.encrypt == nil {
+			return nil, fmt.Errorf("file encryption is not configured on this server")
+		}
+		if file.Content, err = s.encrypt(file.Content, plain); err != nil {
+			return nil, fmt.Errorf("failed to encrypt report: %w", err)
+		}
+		file.Name += ".enc"
+		file.ContentType = "application/octet-stream"
+	}
+
+	if dest.Type == DestinationEmail {
+		if s.mailer == nil {
+			return nil, fmt.Errorf("email delivery is not configured on this server")
+		}
+		return file, s.mailer.Send(dest.Email, "Your scheduled Moneyy report: "+file.Name, file)
+	}
+
+	upload, ok := s.uploaders[dest.Type]
+	if !ok {
+		return nil, fmt.Errorf("invalid destination type: %s", dest.Type)
+	}
+	plainSecret := ""
+	if secret != nil {
+		if plainSecret, err = s.encryption.Decrypt(secret); err != nil {
+			return nil, fmt.Errorf("failed to decrypt destination credentials: %w", err)
+		}
+	}
+	uploadCtx, cancel := context.WithTimeout(ctx, uploadTimeout)
+	defer cancel()
+	return file, upload(uploadCtx, s.client, &dest, plainSecret, file)
+}
+
+// scanSchedule scans a schedule row read with scheduleColumns
+func scanSchedule(row interface{ Scan(...interface{}) error }) (*Schedule, error) {
+	schedule := &Schedule{}
+	var destination string
+	err := row.Scan(&schedule.ID, &schedule.Name, &schedule.Report, &schedule.Cron, &schedule.Timezone, &destination,
+		&schedule.Encrypted, &schedule.IsActive, &schedule.NextRunAt, &schedule.LastRunAt, &schedule.LastStatus,
+		&schedule.LastError, &schedule.LastFile, &schedule.CreatedAt, &schedule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(destination), &schedule.Destination); err != nil {
+		return nil, fmt.Errorf("failed to decode destination: %w", err)
+	}
+	return schedule, nil
+}
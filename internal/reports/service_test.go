@@ -0,0 +1,178 @@
+package reports
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"money/internal/account"
+	"money/internal/sync/encryption"
+)
+
+func setupService(t *testing.T) (*Service, func()) {
+	t.Helper()
+	db := account.SetupTestDB(t)
+	enc, err := encryption.NewService(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	if err != nil {
+		t.Fatalf("Failed to create encryption service: %v", err)
+	}
+	service := NewService(db, enc)
+	service.SetGenerator(ReportNetWorth, func(ctx context.Context) (*File, error) {
+		return &File{Name: "statement.pdf", ContentType: "application/pdf", Content: []byte("%PDF-1.4 statement")}, nil
+	})
+	return service, func() {
+		_, _ = db.Exec("DELETE FROM report_schedules WHERE user_id LIKE 'test-%'")
+		account.CleanupTestDB(t, db)
+	}
+}
+
+// destinationHost is the public-looking host schedules are created with in tests
+const destinationHost = "https://files.example.com"
+
+// pointAtServer moves the user's schedules from destinationHost to the loopback test
+// server, which real destinations are never allowed to reach
+func pointAtServer(t *testing.T, service *Service, server *httptest.Server, userID string) {
+	t.Helper()
+	_, err := service.db.Exec(`
+		UPDATE report_schedules SET destination = REPLACE(destination, $1, $2) WHERE user_id = $3
+	`, destinationHost, server.URL, userID)
+	if err != nil {
+		t.Fatalf("Failed to point schedules at test server: %v", err)
+	}
+	service.client = server.Client()
+}
+
+// upload is a request received by the test destination server
+type upload struct {
+	path, authorization, body string
+}
+
+func TestDeliverDue_UploadsToWebDAVAndS3AndRecordsStatus(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	uploads := make(chan upload, 4)
+	destination := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		uploads <- upload{path: r.URL.Path, authorization: r.Header.Get("Authorization"), body: string(body)}
+		if strings.Contains(r.URL.Path, "/broken/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer destination.Close()
+
+	userID := "test-user-reports-1"
+	account.CreateTestUser(t, service.db, userID)
+	ctx := account.CreateAuthContext(userID)
+	webdav, err := service.CreateSchedule(ctx, &CreateScheduleRequest{
+		Name: "Monthly statement", Report: ReportNetWorth, Cron: "0 7 1 * *", Timezone: "UTC",
+		Destination: Destination{Type: DestinationWebDAV, URL: destinationHost + "/dav/reports", Username: "me"},
+		Secret:      "dav-password",
+	})
+	if err != nil {
+		t.Fatalf("CreateSchedule (WebDAV) failed: %v", err)
+	}
+	s3, err := service.CreateSchedule(ctx, &CreateScheduleRequest{
+		Name: "Statement to S3", Report: ReportNetWorth, Cron: "0 7 1 * *", Timezone: "UTC",
+		Destination: Destination{Type: DestinationS3, Bucket: "moneyy", Region: "ca-central-1", Endpoint: destinationHost, Prefix: "statements", AccessKeyID: "AKIDEXAMPLE"},
+		Secret:      "s3-secret",
+	})
+	if err != nil {
+		t.Fatalf("CreateSchedule (S3) failed: %v", err)
+	}
+	broken, err := service.CreateSchedule(ctx, &CreateScheduleRequest{
+		Name: "Broken", Report: ReportNetWorth, Cron: "0 7 1 * *", Timezone: "UTC",
+		Destination: Destination{Type: DestinationWebDAV, URL: destinationHost + "/broken/"},
+	})
+	if err != nil {
+		t.Fatalf("CreateSchedule (broken) failed: %v", err)
+	}
+	pointAtServer(t, service, destination, userID)
+	due := webdav.NextRunAt.Add(time.Minute)
+
+	// Act
+	delivered, err := service.DeliverDue(context.Background(), due)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("DeliverDue failed: %v", err)
+	}
+	if delivered != 2 {
+		t.Errorf("Expected 2 deliveries, got %d", delivered)
+	}
+	close(uploads)
+	received := make(map[string]upload)
+	for u := range uploads {
+		received[strings.SplitN(strings.TrimPrefix(u.path, "/"), "/", 2)[0]] = u
+	}
+	if u := received["dav"]; !strings.HasPrefix(u.authorization, "Basic ") || u.body != "%PDF-1.4 statement" {
+		t.Errorf("Expected an authenticated WebDAV upload of the statement, got %+v", u)
+	}
+	if u := received["moneyy"]; !strings.HasPrefix(u.path, "/moneyy/statements/moneyy-net-worth-") ||
+		!strings.HasPrefix(u.authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+		t.Errorf("Expected a signed S3 upload under the prefix, got %+v", u)
+	}
+
+	got, err := service.GetSchedule(ctx, s3.ID)
+	if err != nil {
+		t.Fatalf("GetSchedule failed: %v", err)
+	}
+	if got.LastStatus == nil || *got.LastStatus != StatusDelivered || got.LastFile == nil {
+		t.Errorf("Expected the S3 schedule to be delivered, got %+v", got)
+	}
+	if !got.NextRunAt.After(due) {
+		t.Errorf("Expected the next run to move past %s, got %s", due, got.NextRunAt)
+	}
+
+	failed, err := service.GetSchedule(ctx, broken.ID)
+	if err != nil {
+		t.Fatalf("GetSchedule failed: %v", err)
+	}
+	if failed.LastStatus == nil || *failed.LastStatus != StatusFailed || failed.LastError == nil ||
+		!strings.Contains(*failed.LastError, "403") {
+		t.Errorf("Expected the broken schedule to record the failure, got %+v", failed)
+	}
+}
+
+func TestCreateSchedule_Validation(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	userID := "test-user-reports-2"
+	account.CreateTestUser(t, service.db, userID)
+	ctx := account.CreateAuthContext(userID)
+	webdav := Destination{Type: DestinationWebDAV, URL: "https://dav.example.com/reports"}
+
+	tests := []struct {
+		name string
+		req  CreateScheduleRequest
+	}{
+		{name: "more often than hourly", req: CreateScheduleRequest{Name: "x", Report: ReportNetWorth, Cron: "*/5 * * * *", Destination: webdav}},
+		{name: "unknown report", req: CreateScheduleRequest{Name: "x", Report: "taxes", Cron: "0 7 * * *", Destination: webdav}},
+		{name: "unknown timezone", req: CreateScheduleRequest{Name: "x", Report: ReportNetWorth, Cron: "0 7 * * *", Timezone: "Mars/Olympus", Destination: webdav}},
+		{name: "non-http URL", req: CreateScheduleRequest{Name: "x", Report: ReportNetWorth, Cron: "0 7 * * *", Destination: Destination{Type: DestinationWebDAV, URL: "file:///etc"}}},
+		{name: "plain http URL", req: CreateScheduleRequest{Name: "x", Report: ReportNetWorth, Cron: "0 7 * * *", Destination: Destination{Type: DestinationWebDAV, URL: "http://dav.example.com/reports"}}},
+		{name: "internal S3 endpoint", req: CreateScheduleRequest{Name: "x", Report: ReportNetWorth, Cron: "0 7 * * *", Secret: "s",
+			Destination: Destination{Type: DestinationS3, Bucket: "b", Region: "us-east-1", AccessKeyID: "AKID", Endpoint: "https://169.254.169.254"}}},
+		{name: "S3 without secret", req: CreateScheduleRequest{Name: "x", Report: ReportNetWorth, Cron: "0 7 * * *",
+			Destination: Destination{Type: DestinationS3, Bucket: "b", Region: "us-east-1", AccessKeyID: "AKID"}}},
+		{name: "email without a mailer", req: CreateScheduleRequest{Name: "x", Report: ReportNetWorth, Cron: "0 7 * * *",
+			Destination: Destination{Type: DestinationEmail, Email: "me@example.com"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.CreateSchedule(ctx, &tt.req); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
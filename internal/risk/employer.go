@@ -0,0 +1,189 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"money/internal/account"
+	"money/internal/civil"
+	"money/internal/income"
+)
+
+// GetEmployerExposure totals how much of the user's net worth and expected income over the
+// next twelve months depends on their employer. Employer equity is the employer symbol's
+// holdings, which include ESPP purchases, and the grants in stock option accounts. Income
+// from the employer is recurring employment income whose source matches the employer name,
+// expected payouts of the employer's bonus and DPSP plans, and equity vesting within the
+// year. Without an employer name all employment income and bonus plans count.
+func (s *Service) GetEmployerExposure(ctx context.Context) (*EmployerExposure, error) {
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	exposure := &EmployerExposure{
+		EmployerName:   settings.EmployerName,
+		EmployerSymbol: settings.EmployerSymbol,
+		Alerts:         make([]*ExposureAlert, 0),
+	}
+
+	if err := s.employerWealth(ctx, settings, exposure); err != nil {
+		return nil, err
+	}
+	if err := s.employerIncome(ctx, settings, exposure); err != nil {
+		return nil, err
+	}
+
+	exposure.Alerts = exposureAlerts(exposure, settings)
+	roundExposure(exposure)
+	return exposure, nil
+}
+
+// employerWealth values the employer's shares and equity grants against the user's net
+// worth. Stock option accounts count their vested value rather than a recorded balance.
+func (s *Service) employerWealth(ctx context.Context, settings *Settings, exposure *EmployerExposure) error {
+	accountsResp, err := s.accountSvc.ListWithBalance(ctx)
+	if err != nil {
+		return err
+	}
+
+	if settings.EmployerSymbol != nil {
+		accountHoldings, err := s.holdingValues(ctx)
+		if err != nil {
+			return err
+		}
+		for _, list := range accountHoldings {
+			for _, h := range list {
+				if h.Symbol != nil && *h.Symbol == *settings.EmployerSymbol {
+					exposure.HeldShares += h.Value
+				}
+			}
+		}
+	}
+
+	asOf := civil.TodayIn(ctx).Time
+	for _, acc := range accountsResp.Accounts {
+		if acc.Type == account.AccountTypeStockOptions {
+			summary, err := s.accountSvc.GetOptionsSummaryAsOf(ctx, acc.ID, asOf)
+			if err != nil {
+				return err
+			}
+			exposure.VestedEquity += summary.TotalIntrinsicValue
+			exposure.UnvestedEquity += summary.UnvestedValue
+			exposure.NetWorth += summary.TotalIntrinsicValue
+
+			// Equity vesting within the year at today's FMV
+			nextYear, err := s.accountSvc.GetOptionsSummaryAsOf(ctx, acc.ID, asOf.AddDate(1, 0, 0))
+			if err != nil {
+				return err
+			}
+			exposure.UpcomingVests += math.Max(nextYear.TotalIntrinsicValue-summary.TotalIntrinsicValue, 0)
+			continue
+		}
+
+		if acc.CurrentBalance == nil {
+			continue
+		}
+		if acc.IsAsset {
+			exposure.NetWorth += *acc.CurrentBalance
+		} else {
+			exposure.NetWorth -= math.Abs(*acc.CurrentBalance)
+		}
+	}
+
+	exposure.TotalEquity = exposure.HeldShares + exposure.VestedEquity + exposure.UnvestedEquity
+	if exposure.NetWorth > 0 {
+		exposure.NetWorthPercent = (exposure.HeldShares + exposure.VestedEquity) / exposure.NetWorth * 100
+	}
+	if combined := exposure.NetWorth + exposure.UnvestedEquity; combined > 0 {
+		exposure.CombinedNetWorthPercent = exposure.TotalEquity / combined * 100
+	}
+	return nil
+}
+
+// employerIncome splits the user's expected income over the next twelve months into what the
+// employer pays and the rest. One-time income records aren't expected to recur and are left out.
+func (s *Service) employerIncome(ctx context.Context, settings *Settings, exposure *EmployerExposure) error {
+	year := civil.TodayIn(ctx).Year()
+	records, err := s.incomeSvc.ListIncomeRecords(ctx, &income.ListIncomeRecordsRequest{Year: &year})
+	if err != nil {
+		return err
+	}
+
+	isEmployer := func(name string) bool {
+		return settings.EmployerName == nil || strings.EqualFold(strings.TrimSpace(name), *settings.EmployerName)
+	}
+
+	for _, record := range records.Records {
+		if record.Frequency == income.FrequencyOneTime {
+			continue
+		}
+		annual := record.AnnualAmount()
+		exposure.ExpectedIncome += annual
+		if record.Category == income.CategoryEmployment && isEmployer(record.Source) {
+			exposure.EmployerSalary += annual
+		}
+	}
+
+	plans, err := s.incomeSvc.ListBonusPlans(ctx)
+	if err != nil {
+		return err
+	}
+	for _, plan := range plans.Plans {
+		if plan.IsActive && isEmployer(plan.Employer) {
+			exposure.ExpectedBonuses += plan.ExpectedPayout(exposure.EmployerSalary)
+		}
+	}
+
+	exposure.EmployerIncome = exposure.EmployerSalary + exposure.ExpectedBonuses + exposure.UpcomingVests
+	exposure.ExpectedIncome += exposure.ExpectedBonuses + exposure.UpcomingVests
+	if exposure.ExpectedIncome > 0 {
+		exposure.IncomePercent = exposure.EmployerIncome / exposure.ExpectedIncome * 100
+	}
+	return nil
+}
+
+// exposureAlerts raises an alert for each measure of employer exposure past its threshold.
+// Net worth is judged with unvested equity included, since it is lost with the job.
+func exposureAlerts(exposure *EmployerExposure, settings *Settings) []*ExposureAlert {
+	alerts := make([]*ExposureAlert, 0)
+
+	if exposure.CombinedNetWorthPercent > settings.ConcentrationThresholdPercent {
+		alerts = append(alerts, &ExposureAlert{
+			Kind:             ExposureNetWorth,
+			Percent:          exposure.CombinedNetWorthPercent,
+			ThresholdPercent: settings.ConcentrationThresholdPercent,
+			Message: fmt.Sprintf("Employer equity is %.1f%% of your net worth including unvested grants, above your %.0f%% limit",
+				exposure.CombinedNetWorthPercent, settings.ConcentrationThresholdPercent),
+		})
+	}
+	if exposure.IncomePercent > settings.IncomeThresholdPercent {
+		alerts = append(alerts, &ExposureAlert{
+			Kind:             ExposureIncome,
+			Percent:          exposure.IncomePercent,
+			ThresholdPercent: settings.IncomeThresholdPercent,
+			Message: fmt.Sprintf("Your employer provides %.1f%% of your expected income over the next year, above your %.0f%% limit",
+				exposure.IncomePercent, settings.IncomeThresholdPercent),
+		})
+	}
+
+	return alerts
+}
+
+// roundExposure rounds values to cents and percents to two decimals
+func roundExposure(e *EmployerExposure) {
+	round := func(v float64) float64 { return math.Round(v*100) / 100 }
+
+	for _, v := range []*float64{
+		&e.HeldShares, &e.VestedEquity, &e.UnvestedEquity, &e.TotalEquity, &e.NetWorth,
+		&e.NetWorthPercent, &e.CombinedNetWorthPercent, &e.EmployerSalary, &e.ExpectedBonuses,
+		&e.UpcomingVests, &e.EmployerIncome, &e.ExpectedIncome, &e.IncomePercent,
+	} {
+		*v = round(*v)
+	}
+	for _, alert := range e.Alerts {
+		alert.Percent = round(alert.Percent)
+	}
+}
@@ -0,0 +1,159 @@
+package risk
+
+import (
+	"money/internal/account"
+	"money/internal/holdings"
+)
+
+// Tolerance is how much volatility a user is willing to hold
+type Tolerance string
+
+const (
+	ToleranceConservative Tolerance = "conservative"
+	ToleranceModerate     Tolerance = "moderate"
+	ToleranceGrowth       Tolerance = "growth"
+	ToleranceAggressive   Tolerance = "aggressive"
+)
+
+// maxRiskScores is the highest portfolio risk score each tolerance accepts
+var maxRiskScores = map[Tolerance]int{
+	ToleranceConservative: 3,
+	ToleranceModerate:     5,
+	ToleranceGrowth:       7,
+	ToleranceAggressive:   9,
+}
+
+// DefaultConcentrationThresholdPercent is the share of the portfolio a single position may
+// make up before it is flagged
+const DefaultConcentrationThresholdPercent = 25.0
+
+// DefaultIncomeThresholdPercent is the share of expected income one employer may provide
+// before it is flagged
+const DefaultIncomeThresholdPercent = 50.0
+
+// VolatilitySource says where a volatility estimate came from
+type VolatilitySource string
+
+const (
+	VolatilityFromHistory VolatilitySource = "history" // Daily closes in quote_history
+	VolatilityFromDefault VolatilitySource = "default" // Asset class or account type default
+)
+
+// ConcentrationKind is the kind of position a concentration flag is about
+type ConcentrationKind string
+
+const (
+	ConcentrationHolding  ConcentrationKind = "holding"  // One symbol across accounts
+	ConcentrationEmployer ConcentrationKind = "employer" // Employer stock plus equity compensation
+)
+
+// Settings is a user's risk tolerance and how concentration is flagged
+type Settings struct {
+	RiskTolerance                 Tolerance `json:"risk_tolerance"`
+	MaxRiskScore                  int       `json:"max_risk_score"`
+	EmployerSymbol                *string   `json:"employer_symbol,omitempty"`
+	EmployerName                  *string   `json:"employer_name,omitempty"`
+	ConcentrationThresholdPercent float64   `json:"concentration_threshold_percent"`
+	IncomeThresholdPercent        float64   `json:"income_threshold_percent"`
+}
+
+// UpdateSettingsRequest changes a user's risk settings. Omitted fields are left unchanged.
+type UpdateSettingsRequest struct {
+	RiskTolerance                 *Tolerance `json:"risk_tolerance,omitempty"`
+	EmployerSymbol                *string    `json:"employer_symbol,omitempty"` // Empty string clears it
+	EmployerName                  *string    `json:"employer_name,omitempty"`   // Empty string clears it
+	ConcentrationThresholdPercent *float64   `json:"concentration_threshold_percent,omitempty"`
+	IncomeThresholdPercent        *float64   `json:"income_threshold_percent,omitempty"`
+}
+
+// HoldingRisk is the volatility and risk score of one holding
+type HoldingRisk struct {
+	HoldingID        string               `json:"holding_id"`
+	Symbol           *string              `json:"symbol,omitempty"`
+	Type             holdings.HoldingType `json:"type"`
+	AssetClass       holdings.AssetClass  `json:"asset_class"`
+	Value            float64              `json:"value"`
+	Volatility       float64              `json:"volatility"` // Annualized, in percent
+	VolatilitySource VolatilitySource     `json:"volatility_source"`
+	RiskScore        int                  `json:"risk_score"` // 1 (lowest) to 10
+}
+
+// AccountRisk is the value-weighted volatility and risk score of an account
+type AccountRisk struct {
+	AccountID        string              `json:"account_id"`
+	AccountName      string              `json:"account_name"`
+	AccountType      account.AccountType `json:"account_type"`
+	Value            float64             `json:"value"`
+	Volatility       float64             `json:"volatility"`
+	VolatilitySource VolatilitySource    `json:"volatility_source"`
+	RiskScore        int                 `json:"risk_score"`
+	Holdings         []*HoldingRisk      `json:"holdings"`
+}
+
+// Concentration flags a position making up too much of the portfolio
+type Concentration struct {
+	Kind            ConcentrationKind `json:"kind"`
+	Symbol          *string           `json:"symbol,omitempty"`
+	Value           float64           `json:"value"`
+	Percent         float64           `json:"percent"`
+	UnvestedValue   float64           `json:"unvested_value"`   // Employer only
+	CombinedPercent float64           `json:"combined_percent"` // Including unvested equity in both value and portfolio
+	Message         string            `json:"message"`
+}
+
+// PortfolioRisk summarizes the risk of the user's asset accounts against their tolerance
+type PortfolioRisk struct {
+	TotalValue                    float64          `json:"total_value"`
+	Volatility                    float64          `json:"volatility"`
+	RiskScore                     int              `json:"risk_score"`
+	RiskTolerance                 Tolerance        `json:"risk_tolerance"`
+	MaxRiskScore                  int              `json:"max_risk_score"`
+	WithinTolerance               bool             `json:"within_tolerance"`
+	ConcentrationThresholdPercent float64          `json:"concentration_threshold_percent"`
+	Accounts                      []*AccountRisk   `json:"accounts"`
+	Concentrations                []*Concentration `json:"concentrations"`
+}
+
+// ExposureAlertKind is the measure an employer exposure alert is raised on
+type ExposureAlertKind string
+
+const (
+	ExposureNetWorth ExposureAlertKind = "net_worth" // Employer equity as a share of net worth
+	ExposureIncome   ExposureAlertKind = "income"    // Employer pay and equity as a share of expected income
+)
+
+// ExposureAlert is raised when employer exposure passes one of the user's thresholds
+type ExposureAlert struct {
+	Kind             ExposureAlertKind `json:"kind"`
+	Percent          float64           `json:"percent"`
+	ThresholdPercent float64           `json:"threshold_percent"`
+	Message          string            `json:"message"`
+}
+
+// EmployerExposure is how much of the user's wealth and expected income depends on their
+// employer. Equity is valued at the latest FMV or quote.
+type EmployerExposure struct {
+	EmployerName   *string `json:"employer_name,omitempty"`
+	EmployerSymbol *string `json:"employer_symbol,omitempty"`
+
+	// Wealth
+	HeldShares     float64 `json:"held_shares"`     // Employer symbol holdings, including ESPP purchases
+	VestedEquity   float64 `json:"vested_equity"`   // Vested grants in stock option accounts
+	UnvestedEquity float64 `json:"unvested_equity"` // Unvested grants in stock option accounts
+	TotalEquity    float64 `json:"total_equity"`
+	NetWorth       float64 `json:"net_worth"`
+	// Held and vested equity over net worth
+	NetWorthPercent float64 `json:"net_worth_percent"`
+	// Including unvested equity in both the equity and net worth
+	CombinedNetWorthPercent float64 `json:"combined_net_worth_percent"`
+
+	// Income over the next twelve months
+	EmployerSalary  float64 `json:"employer_salary"`  // Recurring employment income from the employer
+	ExpectedBonuses float64 `json:"expected_bonuses"` // Bonus and DPSP plans with the employer
+	UpcomingVests   float64 `json:"upcoming_vests"`   // Equity vesting in the next twelve months
+	EmployerIncome  float64 `json:"employer_income"`
+	ExpectedIncome  float64 `json:"expected_income"` // All recurring income, bonuses and vests
+	IncomePercent   float64 `json:"income_percent"`
+
+	Alerts []*ExposureAlert `json:"alerts"`
+}
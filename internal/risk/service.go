@@ -0,0 +1,485 @@
+// Package risk scores the volatility of accounts and holdings and summarizes portfolio risk
+// against the user's risk tolerance.
+package risk
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"money/internal/account"
+	"money/internal/auth"
+	"money/internal/holdings"
+	"money/internal/income"
+)
+
+// minHistoryPoints is how many daily closes a symbol needs before its own volatility is used
+const minHistoryPoints = 20
+
+// historyWindow is how far back daily closes are read for volatility
+const historyWindow = 365 * 24 * time.Hour
+
+// singleCompanyVolatility is the default volatility of one company's stock, used for equity
+// compensation when the employer has no quote history
+const singleCompanyVolatility = 40.0
+
+// assetClassVolatility is the default annualized volatility, in percent, of each asset class
+var assetClassVolatility = map[holdings.AssetClass]float64{
+	holdings.AssetClassEquity:      18,
+	holdings.AssetClassFixedIncome: 6,
+	holdings.AssetClassCash:        0.5,
+	holdings.AssetClassCrypto:      70,
+	holdings.AssetClassOther:       20,
+}
+
+// accountTypeVolatility is the default volatility of an asset account valued by its balance
+// rather than its holdings
+var accountTypeVolatility = map[account.AccountType]float64{
+	account.AccountTypeChecking:     0.5,
+	account.AccountTypeSavings:      0.5,
+	account.AccountTypeCash:         0.5,
+	account.AccountTypeBrokerage:    18,
+	account.AccountTypeTFSA:         18,
+	account.AccountTypeRRSP:         18,
+	account.AccountTypeCrypto:       70,
+	account.AccountTypeRealEstate:   10,
+	account.AccountTypeVehicle:      15,
+	account.AccountTypeCollectible:  20,
+	account.AccountTypeStockOptions: singleCompanyVolatility,
+}
+
+// riskScoreBands are the volatility upper bounds of risk scores 1 to 9; anything above the
+// last band scores 10
+var riskScoreBands = []float64{1, 4, 8, 12, 16, 20, 25, 35, 50}
+
+// Service provides risk scoring functionality
+type Service struct {
+	db          *sql.DB
+	accountSvc  *account.Service
+	holdingsSvc *holdings.Service
+	incomeSvc   *income.Service
+}
+
+// NewService creates a new risk service
+func NewService(db *sql.DB, accountSvc *account.Service, holdingsSvc *holdings.Service, incomeSvc *income.Service) *Service {
+	return &Service{
+		db:          db,
+		accountSvc:  accountSvc,
+		holdingsSvc: holdingsSvc,
+		incomeSvc:   incomeSvc,
+	}
+}
+
+// GetSettings returns the user's risk settings, defaulting to a moderate tolerance
+func (s *Service) GetSettings(ctx context.Context) (*Settings, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	settings := &Settings{
+		RiskTolerance:                 ToleranceModerate,
+		ConcentrationThresholdPercent: DefaultConcentrationThresholdPercent,
+		IncomeThresholdPercent:        DefaultIncomeThresholdPercent,
+	}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT risk_tolerance, employer_symbol, employer_name, concentration_threshold_percent, income_threshold_percent
+		FROM risk_settings WHERE user_id = $1
+	`, userID).Scan(&settings.RiskTolerance, &settings.EmployerSymbol, &settings.EmployerName,
+		&settings.ConcentrationThresholdPercent, &settings.IncomeThresholdPercent)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get risk settings: %w", err)
+	}
+	settings.MaxRiskScore = maxRiskScores[settings.RiskTolerance]
+
+	return settings, nil
+}
+
+// UpdateSettings changes the user's risk tolerance, employer and alert thresholds
+func (s *Service) UpdateSettings(ctx context.Context, req *UpdateSettingsRequest) (*Settings, error) {
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.RiskTolerance != nil {
+		if _, ok := maxRiskScores[*req.RiskTolerance]; !ok {
+			return nil, fmt.Errorf("invalid risk tolerance: %s", *req.RiskTolerance)
+		}
+		settings.RiskTolerance = *req.RiskTolerance
+	}
+	if req.EmployerSymbol != nil {
+		symbol := strings.ToUpper(strings.TrimSpace(*req.EmployerSymbol))
+		settings.EmployerSymbol = nil
+		if symbol != "" {
+			settings.EmployerSymbol = &symbol
+		}
+	}
+	if req.EmployerName != nil {
+		name := strings.TrimSpace(*req.EmployerName)
+		settings.EmployerName = nil
+		if name != "" {
+			settings.EmployerName = &name
+		}
+	}
+	if req.ConcentrationThresholdPercent != nil {
+		if *req.ConcentrationThresholdPercent <= 0 || *req.ConcentrationThresholdPercent > 100 {
+			return nil, fmt.Errorf("concentration threshold must be above 0 and at most 100")
+		}
+		settings.ConcentrationThresholdPercent = *req.ConcentrationThresholdPercent
+	}
+	if req.IncomeThresholdPercent != nil {
+		if *req.IncomeThresholdPercent <= 0 || *req.IncomeThresholdPercent > 100 {
+			return nil, fmt.Errorf("income threshold must be above 0 and at most 100")
+		}
+		settings.IncomeThresholdPercent = *req.IncomeThresholdPercent
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO risk_settings (user_id, risk_tolerance, employer_symbol, employer_name,
+			concentration_threshold_percent, income_threshold_percent, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id) DO UPDATE SET
+			risk_tolerance = excluded.risk_tolerance,
+			employer_symbol = excluded.employer_symbol,
+			employer_name = excluded.employer_name,
+			concentration_threshold_percent = excluded.concentration_threshold_percent,
+			income_threshold_percent = excluded.income_threshold_percent,
+			updated_at = excluded.updated_at
+	`, auth.GetUserID(ctx), settings.RiskTolerance, settings.EmployerSymbol, settings.EmployerName,
+		settings.ConcentrationThresholdPercent, settings.IncomeThresholdPercent, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to save risk settings: %w", err)
+	}
+
+	settings.MaxRiskScore = maxRiskScores[settings.RiskTolerance]
+	return settings, nil
+}
+
+// GetPortfolioRisk scores each active asset account and its holdings and compares the
+// portfolio with the user's risk tolerance. Holdings are valued at the latest quote, falling
+// back to cost basis; stock option accounts count their vested value. The portfolio's
+// volatility is the value-weighted average of its parts, which ignores diversification and
+// so overstates the risk of a spread of holdings.
+func (s *Service) GetPortfolioRisk(ctx context.Context) (*PortfolioRisk, error) {
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accountsResp, err := s.accountSvc.ListWithBalance(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accountHoldings, err := s.holdingValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	vol := &volatilities{svc: s.holdingsSvc, since: time.Now().Add(-historyWindow), bySymbol: make(map[string]*float64)}
+	portfolio := &PortfolioRisk{
+		RiskTolerance:                 settings.RiskTolerance,
+		MaxRiskScore:                  settings.MaxRiskScore,
+		ConcentrationThresholdPercent: settings.ConcentrationThresholdPercent,
+		Accounts:                      make([]*AccountRisk, 0),
+	}
+
+	unvestedValue := 0.0
+	weighted := 0.0
+	for _, acc := range accountsResp.Accounts {
+		if !acc.IsAsset {
+			continue
+		}
+
+		accRisk := &AccountRisk{
+			AccountID:        acc.ID,
+			AccountName:      acc.Name,
+			AccountType:      acc.Type,
+			VolatilitySource: VolatilityFromDefault,
+			Holdings:         make([]*HoldingRisk, 0),
+		}
+
+		switch {
+		case len(accountHoldings[acc.ID]) > 0:
+			accWeighted := 0.0
+			for _, h := range accountHoldings[acc.ID] {
+				h.Volatility, h.VolatilitySource = assetClassVolatility[h.AssetClass], VolatilityFromDefault
+				if h.Symbol != nil && h.AssetClass != holdings.AssetClassCash {
+					if v, err := vol.of(ctx, *h.Symbol); err != nil {
+						return nil, err
+					} else if v != nil {
+						h.Volatility, h.VolatilitySource = *v, VolatilityFromHistory
+					}
+				}
+				h.RiskScore = riskScore(h.Volatility)
+				if h.VolatilitySource == VolatilityFromHistory {
+					accRisk.VolatilitySource = VolatilityFromHistory
+				}
+				accRisk.Value += h.Value
+				accWeighted += h.Value * h.Volatility
+				accRisk.Holdings = append(accRisk.Holdings, h)
+			}
+			if accRisk.Value > 0 {
+				accRisk.Volatility = accWeighted / accRisk.Value
+			}
+
+		case acc.Type == account.AccountTypeStockOptions:
+			summary, err := s.accountSvc.GetOptionsSummary(ctx, acc.ID)
+			if err != nil {
+				return nil, err
+			}
+			accRisk.Value = summary.TotalIntrinsicValue
+			unvestedValue += summary.UnvestedValue
+			accRisk.Volatility = singleCompanyVolatility
+			if settings.EmployerSymbol != nil {
+				if v, err := vol.of(ctx, *settings.EmployerSymbol); err != nil {
+					return nil, err
+				} else if v != nil {
+					accRisk.Volatility, accRisk.VolatilitySource = *v, VolatilityFromHistory
+				}
+			}
+
+		case acc.CurrentBalance != nil:
+			accRisk.Value = *acc.CurrentBalance
+			if v, ok := accountTypeVolatility[acc.Type]; ok {
+				accRisk.Volatility = v
+			} else {
+				accRisk.Volatility = assetClassVolatility[holdings.AssetClassOther]
+			}
+		}
+
+		if accRisk.Value <= 0 {
+			continue
+		}
+		accRisk.RiskScore = riskScore(accRisk.Volatility)
+		portfolio.TotalValue += accRisk.Value
+		weighted += accRisk.Value * accRisk.Volatility
+		portfolio.Accounts = append(portfolio.Accounts, accRisk)
+	}
+
+	if portfolio.TotalValue > 0 {
+		portfolio.Volatility = weighted / portfolio.TotalValue
+	}
+	portfolio.RiskScore = riskScore(portfolio.Volatility)
+	portfolio.WithinTolerance = portfolio.RiskScore <= portfolio.MaxRiskScore
+	portfolio.Concentrations = findConcentrations(portfolio.Accounts, unvestedValue, settings.EmployerSymbol, settings.ConcentrationThresholdPercent)
+
+	sort.SliceStable(portfolio.Accounts, func(i, j int) bool {
+		return portfolio.Accounts[i].Value > portfolio.Accounts[j].Value
+	})
+	roundPortfolio(portfolio)
+	return portfolio, nil
+}
+
+// holdingValues reads the user's holdings in active accounts, valued at the latest quote and
+// falling back to cost basis, grouped by account
+func (s *Service) holdingValues(ctx context.Context) (map[string][]*HoldingRisk, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT h.id, h.account_id, h.type, h.symbol, h.quantity, h.cost_basis, h.amount, q.price, sec.asset_class
+		FROM holdings h
+		JOIN accounts a ON a.id = h.account_id
+		LEFT JOIN market_data q ON q.symbol = UPPER(h.symbol)
+		LEFT JOIN securities sec ON sec.symbol = UPPER(h.symbol)
+		WHERE a.user_id = $1 AND a.is_active = 1
+		ORDER BY h.symbol
+	`, auth.GetUserID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holdings: %w", err)
+	}
+	defer rows.Close()
+
+	byAccount := make(map[string][]*HoldingRisk)
+	for rows.Next() {
+		var accountID string
+		var quantity, costBasis, amount, price *float64
+		var securityClass *holdings.AssetClass
+		h := &HoldingRisk{}
+		if err := rows.Scan(&h.HoldingID, &accountID, &h.Type, &h.Symbol, &quantity, &costBasis, &amount, &price, &securityClass); err != nil {
+			return nil, fmt.Errorf("failed to scan holding: %w", err)
+		}
+
+		switch {
+		case h.Type == holdings.HoldingTypeCash && amount != nil:
+			h.Value = *amount
+		case quantity != nil && price != nil:
+			h.Value = *quantity * *price
+		case quantity != nil && costBasis != nil:
+			h.Value = *quantity * *costBasis
+		}
+		if h.Symbol != nil {
+			symbol := strings.ToUpper(*h.Symbol)
+			h.Symbol = &symbol
+		}
+		h.AssetClass = holdings.HoldingAssetClass(h.Type, securityClass)
+		byAccount[accountID] = append(byAccount[accountID], h)
+	}
+
+	return byAccount, rows.Err()
+}
+
+// volatilities caches the historical volatility of each symbol for one summary
+type volatilities struct {
+	svc      *holdings.Service
+	since    time.Time
+	bySymbol map[string]*float64
+}
+
+// of returns a symbol's historical volatility, or nil when it has too little quote history
+func (v *volatilities) of(ctx context.Context, symbol string) (*float64, error) {
+	if cached, ok := v.bySymbol[symbol]; ok {
+		return cached, nil
+	}
+
+	points, err := v.svc.GetQuoteHistory(ctx, symbol, v.since)
+	if err != nil {
+		return nil, err
+	}
+	var result *float64
+	if volatility, ok := historicalVolatility(points); ok {
+		result = &volatility
+	}
+	v.bySymbol[symbol] = result
+	return result, nil
+}
+
+// historicalVolatility annualizes the standard deviation of daily log returns, in percent.
+// Returns between closes more than a day apart are scaled to a daily return.
+func historicalVolatility(points []holdings.QuotePoint) (float64, bool) {
+	if len(points) < minHistoryPoints {
+		return 0, false
+	}
+
+	returns := make([]float64, 0, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		prev, cur := points[i-1], points[i]
+		days := cur.Date.Sub(prev.Date).Hours() / 24
+		if prev.Price <= 0 || cur.Price <= 0 || days <= 0 {
+			continue
+		}
+		returns = append(returns, math.Log(cur.Price/prev.Price)/math.Sqrt(days))
+	}
+	if len(returns) < 2 {
+		return 0, false
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns) - 1)
+
+	return math.Sqrt(variance) * math.Sqrt(365) * 100, true
+}
+
+// riskScore maps an annualized volatility, in percent, to a score from 1 to 10
+func riskScore(volatility float64) int {
+	for i, bound := range riskScoreBands {
+		if volatility <= bound {
+			return i + 1
+		}
+	}
+	return len(riskScoreBands) + 1
+}
+
+// findConcentrations flags symbols making up more than threshold percent of the portfolio,
+// and the employer's stock once vested and unvested equity compensation are added to any
+// shares held. The employer flag replaces the holding flag for the employer's symbol.
+func findConcentrations(accounts []*AccountRisk, unvestedValue float64, employerSymbol *string, threshold float64) []*Concentration {
+	concentrations := make([]*Concentration, 0)
+
+	total := 0.0
+	employerValue := 0.0
+	bySymbol := make(map[string]float64)
+	for _, acc := range accounts {
+		total += acc.Value
+		if acc.AccountType == account.AccountTypeStockOptions && len(acc.Holdings) == 0 {
+			employerValue += acc.Value
+		}
+		for _, h := range acc.Holdings {
+			if h.Symbol == nil || h.AssetClass == holdings.AssetClassCash {
+				continue
+			}
+			if employerSymbol != nil && *h.Symbol == *employerSymbol {
+				employerValue += h.Value
+				continue
+			}
+			bySymbol[*h.Symbol] += h.Value
+		}
+	}
+	if total <= 0 && unvestedValue <= 0 {
+		return concentrations
+	}
+
+	for symbol, value := range bySymbol {
+		percent := value / total * 100
+		if percent <= threshold {
+			continue
+		}
+		symbol := symbol
+		concentrations = append(concentrations, &Concentration{
+			Kind:            ConcentrationHolding,
+			Symbol:          &symbol,
+			Value:           value,
+			Percent:         percent,
+			CombinedPercent: percent,
+			Message:         fmt.Sprintf("%s is %.1f%% of your portfolio, above your %.0f%% limit", symbol, percent, threshold),
+		})
+	}
+
+	if employerValue > 0 || unvestedValue > 0 {
+		percent := 0.0
+		if total > 0 {
+			percent = employerValue / total * 100
+		}
+		combined := (employerValue + unvestedValue) / (total + unvestedValue) * 100
+		if combined > threshold {
+			concentrations = append(concentrations, &Concentration{
+				Kind:            ConcentrationEmployer,
+				Symbol:          employerSymbol,
+				Value:           employerValue,
+				Percent:         percent,
+				UnvestedValue:   unvestedValue,
+				CombinedPercent: combined,
+				Message: fmt.Sprintf("Employer stock is %.1f%% of your portfolio including unvested equity, above your %.0f%% limit",
+					combined, threshold),
+			})
+		}
+	}
+
+	sort.Slice(concentrations, func(i, j int) bool {
+		return concentrations[i].CombinedPercent > concentrations[j].CombinedPercent
+	})
+	return concentrations
+}
+
+// roundPortfolio rounds values to cents and volatilities and percents to two decimals
+func roundPortfolio(p *PortfolioRisk) {
+	round := func(v float64) float64 { return math.Round(v*100) / 100 }
+
+	p.TotalValue = round(p.TotalValue)
+	p.Volatility = round(p.Volatility)
+	for _, acc := range p.Accounts {
+		acc.Value = round(acc.Value)
+		acc.Volatility = round(acc.Volatility)
+		for _, h := range acc.Holdings {
+			h.Value = round(h.Value)
+			h.Volatility = round(h.Volatility)
+		}
+	}
+	for _, c := range p.Concentrations {
+		c.Value = round(c.Value)
+		c.Percent = round(c.Percent)
+		c.UnvestedValue = round(c.UnvestedValue)
+		c.CombinedPercent = round(c.CombinedPercent)
+	}
+}
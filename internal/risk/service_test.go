@@ -0,0 +1,197 @@
+package risk
+
+import (
+	"testing"
+	"time"
+
+	"money/internal/account"
+	"money/internal/holdings"
+	"money/internal/income"
+)
+
+func setupService(t *testing.T) (*Service, func()) {
+	t.Helper()
+	db := account.SetupTestDB(t)
+	service := NewService(db, account.SetupAccountService(t, db), holdings.NewService(db), income.NewService(db))
+	return service, func() {
+		_, _ = db.Exec(`DELETE FROM risk_settings WHERE user_id LIKE 'test-%'`)
+		_, _ = db.Exec(`DELETE FROM bonus_plans WHERE user_id LIKE 'test-%'`)
+		_, _ = db.Exec(`DELETE FROM income_records WHERE user_id LIKE 'test-%'`)
+		account.CleanupTestDB(t, db)
+	}
+}
+
+func TestGetPortfolioRisk_FlagsConcentratedHoldingAboveTolerance(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange: 10k of one stock alongside 10k in savings
+	userID := "test-user-risk-portfolio-1"
+	account.CreateTestUser(t, service.db, userID)
+	ctx := account.CreateAuthContext(userID)
+
+	brokerageID := account.CreateTestAccount(t, service.db, userID, account.AccountTypeBrokerage)
+	_, err := service.db.Exec(`
+		INSERT INTO holdings (id, account_id, type, symbol, quantity, cost_basis, created_at, updated_at)
+		VALUES ($1, $2, 'stock', 'XYZ', 100, 100, $3, $3)
+	`, "test-holding-risk-1", brokerageID, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to create holding: %v", err)
+	}
+	savingsID := account.CreateTestAccount(t, service.db, userID, account.AccountTypeSavings)
+	account.CreateTestBalance(t, service.db, savingsID, 10000)
+
+	tolerance := ToleranceConservative
+	if _, err := service.UpdateSettings(ctx, &UpdateSettingsRequest{RiskTolerance: &tolerance}); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+
+	// Act
+	portfolio, err := service.GetPortfolioRisk(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetPortfolioRisk failed: %v", err)
+	}
+	if portfolio.TotalValue != 20000 || len(portfolio.Accounts) != 2 {
+		t.Fatalf("Expected 20000 across 2 accounts, got %.2f across %d", portfolio.TotalValue, len(portfolio.Accounts))
+	}
+	// (10000 * 18 + 10000 * 0.5) / 20000 = 9.25% volatility, score 4
+	if portfolio.Volatility != 9.25 || portfolio.RiskScore != 4 {
+		t.Errorf("Expected 9.25%% volatility scoring 4, got %.2f%% scoring %d", portfolio.Volatility, portfolio.RiskScore)
+	}
+	if portfolio.WithinTolerance || portfolio.MaxRiskScore != 3 {
+		t.Errorf("Expected score 4 to exceed the conservative maximum of 3, got max %d", portfolio.MaxRiskScore)
+	}
+	if len(portfolio.Concentrations) != 1 || portfolio.Concentrations[0].Kind != ConcentrationHolding ||
+		*portfolio.Concentrations[0].Symbol != "XYZ" || portfolio.Concentrations[0].Percent != 50 {
+		t.Errorf("Expected XYZ flagged at 50%%, got %+v", portfolio.Concentrations)
+	}
+}
+
+func TestFindConcentrations_AddsUnvestedEquityToEmployerStock(t *testing.T) {
+	// Arrange: 20k of employer stock and 10k vested options in a 100k portfolio, 50k unvested
+	employer := "ACME"
+	accounts := []*AccountRisk{
+		{AccountType: account.AccountTypeBrokerage, Value: 90000, Holdings: []*HoldingRisk{
+			{Symbol: &employer, AssetClass: holdings.AssetClassEquity, Value: 20000},
+			{Symbol: strPtr("VEQT"), AssetClass: holdings.AssetClassEquity, Value: 20000},
+			{AssetClass: holdings.AssetClassCash, Value: 50000},
+		}},
+		{AccountType: account.AccountTypeStockOptions, Value: 10000},
+	}
+
+	// Act
+	concentrations := findConcentrations(accounts, 50000, &employer, 25)
+
+	// Assert: 30k is 30% alone, (30k + 50k) / 150k = 53.33% with unvested equity
+	if len(concentrations) != 1 {
+		t.Fatalf("Expected only the employer flagged, got %+v", concentrations)
+	}
+	c := concentrations[0]
+	if c.Kind != ConcentrationEmployer || c.Value != 30000 || c.Percent != 30 {
+		t.Errorf("Expected 30000 (30%%) of employer stock, got %.2f (%.2f%%)", c.Value, c.Percent)
+	}
+	if c.CombinedPercent < 53.33 || c.CombinedPercent > 53.34 {
+		t.Errorf("Expected 53.33%% including unvested equity, got %.2f%%", c.CombinedPercent)
+	}
+}
+
+func TestHistoricalVolatility_NeedsEnoughHistory(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := make([]holdings.QuotePoint, 0)
+	for i := 0; i < minHistoryPoints; i++ {
+		price := 100.0
+		if i%2 == 1 {
+			price = 101
+		}
+		points = append(points, holdings.QuotePoint{Date: start.AddDate(0, 0, i), Price: price})
+	}
+
+	if _, ok := historicalVolatility(points[:minHistoryPoints-1]); ok {
+		t.Error("Expected no volatility from too little history")
+	}
+	volatility, ok := historicalVolatility(points)
+	if !ok || volatility < 10 || volatility > 25 {
+		t.Errorf("Expected roughly 19%% volatility from 1%% daily swings, got %.2f (ok %v)", volatility, ok)
+	}
+	if riskScore(volatility) != 6 || riskScore(0.5) != 1 || riskScore(70) != 10 {
+		t.Errorf("Unexpected risk scores: %d, %d, %d", riskScore(volatility), riskScore(0.5), riskScore(70))
+	}
+}
+
+func TestGetEmployerExposure_AlertsOnNetWorthAndIncome(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange: 10k of employer shares in a 40k net worth, a 100k salary with a 10% bonus
+	// and 12k of rental income
+	userID := "test-user-risk-employer-1"
+	account.CreateTestUser(t, service.db, userID)
+	ctx := account.CreateAuthContext(userID)
+
+	brokerageID := account.CreateTestAccount(t, service.db, userID, account.AccountTypeBrokerage)
+	account.CreateTestBalance(t, service.db, brokerageID, 20000)
+	_, err := service.db.Exec(`
+		INSERT INTO holdings (id, account_id, type, symbol, quantity, cost_basis, created_at, updated_at)
+		VALUES ($1, $2, 'stock', 'acme', 100, 100, $3, $3)
+	`, "test-holding-risk-employer-1", brokerageID, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to create holding: %v", err)
+	}
+	savingsID := account.CreateTestAccount(t, service.db, userID, account.AccountTypeSavings)
+	account.CreateTestBalance(t, service.db, savingsID, 20000)
+
+	year := time.Now().Year()
+	for _, req := range []*income.CreateIncomeRecordRequest{
+		{Source: "ACME Corp", Category: income.CategoryEmployment, Amount: 100000, Currency: "CAD", Frequency: income.FrequencyAnnually, TaxYear: year},
+		{Source: "Basement suite", Category: income.CategoryRental, Amount: 1000, Currency: "CAD", Frequency: income.FrequencyMonthly, TaxYear: year},
+		{Source: "ACME Corp", Category: income.CategoryEmployment, Amount: 5000, Currency: "CAD", Frequency: income.FrequencyOneTime, TaxYear: year},
+	} {
+		if _, err := service.incomeSvc.CreateIncomeRecord(ctx, req); err != nil {
+			t.Fatalf("CreateIncomeRecord failed: %v", err)
+		}
+	}
+	_, err = service.incomeSvc.CreateBonusPlan(ctx, &income.CreateBonusPlanRequest{
+		Name: "Annual bonus", Employer: "ACME Corp", PlanType: income.BonusPlanBonus,
+		TargetPercent: 0.10, PayoutMonth: 3, Currency: "CAD",
+	})
+	if err != nil {
+		t.Fatalf("CreateBonusPlan failed: %v", err)
+	}
+
+	symbol, name, threshold := "ACME", "acme corp", 20.0
+	_, err = service.UpdateSettings(ctx, &UpdateSettingsRequest{
+		EmployerSymbol: &symbol, EmployerName: &name, ConcentrationThresholdPercent: &threshold,
+	})
+	if err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+
+	// Act
+	exposure, err := service.GetEmployerExposure(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetEmployerExposure failed: %v", err)
+	}
+	if exposure.HeldShares != 10000 || exposure.NetWorth != 40000 || exposure.NetWorthPercent != 25 {
+		t.Errorf("Expected 10000 held of 40000 net worth (25%%), got %.2f of %.2f (%.2f%%)",
+			exposure.HeldShares, exposure.NetWorth, exposure.NetWorthPercent)
+	}
+	// The one-time payment doesn't recur: (100000 + 10000) / (100000 + 12000 + 10000)
+	if exposure.EmployerSalary != 100000 || exposure.ExpectedBonuses != 10000 || exposure.ExpectedIncome != 122000 {
+		t.Errorf("Expected 100000 salary, 10000 bonus and 122000 income, got %.2f, %.2f and %.2f",
+			exposure.EmployerSalary, exposure.ExpectedBonuses, exposure.ExpectedIncome)
+	}
+	if exposure.IncomePercent != 90.16 {
+		t.Errorf("Expected 90.16%% of income from the employer, got %.2f%%", exposure.IncomePercent)
+	}
+	if len(exposure.Alerts) != 2 || exposure.Alerts[0].Kind != ExposureNetWorth || exposure.Alerts[1].Kind != ExposureIncome {
+		t.Errorf("Expected net worth and income alerts, got %+v", exposure.Alerts)
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
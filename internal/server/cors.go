@@ -0,0 +1,108 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/cors"
+)
+
+// EnvironmentProduction is the APP_ENV value that enables strict startup validation
+const EnvironmentProduction = "production"
+
+// CORSConfig is the cross-origin allowlist for the API
+type CORSConfig struct {
+	// Origins are exact origins such as https://app.example.com, wildcard subdomains such
+	// as https://*.example.com, or * for any origin
+	Origins []string
+	// AllowCredentials lets browsers send cookies and auth headers cross-origin. The
+	// request's origin is only reflected back when it matches the allowlist.
+	AllowCredentials bool
+}
+
+// ParseCORSOrigins splits a comma-separated origin list, dropping blanks and trailing slashes
+func ParseCORSOrigins(spec string) []string {
+	origins := make([]string, 0)
+	for _, origin := range strings.Split(spec, ",") {
+		origin = strings.TrimSuffix(strings.TrimSpace(origin), "/")
+		if origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// Validate checks the allowlist at startup. Any origin combined with credentials is always
+// rejected; in production, origins must also be explicit and served over HTTPS.
+func (c CORSConfig) Validate(environment string) error {
+	if len(c.Origins) == 0 {
+		return fmt.Errorf("at least one CORS origin is required")
+	}
+
+	production := environment == EnvironmentProduction
+	for _, origin := range c.Origins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				return fmt.Errorf("CORS origin * cannot be used with credentials")
+			}
+			if production {
+				return fmt.Errorf("CORS origin * is not allowed in production")
+			}
+			continue
+		}
+
+		u, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+			return fmt.Errorf("invalid CORS origin %q: expected scheme://host[:port]", origin)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("invalid CORS origin %q: scheme must be http or https", origin)
+		}
+		if strings.Contains(strings.Replace(origin, "://*.", "://", 1), "*") {
+			return fmt.Errorf("invalid CORS origin %q: wildcards are only allowed as a leading subdomain", origin)
+		}
+		if production && u.Scheme != "https" {
+			return fmt.Errorf("CORS origin %q must use https in production", origin)
+		}
+	}
+	return nil
+}
+
+// AllowOrigin reports whether a request origin matches the allowlist. A wildcard matches
+// one or more subdomain labels but never the bare domain.
+func (c CORSConfig) AllowOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range c.Origins {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+
+		scheme, host, ok := strings.Cut(allowed, "://*.")
+		if !ok {
+			continue
+		}
+		prefix := scheme + "://"
+		if strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, "."+host) &&
+			len(origin) > len(prefix)+len(host)+1 {
+			return true
+		}
+	}
+	return false
+}
+
+// CORSHandler returns the CORS middleware for the allowlist
+func (c CORSConfig) CORSHandler() func(http.Handler) http.Handler {
+	return cors.Handler(cors.Options{
+		AllowOriginFunc: func(r *http.Request, origin string) bool {
+			return c.AllowOrigin(origin)
+		},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Demo-Mode", APIVersionHeader},
+		ExposedHeaders:   []string{"Link", APIVersionHeader, "Deprecation", "Sunset"},
+		AllowCredentials: c.AllowCredentials,
+		MaxAge:           300,
+	})
+}
@@ -0,0 +1,45 @@
+package server
+
+import "testing"
+
+func TestCORSConfig_AllowOriginMatchesWildcardSubdomains(t *testing.T) {
+	config := CORSConfig{Origins: ParseCORSOrigins("https://app.example.com/, https://*.moneyy.dev")}
+
+	cases := map[string]bool{
+		"https://app.example.com":    true,
+		"https://APP.example.com":    true,
+		"https://evil.example.com":   false,
+		"https://eu.moneyy.dev":      true,
+		"https://a.b.moneyy.dev":     true,
+		"https://moneyy.dev":         false,
+		"http://eu.moneyy.dev":       false,
+		"https://eu.moneyy.dev.evil": false,
+		"https://notmoneyy.dev":      false,
+	}
+	for origin, want := range cases {
+		if got := config.AllowOrigin(origin); got != want {
+			t.Errorf("AllowOrigin(%q) = %v, want %v", origin, got, want)
+		}
+	}
+}
+
+func TestCORSConfig_ValidatePerEnvironment(t *testing.T) {
+	if err := (CORSConfig{Origins: []string{"*"}}).Validate("development"); err != nil {
+		t.Errorf("Expected * to be allowed in development, got %v", err)
+	}
+	if err := (CORSConfig{Origins: []string{"*"}}).Validate(EnvironmentProduction); err == nil {
+		t.Error("Expected * to be rejected in production")
+	}
+	if err := (CORSConfig{Origins: []string{"*"}, AllowCredentials: true}).Validate("development"); err == nil {
+		t.Error("Expected * with credentials to be rejected")
+	}
+	if err := (CORSConfig{Origins: []string{"http://app.example.com"}}).Validate(EnvironmentProduction); err == nil {
+		t.Error("Expected a non-HTTPS origin to be rejected in production")
+	}
+	if err := (CORSConfig{Origins: []string{"https://app.*.example.com"}}).Validate("development"); err == nil {
+		t.Error("Expected a wildcard outside the leading subdomain to be rejected")
+	}
+	if err := (CORSConfig{Origins: []string{"https://*.example.com"}, AllowCredentials: true}).Validate(EnvironmentProduction); err != nil {
+		t.Errorf("Expected an HTTPS wildcard subdomain to be valid, got %v", err)
+	}
+}
@@ -1,10 +1,18 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"money/internal/account"
+	"money/internal/auth"
+	"money/internal/civil"
 	"money/internal/server"
 
 	"github.com/go-chi/chi/v5"
@@ -27,6 +35,14 @@ func (h *AccountHandler) RegisterRoutes(r chi.Router) {
 	r.Get("/accounts-with-balance", h.ListWithBalance)
 	r.Get("/summary/accounts", h.Summary)
 	r.Get("/assets/summary", h.GetAssetsSummary)
+	r.Get("/crypto/summary", h.GetCryptoSummary)
+	r.Get("/equity/summary", h.GetEquitySummary)
+	r.Get("/equity/expiring-grants", h.GetExpiringGrants)
+	r.Get("/real-estate/summary", h.GetRealEstateSummary)
+	r.Get("/liabilities/interest-paid", h.GetInterestPaid)
+	r.Get("/documents/expiring", h.GetExpiringDocuments)
+	r.Get("/reports/estate", h.GetEstateReport)
+	r.Get("/reports/net-worth-statement", h.GetNetWorthStatement)
 
 	r.Route("/accounts", func(r chi.Router) {
 		r.Post("/", h.Create)
@@ -34,6 +50,19 @@ func (h *AccountHandler) RegisterRoutes(r chi.Router) {
 		r.Get("/{id}", h.Get)
 		r.Put("/{id}", h.Update)
 		r.Delete("/{id}", h.Delete)
+		r.Post("/{id}/merge", h.MergeAccounts)
+		r.Put("/{id}/entity", h.AssignEntity)
+		r.Put("/{id}/privacy", h.SetPrivacy)
+		r.Put("/{id}/exclusions", h.SetExclusions)
+		r.Get("/{id}/projection-assumptions", h.GetProjectionAssumptions)
+		r.Put("/{id}/employer-match", h.SetEmployerMatch)
+		r.Get("/{id}/employer-match", h.GetEmployerMatch)
+		r.Put("/{id}/projection-assumptions", h.UpdateProjectionAssumptions)
+		r.Get("/{id}/balance-change", h.GetBalanceChange)
+		r.Get("/{id}/performance", h.GetPerformance)
+		r.Get("/{id}/timeline", h.GetTimeline)
+		r.Post("/bulk-delete/preview", h.PreviewBulkDelete)
+		r.Post("/bulk-delete", h.BulkDelete)
 
 		// Mortgage routes
 		r.Post("/{id}/mortgage", h.CreateMortgageDetails)
@@ -48,6 +77,38 @@ func (h *AccountHandler) RegisterRoutes(r chi.Router) {
 		r.Get("/{id}/loan/amortization", h.GetLoanAmortizationSchedule)
 		r.Post("/{id}/loan/payments", h.RecordLoanPayment)
 		r.Get("/{id}/loan/payments", h.GetLoanPayments)
+		r.Post("/{id}/loan/refinance/analyze", h.AnalyzeRefinance)
+		r.Post("/{id}/loan/refinance", h.RefinanceLoan)
+		r.Get("/{id}/loan/refinances", h.GetLoanRefinances)
+		r.Put("/{id}/loan/student", h.SetStudentLoanDetails)
+		r.Get("/{id}/loan/student", h.GetStudentLoanDetails)
+		r.Put("/{id}/auto-posting", h.SetAutoPosting)
+		r.Get("/{id}/auto-posting", h.GetAutoPosting)
+		r.Post("/{id}/auto-posting/reconcile", h.ClearReconciliationFlags)
+
+		// Documents vault routes
+		r.Post("/{id}/documents", h.UploadDocument)
+		r.Get("/{id}/documents", h.ListDocuments)
+		r.Get("/{id}/documents/{documentId}", h.GetDocument)
+		r.Get("/{id}/documents/{documentId}/download", h.DownloadDocument)
+		r.Put("/{id}/documents/{documentId}", h.UpdateDocument)
+		r.Delete("/{id}/documents/{documentId}", h.DeleteDocument)
+
+		// External reference routes
+		r.Put("/{id}/references", h.SetExternalReferences)
+		r.Get("/{id}/references", h.GetExternalReferences)
+		r.Get("/{id}/contact-card", h.GetContactCard)
+
+		// Estate planning routes
+		r.Put("/{id}/estate", h.SetEstateDetails)
+		r.Get("/{id}/estate", h.GetEstateDetails)
+
+		// HELOC routes
+		r.Post("/{id}/heloc", h.CreateHELOCDetails)
+		r.Get("/{id}/heloc", h.GetHELOCDetails)
+		r.Get("/{id}/heloc/status", h.GetHELOCStatus)
+		r.Post("/{id}/heloc/transactions", h.RecordHELOCTransaction)
+		r.Get("/{id}/heloc/transactions", h.GetHELOCTransactions)
 
 		// Asset routes
 		r.Post("/{id}/asset", h.CreateAssetDetails)
@@ -60,6 +121,7 @@ func (h *AccountHandler) RegisterRoutes(r chi.Router) {
 
 		// Stock Options routes
 		r.Post("/{id}/options/grants", h.CreateEquityGrant)
+		r.Post("/{id}/options/grants/bulk", h.BulkCreateEquityGrants)
 		r.Get("/{id}/options/grants", h.GetEquityGrants)
 		r.Get("/{id}/options/grants/{grantId}", h.GetEquityGrant)
 		r.Put("/{id}/options/grants/{grantId}", h.UpdateEquityGrant)
@@ -80,14 +142,23 @@ func (h *AccountHandler) RegisterRoutes(r chi.Router) {
 		r.Get("/{id}/options/sales", h.GetSales)
 		r.Put("/{id}/options/sales/{saleId}", h.UpdateSale)
 		r.Delete("/{id}/options/sales/{saleId}", h.DeleteSale)
+		r.Post("/{id}/options/sales/sell-to-cover", h.RecordSellToCoverSales)
 
 		r.Post("/{id}/options/fmv", h.RecordFMV)
 		r.Get("/{id}/options/fmv", h.GetFMVHistory)
 		r.Get("/{id}/options/fmv/current", h.GetCurrentFMV)
+		r.Get("/{id}/options/fmv/chart", h.GetFMVChart)
+		r.Get("/{id}/options/recalculations", h.GetEquityRecalculations)
 
 		r.Get("/{id}/options/summary", h.GetOptionsSummary)
+		r.Get("/{id}/options/value-history", h.GetVestedValueHistory)
+		r.Get("/{id}/options/vesting-timeline", h.GetVestingTimeline)
 		r.Get("/{id}/options/tax-summary", h.GetTaxSummary)
 		r.Get("/{id}/options/vesting-timeline", h.GetUpcomingVestingEvents)
+		r.Get("/{id}/options/holding-period-alerts", h.GetHoldingPeriodAlerts)
+		r.Get("/{id}/options/expirations", h.GetGrantExpirations)
+		r.Post("/{id}/options/expirations/process", h.ProcessGrantExpirations)
+		r.Post("/{id}/options/termination-scenario", h.GetTerminationScenario)
 	})
 }
 
@@ -127,6 +198,14 @@ func (h *AccountHandler) ListWithBalance(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if baseCurrency := r.URL.Query().Get("base_currency"); baseCurrency != "" {
+		resp.ConvertedTotal, err = h.service.ConvertTotals(r.Context(), resp.ByCurrency, baseCurrency)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
 	server.RespondJSON(w, http.StatusOK, resp)
 }
 
@@ -138,6 +217,14 @@ func (h *AccountHandler) Summary(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if baseCurrency := r.URL.Query().Get("base_currency"); baseCurrency != "" {
+		summary.ConvertedTotal, err = h.service.ConvertTotals(r.Context(), summary.BalancesByCurrency, baseCurrency)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
 	server.RespondJSON(w, http.StatusOK, summary)
 }
 
@@ -198,6 +285,172 @@ func (h *AccountHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	server.RespondJSON(w, http.StatusOK, resp)
 }
 
+// MergeAccounts merges a duplicate account into the account in the URL
+func (h *AccountHandler) MergeAccounts(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req account.MergeAccountsRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.MergeAccounts(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// AssignEntity moves an account to an entity, or back to the user
+func (h *AccountHandler) AssignEntity(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req account.AssignEntityRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	acc, err := h.service.AssignAccountEntity(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, acc)
+}
+
+// SetPrivacy marks an account private or not
+func (h *AccountHandler) SetPrivacy(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req account.SetAccountPrivacyRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	acc, err := h.service.SetPrivacy(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, acc)
+}
+
+// SetExclusions leaves an account out of projections, net worth or spending analytics
+func (h *AccountHandler) SetExclusions(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req account.SetAccountExclusionsRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	acc, err := h.service.SetExclusions(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, acc)
+}
+
+// GetProjectionAssumptions retrieves an account's projection overrides
+func (h *AccountHandler) GetProjectionAssumptions(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	resp, err := h.service.GetProjectionAssumptions(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// UpdateProjectionAssumptions sets an account's projection overrides
+func (h *AccountHandler) UpdateProjectionAssumptions(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req account.UpdateProjectionAssumptionsRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.UpdateProjectionAssumptions(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// PreviewBulkDelete lists the records that deleting the given accounts would remove
+func (h *AccountHandler) PreviewBulkDelete(w http.ResponseWriter, r *http.Request) {
+	var req account.BulkDeleteRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.PreviewBulkDelete(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// BulkDelete deletes several accounts and their records
+func (h *AccountHandler) BulkDelete(w http.ResponseWriter, r *http.Request) {
+	var req account.BulkDeleteRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.BulkDelete(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
 // Mortgage handlers
 
 // CreateMortgageDetails creates mortgage details for an account
@@ -367,189 +620,987 @@ func (h *AccountHandler) RecordLoanPayment(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	var req account.CreateLoanPaymentRequest
-	if err := server.ParseJSON(r, &req); err != nil {
-		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
-		return
+	var req account.CreateLoanPaymentRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	req.AccountID = id
+	payment, err := h.service.RecordLoanPayment(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, payment)
+}
+
+// GetLoanPayments retrieves all loan payments
+func (h *AccountHandler) GetLoanPayments(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	payments, err := h.service.GetLoanPayments(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, payments)
+}
+
+// AnalyzeRefinance compares refinancing a loan on new terms against keeping it
+func (h *AccountHandler) AnalyzeRefinance(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req account.RefinanceRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	analysis, err := h.service.AnalyzeRefinance(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, analysis)
+}
+
+// RefinanceLoan replaces a loan's terms with refinanced ones, keeping the old terms in its history
+func (h *AccountHandler) RefinanceLoan(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req account.RefinanceRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.RefinanceLoan(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, resp)
+}
+
+// GetLoanRefinances retrieves a loan's refinance history
+func (h *AccountHandler) GetLoanRefinances(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	refinances, err := h.service.GetLoanRefinances(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, refinances)
+}
+
+// SetStudentLoanDetails records a loan's grace period, subsidy periods and repayment program
+func (h *AccountHandler) SetStudentLoanDetails(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req account.SetStudentLoanDetailsRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	details, err := h.service.SetStudentLoanDetails(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, details)
+}
+
+// GetStudentLoanDetails retrieves a loan's student loan terms
+func (h *AccountHandler) GetStudentLoanDetails(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	details, err := h.service.GetStudentLoanDetails(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, details)
+}
+
+// SetEmployerMatch sets a retirement account's employer matching terms
+func (h *AccountHandler) SetEmployerMatch(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req account.SetEmployerMatchRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	details, err := h.service.SetEmployerMatch(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, details)
+}
+
+// GetEmployerMatch retrieves an account's employer matching terms and vested employer money
+func (h *AccountHandler) GetEmployerMatch(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	details, err := h.service.GetEmployerMatch(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, details)
+}
+
+// SetAutoPosting turns scheduled payment auto-posting on or off for a loan or mortgage
+func (h *AccountHandler) SetAutoPosting(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req account.SetAutoPostingRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	settings, err := h.service.SetAutoPosting(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, settings)
+}
+
+// GetAutoPosting retrieves an account's auto-posting state
+func (h *AccountHandler) GetAutoPosting(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	settings, err := h.service.GetAutoPosting(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, settings)
+}
+
+// ClearReconciliationFlags marks an account's flagged payments as reviewed
+func (h *AccountHandler) ClearReconciliationFlags(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	settings, err := h.service.ClearReconciliationFlags(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, settings)
+}
+
+// CreateHELOCDetails links a line of credit to the property that secures it
+func (h *AccountHandler) CreateHELOCDetails(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req account.CreateHELOCDetailsRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	details, err := h.service.CreateHELOCDetails(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, details)
+}
+
+// GetHELOCDetails retrieves HELOC details for an account
+func (h *AccountHandler) GetHELOCDetails(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	details, err := h.service.GetHELOCDetails(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, details)
+}
+
+// GetHELOCStatus retrieves a HELOC's credit limit, balance and available credit
+func (h *AccountHandler) GetHELOCStatus(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	status, err := h.service.GetHELOCStatus(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, status)
+}
+
+// RecordHELOCTransaction records a HELOC draw, repayment or interest charge
+func (h *AccountHandler) RecordHELOCTransaction(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req account.CreateHELOCTransactionRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	transaction, err := h.service.RecordHELOCTransaction(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, transaction)
+}
+
+// GetHELOCTransactions retrieves all HELOC transactions
+func (h *AccountHandler) GetHELOCTransactions(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	transactions, err := h.service.GetHELOCTransactions(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, transactions)
+}
+
+// Asset handlers
+
+// CreateAssetDetails creates asset details for an account
+func (h *AccountHandler) CreateAssetDetails(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req account.CreateAssetDetailsRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	req.AccountID = id
+	details, err := h.service.CreateAssetDetails(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, details)
+}
+
+// GetAssetDetails retrieves asset details for an account
+func (h *AccountHandler) GetAssetDetails(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	details, err := h.service.GetAssetDetails(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, details)
+}
+
+// UpdateAssetDetails updates asset details for an account
+func (h *AccountHandler) UpdateAssetDetails(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req account.UpdateAssetDetailsRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	details, err := h.service.UpdateAssetDetails(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, details)
+}
+
+// GetAssetValuation retrieves asset valuation with current value
+func (h *AccountHandler) GetAssetValuation(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	valuation, err := h.service.GetAssetValuation(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, valuation)
+}
+
+// GetDepreciationHistory retrieves depreciation history for an asset
+func (h *AccountHandler) GetDepreciationHistory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	history, err := h.service.GetDepreciationHistory(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, history)
+}
+
+// RecordDepreciation records a manual depreciation entry
+func (h *AccountHandler) RecordDepreciation(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req account.CreateDepreciationEntryRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	req.AccountID = id
+	entry, err := h.service.RecordDepreciation(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, entry)
+}
+
+// GetDepreciationSchedule retrieves the depreciation schedule
+func (h *AccountHandler) GetDepreciationSchedule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	schedule, err := h.service.GetDepreciationSchedule(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, schedule)
+}
+
+// GetAssetsSummary retrieves all assets with calculated current values
+func (h *AccountHandler) GetAssetsSummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.service.GetAssetsSummary(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, summary)
+}
+
+// GetCryptoSummary values the positions across all crypto accounts
+func (h *AccountHandler) GetCryptoSummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.service.GetCryptoSummary(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, summary)
+}
+
+// GetEquitySummary combines all stock options accounts
+func (h *AccountHandler) GetEquitySummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.service.GetEquitySummary(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, summary)
+}
+
+// GetRealEstateSummary values all properties against their purchase price and secured debt
+func (h *AccountHandler) GetRealEstateSummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.service.GetRealEstateSummary(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, summary)
+}
+
+// UploadDocument stores a file against an account. The multipart form carries the file
+// along with name, document_type, expiry_date, renewal_date, reminder_days and notes.
+func (h *AccountHandler) UploadDocument(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	// Leave room for the form fields around the file
+	r.Body = http.MaxBytesReader(w, r.Body, account.MaxDocumentSize+1<<20)
+	if err := r.ParseMultipartForm(account.MaxDocumentSize); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("failed to parse form: %w", err))
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("failed to get file: %w", err))
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, fmt.Errorf("failed to read file: %w", err))
+		return
+	}
+
+	req := account.UploadDocumentRequest{
+		Name:         r.FormValue("name"),
+		DocumentType: r.FormValue("document_type"),
+		ContentType:  header.Header.Get("Content-Type"),
+		Content:      content,
+	}
+	if req.Name == "" {
+		req.Name = header.Filename
+	}
+	if notes := r.FormValue("notes"); notes != "" {
+		req.Notes = &notes
+	}
+	for field, target := range map[string]**account.Date{"expiry_date": &req.ExpiryDate, "renewal_date": &req.RenewalDate} {
+		value := r.FormValue(field)
+		if value == "" {
+			continue
+		}
+		date, err := civil.Parse(value)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid %s: %w", field, err))
+			return
+		}
+		*target = &date
+	}
+	if value := r.FormValue("reminder_days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid reminder_days: %w", err))
+			return
+		}
+		req.ReminderDays = &days
+	}
+
+	doc, err := h.service.UploadDocument(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, doc)
+}
+
+// ListDocuments lists an account's documents
+func (h *AccountHandler) ListDocuments(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	documents, err := h.service.ListDocuments(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, documents)
+}
+
+// GetDocument retrieves a document's metadata
+func (h *AccountHandler) GetDocument(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	documentID := chi.URLParam(r, "documentId")
+	if id == "" || documentID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID and document ID are required"))
+		return
+	}
+
+	doc, err := h.service.GetDocument(r.Context(), id, documentID)
+	if err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, doc)
+}
+
+// DownloadDocument streams a document's file
+func (h *AccountHandler) DownloadDocument(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	documentID := chi.URLParam(r, "documentId")
+	if id == "" || documentID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID and document ID are required"))
+		return
+	}
+
+	doc, content, err := h.service.GetDocumentContent(r.Context(), id, documentID)
+	if err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", doc.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": doc.Name}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(content)
+}
+
+// UpdateDocument updates a document's metadata
+func (h *AccountHandler) UpdateDocument(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	documentID := chi.URLParam(r, "documentId")
+	if id == "" || documentID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID and document ID are required"))
+		return
+	}
+
+	var req account.UpdateDocumentRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	doc, err := h.service.UpdateDocument(r.Context(), id, documentID, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, doc)
+}
+
+// DeleteDocument removes a document
+func (h *AccountHandler) DeleteDocument(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	documentID := chi.URLParam(r, "documentId")
+	if id == "" || documentID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID and document ID are required"))
+		return
+	}
+
+	if err := h.service.DeleteDocument(r.Context(), id, documentID); err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// GetExpiringDocuments lists documents whose expiry or renewal reminder is due
+func (h *AccountHandler) GetExpiringDocuments(w http.ResponseWriter, r *http.Request) {
+	documents, err := h.service.GetExpiringDocuments(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, documents)
+}
+
+// GetInterestPaid summarizes interest paid per loan and mortgage for ?year= (defaults to
+// the current year)
+func (h *AccountHandler) GetInterestPaid(w http.ResponseWriter, r *http.Request) {
+	year := time.Now().Year()
+	if yearStr := r.URL.Query().Get("year"); yearStr != "" {
+		parsed, err := strconv.Atoi(yearStr)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid year: %w", err))
+			return
+		}
+		year = parsed
 	}
 
-	req.AccountID = id
-	payment, err := h.service.RecordLoanPayment(r.Context(), id, &req)
+	summary, err := h.service.GetInterestPaid(r.Context(), year)
 	if err != nil {
 		server.RespondError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	server.RespondJSON(w, http.StatusCreated, payment)
+	server.RespondJSON(w, http.StatusOK, summary)
 }
 
-// GetLoanPayments retrieves all loan payments
-func (h *AccountHandler) GetLoanPayments(w http.ResponseWriter, r *http.Request) {
+// GetBalanceChange explains an account's balance change over ?from=&to=, defaulting to
+// the month to date
+func (h *AccountHandler) GetBalanceChange(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
 		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
 		return
 	}
 
-	payments, err := h.service.GetLoanPayments(r.Context(), id)
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+	now := time.Now().UTC()
+	if to == nil {
+		endOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1).Add(-time.Nanosecond)
+		to = &endOfDay
+	}
+	if from == nil {
+		monthStart := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+		from = &monthStart
+	}
+
+	change, err := h.service.GetBalanceChange(r.Context(), id, *from, *to)
 	if err != nil {
 		server.RespondError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	server.RespondJSON(w, http.StatusOK, payments)
+	server.RespondJSON(w, http.StatusOK, change)
 }
 
-// Asset handlers
-
-// CreateAssetDetails creates asset details for an account
-func (h *AccountHandler) CreateAssetDetails(w http.ResponseWriter, r *http.Request) {
+// GetPerformance returns an account's time-weighted and money-weighted returns over
+// ?from=&to=, defaulting to the twelve months up to today
+func (h *AccountHandler) GetPerformance(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
 		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
 		return
 	}
 
-	var req account.CreateAssetDetailsRequest
-	if err := server.ParseJSON(r, &req); err != nil {
-		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
 		return
 	}
+	now := time.Now().UTC()
+	if to == nil {
+		endOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1).Add(-time.Nanosecond)
+		to = &endOfDay
+	}
+	if from == nil {
+		yearAgo := time.Date(to.Year()-1, to.Month(), to.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+		from = &yearAgo
+	}
 
-	req.AccountID = id
-	details, err := h.service.CreateAssetDetails(r.Context(), id, &req)
+	performance, err := h.service.GetPerformance(r.Context(), id, *from, *to)
 	if err != nil {
 		server.RespondError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	server.RespondJSON(w, http.StatusCreated, details)
+	server.RespondJSON(w, http.StatusOK, performance)
 }
 
-// GetAssetDetails retrieves asset details for an account
-func (h *AccountHandler) GetAssetDetails(w http.ResponseWriter, r *http.Request) {
+// GetTimeline returns everything that happened to an account, newest first. Accepts
+// ?types= as a comma-separated list of event types, with ?limit= and ?offset= to page.
+func (h *AccountHandler) GetTimeline(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
 		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
 		return
 	}
 
-	details, err := h.service.GetAssetDetails(r.Context(), id)
+	var req account.TimelineRequest
+	if typesStr := r.URL.Query().Get("types"); typesStr != "" {
+		for _, t := range strings.Split(typesStr, ",") {
+			eventType := account.TimelineEventType(strings.TrimSpace(t))
+			if !account.IsValidTimelineEventType(eventType) {
+				server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid timeline event type: %s", eventType))
+				return
+			}
+			req.Types = append(req.Types, eventType)
+		}
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %s", limitStr))
+			return
+		}
+		req.Limit = parsed
+	}
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		parsed, err := strconv.Atoi(offsetStr)
+		if err != nil || parsed < 0 {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid offset: %s", offsetStr))
+			return
+		}
+		req.Offset = parsed
+	}
+
+	timeline, err := h.service.GetTimeline(r.Context(), id, req)
 	if err != nil {
 		server.RespondError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	server.RespondJSON(w, http.StatusOK, details)
+	server.RespondJSON(w, http.StatusOK, timeline)
 }
 
-// UpdateAssetDetails updates asset details for an account
-func (h *AccountHandler) UpdateAssetDetails(w http.ResponseWriter, r *http.Request) {
+// SetExternalReferences records how to reach an account's institution
+func (h *AccountHandler) SetExternalReferences(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
 		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
 		return
 	}
 
-	var req account.UpdateAssetDetailsRequest
+	var req account.SetExternalReferencesRequest
 	if err := server.ParseJSON(r, &req); err != nil {
 		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
 		return
 	}
 
-	details, err := h.service.UpdateAssetDetails(r.Context(), id, &req)
+	refs, err := h.service.SetExternalReferences(r.Context(), id, &req)
 	if err != nil {
-		server.RespondError(w, http.StatusInternalServerError, err)
+		server.RespondError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	server.RespondJSON(w, http.StatusOK, details)
+	server.RespondJSON(w, http.StatusOK, refs)
 }
 
-// GetAssetValuation retrieves asset valuation with current value
-func (h *AccountHandler) GetAssetValuation(w http.ResponseWriter, r *http.Request) {
+// GetExternalReferences retrieves an account's external references, with the account
+// number in full when ?reveal=true and the user logged in recently
+func (h *AccountHandler) GetExternalReferences(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
 		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
 		return
 	}
+	reveal := r.URL.Query().Get("reveal") == "true"
+	if reveal && !auth.RecentlyAuthenticated(r.Context()) {
+		server.RespondError(w, http.StatusForbidden, fmt.Errorf("log in again to reveal the account number"))
+		return
+	}
 
-	valuation, err := h.service.GetAssetValuation(r.Context(), id)
+	refs, err := h.service.GetExternalReferences(r.Context(), id, reveal)
 	if err != nil {
-		server.RespondError(w, http.StatusInternalServerError, err)
+		server.RespondError(w, http.StatusNotFound, err)
 		return
 	}
 
-	server.RespondJSON(w, http.StatusOK, valuation)
+	server.RespondJSON(w, http.StatusOK, refs)
 }
 
-// GetDepreciationHistory retrieves depreciation history for an asset
-func (h *AccountHandler) GetDepreciationHistory(w http.ResponseWriter, r *http.Request) {
+// GetContactCard returns who to contact about an account, in the order to try them
+func (h *AccountHandler) GetContactCard(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
 		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
 		return
 	}
 
-	history, err := h.service.GetDepreciationHistory(r.Context(), id)
+	card, err := h.service.GetContactCard(r.Context(), id)
 	if err != nil {
-		server.RespondError(w, http.StatusInternalServerError, err)
+		server.RespondError(w, http.StatusNotFound, err)
 		return
 	}
 
-	server.RespondJSON(w, http.StatusOK, history)
+	server.RespondJSON(w, http.StatusOK, card)
 }
 
-// RecordDepreciation records a manual depreciation entry
-func (h *AccountHandler) RecordDepreciation(w http.ResponseWriter, r *http.Request) {
+// SetEstateDetails records an account's registration and beneficiary designations
+func (h *AccountHandler) SetEstateDetails(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
 		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
 		return
 	}
 
-	var req account.CreateDepreciationEntryRequest
+	var req account.SetEstateDetailsRequest
 	if err := server.ParseJSON(r, &req); err != nil {
 		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
 		return
 	}
 
-	req.AccountID = id
-	entry, err := h.service.RecordDepreciation(r.Context(), id, &req)
+	details, err := h.service.SetEstateDetails(r.Context(), id, &req)
 	if err != nil {
-		server.RespondError(w, http.StatusInternalServerError, err)
+		server.RespondError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	server.RespondJSON(w, http.StatusCreated, entry)
+	server.RespondJSON(w, http.StatusOK, details)
 }
 
-// GetDepreciationSchedule retrieves the depreciation schedule
-func (h *AccountHandler) GetDepreciationSchedule(w http.ResponseWriter, r *http.Request) {
+// GetEstateDetails retrieves an account's estate designations
+func (h *AccountHandler) GetEstateDetails(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
 		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
 		return
 	}
 
-	schedule, err := h.service.GetDepreciationSchedule(r.Context(), id)
+	details, err := h.service.GetEstateDetails(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, details)
+}
+
+// GetEstateReport returns the estate summary as JSON, or as a PDF with ?format=pdf
+func (h *AccountHandler) GetEstateReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.GetEstateReport(r.Context())
 	if err != nil {
 		server.RespondError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	server.RespondJSON(w, http.StatusOK, schedule)
+	respondEstateReport(w, r, report)
 }
 
-// GetAssetsSummary retrieves all assets with calculated current values
-func (h *AccountHandler) GetAssetsSummary(w http.ResponseWriter, r *http.Request) {
-	summary, err := h.service.GetAssetsSummary(r.Context())
+// GetNetWorthStatement returns the net worth statement as JSON, or as a PDF with ?format=pdf
+func (h *AccountHandler) GetNetWorthStatement(w http.ResponseWriter, r *http.Request) {
+	statement, err := h.service.GetNetWorthStatement(r.Context())
 	if err != nil {
 		server.RespondError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	server.RespondJSON(w, http.StatusOK, summary)
+	respondNetWorthStatement(w, r, statement)
+}
+
+// respondEstateReport writes the estate report in the format asked for by ?format=
+func respondEstateReport(w http.ResponseWriter, r *http.Request, report *account.EstateReport) {
+	if r.URL.Query().Get("format") != "pdf" {
+		server.RespondJSON(w, http.StatusOK, report)
+		return
+	}
+	respondPDF(w, fmt.Sprintf("estate-summary-%s.pdf", report.GeneratedAt.Format("2006-01-02")),
+		account.RenderEstateReportPDF(report))
+}
+
+// respondNetWorthStatement writes the net worth statement in the format asked for by ?format=
+func respondNetWorthStatement(w http.ResponseWriter, r *http.Request, statement *account.NetWorthStatement) {
+	if r.URL.Query().Get("format") != "pdf" {
+		server.RespondJSON(w, http.StatusOK, statement)
+		return
+	}
+	respondPDF(w, fmt.Sprintf("net-worth-statement-%s.pdf", statement.GeneratedAt.Format("2006-01-02")),
+		account.RenderNetWorthStatementPDF(statement))
+}
+
+// respondPDF writes a PDF file as a download
+func respondPDF(w http.ResponseWriter, filename string, content []byte) {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(content)
 }
 
 // Stock Options handlers
@@ -578,6 +1629,33 @@ func (h *AccountHandler) CreateEquityGrant(w http.ResponseWriter, r *http.Reques
 	server.RespondJSON(w, http.StatusCreated, grant)
 }
 
+// BulkCreateEquityGrants creates several grants with schedules and exercises atomically
+func (h *AccountHandler) BulkCreateEquityGrants(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req account.BulkCreateGrantsRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.BulkCreateEquityGrants(r.Context(), id, &req)
+	if errors.Is(err, account.ErrBulkGrantsInvalid) {
+		server.RespondJSON(w, http.StatusUnprocessableEntity, resp)
+		return
+	}
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, resp)
+}
+
 // GetEquityGrants retrieves all equity grants for an account
 func (h *AccountHandler) GetEquityGrants(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -849,6 +1927,23 @@ func (h *AccountHandler) GetSales(w http.ResponseWriter, r *http.Request) {
 	server.RespondJSON(w, http.StatusOK, sales)
 }
 
+// RecordSellToCoverSales records the shares sold to cover withholding on vested RSUs
+func (h *AccountHandler) RecordSellToCoverSales(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	sales, err := h.service.RecordSellToCoverSales(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, sales)
+}
+
 // UpdateSale updates a sale
 func (h *AccountHandler) UpdateSale(w http.ResponseWriter, r *http.Request) {
 	saleID := chi.URLParam(r, "saleId")
@@ -930,6 +2025,68 @@ func (h *AccountHandler) GetFMVHistory(w http.ResponseWriter, r *http.Request) {
 	server.RespondJSON(w, http.StatusOK, history)
 }
 
+// GetVestingTimeline returns monthly vesting buckets per grant and per currency for the
+// next ?years= years
+func (h *AccountHandler) GetVestingTimeline(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	years := account.DefaultVestingTimelineYears
+	if yearsStr := r.URL.Query().Get("years"); yearsStr != "" {
+		parsed, err := strconv.Atoi(yearsStr)
+		if err != nil || parsed < 1 || parsed > account.MaxVestingTimelineYears {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid years: %s", yearsStr))
+			return
+		}
+		years = parsed
+	}
+
+	timeline, err := h.service.GetVestingTimeline(r.Context(), id, years)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, timeline)
+}
+
+// GetFMVChart returns FMV history per currency alongside cumulative vested shares
+func (h *AccountHandler) GetFMVChart(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	chart, err := h.service.GetFMVChart(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, chart)
+}
+
+// GetEquityRecalculations lists the notes of exercises and sales recalculated after strike price or FMV edits
+func (h *AccountHandler) GetEquityRecalculations(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	recalculations, err := h.service.GetEquityRecalculations(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, recalculations)
+}
+
 // GetCurrentFMV retrieves the current FMV for an account
 func (h *AccountHandler) GetCurrentFMV(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -952,7 +2109,7 @@ func (h *AccountHandler) GetCurrentFMV(w http.ResponseWriter, r *http.Request) {
 	server.RespondJSON(w, http.StatusOK, entry)
 }
 
-// GetOptionsSummary retrieves the options summary for an account
+// GetOptionsSummary retrieves the options summary for an account, optionally as of a date
 func (h *AccountHandler) GetOptionsSummary(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
@@ -960,7 +2117,18 @@ func (h *AccountHandler) GetOptionsSummary(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	summary, err := h.service.GetOptionsSummary(r.Context(), id)
+	asOf := time.Now()
+	if asOfStr := r.URL.Query().Get("as_of"); asOfStr != "" {
+		parsed, err := time.Parse("2006-01-02", asOfStr)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid as_of date: %w", err))
+			return
+		}
+		// Include everything dated on the as-of day itself
+		asOf = parsed.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	summary, err := h.service.GetOptionsSummaryAsOf(r.Context(), id, asOf)
 	if err != nil {
 		server.RespondError(w, http.StatusInternalServerError, err)
 		return
@@ -969,7 +2137,46 @@ func (h *AccountHandler) GetOptionsSummary(w http.ResponseWriter, r *http.Reques
 	server.RespondJSON(w, http.StatusOK, summary)
 }
 
-// GetTaxSummary retrieves tax summary for an account and year
+// GetVestedValueHistory retrieves the monthly vested value series for an account
+func (h *AccountHandler) GetVestedValueHistory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var from, to time.Time
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		parsed, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid from date: %w", err))
+			return
+		}
+		from = parsed
+	}
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		parsed, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid to date: %w", err))
+			return
+		}
+		to = parsed
+	}
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("from date must not be after to date"))
+		return
+	}
+
+	history, err := h.service.GetVestedValueHistory(r.Context(), id, from, to)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, history)
+}
+
+// GetTaxSummary retrieves tax summary for an account and tax year
 func (h *AccountHandler) GetTaxSummary(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
@@ -983,7 +2190,38 @@ func (h *AccountHandler) GetTaxSummary(w http.ResponseWriter, r *http.Request) {
 		fmt.Sscanf(yearStr, "%d", &year)
 	}
 
-	summary, err := h.service.GetTaxSummary(r.Context(), id, year)
+	// ?start_month= sets a fiscal tax year, defaulting to the jurisdiction's; ?from= and ?to=
+	// narrow it to a partial year
+	period := account.TaxPeriod{}
+	if monthStr := r.URL.Query().Get("start_month"); monthStr != "" {
+		month, err := strconv.Atoi(monthStr)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid start month: %w", err))
+			return
+		}
+		period.StartMonth = month
+	}
+	for _, bound := range []struct {
+		param  string
+		target **account.Date
+	}{{"from", &period.From}, {"to", &period.To}} {
+		value := r.URL.Query().Get(bound.param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid %s date: %w", bound.param, err))
+			return
+		}
+		*bound.target = &account.Date{Time: parsed}
+	}
+	if period.StartMonth < 1 || period.StartMonth > 12 {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("start month must be between 1 and 12"))
+		return
+	}
+
+	summary, err := h.service.GetTaxSummaryForPeriod(r.Context(), id, year, period)
 	if err != nil {
 		server.RespondError(w, http.StatusInternalServerError, err)
 		return
@@ -1014,3 +2252,94 @@ func (h *AccountHandler) GetUpcomingVestingEvents(w http.ResponseWriter, r *http
 
 	server.RespondJSON(w, http.StatusOK, events)
 }
+
+// GetExpiringGrants lists option grants across all accounts expiring soon with vested shares unexercised
+func (h *AccountHandler) GetExpiringGrants(w http.ResponseWriter, r *http.Request) {
+	alerts, err := h.service.GetExpiringGrants(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, alerts)
+}
+
+// GetGrantExpirations lists the recorded expirations of an account's option grants
+func (h *AccountHandler) GetGrantExpirations(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	expirations, err := h.service.GetGrantExpirations(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, expirations)
+}
+
+// ProcessGrantExpirations records expirations for an account's option grants past their expiration date
+func (h *AccountHandler) ProcessGrantExpirations(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	expirations, err := h.service.ProcessGrantExpirations(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, expirations)
+}
+
+// GetHoldingPeriodAlerts retrieves exercised shares approaching a holding-period threshold
+func (h *AccountHandler) GetHoldingPeriodAlerts(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	daysStr := r.URL.Query().Get("days")
+	days := account.DefaultHoldingPeriodAlertDays
+	if daysStr != "" {
+		fmt.Sscanf(daysStr, "%d", &days)
+	}
+
+	alerts, err := h.service.GetHoldingPeriodAlerts(r.Context(), id, days)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, alerts)
+}
+
+// GetTerminationScenario computes what leaving the company on a given day means for an account's grants
+func (h *AccountHandler) GetTerminationScenario(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req account.TerminationScenarioRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	scenario, err := h.service.GetTerminationScenario(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, scenario)
+}
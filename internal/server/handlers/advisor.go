@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"money/internal/advisor"
+	"money/internal/auth"
+	"money/internal/server"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AdvisorHandler handles advisor access HTTP requests
+type AdvisorHandler struct {
+	service *advisor.Service
+}
+
+// NewAdvisorHandler creates a new advisor access handler
+func NewAdvisorHandler(service *advisor.Service) *AdvisorHandler {
+	return &AdvisorHandler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers all advisor access routes
+func (h *AdvisorHandler) RegisterRoutes(r chi.Router) {
+	// Granting and accepting access needs an interactive session, never a service key
+	r.Route("/advisors", func(r chi.Router) {
+		r.Use(auth.RequireSession)
+		r.Get("/", h.ListGrants)
+		r.Post("/invites", h.InviteAdvisor)
+		r.Post("/invites/accept", h.AcceptInvite)
+		r.Get("/clients", h.ListClients)
+		r.Get("/comments", h.ListComments)
+		r.Post("/comments", h.CreateComment)
+		r.Put("/{id}", h.UpdateGrant)
+		r.Delete("/{id}", h.RevokeGrant)
+		r.Get("/{id}/access-log", h.GetAccessLog)
+	})
+}
+
+// InviteAdvisor invites an advisor and returns the invite token
+func (h *AdvisorHandler) InviteAdvisor(w http.ResponseWriter, r *http.Request) {
+	var req advisor.InviteAdvisorRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	invite, err := h.service.InviteAdvisor(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, invite)
+}
+
+// ListGrants returns the advisors the user has invited
+func (h *AdvisorHandler) ListGrants(w http.ResponseWriter, r *http.Request) {
+	grants, err := h.service.ListGrants(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, grants)
+}
+
+// UpdateGrant changes what an advisor can see
+func (h *AdvisorHandler) UpdateGrant(w http.ResponseWriter, r *http.Request) {
+	var req advisor.UpdateGrantRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	grant, err := h.service.UpdateGrant(r.Context(), chi.URLParam(r, "id"), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, grant)
+}
+
+// RevokeGrant ends an advisor's access
+func (h *AdvisorHandler) RevokeGrant(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.RevokeGrant(r.Context(), chi.URLParam(r, "id")); err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// GetAccessLog returns the requests an advisor made under a grant
+func (h *AdvisorHandler) GetAccessLog(w http.ResponseWriter, r *http.Request) {
+	log, err := h.service.GetAccessLog(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, log)
+}
+
+// AcceptInvite accepts an invite as the signed-in advisor
+func (h *AdvisorHandler) AcceptInvite(w http.ResponseWriter, r *http.Request) {
+	var req advisor.AcceptInviteRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	grant, err := h.service.AcceptInvite(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, grant)
+}
+
+// ListClients returns the users the signed-in advisor can act for
+func (h *AdvisorHandler) ListClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.service.ListClients(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, clients)
+}
+
+// ListComments returns advisor comments
+func (h *AdvisorHandler) ListComments(w http.ResponseWriter, r *http.Request) {
+	comments, err := h.service.ListComments(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, comments)
+}
+
+// CreateComment leaves a comment for the client the advisor is acting for
+func (h *AdvisorHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	var req advisor.CreateCommentRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	comment, err := h.service.CreateComment(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusForbidden, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, comment)
+}
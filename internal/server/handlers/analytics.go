@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"money/internal/analytics"
+	"money/internal/inflation"
+	"money/internal/server"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AnalyticsHandler handles analytics HTTP requests
+type AnalyticsHandler struct {
+	service      *analytics.Service
+	inflationSvc *inflation.Service
+}
+
+// NewAnalyticsHandler creates a new analytics handler
+func NewAnalyticsHandler(service *analytics.Service, inflationSvc *inflation.Service) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		service:      service,
+		inflationSvc: inflationSvc,
+	}
+}
+
+// RegisterRoutes registers all analytics routes
+func (h *AnalyticsHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/analytics", func(r chi.Router) {
+		r.Get("/year-review", h.GetYearReview)
+		r.Get("/kpis", h.GetKPIs)
+		r.Get("/spending-growth", h.GetSpendingGrowth)
+	})
+
+	r.Route("/anomalies", func(r chi.Router) {
+		r.Get("/", h.ListAnomalies)
+		r.Post("/detect", h.DetectAnomalies)
+		r.Post("/{id}/acknowledge", h.AcknowledgeAnomaly)
+		r.Post("/{id}/dismiss", h.DismissAnomaly)
+	})
+}
+
+// GetYearReview compiles the annual review for ?year= (defaults to the current year)
+func (h *AnalyticsHandler) GetYearReview(w http.ResponseWriter, r *http.Request) {
+	year := time.Now().Year()
+	if yearStr := r.URL.Query().Get("year"); yearStr != "" {
+		parsed, err := strconv.Atoi(yearStr)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid year: %w", err))
+			return
+		}
+		year = parsed
+	}
+
+	review, err := h.service.GetYearReview(r.Context(), year)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, review)
+}
+
+// GetKPIs returns savings rate, expense ratio, debt-to-income, investment rate and runway
+// over trailing windows
+func (h *AnalyticsHandler) GetKPIs(w http.ResponseWriter, r *http.Request) {
+	kpis, err := h.service.GetKPIs(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, kpis)
+}
+
+// GetSpendingGrowth compares yearly spending growth with CPI inflation for ?region= (CA or
+// US, default CA) over the last ?years= complete years
+func (h *AnalyticsHandler) GetSpendingGrowth(w http.ResponseWriter, r *http.Request) {
+	regionStr := r.URL.Query().Get("region")
+	if regionStr == "" {
+		regionStr = string(inflation.RegionCanada)
+	}
+	region, err := h.inflationSvc.ParseRegion(regionStr)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+	years := 0
+	if yearsStr := r.URL.Query().Get("years"); yearsStr != "" {
+		years, err = strconv.Atoi(yearsStr)
+		if err != nil || years < 1 {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid years: %s", yearsStr))
+			return
+		}
+	}
+
+	growth, err := h.service.GetSpendingGrowth(r.Context(), region, years)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, growth)
+}
+
+// ListAnomalies lists anomalies, optionally filtered by ?status=
+func (h *AnalyticsHandler) ListAnomalies(w http.ResponseWriter, r *http.Request) {
+	status := analytics.AnomalyStatus(r.URL.Query().Get("status"))
+
+	anomalies, err := h.service.ListAnomalies(r.Context(), status)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, anomalies)
+}
+
+// DetectAnomalies runs the anomaly detectors now
+func (h *AnalyticsHandler) DetectAnomalies(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.DetectAnomalies(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// AcknowledgeAnomaly marks an anomaly as seen and expected
+func (h *AnalyticsHandler) AcknowledgeAnomaly(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.AcknowledgeAnomaly(r.Context(), chi.URLParam(r, "id")); err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// DismissAnomaly marks an anomaly as a false alarm
+func (h *AnalyticsHandler) DismissAnomaly(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DismissAnomaly(r.Context(), chi.URLParam(r, "id")); err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"money/internal/apikeys"
+	"money/internal/auth"
 	"money/internal/moneyy"
 	"money/internal/server"
 
@@ -28,11 +30,28 @@ func NewAPIKeysHandler(apiKeysSvc *apikeys.Service, moneySvc *moneyy.Service) *A
 
 // RegisterRoutes registers all API key routes
 func (h *APIKeysHandler) RegisterRoutes(r chi.Router) {
-	// API Keys routes
+	// Key management needs an interactive session, never a service key
 	r.Route("/api-keys", func(r chi.Router) {
+		r.Use(auth.RequireSession)
 		r.Get("/status/{provider}", h.GetAPIKeyStatus)
 		r.Post("/", h.SaveAPIKey)
 		r.Delete("/{provider}", h.DeleteAPIKey)
+		r.Get("/{provider}/usage", h.GetUsage)
+		r.Get("/{provider}/usage/export", h.ExportUsage)
+		r.Post("/{provider}/revoke", h.RevokeAPIKey)
+	})
+
+	// Service API key routes
+	r.Route("/service-keys", func(r chi.Router) {
+		r.Use(auth.RequireSession)
+		r.Get("/", h.ListServiceKeys)
+		r.Post("/", h.CreateServiceKey)
+		r.Put("/{id}", h.UpdateServiceKey)
+		r.Post("/{id}/rotate", h.RotateServiceKey)
+		r.Post("/{id}/revoke", h.RevokeServiceKey)
+		r.Get("/{id}/usage", h.GetServiceKeyUsage)
+		r.Get("/{id}/usage/export", h.ExportServiceKeyUsage)
+		r.Delete("/{id}", h.DeleteServiceKey)
 	})
 
 	// Moneyy API routes
@@ -93,6 +112,225 @@ func (h *APIKeysHandler) DeleteAPIKey(w http.ResponseWriter, r *http.Request) {
 	server.RespondJSON(w, http.StatusOK, resp)
 }
 
+// GetUsage returns the usage log and detected anomalies for a provider's API key
+func (h *APIKeysHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	provider := chi.URLParam(r, "provider")
+	if provider == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("provider is required"))
+		return
+	}
+
+	days := apikeys.DefaultUsageWindowDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		fmt.Sscanf(daysStr, "%d", &days)
+	}
+
+	usage, err := h.apiKeysSvc.GetUsage(r.Context(), provider, days)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, usage)
+}
+
+// ExportUsage downloads the usage log for a provider's API key as CSV
+func (h *APIKeysHandler) ExportUsage(w http.ResponseWriter, r *http.Request) {
+	provider := chi.URLParam(r, "provider")
+	if provider == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("provider is required"))
+		return
+	}
+
+	days := apikeys.DefaultUsageWindowDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		fmt.Sscanf(daysStr, "%d", &days)
+	}
+
+	data, err := h.apiKeysSvc.ExportUsageCSV(r.Context(), provider, days)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	filename := fmt.Sprintf("api-key-usage-%s-%s.csv", provider, time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		handlersLog.Error("Failed to write response", "error", err)
+	}
+}
+
+// RevokeAPIKey deactivates a provider's API key
+func (h *APIKeysHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	provider := chi.URLParam(r, "provider")
+	if provider == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("provider is required"))
+		return
+	}
+
+	status, err := h.apiKeysSvc.RevokeAPIKey(r.Context(), provider)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, status)
+}
+
+// ListServiceKeys lists the user's service API keys
+func (h *APIKeysHandler) ListServiceKeys(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.apiKeysSvc.ListServiceKeys(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// CreateServiceKey creates a service API key and returns its secret once
+func (h *APIKeysHandler) CreateServiceKey(w http.ResponseWriter, r *http.Request) {
+	var req apikeys.CreateServiceKeyRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.apiKeysSvc.CreateServiceKey(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, resp)
+}
+
+// UpdateServiceKey updates a service key's name, IP allowlist or expiration
+func (h *APIKeysHandler) UpdateServiceKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("service key ID is required"))
+		return
+	}
+
+	var req apikeys.UpdateServiceKeyRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	key, err := h.apiKeysSvc.UpdateServiceKey(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, key)
+}
+
+// RotateServiceKey issues a new secret for a service key, keeping its ID and scopes
+func (h *APIKeysHandler) RotateServiceKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("service key ID is required"))
+		return
+	}
+
+	resp, err := h.apiKeysSvc.RotateServiceKey(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// RevokeServiceKey deactivates a service API key while keeping its usage history
+func (h *APIKeysHandler) RevokeServiceKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("service key ID is required"))
+		return
+	}
+
+	key, err := h.apiKeysSvc.RevokeServiceKey(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, key)
+}
+
+// GetServiceKeyUsage returns the usage log and detected anomalies for a service API key
+func (h *APIKeysHandler) GetServiceKeyUsage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("service key ID is required"))
+		return
+	}
+
+	days := apikeys.DefaultUsageWindowDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		fmt.Sscanf(daysStr, "%d", &days)
+	}
+
+	usage, err := h.apiKeysSvc.GetServiceKeyUsage(r.Context(), id, days)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, usage)
+}
+
+// ExportServiceKeyUsage downloads the usage log for a service API key as CSV
+func (h *APIKeysHandler) ExportServiceKeyUsage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("service key ID is required"))
+		return
+	}
+
+	days := apikeys.DefaultUsageWindowDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		fmt.Sscanf(daysStr, "%d", &days)
+	}
+
+	data, err := h.apiKeysSvc.ExportServiceKeyUsageCSV(r.Context(), id, days)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	filename := fmt.Sprintf("service-key-usage-%s-%s.csv", id, time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		handlersLog.Error("Failed to write response", "error", err)
+	}
+}
+
+// DeleteServiceKey removes a service API key
+func (h *APIKeysHandler) DeleteServiceKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("service key ID is required"))
+		return
+	}
+
+	resp, err := h.apiKeysSvc.DeleteServiceKey(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
 // FetchTaxBrackets fetches tax brackets from the Moneyy API
 func (h *APIKeysHandler) FetchTaxBrackets(w http.ResponseWriter, r *http.Request) {
 	country := chi.URLParam(r, "country")
@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"money/internal/balance"
 	"money/internal/server"
@@ -25,10 +26,38 @@ func NewBalanceHandler(service *balance.Service) *BalanceHandler {
 // RegisterRoutes registers all balance routes
 func (h *BalanceHandler) RegisterRoutes(r chi.Router) {
 	r.Get("/account-balances/{accountId}", h.GetAccountBalances)
+	r.Put("/account-balances/{accountId}/opening", h.SetOpeningBalance)
+	r.Post("/account-balances/{accountId}/corrections", h.CorrectBalance)
+	r.Put("/account-balances/{accountId}/stale-after", h.SetStaleAfter)
+	r.Get("/account-reconciliations/{accountId}", h.GetReconciliations)
+	r.Post("/account-reconciliations/{accountId}", h.Reconcile)
+	r.Post("/reconciliations/{id}/adjust", h.PostAdjustment)
+	r.Get("/account-envelopes/{accountId}", h.GetEnvelopeSummary)
+	r.Post("/account-envelopes/{accountId}", h.CreateEnvelope)
+	r.Get("/account-envelopes/{accountId}/movements", h.GetEnvelopeMovements)
+
+	r.Route("/envelopes", func(r chi.Router) {
+		r.Get("/", h.ListEnvelopeSummaries)
+		r.Post("/transfer", h.TransferBetweenEnvelopes)
+		r.Get("/{id}", h.GetEnvelope)
+		r.Post("/{id}/allocate", h.AllocateEnvelope)
+		r.Delete("/{id}", h.DeleteEnvelope)
+	})
+
+	r.Route("/net-worth", func(r chi.Router) {
+		r.Get("/history", h.GetNetWorthHistory)
+		r.Get("/events", h.ListNetWorthEvents)
+		r.Post("/recompute", h.RecomputeNetWorth)
+	})
 
 	r.Route("/balances", func(r chi.Router) {
 		r.Post("/", h.Create)
 		r.Post("/bulk", h.BulkImport)
+		r.Post("/bulk-update", h.BulkUpdate)
+		r.Post("/quick-update", h.QuickUpdate)
+		r.Get("/stale", h.GetStaleness)
+		r.Get("/reminders", h.GetReminderSettings)
+		r.Put("/reminders", h.UpdateReminderSettings)
 		r.Get("/{id}", h.Get)
 		r.Put("/{id}", h.Update)
 		r.Delete("/{id}", h.Delete)
@@ -117,6 +146,12 @@ func (h *BalanceHandler) GetAccountBalances(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	query := r.URL.Query()
+	if query.Get("from") != "" || query.Get("to") != "" || query.Get("aggregate") != "" {
+		h.getBalanceHistory(w, r, accountID)
+		return
+	}
+
 	resp, err := h.service.GetAccountBalances(r.Context(), accountID)
 	if err != nil {
 		server.RespondError(w, http.StatusInternalServerError, err)
@@ -126,6 +161,33 @@ func (h *BalanceHandler) GetAccountBalances(w http.ResponseWriter, r *http.Reque
 	server.RespondJSON(w, http.StatusOK, resp)
 }
 
+// getBalanceHistory serves a date-range filtered, optionally aggregated balance history
+func (h *BalanceHandler) getBalanceHistory(w http.ResponseWriter, r *http.Request, accountID string) {
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	req := &balance.BalanceHistoryRequest{
+		From:      from,
+		To:        to,
+		Aggregate: balance.Aggregation(r.URL.Query().Get("aggregate")),
+	}
+	if req.Aggregate != balance.AggregationNone && req.Aggregate != balance.AggregationMonth && req.Aggregate != balance.AggregationYear {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("aggregate must be month or year"))
+		return
+	}
+
+	resp, err := h.service.GetBalanceHistory(r.Context(), accountID, req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
 // BulkImport imports multiple balance entries
 func (h *BalanceHandler) BulkImport(w http.ResponseWriter, r *http.Request) {
 	var req balance.BulkImportRequest
@@ -142,3 +204,415 @@ func (h *BalanceHandler) BulkImport(w http.ResponseWriter, r *http.Request) {
 
 	server.RespondJSON(w, http.StatusOK, resp)
 }
+
+// Reconcile compares a statement balance against the account's recorded balance
+func (h *BalanceHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	accountID := chi.URLParam(r, "accountId")
+	if accountID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req balance.ReconcileRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	rec, err := h.service.Reconcile(r.Context(), accountID, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, rec)
+}
+
+// GetReconciliations retrieves the reconciliation history for an account
+func (h *BalanceHandler) GetReconciliations(w http.ResponseWriter, r *http.Request) {
+	accountID := chi.URLParam(r, "accountId")
+	if accountID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	resp, err := h.service.GetReconciliations(r.Context(), accountID)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// PostAdjustment posts an adjustment balance that clears a reconciliation discrepancy
+func (h *BalanceHandler) PostAdjustment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("reconciliation ID is required"))
+		return
+	}
+
+	rec, err := h.service.PostAdjustment(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, rec)
+}
+
+// SetOpeningBalance sets the balance an account's history starts from
+func (h *BalanceHandler) SetOpeningBalance(w http.ResponseWriter, r *http.Request) {
+	accountID := chi.URLParam(r, "accountId")
+	if accountID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req balance.OpeningBalanceRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.SetOpeningBalance(r.Context(), accountID, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// CorrectBalance records a backdated balance and recomputes dependent reconciliations
+func (h *BalanceHandler) CorrectBalance(w http.ResponseWriter, r *http.Request) {
+	accountID := chi.URLParam(r, "accountId")
+	if accountID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req balance.CorrectionRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.CorrectBalance(r.Context(), accountID, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// GetEnvelopeSummary shows how an account's balance is split across envelopes
+func (h *BalanceHandler) GetEnvelopeSummary(w http.ResponseWriter, r *http.Request) {
+	accountID := chi.URLParam(r, "accountId")
+	if accountID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	summary, err := h.service.GetEnvelopeSummary(r.Context(), accountID)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, summary)
+}
+
+// CreateEnvelope creates an envelope on an account
+func (h *BalanceHandler) CreateEnvelope(w http.ResponseWriter, r *http.Request) {
+	accountID := chi.URLParam(r, "accountId")
+	if accountID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req balance.CreateEnvelopeRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	env, err := h.service.CreateEnvelope(r.Context(), accountID, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, env)
+}
+
+// GetEnvelopeMovements returns the envelope allocation history for an account
+func (h *BalanceHandler) GetEnvelopeMovements(w http.ResponseWriter, r *http.Request) {
+	accountID := chi.URLParam(r, "accountId")
+	if accountID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	resp, err := h.service.GetEnvelopeMovements(r.Context(), accountID)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// ListEnvelopeSummaries returns envelope summaries for all of the user's accounts
+func (h *BalanceHandler) ListEnvelopeSummaries(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.ListEnvelopeSummaries(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// GetEnvelope retrieves a single envelope
+func (h *BalanceHandler) GetEnvelope(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("envelope ID is required"))
+		return
+	}
+
+	env, err := h.service.GetEnvelope(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, env)
+}
+
+// AllocateEnvelope moves money between unallocated cash and an envelope
+func (h *BalanceHandler) AllocateEnvelope(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("envelope ID is required"))
+		return
+	}
+
+	var req balance.AllocateEnvelopeRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	env, err := h.service.AllocateEnvelope(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, env)
+}
+
+// TransferBetweenEnvelopes moves money from one envelope to another
+func (h *BalanceHandler) TransferBetweenEnvelopes(w http.ResponseWriter, r *http.Request) {
+	var req balance.EnvelopeTransferRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	movement, err := h.service.TransferBetweenEnvelopes(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, movement)
+}
+
+// DeleteEnvelope deletes an envelope, returning its money to unallocated cash
+func (h *BalanceHandler) DeleteEnvelope(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("envelope ID is required"))
+		return
+	}
+
+	if err := h.service.DeleteEnvelope(r.Context(), id); err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// GetNetWorthHistory returns the stored net worth snapshots within an optional date range.
+// ?base_currency= adds month-over-month attribution of the change to FX movement.
+func (h *BalanceHandler) GetNetWorthHistory(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+	req := &balance.BalanceHistoryRequest{From: from, To: to}
+	if baseCurrency := r.URL.Query().Get("base_currency"); baseCurrency != "" {
+		if req.BaseCurrency, err = balance.ValidBaseCurrency(baseCurrency); err != nil {
+			server.RespondError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	resp, err := h.service.GetNetWorthHistory(r.Context(), req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// ListNetWorthEvents lists snapshot rebuilds finished after ?after=<event id>
+func (h *BalanceHandler) ListNetWorthEvents(w http.ResponseWriter, r *http.Request) {
+	var after int64
+	if value := r.URL.Query().Get("after"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid after: %s", value))
+			return
+		}
+		after = parsed
+	}
+
+	resp, err := h.service.ListNetWorthEvents(r.Context(), after)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// RecomputeNetWorth queues a rebuild of the net worth snapshots
+func (h *BalanceHandler) RecomputeNetWorth(w http.ResponseWriter, r *http.Request) {
+	var req balance.RecomputeNetWorthRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.RequestNetWorthRecompute(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusAccepted, resp)
+}
+
+// GetStaleness lists manual accounts with how long ago their balance was last updated
+func (h *BalanceHandler) GetStaleness(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.GetStaleness(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// BulkUpdate records new balances for several accounts in one go
+func (h *BalanceHandler) BulkUpdate(w http.ResponseWriter, r *http.Request) {
+	var req balance.BulkUpdateRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.BulkUpdate(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// GetReminderSettings returns the stale balance reminder settings
+func (h *BalanceHandler) GetReminderSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.service.GetReminderSettings(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, settings)
+}
+
+// UpdateReminderSettings changes the stale balance reminder settings
+func (h *BalanceHandler) UpdateReminderSettings(w http.ResponseWriter, r *http.Request) {
+	var req balance.UpdateReminderSettingsRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	settings, err := h.service.UpdateReminderSettings(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, settings)
+}
+
+// SetStaleAfter sets how long an account can go without a balance before it is stale
+func (h *BalanceHandler) SetStaleAfter(w http.ResponseWriter, r *http.Request) {
+	accountID := chi.URLParam(r, "accountId")
+	if accountID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req balance.SetStaleAfterRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if err := h.service.SetStaleAfter(r.Context(), accountID, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// QuickUpdate sets today's balance for several accounts from a map of account ID to balance
+// and returns the new net worth. ?base_currency= adds a converted total.
+func (h *BalanceHandler) QuickUpdate(w http.ResponseWriter, r *http.Request) {
+	var baseCurrency string
+	if value := r.URL.Query().Get("base_currency"); value != "" {
+		var err error
+		if baseCurrency, err = balance.ValidBaseCurrency(value); err != nil {
+			server.RespondError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	var req balance.QuickUpdateRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.QuickUpdate(r.Context(), &req, baseCurrency)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"money/internal/calculators"
+	"money/internal/server"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CalculatorsHandler handles the stateless calculator HTTP requests
+type CalculatorsHandler struct {
+	service *calculators.Service
+}
+
+// NewCalculatorsHandler creates a new calculators handler
+func NewCalculatorsHandler(service *calculators.Service) *CalculatorsHandler {
+	return &CalculatorsHandler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers all calculator routes
+func (h *CalculatorsHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/calculators", func(r chi.Router) {
+		r.Post("/loan-payment", h.LoanPayment)
+		r.Post("/mortgage-affordability", h.MortgageAffordability)
+		r.Post("/compound-growth", h.CompoundGrowth)
+		r.Post("/rrsp-vs-tfsa", h.RRSPvsTFSA)
+		r.Post("/offer-comparison", h.CompareOffers)
+	})
+}
+
+// LoanPayment amortizes a hypothetical loan
+func (h *CalculatorsHandler) LoanPayment(w http.ResponseWriter, r *http.Request) {
+	var req calculators.LoanPaymentRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	result, err := h.service.LoanPayment(&req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, result)
+}
+
+// MortgageAffordability finds the largest mortgage an income qualifies for
+func (h *CalculatorsHandler) MortgageAffordability(w http.ResponseWriter, r *http.Request) {
+	var req calculators.AffordabilityRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	result, err := h.service.MortgageAffordability(&req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, result)
+}
+
+// CompoundGrowth grows savings with regular contributions
+func (h *CalculatorsHandler) CompoundGrowth(w http.ResponseWriter, r *http.Request) {
+	var req calculators.CompoundGrowthRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	result, err := h.service.CompoundGrowth(&req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, result)
+}
+
+// RRSPvsTFSA compares an RRSP and a TFSA contribution
+func (h *CalculatorsHandler) RRSPvsTFSA(w http.ResponseWriter, r *http.Request) {
+	var req calculators.RRSPvsTFSARequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	result, err := h.service.RRSPvsTFSA(&req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, result)
+}
+
+// CompareOffers projects two job offers' compensation side by side
+func (h *CalculatorsHandler) CompareOffers(w http.ResponseWriter, r *http.Request) {
+	var req calculators.OfferComparisonRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	result, err := h.service.CompareOffers(&req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, result)
+}
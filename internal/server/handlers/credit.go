@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"money/internal/credit"
+	"money/internal/server"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CreditHandler handles credit score HTTP requests
+type CreditHandler struct {
+	service *credit.Service
+}
+
+// NewCreditHandler creates a new credit score handler
+func NewCreditHandler(service *credit.Service) *CreditHandler {
+	return &CreditHandler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers all credit score routes
+func (h *CreditHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/credit-scores", func(r chi.Router) {
+		r.Get("/", h.ListScores)
+		r.Post("/", h.CreateScore)
+		r.Get("/chart", h.GetChart)
+		r.Get("/reminders", h.GetReminderSettings)
+		r.Put("/reminders", h.SetReminderSettings)
+		r.Delete("/{id}", h.DeleteScore)
+	})
+}
+
+// ListScores returns the credit score history, optionally filtered by ?bureau=
+func (h *CreditHandler) ListScores(w http.ResponseWriter, r *http.Request) {
+	bureau := credit.Bureau(r.URL.Query().Get("bureau"))
+
+	scores, err := h.service.ListScores(r.Context(), bureau)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, scores)
+}
+
+// CreateScore records a credit score
+func (h *CreditHandler) CreateScore(w http.ResponseWriter, r *http.Request) {
+	var req credit.CreateScoreRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	score, err := h.service.CreateScore(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, score)
+}
+
+// DeleteScore removes a credit score entry
+func (h *CreditHandler) DeleteScore(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteScore(r.Context(), chi.URLParam(r, "id")); err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// GetChart returns the credit score history as one line per bureau
+func (h *CreditHandler) GetChart(w http.ResponseWriter, r *http.Request) {
+	chart, err := h.service.GetChart(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, chart)
+}
+
+// GetReminderSettings returns the monthly update reminder settings
+func (h *CreditHandler) GetReminderSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.service.GetReminderSettings(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, settings)
+}
+
+// SetReminderSettings turns monthly update reminders on or off
+func (h *CreditHandler) SetReminderSettings(w http.ResponseWriter, r *http.Request) {
+	var req credit.SetReminderSettingsRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	settings, err := h.service.SetReminderSettings(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, settings)
+}
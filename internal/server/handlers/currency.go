@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 
 	"money/internal/currency"
@@ -24,6 +25,7 @@ func NewCurrencyHandler(service *currency.Service) *CurrencyHandler {
 // RegisterRoutes registers all currency routes
 func (h *CurrencyHandler) RegisterRoutes(r chi.Router) {
 	r.Get("/currency/rates", h.GetLatestRates)
+	r.Put("/currency/rates", h.SetRates)
 }
 
 // GetLatestRates retrieves the latest exchange rates
@@ -36,3 +38,20 @@ func (h *CurrencyHandler) GetLatestRates(w http.ResponseWriter, r *http.Request)
 
 	server.RespondJSON(w, http.StatusOK, rates)
 }
+
+// SetRates stores manually entered or imported exchange rates
+func (h *CurrencyHandler) SetRates(w http.ResponseWriter, r *http.Request) {
+	var req currency.SetRatesRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	rates, err := h.service.SetRates(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, rates)
+}
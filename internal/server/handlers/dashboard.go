@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"money/internal/dashboard"
+	"money/internal/server"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// DashboardHandler handles dashboard layout HTTP requests
+type DashboardHandler struct {
+	service *dashboard.Service
+}
+
+// NewDashboardHandler creates a new dashboard handler
+func NewDashboardHandler(service *dashboard.Service) *DashboardHandler {
+	return &DashboardHandler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers all dashboard routes
+func (h *DashboardHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/dashboard", func(r chi.Router) {
+		r.Get("/layout", h.GetLayout)
+		r.Put("/layout", h.SaveLayout)
+		r.Post("/layout/reset", h.ResetLayout)
+		r.Get("/layouts/defaults", h.GetDefaultLayouts)
+	})
+}
+
+// GetLayout returns the user's dashboard layout
+func (h *DashboardHandler) GetLayout(w http.ResponseWriter, r *http.Request) {
+	layout, err := h.service.GetLayout(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, layout)
+}
+
+// SaveLayout validates and stores the user's dashboard layout
+func (h *DashboardHandler) SaveLayout(w http.ResponseWriter, r *http.Request) {
+	var req dashboard.SaveLayoutRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	layout, err := h.service.SaveLayout(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, layout)
+}
+
+// ResetLayout replaces the user's layout with a persona's default layout
+func (h *DashboardHandler) ResetLayout(w http.ResponseWriter, r *http.Request) {
+	var req dashboard.ResetLayoutRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	layout, err := h.service.ResetLayout(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, layout)
+}
+
+// GetDefaultLayouts returns the default layout for each persona
+func (h *DashboardHandler) GetDefaultLayouts(w http.ResponseWriter, r *http.Request) {
+	server.RespondJSON(w, http.StatusOK, h.service.DefaultLayouts())
+}
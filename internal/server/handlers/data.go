@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"time"
 
 	"money/internal/auth"
+	"money/internal/civil"
 	"money/internal/data"
+	"money/internal/holdings"
 	"money/internal/server"
 
 	"github.com/go-chi/chi/v5"
@@ -20,15 +24,23 @@ const (
 
 // DataHandler handles data export/import HTTP requests
 type DataHandler struct {
-	exportService *data.ExportService
-	importService *data.ImportService
+	exportService   *data.ExportService
+	importService   *data.ImportService
+	deletionService *data.DeletionService
+	snapshotService *data.SnapshotService
+	auditService    *data.AuditExportService
+	holdingsService *holdings.Service
 }
 
 // NewDataHandler creates a new data handler
-func NewDataHandler(exportService *data.ExportService, importService *data.ImportService) *DataHandler {
+func NewDataHandler(exportService *data.ExportService, importService *data.ImportService, deletionService *data.DeletionService, snapshotService *data.SnapshotService, auditService *data.AuditExportService, holdingsService *holdings.Service) *DataHandler {
 	return &DataHandler{
-		exportService: exportService,
-		importService: importService,
+		exportService:   exportService,
+		importService:   importService,
+		deletionService: deletionService,
+		snapshotService: snapshotService,
+		auditService:    auditService,
+		holdingsService: holdingsService,
 	}
 }
 
@@ -37,7 +49,22 @@ func (h *DataHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/data", func(r chi.Router) {
 		r.Post("/export", h.HandleExport)
 		r.Post("/import", h.HandleImport)
+		r.Post("/import/broker", h.HandleBrokerImport)
 		r.Post("/validate", h.HandleValidate)
+		r.Get("/snapshots", h.ListSnapshots)
+		r.Post("/snapshots", h.TakeSnapshot)
+		r.Get("/snapshots/diff", h.DiffSnapshots)
+		r.Get("/audit/export", h.ExportAuditLog)
+		r.Post("/audit/verify", h.VerifyAuditLog)
+
+		// Taking every record out and deleting the account need an interactive session
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireSession)
+			r.Get("/export/complete", h.HandleCompleteExport)
+			r.Post("/deletion", h.RequestDeletion)
+			r.Get("/deletion", h.GetDeletion)
+			r.Delete("/deletion", h.CancelDeletion)
+		})
 	})
 }
 
@@ -52,17 +79,49 @@ func (h *DataHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	archive, err := h.exportService.ExportData(ctx, userID)
+	// An optional passphrase encrypts the archive; anonymize strips personal details
+	var req data.ExportRequest
+	if r.ContentLength != 0 {
+		if err := server.ParseJSON(r, &req); err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+	}
+
+	var archive []byte
+	var err error
+	prefix := "money-export"
+	if req.Anonymize {
+		seed := rand.Uint64()
+		if req.Seed != nil {
+			seed = *req.Seed
+		}
+		archive, err = h.exportService.ExportAnonymized(ctx, userID, seed)
+		prefix = "money-anonymized-export"
+	} else {
+		archive, err = h.exportService.ExportData(ctx, userID)
+	}
 	if err != nil {
 		server.RespondError(w, http.StatusInternalServerError, fmt.Errorf("export failed: %w", err))
 		return
 	}
 
 	// Generate filename with timestamp
-	filename := fmt.Sprintf("money-export-%s.zip", time.Now().Format("2006-01-02T15-04-05"))
+	filename := fmt.Sprintf("%s-%s.zip", prefix, time.Now().Format("2006-01-02T15-04-05"))
+	contentType := "application/zip"
+
+	if req.Passphrase != "" {
+		archive, err = data.EncryptArchive(archive, req.Passphrase)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, err)
+			return
+		}
+		filename += ".enc"
+		contentType = "application/octet-stream"
+	}
 
 	// Set headers for file download
-	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Type", contentType)
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
 	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(archive)))
 
@@ -70,7 +129,7 @@ func (h *DataHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write(archive); err != nil {
 		// Log error but can't send error response as headers are already written
-		fmt.Printf("Error writing response: %v\n", err)
+		handlersLog.Error("Failed to write response", "error", err)
 	}
 }
 
@@ -102,6 +161,12 @@ func (h *DataHandler) HandleImport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	archive, err = decryptUpload(archive, r.FormValue("passphrase"))
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	// Get import mode from form (default to "merge")
 	mode := r.FormValue("mode")
 	if mode == "" {
@@ -112,6 +177,7 @@ func (h *DataHandler) HandleImport(w http.ResponseWriter, r *http.Request) {
 	opts := data.ImportOptions{
 		Mode:         mode,
 		ValidateOnly: false,
+		RemapIDs:     r.FormValue("remap_ids") == "true",
 	}
 
 	userID := auth.GetUserID(ctx)
@@ -134,6 +200,55 @@ func (h *DataHandler) HandleImport(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// HandleBrokerImport imports a broker's CSV export as holding transactions on an account.
+// The multipart form carries the file, the broker (wealthsimple, questrade or ibkr) and the
+// account_id; dry_run=true returns the parsed rows without storing them.
+func (h *DataHandler) HandleBrokerImport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxUploadSize)
+	if err := r.ParseMultipartForm(MaxUploadSize); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("failed to parse form: %w", err))
+		return
+	}
+
+	broker, err := holdings.ParseBroker(r.FormValue("broker"))
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+	accountID := r.FormValue("account_id")
+	if accountID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account_id is required"))
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("failed to get file: %w", err))
+		return
+	}
+	defer file.Close()
+
+	parsed, err := holdings.ParseBrokerCSV(broker, file)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+	if r.FormValue("dry_run") == "true" {
+		server.RespondJSON(w, http.StatusOK, parsed)
+		return
+	}
+
+	result, err := h.holdingsService.ImportBrokerTransactions(ctx, accountID, broker, parsed)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, result)
+}
+
 // HandleValidate handles archive validation requests
 func (h *DataHandler) HandleValidate(w http.ResponseWriter, r *http.Request) {
 	// Limit request body size
@@ -160,6 +275,12 @@ func (h *DataHandler) HandleValidate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	archive, err = decryptUpload(archive, r.FormValue("passphrase"))
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	// Validate archive
 	result, err := h.importService.ValidateArchive(archive)
 	if err != nil {
@@ -170,3 +291,276 @@ func (h *DataHandler) HandleValidate(w http.ResponseWriter, r *http.Request) {
 	// Return validation result
 	server.RespondJSON(w, http.StatusOK, result)
 }
+
+// decryptUpload decrypts an uploaded archive that was exported with a passphrase, and
+// returns plain archives unchanged
+func decryptUpload(archive []byte, passphrase string) ([]byte, error) {
+	if !data.IsEncryptedArchive(archive) {
+		return archive, nil
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("archive is encrypted; a passphrase is required")
+	}
+	return data.DecryptArchive(archive, passphrase)
+}
+
+// HandleCompleteExport downloads everything stored for the user as a single JSON document
+func (h *DataHandler) HandleCompleteExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		server.RespondError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	export, err := h.exportService.ExportComplete(ctx, userID)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, fmt.Errorf("export failed: %w", err))
+		return
+	}
+
+	filename := fmt.Sprintf("money-complete-export-%s.json", time.Now().Format("2006-01-02T15-04-05"))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	server.RespondJSON(w, http.StatusOK, export)
+}
+
+// RequestDeletion schedules all of the user's data for deletion after the grace window
+func (h *DataHandler) RequestDeletion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		server.RespondError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	var req data.RequestDeletionRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	deletion, err := h.deletionService.RequestDeletion(ctx, userID, &req)
+	if errors.Is(err, data.ErrDeletionRefused) {
+		server.RespondError(w, http.StatusForbidden, err)
+		return
+	}
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusAccepted, deletion)
+}
+
+// GetDeletion returns the user's scheduled deletion
+func (h *DataHandler) GetDeletion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		server.RespondError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	deletion, err := h.deletionService.GetDeletionRequest(ctx, userID)
+	if err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, deletion)
+}
+
+// CancelDeletion cancels a scheduled deletion during the grace window
+func (h *DataHandler) CancelDeletion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		server.RespondError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	if err := h.deletionService.CancelDeletion(ctx, userID); err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// ListSnapshots lists the user's data snapshots, newest first
+func (h *DataHandler) ListSnapshots(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		server.RespondError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	snapshots, err := h.snapshotService.ListSnapshots(ctx, userID)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, snapshots)
+}
+
+// TakeSnapshot fingerprints the user's data now, in addition to the daily snapshot
+func (h *DataHandler) TakeSnapshot(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		server.RespondError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	snapshot, err := h.snapshotService.TakeSnapshot(ctx, userID)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, snapshot)
+}
+
+// DiffSnapshots reports which tables changed between ?from= and ?to= (RFC 3339 timestamps or
+// dates). Without ?to= the diff runs up to the data as it is now.
+func (h *DataHandler) DiffSnapshots(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		server.RespondError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	from, err := parseTimestamp(r.URL.Query().Get("from"))
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid from: %w", err))
+		return
+	}
+	var to *time.Time
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		parsed, err := parseTimestamp(toStr)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid to: %w", err))
+			return
+		}
+		if parsed.Before(from) {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("to must not be before from"))
+			return
+		}
+		to = &parsed
+	}
+
+	diff, err := h.snapshotService.Diff(ctx, userID, from, to)
+	if errors.Is(err, data.ErrNoSnapshot) {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, diff)
+}
+
+// ExportAuditLog streams the user's audit and access logs between two dates as CSV or
+// JSONL, each record chained to the one before so the archive can be verified later
+func (h *DataHandler) ExportAuditLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		server.RespondError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	req, err := parseAuditExportRequest(r)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	contentType := "text/csv"
+	if req.Format == data.AuditFormatJSONL {
+		contentType = "application/x-ndjson"
+	}
+	filename := fmt.Sprintf("audit-log-%s-%s.%s", req.From, req.To, req.Format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	w.Header().Set("Trailer", "Audit-Chain, Audit-Records")
+
+	summary, err := h.auditService.Export(ctx, userID, req, w)
+	if err != nil {
+		// Nothing is written until the logs are loaded, so failures before then still get an error response
+		handlersLog.Error("Audit log export failed", "error", err)
+		server.RespondError(w, http.StatusInternalServerError, fmt.Errorf("export failed: %w", err))
+		return
+	}
+	w.Header().Set("Audit-Chain", summary.Chain)
+	w.Header().Set("Audit-Records", fmt.Sprintf("%d", summary.Records))
+}
+
+// VerifyAuditLog checks an uploaded audit log export for the same range against this
+// instance's key and reports where its chain breaks, if anywhere
+func (h *DataHandler) VerifyAuditLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		server.RespondError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	req, err := parseAuditExportRequest(r)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := h.auditService.Verify(userID, req, http.MaxBytesReader(w, r.Body, MaxUploadSize))
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, result)
+}
+
+// parseAuditExportRequest reads the from, to and format query parameters
+func parseAuditExportRequest(r *http.Request) (*data.AuditExportRequest, error) {
+	query := r.URL.Query()
+	from, err := civil.Parse(query.Get("from"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid from: %w", err)
+	}
+	to, err := civil.Parse(query.Get("to"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid to: %w", err)
+	}
+	format, err := data.ParseAuditExportFormat(query.Get("format"))
+	if err != nil {
+		return nil, err
+	}
+	return &data.AuditExportRequest{From: from, To: to, Format: format}, nil
+}
+
+// parseTimestamp accepts an RFC 3339 timestamp or a date, read as midnight UTC
+func parseTimestamp(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -9,6 +9,7 @@ import (
 	"mime/multipart"
 	"money/internal/auth"
 	"money/internal/data"
+	"money/internal/holdings"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -28,7 +29,7 @@ func TestHandleExport_Success(t *testing.T) {
 	// Create handler
 	exportService := data.NewExportService(db)
 	importService := data.NewImportService(db)
-	handler := NewDataHandler(exportService, importService)
+	handler := NewDataHandler(exportService, importService, data.NewDeletionService(db), data.NewSnapshotService(db), data.NewAuditExportService(db, "test-secret"), holdings.NewService(db))
 
 	// Create request with authenticated user
 	req := httptest.NewRequest("POST", "/api/data/export", nil)
@@ -79,7 +80,7 @@ func TestHandleExport_Unauthorized(t *testing.T) {
 
 	exportService := data.NewExportService(db)
 	importService := data.NewImportService(db)
-	handler := NewDataHandler(exportService, importService)
+	handler := NewDataHandler(exportService, importService, data.NewDeletionService(db), data.NewSnapshotService(db), data.NewAuditExportService(db, "test-secret"), holdings.NewService(db))
 
 	// Create request without user context
 	req := httptest.NewRequest("POST", "/api/data/export", nil)
@@ -102,7 +103,7 @@ func TestHandleImport_Success(t *testing.T) {
 
 	exportService := data.NewExportService(db)
 	importService := data.NewImportService(db)
-	handler := NewDataHandler(exportService, importService)
+	handler := NewDataHandler(exportService, importService, data.NewDeletionService(db), data.NewSnapshotService(db), data.NewAuditExportService(db, "test-secret"), holdings.NewService(db))
 
 	userID := "test-import-user"
 
@@ -148,7 +149,7 @@ func TestHandleImport_Unauthorized(t *testing.T) {
 
 	exportService := data.NewExportService(db)
 	importService := data.NewImportService(db)
-	handler := NewDataHandler(exportService, importService)
+	handler := NewDataHandler(exportService, importService, data.NewDeletionService(db), data.NewSnapshotService(db), data.NewAuditExportService(db, "test-secret"), holdings.NewService(db))
 
 	archive := createValidTestArchive(t)
 	body, contentType := createMultipartForm(t, archive)
@@ -176,7 +177,7 @@ func TestHandleImport_InvalidFile(t *testing.T) {
 
 	exportService := data.NewExportService(db)
 	importService := data.NewImportService(db)
-	handler := NewDataHandler(exportService, importService)
+	handler := NewDataHandler(exportService, importService, data.NewDeletionService(db), data.NewSnapshotService(db), data.NewAuditExportService(db, "test-secret"), holdings.NewService(db))
 
 	userID := "test-import-user"
 
@@ -209,7 +210,7 @@ func TestHandleImport_InvalidArchive(t *testing.T) {
 
 	exportService := data.NewExportService(db)
 	importService := data.NewImportService(db)
-	handler := NewDataHandler(exportService, importService)
+	handler := NewDataHandler(exportService, importService, data.NewDeletionService(db), data.NewSnapshotService(db), data.NewAuditExportService(db, "test-secret"), holdings.NewService(db))
 
 	userID := "test-import-user"
 
@@ -242,7 +243,7 @@ func TestHandleImport_FileSizeLimit(t *testing.T) {
 
 	exportService := data.NewExportService(db)
 	importService := data.NewImportService(db)
-	handler := NewDataHandler(exportService, importService)
+	handler := NewDataHandler(exportService, importService, data.NewDeletionService(db), data.NewSnapshotService(db), data.NewAuditExportService(db, "test-secret"), holdings.NewService(db))
 
 	userID := "test-import-user"
 
@@ -276,7 +277,7 @@ func TestHandleValidate_Success(t *testing.T) {
 
 	exportService := data.NewExportService(db)
 	importService := data.NewImportService(db)
-	handler := NewDataHandler(exportService, importService)
+	handler := NewDataHandler(exportService, importService, data.NewDeletionService(db), data.NewSnapshotService(db), data.NewAuditExportService(db, "test-secret"), holdings.NewService(db))
 
 	// Create valid archive
 	archive := createValidTestArchive(t)
@@ -314,7 +315,7 @@ func TestHandleValidate_InvalidArchive(t *testing.T) {
 
 	exportService := data.NewExportService(db)
 	importService := data.NewImportService(db)
-	handler := NewDataHandler(exportService, importService)
+	handler := NewDataHandler(exportService, importService, data.NewDeletionService(db), data.NewSnapshotService(db), data.NewAuditExportService(db, "test-secret"), holdings.NewService(db))
 
 	// Create invalid archive
 	invalidArchive := []byte("not a valid zip")
@@ -349,6 +350,49 @@ func TestHandleValidate_InvalidArchive(t *testing.T) {
 	}
 }
 
+// TestHandleValidate_EncryptedArchive tests validating an archive exported with a passphrase
+func TestHandleValidate_EncryptedArchive(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	handler := NewDataHandler(data.NewExportService(db), data.NewImportService(db), data.NewDeletionService(db), data.NewSnapshotService(db), data.NewAuditExportService(db, "test-secret"), holdings.NewService(db))
+	passphrase := "correct horse battery staple"
+	encrypted, err := data.EncryptArchive(createValidTestArchive(t), passphrase)
+	if err != nil {
+		t.Fatalf("EncryptArchive failed: %v", err)
+	}
+
+	validate := func(passphrase string) int {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("file", "test-archive.zip.enc")
+		if err != nil {
+			t.Fatalf("Failed to create form file: %v", err)
+		}
+		part.Write(encrypted)
+		if passphrase != "" {
+			writer.WriteField("passphrase", passphrase)
+		}
+		writer.Close()
+
+		req := httptest.NewRequest("POST", "/api/data/validate", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		handler.HandleValidate(w, req)
+		return w.Result().StatusCode
+	}
+
+	if status := validate(""); status != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without a passphrase, got %d", status)
+	}
+	if status := validate("wrong horse battery staple"); status != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a wrong passphrase, got %d", status)
+	}
+	if status := validate(passphrase); status != http.StatusOK {
+		t.Errorf("Expected status 200 with the passphrase, got %d", status)
+	}
+}
+
 // Helper functions - use data package helpers
 
 func setupTestDB(t *testing.T) *sql.DB {
@@ -29,6 +29,7 @@ func (h *DemoHandler) RegisterRoutes(r chi.Router) {
 		r.Post("/seed", h.HandleSeed)
 		r.Post("/clear", h.HandleClear)
 		r.Post("/reset", h.HandleReset)
+		r.Post("/regenerate", h.HandleRegenerate)
 		r.Get("/status", h.HandleStatus)
 	})
 }
@@ -108,6 +109,31 @@ func (h *DemoHandler) HandleReset(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// HandleRegenerate replaces the demo data with a freshly generated dataset dated to today
+func (h *DemoHandler) HandleRegenerate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Verify user is authenticated
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		server.RespondError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	// Always regenerate demo data for demo-user
+	const demoUserID = "demo-user"
+	err := h.demoService.RegenerateDemoData(ctx, demoUserID)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, fmt.Errorf("failed to regenerate demo data: %w", err))
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Demo data regenerated successfully",
+	})
+}
+
 // HandleStatus handles demo data status check requests
 func (h *DemoHandler) HandleStatus(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"money/internal/account"
+	"money/internal/server"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// EntityHandler handles personal and business entity HTTP requests
+type EntityHandler struct {
+	service *account.Service
+}
+
+// NewEntityHandler creates a new entity handler
+func NewEntityHandler(service *account.Service) *EntityHandler {
+	return &EntityHandler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers all entity routes
+func (h *EntityHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/entities", func(r chi.Router) {
+		r.Get("/", h.List)
+		r.Post("/", h.Create)
+		r.Get("/summary", h.Summaries)
+		r.Get("/transactions", h.ListTransactions)
+		r.Post("/transactions", h.CreateTransaction)
+		r.Delete("/transactions/{id}", h.DeleteTransaction)
+		r.Get("/{id}", h.Get)
+		r.Put("/{id}", h.Update)
+		r.Delete("/{id}", h.Delete)
+		r.Get("/{id}/tax-summary/{year}", h.GetTaxSummary)
+	})
+}
+
+// List lists the user's entities
+func (h *EntityHandler) List(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.ListEntities(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// Create creates an entity
+func (h *EntityHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req account.CreateEntityRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	entity, err := h.service.CreateEntity(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, entity)
+}
+
+// Summaries totals account balances per entity, converted when ?base_currency= is set
+func (h *EntityHandler) Summaries(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.GetEntitySummaries(r.Context(), r.URL.Query().Get("base_currency"))
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// Get retrieves an entity
+func (h *EntityHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("entity ID is required"))
+		return
+	}
+
+	entity, err := h.service.GetEntity(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, entity)
+}
+
+// Update updates an entity
+func (h *EntityHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("entity ID is required"))
+		return
+	}
+
+	var req account.UpdateEntityRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	entity, err := h.service.UpdateEntity(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, entity)
+}
+
+// Delete deletes an entity, returning its accounts to the user
+func (h *EntityHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("entity ID is required"))
+		return
+	}
+
+	if err := h.service.DeleteEntity(r.Context(), id); err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// GetTaxSummary totals an entity's inter-entity transactions for a fiscal year
+func (h *EntityHandler) GetTaxSummary(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("entity ID is required"))
+		return
+	}
+
+	year, err := parseYearParam(r)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	summary, err := h.service.GetEntityTaxSummary(r.Context(), id, year)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, summary)
+}
+
+// ListTransactions lists inter-entity transactions, optionally for one ?entity_id=
+func (h *EntityHandler) ListTransactions(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.ListEntityTransactions(r.Context(), r.URL.Query().Get("entity_id"))
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// CreateTransaction records an owner draw, dividend or other inter-entity transaction
+func (h *EntityHandler) CreateTransaction(w http.ResponseWriter, r *http.Request) {
+	var req account.CreateEntityTransactionRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	txn, err := h.service.CreateEntityTransaction(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, txn)
+}
+
+// DeleteTransaction deletes an inter-entity transaction
+func (h *EntityHandler) DeleteTransaction(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("transaction ID is required"))
+		return
+	}
+
+	if err := h.service.DeleteEntityTransaction(r.Context(), id); err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
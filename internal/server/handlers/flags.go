@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"money/internal/flags"
+	"money/internal/server"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// FlagsHandler handles feature flag HTTP requests
+type FlagsHandler struct {
+	service *flags.Service
+}
+
+// NewFlagsHandler creates a new feature flags handler
+func NewFlagsHandler(service *flags.Service) *FlagsHandler {
+	return &FlagsHandler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers all feature flag routes
+func (h *FlagsHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/flags", func(r chi.Router) {
+		r.Get("/", h.List)
+		r.Put("/{key}", h.SetUserFlag)
+		r.Delete("/{key}", h.ClearUserFlag)
+	})
+}
+
+// List evaluates every feature flag for the current user
+func (h *FlagsHandler) List(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.List(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// SetUserFlag opts the current user in or out of a feature flag
+func (h *FlagsHandler) SetUserFlag(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "key")
+	if key == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("flag key is required"))
+		return
+	}
+
+	var req flags.SetUserFlagRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	flag, err := h.service.SetUserFlag(r.Context(), key, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, flag)
+}
+
+// ClearUserFlag removes the current user's override for a feature flag
+func (h *FlagsHandler) ClearUserFlag(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "key")
+	if key == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("flag key is required"))
+		return
+	}
+
+	if err := h.service.ClearUserFlag(r.Context(), key); err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"money/internal/holdings"
 	"money/internal/server"
@@ -25,12 +26,33 @@ func NewHoldingsHandler(service *holdings.Service) *HoldingsHandler {
 // RegisterRoutes registers all holdings routes
 func (h *HoldingsHandler) RegisterRoutes(r chi.Router) {
 	r.Get("/account-holdings/{accountId}", h.GetAccountHoldings)
+	r.Get("/account-holdings/{accountId}/cost-basis", h.GetCostBasis)
+	r.Post("/account-holdings/{accountId}/cost-basis", h.ImportCostBasis)
+	r.Get("/account-holdings/{accountId}/reconciliation", h.ReconcilePositions)
+	r.Post("/account-holdings/{accountId}/reconciliation/adjust", h.AdjustPositions)
+	r.Get("/allocation/targets", h.GetAllocationTargets)
+	r.Put("/allocation/targets", h.SaveAllocationTargets)
+	r.Get("/allocation/drift", h.GetAllocationDrift)
 
 	r.Route("/holdings", func(r chi.Router) {
 		r.Post("/", h.Create)
+		r.Put("/quotes/{symbol}", h.RecordQuote)
+		r.Get("/securities", h.ListSecurities)
+		r.Get("/securities/{symbol}", h.GetSecurity)
+		r.Put("/securities/{symbol}", h.UpdateSecurity)
+		r.Get("/transfers", h.ListInKindTransfers)
+		r.Post("/transfers", h.CreateInKindTransfer)
 		r.Get("/{id}", h.Get)
 		r.Put("/{id}", h.Update)
 		r.Delete("/{id}", h.Delete)
+		r.Get("/{id}/alerts", h.ListPriceAlerts)
+		r.Post("/{id}/alerts", h.CreatePriceAlert)
+		r.Put("/{id}/alerts/{alertId}", h.UpdatePriceAlert)
+		r.Delete("/{id}/alerts/{alertId}", h.DeletePriceAlert)
+		r.Get("/{id}/journal", h.ListJournalEntries)
+		r.Post("/{id}/journal", h.CreateJournalEntry)
+		r.Put("/{id}/journal/{entryId}", h.UpdateJournalEntry)
+		r.Delete("/{id}/journal/{entryId}", h.DeleteJournalEntry)
 	})
 }
 
@@ -124,3 +146,398 @@ func (h *HoldingsHandler) GetAccountHoldings(w http.ResponseWriter, r *http.Requ
 
 	server.RespondJSON(w, http.StatusOK, resp)
 }
+
+// GetCostBasis lists an account's imported cost basis lots and opening positions
+func (h *HoldingsHandler) GetCostBasis(w http.ResponseWriter, r *http.Request) {
+	accountID := chi.URLParam(r, "accountId")
+	if accountID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	resp, err := h.service.GetCostBasis(r.Context(), accountID)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// ImportCostBasis bulk-imports historical lots as JSON, or as CSV when sent with a
+// text/csv content type. Pass ?replace=true with CSV to replace earlier imports.
+func (h *HoldingsHandler) ImportCostBasis(w http.ResponseWriter, r *http.Request) {
+	accountID := chi.URLParam(r, "accountId")
+	if accountID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req holdings.ImportCostBasisRequest
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		lots, err := holdings.ParseCostBasisCSV(r.Body)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid CSV: %w", err))
+			return
+		}
+		req.Lots = lots
+		req.Replace = r.URL.Query().Get("replace") == "true"
+	} else if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.ImportCostBasis(r.Context(), accountID, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, resp)
+}
+
+// ReconcilePositions compares an account's holding quantities with their recorded transactions
+func (h *HoldingsHandler) ReconcilePositions(w http.ResponseWriter, r *http.Request) {
+	accountID := chi.URLParam(r, "accountId")
+	if accountID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	resp, err := h.service.ReconcilePositions(r.Context(), accountID)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// AdjustPositions records adjustment transactions for an account's position discrepancies.
+// An empty body adjusts all of them.
+func (h *HoldingsHandler) AdjustPositions(w http.ResponseWriter, r *http.Request) {
+	accountID := chi.URLParam(r, "accountId")
+	if accountID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req holdings.AdjustPositionsRequest
+	if r.ContentLength != 0 {
+		if err := server.ParseJSON(r, &req); err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+	}
+
+	resp, err := h.service.AdjustPositions(r.Context(), accountID, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, resp)
+}
+
+// ListPriceAlerts lists a holding's price alert rules
+func (h *HoldingsHandler) ListPriceAlerts(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("holding ID is required"))
+		return
+	}
+
+	resp, err := h.service.ListPriceAlerts(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// CreatePriceAlert adds a price alert rule to a holding
+func (h *HoldingsHandler) CreatePriceAlert(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("holding ID is required"))
+		return
+	}
+
+	var req holdings.CreatePriceAlertRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	alert, err := h.service.CreatePriceAlert(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, alert)
+}
+
+// UpdatePriceAlert updates a holding's price alert rule
+func (h *HoldingsHandler) UpdatePriceAlert(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	alertID := chi.URLParam(r, "alertId")
+	if id == "" || alertID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("holding ID and alert ID are required"))
+		return
+	}
+
+	var req holdings.UpdatePriceAlertRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	alert, err := h.service.UpdatePriceAlert(r.Context(), id, alertID, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, alert)
+}
+
+// DeletePriceAlert removes a holding's price alert rule
+func (h *HoldingsHandler) DeletePriceAlert(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	alertID := chi.URLParam(r, "alertId")
+	if id == "" || alertID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("holding ID and alert ID are required"))
+		return
+	}
+
+	if err := h.service.DeletePriceAlert(r.Context(), id, alertID); err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// ListJournalEntries lists a holding's journal entries alongside its performance
+func (h *HoldingsHandler) ListJournalEntries(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("holding ID is required"))
+		return
+	}
+
+	resp, err := h.service.ListJournalEntries(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// CreateJournalEntry adds a note, thesis or review to a holding's journal
+func (h *HoldingsHandler) CreateJournalEntry(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("holding ID is required"))
+		return
+	}
+
+	var req holdings.CreateJournalEntryRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	entry, err := h.service.CreateJournalEntry(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, entry)
+}
+
+// UpdateJournalEntry updates a holding's journal entry or marks its review done
+func (h *HoldingsHandler) UpdateJournalEntry(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	entryID := chi.URLParam(r, "entryId")
+	if id == "" || entryID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("holding ID and entry ID are required"))
+		return
+	}
+
+	var req holdings.UpdateJournalEntryRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	entry, err := h.service.UpdateJournalEntry(r.Context(), id, entryID, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, entry)
+}
+
+// DeleteJournalEntry removes a holding's journal entry
+func (h *HoldingsHandler) DeleteJournalEntry(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	entryID := chi.URLParam(r, "entryId")
+	if id == "" || entryID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("holding ID and entry ID are required"))
+		return
+	}
+
+	if err := h.service.DeleteJournalEntry(r.Context(), id, entryID); err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// RecordQuote stores the latest price for a symbol so price alerts can be evaluated
+func (h *HoldingsHandler) RecordQuote(w http.ResponseWriter, r *http.Request) {
+	symbol := chi.URLParam(r, "symbol")
+	if symbol == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("symbol is required"))
+		return
+	}
+
+	var req holdings.RecordQuoteRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	quote, err := h.service.RecordQuote(r.Context(), symbol, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, quote)
+}
+
+// ListSecurities returns the metadata of the symbols the user holds
+func (h *HoldingsHandler) ListSecurities(w http.ResponseWriter, r *http.Request) {
+	securities, err := h.service.ListSecurities(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, securities)
+}
+
+// GetSecurity returns a symbol's metadata
+func (h *HoldingsHandler) GetSecurity(w http.ResponseWriter, r *http.Request) {
+	symbol := chi.URLParam(r, "symbol")
+	if symbol == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("symbol is required"))
+		return
+	}
+
+	security, err := h.service.GetSecurity(r.Context(), symbol)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, security)
+}
+
+// UpdateSecurity corrects a held symbol's metadata
+func (h *HoldingsHandler) UpdateSecurity(w http.ResponseWriter, r *http.Request) {
+	symbol := chi.URLParam(r, "symbol")
+	if symbol == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("symbol is required"))
+		return
+	}
+
+	var req holdings.SecurityUpdate
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	security, err := h.service.UpdateSecurity(r.Context(), symbol, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, security)
+}
+
+// GetAllocationTargets returns the user's target allocation
+func (h *HoldingsHandler) GetAllocationTargets(w http.ResponseWriter, r *http.Request) {
+	targets, err := h.service.GetAllocationTargets(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, targets)
+}
+
+// SaveAllocationTargets replaces the user's target allocation
+func (h *HoldingsHandler) SaveAllocationTargets(w http.ResponseWriter, r *http.Request) {
+	var req holdings.SaveAllocationTargetsRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	targets, err := h.service.SaveAllocationTargets(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, targets)
+}
+
+// GetAllocationDrift reports drift from the target allocation with suggested trades
+func (h *HoldingsHandler) GetAllocationDrift(w http.ResponseWriter, r *http.Request) {
+	drift, err := h.service.GetAllocationDrift(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, drift)
+}
+
+// CreateInKindTransfer moves positions between two investment accounts without a sale
+func (h *HoldingsHandler) CreateInKindTransfer(w http.ResponseWriter, r *http.Request) {
+	var req holdings.CreateInKindTransferRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	transfer, err := h.service.CreateInKindTransfer(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, transfer)
+}
+
+// ListInKindTransfers lists the user's in-kind transfers
+func (h *HoldingsHandler) ListInKindTransfers(w http.ResponseWriter, r *http.Request) {
+	transfers, err := h.service.ListInKindTransfers(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, transfers)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"money/internal/auth"
+	"money/internal/hooks"
+	"money/internal/server"
+	"money/internal/settings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// HooksHandler handles extension hook HTTP requests
+type HooksHandler struct {
+	service *hooks.Service
+	admins  *settings.Service
+}
+
+// NewHooksHandler creates a new hooks handler
+func NewHooksHandler(service *hooks.Service, admins *settings.Service) *HooksHandler {
+	return &HooksHandler{
+		service: service,
+		admins:  admins,
+	}
+}
+
+// RegisterRoutes registers the routes for the user's own hooks and, for administrators,
+// the instance hooks run for every user
+func (h *HooksHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/hooks", func(r chi.Router) {
+		r.Use(auth.RequireSession)
+		h.registerScope(r, hooks.ScopeUser)
+	})
+	r.Route("/admin/hooks", func(r chi.Router) {
+		r.Use(requireAdmin(h.admins))
+		h.registerScope(r, hooks.ScopeInstance)
+	})
+}
+
+// registerScope registers the hook routes for one scope
+func (h *HooksHandler) registerScope(r chi.Router, scope hooks.Scope) {
+	r.Post("/", func(w http.ResponseWriter, r *http.Request) { h.createHook(w, r, scope) })
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) { h.listHooks(w, r, scope) })
+	r.Put("/{id}", func(w http.ResponseWriter, r *http.Request) { h.updateHook(w, r, scope) })
+	r.Delete("/{id}", func(w http.ResponseWriter, r *http.Request) { h.deleteHook(w, r, scope) })
+}
+
+// createHook registers a hook and returns its signing secret, shown only this once
+func (h *HooksHandler) createHook(w http.ResponseWriter, r *http.Request, scope hooks.Scope) {
+	var req hooks.CreateHookRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	hook, err := h.service.CreateHook(r.Context(), scope, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, hook)
+}
+
+// listHooks returns the hooks in the scope
+func (h *HooksHandler) listHooks(w http.ResponseWriter, r *http.Request, scope hooks.Scope) {
+	resp, err := h.service.ListHooks(r.Context(), scope)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// updateHook changes a hook's target, timeout, failure policy or active state
+func (h *HooksHandler) updateHook(w http.ResponseWriter, r *http.Request, scope hooks.Scope) {
+	var req hooks.UpdateHookRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	hook, err := h.service.UpdateHook(r.Context(), scope, chi.URLParam(r, "id"), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, hook)
+}
+
+// deleteHook removes a hook
+func (h *HooksHandler) deleteHook(w http.ResponseWriter, r *http.Request, scope hooks.Scope) {
+	if err := h.service.DeleteHook(r.Context(), scope, chi.URLParam(r, "id")); err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
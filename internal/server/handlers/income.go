@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"money/internal/account"
 	"money/internal/income"
 	"money/internal/server"
 
@@ -38,6 +40,28 @@ func (h *IncomeHandler) RegisterRoutes(r chi.Router) {
 		r.Get("/tax-config/{year}", h.GetTaxConfig)
 		r.Post("/tax-config", h.SaveTaxConfig)
 
+		r.Get("/self-employment-tax/settings", h.GetSelfEmploymentTaxSettings)
+		r.Put("/self-employment-tax/settings", h.SaveSelfEmploymentTaxSettings)
+		r.Get("/self-employment-tax/{year}", h.GetSelfEmploymentTaxStatus)
+
+		r.Get("/tax-installments/upcoming", h.GetUpcomingInstallments)
+		r.Get("/tax-installments/{year}", h.GetInstallmentSchedule)
+		r.Post("/tax-installments/{year}/generate", h.GenerateInstallmentSchedule)
+		r.Post("/tax-installments/payments/{id}", h.RecordInstallmentPayment)
+
+		r.Post("/paystubs/import", h.ImportPaystubs)
+		r.Get("/paystubs", h.ListPaystubs)
+		r.Get("/paystubs/summary/{year}", h.GetPaystubSummary)
+		r.Delete("/paystubs/{id}", h.DeletePaystub)
+
+		r.Get("/bonus-plans", h.ListBonusPlans)
+		r.Post("/bonus-plans", h.CreateBonusPlan)
+		r.Get("/bonus-plans/{id}", h.GetBonusPlan)
+		r.Put("/bonus-plans/{id}", h.UpdateBonusPlan)
+		r.Delete("/bonus-plans/{id}", h.DeleteBonusPlan)
+		r.Post("/bonus-plans/{id}/payouts", h.RecordBonusPayout)
+		r.Delete("/bonus-plans/payouts/{id}", h.DeleteBonusPayout)
+
 		// Tax simulation endpoints
 		r.Post("/tax-simulator/exercise", h.CalculateExerciseTax)
 		r.Post("/tax-simulator/sale", h.CalculateSaleTax)
@@ -290,6 +314,14 @@ func (h *IncomeHandler) CalculateExerciseTax(w http.ResponseWriter, r *http.Requ
 		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("marginal rate must be between 0 and 1"))
 		return
 	}
+	if req.ExerciseMethod != nil {
+		switch *req.ExerciseMethod {
+		case account.ExerciseMethodCash, account.ExerciseMethodCashless, account.ExerciseMethodSameDaySale:
+		default:
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("exercise method must be cash, cashless or same_day_sale"))
+			return
+		}
+	}
 
 	result := h.service.CalculateExerciseTax(&req)
 	server.RespondJSON(w, http.StatusOK, result)
@@ -351,3 +383,353 @@ func (h *IncomeHandler) CalculateBatchTax(w http.ResponseWriter, r *http.Request
 
 	server.RespondJSON(w, http.StatusOK, result)
 }
+
+// GetSelfEmploymentTaxSettings retrieves where self-employment tax is set aside
+func (h *IncomeHandler) GetSelfEmploymentTaxSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.service.GetSelfEmploymentTaxSettings(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, settings)
+}
+
+// SaveSelfEmploymentTaxSettings designates the account or envelope holding self-employment tax
+func (h *IncomeHandler) SaveSelfEmploymentTaxSettings(w http.ResponseWriter, r *http.Request) {
+	var req income.SaveSelfEmploymentTaxSettingsRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	settings, err := h.service.SaveSelfEmploymentTaxSettings(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, settings)
+}
+
+// GetSelfEmploymentTaxStatus compares estimated self-employment tax with the amount set aside
+func (h *IncomeHandler) GetSelfEmploymentTaxStatus(w http.ResponseWriter, r *http.Request) {
+	yearStr := chi.URLParam(r, "year")
+	if yearStr == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("year is required"))
+		return
+	}
+
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid year: %w", err))
+		return
+	}
+
+	status, err := h.service.GetSelfEmploymentTaxStatus(r.Context(), year)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, status)
+}
+
+// parseYearParam reads the {year} URL parameter
+func parseYearParam(r *http.Request) (int, error) {
+	yearStr := chi.URLParam(r, "year")
+	if yearStr == "" {
+		return 0, fmt.Errorf("year is required")
+	}
+
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid year: %w", err)
+	}
+	return year, nil
+}
+
+// GenerateInstallmentSchedule builds a year's quarterly estimated tax installments
+func (h *IncomeHandler) GenerateInstallmentSchedule(w http.ResponseWriter, r *http.Request) {
+	year, err := parseYearParam(r)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var req income.GenerateInstallmentsRequest
+	if r.ContentLength > 0 {
+		if err := server.ParseJSON(r, &req); err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+	}
+
+	schedule, err := h.service.GenerateInstallmentSchedule(r.Context(), year, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, schedule)
+}
+
+// GetInstallmentSchedule retrieves a year's installments, filtered by ?jurisdiction=cra|irs
+func (h *IncomeHandler) GetInstallmentSchedule(w http.ResponseWriter, r *http.Request) {
+	year, err := parseYearParam(r)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	jurisdiction := income.Jurisdiction(r.URL.Query().Get("jurisdiction"))
+	schedule, err := h.service.GetInstallmentSchedule(r.Context(), year, jurisdiction)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, schedule)
+}
+
+// RecordInstallmentPayment records a payment toward an installment
+func (h *IncomeHandler) RecordInstallmentPayment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("installment ID is required"))
+		return
+	}
+
+	var req income.RecordInstallmentPaymentRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	payment, err := h.service.RecordInstallmentPayment(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, payment)
+}
+
+// GetUpcomingInstallments lists unpaid installments due soon, within ?days (default 14)
+func (h *IncomeHandler) GetUpcomingInstallments(w http.ResponseWriter, r *http.Request) {
+	days := income.DefaultInstallmentReminderDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed < 0 {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid days: %s", daysStr))
+			return
+		}
+		days = parsed
+	}
+
+	resp, err := h.service.GetUpcomingInstallments(r.Context(), days)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// ImportPaystubs imports paystubs from an ADP or Workday CSV export sent as the request body.
+// ?format= overrides format detection, ?employer= names the employer when the export has no
+// employer column, and ?dry_run=true previews the import.
+func (h *IncomeHandler) ImportPaystubs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := income.PaystubFormat(query.Get("format"))
+	dryRun := query.Get("dry_run") == "true"
+
+	resp, err := h.service.ImportPaystubs(r.Context(), r.Body, format, query.Get("employer"), dryRun)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	status := http.StatusCreated
+	if dryRun {
+		status = http.StatusOK
+	}
+	server.RespondJSON(w, status, resp)
+}
+
+// ListPaystubs lists paystubs for ?year=, defaulting to the current year
+func (h *IncomeHandler) ListPaystubs(w http.ResponseWriter, r *http.Request) {
+	year := time.Now().Year()
+	if yearStr := r.URL.Query().Get("year"); yearStr != "" {
+		y, err := strconv.Atoi(yearStr)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid year: %w", err))
+			return
+		}
+		year = y
+	}
+
+	resp, err := h.service.ListPaystubs(r.Context(), year)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// GetPaystubSummary totals a year's paystubs
+func (h *IncomeHandler) GetPaystubSummary(w http.ResponseWriter, r *http.Request) {
+	year, err := parseYearParam(r)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	summary, err := h.service.GetPaystubSummary(r.Context(), year)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, summary)
+}
+
+// DeletePaystub deletes a paystub and its income record
+func (h *IncomeHandler) DeletePaystub(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("paystub ID is required"))
+		return
+	}
+
+	if err := h.service.DeletePaystub(r.Context(), id); err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// ListBonusPlans lists bonus and DPSP plans with their payouts
+func (h *IncomeHandler) ListBonusPlans(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.ListBonusPlans(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// CreateBonusPlan creates a bonus or DPSP plan
+func (h *IncomeHandler) CreateBonusPlan(w http.ResponseWriter, r *http.Request) {
+	var req income.CreateBonusPlanRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	plan, err := h.service.CreateBonusPlan(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, plan)
+}
+
+// GetBonusPlan retrieves a bonus plan with its payouts
+func (h *IncomeHandler) GetBonusPlan(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("bonus plan ID is required"))
+		return
+	}
+
+	plan, err := h.service.GetBonusPlan(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, plan)
+}
+
+// UpdateBonusPlan updates a bonus plan
+func (h *IncomeHandler) UpdateBonusPlan(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("bonus plan ID is required"))
+		return
+	}
+
+	var req income.UpdateBonusPlanRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	plan, err := h.service.UpdateBonusPlan(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, plan)
+}
+
+// DeleteBonusPlan deletes a bonus plan, its payouts and their income records
+func (h *IncomeHandler) DeleteBonusPlan(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("bonus plan ID is required"))
+		return
+	}
+
+	if err := h.service.DeleteBonusPlan(r.Context(), id); err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// RecordBonusPayout records a payout under a bonus plan
+func (h *IncomeHandler) RecordBonusPayout(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("bonus plan ID is required"))
+		return
+	}
+
+	var req income.RecordBonusPayoutRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	payout, err := h.service.RecordBonusPayout(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, payout)
+}
+
+// DeleteBonusPayout deletes a bonus payout and its income record
+func (h *IncomeHandler) DeleteBonusPayout(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("bonus payout ID is required"))
+		return
+	}
+
+	if err := h.service.DeleteBonusPayout(r.Context(), id); err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
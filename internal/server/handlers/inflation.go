@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"money/internal/inflation"
+	"money/internal/server"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// InflationHandler handles CPI data HTTP requests
+type InflationHandler struct {
+	service *inflation.Service
+}
+
+// NewInflationHandler creates a new inflation handler
+func NewInflationHandler(service *inflation.Service) *InflationHandler {
+	return &InflationHandler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers all inflation routes
+func (h *InflationHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/inflation/{region}", func(r chi.Router) {
+		r.Get("/", h.GetObservations)
+		r.Get("/trailing", h.GetTrailingInflation)
+	})
+}
+
+// GetObservations lists the stored CPI readings for a region
+func (h *InflationHandler) GetObservations(w http.ResponseWriter, r *http.Request) {
+	region, err := h.service.ParseRegion(chi.URLParam(r, "region"))
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	observations, err := h.service.GetObservations(r.Context(), region)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, observations)
+}
+
+// GetTrailingInflation returns a region's year-over-year inflation to the latest reading
+func (h *InflationHandler) GetTrailingInflation(w http.ResponseWriter, r *http.Request) {
+	region, err := h.service.ParseRegion(chi.URLParam(r, "region"))
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rate, err := h.service.TrailingInflation(r.Context(), region)
+	if errors.Is(err, inflation.ErrNoData) {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, rate)
+}
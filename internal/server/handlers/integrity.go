@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"money/internal/data"
+	"money/internal/server"
+	"money/internal/settings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// IntegrityHandler handles data integrity check HTTP requests
+type IntegrityHandler struct {
+	service *data.IntegrityService
+	admins  *settings.Service
+}
+
+// NewIntegrityHandler creates a new integrity handler
+func NewIntegrityHandler(service *data.IntegrityService, admins *settings.Service) *IntegrityHandler {
+	return &IntegrityHandler{
+		service: service,
+		admins:  admins,
+	}
+}
+
+// RegisterRoutes registers all integrity routes
+func (h *IntegrityHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/admin/integrity", func(r chi.Router) {
+		r.Use(requireAdmin(h.admins))
+		r.Get("/", h.Check)
+		r.Post("/repair", h.Repair)
+	})
+}
+
+// Check scans the instance's data and returns the issues found with a repair plan
+func (h *IntegrityHandler) Check(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.Check(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, report)
+}
+
+// Repair applies the safe fixes and returns the issues left to review
+func (h *IntegrityHandler) Repair(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.Repair(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"money/internal/notification"
+	"money/internal/server"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// NotificationHandler handles notification-related HTTP requests
+type NotificationHandler struct {
+	service *notification.Service
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(service *notification.Service) *NotificationHandler {
+	return &NotificationHandler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers all notification routes
+func (h *NotificationHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/notifications", func(r chi.Router) {
+		r.Get("/", h.List)
+		r.Post("/refresh", h.Refresh)
+		r.Post("/read-all", h.MarkAllRead)
+		r.Get("/preferences", h.GetDeliveryPreferences)
+		r.Put("/preferences", h.UpdateDeliveryPreferences)
+		r.Get("/deliveries", h.ListDeliveries)
+		r.Post("/{id}/read", h.MarkRead)
+		r.Delete("/{id}", h.Delete)
+	})
+}
+
+// List returns the user's notifications
+func (h *NotificationHandler) List(w http.ResponseWriter, r *http.Request) {
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+
+	resp, err := h.service.List(r.Context(), unreadOnly)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// Refresh runs notification checks for the user
+func (h *NotificationHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.Refresh(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// MarkRead marks a notification as read
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("notification ID is required"))
+		return
+	}
+
+	if err := h.service.MarkRead(r.Context(), id); err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// MarkAllRead marks all of the user's notifications as read
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.MarkAllRead(r.Context()); err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// Delete removes a notification
+func (h *NotificationHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("notification ID is required"))
+		return
+	}
+
+	resp, err := h.service.Delete(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// GetDeliveryPreferences returns how the user's notifications are delivered
+func (h *NotificationHandler) GetDeliveryPreferences(w http.ResponseWriter, r *http.Request) {
+	prefs, err := h.service.GetDeliveryPreferences(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, prefs)
+}
+
+// UpdateDeliveryPreferences changes the digest, quiet hours and channels of the user's notifications
+func (h *NotificationHandler) UpdateDeliveryPreferences(w http.ResponseWriter, r *http.Request) {
+	var req notification.UpdateDeliveryPreferencesRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	prefs, err := h.service.UpdateDeliveryPreferences(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, prefs)
+}
+
+// ListDeliveries returns the user's recent email and webhook deliveries
+func (h *NotificationHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.ListDeliveries(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"money/internal/preferences"
+	"money/internal/server"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// PreferencesHandler handles user preference HTTP requests
+type PreferencesHandler struct {
+	service *preferences.Service
+}
+
+// NewPreferencesHandler creates a new preferences handler
+func NewPreferencesHandler(service *preferences.Service) *PreferencesHandler {
+	return &PreferencesHandler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers all preference routes
+func (h *PreferencesHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/preferences", func(r chi.Router) {
+		r.Get("/", h.Get)
+		r.Put("/", h.Update)
+		r.Get("/jurisdiction", h.GetJurisdiction)
+	})
+}
+
+// Get returns the current user's preferences
+func (h *PreferencesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	prefs, err := h.service.Get(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, prefs)
+}
+
+// Update changes the current user's preferences
+func (h *PreferencesHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req preferences.UpdatePreferencesRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	prefs, err := h.service.Update(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, prefs)
+}
+
+// GetJurisdiction returns the current user's jurisdiction profile and its holidays for
+// ?year=, defaulting to the current year
+func (h *PreferencesHandler) GetJurisdiction(w http.ResponseWriter, r *http.Request) {
+	year := time.Now().Year()
+	if yearStr := r.URL.Query().Get("year"); yearStr != "" {
+		parsed, err := strconv.Atoi(yearStr)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid year: %w", err))
+			return
+		}
+		year = parsed
+	}
+
+	details, err := h.service.GetJurisdiction(r.Context(), year)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, details)
+}
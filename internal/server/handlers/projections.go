@@ -27,13 +27,24 @@ func (h *ProjectionsHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/projections", func(r chi.Router) {
 		// Calculate projection based on config
 		r.Post("/calculate", h.Calculate)
+		r.Post("/drawdown-simulation", h.SimulateDrawdown)
+		r.Post("/what-if", h.SimulatePurchase)
+		r.Post("/sensitivity", h.AnalyzeSensitivity)
 
-		// Manage projection scenarios
-		r.Post("/scenarios", h.SaveConfig)
+		// Suggest starting assumptions from a preset and the user's own history
+		r.Get("/assumptions", h.SuggestAssumptions)
+
+		// Manage projection scenarios. Saved configs are sent back as they were loaded and
+		// may carry settings from older versions, so unknown fields are allowed.
+		r.With(server.AllowUnknownFields).Post("/scenarios", h.SaveConfig)
 		r.Get("/scenarios", h.ListScenarios)
 		r.Get("/scenarios/{id}", h.GetConfig)
-		r.Put("/scenarios/{id}", h.UpdateConfig)
+		r.With(server.AllowUnknownFields).Put("/scenarios/{id}", h.UpdateConfig)
 		r.Delete("/scenarios/{id}", h.DeleteConfig)
+
+		// Run every saved scenario, e.g. nightly from a service API key, and read the results
+		r.Post("/scenarios/run", h.RunAllScenarios)
+		r.Get("/scenarios/comparison", h.GetScenarioComparison)
 	})
 }
 
@@ -54,6 +65,73 @@ func (h *ProjectionsHandler) Calculate(w http.ResponseWriter, r *http.Request) {
 	server.RespondJSON(w, http.StatusOK, resp)
 }
 
+// SimulateDrawdown stress-tests a retirement drawdown plan against return sequences
+func (h *ProjectionsHandler) SimulateDrawdown(w http.ResponseWriter, r *http.Request) {
+	var req projections.DrawdownRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.SimulateDrawdown(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// SimulatePurchase shows the impact of a hypothetical purchase without saving anything
+func (h *ProjectionsHandler) SimulatePurchase(w http.ResponseWriter, r *http.Request) {
+	var req projections.WhatIfRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.SimulatePurchase(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// AnalyzeSensitivity ranks projection assumptions by how much they move the outcome
+func (h *ProjectionsHandler) AnalyzeSensitivity(w http.ResponseWriter, r *http.Request) {
+	var req projections.SensitivityRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.AnalyzeSensitivity(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// SuggestAssumptions suggests projection assumptions for a preset
+func (h *ProjectionsHandler) SuggestAssumptions(w http.ResponseWriter, r *http.Request) {
+	req := projections.AssumptionsRequest{
+		Preset:    projections.AssumptionPreset(r.URL.Query().Get("preset")),
+		CPIRegion: r.URL.Query().Get("cpi_region"),
+	}
+
+	resp, err := h.service.SuggestAssumptions(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
 // SaveConfig creates a new projection scenario
 func (h *ProjectionsHandler) SaveConfig(w http.ResponseWriter, r *http.Request) {
 	var req projections.CreateScenarioRequest
@@ -82,6 +160,28 @@ func (h *ProjectionsHandler) ListScenarios(w http.ResponseWriter, r *http.Reques
 	server.RespondJSON(w, http.StatusOK, resp)
 }
 
+// RunAllScenarios runs every saved scenario and stores the results
+func (h *ProjectionsHandler) RunAllScenarios(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.RunAllScenarios(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// GetScenarioComparison returns the latest stored scenario results
+func (h *ProjectionsHandler) GetScenarioComparison(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.GetScenarioComparison(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
 // GetConfig retrieves a specific projection scenario
 func (h *ProjectionsHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
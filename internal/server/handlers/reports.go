@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"money/internal/auth"
+	"money/internal/reports"
+	"money/internal/server"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ReportsHandler handles scheduled report delivery HTTP requests
+type ReportsHandler struct {
+	service *reports.Service
+}
+
+// NewReportsHandler creates a new reports handler
+func NewReportsHandler(service *reports.Service) *ReportsHandler {
+	return &ReportsHandler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers all report schedule routes
+func (h *ReportsHandler) RegisterRoutes(r chi.Router) {
+	// Schedules upload reports elsewhere, so they need an interactive session
+	r.Group(func(r chi.Router) {
+		r.Use(auth.RequireSession)
+		r.Get("/reports/schedules", h.ListSchedules)
+		r.Post("/reports/schedules", h.CreateSchedule)
+		r.Get("/reports/schedules/{id}", h.GetSchedule)
+		r.Put("/reports/schedules/{id}", h.UpdateSchedule)
+		r.Delete("/reports/schedules/{id}", h.DeleteSchedule)
+		r.Post("/reports/schedules/{id}/run", h.RunSchedule)
+	})
+}
+
+// ListSchedules lists the user's report delivery schedules with their last delivery status
+func (h *ReportsHandler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.ListSchedules(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// CreateSchedule sets up a report to be delivered on a cron schedule
+func (h *ReportsHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var req reports.CreateScheduleRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	schedule, err := h.service.CreateSchedule(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, schedule)
+}
+
+// GetSchedule retrieves a report delivery schedule
+func (h *ReportsHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("schedule ID is required"))
+		return
+	}
+
+	schedule, err := h.service.GetSchedule(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, schedule)
+}
+
+// UpdateSchedule changes a report delivery schedule
+func (h *ReportsHandler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("schedule ID is required"))
+		return
+	}
+
+	var req reports.UpdateScheduleRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	schedule, err := h.service.UpdateSchedule(r.Context(), id, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, schedule)
+}
+
+// DeleteSchedule removes a report delivery schedule
+func (h *ReportsHandler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("schedule ID is required"))
+		return
+	}
+
+	if err := h.service.DeleteSchedule(r.Context(), id); err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// RunSchedule delivers a schedule's report now and returns the outcome
+func (h *ReportsHandler) RunSchedule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("schedule ID is required"))
+		return
+	}
+
+	schedule, err := h.service.RunSchedule(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, schedule)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"money/internal/risk"
+	"money/internal/server"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RiskHandler handles risk scoring HTTP requests
+type RiskHandler struct {
+	service *risk.Service
+}
+
+// NewRiskHandler creates a new risk handler
+func NewRiskHandler(service *risk.Service) *RiskHandler {
+	return &RiskHandler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers all risk routes
+func (h *RiskHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/risk", func(r chi.Router) {
+		r.Get("/", h.GetPortfolioRisk)
+		r.Get("/employer-exposure", h.GetEmployerExposure)
+		r.Get("/settings", h.GetSettings)
+		r.Put("/settings", h.UpdateSettings)
+	})
+}
+
+// GetPortfolioRisk returns account and holding risk scores and the portfolio summary
+func (h *RiskHandler) GetPortfolioRisk(w http.ResponseWriter, r *http.Request) {
+	portfolio, err := h.service.GetPortfolioRisk(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, portfolio)
+}
+
+// GetEmployerExposure returns how much of the user's wealth and income depends on their employer
+func (h *RiskHandler) GetEmployerExposure(w http.ResponseWriter, r *http.Request) {
+	exposure, err := h.service.GetEmployerExposure(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, exposure)
+}
+
+// GetSettings returns the user's risk tolerance and concentration settings
+func (h *RiskHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.service.GetSettings(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, settings)
+}
+
+// UpdateSettings changes the user's risk tolerance and concentration settings
+func (h *RiskHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	var req risk.UpdateSettingsRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	settings, err := h.service.UpdateSettings(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, settings)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"money/internal/auth"
+	"money/internal/server"
+	"money/internal/settings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SettingsHandler handles runtime settings HTTP requests
+type SettingsHandler struct {
+	service *settings.Service
+}
+
+// NewSettingsHandler creates a new runtime settings handler
+func NewSettingsHandler(service *settings.Service) *SettingsHandler {
+	return &SettingsHandler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers all runtime settings routes
+func (h *SettingsHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/admin/settings", func(r chi.Router) {
+		r.Use(requireAdmin(h.service))
+		r.Get("/", h.List)
+		r.Put("/{key}", h.Update)
+	})
+}
+
+// requireAdmin rejects requests from users who aren't instance administrators, and any made
+// with a service key
+func requireAdmin(admins *settings.Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return auth.RequireSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !admins.IsAdmin(r.Context()) {
+				server.RespondError(w, http.StatusForbidden, fmt.Errorf("administrator access required"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		}))
+	}
+}
+
+// List returns every runtime setting with its stored value
+func (h *SettingsHandler) List(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.List(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// Update changes a runtime setting and applies it immediately
+func (h *SettingsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "key")
+	if key == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("setting key is required"))
+		return
+	}
+
+	var req settings.UpdateSettingRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	setting, err := h.service.Set(r.Context(), key, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, setting)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"money/internal/account"
+	"money/internal/auth"
+	"money/internal/server"
+	"money/internal/share"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ShareHandler handles report share link HTTP requests
+type ShareHandler struct {
+	service    *share.Service
+	accountSvc *account.Service
+}
+
+// NewShareHandler creates a new share link handler
+func NewShareHandler(service *share.Service, accountSvc *account.Service) *ShareHandler {
+	return &ShareHandler{
+		service:    service,
+		accountSvc: accountSvc,
+	}
+}
+
+// RegisterRoutes registers the routes for managing share links
+func (h *ShareHandler) RegisterRoutes(r chi.Router) {
+	// Share links hand out data publicly, so they need an interactive session
+	r.Route("/shares", func(r chi.Router) {
+		r.Use(auth.RequireSession)
+		r.Post("/", h.CreateLink)
+		r.Get("/", h.ListLinks)
+		r.Delete("/{id}", h.RevokeLink)
+		r.Get("/{id}/accesses", h.ListAccesses)
+	})
+}
+
+// RegisterPublicRoutes registers the unauthenticated route that serves shared reports
+func (h *ShareHandler) RegisterPublicRoutes(r chi.Router) {
+	r.With(auth.ClientIPMiddleware).Get("/shared/{token}", h.GetSharedReport)
+}
+
+// CreateLink creates a share link and returns its token
+func (h *ShareHandler) CreateLink(w http.ResponseWriter, r *http.Request) {
+	var req share.CreateLinkRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	link, err := h.service.CreateLink(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, link)
+}
+
+// ListLinks returns the user's share links
+func (h *ShareHandler) ListLinks(w http.ResponseWriter, r *http.Request) {
+	links, err := h.service.ListLinks(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, links)
+}
+
+// RevokeLink stops a share link from working
+func (h *ShareHandler) RevokeLink(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.RevokeLink(r.Context(), chi.URLParam(r, "id")); err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// ListAccesses returns a share link's access log
+func (h *ShareHandler) ListAccesses(w http.ResponseWriter, r *http.Request) {
+	accesses, err := h.service.ListAccesses(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, accesses)
+}
+
+// GetSharedReport serves the report a share link grants access to, read-only and as the
+// user who shared it
+func (h *ShareHandler) GetSharedReport(w http.ResponseWriter, r *http.Request) {
+	link, err := h.service.Resolve(r.Context(), chi.URLParam(r, "token"), share.AccessInfo{
+		IPAddress: auth.GetClientIP(r.Context()),
+		UserAgent: r.UserAgent(),
+	})
+	if err != nil {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+	// Anyone holding the link sees the report, so private balances are always masked
+	ctx := auth.WithMaskPrivate(auth.WithUserID(r.Context(), link.UserID))
+
+	switch link.Report {
+	case share.ReportEstate:
+		report, err := h.accountSvc.GetEstateReport(ctx)
+		if err != nil {
+			server.RespondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		respondEstateReport(w, r, report)
+	case share.ReportNetWorth:
+		statement, err := h.accountSvc.GetNetWorthStatement(ctx)
+		if err != nil {
+			server.RespondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		respondNetWorthStatement(w, r, statement)
+	default:
+		server.RespondError(w, http.StatusNotFound, fmt.Errorf("unknown report: %s", link.Report))
+	}
+}
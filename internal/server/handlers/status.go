@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"money/internal/server"
+	"money/internal/status"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// StatusHandler serves instance status to uptime monitors
+type StatusHandler struct {
+	service *status.Service
+}
+
+// NewStatusHandler creates a new status handler
+func NewStatusHandler(service *status.Service) *StatusHandler {
+	return &StatusHandler{
+		service: service,
+	}
+}
+
+// RegisterPublicRoutes registers the status route, which needs no authentication
+func (h *StatusHandler) RegisterPublicRoutes(r chi.Router) {
+	r.Get("/status", h.GetStatus)
+}
+
+// GetStatus returns the instance status, with 503 when it is degraded so monitors alert
+func (h *StatusHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	resp := h.service.Get(r.Context())
+
+	code := http.StatusOK
+	if resp.Status != status.StateOK {
+		code = http.StatusServiceUnavailable
+	}
+	server.RespondJSON(w, code, resp)
+}
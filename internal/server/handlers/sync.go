@@ -1,17 +1,20 @@
 package handlers
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
-	"money/internal/auth"
+	"money/internal/logger"
 	"money/internal/server"
 	"money/internal/sync"
 
 	"github.com/go-chi/chi/v5"
 )
 
+// handlersLog logs for the handlers module
+var handlersLog = logger.Module("handlers")
+
 // SyncHandler handles sync-related HTTP requests
 type SyncHandler struct {
 	service *sync.Service
@@ -35,8 +38,23 @@ func (h *SyncHandler) RegisterRoutes(r chi.Router) {
 		r.Get("/connections/{id}", h.GetConnection)
 		r.Get("/connections/{id}/status", h.GetConnectionSyncStatus)
 		r.Post("/connections/{id}/sync", h.TriggerConnectionSync)
+		r.Get("/connections/{id}/discover", h.DiscoverAccounts)
+		r.Post("/connections/{id}/accounts", h.SelectAccounts)
 		r.Put("/connections/{id}", h.UpdateConnection)
 		r.Delete("/connections/{id}", h.DeleteConnection)
+
+		// Per-account pause and single account syncs
+		r.Get("/accounts", h.ListSyncedAccounts)
+		r.Put("/accounts/{accountId}/pause", h.SetAccountPaused)
+		r.Post("/accounts/{accountId}/sync", h.TriggerAccountSync)
+
+		// Sync job audit
+		r.Get("/jobs/{id}/changes", h.GetSyncJobChanges)
+
+		// Conflict handling for manually edited synced accounts
+		r.Put("/accounts/{accountId}/conflict-policy", h.SetConflictPolicy)
+		r.Get("/conflicts", h.ListConflicts)
+		r.Post("/conflicts/{id}/resolve", h.ResolveConflict)
 	})
 }
 
@@ -146,16 +164,14 @@ func (h *SyncHandler) TriggerConnectionSync(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Trigger the sync asynchronously
-	go func() {
-		// Create a new context with the user ID for the background operation
-		ctx := auth.WithUserID(context.Background(), conn.UserID)
-		if err := h.service.TriggerSync(ctx, conn.UserID, id); err != nil {
-			// Log and store the error in the database
-			fmt.Printf("ERROR: sync failed for connection %s: %v\n", id, err)
-			// Update connection to show error
-			_ = h.service.UpdateConnectionError(ctx, id, err.Error())
+	if _, err := h.service.TriggerConnectionSync(r.Context(), conn.ID); err != nil {
+		if errors.Is(err, sync.ErrSyncInProgress) {
+			server.RespondError(w, http.StatusConflict, err)
+			return
 		}
-	}()
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
 
 	// Return a pending status - actual sync happens asynchronously
 	resp := &sync.TriggerSyncResponse{
@@ -167,6 +183,50 @@ func (h *SyncHandler) TriggerConnectionSync(w http.ResponseWriter, r *http.Reque
 	server.RespondJSON(w, http.StatusAccepted, resp)
 }
 
+// DiscoverAccounts lists a connection's provider accounts with their proposed mapping
+func (h *SyncHandler) DiscoverAccounts(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("connection ID is required"))
+		return
+	}
+
+	resp, err := h.service.DiscoverAccounts(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// SelectAccounts creates local accounts for the chosen provider accounts and starts a sync
+func (h *SyncHandler) SelectAccounts(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("connection ID is required"))
+		return
+	}
+
+	var req sync.SelectAccountsRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.SelectAccounts(r.Context(), id, &req)
+	if errors.Is(err, sync.ErrSyncInProgress) {
+		server.RespondError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusAccepted, resp)
+}
+
 // UpdateConnection updates connection settings
 func (h *SyncHandler) UpdateConnection(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -205,3 +265,131 @@ func (h *SyncHandler) DeleteConnection(w http.ResponseWriter, r *http.Request) {
 
 	server.RespondJSON(w, http.StatusOK, resp)
 }
+
+// GetSyncJobChanges returns the change log recorded by a sync job
+func (h *SyncHandler) GetSyncJobChanges(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("sync job ID is required"))
+		return
+	}
+
+	resp, err := h.service.GetSyncJobChanges(r.Context(), id)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// ListSyncedAccounts lists synced accounts with their pause state
+func (h *SyncHandler) ListSyncedAccounts(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.ListSyncedAccounts(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// SetAccountPaused pauses or resumes syncing for a synced account
+func (h *SyncHandler) SetAccountPaused(w http.ResponseWriter, r *http.Request) {
+	accountID := chi.URLParam(r, "accountId")
+	if accountID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req sync.SetAccountPausedRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	acc, err := h.service.SetAccountPaused(r.Context(), accountID, &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, acc)
+}
+
+// TriggerAccountSync syncs a single account rather than its whole connection
+func (h *SyncHandler) TriggerAccountSync(w http.ResponseWriter, r *http.Request) {
+	accountID := chi.URLParam(r, "accountId")
+	if accountID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	resp, err := h.service.TriggerAccountSync(r.Context(), accountID)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusAccepted, resp)
+}
+
+// SetConflictPolicy sets how syncs treat manual edits on a synced account
+func (h *SyncHandler) SetConflictPolicy(w http.ResponseWriter, r *http.Request) {
+	accountID := chi.URLParam(r, "accountId")
+	if accountID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("account ID is required"))
+		return
+	}
+
+	var req sync.SetConflictPolicyRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if !sync.IsValidConflictPolicy(req.Policy) {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid conflict policy: %s", req.Policy))
+		return
+	}
+
+	if err := h.service.SetConflictPolicy(r.Context(), accountID, &req); err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// ListConflicts lists sync conflicts, optionally filtered by status
+func (h *SyncHandler) ListConflicts(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.ListConflicts(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// ResolveConflict resolves a flagged sync conflict
+func (h *SyncHandler) ResolveConflict(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("conflict ID is required"))
+		return
+	}
+
+	var req sync.ResolveConflictRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if err := h.service.ResolveConflict(r.Context(), id, &req); err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
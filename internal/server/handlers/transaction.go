@@ -1,10 +1,17 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"money/internal/server"
+	"money/internal/statement"
 	"money/internal/transaction"
 
 	"github.com/go-chi/chi/v5"
@@ -27,10 +34,106 @@ func (h *TransactionHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/recurring-expenses", func(r chi.Router) {
 		r.Post("/", h.CreateRecurringExpense)
 		r.Get("/", h.ListRecurringExpenses)
+		r.Post("/bulk", h.BulkCreateRecurringExpenses)
+		r.Put("/bulk", h.BulkUpdateRecurringExpenses)
+		r.Post("/bulk/disable", h.BulkDisableRecurringExpenses)
+		r.Get("/annualize", h.AnnualizeRecurringAmount)
 		r.Get("/{id}", h.GetRecurringExpense)
 		r.Put("/{id}", h.UpdateRecurringExpense)
 		r.Delete("/{id}", h.DeleteRecurringExpense)
 	})
+
+	r.Route("/transactions", func(r chi.Router) {
+		r.Post("/", h.CreateTransaction)
+		r.Get("/", h.ListTransactions)
+		r.Get("/spending-by-category", h.GetCategorySpending)
+		r.Get("/variance", h.GetCashFlowVariance)
+		r.Get("/calendar", h.GetCashFlowCalendar)
+		r.Get("/{id}", h.GetTransaction)
+		r.Put("/{id}/splits", h.SetSplits)
+		r.Post("/{id}/settle", h.SettlePending)
+		r.Post("/{id}/iou", h.SplitTransactionWithPerson)
+		r.Delete("/{id}", h.DeleteTransaction)
+	})
+
+	r.Route("/categories", func(r chi.Router) {
+		r.Post("/", h.CreateCategory)
+		r.Get("/", h.ListCategories)
+		r.Get("/{id}", h.GetCategory)
+		r.Put("/{id}", h.UpdateCategory)
+		r.Delete("/{id}", h.DeleteCategory)
+		r.Post("/{id}/merge", h.MergeCategory)
+	})
+
+	r.Route("/budgets", func(r chi.Router) {
+		r.Post("/", h.CreateCategoryBudget)
+		r.Get("/", h.ListCategoryBudgets)
+		r.Get("/progress", h.GetBudgetProgress)
+		r.Get("/{id}", h.GetCategoryBudget)
+		r.Put("/{id}", h.UpdateCategoryBudget)
+		r.Delete("/{id}", h.DeleteCategoryBudget)
+		r.Get("/{id}/progress", h.GetCategoryBudgetProgress)
+	})
+
+	r.Route("/scheduled-transactions", func(r chi.Router) {
+		r.Post("/", h.CreateScheduledTransaction)
+		r.Get("/", h.ListScheduledTransactions)
+		r.Get("/{id}", h.GetScheduledTransaction)
+		r.Post("/{id}/confirm", h.ConfirmScheduledTransaction)
+		r.Post("/{id}/cancel", h.CancelScheduledTransaction)
+	})
+
+	r.Route("/transfers", func(r chi.Router) {
+		r.Post("/", h.CreateTransfer)
+		r.Get("/", h.ListTransfers)
+		r.Get("/{id}", h.GetTransfer)
+		r.Delete("/{id}", h.DeleteTransfer)
+	})
+
+	r.Route("/statements", func(r chi.Router) {
+		r.Get("/templates", h.ListStatementTemplates)
+		r.Post("/imports", h.ImportStatement)
+		// External scripts push balances and transactions here with a push-scoped API key
+		r.Post("/push", h.PushStatement)
+		r.Get("/imports", h.ListStatementImports)
+		r.Get("/imports/{id}", h.GetStatementImport)
+		r.Put("/imports/{id}/lines/{lineId}", h.UpdateStatementImportLine)
+		r.Post("/imports/{id}/commit", h.CommitStatementImport)
+		r.Delete("/imports/{id}", h.DeleteStatementImport)
+	})
+
+	r.Route("/iou", func(r chi.Router) {
+		r.Post("/people", h.CreateIOUPerson)
+		r.Get("/people", h.ListIOUPeople)
+		r.Get("/people/{id}", h.GetIOULedger)
+		r.Put("/people/{id}", h.UpdateIOUPerson)
+		r.Delete("/people/{id}", h.DeleteIOUPerson)
+		r.Post("/people/{id}/entries", h.RecordIOUEntry)
+		r.Delete("/people/{id}/entries/{entryId}", h.DeleteIOUEntry)
+	})
+
+	r.Route("/projects", func(r chi.Router) {
+		r.Post("/", h.CreateProject)
+		r.Get("/", h.ListProjects)
+		r.Get("/{id}", h.GetProject)
+		r.Put("/{id}", h.UpdateProject)
+		r.Delete("/{id}", h.DeleteProject)
+		r.Post("/{id}/transactions", h.TagProjectTransactions)
+		r.Delete("/{id}/transactions/{transactionId}", h.UntagProjectTransaction)
+		r.Post("/{id}/planned", h.AddPlannedExpense)
+		r.Put("/{id}/planned/{plannedId}", h.UpdatePlannedExpense)
+		r.Delete("/{id}/planned/{plannedId}", h.DeletePlannedExpense)
+	})
+
+	r.Route("/sweeps", func(r chi.Router) {
+		r.Post("/rules", h.CreateSweepRule)
+		r.Get("/rules", h.ListSweepRules)
+		r.Put("/rules/{id}", h.UpdateSweepRule)
+		r.Delete("/rules/{id}", h.DeleteSweepRule)
+		r.Get("/runs", h.ListSweepRuns)
+		r.Post("/runs/{id}/accept", h.AcceptSweepRun)
+		r.Post("/runs/{id}/dismiss", h.DismissSweepRun)
+	})
 }
 
 // CreateRecurringExpense creates a new recurring expense
@@ -125,3 +228,1479 @@ func (h *TransactionHandler) DeleteRecurringExpense(w http.ResponseWriter, r *ht
 
 	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
 }
+
+// BulkCreateRecurringExpenses creates a list of recurring expenses as JSON, or as CSV when
+// sent with a text/csv content type. Pass ?convert_to=monthly with CSV to store every amount
+// at one frequency.
+func (h *TransactionHandler) BulkCreateRecurringExpenses(w http.ResponseWriter, r *http.Request) {
+	var req transaction.BulkCreateRecurringExpensesRequest
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		expenses, err := transaction.ParseRecurringExpensesCSV(r.Body)
+		if err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid CSV: %w", err))
+			return
+		}
+		req.Expenses = expenses
+		req.ConvertTo = r.URL.Query().Get("convert_to")
+	} else if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.BulkCreateRecurringExpenses(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, resp)
+}
+
+// BulkUpdateRecurringExpenses applies a list of changes to recurring expenses
+func (h *TransactionHandler) BulkUpdateRecurringExpenses(w http.ResponseWriter, r *http.Request) {
+	var req transaction.BulkUpdateRecurringExpensesRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.BulkUpdateRecurringExpenses(r.Context(), &req)
+	if err != nil {
+		if errors.Is(err, transaction.ErrNotFound) {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// BulkDisableRecurringExpenses deactivates a list of recurring expenses
+func (h *TransactionHandler) BulkDisableRecurringExpenses(w http.ResponseWriter, r *http.Request) {
+	var req transaction.BulkDisableRecurringExpensesRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.BulkDisableRecurringExpenses(r.Context(), &req)
+	if err != nil {
+		if errors.Is(err, transaction.ErrNotFound) {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// AnnualizeRecurringAmount converts ?amount= at ?frequency= to yearly and monthly totals, and
+// to the ?to= frequency when given
+func (h *TransactionHandler) AnnualizeRecurringAmount(w http.ResponseWriter, r *http.Request) {
+	amount, err := strconv.ParseFloat(r.URL.Query().Get("amount"), 64)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid amount"))
+		return
+	}
+
+	resp, err := transaction.Annualize(amount, r.URL.Query().Get("frequency"), r.URL.Query().Get("to"))
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// parseDateRange reads optional from/to (YYYY-MM-DD) query parameters; to is inclusive
+func parseDateRange(r *http.Request) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		parsed, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid from date: %w", err)
+		}
+		from = &parsed
+	}
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		parsed, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid to date: %w", err)
+		}
+		endOfDay := parsed.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		to = &endOfDay
+	}
+	return from, to, nil
+}
+
+// CreateTransaction creates a new transaction, optionally with splits
+func (h *TransactionHandler) CreateTransaction(w http.ResponseWriter, r *http.Request) {
+	var req transaction.CreateTransactionRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	txn, err := h.service.CreateTransaction(r.Context(), &req)
+	if err != nil {
+		if errors.Is(err, transaction.ErrRejected) {
+			server.RespondError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, txn)
+}
+
+// ListTransactions retrieves transactions, optionally filtered by account, date range and
+// ?status=, a page at a time with ?limit= and ?offset=. Pending transactions replaced by a
+// posted one are left out unless ?include_settled=true.
+func (h *TransactionHandler) ListTransactions(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	filter := transaction.ListTransactionsFilter{
+		AccountID:      r.URL.Query().Get("account_id"),
+		From:           from,
+		To:             to,
+		Status:         r.URL.Query().Get("status"),
+		IncludeSettled: r.URL.Query().Get("include_settled") == "true",
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %s", limitStr))
+			return
+		}
+		filter.Limit = parsed
+	}
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		parsed, err := strconv.Atoi(offsetStr)
+		if err != nil || parsed < 0 {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid offset: %s", offsetStr))
+			return
+		}
+		filter.Offset = parsed
+	}
+
+	resp, err := h.service.ListTransactions(r.Context(), filter)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// GetTransaction retrieves a specific transaction with its splits
+func (h *TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("transaction ID is required"))
+		return
+	}
+
+	txn, err := h.service.GetTransaction(r.Context(), id)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, txn)
+}
+
+// SetSplits replaces the category splits of a transaction
+func (h *TransactionHandler) SetSplits(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("transaction ID is required"))
+		return
+	}
+
+	var req transaction.SetSplitsRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	txn, err := h.service.SetSplits(r.Context(), id, &req)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, txn)
+}
+
+// SettlePending links a pending transaction to the posted transaction that replaced it
+func (h *TransactionHandler) SettlePending(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("transaction ID is required"))
+		return
+	}
+
+	var req transaction.SettlePendingRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	txn, err := h.service.SettlePending(r.Context(), id, &req)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, txn)
+}
+
+// DeleteTransaction deletes a transaction
+func (h *TransactionHandler) DeleteTransaction(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("transaction ID is required"))
+		return
+	}
+
+	err := h.service.DeleteTransaction(r.Context(), id)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// GetCategorySpending returns transaction totals per category, counting splits individually.
+// With ?rollup=true subcategories are counted toward their top-level category.
+func (h *TransactionHandler) GetCategorySpending(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var resp *transaction.CategorySpendingResponse
+	if r.URL.Query().Get("rollup") == "true" {
+		resp, err = h.service.GetCategorySpendingRollup(r.Context(), from, to)
+	} else {
+		resp, err = h.service.GetCategorySpending(r.Context(), from, to)
+	}
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// GetCashFlowVariance compares planned recurring expenses with actual spending per category
+func (h *TransactionHandler) GetCashFlowVariance(w http.ResponseWriter, r *http.Request) {
+	months := transaction.DefaultVarianceMonths
+	if monthsStr := r.URL.Query().Get("months"); monthsStr != "" {
+		parsed, err := strconv.Atoi(monthsStr)
+		if err != nil || parsed < 1 || parsed > transaction.MaxVarianceMonths {
+			server.RespondError(w, http.StatusBadRequest,
+				fmt.Errorf("months must be a number from 1 to %d", transaction.MaxVarianceMonths))
+			return
+		}
+		months = parsed
+	}
+
+	resp, err := h.service.GetCashFlowVariance(r.Context(), months)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// CreateTransfer moves money between two accounts
+func (h *TransactionHandler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	var req transaction.CreateTransferRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	transfer, err := h.service.CreateTransfer(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, transfer)
+}
+
+// ListTransfers lists transfers between accounts
+func (h *TransactionHandler) ListTransfers(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.ListTransfers(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// GetTransfer gets a transfer by ID
+func (h *TransactionHandler) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("transfer ID is required"))
+		return
+	}
+
+	transfer, err := h.service.GetTransfer(r.Context(), id)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, transfer)
+}
+
+// DeleteTransfer deletes a transfer and reverses its balance changes
+func (h *TransactionHandler) DeleteTransfer(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("transfer ID is required"))
+		return
+	}
+
+	err := h.service.DeleteTransfer(r.Context(), id)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// GetCashFlowCalendar lists posted and scheduled transactions by day, defaulting to the next 30 days
+func (h *TransactionHandler) GetCashFlowCalendar(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+	if from == nil {
+		today := time.Now().Truncate(24 * time.Hour)
+		from = &today
+	}
+	if to == nil {
+		end := from.AddDate(0, 0, 30)
+		to = &end
+	}
+
+	resp, err := h.service.GetCashFlowCalendar(r.Context(), *from, *to)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// CreateScheduledTransaction schedules a future-dated transaction
+func (h *TransactionHandler) CreateScheduledTransaction(w http.ResponseWriter, r *http.Request) {
+	var req transaction.CreateScheduledTransactionRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	scheduled, err := h.service.CreateScheduledTransaction(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, scheduled)
+}
+
+// ListScheduledTransactions lists scheduled transactions, optionally filtered by status
+func (h *TransactionHandler) ListScheduledTransactions(w http.ResponseWriter, r *http.Request) {
+	status := transaction.ScheduledStatus(r.URL.Query().Get("status"))
+
+	resp, err := h.service.ListScheduledTransactions(r.Context(), status)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// GetScheduledTransaction gets a scheduled transaction by ID
+func (h *TransactionHandler) GetScheduledTransaction(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("scheduled transaction ID is required"))
+		return
+	}
+
+	scheduled, err := h.service.GetScheduledTransaction(r.Context(), id)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, scheduled)
+}
+
+// ConfirmScheduledTransaction posts a scheduled transaction now
+func (h *TransactionHandler) ConfirmScheduledTransaction(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("scheduled transaction ID is required"))
+		return
+	}
+
+	scheduled, err := h.service.ConfirmScheduledTransaction(r.Context(), id)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, scheduled)
+}
+
+// CancelScheduledTransaction cancels a scheduled transaction
+func (h *TransactionHandler) CancelScheduledTransaction(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("scheduled transaction ID is required"))
+		return
+	}
+
+	scheduled, err := h.service.CancelScheduledTransaction(r.Context(), id)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, scheduled)
+}
+
+// CreateCategory creates a transaction category
+func (h *TransactionHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var req transaction.CategoryRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	category, err := h.service.CreateCategory(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, category)
+}
+
+// ListCategories lists the user's categories as a tree
+func (h *TransactionHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.ListCategories(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// GetCategory retrieves a category
+func (h *TransactionHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("category ID is required"))
+		return
+	}
+
+	category, err := h.service.GetCategory(r.Context(), id)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, category)
+}
+
+// UpdateCategory renames, moves or restyles a category
+func (h *TransactionHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("category ID is required"))
+		return
+	}
+
+	var req transaction.CategoryRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	category, err := h.service.UpdateCategory(r.Context(), id, &req)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, category)
+}
+
+// DeleteCategory deletes a category, moving its transactions to ?reassign_to= when set
+func (h *TransactionHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("category ID is required"))
+		return
+	}
+
+	if err := h.service.DeleteCategory(r.Context(), id, r.URL.Query().Get("reassign_to")); err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// MergeCategory merges a category into another
+func (h *TransactionHandler) MergeCategory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("category ID is required"))
+		return
+	}
+
+	var req transaction.MergeCategoryRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	category, err := h.service.MergeCategory(r.Context(), id, &req)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, category)
+}
+
+// CreateCategoryBudget creates a monthly budget for a category
+func (h *TransactionHandler) CreateCategoryBudget(w http.ResponseWriter, r *http.Request) {
+	var req transaction.CategoryBudgetRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	budget, err := h.service.CreateCategoryBudget(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, budget)
+}
+
+// ListCategoryBudgets lists the user's category budgets
+func (h *TransactionHandler) ListCategoryBudgets(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.ListCategoryBudgets(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// GetCategoryBudget retrieves a category budget
+func (h *TransactionHandler) GetCategoryBudget(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("budget ID is required"))
+		return
+	}
+
+	budget, err := h.service.GetCategoryBudget(r.Context(), id)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, budget)
+}
+
+// UpdateCategoryBudget updates a category budget's amount, rollover and start month
+func (h *TransactionHandler) UpdateCategoryBudget(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("budget ID is required"))
+		return
+	}
+
+	var req transaction.CategoryBudgetRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	budget, err := h.service.UpdateCategoryBudget(r.Context(), id, &req)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, budget)
+}
+
+// DeleteCategoryBudget deletes a category budget
+func (h *TransactionHandler) DeleteCategoryBudget(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("budget ID is required"))
+		return
+	}
+
+	if err := h.service.DeleteCategoryBudget(r.Context(), id); err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// GetBudgetProgress reports every budget's progress in ?month=YYYY-MM, defaulting to the
+// current month, with the carryover history leading up to it
+func (h *TransactionHandler) GetBudgetProgress(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.GetBudgetProgress(r.Context(), r.URL.Query().Get("month"))
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// GetCategoryBudgetProgress reports a single budget's progress in ?month=YYYY-MM
+func (h *TransactionHandler) GetCategoryBudgetProgress(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("budget ID is required"))
+		return
+	}
+
+	progress, err := h.service.GetCategoryBudgetProgress(r.Context(), id, r.URL.Query().Get("month"))
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, progress)
+}
+
+// maxStatementSize is the largest statement PDF accepted
+const maxStatementSize = 20 << 20
+
+// statementTemplate describes a statement layout that can be parsed
+type statementTemplate struct {
+	Name        string `json:"name"`
+	Institution string `json:"institution"`
+}
+
+// ListStatementTemplates lists the statement layouts uploads are parsed with
+func (h *TransactionHandler) ListStatementTemplates(w http.ResponseWriter, r *http.Request) {
+	templates := statement.Templates()
+	resp := make([]statementTemplate, 0, len(templates))
+	for _, t := range templates {
+		resp = append(resp, statementTemplate{Name: t.Name(), Institution: t.Institution()})
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]any{"templates": resp})
+}
+
+// ImportStatement parses an uploaded PDF statement (multipart field "file") for the account
+// in the "account_id" field and stages its transactions for review
+func (h *TransactionHandler) ImportStatement(w http.ResponseWriter, r *http.Request) {
+	// Leave room for the form fields around the file
+	r.Body = http.MaxBytesReader(w, r.Body, maxStatementSize+1<<20)
+	if err := r.ParseMultipartForm(maxStatementSize); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("failed to parse form: %w", err))
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("failed to get file: %w", err))
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, fmt.Errorf("failed to read file: %w", err))
+		return
+	}
+
+	parsed, err := statement.Parse(content)
+	if err != nil {
+		if errors.Is(err, statement.ErrUnrecognized) {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("%w; see /statements/templates for supported statements", err))
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("failed to read statement: %w", err))
+		return
+	}
+
+	imp, err := h.service.ImportStatement(r.Context(), r.FormValue("account_id"), header.Filename, parsed)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, imp)
+}
+
+// PushStatement stages a balance and transactions pushed by an external script for review.
+// Unknown fields are rejected so a script's typo isn't silently dropped. An Idempotency-Key
+// header makes retries safe: a repeated key returns the first import with 200.
+func (h *TransactionHandler) PushStatement(w http.ResponseWriter, r *http.Request) {
+	var req transaction.PushStatementRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxStatementSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	resp, err := h.service.PushStatement(r.Context(), r.Header.Get("Idempotency-Key"), &req)
+	if errors.Is(err, transaction.ErrNotFound) {
+		server.RespondError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	status := http.StatusCreated
+	if resp.Replayed {
+		status = http.StatusOK
+	}
+	server.RespondJSON(w, status, resp)
+}
+
+// ListStatementImports lists the user's statement imports, newest first
+func (h *TransactionHandler) ListStatementImports(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.ListStatementImports(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// GetStatementImport returns a statement import with its lines for review
+func (h *TransactionHandler) GetStatementImport(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("import ID is required"))
+		return
+	}
+
+	imp, err := h.service.GetStatementImport(r.Context(), id)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, imp)
+}
+
+// UpdateStatementImportLine corrects or excludes a line before the import is committed
+func (h *TransactionHandler) UpdateStatementImportLine(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	lineID := chi.URLParam(r, "lineId")
+	if id == "" || lineID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("import ID and line ID are required"))
+		return
+	}
+
+	var req transaction.UpdateStatementLineRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	imp, err := h.service.UpdateStatementImportLine(r.Context(), id, lineID, &req)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, imp)
+}
+
+// CommitStatementImport records the reviewed lines as transactions and the closing balance
+func (h *TransactionHandler) CommitStatementImport(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("import ID is required"))
+		return
+	}
+
+	resp, err := h.service.CommitStatementImport(r.Context(), id)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// DeleteStatementImport discards a statement import
+func (h *TransactionHandler) DeleteStatementImport(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("import ID is required"))
+		return
+	}
+
+	if err := h.service.DeleteStatementImport(r.Context(), id); err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// CreateSweepRule creates a rule sweeping month-end surpluses into another account
+func (h *TransactionHandler) CreateSweepRule(w http.ResponseWriter, r *http.Request) {
+	var req transaction.CreateSweepRuleRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	rule, err := h.service.CreateSweepRule(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, rule)
+}
+
+// ListSweepRules lists the user's sweep rules
+func (h *TransactionHandler) ListSweepRules(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.ListSweepRules(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// UpdateSweepRule updates a sweep rule
+func (h *TransactionHandler) UpdateSweepRule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("sweep rule ID is required"))
+		return
+	}
+
+	var req transaction.UpdateSweepRuleRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	rule, err := h.service.UpdateSweepRule(r.Context(), id, &req)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, rule)
+}
+
+// DeleteSweepRule deletes a sweep rule
+func (h *TransactionHandler) DeleteSweepRule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("sweep rule ID is required"))
+		return
+	}
+
+	if err := h.service.DeleteSweepRule(r.Context(), id); err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// ListSweepRuns lists sweep history, optionally filtered by status
+func (h *TransactionHandler) ListSweepRuns(w http.ResponseWriter, r *http.Request) {
+	status := transaction.SweepRunStatus(r.URL.Query().Get("status"))
+
+	resp, err := h.service.ListSweepRuns(r.Context(), status)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// AcceptSweepRun transfers a suggested surplus now
+func (h *TransactionHandler) AcceptSweepRun(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("sweep run ID is required"))
+		return
+	}
+
+	var req transaction.AcceptSweepRunRequest
+	if r.ContentLength > 0 {
+		if err := server.ParseJSON(r, &req); err != nil {
+			server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+	}
+
+	run, err := h.service.AcceptSweepRun(r.Context(), id, &req)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, run)
+}
+
+// DismissSweepRun leaves a suggested surplus in place
+func (h *TransactionHandler) DismissSweepRun(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("sweep run ID is required"))
+		return
+	}
+
+	run, err := h.service.DismissSweepRun(r.Context(), id)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, run)
+}
+
+// CreateIOUPerson adds a person to share expenses with
+func (h *TransactionHandler) CreateIOUPerson(w http.ResponseWriter, r *http.Request) {
+	var req transaction.IOUPersonRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	person, err := h.service.CreateIOUPerson(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, person)
+}
+
+// ListIOUPeople lists the people the user shares expenses with and their balances
+func (h *TransactionHandler) ListIOUPeople(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.ListIOUPeople(r.Context())
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// GetIOULedger gets a person's ledger with running balances
+func (h *TransactionHandler) GetIOULedger(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("person ID is required"))
+		return
+	}
+
+	ledger, err := h.service.GetIOULedger(r.Context(), id)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, ledger)
+}
+
+// UpdateIOUPerson updates a person's name, currency or notes
+func (h *TransactionHandler) UpdateIOUPerson(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("person ID is required"))
+		return
+	}
+
+	var req transaction.IOUPersonRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	person, err := h.service.UpdateIOUPerson(r.Context(), id, &req)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, person)
+}
+
+// DeleteIOUPerson removes a person and their ledger
+func (h *TransactionHandler) DeleteIOUPerson(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("person ID is required"))
+		return
+	}
+
+	if err := h.service.DeleteIOUPerson(r.Context(), id); err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// RecordIOUEntry records a split or settlement with a person
+func (h *TransactionHandler) RecordIOUEntry(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("person ID is required"))
+		return
+	}
+
+	var req transaction.IOUEntryRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	entry, err := h.service.RecordIOUEntry(r.Context(), id, &req)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, entry)
+}
+
+// DeleteIOUEntry removes an entry from a person's ledger
+func (h *TransactionHandler) DeleteIOUEntry(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	entryID := chi.URLParam(r, "entryId")
+	if id == "" || entryID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("person ID and entry ID are required"))
+		return
+	}
+
+	if err := h.service.DeleteIOUEntry(r.Context(), id, entryID); err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// SplitTransactionWithPerson records a person's share of a transaction
+func (h *TransactionHandler) SplitTransactionWithPerson(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("transaction ID is required"))
+		return
+	}
+
+	var req transaction.SplitWithPersonRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	entry, err := h.service.SplitTransactionWithPerson(r.Context(), id, &req)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, entry)
+}
+
+// CreateProject creates a project
+func (h *TransactionHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
+	var req transaction.ProjectRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	project, err := h.service.CreateProject(r.Context(), &req)
+	if err != nil {
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, project)
+}
+
+// ListProjects lists projects with their progress, optionally filtered by status
+func (h *TransactionHandler) ListProjects(w http.ResponseWriter, r *http.Request) {
+	status := transaction.ProjectStatus(r.URL.Query().Get("status"))
+
+	resp, err := h.service.ListProjects(r.Context(), status)
+	if err != nil {
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, resp)
+}
+
+// GetProject gets a project with its transactions and planned expenses
+func (h *TransactionHandler) GetProject(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("project ID is required"))
+		return
+	}
+
+	project, err := h.service.GetProject(r.Context(), id)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, project)
+}
+
+// UpdateProject updates a project
+func (h *TransactionHandler) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("project ID is required"))
+		return
+	}
+
+	var req transaction.ProjectRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	project, err := h.service.UpdateProject(r.Context(), id, &req)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, project)
+}
+
+// DeleteProject deletes a project, keeping its transactions
+func (h *TransactionHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("project ID is required"))
+		return
+	}
+
+	if err := h.service.DeleteProject(r.Context(), id); err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// TagProjectTransactions counts transactions toward a project
+func (h *TransactionHandler) TagProjectTransactions(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("project ID is required"))
+		return
+	}
+
+	var req transaction.TagProjectTransactionsRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	project, err := h.service.TagProjectTransactions(r.Context(), id, &req)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, project)
+}
+
+// UntagProjectTransaction stops counting a transaction toward a project
+func (h *TransactionHandler) UntagProjectTransaction(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	transactionID := chi.URLParam(r, "transactionId")
+	if id == "" || transactionID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("project ID and transaction ID are required"))
+		return
+	}
+
+	if err := h.service.UntagProjectTransaction(r.Context(), id, transactionID); err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// AddPlannedExpense adds a planned expense to a project
+func (h *TransactionHandler) AddPlannedExpense(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("project ID is required"))
+		return
+	}
+
+	var req transaction.PlannedExpenseRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	planned, err := h.service.AddPlannedExpense(r.Context(), id, &req)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusCreated, planned)
+}
+
+// UpdatePlannedExpense updates a planned expense or marks it paid
+func (h *TransactionHandler) UpdatePlannedExpense(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	plannedID := chi.URLParam(r, "plannedId")
+	if id == "" || plannedID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("project ID and planned expense ID are required"))
+		return
+	}
+
+	var req transaction.PlannedExpenseRequest
+	if err := server.ParseJSON(r, &req); err != nil {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	planned, err := h.service.UpdatePlannedExpense(r.Context(), id, plannedID, &req)
+	if err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, planned)
+}
+
+// DeletePlannedExpense removes a planned expense from a project
+func (h *TransactionHandler) DeletePlannedExpense(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	plannedID := chi.URLParam(r, "plannedId")
+	if id == "" || plannedID == "" {
+		server.RespondError(w, http.StatusBadRequest, fmt.Errorf("project ID and planned expense ID are required"))
+		return
+	}
+
+	if err := h.service.DeletePlannedExpense(r.Context(), id, plannedID); err != nil {
+		if err == transaction.ErrNotFound {
+			server.RespondError(w, http.StatusNotFound, err)
+			return
+		}
+		server.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.RespondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
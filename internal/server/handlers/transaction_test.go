@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"money/internal/auth"
+	"money/internal/transaction"
+)
+
+// TestGetCashFlowVariance_RejectsInvalidMonths tests that unparseable and out of range
+// month counts are refused before any spending is loaded
+func TestGetCashFlowVariance_RejectsInvalidMonths(t *testing.T) {
+	handler := NewTransactionHandler(transaction.NewService(nil))
+
+	for _, months := range []string{"abc", "12abc", "0", "61"} {
+		req := httptest.NewRequest("GET", "/api/transactions/variance?months="+months, nil)
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, "test-variance-user"))
+		w := httptest.NewRecorder()
+
+		handler.GetCashFlowVariance(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for months=%s, got %d", months, w.Code)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// allowUnknownFieldsKey marks requests whose routes opted out of strict decoding
+type allowUnknownFieldsKey struct{}
+
+// UnknownFieldsError rejects a request body naming fields the endpoint doesn't accept,
+// usually a typo such as "strike_prise" that would otherwise be silently dropped
+type UnknownFieldsError struct {
+	Fields []string // Dotted paths of the unknown fields, such as "grants[0].strike_prise"
+}
+
+func (e *UnknownFieldsError) Error() string {
+	return "unknown fields: " + strings.Join(e.Fields, ", ")
+}
+
+// AllowUnknownFields opts a route out of strict decoding, for endpoints that accept
+// documents written by other versions or other tools
+func AllowUnknownFields(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), allowUnknownFieldsKey{}, true)))
+	})
+}
+
+// ParseJSON parses JSON from request body into the given struct. Fields the struct doesn't
+// declare are rejected with an UnknownFieldsError listing all of them, unless the route
+// allows unknown fields.
+func ParseJSON(r *http.Request, v any) error {
+	if allowed, _ := r.Context().Value(allowUnknownFieldsKey{}).(bool); allowed {
+		return json.NewDecoder(r.Body).Decode(v)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(v)
+	if err == nil || !strings.HasPrefix(err.Error(), "json: unknown field ") {
+		return err
+	}
+
+	// The decoder stops at the first unknown field, so walk the body to report them all
+	var doc any
+	if json.Unmarshal(body, &doc) == nil {
+		if fields := unknownFields(doc, reflect.TypeOf(v), ""); len(fields) > 0 {
+			return &UnknownFieldsError{Fields: fields}
+		}
+	}
+	field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+	return &UnknownFieldsError{Fields: []string{field}}
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// unknownFields lists the keys in doc that t has no field for, descending into nested
+// objects and arrays. Types that decode themselves are taken as accepting anything.
+func unknownFields(doc any, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+
+	var unknown []string
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := doc.(map[string]any)
+		if !ok {
+			return nil
+		}
+		fields := jsonFields(t)
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			field, ok := lookupJSONField(fields, key)
+			if !ok {
+				unknown = append(unknown, fieldPath)
+				continue
+			}
+			unknown = append(unknown, unknownFields(object[key], field, fieldPath)...)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := doc.([]any)
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			unknown = append(unknown, unknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case reflect.Map:
+		object, ok := doc.(map[string]any)
+		if !ok {
+			return nil
+		}
+		for key, value := range object {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			unknown = append(unknown, unknownFields(value, t.Elem(), keyPath)...)
+		}
+		sort.Strings(unknown)
+	}
+	return unknown
+}
+
+// jsonFields maps the JSON names a struct decodes to the types they decode into,
+// including the fields of embedded structs
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for n, ft := range jsonFields(embedded) {
+					if _, ok := fields[n]; !ok {
+						fields[n] = ft
+					}
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// lookupJSONField finds a key's field the way encoding/json does, preferring an exact
+// match and falling back to a case-insensitive one
+func lookupJSONField(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if t, ok := fields[key]; ok {
+		return t, true
+	}
+	for name, t := range fields {
+		if strings.EqualFold(name, key) {
+			return t, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testGrant struct {
+	Quantity    int       `json:"quantity"`
+	StrikePrice float64   `json:"strike_price"`
+	GrantDate   time.Time `json:"grant_date"`
+}
+
+type testAudit struct {
+	Notes string `json:"notes"`
+}
+
+type testExerciseRequest struct {
+	testAudit
+	AccountID string               `json:"account_id"`
+	Grants    []testGrant          `json:"grants"`
+	ByLabel   map[string]testGrant `json:"by_label"`
+	Internal  string               `json:"-"`
+}
+
+func TestParseJSON_RejectsEveryUnknownField(t *testing.T) {
+	// Arrange
+	body := `{
+		"account_id": "acc-1",
+		"notes": "from the embedded struct",
+		"Internal": "skipped",
+		"grants": [{"quantity": 10, "strike_prise": 2.5, "grant_date": "2024-01-02T00:00:00Z"}],
+		"by_label": {"first": {"Quantity": 1, "vest": true}}
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+
+	// Act
+	var parsed testExerciseRequest
+	err := ParseJSON(req, &parsed)
+
+	// Assert
+	var unknown *UnknownFieldsError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("Expected an UnknownFieldsError, got %v", err)
+	}
+	want := []string{"Internal", "by_label.first.vest", "grants[0].strike_prise"}
+	if !reflect.DeepEqual(unknown.Fields, want) {
+		t.Errorf("Expected unknown fields %v, got %v", want, unknown.Fields)
+	}
+	if !strings.Contains(err.Error(), "grants[0].strike_prise") {
+		t.Errorf("Expected the error to name the field, got %q", err.Error())
+	}
+}
+
+func TestParseJSON_AcceptsKnownFields(t *testing.T) {
+	// Arrange
+	body := `{"account_id": "acc-1", "NOTES": "case-insensitive", "grants": [{"quantity": 10, "strike_price": 2.5}]}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+
+	// Act
+	var parsed testExerciseRequest
+	err := ParseJSON(req, &parsed)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ParseJSON failed: %v", err)
+	}
+	if parsed.AccountID != "acc-1" || parsed.Notes != "case-insensitive" || parsed.Grants[0].StrikePrice != 2.5 {
+		t.Errorf("Expected the body decoded, got %+v", parsed)
+	}
+}
+
+func TestParseJSON_AllowsUnknownFieldsWhenTheRouteOptsOut(t *testing.T) {
+	// Arrange
+	var parsed testExerciseRequest
+	var parseErr error
+	handler := AllowUnknownFields(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parseErr = ParseJSON(r, &parsed)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"account_id": "acc-1", "legacy": true}`))
+
+	// Act
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Assert
+	if parseErr != nil {
+		t.Fatalf("Expected unknown fields to be ignored, got %v", parseErr)
+	}
+	if parsed.AccountID != "acc-1" {
+		t.Errorf("Expected the known fields decoded, got %+v", parsed)
+	}
+}
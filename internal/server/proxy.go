@@ -0,0 +1,98 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ProxyConfig names the reverse proxies whose forwarding headers are believed. Requests
+// from any other peer keep their socket address, so a client can't claim another address
+// with a forged X-Forwarded-For or X-Real-IP header.
+type ProxyConfig struct {
+	TrustedProxies []*net.IPNet
+}
+
+// ParseTrustedProxies reads a comma-separated list of proxy addresses and CIDR ranges
+func ParseTrustedProxies(spec string) ([]*net.IPNet, error) {
+	proxies := make([]*net.IPNet, 0)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// trusted reports whether an address belongs to a trusted proxy
+func (c ProxyConfig) trusted(ip net.IP) bool {
+	for _, network := range c.TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RealIPMiddleware sets the request's RemoteAddr to the client address a trusted proxy
+// forwarded. X-Forwarded-For is read right to left, skipping trusted proxies, so entries a
+// client prepended are never used. Requests from untrusted peers are left untouched.
+func (c ProxyConfig) RealIPMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip := c.clientIP(r); ip != "" {
+				r.RemoteAddr = ip
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP returns the forwarded client address, or "" to keep the socket peer
+func (c ProxyConfig) clientIP(r *http.Request) string {
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	peer := net.ParseIP(host)
+	if peer == nil || !c.trusted(peer) {
+		return ""
+	}
+
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		hops := strings.Split(forwarded, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := net.ParseIP(strings.TrimSpace(hops[i]))
+			if hop == nil {
+				return ""
+			}
+			if !c.trusted(hop) {
+				return hop.String()
+			}
+		}
+		return ""
+	}
+	if real := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); real != nil {
+		return real.String()
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIPMiddleware_OnlyTrustsConfiguredProxies(t *testing.T) {
+	// Arrange
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.5")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies failed: %v", err)
+	}
+	var seen string
+	handler := ProxyConfig{TrustedProxies: proxies}.RealIPMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.RemoteAddr
+	}))
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"untrusted peer keeps its address", "203.0.113.9:5000", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "203.0.113.9:5000"},
+		{"trusted proxy forwards the client", "10.1.2.3:5000", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "198.51.100.1"},
+		{"prepended entries are skipped", "10.1.2.3:5000", map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.7, 10.4.4.4"}, "203.0.113.7"},
+		{"single trusted address", "192.168.1.5:5000", map[string]string{"X-Real-IP": "198.51.100.2"}, "198.51.100.2"},
+		{"untrusted peer with X-Real-IP", "192.168.1.6:5000", map[string]string{"X-Real-IP": "198.51.100.2"}, "192.168.1.6:5000"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tc.remoteAddr
+		for k, v := range tc.headers {
+			req.Header.Set(k, v)
+		}
+
+		// Act
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		// Assert
+		if seen != tc.want {
+			t.Errorf("%s: RemoteAddr = %q, want %q", tc.name, seen, tc.want)
+		}
+	}
+}
+
+func TestParseTrustedProxies_RejectsInvalidEntries(t *testing.T) {
+	if _, err := ParseTrustedProxies("10.0.0.0/8,not-an-ip"); err == nil {
+		t.Error("Expected an invalid entry to be rejected")
+	}
+	if proxies, err := ParseTrustedProxies(""); err != nil || len(proxies) != 0 {
+		t.Errorf("Expected no proxies for an empty list, got %v (%v)", proxies, err)
+	}
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"money/internal/kvstore"
+)
+
+// RateLimitConfig caps how many requests a client address may make per window. Counters
+// live in the store, so instances sharing a Redis store enforce one limit between them.
+type RateLimitConfig struct {
+	Name   string        // Counter namespace, so separately limited routes don't share counts
+	Limit  int           // Requests allowed per window; zero disables the limit
+	Window time.Duration // Length of each fixed window
+	Store  kvstore.Store
+}
+
+// RateLimitMiddleware answers 429 once a client address has used up its window's requests.
+// A store that can't be reached lets requests through rather than locking everyone out.
+func (c RateLimitConfig) RateLimitMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if c.Limit <= 0 || c.Window <= 0 || c.Store == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			count, err := c.Store.Incr(r.Context(), c.key(r), c.Window)
+			if err != nil {
+				handlersLog.Error("Rate limit check failed", "limit", c.Name, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if count > int64(c.Limit) {
+				w.Header().Set("Retry-After", strconv.Itoa(int(c.Window.Seconds())))
+				RespondError(w, http.StatusTooManyRequests, fmt.Errorf("too many requests, try again later"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// key names the counter for the request's client address in the current window
+func (c RateLimitConfig) key(r *http.Request) string {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	window := time.Now().UnixNano() / int64(c.Window)
+	return fmt.Sprintf("ratelimit:%s:%s:%d", c.Name, ip, window)
+}
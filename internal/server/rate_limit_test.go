@@ -0,0 +1,62 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"money/internal/kvstore"
+)
+
+func TestRateLimitMiddleware_LimitsEachClientAddress(t *testing.T) {
+	// Arrange
+	config := RateLimitConfig{Name: "auth", Limit: 2, Window: time.Minute, Store: kvstore.NewMemory()}
+	handler := config.RateLimitMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/auth/login/begin", nil)
+		req.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Act
+	first := request("10.0.0.1:5000")
+	second := request("10.0.0.1:5001")
+	limited := request("10.0.0.1:5002")
+	other := request("10.0.0.2:5000")
+
+	// Assert
+	if first.Code != http.StatusOK || second.Code != http.StatusOK {
+		t.Errorf("Expected requests within the limit to pass, got %d and %d", first.Code, second.Code)
+	}
+	if limited.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the third request to be limited, got %d", limited.Code)
+	}
+	if limited.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected a Retry-After of the window, got %q", limited.Header().Get("Retry-After"))
+	}
+	if other.Code != http.StatusOK {
+		t.Errorf("Expected another address to have its own limit, got %d", other.Code)
+	}
+}
+
+func TestRateLimitMiddleware_DisabledWithoutALimit(t *testing.T) {
+	// Arrange
+	config := RateLimitConfig{Name: "auth", Window: time.Minute, Store: kvstore.NewMemory()}
+	handler := config.RateLimitMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Act & Assert
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/login/begin", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected request %d to pass with no limit set, got %d", i+1, w.Code)
+		}
+	}
+}
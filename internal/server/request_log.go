@@ -0,0 +1,146 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"money/internal/logger"
+)
+
+// maxLoggedBodyBytes caps how much of a request body is logged at debug level
+const maxLoggedBodyBytes = 2048
+
+// RequestLogConfig controls request logging
+type RequestLogConfig struct {
+	// SampleRates maps path prefixes to the share of successful requests that are
+	// logged, so health checks and polling don't flood the logs. Errors and slow
+	// requests are always logged.
+	SampleRates map[string]float64
+	// SlowThreshold is the duration after which a request is always logged
+	SlowThreshold time.Duration
+}
+
+// ParseSampleRates reads a spec such as "/api/health=0.01,/api/sync=0.25"
+func ParseSampleRates(spec string) (map[string]float64, error) {
+	rates := make(map[string]float64)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		prefix, value, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("invalid log sample rate: %s", entry)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("invalid log sample rate for %s: %s", prefix, value)
+		}
+		rates[strings.TrimSpace(prefix)] = rate
+	}
+	return rates, nil
+}
+
+// sampleRate returns the rate for the longest matching prefix, or 1 when none match
+func (c RequestLogConfig) sampleRate(path string) float64 {
+	prefixes := make([]string, 0, len(c.SampleRates))
+	for prefix := range c.SampleRates {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return c.SampleRates[prefix]
+		}
+	}
+	return 1
+}
+
+// RequestLogger logs each request through the handlers module logger with secrets in the
+// URL redacted. At debug level JSON request bodies are logged too, also redacted.
+func (c RequestLogConfig) RequestLogger() func(http.Handler) http.Handler {
+	log := logger.Module("handlers")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			var body string
+			if log.Enabled(slog.LevelDebug) && r.Body != nil &&
+				strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+				body = peekBody(r)
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			duration := time.Since(start)
+
+			level := slog.LevelInfo
+			switch {
+			case status >= 500:
+				level = slog.LevelError
+			case status >= 400:
+				level = slog.LevelWarn
+			}
+			slow := c.SlowThreshold > 0 && duration >= c.SlowThreshold
+			if level == slog.LevelInfo && !slow {
+				if rate := c.sampleRate(r.URL.Path); rate < 1 && rand.Float64() >= rate {
+					return
+				}
+			}
+
+			attrs := []any{
+				"method", r.Method,
+				"path", logger.RedactURL(r.URL),
+				"status", status,
+				"bytes", ww.BytesWritten(),
+				"duration_ms", duration.Milliseconds(),
+				"request_id", middleware.GetReqID(r.Context()),
+				"remote_ip", r.RemoteAddr,
+			}
+			if slow {
+				attrs = append(attrs, "slow", true)
+			}
+			if body != "" {
+				attrs = append(attrs, "body", body)
+			}
+			log.Slog().Log(r.Context(), level, "HTTP request", attrs...)
+		})
+	}
+}
+
+// peekBody reads the start of the request body for logging and restores it for the handler
+func peekBody(r *http.Request) string {
+	head, err := io.ReadAll(io.LimitReader(r.Body, maxLoggedBodyBytes+1))
+	r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(head), r.Body), Closer: r.Body}
+	if err != nil || len(head) == 0 {
+		return ""
+	}
+	if len(head) > maxLoggedBodyBytes {
+		// Truncated JSON can't be parsed, so fall back to free text redaction
+		return logger.RedactString(string(head[:maxLoggedBodyBytes])) + "...(truncated)"
+	}
+	return logger.RedactJSON(head)
+}
+
+// readCloser pairs a replacement body reader with the original body's Close
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,28 @@
+package server
+
+import "testing"
+
+func TestParseSampleRates(t *testing.T) {
+	rates, err := ParseSampleRates("/api/health=0.01, /api/sync=0.5")
+	if err != nil {
+		t.Fatalf("ParseSampleRates failed: %v", err)
+	}
+
+	config := RequestLogConfig{SampleRates: rates}
+	cases := map[string]float64{
+		"/api/health":           0.01,
+		"/api/sync/connections": 0.5,
+		"/api/accounts":         1,
+	}
+	for path, want := range cases {
+		if got := config.sampleRate(path); got != want {
+			t.Errorf("sampleRate(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	for _, spec := range []string{"api/health=0.5", "/api/health=2", "/api/health"} {
+		if _, err := ParseSampleRates(spec); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
@@ -1,11 +1,21 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
 	"net/http"
+
+	"money/internal/logger"
 )
 
+// handlersLog logs for the handlers module
+var handlersLog = logger.Module("handlers")
+
+// StatusClientClosedRequest is the non-standard status recorded when the client went away
+// before the response was ready
+const StatusClientClosedRequest = 499
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error   string `json:"error"`
@@ -19,15 +29,22 @@ func RespondJSON(w http.ResponseWriter, status int, data any) {
 	w.WriteHeader(status)
 
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Printf("ERROR: Failed to encode JSON response: %v", err)
+		handlersLog.Error("Failed to encode JSON response", "error", err)
 	}
 }
 
-// RespondError sends an error response with the given status code
+// RespondError sends an error response with the given status code. Work abandoned because
+// the client disconnected is reported as StatusClientClosedRequest rather than a failure.
 func RespondError(w http.ResponseWriter, status int, err error) {
+	message := http.StatusText(status)
+	if errors.Is(err, context.Canceled) {
+		status = StatusClientClosedRequest
+		message = "Client Closed Request"
+	}
+
 	// Log all server errors (5xx)
 	if status >= 500 {
-		log.Printf("ERROR [%d]: %v", status, err)
+		handlersLog.Error("Request failed", "status", status, "error", err)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -35,12 +52,12 @@ func RespondError(w http.ResponseWriter, status int, err error) {
 
 	response := ErrorResponse{
 		Error:   err.Error(),
-		Message: http.StatusText(status),
+		Message: message,
 		Code:    status,
 	}
 
 	if encodeErr := json.NewEncoder(w).Encode(response); encodeErr != nil {
-		log.Printf("ERROR: Failed to encode error response: %v", encodeErr)
+		handlersLog.Error("Failed to encode error response", "error", encodeErr)
 	}
 }
 
@@ -48,7 +65,7 @@ func RespondError(w http.ResponseWriter, status int, err error) {
 func RespondErrorMessage(w http.ResponseWriter, status int, message string) {
 	// Log all server errors (5xx)
 	if status >= 500 {
-		log.Printf("ERROR [%d]: %s", status, message)
+		handlersLog.Error("Request failed", "status", status, "error", message)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -61,11 +78,6 @@ func RespondErrorMessage(w http.ResponseWriter, status int, message string) {
 	}
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("ERROR: Failed to encode error response: %v", err)
+		handlersLog.Error("Failed to encode error response", "error", err)
 	}
 }
-
-// ParseJSON parses JSON from request body into the given struct
-func ParseJSON(r *http.Request, v any) error {
-	return json.NewDecoder(r.Body).Decode(v)
-}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRespondError_ReportsClientDisconnectAs499(t *testing.T) {
+	// Arrange
+	cancelled := httptest.NewRecorder()
+	failed := httptest.NewRecorder()
+
+	// Act
+	RespondError(cancelled, http.StatusInternalServerError, fmt.Errorf("import failed: %w", context.Canceled))
+	RespondError(failed, http.StatusInternalServerError, errors.New("disk full"))
+
+	// Assert
+	if cancelled.Code != StatusClientClosedRequest {
+		t.Errorf("Expected %d for a cancelled request, got %d", StatusClientClosedRequest, cancelled.Code)
+	}
+	if failed.Code != http.StatusInternalServerError {
+		t.Errorf("Expected other errors to keep their status, got %d", failed.Code)
+	}
+}
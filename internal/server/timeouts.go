@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// DefaultRouteTimeouts gives exports, imports and projections longer than everyday CRUD
+const DefaultRouteTimeouts = "/data=10m,/projections=2m"
+
+// writeTimeoutMargin leaves the server time to write the timeout response itself
+const writeTimeoutMargin = 5 * time.Second
+
+// TimeoutConfig bounds how long a request may run before it is cancelled with a 503
+type TimeoutConfig struct {
+	// Default applies to routes without their own limit; zero leaves them unbounded
+	Default time.Duration
+	// Routes maps route prefixes within a version root, such as /data, to their own limit
+	Routes map[string]time.Duration
+}
+
+// ParseRouteTimeouts reads a spec such as "/data=10m,/projections=2m"
+func ParseRouteTimeouts(spec string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		prefix, value, ok := strings.Cut(entry, "=")
+		prefix = strings.TrimSpace(prefix)
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("invalid route timeout: %s", entry)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid route timeout for %s: %s", prefix, value)
+		}
+		timeouts[strings.TrimSuffix(prefix, "/")] = timeout
+	}
+	return timeouts, nil
+}
+
+// timeout returns the limit of the longest prefix matching a route, or the default
+func (c TimeoutConfig) timeout(route string) time.Duration {
+	longest := -1
+	timeout := c.Default
+	for prefix, t := range c.Routes {
+		if (route == prefix || strings.HasPrefix(route, prefix+"/")) && len(prefix) > longest {
+			longest = len(prefix)
+			timeout = t
+		}
+	}
+	return timeout
+}
+
+// WriteTimeout is the server write timeout that lets the longest route limit fire first,
+// or zero when some route is unbounded
+func (c TimeoutConfig) WriteTimeout() time.Duration {
+	if c.Default <= 0 {
+		return 0
+	}
+	longest := c.Default
+	for _, t := range c.Routes {
+		if t > longest {
+			longest = t
+		}
+	}
+	return longest + writeTimeoutMargin
+}
+
+// TimeoutMiddleware cancels requests that run past their route's limit and answers 503.
+// It must run on a version's own router so the route is relative to the version root.
+func (c TimeoutConfig) TimeoutMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		handlers := make(map[time.Duration]http.Handler)
+		wrap := func(timeout time.Duration) http.Handler {
+			if timeout <= 0 {
+				return next
+			}
+			if h, ok := handlers[timeout]; ok {
+				return h
+			}
+			h := http.TimeoutHandler(next, timeout, `{"error":"request timed out"}`)
+			handlers[timeout] = h
+			return h
+		}
+		// Build every wrapper up front so requests only read the map
+		wrap(c.Default)
+		for _, t := range c.Routes {
+			wrap(t)
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := r.URL.Path
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePath != "" {
+				route = rctx.RoutePath
+			}
+			wrap(c.timeout(route)).ServeHTTP(w, r)
+		})
+	}
+}
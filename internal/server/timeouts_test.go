@@ -0,0 +1,72 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestParseRouteTimeouts(t *testing.T) {
+	routes, err := ParseRouteTimeouts("/data=10m, /data/import=20m,/projections=2m")
+	if err != nil {
+		t.Fatalf("ParseRouteTimeouts failed: %v", err)
+	}
+
+	config := TimeoutConfig{Default: 30 * time.Second, Routes: routes}
+	cases := map[string]time.Duration{
+		"/data/export":       10 * time.Minute,
+		"/data/import":       20 * time.Minute,
+		"/projections":       2 * time.Minute,
+		"/projections-extra": 30 * time.Second,
+		"/accounts":          30 * time.Second,
+	}
+	for route, want := range cases {
+		if got := config.timeout(route); got != want {
+			t.Errorf("timeout(%q) = %v, want %v", route, got, want)
+		}
+	}
+	if got := config.WriteTimeout(); got != 20*time.Minute+writeTimeoutMargin {
+		t.Errorf("Expected the write timeout to outlast the longest route, got %v", got)
+	}
+
+	for _, spec := range []string{"data=10m", "/data=0s", "/data=soon", "/data"} {
+		if _, err := ParseRouteTimeouts(spec); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestTimeoutMiddleware_CancelsSlowRoutesOnly(t *testing.T) {
+	// Arrange: a version router where /slow waits for its context to be cancelled
+	config := TimeoutConfig{Default: 20 * time.Millisecond, Routes: map[string]time.Duration{"/long": time.Second}}
+	api := chi.NewRouter()
+	api.Use(config.TimeoutMiddleware())
+	wait := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(100 * time.Millisecond):
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+	api.Get("/slow", wait)
+	api.Get("/long/export", wait)
+	r := chi.NewRouter()
+	r.Mount("/api/v1", api)
+
+	// Act
+	slow := httptest.NewRecorder()
+	r.ServeHTTP(slow, httptest.NewRequest(http.MethodGet, "/api/v1/slow", nil))
+	long := httptest.NewRecorder()
+	r.ServeHTTP(long, httptest.NewRequest(http.MethodGet, "/api/v1/long/export", nil))
+
+	// Assert
+	if slow.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected the slow route to time out with 503, got %d", slow.Code)
+	}
+	if long.Code != http.StatusOK {
+		t.Errorf("Expected the long route to finish under its own limit, got %d", long.Code)
+	}
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// TLSConfig configures optional built-in HTTPS for deployments without a reverse proxy.
+// Certificates come either from files on disk or from Let's Encrypt via autocert.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// AutocertDomains are the hostnames certificates are requested for. Setting any
+	// enables Let's Encrypt.
+	AutocertDomains  []string
+	AutocertCacheDir string
+	AutocertEmail    string
+	// RedirectAddr is the plain HTTP listener that redirects to HTTPS and answers ACME
+	// challenges, e.g. :80. Empty disables it.
+	RedirectAddr string
+}
+
+// ParseDomains splits a comma-separated hostname list, dropping blanks
+func ParseDomains(spec string) []string {
+	domains := make([]string, 0)
+	for _, domain := range strings.Split(spec, ",") {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// Enabled reports whether the server should terminate TLS itself
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != "" || len(c.AutocertDomains) > 0
+}
+
+// Autocert reports whether certificates are obtained from Let's Encrypt
+func (c TLSConfig) Autocert() bool {
+	return len(c.AutocertDomains) > 0
+}
+
+// Validate checks the TLS settings at startup
+func (c TLSConfig) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("TLS certificate and key files must be set together")
+	}
+	if c.CertFile != "" && c.Autocert() {
+		return fmt.Errorf("TLS certificate files cannot be combined with autocert domains")
+	}
+	if c.Autocert() && c.AutocertCacheDir == "" {
+		return fmt.Errorf("autocert requires a certificate cache directory")
+	}
+	for _, domain := range c.AutocertDomains {
+		if strings.ContainsAny(domain, "/:*") {
+			return fmt.Errorf("invalid autocert domain %q: expected a bare hostname", domain)
+		}
+	}
+	return nil
+}
+
+// Configure prepares srv to serve HTTPS and returns the HTTP redirect server, or nil when
+// no redirect listener is configured
+func (c TLSConfig) Configure(srv *http.Server) *http.Server {
+	redirect := RedirectToHTTPS(srv.Addr)
+
+	if c.Autocert() {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(c.AutocertCacheDir),
+			HostPolicy: autocert.HostWhitelist(c.AutocertDomains...),
+			Email:      c.AutocertEmail,
+		}
+		srv.TLSConfig = manager.TLSConfig()
+		// HTTP-01 challenges must be answered on the plain HTTP listener
+		redirect = manager.HTTPHandler(redirect)
+	} else {
+		srv.TLSConfig = &tls.Config{}
+	}
+	srv.TLSConfig.MinVersion = tls.VersionTLS12
+
+	if c.RedirectAddr == "" {
+		return nil
+	}
+	return &http.Server{
+		Addr:              c.RedirectAddr,
+		Handler:           redirect,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       30 * time.Second,
+	}
+}
+
+// ListenAndServe serves HTTPS on srv, which must have been prepared with Configure
+func (c TLSConfig) ListenAndServe(srv *http.Server) error {
+	// With autocert the certificate comes from srv.TLSConfig.GetCertificate
+	return srv.ListenAndServeTLS(c.CertFile, c.KeyFile)
+}
+
+// RedirectToHTTPS permanently redirects requests to the same host and path over HTTPS on
+// the port of httpsAddr
+func RedirectToHTTPS(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		status := http.StatusMovedPermanently
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			// 308 keeps the method and body
+			status = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTLSConfig_Validate(t *testing.T) {
+	if err := (TLSConfig{}).Validate(); err != nil {
+		t.Errorf("Expected disabled TLS to be valid, got %v", err)
+	}
+	if err := (TLSConfig{CertFile: "cert.pem"}).Validate(); err == nil {
+		t.Error("Expected a certificate without a key to be rejected")
+	}
+	if err := (TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem", AutocertDomains: []string{"example.com"}, AutocertCacheDir: "certs"}).Validate(); err == nil {
+		t.Error("Expected certificate files combined with autocert to be rejected")
+	}
+	if err := (TLSConfig{AutocertDomains: ParseDomains("https://example.com")}).Validate(); err == nil {
+		t.Error("Expected an autocert domain with a scheme to be rejected")
+	}
+	if err := (TLSConfig{AutocertDomains: ParseDomains("Money.Example.com, "), AutocertCacheDir: "certs"}).Validate(); err != nil {
+		t.Errorf("Expected autocert for a hostname to be valid, got %v", err)
+	}
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	cases := []struct {
+		httpsAddr string
+		method    string
+		target    string
+		location  string
+		status    int
+	}{
+		{":443", http.MethodGet, "http://money.example.com/accounts?x=1", "https://money.example.com/accounts?x=1", http.StatusMovedPermanently},
+		{":8443", http.MethodGet, "http://money.example.com:8080/", "https://money.example.com:8443/", http.StatusMovedPermanently},
+		{":443", http.MethodPost, "http://money.example.com/api/accounts", "https://money.example.com/api/accounts", http.StatusPermanentRedirect},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		RedirectToHTTPS(c.httpsAddr).ServeHTTP(rec, httptest.NewRequest(c.method, c.target, nil))
+
+		if rec.Code != c.status {
+			t.Errorf("%s %s: expected status %d, got %d", c.method, c.target, c.status, rec.Code)
+		}
+		if got := rec.Header().Get("Location"); got != c.location {
+			t.Errorf("%s %s: expected Location %q, got %q", c.method, c.target, c.location, got)
+		}
+	}
+}
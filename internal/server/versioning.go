@@ -0,0 +1,121 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// APIVersion names a version of the HTTP API, served under /api/<version>
+type APIVersion string
+
+const (
+	APIVersionV1 APIVersion = "v1"
+	APIVersionV2 APIVersion = "v2"
+)
+
+// APIVersions lists every served version, oldest first
+var APIVersions = []APIVersion{APIVersionV1, APIVersionV2}
+
+// DefaultAPIVersion serves the unversioned /api paths, so clients written before versioning
+// keep the contract they were built against
+const DefaultAPIVersion = APIVersionV1
+
+// APIVersionHeader names the version that served a response. Clients calling the unversioned
+// /api paths may also send it to choose a version.
+const APIVersionHeader = "API-Version"
+
+// ParseAPIVersion reads a version name such as "v2", also accepting a bare "2"
+func ParseAPIVersion(s string) (APIVersion, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if !strings.HasPrefix(s, "v") {
+		s = "v" + s
+	}
+	for _, v := range APIVersions {
+		if string(v) == s {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported API version %q", s)
+}
+
+// Root is the path the version is mounted at
+func (v APIVersion) Root() string {
+	return "/api/" + string(v)
+}
+
+// Deprecation schedules the retirement of a version, or of one module's routes within it
+type Deprecation struct {
+	Version   APIVersion
+	Prefix    string     // Route within the version such as /projections; empty for the whole version
+	Since     time.Time  // When the routes were deprecated
+	Sunset    time.Time  // When they stop being served; zero until scheduled
+	Successor APIVersion // Version clients should move to, if any
+}
+
+// matches reports whether a route, relative to the version root, falls under the deprecation
+func (d Deprecation) matches(version APIVersion, route string) bool {
+	if d.Version != version {
+		return false
+	}
+	return d.Prefix == "" || route == d.Prefix || strings.HasPrefix(route, d.Prefix+"/")
+}
+
+// VersionMiddleware stamps responses with the version that served them. Routes under one of
+// the deprecations also get the Deprecation (RFC 9745) and Sunset (RFC 8594) headers and a
+// Link to the same route in the successor version. It must run on the version's own router
+// so the route is relative to the version root.
+func VersionMiddleware(version APIVersion, deprecations []Deprecation) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(APIVersionHeader, string(version))
+
+			route := r.URL.Path
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePath != "" {
+				route = rctx.RoutePath
+			}
+			for _, d := range deprecations {
+				if !d.matches(version, route) {
+					continue
+				}
+				w.Header().Set("Deprecation", fmt.Sprintf("@%d", d.Since.Unix()))
+				if !d.Sunset.IsZero() {
+					w.Header().Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+				}
+				if d.Successor != "" {
+					w.Header().Add("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, d.Successor.Root(), route))
+				}
+				break
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UnversionedHandler serves the unversioned /api paths from the version named by the
+// API-Version request header, or DefaultAPIVersion without one. It keeps clients that
+// predate versioning working while they move to the versioned paths.
+func UnversionedHandler(versions map[APIVersion]http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version := DefaultAPIVersion
+		if requested := r.Header.Get(APIVersionHeader); requested != "" {
+			v, err := ParseAPIVersion(requested)
+			if err != nil {
+				RespondError(w, http.StatusBadRequest, err)
+				return
+			}
+			version = v
+		}
+
+		handler, ok := versions[version]
+		if !ok {
+			RespondError(w, http.StatusBadRequest, fmt.Errorf("unsupported API version %q", version))
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
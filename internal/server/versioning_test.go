@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestVersionMiddleware_MarksDeprecatedModules(t *testing.T) {
+	// Arrange
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	deprecations := []Deprecation{{Version: APIVersionV1, Prefix: "/projections", Since: since, Sunset: sunset, Successor: APIVersionV2}}
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	v1 := chi.NewRouter()
+	v1.Use(VersionMiddleware(APIVersionV1, deprecations))
+	v1.Get("/projections/scenarios", ok)
+	v1.Get("/projectionsx", ok)
+	v1.Get("/accounts", ok)
+	r := chi.NewRouter()
+	r.Mount(APIVersionV1.Root(), v1)
+
+	get := func(path string) http.Header {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Header()
+	}
+
+	// Act
+	deprecated := get("/api/v1/projections/scenarios")
+	current := get("/api/v1/accounts")
+	similar := get("/api/v1/projectionsx")
+
+	// Assert
+	if deprecated.Get(APIVersionHeader) != "v1" || current.Get(APIVersionHeader) != "v1" {
+		t.Errorf("Expected responses to name v1, got %q and %q", deprecated.Get(APIVersionHeader), current.Get(APIVersionHeader))
+	}
+	if got := deprecated.Get("Deprecation"); got != "@1767225600" {
+		t.Errorf("Expected Deprecation @1767225600, got %q", got)
+	}
+	if got := deprecated.Get("Sunset"); got != "Wed, 01 Jul 2026 00:00:00 GMT" {
+		t.Errorf("Expected Sunset on 2026-07-01, got %q", got)
+	}
+	if got := deprecated.Get("Link"); got != `</api/v2/projections/scenarios>; rel="successor-version"` {
+		t.Errorf("Expected a successor link to v2, got %q", got)
+	}
+	if current.Get("Deprecation") != "" || similar.Get("Deprecation") != "" {
+		t.Error("Expected routes outside the deprecated module to have no Deprecation header")
+	}
+}
+
+func TestUnversionedHandler_ChoosesVersionFromHeader(t *testing.T) {
+	// Arrange
+	versions := make(map[APIVersion]http.Handler)
+	for _, v := range APIVersions {
+		versions[v] = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(APIVersionHeader, string(v))
+		})
+	}
+	handler := UnversionedHandler(versions)
+
+	serve := func(header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/accounts", nil)
+		if header != "" {
+			req.Header.Set(APIVersionHeader, header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act
+	defaulted := serve("")
+	chosen := serve("2")
+	unknown := serve("v9")
+
+	// Assert
+	if got := defaulted.Header().Get(APIVersionHeader); got != string(DefaultAPIVersion) {
+		t.Errorf("Expected the default version without a header, got %q", got)
+	}
+	if got := chosen.Header().Get(APIVersionHeader); got != "v2" {
+		t.Errorf("Expected v2 when requested, got %q", got)
+	}
+	if unknown.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unsupported version, got %d", unknown.Code)
+	}
+}
@@ -0,0 +1,288 @@
+// Package settings stores instance settings that administrators can change without a restart.
+package settings
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/background"
+	"money/internal/flags"
+	"money/internal/logger"
+	"money/internal/sync"
+)
+
+// Known setting keys
+const (
+	KeyLogLevel             = "log_level"
+	KeyLogModuleLevels      = "log_module_levels"
+	KeySyncDefaultFrequency = "sync_default_frequency"
+	KeyFeatureFlags         = "feature_flags"
+)
+
+// Definition describes a runtime setting
+type Definition struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+	EnvVar      string `json:"env_var,omitempty"` // Environment variable the stored value overrides
+	validate    func(value string) error
+}
+
+// Definitions lists every runtime setting
+var Definitions = []Definition{
+	{Key: KeyLogLevel, Description: "Global log level (debug, info, warn or error)", EnvVar: "LOG_LEVEL", validate: validateLogLevel},
+	{Key: KeyLogModuleLevels, Description: "Per-module log levels, e.g. sync=debug,http=warn", EnvVar: "LOG_MODULE_LEVELS", validate: validateModuleLevels},
+	{Key: KeySyncDefaultFrequency, Description: "Sync frequency for new connections (daily, hourly or manual)", validate: validateSyncFrequency},
+	{Key: KeyFeatureFlags, Description: "Instance feature flags, e.g. monte_carlo=on,graphql=25", EnvVar: "FEATURE_FLAGS", validate: flags.ValidateInstanceFlags},
+}
+
+// Setting is a runtime setting with its stored value, if any
+type Setting struct {
+	Definition
+	Value     *string    `json:"value"` // Nil until an administrator sets it
+	UpdatedBy *string    `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// ListSettingsResponse represents the response for listing settings
+type ListSettingsResponse struct {
+	Settings []*Setting `json:"settings"`
+}
+
+// UpdateSettingRequest changes a setting's value
+type UpdateSettingRequest struct {
+	Value string `json:"value"`
+}
+
+// Watcher applies a setting's new value in the module that uses it
+type Watcher func(ctx context.Context, value string) error
+
+// Service provides runtime settings functionality
+type Service struct {
+	db       *sql.DB
+	admins   map[string]bool
+	mu       gosync.Mutex
+	watchers map[string][]Watcher
+	applied  map[string]string // Last value handed to the watchers of each key
+}
+
+// NewService creates a new runtime settings service. Only the given users may change settings.
+func NewService(db *sql.DB, adminUserIDs []string) *Service {
+	admins := make(map[string]bool)
+	for _, id := range adminUserIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			admins[id] = true
+		}
+	}
+	return &Service{
+		db:       db,
+		admins:   admins,
+		watchers: make(map[string][]Watcher),
+		applied:  make(map[string]string),
+	}
+}
+
+// definition looks up a setting definition by key
+func definition(key string) (Definition, bool) {
+	for _, d := range Definitions {
+		if d.Key == key {
+			return d, true
+		}
+	}
+	return Definition{}, false
+}
+
+// Watch registers fn to be called whenever key changes, including when a stored value is loaded
+func (s *Service) Watch(key string, fn Watcher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watchers[key] = append(s.watchers[key], fn)
+}
+
+// IsAdmin reports whether the current user may change settings. Advisors acting for an
+// administrator and demo mode never count.
+func (s *Service) IsAdmin(ctx context.Context) bool {
+	userID := auth.GetUserID(ctx)
+	return userID != "" && auth.GetDelegate(ctx) == "" && s.admins[userID]
+}
+
+// List returns every setting with its stored value
+func (s *Service) List(ctx context.Context) (*ListSettingsResponse, error) {
+	stored, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := make([]*Setting, 0, len(Definitions))
+	for _, d := range Definitions {
+		setting, ok := stored[d.Key]
+		if !ok {
+			setting = &Setting{}
+		}
+		setting.Definition = d
+		settings = append(settings, setting)
+	}
+	return &ListSettingsResponse{Settings: settings}, nil
+}
+
+// Set validates and stores a setting, then applies it to the modules watching it
+func (s *Service) Set(ctx context.Context, key string, req *UpdateSettingRequest) (*Setting, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	d, ok := definition(key)
+	if !ok {
+		return nil, fmt.Errorf("unknown setting: %s", key)
+	}
+	value := strings.TrimSpace(req.Value)
+	if err := d.validate(value); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO runtime_settings (key, value, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE SET
+			value = excluded.value,
+			updated_by = excluded.updated_by,
+			updated_at = excluded.updated_at
+	`, key, value, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save setting %s: %w", key, err)
+	}
+	logger.Info("Runtime setting changed", "key", key, "value", value, "user_id", userID)
+
+	if err := s.apply(ctx, key, value); err != nil {
+		return nil, err
+	}
+	return &Setting{Definition: d, Value: &value, UpdatedBy: &userID, UpdatedAt: &now}, nil
+}
+
+// Load applies every stored setting whose value differs from the one last applied. It runs
+// at startup and then periodically, so changes made by another instance are picked up too.
+func (s *Service) Load(ctx context.Context) error {
+	stored, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range Definitions {
+		setting, ok := stored[d.Key]
+		if !ok {
+			continue
+		}
+		if err := s.apply(ctx, d.Key, *setting.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartWatching reloads stored settings every interval until jobs drains
+func (s *Service) StartWatching(jobs *background.Group, interval time.Duration) {
+	jobs.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if err := s.Load(ctx); err != nil {
+				logger.Error("Failed to reload runtime settings", "error", err)
+			}
+		}
+	})
+}
+
+// load reads the stored settings by key, skipping keys that are no longer defined
+func (s *Service) load(ctx context.Context) (map[string]*Setting, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT key, value, updated_by, updated_at FROM runtime_settings
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	defer rows.Close()
+
+	stored := make(map[string]*Setting)
+	for rows.Next() {
+		var key, value string
+		var updatedBy sql.NullString
+		var updatedAt time.Time
+		if err := rows.Scan(&key, &value, &updatedBy, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan setting: %w", err)
+		}
+		if _, ok := definition(key); !ok {
+			continue
+		}
+		setting := &Setting{Value: &value, UpdatedAt: &updatedAt}
+		if updatedBy.Valid {
+			setting.UpdatedBy = &updatedBy.String
+		}
+		stored[key] = setting
+	}
+	return stored, rows.Err()
+}
+
+// apply hands a value to the key's watchers unless it was the last value applied
+func (s *Service) apply(ctx context.Context, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.applied[key]; ok && last == value {
+		return nil
+	}
+	for _, fn := range s.watchers[key] {
+		if err := fn(ctx, value); err != nil {
+			return fmt.Errorf("failed to apply setting %s: %w", key, err)
+		}
+	}
+	s.applied[key] = value
+	return nil
+}
+
+// validateLogLevel accepts the levels the logger understands
+func validateLogLevel(value string) error {
+	if !logger.ValidLevel(value) {
+		return fmt.Errorf("invalid log level: %s", value)
+	}
+	return nil
+}
+
+// validateModuleLevels accepts a comma separated list of module=level pairs
+func validateModuleLevels(value string) error {
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		module, level, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(module) == "" {
+			return fmt.Errorf("invalid module log level: %s", entry)
+		}
+		if !logger.ValidLevel(level) {
+			return fmt.Errorf("invalid log level for module %s: %s", strings.TrimSpace(module), level)
+		}
+	}
+	return nil
+}
+
+// validateSyncFrequency accepts the frequencies a connection can be created with
+func validateSyncFrequency(value string) error {
+	switch sync.SyncFrequency(value) {
+	case sync.SyncFrequencyDaily, sync.SyncFrequencyHourly, sync.SyncFrequencyManual:
+		return nil
+	}
+	return fmt.Errorf("invalid sync frequency: %s", value)
+}
@@ -0,0 +1,234 @@
+package settings
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "modernc.org/sqlite"
+
+	"money/internal/auth"
+)
+
+var (
+	sharedDB     *sql.DB
+	sharedDBOnce sync.Once
+	sharedDBErr  error
+)
+
+func getSharedDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	sharedDBOnce.Do(func() {
+		tempDir, err := os.MkdirTemp("", "moneyy-settings-test-*")
+		if err != nil {
+			sharedDBErr = fmt.Errorf("failed to create temp dir: %w", err)
+			return
+		}
+
+		dbPath := filepath.Join(tempDir, "test.db")
+		dsn := fmt.Sprintf("%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(ON)", dbPath)
+
+		db, err := sql.Open("sqlite", dsn)
+		if err != nil {
+			sharedDBErr = fmt.Errorf("failed to open database: %w", err)
+			return
+		}
+
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+
+		if err := db.Ping(); err != nil {
+			sharedDBErr = fmt.Errorf("failed to ping database: %w", err)
+			return
+		}
+
+		if err := runMigrations(db); err != nil {
+			db.Close()
+			sharedDBErr = fmt.Errorf("failed to run migrations: %w", err)
+			return
+		}
+
+		sharedDB = db
+	})
+
+	if sharedDBErr != nil {
+		t.Fatalf("Failed to setup shared database: %v", sharedDBErr)
+	}
+
+	return sharedDB
+}
+
+func runMigrations(db *sql.DB) error {
+	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+	if err != nil {
+		return err
+	}
+
+	migrationsPath, err := findMigrationsDir()
+	if err != nil {
+		return err
+	}
+
+	m, err := migrate.NewWithDatabaseInstance(
+		fmt.Sprintf("file://%s", migrationsPath),
+		"sqlite3", driver)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return err
+	}
+
+	return nil
+}
+
+func findMigrationsDir() (string, error) {
+	currentDir, err := filepath.Abs(".")
+	if err != nil {
+		return "", err
+	}
+
+	for i := 0; i < 10; i++ {
+		migrationsPath := filepath.Join(currentDir, "migrations")
+		files, err := filepath.Glob(filepath.Join(migrationsPath, "*.sql"))
+		if err == nil && len(files) > 0 {
+			return migrationsPath, nil
+		}
+
+		if _, err := os.Stat(filepath.Join(currentDir, "go.mod")); err == nil {
+			migrationsPath := filepath.Join(currentDir, "migrations")
+			files, _ := filepath.Glob(filepath.Join(migrationsPath, "*.sql"))
+			if len(files) > 0 {
+				return migrationsPath, nil
+			}
+		}
+
+		currentDir = filepath.Join(currentDir, "..")
+	}
+	return "", fmt.Errorf("migrations directory not found")
+}
+
+func setupTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	return getSharedDB(t)
+}
+
+func cleanupTestDB(t *testing.T, db *sql.DB) {
+	t.Helper()
+	_, _ = db.Exec("DELETE FROM runtime_settings")
+}
+
+func TestSet_ValidatesStoresAndNotifiesWatchers(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	service := NewService(db, []string{"test-admin"})
+	var got []string
+	service.Watch(KeySyncDefaultFrequency, func(_ context.Context, value string) error {
+		got = append(got, value)
+		return nil
+	})
+	ctx := auth.WithUserID(context.Background(), "test-admin")
+
+	// Act
+	_, invalidErr := service.Set(ctx, KeySyncDefaultFrequency, &UpdateSettingRequest{Value: "weekly"})
+	_, unknownErr := service.Set(ctx, "no_such_setting", &UpdateSettingRequest{Value: "x"})
+	setting, err := service.Set(ctx, KeySyncDefaultFrequency, &UpdateSettingRequest{Value: "hourly"})
+
+	// Assert
+	if invalidErr == nil {
+		t.Error("Expected an invalid frequency to be rejected")
+	}
+	if unknownErr == nil {
+		t.Error("Expected an unknown setting to be rejected")
+	}
+	if err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if *setting.Value != "hourly" || *setting.UpdatedBy != "test-admin" {
+		t.Errorf("Unexpected setting: value=%s updated_by=%s", *setting.Value, *setting.UpdatedBy)
+	}
+	if len(got) != 1 || got[0] != "hourly" {
+		t.Errorf("Expected the watcher to see only the valid value, got %v", got)
+	}
+
+	list, err := service.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	for _, s := range list.Settings {
+		if s.Key == KeySyncDefaultFrequency && (s.Value == nil || *s.Value != "hourly") {
+			t.Errorf("Expected the stored value in the list, got %v", s.Value)
+		}
+		if s.Key == KeyLogLevel && s.Value != nil {
+			t.Errorf("Expected no value for an unset setting, got %s", *s.Value)
+		}
+	}
+}
+
+func TestLoad_AppliesChangesFromOtherInstancesOnce(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	ctx := auth.WithUserID(context.Background(), "test-admin")
+	other := NewService(db, []string{"test-admin"})
+	service := NewService(db, nil)
+	calls := 0
+	service.Watch(KeyLogLevel, func(_ context.Context, value string) error {
+		calls++
+		return nil
+	})
+
+	// Act
+	if _, err := other.Set(ctx, KeyLogLevel, &UpdateSettingRequest{Value: "debug"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := service.Load(ctx); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if err := service.Load(ctx); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	// Assert
+	if calls != 1 {
+		t.Errorf("Expected the watcher to run once for an unchanged value, ran %d times", calls)
+	}
+}
+
+func TestIsAdmin_ExcludesDelegatesAndOtherUsers(t *testing.T) {
+	// Arrange
+	service := NewService(nil, []string{" test-admin ", ""})
+	admin := auth.WithUserID(context.Background(), "test-admin")
+
+	// Act
+	isAdmin := service.IsAdmin(admin)
+	otherIsAdmin := service.IsAdmin(auth.WithUserID(context.Background(), "test-other"))
+	delegateIsAdmin := service.IsAdmin(auth.WithDelegate(admin, "test-advisor"))
+	anonymousIsAdmin := service.IsAdmin(context.Background())
+
+	// Assert
+	if !isAdmin {
+		t.Error("Expected the configured user to be an admin")
+	}
+	if otherIsAdmin {
+		t.Error("Expected other users not to be admins")
+	}
+	if delegateIsAdmin {
+		t.Error("Expected an advisor acting for the admin not to be an admin")
+	}
+	if anonymousIsAdmin {
+		t.Error("Expected an anonymous request not to be an admin")
+	}
+}
@@ -0,0 +1,358 @@
+// Package share issues expiring, token-protected links that let someone without an
+// account read one of a user's reports, such as an executor reading the estate summary.
+package share
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"money/internal/auth"
+
+	"github.com/google/uuid"
+)
+
+// Report identifies what a share link grants access to
+type Report string
+
+const (
+	ReportEstate   Report = "estate"
+	ReportNetWorth Report = "net_worth"
+)
+
+// validReports lists the reports a link may share
+var validReports = map[Report]bool{
+	ReportEstate:   true,
+	ReportNetWorth: true,
+}
+
+// Link lifetimes in days
+const (
+	DefaultLinkDays = 7
+	MaxLinkDays     = 90
+)
+
+// Link is a share link. The token itself is only returned when the link is created.
+type Link struct {
+	ID             string     `json:"id"`
+	UserID         string     `json:"-"`
+	Report         Report     `json:"report"`
+	Label          *string    `json:"label,omitempty"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	AccessCount    int        `json:"access_count"` // Successful views
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Access is one attempt to open a share link
+type Access struct {
+	ID         string    `json:"id"`
+	AccessedAt time.Time `json:"accessed_at"`
+	IPAddress  *string   `json:"ip_address,omitempty"`
+	UserAgent  *string   `json:"user_agent,omitempty"`
+	Granted    bool      `json:"granted"` // False when the link had expired or been revoked
+}
+
+// ListAccessesResponse lists a share link's access log, newest first
+type ListAccessesResponse struct {
+	Accesses []*Access `json:"accesses"`
+}
+
+// LinkSecretResponse returns a new link with its token
+type LinkSecretResponse struct {
+	Link
+	Token string `json:"token"`
+}
+
+// CreateLinkRequest represents the request to share a report
+type CreateLinkRequest struct {
+	Report        Report  `json:"report"`
+	Label         *string `json:"label,omitempty"` // Who the link is for, e.g. "Executor"
+	ExpiresInDays int     `json:"expires_in_days,omitempty"`
+}
+
+// ListLinksResponse lists a user's share links
+type ListLinksResponse struct {
+	Links []*Link `json:"links"`
+}
+
+// Service provides share link functionality
+type Service struct {
+	db *sql.DB
+}
+
+// NewService creates a new share link service
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// generateToken creates a new random link token
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken returns the stored hash of a link token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateLink creates a share link for a report and returns its token
+func (s *Service) CreateLink(ctx context.Context, req *CreateLinkRequest) (*LinkSecretResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	if !validReports[req.Report] {
+		return nil, fmt.Errorf("unknown report: %s", req.Report)
+	}
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = DefaultLinkDays
+	}
+	if days < 1 || days > MaxLinkDays {
+		return nil, fmt.Errorf("expires_in_days must be between 1 and %d", MaxLinkDays)
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	link := Link{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Report:    req.Report,
+		Label:     req.Label,
+		ExpiresAt: now.AddDate(0, 0, days),
+		CreatedAt: now,
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO report_shares (id, user_id, report, label, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, link.ID, userID, link.Report, link.Label, hashToken(token), link.ExpiresAt, link.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create share link: %w", err)
+	}
+
+	return &LinkSecretResponse{Link: link, Token: token}, nil
+}
+
+// ListLinks returns the user's share links, newest first
+func (s *Service) ListLinks(ctx context.Context) (*ListLinksResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, linkSelect+`
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list share links: %w", err)
+	}
+	defer rows.Close()
+
+	links := make([]*Link, 0)
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read share links: %w", err)
+	}
+
+	return &ListLinksResponse{Links: links}, nil
+}
+
+// RevokeLink stops a share link from working before it expires
+func (s *Service) RevokeLink(ctx context.Context, id string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE report_shares SET revoked_at = $1
+		WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL
+	`, time.Now(), id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke share link: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("share link not found")
+	}
+
+	return nil
+}
+
+// AccessInfo identifies who opened a share link, for the access log
+type AccessInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+// Resolve returns the link a token belongs to and logs the attempt against it. Unknown,
+// expired and revoked tokens all return the same error so a caller can't tell them apart.
+func (s *Service) Resolve(ctx context.Context, token string, info AccessInfo) (*Link, error) {
+	row := s.db.QueryRowContext(ctx, linkSelect+`
+		WHERE token_hash = $1
+	`, hashToken(token))
+	link, err := scanLink(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("share link is invalid or has expired")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	granted := link.RevokedAt == nil && now.Before(link.ExpiresAt)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO report_share_accesses (id, share_id, accessed_at, ip_address, user_agent, granted)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, uuid.New().String(), link.ID, now, nullIfEmpty(info.IPAddress), nullIfEmpty(info.UserAgent), granted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to log share link access: %w", err)
+	}
+	if granted {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE report_shares SET access_count = access_count + 1, last_accessed_at = $1 WHERE id = $2
+		`, now, link.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update share link: %w", err)
+		}
+		link.AccessCount++
+		link.LastAccessedAt = &now
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit share link access: %w", err)
+	}
+	if !granted {
+		return nil, fmt.Errorf("share link is invalid or has expired")
+	}
+
+	return link, nil
+}
+
+// ListAccesses returns a share link's access log, newest first
+func (s *Service) ListAccesses(ctx context.Context, id string) (*ListAccessesResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM report_shares WHERE id = $1 AND user_id = $2)
+	`, id, userID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("share link not found")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, accessed_at, ip_address, user_agent, granted
+		FROM report_share_accesses
+		WHERE share_id = $1
+		ORDER BY accessed_at DESC
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list share link accesses: %w", err)
+	}
+	defer rows.Close()
+
+	accesses := make([]*Access, 0)
+	for rows.Next() {
+		access := &Access{}
+		var ipAddress, userAgent sql.NullString
+		if err := rows.Scan(&access.ID, &access.AccessedAt, &ipAddress, &userAgent, &access.Granted); err != nil {
+			return nil, fmt.Errorf("failed to scan share link access: %w", err)
+		}
+		if ipAddress.Valid {
+			access.IPAddress = &ipAddress.String
+		}
+		if userAgent.Valid {
+			access.UserAgent = &userAgent.String
+		}
+		accesses = append(accesses, access)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read share link accesses: %w", err)
+	}
+
+	return &ListAccessesResponse{Accesses: accesses}, nil
+}
+
+// nullIfEmpty stores empty strings as NULL
+func nullIfEmpty(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// linkSelect selects share links in the column order scanLink reads
+const linkSelect = `
+	SELECT id, user_id, report, label, expires_at, revoked_at, access_count, last_accessed_at, created_at
+	FROM report_shares
+`
+
+// scanner is satisfied by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanLink reads a share link row selected with linkSelect
+func scanLink(row scanner) (*Link, error) {
+	link := &Link{}
+	var label sql.NullString
+	var revokedAt, lastAccessedAt sql.NullTime
+	err := row.Scan(&link.ID, &link.UserID, &link.Report, &label, &link.ExpiresAt, &revokedAt,
+		&link.AccessCount, &lastAccessedAt, &link.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan share link: %w", err)
+	}
+	if label.Valid {
+		link.Label = &label.String
+	}
+	if revokedAt.Valid {
+		link.RevokedAt = &revokedAt.Time
+	}
+	if lastAccessedAt.Valid {
+		link.LastAccessedAt = &lastAccessedAt.Time
+	}
+	return link, nil
+}
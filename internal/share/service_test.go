@@ -0,0 +1,124 @@
+package share
+
+import (
+	"testing"
+
+	"money/internal/account"
+)
+
+func setupService(t *testing.T) (*Service, func()) {
+	t.Helper()
+	db := account.SetupTestDB(t)
+	return NewService(db), func() { account.CleanupTestDB(t, db) }
+}
+
+func TestResolve_ValidUntilRevoked(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	userID := "test-user-share-1"
+	account.CreateTestUser(t, service.db, userID)
+	ctx := account.CreateAuthContext(userID)
+	created, err := service.CreateLink(ctx, &CreateLinkRequest{Report: ReportEstate})
+	if err != nil {
+		t.Fatalf("CreateLink failed: %v", err)
+	}
+
+	// Act
+	link, err := service.Resolve(ctx, created.Token, AccessInfo{})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if link.UserID != userID || link.Report != ReportEstate {
+		t.Errorf("Expected the link to resolve to the user's estate report, got %+v", link)
+	}
+	if _, err := service.Resolve(ctx, created.Token+"0", AccessInfo{}); err == nil {
+		t.Error("Expected error for an unknown token")
+	}
+	if err := service.RevokeLink(ctx, created.ID); err != nil {
+		t.Fatalf("RevokeLink failed: %v", err)
+	}
+	if _, err := service.Resolve(ctx, created.Token, AccessInfo{}); err == nil {
+		t.Error("Expected error for a revoked link")
+	}
+}
+
+func TestCreateLink_RejectsExpiryBeyondMaximum(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	userID := "test-user-share-2"
+	account.CreateTestUser(t, service.db, userID)
+	ctx := account.CreateAuthContext(userID)
+
+	// Act
+	_, err := service.CreateLink(ctx, &CreateLinkRequest{Report: ReportEstate, ExpiresInDays: MaxLinkDays + 1})
+
+	// Assert
+	if err == nil {
+		t.Error("Expected error for a link outliving the maximum lifetime")
+	}
+}
+
+func TestResolve_LogsGrantedAndDeniedAccess(t *testing.T) {
+	service, cleanup := setupService(t)
+	defer cleanup()
+
+	// Arrange
+	userID := "test-user-share-3"
+	account.CreateTestUser(t, service.db, userID)
+	ctx := account.CreateAuthContext(userID)
+	label := "Mortgage broker"
+	created, err := service.CreateLink(ctx, &CreateLinkRequest{Report: ReportNetWorth, Label: &label})
+	if err != nil {
+		t.Fatalf("CreateLink failed: %v", err)
+	}
+	info := AccessInfo{IPAddress: "203.0.113.7", UserAgent: "test-agent"}
+
+	// Act
+	if _, err := service.Resolve(ctx, created.Token, info); err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if err := service.RevokeLink(ctx, created.ID); err != nil {
+		t.Fatalf("RevokeLink failed: %v", err)
+	}
+	_, revokedErr := service.Resolve(ctx, created.Token, info)
+
+	// Assert
+	if revokedErr == nil {
+		t.Error("Expected error for a revoked link")
+	}
+	log, err := service.ListAccesses(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("ListAccesses failed: %v", err)
+	}
+	if len(log.Accesses) != 2 {
+		t.Fatalf("Expected 2 logged accesses, got %d", len(log.Accesses))
+	}
+	granted := 0
+	for _, access := range log.Accesses {
+		if access.Granted {
+			granted++
+		}
+		if access.IPAddress == nil || *access.IPAddress != info.IPAddress {
+			t.Errorf("Expected IP %s to be logged, got %v", info.IPAddress, access.IPAddress)
+		}
+	}
+	if granted != 1 {
+		t.Errorf("Expected 1 granted access, got %d", granted)
+	}
+	links, err := service.ListLinks(ctx)
+	if err != nil {
+		t.Fatalf("ListLinks failed: %v", err)
+	}
+	if len(links.Links) != 1 || links.Links[0].AccessCount != 1 || links.Links[0].LastAccessedAt == nil {
+		t.Errorf("Expected the link to show one view, got %+v", links.Links)
+	}
+	if _, err := service.ListAccesses(account.CreateAuthContext("test-user-share-4"), created.ID); err == nil {
+		t.Error("Expected error listing another user's access log")
+	}
+}
@@ -0,0 +1,81 @@
+// Package statement reads transactions and balances out of the PDF statements banks
+// without an API issue, using a template per institution to make sense of the layout.
+package statement
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"money/internal/pdf"
+)
+
+// ErrUnrecognized is returned when no template recognizes a statement
+var ErrUnrecognized = errors.New("statement layout not recognized")
+
+// Statement is what a template read from a statement
+type Statement struct {
+	Institution    string        `json:"institution"`
+	Template       string        `json:"template"`
+	PeriodStart    *time.Time    `json:"period_start,omitempty"`
+	PeriodEnd      *time.Time    `json:"period_end,omitempty"`
+	OpeningBalance *float64      `json:"opening_balance,omitempty"` // Owed balances on credit cards are negative
+	ClosingBalance *float64      `json:"closing_balance,omitempty"`
+	Transactions   []Transaction `json:"transactions"`
+	Warnings       []string      `json:"warnings,omitempty"` // Anything the reviewer should check before committing
+}
+
+// Transaction is one statement line
+type Transaction struct {
+	Date        time.Time `json:"date"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"` // Negative for money out, positive for money in
+}
+
+// Template reads statements laid out by one institution
+type Template interface {
+	Name() string                             // Unique identifier, e.g. "rbc_chequing"
+	Institution() string                      // Display name of the institution
+	Matches(lines []string) bool              // Whether the statement text looks like this template's
+	Parse(lines []string) (*Statement, error) // Reads the statement from its text lines
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []Template
+)
+
+// Register adds a template. Templates registered later are tried first, so a specific
+// layout can take precedence over a built-in one for the same institution.
+func Register(t Template) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append([]Template{t}, registry...)
+}
+
+// Templates returns the registered templates in the order they are tried
+func Templates() []Template {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]Template(nil), registry...)
+}
+
+// Parse extracts the text of a PDF statement and reads it with the first template that
+// recognizes it
+func Parse(data []byte) (*Statement, error) {
+	lines, err := pdf.ExtractText(data)
+	if err != nil {
+		return nil, err
+	}
+	return ParseText(lines)
+}
+
+// ParseText reads statement text lines with the first template that recognizes them
+func ParseText(lines []string) (*Statement, error) {
+	for _, t := range Templates() {
+		if t.Matches(lines) {
+			return t.Parse(lines)
+		}
+	}
+	return nil, ErrUnrecognized
+}
@@ -0,0 +1,89 @@
+package statement
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseText_ChequingSignsFromRunningBalance(t *testing.T) {
+	// Arrange
+	lines := []string{
+		"RBC Royal Bank",
+		"Your account statement From December 15, 2023 to January 14, 2024",
+		"Opening balance 1,000.00",
+		"Dec 20 Grocery Store 85.50 914.50",
+		"Dec 28 PAYROLL DEPOSIT ACME 2,500.00 3,414.50",
+		"e-Transfer sent J Smith 100.00 3,314.50",
+		"Jan 3 Monthly fee 4.00",
+		"Total deposits 2,500.00",
+		"Closing balance 3,310.50",
+	}
+
+	// Act
+	s, err := ParseText(lines)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ParseText failed: %v", err)
+	}
+	if s.Template != "rbc_chequing" || s.OpeningBalance == nil || *s.OpeningBalance != 1000 || s.ClosingBalance == nil || *s.ClosingBalance != 3310.50 {
+		t.Fatalf("Expected the RBC chequing template with both balances, got %+v", s)
+	}
+	want := []Transaction{
+		{Date: time.Date(2023, 12, 20, 0, 0, 0, 0, time.UTC), Description: "Grocery Store", Amount: -85.50},
+		{Date: time.Date(2023, 12, 28, 0, 0, 0, 0, time.UTC), Description: "PAYROLL DEPOSIT ACME", Amount: 2500},
+		{Date: time.Date(2023, 12, 28, 0, 0, 0, 0, time.UTC), Description: "e-Transfer sent J Smith", Amount: -100},
+		{Date: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), Description: "Monthly fee", Amount: -4},
+	}
+	if len(s.Transactions) != len(want) {
+		t.Fatalf("Expected %d transactions, got %+v", len(want), s.Transactions)
+	}
+	for i, got := range s.Transactions {
+		if !got.Date.Equal(want[i].Date) || got.Description != want[i].Description || got.Amount != want[i].Amount {
+			t.Errorf("Transaction %d: expected %+v, got %+v", i, want[i], got)
+		}
+	}
+	if len(s.Warnings) != 0 {
+		t.Errorf("Expected the lines to add up to the closing balance, got %v", s.Warnings)
+	}
+}
+
+func TestParseText_CreditCardCreditsAndOwedBalance(t *testing.T) {
+	// Arrange
+	lines := []string{
+		"CIBC Dividend Visa",
+		"Statement period Feb 1, 2024 to Feb 29, 2024",
+		"Previous balance $500.00",
+		"Feb 3 Feb 5 PAYMENT THANK YOU 500.00 CR",
+		"Feb 10 Feb 11 COFFEE SHOP 4.75",
+		"New balance $4.75",
+		"Minimum payment $10.00",
+	}
+
+	// Act
+	s, err := ParseText(lines)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ParseText failed: %v", err)
+	}
+	if s.Template != "cibc_credit_card" || *s.OpeningBalance != -500 || *s.ClosingBalance != -4.75 {
+		t.Fatalf("Expected the CIBC card template with owed balances, got %+v", s)
+	}
+	if len(s.Transactions) != 2 || s.Transactions[0].Amount != 500 || s.Transactions[1].Amount != -4.75 {
+		t.Errorf("Expected a 500 payment and a 4.75 charge, got %+v", s.Transactions)
+	}
+	if len(s.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", s.Warnings)
+	}
+}
+
+func TestParseText_Unrecognized(t *testing.T) {
+	// Act
+	_, err := ParseText([]string{"Some Credit Union", "Jan 2 Coffee 3.00"})
+
+	// Assert
+	if err != ErrUnrecognized {
+		t.Errorf("Expected ErrUnrecognized, got %v", err)
+	}
+}
@@ -0,0 +1,381 @@
+package statement
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Built-in templates for the big Canadian banks. Credit card templates are tried before
+// the bank account ones, since card statements also carry the bank's name.
+func init() {
+	banks := []struct {
+		key, institution string
+		names            []string
+	}{
+		{"rbc", "RBC Royal Bank", []string{"royal bank", "rbc"}},
+		{"td", "TD Canada Trust", []string{"td canada trust", "toronto-dominion", "td bank"}},
+		{"scotiabank", "Scotiabank", []string{"scotiabank", "bank of nova scotia"}},
+		{"bmo", "BMO Bank of Montreal", []string{"bank of montreal", "bmo"}},
+		{"cibc", "CIBC", []string{"cibc", "canadian imperial bank"}},
+	}
+	for _, bank := range banks {
+		Register(&ledger{
+			name:        bank.key + "_chequing",
+			institution: bank.institution,
+			markers:     [][]string{bank.names},
+		})
+	}
+	for _, bank := range banks {
+		Register(&ledger{
+			name:        bank.key + "_credit_card",
+			institution: bank.institution,
+			markers:     [][]string{bank.names, {"minimum payment"}},
+			creditCard:  true,
+		})
+	}
+}
+
+// dateLayouts are the transaction date formats statements use, most specific first
+var dateLayouts = []string{"2006-01-02", "Jan 2, 2006", "Jan. 2", "Jan 2", "2 Jan", "01/02"}
+
+var (
+	amountPattern = regexp.MustCompile(`^\(?[-−]?\$?\d{1,3}(,\d{3})*\.\d{2}\)?[-−]?(CR)?$`)
+	periodPattern = regexp.MustCompile(`(?i)([a-z]{3,9}\.? \d{1,2},? \d{4}|\d{4}-\d{2}-\d{2})\s*(?:to|-|–|through)\s*([a-z]{3,9}\.? \d{1,2},? \d{4}|\d{4}-\d{2}-\d{2})`)
+	periodLayouts = []string{"January 2, 2006", "January 2 2006", "Jan 2, 2006", "Jan 2 2006", "Jan. 2, 2006", "2006-01-02"}
+)
+
+// Balance labels, matched at the start of a line
+var (
+	openingLabels = []string{"opening balance", "balance forward", "previous balance", "previous statement balance", "starting balance"}
+	closingLabels = []string{"closing balance", "ending balance", "new balance", "balance at end"}
+	// Summary lines carrying an amount that isn't a transaction
+	summaryLabels = []string{"minimum payment", "payment due", "credit limit", "available credit", "overdraft limit"}
+	// Words marking money in on a bank account line that only shows the amount
+	creditWords = map[string]bool{
+		"deposit": true, "payroll": true, "salary": true, "refund": true, "interest": true,
+		"credit": true, "received": true, "reversal": true, "rebate": true,
+	}
+)
+
+// ledger reads statements that list one transaction per line as a date, a description and
+// amounts. Bank account lines end with the amount and usually the running balance, which
+// tells deposits from withdrawals; credit card lines end with the amount, with credits
+// marked by a minus sign or CR.
+type ledger struct {
+	name        string
+	institution string
+	markers     [][]string // The text must contain one phrase from each group
+	creditCard  bool
+}
+
+func (l *ledger) Name() string        { return l.name }
+func (l *ledger) Institution() string { return l.institution }
+
+func (l *ledger) Matches(lines []string) bool {
+	text := strings.ToLower(strings.Join(lines, "\n"))
+	for _, group := range l.markers {
+		found := false
+		for _, marker := range group {
+			if containsPhrase(text, marker) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *ledger) Parse(lines []string) (*Statement, error) {
+	s := &Statement{Institution: l.institution, Template: l.name, Transactions: make([]Transaction, 0)}
+
+	for _, line := range lines {
+		if start, end, ok := findPeriod(line); ok {
+			s.PeriodStart, s.PeriodEnd = &start, &end
+			break
+		}
+	}
+	periodEnd := time.Now()
+	if s.PeriodEnd != nil {
+		periodEnd = *s.PeriodEnd
+	} else {
+		s.Warnings = append(s.Warnings, "No statement period found; transaction years were guessed")
+	}
+
+	var balance *float64 // Running balance, to sign bank account lines
+	var lastDate time.Time
+	for _, line := range lines {
+		date, rest, dated := leadingDate(line, periodEnd)
+		lower := strings.ToLower(rest)
+		if amount, ok := labelledAmount(lower, rest, openingLabels); ok {
+			amount = l.balanceSign(amount)
+			if s.OpeningBalance == nil {
+				s.OpeningBalance = &amount
+				balance = &amount
+			}
+			continue
+		}
+		if amount, ok := labelledAmount(lower, rest, closingLabels); ok {
+			amount = l.balanceSign(amount)
+			s.ClosingBalance = &amount
+			continue
+		}
+		if strings.Contains(lower, "total") || hasPrefix(lower, summaryLabels) {
+			continue
+		}
+
+		if !dated {
+			// Banks print the date once per day; later lines that day start with the
+			// description. Card statements date every line.
+			if l.creditCard || lastDate.IsZero() {
+				continue
+			}
+			date = lastDate
+		}
+		if l.creditCard {
+			// Cards list the transaction date then the posting date
+			if _, after, ok := leadingDate(rest, periodEnd); ok {
+				rest = after
+			}
+		}
+
+		description, amounts := trailingAmounts(rest)
+		if len(amounts) == 0 || description == "" {
+			continue
+		}
+		lastDate = date
+
+		t := Transaction{Date: date, Description: description}
+		if l.creditCard {
+			amount := amounts[len(amounts)-1]
+			t.Amount = roundCents(-amount.value)
+			if amount.credit {
+				t.Amount = roundCents(amount.value)
+			}
+		} else {
+			t.Amount = signBankAmount(description, amounts, balance)
+			if len(amounts) > 1 {
+				running := amounts[len(amounts)-1].value
+				balance = &running
+			} else if balance != nil {
+				running := roundCents(*balance + t.Amount)
+				balance = &running
+			}
+		}
+		s.Transactions = append(s.Transactions, t)
+	}
+
+	if len(s.Transactions) == 0 {
+		return nil, fmt.Errorf("no transactions found in %s statement", l.institution)
+	}
+	if s.OpeningBalance != nil && s.ClosingBalance != nil {
+		total := *s.OpeningBalance
+		for _, t := range s.Transactions {
+			total += t.Amount
+		}
+		if diff := roundCents(*s.ClosingBalance - total); diff != 0 {
+			s.Warnings = append(s.Warnings, fmt.Sprintf("Transactions are %.2f short of the closing balance; some lines may be missing or misread", diff))
+		}
+	}
+	return s, nil
+}
+
+// balanceSign stores a card's owed balance as a negative balance
+func (l *ledger) balanceSign(amount float64) float64 {
+	if l.creditCard {
+		return -amount
+	}
+	return amount
+}
+
+// signBankAmount decides whether a bank account line is money in or out. When the line
+// shows the running balance, its change from the previous balance says which; otherwise
+// the description's wording does.
+func signBankAmount(description string, amounts []amountToken, balance *float64) float64 {
+	amount := amounts[0]
+	if amount.negative {
+		return -amount.value
+	}
+	if len(amounts) > 1 && balance != nil {
+		change := roundCents(amounts[len(amounts)-1].value - *balance)
+		if math.Abs(math.Abs(change)-amount.value) < 0.01 {
+			return change
+		}
+	}
+	for _, word := range strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z')
+	}) {
+		if creditWords[word] {
+			return amount.value
+		}
+	}
+	return -amount.value
+}
+
+// amountToken is a money amount read from a line
+type amountToken struct {
+	value    float64 // Always positive
+	negative bool    // Shown with a minus sign or in parentheses
+	credit   bool    // Marked CR, or negative
+}
+
+// parseAmount reads a money amount such as "1,234.56", "-$12.00", "(5.00)", "5.00-" or "45.00CR"
+func parseAmount(token string) (amountToken, bool) {
+	if !amountPattern.MatchString(token) {
+		return amountToken{}, false
+	}
+	var a amountToken
+	if strings.HasSuffix(token, "CR") {
+		a.credit = true
+		token = strings.TrimSuffix(token, "CR")
+	}
+	if strings.HasPrefix(token, "(") && strings.HasSuffix(token, ")") {
+		a.negative = true
+	}
+	if strings.ContainsAny(token, "-−") {
+		a.negative = true
+	}
+	a.credit = a.credit || a.negative
+	digits := strings.NewReplacer("(", "", ")", "", "-", "", "−", "", "$", "", ",", "").Replace(token)
+	value, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return amountToken{}, false
+	}
+	a.value = value
+	return a, true
+}
+
+// trailingAmounts splits the amounts off the end of a line, allowing a detached CR
+func trailingAmounts(line string) (string, []amountToken) {
+	fields := strings.Fields(line)
+	var amounts []amountToken
+	for len(fields) > 0 {
+		last := fields[len(fields)-1]
+		if last == "CR" && len(fields) > 1 {
+			if a, ok := parseAmount(fields[len(fields)-2] + "CR"); ok {
+				amounts = append([]amountToken{a}, amounts...)
+				fields = fields[:len(fields)-2]
+				continue
+			}
+		}
+		a, ok := parseAmount(last)
+		if !ok {
+			break
+		}
+		amounts = append([]amountToken{a}, amounts...)
+		fields = fields[:len(fields)-1]
+	}
+	return strings.Join(fields, " "), amounts
+}
+
+// labelledAmount reads the amount on a line starting with one of the labels
+func labelledAmount(lower, line string, labels []string) (float64, bool) {
+	for _, label := range labels {
+		if !strings.HasPrefix(lower, label) {
+			continue
+		}
+		if _, amounts := trailingAmounts(line); len(amounts) > 0 {
+			a := amounts[len(amounts)-1]
+			if a.negative {
+				return -a.value, true
+			}
+			return a.value, true
+		}
+	}
+	return 0, false
+}
+
+// leadingDate reads a date from the start of a line, placing dates without a year in the
+// statement period: a month after the period's last month belongs to the year before
+func leadingDate(line string, periodEnd time.Time) (time.Time, string, bool) {
+	fields := strings.Fields(line)
+	for n := 3; n >= 1; n-- {
+		if len(fields) <= n {
+			continue
+		}
+		candidate := strings.Join(fields[:n], " ")
+		for _, layout := range dateLayouts {
+			date, err := time.Parse(layout, candidate)
+			if err != nil {
+				continue
+			}
+			if date.Year() == 0 {
+				year := periodEnd.Year()
+				if date.Month() > periodEnd.Month() {
+					year--
+				}
+				date = time.Date(year, date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+			}
+			return date, strings.Join(fields[n:], " "), true
+		}
+	}
+	return time.Time{}, line, false
+}
+
+// findPeriod reads a statement period such as "January 1, 2024 to January 31, 2024"
+func findPeriod(line string) (time.Time, time.Time, bool) {
+	match := periodPattern.FindStringSubmatch(line)
+	if match == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	start, ok := parsePeriodDate(match[1])
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	end, ok := parsePeriodDate(match[2])
+	if !ok || end.Before(start) {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+func parsePeriodDate(value string) (time.Time, bool) {
+	for _, layout := range periodLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// hasPrefix reports whether text starts with one of the prefixes
+func hasPrefix(text string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsPhrase reports whether text contains the phrase as whole words, so "td" doesn't
+// match inside "ltd"
+func containsPhrase(text, phrase string) bool {
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], phrase)
+		if i < 0 {
+			return false
+		}
+		start := offset + i
+		end := start + len(phrase)
+		if (start == 0 || !isWordChar(text[start-1])) && (end == len(text) || !isWordChar(text[end])) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9'
+}
+
+// roundCents rounds an amount to two decimal places
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
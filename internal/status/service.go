@@ -0,0 +1,172 @@
+// Package status reports the health of a self-hosted instance for uptime monitors. It
+// exposes counts and timestamps only, never anything belonging to a user.
+package status
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"money/internal/background"
+)
+
+// Overall instance states
+const (
+	StateOK       = "ok"
+	StateDegraded = "degraded" // Serving, but migrations are pending or dirty
+)
+
+// Migration states
+const (
+	MigrationsUpToDate = "up_to_date"
+	MigrationsPending  = "pending"
+	MigrationsDirty    = "dirty" // A migration failed part way and needs fixing by hand
+	MigrationsUnknown  = "unknown"
+)
+
+// Migrations describes the schema version against the migration files shipped
+type Migrations struct {
+	Status  string `json:"status"`
+	Version uint   `json:"version"`
+	Latest  uint   `json:"latest"` // Zero when the migration files can't be read
+}
+
+// Queues lists how much background work is waiting
+type Queues struct {
+	RunningJobs        int `json:"running_jobs"`         // Schedulers and syncs in flight
+	SyncOutbox         int `json:"sync_outbox"`          // Sync side effects not yet delivered
+	NetWorthRecomputes int `json:"net_worth_recomputes"` // Net worth histories waiting to be rebuilt
+	Backfills          int `json:"backfills"`            // Expand migration backfills still running
+}
+
+// Status is the instance status served to uptime monitors
+type Status struct {
+	Status        string     `json:"status"`
+	Version       string     `json:"version"`
+	StartedAt     time.Time  `json:"started_at"`
+	UptimeSeconds int64      `json:"uptime_seconds"`
+	Database      string     `json:"database"` // ok or unreachable
+	Migrations    Migrations `json:"migrations"`
+	Queues        Queues     `json:"queues"`
+	LastBackupAt  *time.Time `json:"last_backup_at,omitempty"` // Nil until a backup succeeds
+}
+
+// Service reports instance status
+type Service struct {
+	db            *sql.DB
+	jobs          *background.Group
+	version       string
+	startedAt     time.Time
+	migrationsDir string
+	lastBackup    func(ctx context.Context) (*time.Time, error)
+}
+
+// NewService creates a new status service. The version is reported as given.
+func NewService(db *sql.DB, jobs *background.Group, version string) *Service {
+	return &Service{
+		db:            db,
+		jobs:          jobs,
+		version:       version,
+		startedAt:     time.Now(),
+		migrationsDir: "migrations",
+	}
+}
+
+// SetBackupSource sets where the time of the last successful backup is read from
+func (s *Service) SetBackupSource(fn func(ctx context.Context) (*time.Time, error)) {
+	s.lastBackup = fn
+}
+
+// Get returns the instance status. Failures to read any part are reported in the status
+// rather than returned, so monitors always get an answer while the process is up.
+func (s *Service) Get(ctx context.Context) *Status {
+	status := &Status{
+		Status:        StateOK,
+		Version:       s.version,
+		StartedAt:     s.startedAt,
+		UptimeSeconds: int64(time.Since(s.startedAt).Seconds()),
+		Database:      "ok",
+		Migrations:    Migrations{Status: MigrationsUnknown},
+	}
+	if s.jobs != nil {
+		status.Queues.RunningJobs = s.jobs.Running()
+	}
+
+	if err := s.db.PingContext(ctx); err != nil {
+		status.Status = StateDegraded
+		status.Database = "unreachable"
+		return status
+	}
+
+	status.Migrations = s.migrations(ctx)
+	if status.Migrations.Status != MigrationsUpToDate {
+		status.Status = StateDegraded
+	}
+
+	_ = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sync_outbox WHERE dispatched_at IS NULL`).Scan(&status.Queues.SyncOutbox)
+	_ = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM net_worth_recompute_queue`).Scan(&status.Queues.NetWorthRecomputes)
+	_ = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM backfill_progress WHERE done = 0`).Scan(&status.Queues.Backfills)
+
+	if s.lastBackup != nil {
+		if at, err := s.lastBackup(ctx); err == nil {
+			status.LastBackupAt = at
+		}
+	}
+
+	return status
+}
+
+// migrations compares the applied schema version with the newest migration file
+func (s *Service) migrations(ctx context.Context) Migrations {
+	m := Migrations{Status: MigrationsUnknown}
+
+	var dirty bool
+	if err := s.db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&m.Version, &dirty); err != nil {
+		return m
+	}
+
+	latest, err := latestMigration(s.migrationsDir)
+	if err == nil {
+		m.Latest = latest
+	}
+
+	switch {
+	case dirty:
+		m.Status = MigrationsDirty
+	case err != nil:
+		m.Status = MigrationsUnknown
+	case m.Version < latest:
+		m.Status = MigrationsPending
+	default:
+		m.Status = MigrationsUpToDate
+	}
+	return m
+}
+
+// latestMigration returns the highest version among the up migrations in dir
+func latestMigration(dir string) (uint, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return 0, err
+	}
+	if len(files) == 0 {
+		return 0, fmt.Errorf("no migrations found in %s", dir)
+	}
+
+	var latest uint
+	for _, file := range files {
+		prefix, _, _ := strings.Cut(filepath.Base(file), "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		if uint(version) > latest {
+			latest = uint(version)
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,171 @@
+package sync
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"money/internal/auth"
+)
+
+// SyncedAccount is a local account kept up to date from a provider account
+type SyncedAccount struct {
+	ID                string         `json:"id"`
+	ConnectionID      string         `json:"connection_id"`
+	AccountID         string         `json:"account_id"`
+	AccountName       string         `json:"account_name"`
+	ProviderAccountID string         `json:"provider_account_id"`
+	ConflictPolicy    ConflictPolicy `json:"conflict_policy"`
+	Paused            bool           `json:"paused"`
+	PausedAt          *time.Time     `json:"paused_at,omitempty"`
+	LastSyncAt        *time.Time     `json:"last_sync_at,omitempty"`
+}
+
+// ListSyncedAccountsResponse represents a list of synced accounts
+type ListSyncedAccountsResponse struct {
+	Accounts []SyncedAccount `json:"accounts"`
+}
+
+// SetAccountPausedRequest pauses or resumes syncing for one account
+type SetAccountPausedRequest struct {
+	Paused bool `json:"paused"`
+}
+
+// TriggerAccountSyncResponse represents the response after triggering a single account sync
+type TriggerAccountSyncResponse struct {
+	AccountID string     `json:"account_id"`
+	JobID     string     `json:"job_id"`
+	Status    SyncStatus `json:"status"`
+	Message   string     `json:"message"`
+}
+
+// ListSyncedAccounts lists the user's synced accounts with their pause state
+func (s *Service) ListSyncedAccounts(ctx context.Context) (*ListSyncedAccountsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	accounts, err := s.repo.syncedAccounts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list synced accounts: %w", err)
+	}
+	return &ListSyncedAccountsResponse{Accounts: accounts}, nil
+}
+
+// SetAccountPaused pauses or resumes syncing for a synced account. Connection syncs skip
+// paused accounts, leaving their balances and holdings as last synced.
+func (s *Service) SetAccountPaused(ctx context.Context, accountID string, req *SetAccountPausedRequest) (*SyncedAccount, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	var pausedAt *time.Time
+	now := time.Now()
+	if req.Paused {
+		pausedAt = &now
+	}
+
+	// Pausing an already paused account keeps the original pause time
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE synced_accounts
+		SET paused_at = CASE WHEN $1 IS NULL THEN NULL ELSE COALESCE(paused_at, $1) END,
+		    updated_at = $2
+		WHERE local_account_id = $3
+		  AND credential_id IN (SELECT id FROM sync_credentials WHERE user_id = $4)
+	`, pausedAt, now, accountID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update account sync pause: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("synced account not found")
+	}
+
+	return s.getSyncedAccount(ctx, userID, accountID)
+}
+
+// TriggerAccountSync syncs a single account in the background instead of its whole
+// connection. An explicit trigger syncs the account even while it is paused.
+func (s *Service) TriggerAccountSync(ctx context.Context, accountID string) (*TriggerAccountSyncResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	synced, err := s.getSyncedAccount(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var accountType string
+	var isAsset bool
+	err = s.db.QueryRowContext(ctx, `
+		SELECT type, is_asset FROM accounts WHERE id = $1
+	`, accountID).Scan(&accountType, &isAsset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	jobID, err := s.createSyncJob(ctx, synced.ID, SyncJobTypeFull)
+	if err != nil {
+		return nil, err
+	}
+
+	started := s.jobs.Go(func(jobCtx context.Context) {
+		// A single account is quick to sync, so shutdown lets it finish
+		jobCtx = auth.WithUserID(context.WithoutCancel(jobCtx), userID)
+		if err := s.syncSingleAccount(jobCtx, userID, synced, accountType, isAsset, jobID); err != nil {
+			syncLog.Printf("ERROR: account sync failed: account_id=%s job_id=%s error=%v", accountID, jobID, err)
+			_ = s.completeSyncJob(jobCtx, s.db, jobID, SyncJobStatusFailed, err.Error())
+		}
+	})
+	if !started {
+		_ = s.completeSyncJob(ctx, s.db, jobID, SyncJobStatusFailed, errSyncInterrupted.Error())
+		return nil, fmt.Errorf("server is shutting down, try again shortly")
+	}
+
+	return &TriggerAccountSyncResponse{
+		AccountID: accountID,
+		JobID:     jobID,
+		Status:    SyncStatusPending,
+		Message:   "Account sync started in background",
+	}, nil
+}
+
+// syncSingleAccount fetches one account's balances and positions from its provider
+func (s *Service) syncSingleAccount(ctx context.Context, userID string, synced *SyncedAccount, accountType string, isAsset bool, jobID string) error {
+	client, err := s.getDecryptedCredentials(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get credentials: %w", err)
+	}
+
+	var identityID string
+	err = s.db.QueryRowContext(ctx, `
+		SELECT identity_canonical_id FROM sync_credentials WHERE id = $1
+	`, synced.ConnectionID).Scan(&identityID)
+	if err != nil || identityID == "" {
+		return fmt.Errorf("identity ID not found in credentials: %v", err)
+	}
+
+	return s.syncAccountDetails(ctx, client, userID, synced.ID, synced.ProviderAccountID, synced.AccountID,
+		identityID, accountType, isAsset, jobID)
+}
+
+// getSyncedAccount looks up the synced account linked to one of the user's local accounts
+func (s *Service) getSyncedAccount(ctx context.Context, userID, accountID string) (*SyncedAccount, error) {
+	acc, err := s.repo.syncedAccount(ctx, userID, accountID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("synced account not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &acc, nil
+}
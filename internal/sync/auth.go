@@ -6,7 +6,6 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
@@ -77,7 +76,7 @@ func (s *Service) initiateWealthsimpleConnectionReal(ctx context.Context, userID
 			app_instance_id = excluded.app_instance_id,
 			email = excluded.email,
 			updated_at = excluded.updated_at
-	`, credentialID, userID, ProviderWealthsimple, connectionName, StatusSyncing, SyncFrequencyDaily,
+	`, credentialID, userID, ProviderWealthsimple, connectionName, StatusSyncing, s.newConnectionFrequency(),
 		encryptedUsername, encryptedPassword,
 		deviceID, sessionID, appInstanceID, username, now, now)
 
@@ -118,7 +117,7 @@ func (s *Service) initiateWealthsimpleConnectionReal(ctx context.Context, userID
 }
 
 // verifyOTPReal implements the real OTP verification flow
-func (s *Service) verifyOTPReal(ctx context.Context, credentialID, otpCode string) (*VerifyOTPResponse, error) {
+func (s *Service) verifyOTPReal(ctx context.Context, credentialID, otpCode string, selectAccounts bool) (*VerifyOTPResponse, error) {
 	// Get credential from database
 	var creds struct {
 		UserID                string
@@ -180,14 +179,14 @@ func (s *Service) verifyOTPReal(ctx context.Context, credentialID, otpCode strin
 		return nil, fmt.Errorf("failed to verify OTP: %w", err)
 	}
 
-	log.Printf("DEBUG: received token response: identity_id=%s email=%s",
+	syncLog.Printf("DEBUG: received token response: identity_id=%s email=%s",
 		tokenResp.IdentityCanonicalID, tokenResp.Email)
 
 	// If identity_canonical_id is empty, extract it from the JWT token
 	identityID := tokenResp.IdentityCanonicalID
 	if identityID == "" {
 		identityID = extractIdentityFromJWT(tokenResp.AccessToken)
-		log.Printf("INFO: extracted identity from JWT: identity_id=%s", identityID)
+		syncLog.Printf("INFO: extracted identity from JWT: identity_id=%s", identityID)
 	}
 
 	// Store tokens and profiles
@@ -198,7 +197,12 @@ func (s *Service) verifyOTPReal(ctx context.Context, credentialID, otpCode strin
 	// Clear username/password from database - we have tokens now, no longer need credentials
 	// This reduces security risk by not storing credentials long-term
 	// Update email field with the authenticated email from token response
+	// Connections that select accounts wait for the user's choice instead of syncing
 	connectionName := fmt.Sprintf("Wealthsimple - %s", tokenResp.Email)
+	status, selection := StatusSyncing, AccountSelectionAuto
+	if selectAccounts {
+		status, selection = StatusConnected, AccountSelectionManual
+	}
 	_, err = s.db.ExecContext(ctx, `
 		UPDATE sync_credentials
 		SET name = $1,
@@ -207,28 +211,27 @@ func (s *Service) verifyOTPReal(ctx context.Context, credentialID, otpCode strin
 		    encrypted_username = NULL,
 		    encrypted_password = NULL,
 		    encrypted_otp_claim = NULL,
-		    updated_at = $4
-		WHERE id = $5
-	`, connectionName, StatusSyncing, tokenResp.Email, time.Now(), credentialID)
+		    account_selection = $4,
+		    updated_at = $5
+		WHERE id = $6
+	`, connectionName, status, tokenResp.Email, selection, time.Now(), credentialID)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to update connection: %w", err)
 	}
 
-	log.Printf("INFO: cleared stored credentials for %s - using tokens only", credentialID)
+	syncLog.Printf("INFO: cleared stored credentials for %s - using tokens only", credentialID)
+
+	if selectAccounts {
+		return &VerifyOTPResponse{
+			CredentialID: credentialID,
+			Status:       StatusConnected,
+			Message:      "Authentication successful. Choose the accounts to sync.",
+		}, nil
+	}
 
 	// Trigger initial sync in background
-	go func() {
-		bgCtx := context.Background()
-		if err := s.performInitialSync(bgCtx, creds.UserID, credentialID); err != nil {
-			log.Printf("ERROR: initial sync failed: error=%v credential_id=%s", err, credentialID)
-			_, _ = s.db.ExecContext(bgCtx, `
-				UPDATE sync_credentials
-				SET status = $1, last_sync_error = $2, updated_at = $3
-				WHERE id = $4
-			`, StatusError, err.Error(), time.Now(), credentialID)
-		}
-	}()
+	s.startSync(ctx, creds.UserID, credentialID)
 
 	return &VerifyOTPResponse{
 		CredentialID: credentialID,
@@ -280,22 +283,22 @@ func (s *Service) autoRefreshCredentials(ctx context.Context, credentialID strin
 
 	// Use OAuth refresh token flow
 	client := wealthsimple.NewClient(creds.DeviceID, creds.SessionID, creds.AppInstanceID)
-	log.Printf("INFO: attempting OAuth refresh token flow for credential %s", credentialID)
+	syncLog.Printf("INFO: attempting OAuth refresh token flow for credential %s", credentialID)
 
 	tokenResp, err := client.RefreshAccessToken(ctx, refreshToken)
 	if err != nil {
-		log.Printf("WARN: refresh token failed for credential %s: %v - manual re-authentication required", credentialID, err)
+		syncLog.Printf("WARN: refresh token failed for credential %s: %v - manual re-authentication required", credentialID, err)
 		return fmt.Errorf("refresh token expired or invalid: %w", err)
 	}
 
-	log.Printf("INFO: refresh token succeeded for credential %s", credentialID)
+	syncLog.Printf("INFO: refresh token succeeded for credential %s", credentialID)
 
 	// Store refreshed tokens (without identity/profiles - those don't change)
 	if err := s.storeTokens(ctx, encService, credentialID, tokenResp, ""); err != nil {
 		return err
 	}
 
-	log.Printf("INFO: successfully auto-refreshed tokens for credential %s", credentialID)
+	syncLog.Printf("INFO: successfully auto-refreshed tokens for credential %s", credentialID)
 	return nil
 }
 
@@ -367,6 +370,7 @@ func (s *Service) getDecryptedCredentials(ctx context.Context, userID string) (*
 	// Create authenticated client
 	client := wealthsimple.NewClient(creds.DeviceID, creds.SessionID, creds.AppInstanceID)
 	client.SetAccessToken(accessToken)
+	client.SetConnectionID(creds.ID)
 
 	return client, nil
 }
@@ -387,13 +391,13 @@ func (s *Service) storeTokens(ctx context.Context, encService *encryption.Servic
 
 	// Calculate expiration time
 	expiresAt := time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
-	log.Printf("DEBUG: storing tokens for credential %s, expires in %d seconds at %s",
+	syncLog.Printf("DEBUG: storing tokens for credential %s, expires in %d seconds at %s",
 		credentialID, tokenResp.ExpiresIn, expiresAt.Format(time.RFC3339))
 
 	// If this is initial authentication (identityID provided), store identity and profiles
 	if identityID != "" {
 		profilesJSON := serializeProfiles(tokenResp.Profiles)
-		log.Printf("DEBUG: storing identity_canonical_id: identity_id=%s credential_id=%s", identityID, credentialID)
+		syncLog.Printf("DEBUG: storing identity_canonical_id: identity_id=%s credential_id=%s", identityID, credentialID)
 
 		_, err = s.db.ExecContext(ctx, `
 			UPDATE sync_credentials
@@ -456,7 +460,7 @@ func serializeProfiles(profiles map[string]map[string]string) string {
 // Returns true if token is valid or was successfully refreshed, false otherwise
 func (s *Service) ensureTokenValid(ctx context.Context, credentialID string, tokenExpiresAt sql.NullTime) error {
 	if !tokenExpiresAt.Valid {
-		log.Printf("WARN: token_expires_at is NULL for credential %s - cannot validate expiry", credentialID)
+		syncLog.Printf("WARN: token_expires_at is NULL for credential %s - cannot validate expiry", credentialID)
 		return nil // Proceed anyway
 	}
 
@@ -464,15 +468,15 @@ func (s *Service) ensureTokenValid(ctx context.Context, credentialID string, tok
 	expiresAt := tokenExpiresAt.Time
 	timeUntilExpiry := expiresAt.Sub(now)
 
-	log.Printf("DEBUG: checking token expiry for credential %s: expires_at=%s now=%s time_until_expiry=%v",
+	syncLog.Printf("DEBUG: checking token expiry for credential %s: expires_at=%s now=%s time_until_expiry=%v",
 		credentialID, expiresAt.Format(time.RFC3339), now.Format(time.RFC3339), timeUntilExpiry)
 
 	// Token expired or expiring within 5 minutes - try to auto-refresh
 	if now.After(expiresAt.Add(-5 * time.Minute)) {
-		log.Printf("INFO: token expired or expiring soon for credential %s, attempting auto-refresh", credentialID)
+		syncLog.Printf("INFO: token expired or expiring soon for credential %s, attempting auto-refresh", credentialID)
 
 		if err := s.autoRefreshCredentials(ctx, credentialID); err != nil {
-			log.Printf("WARN: auto-refresh failed for credential %s: %v", credentialID, err)
+			syncLog.Printf("WARN: auto-refresh failed for credential %s: %v", credentialID, err)
 			// Mark as disconnected - user needs to re-authenticate with 2FA
 			_, _ = s.db.ExecContext(ctx, `
 				UPDATE sync_credentials
@@ -485,7 +489,7 @@ func (s *Service) ensureTokenValid(ctx context.Context, credentialID string, tok
 			return fmt.Errorf("access token expired - re-authentication required")
 		}
 
-		log.Printf("INFO: successfully auto-refreshed credentials for %s", credentialID)
+		syncLog.Printf("INFO: successfully auto-refreshed credentials for %s", credentialID)
 	}
 
 	return nil
@@ -0,0 +1,122 @@
+package sync
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+	"money/internal/balance"
+	"money/internal/database"
+)
+
+// ChangeEntityType identifies what a sync change touched
+type ChangeEntityType string
+
+const (
+	ChangeEntityBalance ChangeEntityType = "balance"
+	ChangeEntityHolding ChangeEntityType = "holding"
+)
+
+// ChangeType describes how an entity changed during a sync
+type ChangeType string
+
+const (
+	ChangeTypeCreated ChangeType = "created"
+	ChangeTypeUpdated ChangeType = "updated"
+	ChangeTypeRemoved ChangeType = "removed"
+)
+
+// SyncJobChange is a single recorded change made by a sync job
+type SyncJobChange struct {
+	ID         string           `json:"id"`
+	SyncJobID  string           `json:"sync_job_id"`
+	EntityType ChangeEntityType `json:"entity_type"`
+	EntityID   *string          `json:"entity_id,omitempty"`
+	ChangeType ChangeType       `json:"change_type"`
+	Label      string           `json:"label"`
+	Field      string           `json:"field"`
+	OldValue   *float64         `json:"old_value,omitempty"`
+	NewValue   *float64         `json:"new_value,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+// SyncJobChangesResponse represents the change log of a sync job
+type SyncJobChangesResponse struct {
+	SyncJobID string          `json:"sync_job_id"`
+	Changes   []SyncJobChange `json:"changes"`
+}
+
+// recordChange persists a change made by a sync job. Failures are logged, not returned,
+// so the audit trail never aborts a sync.
+func (s *Service) recordChange(ctx context.Context, db database.Querier, jobID string, entityType ChangeEntityType, entityID string, changeType ChangeType, label, field string, oldValue, newValue *float64) {
+	var entityIDPtr *string
+	if entityID != "" {
+		entityIDPtr = &entityID
+	}
+
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO sync_job_changes (
+			id, sync_job_id, entity_type, entity_id, change_type, label, field, old_value, new_value, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, uuid.New().String(), jobID, entityType, entityIDPtr, changeType, label, field, oldValue, newValue, time.Now())
+	if err != nil {
+		syncLog.Printf("ERROR: failed to record sync change: job_id=%s entity_type=%s label=%s error=%v",
+			jobID, entityType, label, err)
+	}
+}
+
+// valueChanged reports whether two optional values differ
+func valueChanged(oldValue, newValue *float64) bool {
+	if oldValue == nil || newValue == nil {
+		return oldValue != newValue
+	}
+	return *oldValue != *newValue
+}
+
+// recordBalanceChange logs a balance written by a sync when it is new or its amount moved
+func (s *Service) recordBalanceChange(ctx context.Context, db database.Querier, jobID string, resp *balance.CreateBalanceResponse, amount float64) {
+	label := resp.Balance.Date.Format("2006-01-02")
+	if !resp.WasUpdate {
+		s.recordChange(ctx, db, jobID, ChangeEntityBalance, resp.Balance.ID, ChangeTypeCreated, label, "amount", nil, &amount)
+		return
+	}
+	if valueChanged(resp.PreviousAmount, &amount) {
+		s.recordChange(ctx, db, jobID, ChangeEntityBalance, resp.Balance.ID, ChangeTypeUpdated, label, "amount", resp.PreviousAmount, &amount)
+	}
+}
+
+// GetSyncJobChanges returns the change log for a sync job owned by the current user
+func (s *Service) GetSyncJobChanges(ctx context.Context, jobID string) (*SyncJobChangesResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	var ownerID string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT sc.user_id
+		FROM sync_jobs sj
+		JOIN synced_accounts sa ON sa.id = sj.synced_account_id
+		JOIN sync_credentials sc ON sc.id = sa.credential_id
+		WHERE sj.id = $1
+	`, jobID).Scan(&ownerID)
+	if err == sql.ErrNoRows || (err == nil && ownerID != userID) {
+		return nil, fmt.Errorf("sync job not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync job: %w", err)
+	}
+
+	changes, err := s.repo.syncJobChanges(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync job changes: %w", err)
+	}
+
+	return &SyncJobChangesResponse{
+		SyncJobID: jobID,
+		Changes:   changes,
+	}, nil
+}
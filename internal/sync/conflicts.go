@@ -0,0 +1,279 @@
+package sync
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+	"money/internal/balance"
+	"money/internal/database"
+)
+
+// ConflictPolicy decides what a sync does when a manual edit is newer than provider data
+type ConflictPolicy string
+
+const (
+	ConflictPolicyProviderWins  ConflictPolicy = "provider_wins"
+	ConflictPolicyManualWins    ConflictPolicy = "manual_wins"
+	ConflictPolicyFlagForReview ConflictPolicy = "flag_for_review"
+)
+
+// ConflictStatus represents the outcome of a sync conflict
+type ConflictStatus string
+
+const (
+	ConflictStatusOpen            ConflictStatus = "open"
+	ConflictStatusKeptManual      ConflictStatus = "kept_manual"
+	ConflictStatusAppliedProvider ConflictStatus = "applied_provider"
+)
+
+// SyncConflict records a manual balance that disagreed with provider data
+type SyncConflict struct {
+	ID              string         `json:"id"`
+	SyncedAccountID string         `json:"synced_account_id"`
+	AccountID       string         `json:"account_id"`
+	AccountName     string         `json:"account_name,omitempty"`
+	SyncJobID       *string        `json:"sync_job_id,omitempty"`
+	BalanceID       string         `json:"balance_id"`
+	BalanceDate     time.Time      `json:"balance_date"`
+	ManualAmount    float64        `json:"manual_amount"`
+	ProviderAmount  float64        `json:"provider_amount"`
+	Policy          ConflictPolicy `json:"policy"`
+	Status          ConflictStatus `json:"status"`
+	ResolvedAt      *time.Time     `json:"resolved_at,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+// ListConflictsResponse represents a list of sync conflicts
+type ListConflictsResponse struct {
+	Conflicts []SyncConflict `json:"conflicts"`
+}
+
+// SetConflictPolicyRequest represents the request to change an account's conflict policy
+type SetConflictPolicyRequest struct {
+	Policy ConflictPolicy `json:"policy"`
+}
+
+// ResolveConflictRequest represents the request to resolve a flagged conflict
+type ResolveConflictRequest struct {
+	Resolution ConflictStatus `json:"resolution"` // kept_manual or applied_provider
+}
+
+// IsValidConflictPolicy checks if a conflict policy is valid
+func IsValidConflictPolicy(policy ConflictPolicy) bool {
+	switch policy {
+	case ConflictPolicyProviderWins, ConflictPolicyManualWins, ConflictPolicyFlagForReview:
+		return true
+	}
+	return false
+}
+
+// applyProviderBalance checks whether the provider balance for a date may be written.
+// A conflict exists when the local balance for that date was entered or edited manually
+// after the account's last sync; the account's policy then decides the outcome. A conflict
+// left open for review raises a conflict_flagged event in the caller's transaction.
+func (s *Service) applyProviderBalance(ctx context.Context, db database.Querier, userID, localAccountID, jobID string, date time.Time, providerAmount float64) (bool, error) {
+	var syncedAccountID string
+	var policy ConflictPolicy
+	var lastSyncAt sql.NullTime
+	err := db.QueryRowContext(ctx, `
+		SELECT id, conflict_policy, last_sync_at
+		FROM synced_accounts
+		WHERE local_account_id = $1
+	`, localAccountID).Scan(&syncedAccountID, &policy, &lastSyncAt)
+	if err != nil {
+		return true, nil
+	}
+
+	var balanceID string
+	var manualAmount float64
+	var source balance.Source
+	var createdAt time.Time
+	var updatedAt sql.NullTime
+	err = db.QueryRowContext(ctx, `
+		SELECT id, amount, source, created_at, updated_at
+		FROM balances
+		WHERE account_id = $1 AND date = $2
+	`, localAccountID, date).Scan(&balanceID, &manualAmount, &source, &createdAt, &updatedAt)
+	if err != nil || source != balance.SourceManual || manualAmount == providerAmount {
+		return true, nil
+	}
+
+	editedAt := createdAt
+	if updatedAt.Valid {
+		editedAt = updatedAt.Time
+	}
+	if lastSyncAt.Valid && !editedAt.After(lastSyncAt.Time) {
+		return true, nil
+	}
+
+	status := ConflictStatusAppliedProvider
+	switch policy {
+	case ConflictPolicyManualWins:
+		status = ConflictStatusKeptManual
+	case ConflictPolicyFlagForReview:
+		status = ConflictStatusOpen
+	}
+
+	conflictID := s.recordConflict(ctx, db, syncedAccountID, localAccountID, jobID, balanceID, date, manualAmount, providerAmount, policy, status)
+	if conflictID != "" && status == ConflictStatusOpen {
+		err := enqueueEvent(ctx, db, userID, jobID, EventConflictFlagged, &ConflictFlaggedPayload{
+			ConflictID:     conflictID,
+			AccountID:      localAccountID,
+			BalanceDate:    date.Format("2006-01-02"),
+			ManualAmount:   manualAmount,
+			ProviderAmount: providerAmount,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return status == ConflictStatusAppliedProvider, nil
+}
+
+// recordConflict stores a conflict, refreshing an existing unresolved one for the same
+// balance, and returns its ID. Failures are logged and return an empty ID.
+func (s *Service) recordConflict(ctx context.Context, db database.Querier, syncedAccountID, accountID, jobID, balanceID string, date time.Time, manualAmount, providerAmount float64, policy ConflictPolicy, status ConflictStatus) string {
+	now := time.Now()
+	var resolvedAt *time.Time
+	if status != ConflictStatusOpen {
+		resolvedAt = &now
+	}
+
+	var conflictID string
+	err := db.QueryRowContext(ctx, `
+		SELECT id FROM sync_conflicts WHERE balance_id = $1 AND status = $2
+	`, balanceID, status).Scan(&conflictID)
+	if err == nil {
+		_, err = db.ExecContext(ctx, `
+			UPDATE sync_conflicts
+			SET sync_job_id = $1, manual_amount = $2, provider_amount = $3, policy = $4
+			WHERE id = $5
+		`, jobID, manualAmount, providerAmount, policy, conflictID)
+	} else {
+		conflictID = uuid.New().String()
+		_, err = db.ExecContext(ctx, `
+			INSERT INTO sync_conflicts (
+				id, synced_account_id, account_id, sync_job_id, balance_id, balance_date,
+				manual_amount, provider_amount, policy, status, resolved_at, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		`, conflictID, syncedAccountID, accountID, jobID, balanceID, date,
+			manualAmount, providerAmount, policy, status, resolvedAt, now)
+	}
+
+	if err != nil {
+		syncLog.Printf("ERROR: failed to record sync conflict: account_id=%s balance_id=%s error=%v",
+			accountID, balanceID, err)
+		return ""
+	}
+
+	syncLog.Printf("INFO: sync conflict detected: account_id=%s balance_id=%s manual=%f provider=%f policy=%s status=%s",
+		accountID, balanceID, manualAmount, providerAmount, policy, status)
+
+	return conflictID
+}
+
+// SetConflictPolicy sets the conflict policy for a synced account
+func (s *Service) SetConflictPolicy(ctx context.Context, accountID string, req *SetConflictPolicyRequest) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	if !IsValidConflictPolicy(req.Policy) {
+		return fmt.Errorf("invalid conflict policy: %s", req.Policy)
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE synced_accounts
+		SET conflict_policy = $1, updated_at = $2
+		WHERE local_account_id = $3
+		  AND credential_id IN (SELECT id FROM sync_credentials WHERE user_id = $4)
+	`, req.Policy, time.Now(), accountID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set conflict policy: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("synced account not found")
+	}
+
+	return nil
+}
+
+// ListConflicts lists the user's sync conflicts, optionally filtered by status
+func (s *Service) ListConflicts(ctx context.Context, status string) (*ListConflictsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	conflicts, err := s.repo.conflicts(ctx, userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sync conflicts: %w", err)
+	}
+
+	return &ListConflictsResponse{Conflicts: conflicts}, nil
+}
+
+// ResolveConflict resolves an open conflict by keeping the manual value or applying the provider value
+func (s *Service) ResolveConflict(ctx context.Context, id string, req *ResolveConflictRequest) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	if req.Resolution != ConflictStatusKeptManual && req.Resolution != ConflictStatusAppliedProvider {
+		return fmt.Errorf("invalid resolution: %s", req.Resolution)
+	}
+
+	var accountID string
+	var balanceDate time.Time
+	var providerAmount float64
+	var status ConflictStatus
+	err := s.db.QueryRowContext(ctx, `
+		SELECT c.account_id, c.balance_date, c.provider_amount, c.status
+		FROM sync_conflicts c
+		JOIN accounts a ON a.id = c.account_id
+		WHERE c.id = $1 AND a.user_id = $2
+	`, id, userID).Scan(&accountID, &balanceDate, &providerAmount, &status)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("sync conflict not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get sync conflict: %w", err)
+	}
+
+	if status != ConflictStatusOpen {
+		return fmt.Errorf("sync conflict is already resolved")
+	}
+
+	if req.Resolution == ConflictStatusAppliedProvider {
+		if _, err := s.balanceSvc.Create(ctx, &balance.CreateBalanceRequest{
+			AccountID: accountID,
+			Amount:    providerAmount,
+			Date:      balanceDate,
+			Notes:     "Synced from Wealthsimple",
+			Source:    balance.SourceSync,
+		}); err != nil {
+			return fmt.Errorf("failed to apply provider balance: %w", err)
+		}
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE sync_conflicts SET status = $1, resolved_at = $2 WHERE id = $3
+	`, req.Resolution, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to resolve sync conflict: %w", err)
+	}
+
+	return nil
+}
@@ -2,10 +2,25 @@ package sync
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
+
+	"money/internal/background"
 )
 
+// errSyncInterrupted is returned when shutdown stops a sync between accounts
+var errSyncInterrupted = errors.New("sync interrupted by shutdown")
+
+// ErrSyncInProgress is returned when a connection is already syncing on another instance
+var ErrSyncInProgress = errors.New("sync already in progress")
+
+// syncLeaseTTL is how long a connection's sync lease lasts without renewal. A running sync
+// renews it, so only a sync whose instance died loses it.
+const syncLeaseTTL = time.Minute
+
 // TriggerConnectionSync triggers a full sync for a connection
 func (s *Service) TriggerConnectionSync(ctx context.Context, id string) (*TriggerSyncResponse, error) {
 	// Get connection details
@@ -23,29 +38,26 @@ func (s *Service) TriggerConnectionSync(ctx context.Context, id string) (*Trigge
 		return nil, fmt.Errorf("connection not found: %w", err)
 	}
 
-	// Trigger sync in background
-	go func() {
-		bgCtx := context.Background()
-
-		// Update connection status
-		_, _ = s.db.ExecContext(bgCtx, `
-			UPDATE sync_credentials
-			SET status = $1, updated_at = $2
-			WHERE id = $3
-		`, StatusSyncing, time.Now(), id)
+	// Take the lease before marking the connection syncing, so a sync running elsewhere is
+	// refused rather than left looking like it was restarted
+	lease, err := s.takeSyncLease(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 
-		// Perform initial sync
-		err := s.performInitialSync(bgCtx, conn.UserID, id)
+	// Update connection status
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE sync_credentials
+		SET status = $1, updated_at = $2
+		WHERE id = $3
+	`, StatusSyncing, time.Now(), id)
+	if err != nil {
+		lease.Release(context.WithoutCancel(ctx))
+		return nil, fmt.Errorf("failed to update connection status: %w", err)
+	}
 
-		if err != nil {
-			// Update connection with error
-			_, _ = s.db.ExecContext(bgCtx, `
-				UPDATE sync_credentials
-				SET status = $1, last_sync_error = $2, updated_at = $3
-				WHERE id = $4
-			`, StatusError, err.Error(), time.Now(), id)
-		}
-	}()
+	// Trigger sync in background
+	s.runSync(lease, conn.UserID, id)
 
 	return &TriggerSyncResponse{
 		ConnectionID: id,
@@ -53,3 +65,130 @@ func (s *Service) TriggerConnectionSync(ctx context.Context, id string) (*Trigge
 		Message:      "Sync started in background",
 	}, nil
 }
+
+// ResumeInterruptedSyncs restarts the syncs that were still running when the server last
+// stopped. Runs interrupted by a graceful shutdown continue from their checkpoint. Every
+// instance calls it on start, so a sync another instance is still running is left to it
+// by the connection's lease.
+func (s *Service) ResumeInterruptedSyncs(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id
+		FROM sync_credentials
+		WHERE status = $1
+	`, StatusSyncing)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find interrupted syncs: %w", err)
+	}
+	defer rows.Close()
+
+	type connection struct{ id, userID string }
+	var connections []connection
+	for rows.Next() {
+		var c connection
+		if err := rows.Scan(&c.id, &c.userID); err != nil {
+			return 0, fmt.Errorf("failed to scan interrupted sync: %w", err)
+		}
+		connections = append(connections, c)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, c := range connections {
+		syncLog.Printf("INFO: resuming interrupted sync: connection_id=%s", c.id)
+		s.startSync(ctx, c.userID, c.id)
+	}
+	return len(connections), nil
+}
+
+// startSync runs a full sync for a connection in the background, unless it's already
+// syncing on another instance.
+func (s *Service) startSync(ctx context.Context, userID, connectionID string) {
+	lease, err := s.takeSyncLease(ctx, connectionID)
+	if errors.Is(err, ErrSyncInProgress) {
+		syncLog.Printf("INFO: sync already running on another instance: connection_id=%s", connectionID)
+		return
+	}
+	if err != nil {
+		syncLog.Printf("ERROR: failed to start sync: error=%v connection_id=%s", err, connectionID)
+		return
+	}
+	s.runSync(lease, userID, connectionID)
+}
+
+// takeSyncLease takes the lease on a connection so instances sharing the database never
+// sync it twice at once, returning ErrSyncInProgress while another instance holds it
+func (s *Service) takeSyncLease(ctx context.Context, connectionID string) (*background.Lease, error) {
+	lease, err := s.jobs.TakeLease(ctx, "sync:"+connectionID, syncLeaseTTL)
+	if errors.Is(err, background.ErrLeaseHeld) {
+		return nil, ErrSyncInProgress
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take sync lease: %w", err)
+	}
+	return lease, nil
+}
+
+// runSync runs a full sync for a connection in the background under lease, releasing it
+// when the sync ends. A sync stopped by shutdown leaves the connection syncing with its
+// checkpoint, for ResumeInterruptedSyncs to pick up.
+func (s *Service) runSync(lease *background.Lease, userID, connectionID string) {
+	started := lease.Go(func(ctx context.Context) {
+		err := s.performInitialSync(ctx, userID, connectionID)
+		if errors.Is(err, errSyncInterrupted) {
+			syncLog.Printf("INFO: sync interrupted, will resume on next start: connection_id=%s", connectionID)
+			return
+		}
+		if err != nil {
+			syncLog.Printf("ERROR: sync failed: error=%v connection_id=%s", err, connectionID)
+			_ = s.UpdateConnectionError(context.WithoutCancel(ctx), connectionID, err.Error())
+		}
+	})
+	if !started {
+		syncLog.Printf("INFO: server shutting down, sync deferred to next start: connection_id=%s", connectionID)
+	}
+}
+
+// loadSyncCheckpoint returns the provider accounts an interrupted run already synced
+func (s *Service) loadSyncCheckpoint(ctx context.Context, connectionID string) (map[string]bool, error) {
+	var checkpoint sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT sync_checkpoint FROM sync_credentials WHERE id = $1
+	`, connectionID).Scan(&checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sync checkpoint: %w", err)
+	}
+
+	done := make(map[string]bool)
+	if !checkpoint.Valid {
+		return done, nil
+	}
+	var providerAccountIDs []string
+	if err := json.Unmarshal([]byte(checkpoint.String), &providerAccountIDs); err != nil {
+		return nil, fmt.Errorf("failed to decode sync checkpoint: %w", err)
+	}
+	for _, id := range providerAccountIDs {
+		done[id] = true
+	}
+	return done, nil
+}
+
+// saveSyncCheckpoint records the provider accounts synced so far in the current run
+func (s *Service) saveSyncCheckpoint(ctx context.Context, connectionID string, done map[string]bool) error {
+	providerAccountIDs := make([]string, 0, len(done))
+	for id := range done {
+		providerAccountIDs = append(providerAccountIDs, id)
+	}
+	checkpoint, err := json.Marshal(providerAccountIDs)
+	if err != nil {
+		return fmt.Errorf("failed to encode sync checkpoint: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE sync_credentials SET sync_checkpoint = $1 WHERE id = $2
+	`, string(checkpoint), connectionID)
+	if err != nil {
+		return fmt.Errorf("failed to save sync checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,396 @@
+package sync
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/account"
+	"money/internal/auth"
+	"money/internal/sync/wealthsimple"
+)
+
+// AccountSelection decides which provider accounts a connection syncs
+type AccountSelection string
+
+const (
+	AccountSelectionAuto   AccountSelection = "auto"   // Every open provider account gets a local account
+	AccountSelectionManual AccountSelection = "manual" // Only accounts chosen after discovery are synced
+)
+
+// providerAccount is an account as listed by the provider
+type providerAccount struct {
+	ID       string
+	Nickname string
+	Type     string
+	Currency string
+	Status   string
+}
+
+// proposedName is the local account name used when the user doesn't pick one
+func (p providerAccount) proposedName() string {
+	if p.Nickname != "" {
+		return p.Nickname
+	}
+	return formatAccountTypeName(mapWealthsimpleAccountType(p.Type))
+}
+
+// DiscoveredAccount is a provider account with the local account it maps to, or would be
+// created as
+type DiscoveredAccount struct {
+	ProviderAccountID string  `json:"provider_account_id"`
+	Nickname          string  `json:"nickname,omitempty"`
+	ProviderType      string  `json:"provider_type"`
+	Currency          string  `json:"currency"`
+	Status            string  `json:"status"`
+	ProposedName      string  `json:"proposed_name"`
+	ProposedType      string  `json:"proposed_type"`
+	IsAsset           bool    `json:"is_asset"`
+	LocalAccountID    *string `json:"local_account_id,omitempty"` // Set once the account is synced
+	Skipped           bool    `json:"skipped"`
+}
+
+// DiscoverAccountsResponse lists a connection's provider accounts before any are created
+type DiscoverAccountsResponse struct {
+	ConnectionID     string              `json:"connection_id"`
+	AccountSelection AccountSelection    `json:"account_selection"`
+	Accounts         []DiscoveredAccount `json:"accounts"`
+}
+
+// AccountChoice is the user's decision for one discovered account. Name and Type override
+// the proposed mapping when set.
+type AccountChoice struct {
+	ProviderAccountID string `json:"provider_account_id"`
+	Skip              bool   `json:"skip"`
+	Name              string `json:"name,omitempty"`
+	Type              string `json:"type,omitempty"`
+}
+
+// SelectAccountsRequest creates local accounts for the chosen provider accounts
+type SelectAccountsRequest struct {
+	Accounts []AccountChoice `json:"accounts"`
+}
+
+// SelectAccountsResponse reports the accounts created and skipped, and the sync started
+type SelectAccountsResponse struct {
+	ConnectionID string              `json:"connection_id"`
+	Created      []DiscoveredAccount `json:"created"`
+	Skipped      int                 `json:"skipped"`
+	Status       SyncStatus          `json:"status"`
+	Message      string              `json:"message"`
+}
+
+// DiscoverAccounts lists the connection's provider accounts with their proposed local
+// mapping, so the user can choose what to sync before any local account is created
+func (s *Service) DiscoverAccounts(ctx context.Context, connectionID string) (*DiscoverAccountsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	var selection AccountSelection
+	err := s.db.QueryRowContext(ctx, `
+		SELECT account_selection FROM sync_credentials WHERE id = $1 AND user_id = $2
+	`, connectionID, userID).Scan(&selection)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("connection not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	providerAccounts, err := s.listConnectionAccounts(ctx, userID, connectionID)
+	if err != nil {
+		return nil, err
+	}
+	skipped, err := s.skippedProviderAccounts(ctx, connectionID)
+	if err != nil {
+		return nil, err
+	}
+	linked, err := s.linkedProviderAccounts(ctx, connectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &DiscoverAccountsResponse{
+		ConnectionID:     connectionID,
+		AccountSelection: selection,
+		Accounts:         make([]DiscoveredAccount, 0, len(providerAccounts)),
+	}
+	for _, p := range providerAccounts {
+		localType := mapWealthsimpleAccountType(p.Type)
+		discovered := DiscoveredAccount{
+			ProviderAccountID: p.ID,
+			Nickname:          p.Nickname,
+			ProviderType:      p.Type,
+			Currency:          mapCurrency(p.Currency),
+			Status:            p.Status,
+			ProposedName:      p.proposedName(),
+			ProposedType:      localType,
+			IsAsset:           isAssetAccount(localType),
+			Skipped:           skipped[p.ID],
+		}
+		if localID, ok := linked[p.ID]; ok {
+			discovered.LocalAccountID = &localID
+		}
+		response.Accounts = append(response.Accounts, discovered)
+	}
+	return response, nil
+}
+
+// SelectAccounts creates local accounts for the chosen provider accounts, remembers the
+// skipped ones, and starts a sync. The connection then only syncs chosen accounts; provider
+// accounts opened later show up in discovery instead of being created automatically.
+func (s *Service) SelectAccounts(ctx context.Context, connectionID string, req *SelectAccountsRequest) (*SelectAccountsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	var status Status
+	err := s.db.QueryRowContext(ctx, `
+		SELECT status FROM sync_credentials WHERE id = $1 AND user_id = $2
+	`, connectionID, userID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("connection not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	if status == StatusSyncing {
+		return nil, fmt.Errorf("connection is syncing, try again when it finishes")
+	}
+
+	providerAccounts, err := s.listConnectionAccounts(ctx, userID, connectionID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]providerAccount, len(providerAccounts))
+	for _, p := range providerAccounts {
+		byID[p.ID] = p
+	}
+	linked, err := s.linkedProviderAccounts(ctx, connectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate every choice before creating anything
+	for _, choice := range req.Accounts {
+		p, ok := byID[choice.ProviderAccountID]
+		if !ok {
+			return nil, fmt.Errorf("provider account not found: %s", choice.ProviderAccountID)
+		}
+		if p.Status != "open" && !choice.Skip {
+			return nil, fmt.Errorf("provider account %s is %s and can't be synced", p.ID, p.Status)
+		}
+		if _, ok := linked[p.ID]; ok && choice.Skip {
+			return nil, fmt.Errorf("provider account %s is already synced; pause it instead", p.ID)
+		}
+	}
+
+	response := &SelectAccountsResponse{ConnectionID: connectionID, Created: make([]DiscoveredAccount, 0)}
+	for _, choice := range req.Accounts {
+		p := byID[choice.ProviderAccountID]
+		if choice.Skip {
+			_, err := s.db.ExecContext(ctx, `
+				INSERT INTO skipped_provider_accounts (credential_id, provider_account_id, created_at)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (credential_id, provider_account_id) DO NOTHING
+			`, connectionID, p.ID, time.Now())
+			if err != nil {
+				return nil, fmt.Errorf("failed to skip provider account: %w", err)
+			}
+			response.Skipped++
+			continue
+		}
+		if _, ok := linked[p.ID]; ok {
+			continue
+		}
+
+		name := choice.Name
+		if name == "" {
+			name = p.proposedName()
+		}
+		localType := choice.Type
+		if localType == "" {
+			localType = mapWealthsimpleAccountType(p.Type)
+		}
+		localID, _, err := s.linkProviderAccount(ctx, connectionID, p, name, localType)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.db.ExecContext(ctx, `
+			DELETE FROM skipped_provider_accounts WHERE credential_id = $1 AND provider_account_id = $2
+		`, connectionID, p.ID); err != nil {
+			return nil, fmt.Errorf("failed to unskip provider account: %w", err)
+		}
+		response.Created = append(response.Created, DiscoveredAccount{
+			ProviderAccountID: p.ID,
+			Nickname:          p.Nickname,
+			ProviderType:      p.Type,
+			Currency:          mapCurrency(p.Currency),
+			Status:            p.Status,
+			ProposedName:      name,
+			ProposedType:      localType,
+			IsAsset:           isAssetAccount(localType),
+			LocalAccountID:    &localID,
+		})
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE sync_credentials SET account_selection = $1, updated_at = $2 WHERE id = $3
+	`, AccountSelectionManual, time.Now(), connectionID); err != nil {
+		return nil, fmt.Errorf("failed to update account selection: %w", err)
+	}
+
+	if _, err := s.TriggerConnectionSync(ctx, connectionID); err != nil {
+		return nil, err
+	}
+	response.Status = SyncStatusPending
+	response.Message = "Accounts selected. Sync started in background."
+	return response, nil
+}
+
+// listConnectionAccounts fetches the provider accounts of one of the user's connections
+func (s *Service) listConnectionAccounts(ctx context.Context, userID, connectionID string) ([]providerAccount, error) {
+	client, err := s.getDecryptedCredentials(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credentials: %w", err)
+	}
+
+	var identityID string
+	err = s.db.QueryRowContext(ctx, `
+		SELECT identity_canonical_id FROM sync_credentials WHERE id = $1
+	`, connectionID).Scan(&identityID)
+	if err != nil || identityID == "" {
+		return nil, fmt.Errorf("identity ID not found in credentials: %v", err)
+	}
+
+	return s.fetchProviderAccounts(ctx, client, identityID)
+}
+
+// fetchProviderAccounts lists the accounts of a provider identity
+func (s *Service) fetchProviderAccounts(ctx context.Context, client *wealthsimple.Client, identityID string) ([]providerAccount, error) {
+	variables := map[string]interface{}{
+		"identityId": identityID,
+	}
+	data, err := client.QueryGraphQL(ctx, wealthsimple.QueryListAccounts, variables, "trade")
+	if err != nil {
+		syncLog.Printf("ERROR: failed to fetch accounts: %v", err)
+		return nil, fmt.Errorf("failed to fetch accounts: %v", err)
+	}
+
+	// Parse accounts from identity.accounts.edges structure
+	identity, ok := data["identity"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid response format: no identity field")
+	}
+	accountsData, ok := identity["accounts"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid response format: no accounts field")
+	}
+	edges, ok := accountsData["edges"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid response format: no edges field")
+	}
+
+	accounts := make([]providerAccount, 0, len(edges))
+	for _, edge := range edges {
+		edgeMap, ok := edge.(map[string]interface{})
+		if !ok {
+			syncLog.Printf("DEBUG: skipping non-map edge: %v", edge)
+			continue
+		}
+		node, ok := edgeMap["node"].(map[string]interface{})
+		if !ok {
+			syncLog.Printf("DEBUG: skipping edge with no node: %v", edgeMap)
+			continue
+		}
+
+		var p providerAccount
+		p.ID, _ = node["id"].(string)
+		p.Nickname, _ = node["nickname"].(string)
+		p.Type, _ = node["type"].(string)
+		p.Currency, _ = node["currency"].(string)
+		p.Status, _ = node["status"].(string)
+		accounts = append(accounts, p)
+	}
+	return accounts, nil
+}
+
+// linkProviderAccount creates a local account for a provider account and links the two
+func (s *Service) linkProviderAccount(ctx context.Context, connectionID string, p providerAccount, name, localType string) (localAccountID, syncedAccountID string, err error) {
+	institution := "Wealthsimple"
+	createdAccount, err := s.accountSvc.Create(ctx, &account.CreateAccountRequest{
+		Name:         name,
+		Type:         account.AccountType(localType),
+		Currency:     account.Currency(mapCurrency(p.Currency)),
+		Institution:  &institution,
+		IsAsset:      isAssetAccount(localType),
+		IsSynced:     true,
+		ConnectionID: connectionID,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create local account: %w", err)
+	}
+
+	syncedAccountID = uuid.New().String()
+	now := time.Now()
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO synced_accounts (
+			id, credential_id, local_account_id, provider_account_id,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6)
+	`, syncedAccountID, connectionID, createdAccount.ID, p.ID, now, now)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create synced account: %w", err)
+	}
+
+	syncLog.Printf("INFO: created new synced account: provider_account_id=%s local_account_id=%s synced_account_id=%s",
+		p.ID, createdAccount.ID, syncedAccountID)
+	return createdAccount.ID, syncedAccountID, nil
+}
+
+// skippedProviderAccounts returns the provider accounts the user chose not to sync
+func (s *Service) skippedProviderAccounts(ctx context.Context, connectionID string) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT provider_account_id FROM skipped_provider_accounts WHERE credential_id = $1
+	`, connectionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get skipped accounts: %w", err)
+	}
+	defer rows.Close()
+
+	skipped := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan skipped account: %w", err)
+		}
+		skipped[id] = true
+	}
+	return skipped, rows.Err()
+}
+
+// linkedProviderAccounts maps the connection's synced provider accounts to their local accounts
+func (s *Service) linkedProviderAccounts(ctx context.Context, connectionID string) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT provider_account_id, local_account_id FROM synced_accounts WHERE credential_id = $1
+	`, connectionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get synced accounts: %w", err)
+	}
+	defer rows.Close()
+
+	linked := make(map[string]string)
+	for rows.Next() {
+		var providerID, localID string
+		if err := rows.Scan(&providerID, &localID); err != nil {
+			return nil, fmt.Errorf("failed to scan synced account: %w", err)
+		}
+		linked[providerID] = localID
+	}
+	return linked, rows.Err()
+}
@@ -0,0 +1,230 @@
+package sync
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"money/internal/sync/wealthsimple"
+	"money/internal/transaction"
+
+	"github.com/google/uuid"
+)
+
+// interestIncomeCategory is the transaction category synced interest and bonuses are
+// filed under
+const interestIncomeCategory = "Interest Income"
+
+// interestActivityPageSize is how many activities are requested per page
+const interestActivityPageSize = 100
+
+// interestActivityTypes maps the provider's activity types for interest paid into a cash
+// account to the kind recorded. Bonuses are promotional interest, such as a rate boost.
+var interestActivityTypes = map[string]string{
+	"INTEREST":  "interest",
+	"BONUS":     "bonus",
+	"PROMOTION": "bonus",
+}
+
+// providerInterest is an interest or bonus payment reported in an account's activity
+type providerInterest struct {
+	activityID string
+	kind       string // interest or bonus
+	amount     float64
+	currency   string
+	date       time.Time
+}
+
+// description names the payment on its transaction and income record
+func (p providerInterest) description() string {
+	if p.kind == "bonus" {
+		return "Wealthsimple Cash bonus"
+	}
+	return "Wealthsimple Cash interest"
+}
+
+// fetchInterestPayments pages through a cash account's activity and returns the settled
+// interest and bonus payments. It returns nil when the activity could not be fetched, in
+// which case the sync goes ahead without them and the next sync picks them up.
+func (s *Service) fetchInterestPayments(ctx context.Context, client *wealthsimple.Client, providerAccountID, localAccountID string) []providerInterest {
+	syncLog.Printf("INFO: fetching account activity: provider_account_id=%s local_account_id=%s",
+		providerAccountID, localAccountID)
+
+	var payments []providerInterest
+	var cursor interface{}
+	for {
+		variables := map[string]interface{}{
+			"accountId": providerAccountID,
+			"limit":     interestActivityPageSize,
+			"cursor":    cursor,
+		}
+		data, err := client.QueryGraphQL(ctx, wealthsimple.QueryFetchAccountActivities, variables, "trade")
+		if err != nil {
+			syncLog.Printf("ERROR: failed to fetch account activity: provider_account_id=%s error=%v",
+				providerAccountID, err)
+			return nil
+		}
+
+		account, _ := data["account"].(map[string]interface{})
+		activities, _ := account["activities"].(map[string]interface{})
+		if activities == nil {
+			syncLog.Printf("WARN: no activities in response: provider_account_id=%s", providerAccountID)
+			return nil
+		}
+
+		edges, _ := activities["edges"].([]interface{})
+		for _, edge := range edges {
+			node, _ := edge.(map[string]interface{})["node"].(map[string]interface{})
+			if payment, ok := parseInterestActivity(node); ok {
+				payments = append(payments, payment)
+			}
+		}
+
+		pageInfo, _ := activities["pageInfo"].(map[string]interface{})
+		next, _ := pageInfo["endCursor"].(string)
+		if hasNext, _ := pageInfo["hasNextPage"].(bool); !hasNext || next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	syncLog.Printf("INFO: found interest payments: provider_account_id=%s count=%d",
+		providerAccountID, len(payments))
+	return payments
+}
+
+// parseInterestActivity reads an activity node, reporting false for anything other than a
+// settled interest or bonus payment
+func parseInterestActivity(node map[string]interface{}) (providerInterest, bool) {
+	activityType, _ := node["type"].(string)
+	kind, ok := interestActivityTypes[strings.ToUpper(activityType)]
+	if !ok {
+		return providerInterest{}, false
+	}
+	id, _ := node["id"].(string)
+	settledAt, _ := node["settledAt"].(string)
+	if id == "" || settledAt == "" {
+		return providerInterest{}, false // Still pending
+	}
+	date, err := time.Parse(time.RFC3339, settledAt)
+	if err != nil {
+		syncLog.Printf("WARN: unreadable activity settlement date: activity_id=%s settled_at=%s", id, settledAt)
+		return providerInterest{}, false
+	}
+
+	value, _ := node["marketValue"].(map[string]interface{})
+	var amount float64
+	switch v := value["amount"].(type) {
+	case string:
+		amount, err = strconv.ParseFloat(v, 64)
+	case float64:
+		amount = v
+	default:
+		err = fmt.Errorf("missing amount")
+	}
+	if err != nil || amount <= 0 {
+		syncLog.Printf("WARN: skipping interest activity without a positive amount: activity_id=%s", id)
+		return providerInterest{}, false
+	}
+	currency, _ := value["currency"].(string)
+
+	return providerInterest{
+		activityID: id,
+		kind:       kind,
+		amount:     amount,
+		currency:   mapCurrency(currency),
+		date:       time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+	}, true
+}
+
+// interestPayment is a payment not synced before, with the transaction that records it
+type interestPayment struct {
+	providerInterest
+	transaction *transaction.NewTransaction
+}
+
+// prepareInterestPayments builds the transactions for payments not synced before and runs
+// them past the transaction validate handlers, ahead of the account's sync transaction. A
+// rejected payment is skipped, and offered again on the next sync.
+func (s *Service) prepareInterestPayments(ctx context.Context, userID, localAccountID string, payments []providerInterest) []*interestPayment {
+	category := interestIncomeCategory
+	var prepared []*interestPayment
+	for _, p := range payments {
+		var exists bool
+		err := s.db.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM transactions WHERE account_id = $1 AND provider_activity_id = $2)
+		`, localAccountID, p.activityID).Scan(&exists)
+		if err != nil {
+			syncLog.Printf("ERROR: failed to check interest payment: activity_id=%s error=%v", p.activityID, err)
+			continue
+		}
+		if exists {
+			continue
+		}
+
+		accountID, activityID := localAccountID, p.activityID
+		row := &transaction.NewTransaction{
+			UserID:             userID,
+			Source:             transaction.SourceSync,
+			AccountID:          &accountID,
+			Date:               p.date,
+			Description:        p.description(),
+			Amount:             p.amount,
+			Currency:           p.currency,
+			Category:           &category,
+			ProviderActivityID: &activityID,
+		}
+		if err := s.transactions.ValidateNew(ctx, row); err != nil {
+			syncLog.Printf("WARN: skipping interest payment refused by validation: activity_id=%s error=%v", p.activityID, err)
+			continue
+		}
+		prepared = append(prepared, &interestPayment{providerInterest: p, transaction: row})
+	}
+	return prepared
+}
+
+// writeInterestPayments records each prepared payment as a transaction in the Interest
+// Income category and as taxable investment income for its tax year, within the account's
+// sync transaction. The transaction links the income record so both stay in step with the
+// provider's activity ID.
+func (s *Service) writeInterestPayments(ctx context.Context, tx *sql.Tx, userID, localAccountID, jobID string, payments []*interestPayment) error {
+	for _, p := range payments {
+		// Checked again in case another sync recorded it since it was prepared
+		var exists bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM transactions WHERE account_id = $1 AND provider_activity_id = $2)
+		`, localAccountID, p.activityID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to check interest payment: %w", err)
+		}
+		if exists {
+			continue
+		}
+
+		now := time.Now()
+		recordID := uuid.New().String()
+		date := p.date.Format("2006-01-02")
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO income_records (id, user_id, source, category, amount, currency, frequency, tax_year, date_received, description, is_taxable, created_at, updated_at)
+			VALUES ($1, $2, 'Wealthsimple', 'investment', $3, $4, 'one_time', $5, $6, $7, true, $8, $8)
+		`, recordID, userID, p.amount, p.currency, p.date.Year(), date, p.description(), now)
+		if err != nil {
+			return fmt.Errorf("failed to create income record: %w", err)
+		}
+
+		p.transaction.IncomeRecordID = &recordID
+		if err := s.transactions.InsertTx(ctx, tx, p.transaction); err != nil {
+			return fmt.Errorf("failed to create interest transaction: %w", err)
+		}
+
+		syncLog.Printf("INFO: recorded interest payment: transaction_id=%s account_id=%s kind=%s amount=%f date=%s",
+			p.transaction.ID, localAccountID, p.kind, p.amount, date)
+		if err := s.updateSyncJobProgress(ctx, tx, jobID, 1, 1, 0, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package sync
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+	"money/internal/background"
+	"money/internal/database"
+)
+
+// EventType identifies a side effect raised by a sync
+type EventType string
+
+const (
+	EventAccountSynced   EventType = "account_synced"   // An account's balances and holdings were committed
+	EventConflictFlagged EventType = "conflict_flagged" // A balance conflict is waiting for review
+)
+
+// MaxOutboxAttempts is how many times delivery of an event is tried before it is abandoned
+const MaxOutboxAttempts = 5
+
+// outboxDispatchInterval is how often undelivered events are retried in the background
+const outboxDispatchInterval = time.Minute
+
+// OutboxEvent is a side effect written in the same transaction as the synced data it
+// describes, so subscribers only ever see changes that were committed
+type OutboxEvent struct {
+	ID        string          `json:"id"`
+	UserID    string          `json:"user_id"`
+	SyncJobID *string         `json:"sync_job_id,omitempty"`
+	Type      EventType       `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"attempts"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AccountSyncedPayload is the payload of an account_synced event
+type AccountSyncedPayload struct {
+	AccountID    string `json:"account_id"`
+	ItemsCreated int    `json:"items_created"`
+	ItemsUpdated int    `json:"items_updated"`
+}
+
+// ConflictFlaggedPayload is the payload of a conflict_flagged event
+type ConflictFlaggedPayload struct {
+	ConflictID     string  `json:"conflict_id"`
+	AccountID      string  `json:"account_id"`
+	BalanceDate    string  `json:"balance_date"`
+	ManualAmount   float64 `json:"manual_amount"`
+	ProviderAmount float64 `json:"provider_amount"`
+}
+
+// OutboxHandler delivers an event. Delivery is at least once, so handlers must be
+// idempotent. The context carries the event's user as the authenticated user.
+type OutboxHandler func(ctx context.Context, event *OutboxEvent) error
+
+// Subscribe registers a handler for an event type. Subscribers must be registered at
+// startup, before any sync runs.
+func (s *Service) Subscribe(eventType EventType, handler OutboxHandler) {
+	s.subscribers[eventType] = append(s.subscribers[eventType], handler)
+}
+
+// enqueueEvent writes an event to the outbox within the caller's transaction
+func enqueueEvent(ctx context.Context, db database.Querier, userID, jobID string, eventType EventType, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	var jobIDPtr *string
+	if jobID != "" {
+		jobIDPtr = &jobID
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO sync_outbox (id, user_id, sync_job_id, event_type, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, uuid.New().String(), userID, jobIDPtr, eventType, string(data), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to enqueue %s event: %w", eventType, err)
+	}
+	return nil
+}
+
+// DispatchOutbox delivers committed events that have not been delivered yet, oldest first,
+// and returns how many were delivered. A failed event stays in the outbox and is retried
+// until it reaches MaxOutboxAttempts.
+func (s *Service) DispatchOutbox(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, sync_job_id, event_type, payload, attempts, created_at
+		FROM sync_outbox
+		WHERE dispatched_at IS NULL AND attempts < $1
+		ORDER BY created_at
+	`, MaxOutboxAttempts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list outbox events: %w", err)
+	}
+
+	events := make([]*OutboxEvent, 0)
+	for rows.Next() {
+		event := &OutboxEvent{}
+		var jobID sql.NullString
+		var payload string
+		if err := rows.Scan(&event.ID, &event.UserID, &jobID, &event.Type, &payload, &event.Attempts, &event.CreatedAt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		if jobID.Valid {
+			event.SyncJobID = &jobID.String
+		}
+		event.Payload = json.RawMessage(payload)
+		events = append(events, event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read outbox events: %w", err)
+	}
+
+	delivered := 0
+	for _, event := range events {
+		if err := s.deliverEvent(ctx, event); err != nil {
+			syncLog.Printf("ERROR: outbox delivery failed: event_id=%s type=%s attempt=%d error=%v",
+				event.ID, event.Type, event.Attempts+1, err)
+			_, err = s.db.ExecContext(ctx, `
+				UPDATE sync_outbox SET attempts = attempts + 1, last_error = $1 WHERE id = $2
+			`, err.Error(), event.ID)
+			if err != nil {
+				return delivered, fmt.Errorf("failed to record outbox failure: %w", err)
+			}
+			continue
+		}
+
+		_, err = s.db.ExecContext(ctx, `
+			UPDATE sync_outbox SET attempts = attempts + 1, last_error = NULL, dispatched_at = $1 WHERE id = $2
+		`, time.Now(), event.ID)
+		if err != nil {
+			return delivered, fmt.Errorf("failed to mark outbox event delivered: %w", err)
+		}
+		delivered++
+	}
+
+	return delivered, nil
+}
+
+// deliverEvent runs every subscriber for an event, stopping at the first failure
+func (s *Service) deliverEvent(ctx context.Context, event *OutboxEvent) error {
+	userCtx := auth.WithUserID(ctx, event.UserID)
+	for _, handler := range s.subscribers[event.Type] {
+		if err := handler(userCtx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartOutboxDispatch retries undelivered events every minute until jobs drains,
+// covering events left behind when the process stopped between commit and delivery
+func (s *Service) StartOutboxDispatch(jobs *background.Group) {
+	jobs.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(outboxDispatchInterval)
+		defer ticker.Stop()
+
+		for {
+			jobs.Exclusive(ctx, "outbox_dispatch", outboxDispatchInterval, func(ctx context.Context) {
+				if _, err := s.DispatchOutbox(ctx); err != nil {
+					syncLog.Printf("ERROR: outbox dispatch failed: %v", err)
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
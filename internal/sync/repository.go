@@ -0,0 +1,216 @@
+package sync
+
+import (
+	"context"
+	"database/sql"
+
+	"money/internal/database"
+)
+
+// repository reads the sync tables. Each method runs one named query and returns typed
+// rows, and a row that fails to scan fails the call instead of being skipped. Bind it to a
+// transaction with repository{db: tx} to read within it.
+type repository struct {
+	db database.Querier
+}
+
+// connectionQuery reads one of the user's connections
+var connectionQuery = database.Query{
+	Name: "connection",
+	SQL: `
+		SELECT id, user_id, provider, name, status, last_sync_at, last_sync_error,
+		       sync_frequency, account_count, created_at, updated_at
+		FROM sync_credentials
+		WHERE id = $1 AND user_id = $2
+	`,
+}
+
+// scanConnection reads a row selected by connectionQuery
+func scanConnection(row database.Scanner) (Connection, error) {
+	var conn Connection
+	var lastSyncAt sql.NullTime
+	var lastSyncError sql.NullString
+	err := row.Scan(
+		&conn.ID, &conn.UserID, &conn.Provider, &conn.Name, &conn.Status, &lastSyncAt, &lastSyncError,
+		&conn.SyncFrequency, &conn.AccountCount, &conn.CreatedAt, &conn.UpdatedAt,
+	)
+	if err != nil {
+		return conn, err
+	}
+	if lastSyncAt.Valid {
+		conn.LastSyncAt = &lastSyncAt.Time
+	}
+	if lastSyncError.Valid {
+		conn.LastSyncError = lastSyncError.String
+	}
+	return conn, nil
+}
+
+// connection returns one of the user's connections, or sql.ErrNoRows
+func (r repository) connection(ctx context.Context, userID, id string) (Connection, error) {
+	return database.One(ctx, r.db, connectionQuery, scanConnection, id, userID)
+}
+
+// recentSyncJobsQuery lists a connection's sync jobs from the last 24 hours, newest first
+var recentSyncJobsQuery = database.Query{
+	Name: "recent sync jobs",
+	SQL: `
+		SELECT
+			sj.id, sj.synced_account_id, sj.type, sj.status,
+			sj.started_at, sj.completed_at, sj.error_message,
+			sj.items_processed, sj.items_created, sj.items_updated, sj.items_failed,
+			sj.created_at,
+			a.name as account_name
+		FROM sync_jobs sj
+		JOIN synced_accounts sa ON sa.id = sj.synced_account_id
+		LEFT JOIN accounts a ON a.id = sa.local_account_id
+		WHERE sa.credential_id = $1
+		  AND sj.created_at > datetime('now', '-24 hours')
+		ORDER BY sj.created_at DESC
+		LIMIT 100
+	`,
+}
+
+// scanSyncJob reads a row selected by recentSyncJobsQuery
+func scanSyncJob(row database.Scanner) (SyncJob, error) {
+	var job SyncJob
+	var startedAt, completedAt sql.NullTime
+	var errorMessage, accountName sql.NullString
+	err := row.Scan(
+		&job.ID, &job.SyncedAccountID, &job.Type, &job.Status,
+		&startedAt, &completedAt, &errorMessage,
+		&job.ItemsProcessed, &job.ItemsCreated, &job.ItemsUpdated, &job.ItemsFailed,
+		&job.CreatedAt,
+		&accountName,
+	)
+	if err != nil {
+		return job, err
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+	if errorMessage.Valid {
+		job.ErrorMessage = errorMessage.String
+	}
+	if accountName.Valid {
+		job.AccountName = accountName.String
+	}
+	return job, nil
+}
+
+// recentSyncJobs lists a connection's sync jobs from the last 24 hours, newest first
+func (r repository) recentSyncJobs(ctx context.Context, connectionID string) ([]SyncJob, error) {
+	return database.All(ctx, r.db, recentSyncJobsQuery, scanSyncJob, connectionID)
+}
+
+// syncedAccountsQuery lists the user's synced accounts, optionally only those of one local account
+var syncedAccountsQuery = database.Query{
+	Name: "synced accounts",
+	SQL: `
+		SELECT sa.id, sa.credential_id, sa.local_account_id, COALESCE(a.name, ''), sa.provider_account_id,
+		       sa.conflict_policy, sa.paused_at, sa.last_sync_at
+		FROM synced_accounts sa
+		JOIN sync_credentials sc ON sc.id = sa.credential_id
+		LEFT JOIN accounts a ON a.id = sa.local_account_id
+		WHERE sc.user_id = $1 AND ($2 = '' OR sa.local_account_id = $2)
+		ORDER BY a.name
+	`,
+}
+
+// scanSyncedAccount reads a synced account row
+func scanSyncedAccount(row database.Scanner) (SyncedAccount, error) {
+	var acc SyncedAccount
+	var pausedAt, lastSyncAt sql.NullTime
+	err := row.Scan(&acc.ID, &acc.ConnectionID, &acc.AccountID, &acc.AccountName, &acc.ProviderAccountID,
+		&acc.ConflictPolicy, &pausedAt, &lastSyncAt)
+	if err != nil {
+		return acc, err
+	}
+	if pausedAt.Valid {
+		acc.Paused = true
+		acc.PausedAt = &pausedAt.Time
+	}
+	if lastSyncAt.Valid {
+		acc.LastSyncAt = &lastSyncAt.Time
+	}
+	return acc, nil
+}
+
+// syncedAccounts lists the user's synced accounts by account name
+func (r repository) syncedAccounts(ctx context.Context, userID string) ([]SyncedAccount, error) {
+	return database.All(ctx, r.db, syncedAccountsQuery, scanSyncedAccount, userID, "")
+}
+
+// syncedAccount returns the synced account linked to one of the user's local accounts, or
+// sql.ErrNoRows
+func (r repository) syncedAccount(ctx context.Context, userID, accountID string) (SyncedAccount, error) {
+	return database.One(ctx, r.db, syncedAccountsQuery, scanSyncedAccount, userID, accountID)
+}
+
+// localAccountIDsQuery lists the local accounts a connection syncs
+var localAccountIDsQuery = database.Query{
+	Name: "synced account ids",
+	SQL:  `SELECT local_account_id FROM synced_accounts WHERE credential_id = $1`,
+}
+
+// localAccountIDs returns the IDs of the local accounts a connection syncs
+func (r repository) localAccountIDs(ctx context.Context, connectionID string) ([]string, error) {
+	return database.All(ctx, r.db, localAccountIDsQuery, database.Strings, connectionID)
+}
+
+// syncJobChangesQuery lists the changes a sync job recorded, oldest first
+var syncJobChangesQuery = database.Query{
+	Name: "sync job changes",
+	SQL: `
+		SELECT id, sync_job_id, entity_type, entity_id, change_type, label, field, old_value, new_value, created_at
+		FROM sync_job_changes
+		WHERE sync_job_id = $1
+		ORDER BY created_at
+	`,
+}
+
+// scanSyncJobChange scans a row selected by syncJobChangesQuery
+func scanSyncJobChange(row database.Scanner) (SyncJobChange, error) {
+	var change SyncJobChange
+	err := row.Scan(
+		&change.ID, &change.SyncJobID, &change.EntityType, &change.EntityID, &change.ChangeType,
+		&change.Label, &change.Field, &change.OldValue, &change.NewValue, &change.CreatedAt,
+	)
+	return change, err
+}
+
+// syncJobChanges lists the changes a sync job recorded, oldest first
+func (r repository) syncJobChanges(ctx context.Context, jobID string) ([]SyncJobChange, error) {
+	return database.All(ctx, r.db, syncJobChangesQuery, scanSyncJobChange, jobID)
+}
+
+// conflictsQuery lists the user's sync conflicts newest first, optionally with one status
+var conflictsQuery = database.Query{
+	Name: "sync conflicts",
+	SQL: `
+		SELECT c.id, c.synced_account_id, c.account_id, a.name, c.sync_job_id, c.balance_id, c.balance_date,
+			c.manual_amount, c.provider_amount, c.policy, c.status, c.resolved_at, c.created_at
+		FROM sync_conflicts c
+		JOIN accounts a ON a.id = c.account_id
+		WHERE a.user_id = $1 AND ($2 = '' OR c.status = $2)
+		ORDER BY c.created_at DESC
+	`,
+}
+
+// scanSyncConflict scans a conflict row joined with its account name
+func scanSyncConflict(row database.Scanner) (SyncConflict, error) {
+	var c SyncConflict
+	err := row.Scan(
+		&c.ID, &c.SyncedAccountID, &c.AccountID, &c.AccountName, &c.SyncJobID, &c.BalanceID, &c.BalanceDate,
+		&c.ManualAmount, &c.ProviderAmount, &c.Policy, &c.Status, &c.ResolvedAt, &c.CreatedAt,
+	)
+	return c, err
+}
+
+// conflicts lists the user's sync conflicts newest first; an empty status lists them all
+func (r repository) conflicts(ctx context.Context, userID, status string) ([]SyncConflict, error) {
+	return database.All(ctx, r.db, conflictsQuery, scanSyncConflict, userID, status)
+}
@@ -5,18 +5,24 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"money/internal/account"
 	"money/internal/auth"
+	"money/internal/background"
 	"money/internal/balance"
 	"money/internal/holdings"
+	"money/internal/logger"
 	"money/internal/sync/encryption"
 	"money/internal/sync/wealthsimple"
+	"money/internal/transaction"
 )
 
+// syncLog logs for the sync module
+var syncLog = logger.Module("sync")
+
 // AuthenticationError represents an authentication failure
 type AuthenticationError struct {
 	Message string
@@ -32,18 +38,48 @@ type Service struct {
 	accountSvc    *account.Service
 	balanceSvc    *balance.Service
 	holdingsSvc   *holdings.Service
+	transactions  *transaction.Service
+	repo          repository
 	encryptionKey string
+	subscribers   map[EventType][]OutboxHandler
+	jobs          *background.Group
+	// defaultFrequency is the SyncFrequency given to new connections
+	defaultFrequency atomic.Value
 }
 
-// NewService creates a new sync service
-func NewService(db *sql.DB, accountSvc *account.Service, balanceSvc *balance.Service, holdingsSvc *holdings.Service, encryptionKey string) *Service {
+// NewService creates a new sync service. Syncs run in the background on jobs.
+func NewService(db *sql.DB, accountSvc *account.Service, balanceSvc *balance.Service, holdingsSvc *holdings.Service, transactions *transaction.Service, encryptionKey string, jobs *background.Group) *Service {
 	return &Service{
 		db:            db,
 		accountSvc:    accountSvc,
 		balanceSvc:    balanceSvc,
 		holdingsSvc:   holdingsSvc,
+		transactions:  transactions,
+		repo:          repository{db: db},
 		encryptionKey: encryptionKey,
+		subscribers:   make(map[EventType][]OutboxHandler),
+		jobs:          jobs,
+	}
+}
+
+// SetDefaultFrequency changes the sync frequency given to new connections. Existing
+// connections keep theirs.
+func (s *Service) SetDefaultFrequency(frequency SyncFrequency) error {
+	switch frequency {
+	case SyncFrequencyDaily, SyncFrequencyHourly, SyncFrequencyManual:
+	default:
+		return fmt.Errorf("invalid sync frequency: %s", frequency)
+	}
+	s.defaultFrequency.Store(frequency)
+	return nil
+}
+
+// newConnectionFrequency returns the sync frequency for a new connection, daily by default
+func (s *Service) newConnectionFrequency() SyncFrequency {
+	if frequency, ok := s.defaultFrequency.Load().(SyncFrequency); ok {
+		return frequency
 	}
+	return SyncFrequencyDaily
 }
 
 // Provider represents a financial institution provider
@@ -116,8 +152,9 @@ type InitiateConnectionResponse struct {
 
 // VerifyOTPRequest represents the request to verify OTP
 type VerifyOTPRequest struct {
-	CredentialID string `json:"credential_id"`
-	OTPCode      string `json:"otp_code"`
+	CredentialID   string `json:"credential_id"`
+	OTPCode        string `json:"otp_code"`
+	SelectAccounts bool   `json:"select_accounts,omitempty"` // Discover accounts first instead of syncing them all
 }
 
 // VerifyOTPResponse represents the response after verifying OTP
@@ -179,13 +216,14 @@ type SyncJob struct {
 
 // ConnectionSyncStatusResponse represents detailed sync status for a connection
 type ConnectionSyncStatusResponse struct {
-	ConnectionID   string        `json:"connection_id"`
-	ConnectionName string        `json:"connection_name"`
-	Status         Status        `json:"status"`
-	LastSyncAt     *time.Time    `json:"last_sync_at,omitempty"`
-	LastSyncError  string        `json:"last_sync_error,omitempty"`
-	Jobs           []SyncJob     `json:"jobs"`
-	Summary        SyncSummary   `json:"summary"`
+	ConnectionID   string                       `json:"connection_id"`
+	ConnectionName string                       `json:"connection_name"`
+	Status         Status                       `json:"status"`
+	LastSyncAt     *time.Time                   `json:"last_sync_at,omitempty"`
+	LastSyncError  string                       `json:"last_sync_error,omitempty"`
+	Jobs           []SyncJob                    `json:"jobs"`
+	Summary        SyncSummary                  `json:"summary"`
+	Client         wealthsimple.ConnectionStats `json:"client"` // Provider call retries and circuit state
 }
 
 // SyncSummary provides aggregate statistics for all sync jobs
@@ -258,7 +296,7 @@ func (s *Service) InitiateWealthsimpleConnection(ctx context.Context, req *Initi
 // VerifyOTP completes authentication by verifying the OTP code
 func (s *Service) VerifyOTP(ctx context.Context, req *VerifyOTPRequest) (*VerifyOTPResponse, error) {
 	// Use real implementation
-	return s.verifyOTPReal(ctx, req.CredentialID, req.OTPCode)
+	return s.verifyOTPReal(ctx, req.CredentialID, req.OTPCode, req.SelectAccounts)
 }
 
 // ListConnections retrieves all connections for the authenticated user
@@ -285,7 +323,7 @@ func (s *Service) ListConnections(ctx context.Context) (*ListConnectionsResponse
 	// Initialize encryption service for token validation
 	encService, err := encryption.NewService(s.encryptionKey)
 	if err != nil {
-		log.Printf("WARN: failed to initialize encryption service: %v", err)
+		syncLog.Printf("WARN: failed to initialize encryption service: %v", err)
 		// Continue without validation
 		encService = nil
 	}
@@ -361,14 +399,14 @@ func (s *Service) validateConnectionSession(ctx context.Context, conn *Connectio
 	if tokenExpiresAt.Valid && time.Now().After(tokenExpiresAt.Time.Add(-5*time.Minute)) {
 		conn.Status = StatusConnected
 		conn.LastSyncError = ""
-		log.Printf("INFO: successfully validated/refreshed token for connection %s", conn.ID)
+		syncLog.Printf("INFO: successfully validated/refreshed token for connection %s", conn.ID)
 		return
 	}
 
 	// Token not near expiry - optionally validate with provider
 	accessToken, err := encService.Decrypt(encryptedAccessToken)
 	if err != nil {
-		log.Printf("WARN: failed to decrypt access token for connection %s: %v", conn.ID, err)
+		syncLog.Printf("WARN: failed to decrypt access token for connection %s: %v", conn.ID, err)
 		return
 	}
 
@@ -377,10 +415,10 @@ func (s *Service) validateConnectionSession(ctx context.Context, conn *Connectio
 	tokenInfo, err := client.CheckTokenInfo(ctx, accessToken)
 	if err != nil {
 		// Session validation failed - try auto-refresh
-		log.Printf("INFO: session validation failed for connection %s, attempting auto-refresh: %v", conn.ID, err)
+		syncLog.Printf("INFO: session validation failed for connection %s, attempting auto-refresh: %v", conn.ID, err)
 
 		if refreshErr := s.autoRefreshCredentials(ctx, conn.ID); refreshErr != nil {
-			log.Printf("WARN: auto-refresh failed for connection %s: %v", conn.ID, refreshErr)
+			syncLog.Printf("WARN: auto-refresh failed for connection %s: %v", conn.ID, refreshErr)
 			conn.Status = StatusDisconnected
 			conn.LastSyncError = "Session expired - please login again"
 			return
@@ -388,12 +426,12 @@ func (s *Service) validateConnectionSession(ctx context.Context, conn *Connectio
 
 		conn.Status = StatusConnected
 		conn.LastSyncError = ""
-		log.Printf("INFO: successfully auto-refreshed token for connection %s after validation failure", conn.ID)
+		syncLog.Printf("INFO: successfully auto-refreshed token for connection %s after validation failure", conn.ID)
 		return
 	}
 
 	// Session is valid
-	log.Printf("DEBUG: session valid for connection %s, expires in %d seconds", conn.ID, tokenInfo.ExpiresIn)
+	syncLog.Printf("DEBUG: session valid for connection %s, expires in %d seconds", conn.ID, tokenInfo.ExpiresIn)
 }
 
 // GetConnection retrieves a single connection by ID
@@ -404,41 +442,11 @@ func (s *Service) GetConnection(ctx context.Context, id string) (*Connection, er
 		return nil, fmt.Errorf("user not authenticated")
 	}
 
-	conn := &Connection{}
-	var lastSyncAt sql.NullTime
-	var lastSyncError sql.NullString
-	err := s.db.QueryRowContext(ctx, `
-		SELECT id, user_id, provider, name, status, last_sync_at, last_sync_error,
-		       sync_frequency, account_count, created_at, updated_at
-		FROM sync_credentials
-		WHERE id = $1 AND user_id = $2
-	`, id, userID).Scan(
-		&conn.ID,
-		&conn.UserID,
-		&conn.Provider,
-		&conn.Name,
-		&conn.Status,
-		&lastSyncAt,
-		&lastSyncError,
-		&conn.SyncFrequency,
-		&conn.AccountCount,
-		&conn.CreatedAt,
-		&conn.UpdatedAt,
-	)
-
+	conn, err := s.repo.connection(ctx, userID, id)
 	if err != nil {
 		return nil, err
 	}
-
-	if lastSyncAt.Valid {
-		conn.LastSyncAt = &lastSyncAt.Time
-	}
-
-	if lastSyncError.Valid {
-		conn.LastSyncError = lastSyncError.String
-	}
-
-	return conn, nil
+	return &conn, nil
 }
 
 // DeleteConnection disconnects and deletes a connection
@@ -450,32 +458,18 @@ func (s *Service) DeleteConnection(ctx context.Context, id string) (*DeleteRespo
 	}
 
 	// Get all synced account IDs before deleting
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT local_account_id
-		FROM synced_accounts
-		WHERE credential_id = $1
-	`, id)
+	accountIDs, err := s.repo.localAccountIDs(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var accountIDs []string
-	for rows.Next() {
-		var accountID string
-		if err := rows.Scan(&accountID); err != nil {
-			continue
-		}
-		accountIDs = append(accountIDs, accountID)
-	}
 
-	log.Printf("INFO: deleting synced accounts: credential_id=%s account_count=%d account_ids=%v",
+	syncLog.Printf("INFO: deleting synced accounts: credential_id=%s account_count=%d account_ids=%v",
 		id, len(accountIDs), accountIDs)
 
 	// Delete accounts via account service
 	for _, accountID := range accountIDs {
 		if _, err := s.accountSvc.Delete(ctx, accountID); err != nil {
-			log.Printf("ERROR: failed to delete account: account_id=%s error=%v", accountID, err)
+			syncLog.Printf("ERROR: failed to delete account: account_id=%s error=%v", accountID, err)
 		}
 	}
 
@@ -489,7 +483,7 @@ func (s *Service) DeleteConnection(ctx context.Context, id string) (*DeleteRespo
 		return nil, err
 	}
 
-	log.Printf("INFO: deleted connection: credential_id=%s", id)
+	syncLog.Printf("INFO: deleted connection: credential_id=%s", id)
 
 	return &DeleteResponse{Success: true}, nil
 }
@@ -536,7 +530,7 @@ func (s *Service) TriggerSync(ctx context.Context, userID, connectionID string)
 		return fmt.Errorf("user not authenticated")
 	}
 
-	log.Printf("INFO: triggering sync for connection: user_id=%s connection_id=%s", userID, connectionID)
+	syncLog.Printf("INFO: triggering sync for connection: user_id=%s connection_id=%s", userID, connectionID)
 
 	// Perform the sync
 	return s.performInitialSync(ctx, userID, connectionID)
@@ -551,23 +545,23 @@ func (s *Service) UpdateConnectionError(ctx context.Context, connectionID, error
 	if isAuthError {
 		status = StatusDisconnected
 		errorMessage = "Authentication failed - please login again"
-		log.Printf("WARN: authentication error detected for connection %s - marking as disconnected", connectionID)
+		syncLog.Printf("WARN: authentication error detected for connection %s - marking as disconnected", connectionID)
 	} else {
 		status = StatusError
 	}
 
 	_, err := s.db.ExecContext(ctx, `
 		UPDATE sync_credentials
-		SET status = $1, last_sync_error = $2, updated_at = CURRENT_TIMESTAMP
+		SET status = $1, last_sync_error = $2, sync_checkpoint = NULL, updated_at = CURRENT_TIMESTAMP
 		WHERE id = $3
 	`, string(status), errorMessage, connectionID)
 
 	if err != nil {
-		log.Printf("ERROR: failed to update connection error: connection_id=%s error=%v", connectionID, err)
+		syncLog.Printf("ERROR: failed to update connection error: connection_id=%s error=%v", connectionID, err)
 		return fmt.Errorf("failed to update connection error: %w", err)
 	}
 
-	log.Printf("INFO: updated connection error: connection_id=%s status=%s error=%s", connectionID, status, errorMessage)
+	syncLog.Printf("INFO: updated connection error: connection_id=%s status=%s error=%s", connectionID, status, errorMessage)
 	return nil
 }
 
@@ -607,63 +601,13 @@ func (s *Service) GetConnectionSyncStatus(ctx context.Context, id string) (*Conn
 	}
 
 	// Get recent sync jobs for this connection (last 24 hours)
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT
-			sj.id, sj.synced_account_id, sj.type, sj.status,
-			sj.started_at, sj.completed_at, sj.error_message,
-			sj.items_processed, sj.items_created, sj.items_updated, sj.items_failed,
-			sj.created_at,
-			a.name as account_name
-		FROM sync_jobs sj
-		JOIN synced_accounts sa ON sa.id = sj.synced_account_id
-		LEFT JOIN accounts a ON a.id = sa.local_account_id
-		WHERE sa.credential_id = $1
-		  AND sj.created_at > datetime('now', '-24 hours')
-		ORDER BY sj.created_at DESC
-		LIMIT 100
-	`, id)
+	jobs, err := s.repo.recentSyncJobs(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch sync jobs: %w", err)
 	}
-	defer rows.Close()
 
-	var jobs []SyncJob
 	summary := SyncSummary{}
-
-	for rows.Next() {
-		var job SyncJob
-		var startedAt, completedAt sql.NullTime
-		var errorMessage sql.NullString
-		var accountName sql.NullString
-
-		err := rows.Scan(
-			&job.ID, &job.SyncedAccountID, &job.Type, &job.Status,
-			&startedAt, &completedAt, &errorMessage,
-			&job.ItemsProcessed, &job.ItemsCreated, &job.ItemsUpdated, &job.ItemsFailed,
-			&job.CreatedAt,
-			&accountName,
-		)
-		if err != nil {
-			log.Printf("ERROR: failed to scan sync job: %v", err)
-			continue
-		}
-
-		if startedAt.Valid {
-			job.StartedAt = &startedAt.Time
-		}
-		if completedAt.Valid {
-			job.CompletedAt = &completedAt.Time
-		}
-		if errorMessage.Valid {
-			job.ErrorMessage = errorMessage.String
-		}
-		if accountName.Valid {
-			job.AccountName = accountName.String
-		}
-
-		jobs = append(jobs, job)
-
-		// Update summary
+	for _, job := range jobs {
 		summary.TotalJobs++
 		summary.TotalProcessed += job.ItemsProcessed
 		summary.TotalCreated += job.ItemsCreated
@@ -682,10 +626,6 @@ func (s *Service) GetConnectionSyncStatus(ctx context.Context, id string) (*Conn
 		}
 	}
 
-	if jobs == nil {
-		jobs = []SyncJob{}
-	}
-
 	return &ConnectionSyncStatusResponse{
 		ConnectionID:   conn.ID,
 		ConnectionName: conn.Name,
@@ -694,5 +634,6 @@ func (s *Service) GetConnectionSyncStatus(ctx context.Context, id string) (*Conn
 		LastSyncError:  conn.LastSyncError,
 		Jobs:           jobs,
 		Summary:        summary,
+		Client:         wealthsimple.Stats(conn.ID),
 	}, nil
 }
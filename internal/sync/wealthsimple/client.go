@@ -6,11 +6,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"time"
+
+	"money/internal/logger"
 )
 
+// syncLog logs under the sync module
+var syncLog = logger.Module("sync")
+
 const (
 	// Base URLs
 	apiBaseURL = "https://api.production.wealthsimple.com"
@@ -31,15 +35,18 @@ type Client struct {
 	sessionID     string
 	appInstanceID string
 	accessToken   string
+	connectionID  string // Keys the circuit breaker and stats; empty disables the breaker
+	retryPolicy   RetryPolicy
 }
 
 // NewClient creates a new Wealthsimple client
 func NewClient(deviceID, sessionID, appInstanceID string) *Client {
 	return &Client{
-		httpClient:    &http.Client{Timeout: 30 * time.Second},
+		httpClient:    &http.Client{Timeout: 30 * time.Second, Transport: transport},
 		deviceID:      deviceID,
 		sessionID:     sessionID,
 		appInstanceID: appInstanceID,
+		retryPolicy:   DefaultRetryPolicy,
 	}
 }
 
@@ -48,6 +55,12 @@ func (c *Client) SetAccessToken(token string) {
 	c.accessToken = token
 }
 
+// SetConnectionID ties the client to a sync connection, whose circuit breaker and stats
+// then cover its GraphQL calls
+func (c *Client) SetConnectionID(connectionID string) {
+	c.connectionID = connectionID
+}
+
 // LoginRequest represents the OAuth login request
 type LoginRequest struct {
 	GrantType    string `json:"grant_type"`
@@ -318,39 +331,33 @@ func (c *Client) QueryGraphQL(ctx context.Context, query string, variables map[s
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", gqlBaseURL, bytes.NewBuffer(body))
-	if err != nil {
-		return nil, err
-	}
-
-	c.setCommonHeaders(req)
-	req.Header.Set("Authorization", "Bearer "+c.accessToken)
-	req.Header.Set("x-ws-client-library", "gql-sdk")
-	req.Header.Set("x-ws-profile", profile)
+	bodyBytes, err := c.doWithRetry(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", gqlBaseURL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
 
-	resp, err := c.httpClient.Do(req)
+		c.setCommonHeaders(req)
+		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+		req.Header.Set("x-ws-client-library", "gql-sdk")
+		req.Header.Set("x-ws-profile", profile)
+		return req, nil
+	})
 	if err != nil {
+		syncLog.Printf("ERROR: graphql query failed: %v", err)
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	bodyBytes, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("ERROR: graphql query failed: status=%d response=%s", resp.StatusCode, string(bodyBytes))
-		return nil, fmt.Errorf("GraphQL query failed (status %d): %s", resp.StatusCode, string(bodyBytes))
-	}
 
-	log.Printf("DEBUG: graphql response: %s", string(bodyBytes))
+	syncLog.Printf("DEBUG: graphql response: %s", string(bodyBytes))
 
 	var gqlResp GraphQLResponse
 	if err := json.Unmarshal(bodyBytes, &gqlResp); err != nil {
-		log.Printf("ERROR: failed to decode graphql response: %v", err)
+		syncLog.Printf("ERROR: failed to decode graphql response: %v", err)
 		return nil, err
 	}
 
 	if len(gqlResp.Errors) > 0 {
-		log.Printf("ERROR: graphql query returned errors: %v", gqlResp.Errors)
+		syncLog.Printf("ERROR: graphql query returned errors: %v", gqlResp.Errors)
 		return nil, fmt.Errorf("GraphQL errors: %v", gqlResp.Errors)
 	}
 
@@ -0,0 +1,253 @@
+package wealthsimple
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls how transient GraphQL failures are retried
+type RetryPolicy struct {
+	MaxAttempts int           // Including the first attempt
+	BaseDelay   time.Duration // Wait before the first retry, doubled for each one after
+	MaxDelay    time.Duration // Cap on a single wait, including a server's Retry-After
+}
+
+// DefaultRetryPolicy retries up to three times, waiting about 0.5s, 1s and 2s
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// Circuit breaker settings, applied per connection
+const (
+	breakerThreshold = 5               // Consecutive failed calls that open the circuit
+	breakerCooldown  = 2 * time.Minute // How long an open circuit refuses calls
+)
+
+// ErrCircuitOpen is returned without calling Wealthsimple while a connection's circuit is open
+var ErrCircuitOpen = errors.New("wealthsimple is failing for this connection, retry later")
+
+// ConnectionStats counts GraphQL traffic for a connection since the server started
+type ConnectionStats struct {
+	Requests    int64      `json:"requests"`     // GraphQL calls made
+	Retries     int64      `json:"retries"`      // Extra attempts after a transient failure
+	RateLimited int64      `json:"rate_limited"` // Responses with status 429
+	Failures    int64      `json:"failures"`     // Calls still failing after every retry
+	Rejected    int64      `json:"rejected"`     // Calls refused while the circuit was open
+	CircuitOpen bool       `json:"circuit_open"`
+	OpenUntil   *time.Time `json:"open_until,omitempty"`
+}
+
+// breaker tracks consecutive failures and traffic for one connection
+type breaker struct {
+	mu                  sync.Mutex
+	consecutiveFailures int
+	openUntil           time.Time
+	stats               ConnectionStats
+}
+
+// breakers holds each connection's breaker, keyed by connection ID
+var breakers = struct {
+	mu           sync.Mutex
+	byConnection map[string]*breaker
+}{byConnection: make(map[string]*breaker)}
+
+// breakerFor returns a connection's breaker, or nil for a client with no connection ID
+func breakerFor(connectionID string) *breaker {
+	if connectionID == "" {
+		return nil
+	}
+	breakers.mu.Lock()
+	defer breakers.mu.Unlock()
+	b, ok := breakers.byConnection[connectionID]
+	if !ok {
+		b = &breaker{}
+		breakers.byConnection[connectionID] = b
+	}
+	return b
+}
+
+// Stats returns the GraphQL traffic counters for a connection
+func Stats(connectionID string) ConnectionStats {
+	b := breakerFor(connectionID)
+	if b == nil {
+		return ConnectionStats{}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := b.stats
+	if now := time.Now(); now.Before(b.openUntil) {
+		openUntil := b.openUntil
+		stats.CircuitOpen = true
+		stats.OpenUntil = &openUntil
+	}
+	return stats
+}
+
+// allow reports whether a call may go ahead. Once the cooldown passes the circuit lets
+// calls through again, but a single further failure reopens it.
+func (b *breaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if time.Now().Before(b.openUntil) {
+		b.stats.Rejected++
+		return ErrCircuitOpen
+	}
+	b.stats.Requests++
+	return nil
+}
+
+// count adds to a connection's counters
+func (b *breaker) count(update func(stats *ConnectionStats)) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	update(&b.stats)
+}
+
+// record notes the outcome of a call. Only transient failures count toward opening the
+// circuit, since a rejected token or bad query says nothing about Wealthsimple's health.
+func (b *breaker) record(transientFailure bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !transientFailure {
+		b.consecutiveFailures = 0
+		return
+	}
+	b.stats.Failures++
+	b.consecutiveFailures++
+	if b.consecutiveFailures >= breakerThreshold {
+		b.openUntil = time.Now().Add(breakerCooldown)
+		b.consecutiveFailures = breakerThreshold - 1
+		syncLog.Printf("WARN: wealthsimple circuit opened: until=%s", b.openUntil.Format(time.RFC3339))
+	}
+}
+
+// retryableStatus reports whether a response status is worth retrying
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter reads a Retry-After header given in seconds or as an HTTP date
+func retryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return time.Until(at), true
+	}
+	return 0, false
+}
+
+// backoff returns the wait before a retry, doubling per attempt with up to 20% jitter
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/5+1))
+}
+
+// statusError is a non-200 response from Wealthsimple
+type statusError struct {
+	status int
+	body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("GraphQL query failed (status %d): %s", e.status, e.body)
+}
+
+// doWithRetry sends the request built by newRequest and returns the body of a 200 response.
+// Network errors and 429/5xx responses are retried with exponential backoff, waiting as
+// long as a Retry-After header asks within the policy's MaxDelay. Calls are refused while
+// the connection's circuit is open.
+func (c *Client) doWithRetry(ctx context.Context, newRequest func() (*http.Request, error)) ([]byte, error) {
+	b := breakerFor(c.connectionID)
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		var wait time.Duration
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			lastErr = err
+		} else {
+			body, readErr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			switch {
+			case readErr != nil:
+				lastErr = fmt.Errorf("failed to read GraphQL response: %w", readErr)
+			case resp.StatusCode == http.StatusOK:
+				b.record(false)
+				return body, nil
+			case !retryableStatus(resp.StatusCode):
+				b.record(false)
+				return nil, &statusError{status: resp.StatusCode, body: string(body)}
+			default:
+				lastErr = &statusError{status: resp.StatusCode, body: string(body)}
+				if resp.StatusCode == http.StatusTooManyRequests {
+					b.count(func(stats *ConnectionStats) { stats.RateLimited++ })
+				}
+				if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+					wait = d
+				}
+			}
+		}
+
+		if attempt >= c.retryPolicy.MaxAttempts {
+			break
+		}
+		if wait <= 0 {
+			wait = c.retryPolicy.backoff(attempt)
+		}
+		if wait > c.retryPolicy.MaxDelay {
+			wait = c.retryPolicy.MaxDelay
+		}
+		syncLog.Printf("WARN: retrying graphql call: attempt=%d wait=%s error=%v", attempt+1, wait, lastErr)
+		b.count(func(stats *ConnectionStats) { stats.Retries++ })
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+
+	b.record(true)
+	return nil, lastErr
+}
@@ -0,0 +1,396 @@
+package wealthsimple
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// transport carries every client's requests; nil uses the real network
+var transport http.RoundTripper
+
+// sandboxIdentityID is the identity the sandbox logs every user in as
+const sandboxIdentityID = "identity-sandbox"
+
+// SandboxConfig describes the brokerage the sandbox simulates
+type SandboxConfig struct {
+	Accounts     []SandboxAccount `json:"accounts"`
+	OTPCode      string           `json:"otp_code"`      // One-time code the sandbox accepts
+	LatencyMS    int              `json:"latency_ms"`    // Delay added to every GraphQL call
+	FailureRate  float64          `json:"failure_rate"`  // Share of GraphQL calls answered with a 503, 0 to 1
+	BalanceDrift float64          `json:"balance_drift"` // Largest relative move in a balance between calls, e.g. 0.01
+}
+
+// SandboxAccount is a simulated brokerage account
+type SandboxAccount struct {
+	ID        string            `json:"id"`
+	Nickname  string            `json:"nickname"`
+	Type      string            `json:"type"` // Wealthsimple account type, e.g. tfsa, non_registered, ca_credit_card
+	Currency  string            `json:"currency"`
+	Status    string            `json:"status"` // Defaults to open
+	Balance   float64           `json:"balance"`
+	Positions []SandboxPosition `json:"positions"`
+	// InterestRate is the annual rate a cash account earns, paid at each month end
+	InterestRate float64 `json:"interest_rate"`
+}
+
+// SandboxPosition is a simulated holding in a sandbox account
+type SandboxPosition struct {
+	Symbol       string  `json:"symbol"`
+	Name         string  `json:"name"`
+	SecurityType string  `json:"security_type"` // e.g. equity, etf, crypto
+	Quantity     float64 `json:"quantity"`
+	AveragePrice float64 `json:"average_price"`
+}
+
+// DefaultSandboxConfig simulates a TFSA, a non-registered account, a cash account earning
+// interest and a credit card
+func DefaultSandboxConfig() *SandboxConfig {
+	return &SandboxConfig{
+		OTPCode:      "123456",
+		LatencyMS:    200,
+		BalanceDrift: 0.01,
+		Accounts: []SandboxAccount{
+			{
+				ID: "sandbox-tfsa", Nickname: "Sandbox TFSA", Type: "tfsa", Currency: "CAD", Balance: 25430.12,
+				Positions: []SandboxPosition{
+					{Symbol: "XEQT", Name: "iShares Core Equity ETF Portfolio", SecurityType: "etf", Quantity: 620, AveragePrice: 27.85},
+					{Symbol: "VFV", Name: "Vanguard S&P 500 Index ETF", SecurityType: "etf", Quantity: 55, AveragePrice: 118.40},
+				},
+			},
+			{
+				ID: "sandbox-personal", Nickname: "Sandbox Personal", Type: "non_registered", Currency: "USD", Balance: 8120.55,
+				Positions: []SandboxPosition{
+					{Symbol: "AAPL", Name: "Apple Inc.", SecurityType: "equity", Quantity: 20, AveragePrice: 172.10},
+					{Symbol: "BTC", Name: "Bitcoin", SecurityType: "crypto", Quantity: 0.05, AveragePrice: 61000},
+				},
+			},
+			{ID: "sandbox-cash", Nickname: "Sandbox Cash", Type: "ca_cash", Currency: "CAD", Balance: 12500, InterestRate: 0.0275},
+			{ID: "sandbox-card", Nickname: "Sandbox Visa", Type: "ca_credit_card", Currency: "CAD", Balance: 1250.40},
+		},
+	}
+}
+
+// LoadSandboxConfig reads a sandbox configuration from a JSON file, or returns the default
+// configuration when path is empty
+func LoadSandboxConfig(path string) (*SandboxConfig, error) {
+	if path == "" {
+		return DefaultSandboxConfig(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sandbox config: %w", err)
+	}
+	cfg := &SandboxConfig{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse sandbox config: %w", err)
+	}
+	if cfg.OTPCode == "" {
+		cfg.OTPCode = DefaultSandboxConfig().OTPCode
+	}
+	if cfg.FailureRate < 0 || cfg.FailureRate > 1 {
+		return nil, fmt.Errorf("sandbox failure_rate must be between 0 and 1")
+	}
+	for _, acc := range cfg.Accounts {
+		if acc.ID == "" || acc.Type == "" {
+			return nil, fmt.Errorf("sandbox accounts need an id and type")
+		}
+	}
+	return cfg, nil
+}
+
+// EnableSandbox answers every Wealthsimple request from a simulated brokerage instead of
+// the real API, so the whole sync pipeline can run locally without real credentials. Any
+// username and password log in, followed by the configured one-time code.
+func EnableSandbox(cfg *SandboxConfig) {
+	balances := make(map[string]float64, len(cfg.Accounts))
+	for _, acc := range cfg.Accounts {
+		balances[acc.ID] = acc.Balance
+	}
+	transport = &sandbox{cfg: cfg, balances: balances}
+}
+
+// sandbox is an http.RoundTripper simulating the Wealthsimple API
+type sandbox struct {
+	cfg      *SandboxConfig
+	mu       sync.Mutex
+	balances map[string]float64 // Current balance per account, drifting between calls
+}
+
+// RoundTrip routes a request to the simulated OAuth or GraphQL endpoint
+func (s *sandbox) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case req.URL.Path == "/v1/oauth/v2/token" && req.Method == http.MethodPost:
+		return s.token(req, body)
+	case req.URL.Path == "/v1/oauth/v2/token/info":
+		return sandboxJSON(req, http.StatusOK, map[string]interface{}{
+			"resource_owner_id":     sandboxIdentityID,
+			"expires_in":            1800,
+			"identity_canonical_id": sandboxIdentityID,
+		})
+	case req.URL.Host == "my.wealthsimple.com" && req.URL.Path == "/graphql":
+		return s.graphQL(req, body)
+	}
+	return sandboxJSON(req, http.StatusNotFound, map[string]string{"error": "sandbox: unknown endpoint"})
+}
+
+// token simulates logging in, one-time code verification and token refresh
+func (s *sandbox) token(req *http.Request, body []byte) (*http.Response, error) {
+	var login struct {
+		GrantType string `json:"grant_type"`
+		Username  string `json:"username"`
+	}
+	if err := json.Unmarshal(body, &login); err != nil {
+		return sandboxJSON(req, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+	}
+
+	email := login.Username
+	if login.GrantType == "password" {
+		otp := req.Header.Get("x-wealthsimple-otp")
+		if otp == "" {
+			resp, err := sandboxJSON(req, http.StatusUnauthorized, map[string]string{"error": "otp_required"})
+			resp.Header.Set("x-wealthsimple-otp-required", "true")
+			resp.Header.Set("x-wealthsimple-otp-authenticated-claim", "sandbox-claim")
+			resp.Header.Set("x-wealthsimple-otp-options", "sandbox")
+			return resp, err
+		}
+		if strings.SplitN(otp, ";", 2)[0] != s.cfg.OTPCode {
+			return sandboxJSON(req, http.StatusUnauthorized, map[string]string{"error": "invalid one-time code"})
+		}
+	} else if login.GrantType != "refresh_token" {
+		return sandboxJSON(req, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+	}
+	if email == "" {
+		email = "sandbox@example.com"
+	}
+
+	return sandboxJSON(req, http.StatusOK, &TokenResponse{
+		AccessToken:         "sandbox-access-" + uuid.New().String(),
+		RefreshToken:        "sandbox-refresh-" + uuid.New().String(),
+		TokenType:           "Bearer",
+		ExpiresIn:           1800,
+		Email:               email,
+		IdentityCanonicalID: sandboxIdentityID,
+		Profiles: map[string]map[string]string{
+			"trade":  {"default": "sandbox-trade"},
+			"invest": {"default": "sandbox-invest"},
+		},
+	})
+}
+
+// graphQL answers the queries the sync worker makes, after the configured latency and
+// with the configured share of simulated outages
+func (s *sandbox) graphQL(req *http.Request, body []byte) (*http.Response, error) {
+	if s.cfg.LatencyMS > 0 {
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(time.Duration(s.cfg.LatencyMS) * time.Millisecond):
+		}
+	}
+	if s.cfg.FailureRate > 0 && rand.Float64() < s.cfg.FailureRate {
+		resp, err := sandboxJSON(req, http.StatusServiceUnavailable, map[string]string{"error": "sandbox: simulated outage"})
+		resp.Header.Set("Retry-After", "1")
+		return resp, err
+	}
+
+	var gql GraphQLRequest
+	if err := json.Unmarshal(body, &gql); err != nil {
+		return sandboxJSON(req, http.StatusBadRequest, map[string]string{"error": "invalid GraphQL request"})
+	}
+
+	var data interface{}
+	switch gql.Query {
+	case QueryListAccounts:
+		data = s.listAccounts()
+	case QueryFetchAccountDetails:
+		data = s.accountDetails(stringList(gql.Variables["ids"]))
+	case QueryFetchAccountPositions:
+		data = s.positions(stringList(gql.Variables["accountIds"]))
+	case QueryFetchCreditCardAccount:
+		id, _ := gql.Variables["id"].(string)
+		data = s.creditCard(id)
+	case QueryFetchAccountActivities:
+		id, _ := gql.Variables["accountId"].(string)
+		data = s.activities(id, time.Now())
+	default:
+		return sandboxJSON(req, http.StatusOK, map[string]interface{}{
+			"data":   nil,
+			"errors": []map[string]string{{"message": "sandbox: query not simulated"}},
+		})
+	}
+	return sandboxJSON(req, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+// listAccounts returns identity.accounts.edges
+func (s *sandbox) listAccounts() interface{} {
+	edges := make([]interface{}, 0, len(s.cfg.Accounts))
+	for _, acc := range s.cfg.Accounts {
+		status := acc.Status
+		if status == "" {
+			status = "open"
+		}
+		edges = append(edges, map[string]interface{}{"node": map[string]interface{}{
+			"id":       acc.ID,
+			"nickname": acc.Nickname,
+			"type":     acc.Type,
+			"currency": acc.Currency,
+			"status":   status,
+		}})
+	}
+	return map[string]interface{}{
+		"identity": map[string]interface{}{"accounts": map[string]interface{}{"edges": edges}},
+	}
+}
+
+// accountDetails returns accounts[].financials with each account's current balance
+func (s *sandbox) accountDetails(ids []string) interface{} {
+	accounts := make([]interface{}, 0, len(ids))
+	for _, acc := range s.accounts(ids) {
+		amount := map[string]interface{}{"amount": s.nextBalance(acc.ID), "currency": acc.Currency}
+		accounts = append(accounts, map[string]interface{}{
+			"id": acc.ID,
+			"financials": map[string]interface{}{
+				"currentCombined": map[string]interface{}{"netLiquidationValueV2": amount},
+			},
+		})
+	}
+	return map[string]interface{}{"accounts": accounts}
+}
+
+// positions returns identity.financials.current.positions.edges for the given accounts
+func (s *sandbox) positions(ids []string) interface{} {
+	edges := make([]interface{}, 0)
+	for _, acc := range s.accounts(ids) {
+		for _, p := range acc.Positions {
+			edges = append(edges, map[string]interface{}{"node": map[string]interface{}{
+				"quantity": strconv.FormatFloat(p.Quantity, 'f', -1, 64),
+				"security": map[string]interface{}{
+					"securityType": p.SecurityType,
+					"stock":        map[string]interface{}{"symbol": p.Symbol, "name": p.Name},
+				},
+				"averagePrice": map[string]interface{}{"amount": strconv.FormatFloat(p.AveragePrice, 'f', 2, 64)},
+			}})
+		}
+	}
+	return map[string]interface{}{
+		"identity": map[string]interface{}{"financials": map[string]interface{}{
+			"current": map[string]interface{}{"positions": map[string]interface{}{"edges": edges}},
+		}},
+	}
+}
+
+// creditCard returns creditCardAccount.balance with the amount owed on the card
+func (s *sandbox) creditCard(id string) interface{} {
+	accounts := s.accounts([]string{id})
+	if len(accounts) == 0 {
+		return map[string]interface{}{"creditCardAccount": nil}
+	}
+	return map[string]interface{}{"creditCardAccount": map[string]interface{}{
+		"id":      id,
+		"balance": map[string]interface{}{"outstanding": s.nextBalance(id)},
+	}}
+}
+
+// activities returns account.activities with an interest payment at each of the last
+// three month ends for accounts earning interest, all on one page
+func (s *sandbox) activities(id string, now time.Time) interface{} {
+	edges := make([]interface{}, 0)
+	for _, acc := range s.accounts([]string{id}) {
+		if acc.InterestRate <= 0 {
+			continue
+		}
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < 3; i++ {
+			paidAt := monthStart.AddDate(0, -i, 0).Add(-time.Hour)
+			amount := acc.Balance * acc.InterestRate / 12
+			edges = append(edges, map[string]interface{}{"node": map[string]interface{}{
+				"id":          fmt.Sprintf("%s-interest-%s", acc.ID, paidAt.Format("2006-01")),
+				"type":        "INTEREST",
+				"status":      "settled",
+				"marketValue": map[string]interface{}{"amount": strconv.FormatFloat(amount, 'f', 2, 64), "currency": acc.Currency},
+				"acceptedAt":  paidAt.Format(time.RFC3339),
+				"settledAt":   paidAt.Format(time.RFC3339),
+				"description": "Interest",
+			}})
+		}
+	}
+	return map[string]interface{}{"account": map[string]interface{}{"activities": map[string]interface{}{
+		"edges":    edges,
+		"pageInfo": map[string]interface{}{"hasNextPage": false, "endCursor": nil},
+	}}}
+}
+
+// accounts returns the configured accounts with the given IDs
+func (s *sandbox) accounts(ids []string) []SandboxAccount {
+	result := make([]SandboxAccount, 0, len(ids))
+	for _, id := range ids {
+		for _, acc := range s.cfg.Accounts {
+			if acc.ID == id {
+				result = append(result, acc)
+			}
+		}
+	}
+	return result
+}
+
+// nextBalance moves an account's balance by up to BalanceDrift and returns it as the API
+// formats amounts
+func (s *sandbox) nextBalance(id string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	balance := s.balances[id]
+	if s.cfg.BalanceDrift > 0 {
+		balance *= 1 + (rand.Float64()*2-1)*s.cfg.BalanceDrift
+		s.balances[id] = balance
+	}
+	return strconv.FormatFloat(balance, 'f', 2, 64)
+}
+
+// stringList reads a GraphQL list variable of strings
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// sandboxJSON builds a JSON response to a request
+func sandboxJSON(req *http.Request, status int, body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+		Request:    req,
+	}, nil
+}
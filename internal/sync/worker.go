@@ -2,20 +2,31 @@ package sync
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"log"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"money/internal/account"
+	"money/internal/auth"
 	"money/internal/balance"
+	"money/internal/database"
 	"money/internal/holdings"
 	"money/internal/sync/wealthsimple"
 )
 
 // performInitialSync performs the initial sync of accounts from Wealthsimple
 func (s *Service) performInitialSync(ctx context.Context, userID, connectionID string) error {
+	// Syncs run in the background, so the account and balance services need the user set here
+	ctx = auth.WithUserID(ctx, userID)
+
+	// Shutdown cancels ctx. The account in progress still finishes, then the run stops between
+	// accounts and keeps its checkpoint so the next start resumes from there.
+	stopping := ctx.Done()
+	ctx = context.WithoutCancel(ctx)
+
 	// Update connection status to syncing
 	_, err := s.db.ExecContext(ctx, `
 		UPDATE sync_credentials
@@ -29,7 +40,7 @@ func (s *Service) performInitialSync(ctx context.Context, userID, connectionID s
 	// Defer function to update connection status on error
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("PANIC: sync panic recovered: connection_id=%s panic=%v", connectionID, r)
+			syncLog.Printf("PANIC: sync panic recovered: connection_id=%s panic=%v", connectionID, r)
 			_ = s.UpdateConnectionError(ctx, connectionID, fmt.Sprintf("sync panic: %v", r))
 		}
 	}()
@@ -56,180 +67,135 @@ func (s *Service) performInitialSync(ctx context.Context, userID, connectionID s
 		return fmt.Errorf("%s", errMsg)
 	}
 
-	// Fetch accounts from Wealthsimple
-	log.Printf("INFO: fetching accounts from wealthsimple: connection_id=%s identity_id=%s",
-		connectionID, identityID)
-	variables := map[string]interface{}{
-		"identityId": identityID,
-	}
-	data, err := client.QueryGraphQL(ctx, wealthsimple.QueryListAccounts, variables, "trade")
+	// Accounts already synced by an interrupted run are skipped
+	done, err := s.loadSyncCheckpoint(ctx, connectionID)
 	if err != nil {
-		log.Printf("ERROR: failed to fetch accounts: %v", err)
-		errMsg := fmt.Sprintf("failed to fetch accounts: %v", err)
-		_ = s.UpdateConnectionError(ctx, connectionID, errMsg)
-		return fmt.Errorf("%s", errMsg)
+		_ = s.UpdateConnectionError(ctx, connectionID, err.Error())
+		return err
 	}
 
-	// Parse accounts from identity.accounts.edges structure
-	identity, ok := data["identity"].(map[string]interface{})
-	if !ok {
-		errMsg := "invalid response format: no identity field"
+	// New provider accounts get local accounts automatically only under auto selection,
+	// and never once the user skipped them
+	var selection AccountSelection
+	err = s.db.QueryRowContext(ctx, `
+		SELECT account_selection FROM sync_credentials WHERE id = $1
+	`, connectionID).Scan(&selection)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to get account selection: %v", err)
 		_ = s.UpdateConnectionError(ctx, connectionID, errMsg)
 		return fmt.Errorf("%s", errMsg)
 	}
-
-	accountsData, ok := identity["accounts"].(map[string]interface{})
-	if !ok {
-		errMsg := "invalid response format: no accounts field"
-		_ = s.UpdateConnectionError(ctx, connectionID, errMsg)
-		return fmt.Errorf("%s", errMsg)
+	skipped, err := s.skippedProviderAccounts(ctx, connectionID)
+	if err != nil {
+		_ = s.UpdateConnectionError(ctx, connectionID, err.Error())
+		return err
 	}
 
-	edges, ok := accountsData["edges"].([]interface{})
-	if !ok {
-		errMsg := "invalid response format: no edges field"
-		_ = s.UpdateConnectionError(ctx, connectionID, errMsg)
-		return fmt.Errorf("%s", errMsg)
+	// Fetch accounts from Wealthsimple
+	syncLog.Printf("INFO: fetching accounts from wealthsimple: connection_id=%s identity_id=%s",
+		connectionID, identityID)
+	providerAccounts, err := s.fetchProviderAccounts(ctx, client, identityID)
+	if err != nil {
+		_ = s.UpdateConnectionError(ctx, connectionID, err.Error())
+		return err
 	}
 
-	log.Printf("INFO: processing account edges: count=%d", len(edges))
+	syncLog.Printf("INFO: processing provider accounts: count=%d", len(providerAccounts))
 
 	accountCount := 0
-	for _, edge := range edges {
-		edgeMap, ok := edge.(map[string]interface{})
-		if !ok {
-			log.Printf("DEBUG: skipping non-map edge: %v", edge)
-			continue
+	for _, providerAccount := range providerAccounts {
+		select {
+		case <-stopping:
+			return errSyncInterrupted
+		default:
 		}
 
-		accountNode, ok := edgeMap["node"].(map[string]interface{})
-		if !ok {
-			log.Printf("DEBUG: skipping edge with no node: %v", edgeMap)
-			continue
-		}
+		providerAccountID := providerAccount.ID
+		syncLog.Printf("INFO: processing account: provider_id=%s nickname=%s type=%s currency=%s status=%s",
+			providerAccountID, providerAccount.Nickname, providerAccount.Type, providerAccount.Currency, providerAccount.Status)
 
-		// Extract account data
-		providerAccountID, _ := accountNode["id"].(string)
-		nickname, _ := accountNode["nickname"].(string)
-		accountType, _ := accountNode["type"].(string)
-		currency, _ := accountNode["currency"].(string)
-		status, _ := accountNode["status"].(string)
-
-		log.Printf("INFO: processing account: provider_id=%s nickname=%s type=%s currency=%s status=%s",
-			providerAccountID, nickname, accountType, currency, status)
-
-		if status != "open" {
-			log.Printf("INFO: skipping closed account: provider_id=%s status=%s", providerAccountID, status)
+		if providerAccount.Status != "open" {
+			syncLog.Printf("INFO: skipping closed account: provider_id=%s status=%s", providerAccountID, providerAccount.Status)
 			continue // Skip closed accounts
 		}
 
-		// Map Wealthsimple account type to local type
-		localAccountType := mapWealthsimpleAccountType(accountType)
-
-		// Use account type as name if nickname is empty
-		if nickname == "" {
-			nickname = formatAccountTypeName(localAccountType)
+		if done[providerAccountID] {
+			syncLog.Printf("INFO: skipping account synced before the interruption: provider_id=%s", providerAccountID)
+			accountCount++
+			continue
 		}
 
+		// Map Wealthsimple account type to local type
+		localAccountType := mapWealthsimpleAccountType(providerAccount.Type)
+
 		// Check if this account is already synced
 		var localAccountID string
 		var syncedAccountID string
+		var pausedAt sql.NullTime
 		err = s.db.QueryRowContext(ctx, `
-			SELECT id, local_account_id
+			SELECT id, local_account_id, paused_at
 			FROM synced_accounts
 			WHERE credential_id = $1 AND provider_account_id = $2
-		`, connectionID, providerAccountID).Scan(&syncedAccountID, &localAccountID)
+		`, connectionID, providerAccountID).Scan(&syncedAccountID, &localAccountID, &pausedAt)
 
-		if err == nil {
+		if err == nil && pausedAt.Valid {
+			syncLog.Printf("INFO: skipping paused account: provider_account_id=%s local_account_id=%s",
+				providerAccountID, localAccountID)
+			accountCount++
+			continue
+		} else if err == nil {
 			// Account already exists, sync details
-			log.Printf("INFO: account already synced, will update details: provider_account_id=%s local_account_id=%s synced_account_id=%s",
+			syncLog.Printf("INFO: account already synced, will update details: provider_account_id=%s local_account_id=%s synced_account_id=%s",
 				providerAccountID, localAccountID, syncedAccountID)
+		} else if skipped[providerAccountID] {
+			syncLog.Printf("INFO: skipping account the user chose not to sync: provider_account_id=%s", providerAccountID)
+			continue
+		} else if selection == AccountSelectionManual {
+			syncLog.Printf("INFO: skipping account awaiting selection: provider_account_id=%s", providerAccountID)
+			continue
 		} else {
 			// Account doesn't exist, create it
-			// Determine if this is an asset or liability account
-			isAsset := isAssetAccount(localAccountType)
-			institution := "Wealthsimple"
-
-			createdAccount, err := s.accountSvc.Create(ctx, &account.CreateAccountRequest{
-				Name:         nickname,
-				Type:         account.AccountType(localAccountType),
-				Currency:     account.Currency(mapCurrency(currency)),
-				Institution:  &institution,
-				IsAsset:      isAsset,
-				IsSynced:     true,
-				ConnectionID: connectionID,
-			})
-
-			if err != nil {
-				log.Printf("ERROR: failed to create local account: provider_account_id=%s error=%v",
-					providerAccountID, err)
-				continue
-			}
-
-			localAccountID = createdAccount.ID
-
-			// Create synced account record and get its ID
-			syncedAccountID = uuid.New().String()
-			now := time.Now()
-			_, err = s.db.ExecContext(ctx, `
-				INSERT INTO synced_accounts (
-					id, credential_id, local_account_id, provider_account_id,
-					created_at, updated_at
-				) VALUES ($1, $2, $3, $4, $5, $6)
-			`, syncedAccountID, connectionID, localAccountID, providerAccountID, now, now)
-
+			localAccountID, syncedAccountID, err = s.linkProviderAccount(ctx, connectionID, providerAccount,
+				providerAccount.proposedName(), localAccountType)
 			if err != nil {
-				log.Printf("ERROR: failed to create synced account: provider_account_id=%s error=%v",
-					providerAccountID, err)
+				syncLog.Printf("ERROR: %v: provider_account_id=%s", err, providerAccountID)
 				continue
 			}
-
-			log.Printf("INFO: created new synced account: provider_account_id=%s local_account_id=%s synced_account_id=%s",
-				providerAccountID, localAccountID, syncedAccountID)
 		}
 
 		// Create a sync job for this account
-		log.Printf("INFO: creating sync job: synced_account_id=%s", syncedAccountID)
+		syncLog.Printf("INFO: creating sync job: synced_account_id=%s", syncedAccountID)
 		jobID, err := s.createSyncJob(ctx, syncedAccountID, SyncJobTypeFull)
 		if err != nil {
-			log.Printf("ERROR: failed to create sync job: synced_account_id=%s error=%v",
+			syncLog.Printf("ERROR: failed to create sync job: synced_account_id=%s error=%v",
 				syncedAccountID, err)
 			continue
 		}
-		log.Printf("INFO: created sync job: job_id=%s synced_account_id=%s", jobID, syncedAccountID)
+		syncLog.Printf("INFO: created sync job: job_id=%s synced_account_id=%s", jobID, syncedAccountID)
 
 		// Fetch account details (balances, positions)
 		isAssetAcc := isAssetAccount(localAccountType)
-		isCreditCard := localAccountType == "credit_card"
-		log.Printf("INFO: syncing account details: provider_account_id=%s local_account_id=%s is_asset=%v is_credit_card=%v",
-			providerAccountID, localAccountID, isAssetAcc, isCreditCard)
+		syncLog.Printf("INFO: syncing account details: provider_account_id=%s local_account_id=%s type=%s is_asset=%v",
+			providerAccountID, localAccountID, localAccountType, isAssetAcc)
 
-		if err := s.syncAccountDetails(ctx, client, providerAccountID, localAccountID, identityID, isAssetAcc, isCreditCard, jobID); err != nil {
-			log.Printf("ERROR: failed to sync account details: provider_account_id=%s local_account_id=%s error=%v",
+		if err := s.syncAccountDetails(ctx, client, userID, syncedAccountID, providerAccountID, localAccountID, identityID, localAccountType, isAssetAcc, jobID); err != nil {
+			syncLog.Printf("ERROR: failed to sync account details: provider_account_id=%s local_account_id=%s error=%v",
 				providerAccountID, localAccountID, err)
-			_ = s.completeSyncJob(ctx, jobID, SyncJobStatusFailed, err.Error())
+			_ = s.completeSyncJob(ctx, s.db, jobID, SyncJobStatusFailed, err.Error())
 		} else {
-			log.Printf("INFO: successfully synced account details: provider_account_id=%s local_account_id=%s",
+			syncLog.Printf("INFO: successfully synced account details: provider_account_id=%s local_account_id=%s",
 				providerAccountID, localAccountID)
-			_ = s.completeSyncJob(ctx, jobID, SyncJobStatusCompleted, "")
-
-			// Update synced_account timestamps
-			_, err = s.db.ExecContext(ctx, `
-				UPDATE synced_accounts
-				SET last_sync_at = CURRENT_TIMESTAMP,
-				    updated_at = CURRENT_TIMESTAMP
-				WHERE id = $1
-			`, syncedAccountID)
-			if err != nil {
-				log.Printf("ERROR: failed to update synced_account timestamps: synced_account_id=%s error=%v",
-					syncedAccountID, err)
+
+			done[providerAccountID] = true
+			if err := s.saveSyncCheckpoint(ctx, connectionID, done); err != nil {
+				syncLog.Printf("ERROR: %v: connection_id=%s", err, connectionID)
 			}
 		}
 
 		accountCount++
 	}
 
-	log.Printf("INFO: finished processing accounts: total_accounts=%d connection_id=%s", accountCount, connectionID)
+	syncLog.Printf("INFO: finished processing accounts: total_accounts=%d connection_id=%s", accountCount, connectionID)
 
 	// Update connection with success
 	_, err = s.db.ExecContext(ctx, `
@@ -238,6 +204,7 @@ func (s *Service) performInitialSync(ctx context.Context, userID, connectionID s
 		    account_count = $2,
 		    last_sync_at = $3,
 		    last_sync_error = NULL,
+		    sync_checkpoint = NULL,
 		    updated_at = $4
 		WHERE id = $5
 	`, StatusConnected, accountCount, time.Now(), time.Now(), connectionID)
@@ -245,37 +212,38 @@ func (s *Service) performInitialSync(ctx context.Context, userID, connectionID s
 	return err
 }
 
-// syncAccountDetails fetches and stores account balances and positions
-func (s *Service) syncAccountDetails(ctx context.Context, client *wealthsimple.Client, providerAccountID, localAccountID, identityID string, isAsset, isCreditCard bool, jobID string) error {
+// syncAccountDetails fetches account balances and positions, and interest paid into cash
+// accounts, then stores them in a single transaction that also completes the sync job
+func (s *Service) syncAccountDetails(ctx context.Context, client *wealthsimple.Client, userID, syncedAccountID, providerAccountID, localAccountID, identityID, accountType string, isAsset bool, jobID string) error {
 	// Credit cards use a different GraphQL endpoint
-	if isCreditCard {
-		return s.syncCreditCardDetails(ctx, client, providerAccountID, localAccountID, isAsset, jobID)
+	if accountType == "credit_card" {
+		return s.syncCreditCardDetails(ctx, client, userID, syncedAccountID, providerAccountID, localAccountID, isAsset, jobID)
 	}
 
 	variables := map[string]interface{}{
 		"ids": []string{providerAccountID},
 	}
 
-	log.Printf("INFO: fetching account details: provider_account_id=%s local_account_id=%s",
+	syncLog.Printf("INFO: fetching account details: provider_account_id=%s local_account_id=%s",
 		providerAccountID, localAccountID)
 
 	data, err := client.QueryGraphQL(ctx, wealthsimple.QueryFetchAccountDetails, variables, "trade")
 	if err != nil {
-		log.Printf("ERROR: failed to fetch account details: provider_account_id=%s error=%v",
+		syncLog.Printf("ERROR: failed to fetch account details: provider_account_id=%s error=%v",
 			providerAccountID, err)
 		return fmt.Errorf("failed to fetch account details: %w", err)
 	}
 
 	accounts, ok := data["accounts"].([]interface{})
 	if !ok || len(accounts) == 0 {
-		log.Printf("ERROR: invalid response format - no accounts field or empty: provider_account_id=%s data=%v",
+		syncLog.Printf("ERROR: invalid response format - no accounts field or empty: provider_account_id=%s data=%v",
 			providerAccountID, data)
 		return fmt.Errorf("invalid response format")
 	}
 
 	accountData, ok := accounts[0].(map[string]interface{})
 	if !ok {
-		log.Printf("ERROR: invalid account format: provider_account_id=%s", providerAccountID)
+		syncLog.Printf("ERROR: invalid account format: provider_account_id=%s", providerAccountID)
 		return fmt.Errorf("invalid account format")
 	}
 
@@ -288,7 +256,7 @@ func (s *Service) syncAccountDetails(ctx context.Context, client *wealthsimple.C
 		if !isAsset {
 			// Try currentBalance for liability accounts
 			if currentBalance, ok := financials["currentBalance"].(map[string]interface{}); ok {
-				log.Printf("DEBUG: found currentBalance for liability account: provider_account_id=%s currentBalance_keys=%v",
+				syncLog.Printf("DEBUG: found currentBalance for liability account: provider_account_id=%s currentBalance_keys=%v",
 					providerAccountID, getMapKeys(currentBalance))
 
 				if amountObj, ok := currentBalance["amount"].(string); ok {
@@ -296,7 +264,7 @@ func (s *Service) syncAccountDetails(ctx context.Context, client *wealthsimple.C
 					if err == nil {
 						amount = parsedAmount
 						foundBalance = true
-						log.Printf("INFO: found balance from currentBalance: provider_account_id=%s amount=%f",
+						syncLog.Printf("INFO: found balance from currentBalance: provider_account_id=%s amount=%f",
 							providerAccountID, amount)
 					}
 				}
@@ -306,14 +274,14 @@ func (s *Service) syncAccountDetails(ctx context.Context, client *wealthsimple.C
 		// If not found yet, try netLiquidationValue (for investment accounts)
 		if !foundBalance {
 			if currentCombined, ok := financials["currentCombined"].(map[string]interface{}); ok {
-				log.Printf("DEBUG: found currentCombined: provider_account_id=%s currentCombined_keys=%v",
+				syncLog.Printf("DEBUG: found currentCombined: provider_account_id=%s currentCombined_keys=%v",
 					providerAccountID, getMapKeys(currentCombined))
 
 				if netLiquidationValue, ok := currentCombined["netLiquidationValueV2"].(map[string]interface{}); ok {
 					// Amount comes as a string, need to parse it
 					amountStr, ok := netLiquidationValue["amount"].(string)
 					if !ok {
-						log.Printf("DEBUG: amount is not a string, trying float64: provider_account_id=%s",
+						syncLog.Printf("DEBUG: amount is not a string, trying float64: provider_account_id=%s",
 							providerAccountID)
 						// Try as float64 in case API changes
 						if amountFloat, ok := netLiquidationValue["amount"].(float64); ok {
@@ -326,70 +294,121 @@ func (s *Service) syncAccountDetails(ctx context.Context, client *wealthsimple.C
 						if err == nil {
 							amount = parsedAmount
 							foundBalance = true
-							log.Printf("INFO: found balance from netLiquidationValue: provider_account_id=%s amount=%f",
+							syncLog.Printf("INFO: found balance from netLiquidationValue: provider_account_id=%s amount=%f",
 								providerAccountID, amount)
 						}
 					}
 				} else {
-					log.Printf("DEBUG: no netLiquidationValueV2 found: provider_account_id=%s", providerAccountID)
+					syncLog.Printf("DEBUG: no netLiquidationValueV2 found: provider_account_id=%s", providerAccountID)
 				}
 			} else {
-				log.Printf("DEBUG: no currentCombined found: provider_account_id=%s", providerAccountID)
+				syncLog.Printf("DEBUG: no currentCombined found: provider_account_id=%s", providerAccountID)
 			}
 		}
 
 		// If still not found, log all available keys for debugging
 		if !foundBalance {
-			log.Printf("WARN: could not find balance in any known location: provider_account_id=%s financials_keys=%v is_asset=%v",
+			syncLog.Printf("WARN: could not find balance in any known location: provider_account_id=%s financials_keys=%v is_asset=%v",
 				providerAccountID, getMapKeys(financials), isAsset)
 		}
 	} else {
-		log.Printf("DEBUG: no financials field found: provider_account_id=%s", providerAccountID)
+		syncLog.Printf("DEBUG: no financials field found: provider_account_id=%s", providerAccountID)
 	}
 
-	// Create balance entry if we found one
 	if foundBalance {
 		// For liability accounts (credit cards, loans), negate the balance
 		// because they represent debt
 		if !isAsset {
 			amount = -amount
 		}
+	} else {
+		syncLog.Printf("WARN: no balance found for account: provider_account_id=%s local_account_id=%s",
+			providerAccountID, localAccountID)
+	}
 
-		// Truncate to just the date (no time component) for proper upsert
-		now := time.Now()
-		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	positions, positionsErr := s.fetchPositions(ctx, client, providerAccountID, localAccountID, identityID)
+	// Holdings missing from the positions are only removed after a complete fetch that
+	// reported at least one position, so a partial or empty response never deletes them
+	prune := positionsErr == nil && len(positions) > 0
+	var unreadPositions int
+	if positionsErr != nil {
+		syncLog.Printf("ERROR: positions incomplete, holdings will not be pruned: provider_account_id=%s error=%v",
+			providerAccountID, positionsErr)
+		var parseErr *positionsError
+		if errors.As(positionsErr, &parseErr) {
+			unreadPositions = parseErr.failed
+		}
+	}
 
-		// Create or update balance via balance service
-		balanceResp, err := s.balanceSvc.Create(ctx, &balance.CreateBalanceRequest{
-			AccountID: localAccountID,
-			Amount:    amount,
-			Date:      today,
-			Notes:     "Synced from Wealthsimple",
-		})
+	// Cash accounts accrue interest monthly, paid into the account as activity
+	var interest []*interestPayment
+	if accountType == cashAccountType {
+		payments := s.fetchInterestPayments(ctx, client, providerAccountID, localAccountID)
+		interest = s.prepareInterestPayments(ctx, userID, localAccountID, payments)
+	}
 
-		if err != nil {
-			log.Printf("ERROR: failed to create balance: account_id=%s amount=%f error=%v",
-				localAccountID, amount, err)
-			_ = s.updateSyncJobProgress(ctx, jobID, 1, 0, 0, 1)
-		} else {
-			// Track created vs updated
-			if balanceResp.WasUpdate {
-				log.Printf("INFO: updated balance: balance_id=%s account_id=%s amount=%f",
-					balanceResp.Balance.ID, localAccountID, amount)
-				_ = s.updateSyncJobProgress(ctx, jobID, 1, 0, 1, 0)
-			} else {
-				log.Printf("INFO: created balance: balance_id=%s account_id=%s amount=%f",
-					balanceResp.Balance.ID, localAccountID, amount)
-				_ = s.updateSyncJobProgress(ctx, jobID, 1, 1, 0, 0)
+	// Snapshot existing holdings so the change log can record old values and removals
+	existingHoldings := make(map[string]*holdings.Holding)
+	if len(positions) > 0 {
+		if existing, err := s.holdingsSvc.GetAccountHoldings(ctx, localAccountID); err == nil {
+			for _, h := range existing.Holdings {
+				if h.Symbol != nil {
+					existingHoldings[*h.Symbol] = h
+				}
 			}
 		}
-	} else {
-		log.Printf("WARN: no balance found for account: provider_account_id=%s local_account_id=%s",
-			providerAccountID, localAccountID)
 	}
 
+	return s.commitAccountSync(ctx, userID, syncedAccountID, localAccountID, jobID, func(tx *sql.Tx) error {
+		if foundBalance {
+			if err := s.writeProviderBalance(ctx, tx, userID, localAccountID, jobID, amount, "Synced from Wealthsimple"); err != nil {
+				return err
+			}
+		}
+		if err := s.writeInterestPayments(ctx, tx, userID, localAccountID, jobID, interest); err != nil {
+			return err
+		}
+		if unreadPositions > 0 {
+			if err := s.updateSyncJobProgress(ctx, tx, jobID, unreadPositions, 0, 0, unreadPositions); err != nil {
+				return err
+			}
+		}
+		if len(positions) > 0 {
+			return s.writePositions(ctx, tx, localAccountID, jobID, positions, existingHoldings, prune)
+		}
+		return nil
+	})
+}
+
+// providerPosition is a position reported by the provider for an account
+type providerPosition struct {
+	symbol      string
+	name        string
+	exchange    string
+	currency    string
+	holdingType holdings.HoldingType
+	quantity    float64
+	costBasis   float64
+}
+
+// positionsError reports positions the provider sent that could not be read. The positions
+// that were read are still stored, but holdings are only pruned after a complete fetch.
+type positionsError struct {
+	failed int
+	total  int
+	first  error
+}
+
+func (e *positionsError) Error() string {
+	return fmt.Sprintf("failed to read %d of %d positions: %v", e.failed, e.total, e.first)
+}
+
+// fetchPositions fetches an account's positions from the provider. It returns an error when
+// the positions could not be fetched, leaving the account's holdings as they are, or a
+// *positionsError alongside the positions it could read.
+func (s *Service) fetchPositions(ctx context.Context, client *wealthsimple.Client, providerAccountID, localAccountID, identityID string) ([]providerPosition, error) {
 	// Fetch positions using identity-based query
-	log.Printf("INFO: fetching account positions: provider_account_id=%s local_account_id=%s identity_id=%s",
+	syncLog.Printf("INFO: fetching account positions: provider_account_id=%s local_account_id=%s identity_id=%s",
 		providerAccountID, localAccountID, identityID)
 
 	positionsVariables := map[string]interface{}{
@@ -401,250 +420,373 @@ func (s *Service) syncAccountDetails(ctx context.Context, client *wealthsimple.C
 
 	positionsData, err := client.QueryGraphQL(ctx, wealthsimple.QueryFetchAccountPositions, positionsVariables, "trade")
 	if err != nil {
-		log.Printf("ERROR: failed to fetch positions: provider_account_id=%s error=%v",
-			providerAccountID, err)
-		// Don't return error, balances already synced
-		return nil
+		return nil, fmt.Errorf("failed to fetch positions: %w", err)
 	}
 
-	log.Printf("DEBUG: positions response keys: provider_account_id=%s keys=%v",
+	syncLog.Printf("DEBUG: positions response keys: provider_account_id=%s keys=%v",
 		providerAccountID, getMapKeys(positionsData))
 
 	// Parse positions response: identity.financials.current.positions.edges
-	identity, ok := positionsData["identity"].(map[string]interface{})
+	identity, _ := positionsData["identity"].(map[string]interface{})
+	financials, _ := identity["financials"].(map[string]interface{})
+	current, _ := financials["current"].(map[string]interface{})
+	positions, _ := current["positions"].(map[string]interface{})
+	edges, ok := positions["edges"].([]interface{})
 	if !ok {
-		log.Printf("WARN: no identity in positions response: provider_account_id=%s data=%v",
-			providerAccountID, positionsData)
-		return nil
+		return nil, fmt.Errorf("no position edges in response")
 	}
 
-	financials, ok := identity["financials"].(map[string]interface{})
-	if !ok {
-		log.Printf("DEBUG: no financials in positions response: provider_account_id=%s", providerAccountID)
-		return nil
+	syncLog.Printf("INFO: found positions: provider_account_id=%s position_count=%d",
+		providerAccountID, len(edges))
+
+	result := make([]providerPosition, 0, len(edges))
+	var parseErr *positionsError
+	for _, edge := range edges {
+		edgeMap, _ := edge.(map[string]interface{})
+		node, _ := edgeMap["node"].(map[string]interface{})
+		position, err := parsePosition(node)
+		if err != nil {
+			if parseErr == nil {
+				parseErr = &positionsError{total: len(edges), first: err}
+			}
+			parseErr.failed++
+			continue
+		}
+		result = append(result, position)
+	}
+
+	if parseErr != nil {
+		return result, parseErr
+	}
+	return result, nil
+}
+
+// parsePosition reads a position node
+func parsePosition(node map[string]interface{}) (providerPosition, error) {
+	if node == nil {
+		return providerPosition{}, fmt.Errorf("position without a node")
 	}
 
-	current, ok := financials["current"].(map[string]interface{})
+	// Quantity and average price (cost basis) are sent as strings
+	quantityStr, ok := node["quantity"].(string)
 	if !ok {
-		log.Printf("DEBUG: no current in positions response: provider_account_id=%s", providerAccountID)
-		return nil
+		return providerPosition{}, fmt.Errorf("position quantity is not a string")
+	}
+	quantity, err := strconv.ParseFloat(quantityStr, 64)
+	if err != nil {
+		return providerPosition{}, fmt.Errorf("failed to parse quantity %q: %w", quantityStr, err)
 	}
 
-	positions, ok := current["positions"].(map[string]interface{})
+	security, ok := node["security"].(map[string]interface{})
 	if !ok {
-		log.Printf("DEBUG: no positions object found: provider_account_id=%s", providerAccountID)
-		return nil
+		return providerPosition{}, fmt.Errorf("position without a security")
+	}
+	securityType, _ := security["securityType"].(string)
+	stock, ok := security["stock"].(map[string]interface{})
+	if !ok {
+		return providerPosition{}, fmt.Errorf("security without stock details")
+	}
+	symbol, _ := stock["symbol"].(string)
+	if symbol == "" {
+		return providerPosition{}, fmt.Errorf("security without a symbol")
 	}
+	name, _ := stock["name"].(string)
+	exchange, _ := stock["primaryExchange"].(string)
 
-	edges, ok := positions["edges"].([]interface{})
+	avgPrice, ok := node["averagePrice"].(map[string]interface{})
 	if !ok {
-		log.Printf("WARN: no edges in positions: provider_account_id=%s positions=%v",
-			providerAccountID, positions)
-		return nil
+		return providerPosition{}, fmt.Errorf("position %s without an average price", symbol)
+	}
+	currency, _ := avgPrice["currency"].(string)
+	costBasisStr, ok := avgPrice["amount"].(string)
+	if !ok {
+		return providerPosition{}, fmt.Errorf("position %s average price is not a string", symbol)
 	}
+	costBasis, err := strconv.ParseFloat(costBasisStr, 64)
+	if err != nil {
+		return providerPosition{}, fmt.Errorf("failed to parse cost basis %q for %s: %w", costBasisStr, symbol, err)
+	}
+
+	// Map security type to holding type
+	holdingType := holdings.HoldingTypeStock // Default
+	switch securityType {
+	case "crypto", "cryptocurrency":
+		holdingType = holdings.HoldingTypeCrypto
+	case "etf":
+		holdingType = holdings.HoldingTypeETF
+	case "mutual_fund":
+		holdingType = holdings.HoldingTypeMutualFund
+	}
+
+	return providerPosition{
+		symbol:      symbol,
+		name:        name,
+		exchange:    exchange,
+		currency:    currency,
+		holdingType: holdingType,
+		quantity:    quantity,
+		costBasis:   costBasis,
+	}, nil
+}
 
-	if len(edges) == 0 {
-		log.Printf("INFO: no positions found for account: provider_account_id=%s local_account_id=%s",
-			providerAccountID, localAccountID)
-		return nil
+// security is the metadata the provider reported for the position's symbol
+func (p providerPosition) security() *holdings.SecurityUpdate {
+	assetClass := holdings.AssetClassOf(p.holdingType)
+	update := &holdings.SecurityUpdate{AssetClass: &assetClass}
+	if p.name != "" {
+		update.Name = &p.name
+	}
+	if p.exchange != "" {
+		update.Exchange = &p.exchange
 	}
+	switch currency := holdings.Currency(strings.ToUpper(p.currency)); currency {
+	case holdings.CurrencyCAD, holdings.CurrencyUSD, holdings.CurrencyINR:
+		update.Currency = &currency
+	}
+	return update
+}
 
-	log.Printf("INFO: found positions: provider_account_id=%s position_count=%d",
-		providerAccountID, len(edges))
+// writePositions stores the provider's positions as holdings within the account's sync
+// transaction. When prune is set, holdings the provider no longer reports are removed and
+// the removals recorded in the change log.
+func (s *Service) writePositions(ctx context.Context, tx *sql.Tx, localAccountID, jobID string, positions []providerPosition, existingHoldings map[string]*holdings.Holding, prune bool) error {
+	seenSymbols := make(map[string]bool)
 
-	for _, edge := range edges {
-		edgeMap, ok := edge.(map[string]interface{})
-		if !ok {
-			continue
+	for _, p := range positions {
+		symbol, quantity, costBasis := p.symbol, p.quantity, p.costBasis
+
+		// Create or update holding via holdings service
+		holdingResp, err := s.holdingsSvc.CreateTx(ctx, tx, &holdings.CreateHoldingRequest{
+			AccountID: localAccountID,
+			Type:      p.holdingType,
+			Symbol:    &symbol,
+			Quantity:  &quantity,
+			CostBasis: &costBasis,
+		})
+		if err != nil {
+			syncLog.Printf("ERROR: failed to create holding: symbol=%s account_id=%s error=%v",
+				symbol, localAccountID, err)
+			return fmt.Errorf("failed to create holding %s: %w", symbol, err)
 		}
 
-		node, ok := edgeMap["node"].(map[string]interface{})
-		if !ok {
-			continue
+		// Descriptive metadata goes to the security master rather than the holding's notes
+		if err := s.holdingsSvc.UpsertSecurityTx(ctx, tx, symbol, p.security(), holdings.SecuritySourceSync); err != nil {
+			syncLog.Printf("WARN: failed to save security metadata: symbol=%s error=%v", symbol, err)
 		}
 
-		// Extract quantity (as string, need to parse)
-		quantityStr, ok := node["quantity"].(string)
-		if !ok {
-			log.Printf("DEBUG: quantity not a string: node=%v", node)
-			continue
+		seenSymbols[symbol] = true
+		if previous, ok := existingHoldings[symbol]; ok {
+			if valueChanged(previous.Quantity, &quantity) {
+				s.recordChange(ctx, tx, jobID, ChangeEntityHolding, holdingResp.Holding.ID, ChangeTypeUpdated, symbol, "quantity", previous.Quantity, &quantity)
+			}
+			if valueChanged(previous.CostBasis, &costBasis) {
+				s.recordChange(ctx, tx, jobID, ChangeEntityHolding, holdingResp.Holding.ID, ChangeTypeUpdated, symbol, "cost_basis", previous.CostBasis, &costBasis)
+			}
+		} else {
+			s.recordChange(ctx, tx, jobID, ChangeEntityHolding, holdingResp.Holding.ID, ChangeTypeCreated, symbol, "quantity", nil, &quantity)
 		}
 
-		quantity, err := strconv.ParseFloat(quantityStr, 64)
+		// Track created vs updated
+		if holdingResp.WasUpdate {
+			syncLog.Printf("INFO: updated holding: holding_id=%s symbol=%s quantity=%f cost_basis=%f",
+				holdingResp.Holding.ID, symbol, quantity, costBasis)
+			err = s.updateSyncJobProgress(ctx, tx, jobID, 1, 0, 1, 0)
+		} else {
+			syncLog.Printf("INFO: created holding: holding_id=%s symbol=%s quantity=%f cost_basis=%f",
+				holdingResp.Holding.ID, symbol, quantity, costBasis)
+			err = s.updateSyncJobProgress(ctx, tx, jobID, 1, 1, 0, 0)
+		}
 		if err != nil {
-			log.Printf("ERROR: failed to parse quantity: quantity_str=%s error=%v",
-				quantityStr, err)
-			continue
+			return err
 		}
+	}
 
-		// Extract security info
-		security, ok := node["security"].(map[string]interface{})
-		if !ok {
-			log.Printf("DEBUG: no security found: node=%v", node)
+	// Remove holdings the provider no longer reports (position closed)
+	for symbol, h := range existingHoldings {
+		if !prune || seenSymbols[symbol] || h.Type == holdings.HoldingTypeCash {
 			continue
 		}
+		if _, err := s.holdingsSvc.DeleteTx(ctx, tx, h.ID); err != nil {
+			syncLog.Printf("ERROR: failed to remove closed holding: holding_id=%s symbol=%s error=%v",
+				h.ID, symbol, err)
+			return fmt.Errorf("failed to remove closed holding %s: %w", symbol, err)
+		}
+		syncLog.Printf("INFO: removed closed holding: holding_id=%s symbol=%s", h.ID, symbol)
+		s.recordChange(ctx, tx, jobID, ChangeEntityHolding, h.ID, ChangeTypeRemoved, symbol, "quantity", h.Quantity, nil)
+	}
 
-		securityType, _ := security["securityType"].(string)
+	syncLog.Printf("INFO: finished syncing positions for account: account_id=%s position_count=%d",
+		localAccountID, len(positions))
 
-		stock, ok := security["stock"].(map[string]interface{})
-		if !ok {
-			log.Printf("DEBUG: no stock found in security: security=%v", security)
-			continue
-		}
+	return nil
+}
 
-		symbol, _ := stock["symbol"].(string)
-		name, _ := stock["name"].(string)
+// writeProviderBalance stores today's provider balance within the account's sync
+// transaction, unless the account's conflict policy keeps a newer manual balance
+func (s *Service) writeProviderBalance(ctx context.Context, tx *sql.Tx, userID, localAccountID, jobID string, amount float64, notes string) error {
+	// Truncate to just the date (no time component) for proper upsert
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 
-		// Extract average price (cost basis)
-		avgPrice, ok := node["averagePrice"].(map[string]interface{})
-		if !ok {
-			log.Printf("DEBUG: no averagePrice found: node=%v", node)
-			continue
-		}
+	apply, err := s.applyProviderBalance(ctx, tx, userID, localAccountID, jobID, today, amount)
+	if err != nil {
+		return err
+	}
+	if !apply {
+		syncLog.Printf("INFO: kept manual balance over provider data: account_id=%s provider_amount=%f",
+			localAccountID, amount)
+		return s.updateSyncJobProgress(ctx, tx, jobID, 1, 0, 0, 0)
+	}
 
-		costBasisStr, ok := avgPrice["amount"].(string)
-		if !ok {
-			log.Printf("DEBUG: cost basis not a string: avgPrice=%v", avgPrice)
-			continue
-		}
+	// Create or update balance via balance service
+	balanceResp, err := s.balanceSvc.CreateTx(ctx, tx, &balance.CreateBalanceRequest{
+		AccountID: localAccountID,
+		Amount:    amount,
+		Date:      today,
+		Notes:     notes,
+		Source:    balance.SourceSync,
+	})
+	if err != nil {
+		syncLog.Printf("ERROR: failed to create balance: account_id=%s amount=%f error=%v",
+			localAccountID, amount, err)
+		return fmt.Errorf("failed to create balance: %w", err)
+	}
 
-		costBasis, err := strconv.ParseFloat(costBasisStr, 64)
-		if err != nil {
-			log.Printf("ERROR: failed to parse cost basis: cost_basis_str=%s error=%v",
-				costBasisStr, err)
-			continue
-		}
+	// Track created vs updated
+	s.recordBalanceChange(ctx, tx, jobID, balanceResp, amount)
+	if balanceResp.WasUpdate {
+		syncLog.Printf("INFO: updated balance: balance_id=%s account_id=%s amount=%f",
+			balanceResp.Balance.ID, localAccountID, amount)
+		return s.updateSyncJobProgress(ctx, tx, jobID, 1, 0, 1, 0)
+	}
+	syncLog.Printf("INFO: created balance: balance_id=%s account_id=%s amount=%f",
+		balanceResp.Balance.ID, localAccountID, amount)
+	return s.updateSyncJobProgress(ctx, tx, jobID, 1, 1, 0, 0)
+}
 
-		// Map security type to holding type
-		holdingType := holdings.HoldingTypeStock // Default
-		switch securityType {
-		case "crypto", "cryptocurrency":
-			holdingType = holdings.HoldingTypeCrypto
-		case "etf":
-			holdingType = holdings.HoldingTypeETF
-		case "mutual_fund":
-			holdingType = holdings.HoldingTypeMutualFund
-		}
+// commitAccountSync runs an account's sync writes in one transaction. The sync job is
+// completed, the account's last sync time moved and an account_synced event raised in the
+// same transaction, so a failure part way through leaves none of the account's changes
+// behind. Outbox events are delivered once the transaction has committed.
+func (s *Service) commitAccountSync(ctx context.Context, userID, syncedAccountID, localAccountID, jobID string, write func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-		// Create or update holding via holdings service
-		holdingResp, err := s.holdingsSvc.Create(ctx, &holdings.CreateHoldingRequest{
-			AccountID: localAccountID,
-			Type:      holdingType,
-			Symbol:    &symbol,
-			Quantity:  &quantity,
-			CostBasis: &costBasis,
-			Notes:     name,
-		})
+	if err := write(tx); err != nil {
+		return err
+	}
 
-		if err != nil {
-			log.Printf("ERROR: failed to create holding: symbol=%s account_id=%s error=%v",
-				symbol, localAccountID, err)
-			_ = s.updateSyncJobProgress(ctx, jobID, 1, 0, 0, 1)
-		} else {
-			// Track created vs updated
-			if holdingResp.WasUpdate {
-				log.Printf("INFO: updated holding: holding_id=%s symbol=%s quantity=%f cost_basis=%f",
-					holdingResp.Holding.ID, symbol, quantity, costBasis)
-				_ = s.updateSyncJobProgress(ctx, jobID, 1, 0, 1, 0)
-			} else {
-				log.Printf("INFO: created holding: holding_id=%s symbol=%s quantity=%f cost_basis=%f",
-					holdingResp.Holding.ID, symbol, quantity, costBasis)
-				_ = s.updateSyncJobProgress(ctx, jobID, 1, 1, 0, 0)
-			}
-		}
+	if err := s.completeSyncJob(ctx, tx, jobID, SyncJobStatusCompleted, ""); err != nil {
+		return err
 	}
 
-	log.Printf("INFO: finished syncing positions for account: provider_account_id=%s local_account_id=%s position_count=%d",
-		providerAccountID, localAccountID, len(edges))
+	_, err = tx.ExecContext(ctx, `
+		UPDATE synced_accounts
+		SET last_sync_at = CURRENT_TIMESTAMP,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, syncedAccountID)
+	if err != nil {
+		return fmt.Errorf("failed to update synced_account timestamps: %w", err)
+	}
+
+	payload := &AccountSyncedPayload{AccountID: localAccountID}
+	err = tx.QueryRowContext(ctx, `
+		SELECT items_created, items_updated FROM sync_jobs WHERE id = $1
+	`, jobID).Scan(&payload.ItemsCreated, &payload.ItemsUpdated)
+	if err != nil {
+		return fmt.Errorf("failed to read sync job counts: %w", err)
+	}
+	if err := enqueueEvent(ctx, tx, userID, jobID, EventAccountSynced, payload); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit account sync: %w", err)
+	}
+
+	if _, err := s.DispatchOutbox(ctx); err != nil {
+		// Undelivered events stay in the outbox for the background dispatcher
+		syncLog.Printf("ERROR: outbox dispatch failed: job_id=%s error=%v", jobID, err)
+	}
 
 	return nil
 }
 
 // syncCreditCardDetails fetches and stores credit card balance
-func (s *Service) syncCreditCardDetails(ctx context.Context, client *wealthsimple.Client, providerAccountID, localAccountID string, isAsset bool, jobID string) error {
+func (s *Service) syncCreditCardDetails(ctx context.Context, client *wealthsimple.Client, userID, syncedAccountID, providerAccountID, localAccountID string, isAsset bool, jobID string) error {
 	variables := map[string]interface{}{
 		"id": providerAccountID,
 	}
 
-	log.Printf("INFO: fetching credit card details: provider_account_id=%s local_account_id=%s",
+	syncLog.Printf("INFO: fetching credit card details: provider_account_id=%s local_account_id=%s",
 		providerAccountID, localAccountID)
 
 	data, err := client.QueryGraphQL(ctx, wealthsimple.QueryFetchCreditCardAccount, variables, "invest")
 	if err != nil {
-		log.Printf("ERROR: failed to fetch credit card details: provider_account_id=%s error=%v",
+		syncLog.Printf("ERROR: failed to fetch credit card details: provider_account_id=%s error=%v",
 			providerAccountID, err)
 		return fmt.Errorf("failed to fetch credit card details: %w", err)
 	}
 
-	log.Printf("DEBUG: received credit card details: provider_account_id=%s data_keys=%v",
+	syncLog.Printf("DEBUG: received credit card details: provider_account_id=%s data_keys=%v",
 		providerAccountID, getMapKeys(data))
 
 	// Parse credit card account response
 	creditCardAccount, ok := data["creditCardAccount"].(map[string]interface{})
 	if !ok {
-		log.Printf("ERROR: invalid credit card response format: provider_account_id=%s data=%v",
+		syncLog.Printf("ERROR: invalid credit card response format: provider_account_id=%s data=%v",
 			providerAccountID, data)
 		return fmt.Errorf("invalid credit card response format")
 	}
 
+	amount, found, err := parseCreditCardBalance(providerAccountID, creditCardAccount)
+	if err != nil {
+		return err
+	}
+
+	// For credit cards, the outstanding balance is debt, so store as negative
+	if found && !isAsset {
+		amount = -amount
+	}
+
+	return s.commitAccountSync(ctx, userID, syncedAccountID, localAccountID, jobID, func(tx *sql.Tx) error {
+		if !found {
+			return nil
+		}
+		return s.writeProviderBalance(ctx, tx, userID, localAccountID, jobID, amount, "Synced from Wealthsimple Credit Card")
+	})
+}
+
+// parseCreditCardBalance reads the outstanding balance from a credit card account response.
+// found is false when the response has no usable balance.
+func parseCreditCardBalance(providerAccountID string, creditCardAccount map[string]interface{}) (amount float64, found bool, err error) {
 	// Extract balance
 	balanceData, ok := creditCardAccount["balance"].(map[string]interface{})
 	if !ok {
-		log.Printf("WARN: no balance found for credit card: provider_account_id=%s", providerAccountID)
-		return nil
+		syncLog.Printf("WARN: no balance found for credit card: provider_account_id=%s", providerAccountID)
+		return 0, false, nil
 	}
 
 	// Get outstanding balance (what is owed on the card)
 	outstandingStr, ok := balanceData["outstanding"].(string)
 	if !ok {
-		log.Printf("WARN: outstanding balance not a string: provider_account_id=%s balance=%v",
+		syncLog.Printf("WARN: outstanding balance not a string: provider_account_id=%s balance=%v",
 			providerAccountID, balanceData)
-		return nil
+		return 0, false, nil
 	}
 
-	amount, err := strconv.ParseFloat(outstandingStr, 64)
+	amount, err = strconv.ParseFloat(outstandingStr, 64)
 	if err != nil {
-		log.Printf("ERROR: failed to parse outstanding balance: provider_account_id=%s outstanding_str=%s error=%v",
+		syncLog.Printf("ERROR: failed to parse outstanding balance: provider_account_id=%s outstanding_str=%s error=%v",
 			providerAccountID, outstandingStr, err)
-		return fmt.Errorf("failed to parse outstanding balance: %w", err)
+		return 0, false, fmt.Errorf("failed to parse outstanding balance: %w", err)
 	}
 
-	// For credit cards, the outstanding balance is debt, so store as negative
-	if !isAsset {
-		amount = -amount
-	}
-
-	// Truncate to just the date (no time component) for proper upsert
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-
-	// Create or update credit card balance via balance service
-	balanceResp, err := s.balanceSvc.Create(ctx, &balance.CreateBalanceRequest{
-		AccountID: localAccountID,
-		Amount:    amount,
-		Date:      today,
-		Notes:     "Synced from Wealthsimple Credit Card",
-	})
-
-	if err != nil {
-		log.Printf("ERROR: failed to create credit card balance: account_id=%s amount=%f error=%v",
-			localAccountID, amount, err)
-		_ = s.updateSyncJobProgress(ctx, jobID, 1, 0, 0, 1)
-		return fmt.Errorf("failed to create credit card balance: %w", err)
-	}
-
-	// Track created vs updated
-	if balanceResp.WasUpdate {
-		log.Printf("INFO: updated credit card balance: account_id=%s amount=%f outstanding=%s",
-			localAccountID, amount, outstandingStr)
-		_ = s.updateSyncJobProgress(ctx, jobID, 1, 0, 1, 0)
-	} else {
-		log.Printf("INFO: created credit card balance: account_id=%s amount=%f outstanding=%s",
-			localAccountID, amount, outstandingStr)
-		_ = s.updateSyncJobProgress(ctx, jobID, 1, 1, 0, 0)
-	}
-
-	return nil
+	return amount, true, nil
 }
 
 // isAssetAccount determines if an account type is an asset or liability
@@ -658,6 +800,9 @@ func isAssetAccount(accountType string) bool {
 	return true
 }
 
+// cashAccountType is the local type Wealthsimple Cash accounts are synced as
+const cashAccountType = "checking"
+
 // mapWealthsimpleAccountType maps Wealthsimple account types to local types
 func mapWealthsimpleAccountType(wsType string) string {
 	switch wsType {
@@ -674,7 +819,7 @@ func mapWealthsimpleAccountType(wsType string) string {
 	case "ca_resp":
 		return "other"
 	case "ca_cash_msb", "ca_cash", "cash":
-		return "checking"
+		return cashAccountType
 	case "ca_credit_card":
 		return "credit_card"
 	case "crypto":
@@ -742,15 +887,15 @@ func (s *Service) createSyncJob(ctx context.Context, syncedAccountID string, job
 		return "", fmt.Errorf("failed to create sync job: %w", err)
 	}
 
-	log.Printf("INFO: created sync job: job_id=%s synced_account_id=%s type=%s",
+	syncLog.Printf("INFO: created sync job: job_id=%s synced_account_id=%s type=%s",
 		jobID, syncedAccountID, jobType)
 
 	return jobID, nil
 }
 
 // updateSyncJobProgress updates the progress counters for a sync job
-func (s *Service) updateSyncJobProgress(ctx context.Context, jobID string, processed, created, updated, failed int) error {
-	_, err := s.db.ExecContext(ctx, `
+func (s *Service) updateSyncJobProgress(ctx context.Context, db database.Querier, jobID string, processed, created, updated, failed int) error {
+	_, err := db.ExecContext(ctx, `
 		UPDATE sync_jobs
 		SET items_processed = items_processed + $2,
 		    items_created = items_created + $3,
@@ -759,22 +904,25 @@ func (s *Service) updateSyncJobProgress(ctx context.Context, jobID string, proce
 		WHERE id = $1
 	`, jobID, processed, created, updated, failed)
 
-	return err
+	if err != nil {
+		return fmt.Errorf("failed to update sync job progress: %w", err)
+	}
+	return nil
 }
 
 // completeSyncJob marks a sync job as completed or failed
-func (s *Service) completeSyncJob(ctx context.Context, jobID string, status SyncJobStatus, errorMsg string) error {
+func (s *Service) completeSyncJob(ctx context.Context, db database.Querier, jobID string, status SyncJobStatus, errorMsg string) error {
 	now := time.Now()
 
 	var err error
 	if errorMsg != "" {
-		_, err = s.db.ExecContext(ctx, `
+		_, err = db.ExecContext(ctx, `
 			UPDATE sync_jobs
 			SET status = $2, completed_at = $3, error_message = $4
 			WHERE id = $1
 		`, jobID, status, now, errorMsg)
 	} else {
-		_, err = s.db.ExecContext(ctx, `
+		_, err = db.ExecContext(ctx, `
 			UPDATE sync_jobs
 			SET status = $2, completed_at = $3
 			WHERE id = $1
@@ -785,7 +933,7 @@ func (s *Service) completeSyncJob(ctx context.Context, jobID string, status Sync
 		return fmt.Errorf("failed to complete sync job: %w", err)
 	}
 
-	log.Printf("INFO: completed sync job: job_id=%s status=%s error=%s",
+	syncLog.Printf("INFO: completed sync job: job_id=%s status=%s error=%s",
 		jobID, status, errorMsg)
 
 	return nil
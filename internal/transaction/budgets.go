@@ -0,0 +1,451 @@
+package transaction
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"money/internal/auth"
+)
+
+// monthLayout is the YYYY-MM format budgets use for months
+const monthLayout = "2006-01"
+
+// maxAlertThreshold is the highest alert threshold accepted, as a percentage of the budget
+const maxAlertThreshold = 1000
+
+// CategoryBudget is a monthly spending budget for a category and its subcategories.
+// With rollover, whatever is left at the end of a month carries into the next one, and
+// overspending is taken from the next month's budget.
+type CategoryBudget struct {
+	ID              string    `json:"id"`
+	UserID          string    `json:"user_id"`
+	CategoryID      string    `json:"category_id"`
+	Category        string    `json:"category"`
+	Amount          float64   `json:"amount"` // Budgeted each month
+	Currency        string    `json:"currency"`
+	Rollover        bool      `json:"rollover"`
+	StartMonth      string    `json:"start_month"`      // YYYY-MM; carryover accumulates from this month
+	AlertThresholds []float64 `json:"alert_thresholds"` // Percentages of the available budget to notify at, ascending
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// CategoryBudgetRequest creates or updates a category budget
+type CategoryBudgetRequest struct {
+	CategoryID      string    `json:"category_id"`
+	Amount          float64   `json:"amount"`
+	Currency        string    `json:"currency"`
+	Rollover        bool      `json:"rollover"`
+	StartMonth      string    `json:"start_month,omitempty"`      // Defaults to the current month
+	AlertThresholds []float64 `json:"alert_thresholds,omitempty"` // e.g. [75, 100]; omitted on update keeps the current thresholds
+}
+
+// ListCategoryBudgetsResponse lists the user's category budgets
+type ListCategoryBudgetsResponse struct {
+	Budgets []CategoryBudget `json:"budgets"`
+}
+
+// BudgetMonth is a budget's position in one month
+type BudgetMonth struct {
+	Month      string  `json:"month"` // YYYY-MM
+	Budgeted   float64 `json:"budgeted"`
+	CarriedIn  float64 `json:"carried_in"` // Left over from the previous month, negative after overspending
+	Available  float64 `json:"available"`  // budgeted + carried_in
+	Spent      float64 `json:"spent"`
+	Remaining  float64 `json:"remaining"`   // available - spent
+	CarriedOut float64 `json:"carried_out"` // Carried into the next month; zero without rollover
+}
+
+// BudgetProgress is a budget's progress in a month, with the carryover history from the
+// budget's start month that led up to it
+type BudgetProgress struct {
+	Budget            CategoryBudget `json:"budget"`
+	BudgetMonth                      // The requested month
+	PercentUsed       float64        `json:"percent_used"` // spent / available * 100; zero when nothing is available
+	Overspent         bool           `json:"overspent"`
+	ThresholdsReached []float64      `json:"thresholds_reached"` // Alert thresholds the month's spending has reached
+	History           []BudgetMonth  `json:"history"`            // Oldest first, ending with the requested month
+}
+
+// BudgetProgressResponse is the progress of all of the user's budgets in a month
+type BudgetProgressResponse struct {
+	Month   string           `json:"month"`
+	Budgets []BudgetProgress `json:"budgets"`
+}
+
+// validate checks a budget's amount, currency and start month, defaulting the start month
+// to the one containing now
+func (r *CategoryBudgetRequest) validate(now time.Time) error {
+	if r.CategoryID == "" {
+		return fmt.Errorf("category_id is required")
+	}
+	if r.Amount < 0 {
+		return fmt.Errorf("amount must not be negative")
+	}
+	r.Currency = strings.ToUpper(strings.TrimSpace(r.Currency))
+	if r.Currency == "" {
+		return fmt.Errorf("currency is required")
+	}
+	if r.StartMonth == "" {
+		r.StartMonth = now.Format(monthLayout)
+	}
+	if _, err := time.Parse(monthLayout, r.StartMonth); err != nil {
+		return fmt.Errorf("start_month must be in YYYY-MM format")
+	}
+	seen := make(map[float64]bool)
+	for _, threshold := range r.AlertThresholds {
+		if threshold <= 0 || threshold > maxAlertThreshold {
+			return fmt.Errorf("alert thresholds must be between 0 and %d percent", maxAlertThreshold)
+		}
+		if seen[threshold] {
+			return fmt.Errorf("alert threshold %g%% is listed more than once", threshold)
+		}
+		seen[threshold] = true
+	}
+	sort.Float64s(r.AlertThresholds)
+	return nil
+}
+
+// encodeThresholds encodes alert thresholds for storage
+func encodeThresholds(thresholds []float64) (string, error) {
+	if thresholds == nil {
+		thresholds = []float64{}
+	}
+	data, err := json.Marshal(thresholds)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode alert thresholds: %w", err)
+	}
+	return string(data), nil
+}
+
+// CreateCategoryBudget creates a monthly budget for one of the user's categories
+func (s *Service) CreateCategoryBudget(ctx context.Context, req *CategoryBudgetRequest) (*CategoryBudget, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if err := req.validate(time.Now()); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetCategory(ctx, req.CategoryID); err != nil {
+		if err == ErrNotFound {
+			return nil, fmt.Errorf("category not found")
+		}
+		return nil, err
+	}
+
+	thresholds, err := encodeThresholds(req.AlertThresholds)
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.New().String()
+	now := time.Now()
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO category_budgets (
+			id, user_id, category_id, amount, currency, rollover, start_month, alert_thresholds, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+	`, id, userID, req.CategoryID, req.Amount, req.Currency, req.Rollover, req.StartMonth, thresholds, now)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return nil, fmt.Errorf("a %s budget already exists for this category", req.Currency)
+		}
+		return nil, fmt.Errorf("failed to create budget: %w", err)
+	}
+
+	return s.GetCategoryBudget(ctx, id)
+}
+
+// ListCategoryBudgets lists the user's category budgets
+func (s *Service) ListCategoryBudgets(ctx context.Context) (*ListCategoryBudgetsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	budgets, err := s.listCategoryBudgets(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+	return &ListCategoryBudgetsResponse{Budgets: budgets}, nil
+}
+
+// listCategoryBudgets reads the user's budgets, or only the one with the ID when set
+func (s *Service) listCategoryBudgets(ctx context.Context, userID, id string) ([]CategoryBudget, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT b.id, b.user_id, b.category_id, c.name, b.amount, b.currency, b.rollover, b.start_month,
+			b.alert_thresholds, b.created_at, b.updated_at
+		FROM category_budgets b
+		JOIN categories c ON c.id = b.category_id
+		WHERE b.user_id = $1 AND ($2 = '' OR b.id = $2)
+		ORDER BY c.name, b.currency
+	`, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list budgets: %w", err)
+	}
+	defer rows.Close()
+
+	budgets := make([]CategoryBudget, 0)
+	for rows.Next() {
+		var b CategoryBudget
+		var thresholds string
+		if err := rows.Scan(&b.ID, &b.UserID, &b.CategoryID, &b.Category, &b.Amount, &b.Currency,
+			&b.Rollover, &b.StartMonth, &thresholds, &b.CreatedAt, &b.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan budget: %w", err)
+		}
+		if err := json.Unmarshal([]byte(thresholds), &b.AlertThresholds); err != nil {
+			return nil, fmt.Errorf("failed to parse alert thresholds: %w", err)
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, rows.Err()
+}
+
+// GetCategoryBudget gets a single category budget
+func (s *Service) GetCategoryBudget(ctx context.Context, id string) (*CategoryBudget, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	budgets, err := s.listCategoryBudgets(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(budgets) == 0 {
+		return nil, ErrNotFound
+	}
+	return &budgets[0], nil
+}
+
+// UpdateCategoryBudget replaces a budget's amount, rollover setting and start month. The
+// carryover history is recalculated from the new settings.
+func (s *Service) UpdateCategoryBudget(ctx context.Context, id string, req *CategoryBudgetRequest) (*CategoryBudget, error) {
+	existing, err := s.GetCategoryBudget(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.CategoryID == "" {
+		req.CategoryID = existing.CategoryID
+	}
+	if req.StartMonth == "" {
+		req.StartMonth = existing.StartMonth
+	}
+	if req.AlertThresholds == nil {
+		req.AlertThresholds = existing.AlertThresholds
+	}
+	if err := req.validate(time.Now()); err != nil {
+		return nil, err
+	}
+	if req.CategoryID != existing.CategoryID {
+		if _, err := s.GetCategory(ctx, req.CategoryID); err != nil {
+			if err == ErrNotFound {
+				return nil, fmt.Errorf("category not found")
+			}
+			return nil, err
+		}
+	}
+
+	thresholds, err := encodeThresholds(req.AlertThresholds)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE category_budgets
+		SET category_id = $1, amount = $2, currency = $3, rollover = $4, start_month = $5,
+			alert_thresholds = $6, updated_at = $7
+		WHERE id = $8 AND user_id = $9
+	`, req.CategoryID, req.Amount, req.Currency, req.Rollover, req.StartMonth, thresholds, time.Now(), id, existing.UserID)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return nil, fmt.Errorf("a %s budget already exists for this category", req.Currency)
+		}
+		return nil, fmt.Errorf("failed to update budget: %w", err)
+	}
+
+	return s.GetCategoryBudget(ctx, id)
+}
+
+// DeleteCategoryBudget deletes a category budget
+func (s *Service) DeleteCategoryBudget(ctx context.Context, id string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM category_budgets WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete budget: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetBudgetProgress reports every budget's progress in the month (YYYY-MM, defaulting to
+// the current month), carrying leftovers and overspending forward from each budget's start
+func (s *Service) GetBudgetProgress(ctx context.Context, month string) (*BudgetProgressResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	budgets, err := s.listCategoryBudgets(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+	return s.budgetProgress(ctx, userID, budgets, month)
+}
+
+// GetCategoryBudgetProgress reports a single budget's progress in the month
+func (s *Service) GetCategoryBudgetProgress(ctx context.Context, id, month string) (*BudgetProgress, error) {
+	budget, err := s.GetCategoryBudget(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.budgetProgress(ctx, budget.UserID, []CategoryBudget{*budget}, month)
+	if err != nil {
+		return nil, err
+	}
+	return &resp.Budgets[0], nil
+}
+
+// budgetProgress walks each budget month by month from its start month to the requested
+// month, attributing spending in the budget's category and its subcategories
+func (s *Service) budgetProgress(ctx context.Context, userID string, budgets []CategoryBudget, month string) (*BudgetProgressResponse, error) {
+	if month == "" {
+		month = time.Now().UTC().Format(monthLayout)
+	}
+	end, err := time.Parse(monthLayout, month)
+	if err != nil {
+		return nil, fmt.Errorf("month must be in YYYY-MM format")
+	}
+
+	resp := &BudgetProgressResponse{Month: month, Budgets: make([]BudgetProgress, 0, len(budgets))}
+	if len(budgets) == 0 {
+		return resp, nil
+	}
+
+	start := end
+	for _, b := range budgets {
+		if first, err := time.Parse(monthLayout, b.StartMonth); err == nil && first.Before(start) {
+			start = first
+		}
+	}
+	to := end.AddDate(0, 1, 0).Add(-time.Nanosecond)
+	lines, err := s.GetCategoryLines(ctx, &start, &to)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.listCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range budgets {
+		covered := categorySubtree(categories, b.CategoryID)
+		spent := make(map[string]float64)
+		for _, line := range lines {
+			if line.Currency == b.Currency && covered[line.Category] {
+				// Transactions record outflows as negative amounts; spending is reported as positive
+				spent[line.Date.Format(monthLayout)] -= line.Amount
+			}
+		}
+
+		first, err := time.Parse(monthLayout, b.StartMonth)
+		if err != nil || first.After(end) {
+			first = end
+		}
+		progress := BudgetProgress{Budget: b}
+		carry := 0.0
+		for m := first; !m.After(end); m = m.AddDate(0, 1, 0) {
+			key := m.Format(monthLayout)
+			bm := BudgetMonth{
+				Month:     key,
+				Budgeted:  b.Amount,
+				CarriedIn: roundCents(carry),
+				Available: roundCents(b.Amount + carry),
+				Spent:     roundCents(spent[key]),
+			}
+			bm.Remaining = roundCents(bm.Available - bm.Spent)
+			if b.Rollover {
+				bm.CarriedOut = bm.Remaining
+			}
+			carry = bm.CarriedOut
+			progress.History = append(progress.History, bm)
+		}
+
+		progress.BudgetMonth = progress.History[len(progress.History)-1]
+		if progress.Available > 0 {
+			progress.PercentUsed = math.Round(progress.Spent/progress.Available*10000) / 100
+		}
+		progress.Overspent = progress.Remaining < 0
+		progress.ThresholdsReached = thresholdsReached(b.AlertThresholds, progress.BudgetMonth)
+		resp.Budgets = append(resp.Budgets, progress)
+	}
+
+	return resp, nil
+}
+
+// thresholdsReached returns the alert thresholds the month's spending has reached. Any
+// spending reaches every threshold once nothing is available, for example after rollover
+// carried in more overspending than the month's budget.
+func thresholdsReached(thresholds []float64, month BudgetMonth) []float64 {
+	reached := make([]float64, 0)
+	if month.Spent <= 0 {
+		return reached
+	}
+	for _, threshold := range thresholds {
+		if month.Available <= 0 || month.Spent >= roundCents(month.Available*threshold/100) {
+			reached = append(reached, threshold)
+		}
+	}
+	return reached
+}
+
+// categorySubtree returns the names of the category and all of its subcategories
+func categorySubtree(categories []Category, rootID string) map[string]bool {
+	children := make(map[string][]Category)
+	var root *Category
+	for i, c := range categories {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+		if c.ID == rootID {
+			root = &categories[i]
+		}
+	}
+
+	names := make(map[string]bool)
+	if root == nil {
+		return names
+	}
+	queue := []Category{*root}
+	seen := map[string]bool{root.ID: true}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		names[c.Name] = true
+		for _, child := range children[c.ID] {
+			if !seen[child.ID] {
+				seen[child.ID] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	return names
+}
+
+// roundCents rounds an amount to two decimal places
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
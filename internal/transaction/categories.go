@@ -0,0 +1,483 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"money/internal/auth"
+)
+
+// transferCategory is the category the system gives both legs of a transfer. It is not a
+// user category and never appears in the category model.
+const transferCategory = "transfer"
+
+// colorPattern matches hex colours such as #f80 or #ff8800
+var colorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Category is a user-defined transaction category, optionally nested under a parent
+type Category struct {
+	ID               string     `json:"id"`
+	UserID           string     `json:"user_id"`
+	Name             string     `json:"name"`
+	ParentID         *string    `json:"parent_id,omitempty"`
+	Icon             *string    `json:"icon,omitempty"`
+	Color            *string    `json:"color,omitempty"`
+	TransactionCount int        `json:"transaction_count"` // Transactions and splits in this category, excluding subcategories
+	Children         []Category `json:"children,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// CategoryRequest creates or updates a category
+type CategoryRequest struct {
+	Name     string  `json:"name"`
+	ParentID *string `json:"parent_id,omitempty"` // Null for a top-level category
+	Icon     *string `json:"icon,omitempty"`
+	Color    *string `json:"color,omitempty"`
+}
+
+// MergeCategoryRequest merges a category into another
+type MergeCategoryRequest struct {
+	TargetID string `json:"target_id"`
+}
+
+// ListCategoriesResponse lists categories as a tree of top-level categories
+type ListCategoriesResponse struct {
+	Categories []Category `json:"categories"`
+}
+
+// validate checks a category's name and colour
+func (r *CategoryRequest) validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if strings.EqualFold(r.Name, transferCategory) {
+		return fmt.Errorf("%q is reserved for transfers", transferCategory)
+	}
+	if r.Color != nil && !colorPattern.MatchString(*r.Color) {
+		return fmt.Errorf("color must be a hex colour such as #ff8800")
+	}
+	return nil
+}
+
+// ListCategories lists the user's categories as a tree, each with its transaction count
+func (s *Service) ListCategories(ctx context.Context) (*ListCategoriesResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	categories, err := s.listCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byParent := make(map[string][]Category)
+	for _, c := range categories {
+		parent := ""
+		if c.ParentID != nil {
+			parent = *c.ParentID
+		}
+		byParent[parent] = append(byParent[parent], c)
+	}
+	var build func(parent string) []Category
+	build = func(parent string) []Category {
+		children := byParent[parent]
+		for i := range children {
+			children[i].Children = build(children[i].ID)
+		}
+		return children
+	}
+
+	roots := build("")
+	if roots == nil {
+		roots = []Category{}
+	}
+	return &ListCategoriesResponse{Categories: roots}, nil
+}
+
+// listCategories loads the user's categories ordered by name
+func (s *Service) listCategories(ctx context.Context, userID string) ([]Category, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.id, c.user_id, c.name, c.parent_id, c.icon, c.color,
+			(SELECT COUNT(*) FROM transactions t WHERE t.category_id = c.id AND t.settled_transaction_id IS NULL) +
+			(SELECT COUNT(*) FROM transaction_splits s WHERE s.category_id = c.id),
+			c.created_at, c.updated_at
+		FROM categories c
+		WHERE c.user_id = $1
+		ORDER BY c.name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []Category
+	for rows.Next() {
+		var c Category
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Name, &c.ParentID, &c.Icon, &c.Color,
+			&c.TransactionCount, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+// GetCategory gets a single category
+func (s *Service) GetCategory(ctx context.Context, id string) (*Category, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	categories, err := s.listCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range categories {
+		if c.ID == id {
+			return &c, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// CreateCategory creates a category, nested under parent_id when set
+func (s *Service) CreateCategory(ctx context.Context, req *CategoryRequest) (*Category, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	if req.ParentID != nil {
+		if err := s.checkParent(ctx, userID, "", *req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	id := generateID()
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO categories (id, user_id, name, parent_id, icon, color, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+	`, id, userID, req.Name, req.ParentID, req.Icon, req.Color, now)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return nil, fmt.Errorf("a category named %q already exists", req.Name)
+		}
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+
+	return s.GetCategory(ctx, id)
+}
+
+// UpdateCategory renames, moves or restyles a category. Renaming updates the category name
+// recorded on its transactions and splits.
+func (s *Service) UpdateCategory(ctx context.Context, id string, req *CategoryRequest) (*Category, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetCategory(ctx, id); err != nil {
+		return nil, err
+	}
+	if req.ParentID != nil {
+		if err := s.checkParent(ctx, userID, id, *req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	_, err = tx.ExecContext(ctx, `
+		UPDATE categories SET name = $1, parent_id = $2, icon = $3, color = $4, updated_at = $5
+		WHERE id = $6 AND user_id = $7
+	`, req.Name, req.ParentID, req.Icon, req.Color, now, id, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return nil, fmt.Errorf("a category named %q already exists", req.Name)
+		}
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+	if err := syncCategoryName(ctx, tx, id, req.Name); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit category: %w", err)
+	}
+
+	return s.GetCategory(ctx, id)
+}
+
+// checkParent verifies that the parent category belongs to the user and that nesting the
+// category under it would not create a cycle
+func (s *Service) checkParent(ctx context.Context, userID, id, parentID string) error {
+	categories, err := s.listCategories(ctx, userID)
+	if err != nil {
+		return err
+	}
+	parents := make(map[string]*string, len(categories))
+	for _, c := range categories {
+		parents[c.ID] = c.ParentID
+	}
+	if _, ok := parents[parentID]; !ok {
+		return fmt.Errorf("parent category not found")
+	}
+	for current := &parentID; current != nil; current = parents[*current] {
+		if *current == id {
+			return fmt.Errorf("a category cannot be nested under itself or its subcategories")
+		}
+	}
+	return nil
+}
+
+// DeleteCategory deletes a category. Its transactions move to reassignTo, or become
+// uncategorized when it is empty, and its subcategories move up to its parent.
+func (s *Service) DeleteCategory(ctx context.Context, id, reassignTo string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	category, err := s.GetCategory(ctx, id)
+	if err != nil {
+		return err
+	}
+	var target *Category
+	if reassignTo != "" {
+		if reassignTo == id {
+			return fmt.Errorf("cannot reassign a category's transactions to itself")
+		}
+		if target, err = s.GetCategory(ctx, reassignTo); err != nil {
+			return fmt.Errorf("reassignment category not found")
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := reassignCategory(ctx, tx, category, target); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE categories SET parent_id = $1 WHERE parent_id = $2`, category.ParentID, id); err != nil {
+		return fmt.Errorf("failed to move subcategories: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM categories WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// MergeCategory moves a category's transactions and subcategories into the target
+// category and deletes it
+func (s *Service) MergeCategory(ctx context.Context, id string, req *MergeCategoryRequest) (*Category, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if req.TargetID == "" {
+		return nil, fmt.Errorf("target_id is required")
+	}
+	if req.TargetID == id {
+		return nil, fmt.Errorf("cannot merge a category into itself")
+	}
+
+	source, err := s.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.GetCategory(ctx, req.TargetID)
+	if err != nil {
+		return nil, fmt.Errorf("target category not found")
+	}
+	// Merging a category into one of its own subcategories would orphan the subtree
+	if err := s.checkParent(ctx, userID, id, target.ID); err != nil {
+		return nil, fmt.Errorf("cannot merge a category into its own subcategory")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := reassignCategory(ctx, tx, source, target); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE categories SET parent_id = $1 WHERE parent_id = $2`, target.ID, id); err != nil {
+		return nil, fmt.Errorf("failed to move subcategories: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM categories WHERE id = $1`, id); err != nil {
+		return nil, fmt.Errorf("failed to delete merged category: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit merge: %w", err)
+	}
+
+	return s.GetCategory(ctx, target.ID)
+}
+
+// reassignCategory moves the transactions and splits of a category to the target, or
+// leaves them uncategorized when target is nil
+func reassignCategory(ctx context.Context, tx *sql.Tx, source, target *Category) error {
+	now := time.Now()
+	if target == nil {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE transactions SET category_id = NULL, category = NULL, updated_at = $1 WHERE category_id = $2
+		`, now, source.ID); err != nil {
+			return fmt.Errorf("failed to uncategorize transactions: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE transaction_splits SET category_id = NULL, category = $1 WHERE category_id = $2
+		`, uncategorized, source.ID); err != nil {
+			return fmt.Errorf("failed to uncategorize splits: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE transactions SET category_id = $1, category = $2, updated_at = $3 WHERE category_id = $4
+	`, target.ID, target.Name, now, source.ID); err != nil {
+		return fmt.Errorf("failed to reassign transactions: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE transaction_splits SET category_id = $1, category = $2 WHERE category_id = $3
+	`, target.ID, target.Name, source.ID); err != nil {
+		return fmt.Errorf("failed to reassign splits: %w", err)
+	}
+	return nil
+}
+
+// syncCategoryName updates the category name recorded on a category's transactions and splits
+func syncCategoryName(ctx context.Context, tx *sql.Tx, id, name string) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE transactions SET category = $1 WHERE category_id = $2`, name, id); err != nil {
+		return fmt.Errorf("failed to rename transaction categories: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE transaction_splits SET category = $1 WHERE category_id = $2`, name, id); err != nil {
+		return fmt.Errorf("failed to rename split categories: %w", err)
+	}
+	return nil
+}
+
+// resolveCategory returns the ID of the user's category with the given name, creating a
+// top-level category the first time a name is used. Transfers and empty names have none.
+func resolveCategory(ctx context.Context, tx *sql.Tx, userID string, name *string) (*string, error) {
+	if name == nil || strings.TrimSpace(*name) == "" || *name == transferCategory {
+		return nil, nil
+	}
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO categories (id, user_id, name, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (user_id, name) DO NOTHING
+	`, generateID(), userID, *name, now); err != nil {
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+
+	var id string
+	if err := tx.QueryRowContext(ctx, `
+		SELECT id FROM categories WHERE user_id = $1 AND name = $2
+	`, userID, *name).Scan(&id); err != nil {
+		return nil, fmt.Errorf("failed to resolve category: %w", err)
+	}
+	return &id, nil
+}
+
+// categoryFor returns the category ID and name for a transaction or split given either
+// the ID of one of the user's categories or a category name
+func categoryFor(ctx context.Context, tx *sql.Tx, userID string, id, name *string) (*string, *string, error) {
+	if id == nil {
+		categoryID, err := resolveCategory(ctx, tx, userID, name)
+		return categoryID, name, err
+	}
+
+	var categoryName string
+	err := tx.QueryRowContext(ctx, `SELECT name FROM categories WHERE id = $1 AND user_id = $2`, *id, userID).Scan(&categoryName)
+	if err == sql.ErrNoRows {
+		return nil, nil, fmt.Errorf("category not found")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	return id, &categoryName, nil
+}
+
+// categoryRoots maps each of the user's category names to the name of its top-level
+// ancestor, for rolling spending up the hierarchy
+func (s *Service) categoryRoots(ctx context.Context, userID string) (map[string]string, error) {
+	categories, err := s.listCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	roots := make(map[string]string, len(categories))
+	for _, c := range categories {
+		root := c
+		// Bounded by the number of categories in case stored data has a cycle
+		for i := 0; root.ParentID != nil && i < len(categories); i++ {
+			parent, ok := byID[*root.ParentID]
+			if !ok {
+				break
+			}
+			root = parent
+		}
+		roots[c.Name] = root.Name
+	}
+	return roots, nil
+}
+
+// GetCategorySpendingRollup totals transaction amounts per top-level category and
+// currency, counting each subcategory toward its top-level ancestor
+func (s *Service) GetCategorySpendingRollup(ctx context.Context, from, to *time.Time) (*CategorySpendingResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	lines, err := s.GetCategoryLines(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := s.categoryRoots(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range lines {
+		if root, ok := roots[lines[i].Category]; ok {
+			lines[i].Category = root
+		}
+	}
+
+	return &CategorySpendingResponse{
+		From:       from,
+		To:         to,
+		Categories: totalCategoryLines(lines),
+	}, nil
+}
@@ -0,0 +1,90 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Source is the path a new transaction arrived through
+type Source string
+
+const (
+	SourceManual    Source = "manual"    // Created through the API
+	SourceStatement Source = "statement" // Committed from a statement import or push
+	SourceScheduled Source = "scheduled" // Posted from a scheduled transaction
+	SourceTransfer  Source = "transfer"  // One leg of a transfer between accounts
+	SourceSync      Source = "sync"      // Written by a provider sync
+)
+
+// NewTransaction is a transaction about to be written. Every path that creates
+// transactions builds one, passes it to ValidateNew before its database transaction
+// begins and then writes it with InsertTx, so validate handlers see every new transaction.
+type NewTransaction struct {
+	ID                 string         `json:"id"`
+	UserID             string         `json:"user_id"`
+	Source             Source         `json:"source"`
+	AccountID          *string        `json:"account_id,omitempty"`
+	Date               time.Time      `json:"date"`
+	Description        string         `json:"description"`
+	Amount             float64        `json:"amount"`
+	Currency           string         `json:"currency"`
+	Category           *string        `json:"category,omitempty"`    // Category name; a new name creates a top-level category
+	CategoryID         *string        `json:"category_id,omitempty"` // Takes precedence over category
+	Notes              *string        `json:"notes,omitempty"`
+	Status             string         `json:"status"` // pending or posted (default)
+	Splits             []SplitRequest `json:"splits,omitempty"`
+	TransferID         *string        `json:"transfer_id,omitempty"`
+	ProviderActivityID *string        `json:"provider_activity_id,omitempty"`
+	IncomeRecordID     *string        `json:"-"`
+
+	validated bool
+}
+
+// ValidateNew runs the validate handlers over transactions about to be written. Call it
+// before opening the database transaction they are written in: handlers may call out over
+// the network and record their outcome, which would otherwise wait on the write lock.
+func (s *Service) ValidateNew(ctx context.Context, rows ...*NewTransaction) error {
+	for _, t := range rows {
+		if t.ID == "" {
+			t.ID = generateID()
+		}
+		if t.Status == "" {
+			t.Status = StatusPosted
+		}
+		for _, validate := range s.validateHandlers {
+			if err := validate(ctx, t); err != nil {
+				return err
+			}
+		}
+		t.validated = true
+	}
+	return nil
+}
+
+// InsertTx writes a validated transaction and its splits within tx, resolving its category
+func (s *Service) InsertTx(ctx context.Context, tx *sql.Tx, t *NewTransaction) error {
+	if !t.validated {
+		return fmt.Errorf("transaction %s was not validated before it was written", t.ID)
+	}
+
+	categoryID, category, err := categoryFor(ctx, tx, t.UserID, t.CategoryID, t.Category)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO transactions (
+			id, user_id, account_id, date, description, amount, currency, category, category_id, notes, status,
+			transfer_id, provider_activity_id, income_record_id, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $15)
+	`, t.ID, t.UserID, t.AccountID, t.Date, t.Description, t.Amount, t.Currency, category, categoryID, t.Notes, t.Status,
+		t.TransferID, t.ProviderActivityID, t.IncomeRecordID, now)
+	if err != nil {
+		return fmt.Errorf("failed to create transaction: %w", err)
+	}
+
+	return insertSplits(ctx, tx, t.UserID, t.ID, t.Splits)
+}
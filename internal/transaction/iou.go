@@ -0,0 +1,411 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"money/internal/auth"
+)
+
+// IOUEntryType is the kind of change to what a person owes
+type IOUEntryType string
+
+const (
+	IOUEntrySplit      IOUEntryType = "split"      // A share of a shared expense
+	IOUEntrySettlement IOUEntryType = "settlement" // Money paid back in either direction
+)
+
+// IOUPerson is someone outside the app the user shares expenses with
+type IOUPerson struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Currency  string    `json:"currency"`
+	Notes     *string   `json:"notes,omitempty"`
+	Balance   float64   `json:"balance"` // Positive when they owe the user, negative when the user owes them
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IOUPersonRequest is the request for creating or updating a person
+type IOUPersonRequest struct {
+	Name     string  `json:"name"`
+	Currency string  `json:"currency,omitempty"` // Defaults to CAD; fixed once the person has entries
+	Notes    *string `json:"notes,omitempty"`
+}
+
+// IOUEntry is one line of a person's ledger
+type IOUEntry struct {
+	ID            string       `json:"id"`
+	PersonID      string       `json:"person_id"`
+	TransactionID *string      `json:"transaction_id,omitempty"`
+	Type          IOUEntryType `json:"type"`
+	Amount        float64      `json:"amount"` // Positive when the person owes the user more
+	Date          time.Time    `json:"date"`
+	Description   string       `json:"description"`
+	Balance       float64      `json:"balance"` // Running balance after this entry
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// IOUEntryRequest records an entry that isn't tied to one of the user's transactions, such as
+// an expense the other person paid. For a split the amount is what the person owes the user,
+// negative when the user owes them. For a settlement it is what the person paid the user,
+// negative when the user paid them.
+type IOUEntryRequest struct {
+	Type          IOUEntryType `json:"type"`
+	Amount        float64      `json:"amount"`
+	Date          time.Time    `json:"date"`
+	Description   string       `json:"description"`
+	TransactionID *string      `json:"transaction_id,omitempty"`
+}
+
+// SplitWithPersonRequest tags a transaction as shared with a person
+type SplitWithPersonRequest struct {
+	PersonID    string   `json:"person_id"`
+	Share       *float64 `json:"share,omitempty"` // The person's part of the amount; defaults to half
+	Description string   `json:"description,omitempty"`
+}
+
+// IOULedger is a person's entries, oldest first, with running balances
+type IOULedger struct {
+	Person  IOUPerson  `json:"person"`
+	Entries []IOUEntry `json:"entries"`
+}
+
+// ListIOUPeopleResponse lists people with what is owed in each direction
+type ListIOUPeopleResponse struct {
+	People    []IOUPerson        `json:"people"`
+	OwedToYou map[string]float64 `json:"owed_to_you"` // currency -> total people owe the user
+	YouOwe    map[string]float64 `json:"you_owe"`     // currency -> total the user owes people
+}
+
+// CreateIOUPerson adds a person to share expenses with
+func (s *Service) CreateIOUPerson(ctx context.Context, req *IOUPersonRequest) (*IOUPerson, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if req.Currency == "" {
+		req.Currency = "CAD"
+	}
+
+	id := generateID()
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO iou_people (id, user_id, name, currency, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, id, userID, req.Name, req.Currency, req.Notes, now, now)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return nil, fmt.Errorf("a person named %q already exists", req.Name)
+		}
+		return nil, fmt.Errorf("failed to create person: %w", err)
+	}
+
+	return s.getIOUPerson(ctx, id)
+}
+
+// ListIOUPeople lists the people the user shares expenses with and their balances
+func (s *Service) ListIOUPeople(ctx context.Context) (*ListIOUPeopleResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+iouPersonColumns+`
+		FROM iou_people p
+		WHERE p.user_id = $1
+		ORDER BY p.name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list people: %w", err)
+	}
+	defer rows.Close()
+
+	resp := &ListIOUPeopleResponse{
+		People:    []IOUPerson{},
+		OwedToYou: make(map[string]float64),
+		YouOwe:    make(map[string]float64),
+	}
+	for rows.Next() {
+		person, err := scanIOUPerson(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan person: %w", err)
+		}
+		if person.Balance > 0 {
+			resp.OwedToYou[person.Currency] = roundCents(resp.OwedToYou[person.Currency] + person.Balance)
+		} else if person.Balance < 0 {
+			resp.YouOwe[person.Currency] = roundCents(resp.YouOwe[person.Currency] - person.Balance)
+		}
+		resp.People = append(resp.People, *person)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list people: %w", err)
+	}
+	return resp, nil
+}
+
+// GetIOULedger gets a person's ledger with a running balance on each entry
+func (s *Service) GetIOULedger(ctx context.Context, personID string) (*IOULedger, error) {
+	person, err := s.getIOUPerson(ctx, personID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, person_id, transaction_id, type, amount, date, description, created_at
+		FROM iou_entries
+		WHERE person_id = $1
+		ORDER BY date, created_at
+	`, personID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger: %w", err)
+	}
+	defer rows.Close()
+
+	ledger := &IOULedger{Person: *person, Entries: []IOUEntry{}}
+	balance := 0.0
+	for rows.Next() {
+		var e IOUEntry
+		if err := rows.Scan(&e.ID, &e.PersonID, &e.TransactionID, &e.Type, &e.Amount, &e.Date, &e.Description, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger entry: %w", err)
+		}
+		balance = roundCents(balance + e.Amount)
+		e.Balance = balance
+		ledger.Entries = append(ledger.Entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get ledger: %w", err)
+	}
+	return ledger, nil
+}
+
+// UpdateIOUPerson renames a person or changes their notes or currency
+func (s *Service) UpdateIOUPerson(ctx context.Context, personID string, req *IOUPersonRequest) (*IOUPerson, error) {
+	person, err := s.getIOUPerson(ctx, personID)
+	if err != nil {
+		return nil, err
+	}
+
+	if name := strings.TrimSpace(req.Name); name != "" {
+		person.Name = name
+	}
+	if req.Notes != nil {
+		person.Notes = req.Notes
+	}
+	if req.Currency != "" && req.Currency != person.Currency {
+		var entries int
+		if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM iou_entries WHERE person_id = $1`, personID).Scan(&entries); err != nil {
+			return nil, fmt.Errorf("failed to check ledger: %w", err)
+		}
+		if entries > 0 {
+			return nil, fmt.Errorf("cannot change the currency of a person with ledger entries")
+		}
+		person.Currency = req.Currency
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE iou_people SET name = $1, currency = $2, notes = $3, updated_at = $4 WHERE id = $5
+	`, person.Name, person.Currency, person.Notes, time.Now(), personID)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return nil, fmt.Errorf("a person named %q already exists", person.Name)
+		}
+		return nil, fmt.Errorf("failed to update person: %w", err)
+	}
+
+	return s.getIOUPerson(ctx, personID)
+}
+
+// DeleteIOUPerson removes a person and their ledger. The transactions they shared are kept.
+func (s *Service) DeleteIOUPerson(ctx context.Context, personID string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM iou_people WHERE id = $1 AND user_id = $2`, personID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete person: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RecordIOUEntry adds a split or settlement to a person's ledger
+func (s *Service) RecordIOUEntry(ctx context.Context, personID string, req *IOUEntryRequest) (*IOUEntry, error) {
+	if _, err := s.getIOUPerson(ctx, personID); err != nil {
+		return nil, err
+	}
+
+	amount := roundCents(req.Amount)
+	switch req.Type {
+	case IOUEntrySplit:
+	case IOUEntrySettlement:
+		// A payment from the person reduces what they owe
+		amount = -amount
+	default:
+		return nil, fmt.Errorf("type must be split or settlement")
+	}
+	if amount == 0 {
+		return nil, fmt.Errorf("amount is required")
+	}
+	if req.Date.IsZero() {
+		return nil, fmt.Errorf("date is required")
+	}
+	if req.Description == "" {
+		if req.Type == IOUEntrySettlement {
+			req.Description = "Settlement"
+		} else {
+			return nil, fmt.Errorf("description is required")
+		}
+	}
+	if req.TransactionID != nil {
+		if _, err := s.GetTransaction(ctx, *req.TransactionID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.insertIOUEntry(ctx, personID, req.TransactionID, req.Type, amount, req.Date, req.Description)
+}
+
+// SplitTransactionWithPerson records a person's share of one of the user's transactions. For
+// money the user paid out the person owes their share; for money the user received, such as
+// a refund, the user owes it to them.
+func (s *Service) SplitTransactionWithPerson(ctx context.Context, transactionID string, req *SplitWithPersonRequest) (*IOUEntry, error) {
+	t, err := s.GetTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	person, err := s.getIOUPerson(ctx, req.PersonID)
+	if err != nil {
+		return nil, err
+	}
+	if t.TransferID != nil {
+		return nil, fmt.Errorf("transfers between accounts cannot be split")
+	}
+	if t.Currency != person.Currency {
+		return nil, fmt.Errorf("transaction is in %s but %s's ledger is in %s", t.Currency, person.Name, person.Currency)
+	}
+
+	var exists bool
+	err = s.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM iou_entries WHERE person_id = $1 AND transaction_id = $2 AND type = $3)
+	`, person.ID, t.ID, IOUEntrySplit).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing split: %w", err)
+	}
+	if exists {
+		return nil, fmt.Errorf("transaction is already split with %s", person.Name)
+	}
+
+	share := math.Abs(t.Amount) / 2
+	if req.Share != nil {
+		share = math.Abs(*req.Share)
+	}
+	share = roundCents(share)
+	if share == 0 || share > math.Abs(t.Amount) {
+		return nil, fmt.Errorf("share must be between 0 and the transaction amount")
+	}
+	amount := share
+	if t.Amount > 0 {
+		amount = -share
+	}
+
+	description := req.Description
+	if description == "" {
+		description = t.Description
+	}
+	return s.insertIOUEntry(ctx, person.ID, &t.ID, IOUEntrySplit, amount, t.Date, description)
+}
+
+// DeleteIOUEntry removes an entry from a person's ledger
+func (s *Service) DeleteIOUEntry(ctx context.Context, personID, entryID string) error {
+	if _, err := s.getIOUPerson(ctx, personID); err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM iou_entries WHERE id = $1 AND person_id = $2`, entryID, personID)
+	if err != nil {
+		return fmt.Errorf("failed to delete ledger entry: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Service) insertIOUEntry(ctx context.Context, personID string, transactionID *string, entryType IOUEntryType, amount float64, date time.Time, description string) (*IOUEntry, error) {
+	entry := &IOUEntry{
+		ID:            generateID(),
+		PersonID:      personID,
+		TransactionID: transactionID,
+		Type:          entryType,
+		Amount:        amount,
+		Date:          date,
+		Description:   description,
+		CreatedAt:     time.Now(),
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO iou_entries (id, person_id, transaction_id, type, amount, date, description, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, entry.ID, entry.PersonID, entry.TransactionID, entry.Type, entry.Amount, entry.Date, entry.Description, entry.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record ledger entry: %w", err)
+	}
+
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(amount), 0) FROM iou_entries WHERE person_id = $1
+	`, personID).Scan(&entry.Balance); err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+	entry.Balance = roundCents(entry.Balance)
+	return entry, nil
+}
+
+// getIOUPerson gets one of the user's people with their balance
+func (s *Service) getIOUPerson(ctx context.Context, personID string) (*IOUPerson, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+iouPersonColumns+`
+		FROM iou_people p
+		WHERE p.id = $1 AND p.user_id = $2
+	`, personID, userID)
+	person, err := scanIOUPerson(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get person: %w", err)
+	}
+	return person, nil
+}
+
+// iouPersonColumns are the columns read into an IOUPerson from iou_people aliased as p
+const iouPersonColumns = `p.id, p.name, p.currency, p.notes,
+		(SELECT COALESCE(SUM(e.amount), 0) FROM iou_entries e WHERE e.person_id = p.id),
+		p.created_at, p.updated_at`
+
+func scanIOUPerson(row interface{ Scan(...interface{}) error }) (*IOUPerson, error) {
+	var p IOUPerson
+	if err := row.Scan(&p.ID, &p.Name, &p.Currency, &p.Notes, &p.Balance, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	p.Balance = roundCents(p.Balance)
+	return &p, nil
+}
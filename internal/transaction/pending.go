@@ -0,0 +1,158 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"money/internal/auth"
+)
+
+// Transaction statuses
+const (
+	StatusPending = "pending"
+	StatusPosted  = "posted"
+)
+
+const (
+	// settlementWindowDays is how long after a pending transaction its posted transaction
+	// may arrive and still settle it
+	settlementWindowDays = 10
+	// settlementTolerance is how far a posted amount may drift from the pending amount, as a
+	// share of it. Tips and currency conversion often change the amount on posting.
+	settlementTolerance = 0.25
+)
+
+// SettlePendingRequest links a pending transaction to the posted transaction that replaced it
+type SettlePendingRequest struct {
+	PostedTransactionID string `json:"posted_transaction_id"`
+}
+
+// SettlePending links a pending transaction to its posted transaction when automatic
+// matching missed it, for example because the amount changed beyond the tolerance
+func (s *Service) SettlePending(ctx context.Context, pendingID string, req *SettlePendingRequest) (*Transaction, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if req.PostedTransactionID == "" {
+		return nil, fmt.Errorf("posted_transaction_id is required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := settlePending(ctx, tx, userID, pendingID, req.PostedTransactionID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return s.GetTransaction(ctx, pendingID)
+}
+
+// findPendingMatch finds the unsettled pending transaction on the account that a posted
+// transaction most likely replaces: same currency and direction, dated up to the settlement
+// window before it, with the closest amount and then the closest date
+func findPendingMatch(ctx context.Context, tx *sql.Tx, userID, accountID string, date time.Time, amount float64, currency string) (*string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, date, amount
+		FROM transactions
+		WHERE user_id = $1 AND account_id = $2 AND currency = $3
+		  AND status = $4 AND settled_transaction_id IS NULL
+		  AND date >= $5 AND date <= $6
+	`, userID, accountID, currency, StatusPending,
+		date.Add(-settlementWindowDays*24*time.Hour), date.Add(24*time.Hour))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pending transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var best *string
+	bestAmountDiff, bestDays := math.Inf(1), math.Inf(1)
+	for rows.Next() {
+		var id string
+		var pendingDate time.Time
+		var pendingAmount float64
+		if err := rows.Scan(&id, &pendingDate, &pendingAmount); err != nil {
+			return nil, fmt.Errorf("failed to scan pending transaction: %w", err)
+		}
+
+		days := date.Sub(pendingDate).Hours() / 24
+		if (pendingAmount < 0) != (amount < 0) {
+			continue
+		}
+		amountDiff := math.Abs(amount - pendingAmount)
+		if amountDiff > math.Abs(pendingAmount)*settlementTolerance {
+			continue
+		}
+		if amountDiff < bestAmountDiff || (amountDiff == bestAmountDiff && math.Abs(days) < bestDays) {
+			matchID := id
+			best = &matchID
+			bestAmountDiff, bestDays = amountDiff, math.Abs(days)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find pending transactions: %w", err)
+	}
+	return best, nil
+}
+
+// settlePending marks a pending transaction as replaced by a posted one. The posted
+// transaction takes the pending one's category when it has none of its own, so a category
+// picked while the charge was pending isn't lost.
+func settlePending(ctx context.Context, tx *sql.Tx, userID, pendingID, postedID string) error {
+	var status string
+	var settledBy *string
+	err := tx.QueryRowContext(ctx, `
+		SELECT status, settled_transaction_id FROM transactions WHERE id = $1 AND user_id = $2
+	`, pendingID, userID).Scan(&status, &settledBy)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get pending transaction: %w", err)
+	}
+	if status != StatusPending {
+		return fmt.Errorf("transaction is not pending")
+	}
+	if settledBy != nil {
+		return fmt.Errorf("pending transaction is already settled")
+	}
+
+	var postedStatus string
+	err = tx.QueryRowContext(ctx, `
+		SELECT status FROM transactions WHERE id = $1 AND user_id = $2
+	`, postedID, userID).Scan(&postedStatus)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get posted transaction: %w", err)
+	}
+	if postedStatus != StatusPosted {
+		return fmt.Errorf("a pending transaction can only be settled by a posted one")
+	}
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE transactions SET settled_transaction_id = $1, updated_at = $2 WHERE id = $3
+	`, postedID, now, pendingID); err != nil {
+		return fmt.Errorf("failed to settle pending transaction: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE transactions
+		SET category = (SELECT category FROM transactions WHERE id = $1), updated_at = $2
+		WHERE id = $3 AND (category IS NULL OR category = '')
+	`, pendingID, now, postedID); err != nil {
+		return fmt.Errorf("failed to carry over category: %w", err)
+	}
+	return nil
+}
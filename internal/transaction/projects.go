@@ -0,0 +1,594 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/database"
+)
+
+// ProjectStatus is whether a project is still being spent on
+type ProjectStatus string
+
+const (
+	ProjectStatusActive    ProjectStatus = "active"
+	ProjectStatusCompleted ProjectStatus = "completed"
+)
+
+// Project groups the transactions and planned expenses of something like a trip or a
+// renovation, across accounts, against a budget
+type Project struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description *string         `json:"description,omitempty"`
+	Budget      float64         `json:"budget"`
+	Currency    string          `json:"currency"`
+	StartDate   *time.Time      `json:"start_date,omitempty"`
+	EndDate     *time.Time      `json:"end_date,omitempty"`
+	Status      ProjectStatus   `json:"status"`
+	Progress    ProjectProgress `json:"progress"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// ProjectProgress compares what a project has spent and still plans to spend with its budget
+type ProjectProgress struct {
+	Spent          float64  `json:"spent"`       // Net outflow of the tagged transactions
+	Planned        float64  `json:"planned"`     // Total of all planned expenses
+	Outstanding    float64  `json:"outstanding"` // Planned expenses not yet paid
+	Committed      float64  `json:"committed"`   // Spent plus outstanding
+	Remaining      float64  `json:"remaining"`   // Budget left after what is committed
+	PercentUsed    float64  `json:"percent_used"`
+	OverBudget     bool     `json:"over_budget"`
+	Transactions   int      `json:"transactions"`
+	ElapsedPercent *float64 `json:"elapsed_percent,omitempty"` // How far through its timeline the project is
+}
+
+// ProjectPlannedExpense is an expense a project expects to pay
+type ProjectPlannedExpense struct {
+	ID            string    `json:"id"`
+	ProjectID     string    `json:"project_id"`
+	Description   string    `json:"description"`
+	Amount        float64   `json:"amount"`
+	Date          time.Time `json:"date"`
+	TransactionID *string   `json:"transaction_id,omitempty"` // Transaction that paid it; unset while outstanding
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ProjectDetail is a project with its transactions and planned expenses
+type ProjectDetail struct {
+	Project
+	Transactions    []Transaction           `json:"transactions"`
+	PlannedExpenses []ProjectPlannedExpense `json:"planned_expenses"`
+}
+
+// ProjectRequest is the request for creating or updating a project
+type ProjectRequest struct {
+	Name        string         `json:"name"`
+	Description *string        `json:"description,omitempty"`
+	Budget      *float64       `json:"budget,omitempty"`
+	Currency    string         `json:"currency,omitempty"` // Defaults to CAD
+	StartDate   *time.Time     `json:"start_date,omitempty"`
+	EndDate     *time.Time     `json:"end_date,omitempty"`
+	Status      *ProjectStatus `json:"status,omitempty"`
+}
+
+// TagProjectTransactionsRequest adds transactions to a project
+type TagProjectTransactionsRequest struct {
+	TransactionIDs []string `json:"transaction_ids"`
+}
+
+// PlannedExpenseRequest is the request for adding or updating a planned expense
+type PlannedExpenseRequest struct {
+	Description   string     `json:"description"`
+	Amount        float64    `json:"amount"`
+	Date          *time.Time `json:"date,omitempty"`
+	TransactionID *string    `json:"transaction_id,omitempty"` // Marks the expense paid
+}
+
+// ListProjectsResponse is the response for listing projects
+type ListProjectsResponse struct {
+	Projects []Project `json:"projects"`
+}
+
+// CreateProject creates a project
+func (s *Service) CreateProject(ctx context.Context, req *ProjectRequest) (*Project, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	project := &Project{
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Currency:    req.Currency,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		Status:      ProjectStatusActive,
+	}
+	if req.Budget != nil {
+		project.Budget = *req.Budget
+	}
+	if project.Currency == "" {
+		project.Currency = "CAD"
+	}
+	if req.Status != nil {
+		project.Status = *req.Status
+	}
+	if err := validateProject(project); err != nil {
+		return nil, err
+	}
+
+	id := generateID()
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO projects (id, user_id, name, description, budget, currency, start_date, end_date, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, id, userID, project.Name, project.Description, project.Budget, project.Currency,
+		project.StartDate, project.EndDate, project.Status, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	return s.getProject(ctx, id)
+}
+
+// ListProjects lists the user's projects with their progress, optionally by status
+func (s *Service) ListProjects(ctx context.Context, status ProjectStatus) (*ListProjectsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+projectColumns+`
+		FROM projects p
+		WHERE p.user_id = $1 AND ($2 = '' OR p.status = $2)
+		ORDER BY p.status, COALESCE(p.start_date, p.created_at) DESC
+	`, userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	projects := []Project{}
+	for rows.Next() {
+		project, err := scanProject(rows, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		projects = append(projects, *project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	return &ListProjectsResponse{Projects: projects}, nil
+}
+
+// GetProject gets a project with its transactions and planned expenses
+func (s *Service) GetProject(ctx context.Context, id string) (*ProjectDetail, error) {
+	project, err := s.getProject(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	detail := &ProjectDetail{Project: *project, Transactions: []Transaction{}}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+transactionColumns+`
+		FROM transactions t
+		JOIN project_transactions pt ON pt.transaction_id = t.id
+		WHERE pt.project_id = $1
+		ORDER BY t.date, t.created_at
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project transactions: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		detail.Transactions = append(detail.Transactions, *t)
+		ids = append(ids, t.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get project transactions: %w", err)
+	}
+
+	splits, err := s.getSplits(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range detail.Transactions {
+		detail.Transactions[i].Splits = splits[detail.Transactions[i].ID]
+		if detail.Transactions[i].Splits == nil {
+			detail.Transactions[i].Splits = []TransactionSplit{}
+		}
+	}
+
+	detail.PlannedExpenses, err = s.listPlannedExpenses(ctx, `WHERE pe.project_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// UpdateProject updates a project's details, budget, timeline or status
+func (s *Service) UpdateProject(ctx context.Context, id string, req *ProjectRequest) (*Project, error) {
+	project, err := s.getProject(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if name := strings.TrimSpace(req.Name); name != "" {
+		project.Name = name
+	}
+	if req.Description != nil {
+		project.Description = req.Description
+	}
+	if req.Budget != nil {
+		project.Budget = *req.Budget
+	}
+	if req.Currency != "" {
+		project.Currency = req.Currency
+	}
+	if req.StartDate != nil {
+		project.StartDate = req.StartDate
+	}
+	if req.EndDate != nil {
+		project.EndDate = req.EndDate
+	}
+	if req.Status != nil {
+		project.Status = *req.Status
+	}
+	if err := validateProject(project); err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE projects
+		SET name = $1, description = $2, budget = $3, currency = $4, start_date = $5, end_date = $6, status = $7, updated_at = $8
+		WHERE id = $9
+	`, project.Name, project.Description, project.Budget, project.Currency, project.StartDate, project.EndDate,
+		project.Status, time.Now(), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update project: %w", err)
+	}
+
+	return s.getProject(ctx, id)
+}
+
+// DeleteProject deletes a project. Its transactions are kept.
+func (s *Service) DeleteProject(ctx context.Context, id string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM projects WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// TagProjectTransactions counts transactions from any of the user's accounts toward a project
+func (s *Service) TagProjectTransactions(ctx context.Context, id string, req *TagProjectTransactionsRequest) (*Project, error) {
+	project, err := s.getProject(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.TransactionIDs) == 0 {
+		return nil, fmt.Errorf("transaction_ids is required")
+	}
+
+	for _, transactionID := range req.TransactionIDs {
+		t, err := s.GetTransaction(ctx, transactionID)
+		if err != nil {
+			if err == ErrNotFound {
+				return nil, fmt.Errorf("transaction not found: %s", transactionID)
+			}
+			return nil, err
+		}
+		if t.Currency != project.Currency {
+			return nil, fmt.Errorf("transaction %s is in %s but the project is in %s", t.ID, t.Currency, project.Currency)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, transactionID := range req.TransactionIDs {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO project_transactions (project_id, transaction_id, created_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (project_id, transaction_id) DO NOTHING
+		`, id, transactionID, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to tag transaction: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return s.getProject(ctx, id)
+}
+
+// UntagProjectTransaction stops counting a transaction toward a project
+func (s *Service) UntagProjectTransaction(ctx context.Context, id, transactionID string) error {
+	if _, err := s.getProject(ctx, id); err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM project_transactions WHERE project_id = $1 AND transaction_id = $2
+	`, id, transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to untag transaction: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddPlannedExpense adds an expense the project expects to pay
+func (s *Service) AddPlannedExpense(ctx context.Context, id string, req *PlannedExpenseRequest) (*ProjectPlannedExpense, error) {
+	if _, err := s.getProject(ctx, id); err != nil {
+		return nil, err
+	}
+	if req.Description == "" {
+		return nil, fmt.Errorf("description is required")
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+	if req.Date == nil || req.Date.IsZero() {
+		return nil, fmt.Errorf("date is required")
+	}
+	if err := s.checkProjectTransaction(ctx, id, req.TransactionID); err != nil {
+		return nil, err
+	}
+
+	plannedID := generateID()
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO project_planned_expenses (id, project_id, description, amount, date, transaction_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, plannedID, id, req.Description, req.Amount, *req.Date, req.TransactionID, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add planned expense: %w", err)
+	}
+
+	return s.getPlannedExpense(ctx, id, plannedID)
+}
+
+// UpdatePlannedExpense changes a planned expense, or marks it paid by a transaction
+func (s *Service) UpdatePlannedExpense(ctx context.Context, id, plannedID string, req *PlannedExpenseRequest) (*ProjectPlannedExpense, error) {
+	if _, err := s.getProject(ctx, id); err != nil {
+		return nil, err
+	}
+	planned, err := s.getPlannedExpense(ctx, id, plannedID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Description != "" {
+		planned.Description = req.Description
+	}
+	if req.Amount != 0 {
+		if req.Amount < 0 {
+			return nil, fmt.Errorf("amount must be greater than zero")
+		}
+		planned.Amount = req.Amount
+	}
+	if req.Date != nil && !req.Date.IsZero() {
+		planned.Date = *req.Date
+	}
+	if req.TransactionID != nil {
+		if err := s.checkProjectTransaction(ctx, id, req.TransactionID); err != nil {
+			return nil, err
+		}
+		planned.TransactionID = req.TransactionID
+		if *req.TransactionID == "" {
+			planned.TransactionID = nil
+		}
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE project_planned_expenses
+		SET description = $1, amount = $2, date = $3, transaction_id = $4, updated_at = $5
+		WHERE id = $6
+	`, planned.Description, planned.Amount, planned.Date, planned.TransactionID, time.Now(), plannedID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update planned expense: %w", err)
+	}
+
+	return s.getPlannedExpense(ctx, id, plannedID)
+}
+
+// DeletePlannedExpense removes a planned expense
+func (s *Service) DeletePlannedExpense(ctx context.Context, id, plannedID string) error {
+	if _, err := s.getProject(ctx, id); err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM project_planned_expenses WHERE id = $1 AND project_id = $2
+	`, plannedID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete planned expense: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListOutstandingPlannedExpenses lists the unpaid planned expenses of the user's active
+// projects, for projecting what they will still cost
+func (s *Service) ListOutstandingPlannedExpenses(ctx context.Context) ([]ProjectPlannedExpense, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	return s.listPlannedExpenses(ctx, `
+		JOIN projects p ON p.id = pe.project_id
+		WHERE p.user_id = $1 AND p.status = 'active' AND pe.transaction_id IS NULL
+	`, userID)
+}
+
+// checkProjectTransaction verifies that a transaction marking a planned expense paid belongs
+// to the user, tagging it to the project so its spend is counted
+func (s *Service) checkProjectTransaction(ctx context.Context, id string, transactionID *string) error {
+	if transactionID == nil || *transactionID == "" {
+		return nil
+	}
+	if _, err := s.GetTransaction(ctx, *transactionID); err != nil {
+		if err == ErrNotFound {
+			return fmt.Errorf("transaction not found: %s", *transactionID)
+		}
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO project_transactions (project_id, transaction_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (project_id, transaction_id) DO NOTHING
+	`, id, *transactionID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to tag transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) getProject(ctx context.Context, id string) (*Project, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+projectColumns+`
+		FROM projects p
+		WHERE p.id = $1 AND p.user_id = $2
+	`, id, userID)
+	project, err := scanProject(row, time.Now())
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	return project, nil
+}
+
+func (s *Service) getPlannedExpense(ctx context.Context, id, plannedID string) (*ProjectPlannedExpense, error) {
+	planned, err := s.listPlannedExpenses(ctx, `WHERE pe.project_id = $1 AND pe.id = $2`, id, plannedID)
+	if err != nil {
+		return nil, err
+	}
+	if len(planned) == 0 {
+		return nil, ErrNotFound
+	}
+	return &planned[0], nil
+}
+
+// listPlannedExpenses lists planned expenses, aliased as pe, matching a join and where clause
+func (s *Service) listPlannedExpenses(ctx context.Context, where string, args ...any) ([]ProjectPlannedExpense, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT pe.id, pe.project_id, pe.description, pe.amount, pe.date, pe.transaction_id, pe.created_at, pe.updated_at
+		FROM project_planned_expenses pe
+		`+where+`
+		ORDER BY pe.date, pe.created_at
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get planned expenses: %w", err)
+	}
+	defer rows.Close()
+
+	planned := []ProjectPlannedExpense{}
+	for rows.Next() {
+		var pe ProjectPlannedExpense
+		if err := rows.Scan(&pe.ID, &pe.ProjectID, &pe.Description, &pe.Amount, &pe.Date, &pe.TransactionID, &pe.CreatedAt, &pe.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan planned expense: %w", err)
+		}
+		planned = append(planned, pe)
+	}
+	return planned, rows.Err()
+}
+
+func validateProject(p *Project) error {
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if p.Budget < 0 {
+		return fmt.Errorf("budget must not be negative")
+	}
+	if p.StartDate != nil && p.EndDate != nil && p.EndDate.Before(*p.StartDate) {
+		return fmt.Errorf("end_date must not be before start_date")
+	}
+	if p.Status != ProjectStatusActive && p.Status != ProjectStatusCompleted {
+		return fmt.Errorf("status must be active or completed")
+	}
+	return nil
+}
+
+// projectColumns are the columns read into a Project from projects aliased as p, with the
+// sums its progress is worked out from
+const projectColumns = `p.id, p.name, p.description, p.budget, p.currency, p.start_date, p.end_date, p.status,
+		p.created_at, p.updated_at,
+		(SELECT COALESCE(-SUM(t.amount), 0) FROM transactions t
+		 JOIN project_transactions pt ON pt.transaction_id = t.id WHERE pt.project_id = p.id),
+		(SELECT COUNT(*) FROM project_transactions pt WHERE pt.project_id = p.id),
+		(SELECT COALESCE(SUM(pe.amount), 0) FROM project_planned_expenses pe WHERE pe.project_id = p.id),
+		(SELECT COALESCE(SUM(pe.amount), 0) FROM project_planned_expenses pe
+		 WHERE pe.project_id = p.id AND pe.transaction_id IS NULL)`
+
+func scanProject(row database.Scanner, now time.Time) (*Project, error) {
+	var p Project
+	var progress ProjectProgress
+	err := row.Scan(
+		&p.ID, &p.Name, &p.Description, &p.Budget, &p.Currency, &p.StartDate, &p.EndDate, &p.Status,
+		&p.CreatedAt, &p.UpdatedAt,
+		&progress.Spent, &progress.Transactions, &progress.Planned, &progress.Outstanding,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	progress.Spent = roundCents(progress.Spent)
+	progress.Planned = roundCents(progress.Planned)
+	progress.Outstanding = roundCents(progress.Outstanding)
+	progress.Committed = roundCents(progress.Spent + progress.Outstanding)
+	progress.Remaining = roundCents(p.Budget - progress.Committed)
+	if p.Budget > 0 {
+		progress.PercentUsed = math.Round(progress.Spent/p.Budget*10000) / 100
+		progress.OverBudget = progress.Committed > p.Budget
+	}
+	if p.StartDate != nil && p.EndDate != nil && p.EndDate.After(*p.StartDate) {
+		elapsed := now.Sub(*p.StartDate).Hours() / p.EndDate.Sub(*p.StartDate).Hours() * 100
+		elapsed = math.Round(math.Min(math.Max(elapsed, 0), 100)*100) / 100
+		progress.ElapsedPercent = &elapsed
+	}
+	p.Progress = progress
+	return &p, nil
+}
@@ -0,0 +1,178 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/statement"
+)
+
+const (
+	// PushTemplate marks statement imports pushed by external scripts rather than parsed from a PDF
+	PushTemplate = "push"
+	// maxPushTransactions caps the lines of a single push
+	maxPushTransactions = 1000
+	// maxIdempotencyKeyLength caps idempotency keys, which are stored as they are
+	maxIdempotencyKeyLength = 255
+)
+
+// PushStatementRequest is a balance and transactions pushed by an external script, such as a
+// bank scraper the user runs. It is staged for review like an uploaded statement.
+type PushStatementRequest struct {
+	AccountID      string                `json:"account_id"`
+	Source         string                `json:"source"`                     // Shown as the institution, e.g. "my-bank-scraper"
+	BalanceDate    *time.Time            `json:"balance_date,omitempty"`     // Required with balance
+	Balance        *float64              `json:"balance,omitempty"`          // Recorded on balance_date when committed
+	OpeningBalance *float64              `json:"opening_balance,omitempty"`  // Informational, as on a statement
+	Transactions   []PushTransactionLine `json:"transactions,omitempty"`
+	Warnings       []string              `json:"warnings,omitempty"`
+}
+
+// PushTransactionLine is one pushed transaction
+type PushTransactionLine struct {
+	Date        time.Time `json:"date"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"` // Negative for money out, positive for money in
+}
+
+// PushStatementResponse is the staged import. Replayed is set when the idempotency key was
+// seen before, in which case nothing new was staged.
+type PushStatementResponse struct {
+	Import   *StatementImport `json:"import"`
+	Replayed bool             `json:"replayed"`
+}
+
+// Validate checks a pushed statement against the schema
+func (r *PushStatementRequest) Validate() error {
+	if r.AccountID == "" {
+		return fmt.Errorf("account_id is required")
+	}
+	if strings.TrimSpace(r.Source) == "" {
+		return fmt.Errorf("source is required")
+	}
+	if len(r.Source) > 100 {
+		return fmt.Errorf("source must be at most 100 characters")
+	}
+	if r.Balance == nil && len(r.Transactions) == 0 {
+		return fmt.Errorf("balance or transactions are required")
+	}
+	if r.Balance != nil {
+		if r.BalanceDate == nil || r.BalanceDate.IsZero() {
+			return fmt.Errorf("balance_date is required with balance")
+		}
+		if math.IsNaN(*r.Balance) || math.IsInf(*r.Balance, 0) {
+			return fmt.Errorf("balance must be a number")
+		}
+	} else if r.BalanceDate != nil {
+		return fmt.Errorf("balance_date requires balance")
+	}
+	if len(r.Transactions) > maxPushTransactions {
+		return fmt.Errorf("at most %d transactions can be pushed at once", maxPushTransactions)
+	}
+	for i, line := range r.Transactions {
+		if line.Date.IsZero() {
+			return fmt.Errorf("transactions[%d].date is required", i)
+		}
+		if strings.TrimSpace(line.Description) == "" {
+			return fmt.Errorf("transactions[%d].description is required", i)
+		}
+		if line.Amount == 0 || math.IsNaN(line.Amount) || math.IsInf(line.Amount, 0) {
+			return fmt.Errorf("transactions[%d].amount must be a non-zero number", i)
+		}
+	}
+	return nil
+}
+
+// statement converts the push into the statement the review queue stages
+func (r *PushStatementRequest) statement() *statement.Statement {
+	parsed := &statement.Statement{
+		Institution:    strings.TrimSpace(r.Source),
+		Template:       PushTemplate,
+		OpeningBalance: r.OpeningBalance,
+		ClosingBalance: r.Balance,
+		Warnings:       r.Warnings,
+	}
+	for _, line := range r.Transactions {
+		parsed.Transactions = append(parsed.Transactions, statement.Transaction{
+			Date:        line.Date,
+			Description: strings.TrimSpace(line.Description),
+			Amount:      line.Amount,
+		})
+		if parsed.PeriodStart == nil || line.Date.Before(*parsed.PeriodStart) {
+			date := line.Date
+			parsed.PeriodStart = &date
+		}
+		if parsed.PeriodEnd == nil || line.Date.After(*parsed.PeriodEnd) {
+			date := line.Date
+			parsed.PeriodEnd = &date
+		}
+	}
+	if r.BalanceDate != nil {
+		// The balance is recorded on the period end when the import is committed
+		date := *r.BalanceDate
+		parsed.PeriodEnd = &date
+		if parsed.PeriodStart == nil {
+			parsed.PeriodStart = &date
+		}
+	}
+	return parsed
+}
+
+// PushStatement stages a pushed balance and transactions for review, exactly as an
+// uploaded statement is. A repeated idempotency key returns the import the first push
+// staged instead of staging it again.
+func (s *Service) PushStatement(ctx context.Context, idempotencyKey string, req *PushStatementRequest) (*PushStatementResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		return nil, fmt.Errorf("idempotency key must be at most %d characters", maxIdempotencyKeyLength)
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	var key *string
+	if idempotencyKey != "" {
+		key = &idempotencyKey
+		if imp, err := s.importByIdempotencyKey(ctx, userID, idempotencyKey); err != nil {
+			return nil, err
+		} else if imp != nil {
+			return &PushStatementResponse{Import: imp, Replayed: true}, nil
+		}
+	}
+
+	fileName := fmt.Sprintf("push-%s.json", time.Now().UTC().Format("2006-01-02T15-04-05"))
+	imp, err := s.importStatement(ctx, req.AccountID, fileName, req.statement(), key)
+	if err != nil && key != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		// A concurrent push with the same key won the race
+		if existing, lookupErr := s.importByIdempotencyKey(ctx, userID, idempotencyKey); lookupErr == nil && existing != nil {
+			return &PushStatementResponse{Import: existing, Replayed: true}, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &PushStatementResponse{Import: imp}, nil
+}
+
+// importByIdempotencyKey returns the import staged under a key, or nil when there is none
+func (s *Service) importByIdempotencyKey(ctx context.Context, userID, key string) (*StatementImport, error) {
+	var id string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id FROM statement_imports WHERE user_id = $1 AND idempotency_key = $2
+	`, userID, key).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up idempotency key: %w", err)
+	}
+	return s.GetStatementImport(ctx, id)
+}
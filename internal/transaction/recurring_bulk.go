@@ -0,0 +1,370 @@
+package transaction
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"money/internal/auth"
+)
+
+// maxBulkRecurringExpenses caps how many expenses one bulk request may touch
+const maxBulkRecurringExpenses = 500
+
+// recurringPeriodsPerYear is how often each recurring expense frequency occurs in a year
+var recurringPeriodsPerYear = map[string]float64{
+	"weekly":    52,
+	"bi-weekly": 26,
+	"monthly":   12,
+	"quarterly": 4,
+	"annually":  1,
+}
+
+// AnnualizedAmount is a recurring amount expressed per year, per month and, when asked, in
+// another frequency
+type AnnualizedAmount struct {
+	Amount    float64  `json:"amount"`
+	Frequency string   `json:"frequency"`
+	Annual    float64  `json:"annual"`
+	Monthly   float64  `json:"monthly"`
+	To        string   `json:"to,omitempty"`
+	Converted *float64 `json:"converted,omitempty"` // The amount in the To frequency
+}
+
+// Annualize converts a recurring amount to its yearly and monthly totals, and to another
+// frequency when to is set
+func Annualize(amount float64, frequency, to string) (*AnnualizedAmount, error) {
+	periods, ok := recurringPeriodsPerYear[frequency]
+	if !ok {
+		return nil, fmt.Errorf("unsupported frequency %q", frequency)
+	}
+
+	result := &AnnualizedAmount{
+		Amount:    amount,
+		Frequency: frequency,
+		Annual:    roundCents(amount * periods),
+		Monthly:   roundCents(amount * periods / 12),
+	}
+	if to != "" {
+		converted, err := ConvertFrequency(amount, frequency, to)
+		if err != nil {
+			return nil, err
+		}
+		result.To = to
+		result.Converted = &converted
+	}
+	return result, nil
+}
+
+// ConvertFrequency converts a recurring amount between frequencies through its annual total,
+// rounded to the cent
+func ConvertFrequency(amount float64, from, to string) (float64, error) {
+	fromPeriods, ok := recurringPeriodsPerYear[from]
+	if !ok {
+		return 0, fmt.Errorf("unsupported frequency %q", from)
+	}
+	toPeriods, ok := recurringPeriodsPerYear[to]
+	if !ok {
+		return 0, fmt.Errorf("unsupported frequency %q", to)
+	}
+	return roundCents(amount * fromPeriods / toPeriods), nil
+}
+
+// BulkCreateRecurringExpensesRequest creates several recurring expenses at once
+type BulkCreateRecurringExpensesRequest struct {
+	Expenses []CreateRecurringExpenseRequest `json:"expenses"`
+	// ConvertTo stores every expense at this frequency, converting its amount, e.g. to keep a
+	// spreadsheet of annual subscriptions as monthly expenses
+	ConvertTo string `json:"convert_to,omitempty"`
+}
+
+// BulkRecurringExpenseUpdate changes one expense in a bulk update
+type BulkRecurringExpenseUpdate struct {
+	ID string `json:"id"`
+	UpdateRecurringExpenseRequest
+}
+
+// BulkUpdateRecurringExpensesRequest updates several recurring expenses at once
+type BulkUpdateRecurringExpensesRequest struct {
+	Updates []BulkRecurringExpenseUpdate `json:"updates"`
+}
+
+// BulkDisableRecurringExpensesRequest deactivates several recurring expenses at once
+type BulkDisableRecurringExpensesRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// BulkRecurringExpensesResponse reports the expenses a bulk request created or changed
+type BulkRecurringExpensesResponse struct {
+	Count    int                `json:"count"`
+	Expenses []RecurringExpense `json:"expenses"`
+}
+
+// validateRecurringExpense checks a new expense before it's written
+func validateRecurringExpense(req *CreateRecurringExpenseRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if req.Amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	if _, ok := recurringPeriodsPerYear[req.Frequency]; !ok {
+		return fmt.Errorf("unsupported frequency %q", req.Frequency)
+	}
+	switch req.Currency {
+	case "CAD", "USD", "INR":
+	default:
+		return fmt.Errorf("unsupported currency %q", req.Currency)
+	}
+	return nil
+}
+
+// BulkCreateRecurringExpenses creates all the expenses in one transaction. Every expense is
+// validated first and any problems are reported together, with nothing saved.
+func (s *Service) BulkCreateRecurringExpenses(ctx context.Context, req *BulkCreateRecurringExpensesRequest) (*BulkRecurringExpensesResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if len(req.Expenses) == 0 {
+		return nil, fmt.Errorf("at least one expense is required")
+	}
+	if len(req.Expenses) > maxBulkRecurringExpenses {
+		return nil, fmt.Errorf("at most %d expenses can be created at once", maxBulkRecurringExpenses)
+	}
+	if req.ConvertTo != "" {
+		if _, ok := recurringPeriodsPerYear[req.ConvertTo]; !ok {
+			return nil, fmt.Errorf("unsupported frequency %q", req.ConvertTo)
+		}
+	}
+
+	var problems []error
+	for i := range req.Expenses {
+		if err := validateRecurringExpense(&req.Expenses[i]); err != nil {
+			problems = append(problems, fmt.Errorf("expense %d: %w", i+1, err))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	expenses := make([]RecurringExpense, 0, len(req.Expenses))
+	for i, e := range req.Expenses {
+		if req.ConvertTo != "" && req.ConvertTo != e.Frequency {
+			if e.Amount, err = ConvertFrequency(e.Amount, e.Frequency, req.ConvertTo); err != nil {
+				return nil, err
+			}
+			e.Frequency = req.ConvertTo
+		}
+
+		expense := RecurringExpense{
+			ID:          generateID(),
+			UserID:      userID,
+			Name:        strings.TrimSpace(e.Name),
+			Description: e.Description,
+			Amount:      e.Amount,
+			Currency:    e.Currency,
+			Category:    e.Category,
+			AccountID:   e.AccountID,
+			Frequency:   e.Frequency,
+			DayOfMonth:  e.DayOfMonth,
+			DayOfWeek:   e.DayOfWeek,
+			IsActive:    true,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO recurring_expenses (
+				id, user_id, name, description, amount, currency, category, account_id,
+				frequency, day_of_month, day_of_week, is_active, created_at, updated_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, true, $12, $13)
+		`, expense.ID, userID, expense.Name, expense.Description, expense.Amount, expense.Currency,
+			expense.Category, expense.AccountID, expense.Frequency, expense.DayOfMonth, expense.DayOfWeek, now, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create expense %d: %w", i+1, err)
+		}
+		expenses = append(expenses, expense)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &BulkRecurringExpensesResponse{Count: len(expenses), Expenses: expenses}, nil
+}
+
+// BulkUpdateRecurringExpenses applies every update in one transaction. An unknown ID or an
+// invalid change rolls back the whole request.
+func (s *Service) BulkUpdateRecurringExpenses(ctx context.Context, req *BulkUpdateRecurringExpensesRequest) (*BulkRecurringExpensesResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if len(req.Updates) == 0 {
+		return nil, fmt.Errorf("at least one update is required")
+	}
+	if len(req.Updates) > maxBulkRecurringExpenses {
+		return nil, fmt.Errorf("at most %d expenses can be updated at once", maxBulkRecurringExpenses)
+	}
+
+	var problems []error
+	for i, u := range req.Updates {
+		switch {
+		case u.ID == "":
+			problems = append(problems, fmt.Errorf("update %d: id is required", i+1))
+		case u.Frequency != nil && recurringPeriodsPerYear[*u.Frequency] == 0:
+			problems = append(problems, fmt.Errorf("update %d: unsupported frequency %q", i+1, *u.Frequency))
+		case u.Amount != nil && *u.Amount <= 0:
+			problems = append(problems, fmt.Errorf("update %d: amount must be positive", i+1))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+
+	ids, err := s.applyRecurringExpenseUpdates(ctx, userID, req.Updates)
+	if err != nil {
+		return nil, err
+	}
+	return s.recurringExpensesByID(ctx, ids)
+}
+
+// BulkDisableRecurringExpenses deactivates the expenses, keeping them for history. Projections
+// and variance reports skip inactive expenses.
+func (s *Service) BulkDisableRecurringExpenses(ctx context.Context, req *BulkDisableRecurringExpensesRequest) (*BulkRecurringExpensesResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if len(req.IDs) == 0 {
+		return nil, fmt.Errorf("at least one expense ID is required")
+	}
+	if len(req.IDs) > maxBulkRecurringExpenses {
+		return nil, fmt.Errorf("at most %d expenses can be disabled at once", maxBulkRecurringExpenses)
+	}
+
+	inactive := false
+	updates := make([]BulkRecurringExpenseUpdate, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		updates = append(updates, BulkRecurringExpenseUpdate{ID: id, UpdateRecurringExpenseRequest: UpdateRecurringExpenseRequest{IsActive: &inactive}})
+	}
+
+	ids, err := s.applyRecurringExpenseUpdates(ctx, userID, updates)
+	if err != nil {
+		return nil, err
+	}
+	return s.recurringExpensesByID(ctx, ids)
+}
+
+// applyRecurringExpenseUpdates runs the updates in one transaction and returns the IDs changed
+func (s *Service) applyRecurringExpenseUpdates(ctx context.Context, userID string, updates []BulkRecurringExpenseUpdate) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	ids := make([]string, 0, len(updates))
+	for _, u := range updates {
+		query, args := recurringExpenseUpdate(u.ID, userID, &u.UpdateRecurringExpenseRequest, now)
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update recurring expense %s: %w", u.ID, err)
+		}
+		if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+			return nil, fmt.Errorf("recurring expense %s: %w", u.ID, ErrNotFound)
+		}
+		ids = append(ids, u.ID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return ids, nil
+}
+
+// recurringExpensesByID loads the expenses in the order given
+func (s *Service) recurringExpensesByID(ctx context.Context, ids []string) (*BulkRecurringExpensesResponse, error) {
+	expenses := make([]RecurringExpense, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		expense, err := s.GetRecurringExpense(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, *expense)
+	}
+	return &BulkRecurringExpensesResponse{Count: len(expenses), Expenses: expenses}, nil
+}
+
+// ParseRecurringExpensesCSV reads expenses from a spreadsheet export with name, amount,
+// frequency and currency columns, and optional category and description columns. Amounts
+// may use thousands separators and a leading $.
+func ParseRecurringExpensesCSV(r io.Reader) ([]CreateRecurringExpenseRequest, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"name", "amount", "frequency", "currency"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV is missing a %s column", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	expenses := make([]CreateRecurringExpenseRequest, 0)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		amount, err := strconv.ParseFloat(strings.NewReplacer(",", "", "$", "").Replace(field(record, "amount")), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid amount", line)
+		}
+		expenses = append(expenses, CreateRecurringExpenseRequest{
+			Name:        field(record, "name"),
+			Description: field(record, "description"),
+			Amount:      amount,
+			Currency:    strings.ToUpper(field(record, "currency")),
+			Category:    field(record, "category"),
+			Frequency:   strings.ToLower(field(record, "frequency")),
+		})
+	}
+	return expenses, nil
+}
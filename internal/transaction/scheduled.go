@@ -0,0 +1,408 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/background"
+)
+
+// ScheduledStatus is the lifecycle state of a scheduled transaction
+type ScheduledStatus string
+
+const (
+	ScheduledStatusScheduled ScheduledStatus = "scheduled"
+	ScheduledStatusPosted    ScheduledStatus = "posted"
+	ScheduledStatusCancelled ScheduledStatus = "cancelled"
+)
+
+// ScheduledTransaction is a planned future transaction. It shows up in the cash flow
+// calendar and projections but only touches balances once it is posted.
+type ScheduledTransaction struct {
+	ID                  string          `json:"id"`
+	UserID              string          `json:"user_id"`
+	AccountID           *string         `json:"account_id,omitempty"`
+	ScheduledDate       time.Time       `json:"scheduled_date"`
+	Description         string          `json:"description"`
+	Amount              float64         `json:"amount"` // Negative for money out, positive for money in
+	Currency            string          `json:"currency"`
+	Category            *string         `json:"category,omitempty"`
+	Notes               *string         `json:"notes,omitempty"`
+	Status              ScheduledStatus `json:"status"`
+	PostedTransactionID *string         `json:"posted_transaction_id,omitempty"`
+	PostedAt            *time.Time      `json:"posted_at,omitempty"`
+	CreatedAt           time.Time       `json:"created_at"`
+	UpdatedAt           time.Time       `json:"updated_at"`
+}
+
+// CreateScheduledTransactionRequest is the request for scheduling a transaction
+type CreateScheduledTransactionRequest struct {
+	AccountID     *string   `json:"account_id,omitempty"`
+	ScheduledDate time.Time `json:"scheduled_date"`
+	Description   string    `json:"description"`
+	Amount        float64   `json:"amount"`
+	Currency      string    `json:"currency"`
+	Category      *string   `json:"category,omitempty"`
+	Notes         *string   `json:"notes,omitempty"`
+}
+
+// ListScheduledTransactionsResponse is the response for listing scheduled transactions
+type ListScheduledTransactionsResponse struct {
+	ScheduledTransactions []ScheduledTransaction `json:"scheduled_transactions"`
+}
+
+// CalendarDay is one day of the cash flow calendar
+type CalendarDay struct {
+	Date         string                 `json:"date"` // YYYY-MM-DD
+	Posted       []Transaction          `json:"posted"`
+	Scheduled    []ScheduledTransaction `json:"scheduled"`
+	NetPosted    float64                `json:"net_posted"`
+	NetScheduled float64                `json:"net_scheduled"`
+}
+
+// CashFlowCalendarResponse lists posted and scheduled transactions by day
+type CashFlowCalendarResponse struct {
+	From time.Time     `json:"from"`
+	To   time.Time     `json:"to"`
+	Days []CalendarDay `json:"days"`
+}
+
+// scheduledPostInterval is how often due scheduled transactions are posted
+const scheduledPostInterval = time.Hour
+
+// CreateScheduledTransaction schedules a future-dated transaction
+func (s *Service) CreateScheduledTransaction(ctx context.Context, req *CreateScheduledTransactionRequest) (*ScheduledTransaction, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	if req.ScheduledDate.IsZero() {
+		return nil, fmt.Errorf("scheduled date is required")
+	}
+	if req.Description == "" {
+		return nil, fmt.Errorf("description is required")
+	}
+	if req.Amount == 0 {
+		return nil, fmt.Errorf("amount is required")
+	}
+	if req.AccountID != nil {
+		var ownerID string
+		err := s.db.QueryRowContext(ctx, `SELECT user_id FROM accounts WHERE id = $1`, *req.AccountID).Scan(&ownerID)
+		if err != nil || ownerID != userID {
+			return nil, fmt.Errorf("account not found")
+		}
+	}
+
+	id := generateID()
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO scheduled_transactions (
+			id, user_id, account_id, scheduled_date, description, amount, currency, category, notes,
+			status, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, id, userID, req.AccountID, req.ScheduledDate, req.Description, req.Amount, req.Currency, req.Category, req.Notes,
+		ScheduledStatusScheduled, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scheduled transaction: %w", err)
+	}
+
+	return s.GetScheduledTransaction(ctx, id)
+}
+
+// GetScheduledTransaction gets a single scheduled transaction
+func (s *Service) GetScheduledTransaction(ctx context.Context, id string) (*ScheduledTransaction, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, user_id, account_id, scheduled_date, description, amount, currency, category, notes,
+			status, posted_transaction_id, posted_at, created_at, updated_at
+		FROM scheduled_transactions
+		WHERE id = $1 AND user_id = $2
+	`, id, userID)
+	st, err := scanScheduledTransaction(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// ListScheduledTransactions lists the user's scheduled transactions by date, optionally by status
+func (s *Service) ListScheduledTransactions(ctx context.Context, status ScheduledStatus) (*ListScheduledTransactionsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, account_id, scheduled_date, description, amount, currency, category, notes,
+			status, posted_transaction_id, posted_at, created_at, updated_at
+		FROM scheduled_transactions
+		WHERE user_id = $1 AND ($2 = '' OR status = $2)
+	`, userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled transactions: %w", err)
+	}
+	defer rows.Close()
+
+	scheduled := []ScheduledTransaction{}
+	for rows.Next() {
+		st, err := scanScheduledTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		scheduled = append(scheduled, *st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list scheduled transactions: %w", err)
+	}
+
+	sort.SliceStable(scheduled, func(i, j int) bool {
+		return scheduled[i].ScheduledDate.Before(scheduled[j].ScheduledDate)
+	})
+
+	return &ListScheduledTransactionsResponse{ScheduledTransactions: scheduled}, nil
+}
+
+// ConfirmScheduledTransaction posts a scheduled transaction now, ahead of its date if needed
+func (s *Service) ConfirmScheduledTransaction(ctx context.Context, id string) (*ScheduledTransaction, error) {
+	st, err := s.GetScheduledTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if st.Status != ScheduledStatusScheduled {
+		return nil, fmt.Errorf("scheduled transaction is already %s", st.Status)
+	}
+
+	// Confirming early means it happened today
+	postDate := st.ScheduledDate
+	if today := time.Now(); postDate.After(today) {
+		postDate = today
+	}
+
+	if err := s.postScheduledTransaction(ctx, st, postDate); err != nil {
+		return nil, err
+	}
+
+	return s.GetScheduledTransaction(ctx, id)
+}
+
+// CancelScheduledTransaction cancels a scheduled transaction that hasn't been posted
+func (s *Service) CancelScheduledTransaction(ctx context.Context, id string) (*ScheduledTransaction, error) {
+	st, err := s.GetScheduledTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if st.Status != ScheduledStatusScheduled {
+		return nil, fmt.Errorf("scheduled transaction is already %s", st.Status)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE scheduled_transactions SET status = $1, updated_at = $2 WHERE id = $3
+	`, ScheduledStatusCancelled, time.Now(), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel scheduled transaction: %w", err)
+	}
+
+	return s.GetScheduledTransaction(ctx, id)
+}
+
+// postScheduledTransaction records the transaction, applies it to the account balance and
+// marks the scheduled entry as posted, all in one database transaction
+func (s *Service) postScheduledTransaction(ctx context.Context, st *ScheduledTransaction, postDate time.Time) error {
+	row := &NewTransaction{
+		UserID:      st.UserID,
+		Source:      SourceScheduled,
+		AccountID:   st.AccountID,
+		Date:        postDate,
+		Description: st.Description,
+		Amount:      st.Amount,
+		Currency:    st.Currency,
+		Category:    st.Category,
+		Notes:       st.Notes,
+	}
+	if err := s.ValidateNew(ctx, row); err != nil {
+		return err
+	}
+	transactionID := row.ID
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.InsertTx(ctx, tx, row); err != nil {
+		return fmt.Errorf("failed to post scheduled transaction: %w", err)
+	}
+
+	if st.AccountID != nil {
+		if err := adjustBalance(ctx, tx, *st.AccountID, postDate, st.Amount, st.Description); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	result, err := tx.ExecContext(ctx, `
+		UPDATE scheduled_transactions
+		SET status = $1, posted_transaction_id = $2, posted_at = $3, updated_at = $3
+		WHERE id = $4 AND status = $5
+	`, ScheduledStatusPosted, transactionID, now, st.ID, ScheduledStatusScheduled)
+	if err != nil {
+		return fmt.Errorf("failed to update scheduled transaction: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return fmt.Errorf("scheduled transaction was already posted or cancelled")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if len(s.postedHandlers) > 0 {
+		userCtx := auth.WithUserID(ctx, st.UserID)
+		posted, err := s.GetTransaction(userCtx, transactionID)
+		if err != nil {
+			// The transaction is already committed, so the posting itself succeeded
+			log.Printf("WARN: failed to load posted transaction for handlers: id=%s error=%v", transactionID, err)
+			return nil
+		}
+		s.notifyPosted(userCtx, posted)
+	}
+	return nil
+}
+
+// PostDueScheduledTransactions posts every user's scheduled transactions whose date has passed
+func (s *Service) PostDueScheduledTransactions(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, account_id, scheduled_date, description, amount, currency, category, notes,
+			status, posted_transaction_id, posted_at, created_at, updated_at
+		FROM scheduled_transactions
+		WHERE status = $1
+	`, ScheduledStatusScheduled)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get scheduled transactions: %w", err)
+	}
+
+	now := time.Now()
+	var due []*ScheduledTransaction
+	for rows.Next() {
+		st, err := scanScheduledTransaction(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if !st.ScheduledDate.After(now) {
+			due = append(due, st)
+		}
+	}
+	rows.Close()
+
+	posted := 0
+	for _, st := range due {
+		if err := s.postScheduledTransaction(ctx, st, st.ScheduledDate); err != nil {
+			log.Printf("ERROR: failed to post scheduled transaction: id=%s error=%v", st.ID, err)
+			continue
+		}
+		posted++
+	}
+
+	return posted, nil
+}
+
+// StartScheduledPosting posts due scheduled transactions hourly until jobs drains
+func (s *Service) StartScheduledPosting(jobs *background.Group) {
+	jobs.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(scheduledPostInterval)
+		defer ticker.Stop()
+
+		for {
+			jobs.Exclusive(ctx, "scheduled_posting", scheduledPostInterval, func(ctx context.Context) {
+				if posted, err := s.PostDueScheduledTransactions(ctx); err != nil {
+					log.Printf("ERROR: scheduled transaction posting failed: %v", err)
+				} else if posted > 0 {
+					log.Printf("INFO: posted %d scheduled transactions", posted)
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// GetCashFlowCalendar lists posted and still-scheduled transactions by day between from and to
+func (s *Service) GetCashFlowCalendar(ctx context.Context, from, to time.Time) (*CashFlowCalendarResponse, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("to must not be before from")
+	}
+
+	posted, err := s.ListTransactions(ctx, ListTransactionsFilter{From: &from, To: &to})
+	if err != nil {
+		return nil, err
+	}
+	scheduled, err := s.ListScheduledTransactions(ctx, ScheduledStatusScheduled)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make(map[string]*CalendarDay)
+	day := func(date time.Time) *CalendarDay {
+		key := date.Format("2006-01-02")
+		if days[key] == nil {
+			days[key] = &CalendarDay{Date: key, Posted: []Transaction{}, Scheduled: []ScheduledTransaction{}}
+		}
+		return days[key]
+	}
+
+	for _, t := range posted.Transactions {
+		d := day(t.Date)
+		d.Posted = append(d.Posted, t)
+		if t.TransferID == nil {
+			d.NetPosted += t.Amount
+		}
+	}
+	for _, st := range scheduled.ScheduledTransactions {
+		if st.ScheduledDate.Before(from) || st.ScheduledDate.After(to) {
+			continue
+		}
+		d := day(st.ScheduledDate)
+		d.Scheduled = append(d.Scheduled, st)
+		d.NetScheduled += st.Amount
+	}
+
+	resp := &CashFlowCalendarResponse{From: from, To: to, Days: make([]CalendarDay, 0, len(days))}
+	for _, d := range days {
+		resp.Days = append(resp.Days, *d)
+	}
+	sort.Slice(resp.Days, func(i, j int) bool { return resp.Days[i].Date < resp.Days[j].Date })
+
+	return resp, nil
+}
+
+// scanScheduledTransaction scans a scheduled transaction row
+func scanScheduledTransaction(row interface{ Scan(...interface{}) error }) (*ScheduledTransaction, error) {
+	var st ScheduledTransaction
+	err := row.Scan(
+		&st.ID, &st.UserID, &st.AccountID, &st.ScheduledDate, &st.Description, &st.Amount, &st.Currency,
+		&st.Category, &st.Notes, &st.Status, &st.PostedTransactionID, &st.PostedAt, &st.CreatedAt, &st.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
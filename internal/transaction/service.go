@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"money/internal/auth"
+	"money/internal/logger"
 
 	"github.com/google/uuid"
 )
@@ -15,18 +16,52 @@ import (
 // Common errors
 var (
 	ErrNotFound = errors.New("not found")
+	ErrRejected = errors.New("transaction rejected") // Refused by a validate handler
 )
 
 // Service provides transaction management functionality
 type Service struct {
-	db *sql.DB
+	db               *sql.DB
+	postedHandlers   []PostedHandler
+	validateHandlers []ValidateHandler
 }
 
+// PostedHandler reacts to a transaction that posted or whose splits changed. The context
+// carries the transaction's user as the authenticated user.
+type PostedHandler func(ctx context.Context, t *Transaction) error
+
+// ValidateHandler vets a transaction before it is written, whichever path creates it,
+// returning an error wrapping ErrRejected to refuse it. Handlers run before the write's
+// database transaction begins, so they may take their time and read the database.
+type ValidateHandler func(ctx context.Context, t *NewTransaction) error
+
 // NewService creates a new transaction service
 func NewService(db *sql.DB) *Service {
 	return &Service{db: db}
 }
 
+// OnPosted registers a handler for posted transactions. Handlers must be registered at
+// startup, before any transaction is posted.
+func (s *Service) OnPosted(handler PostedHandler) {
+	s.postedHandlers = append(s.postedHandlers, handler)
+}
+
+// OnValidate registers a handler that vets new transactions. Handlers must be registered
+// at startup, before any transaction is created.
+func (s *Service) OnValidate(handler ValidateHandler) {
+	s.validateHandlers = append(s.validateHandlers, handler)
+}
+
+// notifyPosted runs the posted handlers once the transaction is committed. A failing
+// handler is logged and never undoes the transaction.
+func (s *Service) notifyPosted(ctx context.Context, t *Transaction) {
+	for _, handler := range s.postedHandlers {
+		if err := handler(ctx, t); err != nil {
+			logger.Warn("Posted transaction handler failed", "transaction_id", t.ID, "error", err)
+		}
+	}
+}
+
 func generateID() string {
 	return uuid.New().String()
 }
@@ -347,9 +382,18 @@ func (s *Service) UpdateRecurringExpense(ctx context.Context, id string, req *Up
 		return nil, fmt.Errorf("user not authenticated")
 	}
 
-	now := time.Now()
+	query, args := recurringExpenseUpdate(id, userID, req, time.Now())
+	_, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update recurring expense: %w", err)
+	}
 
-	// Build dynamic update query
+	// Fetch and return the updated expense
+	return s.GetRecurringExpense(ctx, id)
+}
+
+// recurringExpenseUpdate builds the update for the fields set in the request
+func recurringExpenseUpdate(id, userID string, req *UpdateRecurringExpenseRequest, now time.Time) (string, []any) {
 	query := `UPDATE recurring_expenses SET updated_at = $3`
 	args := []any{id, userID, now}
 	argIdx := 4
@@ -402,18 +446,9 @@ func (s *Service) UpdateRecurringExpense(ctx context.Context, id string, req *Up
 	if req.IsActive != nil {
 		query += fmt.Sprintf(`, is_active = $%d`, argIdx)
 		args = append(args, *req.IsActive)
-		argIdx++
-	}
-
-	query += ` WHERE id = $1 AND user_id = $2`
-
-	_, err := s.db.ExecContext(ctx, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update recurring expense: %w", err)
 	}
 
-	// Fetch and return the updated expense
-	return s.GetRecurringExpense(ctx, id)
+	return query + ` WHERE id = $1 AND user_id = $2`, args
 }
 
 // DeleteRecurringExpense deletes a recurring expense
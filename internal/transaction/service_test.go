@@ -3,9 +3,11 @@ package transaction
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -16,6 +18,7 @@ import (
 	_ "modernc.org/sqlite"
 
 	"money/internal/auth"
+	"money/internal/statement"
 )
 
 var (
@@ -126,6 +129,12 @@ func setupTestDB(t *testing.T) *sql.DB {
 func cleanupTestDB(t *testing.T, db *sql.DB) {
 	t.Helper()
 	_, _ = db.Exec("DELETE FROM recurring_expenses WHERE id LIKE 'test-%' OR user_id LIKE 'test-%'")
+	_, _ = db.Exec("DELETE FROM scheduled_transactions WHERE user_id LIKE 'test-%'")
+	_, _ = db.Exec("DELETE FROM transactions WHERE user_id LIKE 'test-%'")
+	_, _ = db.Exec("DELETE FROM categories WHERE user_id LIKE 'test-%'")
+	_, _ = db.Exec("DELETE FROM transfers WHERE user_id LIKE 'test-%'")
+	_, _ = db.Exec("DELETE FROM balances WHERE account_id LIKE 'test-%'")
+	_, _ = db.Exec("DELETE FROM accounts WHERE user_id LIKE 'test-%'")
 	_, _ = db.Exec("DELETE FROM users WHERE id LIKE 'test-%'")
 }
 
@@ -368,6 +377,91 @@ func TestUpdateRecurringExpense_Success(t *testing.T) {
 	}
 }
 
+func TestBulkRecurringExpenses_CreateFromCSVThenUpdateAndDisable(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-expense-bulk"
+	createTestUser(t, db, userID)
+	ctx := createAuthContext(userID)
+	service := NewService(db)
+
+	expenses, err := ParseRecurringExpensesCSV(strings.NewReader(
+		"Name,Amount,Frequency,Currency,Category\n" +
+			"Streaming,\"$1,200.00\",annually,cad,entertainment\n" +
+			"Cloud Storage,3.25,Monthly,CAD,software\n"))
+	if err != nil {
+		t.Fatalf("ParseRecurringExpensesCSV failed: %v", err)
+	}
+
+	// Act
+	created, err := service.BulkCreateRecurringExpenses(ctx, &BulkCreateRecurringExpensesRequest{Expenses: expenses, ConvertTo: "monthly"})
+	if err != nil {
+		t.Fatalf("BulkCreateRecurringExpenses failed: %v", err)
+	}
+	newAmount := 4.00
+	updated, err := service.BulkUpdateRecurringExpenses(ctx, &BulkUpdateRecurringExpensesRequest{
+		Updates: []BulkRecurringExpenseUpdate{{ID: created.Expenses[1].ID, UpdateRecurringExpenseRequest: UpdateRecurringExpenseRequest{Amount: &newAmount}}},
+	})
+	if err != nil {
+		t.Fatalf("BulkUpdateRecurringExpenses failed: %v", err)
+	}
+	disabled, err := service.BulkDisableRecurringExpenses(ctx, &BulkDisableRecurringExpensesRequest{
+		IDs: []string{created.Expenses[0].ID, created.Expenses[1].ID},
+	})
+	if err != nil {
+		t.Fatalf("BulkDisableRecurringExpenses failed: %v", err)
+	}
+	_, invalidErr := service.BulkCreateRecurringExpenses(ctx, &BulkCreateRecurringExpensesRequest{Expenses: []CreateRecurringExpenseRequest{
+		{Name: "Gym", Amount: 50, Currency: "CAD", Frequency: "monthly"},
+		{Name: "", Amount: 10, Currency: "CAD", Frequency: "daily"},
+	}})
+	_, missingErr := service.BulkDisableRecurringExpenses(ctx, &BulkDisableRecurringExpensesRequest{IDs: []string{"missing-expense"}})
+
+	// Assert
+	if created.Count != 2 || created.Expenses[0].Amount != 100 || created.Expenses[0].Frequency != "monthly" {
+		t.Errorf("Expected the annual streaming fee stored as 100 monthly, got %+v", created.Expenses[0])
+	}
+	if updated.Count != 1 || updated.Expenses[0].Amount != 4 {
+		t.Errorf("Expected cloud storage updated to 4, got %+v", updated.Expenses)
+	}
+	for _, e := range disabled.Expenses {
+		if e.IsActive {
+			t.Errorf("Expected %s to be disabled", e.Name)
+		}
+	}
+	if invalidErr == nil {
+		t.Error("Expected an invalid expense to fail the bulk create")
+	}
+	list, err := service.ListRecurringExpenses(ctx)
+	if err != nil {
+		t.Fatalf("ListRecurringExpenses failed: %v", err)
+	}
+	if len(list.Expenses) != 2 {
+		t.Errorf("Expected the failed bulk create to save nothing, got %d expenses", len(list.Expenses))
+	}
+	if !errors.Is(missingErr, ErrNotFound) {
+		t.Errorf("Expected an unknown ID to be not found, got %v", missingErr)
+	}
+}
+
+func TestAnnualize_ConvertsBetweenFrequencies(t *testing.T) {
+	// Act
+	weekly, err := Annualize(10, "weekly", "quarterly")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Annualize failed: %v", err)
+	}
+	if weekly.Annual != 520 || weekly.Monthly != 43.33 || weekly.Converted == nil || *weekly.Converted != 130 {
+		t.Errorf("Expected 10 weekly to be 520/year, 43.33/month and 130/quarter, got %+v", weekly)
+	}
+	if _, err := Annualize(10, "daily", ""); err == nil {
+		t.Error("Expected an unsupported frequency to be rejected")
+	}
+}
+
 func TestDeleteRecurringExpense_Success(t *testing.T) {
 	db := setupTestDB(t)
 	defer cleanupTestDB(t, db)
@@ -457,3 +551,1350 @@ func TestListRecurringExpenses_OtherUserCannotSee(t *testing.T) {
 		t.Errorf("Expected 0 expenses for user2, got %d", len(resp.Expenses))
 	}
 }
+
+func TestCreateTransaction_SplitsMustSumToAmount(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-txn-split-mismatch-1"
+	createTestUser(t, db, userID)
+	ctx := createAuthContext(userID)
+	service := NewService(db)
+
+	req := &CreateTransactionRequest{
+		Date:        time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC),
+		Description: "Costco",
+		Amount:      -150.00,
+		Currency:    "CAD",
+		Splits: []SplitRequest{
+			{Category: "groceries", Amount: -100.00},
+			{Category: "household", Amount: -40.00},
+		},
+	}
+
+	// Act
+	_, err := service.CreateTransaction(ctx, req)
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected error when splits do not sum to the transaction amount, got nil")
+	}
+}
+
+func TestCreateTransaction_ValidateHandlerRejects(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-txn-validate-1"
+	createTestUser(t, db, userID)
+	ctx := createAuthContext(userID)
+	service := NewService(db)
+	service.OnValidate(func(ctx context.Context, t *NewTransaction) error {
+		if t.Amount < -1000 {
+			return fmt.Errorf("%w: over the spending limit", ErrRejected)
+		}
+		return nil
+	})
+
+	// Act
+	_, rejected := service.CreateTransaction(ctx, &CreateTransactionRequest{
+		Date: time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC), Description: "Television", Amount: -2500, Currency: "CAD",
+	})
+	created, err := service.CreateTransaction(ctx, &CreateTransactionRequest{
+		Date: time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC), Description: "Coffee", Amount: -4.50, Currency: "CAD",
+	})
+
+	// Assert
+	if !errors.Is(rejected, ErrRejected) {
+		t.Errorf("Expected the validate handler to reject the transaction, got %v", rejected)
+	}
+	if err != nil || created == nil {
+		t.Fatalf("Expected the transaction within the limit to be created, got %v", err)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM transactions WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		t.Fatalf("Failed to count transactions: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected only the accepted transaction to be written, got %d", count)
+	}
+}
+
+func TestListTransactions_FiltersAndPagesInQuery(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-txn-list-page-1"
+	createTestUser(t, db, userID)
+	ctx := createAuthContext(userID)
+	service := NewService(db)
+
+	accountID, otherID := "test-txn-list-page-checking", "test-txn-list-page-savings"
+	for _, id := range []string{accountID, otherID} {
+		_, err := db.Exec(`
+			INSERT INTO accounts (id, user_id, name, type, currency, is_asset, is_active, created_at, updated_at)
+			VALUES ($1, $2, $1, 'checking', 'CAD', 1, 1, $3, $3)
+		`, id, userID, time.Now())
+		if err != nil {
+			t.Fatalf("Failed to create test account: %v", err)
+		}
+	}
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	for day := 0; day < 5; day++ {
+		req := &CreateTransactionRequest{
+			AccountID: &accountID, Date: start.AddDate(0, 0, day), Description: fmt.Sprintf("Day %d", day+1), Amount: -10, Currency: "CAD",
+		}
+		if day == 2 {
+			req.Splits = []SplitRequest{{Category: "Groceries", Amount: -6}, {Category: "Household", Amount: -4}}
+		}
+		if _, err := service.CreateTransaction(ctx, req); err != nil {
+			t.Fatalf("CreateTransaction failed: %v", err)
+		}
+	}
+	if _, err := service.CreateTransaction(ctx, &CreateTransactionRequest{
+		AccountID: &otherID, Date: start.AddDate(0, 0, 2), Description: "Other account", Amount: -10, Currency: "CAD",
+	}); err != nil {
+		t.Fatalf("CreateTransaction failed: %v", err)
+	}
+	from, to := start.AddDate(0, 0, 1), start.AddDate(0, 0, 3)
+
+	// Act
+	page, err := service.ListTransactions(ctx, ListTransactionsFilter{AccountID: accountID, From: &from, To: &to, Limit: 2, Offset: 1})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ListTransactions failed: %v", err)
+	}
+	if len(page.Transactions) != 2 || page.Transactions[0].Description != "Day 3" || page.Transactions[1].Description != "Day 2" {
+		t.Fatalf("Expected days 3 and 2, got %+v", page.Transactions)
+	}
+	if len(page.Transactions[0].Splits) != 2 || len(page.Transactions[1].Splits) != 0 {
+		t.Errorf("Expected the splits loaded for day 3 only, got %d and %d",
+			len(page.Transactions[0].Splits), len(page.Transactions[1].Splits))
+	}
+}
+
+func TestGetCategorySpending_UsesSplits(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-txn-split-spending-1"
+	createTestUser(t, db, userID)
+	ctx := createAuthContext(userID)
+	service := NewService(db)
+	groceries := "groceries"
+
+	_, err := service.CreateTransaction(ctx, &CreateTransactionRequest{
+		Date:        time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC),
+		Description: "Costco",
+		Amount:      -150.00,
+		Currency:    "CAD",
+		Category:    &groceries,
+		Splits: []SplitRequest{
+			{Category: "groceries", Amount: -100.00},
+			{Category: "household", Amount: -50.00},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create split transaction: %v", err)
+	}
+	_, err = service.CreateTransaction(ctx, &CreateTransactionRequest{
+		Date:        time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),
+		Description: "Farmers market",
+		Amount:      -30.00,
+		Currency:    "CAD",
+		Category:    &groceries,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create transaction: %v", err)
+	}
+
+	// Act
+	resp, err := service.GetCategorySpending(ctx, nil, nil)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	totals := make(map[string]float64)
+	for _, c := range resp.Categories {
+		totals[c.Category] = c.Amount
+	}
+	if totals["groceries"] != -130.00 {
+		t.Errorf("Expected groceries -130.00, got %.2f", totals["groceries"])
+	}
+	if totals["household"] != -50.00 {
+		t.Errorf("Expected household -50.00, got %.2f", totals["household"])
+	}
+}
+
+func TestGetCategorySpending_SkipsAccountsExcludedFromSpending(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-txn-excluded-spending-1"
+	createTestUser(t, db, userID)
+	ctx := createAuthContext(userID)
+	service := NewService(db)
+	groceries := "groceries"
+
+	personalID, businessID := "test-excluded-personal", "test-excluded-business"
+	for _, acc := range []struct {
+		id       string
+		excluded bool
+	}{{personalID, false}, {businessID, true}} {
+		_, err := db.Exec(`
+			INSERT INTO accounts (id, user_id, name, type, currency, is_asset, is_active, exclude_from_spending, created_at, updated_at)
+			VALUES ($1, $2, $1, 'checking', 'CAD', 1, 1, $3, $4, $4)
+		`, acc.id, userID, acc.excluded, time.Now())
+		if err != nil {
+			t.Fatalf("Failed to create test account: %v", err)
+		}
+	}
+	for _, accountID := range []string{personalID, businessID} {
+		_, err := service.CreateTransaction(ctx, &CreateTransactionRequest{
+			AccountID:   &accountID,
+			Date:        time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC),
+			Description: "Groceries",
+			Amount:      -80.00,
+			Currency:    "CAD",
+			Category:    &groceries,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create transaction: %v", err)
+		}
+	}
+
+	// Act
+	resp, err := service.GetCategorySpending(ctx, nil, nil)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(resp.Categories) != 1 || resp.Categories[0].Amount != -80.00 {
+		t.Errorf("Expected only the personal account's spending, got %+v", resp.Categories)
+	}
+}
+
+func TestGetCashFlowVariance_ComparesPlannedWithActual(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-txn-variance-1"
+	createTestUser(t, db, userID)
+	ctx := createAuthContext(userID)
+	service := NewService(db)
+	now := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+
+	_, err := service.CreateRecurringExpense(ctx, &CreateRecurringExpenseRequest{
+		Name:      "Groceries",
+		Amount:    400.00,
+		Currency:  "CAD",
+		Category:  "groceries",
+		Frequency: "monthly",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create recurring expense: %v", err)
+	}
+	_, err = service.CreateTransaction(ctx, &CreateTransactionRequest{
+		Date:        time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC),
+		Description: "Costco",
+		Amount:      -450.00,
+		Currency:    "CAD",
+		Splits: []SplitRequest{
+			{Category: "groceries", Amount: -425.00},
+			{Category: "household", Amount: -25.00},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create transaction: %v", err)
+	}
+
+	// Act
+	resp, err := service.getCashFlowVariance(ctx, 2, now)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.FromMonth != "2024-05" || resp.ToMonth != "2024-06" {
+		t.Errorf("Expected 2024-05 to 2024-06, got %s to %s", resp.FromMonth, resp.ToMonth)
+	}
+	var groceries *CategoryVariance
+	for i := range resp.Categories {
+		if resp.Categories[i].Category == "groceries" {
+			groceries = &resp.Categories[i]
+		}
+	}
+	if groceries == nil {
+		t.Fatal("Expected groceries category in variance")
+	}
+	june := groceries.Months[1]
+	if june.Planned != 400.00 || june.Actual != 425.00 || june.Variance != 25.00 {
+		t.Errorf("Expected June planned 400 actual 425 variance 25, got %+v", june)
+	}
+	if groceries.Trend != TrendIncreasing {
+		t.Errorf("Expected increasing trend, got %s", groceries.Trend)
+	}
+}
+
+func TestCreateTransfer_MovesBalanceAndSkipsCashFlow(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-transfer-1"
+	createTestUser(t, db, userID)
+	service := NewService(db)
+	ctx := createAuthContext(userID)
+
+	date := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	for _, acc := range []struct{ id, currency string }{
+		{"test-transfer-checking", "CAD"},
+		{"test-transfer-usd", "USD"},
+	} {
+		_, err := db.Exec(`
+			INSERT INTO accounts (id, user_id, name, type, currency, is_asset, is_active, created_at, updated_at)
+			VALUES ($1, $2, $3, 'checking', $4, 1, 1, $5, $5)
+		`, acc.id, userID, acc.id, acc.currency, time.Now())
+		if err != nil {
+			t.Fatalf("Failed to create test account: %v", err)
+		}
+	}
+	_, err := db.Exec(`
+		INSERT INTO balances (id, account_id, amount, date, created_at)
+		VALUES ('test-transfer-balance', 'test-transfer-checking', 1000, $1, $2)
+	`, date.AddDate(0, 0, -5), time.Now())
+	if err != nil {
+		t.Fatalf("Failed to create test balance: %v", err)
+	}
+	rate := 0.75
+
+	// Act
+	transfer, err := service.CreateTransfer(ctx, &CreateTransferRequest{
+		FromAccountID: "test-transfer-checking",
+		ToAccountID:   "test-transfer-usd",
+		Date:          date,
+		Amount:        400,
+		ExchangeRate:  &rate,
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CreateTransfer failed: %v", err)
+	}
+	if transfer.ConvertedAmount != 300 {
+		t.Errorf("Expected converted amount 300, got %.2f", transfer.ConvertedAmount)
+	}
+
+	var fromBalance, toBalance float64
+	if err := db.QueryRow(`SELECT amount FROM balances WHERE account_id = 'test-transfer-checking' ORDER BY date DESC LIMIT 1`).Scan(&fromBalance); err != nil {
+		t.Fatalf("Failed to read balance: %v", err)
+	}
+	if err := db.QueryRow(`SELECT amount FROM balances WHERE account_id = 'test-transfer-usd' ORDER BY date DESC LIMIT 1`).Scan(&toBalance); err != nil {
+		t.Fatalf("Failed to read balance: %v", err)
+	}
+	if fromBalance != 600 || toBalance != 300 {
+		t.Errorf("Expected balances 600 and 300, got %.2f and %.2f", fromBalance, toBalance)
+	}
+
+	spending, err := service.GetCategorySpending(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("GetCategorySpending failed: %v", err)
+	}
+	if len(spending.Categories) != 0 {
+		t.Errorf("Expected transfers to be excluded from spending, got %+v", spending.Categories)
+	}
+
+	if err := service.DeleteTransfer(ctx, transfer.ID); err != nil {
+		t.Fatalf("DeleteTransfer failed: %v", err)
+	}
+	if err := db.QueryRow(`SELECT amount FROM balances WHERE account_id = 'test-transfer-checking' ORDER BY date DESC LIMIT 1`).Scan(&fromBalance); err != nil {
+		t.Fatalf("Failed to read balance: %v", err)
+	}
+	if fromBalance != 1000 {
+		t.Errorf("Expected balance restored to 1000, got %.2f", fromBalance)
+	}
+}
+
+func TestCreateTransfer_ShiftsLaterBalances(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-transfer-backdated-1"
+	createTestUser(t, db, userID)
+	service := NewService(db)
+	ctx := createAuthContext(userID)
+
+	for _, id := range []string{"test-transfer-backdated-from", "test-transfer-backdated-to"} {
+		_, err := db.Exec(`
+			INSERT INTO accounts (id, user_id, name, type, currency, is_asset, is_active, created_at, updated_at)
+			VALUES ($1, $2, $1, 'checking', 'CAD', 1, 1, $3, $3)
+		`, id, userID, time.Now())
+		if err != nil {
+			t.Fatalf("Failed to create test account: %v", err)
+		}
+	}
+	for _, b := range []struct {
+		id     string
+		amount float64
+		date   time.Time
+	}{
+		{"test-transfer-backdated-balance-1", 1000, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"test-transfer-backdated-balance-2", 1200, time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC)},
+	} {
+		_, err := db.Exec(`
+			INSERT INTO balances (id, account_id, amount, date, created_at)
+			VALUES ($1, 'test-transfer-backdated-from', $2, $3, $4)
+		`, b.id, b.amount, b.date, time.Now())
+		if err != nil {
+			t.Fatalf("Failed to create test balance: %v", err)
+		}
+	}
+
+	// Act
+	transfer, err := service.CreateTransfer(ctx, &CreateTransferRequest{
+		FromAccountID: "test-transfer-backdated-from",
+		ToAccountID:   "test-transfer-backdated-to",
+		Date:          time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC),
+		Amount:        400,
+	})
+	if err != nil {
+		t.Fatalf("CreateTransfer failed: %v", err)
+	}
+	var onDate, later float64
+	if err := db.QueryRow(`SELECT amount FROM balances WHERE account_id = 'test-transfer-backdated-from' AND date = $1`,
+		time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)).Scan(&onDate); err != nil {
+		t.Fatalf("Failed to read balance: %v", err)
+	}
+	if err := db.QueryRow(`SELECT amount FROM balances WHERE id = 'test-transfer-backdated-balance-2'`).Scan(&later); err != nil {
+		t.Fatalf("Failed to read balance: %v", err)
+	}
+	if err := service.DeleteTransfer(ctx, transfer.ID); err != nil {
+		t.Fatalf("DeleteTransfer failed: %v", err)
+	}
+	var restored float64
+	if err := db.QueryRow(`SELECT amount FROM balances WHERE id = 'test-transfer-backdated-balance-2'`).Scan(&restored); err != nil {
+		t.Fatalf("Failed to read balance: %v", err)
+	}
+
+	// Assert
+	if onDate != 600 {
+		t.Errorf("Expected a balance of 600 on the transfer date, got %.2f", onDate)
+	}
+	if later != 800 {
+		t.Errorf("Expected the later balance shifted to 800, got %.2f", later)
+	}
+	if restored != 1200 {
+		t.Errorf("Expected the later balance restored to 1200 after the transfer was deleted, got %.2f", restored)
+	}
+}
+
+func TestCreateTransfer_UpdatesThatDaysBalance(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-transfer-day-1"
+	createTestUser(t, db, userID)
+	service := NewService(db)
+	ctx := createAuthContext(userID)
+
+	for _, id := range []string{"test-transfer-day-from", "test-transfer-day-to"} {
+		_, err := db.Exec(`
+			INSERT INTO accounts (id, user_id, name, type, currency, is_asset, is_active, created_at, updated_at)
+			VALUES ($1, $2, $1, 'checking', 'CAD', 1, 1, $3, $3)
+		`, id, userID, time.Now())
+		if err != nil {
+			t.Fatalf("Failed to create test account: %v", err)
+		}
+	}
+	day := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	_, err := db.Exec(`
+		INSERT INTO balances (id, account_id, amount, date, created_at)
+		VALUES ('test-transfer-day-balance', 'test-transfer-day-from', 1000, $1, $2)
+	`, day, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to create test balance: %v", err)
+	}
+
+	// Act
+	_, err = service.CreateTransfer(ctx, &CreateTransferRequest{
+		FromAccountID: "test-transfer-day-from",
+		ToAccountID:   "test-transfer-day-to",
+		Date:          day.Add(15*time.Hour + 30*time.Minute),
+		Amount:        400,
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CreateTransfer failed: %v", err)
+	}
+	rows, err := db.Query(`SELECT amount, date FROM balances WHERE account_id = 'test-transfer-day-from'`)
+	if err != nil {
+		t.Fatalf("Failed to read balances: %v", err)
+	}
+	defer rows.Close()
+	var count int
+	for rows.Next() {
+		var amount float64
+		var date time.Time
+		if err := rows.Scan(&amount, &date); err != nil {
+			t.Fatalf("Failed to scan balance: %v", err)
+		}
+		count++
+		if amount != 600 || !date.Equal(day) {
+			t.Errorf("Expected a balance of 600 on %s, got %.2f on %s", day.Format("2006-01-02"), amount, date)
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected the transfer to update that day's balance, got %d balance entries", count)
+	}
+}
+
+func TestCreateTransfer_ValidateHandlerSeesBothLegs(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-transfer-validate-1"
+	createTestUser(t, db, userID)
+	service := NewService(db)
+	ctx := createAuthContext(userID)
+
+	for _, id := range []string{"test-transfer-validate-from", "test-transfer-validate-to"} {
+		_, err := db.Exec(`
+			INSERT INTO accounts (id, user_id, name, type, currency, is_asset, is_active, created_at, updated_at)
+			VALUES ($1, $2, $1, 'checking', 'CAD', 1, 1, $3, $3)
+		`, id, userID, time.Now())
+		if err != nil {
+			t.Fatalf("Failed to create test account: %v", err)
+		}
+	}
+	var sources []Source
+	service.OnValidate(func(ctx context.Context, t *NewTransaction) error {
+		sources = append(sources, t.Source)
+		if t.Amount > 0 {
+			return fmt.Errorf("%w: deposits are reviewed by hand", ErrRejected)
+		}
+		return nil
+	})
+
+	// Act
+	_, err := service.CreateTransfer(ctx, &CreateTransferRequest{
+		FromAccountID: "test-transfer-validate-from",
+		ToAccountID:   "test-transfer-validate-to",
+		Date:          time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC),
+		Amount:        250,
+	})
+
+	// Assert
+	if !errors.Is(err, ErrRejected) {
+		t.Fatalf("Expected the validate handler to reject the transfer, got %v", err)
+	}
+	if len(sources) != 2 || sources[0] != SourceTransfer || sources[1] != SourceTransfer {
+		t.Errorf("Expected both legs to be validated as transfers, got %v", sources)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM transactions WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		t.Fatalf("Failed to count transactions: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected neither leg to be written, got %d", count)
+	}
+}
+
+func TestScheduledTransaction_PostsOnlyWhenConfirmed(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-scheduled-1"
+	createTestUser(t, db, userID)
+	service := NewService(db)
+	ctx := createAuthContext(userID)
+
+	accountID := "test-scheduled-checking"
+	_, err := db.Exec(`
+		INSERT INTO accounts (id, user_id, name, type, currency, is_asset, is_active, created_at, updated_at)
+		VALUES ($1, $2, 'Checking', 'checking', 'CAD', 1, 1, $3, $3)
+	`, accountID, userID, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to create test account: %v", err)
+	}
+	scheduledDate := time.Now().AddDate(0, 1, 0)
+
+	scheduled, err := service.CreateScheduledTransaction(ctx, &CreateScheduledTransactionRequest{
+		AccountID:     &accountID,
+		ScheduledDate: scheduledDate,
+		Description:   "Tuition",
+		Amount:        -2500,
+		Currency:      "CAD",
+	})
+	if err != nil {
+		t.Fatalf("CreateScheduledTransaction failed: %v", err)
+	}
+
+	// Act
+	calendar, err := service.GetCashFlowCalendar(ctx, time.Now(), scheduledDate.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("GetCashFlowCalendar failed: %v", err)
+	}
+	postedBefore, err := service.PostDueScheduledTransactions(ctx)
+	if err != nil {
+		t.Fatalf("PostDueScheduledTransactions failed: %v", err)
+	}
+	confirmed, err := service.ConfirmScheduledTransaction(ctx, scheduled.ID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ConfirmScheduledTransaction failed: %v", err)
+	}
+	if len(calendar.Days) != 1 || calendar.Days[0].NetScheduled != -2500 {
+		t.Errorf("Expected the scheduled transaction in the calendar, got %+v", calendar.Days)
+	}
+	if postedBefore != 0 {
+		t.Errorf("Expected nothing posted before the scheduled date, got %d", postedBefore)
+	}
+	if confirmed.Status != ScheduledStatusPosted || confirmed.PostedTransactionID == nil {
+		t.Errorf("Expected posted status with a transaction, got %+v", confirmed)
+	}
+
+	var balance float64
+	if err := db.QueryRow(`SELECT amount FROM balances WHERE account_id = $1`, accountID).Scan(&balance); err != nil {
+		t.Fatalf("Failed to read balance: %v", err)
+	}
+	if balance != -2500 {
+		t.Errorf("Expected balance -2500 after confirmation, got %.2f", balance)
+	}
+}
+
+func TestCreateTransaction_PostedSettlesPending(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-pending-1"
+	createTestUser(t, db, userID)
+	service := NewService(db)
+	ctx := createAuthContext(userID)
+
+	accountID := "test-pending-card"
+	category := "dining"
+	date := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	pending, err := service.CreateTransaction(ctx, &CreateTransactionRequest{
+		AccountID:   &accountID,
+		Date:        date,
+		Description: "RESTAURANT PENDING",
+		Amount:      -45,
+		Currency:    "CAD",
+		Category:    &category,
+		Status:      StatusPending,
+	})
+	if err != nil {
+		t.Fatalf("CreateTransaction (pending) failed: %v", err)
+	}
+	unrelated, err := service.CreateTransaction(ctx, &CreateTransactionRequest{
+		AccountID:   &accountID,
+		Date:        date,
+		Description: "BOOKSTORE PENDING",
+		Amount:      -120,
+		Currency:    "CAD",
+		Status:      StatusPending,
+	})
+	if err != nil {
+		t.Fatalf("CreateTransaction (unrelated pending) failed: %v", err)
+	}
+
+	// Act
+	posted, err := service.CreateTransaction(ctx, &CreateTransactionRequest{
+		AccountID:   &accountID,
+		Date:        date.AddDate(0, 0, 2),
+		Description: "RESTAURANT",
+		Amount:      -52.50,
+		Currency:    "CAD",
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CreateTransaction (posted) failed: %v", err)
+	}
+	if posted.PendingID == nil || *posted.PendingID != pending.ID {
+		t.Fatalf("Expected the posted transaction to settle the restaurant charge, got %v", posted.PendingID)
+	}
+	if posted.Category == nil || *posted.Category != category {
+		t.Errorf("Expected the pending category to carry over, got %v", posted.Category)
+	}
+
+	listed, err := service.ListTransactions(ctx, ListTransactionsFilter{AccountID: accountID})
+	if err != nil {
+		t.Fatalf("ListTransactions failed: %v", err)
+	}
+	if len(listed.Transactions) != 2 {
+		t.Errorf("Expected the settled pending transaction to be hidden, got %d transactions", len(listed.Transactions))
+	}
+	all, err := service.ListTransactions(ctx, ListTransactionsFilter{AccountID: accountID, IncludeSettled: true})
+	if err != nil {
+		t.Fatalf("ListTransactions failed: %v", err)
+	}
+	if len(all.Transactions) != 3 {
+		t.Errorf("Expected 3 transactions including settled ones, got %d", len(all.Transactions))
+	}
+
+	spending, err := service.GetCategorySpending(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("GetCategorySpending failed: %v", err)
+	}
+	for _, total := range spending.Categories {
+		if total.Category == category && (total.Amount != -52.50 || total.Count != 1) {
+			t.Errorf("Expected dining counted once at the posted amount, got %+v", total)
+		}
+	}
+
+	reloaded, err := service.GetTransaction(ctx, unrelated.ID)
+	if err != nil {
+		t.Fatalf("GetTransaction failed: %v", err)
+	}
+	if reloaded.SettledByID != nil {
+		t.Errorf("Expected the unrelated pending charge to stay unsettled")
+	}
+}
+
+func TestCategories_NestRenameAndMerge(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-categories-1"
+	createTestUser(t, db, userID)
+	service := NewService(db)
+	ctx := createAuthContext(userID)
+
+	date := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	for _, txn := range []struct {
+		category string
+		amount   float64
+	}{
+		{"Restaurants", -60},
+		{"Groceries", -140},
+		{"Cafes", -8},
+	} {
+		category := txn.category
+		if _, err := service.CreateTransaction(ctx, &CreateTransactionRequest{
+			Date:        date,
+			Description: category,
+			Amount:      txn.amount,
+			Currency:    "CAD",
+			Category:    &category,
+		}); err != nil {
+			t.Fatalf("CreateTransaction failed: %v", err)
+		}
+	}
+	listed, err := service.ListCategories(ctx)
+	if err != nil {
+		t.Fatalf("ListCategories failed: %v", err)
+	}
+	ids := make(map[string]string)
+	for _, c := range listed.Categories {
+		ids[c.Name] = c.ID
+	}
+	color := "#ff8800"
+
+	// Act
+	food, err := service.CreateCategory(ctx, &CategoryRequest{Name: "Food", Color: &color})
+	if err != nil {
+		t.Fatalf("CreateCategory failed: %v", err)
+	}
+	for _, name := range []string{"Restaurants", "Groceries"} {
+		if _, err := service.UpdateCategory(ctx, ids[name], &CategoryRequest{Name: name, ParentID: &food.ID}); err != nil {
+			t.Fatalf("UpdateCategory failed: %v", err)
+		}
+	}
+	if _, err := service.UpdateCategory(ctx, ids["Restaurants"], &CategoryRequest{Name: "Dining Out", ParentID: &food.ID}); err != nil {
+		t.Fatalf("UpdateCategory (rename) failed: %v", err)
+	}
+	if _, err := service.MergeCategory(ctx, ids["Cafes"], &MergeCategoryRequest{TargetID: ids["Restaurants"]}); err != nil {
+		t.Fatalf("MergeCategory failed: %v", err)
+	}
+
+	// Assert
+	groceriesID := ids["Groceries"]
+	if _, err := service.UpdateCategory(ctx, food.ID, &CategoryRequest{Name: "Food", ParentID: &groceriesID}); err == nil {
+		t.Error("Expected nesting a category under its own subcategory to fail")
+	}
+	tree, err := service.ListCategories(ctx)
+	if err != nil {
+		t.Fatalf("ListCategories failed: %v", err)
+	}
+	if len(tree.Categories) != 1 || tree.Categories[0].Name != "Food" || len(tree.Categories[0].Children) != 2 {
+		t.Fatalf("Expected Food with two subcategories, got %+v", tree.Categories)
+	}
+	for _, child := range tree.Categories[0].Children {
+		if child.Name == "Dining Out" && child.TransactionCount != 2 {
+			t.Errorf("Expected the merged cafe transaction under Dining Out, got %d", child.TransactionCount)
+		}
+	}
+
+	spending, err := service.GetCategorySpending(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("GetCategorySpending failed: %v", err)
+	}
+	if len(spending.Categories) != 2 || spending.Categories[0].Category != "Dining Out" || spending.Categories[0].Amount != -68 {
+		t.Errorf("Expected renamed and merged categories in spending, got %+v", spending.Categories)
+	}
+	rollup, err := service.GetCategorySpendingRollup(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("GetCategorySpendingRollup failed: %v", err)
+	}
+	if len(rollup.Categories) != 1 || rollup.Categories[0].Category != "Food" || rollup.Categories[0].Amount != -208 {
+		t.Errorf("Expected all spending rolled up into Food, got %+v", rollup.Categories)
+	}
+}
+
+func TestBudgetProgress_RolloverCarriesLeftoversAndOverspend(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-budgets-1"
+	createTestUser(t, db, userID)
+	service := NewService(db)
+	ctx := createAuthContext(userID)
+
+	food, err := service.CreateCategory(ctx, &CategoryRequest{Name: "Food"})
+	if err != nil {
+		t.Fatalf("CreateCategory failed: %v", err)
+	}
+	dining, err := service.CreateCategory(ctx, &CategoryRequest{Name: "Dining", ParentID: &food.ID})
+	if err != nil {
+		t.Fatalf("CreateCategory failed: %v", err)
+	}
+	transit, err := service.CreateCategory(ctx, &CategoryRequest{Name: "Transit"})
+	if err != nil {
+		t.Fatalf("CreateCategory failed: %v", err)
+	}
+
+	spend := func(categoryID string, amount float64, date time.Time) {
+		if _, err := service.CreateTransaction(ctx, &CreateTransactionRequest{
+			Date:        date,
+			Description: "test spend",
+			Amount:      -amount,
+			Currency:    "CAD",
+			CategoryID:  &categoryID,
+		}); err != nil {
+			t.Fatalf("CreateTransaction failed: %v", err)
+		}
+	}
+	spend(food.ID, 100, time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC))
+	spend(dining.ID, 50, time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC))
+	spend(dining.ID, 300, time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC))
+	spend(transit.ID, 150, time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC))
+
+	foodBudget, err := service.CreateCategoryBudget(ctx, &CategoryBudgetRequest{
+		CategoryID: food.ID, Amount: 200, Currency: "cad", Rollover: true, StartMonth: "2024-01",
+	})
+	if err != nil {
+		t.Fatalf("CreateCategoryBudget failed: %v", err)
+	}
+	if _, err := service.CreateCategoryBudget(ctx, &CategoryBudgetRequest{
+		CategoryID: transit.ID, Amount: 100, Currency: "CAD", StartMonth: "2024-01",
+	}); err != nil {
+		t.Fatalf("CreateCategoryBudget failed: %v", err)
+	}
+
+	// Act
+	resp, err := service.GetBudgetProgress(ctx, "2024-03")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetBudgetProgress failed: %v", err)
+	}
+	if len(resp.Budgets) != 2 {
+		t.Fatalf("Expected 2 budgets, got %d", len(resp.Budgets))
+	}
+	foodProgress := resp.Budgets[0]
+	if foodProgress.Budget.ID != foodBudget.ID || len(foodProgress.History) != 3 {
+		t.Fatalf("Expected three months of Food history, got %+v", foodProgress)
+	}
+	if jan := foodProgress.History[0]; jan.Spent != 150 || jan.CarriedOut != 50 {
+		t.Errorf("Expected January to spend 150 and carry 50, got %+v", jan)
+	}
+	if feb := foodProgress.History[1]; feb.Available != 250 || feb.Remaining != -50 || feb.CarriedOut != -50 {
+		t.Errorf("Expected February to overspend by 50, got %+v", feb)
+	}
+	if foodProgress.CarriedIn != -50 || foodProgress.Available != 150 || foodProgress.Overspent {
+		t.Errorf("Expected March to start 50 short with 150 available, got %+v", foodProgress.BudgetMonth)
+	}
+
+	transitProgress := resp.Budgets[1]
+	if jan := transitProgress.History[0]; jan.Remaining != -50 || jan.CarriedOut != 0 {
+		t.Errorf("Expected January transit overspend not to carry without rollover, got %+v", jan)
+	}
+	if transitProgress.CarriedIn != 0 || transitProgress.Available != 100 {
+		t.Errorf("Expected March transit to start fresh, got %+v", transitProgress.BudgetMonth)
+	}
+}
+
+func TestStatementImport_FlagsDuplicatesAndCommits(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-statement-1"
+	createTestUser(t, db, userID)
+	service := NewService(db)
+	ctx := createAuthContext(userID)
+
+	_, err := db.Exec(`
+		INSERT INTO accounts (id, user_id, name, type, currency, is_asset, is_active, created_at, updated_at)
+		VALUES ('test-statement-checking', $1, 'Chequing', 'checking', 'CAD', 1, 1, $2, $2)
+	`, userID, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to create test account: %v", err)
+	}
+	accountID := "test-statement-checking"
+	existing, err := service.CreateTransaction(ctx, &CreateTransactionRequest{
+		AccountID:   &accountID,
+		Date:        time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+		Description: "Grocery store",
+		Amount:      -82.15,
+		Currency:    "CAD",
+	})
+	if err != nil {
+		t.Fatalf("CreateTransaction failed: %v", err)
+	}
+
+	periodStart := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	periodEnd := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	opening, closing := 1000.0, 2917.85
+	parsed := &statement.Statement{
+		Institution:    "RBC Royal Bank",
+		Template:       "rbc_chequing",
+		PeriodStart:    &periodStart,
+		PeriodEnd:      &periodEnd,
+		OpeningBalance: &opening,
+		ClosingBalance: &closing,
+		Transactions: []statement.Transaction{
+			{Date: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), Description: "GROCERY STORE #12", Amount: -82.15},
+			{Date: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), Description: "PAYROLL DEPOSIT", Amount: 2000},
+		},
+	}
+
+	// Act
+	imp, err := service.ImportStatement(ctx, "test-statement-checking", "march.pdf", parsed)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ImportStatement failed: %v", err)
+	}
+	if len(imp.Lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(imp.Lines))
+	}
+	duplicate, deposit := imp.Lines[0], imp.Lines[1]
+	if !duplicate.Excluded || duplicate.DuplicateOf == nil || *duplicate.DuplicateOf != existing.ID {
+		t.Errorf("Expected the grocery line to be excluded as a duplicate of %s, got %+v", existing.ID, duplicate)
+	}
+	if deposit.Excluded || deposit.DuplicateOf != nil {
+		t.Errorf("Expected the deposit line to be included, got %+v", deposit)
+	}
+
+	// Nothing is recorded until the import is committed
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM transactions WHERE account_id = 'test-statement-checking'`).Scan(&count); err != nil {
+		t.Fatalf("Failed to count transactions: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 transaction before commit, got %d", count)
+	}
+
+	category := "Salary"
+	if _, err := service.UpdateStatementImportLine(ctx, imp.ID, deposit.ID, &UpdateStatementLineRequest{Category: &category}); err != nil {
+		t.Fatalf("UpdateStatementImportLine failed: %v", err)
+	}
+
+	resp, err := service.CommitStatementImport(ctx, imp.ID)
+	if err != nil {
+		t.Fatalf("CommitStatementImport failed: %v", err)
+	}
+	if resp.Created != 1 || !resp.BalanceRecorded {
+		t.Errorf("Expected 1 transaction and the closing balance recorded, got %+v", resp)
+	}
+	if resp.Import.Status != StatementImportCommitted {
+		t.Errorf("Expected status committed, got %s", resp.Import.Status)
+	}
+
+	var amount float64
+	var storedCategory sql.NullString
+	if err := db.QueryRow(`
+		SELECT amount, category FROM transactions WHERE account_id = 'test-statement-checking' AND description = 'PAYROLL DEPOSIT'
+	`).Scan(&amount, &storedCategory); err != nil {
+		t.Fatalf("Failed to read imported transaction: %v", err)
+	}
+	if amount != 2000 || storedCategory.String != "Salary" {
+		t.Errorf("Expected a 2000 Salary transaction, got %.2f %q", amount, storedCategory.String)
+	}
+
+	var balance float64
+	if err := db.QueryRow(`SELECT amount FROM balances WHERE account_id = 'test-statement-checking' ORDER BY date DESC LIMIT 1`).Scan(&balance); err != nil {
+		t.Fatalf("Failed to read balance: %v", err)
+	}
+	if balance != closing {
+		t.Errorf("Expected balance %.2f, got %.2f", closing, balance)
+	}
+
+	if _, err := service.CommitStatementImport(ctx, imp.ID); err == nil {
+		t.Error("Expected committing twice to fail")
+	}
+}
+
+func TestPushStatement_StagesForReviewOncePerKey(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-push-1"
+	createTestUser(t, db, userID)
+	service := NewService(db)
+	ctx := createAuthContext(userID)
+
+	_, err := db.Exec(`
+		INSERT INTO accounts (id, user_id, name, type, currency, is_asset, is_active, created_at, updated_at)
+		VALUES ('test-push-checking', $1, 'Chequing', 'checking', 'CAD', 1, 1, $2, $2)
+	`, userID, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to create test account: %v", err)
+	}
+	balanceDate := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
+	balance := 1520.40
+	req := &PushStatementRequest{
+		AccountID:   "test-push-checking",
+		Source:      "bank-scraper",
+		BalanceDate: &balanceDate,
+		Balance:     &balance,
+		Transactions: []PushTransactionLine{
+			{Date: time.Date(2024, 4, 12, 0, 0, 0, 0, time.UTC), Description: "Hydro bill", Amount: -95.20},
+		},
+	}
+
+	// Act
+	first, err := service.PushStatement(ctx, "scrape-2024-04-30", req)
+	if err != nil {
+		t.Fatalf("PushStatement failed: %v", err)
+	}
+	retry, err := service.PushStatement(ctx, "scrape-2024-04-30", req)
+	if err != nil {
+		t.Fatalf("PushStatement retry failed: %v", err)
+	}
+
+	// Assert
+	if first.Replayed || first.Import.Status != StatementImportPending || first.Import.Template != PushTemplate {
+		t.Errorf("Expected a new pending push import, got %+v", first)
+	}
+	if first.Import.PeriodEnd == nil || !first.Import.PeriodEnd.Equal(balanceDate) || len(first.Import.Lines) != 1 {
+		t.Errorf("Expected the balance date as period end and 1 line, got %+v", first.Import)
+	}
+	if !retry.Replayed || retry.Import.ID != first.Import.ID {
+		t.Errorf("Expected the retry to return the first import, got %+v", retry)
+	}
+	imports, err := service.ListStatementImports(ctx)
+	if err != nil {
+		t.Fatalf("ListStatementImports failed: %v", err)
+	}
+	if len(imports.Imports) != 1 {
+		t.Errorf("Expected a single staged import, got %d", len(imports.Imports))
+	}
+
+	resp, err := service.CommitStatementImport(ctx, first.Import.ID)
+	if err != nil {
+		t.Fatalf("CommitStatementImport failed: %v", err)
+	}
+	if resp.Created != 1 || !resp.BalanceRecorded {
+		t.Errorf("Expected the line and balance recorded on commit, got %+v", resp)
+	}
+
+	if _, err := service.PushStatement(ctx, "", &PushStatementRequest{AccountID: "test-push-checking", Source: "bank-scraper", Balance: &balance}); err == nil {
+		t.Error("Expected a balance without balance_date to be rejected")
+	}
+}
+
+func TestSweepRules_SuggestAndAcceptMonthEndSurplus(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-sweep-1"
+	createTestUser(t, db, userID)
+	service := NewService(db)
+	ctx := createAuthContext(userID)
+
+	for _, acc := range []struct{ id, accountType string }{
+		{"test-sweep-checking", "checking"},
+		{"test-sweep-brokerage", "brokerage"},
+	} {
+		_, err := db.Exec(`
+			INSERT INTO accounts (id, user_id, name, type, currency, is_asset, is_active, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, 'CAD', 1, 1, $5, $5)
+		`, acc.id, userID, acc.id, acc.accountType, time.Now())
+		if err != nil {
+			t.Fatalf("Failed to create test account: %v", err)
+		}
+	}
+	_, err := db.Exec(`
+		INSERT INTO balances (id, account_id, amount, date, created_at)
+		VALUES ('test-sweep-balance', 'test-sweep-checking', 1000, $1, $2)
+	`, time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC), time.Now())
+	if err != nil {
+		t.Fatalf("Failed to create test balance: %v", err)
+	}
+
+	rule, err := service.CreateSweepRule(ctx, &CreateSweepRuleRequest{
+		FromAccountID: "test-sweep-checking",
+		ToAccountID:   "test-sweep-brokerage",
+		Threshold:     600,
+		MinAmount:     50,
+	})
+	if err != nil {
+		t.Fatalf("CreateSweepRule failed: %v", err)
+	}
+
+	// Act
+	found, err := service.RunSweeps(context.Background(), time.Date(2024, 6, 2, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("RunSweeps failed: %v", err)
+	}
+	again, err := service.RunSweeps(context.Background(), time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("RunSweeps failed: %v", err)
+	}
+
+	// Assert
+	if found != 1 || again != 0 {
+		t.Fatalf("Expected one surplus found once, got %d then %d", found, again)
+	}
+	runs, err := service.ListSweepRuns(ctx, SweepRunSuggested)
+	if err != nil {
+		t.Fatalf("ListSweepRuns failed: %v", err)
+	}
+	if len(runs.Runs) != 1 {
+		t.Fatalf("Expected 1 suggested sweep, got %d", len(runs.Runs))
+	}
+	run := runs.Runs[0]
+	if run.RuleID != rule.ID || run.Period != "2024-05" || run.Amount != 400 {
+		t.Errorf("Expected a 400 sweep for 2024-05, got %+v", run)
+	}
+
+	accepted, err := service.AcceptSweepRun(ctx, run.ID, &AcceptSweepRunRequest{})
+	if err != nil {
+		t.Fatalf("AcceptSweepRun failed: %v", err)
+	}
+	if accepted.Status != SweepRunPosted || accepted.TransferID == nil {
+		t.Fatalf("Expected the sweep to be posted with a transfer, got %+v", accepted)
+	}
+	transfer, err := service.GetTransfer(ctx, *accepted.TransferID)
+	if err != nil {
+		t.Fatalf("GetTransfer failed: %v", err)
+	}
+	if transfer.Amount != 400 || transfer.ToAccountID != "test-sweep-brokerage" {
+		t.Errorf("Expected a 400 transfer into the brokerage account, got %+v", transfer)
+	}
+	if _, err := service.DismissSweepRun(ctx, run.ID); err == nil {
+		t.Error("Expected dismissing a posted sweep to fail")
+	}
+}
+
+func TestCreateTransaction_LeavesPendingOutsideTheWindow(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-pending-window-1"
+	createTestUser(t, db, userID)
+	service := NewService(db)
+	ctx := createAuthContext(userID)
+
+	accountID := "test-pending-window-card"
+	date := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	for _, pendingDate := range []time.Time{date.AddDate(0, 0, -settlementWindowDays-1), date.AddDate(0, 0, 2)} {
+		_, err := service.CreateTransaction(ctx, &CreateTransactionRequest{
+			AccountID:   &accountID,
+			Date:        pendingDate,
+			Description: "HARDWARE PENDING",
+			Amount:      -80,
+			Currency:    "CAD",
+			Status:      StatusPending,
+		})
+		if err != nil {
+			t.Fatalf("CreateTransaction (pending) failed: %v", err)
+		}
+	}
+
+	// Act
+	posted, err := service.CreateTransaction(ctx, &CreateTransactionRequest{
+		AccountID:   &accountID,
+		Date:        date,
+		Description: "HARDWARE",
+		Amount:      -80,
+		Currency:    "CAD",
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CreateTransaction (posted) failed: %v", err)
+	}
+	if posted.PendingID != nil {
+		t.Errorf("Expected pending charges outside the settlement window to be left alone, got %s settled", *posted.PendingID)
+	}
+}
+
+func TestIOULedger_SplitsAndSettlementsNetToZero(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-iou-1"
+	createTestUser(t, db, userID)
+	service := NewService(db)
+	ctx := createAuthContext(userID)
+
+	person, err := service.CreateIOUPerson(ctx, &IOUPersonRequest{Name: "Sam"})
+	if err != nil {
+		t.Fatalf("CreateIOUPerson failed: %v", err)
+	}
+	dinner, err := service.CreateTransaction(ctx, &CreateTransactionRequest{
+		Date:        time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC),
+		Description: "Dinner",
+		Amount:      -100,
+		Currency:    "CAD",
+	})
+	if err != nil {
+		t.Fatalf("CreateTransaction failed: %v", err)
+	}
+
+	// Act
+	split, err := service.SplitTransactionWithPerson(ctx, dinner.ID, &SplitWithPersonRequest{PersonID: person.ID})
+	if err != nil {
+		t.Fatalf("SplitTransactionWithPerson failed: %v", err)
+	}
+	_, duplicateErr := service.SplitTransactionWithPerson(ctx, dinner.ID, &SplitWithPersonRequest{PersonID: person.ID})
+	_, err = service.RecordIOUEntry(ctx, person.ID, &IOUEntryRequest{
+		Type:        IOUEntrySplit,
+		Amount:      -20,
+		Date:        time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),
+		Description: "Taxi Sam paid for",
+	})
+	if err != nil {
+		t.Fatalf("RecordIOUEntry split failed: %v", err)
+	}
+	_, err = service.RecordIOUEntry(ctx, person.ID, &IOUEntryRequest{
+		Type:   IOUEntrySettlement,
+		Amount: 30,
+		Date:   time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("RecordIOUEntry settlement failed: %v", err)
+	}
+
+	// Assert
+	if split.Amount != 50 || split.Balance != 50 {
+		t.Errorf("Expected Sam to owe half the dinner, got %+v", split)
+	}
+	if duplicateErr == nil {
+		t.Error("Expected splitting the same transaction twice to fail")
+	}
+	ledger, err := service.GetIOULedger(ctx, person.ID)
+	if err != nil {
+		t.Fatalf("GetIOULedger failed: %v", err)
+	}
+	want := []float64{50, 30, 0}
+	if len(ledger.Entries) != len(want) {
+		t.Fatalf("Expected %d ledger entries, got %d", len(want), len(ledger.Entries))
+	}
+	for i, balance := range want {
+		if ledger.Entries[i].Balance != balance {
+			t.Errorf("Entry %d: expected running balance %.2f, got %.2f", i, balance, ledger.Entries[i].Balance)
+		}
+	}
+	people, err := service.ListIOUPeople(ctx)
+	if err != nil {
+		t.Fatalf("ListIOUPeople failed: %v", err)
+	}
+	if len(people.People) != 1 || people.People[0].Balance != 0 || len(people.OwedToYou) != 0 {
+		t.Errorf("Expected a settled ledger, got %+v", people)
+	}
+}
+
+func TestProjects_TrackSpendAgainstPlan(t *testing.T) {
+	db := setupTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	// Arrange
+	userID := "test-user-project-1"
+	createTestUser(t, db, userID)
+	service := NewService(db)
+	ctx := createAuthContext(userID)
+
+	budget := 3000.0
+	project, err := service.CreateProject(ctx, &ProjectRequest{Name: "Lisbon trip", Budget: &budget})
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	flights, err := service.CreateTransaction(ctx, &CreateTransactionRequest{
+		Date:        time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Description: "Flights",
+		Amount:      -1200,
+		Currency:    "CAD",
+	})
+	if err != nil {
+		t.Fatalf("CreateTransaction failed: %v", err)
+	}
+	hotelDate := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	hotel, err := service.AddPlannedExpense(ctx, project.ID, &PlannedExpenseRequest{
+		Description: "Hotel",
+		Amount:      1500,
+		Date:        &hotelDate,
+	})
+	if err != nil {
+		t.Fatalf("AddPlannedExpense failed: %v", err)
+	}
+
+	// Act
+	tagged, err := service.TagProjectTransactions(ctx, project.ID, &TagProjectTransactionsRequest{
+		TransactionIDs: []string{flights.ID},
+	})
+	if err != nil {
+		t.Fatalf("TagProjectTransactions failed: %v", err)
+	}
+	outstanding, err := service.ListOutstandingPlannedExpenses(ctx)
+	if err != nil {
+		t.Fatalf("ListOutstandingPlannedExpenses failed: %v", err)
+	}
+
+	// Assert
+	progress := tagged.Progress
+	if progress.Spent != 1200 || progress.Outstanding != 1500 || progress.Remaining != 300 || progress.OverBudget {
+		t.Errorf("Expected 1200 spent, 1500 outstanding and 300 remaining, got %+v", progress)
+	}
+	if progress.PercentUsed != 40 {
+		t.Errorf("Expected 40%% of the budget used, got %.2f", progress.PercentUsed)
+	}
+	if len(outstanding) != 1 || outstanding[0].ID != hotel.ID {
+		t.Errorf("Expected the hotel to be outstanding, got %+v", outstanding)
+	}
+
+	detail, err := service.GetProject(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("GetProject failed: %v", err)
+	}
+	if len(detail.Transactions) != 1 || len(detail.PlannedExpenses) != 1 {
+		t.Errorf("Expected 1 transaction and 1 planned expense, got %d and %d",
+			len(detail.Transactions), len(detail.PlannedExpenses))
+	}
+}
@@ -0,0 +1,526 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/balance"
+	"money/internal/database"
+	"money/internal/logger"
+	"money/internal/statement"
+)
+
+// Statement import statuses
+const (
+	StatementImportPending   = "pending"
+	StatementImportCommitted = "committed"
+)
+
+// duplicateWindowDays is how far apart a statement line and an existing transaction of the
+// same amount can be dated and still be taken for the same transaction
+const duplicateWindowDays = 3
+
+// StatementImport is a parsed statement waiting for review, or already committed
+type StatementImport struct {
+	ID             string                `json:"id"`
+	UserID         string                `json:"user_id"`
+	AccountID      string                `json:"account_id"`
+	FileName       string                `json:"file_name"`
+	Institution    string                `json:"institution"`
+	Template       string                `json:"template"`
+	PeriodStart    *time.Time            `json:"period_start,omitempty"`
+	PeriodEnd      *time.Time            `json:"period_end,omitempty"`
+	OpeningBalance *float64              `json:"opening_balance,omitempty"`
+	ClosingBalance *float64              `json:"closing_balance,omitempty"` // Recorded as the account balance on period_end when committed
+	Warnings       []string              `json:"warnings"`
+	Status         string                `json:"status"` // pending or committed
+	CommittedAt    *time.Time            `json:"committed_at,omitempty"`
+	LineCount      int                   `json:"line_count"`
+	Lines          []StatementImportLine `json:"lines,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// StatementImportLine is a transaction read from a statement. Lines that look like a
+// transaction already recorded on the account start out excluded.
+type StatementImportLine struct {
+	ID            string    `json:"id"`
+	ImportID      string    `json:"import_id"`
+	LineNumber    int       `json:"line_number"`
+	Date          time.Time `json:"date"`
+	Description   string    `json:"description"`
+	Amount        float64   `json:"amount"` // Negative for money out, positive for money in
+	Category      *string   `json:"category,omitempty"`
+	Excluded      bool      `json:"excluded"`
+	DuplicateOf   *string   `json:"duplicate_of,omitempty"`   // Existing transaction this line appears to repeat
+	TransactionID *string   `json:"transaction_id,omitempty"` // Transaction created on commit
+}
+
+// UpdateStatementLineRequest corrects a statement line before it is committed. Omitted
+// fields are left unchanged; an empty category clears it.
+type UpdateStatementLineRequest struct {
+	Date        *time.Time `json:"date,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	Amount      *float64   `json:"amount,omitempty"`
+	Category    *string    `json:"category,omitempty"`
+	Excluded    *bool      `json:"excluded,omitempty"`
+}
+
+// ListStatementImportsResponse lists the user's statement imports, newest first
+type ListStatementImportsResponse struct {
+	Imports []StatementImport `json:"imports"`
+}
+
+// CommitStatementImportResponse reports what committing an import recorded
+type CommitStatementImportResponse struct {
+	Import          *StatementImport `json:"import"`
+	Created         int              `json:"created"`          // Transactions created
+	BalanceRecorded bool             `json:"balance_recorded"` // Whether the closing balance was recorded
+}
+
+// ImportStatement stages a parsed statement for review against one of the user's accounts.
+// Nothing is recorded on the account until the import is committed.
+func (s *Service) ImportStatement(ctx context.Context, accountID, fileName string, parsed *statement.Statement) (*StatementImport, error) {
+	return s.importStatement(ctx, accountID, fileName, parsed, nil)
+}
+
+// importStatement stages a statement, recording the idempotency key of a pushed one
+func (s *Service) importStatement(ctx context.Context, accountID, fileName string, parsed *statement.Statement, idempotencyKey *string) (*StatementImport, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if accountID == "" {
+		return nil, fmt.Errorf("account_id is required")
+	}
+	// A statement with only a closing balance still updates the account when committed
+	if len(parsed.Transactions) == 0 && parsed.ClosingBalance == nil {
+		return nil, fmt.Errorf("statement has no transactions")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := getTransferAccount(ctx, tx, userID, accountID); err != nil {
+		return nil, err
+	}
+	existing, err := accountTransactions(ctx, tx, userID, accountID, parsed.Transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := parsed.Warnings
+	if warnings == nil {
+		warnings = []string{}
+	}
+	encodedWarnings, err := json.Marshal(warnings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode warnings: %w", err)
+	}
+
+	id := generateID()
+	now := time.Now()
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO statement_imports (
+			id, user_id, account_id, file_name, institution, template, period_start, period_end,
+			opening_balance, closing_balance, warnings, status, idempotency_key, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`, id, userID, accountID, fileName, parsed.Institution, parsed.Template, parsed.PeriodStart, parsed.PeriodEnd,
+		parsed.OpeningBalance, parsed.ClosingBalance, string(encodedWarnings), StatementImportPending, idempotencyKey, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create statement import: %w", err)
+	}
+
+	matched := make(map[string]bool)
+	for i, line := range parsed.Transactions {
+		duplicateOf := findDuplicate(existing, matched, line.Date, line.Amount)
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO statement_import_lines (id, import_id, line_number, date, description, amount, excluded, duplicate_of)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, generateID(), id, i+1, line.Date, line.Description, line.Amount, duplicateOf != nil, duplicateOf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save statement line: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return s.GetStatementImport(ctx, id)
+}
+
+// existingTransaction is a transaction already on an account, for spotting duplicates
+type existingTransaction struct {
+	id     string
+	date   time.Time
+	amount float64
+}
+
+// accountTransactions loads the account's posted transactions within the duplicate window
+// of the statement's lines. Pending transactions aren't duplicates: committing the
+// statement line settles them.
+func accountTransactions(ctx context.Context, tx *sql.Tx, userID, accountID string, lines []statement.Transaction) ([]existingTransaction, error) {
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	first, last := lines[0].Date, lines[0].Date
+	for _, line := range lines[1:] {
+		if line.Date.Before(first) {
+			first = line.Date
+		}
+		if line.Date.After(last) {
+			last = line.Date
+		}
+	}
+	window := duplicateWindowDays * 24 * time.Hour
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, date, amount FROM transactions
+		WHERE user_id = $1 AND account_id = $2 AND status = $3
+		  AND date >= $4 AND date <= $5
+	`, userID, accountID, StatusPosted, first.Add(-window), last.Add(window))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []existingTransaction
+	for rows.Next() {
+		var t existingTransaction
+		if err := rows.Scan(&t.id, &t.date, &t.amount); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+// findDuplicate returns the closest-dated unmatched transaction with the same amount
+// within the duplicate window, and marks it matched so two identical lines don't both
+// claim it
+func findDuplicate(existing []existingTransaction, matched map[string]bool, date time.Time, amount float64) *string {
+	var best *existingTransaction
+	bestDays := math.Inf(1)
+	for i := range existing {
+		t := &existing[i]
+		if matched[t.id] || math.Abs(t.amount-amount) >= 0.005 {
+			continue
+		}
+		days := math.Abs(date.Sub(t.date).Hours() / 24)
+		if days <= duplicateWindowDays && days < bestDays {
+			best, bestDays = t, days
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	matched[best.id] = true
+	id := best.id
+	return &id
+}
+
+// ListStatementImports lists the user's statement imports without their lines
+func (s *Service) ListStatementImports(ctx context.Context) (*ListStatementImportsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+statementImportColumns+`
+		FROM statement_imports i
+		WHERE i.user_id = $1
+		ORDER BY i.created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statement imports: %w", err)
+	}
+	defer rows.Close()
+
+	imports := make([]StatementImport, 0)
+	for rows.Next() {
+		imp, err := scanStatementImport(rows)
+		if err != nil {
+			return nil, err
+		}
+		imports = append(imports, *imp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &ListStatementImportsResponse{Imports: imports}, nil
+}
+
+// statementImportColumns are the columns scanStatementImport reads
+const statementImportColumns = `i.id, i.user_id, i.account_id, i.file_name, i.institution, i.template, i.period_start,
+	i.period_end, i.opening_balance, i.closing_balance, i.warnings, i.status, i.committed_at,
+	(SELECT COUNT(*) FROM statement_import_lines l WHERE l.import_id = i.id), i.created_at, i.updated_at`
+
+func scanStatementImport(row database.Scanner) (*StatementImport, error) {
+	var imp StatementImport
+	var warnings string
+	err := row.Scan(&imp.ID, &imp.UserID, &imp.AccountID, &imp.FileName, &imp.Institution, &imp.Template,
+		&imp.PeriodStart, &imp.PeriodEnd, &imp.OpeningBalance, &imp.ClosingBalance, &warnings, &imp.Status,
+		&imp.CommittedAt, &imp.LineCount, &imp.CreatedAt, &imp.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(warnings), &imp.Warnings); err != nil {
+		return nil, fmt.Errorf("failed to decode warnings: %w", err)
+	}
+	return &imp, nil
+}
+
+// GetStatementImport returns a statement import with its lines
+func (s *Service) GetStatementImport(ctx context.Context, id string) (*StatementImport, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	imp, err := scanStatementImport(s.db.QueryRowContext(ctx, `
+		SELECT `+statementImportColumns+`
+		FROM statement_imports i
+		WHERE i.id = $1 AND i.user_id = $2
+	`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statement import: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, import_id, line_number, date, description, amount, category, excluded, duplicate_of, transaction_id
+		FROM statement_import_lines
+		WHERE import_id = $1
+		ORDER BY line_number
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statement lines: %w", err)
+	}
+	defer rows.Close()
+
+	imp.Lines = make([]StatementImportLine, 0, imp.LineCount)
+	for rows.Next() {
+		var line StatementImportLine
+		err := rows.Scan(&line.ID, &line.ImportID, &line.LineNumber, &line.Date, &line.Description, &line.Amount,
+			&line.Category, &line.Excluded, &line.DuplicateOf, &line.TransactionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan statement line: %w", err)
+		}
+		imp.Lines = append(imp.Lines, line)
+	}
+	return imp, rows.Err()
+}
+
+// UpdateStatementImportLine corrects or excludes a line of a pending import
+func (s *Service) UpdateStatementImportLine(ctx context.Context, importID, lineID string, req *UpdateStatementLineRequest) (*StatementImport, error) {
+	imp, err := s.GetStatementImport(ctx, importID)
+	if err != nil {
+		return nil, err
+	}
+	if imp.Status != StatementImportPending {
+		return nil, fmt.Errorf("statement import has already been committed")
+	}
+	if req.Description != nil && strings.TrimSpace(*req.Description) == "" {
+		return nil, fmt.Errorf("description must not be empty")
+	}
+	if req.Date != nil && req.Date.IsZero() {
+		return nil, fmt.Errorf("date must not be empty")
+	}
+	var category *string
+	clearCategory := false
+	if req.Category != nil {
+		if trimmed := strings.TrimSpace(*req.Category); trimmed != "" {
+			category = &trimmed
+		} else {
+			clearCategory = true
+		}
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE statement_import_lines SET
+			date = COALESCE($1, date),
+			description = COALESCE($2, description),
+			amount = COALESCE($3, amount),
+			category = CASE WHEN $4 THEN NULL ELSE COALESCE($5, category) END,
+			excluded = COALESCE($6, excluded)
+		WHERE id = $7 AND import_id = $8
+	`, req.Date, req.Description, req.Amount, clearCategory, category, req.Excluded, lineID, importID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update statement line: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, ErrNotFound
+	}
+
+	if _, err := s.db.ExecContext(ctx, `UPDATE statement_imports SET updated_at = $1 WHERE id = $2`, time.Now(), importID); err != nil {
+		return nil, fmt.Errorf("failed to update statement import: %w", err)
+	}
+	return s.GetStatementImport(ctx, importID)
+}
+
+// CommitStatementImport records the import's included lines as posted transactions on
+// its account, settling any pending transactions they replace, and records the closing
+// balance on the last day of the statement period. It all happens in one database
+// transaction, so a failure leaves the import pending and nothing recorded.
+func (s *Service) CommitStatementImport(ctx context.Context, id string) (*CommitStatementImportResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	imp, err := s.GetStatementImport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if imp.Status != StatementImportPending {
+		return nil, fmt.Errorf("statement import has already been committed")
+	}
+
+	acc, err := getTransferAccount(ctx, s.db, userID, imp.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every included line is validated before anything is written
+	accountID := imp.AccountID
+	lines := make(map[string]*NewTransaction)
+	var rows []*NewTransaction
+	for _, line := range imp.Lines {
+		if line.Excluded {
+			continue
+		}
+		row := &NewTransaction{
+			UserID:      userID,
+			Source:      SourceStatement,
+			AccountID:   &accountID,
+			Date:        line.Date,
+			Description: line.Description,
+			Amount:      line.Amount,
+			Currency:    acc.currency,
+			Category:    line.Category,
+			Status:      StatusPosted,
+		}
+		lines[line.ID] = row
+		rows = append(rows, row)
+	}
+	if err := s.ValidateNew(ctx, rows...); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var created []string
+	for _, line := range imp.Lines {
+		row, ok := lines[line.ID]
+		if !ok {
+			continue
+		}
+		if err := s.InsertTx(ctx, tx, row); err != nil {
+			return nil, err
+		}
+		transactionID := row.ID
+
+		pendingID, err := findPendingMatch(ctx, tx, userID, imp.AccountID, line.Date, line.Amount, acc.currency)
+		if err != nil {
+			return nil, err
+		}
+		if pendingID != nil {
+			if err := settlePending(ctx, tx, userID, *pendingID, transactionID); err != nil {
+				return nil, err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE statement_import_lines SET transaction_id = $1 WHERE id = $2
+		`, transactionID, line.ID); err != nil {
+			return nil, fmt.Errorf("failed to update statement line: %w", err)
+		}
+		created = append(created, transactionID)
+	}
+
+	resp := &CommitStatementImportResponse{Created: len(created)}
+	if imp.ClosingBalance != nil && imp.PeriodEnd != nil {
+		notes := fmt.Sprintf("Closing balance from %s statement", imp.Institution)
+		if err := setBalance(ctx, tx, imp.AccountID, *imp.PeriodEnd, *imp.ClosingBalance, notes); err != nil {
+			return nil, err
+		}
+		resp.BalanceRecorded = true
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE statement_imports SET status = $1, committed_at = $2, updated_at = $2 WHERE id = $3
+	`, StatementImportCommitted, now, id); err != nil {
+		return nil, fmt.Errorf("failed to update statement import: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, transactionID := range created {
+		t, err := s.GetTransaction(ctx, transactionID)
+		if err != nil {
+			logger.Warn("Failed to load imported transaction", "transaction_id", transactionID, "error", err)
+			continue
+		}
+		s.notifyPosted(ctx, t)
+	}
+
+	resp.Import, err = s.GetStatementImport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// setBalance records an account's balance on a date, replacing any balance already
+// recorded that day
+func setBalance(ctx context.Context, tx *sql.Tx, accountID string, date time.Time, amount float64, notes string) error {
+	now := time.Now()
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO balances (id, account_id, amount, date, notes, source, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, 'manual', $6, $7)
+		ON CONFLICT (account_id, date) DO UPDATE SET
+			amount = excluded.amount,
+			notes = excluded.notes,
+			updated_at = excluded.updated_at
+	`, generateID(), accountID, math.Round(amount*100)/100, date, notes, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to record balance: %w", err)
+	}
+	return balance.QueueAccountNetWorthRecompute(ctx, tx, accountID, date)
+}
+
+// DeleteStatementImport discards an import. Transactions a committed import created are
+// kept.
+func (s *Service) DeleteStatementImport(ctx context.Context, id string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM statement_imports WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete statement import: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
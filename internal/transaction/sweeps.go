@@ -0,0 +1,533 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/background"
+	"money/internal/logger"
+)
+
+// SweepMode chooses whether a sweep rule's transfers wait for confirmation
+type SweepMode string
+
+const (
+	SweepModeSuggest SweepMode = "suggest" // Each month's surplus is suggested for the user to accept
+	SweepModeAuto    SweepMode = "auto"    // Each month's surplus is transferred right away
+)
+
+// SweepRunStatus is what became of a month's surplus
+type SweepRunStatus string
+
+const (
+	SweepRunSuggested SweepRunStatus = "suggested"
+	SweepRunPosted    SweepRunStatus = "posted"
+	SweepRunDismissed SweepRunStatus = "dismissed"
+)
+
+// sweepInterval is how often sweep rules are checked for a newly closed month
+const sweepInterval = 6 * time.Hour
+
+// SweepRule moves whatever a cash account holds above a threshold at month end into an
+// investment account
+type SweepRule struct {
+	ID            string    `json:"id"`
+	UserID        string    `json:"user_id"`
+	FromAccountID string    `json:"from_account_id"`
+	ToAccountID   string    `json:"to_account_id"`
+	Threshold     float64   `json:"threshold"`  // Balance kept in the source account
+	MinAmount     float64   `json:"min_amount"` // Smaller surpluses are left alone
+	Mode          SweepMode `json:"mode"`
+	IsActive      bool      `json:"is_active"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// CreateSweepRuleRequest is the request for creating a sweep rule
+type CreateSweepRuleRequest struct {
+	FromAccountID string    `json:"from_account_id"`
+	ToAccountID   string    `json:"to_account_id"`
+	Threshold     float64   `json:"threshold"`
+	MinAmount     float64   `json:"min_amount,omitempty"`
+	Mode          SweepMode `json:"mode,omitempty"` // Defaults to suggest
+}
+
+// UpdateSweepRuleRequest is the request for changing a sweep rule
+type UpdateSweepRuleRequest struct {
+	Threshold *float64   `json:"threshold,omitempty"`
+	MinAmount *float64   `json:"min_amount,omitempty"`
+	Mode      *SweepMode `json:"mode,omitempty"`
+	IsActive  *bool      `json:"is_active,omitempty"`
+}
+
+// ListSweepRulesResponse is the response for listing sweep rules
+type ListSweepRulesResponse struct {
+	Rules []SweepRule `json:"rules"`
+}
+
+// SweepRun is one month's surplus found by a rule
+type SweepRun struct {
+	ID            string         `json:"id"`
+	RuleID        string         `json:"rule_id"`
+	FromAccountID string         `json:"from_account_id"`
+	ToAccountID   string         `json:"to_account_id"`
+	Period        string         `json:"period"`  // YYYY-MM
+	Balance       float64        `json:"balance"` // Source balance at month end
+	Amount        float64        `json:"amount"`
+	Status        SweepRunStatus `json:"status"`
+	TransferID    *string        `json:"transfer_id,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// ListSweepRunsResponse is the response for listing sweep history
+type ListSweepRunsResponse struct {
+	Runs []SweepRun `json:"runs"`
+}
+
+// AcceptSweepRunRequest accepts a suggested sweep, optionally for a different amount
+type AcceptSweepRunRequest struct {
+	Amount *float64 `json:"amount,omitempty"`
+}
+
+// CreateSweepRule creates a sweep rule between two of the user's accounts
+func (s *Service) CreateSweepRule(ctx context.Context, req *CreateSweepRuleRequest) (*SweepRule, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	if req.FromAccountID == "" || req.ToAccountID == "" {
+		return nil, fmt.Errorf("from_account_id and to_account_id are required")
+	}
+	if req.FromAccountID == req.ToAccountID {
+		return nil, fmt.Errorf("cannot sweep into the same account")
+	}
+	if req.Threshold < 0 || req.MinAmount < 0 {
+		return nil, fmt.Errorf("threshold and min_amount must not be negative")
+	}
+	if req.Mode == "" {
+		req.Mode = SweepModeSuggest
+	}
+	if req.Mode != SweepModeSuggest && req.Mode != SweepModeAuto {
+		return nil, fmt.Errorf("mode must be suggest or auto")
+	}
+	for _, accountID := range []string{req.FromAccountID, req.ToAccountID} {
+		var ownerID string
+		err := s.db.QueryRowContext(ctx, `SELECT user_id FROM accounts WHERE id = $1`, accountID).Scan(&ownerID)
+		if err != nil || ownerID != userID {
+			return nil, fmt.Errorf("account not found: %s", accountID)
+		}
+	}
+
+	id := generateID()
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO sweep_rules (id, user_id, from_account_id, to_account_id, threshold, min_amount, mode, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, true, $8, $9)
+	`, id, userID, req.FromAccountID, req.ToAccountID, req.Threshold, req.MinAmount, req.Mode, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sweep rule: %w", err)
+	}
+
+	return s.GetSweepRule(ctx, id)
+}
+
+// GetSweepRule gets one of the user's sweep rules
+func (s *Service) GetSweepRule(ctx context.Context, id string) (*SweepRule, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, user_id, from_account_id, to_account_id, threshold, min_amount, mode, is_active, created_at, updated_at
+		FROM sweep_rules
+		WHERE id = $1 AND user_id = $2
+	`, id, userID)
+	rule, err := scanSweepRule(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sweep rule: %w", err)
+	}
+	return rule, nil
+}
+
+// ListSweepRules lists the user's sweep rules
+func (s *Service) ListSweepRules(ctx context.Context) (*ListSweepRulesResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, from_account_id, to_account_id, threshold, min_amount, mode, is_active, created_at, updated_at
+		FROM sweep_rules
+		WHERE user_id = $1
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sweep rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []SweepRule{}
+	for rows.Next() {
+		rule, err := scanSweepRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sweep rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sweep rules: %w", err)
+	}
+	return &ListSweepRulesResponse{Rules: rules}, nil
+}
+
+// UpdateSweepRule changes a sweep rule's threshold, mode or whether it runs
+func (s *Service) UpdateSweepRule(ctx context.Context, id string, req *UpdateSweepRuleRequest) (*SweepRule, error) {
+	rule, err := s.GetSweepRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Threshold != nil {
+		rule.Threshold = *req.Threshold
+	}
+	if req.MinAmount != nil {
+		rule.MinAmount = *req.MinAmount
+	}
+	if req.Mode != nil {
+		rule.Mode = *req.Mode
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	if rule.Threshold < 0 || rule.MinAmount < 0 {
+		return nil, fmt.Errorf("threshold and min_amount must not be negative")
+	}
+	if rule.Mode != SweepModeSuggest && rule.Mode != SweepModeAuto {
+		return nil, fmt.Errorf("mode must be suggest or auto")
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE sweep_rules SET threshold = $1, min_amount = $2, mode = $3, is_active = $4, updated_at = $5
+		WHERE id = $6
+	`, rule.Threshold, rule.MinAmount, rule.Mode, rule.IsActive, time.Now(), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update sweep rule: %w", err)
+	}
+
+	return s.GetSweepRule(ctx, id)
+}
+
+// DeleteSweepRule deletes a sweep rule and its history. Transfers it posted are kept.
+func (s *Service) DeleteSweepRule(ctx context.Context, id string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM sweep_rules WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete sweep rule: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListSweepRuns lists the user's sweep history, newest month first, optionally by status
+func (s *Service) ListSweepRuns(ctx context.Context, status SweepRunStatus) (*ListSweepRunsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT sr.id, sr.rule_id, r.from_account_id, r.to_account_id, sr.period, sr.balance, sr.amount,
+		       sr.status, sr.transfer_id, sr.created_at, sr.updated_at
+		FROM sweep_runs sr
+		JOIN sweep_rules r ON r.id = sr.rule_id
+		WHERE r.user_id = $1 AND ($2 = '' OR sr.status = $2)
+		ORDER BY sr.period DESC, sr.created_at DESC
+	`, userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sweep runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []SweepRun{}
+	for rows.Next() {
+		run, err := scanSweepRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sweep run: %w", err)
+		}
+		runs = append(runs, *run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sweep runs: %w", err)
+	}
+	return &ListSweepRunsResponse{Runs: runs}, nil
+}
+
+// getSweepRun gets one of the user's sweep runs
+func (s *Service) getSweepRun(ctx context.Context, id string) (*SweepRun, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		SELECT sr.id, sr.rule_id, r.from_account_id, r.to_account_id, sr.period, sr.balance, sr.amount,
+		       sr.status, sr.transfer_id, sr.created_at, sr.updated_at
+		FROM sweep_runs sr
+		JOIN sweep_rules r ON r.id = sr.rule_id
+		WHERE sr.id = $1 AND r.user_id = $2
+	`, id, userID)
+	run, err := scanSweepRun(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sweep run: %w", err)
+	}
+	return run, nil
+}
+
+// AcceptSweepRun transfers a suggested surplus today
+func (s *Service) AcceptSweepRun(ctx context.Context, id string, req *AcceptSweepRunRequest) (*SweepRun, error) {
+	run, err := s.getSweepRun(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != SweepRunSuggested {
+		return nil, fmt.Errorf("sweep is already %s", run.Status)
+	}
+	if req.Amount != nil {
+		if *req.Amount <= 0 {
+			return nil, fmt.Errorf("amount must be greater than zero")
+		}
+		run.Amount = *req.Amount
+	}
+
+	if err := s.postSweep(ctx, run, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.getSweepRun(ctx, id)
+}
+
+// DismissSweepRun leaves a suggested surplus where it is
+func (s *Service) DismissSweepRun(ctx context.Context, id string) (*SweepRun, error) {
+	run, err := s.getSweepRun(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != SweepRunSuggested {
+		return nil, fmt.Errorf("sweep is already %s", run.Status)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE sweep_runs SET status = $1, updated_at = $2 WHERE id = $3 AND status = $4
+	`, SweepRunDismissed, time.Now(), id, SweepRunSuggested)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dismiss sweep: %w", err)
+	}
+	return s.getSweepRun(ctx, id)
+}
+
+// postSweep claims a suggested run and records its transfer. The claim is released again if
+// the transfer fails, so the run can be retried.
+func (s *Service) postSweep(ctx context.Context, run *SweepRun, date time.Time) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE sweep_runs SET status = $1, amount = $2, updated_at = $3 WHERE id = $4 AND status = $5
+	`, SweepRunPosted, run.Amount, time.Now(), run.ID, SweepRunSuggested)
+	if err != nil {
+		return fmt.Errorf("failed to claim sweep: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return fmt.Errorf("sweep was already posted or dismissed")
+	}
+
+	notes := fmt.Sprintf("Sweep of surplus for %s", run.Period)
+	transfer, err := s.CreateTransfer(ctx, &CreateTransferRequest{
+		FromAccountID: run.FromAccountID,
+		ToAccountID:   run.ToAccountID,
+		Date:          time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+		Amount:        run.Amount,
+		Notes:         &notes,
+	})
+	if err != nil {
+		if _, releaseErr := s.db.ExecContext(ctx, `
+			UPDATE sweep_runs SET status = $1, updated_at = $2 WHERE id = $3
+		`, SweepRunSuggested, time.Now(), run.ID); releaseErr != nil {
+			logger.Error("Failed to release sweep after transfer failed", "run_id", run.ID, "error", releaseErr)
+		}
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `UPDATE sweep_runs SET transfer_id = $1 WHERE id = $2`, transfer.ID, run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to link sweep transfer: %w", err)
+	}
+	return nil
+}
+
+// RunSweeps checks every active rule against the closing balance of the month before now.
+// A surplus is recorded once per rule and month, and transferred straight away for rules in
+// auto mode. It returns how many surpluses were found.
+func (s *Service) RunSweeps(ctx context.Context, now time.Time) (int, error) {
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 0, -1)
+	period := monthEnd.Format("2006-01")
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, from_account_id, to_account_id, threshold, min_amount, mode, is_active, created_at, updated_at
+		FROM sweep_rules
+		WHERE is_active = true AND from_account_id != to_account_id
+		  AND NOT EXISTS (SELECT 1 FROM sweep_runs WHERE rule_id = sweep_rules.id AND period = $1)
+	`, period)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get sweep rules: %w", err)
+	}
+	var rules []*SweepRule
+	for rows.Next() {
+		rule, err := scanSweepRule(rows)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan sweep rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to get sweep rules: %w", err)
+	}
+
+	found := 0
+	for _, rule := range rules {
+		balance, ok, err := balanceOn(ctx, s.db, rule.FromAccountID, monthEnd)
+		if err != nil {
+			return found, err
+		}
+		if !ok {
+			continue
+		}
+		surplus := math.Round((balance-rule.Threshold)*100) / 100
+		if surplus <= 0 || surplus < rule.MinAmount {
+			continue
+		}
+
+		run := &SweepRun{
+			ID:            generateID(),
+			RuleID:        rule.ID,
+			FromAccountID: rule.FromAccountID,
+			ToAccountID:   rule.ToAccountID,
+			Period:        period,
+			Balance:       balance,
+			Amount:        surplus,
+			Status:        SweepRunSuggested,
+		}
+		result, err := s.db.ExecContext(ctx, `
+			INSERT INTO sweep_runs (id, rule_id, period, balance, amount, status, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+			ON CONFLICT (rule_id, period) DO NOTHING
+		`, run.ID, run.RuleID, run.Period, run.Balance, run.Amount, run.Status, time.Now())
+		if err != nil {
+			return found, fmt.Errorf("failed to record sweep: %w", err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			continue
+		}
+		found++
+
+		if rule.Mode == SweepModeAuto {
+			userCtx := auth.WithUserID(ctx, rule.UserID)
+			if err := s.postSweep(userCtx, run, now); err != nil {
+				// Left as a suggestion for the user to accept
+				logger.Warn("Automatic sweep failed", "rule_id", rule.ID, "period", period, "error", err)
+			}
+		}
+	}
+	return found, nil
+}
+
+// StartSweeps checks sweep rules every few hours until jobs drains, so a closed month is
+// picked up soon after it ends
+func (s *Service) StartSweeps(jobs *background.Group) {
+	jobs.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+
+		for {
+			jobs.Exclusive(ctx, "sweeps", sweepInterval, func(ctx context.Context) {
+				if found, err := s.RunSweeps(ctx, time.Now()); err != nil {
+					logger.Error("Sweep check failed", "error", err)
+				} else if found > 0 {
+					logger.Info("Found month-end surpluses to sweep", "count", found)
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// balanceOn returns an account's latest balance on or before a date, if it has one
+func balanceOn(ctx context.Context, db *sql.DB, accountID string, date time.Time) (float64, bool, error) {
+	rows, err := db.QueryContext(ctx, `SELECT amount, date FROM balances WHERE account_id = $1`, accountID)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get balances: %w", err)
+	}
+	defer rows.Close()
+
+	var current float64
+	var currentDate time.Time
+	for rows.Next() {
+		var amount float64
+		var d time.Time
+		if err := rows.Scan(&amount, &d); err != nil {
+			return 0, false, fmt.Errorf("failed to scan balance: %w", err)
+		}
+		if !d.After(date) && (currentDate.IsZero() || d.After(currentDate)) {
+			current, currentDate = amount, d
+		}
+	}
+	return current, !currentDate.IsZero(), rows.Err()
+}
+
+func scanSweepRule(row interface{ Scan(...interface{}) error }) (*SweepRule, error) {
+	var rule SweepRule
+	err := row.Scan(
+		&rule.ID, &rule.UserID, &rule.FromAccountID, &rule.ToAccountID, &rule.Threshold, &rule.MinAmount,
+		&rule.Mode, &rule.IsActive, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func scanSweepRun(row interface{ Scan(...interface{}) error }) (*SweepRun, error) {
+	var run SweepRun
+	err := row.Scan(
+		&run.ID, &run.RuleID, &run.FromAccountID, &run.ToAccountID, &run.Period, &run.Balance, &run.Amount,
+		&run.Status, &run.TransferID, &run.CreatedAt, &run.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
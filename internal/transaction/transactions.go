@@ -0,0 +1,655 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/database"
+)
+
+// Transaction represents a single pending or posted transaction
+type Transaction struct {
+	ID          string             `json:"id"`
+	UserID      string             `json:"user_id"`
+	AccountID   *string            `json:"account_id,omitempty"`
+	Date        time.Time          `json:"date"`
+	Description string             `json:"description"`
+	Amount      float64            `json:"amount"`   // Negative for money out, positive for money in
+	Currency    string             `json:"currency"` // CAD, USD, INR
+	Category    *string            `json:"category,omitempty"`
+	CategoryID  *string            `json:"category_id,omitempty"`
+	Notes       *string            `json:"notes,omitempty"`
+	TransferID  *string            `json:"transfer_id,omitempty"`            // Set on both legs of a transfer between accounts
+	Status      string             `json:"status"`                           // pending or posted
+	SettledByID *string            `json:"settled_transaction_id,omitempty"` // Posted transaction that replaced this pending one
+	PendingID   *string            `json:"pending_transaction_id,omitempty"` // Pending transaction this posted one replaced
+	Splits      []TransactionSplit `json:"splits"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// TransactionSplit assigns part of a transaction's amount to a category
+type TransactionSplit struct {
+	ID            string  `json:"id"`
+	TransactionID string  `json:"transaction_id"`
+	Category      string  `json:"category"`
+	CategoryID    *string `json:"category_id,omitempty"`
+	Amount        float64 `json:"amount"`
+	Notes         *string `json:"notes,omitempty"`
+}
+
+// SplitRequest is a single split line in a create or set-splits request. A category is
+// given by name or by category_id; a new name creates a top-level category.
+type SplitRequest struct {
+	Category   string  `json:"category"`
+	CategoryID *string `json:"category_id,omitempty"`
+	Amount     float64 `json:"amount"`
+	Notes      *string `json:"notes,omitempty"`
+}
+
+// CreateTransactionRequest is the request for creating a transaction
+type CreateTransactionRequest struct {
+	AccountID   *string        `json:"account_id,omitempty"`
+	Date        time.Time      `json:"date"`
+	Description string         `json:"description"`
+	Amount      float64        `json:"amount"`
+	Currency    string         `json:"currency"`
+	Category    *string        `json:"category,omitempty"`    // Category name; a new name creates a top-level category
+	CategoryID  *string        `json:"category_id,omitempty"` // Takes precedence over category
+	Notes       *string        `json:"notes,omitempty"`
+	Splits      []SplitRequest `json:"splits,omitempty"`
+	Status      string         `json:"status,omitempty"`             // pending or posted (default)
+	SettlesID   *string        `json:"settles_pending_id,omitempty"` // Pending transaction this one replaces; matched automatically when unset
+}
+
+// SetSplitsRequest replaces a transaction's splits; an empty list removes them
+type SetSplitsRequest struct {
+	Splits []SplitRequest `json:"splits"`
+}
+
+// ListTransactionsFilter narrows the transactions returned by ListTransactions
+type ListTransactionsFilter struct {
+	AccountID      string
+	From           *time.Time
+	To             *time.Time
+	Status         string // pending or posted; empty for both
+	IncludeSettled bool   // Include pending transactions already replaced by their posted transaction
+	Limit          int    // Zero for every matching transaction
+	Offset         int
+}
+
+// ListTransactionsResponse is the response for listing transactions
+type ListTransactionsResponse struct {
+	Transactions []Transaction `json:"transactions"`
+}
+
+// CategoryLine is an amount attributed to a category, taken from a split when the
+// transaction has splits and from the transaction itself otherwise
+type CategoryLine struct {
+	TransactionID string    `json:"transaction_id"`
+	Date          time.Time `json:"date"`
+	Category      string    `json:"category"`
+	Amount        float64   `json:"amount"`
+	Currency      string    `json:"currency"`
+}
+
+// CategoryTotal is the total amount attributed to a category
+type CategoryTotal struct {
+	Category string  `json:"category"`
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+	Count    int     `json:"count"`
+}
+
+// CategorySpendingResponse is the response for per-category analytics
+type CategorySpendingResponse struct {
+	From       *time.Time      `json:"from,omitempty"`
+	To         *time.Time      `json:"to,omitempty"`
+	Categories []CategoryTotal `json:"categories"`
+}
+
+// uncategorized is the category reported for transactions without one
+const uncategorized = "uncategorized"
+
+// validateSplits checks that split lines are categorized and sum to the transaction amount
+func validateSplits(amount float64, splits []SplitRequest) error {
+	if len(splits) == 0 {
+		return nil
+	}
+	if len(splits) == 1 {
+		return fmt.Errorf("a split transaction needs at least two splits")
+	}
+
+	total := 0.0
+	for _, split := range splits {
+		if split.Category == "" && split.CategoryID == nil {
+			return fmt.Errorf("split category is required")
+		}
+		total += split.Amount
+	}
+
+	if math.Abs(math.Round(total*100)-math.Round(amount*100)) >= 1 {
+		return fmt.Errorf("splits total %.2f does not match transaction amount %.2f", total, amount)
+	}
+
+	return nil
+}
+
+// CreateTransaction creates a transaction, optionally split across categories
+func (s *Service) CreateTransaction(ctx context.Context, req *CreateTransactionRequest) (*Transaction, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	if req.Date.IsZero() {
+		return nil, fmt.Errorf("date is required")
+	}
+	if err := validateSplits(req.Amount, req.Splits); err != nil {
+		return nil, err
+	}
+	if req.Status == "" {
+		req.Status = StatusPosted
+	}
+	if req.Status != StatusPending && req.Status != StatusPosted {
+		return nil, fmt.Errorf("invalid status: %s", req.Status)
+	}
+	if req.Status == StatusPending && req.SettlesID != nil {
+		return nil, fmt.Errorf("a pending transaction cannot settle another")
+	}
+
+	row := &NewTransaction{
+		UserID:      userID,
+		Source:      SourceManual,
+		AccountID:   req.AccountID,
+		Date:        req.Date,
+		Description: req.Description,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Category:    req.Category,
+		CategoryID:  req.CategoryID,
+		Notes:       req.Notes,
+		Status:      req.Status,
+		Splits:      req.Splits,
+	}
+	if err := s.ValidateNew(ctx, row); err != nil {
+		return nil, err
+	}
+	id := row.ID
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.InsertTx(ctx, tx, row); err != nil {
+		return nil, err
+	}
+
+	if req.Status == StatusPosted {
+		pendingID := req.SettlesID
+		if pendingID == nil && req.AccountID != nil {
+			pendingID, err = findPendingMatch(ctx, tx, userID, *req.AccountID, req.Date, req.Amount, req.Currency)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if pendingID != nil {
+			if err := settlePending(ctx, tx, userID, *pendingID, id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	created, err := s.GetTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if created.Status == StatusPosted {
+		s.notifyPosted(ctx, created)
+	}
+	return created, nil
+}
+
+// insertSplits writes split lines for a transaction
+func insertSplits(ctx context.Context, tx *sql.Tx, userID, transactionID string, splits []SplitRequest) error {
+	now := time.Now()
+	for _, split := range splits {
+		categoryID, category, err := categoryFor(ctx, tx, userID, split.CategoryID, &split.Category)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO transaction_splits (id, transaction_id, category, category_id, amount, notes, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, generateID(), transactionID, *category, categoryID, split.Amount, split.Notes, now)
+		if err != nil {
+			return fmt.Errorf("failed to create transaction split: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetTransaction gets a single transaction with its splits
+func (s *Service) GetTransaction(ctx context.Context, id string) (*Transaction, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+transactionColumns+`
+		FROM transactions t
+		WHERE t.id = $1 AND t.user_id = $2
+	`, id, userID)
+	t, err := scanTransaction(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	splits, err := s.getSplits(ctx, []string{t.ID})
+	if err != nil {
+		return nil, err
+	}
+	t.Splits = splits[t.ID]
+	if t.Splits == nil {
+		t.Splits = []TransactionSplit{}
+	}
+
+	return t, nil
+}
+
+// transactionColumns are the columns read into a Transaction from transactions aliased as t
+const transactionColumns = `t.id, t.user_id, t.account_id, t.date, t.description, t.amount, t.currency,
+		t.category, t.category_id, t.notes, t.transfer_id, t.status, t.settled_transaction_id,
+		(SELECT p.id FROM transactions p WHERE p.settled_transaction_id = t.id LIMIT 1),
+		t.created_at, t.updated_at`
+
+// scanTransaction reads a row selected with transactionColumns
+func scanTransaction(row database.Scanner) (*Transaction, error) {
+	var t Transaction
+	err := row.Scan(
+		&t.ID, &t.UserID, &t.AccountID, &t.Date, &t.Description, &t.Amount, &t.Currency,
+		&t.Category, &t.CategoryID, &t.Notes, &t.TransferID, &t.Status, &t.SettledByID, &t.PendingID,
+		&t.CreatedAt, &t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListTransactions lists the user's transactions matching the filter, newest first
+func (s *Service) ListTransactions(ctx context.Context, filter ListTransactionsFilter) (*ListTransactionsResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions t
+		WHERE t.user_id = $1
+	`
+	args := []interface{}{userID}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		query += fmt.Sprintf(" AND "+condition, len(args))
+	}
+	if filter.AccountID != "" {
+		where("t.account_id = $%d", filter.AccountID)
+	}
+	if filter.From != nil {
+		where("t.date >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		where("t.date <= $%d", *filter.To)
+	}
+	if filter.Status != "" {
+		where("t.status = $%d", filter.Status)
+	}
+	// A settled pending transaction is replaced by its posted one, so counting both
+	// would double-count the spending
+	if !filter.IncludeSettled {
+		query += " AND t.settled_transaction_id IS NULL"
+	}
+	query += " ORDER BY t.date DESC, t.created_at DESC"
+	if filter.Limit > 0 || filter.Offset > 0 {
+		limit := filter.Limit
+		if limit == 0 {
+			limit = -1 // No limit in SQLite
+		}
+		args = append(args, limit, filter.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %w", err)
+	}
+	defer rows.Close()
+
+	transactions := []Transaction{}
+	var ids []string
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, *t)
+		ids = append(ids, t.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %w", err)
+	}
+
+	splits, err := s.getSplits(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range transactions {
+		transactions[i].Splits = splits[transactions[i].ID]
+		if transactions[i].Splits == nil {
+			transactions[i].Splits = []TransactionSplit{}
+		}
+	}
+
+	return &ListTransactionsResponse{Transactions: transactions}, nil
+}
+
+// splitBatchSize bounds how many transaction IDs one splits query binds
+const splitBatchSize = 500
+
+// getSplits loads the splits for the given transactions keyed by transaction ID, in one
+// query per batch of IDs
+func (s *Service) getSplits(ctx context.Context, transactionIDs []string) (map[string][]TransactionSplit, error) {
+	result := make(map[string][]TransactionSplit)
+	for len(transactionIDs) > 0 {
+		batch := transactionIDs[:min(len(transactionIDs), splitBatchSize)]
+		transactionIDs = transactionIDs[len(batch):]
+
+		placeholders := make([]string, len(batch))
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+			args[i] = id
+		}
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT id, transaction_id, category, category_id, amount, notes
+			FROM transaction_splits
+			WHERE transaction_id IN (`+strings.Join(placeholders, ", ")+`)
+			ORDER BY created_at, id
+		`, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get transaction splits: %w", err)
+		}
+
+		for rows.Next() {
+			var split TransactionSplit
+			if err := rows.Scan(&split.ID, &split.TransactionID, &split.Category, &split.CategoryID, &split.Amount, &split.Notes); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan transaction split: %w", err)
+			}
+			result[split.TransactionID] = append(result[split.TransactionID], split)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get transaction splits: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// SetSplits replaces the splits of a transaction. Passing no splits reverts the
+// transaction to its own category.
+func (s *Service) SetSplits(ctx context.Context, id string, req *SetSplitsRequest) (*Transaction, error) {
+	existing, err := s.GetTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing.TransferID != nil {
+		return nil, fmt.Errorf("transfer transactions cannot be split")
+	}
+	if err := validateSplits(existing.Amount, req.Splits); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM transaction_splits WHERE transaction_id = $1`, id); err != nil {
+		return nil, fmt.Errorf("failed to clear transaction splits: %w", err)
+	}
+	if err := insertSplits(ctx, tx, existing.UserID, id, req.Splits); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE transactions SET updated_at = $1 WHERE id = $2`, time.Now(), id); err != nil {
+		return nil, fmt.Errorf("failed to update transaction: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	updated, err := s.GetTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if updated.Status == StatusPosted {
+		s.notifyPosted(ctx, updated)
+	}
+	return updated, nil
+}
+
+// SetDetailsRequest changes a transaction's category or notes; fields left nil are kept
+type SetDetailsRequest struct {
+	Category *string `json:"category,omitempty"` // Category name; a new name creates a top-level category
+	Notes    *string `json:"notes,omitempty"`
+}
+
+// SetDetails recategorizes or annotates a transaction, such as when an extension enriches
+// synced transactions
+func (s *Service) SetDetails(ctx context.Context, id string, req *SetDetailsRequest) (*Transaction, error) {
+	existing, err := s.GetTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Category == nil && req.Notes == nil {
+		return existing, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	categoryID, category := existing.CategoryID, existing.Category
+	if req.Category != nil {
+		if categoryID, category, err = categoryFor(ctx, tx, existing.UserID, nil, req.Category); err != nil {
+			return nil, err
+		}
+	}
+	notes := existing.Notes
+	if req.Notes != nil {
+		notes = req.Notes
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE transactions SET category = $1, category_id = $2, notes = $3, updated_at = $4 WHERE id = $5
+	`, category, categoryID, notes, time.Now(), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update transaction: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	updated, err := s.GetTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if updated.Status == StatusPosted && req.Category != nil {
+		s.notifyPosted(ctx, updated)
+	}
+	return updated, nil
+}
+
+// DeleteTransaction deletes a transaction and its splits. Transfer legs are removed by
+// deleting the transfer so both sides stay in step.
+func (s *Service) DeleteTransaction(ctx context.Context, id string) error {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return fmt.Errorf("user not authenticated")
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM transactions
+		WHERE id = $1 AND user_id = $2 AND transfer_id IS NULL
+	`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete transaction: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetCategoryLines returns the user's transactions broken down into per-category lines.
+// Split transactions contribute one line per split instead of their parent category, so
+// analytics and budgets see the true category mix. Transactions on accounts excluded from
+// spending are left out.
+func (s *Service) GetCategoryLines(ctx context.Context, from, to *time.Time) ([]CategoryLine, error) {
+	resp, err := s.ListTransactions(ctx, ListTransactionsFilter{From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+	excluded, err := s.spendingExcludedAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []CategoryLine
+	for _, t := range resp.Transactions {
+		if t.AccountID != nil && excluded[*t.AccountID] {
+			continue
+		}
+		// Transfers move money between the user's own accounts; they are neither income nor spending
+		if t.TransferID != nil {
+			continue
+		}
+		if len(t.Splits) > 0 {
+			for _, split := range t.Splits {
+				lines = append(lines, CategoryLine{
+					TransactionID: t.ID,
+					Date:          t.Date,
+					Category:      split.Category,
+					Amount:        split.Amount,
+					Currency:      t.Currency,
+				})
+			}
+			continue
+		}
+
+		category := uncategorized
+		if t.Category != nil && *t.Category != "" {
+			category = *t.Category
+		}
+		lines = append(lines, CategoryLine{
+			TransactionID: t.ID,
+			Date:          t.Date,
+			Category:      category,
+			Amount:        t.Amount,
+			Currency:      t.Currency,
+		})
+	}
+
+	return lines, nil
+}
+
+// spendingExcludedAccounts returns the IDs of the user's accounts excluded from spending
+func (s *Service) spendingExcludedAccounts(ctx context.Context) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id FROM accounts WHERE user_id = $1 AND exclude_from_spending = true
+	`, auth.GetUserID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get excluded accounts: %w", err)
+	}
+	defer rows.Close()
+
+	excluded := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan excluded account: %w", err)
+		}
+		excluded[id] = true
+	}
+	return excluded, rows.Err()
+}
+
+// GetCategorySpending totals transaction amounts per category and currency
+func (s *Service) GetCategorySpending(ctx context.Context, from, to *time.Time) (*CategorySpendingResponse, error) {
+	lines, err := s.GetCategoryLines(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CategorySpendingResponse{
+		From:       from,
+		To:         to,
+		Categories: totalCategoryLines(lines),
+	}, nil
+}
+
+// totalCategoryLines totals category lines per category and currency
+func totalCategoryLines(lines []CategoryLine) []CategoryTotal {
+	type key struct{ category, currency string }
+	totals := make(map[key]*CategoryTotal)
+	for _, line := range lines {
+		k := key{line.Category, line.Currency}
+		total, ok := totals[k]
+		if !ok {
+			total = &CategoryTotal{Category: line.Category, Currency: line.Currency}
+			totals[k] = total
+		}
+		total.Amount += line.Amount
+		total.Count++
+	}
+
+	categories := make([]CategoryTotal, 0, len(totals))
+	for _, total := range totals {
+		total.Amount = math.Round(total.Amount*100) / 100
+		categories = append(categories, *total)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Category != categories[j].Category {
+			return categories[i].Category < categories[j].Category
+		}
+		return categories[i].Currency < categories[j].Currency
+	})
+	return categories
+}
@@ -0,0 +1,333 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"money/internal/auth"
+	"money/internal/balance"
+	"money/internal/civil"
+	"money/internal/database"
+)
+
+// Transfer moves money between two of the user's accounts. It is recorded as a pair of
+// linked transactions and a balance change on each account, so net worth is unchanged
+// and cash flow analytics skip it.
+type Transfer struct {
+	ID                string    `json:"id"`
+	FromAccountID     string    `json:"from_account_id"`
+	ToAccountID       string    `json:"to_account_id"`
+	Date              time.Time `json:"date"`
+	Amount            float64   `json:"amount"` // Leaves the source account, in its currency
+	FromCurrency      string    `json:"from_currency"`
+	ToCurrency        string    `json:"to_currency"`
+	ExchangeRate      float64   `json:"exchange_rate"`
+	ConvertedAmount   float64   `json:"converted_amount"` // Arrives in the destination account, in its currency
+	Notes             *string   `json:"notes,omitempty"`
+	FromTransactionID string    `json:"from_transaction_id"`
+	ToTransactionID   string    `json:"to_transaction_id"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// CreateTransferRequest is the request for transferring money between accounts.
+// For accounts in different currencies, the converted amount comes from ConvertedAmount,
+// then ExchangeRate, then the latest stored exchange rate.
+type CreateTransferRequest struct {
+	FromAccountID   string    `json:"from_account_id"`
+	ToAccountID     string    `json:"to_account_id"`
+	Date            time.Time `json:"date"`
+	Amount          float64   `json:"amount"`
+	ExchangeRate    *float64  `json:"exchange_rate,omitempty"`
+	ConvertedAmount *float64  `json:"converted_amount,omitempty"`
+	Notes           *string   `json:"notes,omitempty"`
+}
+
+// ListTransfersResponse is the response for listing transfers
+type ListTransfersResponse struct {
+	Transfers []Transfer `json:"transfers"`
+}
+
+// transferAccount is the account data a transfer or statement import needs
+type transferAccount struct {
+	name     string
+	currency string
+}
+
+// getTransferAccount loads an account owned by the user
+func getTransferAccount(ctx context.Context, tx database.Querier, userID, accountID string) (*transferAccount, error) {
+	var acc transferAccount
+	err := tx.QueryRowContext(ctx, `
+		SELECT name, currency FROM accounts WHERE id = $1 AND user_id = $2
+	`, accountID, userID).Scan(&acc.name, &acc.currency)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("account not found: %s", accountID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	return &acc, nil
+}
+
+// latestExchangeRate returns the most recent stored rate between two currencies
+func latestExchangeRate(ctx context.Context, tx database.Querier, from, to string) (float64, error) {
+	var rate float64
+	err := tx.QueryRowContext(ctx, `
+		SELECT rate FROM exchange_rates
+		WHERE from_currency = $1 AND to_currency = $2
+		ORDER BY date DESC
+		LIMIT 1
+	`, from, to).Scan(&rate)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("no exchange rate from %s to %s; provide exchange_rate or converted_amount", from, to)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get exchange rate: %w", err)
+	}
+	return rate, nil
+}
+
+// adjustBalance applies a change to an account's balance on a date, starting from the
+// latest balance on or before that date. Later balance entries are shifted by the same
+// change, so a backdated transfer carries through to today's balance.
+func adjustBalance(ctx context.Context, tx *sql.Tx, accountID string, date time.Time, delta float64, notes string) error {
+	// Balances are kept per calendar day in the user's timezone, as balance.Service.Create
+	// stores them, so the transfer lands on that day's entry
+	date = civil.DateFor(ctx, date).Time
+
+	var current float64
+	err := tx.QueryRowContext(ctx, `
+		SELECT amount FROM balances
+		WHERE account_id = $1 AND date <= $2
+		ORDER BY date DESC, created_at DESC
+		LIMIT 1
+	`, accountID, date).Scan(&current)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	now := time.Now()
+	_, err = tx.ExecContext(ctx, `
+		UPDATE balances SET amount = ROUND(amount + $1, 2), updated_at = $2
+		WHERE account_id = $3 AND date > $4
+	`, delta, now, accountID, date)
+	if err != nil {
+		return fmt.Errorf("failed to shift later balances: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO balances (id, account_id, amount, date, notes, source, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, 'manual', $6, $7)
+		ON CONFLICT (account_id, date) DO UPDATE SET
+			amount = excluded.amount,
+			notes = excluded.notes,
+			updated_at = excluded.updated_at
+	`, generateID(), accountID, math.Round((current+delta)*100)/100, date, notes, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to update balance: %w", err)
+	}
+	return balance.QueueAccountNetWorthRecompute(ctx, tx, accountID, date)
+}
+
+// CreateTransfer atomically moves money between two accounts on a date
+func (s *Service) CreateTransfer(ctx context.Context, req *CreateTransferRequest) (*Transfer, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	if req.FromAccountID == "" || req.ToAccountID == "" {
+		return nil, fmt.Errorf("from_account_id and to_account_id are required")
+	}
+	if req.FromAccountID == req.ToAccountID {
+		return nil, fmt.Errorf("cannot transfer to the same account")
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+	if req.Date.IsZero() {
+		return nil, fmt.Errorf("date is required")
+	}
+
+	from, err := getTransferAccount(ctx, s.db, userID, req.FromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := getTransferAccount(ctx, s.db, userID, req.ToAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Transfer{
+		ID:            generateID(),
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Date:          req.Date,
+		Amount:        req.Amount,
+		FromCurrency:  from.currency,
+		ToCurrency:    to.currency,
+		ExchangeRate:  1,
+		Notes:         req.Notes,
+		CreatedAt:     time.Now(),
+	}
+
+	switch {
+	case req.ConvertedAmount != nil:
+		if *req.ConvertedAmount <= 0 {
+			return nil, fmt.Errorf("converted amount must be greater than zero")
+		}
+		t.ConvertedAmount = *req.ConvertedAmount
+		t.ExchangeRate = *req.ConvertedAmount / req.Amount
+	case req.ExchangeRate != nil:
+		if *req.ExchangeRate <= 0 {
+			return nil, fmt.Errorf("exchange rate must be greater than zero")
+		}
+		t.ExchangeRate = *req.ExchangeRate
+	case from.currency != to.currency:
+		t.ExchangeRate, err = latestExchangeRate(ctx, s.db, from.currency, to.currency)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if t.ConvertedAmount == 0 {
+		t.ConvertedAmount = math.Round(req.Amount*t.ExchangeRate*100) / 100
+	}
+
+	// Both legs are validated before anything is written
+	category := transferCategory
+	legs := []*NewTransaction{
+		{AccountID: &t.FromAccountID, Description: "Transfer to " + to.name, Amount: -t.Amount, Currency: t.FromCurrency},
+		{AccountID: &t.ToAccountID, Description: "Transfer from " + from.name, Amount: t.ConvertedAmount, Currency: t.ToCurrency},
+	}
+	for _, leg := range legs {
+		leg.UserID, leg.Source, leg.Date = userID, SourceTransfer, t.Date
+		leg.Category, leg.Notes, leg.TransferID = &category, t.Notes, &t.ID
+	}
+	if err := s.ValidateNew(ctx, legs...); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO transfers (
+			id, user_id, from_account_id, to_account_id, date, amount,
+			from_currency, to_currency, exchange_rate, converted_amount, notes, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, t.ID, userID, t.FromAccountID, t.ToAccountID, t.Date, t.Amount,
+		t.FromCurrency, t.ToCurrency, t.ExchangeRate, t.ConvertedAmount, t.Notes, t.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transfer: %w", err)
+	}
+
+	for _, leg := range legs {
+		if err := s.InsertTx(ctx, tx, leg); err != nil {
+			return nil, fmt.Errorf("failed to create transfer transaction: %w", err)
+		}
+		if err := adjustBalance(ctx, tx, *leg.AccountID, t.Date, leg.Amount, leg.Description); err != nil {
+			return nil, err
+		}
+	}
+	t.FromTransactionID, t.ToTransactionID = legs[0].ID, legs[1].ID
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return t, nil
+}
+
+// GetTransfer gets a single transfer with its linked transactions
+func (s *Service) GetTransfer(ctx context.Context, id string) (*Transfer, error) {
+	resp, err := s.listTransfers(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Transfers) == 0 {
+		return nil, ErrNotFound
+	}
+	return &resp.Transfers[0], nil
+}
+
+// ListTransfers lists the user's transfers, newest first
+func (s *Service) ListTransfers(ctx context.Context) (*ListTransfersResponse, error) {
+	return s.listTransfers(ctx, "")
+}
+
+// listTransfers loads the user's transfers, or a single transfer when id is set
+func (s *Service) listTransfers(ctx context.Context, id string) (*ListTransfersResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.id, t.from_account_id, t.to_account_id, t.date, t.amount, t.from_currency, t.to_currency,
+			t.exchange_rate, t.converted_amount, t.notes, t.created_at,
+			COALESCE((SELECT id FROM transactions WHERE transfer_id = t.id AND amount < 0), ''),
+			COALESCE((SELECT id FROM transactions WHERE transfer_id = t.id AND amount >= 0), '')
+		FROM transfers t
+		WHERE t.user_id = $1 AND ($2 = '' OR t.id = $2)
+		ORDER BY t.date DESC, t.created_at DESC
+	`, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transfers: %w", err)
+	}
+	defer rows.Close()
+
+	transfers := []Transfer{}
+	for rows.Next() {
+		var t Transfer
+		err := rows.Scan(
+			&t.ID, &t.FromAccountID, &t.ToAccountID, &t.Date, &t.Amount, &t.FromCurrency, &t.ToCurrency,
+			&t.ExchangeRate, &t.ConvertedAmount, &t.Notes, &t.CreatedAt,
+			&t.FromTransactionID, &t.ToTransactionID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transfer: %w", err)
+		}
+		transfers = append(transfers, t)
+	}
+
+	return &ListTransfersResponse{Transfers: transfers}, rows.Err()
+}
+
+// DeleteTransfer removes a transfer and its transactions and reverses its balance changes
+func (s *Service) DeleteTransfer(ctx context.Context, id string) error {
+	t, err := s.GetTransfer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := adjustBalance(ctx, tx, t.FromAccountID, t.Date, t.Amount, "Transfer reversed"); err != nil {
+		return err
+	}
+	if err := adjustBalance(ctx, tx, t.ToAccountID, t.Date, -t.ConvertedAmount, "Transfer reversed"); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM transactions WHERE transfer_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete transfer transactions: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM transfers WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete transfer: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
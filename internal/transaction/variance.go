@@ -0,0 +1,190 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"money/internal/auth"
+)
+
+// DefaultVarianceMonths is the number of months compared when none is requested
+const DefaultVarianceMonths = 6
+
+// MaxVarianceMonths caps how many months one comparison covers
+const MaxVarianceMonths = 60
+
+// Trend describes the direction of actual spending across the compared months
+type Trend string
+
+const (
+	TrendIncreasing Trend = "increasing"
+	TrendDecreasing Trend = "decreasing"
+	TrendStable     Trend = "stable"
+)
+
+// trendThreshold is the relative change between the earlier and later months treated as a trend
+const trendThreshold = 0.05
+
+// MonthVariance compares planned and actual spending for one category in one month
+type MonthVariance struct {
+	Month    string  `json:"month"` // YYYY-MM
+	Planned  float64 `json:"planned"`
+	Actual   float64 `json:"actual"`
+	Variance float64 `json:"variance"` // actual - planned; positive means overspent
+}
+
+// CategoryVariance is the planned vs actual history for a category
+type CategoryVariance struct {
+	Category      string          `json:"category"`
+	Currency      string          `json:"currency"`
+	Months        []MonthVariance `json:"months"`
+	TotalPlanned  float64         `json:"total_planned"`
+	TotalActual   float64         `json:"total_actual"`
+	TotalVariance float64         `json:"total_variance"`
+	Trend         Trend           `json:"trend"`
+}
+
+// CashFlowVarianceResponse is the response for planned vs actual cash flow
+type CashFlowVarianceResponse struct {
+	FromMonth  string             `json:"from_month"`
+	ToMonth    string             `json:"to_month"`
+	Categories []CategoryVariance `json:"categories"`
+}
+
+// spendingTrend compares average spending in the later half of the months with the earlier half
+func spendingTrend(months []MonthVariance) Trend {
+	if len(months) < 2 {
+		return TrendStable
+	}
+
+	half := len(months) / 2
+	earlier, later := 0.0, 0.0
+	for i, m := range months {
+		if i < half {
+			earlier += m.Actual
+		} else if i >= len(months)-half {
+			later += m.Actual
+		}
+	}
+	earlier /= float64(half)
+	later /= float64(half)
+
+	base := math.Max(math.Abs(earlier), 1)
+	change := (later - earlier) / base
+	switch {
+	case change > trendThreshold:
+		return TrendIncreasing
+	case change < -trendThreshold:
+		return TrendDecreasing
+	default:
+		return TrendStable
+	}
+}
+
+// GetCashFlowVariance compares planned recurring expenses with actual transaction spending
+// per category for the last n months, including the current month
+func (s *Service) GetCashFlowVariance(ctx context.Context, months int) (*CashFlowVarianceResponse, error) {
+	return s.getCashFlowVariance(ctx, months, time.Now())
+}
+
+func (s *Service) getCashFlowVariance(ctx context.Context, months int, now time.Time) (*CashFlowVarianceResponse, error) {
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return nil, fmt.Errorf("user not authenticated")
+	}
+	if months <= 0 {
+		months = DefaultVarianceMonths
+	}
+	if months > MaxVarianceMonths {
+		return nil, fmt.Errorf("months must be at most %d", MaxVarianceMonths)
+	}
+
+	lastMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	firstMonth := lastMonth.AddDate(0, -(months - 1), 0)
+	to := lastMonth.AddDate(0, 1, 0).Add(-time.Nanosecond)
+
+	monthKeys := make([]string, months)
+	for i := range monthKeys {
+		monthKeys[i] = firstMonth.AddDate(0, i, 0).Format("2006-01")
+	}
+
+	type key struct{ category, currency string }
+	planned := make(map[key]float64)
+	actual := make(map[key]map[string]float64)
+
+	expenses, err := s.ListRecurringExpenses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, expense := range expenses.Expenses {
+		if !expense.IsActive {
+			continue
+		}
+		k := key{expense.Category, expense.Currency}
+		monthly, err := ConvertFrequency(expense.Amount, expense.Frequency, "monthly")
+		if err != nil {
+			// An expense with an unknown frequency is planned as monthly
+			monthly = expense.Amount
+		}
+		planned[k] += monthly
+	}
+
+	lines, err := s.GetCategoryLines(ctx, &firstMonth, &to)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		k := key{line.Category, line.Currency}
+		if actual[k] == nil {
+			actual[k] = make(map[string]float64)
+		}
+		// Transactions record outflows as negative amounts; spending is reported as positive
+		actual[k][line.Date.Format("2006-01")] -= line.Amount
+	}
+
+	keys := make(map[key]bool)
+	for k := range planned {
+		keys[k] = true
+	}
+	for k := range actual {
+		keys[k] = true
+	}
+
+	categories := make([]CategoryVariance, 0, len(keys))
+	for k := range keys {
+		cv := CategoryVariance{Category: k.category, Currency: k.currency}
+		plan := math.Round(planned[k]*100) / 100
+		for _, month := range monthKeys {
+			spent := math.Round(actual[k][month]*100) / 100
+			cv.Months = append(cv.Months, MonthVariance{
+				Month:    month,
+				Planned:  plan,
+				Actual:   spent,
+				Variance: math.Round((spent-plan)*100) / 100,
+			})
+			cv.TotalPlanned += plan
+			cv.TotalActual += spent
+		}
+		cv.TotalPlanned = math.Round(cv.TotalPlanned*100) / 100
+		cv.TotalActual = math.Round(cv.TotalActual*100) / 100
+		cv.TotalVariance = math.Round((cv.TotalActual-cv.TotalPlanned)*100) / 100
+		cv.Trend = spendingTrend(cv.Months)
+		categories = append(categories, cv)
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Category != categories[j].Category {
+			return categories[i].Category < categories[j].Category
+		}
+		return categories[i].Currency < categories[j].Currency
+	})
+
+	return &CashFlowVarianceResponse{
+		FromMonth:  monthKeys[0],
+		ToMonth:    monthKeys[len(monthKeys)-1],
+		Categories: categories,
+	}, nil
+}
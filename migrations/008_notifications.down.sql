@@ -0,0 +1,4 @@
+-- Drop notifications table (SQLite)
+DROP INDEX IF EXISTS idx_notifications_is_read;
+DROP INDEX IF EXISTS idx_notifications_user_id;
+DROP TABLE IF EXISTS notifications;
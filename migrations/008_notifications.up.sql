@@ -0,0 +1,19 @@
+-- User notifications raised by background checks (SQLite)
+CREATE TABLE IF NOT EXISTS notifications (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    title TEXT NOT NULL,
+    message TEXT NOT NULL,
+    entity_type TEXT,
+    entity_id TEXT,
+    dedupe_key TEXT NOT NULL,  -- Prevents the same condition from notifying twice
+    due_date DATE,  -- Date the underlying event happens, if any
+    is_read INTEGER NOT NULL DEFAULT 0,
+    read_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    UNIQUE(user_id, dedupe_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_is_read ON notifications(user_id, is_read);
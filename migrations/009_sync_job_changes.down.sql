@@ -0,0 +1,3 @@
+-- Drop sync job change log (SQLite)
+DROP INDEX IF EXISTS idx_sync_job_changes_sync_job_id;
+DROP TABLE IF EXISTS sync_job_changes;
@@ -0,0 +1,15 @@
+-- Per-sync-job change log of balances and holdings written by a sync (SQLite)
+CREATE TABLE IF NOT EXISTS sync_job_changes (
+    id TEXT PRIMARY KEY,
+    sync_job_id TEXT NOT NULL REFERENCES sync_jobs(id) ON DELETE CASCADE,
+    entity_type TEXT NOT NULL CHECK (entity_type IN ('balance', 'holding')),
+    entity_id TEXT,
+    change_type TEXT NOT NULL CHECK (change_type IN ('created', 'updated', 'removed')),
+    label TEXT NOT NULL,  -- Balance date or holding symbol
+    field TEXT NOT NULL,  -- amount, quantity, cost_basis
+    old_value DECIMAL(20,8),
+    new_value DECIMAL(20,8),
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_job_changes_sync_job_id ON sync_job_changes(sync_job_id);
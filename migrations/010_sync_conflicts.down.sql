@@ -0,0 +1,8 @@
+-- Drop sync conflict tracking (SQLite)
+DROP INDEX IF EXISTS idx_sync_conflicts_status;
+DROP INDEX IF EXISTS idx_sync_conflicts_account_id;
+DROP TABLE IF EXISTS sync_conflicts;
+
+ALTER TABLE synced_accounts DROP COLUMN conflict_policy;
+ALTER TABLE balances DROP COLUMN updated_at;
+ALTER TABLE balances DROP COLUMN source;
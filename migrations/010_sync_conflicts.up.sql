@@ -0,0 +1,28 @@
+-- Sync conflict detection for manually edited synced accounts (SQLite)
+
+-- Track where a balance came from and when it was last edited
+ALTER TABLE balances ADD COLUMN source TEXT NOT NULL DEFAULT 'manual' CHECK (source IN ('manual', 'sync'));
+ALTER TABLE balances ADD COLUMN updated_at DATETIME;
+
+-- Per-account policy applied when a manual edit is newer than provider data
+ALTER TABLE synced_accounts ADD COLUMN conflict_policy TEXT NOT NULL DEFAULT 'provider_wins'
+    CHECK (conflict_policy IN ('provider_wins', 'manual_wins', 'flag_for_review'));
+
+-- Conflicts detected during sync
+CREATE TABLE IF NOT EXISTS sync_conflicts (
+    id TEXT PRIMARY KEY,
+    synced_account_id TEXT NOT NULL REFERENCES synced_accounts(id) ON DELETE CASCADE,
+    account_id TEXT NOT NULL,
+    sync_job_id TEXT REFERENCES sync_jobs(id) ON DELETE SET NULL,
+    balance_id TEXT NOT NULL,
+    balance_date DATE NOT NULL,
+    manual_amount DECIMAL(15,2) NOT NULL,
+    provider_amount DECIMAL(15,2) NOT NULL,
+    policy TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'kept_manual', 'applied_provider')),
+    resolved_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_conflicts_account_id ON sync_conflicts(account_id);
+CREATE INDEX IF NOT EXISTS idx_sync_conflicts_status ON sync_conflicts(status);
@@ -0,0 +1,3 @@
+-- Drop reconciliations table (SQLite)
+DROP INDEX IF EXISTS idx_reconciliations_account_id;
+DROP TABLE IF EXISTS reconciliations;
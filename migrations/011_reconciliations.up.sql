@@ -0,0 +1,17 @@
+-- Statement reconciliation history per account (SQLite)
+CREATE TABLE IF NOT EXISTS reconciliations (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    statement_date DATE NOT NULL,
+    statement_balance DECIMAL(15,2) NOT NULL,
+    recorded_balance DECIMAL(15,2),  -- Latest recorded balance on or before the statement date
+    recorded_balance_date DATE,
+    discrepancy DECIMAL(15,2) NOT NULL,  -- statement_balance - recorded_balance
+    status TEXT NOT NULL CHECK (status IN ('matched', 'discrepancy', 'adjusted')),
+    adjustment_balance_id TEXT,
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    resolved_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliations_account_id ON reconciliations(account_id);
@@ -0,0 +1,9 @@
+-- Drop transactions and splits (SQLite)
+DROP INDEX IF EXISTS idx_transaction_splits_category;
+DROP INDEX IF EXISTS idx_transaction_splits_transaction_id;
+DROP TABLE IF EXISTS transaction_splits;
+
+DROP INDEX IF EXISTS idx_transactions_date;
+DROP INDEX IF EXISTS idx_transactions_account_id;
+DROP INDEX IF EXISTS idx_transactions_user_id;
+DROP TABLE IF EXISTS transactions;
@@ -0,0 +1,31 @@
+-- Transactions and category splits (SQLite)
+CREATE TABLE IF NOT EXISTS transactions (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    account_id TEXT,
+    date DATE NOT NULL,
+    description TEXT NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,  -- Negative for money out, positive for money in
+    currency TEXT NOT NULL CHECK (currency IN ('CAD', 'USD', 'INR')),
+    category TEXT,  -- Ignored by analytics when the transaction has splits
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id ON transactions(user_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_account_id ON transactions(account_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_date ON transactions(date);
+
+-- Split lines; amounts always sum to the parent transaction amount
+CREATE TABLE IF NOT EXISTS transaction_splits (
+    id TEXT PRIMARY KEY,
+    transaction_id TEXT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    category TEXT NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_splits_transaction_id ON transaction_splits(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_splits_category ON transaction_splits(category);
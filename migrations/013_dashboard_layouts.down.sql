@@ -0,0 +1,2 @@
+-- Drop dashboard layouts table (SQLite)
+DROP TABLE IF EXISTS dashboard_layouts;
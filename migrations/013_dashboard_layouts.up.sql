@@ -0,0 +1,8 @@
+-- Per-user dashboard layout (SQLite)
+CREATE TABLE IF NOT EXISTS dashboard_layouts (
+    user_id TEXT PRIMARY KEY,
+    persona TEXT NOT NULL,
+    widgets TEXT NOT NULL,  -- JSON array of widgets in display order
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
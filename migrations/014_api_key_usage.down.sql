@@ -0,0 +1,4 @@
+-- Drop API key usage table (SQLite)
+DROP INDEX IF EXISTS idx_api_key_usage_created_at;
+DROP INDEX IF EXISTS idx_api_key_usage_api_key_id;
+DROP TABLE IF EXISTS api_key_usage;
@@ -0,0 +1,13 @@
+-- Per-request usage log for API keys (SQLite)
+CREATE TABLE IF NOT EXISTS api_key_usage (
+    id TEXT PRIMARY KEY,
+    api_key_id TEXT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    endpoint TEXT NOT NULL,
+    ip_address TEXT,
+    status_code INTEGER NOT NULL,  -- 0 when the request never completed
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_key_usage_api_key_id ON api_key_usage(api_key_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_api_key_usage_created_at ON api_key_usage(created_at);
@@ -0,0 +1,3 @@
+-- Drop service API keys table (SQLite)
+DROP INDEX IF EXISTS idx_service_api_keys_user_id;
+DROP TABLE IF EXISTS service_api_keys;
@@ -0,0 +1,18 @@
+-- Service API keys for authenticating automation against this server (SQLite)
+CREATE TABLE IF NOT EXISTS service_api_keys (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    key_prefix TEXT NOT NULL,  -- First characters of the key, shown to identify it
+    key_hash TEXT NOT NULL UNIQUE,  -- SHA-256 of the full key; the key itself is never stored
+    scopes TEXT NOT NULL DEFAULT '["read"]',  -- JSON array of scopes
+    allowed_ips TEXT NOT NULL DEFAULT '[]',  -- JSON array of IPs/CIDRs; empty allows any address
+    expires_at DATETIME,
+    is_active INTEGER NOT NULL DEFAULT 1,
+    last_used_at DATETIME,
+    rotated_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_api_keys_user_id ON service_api_keys(user_id);
@@ -0,0 +1,2 @@
+-- Remove opening balance flag (SQLite)
+ALTER TABLE balances DROP COLUMN is_opening;
@@ -0,0 +1,2 @@
+-- Mark the balance that starts an account's history (SQLite)
+ALTER TABLE balances ADD COLUMN is_opening INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Remove per-account projection assumptions (SQLite)
+ALTER TABLE accounts DROP COLUMN expected_appreciation;
+ALTER TABLE accounts DROP COLUMN expected_return;
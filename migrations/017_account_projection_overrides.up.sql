@@ -0,0 +1,3 @@
+-- Per-account projection assumptions that override the type-level rates (SQLite)
+ALTER TABLE accounts ADD COLUMN expected_return DECIMAL(7,4);
+ALTER TABLE accounts ADD COLUMN expected_appreciation DECIMAL(7,4);
@@ -0,0 +1,6 @@
+-- Drop HELOC tables (SQLite)
+DROP INDEX IF EXISTS idx_heloc_transactions_date;
+DROP INDEX IF EXISTS idx_heloc_transactions_account;
+DROP TABLE IF EXISTS heloc_transactions;
+DROP INDEX IF EXISTS idx_heloc_details_account;
+DROP TABLE IF EXISTS heloc_details;
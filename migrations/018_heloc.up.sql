@@ -0,0 +1,31 @@
+-- Home equity lines of credit secured by a property account (SQLite)
+CREATE TABLE IF NOT EXISTS heloc_details (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL UNIQUE REFERENCES accounts(id) ON DELETE CASCADE,
+    property_account_id TEXT REFERENCES accounts(id) ON DELETE SET NULL,
+    mortgage_account_id TEXT REFERENCES accounts(id) ON DELETE SET NULL,
+    max_ltv DECIMAL(5,4) NOT NULL,  -- Combined loan-to-value limit, e.g. 0.80
+    credit_limit DECIMAL(15,2),  -- Lender's cap on the line, if lower than the LTV limit
+    interest_rate DECIMAL(5,4) NOT NULL,
+    lender TEXT,
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_heloc_details_account ON heloc_details(account_id);
+
+-- Draws, repayments and capitalized interest on a HELOC
+CREATE TABLE IF NOT EXISTS heloc_transactions (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    transaction_date DATE NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('draw', 'repayment', 'interest')),
+    amount DECIMAL(15,2) NOT NULL,
+    balance_after DECIMAL(15,2) NOT NULL,  -- Amount owed after the transaction
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_heloc_transactions_account ON heloc_transactions(account_id);
+CREATE INDEX IF NOT EXISTS idx_heloc_transactions_date ON heloc_transactions(transaction_date);
@@ -0,0 +1,5 @@
+-- Drop transfers (SQLite)
+DROP INDEX IF EXISTS idx_transactions_transfer_id;
+ALTER TABLE transactions DROP COLUMN transfer_id;
+DROP INDEX IF EXISTS idx_transfers_user_id;
+DROP TABLE IF EXISTS transfers;
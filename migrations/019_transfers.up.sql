@@ -0,0 +1,21 @@
+-- Transfers between accounts, recorded as two linked transactions (SQLite)
+CREATE TABLE IF NOT EXISTS transfers (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    from_account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    to_account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,  -- Amount leaving the source account, in its currency
+    from_currency TEXT NOT NULL CHECK (from_currency IN ('CAD', 'USD', 'INR')),
+    to_currency TEXT NOT NULL CHECK (to_currency IN ('CAD', 'USD', 'INR')),
+    exchange_rate DECIMAL(15,6) NOT NULL DEFAULT 1,
+    converted_amount DECIMAL(15,2) NOT NULL,  -- Amount arriving in the destination account
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfers_user_id ON transfers(user_id);
+
+ALTER TABLE transactions ADD COLUMN transfer_id TEXT REFERENCES transfers(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_transfer_id ON transactions(transfer_id);
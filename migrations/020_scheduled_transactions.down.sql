@@ -0,0 +1,4 @@
+-- Drop scheduled transactions (SQLite)
+DROP INDEX IF EXISTS idx_scheduled_transactions_status;
+DROP INDEX IF EXISTS idx_scheduled_transactions_user_id;
+DROP TABLE IF EXISTS scheduled_transactions;
@@ -0,0 +1,20 @@
+-- Future-dated planned transactions, posted when their date passes or they are confirmed (SQLite)
+CREATE TABLE IF NOT EXISTS scheduled_transactions (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    account_id TEXT REFERENCES accounts(id) ON DELETE CASCADE,
+    scheduled_date DATE NOT NULL,
+    description TEXT NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,  -- Negative for money out, positive for money in
+    currency TEXT NOT NULL CHECK (currency IN ('CAD', 'USD', 'INR')),
+    category TEXT,
+    notes TEXT,
+    status TEXT NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'posted', 'cancelled')),
+    posted_transaction_id TEXT,
+    posted_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_transactions_user_id ON scheduled_transactions(user_id);
+CREATE INDEX IF NOT EXISTS idx_scheduled_transactions_status ON scheduled_transactions(status);
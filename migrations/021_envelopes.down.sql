@@ -0,0 +1,5 @@
+-- Drop envelope budgeting (SQLite)
+DROP INDEX IF EXISTS idx_envelope_movements_account_id;
+DROP INDEX IF EXISTS idx_envelopes_account_id;
+DROP TABLE IF EXISTS envelope_movements;
+DROP TABLE IF EXISTS envelopes;
@@ -0,0 +1,26 @@
+-- Virtual envelopes that partition an account's balance into purposes (SQLite)
+CREATE TABLE IF NOT EXISTS envelopes (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    allocated DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (allocated >= 0),
+    target_amount DECIMAL(15,2),  -- Optional goal for the envelope
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    UNIQUE(account_id, name)
+);
+
+-- History of money moved into, out of, and between envelopes
+CREATE TABLE IF NOT EXISTS envelope_movements (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    from_envelope_id TEXT REFERENCES envelopes(id) ON DELETE SET NULL,  -- NULL means unallocated cash
+    to_envelope_id TEXT REFERENCES envelopes(id) ON DELETE SET NULL,    -- NULL means unallocated cash
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_envelopes_account_id ON envelopes(account_id);
+CREATE INDEX IF NOT EXISTS idx_envelope_movements_account_id ON envelope_movements(account_id);
@@ -0,0 +1,2 @@
+-- Drop self-employment tax settings (SQLite)
+DROP TABLE IF EXISTS self_employment_tax_settings;
@@ -0,0 +1,8 @@
+-- Where a user sets aside tax on self-employment income (SQLite)
+CREATE TABLE IF NOT EXISTS self_employment_tax_settings (
+    user_id TEXT PRIMARY KEY,
+    set_aside_account_id TEXT REFERENCES accounts(id) ON DELETE SET NULL,
+    set_aside_envelope_id TEXT REFERENCES envelopes(id) ON DELETE SET NULL,  -- Takes precedence over the account balance
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
@@ -0,0 +1,5 @@
+-- Drop tax installments (SQLite)
+DROP INDEX IF EXISTS idx_tax_installment_payments_installment_id;
+DROP INDEX IF EXISTS idx_tax_installments_user_id;
+DROP TABLE IF EXISTS tax_installment_payments;
+DROP TABLE IF EXISTS tax_installments;
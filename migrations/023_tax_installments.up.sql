@@ -0,0 +1,25 @@
+-- Quarterly estimated tax installments and the payments made against them (SQLite)
+CREATE TABLE IF NOT EXISTS tax_installments (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    tax_year INTEGER NOT NULL,
+    jurisdiction TEXT NOT NULL CHECK (jurisdiction IN ('cra', 'irs')),
+    quarter INTEGER NOT NULL CHECK (quarter BETWEEN 1 AND 4),
+    due_date DATE NOT NULL,
+    amount_due DECIMAL(15,2) NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    UNIQUE(user_id, tax_year, jurisdiction, quarter)
+);
+
+CREATE TABLE IF NOT EXISTS tax_installment_payments (
+    id TEXT PRIMARY KEY,
+    installment_id TEXT NOT NULL REFERENCES tax_installments(id) ON DELETE CASCADE,
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    paid_date DATE NOT NULL,
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_tax_installments_user_id ON tax_installments(user_id);
+CREATE INDEX IF NOT EXISTS idx_tax_installment_payments_installment_id ON tax_installment_payments(installment_id);
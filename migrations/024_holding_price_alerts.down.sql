@@ -0,0 +1,4 @@
+-- Drop security quotes and holding price alerts (SQLite)
+DROP INDEX IF EXISTS idx_holding_price_alerts_holding_id;
+DROP TABLE IF EXISTS holding_price_alerts;
+DROP TABLE IF EXISTS security_quotes;
@@ -0,0 +1,22 @@
+-- Latest security quotes and per-holding price alert rules (SQLite)
+CREATE TABLE IF NOT EXISTS security_quotes (
+    symbol TEXT PRIMARY KEY,
+    price DECIMAL(20,8) NOT NULL CHECK (price > 0),
+    previous_close DECIMAL(20,8) CHECK (previous_close > 0),  -- Used for daily move alerts
+    quoted_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS holding_price_alerts (
+    id TEXT PRIMARY KEY,
+    holding_id TEXT NOT NULL REFERENCES holdings(id) ON DELETE CASCADE,
+    condition TEXT NOT NULL CHECK (condition IN ('price_above', 'price_below', 'daily_move')),
+    threshold DECIMAL(20,8) NOT NULL CHECK (threshold > 0),  -- Price, or percent for daily_move
+    is_active INTEGER NOT NULL DEFAULT 1,
+    notes TEXT,
+    last_triggered_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_holding_price_alerts_holding_id ON holding_price_alerts(holding_id);
@@ -0,0 +1,5 @@
+-- Drop target allocations (SQLite)
+ALTER TABLE notifications DROP COLUMN payload;
+DROP INDEX IF EXISTS idx_target_allocations_user_id;
+DROP TABLE IF EXISTS allocation_settings;
+DROP TABLE IF EXISTS target_allocations;
@@ -0,0 +1,20 @@
+-- Target asset allocations, drift settings and structured notification payloads (SQLite)
+CREATE TABLE IF NOT EXISTS target_allocations (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    asset_class TEXT NOT NULL CHECK (asset_class IN ('equity', 'fixed_income', 'cash', 'crypto', 'other')),
+    target_percent DECIMAL(5,2) NOT NULL CHECK (target_percent >= 0 AND target_percent <= 100),
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    UNIQUE(user_id, asset_class)
+);
+
+CREATE TABLE IF NOT EXISTS allocation_settings (
+    user_id TEXT PRIMARY KEY,
+    drift_band_percent DECIMAL(5,2) NOT NULL DEFAULT 5 CHECK (drift_band_percent > 0),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+ALTER TABLE notifications ADD COLUMN payload TEXT;  -- Optional JSON details for the client
+
+CREATE INDEX IF NOT EXISTS idx_target_allocations_user_id ON target_allocations(user_id);
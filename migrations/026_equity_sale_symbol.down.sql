@@ -0,0 +1,2 @@
+-- Remove equity sale symbol (SQLite)
+ALTER TABLE equity_sales DROP COLUMN symbol;
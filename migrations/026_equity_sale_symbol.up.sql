@@ -0,0 +1,2 @@
+-- Ticker of the shares sold, used to find replacement purchases in holdings (SQLite)
+ALTER TABLE equity_sales ADD COLUMN symbol TEXT;
@@ -0,0 +1,3 @@
+-- Drop cost basis lots (SQLite)
+DROP INDEX IF EXISTS idx_cost_basis_lots_account_symbol;
+DROP TABLE IF EXISTS cost_basis_lots;
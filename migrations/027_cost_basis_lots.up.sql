@@ -0,0 +1,13 @@
+-- Historical cost basis lots imported for brokerage positions opened before sync (SQLite)
+CREATE TABLE IF NOT EXISTS cost_basis_lots (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    symbol TEXT NOT NULL,
+    quantity DECIMAL(20,8) NOT NULL CHECK (quantity > 0),
+    total_cost DECIMAL(20,2) NOT NULL CHECK (total_cost >= 0),
+    acquired_date DATE,
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_basis_lots_account_symbol ON cost_basis_lots(account_id, symbol);
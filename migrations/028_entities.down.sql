@@ -0,0 +1,7 @@
+-- Drop entities (SQLite)
+DROP INDEX IF EXISTS idx_entity_transactions_user_id;
+DROP TABLE IF EXISTS entity_transactions;
+DROP INDEX IF EXISTS idx_accounts_entity_id;
+ALTER TABLE accounts DROP COLUMN entity_id;
+DROP INDEX IF EXISTS idx_entities_user_id;
+DROP TABLE IF EXISTS entities;
@@ -0,0 +1,35 @@
+-- Personal and business entities that own accounts, with inter-entity transactions (SQLite)
+CREATE TABLE IF NOT EXISTS entities (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('personal', 'sole_proprietorship', 'corporation')),
+    tax_jurisdiction TEXT,
+    corporate_tax_rate DECIMAL(5,2) CHECK (corporate_tax_rate IS NULL OR (corporate_tax_rate >= 0 AND corporate_tax_rate <= 100)),
+    fiscal_year_end_month INTEGER NOT NULL DEFAULT 12 CHECK (fiscal_year_end_month BETWEEN 1 AND 12),
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_entities_user_id ON entities(user_id);
+
+ALTER TABLE accounts ADD COLUMN entity_id TEXT REFERENCES entities(id) ON DELETE SET NULL;  -- NULL is the user's personal holdings
+
+CREATE INDEX IF NOT EXISTS idx_accounts_entity_id ON accounts(entity_id);
+
+CREATE TABLE IF NOT EXISTS entity_transactions (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    from_entity_id TEXT NOT NULL REFERENCES entities(id) ON DELETE CASCADE,
+    to_entity_id TEXT NOT NULL REFERENCES entities(id) ON DELETE CASCADE,
+    type TEXT NOT NULL CHECK (type IN ('owner_draw', 'dividend', 'salary', 'capital_contribution', 'loan_repayment')),
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    currency TEXT NOT NULL CHECK (currency IN ('CAD', 'USD', 'INR')),
+    date DATE NOT NULL,
+    transfer_id TEXT REFERENCES transfers(id) ON DELETE SET NULL,  -- Optional transfer that moved the money
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_entity_transactions_user_id ON entity_transactions(user_id);
@@ -0,0 +1,3 @@
+-- Drop feature flags (SQLite)
+DROP TABLE IF EXISTS user_feature_flags;
+DROP TABLE IF EXISTS feature_flags;
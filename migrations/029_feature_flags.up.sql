@@ -0,0 +1,15 @@
+-- Instance-wide feature flag settings and per-user overrides (SQLite)
+CREATE TABLE IF NOT EXISTS feature_flags (
+    key TEXT PRIMARY KEY,
+    enabled INTEGER NOT NULL DEFAULT 0,
+    rollout_percent INTEGER NOT NULL DEFAULT 100 CHECK (rollout_percent BETWEEN 0 AND 100),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS user_feature_flags (
+    user_id TEXT NOT NULL,
+    flag_key TEXT NOT NULL,
+    enabled INTEGER NOT NULL,
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    PRIMARY KEY (user_id, flag_key)
+);
@@ -0,0 +1,2 @@
+-- Remove user timezone (SQLite)
+ALTER TABLE users DROP COLUMN timezone;
@@ -0,0 +1,2 @@
+-- User timezone used to decide which calendar day dates fall on (SQLite)
+ALTER TABLE users ADD COLUMN timezone TEXT;
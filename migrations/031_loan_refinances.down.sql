@@ -0,0 +1,3 @@
+-- Drop loan refinance history (SQLite)
+DROP INDEX IF EXISTS idx_loan_refinances_account;
+DROP TABLE IF EXISTS loan_refinances;
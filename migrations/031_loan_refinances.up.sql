@@ -0,0 +1,23 @@
+-- Loan refinance history: the terms a loan had before each refinance (SQLite)
+CREATE TABLE IF NOT EXISTS loan_refinances (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    previous_loan_details_id TEXT NOT NULL,  -- Closed loan_details row, kept here since an account has one active row
+    new_loan_details_id TEXT NOT NULL,
+    refinance_date DATE NOT NULL,
+    balance_at_refinance DECIMAL(15,2) NOT NULL,
+    closing_costs DECIMAL(15,2) NOT NULL DEFAULT 0,
+    previous_interest_rate DECIMAL(5,4) NOT NULL,
+    previous_rate_type TEXT NOT NULL,
+    previous_start_date DATE NOT NULL,
+    previous_term_months INTEGER NOT NULL,
+    previous_payment_amount DECIMAL(15,2) NOT NULL,
+    previous_payment_frequency TEXT NOT NULL,
+    previous_original_amount DECIMAL(15,2) NOT NULL,
+    previous_lender TEXT,
+    previous_maturity_date DATE NOT NULL,
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_loan_refinances_account ON loan_refinances(account_id);
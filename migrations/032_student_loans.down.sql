@@ -0,0 +1,4 @@
+-- Drop student loan terms (SQLite)
+DROP INDEX IF EXISTS idx_student_loan_subsidy_periods_account;
+DROP TABLE IF EXISTS student_loan_subsidy_periods;
+DROP TABLE IF EXISTS student_loan_details;
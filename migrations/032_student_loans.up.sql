@@ -0,0 +1,27 @@
+-- Student loan terms: grace period, interest subsidy periods and repayment programs (SQLite)
+CREATE TABLE IF NOT EXISTS student_loan_details (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL UNIQUE REFERENCES accounts(id) ON DELETE CASCADE,
+    grace_period_end DATE,  -- No payments are due before this date
+    repayment_plan TEXT NOT NULL DEFAULT 'standard' CHECK (repayment_plan IN ('standard', 'income_driven')),
+    income_driven_percent DECIMAL(5,4),  -- Share of income above the threshold paid each year
+    income_driven_threshold DECIMAL(15,2),  -- Annual income exempt from income-driven payments
+    forgiveness_months INTEGER CHECK (forgiveness_months IS NULL OR forgiveness_months > 0),
+    qualifying_payments INTEGER NOT NULL DEFAULT 0,  -- Qualifying payments already made toward forgiveness
+    forgiveness_taxable BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+-- Periods when the government pays the interest, e.g. in school or during deferment
+CREATE TABLE IF NOT EXISTS student_loan_subsidy_periods (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    description TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_student_loan_subsidy_periods_account ON student_loan_subsidy_periods(account_id);
@@ -0,0 +1,6 @@
+-- Drop scheduled payment auto-posting (SQLite)
+ALTER TABLE mortgage_payments DROP COLUMN needs_reconciliation;
+ALTER TABLE mortgage_payments DROP COLUMN auto_posted;
+ALTER TABLE loan_payments DROP COLUMN needs_reconciliation;
+ALTER TABLE loan_payments DROP COLUMN auto_posted;
+DROP TABLE IF EXISTS payment_auto_posting;
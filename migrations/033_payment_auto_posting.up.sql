@@ -0,0 +1,15 @@
+-- Scheduled payment auto-posting for loans and mortgages (SQLite)
+CREATE TABLE IF NOT EXISTS payment_auto_posting (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL UNIQUE REFERENCES accounts(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT 1,
+    last_posted_date DATE NOT NULL,  -- Payments due after this date are posted as their dates pass
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Auto-posted payments, and whether a real payment on the same date differed from the schedule
+ALTER TABLE loan_payments ADD COLUMN auto_posted BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE loan_payments ADD COLUMN needs_reconciliation BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE mortgage_payments ADD COLUMN auto_posted BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE mortgage_payments ADD COLUMN needs_reconciliation BOOLEAN NOT NULL DEFAULT 0;
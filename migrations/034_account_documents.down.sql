@@ -0,0 +1,3 @@
+-- Drop account documents vault (SQLite)
+DROP INDEX IF EXISTS idx_account_documents_account;
+DROP TABLE IF EXISTS account_documents;
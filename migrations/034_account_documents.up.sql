@@ -0,0 +1,18 @@
+-- Account documents vault with expiry and renewal dates (SQLite)
+CREATE TABLE IF NOT EXISTS account_documents (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    document_type TEXT NOT NULL DEFAULT 'other' CHECK (document_type IN ('contract', 'insurance', 'grant_agreement', 'statement', 'tax', 'other')),
+    content_type TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL,
+    content BLOB NOT NULL,
+    expiry_date DATE,
+    renewal_date DATE,
+    reminder_days INTEGER NOT NULL DEFAULT 30,  -- Days before expiry or renewal to send a reminder
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_documents_account ON account_documents(account_id);
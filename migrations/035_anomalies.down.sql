@@ -0,0 +1,3 @@
+-- Drop anomalies (SQLite)
+DROP INDEX IF EXISTS idx_anomalies_user_status;
+DROP TABLE IF EXISTS anomalies;
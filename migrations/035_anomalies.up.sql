@@ -0,0 +1,20 @@
+-- Anomalies found in balances and spending, with their review status (SQLite)
+CREATE TABLE IF NOT EXISTS anomalies (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    account_id TEXT REFERENCES accounts(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('balance_drop', 'duplicate_charge', 'spending_spike')),
+    fingerprint TEXT NOT NULL,  -- Identifies the underlying finding so detection runs don't repeat it
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,    -- The unusual amount
+    baseline DECIMAL(15,2),           -- What was expected, e.g. the trailing average
+    observed_on DATE NOT NULL,
+    details TEXT,                     -- JSON, e.g. the transactions involved
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'acknowledged', 'dismissed')),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, fingerprint)
+);
+
+CREATE INDEX IF NOT EXISTS idx_anomalies_user_status ON anomalies(user_id, status);
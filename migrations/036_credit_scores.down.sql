@@ -0,0 +1,4 @@
+-- Drop credit score tables (SQLite)
+DROP TABLE IF EXISTS credit_score_settings;
+DROP INDEX IF EXISTS idx_credit_scores_user_date;
+DROP TABLE IF EXISTS credit_scores;
@@ -0,0 +1,19 @@
+-- Credit score history and monthly update reminders (SQLite)
+CREATE TABLE IF NOT EXISTS credit_scores (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    score INTEGER NOT NULL CHECK (score >= 300 AND score <= 900),
+    bureau TEXT NOT NULL CHECK (bureau IN ('equifax', 'transunion', 'experian', 'other')),
+    recorded_on DATE NOT NULL,
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_credit_scores_user_date ON credit_scores(user_id, recorded_on);
+
+CREATE TABLE IF NOT EXISTS credit_score_settings (
+    user_id TEXT PRIMARY KEY,
+    reminders_enabled BOOLEAN NOT NULL DEFAULT 0,  -- Remind the user to enter a new score monthly
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+-- Drop estate planning tables (SQLite)
+DROP INDEX IF EXISTS idx_report_shares_user;
+DROP TABLE IF EXISTS report_shares;
+DROP TABLE IF EXISTS account_estate_details;
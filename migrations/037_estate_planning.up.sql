@@ -0,0 +1,24 @@
+-- Estate planning designations per account and report share links (SQLite)
+CREATE TABLE IF NOT EXISTS account_estate_details (
+    account_id TEXT PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+    is_registered BOOLEAN NOT NULL DEFAULT 0,  -- Tax-registered plan such as an RRSP, TFSA or 401(k)
+    registration_type TEXT,
+    beneficiaries TEXT NOT NULL DEFAULT '[]',  -- JSON array of designated beneficiaries
+    successor_holder TEXT,
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS report_shares (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    report TEXT NOT NULL CHECK (report IN ('estate')),
+    label TEXT,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_shares_user ON report_shares(user_id);
@@ -0,0 +1,23 @@
+-- Drop share link access log and net worth statement links (SQLite)
+DROP INDEX IF EXISTS idx_report_share_accesses_share;
+DROP TABLE IF EXISTS report_share_accesses;
+
+CREATE TABLE IF NOT EXISTS report_shares_old (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    report TEXT NOT NULL CHECK (report IN ('estate')),
+    label TEXT,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO report_shares_old (id, user_id, report, label, token_hash, expires_at, revoked_at, created_at)
+SELECT id, user_id, report, label, token_hash, expires_at, revoked_at, created_at FROM report_shares
+WHERE report = 'estate';
+
+DROP TABLE report_shares;
+ALTER TABLE report_shares_old RENAME TO report_shares;
+
+CREATE INDEX IF NOT EXISTS idx_report_shares_user ON report_shares(user_id);
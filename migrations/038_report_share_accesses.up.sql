@@ -0,0 +1,34 @@
+-- Net worth statement share links and share link access log (SQLite)
+
+-- Rebuild report_shares to widen the report CHECK constraint and track access
+CREATE TABLE IF NOT EXISTS report_shares_new (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    report TEXT NOT NULL CHECK (report IN ('estate', 'net_worth')),
+    label TEXT,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME,
+    access_count INTEGER NOT NULL DEFAULT 0,  -- Successful views
+    last_accessed_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO report_shares_new (id, user_id, report, label, token_hash, expires_at, revoked_at, created_at)
+SELECT id, user_id, report, label, token_hash, expires_at, revoked_at, created_at FROM report_shares;
+
+DROP TABLE report_shares;
+ALTER TABLE report_shares_new RENAME TO report_shares;
+
+CREATE INDEX IF NOT EXISTS idx_report_shares_user ON report_shares(user_id);
+
+CREATE TABLE IF NOT EXISTS report_share_accesses (
+    id TEXT PRIMARY KEY,
+    share_id TEXT NOT NULL REFERENCES report_shares(id) ON DELETE CASCADE,
+    accessed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ip_address TEXT,
+    user_agent TEXT,
+    granted BOOLEAN NOT NULL  -- False when the link was used after it expired or was revoked
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_share_accesses_share ON report_share_accesses(share_id, accessed_at);
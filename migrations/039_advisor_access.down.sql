@@ -0,0 +1,8 @@
+-- Drop advisor access tables (SQLite)
+DROP INDEX IF EXISTS idx_advisor_comments_grant;
+DROP TABLE IF EXISTS advisor_comments;
+DROP INDEX IF EXISTS idx_advisor_access_log_grant;
+DROP TABLE IF EXISTS advisor_access_log;
+DROP INDEX IF EXISTS idx_advisor_grants_advisor;
+DROP INDEX IF EXISTS idx_advisor_grants_owner;
+DROP TABLE IF EXISTS advisor_grants;
@@ -0,0 +1,42 @@
+-- Delegated read-only or comment access for financial advisors (SQLite)
+CREATE TABLE IF NOT EXISTS advisor_grants (
+    id TEXT PRIMARY KEY,
+    owner_user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    advisor_email TEXT NOT NULL,
+    advisor_user_id TEXT REFERENCES users(id) ON DELETE CASCADE,  -- Set when the invite is accepted
+    access TEXT NOT NULL CHECK (access IN ('read', 'comment')),
+    modules TEXT NOT NULL,  -- JSON array of granted modules
+    invite_token_hash TEXT NOT NULL UNIQUE,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'active', 'revoked')),
+    expires_at DATETIME,  -- Access ends at this time when set
+    accepted_at DATETIME,
+    revoked_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_advisor_grants_owner ON advisor_grants(owner_user_id);
+CREATE INDEX IF NOT EXISTS idx_advisor_grants_advisor ON advisor_grants(advisor_user_id, owner_user_id);
+
+CREATE TABLE IF NOT EXISTS advisor_access_log (
+    id TEXT PRIMARY KEY,
+    grant_id TEXT NOT NULL REFERENCES advisor_grants(id) ON DELETE CASCADE,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    client_ip TEXT,
+    allowed BOOLEAN NOT NULL,
+    accessed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_advisor_access_log_grant ON advisor_access_log(grant_id, accessed_at);
+
+CREATE TABLE IF NOT EXISTS advisor_comments (
+    id TEXT PRIMARY KEY,
+    grant_id TEXT NOT NULL REFERENCES advisor_grants(id) ON DELETE CASCADE,
+    module TEXT NOT NULL,
+    entity_id TEXT,  -- Optional account, holding or other record the comment is about
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_advisor_comments_grant ON advisor_comments(grant_id, created_at);
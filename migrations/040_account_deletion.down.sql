@@ -0,0 +1,3 @@
+-- Drop account closure requests (SQLite)
+DROP INDEX IF EXISTS idx_account_deletion_requests_purge_after;
+DROP TABLE IF EXISTS account_deletion_requests;
@@ -0,0 +1,8 @@
+-- Account closure requests, purged once the grace window ends (SQLite)
+CREATE TABLE IF NOT EXISTS account_deletion_requests (
+    user_id TEXT PRIMARY KEY,
+    requested_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    purge_after DATETIME NOT NULL  -- All of the user's data is deleted after this time
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_deletion_requests_purge_after ON account_deletion_requests(purge_after);
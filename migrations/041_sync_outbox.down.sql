@@ -0,0 +1,3 @@
+-- Drop sync outbox (SQLite)
+DROP INDEX IF EXISTS idx_sync_outbox_pending;
+DROP TABLE IF EXISTS sync_outbox;
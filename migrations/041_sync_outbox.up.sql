@@ -0,0 +1,15 @@
+-- Side effects of a sync, written in the same transaction as the synced data and
+-- delivered only after it commits (SQLite)
+CREATE TABLE IF NOT EXISTS sync_outbox (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    sync_job_id TEXT REFERENCES sync_jobs(id) ON DELETE SET NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,  -- JSON event body
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    dispatched_at DATETIME,  -- Set once every subscriber has handled the event
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_outbox_pending ON sync_outbox(dispatched_at, created_at);
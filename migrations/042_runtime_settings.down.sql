@@ -0,0 +1,2 @@
+-- Drop runtime settings (SQLite)
+DROP TABLE IF EXISTS runtime_settings;
@@ -0,0 +1,7 @@
+-- Runtime settings changed through the admin API without a restart (SQLite)
+CREATE TABLE IF NOT EXISTS runtime_settings (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_by TEXT,
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
@@ -0,0 +1,2 @@
+-- Drop sync checkpoints (SQLite)
+ALTER TABLE sync_credentials DROP COLUMN sync_checkpoint;
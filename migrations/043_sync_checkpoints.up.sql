@@ -0,0 +1,3 @@
+-- Provider accounts already synced by a run that was interrupted, so the next start resumes
+-- the run instead of repeating it (SQLite)
+ALTER TABLE sync_credentials ADD COLUMN sync_checkpoint TEXT;
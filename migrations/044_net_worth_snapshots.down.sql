@@ -0,0 +1,5 @@
+-- Drop net worth snapshots (SQLite)
+DROP INDEX IF EXISTS idx_net_worth_events_user;
+DROP TABLE IF EXISTS net_worth_events;
+DROP TABLE IF EXISTS net_worth_recompute_queue;
+DROP TABLE IF EXISTS net_worth_snapshots;
@@ -0,0 +1,31 @@
+-- Daily net worth snapshots per currency, rebuilt in the background when balances on or
+-- before a snapshot change (SQLite)
+CREATE TABLE IF NOT EXISTS net_worth_snapshots (
+    user_id TEXT NOT NULL,
+    date TEXT NOT NULL,  -- YYYY-MM-DD, one row for each day a balance was recorded
+    currency TEXT NOT NULL,
+    assets REAL NOT NULL,
+    liabilities REAL NOT NULL,  -- Positive amount owed
+    net_worth REAL NOT NULL,
+    computed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, date, currency)
+);
+
+-- Pending snapshot rebuilds, one per user. Repeated edits widen the range to the earliest
+-- changed date rather than queueing another rebuild.
+CREATE TABLE IF NOT EXISTS net_worth_recompute_queue (
+    user_id TEXT PRIMARY KEY,
+    from_date TEXT NOT NULL,  -- YYYY-MM-DD, snapshots on or after this date are rebuilt
+    requested_at DATETIME NOT NULL
+);
+
+-- Finished rebuilds, polled by clients to know when to refresh net worth charts
+CREATE TABLE IF NOT EXISTS net_worth_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+    from_date TEXT NOT NULL,
+    snapshots INTEGER NOT NULL,  -- Snapshots written by the rebuild
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_net_worth_events_user ON net_worth_events(user_id, id);
@@ -0,0 +1,2 @@
+-- Drop synced account pausing (SQLite)
+ALTER TABLE synced_accounts DROP COLUMN paused_at;
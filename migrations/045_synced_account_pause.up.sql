@@ -0,0 +1,2 @@
+-- Synced accounts the user paused, skipped by connection syncs until resumed (SQLite)
+ALTER TABLE synced_accounts ADD COLUMN paused_at DATETIME;
@@ -0,0 +1,3 @@
+-- Drop provider account discovery (SQLite)
+DROP TABLE IF EXISTS skipped_provider_accounts;
+ALTER TABLE sync_credentials DROP COLUMN account_selection;
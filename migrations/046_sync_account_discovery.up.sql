@@ -0,0 +1,12 @@
+-- How a connection picks the provider accounts it syncs: 'auto' creates a local account for
+-- every open provider account, 'manual' only syncs the accounts chosen after discovery (SQLite)
+ALTER TABLE sync_credentials ADD COLUMN account_selection TEXT NOT NULL DEFAULT 'auto'
+    CHECK (account_selection IN ('auto', 'manual'));
+
+-- Provider accounts the user chose not to sync, so syncs never create local accounts for them
+CREATE TABLE IF NOT EXISTS skipped_provider_accounts (
+    credential_id TEXT NOT NULL REFERENCES sync_credentials(id) ON DELETE CASCADE,
+    provider_account_id TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (credential_id, provider_account_id)
+);
@@ -0,0 +1,3 @@
+-- Drop employer matching tables (SQLite)
+DROP TABLE IF EXISTS employer_match_vesting;
+DROP TABLE IF EXISTS employer_match_details;
@@ -0,0 +1,23 @@
+-- Employer matching on retirement accounts such as group RRSPs and 401(k)s (SQLite)
+CREATE TABLE IF NOT EXISTS employer_match_details (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL UNIQUE REFERENCES accounts(id) ON DELETE CASCADE,
+    employee_contribution_rate DECIMAL(5,4) NOT NULL DEFAULT 0,  -- Share of gross salary the employee contributes
+    match_rate DECIMAL(5,4) NOT NULL,  -- Employer contribution per dollar the employee contributes
+    match_cap_rate DECIMAL(5,4),  -- Employer match limited to this share of gross salary
+    annual_match_cap DECIMAL(15,2),  -- Employer match limited to this amount per year
+    employment_start_date DATE,  -- Service for vesting counts from this date
+    employer_contributions DECIMAL(15,2) NOT NULL DEFAULT 0,  -- Employer money already in the account
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+-- Graded vesting of the employer portion: the share vested after each number of years of service
+CREATE TABLE IF NOT EXISTS employer_match_vesting (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    years_of_service INTEGER NOT NULL CHECK (years_of_service >= 0),
+    vested_percent DECIMAL(5,4) NOT NULL CHECK (vested_percent >= 0 AND vested_percent <= 1),
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    UNIQUE(account_id, years_of_service)
+);
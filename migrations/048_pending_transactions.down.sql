@@ -0,0 +1,4 @@
+-- Drop pending transaction state (SQLite)
+DROP INDEX IF EXISTS idx_transactions_pending;
+ALTER TABLE transactions DROP COLUMN settled_transaction_id;
+ALTER TABLE transactions DROP COLUMN status;
@@ -0,0 +1,6 @@
+-- Pending transactions and their settlement: a pending transaction is linked to the posted
+-- transaction that replaced it, and is then left out of listings and analytics (SQLite)
+ALTER TABLE transactions ADD COLUMN status TEXT NOT NULL DEFAULT 'posted' CHECK (status IN ('pending', 'posted'));
+ALTER TABLE transactions ADD COLUMN settled_transaction_id TEXT REFERENCES transactions(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_pending ON transactions(account_id, status);
@@ -0,0 +1,7 @@
+-- Drop categories (SQLite)
+DROP INDEX IF EXISTS idx_transaction_splits_category;
+DROP INDEX IF EXISTS idx_transactions_category;
+ALTER TABLE transaction_splits DROP COLUMN category_id;
+ALTER TABLE transactions DROP COLUMN category_id;
+DROP INDEX IF EXISTS idx_categories_parent;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,45 @@
+-- User-defined transaction categories, nested under a parent category (SQLite)
+CREATE TABLE IF NOT EXISTS categories (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    parent_id TEXT REFERENCES categories(id) ON DELETE SET NULL,
+    icon TEXT,
+    color TEXT,  -- Hex colour such as #ff8800
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    UNIQUE(user_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent ON categories(parent_id);
+
+-- Transactions and splits reference their category; the category text is kept as its name
+ALTER TABLE transactions ADD COLUMN category_id TEXT REFERENCES categories(id) ON DELETE SET NULL;
+ALTER TABLE transaction_splits ADD COLUMN category_id TEXT REFERENCES categories(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_category ON transactions(category_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_splits_category ON transaction_splits(category_id);
+
+-- Existing free-text categories become top-level categories. Transfer legs are categorized
+-- as 'transfer' by the system and stay out of the category model.
+INSERT OR IGNORE INTO categories (id, user_id, name)
+SELECT lower(hex(randomblob(16))), user_id, category
+FROM (
+    SELECT DISTINCT user_id, category FROM transactions
+    WHERE category IS NOT NULL AND category <> '' AND transfer_id IS NULL
+    UNION
+    SELECT DISTINCT t.user_id, s.category FROM transaction_splits s
+    JOIN transactions t ON t.id = s.transaction_id
+    WHERE s.category <> ''
+);
+
+UPDATE transactions
+SET category_id = (SELECT c.id FROM categories c WHERE c.user_id = transactions.user_id AND c.name = transactions.category)
+WHERE category IS NOT NULL AND transfer_id IS NULL;
+
+UPDATE transaction_splits
+SET category_id = (
+    SELECT c.id FROM categories c
+    JOIN transactions t ON t.user_id = c.user_id
+    WHERE t.id = transaction_splits.transaction_id AND c.name = transaction_splits.category
+);
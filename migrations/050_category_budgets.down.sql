@@ -0,0 +1,4 @@
+-- Drop category budgets (SQLite)
+DROP INDEX IF EXISTS idx_category_budgets_category;
+DROP INDEX IF EXISTS idx_category_budgets_user;
+DROP TABLE IF EXISTS category_budgets;
@@ -0,0 +1,16 @@
+-- Monthly spending budgets per category, optionally rolling over between months (SQLite)
+CREATE TABLE IF NOT EXISTS category_budgets (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    category_id TEXT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    amount DECIMAL(15,2) NOT NULL CHECK (amount >= 0),  -- Budgeted each month
+    currency TEXT NOT NULL,
+    rollover BOOLEAN NOT NULL DEFAULT 0,  -- Unspent budget carries into the next month, overspend is taken from it
+    start_month TEXT NOT NULL,            -- YYYY-MM; carryover accumulates from this month
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    UNIQUE(user_id, category_id, currency)
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_budgets_user ON category_budgets(user_id);
+CREATE INDEX IF NOT EXISTS idx_category_budgets_category ON category_budgets(category_id);
@@ -0,0 +1,2 @@
+-- Drop budget alert thresholds (SQLite)
+ALTER TABLE category_budgets DROP COLUMN alert_thresholds;
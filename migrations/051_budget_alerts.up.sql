@@ -0,0 +1,2 @@
+-- Percentages of a budget at which the user is notified, as a JSON array such as [75, 100] (SQLite)
+ALTER TABLE category_budgets ADD COLUMN alert_thresholds TEXT NOT NULL DEFAULT '[]';
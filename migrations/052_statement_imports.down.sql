@@ -0,0 +1,4 @@
+-- Drop statement imports (SQLite)
+DROP TABLE IF EXISTS statement_import_lines;
+DROP INDEX IF EXISTS idx_statement_imports_user;
+DROP TABLE IF EXISTS statement_imports;
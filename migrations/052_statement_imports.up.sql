@@ -0,0 +1,34 @@
+-- Transactions read from uploaded PDF statements, held for review until committed (SQLite)
+CREATE TABLE IF NOT EXISTS statement_imports (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    file_name TEXT NOT NULL,
+    institution TEXT NOT NULL,
+    template TEXT NOT NULL,
+    period_start DATE,
+    period_end DATE,
+    opening_balance DECIMAL(15,2),
+    closing_balance DECIMAL(15,2),  -- Recorded as the account balance on period_end when committed
+    warnings TEXT NOT NULL DEFAULT '[]',  -- JSON array of parser warnings
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'committed')),
+    committed_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_statement_imports_user ON statement_imports(user_id, status);
+
+CREATE TABLE IF NOT EXISTS statement_import_lines (
+    id TEXT PRIMARY KEY,
+    import_id TEXT NOT NULL REFERENCES statement_imports(id) ON DELETE CASCADE,
+    line_number INTEGER NOT NULL,
+    date DATE NOT NULL,
+    description TEXT NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,  -- Negative for money out, positive for money in
+    category TEXT,
+    excluded BOOLEAN NOT NULL DEFAULT 0,  -- Left out when the import is committed
+    duplicate_of TEXT REFERENCES transactions(id) ON DELETE SET NULL,  -- Existing transaction this line appears to repeat
+    transaction_id TEXT REFERENCES transactions(id) ON DELETE SET NULL,  -- Created on commit
+    UNIQUE(import_id, line_number)
+);
@@ -0,0 +1,3 @@
+-- Drop balance reminders (SQLite)
+ALTER TABLE accounts DROP COLUMN stale_after_days;
+DROP TABLE IF EXISTS balance_reminder_settings;
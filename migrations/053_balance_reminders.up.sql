@@ -0,0 +1,10 @@
+-- Reminders to update balances on accounts that haven't been updated in a while (SQLite)
+CREATE TABLE IF NOT EXISTS balance_reminder_settings (
+    user_id TEXT PRIMARY KEY,
+    reminders_enabled BOOLEAN NOT NULL DEFAULT 1,
+    stale_after_days INTEGER NOT NULL DEFAULT 30 CHECK (stale_after_days > 0),
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Per-account threshold; NULL uses the user's stale_after_days
+ALTER TABLE accounts ADD COLUMN stale_after_days INTEGER CHECK (stale_after_days IS NULL OR stale_after_days > 0);
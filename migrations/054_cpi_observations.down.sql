@@ -0,0 +1,2 @@
+-- Drop CPI observations (SQLite)
+DROP TABLE IF EXISTS cpi_observations;
@@ -0,0 +1,9 @@
+-- Monthly consumer price index readings fetched from national statistics agencies (SQLite)
+CREATE TABLE IF NOT EXISTS cpi_observations (
+    region TEXT NOT NULL CHECK (region IN ('CA', 'US')),
+    month TEXT NOT NULL,  -- YYYY-MM
+    index_value REAL NOT NULL CHECK (index_value > 0),
+    source TEXT NOT NULL,
+    fetched_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (region, month)
+);
@@ -0,0 +1,2 @@
+-- Drop data snapshots (SQLite)
+DROP TABLE IF EXISTS data_snapshots;
@@ -0,0 +1,9 @@
+-- Per-table row counts and checksums of a user's data, diffed to debug unexpected changes (SQLite)
+CREATE TABLE IF NOT EXISTS data_snapshots (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    taken_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    tables TEXT NOT NULL  -- JSON object of table name to {rows, checksum}
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_snapshots_user_taken ON data_snapshots(user_id, taken_at);
@@ -0,0 +1,23 @@
+-- Drop broker import columns and fee and interest holding transactions (SQLite)
+CREATE TABLE IF NOT EXISTS holding_transactions_old (
+    id TEXT PRIMARY KEY,
+    holding_id TEXT NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('buy', 'sell', 'dividend', 'split', 'transfer', 'deposit', 'withdrawal')),
+    quantity DECIMAL(20,8),
+    price DECIMAL(20,2),
+    total_amount DECIMAL(20,2),
+    transaction_date DATE NOT NULL,
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+INSERT INTO holding_transactions_old (id, holding_id, type, quantity, price, total_amount, transaction_date, notes, created_at)
+SELECT id, holding_id, type, quantity, price, total_amount, transaction_date, notes, created_at FROM holding_transactions
+WHERE type NOT IN ('fee', 'interest');
+
+DROP TABLE holding_transactions;
+ALTER TABLE holding_transactions_old RENAME TO holding_transactions;
+
+CREATE INDEX IF NOT EXISTS idx_holding_transactions_holding_id ON holding_transactions(holding_id);
+CREATE INDEX IF NOT EXISTS idx_holding_transactions_date ON holding_transactions(transaction_date);
+CREATE INDEX IF NOT EXISTS idx_holding_transactions_type ON holding_transactions(type);
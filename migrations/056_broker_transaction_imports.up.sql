@@ -0,0 +1,27 @@
+-- Holding transactions imported from broker CSV exports (SQLite)
+
+-- Rebuild holding_transactions to allow fee and interest rows and record the trade currency
+CREATE TABLE IF NOT EXISTS holding_transactions_new (
+    id TEXT PRIMARY KEY,
+    holding_id TEXT NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('buy', 'sell', 'dividend', 'split', 'transfer', 'deposit', 'withdrawal', 'fee', 'interest')),
+    quantity DECIMAL(20,8),
+    price DECIMAL(20,2),
+    total_amount DECIMAL(20,2),
+    currency TEXT,  -- Currency the broker settled the transaction in
+    transaction_date DATE NOT NULL,
+    notes TEXT,
+    import_hash TEXT,  -- Fingerprint of the imported CSV row, so re-importing a file skips it
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+INSERT INTO holding_transactions_new (id, holding_id, type, quantity, price, total_amount, transaction_date, notes, created_at)
+SELECT id, holding_id, type, quantity, price, total_amount, transaction_date, notes, created_at FROM holding_transactions;
+
+DROP TABLE holding_transactions;
+ALTER TABLE holding_transactions_new RENAME TO holding_transactions;
+
+CREATE INDEX IF NOT EXISTS idx_holding_transactions_holding_id ON holding_transactions(holding_id);
+CREATE INDEX IF NOT EXISTS idx_holding_transactions_date ON holding_transactions(transaction_date);
+CREATE INDEX IF NOT EXISTS idx_holding_transactions_type ON holding_transactions(type);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_holding_transactions_import ON holding_transactions(holding_id, import_hash);
@@ -0,0 +1,5 @@
+-- Drop sweep rules (SQLite)
+DROP INDEX IF EXISTS idx_sweep_runs_status;
+DROP TABLE IF EXISTS sweep_runs;
+DROP INDEX IF EXISTS idx_sweep_rules_user_id;
+DROP TABLE IF EXISTS sweep_rules;
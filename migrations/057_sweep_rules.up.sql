@@ -0,0 +1,31 @@
+-- Sweep rules moving month-end surpluses from a cash account into an investment account (SQLite)
+CREATE TABLE IF NOT EXISTS sweep_rules (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    from_account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    to_account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    threshold DECIMAL(15,2) NOT NULL,  -- Balance kept in the source account; anything above is swept
+    min_amount DECIMAL(15,2) NOT NULL DEFAULT 0,  -- Surpluses smaller than this are left alone
+    mode TEXT NOT NULL DEFAULT 'suggest' CHECK (mode IN ('suggest', 'auto')),
+    is_active INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_sweep_rules_user_id ON sweep_rules(user_id);
+
+-- One run per rule and month, recording the surplus found and what became of it
+CREATE TABLE IF NOT EXISTS sweep_runs (
+    id TEXT PRIMARY KEY,
+    rule_id TEXT NOT NULL REFERENCES sweep_rules(id) ON DELETE CASCADE,
+    period TEXT NOT NULL,  -- YYYY-MM of the month whose closing balance was checked
+    balance DECIMAL(15,2) NOT NULL,  -- Source account balance at month end
+    amount DECIMAL(15,2) NOT NULL,  -- Surplus to sweep
+    status TEXT NOT NULL DEFAULT 'suggested' CHECK (status IN ('suggested', 'posted', 'dismissed')),
+    transfer_id TEXT REFERENCES transfers(id) ON DELETE SET NULL,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    UNIQUE (rule_id, period)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sweep_runs_status ON sweep_runs(status);
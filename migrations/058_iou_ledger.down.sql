@@ -0,0 +1,5 @@
+-- Drop IOU ledger (SQLite)
+DROP INDEX IF EXISTS idx_iou_entries_transaction_id;
+DROP INDEX IF EXISTS idx_iou_entries_person_id;
+DROP TABLE IF EXISTS iou_entries;
+DROP TABLE IF EXISTS iou_people;
@@ -0,0 +1,26 @@
+-- IOU ledger of shared expenses with people outside the app (SQLite)
+CREATE TABLE IF NOT EXISTS iou_people (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    currency TEXT NOT NULL DEFAULT 'CAD' CHECK (currency IN ('CAD', 'USD', 'INR')),
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    UNIQUE (user_id, name)
+);
+
+-- Shares of split expenses and settlements; the sum of a person's entries is what they owe
+CREATE TABLE IF NOT EXISTS iou_entries (
+    id TEXT PRIMARY KEY,
+    person_id TEXT NOT NULL REFERENCES iou_people(id) ON DELETE CASCADE,
+    transaction_id TEXT REFERENCES transactions(id) ON DELETE SET NULL,
+    type TEXT NOT NULL CHECK (type IN ('split', 'settlement')),
+    amount DECIMAL(15,2) NOT NULL,  -- Positive when the person owes the user more, negative when the user owes them
+    date DATE NOT NULL,
+    description TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_iou_entries_person_id ON iou_entries(person_id);
+CREATE INDEX IF NOT EXISTS idx_iou_entries_transaction_id ON iou_entries(transaction_id);
@@ -0,0 +1,7 @@
+-- Drop projects (SQLite)
+DROP INDEX IF EXISTS idx_project_planned_expenses_project_id;
+DROP TABLE IF EXISTS project_planned_expenses;
+DROP INDEX IF EXISTS idx_project_transactions_transaction_id;
+DROP TABLE IF EXISTS project_transactions;
+DROP INDEX IF EXISTS idx_projects_user_id;
+DROP TABLE IF EXISTS projects;
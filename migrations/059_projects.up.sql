@@ -0,0 +1,40 @@
+-- Projects such as a trip or renovation, grouping transactions and planned expenses against a budget (SQLite)
+CREATE TABLE IF NOT EXISTS projects (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT,
+    budget DECIMAL(15,2) NOT NULL DEFAULT 0,
+    currency TEXT NOT NULL DEFAULT 'CAD' CHECK (currency IN ('CAD', 'USD', 'INR')),
+    start_date DATE,
+    end_date DATE,
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'completed')),
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_projects_user_id ON projects(user_id);
+
+-- Transactions counted toward a project, from any account
+CREATE TABLE IF NOT EXISTS project_transactions (
+    project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    transaction_id TEXT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    PRIMARY KEY (project_id, transaction_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_transactions_transaction_id ON project_transactions(transaction_id);
+
+-- Expenses still to come; outstanding until linked to the transaction that paid them
+CREATE TABLE IF NOT EXISTS project_planned_expenses (
+    id TEXT PRIMARY KEY,
+    project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    description TEXT NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    date DATE NOT NULL,
+    transaction_id TEXT REFERENCES transactions(id) ON DELETE SET NULL,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_planned_expenses_project_id ON project_planned_expenses(project_id);
@@ -0,0 +1,3 @@
+-- Drop paystubs (SQLite)
+DROP INDEX IF EXISTS idx_paystubs_user_pay_date;
+DROP TABLE IF EXISTS paystubs;
@@ -0,0 +1,25 @@
+-- Paystubs imported from payroll exports, each recorded as employment income (SQLite)
+CREATE TABLE IF NOT EXISTS paystubs (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    employer TEXT NOT NULL,
+    pay_date DATE NOT NULL,
+    period_start DATE,
+    period_end DATE,
+    currency TEXT NOT NULL DEFAULT 'CAD' CHECK (currency IN ('CAD', 'USD', 'INR')),
+    gross_pay DECIMAL(15,2) NOT NULL,  -- All earnings, including RSU income
+    income_tax DECIMAL(15,2) NOT NULL DEFAULT 0,  -- Federal and provincial tax withheld on cash pay
+    cpp DECIMAL(15,2) NOT NULL DEFAULT 0,
+    ei DECIMAL(15,2) NOT NULL DEFAULT 0,
+    other_deductions DECIMAL(15,2) NOT NULL DEFAULT 0,  -- Benefits, pension and other payroll deductions
+    employer_contributions DECIMAL(15,2) NOT NULL DEFAULT 0,  -- Employer pension or savings plan contributions
+    rsu_income DECIMAL(15,2) NOT NULL DEFAULT 0,  -- Value of shares vested, included in gross pay
+    rsu_withholding DECIMAL(15,2) NOT NULL DEFAULT 0,  -- Tax withheld on vested shares, usually by selling some
+    net_pay DECIMAL(15,2) NOT NULL DEFAULT 0,
+    source TEXT NOT NULL CHECK (source IN ('adp', 'workday', 'generic')),
+    income_record_id TEXT REFERENCES income_records(id) ON DELETE SET NULL,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    UNIQUE (user_id, employer, pay_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_paystubs_user_pay_date ON paystubs(user_id, pay_date);
@@ -0,0 +1,5 @@
+-- Drop sell-to-cover withholding (SQLite)
+DROP INDEX IF EXISTS idx_equity_sales_vesting_event;
+
+ALTER TABLE equity_sales DROP COLUMN vesting_event_id;
+ALTER TABLE equity_grants DROP COLUMN sell_to_cover_rate;
@@ -0,0 +1,5 @@
+-- Sell-to-cover withholding on RSU vests (SQLite)
+ALTER TABLE equity_grants ADD COLUMN sell_to_cover_rate DECIMAL(5,4);  -- Share of each vest sold for taxes
+ALTER TABLE equity_sales ADD COLUMN vesting_event_id TEXT;             -- Set on automatic sell-to-cover sales
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_equity_sales_vesting_event ON equity_sales(vesting_event_id) WHERE vesting_event_id IS NOT NULL;
@@ -0,0 +1,5 @@
+-- Drop bonus and deferred profit sharing plans (SQLite)
+DROP INDEX IF EXISTS idx_bonus_payouts_plan_date;
+DROP INDEX IF EXISTS idx_bonus_plans_user;
+DROP TABLE IF EXISTS bonus_payouts;
+DROP TABLE IF EXISTS bonus_plans;
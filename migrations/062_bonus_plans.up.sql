@@ -0,0 +1,32 @@
+-- Bonus and deferred profit sharing plans with their payouts (SQLite)
+CREATE TABLE IF NOT EXISTS bonus_plans (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    employer TEXT NOT NULL,
+    plan_type TEXT NOT NULL CHECK (plan_type IN ('bonus', 'dpsp')),
+    target_percent DECIMAL(7,4) NOT NULL,  -- Target payout as a share of base salary
+    payout_month INTEGER NOT NULL CHECK (payout_month BETWEEN 1 AND 12),
+    confidence DECIMAL(5,4) NOT NULL DEFAULT 1,  -- Expected share of the target actually paid
+    currency TEXT NOT NULL DEFAULT 'CAD' CHECK (currency IN ('CAD', 'USD', 'INR')),
+    account_id TEXT REFERENCES accounts(id) ON DELETE SET NULL,  -- DPSP account contributions land in
+    is_active INTEGER NOT NULL DEFAULT 1,
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS bonus_payouts (
+    id TEXT PRIMARY KEY,
+    plan_id TEXT NOT NULL REFERENCES bonus_plans(id) ON DELETE CASCADE,
+    payout_date DATE NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    base_salary DECIMAL(15,2),  -- Salary the target applied to, for attainment
+    income_record_id TEXT REFERENCES income_records(id) ON DELETE SET NULL,
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_bonus_plans_user ON bonus_plans(user_id);
+CREATE INDEX IF NOT EXISTS idx_bonus_payouts_plan_date ON bonus_payouts(plan_id, payout_date);
@@ -0,0 +1,3 @@
+-- Drop quote history and risk settings (SQLite)
+DROP TABLE IF EXISTS risk_settings;
+DROP TABLE IF EXISTS quote_history;
@@ -0,0 +1,16 @@
+-- Daily quote history for volatility and per-user risk tolerance settings (SQLite)
+CREATE TABLE IF NOT EXISTS quote_history (
+    symbol TEXT NOT NULL,
+    quote_date DATE NOT NULL,
+    price DECIMAL(20,2) NOT NULL,
+    currency TEXT NOT NULL CHECK (currency IN ('CAD', 'USD', 'INR')),
+    PRIMARY KEY (symbol, quote_date)
+);
+
+CREATE TABLE IF NOT EXISTS risk_settings (
+    user_id TEXT PRIMARY KEY,
+    risk_tolerance TEXT NOT NULL DEFAULT 'moderate' CHECK (risk_tolerance IN ('conservative', 'moderate', 'growth', 'aggressive')),
+    employer_symbol TEXT,  -- Holdings of this symbol count towards employer concentration
+    concentration_threshold_percent DECIMAL(5,2) NOT NULL DEFAULT 25 CHECK (concentration_threshold_percent > 0 AND concentration_threshold_percent <= 100),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
@@ -0,0 +1,3 @@
+-- Drop employer exposure settings (SQLite)
+ALTER TABLE risk_settings DROP COLUMN income_threshold_percent;
+ALTER TABLE risk_settings DROP COLUMN employer_name;
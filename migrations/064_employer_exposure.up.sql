@@ -0,0 +1,3 @@
+-- Employer name and income dependence threshold for employer exposure alerts (SQLite)
+ALTER TABLE risk_settings ADD COLUMN employer_name TEXT;  -- Matched against income sources and bonus plan employers
+ALTER TABLE risk_settings ADD COLUMN income_threshold_percent DECIMAL(5,2) NOT NULL DEFAULT 50 CHECK (income_threshold_percent > 0 AND income_threshold_percent <= 100);
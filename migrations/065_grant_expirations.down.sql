@@ -0,0 +1,2 @@
+-- Drop stock option grant expirations (SQLite)
+DROP TABLE IF EXISTS equity_grant_expirations;
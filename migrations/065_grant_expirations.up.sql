@@ -0,0 +1,10 @@
+-- Expiration events for stock option grants (SQLite)
+CREATE TABLE IF NOT EXISTS equity_grant_expirations (
+    id TEXT PRIMARY KEY,
+    grant_id TEXT NOT NULL UNIQUE REFERENCES equity_grants(id) ON DELETE CASCADE,
+    expiration_date DATE NOT NULL,
+    shares_expired INTEGER NOT NULL CHECK (shares_expired >= 0),  -- Vested shares left unexercised
+    fmv_at_expiration DECIMAL(15,4),
+    intrinsic_value_lost DECIMAL(15,2) NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
@@ -0,0 +1,2 @@
+-- Drop exercise withholding (SQLite)
+ALTER TABLE equity_exercises DROP COLUMN withholding_rate;
@@ -0,0 +1,2 @@
+-- Withholding on option exercises for cashless and same-day-sale settlement (SQLite)
+ALTER TABLE equity_exercises ADD COLUMN withholding_rate DECIMAL(5,4);  -- Share of the taxable benefit withheld
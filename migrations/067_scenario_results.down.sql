@@ -0,0 +1,3 @@
+-- Drop projection scenario results (SQLite)
+DROP INDEX IF EXISTS idx_projection_scenario_results_user;
+DROP TABLE IF EXISTS projection_scenario_results;
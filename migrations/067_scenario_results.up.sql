@@ -0,0 +1,15 @@
+-- Latest result of each saved projection scenario from batch runs (SQLite)
+CREATE TABLE IF NOT EXISTS projection_scenario_results (
+    scenario_id TEXT PRIMARY KEY REFERENCES projection_scenarios(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    time_horizon_years INTEGER NOT NULL DEFAULT 0,
+    start_net_worth DECIMAL(15,2) NOT NULL DEFAULT 0,
+    end_net_worth DECIMAL(15,2) NOT NULL DEFAULT 0,
+    end_assets DECIMAL(15,2) NOT NULL DEFAULT 0,
+    end_liabilities DECIMAL(15,2) NOT NULL DEFAULT 0,
+    debt_free_date DATE,                -- First projected month with no debt left
+    error TEXT,                         -- Set when the scenario failed to run
+    run_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_projection_scenario_results_user ON projection_scenario_results(user_id);
@@ -0,0 +1,3 @@
+-- Drop statement import idempotency keys (SQLite)
+DROP INDEX IF EXISTS idx_statement_imports_idempotency;
+ALTER TABLE statement_imports DROP COLUMN idempotency_key;
@@ -0,0 +1,5 @@
+-- Idempotency keys for statement imports pushed by external scripts (SQLite)
+ALTER TABLE statement_imports ADD COLUMN idempotency_key TEXT;  -- Repeated pushes with the same key return the first import
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_statement_imports_idempotency
+    ON statement_imports(user_id, idempotency_key) WHERE idempotency_key IS NOT NULL;
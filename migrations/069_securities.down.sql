@@ -0,0 +1,6 @@
+-- Drop the security master, returning names to synced holdings' notes (SQLite)
+UPDATE holdings SET notes = (SELECT s.name FROM securities s WHERE s.symbol = UPPER(holdings.symbol))
+WHERE symbol IS NOT NULL AND notes IS NULL
+  AND account_id IN (SELECT local_account_id FROM synced_accounts);
+
+DROP TABLE IF EXISTS securities;
@@ -0,0 +1,24 @@
+-- Security master: descriptive metadata for each symbol, shared across users (SQLite)
+CREATE TABLE IF NOT EXISTS securities (
+    symbol TEXT PRIMARY KEY,  -- Upper case, as holdings and market_data are joined on
+    name TEXT,
+    exchange TEXT,
+    currency TEXT CHECK (currency IN ('CAD', 'USD', 'INR')),
+    asset_class TEXT CHECK (asset_class IN ('equity', 'fixed_income', 'cash', 'crypto', 'other')),  -- Overrides the holding type's class
+    sector TEXT,
+    source TEXT,  -- Where the metadata last came from: sync, quote or manual
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Sync stashed the security name in the notes of synced holdings; move it here
+INSERT OR IGNORE INTO securities (symbol, name, source)
+SELECT UPPER(h.symbol), MAX(h.notes), 'sync'
+FROM holdings h
+WHERE h.symbol IS NOT NULL AND h.notes IS NOT NULL AND h.notes != ''
+  AND h.account_id IN (SELECT local_account_id FROM synced_accounts)
+GROUP BY UPPER(h.symbol);
+
+UPDATE holdings SET notes = NULL
+WHERE symbol IS NOT NULL
+  AND account_id IN (SELECT local_account_id FROM synced_accounts)
+  AND notes = (SELECT s.name FROM securities s WHERE s.symbol = UPPER(holdings.symbol));
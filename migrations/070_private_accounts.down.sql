@@ -0,0 +1,3 @@
+-- Drop private accounts and privacy mode (SQLite)
+ALTER TABLE users DROP COLUMN privacy_mode;
+ALTER TABLE accounts DROP COLUMN is_private;
@@ -0,0 +1,3 @@
+-- Private accounts and privacy mode for hiding balances (SQLite)
+ALTER TABLE accounts ADD COLUMN is_private BOOLEAN NOT NULL DEFAULT 0;  -- Balances masked while privacy mode is on
+ALTER TABLE users ADD COLUMN privacy_mode BOOLEAN NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+-- Drop equity recalculation tables (SQLite)
+DROP INDEX IF EXISTS idx_equity_recalculations_account;
+DROP TABLE IF EXISTS equity_recalculations;
+DROP TABLE IF EXISTS equity_recalc_queue;
+ALTER TABLE equity_exercises DROP COLUMN fmv_from_history;
@@ -0,0 +1,24 @@
+-- Background recalculation of exercise and sale amounts after a strike price or FMV edit (SQLite)
+ALTER TABLE equity_exercises ADD COLUMN fmv_from_history BOOLEAN NOT NULL DEFAULT 0;  -- FMV was looked up, so FMV edits flow through
+
+-- Pending recalculations, one per account. Repeated edits widen the range to the earliest
+-- changed date rather than queueing another pass.
+CREATE TABLE IF NOT EXISTS equity_recalc_queue (
+    account_id TEXT PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+    from_date TEXT NOT NULL,  -- YYYY-MM-DD, exercises on or after this date are recalculated
+    reason TEXT NOT NULL,
+    requested_at DATETIME NOT NULL
+);
+
+-- Audit notes of the derived amounts each recalculation changed
+CREATE TABLE IF NOT EXISTS equity_recalculations (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    exercise_id TEXT,
+    sale_id TEXT,
+    reason TEXT NOT NULL,
+    note TEXT NOT NULL,  -- Old and new values of the changed fields
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_equity_recalculations_account ON equity_recalculations(account_id, created_at);
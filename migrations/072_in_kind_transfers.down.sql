@@ -0,0 +1,5 @@
+-- Drop in-kind transfer tables (SQLite)
+DROP INDEX IF EXISTS idx_in_kind_transfer_legs_transfer;
+DROP INDEX IF EXISTS idx_in_kind_transfers_user;
+DROP TABLE IF EXISTS in_kind_transfer_legs;
+DROP TABLE IF EXISTS in_kind_transfers;
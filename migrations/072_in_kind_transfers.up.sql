@@ -0,0 +1,23 @@
+-- In-kind transfers of positions between investment accounts, moved without a sale (SQLite)
+CREATE TABLE IF NOT EXISTS in_kind_transfers (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    from_account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    to_account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    transfer_date DATE NOT NULL,
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+-- One leg per symbol moved. The cost moves with the shares, so the ACB is unchanged.
+CREATE TABLE IF NOT EXISTS in_kind_transfer_legs (
+    id TEXT PRIMARY KEY,
+    transfer_id TEXT NOT NULL REFERENCES in_kind_transfers(id) ON DELETE CASCADE,
+    symbol TEXT NOT NULL,
+    quantity DECIMAL(20,8) NOT NULL CHECK (quantity > 0),
+    cost_per_share DECIMAL(20,8),  -- Source average cost, NULL when unknown
+    lots_moved INTEGER NOT NULL DEFAULT 0  -- Imported cost basis lots moved or split into the destination
+);
+
+CREATE INDEX IF NOT EXISTS idx_in_kind_transfers_user ON in_kind_transfers(user_id, transfer_date);
+CREATE INDEX IF NOT EXISTS idx_in_kind_transfer_legs_transfer ON in_kind_transfer_legs(transfer_id);
@@ -0,0 +1,9 @@
+-- Drop notification delivery preferences (SQLite)
+DROP INDEX IF EXISTS idx_notification_deliveries_user;
+DROP INDEX IF EXISTS idx_notifications_undispatched;
+DROP TABLE IF EXISTS notification_deliveries;
+ALTER TABLE notifications DROP COLUMN dispatched_at;
+ALTER TABLE notifications DROP COLUMN channels;
+ALTER TABLE notifications DROP COLUMN in_app;
+DROP TABLE IF EXISTS notification_channels;
+DROP TABLE IF EXISTS notification_preferences;
@@ -0,0 +1,39 @@
+-- Notification delivery preferences, per-type channels and the delivery log (SQLite)
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id TEXT PRIMARY KEY,
+    digest_enabled BOOLEAN NOT NULL DEFAULT 0,  -- Batch external deliveries into one message a day
+    digest_hour INTEGER NOT NULL DEFAULT 8 CHECK (digest_hour BETWEEN 0 AND 23),  -- Local hour the digest goes out
+    quiet_start TEXT,  -- HH:MM local, no external deliveries until quiet_end
+    quiet_end TEXT,
+    email TEXT,
+    webhook_url TEXT,
+    last_digest_date TEXT,  -- YYYY-MM-DD local date of the last digest sent
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+-- Channels chosen for a notification type. Types without a row are shown in-app only.
+CREATE TABLE IF NOT EXISTS notification_channels (
+    user_id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    channels TEXT NOT NULL,  -- Comma separated: in_app, email, webhook
+    PRIMARY KEY (user_id, type)
+);
+
+ALTER TABLE notifications ADD COLUMN in_app BOOLEAN NOT NULL DEFAULT 1;  -- Listed in the app
+ALTER TABLE notifications ADD COLUMN channels TEXT NOT NULL DEFAULT '';  -- External channels still to deliver to
+ALTER TABLE notifications ADD COLUMN dispatched_at DATETIME;
+
+-- One row per message sent, or attempted, on an external channel
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    channel TEXT NOT NULL,
+    notifications INTEGER NOT NULL,  -- Notifications the message covered
+    is_digest BOOLEAN NOT NULL DEFAULT 0,
+    status TEXT NOT NULL CHECK (status IN ('sent', 'failed')),
+    error TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_undispatched ON notifications(user_id) WHERE dispatched_at IS NULL AND channels != '';
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_user ON notification_deliveries(user_id, created_at);
@@ -0,0 +1,2 @@
+-- Drop backfill progress (SQLite)
+DROP TABLE IF EXISTS backfill_progress;
@@ -0,0 +1,10 @@
+-- Progress of batched backfills run alongside expand migrations (SQLite)
+CREATE TABLE IF NOT EXISTS backfill_progress (
+    name TEXT PRIMARY KEY,
+    cursor TEXT NOT NULL DEFAULT '',  -- Where the next batch resumes, opaque to the runner
+    processed INTEGER NOT NULL DEFAULT 0,
+    done BOOLEAN NOT NULL DEFAULT 0,
+    started_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    completed_at DATETIME
+);
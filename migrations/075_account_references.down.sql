@@ -0,0 +1,2 @@
+-- Drop account references (SQLite)
+DROP TABLE IF EXISTS account_references;
@@ -0,0 +1,13 @@
+-- External references for reaching an account's institution (SQLite)
+CREATE TABLE IF NOT EXISTS account_references (
+    account_id TEXT PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+    institution_url TEXT,
+    support_phone TEXT,
+    fraud_phone TEXT,  -- Lost cards and suspected fraud, often a separate line
+    support_email TEXT,
+    encrypted_account_number BLOB,  -- AES-256-GCM under the master key
+    account_number_last4 TEXT,  -- Kept in the clear so masked views don't need to decrypt
+    notes TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- Drop user jurisdiction (SQLite)
+ALTER TABLE users DROP COLUMN jurisdiction;
@@ -0,0 +1,2 @@
+-- Jurisdiction profile per user, switching tax, account and calendar defaults (SQLite)
+ALTER TABLE users ADD COLUMN jurisdiction TEXT NOT NULL DEFAULT 'CA';  -- CA, US or UK
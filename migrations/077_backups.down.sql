@@ -0,0 +1,2 @@
+-- Drop backups (SQLite)
+DROP TABLE IF EXISTS backups;
@@ -0,0 +1,10 @@
+-- Backups taken by the backup command, for retention and instance status (SQLite)
+CREATE TABLE IF NOT EXISTS backups (
+    name TEXT PRIMARY KEY,               -- File name in the backup directory
+    size_bytes INTEGER NOT NULL,
+    schema_version INTEGER NOT NULL,     -- Migration version the backup was taken at
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    pruned_at DATETIME                   -- Set when retention removed the file
+);
+
+CREATE INDEX IF NOT EXISTS idx_backups_created_at ON backups(created_at);
@@ -0,0 +1,4 @@
+-- Drop holding journal entries (SQLite)
+DROP INDEX IF EXISTS idx_holding_journal_entries_review_on;
+DROP INDEX IF EXISTS idx_holding_journal_entries_holding_id;
+DROP TABLE IF EXISTS holding_journal_entries;
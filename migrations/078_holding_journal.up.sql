@@ -0,0 +1,18 @@
+-- Dated notes, conviction ratings and review reminders on holdings (SQLite)
+CREATE TABLE IF NOT EXISTS holding_journal_entries (
+    id TEXT PRIMARY KEY,
+    holding_id TEXT NOT NULL REFERENCES holdings(id) ON DELETE CASCADE,
+    entry_date DATE NOT NULL,
+    kind TEXT NOT NULL DEFAULT 'note' CHECK (kind IN ('thesis', 'note', 'review')),
+    body TEXT NOT NULL,
+    conviction INTEGER CHECK (conviction BETWEEN 1 AND 5),
+    tags TEXT NOT NULL DEFAULT '[]',  -- JSON array of tags
+    price_at_entry DECIMAL(20,8),     -- Latest quote when the entry was written
+    review_on DATE,                   -- When to revisit the entry
+    reviewed_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_holding_journal_entries_holding_id ON holding_journal_entries(holding_id, entry_date);
+CREATE INDEX IF NOT EXISTS idx_holding_journal_entries_review_on ON holding_journal_entries(review_on);
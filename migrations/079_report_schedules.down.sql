@@ -0,0 +1,4 @@
+-- Drop report schedules (SQLite)
+DROP INDEX IF EXISTS idx_report_schedules_next_run_at;
+DROP INDEX IF EXISTS idx_report_schedules_user_id;
+DROP TABLE IF EXISTS report_schedules;
@@ -0,0 +1,24 @@
+-- Scheduled delivery of reports to S3, WebDAV or email (SQLite)
+CREATE TABLE IF NOT EXISTS report_schedules (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    report TEXT NOT NULL CHECK (report IN ('estate', 'net_worth', 'export')),
+    cron TEXT NOT NULL,                  -- Five-field cron expression
+    timezone TEXT NOT NULL,              -- IANA zone the cron expression is read in
+    destination_type TEXT NOT NULL CHECK (destination_type IN ('s3', 'webdav', 'email')),
+    destination TEXT NOT NULL,           -- JSON destination settings, without credentials
+    encrypted_secret BLOB,               -- S3 secret access key or WebDAV password
+    encrypted_passphrase BLOB,           -- Set when delivered files are encrypted
+    is_active BOOLEAN NOT NULL DEFAULT 1,
+    next_run_at DATETIME,
+    last_run_at DATETIME,
+    last_status TEXT CHECK (last_status IN ('delivered', 'failed')),
+    last_error TEXT,
+    last_file TEXT,                      -- Name of the last file delivered
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_schedules_user_id ON report_schedules(user_id);
+CREATE INDEX IF NOT EXISTS idx_report_schedules_next_run_at ON report_schedules(is_active, next_run_at);
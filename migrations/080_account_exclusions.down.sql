@@ -0,0 +1,4 @@
+-- Drop account exclusion flags (SQLite)
+ALTER TABLE accounts DROP COLUMN exclude_from_spending;
+ALTER TABLE accounts DROP COLUMN exclude_from_net_worth;
+ALTER TABLE accounts DROP COLUMN exclude_from_projections;
@@ -0,0 +1,4 @@
+-- Per-account flags leaving an account out of projections, net worth or spending (SQLite)
+ALTER TABLE accounts ADD COLUMN exclude_from_projections BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE accounts ADD COLUMN exclude_from_net_worth BOOLEAN NOT NULL DEFAULT 0;   -- Also left out of net worth snapshots
+ALTER TABLE accounts ADD COLUMN exclude_from_spending BOOLEAN NOT NULL DEFAULT 0;    -- Transactions left out of spending analytics and budgets
@@ -0,0 +1,2 @@
+-- Drop materialized account summaries (SQLite)
+DROP TABLE IF EXISTS account_summaries;
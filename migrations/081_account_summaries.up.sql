@@ -0,0 +1,11 @@
+-- Dashboard summaries materialized per user, rebuilt when a write they depend on bumps the
+-- generation (SQLite)
+CREATE TABLE IF NOT EXISTS account_summaries (
+    user_id TEXT PRIMARY KEY,
+    generation INTEGER NOT NULL DEFAULT 1,        -- Bumped by every account, balance or asset write
+    built_generation INTEGER NOT NULL DEFAULT 0,  -- Generation the stored summaries reflect, stale while behind
+    summary TEXT,                                 -- JSON accounts summary
+    masked_summary TEXT,                          -- JSON accounts summary with private balances masked
+    assets_summary TEXT,                          -- JSON assets summary, valued on the day it was built
+    refreshed_at DATETIME
+);
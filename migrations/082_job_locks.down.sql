@@ -0,0 +1,2 @@
+-- Drop background job leases (SQLite)
+DROP TABLE IF EXISTS job_locks;
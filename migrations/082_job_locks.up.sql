@@ -0,0 +1,7 @@
+-- Leases giving one server instance at a time the right to run each background job (SQLite)
+CREATE TABLE IF NOT EXISTS job_locks (
+    name TEXT PRIMARY KEY,
+    holder TEXT NOT NULL,          -- Instance holding the lease
+    acquired_at INTEGER NOT NULL,  -- Unix milliseconds the holder first took the lease
+    expires_at INTEGER NOT NULL    -- Unix milliseconds; other instances may take the lease after
+);
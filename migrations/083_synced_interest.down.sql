@@ -0,0 +1,4 @@
+-- Drop synced interest payment links (SQLite)
+DROP INDEX IF EXISTS idx_transactions_provider_activity;
+ALTER TABLE transactions DROP COLUMN income_record_id;
+ALTER TABLE transactions DROP COLUMN provider_activity_id;
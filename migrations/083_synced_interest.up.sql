@@ -0,0 +1,8 @@
+-- Interest and bonus payments pulled from a provider's account activity: the transaction
+-- keeps the provider's activity ID so later syncs skip it, and links the income record it
+-- was reported as (SQLite)
+ALTER TABLE transactions ADD COLUMN provider_activity_id TEXT;
+ALTER TABLE transactions ADD COLUMN income_record_id TEXT REFERENCES income_records(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_provider_activity ON transactions(account_id, provider_activity_id)
+    WHERE provider_activity_id IS NOT NULL;
@@ -0,0 +1,3 @@
+-- Drop account detail edits (SQLite)
+DROP INDEX IF EXISTS idx_account_edits_account;
+DROP TABLE IF EXISTS account_edits;
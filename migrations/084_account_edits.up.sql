@@ -0,0 +1,12 @@
+-- Changes made to an account's details, one row per field, for the account timeline (SQLite)
+CREATE TABLE IF NOT EXISTS account_edits (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    field TEXT NOT NULL,  -- name, type, currency, institution, is_asset, is_active, is_private or an exclusion
+    old_value TEXT,
+    new_value TEXT,
+    edited_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_edits_account ON account_edits(account_id, edited_at);
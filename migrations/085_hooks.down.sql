@@ -0,0 +1,4 @@
+-- Drop extension hooks (SQLite)
+DROP INDEX IF EXISTS idx_hooks_user_id;
+DROP INDEX IF EXISTS idx_hooks_event;
+DROP TABLE IF EXISTS hooks;
@@ -0,0 +1,20 @@
+-- Extension hooks: HTTP callbacks run when transactions are created and syncs commit (SQLite)
+CREATE TABLE IF NOT EXISTS hooks (
+    id TEXT PRIMARY KEY,
+    user_id TEXT REFERENCES users(id) ON DELETE CASCADE,  -- NULL for instance hooks, which run for every user
+    name TEXT NOT NULL,
+    event TEXT NOT NULL CHECK (event IN ('transaction.validate', 'sync.completed')),
+    url TEXT NOT NULL,
+    encrypted_secret BLOB NOT NULL,      -- Signs each request body
+    timeout_ms INTEGER NOT NULL DEFAULT 5000,
+    failure_policy TEXT NOT NULL DEFAULT 'ignore' CHECK (failure_policy IN ('ignore', 'fail')),
+    is_active BOOLEAN NOT NULL DEFAULT 1,
+    last_called_at DATETIME,
+    last_status TEXT CHECK (last_status IN ('ok', 'failed')),
+    last_error TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_hooks_event ON hooks(event, is_active);
+CREATE INDEX IF NOT EXISTS idx_hooks_user_id ON hooks(user_id);
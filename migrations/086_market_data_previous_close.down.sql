@@ -0,0 +1,10 @@
+-- Restore security quotes and drop the previous close from market data (SQLite)
+CREATE TABLE IF NOT EXISTS security_quotes (
+    symbol TEXT PRIMARY KEY,
+    price DECIMAL(20,8) NOT NULL CHECK (price > 0),
+    previous_close DECIMAL(20,8) CHECK (previous_close > 0),  -- Used for daily move alerts
+    quoted_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+ALTER TABLE market_data DROP COLUMN previous_close;
@@ -0,0 +1,4 @@
+-- Previous close on market data for daily move alerts, replacing security_quotes (SQLite)
+ALTER TABLE market_data ADD COLUMN previous_close DECIMAL(20,2);
+
+DROP TABLE IF EXISTS security_quotes;
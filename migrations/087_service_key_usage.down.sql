@@ -0,0 +1,20 @@
+-- Drop service key usage and restore the provider key usage log (SQLite)
+CREATE TABLE IF NOT EXISTS api_key_usage_old (
+    id TEXT PRIMARY KEY,
+    api_key_id TEXT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    endpoint TEXT NOT NULL,
+    ip_address TEXT,
+    status_code INTEGER NOT NULL,  -- 0 when the request never completed
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+INSERT INTO api_key_usage_old (id, api_key_id, user_id, endpoint, ip_address, status_code, created_at)
+SELECT id, api_key_id, user_id, endpoint, ip_address, status_code, created_at FROM api_key_usage
+WHERE api_key_id IS NOT NULL;
+
+DROP TABLE api_key_usage;
+ALTER TABLE api_key_usage_old RENAME TO api_key_usage;
+
+CREATE INDEX IF NOT EXISTS idx_api_key_usage_api_key_id ON api_key_usage(api_key_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_api_key_usage_created_at ON api_key_usage(created_at);
@@ -0,0 +1,24 @@
+-- Log requests made with service API keys alongside provider key usage (SQLite)
+
+-- Rebuild api_key_usage so each entry belongs to either a provider key or a service key
+CREATE TABLE IF NOT EXISTS api_key_usage_new (
+    id TEXT PRIMARY KEY,
+    api_key_id TEXT REFERENCES api_keys(id) ON DELETE CASCADE,
+    service_key_id TEXT REFERENCES service_api_keys(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    endpoint TEXT NOT NULL,
+    ip_address TEXT,
+    status_code INTEGER NOT NULL,  -- 0 when the request never completed
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    CHECK ((api_key_id IS NULL) <> (service_key_id IS NULL))
+);
+
+INSERT INTO api_key_usage_new (id, api_key_id, user_id, endpoint, ip_address, status_code, created_at)
+SELECT id, api_key_id, user_id, endpoint, ip_address, status_code, created_at FROM api_key_usage;
+
+DROP TABLE api_key_usage;
+ALTER TABLE api_key_usage_new RENAME TO api_key_usage;
+
+CREATE INDEX IF NOT EXISTS idx_api_key_usage_api_key_id ON api_key_usage(api_key_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_api_key_usage_service_key_id ON api_key_usage(service_key_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_api_key_usage_created_at ON api_key_usage(created_at);
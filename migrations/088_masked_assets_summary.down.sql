@@ -0,0 +1,2 @@
+-- Drop the masked assets summary (SQLite)
+ALTER TABLE account_summaries DROP COLUMN masked_assets_summary;
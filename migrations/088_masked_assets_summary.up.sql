@@ -0,0 +1,5 @@
+-- Materialize the assets summary with private accounts left out, for privacy mode (SQLite)
+ALTER TABLE account_summaries ADD COLUMN masked_assets_summary TEXT;  -- JSON assets summary without private accounts
+
+-- Rebuild every stored summary so the masked one is filled in
+UPDATE account_summaries SET generation = generation + 1;